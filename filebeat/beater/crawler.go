@@ -24,10 +24,12 @@ import (
 	"github.com/mitchellh/hashstructure"
 
 	"github.com/elastic/beats/v7/filebeat/input"
+	"github.com/elastic/beats/v7/libbeat/audit"
 	"github.com/elastic/beats/v7/libbeat/beat"
 	"github.com/elastic/beats/v7/libbeat/cfgfile"
 	conf "github.com/elastic/elastic-agent-libs/config"
 	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
 )
 
 type crawler struct {
@@ -147,6 +149,7 @@ func (c *crawler) startInput(
 
 	c.log.Infof("Starting input (ID: %d)", id)
 	runner.Start()
+	audit.Record(audit.CategoryInput, "started", mapstr.M{"runner": runner.String(), "hash": id})
 
 	return nil
 }
@@ -169,6 +172,7 @@ func (c *crawler) Stop() {
 		asyncWaitStop(func() {
 			c.log.Infof("Stopping input: %d", id)
 			p.Stop()
+			audit.Record(audit.CategoryInput, "stopped", mapstr.M{"runner": p.String(), "hash": id})
 		})
 	}
 
@@ -42,6 +42,7 @@ import (
 	"github.com/elastic/beats/v7/libbeat/common/cfgwarn"
 	"github.com/elastic/beats/v7/libbeat/esleg/eslegclient"
 	"github.com/elastic/beats/v7/libbeat/management"
+	"github.com/elastic/beats/v7/libbeat/management/status"
 	"github.com/elastic/beats/v7/libbeat/monitoring/inputmon"
 	"github.com/elastic/beats/v7/libbeat/outputs/elasticsearch"
 	"github.com/elastic/beats/v7/libbeat/publisher/pipetool"
@@ -72,6 +73,7 @@ type Filebeat struct {
 	config         *cfg.Config
 	moduleRegistry *fileset.ModuleRegistry
 	pluginFactory  PluginFactory
+	openRegistry   RegistryBackend
 	done           chan struct{}
 	stopOnce       sync.Once // wraps the Stop() method
 	pipeline       beat.PipelineConnector
@@ -86,12 +88,20 @@ type StateStore interface {
 
 // New creates a new Filebeat pointer instance.
 func New(plugins PluginFactory) beat.Creator {
+	return NewWithRegistryBackend(plugins, nil)
+}
+
+// NewWithRegistryBackend creates a new Filebeat storing its registry in the
+// backend opened by openRegistry instead of the files under the registry
+// path. The backend is opened when Filebeat runs. The registries of the
+// tenants are still stored in files.
+func NewWithRegistryBackend(plugins PluginFactory, openRegistry RegistryBackend) beat.Creator {
 	return func(b *beat.Beat, rawConfig *conf.C) (beat.Beater, error) {
-		return newBeater(b, plugins, rawConfig)
+		return newBeater(b, plugins, openRegistry, rawConfig)
 	}
 }
 
-func newBeater(b *beat.Beat, plugins PluginFactory, rawConfig *conf.C) (beat.Beater, error) {
+func newBeater(b *beat.Beat, plugins PluginFactory, openRegistry RegistryBackend, rawConfig *conf.C) (beat.Beater, error) {
 	config := cfg.DefaultConfig
 	if err := rawConfig.Unpack(&config); err != nil {
 		return nil, fmt.Errorf("Error reading config file: %w", err)
@@ -155,7 +165,7 @@ func newBeater(b *beat.Beat, plugins PluginFactory, rawConfig *conf.C) (beat.Bea
 		haveEnabledInputs = true
 	}
 
-	if !config.ConfigInput.Enabled() && !config.ConfigModules.Enabled() && !haveEnabledInputs && config.Autodiscover == nil && !b.Manager.Enabled() {
+	if !config.ConfigInput.Enabled() && !config.ConfigModules.Enabled() && !haveEnabledInputs && config.Autodiscover == nil && !b.Manager.Enabled() && len(b.Tenants) == 0 {
 		if !b.InSetupCmd {
 			return nil, fmt.Errorf("no modules or inputs enabled and configuration reloading disabled. What files do you want me to watch?")
 		}
@@ -177,6 +187,7 @@ func newBeater(b *beat.Beat, plugins PluginFactory, rawConfig *conf.C) (beat.Bea
 		config:         &config,
 		moduleRegistry: moduleRegistry,
 		pluginFactory:  plugins,
+		openRegistry:   openRegistry,
 	}
 
 	err = fb.setupPipelineLoaderCallback(b)
@@ -284,7 +295,7 @@ func (fb *Filebeat) Run(b *beat.Beat) error {
 		return err
 	}
 
-	stateStore, err := openStateStore(b.Info, logp.NewLogger("filebeat"), config.Registry)
+	stateStore, err := openStateStore(b.Info, logp.NewLogger("filebeat"), config.Registry, fb.openRegistry)
 	if err != nil {
 		logp.Err("Failed to open state store: %+v", err)
 		return err
@@ -388,6 +399,18 @@ func (fb *Filebeat) Run(b *beat.Beat) error {
 		close(outDone) // finally close all active connections to publisher pipeline
 	}()
 
+	// The tenants are closed after all events have been processed, like the
+	// registrar and the pipeline connections above.
+	tenants, err := startTenants(b, fb.pluginFactory, wgEvents, finishedLogger, fb.done)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, t := range tenants {
+			t.Close()
+		}
+	}()
+
 	// Wait for all events to be processed or timeout
 	defer waitEvents.Wait()
 
@@ -402,11 +425,24 @@ func (fb *Filebeat) Run(b *beat.Beat) error {
 		return fmt.Errorf("Failed to start crawler: %w", err)
 	}
 
+	if config.HealthReport.Enabled {
+		reporter, err := newHealthReporter(b.Publisher, status.DefaultHealth, config.HealthReport.Period)
+		if err != nil {
+			crawler.Stop()
+			return err
+		}
+		reporter.Start()
+		defer reporter.Stop()
+	}
+
 	// If run once, add crawler completion check as alternative to done signal
 	if *once {
 		runOnce := func() {
 			logp.Info("Running filebeat once. Waiting for completion ...")
 			crawler.WaitForCompletion()
+			for _, t := range tenants {
+				t.crawler.WaitForCompletion()
+			}
 			logp.Info("All data collection completed. Shutting down.")
 		}
 		waitFinished.Add(runOnce)
@@ -454,6 +490,9 @@ func (fb *Filebeat) Run(b *beat.Beat) error {
 	modules.Stop()
 	adiscover.Stop()
 	crawler.Stop()
+	for _, t := range tenants {
+		t.StopInputs()
+	}
 	cancelPipelineFactoryCtx()
 
 	timeout := fb.config.ShutdownTimeout
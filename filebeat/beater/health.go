@@ -0,0 +1,135 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package beater
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/management/status"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// healthReporter periodically publishes an event with the aggregated health
+// of the inputs, listing the inputs that are not healthy and the reasons they
+// reported. It lets fleets that are not managed by Elastic Agent monitor the
+// inputs from the events they ingest.
+type healthReporter struct {
+	log    *logp.Logger
+	health *status.Health
+	client beat.Client
+	period time.Duration
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newHealthReporter(pipeline beat.PipelineConnector, health *status.Health, period time.Duration) (*healthReporter, error) {
+	// The report is dropped rather than blocking while the intake of the
+	// pipeline is paused or the queue is full, the next report replaces it.
+	client, err := pipeline.ConnectWith(beat.ClientConfig{
+		PublishMode: beat.DropIfFull,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect the health report to the pipeline: %w", err)
+	}
+	return &healthReporter{
+		log:    logp.NewLogger("health"),
+		health: health,
+		client: client,
+		period: period,
+		done:   make(chan struct{}),
+	}, nil
+}
+
+func (r *healthReporter) Start() {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ticker := time.NewTicker(r.period)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.done:
+				return
+			case <-ticker.C:
+				r.report(time.Now())
+			}
+		}
+	}()
+}
+
+func (r *healthReporter) Stop() {
+	close(r.done)
+	// Closing the client releases a report waiting to be published.
+	_ = r.client.Close()
+	r.wg.Wait()
+}
+
+func (r *healthReporter) report(now time.Time) {
+	state := r.health.State()
+	if len(state.Reasons) > 0 {
+		r.log.Warnf("Filebeat is %s: %v", state.Name(), state.Reasons)
+	}
+	r.client.Publish(healthEvent(now, state))
+}
+
+func healthEvent(now time.Time, state status.HealthState) beat.Event {
+	unhealthy := make([]mapstr.M, 0, len(state.Units))
+	for _, u := range state.Units {
+		unit := mapstr.M{
+			"id":     u.ID,
+			"type":   u.Name,
+			"status": u.Status,
+			"since":  u.Since,
+		}
+		if u.Message != "" {
+			unit["message"] = u.Message
+		}
+		unhealthy = append(unhealthy, unit)
+	}
+
+	health := mapstr.M{
+		"status": state.Name(),
+		"inputs": mapstr.M{
+			"total":     state.Total,
+			"unhealthy": unhealthy,
+		},
+	}
+	if len(state.Reasons) > 0 {
+		health["reasons"] = state.Reasons
+	}
+
+	return beat.Event{
+		Timestamp: now,
+		Fields: mapstr.M{
+			"message": fmt.Sprintf("Filebeat is %s, %d of %d inputs are not healthy",
+				state.Name(), len(state.Units), state.Total),
+			"event": mapstr.M{
+				"kind":    "state",
+				"dataset": "filebeat.health",
+			},
+			"filebeat": mapstr.M{
+				"health": health,
+			},
+		},
+	}
+}
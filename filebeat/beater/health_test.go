@@ -0,0 +1,101 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package beater
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/management/status"
+	pubtest "github.com/elastic/beats/v7/libbeat/publisher/testing"
+)
+
+func TestHealthReporter(t *testing.T) {
+	health := status.NewHealth()
+	a, _ := health.Register("a", "winlog", nil)
+	health.Register("b", "filestream", nil)
+	a.UpdateStatus(status.Degraded, "dropped events")
+
+	client := pubtest.NewChanClient(1)
+	r, err := newHealthReporter(pubtest.PublisherWithClient(client), health, time.Hour)
+	require.NoError(t, err)
+
+	now := time.Now()
+	r.report(now)
+	event := client.ReceiveEvent()
+
+	assert.Equal(t, now, event.Timestamp)
+	v, _ := event.Fields.GetValue("message")
+	assert.Equal(t, "Filebeat is degraded, 1 of 2 inputs are not healthy", v)
+	v, _ = event.Fields.GetValue("event.dataset")
+	assert.Equal(t, "filebeat.health", v)
+	v, _ = event.Fields.GetValue("filebeat.health.status")
+	assert.Equal(t, "degraded", v)
+	v, _ = event.Fields.GetValue("filebeat.health.inputs.total")
+	assert.Equal(t, 2, v)
+	v, _ = event.Fields.GetValue("filebeat.health.reasons")
+	assert.Equal(t, []string{"winlog input 'a' is degraded: dropped events"}, v)
+}
+
+func TestHealthReporterStopWhilePublishBlocks(t *testing.T) {
+	var mode beat.PublishMode
+	published := make(chan struct{}, 1)
+	closed := make(chan struct{})
+	client := &pubtest.FakeClient{
+		PublishFunc: func(beat.Event) {
+			// Block like a client waiting for the paused intake to resume.
+			select {
+			case published <- struct{}{}:
+			default:
+			}
+			<-closed
+		},
+		CloseFunc: func() error {
+			close(closed)
+			return nil
+		},
+	}
+	connector := pubtest.FakeConnector{
+		ConnectFunc: func(cfg beat.ClientConfig) (beat.Client, error) {
+			mode = cfg.PublishMode
+			return client, nil
+		},
+	}
+
+	r, err := newHealthReporter(connector, status.NewHealth(), time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, beat.DropIfFull, mode)
+
+	r.Start()
+	<-published
+
+	stopped := make(chan struct{})
+	go func() {
+		r.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop did not return while a report was being published")
+	}
+}
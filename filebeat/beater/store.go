@@ -23,6 +23,7 @@ import (
 	"github.com/elastic/beats/v7/filebeat/config"
 	"github.com/elastic/beats/v7/libbeat/beat"
 	"github.com/elastic/beats/v7/libbeat/statestore"
+	"github.com/elastic/beats/v7/libbeat/statestore/backend"
 	"github.com/elastic/beats/v7/libbeat/statestore/backend/memlog"
 	"github.com/elastic/elastic-agent-libs/logp"
 	"github.com/elastic/elastic-agent-libs/paths"
@@ -34,17 +35,29 @@ type filebeatStore struct {
 	cleanInterval time.Duration
 }
 
-func openStateStore(info beat.Info, logger *logp.Logger, cfg config.Registry) (*filebeatStore, error) {
-	memlog, err := memlog.New(logger, memlog.Settings{
+// RegistryBackend opens the backend storing the registry.
+type RegistryBackend func(logger *logp.Logger, cfg config.Registry) (backend.Registry, error)
+
+// openMemlog opens the default backend, storing the registry in files under
+// the registry path.
+func openMemlog(logger *logp.Logger, cfg config.Registry) (backend.Registry, error) {
+	return memlog.New(logger, memlog.Settings{
 		Root:     paths.Resolve(paths.Data, cfg.Path),
 		FileMode: cfg.Permissions,
 	})
+}
+
+func openStateStore(info beat.Info, logger *logp.Logger, cfg config.Registry, openBackend RegistryBackend) (*filebeatStore, error) {
+	if openBackend == nil {
+		openBackend = openMemlog
+	}
+	reg, err := openBackend(logger, cfg)
 	if err != nil {
 		return nil, err
 	}
 
 	return &filebeatStore{
-		registry:      statestore.NewRegistry(memlog),
+		registry:      statestore.NewRegistry(reg),
 		storeName:     info.Beat,
 		cleanInterval: cfg.CleanInterval,
 	}, nil
@@ -0,0 +1,238 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package beater
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/elastic/beats/v7/filebeat/channel"
+	cfg "github.com/elastic/beats/v7/filebeat/config"
+	"github.com/elastic/beats/v7/filebeat/input"
+	v2 "github.com/elastic/beats/v7/filebeat/input/v2"
+	"github.com/elastic/beats/v7/filebeat/input/v2/compat"
+	"github.com/elastic/beats/v7/filebeat/registrar"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/cfgfile"
+	"github.com/elastic/beats/v7/libbeat/common/cleanup"
+	"github.com/elastic/beats/v7/libbeat/publisher/pipetool"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/go-concert/unison"
+)
+
+// tenantRunner runs the inputs of a tenant next to the inputs of the main
+// configuration. A tenant keeps its registry in its own data directory and
+// publishes to its own pipeline. Modules and autodiscover are not supported
+// for tenants.
+type tenantRunner struct {
+	id               string
+	log              *logp.Logger
+	config           cfg.Config
+	stateStore       *filebeatStore
+	registrar        *registrar.Registrar
+	registrarChannel *registrarLogger
+	pipeline         beat.PipelineConnector
+	outDone          chan struct{}
+	inputTaskGroup   unison.TaskGroup
+	crawler          *crawler
+	stopOnce         sync.Once
+}
+
+func newTenantRunner(
+	info beat.Info,
+	t beat.Tenant,
+	plugins PluginFactory,
+	wgEvents *eventCounter,
+	finishedLogger *finishedLogger,
+	done chan struct{},
+) (*tenantRunner, error) {
+	config := cfg.DefaultConfig
+	settings, err := t.Config.Child("filebeat", -1)
+	if err != nil {
+		return nil, fmt.Errorf("tenant %s has no filebeat settings: %w", t.ID, err)
+	}
+	if err := settings.Unpack(&config); err != nil {
+		return nil, fmt.Errorf("error reading settings of tenant %s: %w", t.ID, err)
+	}
+	if len(config.Modules) > 0 || config.ConfigModules.Enabled() || config.Autodiscover != nil {
+		return nil, fmt.Errorf("tenant %s: modules and autodiscover are not supported for tenants", t.ID)
+	}
+	if len(config.ListEnabledInputs()) == 0 && !config.ConfigInput.Enabled() {
+		return nil, fmt.Errorf("tenant %s has no inputs enabled", t.ID)
+	}
+	if !filepath.IsAbs(config.Registry.Path) {
+		config.Registry.Path = filepath.Join(t.DataPath, config.Registry.Path)
+	}
+
+	ok := false
+	r := &tenantRunner{
+		id:      t.ID,
+		log:     logp.NewLogger("filebeat").With("tenant", t.ID),
+		config:  config,
+		outDone: make(chan struct{}),
+	}
+
+	r.stateStore, err = openStateStore(info, r.log, config.Registry, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state store of tenant %s: %w", t.ID, err)
+	}
+	defer cleanup.IfNot(&ok, r.stateStore.Close)
+
+	if err := processLogInputTakeOver(r.stateStore, &r.config); err != nil {
+		return nil, fmt.Errorf("failed to attempt filestream state take over for tenant %s: %w", t.ID, err)
+	}
+
+	r.registrar, err = registrar.New(r.stateStore, finishedLogger, config.Registry.FlushTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("could not init registrar of tenant %s: %w", t.ID, err)
+	}
+	r.registrarChannel = newRegistrarLogger(r.registrar)
+
+	r.pipeline = withPipelineEventCounter(t.Publisher, wgEvents)
+	r.pipeline = pipetool.WithACKer(r.pipeline, eventACKer(finishedLogger, r.registrarChannel))
+	r.pipeline = pipetool.WithDefaultGuarantees(r.pipeline, beat.GuaranteedSend)
+
+	inputsLogger := logp.NewLogger("input").With("tenant", t.ID)
+	v2InputLoader, err := v2.NewLoader(inputsLogger, plugins(info, inputsLogger, r.stateStore), "type", cfg.DefaultType)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup.IfNot(&ok, func() { _ = r.inputTaskGroup.Stop() })
+	if err := v2InputLoader.Init(&r.inputTaskGroup); err != nil {
+		return nil, fmt.Errorf("failed to initialize the input managers of tenant %s: %w", t.ID, err)
+	}
+
+	inputLoader := &tenantInputFactory{
+		tenantID: t.ID,
+		factory: channel.RunnerFactoryWithCommonInputSettings(info, compat.Combine(
+			compat.RunnerFactory(inputsLogger, info, v2InputLoader),
+			input.NewRunnerFactory(channel.NewOutletFactory(r.outDone).Create, r.registrar, done),
+		)),
+	}
+	r.crawler, err = newCrawler(inputLoader, nil, config.Inputs, done, *once)
+	if err != nil {
+		return nil, err
+	}
+
+	ok = true
+	return r, nil
+}
+
+// Start starts the registrar and the inputs of the tenant.
+func (r *tenantRunner) Start() error {
+	r.log.Infof("Starting tenant %s", r.id)
+	if err := r.registrar.Start(); err != nil {
+		return fmt.Errorf("could not start registrar of tenant %s: %w", r.id, err)
+	}
+	if err := r.crawler.Start(r.pipeline, r.config.ConfigInput, nil); err != nil {
+		return fmt.Errorf("failed to start inputs of tenant %s: %w", r.id, err)
+	}
+	return nil
+}
+
+// StopInputs stops the inputs of the tenant.
+func (r *tenantRunner) StopInputs() {
+	r.stopOnce.Do(r.crawler.Stop)
+}
+
+// Close stops the inputs if they still run, closes the connections to the
+// pipeline and writes the last state of the registry.
+func (r *tenantRunner) Close() {
+	r.StopInputs()
+	r.registrarChannel.Close()
+	close(r.outDone)
+	r.registrar.Stop()
+	_ = r.inputTaskGroup.Stop()
+	r.stateStore.Close()
+	r.log.Infof("Tenant %s stopped", r.id)
+}
+
+// tenantInputFactory prefixes the IDs of the inputs of a tenant with the
+// tenant ID. Input metrics are registered globally by input ID, so inputs of
+// different tenants can use the same ID.
+type tenantInputFactory struct {
+	tenantID string
+	factory  cfgfile.RunnerFactory
+}
+
+func (f *tenantInputFactory) Create(p beat.PipelineConnector, config *conf.C) (cfgfile.Runner, error) {
+	config, err := f.withTenantID(config)
+	if err != nil {
+		return nil, err
+	}
+	return f.factory.Create(p, config)
+}
+
+func (f *tenantInputFactory) CheckConfig(config *conf.C) error {
+	config, err := f.withTenantID(config)
+	if err != nil {
+		return err
+	}
+	return f.factory.CheckConfig(config)
+}
+
+// withTenantID returns a copy of the input configuration with its ID
+// prefixed with the tenant ID. Inputs without ID are not registered by ID and
+// are returned as is.
+func (f *tenantInputFactory) withTenantID(config *conf.C) (*conf.C, error) {
+	id, err := config.String("id", -1)
+	if err != nil || id == "" {
+		return config, nil //nolint:nilerr // The input has no ID.
+	}
+	prefixed := conf.NewConfig()
+	if err := prefixed.Merge(config); err != nil {
+		return nil, fmt.Errorf("error copying input configuration of tenant %s: %w", f.tenantID, err)
+	}
+	if err := prefixed.SetString("id", -1, f.tenantID+"/"+id); err != nil {
+		return nil, fmt.Errorf("error setting input ID of tenant %s: %w", f.tenantID, err)
+	}
+	return prefixed, nil
+}
+
+// startTenants creates and starts the runners of the tenants. On error the
+// runners already started are closed.
+func startTenants(
+	b *beat.Beat,
+	plugins PluginFactory,
+	wgEvents *eventCounter,
+	finishedLogger *finishedLogger,
+	done chan struct{},
+) ([]*tenantRunner, error) {
+	runners := make([]*tenantRunner, 0, len(b.Tenants))
+	var err error
+	for _, t := range b.Tenants {
+		var r *tenantRunner
+		r, err = newTenantRunner(b.Info, t, plugins, wgEvents, finishedLogger, done)
+		if err != nil {
+			break
+		}
+		runners = append(runners, r)
+		if err = r.Start(); err != nil {
+			break
+		}
+	}
+	if err != nil {
+		for _, r := range runners {
+			r.Close()
+		}
+		return nil, err
+	}
+	return runners, nil
+}
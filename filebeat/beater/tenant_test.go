@@ -0,0 +1,174 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package beater
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v2 "github.com/elastic/beats/v7/filebeat/input/v2"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/cfgfile"
+	"github.com/elastic/beats/v7/libbeat/feature"
+	pubtest "github.com/elastic/beats/v7/libbeat/publisher/testing"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+type tenantTestInput struct{}
+
+func (tenantTestInput) Name() string              { return "tenant-test" }
+func (tenantTestInput) Test(v2.TestContext) error { return nil }
+
+func (tenantTestInput) Run(ctx v2.Context, pipeline beat.PipelineConnector) error {
+	client, err := pipeline.Connect()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	client.Publish(beat.Event{Timestamp: time.Now(), Fields: mapstr.M{"message": ctx.ID}})
+	<-ctx.Cancelation.Done()
+	return nil
+}
+
+func tenantTestPlugins(beat.Info, *logp.Logger, StateStore) []v2.Plugin {
+	return []v2.Plugin{{
+		Name:      "tenant-test",
+		Stability: feature.Stable,
+		Manager: v2.ConfigureWith(func(*conf.C) (v2.Input, error) {
+			return tenantTestInput{}, nil
+		}),
+	}}
+}
+
+func TestTenantRunner(t *testing.T) {
+	ch := make(chan beat.Event, 1)
+	dataPath := t.TempDir()
+	b := &beat.Beat{
+		Info: beat.Info{Beat: "filebeat"},
+		Tenants: []beat.Tenant{{
+			ID: "acme",
+			Config: conf.MustNewConfigFrom(`
+filebeat.inputs:
+  - type: tenant-test
+    id: acme-input
+`),
+			DataPath:  dataPath,
+			Publisher: pubtest.ConstClient(pubtest.ChClient(ch)),
+		}},
+	}
+	reg := monitoring.NewRegistry()
+	wgEvents := &eventCounter{
+		count: monitoring.NewInt(reg, "active"),
+		added: monitoring.NewUint(reg, "added"),
+		done:  monitoring.NewUint(reg, "done"),
+	}
+	done := make(chan struct{})
+	defer close(done)
+
+	runners, err := startTenants(b, tenantTestPlugins, wgEvents, newFinishedLogger(wgEvents), done)
+	require.NoError(t, err)
+	require.Len(t, runners, 1)
+
+	select {
+	case event := <-ch:
+		assert.Equal(t, "acme/acme-input", event.Fields["message"], "the input ID must be prefixed with the tenant ID")
+	case <-time.After(10 * time.Second):
+		t.Fatal("the input of the tenant did not publish")
+	}
+
+	runners[0].StopInputs()
+	runners[0].Close()
+
+	_, err = os.Stat(filepath.Join(dataPath, "registry", "filebeat"))
+	assert.NoError(t, err, "registry of the tenant must be in its data path")
+}
+
+type recordingRunnerFactory struct {
+	ids []string
+}
+
+func (f *recordingRunnerFactory) Create(_ beat.PipelineConnector, config *conf.C) (cfgfile.Runner, error) {
+	return nil, f.CheckConfig(config)
+}
+
+func (f *recordingRunnerFactory) CheckConfig(config *conf.C) error {
+	id, _ := config.String("id", -1)
+	f.ids = append(f.ids, id)
+	return nil
+}
+
+func TestTenantInputFactory(t *testing.T) {
+	inputs := &recordingRunnerFactory{}
+	factory := &tenantInputFactory{tenantID: "acme", factory: inputs}
+
+	config := conf.MustNewConfigFrom(`{type: tenant-test, id: app}`)
+	require.NoError(t, factory.CheckConfig(config))
+	_, err := factory.Create(nil, config)
+	require.NoError(t, err)
+	_, err = factory.Create(nil, conf.MustNewConfigFrom(`{type: tenant-test}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"acme/app", "acme/app", ""}, inputs.ids)
+	id, err := config.String("id", -1)
+	require.NoError(t, err)
+	assert.Equal(t, "app", id, "the configuration of the input must not be modified")
+}
+
+func TestTenantRunnerErrors(t *testing.T) {
+	cases := map[string]struct {
+		config string
+		err    string
+	}{
+		"no filebeat settings": {
+			config: `output.console.enabled: true`,
+			err:    "tenant acme has no filebeat settings",
+		},
+		"no inputs": {
+			config: `filebeat.registry.flush: 1s`,
+			err:    "tenant acme has no inputs enabled",
+		},
+		"modules": {
+			config: `
+filebeat.inputs: [{type: tenant-test}]
+filebeat.modules: [{module: nginx}]
+`,
+			err: "modules and autodiscover are not supported for tenants",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			tenant := beat.Tenant{
+				ID:        "acme",
+				Config:    conf.MustNewConfigFrom(tc.config),
+				DataPath:  t.TempDir(),
+				Publisher: pubtest.ConstClient(pubtest.ChClient(make(chan beat.Event))),
+			}
+			_, err := newTenantRunner(beat.Info{Beat: "filebeat"}, tenant, tenantTestPlugins, nil, nil, nil)
+			assert.ErrorContains(t, err, tc.err)
+		})
+	}
+}
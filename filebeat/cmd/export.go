@@ -0,0 +1,106 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/elastic/beats/v7/filebeat/fileset"
+	"github.com/elastic/beats/v7/libbeat/cfgfile"
+	"github.com/elastic/beats/v7/libbeat/cmd/export"
+	"github.com/elastic/beats/v7/libbeat/cmd/instance"
+	"github.com/elastic/beats/v7/libbeat/common/cli"
+	"github.com/elastic/beats/v7/libbeat/idxmgmt"
+	"github.com/elastic/beats/v7/libbeat/idxmgmt/lifecycle"
+	conf "github.com/elastic/elastic-agent-libs/config"
+)
+
+func genExportPipelinesCmd(settings instance.Settings) *cobra.Command {
+	exportCmd := &cobra.Command{
+		Use:   "pipelines",
+		Short: "Export ingest pipelines, index template and ILM policy",
+		Long: `Export writes the ingest pipelines of all the filesets of the modules
+enabled with --modules or in filebeat.modules, together with the index
+template and ILM policy, to a directory. The directory can be loaded with
+'setup --from-dir', so that clusters can be set up without Filebeat
+connecting to them.`,
+		Run: cli.RunWith(func(cmd *cobra.Command, args []string) error {
+			version, _ := cmd.Flags().GetString("es.version")
+			dir, _ := cmd.Flags().GetString("dir")
+			if dir == "" {
+				return errors.New("the --dir flag is required")
+			}
+
+			if settings.ILM == nil {
+				settings.ILM = lifecycle.StdSupport
+			}
+			b, err := instance.NewInitializedBeat(settings)
+			if err != nil {
+				return fmt.Errorf("failed to initialize 'export' command: %w", err)
+			}
+
+			client, err := export.NewFileClient(dir, version)
+			if err != nil {
+				return fmt.Errorf("error creating directory: %w", err)
+			}
+			clientHandler, err := idxmgmt.NewFileClientHandler(client, b.Info, b.Config.LifecycleConfig)
+			if err != nil {
+				return fmt.Errorf("error creating file handler: %w", err)
+			}
+			idxManager := b.IdxSupporter.Manager(clientHandler, idxmgmt.BeatsAssets(b.Fields))
+			if err := idxManager.Setup(idxmgmt.LoadModeForce, idxmgmt.LoadModeForce); err != nil {
+				return fmt.Errorf("error exporting index management assets: %w", err)
+			}
+
+			var config struct {
+				Modules []*conf.C `config:"modules"`
+			}
+			if err := b.Beat.BeatConfig.Unpack(&config); err != nil {
+				return fmt.Errorf("error reading modules configuration: %w", err)
+			}
+			// Export the pipelines of every fileset of the enabled modules.
+			registry, err := fileset.NewModuleRegistry(config.Modules, b.Info, true, fileset.FilesetOverrides{
+				ForceEnableModuleFilesets: true,
+			})
+			if err != nil {
+				return fmt.Errorf("error loading modules: %w", err)
+			}
+			if registry.Empty() {
+				return errors.New("no modules enabled, use --modules to select the modules to export")
+			}
+			pipelines, err := registry.ExportPipelines(client)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Exported %d ingest pipelines\n", len(pipelines))
+			return nil
+		}),
+	}
+
+	exportCmd.Flags().AddGoFlag(flag.CommandLine.Lookup("modules"))
+	exportCmd.Flags().String("es.version", settings.Version, "Elasticsearch version")
+	cfgfile.AddAllowedBackwardsCompatibleFlag("es.version")
+	exportCmd.Flags().String("dir", "", "Directory to write the exported files to")
+	cfgfile.AddAllowedBackwardsCompatibleFlag("dir")
+
+	return exportCmd
+}
@@ -0,0 +1,156 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/elastic/beats/v7/filebeat/lint"
+	"github.com/elastic/beats/v7/libbeat/cfgfile"
+	"github.com/elastic/beats/v7/libbeat/cmd/instance"
+	"github.com/elastic/beats/v7/libbeat/common/cli"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/paths"
+)
+
+func genLintCmd(settings instance.Settings) *cobra.Command {
+	lintCmd := &cobra.Command{
+		Use:   "lint",
+		Short: "Check the semantics of the input configurations",
+		Long: `Lint goes beyond test config and checks the input specific semantics of the
+configuration: whether the paths of the inputs match files, whether the
+winlog channels exist, whether the httpjson templates compile, and whether the
+processors reference known fields. Findings are printed as text or, with
+--format json, as a JSON array. Lint fails if any finding is an error.`,
+		Run: cli.RunWith(func(cmd *cobra.Command, args []string) error {
+			format, _ := cmd.Flags().GetString("format")
+			if format != "text" && format != "json" {
+				return fmt.Errorf("unsupported format %q, must be text or json", format)
+			}
+
+			b, err := instance.NewInitializedBeat(settings)
+			if err != nil {
+				return fmt.Errorf("error initializing beat: %w", err)
+			}
+
+			linter, err := lint.New(b.Fields)
+			if err != nil {
+				return err
+			}
+
+			var global struct {
+				Processors []*conf.C `config:"processors"`
+			}
+			if err := b.RawConfig.Unpack(&global); err != nil {
+				return fmt.Errorf("error reading processors configuration: %w", err)
+			}
+			inputs, err := lintInputs(b.Beat.BeatConfig)
+			if err != nil {
+				return err
+			}
+
+			findings := linter.Lint(global.Processors, inputs)
+			if err := writeFindings(cmd.OutOrStdout(), format, findings); err != nil {
+				return err
+			}
+
+			count := 0
+			for _, f := range findings {
+				if f.Severity == lint.SeverityError {
+					count++
+				}
+			}
+			if count > 0 {
+				return fmt.Errorf("found %d errors in the configuration", count)
+			}
+			return nil
+		}),
+	}
+	lintCmd.Flags().String("format", "text", "Output format of the findings, text or json")
+
+	return lintCmd
+}
+
+// lintInputs returns the inputs of filebeat.inputs and of the files loaded
+// by filebeat.config.inputs.
+func lintInputs(cfg *conf.C) ([]lint.Input, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	settings := struct {
+		Inputs      []*conf.C             `config:"inputs"`
+		ConfigInput cfgfile.DynamicConfig `config:"config.inputs"`
+	}{ConfigInput: cfgfile.DefaultDynamicConfig}
+	if err := cfg.Unpack(&settings); err != nil {
+		return nil, fmt.Errorf("error reading inputs configuration: %w", err)
+	}
+
+	var inputs []lint.Input
+	for i, in := range settings.Inputs {
+		inputs = append(inputs, lint.Input{Path: "filebeat.inputs." + strconv.Itoa(i), Config: in})
+	}
+
+	if !cfg.HasField("config.inputs") || settings.ConfigInput.Path == "" {
+		return inputs, nil
+	}
+	pattern := settings.ConfigInput.Path
+	if !filepath.IsAbs(pattern) {
+		pattern = paths.Resolve(paths.Config, pattern)
+	}
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config.inputs.path: %w", err)
+	}
+	for _, file := range files {
+		list, err := cfgfile.LoadList(file)
+		if err != nil {
+			return nil, err
+		}
+		for i, in := range list {
+			inputs = append(inputs, lint.Input{Path: file + ":" + strconv.Itoa(i), Config: in})
+		}
+	}
+	return inputs, nil
+}
+
+func writeFindings(w io.Writer, format string, findings []lint.Finding) error {
+	if format == "json" {
+		if findings == nil {
+			findings = []lint.Finding{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(findings)
+	}
+
+	if len(findings) == 0 {
+		fmt.Fprintln(w, "No findings")
+		return nil
+	}
+	for _, f := range findings {
+		fmt.Fprintln(w, f.String())
+	}
+	return nil
+}
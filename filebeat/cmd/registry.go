@@ -0,0 +1,341 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/elastic/beats/v7/filebeat/config"
+	"github.com/elastic/beats/v7/libbeat/cmd/instance"
+	"github.com/elastic/beats/v7/libbeat/cmd/instance/locks"
+	"github.com/elastic/beats/v7/libbeat/common/cli"
+	"github.com/elastic/beats/v7/libbeat/statestore/backend"
+	"github.com/elastic/beats/v7/libbeat/statestore/backend/memlog"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/paths"
+)
+
+func genRegistryCmd(settings instance.Settings) *cobra.Command {
+	registryCmd := cobra.Command{
+		Use:   "registry",
+		Short: "Inspect and maintain the registry",
+	}
+	registryCmd.AddCommand(genRegistryInspectCmd(settings))
+	registryCmd.AddCommand(genRegistryRemoveCmd(settings))
+	registryCmd.AddCommand(genRegistryCompactCmd(settings))
+
+	return &registryCmd
+}
+
+func genRegistryInspectCmd(settings instance.Settings) *cobra.Command {
+	inspectCmd := &cobra.Command{
+		Use:   "inspect",
+		Short: "List the entries of the registry",
+		Long: `Inspect prints every entry of the registry as a JSON document per line.
+Filebeat must be stopped.`,
+		Run: cli.RunWith(func(cmd *cobra.Command, args []string) error {
+			input, _ := cmd.Flags().GetString("input")
+			return withRegistryStore(settings, func(store backend.Store, _ string) error {
+				entries, err := readRegistryEntries(store, input)
+				if err != nil {
+					return err
+				}
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				for _, e := range entries {
+					if err := enc.Encode(e.document()); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		}),
+	}
+	inspectCmd.Flags().String("input", "", "Only list the entries of the input with this ID")
+
+	return inspectCmd
+}
+
+func genRegistryRemoveCmd(settings instance.Settings) *cobra.Command {
+	removeCmd := &cobra.Command{
+		Use:   "remove",
+		Short: "Remove entries of an input from the registry",
+		Long: `Remove deletes the entries of an input from the registry. Without filters
+all entries of the input are removed. With --older-than and --missing-files
+only the entries matching all filters are removed. Filebeat must be stopped.`,
+		Run: cli.RunWith(func(cmd *cobra.Command, args []string) error {
+			input, _ := cmd.Flags().GetString("input")
+			olderThan, _ := cmd.Flags().GetDuration("older-than")
+			missingFiles, _ := cmd.Flags().GetBool("missing-files")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			if input == "" {
+				return errors.New("--input is required")
+			}
+
+			filter := registryFilter{olderThan: olderThan, missingFiles: missingFiles, now: time.Now()}
+			return withRegistryStore(settings, func(store backend.Store, _ string) error {
+				removed, err := removeRegistryEntries(store, input, filter, dryRun)
+				for _, key := range removed {
+					fmt.Fprintln(cmd.OutOrStdout(), key)
+				}
+				if err != nil {
+					return err
+				}
+				verb := "Removed"
+				if dryRun {
+					verb = "Would remove"
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "%s %d registry entries of input %s\n", verb, len(removed), input)
+				return nil
+			})
+		}),
+	}
+	removeCmd.Flags().String("input", "", "ID of the input whose entries are removed")
+	removeCmd.Flags().Duration("older-than", 0, "Only remove entries not updated for this duration")
+	removeCmd.Flags().Bool("missing-files", false, "Only remove entries of files that don't exist anymore")
+	removeCmd.Flags().Bool("dry-run", false, "List the entries without removing them")
+
+	return removeCmd
+}
+
+func genRegistryCompactCmd(settings instance.Settings) *cobra.Command {
+	return &cobra.Command{
+		Use:   "compact",
+		Short: "Compact the data files of the registry",
+		Long: `Compact writes the current state of the registry to a new checkpoint file
+and deletes the log of operations and older data files. Filebeat must be
+stopped.`,
+		Run: cli.RunWith(func(cmd *cobra.Command, args []string) error {
+			return withRegistryStore(settings, func(store backend.Store, home string) error {
+				before, err := dirSize(home)
+				if err != nil {
+					return err
+				}
+				checkpointer, ok := store.(interface{ Checkpoint() error })
+				if !ok {
+					return errors.New("the registry doesn't support compaction")
+				}
+				if err := checkpointer.Checkpoint(); err != nil {
+					return fmt.Errorf("failed to compact registry: %w", err)
+				}
+				after, err := dirSize(home)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "Compacted registry from %d to %d bytes\n", before, after)
+				return nil
+			})
+		}),
+	}
+}
+
+// registryEntry is an entry of the registry. The states of inputs based on
+// the v2 input API share the same layout.
+type registryEntry struct {
+	key   string
+	value mapstr.M
+	state struct {
+		TTL     time.Duration
+		Updated time.Time
+		Cursor  interface{}
+		Meta    interface{}
+	}
+}
+
+// document returns the entry as it's printed by inspect.
+func (e registryEntry) document() mapstr.M {
+	value := e.value.Clone()
+	if !e.state.Updated.IsZero() {
+		value["updated"] = e.state.Updated.Format(time.RFC3339Nano)
+		value["ttl"] = e.state.TTL.String()
+	}
+	return mapstr.M{"key": e.key, "value": value}
+}
+
+// source returns the path of the file of the entry, if it has one.
+func (e registryEntry) source() string {
+	if meta, ok := e.state.Meta.(map[string]interface{}); ok {
+		if source, ok := meta["source"].(string); ok {
+			return source
+		}
+	}
+	return ""
+}
+
+// readRegistryEntries returns the entries of the input with the given ID,
+// or all entries if input is empty, sorted by key.
+func readRegistryEntries(store backend.Store, input string) ([]registryEntry, error) {
+	var entries []registryEntry
+	err := store.Each(func(key string, dec backend.ValueDecoder) (bool, error) {
+		if !keyMatchesInput(key, input) {
+			return true, nil
+		}
+		e := registryEntry{key: key}
+		if err := dec.Decode(&e.value); err != nil {
+			return false, fmt.Errorf("failed to read registry entry %s: %w", key, err)
+		}
+		// Entries that are not input states keep a zero state.
+		_ = dec.Decode(&e.state)
+		entries = append(entries, e)
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	return entries, nil
+}
+
+// keyMatchesInput returns true if the key belongs to the input with the given
+// ID. Keys of the v2 inputs have the form <type>::<input ID>::<source>.
+func keyMatchesInput(key, input string) bool {
+	if input == "" {
+		return true
+	}
+	parts := strings.SplitN(key, "::", 3)
+	return len(parts) == 3 && parts[1] == input
+}
+
+type registryFilter struct {
+	olderThan    time.Duration
+	missingFiles bool
+	now          time.Time
+}
+
+func (f registryFilter) matches(e registryEntry) bool {
+	if f.olderThan > 0 {
+		if e.state.Updated.IsZero() || f.now.Sub(e.state.Updated) < f.olderThan {
+			return false
+		}
+	}
+	if f.missingFiles {
+		source := e.source()
+		if source == "" {
+			return false
+		}
+		if _, err := os.Stat(source); !errors.Is(err, fs.ErrNotExist) {
+			return false
+		}
+	}
+	return true
+}
+
+// removeRegistryEntries removes the entries of the input matching the filter
+// and returns their keys.
+func removeRegistryEntries(store backend.Store, input string, filter registryFilter, dryRun bool) ([]string, error) {
+	entries, err := readRegistryEntries(store, input)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for _, e := range entries {
+		if !filter.matches(e) {
+			continue
+		}
+		if !dryRun {
+			if err := store.Remove(e.key); err != nil {
+				return removed, fmt.Errorf("failed to remove registry entry %s: %w", e.key, err)
+			}
+		}
+		removed = append(removed, e.key)
+	}
+	return removed, nil
+}
+
+func dirSize(path string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read registry size: %w", err)
+	}
+	return size, nil
+}
+
+// withRegistry opens the registry of the beat and calls fn with it and the
+// name of the beat's store. The data path is locked to make sure Filebeat is
+// not running, it would otherwise overwrite the changes.
+func withRegistry(settings instance.Settings, fn func(reg *memlog.Registry, root, storeName string) error) error {
+	b, err := instance.NewInitializedBeat(settings)
+	if err != nil {
+		return fmt.Errorf("error initializing beat: %w", err)
+	}
+
+	cfg := struct {
+		Registry config.Registry `config:"registry"`
+	}{Registry: config.DefaultConfig.Registry}
+	if b.Beat.BeatConfig != nil {
+		if err := b.Beat.BeatConfig.Unpack(&cfg); err != nil {
+			return fmt.Errorf("error reading registry configuration: %w", err)
+		}
+	}
+
+	lock := locks.New(b.Info)
+	if err := lock.Lock(); err != nil {
+		return fmt.Errorf("filebeat must be stopped: %w", err)
+	}
+	defer func() {
+		_ = lock.Unlock()
+	}()
+
+	root := paths.Resolve(paths.Data, cfg.Registry.Path)
+	reg, err := memlog.New(logp.NewLogger("registry"), memlog.Settings{
+		Root:     root,
+		FileMode: cfg.Registry.Permissions,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to open registry: %w", err)
+	}
+	defer reg.Close()
+
+	return fn(reg, root, b.Info.Beat)
+}
+
+// withRegistryStore calls fn with the store of the beat and its directory.
+func withRegistryStore(settings instance.Settings, fn func(store backend.Store, home string) error) error {
+	return withRegistry(settings, func(reg *memlog.Registry, root, storeName string) error {
+		store, err := reg.Access(storeName)
+		if err != nil {
+			return fmt.Errorf("failed to open registry: %w", err)
+		}
+		defer store.Close()
+
+		return fn(store, filepath.Join(root, storeName))
+	})
+}
@@ -0,0 +1,137 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/statestore/backend"
+	"github.com/elastic/beats/v7/libbeat/statestore/backend/memlog"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+type testState struct {
+	TTL     time.Duration
+	Updated time.Time
+	Cursor  interface{}
+	Meta    interface{}
+}
+
+func openTestRegistryStore(t *testing.T) backend.Store {
+	reg, err := memlog.New(logp.NewLogger("test"), memlog.Settings{Root: t.TempDir(), FileMode: 0o600})
+	require.NoError(t, err)
+	t.Cleanup(func() { reg.Close() })
+
+	store, err := reg.Access("filebeat")
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestRegistryEntries(t *testing.T) {
+	now := time.Now()
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "existing.log")
+	require.NoError(t, os.WriteFile(existing, nil, 0o600))
+
+	setup := func(t *testing.T) backend.Store {
+		store := openTestRegistryStore(t)
+		entries := map[string]testState{
+			"filestream::a::old-missing": {
+				TTL: -1, Updated: now.Add(-100 * time.Hour),
+				Cursor: map[string]interface{}{"offset": 10},
+				Meta:   map[string]interface{}{"source": filepath.Join(dir, "gone.log")},
+			},
+			"filestream::a::old-existing": {
+				TTL: -1, Updated: now.Add(-100 * time.Hour),
+				Meta: map[string]interface{}{"source": existing},
+			},
+			"filestream::a::new-missing": {
+				TTL: -1, Updated: now,
+				Meta: map[string]interface{}{"source": filepath.Join(dir, "gone2.log")},
+			},
+			"filestream::b::other": {TTL: -1, Updated: now.Add(-100 * time.Hour)},
+		}
+		for key, st := range entries {
+			require.NoError(t, store.Set(key, st))
+		}
+		require.NoError(t, store.Set("filebeat::logs::legacy", map[string]interface{}{"source": "x", "offset": 1}))
+		return store
+	}
+
+	t.Run("inspect", func(t *testing.T) {
+		store := setup(t)
+
+		all, err := readRegistryEntries(store, "")
+		require.NoError(t, err)
+		require.Len(t, all, 5)
+		assert.Equal(t, "filebeat::logs::legacy", all[0].key)
+
+		entries, err := readRegistryEntries(store, "a")
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+		assert.Equal(t, "filestream::a::new-missing", entries[0].key)
+
+		doc := entries[1].document()
+		assert.Equal(t, "filestream::a::old-existing", doc["key"])
+		value, _ := doc.GetValue("value.updated")
+		assert.Equal(t, now.Add(-100*time.Hour).Format(time.RFC3339Nano), value)
+	})
+
+	t.Run("remove with filters", func(t *testing.T) {
+		store := setup(t)
+		filter := registryFilter{olderThan: 24 * time.Hour, missingFiles: true, now: now}
+
+		removed, err := removeRegistryEntries(store, "a", filter, true)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"filestream::a::old-missing"}, removed)
+		has, _ := store.Has("filestream::a::old-missing")
+		assert.True(t, has, "dry run must not remove entries")
+
+		removed, err = removeRegistryEntries(store, "a", filter, false)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"filestream::a::old-missing"}, removed)
+		has, _ = store.Has("filestream::a::old-missing")
+		assert.False(t, has)
+	})
+
+	t.Run("remove all entries of an input", func(t *testing.T) {
+		store := setup(t)
+
+		removed, err := removeRegistryEntries(store, "a", registryFilter{now: now}, false)
+		require.NoError(t, err)
+		assert.Len(t, removed, 3)
+
+		all, err := readRegistryEntries(store, "")
+		require.NoError(t, err)
+		assert.Len(t, all, 2)
+	})
+}
+
+func TestKeyMatchesInput(t *testing.T) {
+	assert.True(t, keyMatchesInput("filestream::my-id::native::1-2", "my-id"))
+	assert.True(t, keyMatchesInput("anything", ""))
+	assert.False(t, keyMatchesInput("filestream::other::native::1-2", "my-id"))
+	assert.False(t, keyMatchesInput("filestream::my-id", "my-id"))
+}
@@ -57,6 +57,7 @@ func FilebeatSettings(moduleNameSpace string) instance.Settings {
 		RunFlags:      runFlags,
 		Name:          Name,
 		HasDashboards: true,
+		Tenants:       true,
 		Initialize: []func(){
 			include.InitializeModule,
 			func() { fileset.RegisterMonitoringModules(moduleNameSpace) },
@@ -75,5 +76,9 @@ func Filebeat(inputs beater.PluginFactory, settings instance.Settings) *cmd.Beat
 	cfgfile.AddAllowedBackwardsCompatibleFlag("modules")
 	command.AddCommand(cmd.GenModulesCmd(Name, "", buildModulesManager))
 	command.AddCommand(genGenerateCmd())
+	command.AddCommand(genWinlogCmd(settings))
+	command.AddCommand(genRegistryCmd(settings))
+	command.AddCommand(genLintCmd(settings))
+	command.ExportCmd.AddCommand(genExportPipelinesCmd(settings))
 	return command
 }
@@ -0,0 +1,131 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/elastic/beats/v7/filebeat/input/winlog"
+	"github.com/elastic/beats/v7/libbeat/cmd/instance"
+	"github.com/elastic/beats/v7/libbeat/common/cli"
+	"github.com/elastic/beats/v7/libbeat/statestore"
+	"github.com/elastic/beats/v7/libbeat/statestore/backend/memlog"
+)
+
+func genWinlogCmd(settings instance.Settings) *cobra.Command {
+	winlogCmd := cobra.Command{
+		Use:   "winlog",
+		Short: "Manage the checkpoints of the winlog input",
+	}
+	winlogCmd.AddCommand(genWinlogExportCmd(settings))
+	winlogCmd.AddCommand(genWinlogImportCmd(settings))
+
+	return &winlogCmd
+}
+
+func genWinlogExportCmd(settings instance.Settings) *cobra.Command {
+	exportCmd := &cobra.Command{
+		Use:   "export-bookmarks",
+		Short: "Export the winlog checkpoints from the registry to a file",
+		Long: `Export writes the checkpoint (bookmark and record number) of every winlog
+input source in the registry to a JSON file, which can be imported on another
+host with import-bookmarks. Filebeat must be stopped.`,
+		Run: cli.RunWith(func(cmd *cobra.Command, args []string) error {
+			output, _ := cmd.Flags().GetString("output")
+			return withWinlogStore(settings, func(store *statestore.Store) error {
+				f, err := winlog.ExportBookmarks(store)
+				if err != nil {
+					return err
+				}
+				data, err := json.MarshalIndent(f, "", "  ")
+				if err != nil {
+					return err
+				}
+				if err := os.WriteFile(output, data, 0o600); err != nil {
+					return fmt.Errorf("failed to write bookmarks file: %w", err)
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "Exported %d winlog bookmarks to %s\n", len(f.Bookmarks), output)
+				return nil
+			})
+		}),
+	}
+	exportCmd.Flags().StringP("output", "o", "winlog-bookmarks.json", "Path of the bookmarks file")
+
+	return exportCmd
+}
+
+func genWinlogImportCmd(settings instance.Settings) *cobra.Command {
+	importCmd := &cobra.Command{
+		Use:   "import-bookmarks",
+		Short: "Import winlog checkpoints from a file into the registry",
+		Long: `Import writes the checkpoints of a file created by export-bookmarks to the
+registry, so that the winlog inputs continue from the exported position.
+Sources that already have a checkpoint are skipped unless --force is set.
+Filebeat must be stopped.`,
+		Run: cli.RunWith(func(cmd *cobra.Command, args []string) error {
+			input, _ := cmd.Flags().GetString("input")
+			force, _ := cmd.Flags().GetBool("force")
+
+			data, err := os.ReadFile(input)
+			if err != nil {
+				return fmt.Errorf("failed to read bookmarks file: %w", err)
+			}
+			var f winlog.BookmarksFile
+			if err := json.Unmarshal(data, &f); err != nil {
+				return fmt.Errorf("failed to parse bookmarks file: %w", err)
+			}
+
+			return withWinlogStore(settings, func(store *statestore.Store) error {
+				imported, skipped, err := winlog.ImportBookmarks(store, f, force)
+				for _, key := range skipped {
+					fmt.Fprintf(cmd.OutOrStdout(), "Skipped %s: checkpoint already exists, use --force to overwrite\n", key)
+				}
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "Imported %d winlog bookmarks from %s\n", len(imported), input)
+				return nil
+			})
+		}),
+	}
+	importCmd.Flags().StringP("input", "i", "winlog-bookmarks.json", "Path of the bookmarks file")
+	importCmd.Flags().Bool("force", false, "Overwrite existing checkpoints")
+
+	return importCmd
+}
+
+// withWinlogStore opens the registry of the beat and calls fn with its
+// store.
+func withWinlogStore(settings instance.Settings, fn func(*statestore.Store) error) error {
+	return withRegistry(settings, func(reg *memlog.Registry, _, storeName string) error {
+		registry := statestore.NewRegistry(reg)
+		defer registry.Close()
+
+		store, err := registry.Get(storeName)
+		if err != nil {
+			return fmt.Errorf("failed to open registry: %w", err)
+		}
+		defer store.Close()
+
+		return fn(store)
+	})
+}
@@ -48,6 +48,21 @@ type Config struct {
 	ConfigModules      *conf.C              `config:"config.modules"`
 	Autodiscover       *autodiscover.Config `config:"autodiscover"`
 	OverwritePipelines bool                 `config:"overwrite_pipelines"`
+	HealthReport       HealthReport         `config:"health_report"`
+}
+
+// HealthReport configures the periodic event reporting the aggregated health
+// of the inputs.
+type HealthReport struct {
+	Enabled bool          `config:"enabled"`
+	Period  time.Duration `config:"period"`
+}
+
+func (c *HealthReport) Validate() error {
+	if c.Enabled && c.Period <= 0 {
+		return fmt.Errorf("health_report.period must be positive, got %v", c.Period)
+	}
+	return nil
 }
 
 type Registry struct {
@@ -68,6 +83,10 @@ var DefaultConfig = Config{
 	},
 	ShutdownTimeout:    0,
 	OverwritePipelines: false,
+	HealthReport: HealthReport{
+		Enabled: false,
+		Period:  5 * time.Minute,
+	},
 }
 
 // getConfigFiles returns list of config files.
@@ -114,6 +114,44 @@ func (reg *ModuleRegistry) LoadPipelines(esClient PipelineLoader, overwrite bool
 	return nil
 }
 
+// PipelineWriter writes exported pipelines, for example to a directory.
+type PipelineWriter interface {
+	GetVersion() version.V
+	Write(component string, name string, body string) error
+}
+
+// ExportPipelines writes the pipelines of each configured fileset to w, adapted
+// to the Elasticsearch version of w. It returns the IDs of the exported
+// pipelines.
+func (reg *ModuleRegistry) ExportPipelines(w PipelineWriter) ([]string, error) {
+	esVersion := w.GetVersion()
+	var exported []string
+	for _, module := range reg.registry {
+		for _, fileset := range module.filesets {
+			pipelines, err := fileset.GetPipelines(esVersion)
+			if err != nil {
+				return exported, fmt.Errorf("error getting pipeline for fileset %s/%s: %w", module.config.Module, fileset.name, err)
+			}
+
+			for _, pipeline := range pipelines {
+				log := reg.log.With("pipeline", pipeline.id)
+				if err := AdaptPipelineForCompatibility(esVersion, pipeline.id, pipeline.contents, log); err != nil {
+					return exported, fmt.Errorf("failed to adapt pipeline %s with backwards compatibility changes: %w", pipeline.id, err)
+				}
+				body, err := json.MarshalIndent(pipeline.contents, "", "  ")
+				if err != nil {
+					return exported, fmt.Errorf("error encoding pipeline %s: %w", pipeline.id, err)
+				}
+				if err := w.Write("pipeline", pipeline.id, string(body)+"\n"); err != nil {
+					return exported, fmt.Errorf("error writing pipeline %s: %w", pipeline.id, err)
+				}
+				exported = append(exported, pipeline.id)
+			}
+		}
+	}
+	return exported, nil
+}
+
 func LoadPipeline(esClient PipelineLoader, pipelineID string, content map[string]interface{}, overwrite bool, log *logp.Logger) error {
 	path := makeIngestPipelinePath(pipelineID)
 	if !overwrite {
@@ -21,8 +21,11 @@ package fileset
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -32,6 +35,7 @@ import (
 	"github.com/elastic/beats/v7/libbeat/esleg/eslegclient"
 	"github.com/elastic/elastic-agent-libs/logp"
 	"github.com/elastic/elastic-agent-libs/transport/httpcommon"
+	"github.com/elastic/elastic-agent-libs/version"
 )
 
 func TestLoadPipelinesWithMultiPipelineFileset(t *testing.T) {
@@ -116,3 +120,59 @@ func TestLoadPipelinesWithMultiPipelineFileset(t *testing.T) {
 		})
 	}
 }
+
+type testPipelineWriter struct {
+	version version.V
+	written map[string]string
+}
+
+func (w *testPipelineWriter) GetVersion() version.V {
+	return w.version
+}
+
+func (w *testPipelineWriter) Write(component string, name string, body string) error {
+	w.written[component+"/"+name] = body
+	return nil
+}
+
+func TestExportPipelines(t *testing.T) {
+	modulePath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(modulePath, "fls"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(modulePath, "fls", "pipeline-json.json"),
+		[]byte(`{"processors": [{"json": {"field": "message"}}]}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(modulePath, "fls", "pipeline-plain.yml"),
+		[]byte("processors:\n- set:\n    field: event.kind\n    value: event\n"), 0o644))
+
+	testRegistry := ModuleRegistry{
+		registry: []Module{
+			{
+				filesets: []Fileset{
+					{
+						name:       "fls",
+						modulePath: modulePath,
+						manifest: &manifest{
+							IngestPipeline: []string{"pipeline-plain.yml", "pipeline-json.json"},
+						},
+						vars: map[string]interface{}{
+							"builtin": map[string]interface{}{},
+						},
+						pipelineIDs: []string{"filebeat-8.0.0-mod-fls-pipeline-plain", "filebeat-8.0.0-mod-fls-pipeline-json"},
+					},
+				},
+			},
+		},
+		log: logp.NewLogger(logName),
+	}
+
+	w := &testPipelineWriter{version: *version.MustNew("8.0.0"), written: map[string]string{}}
+	exported, err := testRegistry.ExportPipelines(w)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"filebeat-8.0.0-mod-fls-pipeline-plain", "filebeat-8.0.0-mod-fls-pipeline-json"}, exported)
+	require.Len(t, w.written, 2)
+
+	var content map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(w.written["pipeline/filebeat-8.0.0-mod-fls-pipeline-plain"]), &content))
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"set": map[string]interface{}{"field": "event.kind", "value": "event"}},
+	}, content["processors"])
+}
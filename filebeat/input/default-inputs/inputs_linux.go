@@ -19,6 +19,7 @@ package inputs
 
 import (
 	"github.com/elastic/beats/v7/filebeat/input/journald"
+	"github.com/elastic/beats/v7/filebeat/input/journalremote"
 	"github.com/elastic/beats/v7/filebeat/input/systemlogs"
 	v2 "github.com/elastic/beats/v7/filebeat/input/v2"
 	cursor "github.com/elastic/beats/v7/filebeat/input/v2/input-cursor"
@@ -40,6 +41,7 @@ func osInputs(info beat.Info, log *logp.Logger, components osComponents) []v2.Pl
 		plugins = append(plugins, journald)
 		plugins = append(plugins, systemlogs.PluginV2(log, components))
 	}
+	plugins = append(plugins, journalremote.Plugin())
 
 	return plugins
 }
@@ -0,0 +1,128 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package filestream
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	loginp "github.com/elastic/beats/v7/filebeat/input/filestream/internal/input-logfile"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+// DefaultAnchorSize is the default number of bytes hashed for a content anchor.
+const DefaultAnchorSize int64 = 1024
+
+type contentAnchorConfig struct {
+	Enabled bool  `config:"enabled"`
+	Offset  int64 `config:"offset" validate:"min=0"`
+	Length  int64 `config:"length" validate:"min=1"`
+}
+
+func defaultContentAnchorConfig() contentAnchorConfig {
+	return contentAnchorConfig{
+		Enabled: false,
+		Offset:  0,
+		Length:  DefaultAnchorSize,
+	}
+}
+
+// contentAnchor is a hash of a section of a file stored in its cursor. It
+// detects inode reuse: on some file systems a new file can get the inode of
+// a rotated one and would otherwise be resumed from the old file's offset.
+type contentAnchor struct {
+	Offset int64  `json:"offset" struct:"offset"`
+	Length int64  `json:"length" struct:"length"`
+	Hash   string `json:"hash" struct:"hash"`
+}
+
+// newContentAnchor hashes up to length bytes of the file starting at offset.
+// It returns nil if the file has no content at offset yet. If decompress is
+// set, the decompressed content of compressed files is hashed, so that a
+// rotated file keeps its anchor once it is compressed.
+func newContentAnchor(path string, offset, length int64, decompress bool) (*contentAnchor, error) {
+	hash, n, err := hashFileSection(path, offset, length, decompress)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	return &contentAnchor{Offset: offset, Length: n, Hash: hash}, nil
+}
+
+// matches returns true if the file still contains the anchored content.
+func (a *contentAnchor) matches(path string, decompress bool) (bool, error) {
+	hash, n, err := hashFileSection(path, a.Offset, a.Length, decompress)
+	if err != nil {
+		return false, err
+	}
+	return n == a.Length && hash == a.Hash, nil
+}
+
+func hashFileSection(path string, offset, length int64, decompress bool) (string, int64, error) {
+	r, err := openContent(path, decompress)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to open %q for content anchor: %w", path, err)
+	}
+	defer r.Close()
+
+	if err := r.Skip(offset); err != nil {
+		if errors.Is(err, io.EOF) {
+			return "", 0, nil
+		}
+		return "", 0, fmt.Errorf("failed to hash content anchor of %q: %w", path, err)
+	}
+	h := sha256.New()
+	n, err := io.Copy(h, io.LimitReader(r, length))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to hash content anchor of %q: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+// verifyAnchor checks that the file at path still has the content the state
+// was recorded for. If it doesn't, reading starts over from the beginning.
+// A state without an anchor gets a new one.
+func (inp *filestream) verifyAnchor(log *logp.Logger, path string, s state, metrics *loginp.Metrics) state {
+	if s.Anchor != nil && s.Offset > 0 {
+		ok, err := s.Anchor.matches(path, inp.decompress)
+		if err != nil {
+			log.Warnf("Cannot verify content anchor: %v", err)
+			return s
+		}
+		if !ok {
+			log.Warnf("Content anchor doesn't match, the file is not the one the state was recorded for. Reading file from offset 0. Path=%s", path)
+			metrics.AnchorMismatches.Inc()
+			s = state{}
+		}
+	}
+
+	if s.Anchor == nil {
+		anchor, err := newContentAnchor(path, inp.anchorConfig.Offset, inp.anchorConfig.Length, inp.decompress)
+		if err != nil {
+			log.Warnf("Cannot create content anchor: %v", err)
+			return s
+		}
+		s.Anchor = anchor
+	}
+	return s
+}
@@ -0,0 +1,98 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package filestream
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	loginp "github.com/elastic/beats/v7/filebeat/input/filestream/internal/input-logfile"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+func TestContentAnchor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+
+	t.Run("empty file has no anchor", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, nil, 0o644))
+		anchor, err := newContentAnchor(path, 0, 8, false)
+		require.NoError(t, err)
+		assert.Nil(t, anchor)
+	})
+
+	t.Run("small file is anchored on its content", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte("line\n"), 0o644))
+		anchor, err := newContentAnchor(path, 0, 8, false)
+		require.NoError(t, err)
+		require.NotNil(t, anchor)
+		assert.Equal(t, int64(5), anchor.Length)
+
+		require.NoError(t, os.WriteFile(path, []byte("line\nmore lines\n"), 0o644))
+		ok, err := anchor.matches(path, false)
+		require.NoError(t, err)
+		assert.True(t, ok, "appending to the file must keep the anchor")
+	})
+
+	t.Run("different content doesn't match", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte("first line\n"), 0o644))
+		anchor, err := newContentAnchor(path, 2, 4, false)
+		require.NoError(t, err)
+		require.NotNil(t, anchor)
+
+		require.NoError(t, os.WriteFile(path, []byte("fiXXt line\n"), 0o644))
+		ok, err := anchor.matches(path, false)
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		require.NoError(t, os.WriteFile(path, []byte("fi"), 0o644))
+		ok, err = anchor.matches(path, false)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
+
+func TestVerifyAnchor(t *testing.T) {
+	log := logp.NewLogger("test")
+	path := filepath.Join(t.TempDir(), "test.log")
+	inp := &filestream{anchorConfig: contentAnchorConfig{Enabled: true, Length: 16}}
+	metrics := loginp.NewMetrics("test-verify-anchor")
+	defer metrics.Close()
+
+	require.NoError(t, os.WriteFile(path, []byte("old file\n"), 0o644))
+	s := inp.verifyAnchor(log, path, state{}, metrics)
+	require.NotNil(t, s.Anchor)
+	s.Offset = 9
+
+	s = inp.verifyAnchor(log, path, s, metrics)
+	assert.Equal(t, int64(9), s.Offset)
+	assert.Zero(t, metrics.AnchorMismatches.Get())
+
+	// A new file with the inode of the old one.
+	require.NoError(t, os.WriteFile(path, []byte("new file, longer\n"), 0o644))
+	s = inp.verifyAnchor(log, path, s, metrics)
+	assert.Zero(t, s.Offset)
+	assert.Equal(t, uint64(1), metrics.AnchorMismatches.Get())
+
+	anchor, err := newContentAnchor(path, 0, 16, false)
+	require.NoError(t, err)
+	assert.Equal(t, anchor, s.Anchor)
+}
@@ -0,0 +1,133 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package filestream
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	compressionNone = "none"
+	compressionAuto = "auto"
+
+	compressionGzip = "gzip"
+	compressionZstd = "zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// detectCompression returns the compression of a file from its magic bytes,
+// gzip, zstd, or an empty string if the file is not compressed. The file is
+// rewound to its start.
+func detectCompression(f io.ReadSeeker) (string, error) {
+	magic := make([]byte, len(zstdMagic))
+	n, err := io.ReadFull(f, magic)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF { //nolint:errorlint // io.ReadFull returns the errors unwrapped.
+		return "", err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	magic = magic[:n]
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		return compressionGzip, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		return compressionZstd, nil
+	default:
+		return "", nil
+	}
+}
+
+// newDecompressor returns a reader of the decompressed content of r.
+func newDecompressor(kind string, r io.Reader) (io.ReadCloser, error) {
+	switch kind {
+	case compressionGzip:
+		return gzip.NewReader(r)
+	case compressionZstd:
+		dec, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return dec.IOReadCloser(), nil
+	default:
+		return nil, fmt.Errorf("unsupported compression %q", kind)
+	}
+}
+
+// contentReader reads the content of a file, decompressing it if it is
+// compressed.
+type contentReader struct {
+	io.Reader
+	file         *os.File
+	decompressor io.ReadCloser
+}
+
+// openContent opens the file at path for reading its content. If decompress
+// is set and the file is compressed, the content is decompressed.
+func openContent(path string, decompress bool) (*contentReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &contentReader{Reader: f, file: f}
+	if !decompress {
+		return r, nil
+	}
+
+	kind, err := detectCompression(f)
+	if err == nil && kind != "" {
+		r.decompressor, err = newDecompressor(kind, f)
+		r.Reader = r.decompressor
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to decompress %q: %w", path, err)
+	}
+	return r, nil
+}
+
+// Skip discards the first n bytes of the content.
+func (r *contentReader) Skip(n int64) error {
+	if n <= 0 {
+		return nil
+	}
+	if r.decompressor == nil {
+		_, err := r.file.Seek(n, io.SeekStart)
+		return err
+	}
+	_, err := io.CopyN(io.Discard, r.decompressor, n)
+	return err
+}
+
+func (r *contentReader) Close() error {
+	if r.decompressor != nil {
+		_ = r.decompressor.Close()
+	}
+	return r.file.Close()
+}
@@ -0,0 +1,213 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package filestream
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	loginp "github.com/elastic/beats/v7/filebeat/input/filestream/internal/input-logfile"
+	"github.com/elastic/beats/v7/libbeat/common/file"
+	"github.com/elastic/beats/v7/libbeat/reader/readfile/encoding"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+func compress(t *testing.T, kind string, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch kind {
+	case compressionGzip:
+		w = gzip.NewWriter(&buf)
+	case compressionZstd:
+		zw, err := zstd.NewWriter(&buf)
+		require.NoError(t, err)
+		w = zw
+	}
+	_, err := w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestDetectCompression(t *testing.T) {
+	content := []byte("first line\nsecond line\n")
+	tests := map[string]struct {
+		data []byte
+		want string
+	}{
+		"plain": {data: content, want: ""},
+		"empty": {data: nil, want: ""},
+		"short": {data: []byte{0x1f}, want: ""},
+		"gzip":  {data: compress(t, compressionGzip, content), want: compressionGzip},
+		"zstd":  {data: compress(t, compressionZstd, content), want: compressionZstd},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := bytes.NewReader(test.data)
+			kind, err := detectCompression(r)
+			require.NoError(t, err)
+			assert.Equal(t, test.want, kind)
+
+			pos, _ := r.Seek(0, io.SeekCurrent)
+			assert.Zero(t, pos, "the reader must be rewound")
+		})
+	}
+}
+
+func TestOpenCompressedFile(t *testing.T) {
+	content := []byte("line 1\nline 2\nline 3\n")
+	inp := &filestream{
+		readerConfig:    defaultReaderConfig(),
+		encodingFactory: encoding.Plain,
+		closerConfig:    defaultCloserConfig(),
+		decompress:      true,
+	}
+	log := logp.NewLogger("test")
+
+	readAll := func(t *testing.T, path string, offset int64) ([]string, bool) {
+		t.Helper()
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		fs := fileSource{
+			newPath: path,
+			desc:    loginp.FileDescriptor{Filename: path, Info: file.ExtendFileInfo(info)},
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		r, truncated, err := inp.open(log, ctx, fs, offset)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var lines []string
+		for {
+			msg, err := r.Next()
+			if errors.Is(err, io.EOF) {
+				return lines, truncated
+			}
+			require.NoError(t, err)
+			lines = append(lines, string(msg.Content))
+		}
+	}
+
+	for _, kind := range []string{compressionGzip, compressionZstd} {
+		t.Run(kind, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "app.log.1."+kind)
+			require.NoError(t, os.WriteFile(path, compress(t, kind, content), 0o644))
+
+			lines, truncated := readAll(t, path, 0)
+			assert.False(t, truncated)
+			assert.Equal(t, []string{"line 1", "line 2", "line 3"}, lines)
+
+			// The offset is an offset in the decompressed content.
+			lines, truncated = readAll(t, path, 7)
+			assert.False(t, truncated)
+			assert.Equal(t, []string{"line 2", "line 3"}, lines)
+
+			lines, truncated = readAll(t, path, 100)
+			assert.True(t, truncated)
+			assert.Equal(t, []string{"line 1", "line 2", "line 3"}, lines)
+		})
+	}
+}
+
+func TestCompressedRotationKeepsFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	content := []byte(strings.Repeat("a line of the rotated file\n", 100))
+	plain := filepath.Join(dir, "app.log.1")
+	require.NoError(t, os.WriteFile(plain, content, 0o644))
+
+	cfg := conf.MustNewConfigFrom(map[string]interface{}{
+		"fingerprint.enabled": true,
+		"check_interval":      "50ms",
+	})
+	fw, err := newScannerWatcher([]string{filepath.Join(dir, "app.log*")}, cfg, watcherOptions{decompress: true})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go fw.Run(ctx)
+
+	created := fw.Event()
+	require.Equal(t, loginp.OpCreate, created.Op)
+	require.NotEmpty(t, created.Descriptor.Fingerprint)
+
+	// logrotate compresses the rotated file and removes it.
+	compressed := plain + ".gz"
+	require.NoError(t, os.WriteFile(compressed, compress(t, compressionGzip, content), 0o644))
+	require.NoError(t, os.Remove(plain))
+
+	renamed := fw.Event()
+	assert.Equal(t, loginp.OpRename, renamed.Op)
+	assert.Equal(t, plain, renamed.OldPath)
+	assert.Equal(t, compressed, renamed.NewPath)
+	assert.Equal(t, created.Descriptor.Fingerprint, renamed.Descriptor.Fingerprint)
+}
+
+func TestCompressedFileTooSmallForFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log.1.gz")
+	require.NoError(t, os.WriteFile(path, compress(t, compressionGzip, []byte("short\n")), 0o644))
+
+	s, err := newFileScanner([]string{path}, fileScannerConfig{
+		Fingerprint: fingerprintConfig{Enabled: true, Length: 64},
+		decompress:  true,
+	})
+	require.NoError(t, err)
+	_, err = s.contentFingerprint(path)
+	assert.ErrorIs(t, err, errFileTooSmall)
+}
+
+func TestWatcherReportsOldestFilesFirst(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	files := []string{"app.log", "app.log.2.gz", "app.log.1.gz"}
+	for i, name := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte("content\n"), 0o644))
+		mtime := now.Add(-time.Duration(i) * time.Hour)
+		require.NoError(t, os.Chtimes(path, mtime, mtime))
+	}
+
+	fw, err := newScannerWatcher([]string{filepath.Join(dir, "app.log*")}, conf.NewConfig(), watcherOptions{})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go fw.Run(ctx)
+
+	var order []string
+	for range files {
+		order = append(order, filepath.Base(fw.Event().NewPath))
+	}
+	assert.Equal(t, []string{"app.log.1.gz", "app.log.2.gz", "app.log"}, order)
+}
@@ -46,7 +46,49 @@ type config struct {
 	IgnoreOlder    time.Duration      `config:"ignore_older"`
 	IgnoreInactive ignoreInactiveType `config:"ignore_inactive"`
 	Rotation       *conf.Namespace    `config:"rotation"`
-	TakeOver       bool               `config:"take_over"`
+	TakeOver       TakeOverConfig     `config:"take_over"`
+
+	ContentAnchor contentAnchorConfig `config:"content_anchor"`
+	Compression   string              `config:"compression"`
+	NetworkFS     networkFSConfig     `config:"network_fs"`
+}
+
+// TakeOverConfig configures the take over of the states of other inputs and
+// shippers. It can also be set with a boolean, which sets Enabled.
+type TakeOverConfig struct {
+	Enabled bool `config:"enabled"`
+	// From lists the state files of third-party shippers whose positions
+	// are imported.
+	From []TakeOverSource `config:"from"`
+}
+
+// TakeOverSource is the state file of a third-party shipper.
+type TakeOverSource struct {
+	Type string `config:"type" validate:"required"`
+	Path string `config:"path" validate:"required"`
+}
+
+// Unpack unpacks the configuration from a boolean or from an object.
+func (c *TakeOverConfig) Unpack(value interface{}) error {
+	switch v := value.(type) {
+	case bool:
+		*c = TakeOverConfig{Enabled: v}
+		return nil
+	case map[string]interface{}:
+		cfg, err := conf.NewConfigFrom(v)
+		if err != nil {
+			return err
+		}
+		type takeOverConfig TakeOverConfig
+		var tmp takeOverConfig
+		if err := cfg.Unpack(&tmp); err != nil {
+			return err
+		}
+		*c = TakeOverConfig(tmp)
+		return nil
+	default:
+		return fmt.Errorf("take_over must be a boolean or an object, got %T", value)
+	}
 }
 
 type closerConfig struct {
@@ -104,6 +146,9 @@ func defaultConfig() config {
 		CleanRemoved:   true,
 		HarvesterLimit: 0,
 		IgnoreOlder:    0,
+		ContentAnchor:  defaultContentAnchorConfig(),
+		Compression:    compressionNone,
+		NetworkFS:      defaultNetworkFSConfig(),
 	}
 }
 
@@ -140,5 +185,11 @@ func (c *config) Validate() error {
 		return fmt.Errorf("no path is configured")
 	}
 
+	switch c.Compression {
+	case compressionNone, compressionAuto:
+	default:
+		return fmt.Errorf("invalid compression %q, must be %s or %s", c.Compression, compressionNone, compressionAuto)
+	}
+
 	return nil
 }
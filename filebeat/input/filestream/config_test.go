@@ -21,6 +21,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	conf "github.com/elastic/elastic-agent-libs/config"
 )
 
 func TestConfigValidate(t *testing.T) {
@@ -30,3 +32,32 @@ func TestConfigValidate(t *testing.T) {
 		require.Error(t, err)
 	})
 }
+
+func TestTakeOverConfigUnpack(t *testing.T) {
+	t.Run("boolean", func(t *testing.T) {
+		c := defaultConfig()
+		err := conf.MustNewConfigFrom(`
+paths: [/var/log/*.log]
+take_over: true
+`).Unpack(&c)
+		require.NoError(t, err)
+		require.Equal(t, TakeOverConfig{Enabled: true}, c.TakeOver)
+	})
+
+	t.Run("object", func(t *testing.T) {
+		c := defaultConfig()
+		err := conf.MustNewConfigFrom(`
+paths: [/var/log/*.log]
+take_over:
+  enabled: true
+  from:
+    - type: promtail
+      path: /var/lib/promtail/positions.yaml
+`).Unpack(&c)
+		require.NoError(t, err)
+		require.Equal(t, TakeOverConfig{
+			Enabled: true,
+			From:    []TakeOverSource{{Type: "promtail", Path: "/var/lib/promtail/positions.yaml"}},
+		}, c.TakeOver)
+	})
+}
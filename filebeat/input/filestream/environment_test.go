@@ -573,6 +573,10 @@ func (c *mockClient) PublishAll(events []beat.Event) {
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
 
+	for i := range events {
+		// Like the pipeline client, only keep the nested fields.
+		events[i].Materialize()
+	}
 	c.publishing = append(c.publishing, events...)
 	for _, event := range events {
 		c.ackHandler.AddEvent(event, true)
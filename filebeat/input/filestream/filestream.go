@@ -20,8 +20,10 @@ package filestream
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/elastic/go-concert/ctxtool"
@@ -53,6 +55,12 @@ type logFile struct {
 	closeRemoved  bool
 	closeRenamed  bool
 
+	// decompressor reads the decompressed content of compressed files. It
+	// is nil for plain files. decompressorMu prevents closing it while it
+	// is read.
+	decompressor   io.ReadCloser
+	decompressorMu sync.Mutex
+
 	offset       int64
 	lastTimeRead time.Time
 	backoff      backoff.Backoff
@@ -102,7 +110,7 @@ func (f *logFile) Read(buf []byte) (int, error) {
 	totalN := 0
 
 	for f.readerCtx.Err() == nil {
-		n, err := f.file.Read(buf)
+		n, err := f.read(buf)
 		if n > 0 {
 			f.offset += int64(n)
 			f.lastTimeRead = time.Now()
@@ -135,6 +143,15 @@ func (f *logFile) Read(buf []byte) (int, error) {
 	return 0, ErrClosed
 }
 
+func (f *logFile) read(buf []byte) (int, error) {
+	if f.decompressor == nil {
+		return f.file.Read(buf)
+	}
+	f.decompressorMu.Lock()
+	defer f.decompressorMu.Unlock()
+	return f.decompressor.Read(buf)
+}
+
 func (f *logFile) startFileMonitoringIfNeeded() {
 	if f.closeInactive > 0 || f.closeRemoved || f.closeRenamed {
 		err := f.tg.Go(func(ctx context.Context) error {
@@ -196,6 +213,12 @@ func (f *logFile) shouldBeClosed() bool {
 			return true
 		}
 
+		// the handle is not valid anymore, reading the file again requires opening it again
+		if isStaleFileHandle(statErr) {
+			f.log.Warnf("File handle of %s is stale, closing the reader", f.file.Name())
+			return true
+		}
+
 		// If an unexpected error happens we keep the reader open hoping once everything will go back to normal.
 		f.log.Errorf("Unexpected error reading from %s; error: %s", f.file.Name(), statErr)
 		return false
@@ -232,6 +255,10 @@ func isSameFile(path string, info os.FileInfo) bool {
 // errorChecks determines the cause for EOF errors, and how the EOF event should be handled
 // based on the config options.
 func (f *logFile) errorChecks(err error) error {
+	if isStaleFileHandle(err) {
+		return fmt.Errorf("%w: %s", ErrStaleFileHandle, f.file.Name())
+	}
+
 	if !errors.Is(err, io.EOF) {
 		f.log.Error("Unexpected state reading from %s; error: %s", f.file.Name(), err)
 		return err
@@ -267,6 +294,12 @@ func (f *logFile) handleEOF() error {
 func (f *logFile) Close() error {
 	f.readerCtx.Cancel()
 	err := f.file.Close()
+	if f.decompressor != nil {
+		// Closing the file fails the read in progress, if any.
+		f.decompressorMu.Lock()
+		_ = f.decompressor.Close()
+		f.decompressorMu.Unlock()
+	}
 	_ = f.tg.Stop() // Wait until all resources are released for sure.
 	return err
 }
@@ -26,6 +26,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"time"
 
 	"github.com/elastic/go-concert/timed"
@@ -58,6 +59,10 @@ type fileWatcherConfig struct {
 	ResendOnModTime bool `config:"resend_on_touch"`
 	// Scanner is the configuration of the scanner.
 	Scanner fileScannerConfig `config:",inline"`
+
+	// minInterval is the minimum time between two scans with adaptive
+	// polling, which is disabled when it is zero.
+	minInterval time.Duration
 }
 
 // fileWatcher gets the list of files from a FSWatcher and creates events by
@@ -70,7 +75,19 @@ type fileWatcher struct {
 	events  chan loginp.FSEvent
 }
 
-func newFileWatcher(paths []string, ns *conf.Namespace) (loginp.FSWatcher, error) {
+// watcherOptions are the options of the file watcher set by the input
+// configuration rather than by the prospector configuration.
+type watcherOptions struct {
+	// decompress is set when the fingerprints of compressed files are
+	// computed on their decompressed content.
+	decompress bool
+	// minInterval enables adaptive polling when it is positive: the time
+	// between two scans is reset to minInterval when files changed, and
+	// doubles up to check_interval while they don't.
+	minInterval time.Duration
+}
+
+func newFileWatcher(paths []string, ns *conf.Namespace, opts watcherOptions) (loginp.FSWatcher, error) {
 	var config *conf.C
 	if ns == nil {
 		config = conf.NewConfig()
@@ -78,15 +95,20 @@ func newFileWatcher(paths []string, ns *conf.Namespace) (loginp.FSWatcher, error
 		config = ns.Config()
 	}
 
-	return newScannerWatcher(paths, config)
+	return newScannerWatcher(paths, config, opts)
 }
 
-func newScannerWatcher(paths []string, c *conf.C) (loginp.FSWatcher, error) {
+func newScannerWatcher(paths []string, c *conf.C, opts watcherOptions) (loginp.FSWatcher, error) {
 	config := defaultFileWatcherConfig()
 	err := c.Unpack(&config)
 	if err != nil {
 		return nil, err
 	}
+	config.Scanner.decompress = opts.decompress
+	config.minInterval = opts.minInterval
+	if config.minInterval > config.Interval {
+		config.minInterval = config.Interval
+	}
 	scanner, err := newFileScanner(paths, config.Scanner)
 	if err != nil {
 		return nil, err
@@ -114,6 +136,11 @@ func (w *fileWatcher) Run(ctx unison.Canceler) {
 	// run initial scan before starting regular
 	w.watch(ctx)
 
+	if w.cfg.minInterval > 0 {
+		w.runAdaptive(ctx)
+		return
+	}
+
 	_ = timed.Periodic(ctx, w.cfg.Interval, func() error {
 		w.watch(ctx)
 
@@ -121,7 +148,35 @@ func (w *fileWatcher) Run(ctx unison.Canceler) {
 	})
 }
 
-func (w *fileWatcher) watch(ctx unison.Canceler) {
+// runAdaptive scans the files at an interval that is reset to the minimum
+// interval when files changed, and doubles up to the check interval while
+// they don't. File systems that don't notify changes, like network file
+// systems, are then scanned often only while they are active.
+func (w *fileWatcher) runAdaptive(ctx unison.Canceler) {
+	interval := w.cfg.minInterval
+	for {
+		if err := timed.Wait(ctx, interval); err != nil {
+			return
+		}
+		if w.watch(ctx) {
+			interval = w.cfg.minInterval
+		} else {
+			interval = nextInterval(interval, w.cfg.Interval)
+		}
+	}
+}
+
+func nextInterval(interval, max time.Duration) time.Duration {
+	interval *= 2
+	if interval > max {
+		return max
+	}
+	return interval
+}
+
+// watch scans the files and sends the events of the changes. It returns true
+// if files changed.
+func (w *fileWatcher) watch(ctx unison.Canceler) bool {
 	w.log.Debug("Start next scan")
 
 	paths := w.scanner.GetFiles()
@@ -172,7 +227,7 @@ func (w *fileWatcher) watch(ctx unison.Canceler) {
 		if e.Op != loginp.OpDone {
 			select {
 			case <-ctx.Done():
-				return
+				return false
 			case w.events <- e:
 			}
 		}
@@ -198,13 +253,27 @@ func (w *fileWatcher) watch(ctx unison.Canceler) {
 		}
 		select {
 		case <-ctx.Done():
-			return
+			return false
 		case w.events <- e:
 		}
 	}
 
-	// remaining files in newFiles are newly created files
-	for path, fd := range newFilesByName {
+	// remaining files in newFiles are newly created files, they are reported
+	// from the oldest to the newest, so that rotated files are picked up
+	// before the file they were rotated from
+	created := make([]string, 0, len(newFilesByName))
+	for path := range newFilesByName {
+		created = append(created, path)
+	}
+	sort.Slice(created, func(i, j int) bool {
+		ti, tj := newFilesByName[created[i]].Info.ModTime(), newFilesByName[created[j]].Info.ModTime()
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return created[i] < created[j]
+	})
+	for _, path := range created {
+		fd := newFilesByName[path]
 		// no need to react on empty new files
 		if fd.Info.Size() == 0 {
 			w.log.Debugf("file %q has no content yet, skipping", fd.Filename)
@@ -213,7 +282,7 @@ func (w *fileWatcher) watch(ctx unison.Canceler) {
 		}
 		select {
 		case <-ctx.Done():
-			return
+			return false
 		case w.events <- createEvent(path, *fd):
 			createdCount++
 		}
@@ -229,6 +298,8 @@ func (w *fileWatcher) watch(ctx unison.Canceler) {
 	).Debugf("File scan complete")
 
 	w.prev = paths
+
+	return writtenCount+truncatedCount+renamedCount+removedCount+createdCount > 0
 }
 
 func createEvent(path string, fd loginp.FileDescriptor) loginp.FSEvent {
@@ -271,6 +342,10 @@ type fileScannerConfig struct {
 	Symlinks      bool              `config:"symlinks"`
 	RecursiveGlob bool              `config:"recursive_glob"`
 	Fingerprint   fingerprintConfig `config:"fingerprint"`
+
+	// decompress is set when compressed files are read decompressed, their
+	// fingerprint is then computed on the decompressed content.
+	decompress bool
 }
 
 func defaultFileScannerConfig() fileScannerConfig {
@@ -478,6 +553,11 @@ func (s *fileScanner) toFileDescriptor(it *ingestTarget) (fd loginp.FileDescript
 	fd.Info = it.info
 
 	if s.cfg.Fingerprint.Enabled {
+		if s.cfg.decompress {
+			fd.Fingerprint, err = s.contentFingerprint(it.originalFilename)
+			return fd, err
+		}
+
 		fileSize := it.info.Size()
 		// we should not open the file if we know it's too small
 		minSize := s.cfg.Fingerprint.Offset + s.cfg.Fingerprint.Length
@@ -514,6 +594,37 @@ func (s *fileScanner) toFileDescriptor(it *ingestTarget) (fd loginp.FileDescript
 	return fd, nil
 }
 
+// contentFingerprint computes the fingerprint of a file on its content,
+// decompressed if the file is compressed. A compressed file then has the
+// fingerprint of the file it was compressed from.
+func (s *fileScanner) contentFingerprint(path string) (string, error) {
+	r, err := openContent(path, true)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %q for fingerprinting: %w", path, err)
+	}
+	defer r.Close()
+
+	minSize := s.cfg.Fingerprint.Offset + s.cfg.Fingerprint.Length
+	err = r.Skip(s.cfg.Fingerprint.Offset)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", fmt.Errorf("content of %q is smaller than %d bytes expected for fingerprinting: %w", path, minSize, errFileTooSmall)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to seek %q for fingerprinting: %w", path, err)
+	}
+
+	s.hasher.Reset()
+	written, err := io.CopyBuffer(s.hasher, io.LimitReader(r, s.cfg.Fingerprint.Length), s.readBuffer)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", fmt.Errorf("failed to compute hash for first %d bytes of %q: %w", s.cfg.Fingerprint.Length, path, err)
+	}
+	if written != s.cfg.Fingerprint.Length {
+		return "", fmt.Errorf("content of %q is smaller than %d bytes expected for fingerprinting: %w", path, minSize, errFileTooSmall)
+	}
+
+	return hex.EncodeToString(s.hasher.Sum(nil)), nil
+}
+
 func (s *fileScanner) isFileExcluded(file string) bool {
 	return len(s.cfg.ExcludedFiles) > 0 && s.matchAny(s.cfg.ExcludedFiles, file)
 }
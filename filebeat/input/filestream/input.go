@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"time"
 
 	"golang.org/x/text/transform"
@@ -48,7 +49,13 @@ import (
 const pluginName = "filestream"
 
 type state struct {
-	Offset int64 `json:"offset" struct:"offset"`
+	Offset int64          `json:"offset" struct:"offset"`
+	Anchor *contentAnchor `json:"anchor" struct:"anchor"`
+}
+
+// Position returns the offset following the published event.
+func (s state) Position() string {
+	return strconv.FormatInt(s.Offset, 10)
 }
 
 type fileMeta struct {
@@ -62,8 +69,10 @@ type filestream struct {
 	readerConfig    readerConfig
 	encodingFactory encoding.EncodingFactory
 	closerConfig    closerConfig
+	anchorConfig    contentAnchorConfig
 	parsers         parser.Config
 	takeOver        bool
+	decompress      bool
 }
 
 // Plugin creates a new filestream input plugin for creating a stateful input.
@@ -104,8 +113,10 @@ func configure(cfg *conf.C) (loginp.Prospector, loginp.Harvester, error) {
 		readerConfig:    config.Reader,
 		encodingFactory: encodingFactory,
 		closerConfig:    config.Close,
+		anchorConfig:    config.ContentAnchor,
 		parsers:         config.Reader.Parsers,
-		takeOver:        config.TakeOver,
+		takeOver:        config.TakeOver.Enabled,
+		decompress:      config.Compression == compressionAuto,
 	}
 
 	return prospector, filestream, nil
@@ -140,6 +151,9 @@ func (inp *filestream) Run(
 
 	log := ctx.Logger.With("path", fs.newPath).With("state-id", src.Name())
 	state := initState(log, cursor, fs)
+	if inp.anchorConfig.Enabled {
+		state = inp.verifyAnchor(log, fs.newPath, state, metrics)
+	}
 
 	r, truncated, err := inp.open(log, ctx.Cancelation, fs, state.Offset)
 	if err != nil {
@@ -193,7 +207,7 @@ func (inp *filestream) open(
 	offset int64,
 ) (reader.Reader, bool, error) {
 
-	f, encoding, truncated, err := inp.openFile(log, fs.newPath, offset)
+	f, decompressor, encoding, truncated, err := inp.openFile(log, fs.newPath, offset)
 	if err != nil {
 		return nil, truncated, err
 	}
@@ -204,13 +218,16 @@ func (inp *filestream) open(
 
 	ok := false // used for cleanup
 	defer cleanup.IfNot(&ok, cleanup.IgnoreError(f.Close))
+	if decompressor != nil {
+		defer cleanup.IfNot(&ok, cleanup.IgnoreError(decompressor.Close))
+	}
 
 	log.Debug("newLogFileReader with config.MaxBytes:", inp.readerConfig.MaxBytes)
 
-	// if the file is archived, it means that it is not going to be updated in the future
-	// thus, when EOF is reached, it can be closed
+	// if the file is archived or compressed, it means that it is not going to be
+	// updated in the future thus, when EOF is reached, it can be closed
 	closerCfg := inp.closerConfig
-	if fs.archived && !inp.closerConfig.Reader.OnEOF {
+	if (fs.archived || decompressor != nil) && !inp.closerConfig.Reader.OnEOF {
 		closerCfg = closerConfig{
 			Reader: readerCloserConfig{
 				OnEOF:         true,
@@ -226,6 +243,7 @@ func (inp *filestream) open(
 	if err != nil {
 		return nil, truncated, err
 	}
+	logReader.decompressor = decompressor
 
 	dbgReader, err := debug.AppendReaders(logReader)
 	if err != nil {
@@ -251,7 +269,13 @@ func (inp *filestream) open(
 
 	r = readfile.NewStripNewline(r, inp.readerConfig.LineTerminator)
 
-	r = readfile.NewFilemeta(r, fs.newPath, fs.desc.Info, fs.desc.Fingerprint, offset)
+	if inp.parsers.Empty() {
+		// The file metadata goes straight to the events, so it doesn't
+		// need to be nested.
+		r = readfile.NewFlatFilemeta(r, fs.newPath, fs.desc.Info, fs.desc.Fingerprint, offset)
+	} else {
+		r = readfile.NewFilemeta(r, fs.newPath, fs.desc.Info, fs.desc.Fingerprint, offset)
+	}
 
 	r = inp.parsers.Create(r)
 
@@ -267,38 +291,57 @@ func (inp *filestream) open(
 // the file system is scanned.
 //
 // openFile will also detect and hadle file truncation. If a file is truncated
-// then the 3rd return value is true.
+// then the 4th return value is true.
+//
+// If decompression is enabled and the file is compressed, the 2nd return value
+// is the reader of its decompressed content, positioned at offset, which is
+// an offset in the decompressed content.
 func (inp *filestream) openFile(
 	log *logp.Logger,
 	path string,
 	offset int64,
-) (*os.File, encoding.Encoding, bool, error) {
+) (*os.File, io.ReadCloser, encoding.Encoding, bool, error) {
 	fi, err := os.Stat(path)
 	if err != nil {
-		return nil, nil, false, fmt.Errorf("failed to stat source file %s: %w", path, err)
+		return nil, nil, nil, false, fmt.Errorf("failed to stat source file %s: %w", path, err)
 	}
 
 	// it must be checked if the file is not a named pipe before we try to open it
 	// if it is a named pipe os.OpenFile fails, so there is no need to try opening it.
 	if fi.Mode()&os.ModeNamedPipe != 0 {
-		return nil, nil, false, fmt.Errorf("failed to open file %s, named pipes are not supported", fi.Name())
+		return nil, nil, nil, false, fmt.Errorf("failed to open file %s, named pipes are not supported", fi.Name())
 	}
 
 	f, err := file.ReadOpen(path)
 	if err != nil {
-		return nil, nil, false, fmt.Errorf("failed opening %s: %w", path, err)
+		return nil, nil, nil, false, fmt.Errorf("failed opening %s: %w", path, err)
 	}
 	ok := false
 	defer cleanup.IfNot(&ok, cleanup.IgnoreError(f.Close))
 
 	fi, err = f.Stat()
 	if err != nil {
-		return nil, nil, false, fmt.Errorf("failed to stat source file %s: %w", path, err)
+		return nil, nil, nil, false, fmt.Errorf("failed to stat source file %s: %w", path, err)
 	}
 
 	err = checkFileBeforeOpening(fi)
 	if err != nil {
-		return nil, nil, false, err
+		return nil, nil, nil, false, err
+	}
+
+	if inp.decompress {
+		kind, err := detectCompression(f)
+		if err != nil {
+			return nil, nil, nil, false, fmt.Errorf("failed to detect compression of %s: %w", path, err)
+		}
+		if kind != "" {
+			decompressor, encoding, truncated, err := inp.openDecompressor(log, f, kind, offset)
+			if err != nil {
+				return nil, nil, nil, truncated, err
+			}
+			ok = true // no need to close the file
+			return f, decompressor, encoding, truncated, nil
+		}
 	}
 
 	truncated := false
@@ -311,19 +354,65 @@ func (inp *filestream) openFile(
 	}
 	err = inp.initFileOffset(f, offset)
 	if err != nil {
+		return nil, nil, nil, truncated, err
+	}
+
+	encoding, err := inp.newEncoding(f)
+	if err != nil {
+		return nil, nil, nil, truncated, err
+	}
+
+	ok = true // no need to close the file
+	return f, nil, encoding, truncated, nil
+}
+
+// openDecompressor returns a reader of the decompressed content of the
+// compressed file f, skipping the first offset bytes of the content. If the
+// content is shorter than offset, it is read from the start.
+func (inp *filestream) openDecompressor(
+	log *logp.Logger,
+	f *os.File,
+	kind string,
+	offset int64,
+) (io.ReadCloser, encoding.Encoding, bool, error) {
+	decompressor, err := newDecompressor(kind, f)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("failed to decompress %s: %w", f.Name(), err)
+	}
+
+	truncated := false
+	if offset > 0 {
+		_, err = io.CopyN(io.Discard, decompressor, offset)
+		if errors.Is(err, io.EOF) {
+			truncated = true
+			log.Infof("Compressed file is shorter than the offset. Reading file from offset 0. Path=%s", f.Name())
+			_ = decompressor.Close()
+			if _, err = f.Seek(0, io.SeekStart); err == nil {
+				decompressor, err = newDecompressor(kind, f)
+			}
+		}
+		if err != nil {
+			return nil, nil, truncated, fmt.Errorf("failed to decompress %s: %w", f.Name(), err)
+		}
+	}
+
+	encoding, err := inp.newEncoding(decompressor)
+	if err != nil {
+		_ = decompressor.Close()
 		return nil, nil, truncated, err
 	}
+	return decompressor, encoding, truncated, nil
+}
 
-	encoding, err := inp.encodingFactory(f)
+func (inp *filestream) newEncoding(r io.Reader) (encoding.Encoding, error) {
+	encoding, err := inp.encodingFactory(r)
 	if err != nil {
 		if errors.Is(err, transform.ErrShortSrc) {
-			return nil, nil, truncated, fmt.Errorf("initialising encoding for '%v' failed due to file being too short", f)
+			return nil, fmt.Errorf("initialising encoding for '%v' failed due to file being too short", r)
 		}
-		return nil, nil, truncated, fmt.Errorf("initialising encoding for '%v' failed: %w", f, err)
+		return nil, fmt.Errorf("initialising encoding for '%v' failed: %w", r, err)
 	}
-
-	ok = true // no need to close the file
-	return f, encoding, truncated, nil
+	return encoding, nil
 }
 
 func checkFileBeforeOpening(fi os.FileInfo) error {
@@ -366,6 +455,9 @@ func (inp *filestream) readFromSource(
 		if err != nil {
 			if errors.Is(err, ErrFileTruncate) {
 				log.Infof("File was truncated, nothing to read. Path='%s'", path)
+			} else if errors.Is(err, ErrStaleFileHandle) {
+				log.Warnf("File handle is stale, the file will be opened again on its next change. Path='%s'", path)
+				metrics.StaleFileHandles.Inc()
 			} else if errors.Is(err, ErrClosed) {
 				log.Infof("Reader was closed. Closing. Path='%s'", path)
 			} else if errors.Is(err, io.EOF) {
@@ -26,6 +26,7 @@ import (
 	"github.com/elastic/beats/v7/libbeat/beat"
 	"github.com/elastic/beats/v7/libbeat/common/acker"
 	"github.com/elastic/beats/v7/libbeat/management/status"
+	"github.com/elastic/elastic-agent-libs/mapstr"
 	"github.com/elastic/go-concert/ctxtool"
 )
 
@@ -41,6 +42,43 @@ type managedInput struct {
 	harvesterLimit   uint64
 }
 
+// Cursors reports the ACKed cursor and metadata of each file of the
+// input that has state in the registry, and the most recent cursor update
+// that still waits for its events to be ACKed.
+func (inp *managedInput) Cursors() map[string]interface{} {
+	states := inp.manager.store.ephemeralStore
+	states.mu.Lock()
+	resources := make([]*resource, 0, len(states.table))
+	for key, resource := range states.table {
+		if inp.sourceIdentifier.MatchesInput(key) {
+			resources = append(resources, resource)
+		}
+	}
+	states.mu.Unlock()
+
+	cursors := map[string]interface{}{}
+	for _, resource := range resources {
+		resource.stateMutex.Lock()
+		if resource.invalid {
+			resource.stateMutex.Unlock()
+			continue
+		}
+		st := resource.inSyncStateSnapshot()
+		var pending interface{}
+		if resource.activeCursorOperations > 0 {
+			pending = resource.pendingCursor()
+		}
+		resource.stateMutex.Unlock()
+
+		cursor := mapstr.M{"cursor": st.Cursor, "meta": st.Meta, "updated": st.Updated}
+		if pending != nil {
+			cursor["pending"] = pending
+		}
+		cursors[resource.key] = cursor
+	}
+	return cursors
+}
+
 // Name is required to implement the v2.Input interface
 func (inp *managedInput) Name() string { return inp.harvester.Name() }
 
@@ -20,6 +20,7 @@ package input_logfile
 import (
 	"bytes"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -30,6 +31,7 @@ import (
 	"github.com/elastic/beats/v7/libbeat/statestore/storetest"
 	"github.com/elastic/elastic-agent-libs/config"
 	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
 )
 
 const testPluginName = "my_test_plugin"
@@ -210,3 +212,32 @@ func newBufferLogger() (*logp.Logger, *bytes.Buffer) {
 	}))
 	return log, buf
 }
+
+func TestManagedInputCursors(t *testing.T) {
+	store := testOpenStore(t, "test", createSampleStore(t, map[string]state{
+		"test::my-id::a": {
+			TTL:    60 * time.Second,
+			Cursor: map[string]interface{}{"offset": int64(10)},
+			Meta:   map[string]interface{}{"source": "/var/log/a.log"},
+		},
+		"test::other-id::b": {
+			TTL:    60 * time.Second,
+			Cursor: map[string]interface{}{"offset": int64(20)},
+		},
+	}))
+	defer store.Release()
+
+	identifier, err := newSourceIdentifier("test", "my-id")
+	require.NoError(t, err)
+	inp := &managedInput{
+		manager:          &InputManager{store: store},
+		sourceIdentifier: identifier,
+	}
+
+	cursors := inp.Cursors()
+	require.Len(t, cursors, 1)
+	cursor := cursors["test::my-id::a"].(mapstr.M)
+	assert.Equal(t, map[string]interface{}{"offset": int64(10)}, cursor["cursor"])
+	assert.Equal(t, map[string]interface{}{"source": "/var/log/a.log"}, cursor["meta"])
+	assert.NotContains(t, cursor, "pending")
+}
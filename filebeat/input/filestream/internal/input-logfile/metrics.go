@@ -37,6 +37,8 @@ type Metrics struct {
 	EventsProcessed  *monitoring.Uint // Number of events processed.
 	ProcessingErrors *monitoring.Uint // Number of processing errors.
 	ProcessingTime   metrics.Sample   // Histogram of the elapsed time for processing an event.
+	AnchorMismatches *monitoring.Uint // Number of files whose content anchor didn't match their state.
+	StaleFileHandles *monitoring.Uint // Number of readers closed because their file handle became stale.
 
 	// Those metrics use the same registry/keys as the log input uses
 	HarvesterStarted   *monitoring.Int
@@ -74,6 +76,8 @@ func NewMetrics(id string) *Metrics {
 		EventsProcessed:  monitoring.NewUint(reg, "events_processed_total"),
 		ProcessingErrors: monitoring.NewUint(reg, "processing_errors_total"),
 		ProcessingTime:   metrics.NewUniformSample(1024),
+		AnchorMismatches: monitoring.NewUint(reg, "content_anchor_mismatches_total"),
+		StaleFileHandles: monitoring.NewUint(reg, "stale_file_handles_total"),
 
 		HarvesterStarted:   monitoring.NewInt(harvesterMetrics, "started"),
 		HarvesterClosed:    monitoring.NewInt(harvesterMetrics, "closed"),
@@ -59,6 +59,24 @@ func TestPublish(t *testing.T) {
 		require.Nil(t, actual.Private)
 	})
 
+	t.Run("event with cursor position has an idempotency key", func(t *testing.T) {
+		store := testOpenStore(t, "test", createSampleStore(t, nil))
+		defer store.Release()
+		cursor := makeCursor(store.Get("test::key"))
+
+		var actual []beat.Event
+		client := &pubtest.FakeClient{
+			PublishFunc: func(event beat.Event) { actual = append(actual, event) },
+		}
+		publisher := cursorPublisher{nil, client, &cursor}
+		require.NoError(t, publisher.Publish(beat.Event{}, testPosition("1024")))
+		require.NoError(t, publisher.Publish(beat.Event{}, "no position"))
+
+		require.Len(t, actual, 2)
+		assert.Equal(t, "test::key::1024", actual[0].IdempotencyKey())
+		assert.Empty(t, actual[1].IdempotencyKey())
+	})
+
 	t.Run("publish returns error if context has been cancelled", func(t *testing.T) {
 		ctx, cancel := context.WithCancel(context.TODO())
 		cancel()
@@ -157,3 +175,7 @@ func mustCreateUpdateOp(t *testing.T, resource *resource, updates interface{}) *
 	}
 	return op
 }
+
+type testPosition string
+
+func (p testPosition) Position() string { return string(p) }
@@ -0,0 +1,354 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package filestream
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	loginp "github.com/elastic/beats/v7/filebeat/input/filestream/internal/input-logfile"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/go-concert/timed"
+	"github.com/elastic/go-concert/unison"
+)
+
+// ErrStaleFileHandle is returned by the reader when the handle of the file
+// it reads became invalid, which happens on network file systems when the
+// file was replaced or the share was remounted.
+var ErrStaleFileHandle = errors.New("stale file handle")
+
+// networkFSConfig configures the handling of files on network file systems
+// like NFS or SMB, which don't notify changes and whose file handles can
+// become stale.
+type networkFSConfig struct {
+	Enabled          bool          `config:"enabled"`
+	MinCheckInterval time.Duration `config:"min_check_interval" validate:"positive,nonzero"`
+	Lease            leaseConfig   `config:"lease"`
+}
+
+// leaseConfig configures the lease file that lets a single beat at a time
+// read the files of an input when several beats read the same share.
+type leaseConfig struct {
+	Enabled bool          `config:"enabled"`
+	Path    string        `config:"path"`
+	TTL     time.Duration `config:"ttl" validate:"positive,nonzero"`
+}
+
+func defaultNetworkFSConfig() networkFSConfig {
+	return networkFSConfig{
+		Enabled:          false,
+		MinCheckInterval: 1 * time.Second,
+		Lease: leaseConfig{
+			Enabled: false,
+			TTL:     30 * time.Second,
+		},
+	}
+}
+
+func (c *networkFSConfig) Validate() error {
+	if c.Lease.Enabled && c.Lease.Path == "" {
+		return fmt.Errorf("network_fs.lease.path is required when the lease is enabled")
+	}
+	return nil
+}
+
+// minCheckInterval returns the minimum interval between two scans of the
+// file watcher, or zero if adaptive polling is disabled.
+func (c networkFSConfig) minCheckInterval() time.Duration {
+	if !c.Enabled {
+		return 0
+	}
+	return c.MinCheckInterval
+}
+
+// leaseRecord is the content of a lease file.
+type leaseRecord struct {
+	Holder  string    `json:"holder"`
+	Expires time.Time `json:"expires"`
+	// Offsets are the acknowledged offsets of the files read by the holder,
+	// by source name. The next holder resumes reading from them.
+	Offsets map[string]int64 `json:"offsets,omitempty"`
+}
+
+// lease is a file on the share that records which beat reads the files of
+// an input. The holder renews the lease before it expires, other beats wait
+// for it to expire before they take it over.
+//
+// The holder checkpoints the offsets of its files to the lease file each
+// time it renews it, the beat taking over the lease resumes from them. Events
+// that were read but not checkpointed yet are read again by the new holder.
+//
+// The lease relies on the atomicity of renames on the share, it reduces
+// double ingestion but does not exclude it.
+type lease struct {
+	path   string
+	holder string
+	ttl    time.Duration
+	now    func() time.Time
+
+	// checkpoint returns the offsets written to the lease file by the
+	// holder, it may be nil.
+	checkpoint func() map[string]int64
+
+	mu   sync.Mutex
+	held bool
+}
+
+func newLease(path, holder string, ttl time.Duration, checkpoint func() map[string]int64) *lease {
+	return &lease{
+		path:       path,
+		holder:     holder,
+		ttl:        ttl,
+		now:        time.Now,
+		checkpoint: checkpoint,
+	}
+}
+
+// tryAcquire acquires or renews the lease. It returns true if the lease is
+// held by this beat. When the lease was not held before, it also returns the
+// offsets checkpointed by the previous holder.
+func (l *lease) tryAcquire() (bool, map[string]int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	current, err := l.read()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		l.held = false
+		return false, nil, err
+	}
+	if err == nil && current.Holder != l.holder && now.Before(current.Expires) {
+		l.held = false
+		return false, nil, nil
+	}
+
+	// The lease can also be taken over from this beat, when it did not
+	// renew it in time.
+	takeover := !l.held || current.Holder != l.holder
+
+	record := leaseRecord{Holder: l.holder, Expires: now.Add(l.ttl), Offsets: l.offsets()}
+	var taken map[string]int64
+	if takeover {
+		// The offsets of the previous holder are kept until the files are
+		// read again, in case this beat loses the lease before.
+		taken = current.Offsets
+		record.Offsets = mergeOffsets(taken, record.Offsets)
+	}
+	if err := l.write(record); err != nil {
+		l.held = false
+		return false, nil, err
+	}
+
+	// Two beats can write the lease at the same time, the one whose record
+	// was written last holds it.
+	written, err := l.read()
+	if err != nil {
+		l.held = false
+		return false, nil, err
+	}
+	l.held = written.Holder == l.holder
+	if !l.held {
+		return false, nil, nil
+	}
+	return true, taken, nil
+}
+
+// release expires the lease if it is held by this beat, so that another
+// beat can take it over without waiting for the TTL.
+func (l *lease) release() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.held {
+		return nil
+	}
+	l.held = false
+
+	current, err := l.read()
+	if err != nil || current.Holder != l.holder {
+		return err
+	}
+	return l.write(leaseRecord{Holder: l.holder, Expires: l.now(), Offsets: l.offsets()})
+}
+
+func (l *lease) offsets() map[string]int64 {
+	if l.checkpoint == nil {
+		return nil
+	}
+	return l.checkpoint()
+}
+
+// mergeOffsets returns the highest offset of each source.
+func mergeOffsets(a, b map[string]int64) map[string]int64 {
+	if len(a) == 0 {
+		return b
+	}
+	merged := make(map[string]int64, len(a)+len(b))
+	for name, offset := range a {
+		merged[name] = offset
+	}
+	for name, offset := range b {
+		if offset > merged[name] {
+			merged[name] = offset
+		}
+	}
+	return merged
+}
+
+// read reads the lease file. It is opened again on each read, so that
+// network file systems with close-to-open consistency like NFS revalidate
+// the cached attributes and content of the file.
+func (l *lease) read() (leaseRecord, error) {
+	var r leaseRecord
+
+	f, err := os.Open(l.path)
+	if err != nil {
+		return r, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return r, fmt.Errorf("failed to read lease file %s: %w", l.path, err)
+	}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return r, fmt.Errorf("invalid lease file %s: %w", l.path, err)
+	}
+	return r, nil
+}
+
+// write replaces the lease file. The new content and the rename are synced
+// before write returns, so that other beats don't read a cached version of
+// the file.
+func (l *lease) write(r leaseRecord) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(l.path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(l.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create lease file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write lease file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync lease file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write lease file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), l.path); err != nil {
+		return fmt.Errorf("failed to replace lease file: %w", err)
+	}
+
+	// Syncing a directory is not supported by all platforms and file
+	// systems, the rename is still visible to the beats that open the file
+	// again.
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// run acquires and renews the lease until ctx is cancelled. onChange is
+// called with the new state each time the lease is acquired or lost, with
+// the offsets of the previous holder when it is acquired. The lease is
+// released when run returns.
+func (l *lease) run(ctx unison.Canceler, log *logp.Logger, onChange func(held bool, offsets map[string]int64)) {
+	held := false
+	update := func() {
+		ok, offsets, err := l.tryAcquire()
+		if err != nil {
+			log.Errorf("Failed to acquire lease %s: %v", l.path, err)
+		}
+		// Offsets are also returned when the lease was taken over from
+		// another beat that held it since the last renewal.
+		if ok == held && offsets == nil {
+			return
+		}
+		held = ok
+		if held {
+			log.Infof("Lease %s acquired, reading files", l.path)
+		} else {
+			log.Infof("Lease %s is held by another beat, not reading files", l.path)
+		}
+		onChange(held, offsets)
+	}
+
+	update()
+	_ = timed.Periodic(ctx, l.ttl/3, func() error {
+		update()
+		return nil
+	})
+
+	if err := l.release(); err != nil {
+		log.Errorf("Failed to release lease %s: %v", l.path, err)
+	}
+}
+
+// pendingEvents holds the file system events received while another beat
+// holds the lease, they are handled once the lease is acquired. A write
+// event of a file with a pending create or write event is dropped, the
+// harvester reads the file up to its end anyway.
+type pendingEvents struct {
+	events []loginp.FSEvent
+	active map[string]struct{}
+}
+
+func (q *pendingEvents) add(fe loginp.FSEvent) {
+	if q.active == nil {
+		q.active = map[string]struct{}{}
+	}
+	switch fe.Op {
+	case loginp.OpWrite:
+		if _, ok := q.active[fe.NewPath]; ok {
+			return
+		}
+		q.active[fe.NewPath] = struct{}{}
+	case loginp.OpCreate, loginp.OpTruncate:
+		q.active[fe.NewPath] = struct{}{}
+	case loginp.OpRename:
+		delete(q.active, fe.OldPath)
+		q.active[fe.NewPath] = struct{}{}
+	case loginp.OpDelete:
+		delete(q.active, fe.OldPath)
+	}
+	q.events = append(q.events, fe)
+}
+
+// drain returns the pending events and clears them.
+func (q *pendingEvents) drain() []loginp.FSEvent {
+	events := q.events
+	q.events = nil
+	q.active = nil
+	return events
+}
@@ -0,0 +1,283 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package filestream
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	loginp "github.com/elastic/beats/v7/filebeat/input/filestream/internal/input-logfile"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+func TestNetworkFSConfig(t *testing.T) {
+	t.Run("defaults disable adaptive polling", func(t *testing.T) {
+		c := defaultNetworkFSConfig()
+		assert.Zero(t, c.minCheckInterval())
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		c := defaultNetworkFSConfig()
+		require.NoError(t, conf.MustNewConfigFrom(map[string]interface{}{
+			"enabled":            true,
+			"min_check_interval": "2s",
+		}).Unpack(&c))
+		assert.Equal(t, 2*time.Second, c.minCheckInterval())
+	})
+
+	t.Run("lease requires a path", func(t *testing.T) {
+		c := defaultNetworkFSConfig()
+		err := conf.MustNewConfigFrom(map[string]interface{}{
+			"lease.enabled": true,
+		}).Unpack(&c)
+		require.ErrorContains(t, err, "network_fs.lease.path is required")
+	})
+}
+
+func TestNextInterval(t *testing.T) {
+	assert.Equal(t, 2*time.Second, nextInterval(time.Second, 10*time.Second))
+	assert.Equal(t, 10*time.Second, nextInterval(8*time.Second, 10*time.Second))
+	assert.Equal(t, 10*time.Second, nextInterval(10*time.Second, 10*time.Second))
+}
+
+func TestLease(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input.lease")
+	now := time.Now()
+	clock := func() time.Time { return now }
+
+	a := newLease(path, "host-a/1", 30*time.Second, nil)
+	a.now = clock
+	b := newLease(path, "host-b/2", 30*time.Second, nil)
+	b.now = clock
+
+	held, _, err := a.tryAcquire()
+	require.NoError(t, err)
+	assert.True(t, held, "a free lease must be acquired")
+
+	held, _, err = b.tryAcquire()
+	require.NoError(t, err)
+	assert.False(t, held, "a lease held by another beat must not be acquired")
+
+	now = now.Add(20 * time.Second)
+	held, _, err = a.tryAcquire()
+	require.NoError(t, err)
+	assert.True(t, held, "the holder must renew the lease")
+
+	now = now.Add(20 * time.Second)
+	held, _, err = b.tryAcquire()
+	require.NoError(t, err)
+	assert.False(t, held, "a renewed lease must not be acquired before it expires")
+
+	now = now.Add(20 * time.Second)
+	held, _, err = b.tryAcquire()
+	require.NoError(t, err)
+	assert.True(t, held, "an expired lease must be taken over")
+
+	held, _, err = a.tryAcquire()
+	require.NoError(t, err)
+	assert.False(t, held, "the previous holder must lose the lease")
+
+	require.NoError(t, b.release())
+	held, _, err = a.tryAcquire()
+	require.NoError(t, err)
+	assert.True(t, held, "a released lease must be acquired without waiting for its expiration")
+
+	matches, err := filepath.Glob(path + ".*.tmp")
+	require.NoError(t, err)
+	assert.Empty(t, matches, "temporary lease files must be removed")
+}
+
+func TestLeaseOffsets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input.lease")
+	now := time.Now()
+	clock := func() time.Time { return now }
+
+	offsetsA := map[string]int64{"file-1": 100, "file-2": 20}
+	a := newLease(path, "host-a/1", 30*time.Second, func() map[string]int64 { return offsetsA })
+	a.now = clock
+	offsetsB := map[string]int64{"file-1": 10, "file-3": 5}
+	b := newLease(path, "host-b/2", 30*time.Second, func() map[string]int64 { return offsetsB })
+	b.now = clock
+
+	held, taken, err := a.tryAcquire()
+	require.NoError(t, err)
+	assert.True(t, held)
+	assert.Empty(t, taken, "a free lease has no offsets")
+
+	// The holder checkpoints its offsets on renewal.
+	offsetsA = map[string]int64{"file-1": 150, "file-2": 20}
+	now = now.Add(10 * time.Second)
+	held, taken, err = a.tryAcquire()
+	require.NoError(t, err)
+	assert.True(t, held)
+	assert.Nil(t, taken, "renewing the lease must not return offsets")
+
+	now = now.Add(time.Minute)
+	held, taken, err = b.tryAcquire()
+	require.NoError(t, err)
+	assert.True(t, held)
+	assert.Equal(t, map[string]int64{"file-1": 150, "file-2": 20}, taken,
+		"the offsets of the previous holder must be returned")
+
+	// The offsets of the previous holder are kept for the next one.
+	r, err := b.read()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"file-1": 150, "file-2": 20, "file-3": 5}, r.Offsets)
+
+	offsetsB = map[string]int64{"file-1": 200, "file-2": 20, "file-3": 5}
+	require.NoError(t, b.release())
+	held, taken, err = a.tryAcquire()
+	require.NoError(t, err)
+	assert.True(t, held)
+	assert.Equal(t, offsetsB, taken, "the offsets must be checkpointed on release")
+}
+
+func TestPendingEvents(t *testing.T) {
+	var q pendingEvents
+	q.add(loginp.FSEvent{Op: loginp.OpCreate, NewPath: "/a"})
+	q.add(loginp.FSEvent{Op: loginp.OpWrite, NewPath: "/a"})
+	q.add(loginp.FSEvent{Op: loginp.OpWrite, NewPath: "/b"})
+	q.add(loginp.FSEvent{Op: loginp.OpWrite, NewPath: "/b"})
+	q.add(loginp.FSEvent{Op: loginp.OpRename, OldPath: "/b", NewPath: "/c"})
+	q.add(loginp.FSEvent{Op: loginp.OpWrite, NewPath: "/c"})
+	q.add(loginp.FSEvent{Op: loginp.OpDelete, OldPath: "/a"})
+	q.add(loginp.FSEvent{Op: loginp.OpCreate, NewPath: "/a"})
+	q.add(loginp.FSEvent{Op: loginp.OpTruncate, NewPath: "/a"})
+	q.add(loginp.FSEvent{Op: loginp.OpWrite, NewPath: "/a"})
+
+	assert.Equal(t, []loginp.FSEvent{
+		{Op: loginp.OpCreate, NewPath: "/a"},
+		{Op: loginp.OpWrite, NewPath: "/b"},
+		{Op: loginp.OpRename, OldPath: "/b", NewPath: "/c"},
+		{Op: loginp.OpDelete, OldPath: "/a"},
+		{Op: loginp.OpCreate, NewPath: "/a"},
+		{Op: loginp.OpTruncate, NewPath: "/a"},
+	}, q.drain())
+	assert.Empty(t, q.drain())
+
+	q.add(loginp.FSEvent{Op: loginp.OpWrite, NewPath: "/a"})
+	assert.Len(t, q.drain(), 1, "the writes of drained events must not be dropped")
+}
+
+func TestProspectorResumeFromLeaseOffsets(t *testing.T) {
+	minuteAgo := time.Now().Add(-time.Minute)
+	files := map[string]loginp.FileDescriptor{
+		"/path/to/behind":    createTestFileDescriptorWithInfo(&testFileInfo{"/path/to/behind", 100, minuteAgo, nil}),
+		"/path/to/ahead":     createTestFileDescriptorWithInfo(&testFileInfo{"/path/to/ahead", 100, minuteAgo, nil}),
+		"/path/to/new":       createTestFileDescriptorWithInfo(&testFileInfo{"/path/to/new", 100, minuteAgo, nil}),
+		"/path/to/truncated": createTestFileDescriptorWithInfo(&testFileInfo{"/path/to/truncated", 10, minuteAgo, nil}),
+	}
+	p := fileProspector{
+		filewatcher: newMockFileWatcherWithFiles(files),
+		identifier:  mustPathIdentifier(false),
+	}
+	name := func(path string) string {
+		return p.identifier.GetSource(createEvent(path, files[path])).Name()
+	}
+
+	updater := newMockMetadataUpdater()
+	updater.table[name("/path/to/behind")] = state{Offset: 10}
+	updater.table[name("/path/to/ahead")] = state{Offset: 90}
+	assert.Equal(t, map[string]int64{
+		name("/path/to/behind"): 10,
+		name("/path/to/ahead"):  90,
+	}, p.offsets(updater))
+
+	p.resumeFrom(logp.L(), updater, map[string]int64{
+		name("/path/to/behind"):    50,
+		name("/path/to/ahead"):     50,
+		name("/path/to/new"):       30,
+		name("/path/to/truncated"): 30,
+		"unknown":                  40,
+	})
+	assert.True(t, updater.checkOffset(name("/path/to/behind"), 50))
+	assert.True(t, updater.checkOffset(name("/path/to/ahead"), 90))
+	assert.True(t, updater.checkOffset(name("/path/to/new"), 30))
+	assert.False(t, updater.has(name("/path/to/truncated")))
+}
+
+func TestLeaseRunNotifiesChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input.lease")
+	l := newLease(path, "host-a/1", 30*time.Millisecond, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan bool, 10)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.run(ctx, logp.L(), func(held bool, _ map[string]int64) { changes <- held })
+	}()
+
+	assert.True(t, <-changes, "the lease must be acquired")
+	cancel()
+	<-done
+
+	assert.Empty(t, changes, "renewing the lease must not notify a change")
+	r, err := l.read()
+	require.NoError(t, err)
+	assert.False(t, time.Now().Before(r.Expires), "the lease must be released when run returns")
+}
+
+func TestErrorChecksStaleFileHandle(t *testing.T) {
+	file := createTestLogFile()
+	defer os.Remove(file.Name())
+	defer file.Close()
+	f := &logFile{file: file, log: logp.L()}
+
+	err := f.errorChecks(&fs.PathError{Op: "read", Path: f.file.Name(), Err: syscall.ESTALE})
+	if isStaleFileHandle(syscall.ESTALE) {
+		assert.ErrorIs(t, err, ErrStaleFileHandle)
+	} else {
+		assert.NotErrorIs(t, err, ErrStaleFileHandle)
+	}
+
+	err = f.errorChecks(fmt.Errorf("unexpected"))
+	assert.NotErrorIs(t, err, ErrStaleFileHandle)
+}
+
+func TestFileWatcherAdaptivePolling(t *testing.T) {
+	dir := t.TempDir()
+	cfg := conf.MustNewConfigFrom(map[string]interface{}{
+		"check_interval": "1h",
+	})
+	fw, err := newScannerWatcher([]string{filepath.Join(dir, "*.log")}, cfg, watcherOptions{minInterval: 10 * time.Millisecond})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go fw.Run(ctx)
+
+	path := filepath.Join(dir, "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("line\n"), 0o644))
+
+	// with a check interval of one hour, the file is only found this early
+	// by the adaptive polling
+	e := fw.Event()
+	assert.Equal(t, loginp.OpCreate, e.Op)
+	assert.Equal(t, path, e.NewPath)
+}
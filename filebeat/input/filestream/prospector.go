@@ -20,6 +20,7 @@ package filestream
 import (
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	loginp "github.com/elastic/beats/v7/filebeat/input/filestream/internal/input-logfile"
@@ -58,6 +59,7 @@ type fileProspector struct {
 	ignoreInactiveSince ignoreInactiveType
 	cleanRemoved        bool
 	stateChangeCloser   stateChangeCloserConfig
+	lease               leaseConfig
 }
 
 func (p *fileProspector) Init(
@@ -141,9 +143,43 @@ func (p *fileProspector) Run(ctx input.Context, s loginp.StateMetadataUpdater, h
 		return nil
 	})
 
-	tg.Go(func() error {
-		ignoreInactiveSince := getIgnoreSince(p.ignoreInactiveSince, ctx.Agent)
+	ignoreInactiveSince := getIgnoreSince(p.ignoreInactiveSince, ctx.Agent)
+
+	// mu serializes the handling of file system events with the changes
+	// of the lease, files are only read while the lease is held. The events
+	// received while another beat holds it are handled once it is acquired.
+	var (
+		mu      sync.Mutex
+		held    = !p.lease.Enabled
+		pending pendingEvents
+	)
+
+	if p.lease.Enabled {
+		holder := ctx.Agent.Hostname + "/" + ctx.Agent.ID.String()
+		checkpoint := func() map[string]int64 { return p.offsets(s) }
+		l := newLease(p.lease.Path, holder, p.lease.TTL, checkpoint)
+		tg.Go(func() error {
+			l.run(ctx.Cancelation, log, func(ok bool, offsets map[string]int64) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				held = ok
+				if !held {
+					p.stopHarvesters(hg)
+					return
+				}
+				p.resumeFrom(log, s, offsets)
+				for _, fe := range pending.drain() {
+					src := p.identifier.GetSource(fe)
+					p.onFSEvent(loggerWithEvent(log, fe, src), ctx, fe, src, s, hg, ignoreInactiveSince)
+				}
+				p.startHarvesters(log, ctx, s, hg, ignoreInactiveSince)
+			})
+			return nil
+		})
+	}
 
+	tg.Go(func() error {
 		for ctx.Cancelation.Err() == nil {
 			fe := p.filewatcher.Event()
 
@@ -151,8 +187,14 @@ func (p *fileProspector) Run(ctx input.Context, s loginp.StateMetadataUpdater, h
 				return nil
 			}
 
-			src := p.identifier.GetSource(fe)
-			p.onFSEvent(loggerWithEvent(log, fe, src), ctx, fe, src, s, hg, ignoreInactiveSince)
+			mu.Lock()
+			if held {
+				src := p.identifier.GetSource(fe)
+				p.onFSEvent(loggerWithEvent(log, fe, src), ctx, fe, src, s, hg, ignoreInactiveSince)
+			} else {
+				pending.add(fe)
+			}
+			mu.Unlock()
 		}
 		return nil
 	})
@@ -163,6 +205,79 @@ func (p *fileProspector) Run(ctx input.Context, s loginp.StateMetadataUpdater, h
 	}
 }
 
+// startHarvesters starts the harvesters of all the files found by the file
+// watcher. It is called when the lease is acquired, the harvesters were
+// never started or were stopped when the lease was lost.
+func (p *fileProspector) startHarvesters(
+	log *logp.Logger,
+	ctx input.Context,
+	updater loginp.StateMetadataUpdater,
+	group loginp.HarvesterGroup,
+	ignoreSince time.Time,
+) {
+	for path, fd := range p.filewatcher.GetFiles() {
+		fe := createEvent(path, fd)
+		src := p.identifier.GetSource(fe)
+		p.onFSEvent(loggerWithEvent(log, fe, src), ctx, fe, src, updater, group, ignoreSince)
+	}
+}
+
+// offsets returns the acknowledged offsets of the files found by the file
+// watcher, by source name.
+func (p *fileProspector) offsets(updater loginp.StateMetadataUpdater) map[string]int64 {
+	offsets := map[string]int64{}
+	for path, fd := range p.filewatcher.GetFiles() {
+		src := p.identifier.GetSource(createEvent(path, fd))
+		var st state
+		if err := updater.FindCursor(src, &st); err == nil {
+			offsets[src.Name()] = st.Offset
+		}
+	}
+	return offsets
+}
+
+// resumeFrom moves the cursors of the files found by the file watcher to
+// the offsets checkpointed by the previous holder of the lease, when they
+// are ahead of the registry of this beat.
+func (p *fileProspector) resumeFrom(log *logp.Logger, updater loginp.StateMetadataUpdater, offsets map[string]int64) {
+	if len(offsets) == 0 {
+		return
+	}
+	for path, fd := range p.filewatcher.GetFiles() {
+		src := p.identifier.GetSource(createEvent(path, fd))
+		offset, ok := offsets[src.Name()]
+		if !ok || offset > fd.Info.Size() {
+			continue
+		}
+
+		var st state
+		err := updater.FindCursor(src, &st)
+		if err == nil && st.Offset >= offset {
+			continue
+		}
+		if err != nil {
+			err = updater.UpdateMetadata(src, fileMeta{Source: path, IdentifierName: p.identifier.Name()})
+			if err != nil {
+				log.Errorf("Failed to set cursor meta data of entry %s: %v", src.Name(), err)
+				continue
+			}
+		}
+		if err := updater.ResetCursor(src, state{Offset: offset}); err != nil {
+			log.Errorf("Failed to resume %s from the offset of the previous lease holder: %v", path, err)
+			continue
+		}
+		log.Debugf("Resuming %s from offset %d of the previous lease holder", path, offset)
+	}
+}
+
+// stopHarvesters stops the harvesters of all the files found by the file
+// watcher when the lease is lost.
+func (p *fileProspector) stopHarvesters(group loginp.HarvesterGroup) {
+	for path, fd := range p.filewatcher.GetFiles() {
+		group.Stop(p.identifier.GetSource(createEvent(path, fd)))
+	}
+}
+
 func (p *fileProspector) onFSEvent(
 	log *logp.Logger,
 	ctx input.Context,
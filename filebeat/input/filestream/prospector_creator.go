@@ -43,7 +43,10 @@ func newProspector(config config) (loginp.Prospector, error) {
 		return nil, err
 	}
 
-	filewatcher, err := newFileWatcher(config.Paths, config.FileWatcher)
+	filewatcher, err := newFileWatcher(config.Paths, config.FileWatcher, watcherOptions{
+		decompress:  config.Compression == compressionAuto,
+		minInterval: config.NetworkFS.minCheckInterval(),
+	})
 	if err != nil {
 		return nil, fmt.Errorf("error while creating filewatcher %w", err)
 	}
@@ -64,6 +67,7 @@ func newProspector(config config) (loginp.Prospector, error) {
 		ignoreInactiveSince: config.IgnoreInactive,
 		cleanRemoved:        config.CleanRemoved,
 		stateChangeCloser:   config.Close.OnStateChange,
+		lease:               config.NetworkFS.Lease,
 	}
 	if config.Rotation == nil {
 		return &fileprospector, nil
@@ -87,6 +91,9 @@ func newProspector(config config) (loginp.Prospector, error) {
 		strategy := cfg.Strategy.Name()
 		switch strategy {
 		case copytruncateStrategy:
+			if config.NetworkFS.Lease.Enabled {
+				return nil, fmt.Errorf("network_fs.lease is not supported with copytruncate rotation")
+			}
 			experimentalWarning.Do(func() {
 				cfgwarn.Experimental("rotation.external.copytruncate is used.")
 			})
@@ -583,6 +583,14 @@ func (mu *mockMetadataUpdater) FindCursorMeta(s loginp.Source, v interface{}) er
 	return typeconv.Convert(v, meta)
 }
 
+func (mu *mockMetadataUpdater) FindCursor(s loginp.Source, v interface{}) error {
+	cur, ok := mu.table[s.Name()]
+	if !ok {
+		return fmt.Errorf("no such id [%q]", s.Name())
+	}
+	return typeconv.Convert(v, cur)
+}
+
 func (mu *mockMetadataUpdater) ResetCursor(s loginp.Source, cur interface{}) error {
 	mu.table[s.Name()] = cur
 	return nil
@@ -0,0 +1,33 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build windows
+
+package filestream
+
+import (
+	"errors"
+
+	"golang.org/x/sys/windows"
+)
+
+// isStaleFileHandle returns true if err is returned for a file handle that
+// is not valid anymore on a network share.
+func isStaleFileHandle(err error) bool {
+	return errors.Is(err, windows.ERROR_NETNAME_DELETED) ||
+		errors.Is(err, windows.ERROR_UNEXP_NET_ERR)
+}
@@ -17,6 +17,11 @@
 
 package takeover
 
+import (
+	"github.com/elastic/beats/v7/filebeat/input/filestream"
+	conf "github.com/elastic/elastic-agent-libs/config"
+)
+
 type scanner struct {
 	RecursiveGlob bool `config:"recursive_glob"`
 }
@@ -26,11 +31,12 @@ type prospector struct {
 }
 
 type inputConfig struct {
-	Type       string     `config:"type"`
-	ID         string     `config:"id"`
-	Paths      []string   `config:"paths"`
-	TakeOver   bool       `config:"take_over"`
-	Prospector prospector `config:"prospector"`
+	Type         string                    `config:"type"`
+	ID           string                    `config:"id"`
+	Paths        []string                  `config:"paths"`
+	TakeOver     filestream.TakeOverConfig `config:"take_over"`
+	Prospector   prospector                `config:"prospector"`
+	FileIdentity *conf.Namespace           `config:"file_identity"`
 }
 
 func defaultInputConfig() inputConfig {
@@ -38,7 +44,7 @@ func defaultInputConfig() inputConfig {
 		Type:     "",
 		ID:       "",
 		Paths:    []string{},
-		TakeOver: false,
+		TakeOver: filestream.TakeOverConfig{},
 		Prospector: prospector{
 			Scanner: scanner{
 				RecursiveGlob: true,
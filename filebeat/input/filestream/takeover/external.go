@@ -0,0 +1,289 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package takeover
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/elastic/beats/v7/filebeat/input/filestream"
+	"github.com/elastic/beats/v7/libbeat/common/file"
+	"github.com/elastic/beats/v7/libbeat/common/sqlite"
+	"github.com/elastic/beats/v7/libbeat/statestore/backend"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+const (
+	fluentBitSource = "fluentbit"
+	promtailSource  = "promtail"
+	sincedbSource   = "sincedb"
+
+	nativeIdentity = "native"
+	pathIdentity   = "path"
+)
+
+// position is the offset a third-party shipper reached in a file.
+type position struct {
+	// path of the file, empty if the shipper doesn't store it.
+	path string
+	// inode of the file, empty if the shipper doesn't store it.
+	inode  string
+	offset int64
+}
+
+// positionReaders read the positions stored in the state file of each
+// supported third-party shipper.
+var positionReaders = map[string]func(path string) ([]position, error){
+	fluentBitSource: readFluentBitPositions,
+	promtailSource:  readPromtailPositions,
+	sincedbSource:   readSincedbPositions,
+}
+
+// externalTakeOver is a filestream input taking over the positions of
+// third-party shippers.
+type externalTakeOver struct {
+	id       string
+	identity string
+	patterns []string
+	match    func(source string) bool
+	sources  []filestream.TakeOverSource
+}
+
+func newExternalTakeOver(cfg inputConfig, patterns []string, match func(string) bool) (*externalTakeOver, error) {
+	for _, src := range cfg.TakeOver.From {
+		if _, ok := positionReaders[src.Type]; !ok {
+			return nil, fmt.Errorf("unsupported take_over.from type `%s`, must be one of %s, %s or %s",
+				src.Type, fluentBitSource, promtailSource, sincedbSource)
+		}
+	}
+
+	identity := nativeIdentity
+	if cfg.FileIdentity != nil && cfg.FileIdentity.Name() != "" {
+		identity = cfg.FileIdentity.Name()
+	}
+	if identity != nativeIdentity && identity != pathIdentity {
+		return nil, fmt.Errorf("take_over.from requires the `%s` or `%s` file_identity, filestream `%s` uses `%s`",
+			nativeIdentity, pathIdentity, cfg.ID, identity)
+	}
+
+	return &externalTakeOver{
+		id:       cfg.ID,
+		identity: identity,
+		patterns: patterns,
+		match:    match,
+		sources:  cfg.TakeOver.From,
+	}, nil
+}
+
+// takeOverExternalStates converts the positions of third-party shippers in
+// the files of the filestream inputs to filestream states. Files that already
+// have a state in the registry are left untouched.
+func takeOverExternalStates(log *logp.Logger, store backend.Store, takeOvers []*externalTakeOver) (map[string]mapstr.M, error) {
+	toSet := make(map[string]mapstr.M)
+	now := time.Now()
+
+	for _, t := range takeOvers {
+		var files map[string]string // inode -> path, listed on first use
+
+		for _, src := range t.sources {
+			positions, err := positionReaders[src.Type](src.Path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s state from %s: %w", src.Type, src.Path, err)
+			}
+
+			for _, pos := range positions {
+				path := pos.path
+				info, err := os.Stat(path)
+				if path == "" || err != nil || !t.match(path) || !sameInode(info, pos.inode) {
+					// the file was rotated or its path is not stored,
+					// it is looked up by inode in the files of the input
+					if pos.inode == "" {
+						continue
+					}
+					if files == nil {
+						files = listFiles(t.patterns)
+					}
+					path = files[pos.inode]
+					if path == "" {
+						continue
+					}
+					info, err = os.Stat(path)
+					if err != nil {
+						continue
+					}
+				}
+
+				if pos.offset > info.Size() {
+					log.Infof("skipping %s position of %s, the file is smaller than the offset %d", src.Type, path, pos.offset)
+					continue
+				}
+
+				key := t.stateKey(path, info)
+				if _, ok := toSet[key]; ok {
+					continue
+				}
+				exists, err := store.Has(key)
+				if err != nil {
+					return nil, fmt.Errorf("failed to read the state of %s: %w", path, err)
+				}
+				if exists {
+					log.Debugf("skipping %s position of %s, filestream `%s` already has a state", src.Type, path, t.id)
+					continue
+				}
+
+				log.Infof("found %s position of `%s` to take over by `%s`", src.Type, path, key)
+				toSet[key] = mapstr.M{
+					"ttl":     -1,
+					"updated": now,
+					"cursor": mapstr.M{
+						"offset": pos.offset,
+					},
+					"meta": mapstr.M{
+						"source":          path,
+						"identifier_name": t.identity,
+					},
+				}
+			}
+		}
+	}
+
+	return toSet, nil
+}
+
+func (t *externalTakeOver) stateKey(path string, info os.FileInfo) string {
+	id := path
+	if t.identity == nativeIdentity {
+		id = file.GetOSState(info).String()
+	}
+	return fmt.Sprintf("filestream::%s::%s::%s", t.id, t.identity, id)
+}
+
+func sameInode(info os.FileInfo, inode string) bool {
+	if inode == "" {
+		return true
+	}
+	state := file.GetOSState(info)
+	return state.InodeString() == inode
+}
+
+// listFiles returns the paths of the files matching the patterns by inode.
+func listFiles(patterns []string) map[string]string {
+	files := make(map[string]string)
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			continue
+		}
+		for _, path := range matches {
+			info, err := os.Stat(path)
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			state := file.GetOSState(info)
+			files[state.InodeString()] = path
+		}
+	}
+	return files
+}
+
+// readFluentBitPositions reads the in_tail_files table of the database of
+// the fluent-bit tail input.
+func readFluentBitPositions(path string) ([]position, error) {
+	db, err := sqlite.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var positions []position
+	err = db.Rows("in_tail_files", func(row map[string]interface{}) error {
+		name, _ := row["name"].(string)
+		offset, _ := row["offset"].(int64)
+		pos := position{path: name, offset: offset}
+		if inode, ok := row["inode"].(int64); ok {
+			pos.inode = strconv.FormatUint(uint64(inode), 10)
+		}
+		positions = append(positions, pos)
+		return nil
+	})
+	return positions, err
+}
+
+// readPromtailPositions reads a promtail positions file.
+func readPromtailPositions(path string) ([]position, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var positionsFile struct {
+		Positions map[string]string `yaml:"positions"`
+	}
+	if err := yaml.Unmarshal(data, &positionsFile); err != nil {
+		return nil, err
+	}
+
+	positions := make([]position, 0, len(positionsFile.Positions))
+	for name, value := range positionsFile.Positions {
+		offset, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			// positions of other targets, like the journal, aren't offsets
+			continue
+		}
+		positions = append(positions, position{path: name, offset: offset})
+	}
+	return positions, nil
+}
+
+// readSincedbPositions reads a sincedb file of the Logstash file input. Its
+// lines are `inode major minor offset [last_active path]`, the last two
+// fields being missing in files written by older versions.
+func readSincedbPositions(path string) ([]position, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var positions []position
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		offset, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			continue
+		}
+		pos := position{inode: fields[0], offset: offset}
+		if len(fields) > 5 {
+			pos.path = strings.Join(fields[5:], " ")
+		}
+		positions = append(positions, pos)
+	}
+	return positions, scanner.Err()
+}
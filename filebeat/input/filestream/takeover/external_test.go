@@ -0,0 +1,224 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package takeover
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/common/file"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func TestReadFluentBitPositions(t *testing.T) {
+	positions, err := readFluentBitPositions(filepath.Join("testdata", "fluentbit.db"))
+	require.NoError(t, err)
+	require.Len(t, positions, 201, "all the rows of the interior and leaf pages must be read")
+
+	assert.Equal(t, position{path: "/var/log/app/app-001.log", inode: "100001", offset: 1000}, positions[0])
+	assert.Equal(t, position{path: "/var/log/app/app-200.log", inode: "100200", offset: 200000}, positions[199])
+
+	long := positions[200]
+	assert.Equal(t, "/var/log/"+strings.Repeat("d", 5000)+"/long.log", long.path, "the overflow pages must be read")
+	assert.Equal(t, "18446744073709551615", long.inode)
+	assert.Equal(t, int64(12345678901), long.offset)
+}
+
+func TestReadFluentBitPositionsErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("invalid write-ahead log", func(t *testing.T) {
+		data, err := os.ReadFile(filepath.Join("testdata", "fluentbit.db"))
+		require.NoError(t, err)
+		path := filepath.Join(dir, "tail.db")
+		require.NoError(t, os.WriteFile(path, data, 0o600))
+		require.NoError(t, os.WriteFile(path+"-wal", []byte(strings.Repeat("frames", 10)), 0o600))
+
+		_, err = readFluentBitPositions(path)
+		require.ErrorContains(t, err, "invalid write-ahead log header")
+	})
+
+	t.Run("not a database", func(t *testing.T) {
+		path := filepath.Join(dir, "positions.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("positions: {}\n"), 0o600))
+
+		_, err := readFluentBitPositions(path)
+		require.ErrorContains(t, err, "not a SQLite database")
+	})
+}
+
+func TestReadPromtailPositions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "positions.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`positions:
+  /var/log/app.log: "1024"
+  /var/log/other.log: "42"
+  journal-default: "s=abcdef;i=1"
+`), 0o600))
+
+	positions, err := readPromtailPositions(path)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []position{
+		{path: "/var/log/app.log", offset: 1024},
+		{path: "/var/log/other.log", offset: 42},
+	}, positions)
+}
+
+func TestReadSincedbPositions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".sincedb")
+	require.NoError(t, os.WriteFile(path, []byte(`12345 0 64768 2048 1700000000.123 /var/log/app.log
+12346 0 64768 512
+invalid line
+`), 0o600))
+
+	positions, err := readSincedbPositions(path)
+	require.NoError(t, err)
+	assert.Equal(t, []position{
+		{path: "/var/log/app.log", inode: "12345", offset: 2048},
+		{inode: "12346", offset: 512},
+	}, positions)
+}
+
+func TestTakeOverExternalStates(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name string, size int) (string, os.FileInfo) {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(strings.Repeat("a", size)), 0o600))
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		return path, info
+	}
+
+	appPath, appInfo := writeFile("app.log", 100)
+	_, rotatedInfo := writeFile("app.log.1", 100)
+	smallPath, _ := writeFile("small.log", 10)
+	readPath, readInfo := writeFile("read.log", 100)
+	otherPath, _ := writeFile("other.txt", 100)
+
+	promtail := filepath.Join(dir, "positions.yaml")
+	require.NoError(t, os.WriteFile(promtail, []byte(fmt.Sprintf(`positions:
+  %q: "50"
+  %q: "50"
+  %q: "50"
+  %q: "50"
+`, appPath, smallPath, readPath, otherPath)), 0o600))
+
+	// the sincedb stores the path the file had before it was rotated
+	sincedb := filepath.Join(dir, ".sincedb")
+	rotatedState := file.GetOSState(rotatedInfo)
+	require.NoError(t, os.WriteFile(sincedb, []byte(fmt.Sprintf("%s 0 0 70 1700000000.1 %s\n",
+		rotatedState.InodeString(), filepath.Join(dir, "app.log.0"))), 0o600))
+
+	nativeKey := func(info os.FileInfo) string {
+		return "filestream::fs-id::native::" + file.GetOSState(info).String()
+	}
+
+	cfg := newInputConfigFrom(t, fmt.Sprintf(`
+type: filestream
+id: fs-id
+paths:
+  - %q
+take_over:
+  enabled: true
+  from:
+    - type: promtail
+      path: %q
+    - type: sincedb
+      path: %q
+`, filepath.Join(dir, "*.log*"), promtail, sincedb))
+
+	store := storeMock{
+		states: []state{
+			{key: nativeKey(readInfo), value: mapstr.M{}},
+		},
+	}
+	backuper := backuperMock{}
+	err := TakeOverLogInputStates(logp.NewLogger("takeover-test"), &store, &backuper, cfg)
+	require.NoError(t, err)
+	require.Equal(t, 1, backuper.called, "backup must be called exactly once")
+
+	offsets := make(map[string]interface{})
+	for _, op := range store.set {
+		value, ok := op.value.(mapstr.M)
+		require.True(t, ok)
+		offsets[op.key], err = value.GetValue("cursor.offset")
+		require.NoError(t, err)
+		assert.Equal(t, -1, value["ttl"])
+		source, _ := value.GetValue("meta.source")
+		assert.NotEqual(t, smallPath, source, "files smaller than the offset must be skipped")
+	}
+
+	expected := map[string]interface{}{
+		nativeKey(appInfo): int64(50),
+	}
+	if runtime.GOOS != "windows" {
+		// rotated files are found by inode
+		expected[nativeKey(rotatedInfo)] = int64(70)
+	}
+	assert.Equal(t, expected, offsets)
+	assert.Empty(t, store.removed)
+}
+
+func TestTakeOverExternalStatesConfig(t *testing.T) {
+	cases := map[string]struct {
+		cfg    string
+		expErr string
+	}{
+		"unsupported type": {
+			cfg: `
+type: filestream
+id: fs-id
+paths: ["/var/log/*.log"]
+take_over:
+  enabled: true
+  from:
+    - type: unknown
+      path: /tmp/state
+`,
+			expErr: "unsupported take_over.from type `unknown`",
+		},
+		"unsupported file identity": {
+			cfg: `
+type: filestream
+id: fs-id
+paths: ["/var/log/*.log"]
+file_identity.fingerprint: ~
+take_over:
+  enabled: true
+  from:
+    - type: promtail
+      path: /tmp/positions.yaml
+`,
+			expErr: "take_over.from requires the `native` or `path` file_identity",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := TakeOverLogInputStates(logp.NewLogger("takeover-test"), &storeMock{}, &backuperMock{}, newInputConfigFrom(t, tc.cfg))
+			require.ErrorContains(t, err, tc.expErr)
+		})
+	}
+}
@@ -45,10 +45,13 @@ type filestreamMatchers map[string]func(source string) bool
 // `take over` means every state that belongs to a loginput will be converted to a filestream state
 // if the source file path matches one of the paths/globs of the filestream input.
 //
+// The positions stored by the third-party shippers listed in `take_over.from` are
+// converted to filestream states as well, for the files that don't have a state yet.
+//
 // This mode is created for a smooth loginput->filestream migration experience, so the filestream
 // inputs would pick up ingesting files from the same point where a loginput stopped.
 func TakeOverLogInputStates(log *logp.Logger, store backend.Store, backuper backup.Backuper, inputsCfg []*conf.C) error {
-	filestreamMatchers, err := findFilestreams(log, inputsCfg)
+	filestreamMatchers, externalTakeOvers, err := findFilestreams(log, inputsCfg)
 	if err != nil {
 		return fmt.Errorf("failed to read input configuration: %w", err)
 	}
@@ -60,6 +63,18 @@ func TakeOverLogInputStates(log *logp.Logger, store backend.Store, backuper back
 	if err != nil {
 		return fmt.Errorf("failed to take over one of loginput states: %w", err)
 	}
+	if len(externalTakeOvers) > 0 {
+		externalStates, err := takeOverExternalStates(log, store, externalTakeOvers)
+		if err != nil {
+			return fmt.Errorf("failed to take over third-party states: %w", err)
+		}
+		for key, state := range externalStates {
+			// the states of the loginput take precedence
+			if _, exists := statesToSet[key]; !exists {
+				statesToSet[key] = state
+			}
+		}
+	}
 	if len(statesToSet) == 0 {
 		log.Info("no state to take over")
 		return nil
@@ -84,7 +99,7 @@ func TakeOverLogInputStates(log *logp.Logger, store backend.Store, backuper back
 		}
 	}
 
-	log.Infof("filestream inputs took over %d file(s) from loginputs", len(statesToSet))
+	log.Infof("filestream inputs took over %d file(s) from loginputs and third-party shippers", len(statesToSet))
 	return nil
 }
 
@@ -140,26 +155,36 @@ func takeOverStates(log *logp.Logger, store backend.Store, matchers filestreamMa
 
 // findFilestreams finds filestream inputs that are marked as `take_over: true`
 // and creates a file matcher for each such filestream for the future use in state
-// processing
-func findFilestreams(log *logp.Logger, inputs []*conf.C) (matchers filestreamMatchers, err error) {
+// processing. The filestreams taking over the state of third-party shippers are
+// returned as well.
+func findFilestreams(log *logp.Logger, inputs []*conf.C) (matchers filestreamMatchers, external []*externalTakeOver, err error) {
 	matchers = make(filestreamMatchers)
 
 	for _, input := range inputs {
 		inputCfg := defaultInputConfig()
 		err := input.Unpack(&inputCfg)
 		if err != nil {
-			return nil, fmt.Errorf("failed to unpack input configuration: %w", err)
+			return nil, nil, fmt.Errorf("failed to unpack input configuration: %w", err)
 		}
-		if inputCfg.Type != "filestream" || !inputCfg.TakeOver {
+		if inputCfg.Type != "filestream" || !inputCfg.TakeOver.Enabled {
 			continue
 		}
 		if _, exists := matchers[inputCfg.ID]; exists || inputCfg.ID == "" {
-			return matchers, fmt.Errorf("filestream with ID `%s` in `take over` mode requires a unique ID. Add the `id:` key with a unique value.", inputCfg.ID)
+			return matchers, nil, fmt.Errorf("filestream with ID `%s` in `take over` mode requires a unique ID. Add the `id:` key with a unique value.", inputCfg.ID)
 		}
 
-		matchers[inputCfg.ID], err = createMatcher(log, inputCfg)
+		patterns, err := globPatterns(log, inputCfg)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create filestream matcher: %w", err)
+			return nil, nil, fmt.Errorf("failed to create filestream matcher: %w", err)
+		}
+		matchers[inputCfg.ID] = createMatcher(patterns)
+
+		if len(inputCfg.TakeOver.From) > 0 {
+			t, err := newExternalTakeOver(inputCfg, patterns, matchers[inputCfg.ID])
+			if err != nil {
+				return nil, nil, err
+			}
+			external = append(external, t)
 		}
 	}
 
@@ -167,13 +192,13 @@ func findFilestreams(log *logp.Logger, inputs []*conf.C) (matchers filestreamMat
 		log.Infof("found %d filestream inputs in `take over` mode", len(matchers))
 	}
 
-	return matchers, nil
+	return matchers, external, nil
 }
 
-// createMatcher creates a match function that determines whether the given
-// source file matches one of the glob expressions listed in the filestream configuration
-func createMatcher(log *logp.Logger, cfg inputConfig) (matcher func(source string) bool, err error) {
-	patterns := cfg.Paths
+// globPatterns returns the glob expressions listed in the filestream
+// configuration, with the recursive globs expanded.
+func globPatterns(log *logp.Logger, cfg inputConfig) (patterns []string, err error) {
+	patterns = cfg.Paths
 
 	// see `../fswatch.go` for the similar logic
 	if cfg.Prospector.Scanner.RecursiveGlob {
@@ -193,6 +218,12 @@ func createMatcher(log *logp.Logger, cfg inputConfig) (matcher func(source strin
 
 	log.Infof("found %d patterns for filestream `%s`", len(patterns), cfg.ID)
 
+	return patterns, nil
+}
+
+// createMatcher creates a match function that determines whether the given
+// source file matches one of the glob expressions
+func createMatcher(patterns []string) (matcher func(source string) bool) {
 	return func(source string) bool {
 		for _, pattern := range patterns {
 			matched, err := filepath.Match(pattern, source)
@@ -207,7 +238,7 @@ func createMatcher(log *logp.Logger, cfg inputConfig) (matcher func(source strin
 		}
 
 		return false
-	}, nil
+	}
 }
 
 func loginputToFilestreamKey(key, filestreamID string) string {
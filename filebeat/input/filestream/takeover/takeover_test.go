@@ -293,6 +293,15 @@ func (s *storeMock) Set(key string, value interface{}) error {
 	return nil
 }
 
+func (s *storeMock) Has(key string) (bool, error) {
+	for _, st := range s.states {
+		if st.key == key {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func (s *storeMock) Remove(key string) error {
 	s.removed = append(s.removed, key)
 	return nil
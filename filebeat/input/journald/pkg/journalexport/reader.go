@@ -0,0 +1,201 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package journalexport reads journal entries in the journal export format,
+// the format produced by "journalctl -o export" and sent by
+// systemd-journal-upload. See https://systemd.io/JOURNAL_EXPORT_FORMATS/.
+package journalexport
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// ErrEntryTooLarge is returned when an entry exceeds the maximum entry size.
+var ErrEntryTooLarge = errors.New("journal entry too large")
+
+// Reader reads journal entries in the export format.
+type Reader struct {
+	r            *bufio.Reader
+	maxEntrySize int
+	size         int
+}
+
+// NewReader returns a Reader reading entries from r. Entries larger than
+// maxEntrySize bytes are rejected with ErrEntryTooLarge.
+func NewReader(r io.Reader, maxEntrySize int) *Reader {
+	return &Reader{r: bufio.NewReader(r), maxEntrySize: maxEntrySize}
+}
+
+// Next returns the fields of the next entry. It returns io.EOF when there
+// are no more entries.
+//
+// Field values are strings, or lists of byte values when they are not valid
+// UTF-8, like the JSON output of journalctl. Fields set more than once in an
+// entry have a list of values.
+func (r *Reader) Next() (map[string]any, error) {
+	var entry map[string]any
+	// multi holds the fields set more than once.
+	multi := map[string]bool{}
+	r.size = 0
+	for {
+		line, err := r.readLine()
+		if err != nil {
+			if errors.Is(err, io.EOF) && len(line) == 0 {
+				if entry == nil {
+					return nil, io.EOF
+				}
+				// The last entry doesn't need to be followed by an
+				// empty line.
+				return entry, nil
+			}
+			if errors.Is(err, io.EOF) {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		if len(line) == 0 {
+			if entry == nil {
+				continue
+			}
+			return entry, nil
+		}
+		if entry == nil {
+			entry = map[string]any{}
+		}
+
+		var name string
+		var value []byte
+		if i := bytes.IndexByte(line, '='); i >= 0 {
+			name, value = string(line[:i]), line[i+1:]
+			if err := validateName(name); err != nil {
+				return nil, err
+			}
+		} else {
+			// Binary field: the name is followed by the size of the value
+			// as a little endian 64 bit integer, the value and a newline.
+			name = string(line)
+			if err := validateName(name); err != nil {
+				return nil, err
+			}
+			if value, err = r.readBinary(); err != nil {
+				return nil, fmt.Errorf("failed to read field %s: %w", name, err)
+			}
+		}
+		addField(entry, multi, name, decodeValue(value))
+	}
+}
+
+// readLine reads a line without its trailing newline.
+func (r *Reader) readLine() ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := r.r.ReadSlice('\n')
+		r.size += len(chunk)
+		if r.size > r.maxEntrySize {
+			return nil, ErrEntryTooLarge
+		}
+		switch {
+		case err == nil:
+			if line == nil {
+				return append([]byte(nil), chunk[:len(chunk)-1]...), nil
+			}
+			return append(line, chunk[:len(chunk)-1]...), nil
+		case errors.Is(err, bufio.ErrBufferFull):
+			line = append(line, chunk...)
+		default:
+			return append(line, chunk...), err
+		}
+	}
+}
+
+func (r *Reader) readBinary() ([]byte, error) {
+	var size [8]byte
+	if _, err := io.ReadFull(r.r, size[:]); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	n := binary.LittleEndian.Uint64(size[:])
+	if n > uint64(r.maxEntrySize-r.size) {
+		return nil, ErrEntryTooLarge
+	}
+	r.size += 8 + int(n) + 1
+	if r.size > r.maxEntrySize {
+		return nil, ErrEntryTooLarge
+	}
+	value := make([]byte, n+1)
+	if _, err := io.ReadFull(r.r, value); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if value[n] != '\n' {
+		return nil, errors.New("binary field not terminated by a newline")
+	}
+	return value[:n], nil
+}
+
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// validateName checks that a field name is valid. Names are made of
+// uppercase letters, digits and underscores, and don't start with a digit.
+// Names of the fields added by journald start with one or two underscores.
+func validateName(name string) error {
+	if name == "" || len(name) > 64 {
+		return fmt.Errorf("invalid field name %q", name)
+	}
+	for i, c := range []byte(name) {
+		switch {
+		case c >= 'A' && c <= 'Z', c == '_':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return fmt.Errorf("invalid field name %q", name)
+		}
+	}
+	return nil
+}
+
+func decodeValue(b []byte) any {
+	if utf8.Valid(b) {
+		return string(b)
+	}
+	v := make([]any, len(b))
+	for i, c := range b {
+		v[i] = int(c)
+	}
+	return v
+}
+
+func addField(entry map[string]any, multi map[string]bool, name string, value any) {
+	prev, found := entry[name]
+	switch {
+	case !found:
+		entry[name] = value
+	case multi[name]:
+		entry[name] = append(prev.([]any), value)
+	default:
+		multi[name] = true
+		entry[name] = []any{prev, value}
+	}
+}
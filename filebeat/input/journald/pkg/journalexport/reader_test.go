@@ -0,0 +1,126 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package journalexport
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func binaryField(name, value string) string {
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	return name + "\n" + string(size[:]) + value + "\n"
+}
+
+func readAll(t *testing.T, data string, maxEntrySize int) ([]map[string]any, error) {
+	t.Helper()
+	r := NewReader(strings.NewReader(data), maxEntrySize)
+	var entries []map[string]any
+	for {
+		entry, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return entries, err
+		}
+		entries = append(entries, entry)
+	}
+}
+
+func TestReader(t *testing.T) {
+	data := "__CURSOR=s=1;i=1\n" +
+		"__REALTIME_TIMESTAMP=1700000000000000\n" +
+		"_HOSTNAME=edge-1\n" +
+		"MESSAGE=first = line\n" +
+		"\n" +
+		"\n" +
+		"__CURSOR=s=1;i=2\n" +
+		binaryField("MESSAGE", "multi\nline") +
+		"TAG=a\n" +
+		"TAG=b\n" +
+		binaryField("TAG", "c") +
+		binaryField("RAW", "\xff\x00") +
+		"EMPTY=\n" +
+		"\n" +
+		"__CURSOR=s=1;i=3\n" +
+		"MESSAGE=no trailing newline\n"
+
+	entries, err := readAll(t, data, 1<<20)
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]any{
+		{
+			"__CURSOR":             "s=1;i=1",
+			"__REALTIME_TIMESTAMP": "1700000000000000",
+			"_HOSTNAME":            "edge-1",
+			"MESSAGE":              "first = line",
+		},
+		{
+			"__CURSOR": "s=1;i=2",
+			"MESSAGE":  "multi\nline",
+			"TAG":      []any{"a", "b", "c"},
+			"RAW":      []any{255, 0},
+			"EMPTY":    "",
+		},
+		{
+			"__CURSOR": "s=1;i=3",
+			"MESSAGE":  "no trailing newline",
+		},
+	}, entries)
+}
+
+func TestReaderErrors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		data         string
+		maxEntrySize int
+		err          error
+	}{
+		"invalid name":         {data: "message=lower\n\n"},
+		"name starts by digit": {data: "1A=x\n\n"},
+		"truncated line":       {data: "MESSAGE=x", err: io.ErrUnexpectedEOF},
+		"truncated binary":     {data: binaryField("MESSAGE", "hello")[:12], err: io.ErrUnexpectedEOF},
+		"unterminated binary":  {data: strings.TrimSuffix(binaryField("MESSAGE", "hello"), "\n") + "X\n"},
+		"entry too large":      {data: "MESSAGE=" + strings.Repeat("x", 100) + "\n\n", maxEntrySize: 64, err: ErrEntryTooLarge},
+		"binary too large":     {data: binaryField("MESSAGE", strings.Repeat("x", 100)), maxEntrySize: 64, err: ErrEntryTooLarge},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if tc.maxEntrySize == 0 {
+				tc.maxEntrySize = 1 << 20
+			}
+			_, err := readAll(t, tc.data, tc.maxEntrySize)
+			require.Error(t, err)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestReaderLongLines(t *testing.T) {
+	long := strings.Repeat("x", 10000)
+	entries, err := readAll(t, "MESSAGE="+long+"\n\n", 1<<20)
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]any{{"MESSAGE": long}}, entries)
+}
@@ -0,0 +1,87 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build linux
+
+package journalremote
+
+import (
+	"sync"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/acker"
+)
+
+// newEventACKHandler returns a beat ACKer that invokes the ACK() method of the
+// batchACKTracker of the events acknowledged by the output.
+func newEventACKHandler() beat.EventListener {
+	return acker.ConnectionOnly(
+		acker.EventPrivateReporter(func(_ int, privates []interface{}) {
+			for _, private := range privates {
+				if ack, ok := private.(*batchACKTracker); ok {
+					ack.ACK()
+				}
+			}
+		}),
+	)
+}
+
+// batchACKTracker invokes batchACK when all events of an upload have been
+// published and acknowledged by the output.
+type batchACKTracker struct {
+	batchACK func()
+
+	mu      sync.Mutex
+	pending int64
+}
+
+// newBatchACKTracker returns a new batchACKTracker. Ready() must be invoked
+// after all events of the upload are published.
+func newBatchACKTracker(fn func()) *batchACKTracker {
+	return &batchACKTracker{
+		batchACK: fn,
+		pending:  1, // Ready() must be called to consume this "1".
+	}
+}
+
+// Ready signals that all the events of the upload were published.
+func (t *batchACKTracker) Ready() {
+	t.ACK()
+}
+
+// Add increments the number of pending ACKs.
+func (t *batchACKTracker) Add() {
+	t.mu.Lock()
+	t.pending++
+	t.mu.Unlock()
+}
+
+// ACK decrements the number of pending ACKs, batchACK is invoked when it
+// reaches zero.
+func (t *batchACKTracker) ACK() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.pending <= 0 {
+		panic("misuse detected: negative ACK counter")
+	}
+
+	t.pending--
+	if t.pending == 0 {
+		t.batchACK()
+	}
+}
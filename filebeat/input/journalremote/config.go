@@ -0,0 +1,61 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build linux
+
+package journalremote
+
+import (
+	"errors"
+
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+)
+
+type config struct {
+	// ListenAddress is the address the HTTP server listens on.
+	ListenAddress string `config:"listen_address"`
+
+	// ListenPort is the port the HTTP server listens on.
+	ListenPort string `config:"listen_port"`
+
+	// TLS configures the HTTP server.
+	TLS *tlscommon.ServerConfig `config:"ssl"`
+
+	// MaxEntrySize is the maximum size of a journal entry.
+	MaxEntrySize int `config:"max_entry_size" validate:"positive"`
+
+	// SaveRemoteHostname copies the hostname of the journal entries to
+	// log.source.address, as add_host_metadata overwrites host.hostname.
+	SaveRemoteHostname bool `config:"save_remote_hostname"`
+}
+
+func defaultConfig() config {
+	return config{
+		ListenAddress: "localhost",
+		// The port of systemd-journal-remote.
+		ListenPort:         "19532",
+		MaxEntrySize:       1 << 20,
+		SaveRemoteHostname: true,
+	}
+}
+
+func (c *config) Validate() error {
+	if c.ListenPort == "" {
+		return errors.New("listen_port cannot be empty")
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build linux
+
+package journalremote
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/elastic/beats/v7/filebeat/input/journald/pkg/journalexport"
+	"github.com/elastic/beats/v7/filebeat/input/journald/pkg/journalfield"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+const (
+	uploadPath        = "/upload"
+	exportContentType = "application/vnd.fdo.journal"
+)
+
+// handler receives the journal entries uploaded by systemd-journal-upload.
+// The upload is answered once all its entries were acknowledged by the
+// output, so that systemd-journal-upload only saves its position in the
+// journal once the entries are safe.
+type handler struct {
+	log                *logp.Logger
+	publish            func(beat.Event)
+	converter          *journalfield.Converter
+	maxEntrySize       int
+	saveRemoteHostname bool
+	// done is closed when the input stops.
+	done <-chan struct{}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != uploadPath {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed.", http.StatusMethodNotAllowed)
+		return
+	}
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != exportContentType {
+		http.Error(w, "Content-Type must be "+exportContentType+".", http.StatusUnsupportedMediaType)
+		return
+	}
+	body, err := decodeBody(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	}
+	defer body.Close()
+
+	log := h.log.With("remote_address", r.RemoteAddr)
+	acked := make(chan struct{})
+	tracker := newBatchACKTracker(func() { close(acked) })
+	reader := journalexport.NewReader(body, h.maxEntrySize)
+	var n int
+	for {
+		entry, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// The entries published so far are kept, systemd-journal-upload
+			// will send them again.
+			tracker.Ready()
+			log.Warnw("Failed to read uploaded journal entries.", "error", err, "entries", n)
+			status := http.StatusBadRequest
+			if errors.Is(err, journalexport.ErrEntryTooLarge) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		event := h.makeEvent(entry)
+		tracker.Add()
+		event.Private = tracker
+		h.publish(event)
+		n++
+	}
+	tracker.Ready()
+	log.Debugf("Received %d journal entries.", n)
+
+	select {
+	case <-acked:
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusAccepted)
+		io.WriteString(w, "OK.\n")
+	case <-h.done:
+		http.Error(w, "Shutting down.", http.StatusServiceUnavailable)
+	case <-r.Context().Done():
+	}
+}
+
+// decodeBody returns the request body, decompressed according to its
+// Content-Encoding.
+func decodeBody(r *http.Request) (io.ReadCloser, error) {
+	switch enc := r.Header.Get("Content-Encoding"); enc {
+	case "", "identity":
+		return r.Body, nil
+	case "gzip":
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip body: %w", err)
+		}
+		return zr, nil
+	case "zstd":
+		zr, err := zstd.NewReader(r.Body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid zstd body: %w", err)
+		}
+		return zr.IOReadCloser(), nil
+	default:
+		return nil, fmt.Errorf("unsupported Content-Encoding %q, supported encodings are gzip and zstd", enc)
+	}
+}
+
+// makeEvent converts an entry to an event with the schema of the journald
+// input.
+func (h *handler) makeEvent(entry map[string]any) beat.Event {
+	created := time.Now()
+	ts := created
+	if s, ok := entry["__REALTIME_TIMESTAMP"].(string); ok {
+		if us, err := strconv.ParseInt(s, 10, 64); err == nil {
+			ts = time.UnixMicro(us)
+		}
+	}
+
+	// See the journald input for non string messages.
+	msg, isString := entry["MESSAGE"].(string)
+	if !isString && entry["MESSAGE"] != nil {
+		msg = fmt.Sprint(entry["MESSAGE"])
+	}
+	delete(entry, "MESSAGE")
+
+	fields := h.converter.Convert(entry)
+	fields.Put("message", msg)
+	fields.Put("event.kind", "event")
+	fields.Put("event.created", created)
+	if h.saveRemoteHostname {
+		if hostname, err := fields.GetValue("host.hostname"); err == nil {
+			fields.Put("log.source.address", hostname)
+		}
+	}
+	return beat.Event{
+		Timestamp: ts,
+		Fields:    fields,
+	}
+}
@@ -0,0 +1,229 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build linux
+
+package journalremote
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/filebeat/input/journald/pkg/journalfield"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+const testEntries = "__CURSOR=s=abc;i=1\n" +
+	"__REALTIME_TIMESTAMP=1700000000123456\n" +
+	"__MONOTONIC_TIMESTAMP=123\n" +
+	"_BOOT_ID=boot\n" +
+	"_HOSTNAME=edge-1\n" +
+	"_SYSTEMD_UNIT=sshd.service\n" +
+	"PRIORITY=6\n" +
+	"SYSLOG_IDENTIFIER=sshd\n" +
+	"_PID=42\n" +
+	"CUSTOM_FIELD=custom\n" +
+	"MESSAGE=Accepted publickey for root\n" +
+	"\n" +
+	"__CURSOR=s=abc;i=2\n" +
+	"__REALTIME_TIMESTAMP=1700000001000000\n" +
+	"_HOSTNAME=edge-1\n" +
+	"MESSAGE\n" +
+	"\x0a\x00\x00\x00\x00\x00\x00\x00multi\nline\n" +
+	"\n"
+
+type testPublisher struct {
+	mu     sync.Mutex
+	events []beat.Event
+	// hold delays the acknowledgement of the events while it is open.
+	hold chan struct{}
+}
+
+func (p *testPublisher) publish(event beat.Event) {
+	p.mu.Lock()
+	p.events = append(p.events, event)
+	p.mu.Unlock()
+	go func() {
+		if p.hold != nil {
+			<-p.hold
+		}
+		event.Private.(*batchACKTracker).ACK()
+	}()
+}
+
+func (p *testPublisher) Events() []beat.Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]beat.Event(nil), p.events...)
+}
+
+func newTestServer(t *testing.T, pub *testPublisher, done <-chan struct{}) *httptest.Server {
+	logp.TestingSetup()
+	srv := httptest.NewServer(&handler{
+		log:                logp.NewLogger(pluginName),
+		publish:            pub.publish,
+		converter:          journalfield.NewConverter(logp.NewLogger(pluginName), nil),
+		maxEntrySize:       1024,
+		saveRemoteHostname: true,
+		done:               done,
+	})
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func upload(t *testing.T, url string, body io.Reader, header map[string]string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url+uploadPath, body)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", exportContentType)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(b)
+}
+
+func TestUpload(t *testing.T) {
+	pub := &testPublisher{hold: make(chan struct{})}
+	srv := newTestServer(t, pub, nil)
+
+	result := make(chan int, 1)
+	go func() {
+		status, _ := upload(t, srv.URL, strings.NewReader(testEntries), nil)
+		result <- status
+	}()
+
+	// The upload is answered once its events are acknowledged.
+	require.Eventually(t, func() bool { return len(pub.Events()) == 2 }, 5*time.Second, 10*time.Millisecond)
+	select {
+	case <-result:
+		t.Fatal("upload answered before the events were acknowledged")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(pub.hold)
+	assert.Equal(t, http.StatusAccepted, <-result)
+
+	events := pub.Events()
+	assert.Equal(t, time.UnixMicro(1700000000123456), events[0].Timestamp)
+	created, err := events[0].Fields.GetValue("event.created")
+	require.NoError(t, err)
+	events[0].Fields.Delete("event.created")
+	assert.IsType(t, time.Time{}, created)
+	assert.Equal(t, mapstr.M{
+		"message": "Accepted publickey for root",
+		"event":   mapstr.M{"kind": "event"},
+		"host":    mapstr.M{"hostname": "edge-1"},
+		"log": mapstr.M{
+			"source": mapstr.M{"address": "edge-1"},
+			"syslog": mapstr.M{"priority": int64(6)},
+		},
+		"syslog":  mapstr.M{"priority": int64(6), "identifier": "sshd"},
+		"systemd": mapstr.M{"unit": "sshd.service"},
+		"process": mapstr.M{"pid": int64(42)},
+		"journald": mapstr.M{
+			"host":   mapstr.M{"boot_id": "boot"},
+			"pid":    int64(42),
+			"custom": mapstr.M{"custom_field": "custom"},
+		},
+	}, events[0].Fields)
+
+	msg, _ := events[1].Fields.GetValue("message")
+	assert.Equal(t, "multi\nline", msg)
+}
+
+func TestUploadCompressed(t *testing.T) {
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte(testEntries))
+	zw.Close()
+
+	enc, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	zst := enc.EncodeAll([]byte(testEntries), nil)
+
+	for encoding, body := range map[string][]byte{"gzip": gz.Bytes(), "zstd": zst} {
+		t.Run(encoding, func(t *testing.T) {
+			pub := &testPublisher{}
+			srv := newTestServer(t, pub, nil)
+			status, _ := upload(t, srv.URL, bytes.NewReader(body), map[string]string{"Content-Encoding": encoding})
+			assert.Equal(t, http.StatusAccepted, status)
+			assert.Len(t, pub.Events(), 2)
+		})
+	}
+}
+
+func TestUploadErrors(t *testing.T) {
+	pub := &testPublisher{}
+	srv := newTestServer(t, pub, nil)
+
+	status, _ := upload(t, srv.URL, strings.NewReader(testEntries), map[string]string{"Content-Type": "application/json"})
+	assert.Equal(t, http.StatusUnsupportedMediaType, status)
+
+	status, _ = upload(t, srv.URL, strings.NewReader(testEntries), map[string]string{"Content-Encoding": "xz"})
+	assert.Equal(t, http.StatusUnsupportedMediaType, status)
+
+	status, _ = upload(t, srv.URL, strings.NewReader("MESSAGE=ok\n\nlower=case\n\n"), nil)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Len(t, pub.Events(), 1)
+
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], 1<<20)
+	status, _ = upload(t, srv.URL, strings.NewReader("MESSAGE\n"+string(size[:])), nil)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, status)
+
+	resp, err := http.Get(srv.URL + uploadPath)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+	status, _ = upload(t, srv.URL+"/other", strings.NewReader(testEntries), nil)
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestUploadShutdown(t *testing.T) {
+	pub := &testPublisher{hold: make(chan struct{})}
+	defer close(pub.hold)
+	done := make(chan struct{})
+	srv := newTestServer(t, pub, done)
+
+	result := make(chan int, 1)
+	go func() {
+		status, _ := upload(t, srv.URL, strings.NewReader(testEntries), nil)
+		result <- status
+	}()
+	require.Eventually(t, func() bool { return len(pub.Events()) == 2 }, 5*time.Second, 10*time.Millisecond)
+	close(done)
+	assert.Equal(t, http.StatusServiceUnavailable, <-result)
+}
@@ -0,0 +1,126 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build linux
+
+package journalremote
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/elastic/beats/v7/filebeat/input/journald/pkg/journalfield"
+	v2 "github.com/elastic/beats/v7/filebeat/input/v2"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/feature"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+	"github.com/elastic/go-concert/ctxtool"
+)
+
+const pluginName = "journald_remote"
+
+// Plugin creates a new journald_remote input plugin. The input is stateless,
+// systemd-journal-upload keeps track of the entries it uploaded.
+func Plugin() v2.Plugin {
+	return v2.Plugin{
+		Name:      pluginName,
+		Stability: feature.Experimental,
+		Info:      "journald remote input",
+		Doc:       "The journald_remote input receives journal entries uploaded by systemd-journal-upload",
+		Manager:   v2.ConfigureWith(configure),
+	}
+}
+
+func configure(cfg *conf.C) (v2.Input, error) {
+	config := defaultConfig()
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, err
+	}
+	return &journalRemote{config: config}, nil
+}
+
+type journalRemote struct {
+	config config
+}
+
+func (inp *journalRemote) Name() string { return pluginName }
+
+func (inp *journalRemote) Test(_ v2.TestContext) error {
+	l, err := inp.listen()
+	if err != nil {
+		return err
+	}
+	return l.Close()
+}
+
+func (inp *journalRemote) listen() (net.Listener, error) {
+	addr := net.JoinHostPort(inp.config.ListenAddress, inp.config.ListenPort)
+	tlsConfigBuilder, err := tlscommon.LoadTLSServerConfig(inp.config.TLS)
+	if err != nil {
+		return nil, err
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	if tlsConfigBuilder != nil {
+		l = tls.NewListener(l, tlsConfigBuilder.BuildServerConfig(addr))
+	}
+	return l, nil
+}
+
+func (inp *journalRemote) Run(ctx v2.Context, pipeline beat.Pipeline) error {
+	client, err := pipeline.ConnectWith(beat.ClientConfig{
+		EventListener: newEventACKHandler(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create pipeline client: %w", err)
+	}
+	defer client.Close()
+
+	l, err := inp.listen()
+	if err != nil {
+		return err
+	}
+	ctx.Logger.Infof("Listening for journal uploads on %s", l.Addr())
+
+	cancelCtx := ctxtool.FromCanceller(ctx.Cancelation)
+	srv := &http.Server{
+		Handler: &handler{
+			log:                ctx.Logger,
+			publish:            client.Publish,
+			converter:          journalfield.NewConverter(ctx.Logger, nil),
+			maxEntrySize:       inp.config.MaxEntrySize,
+			saveRemoteHostname: inp.config.SaveRemoteHostname,
+			done:               cancelCtx.Done(),
+		},
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-cancelCtx.Done()
+		srv.Close()
+	}()
+	if err := srv.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
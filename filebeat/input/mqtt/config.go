@@ -19,10 +19,19 @@ package mqtt
 
 import (
 	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
 
 	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
 )
 
+const (
+	protocolV311 = "3.1.1"
+	protocolV5   = "5"
+)
+
 type mqttInputConfig struct {
 	Hosts  []string `config:"hosts" validate:"required,min=1"`
 	Topics []string `config:"topics" validate:"required,min=1"`
@@ -33,6 +42,13 @@ type mqttInputConfig struct {
 	Password     string `config:"password"`
 	CleanSession bool   `config:"clean_session"`
 
+	ProtocolVersion string `config:"protocol_version"`
+
+	// MQTT v5 options.
+	SharedGroup       string        `config:"shared_group"`
+	SessionExpiry     time.Duration `config:"session_expiry"`
+	TopicAliasMaximum uint16        `config:"topic_alias_maximum"`
+
 	TLS *tlscommon.Config `config:"ssl"`
 }
 
@@ -42,13 +58,31 @@ func defaultConfig() mqttInputConfig {
 		ClientID:     "filebeat",
 		Topics:       []string{"#"},
 		CleanSession: true,
+
+		ProtocolVersion:   protocolV311,
+		TopicAliasMaximum: 10,
 	}
 }
 
 // Validate validates the config.
 func (mic *mqttInputConfig) Validate() error {
-	if len(mic.ClientID) < 1 || len(mic.ClientID) > 23 {
-		return errors.New("ClientID must be between 1 and 23 characters long")
+	switch mic.ProtocolVersion {
+	case protocolV311:
+		if len(mic.ClientID) < 1 || len(mic.ClientID) > 23 {
+			return errors.New("ClientID must be between 1 and 23 characters long")
+		}
+		if mic.SharedGroup != "" || mic.SessionExpiry != 0 {
+			return errors.New("shared_group and session_expiry require protocol_version 5")
+		}
+	case protocolV5:
+		if strings.ContainsAny(mic.SharedGroup, "/+#") {
+			return fmt.Errorf("shared_group %q must not contain '/', '+' or '#'", mic.SharedGroup)
+		}
+		if mic.SessionExpiry < 0 || mic.SessionExpiry.Seconds() > math.MaxUint32 {
+			return fmt.Errorf("session_expiry must be between 0 and %d seconds", uint32(math.MaxUint32))
+		}
+	default:
+		return fmt.Errorf("protocol_version must be %s or %s, got %q", protocolV311, protocolV5, mic.ProtocolVersion)
 	}
 	return nil
 }
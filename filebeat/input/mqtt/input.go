@@ -27,6 +27,7 @@ import (
 
 	"github.com/elastic/beats/v7/filebeat/channel"
 	"github.com/elastic/beats/v7/filebeat/input"
+	v2 "github.com/elastic/beats/v7/filebeat/input/v2"
 	"github.com/elastic/beats/v7/libbeat/beat"
 	"github.com/elastic/beats/v7/libbeat/common/backoff"
 	conf "github.com/elastic/elastic-agent-libs/config"
@@ -35,6 +36,8 @@ import (
 )
 
 const (
+	inputName = "mqtt"
+
 	disconnectTimeout = 3 * time.Second
 
 	subscribeTimeout       = 35 * time.Second // in client: subscribeWaitTimeout = 30s
@@ -53,7 +56,7 @@ type mqttInput struct {
 }
 
 func init() {
-	err := input.Register("mqtt", NewInput)
+	err := input.Register(inputName, NewInput)
 	if err != nil {
 		panic(err)
 	}
@@ -79,6 +82,10 @@ func newInput(
 	if err := cfg.Unpack(&config); err != nil {
 		return nil, fmt.Errorf("reading mqtt input config: %w", err)
 	}
+	if config.ProtocolVersion == protocolV5 {
+		// MQTT v5 is handled by the v2 input.
+		return nil, v2.ErrUnknownInput
+	}
 
 	out, err := connector.Connect(cfg)
 	if err != nil {
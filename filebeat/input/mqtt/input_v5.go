@@ -0,0 +1,332 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package mqtt
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eclipse/paho.golang/autopaho"
+	"github.com/eclipse/paho.golang/paho"
+
+	v2 "github.com/elastic/beats/v7/filebeat/input/v2"
+	cursor "github.com/elastic/beats/v7/filebeat/input/v2/input-cursor"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/backoff"
+	"github.com/elastic/beats/v7/libbeat/feature"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+	"github.com/elastic/go-concert/ctxtool"
+)
+
+// Plugin creates the MQTT v5 input. Configurations using an older protocol
+// version are handled by the v1 input registered under the same name.
+func Plugin(log *logp.Logger, store cursor.StateStore) v2.Plugin {
+	return v2.Plugin{
+		Name:       inputName,
+		Stability:  feature.Beta,
+		Deprecated: false,
+		Info:       "mqtt v5 input",
+		Doc:        "The mqtt input collects messages from MQTT v5 brokers",
+		Manager: &cursor.InputManager{
+			Logger:     log,
+			StateStore: store,
+			Type:       inputName,
+			Configure:  configureV5,
+		},
+	}
+}
+
+type sessionSource string
+
+func (s sessionSource) Name() string { return string(s) }
+
+// sessionState is the per session information persisted in the cursor store.
+// The client ID is kept so that a restarted input resumes the broker side
+// session even if the ID was generated.
+type sessionState struct {
+	ClientID string `json:"client_id" struct:"client_id"`
+}
+
+type mqttV5Input struct {
+	config mqttInputConfig
+}
+
+func configureV5(cfg *conf.C) ([]cursor.Source, cursor.Input, error) {
+	config := defaultConfig()
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, nil, err
+	}
+	if config.ProtocolVersion != protocolV5 {
+		return nil, nil, v2.ErrUnknownInput
+	}
+
+	src := sessionSource(config.ClientID + "@" + strings.Join(config.Hosts, ","))
+	return []cursor.Source{src}, &mqttV5Input{config: config}, nil
+}
+
+func (inp *mqttV5Input) Name() string { return inputName }
+
+func (inp *mqttV5Input) Test(_ cursor.Source, _ v2.TestContext) error {
+	_, err := parseServerURLs(inp.config.Hosts)
+	return err
+}
+
+func (inp *mqttV5Input) Run(ctx v2.Context, _ cursor.Source, crsr cursor.Cursor, publisher cursor.Publisher) error {
+	log := ctx.Logger.With("hosts", inp.config.Hosts)
+
+	state, err := inp.sessionState(crsr)
+	if err != nil {
+		return err
+	}
+	log = log.With("client_id", state.ClientID)
+
+	urls, err := parseServerURLs(inp.config.Hosts)
+	if err != nil {
+		return err
+	}
+
+	cancelCtx := ctxtool.FromCanceller(ctx.Cancelation)
+	handler := newMessageHandler()
+	logger := logp.NewLogger("libmqtt")
+
+	cliCfg := autopaho.ClientConfig{
+		ServerUrls:                    urls,
+		KeepAlive:                     30,
+		CleanStartOnInitialConnection: inp.config.CleanSession,
+		SessionExpiryInterval:         uint32(inp.config.SessionExpiry.Seconds()),
+		ConnectUsername:               inp.config.Username,
+		ConnectPassword:               []byte(inp.config.Password),
+		ConnectPacketBuilder: func(c *paho.Connect, _ *url.URL) (*paho.Connect, error) {
+			if c.Properties == nil {
+				c.Properties = &paho.ConnectProperties{}
+			}
+			c.Properties.TopicAliasMaximum = &inp.config.TopicAliasMaximum
+			return c, nil
+		},
+		OnConnectionUp: func(cm *autopaho.ConnectionManager, connack *paho.Connack) {
+			log.Infof("MQTT client connected, session present: %t", connack.SessionPresent)
+			// Topic aliases are scoped to a network connection.
+			handler.reset()
+			inp.subscribe(cancelCtx, log, cm)
+		},
+		OnConnectError: func(err error) {
+			log.Warnw("MQTT connection attempt failed", "error", err)
+		},
+		Debug:      &debugLogger{log: logger},
+		Errors:     &errorLogger{log: logger},
+		PahoDebug:  &debugLogger{log: logger},
+		PahoErrors: &errorLogger{log: logger},
+		ClientConfig: paho.ClientConfig{
+			ClientID: state.ClientID,
+			OnPublishReceived: []func(paho.PublishReceived) (bool, error){
+				func(pr paho.PublishReceived) (bool, error) {
+					event, err := handler.event(pr.Packet)
+					if err != nil {
+						log.Warnw("Dropping MQTT message", "error", err)
+						return false, err
+					}
+					return true, publisher.Publish(event, state)
+				},
+			},
+			OnServerDisconnect: func(d *paho.Disconnect) {
+				log.Warnf("MQTT server requested disconnect, reason code: %d", d.ReasonCode)
+			},
+			OnClientError: func(err error) {
+				log.Errorw("MQTT client error", "error", err)
+			},
+		},
+	}
+	if inp.config.TLS != nil {
+		tlsConfig, err := tlscommon.LoadTLSConfig(inp.config.TLS)
+		if err != nil {
+			return err
+		}
+		cliCfg.TlsCfg = tlsConfig.BuildModuleClientConfig("")
+	}
+
+	cm, err := autopaho.NewConnection(cancelCtx, cliCfg)
+	if err != nil {
+		return fmt.Errorf("creating MQTT connection: %w", err)
+	}
+
+	<-cancelCtx.Done()
+	disconnectCtx, cancel := context.WithTimeout(context.Background(), disconnectTimeout)
+	defer cancel()
+	if err := cm.Disconnect(disconnectCtx); err != nil {
+		log.Debugw("Disconnecting MQTT client failed", "error", err)
+	}
+	return nil
+}
+
+// sessionState restores the persisted session or creates a new one. Members
+// of a shared subscription group get a unique client ID so that multiple
+// Filebeat instances can share the same configuration.
+func (inp *mqttV5Input) sessionState(crsr cursor.Cursor) (sessionState, error) {
+	var state sessionState
+	if !crsr.IsNew() {
+		if err := crsr.Unpack(&state); err != nil {
+			return state, fmt.Errorf("reading mqtt session state: %w", err)
+		}
+		if state.ClientID != "" {
+			return state, nil
+		}
+	}
+
+	state.ClientID = inp.config.ClientID
+	if inp.config.SharedGroup != "" {
+		suffix := make([]byte, 4)
+		if _, err := rand.Read(suffix); err != nil {
+			return state, fmt.Errorf("generating mqtt client id: %w", err)
+		}
+		state.ClientID += "-" + hex.EncodeToString(suffix)
+	}
+	return state, nil
+}
+
+func (inp *mqttV5Input) subscribe(ctx context.Context, log *logp.Logger, cm *autopaho.ConnectionManager) {
+	sub := &paho.Subscribe{Subscriptions: createSubscribeOptions(inp.config)}
+	b := backoff.NewEqualJitterBackoff(ctx.Done(), subscribeRetryInterval, subscribeTimeout)
+	for ctx.Err() == nil {
+		_, err := cm.Subscribe(ctx, sub)
+		if err == nil {
+			return
+		}
+		log.Warnw("MQTT subscription failed", "error", err)
+		b.Wait()
+	}
+}
+
+func createSubscribeOptions(config mqttInputConfig) []paho.SubscribeOptions {
+	opts := make([]paho.SubscribeOptions, 0, len(config.Topics))
+	for _, topic := range config.Topics {
+		if config.SharedGroup != "" {
+			topic = "$share/" + config.SharedGroup + "/" + topic
+		}
+		opts = append(opts, paho.SubscribeOptions{Topic: topic, QoS: byte(config.QoS)})
+	}
+	return opts
+}
+
+func parseServerURLs(hosts []string) ([]*url.URL, error) {
+	urls := make([]*url.URL, 0, len(hosts))
+	for _, host := range hosts {
+		u, err := url.Parse(host)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mqtt host %q: %w", host, err)
+		}
+		switch u.Scheme {
+		case "mqtt", "tcp", "ssl", "tls", "mqtts", "ws", "wss":
+		default:
+			return nil, fmt.Errorf("unsupported scheme in mqtt host %q", host)
+		}
+		urls = append(urls, u)
+	}
+	return urls, nil
+}
+
+// messageHandler converts MQTT v5 messages into events. It keeps track of the
+// topic aliases announced by the broker on the current connection.
+type messageHandler struct {
+	mu      sync.Mutex
+	aliases map[uint16]string
+}
+
+func newMessageHandler() *messageHandler {
+	return &messageHandler{aliases: map[uint16]string{}}
+}
+
+func (h *messageHandler) reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.aliases = map[uint16]string{}
+}
+
+func (h *messageHandler) resolveTopic(p *paho.Publish) (string, error) {
+	if p.Properties == nil || p.Properties.TopicAlias == nil {
+		return p.Topic, nil
+	}
+
+	alias := *p.Properties.TopicAlias
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if p.Topic != "" {
+		h.aliases[alias] = p.Topic
+		return p.Topic, nil
+	}
+	topic, ok := h.aliases[alias]
+	if !ok {
+		return "", fmt.Errorf("unknown topic alias %d", alias)
+	}
+	return topic, nil
+}
+
+func (h *messageHandler) event(p *paho.Publish) (beat.Event, error) {
+	topic, err := h.resolveTopic(p)
+	if err != nil {
+		return beat.Event{}, err
+	}
+
+	mqttFields := mapstr.M{
+		"message_id": p.PacketID,
+		"qos":        p.QoS,
+		"retained":   p.Retain,
+		"topic":      topic,
+	}
+	if p.Properties != nil {
+		if p.Properties.ContentType != "" {
+			mqttFields["content_type"] = p.Properties.ContentType
+		}
+		if len(p.Properties.User) > 0 {
+			mqttFields["user_properties"] = userPropertiesToMap(p.Properties.User)
+		}
+	}
+
+	return beat.Event{
+		Timestamp: time.Now(),
+		Fields: mapstr.M{
+			"message": string(p.Payload),
+			"mqtt":    mqttFields,
+		},
+	}, nil
+}
+
+// userPropertiesToMap converts user properties into a map. Keys that are
+// repeated in the message are collected into a list.
+func userPropertiesToMap(props paho.UserProperties) mapstr.M {
+	m := mapstr.M{}
+	for _, p := range props {
+		switch v := m[p.Key].(type) {
+		case nil:
+			m[p.Key] = p.Value
+		case string:
+			m[p.Key] = []string{v, p.Value}
+		case []string:
+			m[p.Key] = append(v, p.Value)
+		}
+	}
+	return m
+}
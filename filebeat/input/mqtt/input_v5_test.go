@@ -0,0 +1,156 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package mqtt
+
+import (
+	"testing"
+
+	"github.com/eclipse/paho.golang/paho"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	finput "github.com/elastic/beats/v7/filebeat/input"
+	v2 "github.com/elastic/beats/v7/filebeat/input/v2"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func TestProtocolVersionRouting(t *testing.T) {
+	v5 := conf.MustNewConfigFrom(mapstr.M{
+		"hosts":            "tcp://mocked:1234",
+		"protocol_version": "5",
+	})
+	_, err := NewInput(v5, new(mockedConnector), finput.Context{})
+	require.ErrorIs(t, err, v2.ErrUnknownInput)
+
+	srcs, inp, err := configureV5(v5)
+	require.NoError(t, err)
+	require.NotNil(t, inp)
+	require.Len(t, srcs, 1)
+	assert.Equal(t, "filebeat@tcp://mocked:1234", srcs[0].Name())
+
+	v311 := conf.MustNewConfigFrom(mapstr.M{
+		"hosts": "tcp://mocked:1234",
+	})
+	_, _, err = configureV5(v311)
+	require.ErrorIs(t, err, v2.ErrUnknownInput)
+}
+
+func TestConfigValidateProtocolVersion(t *testing.T) {
+	tests := map[string]struct {
+		settings mapstr.M
+		wantErr  bool
+	}{
+		"v5 options on 3.1.1": {
+			settings: mapstr.M{"shared_group": "workers"},
+			wantErr:  true,
+		},
+		"unknown version": {
+			settings: mapstr.M{"protocol_version": "4"},
+			wantErr:  true,
+		},
+		"long client id on v5": {
+			settings: mapstr.M{
+				"protocol_version": "5",
+				"client_id":        "a-client-id-longer-than-23-characters",
+				"shared_group":     "workers",
+				"session_expiry":   "1h",
+			},
+		},
+		"invalid shared group": {
+			settings: mapstr.M{"protocol_version": "5", "shared_group": "a/b"},
+			wantErr:  true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			tc.settings["hosts"] = "tcp://mocked:1234"
+			config := defaultConfig()
+			err := conf.MustNewConfigFrom(tc.settings).Unpack(&config)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestCreateSubscribeOptions(t *testing.T) {
+	config := defaultConfig()
+	config.Topics = []string{"sensors/#", "alerts"}
+	config.QoS = 1
+	config.SharedGroup = "workers"
+
+	assert.Equal(t, []paho.SubscribeOptions{
+		{Topic: "$share/workers/sensors/#", QoS: 1},
+		{Topic: "$share/workers/alerts", QoS: 1},
+	}, createSubscribeOptions(config))
+}
+
+func TestMessageHandler(t *testing.T) {
+	alias := uint16(3)
+	h := newMessageHandler()
+
+	event, err := h.event(&paho.Publish{
+		PacketID: 7,
+		QoS:      1,
+		Topic:    "sensors/temperature",
+		Payload:  []byte("21.5"),
+		Properties: &paho.PublishProperties{
+			TopicAlias: &alias,
+			User: paho.UserProperties{
+				{Key: "unit", Value: "celsius"},
+				{Key: "tag", Value: "a"},
+				{Key: "tag", Value: "b"},
+			},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, mapstr.M{
+		"message": "21.5",
+		"mqtt": mapstr.M{
+			"message_id": uint16(7),
+			"qos":        byte(1),
+			"retained":   false,
+			"topic":      "sensors/temperature",
+			"user_properties": mapstr.M{
+				"unit": "celsius",
+				"tag":  []string{"a", "b"},
+			},
+		},
+	}, event.Fields)
+
+	// Subsequent messages only carry the alias.
+	event, err = h.event(&paho.Publish{
+		Payload:    []byte("22"),
+		Properties: &paho.PublishProperties{TopicAlias: &alias},
+	})
+	require.NoError(t, err)
+	topic, _ := event.Fields.GetValue("mqtt.topic")
+	assert.Equal(t, "sensors/temperature", topic)
+
+	// Aliases do not survive a reconnect.
+	h.reset()
+	_, err = h.event(&paho.Publish{
+		Payload:    []byte("23"),
+		Properties: &paho.PublishProperties{TopicAlias: &alias},
+	})
+	require.Error(t, err)
+}
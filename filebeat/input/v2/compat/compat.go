@@ -32,7 +32,9 @@ import (
 	v2 "github.com/elastic/beats/v7/filebeat/input/v2"
 	"github.com/elastic/beats/v7/libbeat/beat"
 	"github.com/elastic/beats/v7/libbeat/cfgfile"
+	"github.com/elastic/beats/v7/libbeat/management/lifecycle"
 	"github.com/elastic/beats/v7/libbeat/management/status"
+	"github.com/elastic/beats/v7/libbeat/publisher/pause"
 	conf "github.com/elastic/elastic-agent-libs/config"
 	"github.com/elastic/elastic-agent-libs/logp"
 	"github.com/elastic/go-concert/ctxtool"
@@ -51,15 +53,29 @@ type factory struct {
 // the `sig` setup for shutdown signaling.
 // On stop the runner triggers the shutdown signal and waits until the input
 // has returned.
+// While the runner is started it can also be stopped, started and reloaded
+// by ID through the lifecycle registry.
 type runner struct {
 	id             string
 	log            *logp.Logger
 	agent          *beat.Info
+	loader         *v2.Loader
+	config         *conf.C
 	wg             sync.WaitGroup
 	sig            ctxtool.CancelContext
 	input          v2.Input
 	connector      beat.PipelineConnector
 	statusReporter status.StatusReporter
+	slo            sloConfig
+
+	// mu protects the lifecycle of the input. running is true from start
+	// to stop, done is closed when the input has returned.
+	mu      sync.Mutex
+	running bool
+	done    chan struct{}
+
+	unregisterHealth    func()
+	unregisterLifecycle func()
 }
 
 // RunnerFactory creates a cfgfile.RunnerFactory from an input Loader that is
@@ -109,13 +125,21 @@ func (f *factory) Create(
 		return nil, err
 	}
 
+	var slo sloConfig
+	if err := config.Unpack(&slo); err != nil {
+		return nil, fmt.Errorf("invalid input SLO settings: %w", err)
+	}
+
 	return &runner{
 		id:        id,
 		log:       f.log.Named(input.Name()).With("id", id),
 		agent:     &f.info,
+		loader:    f.loader,
+		config:    config,
 		sig:       ctxtool.WithCancelContext(context.Background()),
 		input:     input,
 		connector: p,
+		slo:       slo,
 	}, nil
 }
 
@@ -126,12 +150,65 @@ func (r *runner) SetStatusReporter(reported status.StatusReporter) {
 func (r *runner) String() string { return r.input.Name() }
 
 func (r *runner) Start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.unregisterLifecycle == nil {
+		r.unregisterLifecycle = lifecycle.Default.Register(r.id, (*controlledRunner)(r))
+	}
+	r.start()
+}
+
+func (r *runner) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.unregisterLifecycle != nil {
+		r.unregisterLifecycle()
+		r.unregisterLifecycle = nil
+	}
+	r.stop()
+	r.statusReporter = nil
+}
+
+// start runs the input in a new go-routine. It is a no-op if the input is
+// running. r.mu must be held.
+func (r *runner) start() {
+	if r.running {
+		return
+	}
+	if r.sig.Err() != nil {
+		// The input was stopped before, it needs a new shutdown signal.
+		r.sig = ctxtool.WithCancelContext(context.Background())
+	}
+	r.running = true
+	done := make(chan struct{})
+	r.done = done
+
 	r.wg.Add(1)
 	log := r.log
 	name := r.input.Name()
 
+	// The input can be paused and resumed by ID from the control API.
+	gate, unregister := pause.Default.Register(r.id)
+	connector := pause.WithGate(r.connector, gate, r.sig.Done())
+
+	// The statuses of the input are aggregated into the health of the beat.
+	reporter, unregisterHealth := status.DefaultHealth.Register(r.id, name, r.statusReporter)
+	r.unregisterHealth = unregisterHealth
+
+	// The input is reported as degraded while its ingest lag exceeds the SLO.
+	if r.slo.MaxLag > 0 {
+		slo := newSLOReporter(log, r.slo.MaxLag, reporter)
+		reporter = slo
+		connector = slo.connector(connector)
+		go slo.run(done)
+	}
+
 	go func() {
 		defer r.wg.Done()
+		defer close(done)
+		defer unregister()
 		log.Infof("Input '%s' starting", name)
 		err := r.input.Run(
 			v2.Context{
@@ -140,23 +217,109 @@ func (r *runner) Start() {
 				Agent:          *r.agent,
 				Logger:         log,
 				Cancelation:    r.sig,
-				StatusReporter: r.statusReporter,
+				StatusReporter: reporter,
 			},
-			r.connector,
+			connector,
 		)
 		if err != nil && !errors.Is(err, context.Canceled) {
 			log.Errorf("Input '%s' failed with: %+v", name, err)
+			reporter.UpdateStatus(status.Failed, err.Error())
 		} else {
 			log.Infof("Input '%s' stopped (goroutine)", name)
 		}
 	}()
 }
 
-func (r *runner) Stop() {
+// stop signals the input to stop and waits until it has returned. It is a
+// no-op if the input is not running. r.mu must be held.
+func (r *runner) stop() {
+	if !r.running {
+		return
+	}
 	r.sig.Cancel()
 	r.wg.Wait()
+	r.running = false
 	r.log.Infof("Input '%s' stopped (runner)", r.input.Name())
-	r.statusReporter = nil
+	if r.unregisterHealth != nil {
+		r.unregisterHealth()
+		r.unregisterHealth = nil
+	}
+}
+
+// controlledRunner exposes the lifecycle of a runner to the lifecycle
+// registry.
+type controlledRunner runner
+
+func (c *controlledRunner) Type() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.input.Name()
+}
+
+func (c *controlledRunner) Running() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.isRunning()
+}
+
+// isRunning returns true if the input was started and has not returned
+// yet. c.mu must be held.
+func (c *controlledRunner) isRunning() bool {
+	if !c.running {
+		return false
+	}
+	select {
+	case <-c.done:
+		return false
+	default:
+		return true
+	}
+}
+
+func (c *controlledRunner) Start() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.isRunning() {
+		return nil
+	}
+	// Clean up after an input that returned on its own.
+	(*runner)(c).stop()
+	(*runner)(c).start()
+	return nil
+}
+
+func (c *controlledRunner) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	(*runner)(c).stop()
+}
+
+func (c *controlledRunner) Reload() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	(*runner)(c).stop()
+	if err := c.loader.Delete(c.config); err != nil {
+		c.log.Warnf("Failed to delete input before reloading it: %v", err)
+	}
+	input, err := c.loader.Configure(c.config)
+	if err != nil {
+		return fmt.Errorf("failed to create input: %w", err)
+	}
+	c.input = input
+	(*runner)(c).start()
+	return nil
+}
+
+func (c *controlledRunner) Cursors() (map[string]interface{}, bool) {
+	c.mu.Lock()
+	reporter, ok := c.input.(v2.CursorReporter)
+	c.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	return reporter.Cursors(), true
 }
 
 func configID(config *conf.C) (string, error) {
@@ -21,12 +21,14 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/management/lifecycle"
 	"github.com/elastic/elastic-agent-libs/logp"
 
 	v2 "github.com/elastic/beats/v7/filebeat/input/v2"
@@ -172,6 +174,57 @@ func TestRunnerFactory_CreateAndRun(t *testing.T) {
 		assert.Equal(t, 1, countRun)
 	})
 
+	t.Run("runner can be controlled through the lifecycle registry", func(t *testing.T) {
+		log := logp.NewLogger("test")
+		var countConfigure, countRun atomic.Int32
+		plugins := inputest.SinglePlugin("test", &inputest.MockInputManager{
+			OnConfigure: func(_ *conf.C) (v2.Input, error) {
+				countConfigure.Add(1)
+				return &inputest.MockInput{
+					Type: "test",
+					OnRun: func(ctx v2.Context, _ beat.PipelineConnector) error {
+						countRun.Add(1)
+						<-ctx.Cancelation.Done()
+						return nil
+					},
+				}, nil
+			},
+		})
+		loader := inputest.MustNewTestLoader(t, plugins, "type", "test")
+		factory := RunnerFactory(log, beat.Info{}, loader.Loader)
+
+		runner, err := factory.Create(nil, conf.MustNewConfigFrom(map[string]interface{}{
+			"type": "test",
+			"id":   "lifecycle-test",
+		}))
+		require.NoError(t, err)
+		assert.Nil(t, lifecycle.Default.Get("lifecycle-test"))
+
+		runner.Start()
+		input := lifecycle.Default.Get("lifecycle-test")
+		require.NotNil(t, input)
+		assert.Equal(t, "test", input.Type())
+		assert.True(t, input.Running())
+
+		input.Stop()
+		assert.False(t, input.Running())
+
+		require.NoError(t, input.Start())
+		assert.True(t, input.Running())
+
+		require.NoError(t, input.Reload())
+		assert.True(t, input.Running())
+		assert.Equal(t, int32(2), countConfigure.Load())
+
+		_, ok := input.Cursors()
+		assert.False(t, ok, "mock input does not report cursors")
+
+		runner.Stop()
+		assert.False(t, input.Running())
+		assert.Nil(t, lifecycle.Default.Get("lifecycle-test"))
+		assert.Equal(t, int32(3), countRun.Load())
+	})
+
 	t.Run("fail if input type is unknown to loader", func(t *testing.T) {
 		log := logp.NewLogger("test")
 		plugins := inputest.SinglePlugin("test", inputest.ConstInputManager(nil))
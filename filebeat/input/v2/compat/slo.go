@@ -0,0 +1,255 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package compat
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/acker"
+	"github.com/elastic/beats/v7/libbeat/management/status"
+	"github.com/elastic/beats/v7/libbeat/publisher/pipetool"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+const (
+	// sloReportInterval is how often the status of an input is updated
+	// with the current lag while it exceeds its SLO.
+	sloReportInterval = time.Minute
+
+	// sloCheckInterval is how often the age of the oldest pending event is
+	// checked, so that the SLO is also violated when no event is
+	// acknowledged anymore.
+	sloCheckInterval = 10 * time.Second
+)
+
+// sloConfig is the ingest lag SLO of an input.
+type sloConfig struct {
+	// MaxLag is the maximum time between the timestamp of an event and
+	// its acknowledgement by the output. The SLO is disabled when zero.
+	MaxLag time.Duration `config:"slo.max_lag" validate:"min=0"`
+}
+
+// sloReporter tracks the ingest lag of an input and reports it as degraded
+// while the lag exceeds the SLO. Otherwise the status reported by the input
+// itself is passed through.
+type sloReporter struct {
+	log    *logp.Logger
+	maxLag time.Duration
+	now    func() time.Time
+
+	mu         sync.Mutex
+	reporter   status.StatusReporter
+	status     status.Status // Last status reported by the input.
+	msg        string
+	lag        time.Duration
+	violated   bool
+	lastReport time.Time
+	listeners  map[*lagListener]struct{}
+}
+
+func newSLOReporter(log *logp.Logger, maxLag time.Duration, reporter status.StatusReporter) *sloReporter {
+	return &sloReporter{
+		log:       log,
+		maxLag:    maxLag,
+		now:       time.Now,
+		reporter:  reporter,
+		status:    status.Unknown,
+		listeners: map[*lagListener]struct{}{},
+	}
+}
+
+// run checks the pending events every sloCheckInterval until done is
+// closed.
+func (r *sloReporter) run(done <-chan struct{}) {
+	ticker := time.NewTicker(sloCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			r.check()
+		}
+	}
+}
+
+// check raises the lag of the input to the age of its oldest pending event.
+// Events stuck in the pipeline are never acknowledged, so their lag would
+// otherwise not be noticed. The lag is only lowered by acknowledgements.
+func (r *sloReporter) check() {
+	r.mu.Lock()
+	listeners := make([]*lagListener, 0, len(r.listeners))
+	for l := range r.listeners {
+		listeners = append(listeners, l)
+	}
+	r.mu.Unlock()
+
+	var oldest time.Time
+	for _, l := range listeners {
+		if ts := l.oldest(); !ts.IsZero() && (oldest.IsZero() || ts.Before(oldest)) {
+			oldest = ts
+		}
+	}
+	if oldest.IsZero() {
+		return
+	}
+
+	r.mu.Lock()
+	lag := r.now().Sub(oldest)
+	raised := lag > r.lag
+	r.mu.Unlock()
+	if raised {
+		r.observe(lag)
+	}
+}
+
+// UpdateStatus records the status of the input and reports it unless the
+// input is degraded by its lag.
+func (r *sloReporter) UpdateStatus(s status.Status, msg string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status, r.msg = s, msg
+	r.report()
+}
+
+// observe updates the lag of the input with the lag of the last
+// acknowledged event.
+func (r *sloReporter) observe(lag time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lag = lag
+	violated := lag > r.maxLag
+	switch {
+	case violated && !r.violated:
+		r.log.Warnf("Ingest lag of %v exceeds the SLO of %v", lag.Round(time.Second), r.maxLag)
+	case !violated && r.violated:
+		r.log.Infof("Ingest lag of %v is back within the SLO of %v", lag.Round(time.Second), r.maxLag)
+	case violated && r.now().Sub(r.lastReport) >= sloReportInterval:
+		// Refresh the lag in the status message.
+	default:
+		return
+	}
+	r.violated = violated
+	r.report()
+}
+
+func (r *sloReporter) report() {
+	if r.reporter == nil {
+		return
+	}
+	r.lastReport = r.now()
+
+	// The SLO only degrades inputs that consider themselves healthy.
+	healthy := r.status == status.Unknown || r.status == status.Running
+	if r.violated && healthy {
+		r.reporter.UpdateStatus(status.Degraded, fmt.Sprintf("Ingest lag of %v exceeds the SLO of %v", r.lag.Round(time.Second), r.maxLag))
+		return
+	}
+	if r.status == status.Unknown {
+		r.reporter.UpdateStatus(status.Running, "")
+		return
+	}
+	r.reporter.UpdateStatus(r.status, r.msg)
+}
+
+// connector returns a pipeline connector whose clients measure the lag of
+// the events they publish.
+func (r *sloReporter) connector(pipeline beat.PipelineConnector) beat.PipelineConnector {
+	return pipetool.WithClientConfigEdit(pipeline, func(cfg beat.ClientConfig) (beat.ClientConfig, error) {
+		// Each client acknowledges its events in order, so it needs its
+		// own listener.
+		listener := r.newListener()
+		if cfg.EventListener != nil {
+			cfg.EventListener = acker.Combine(listener, cfg.EventListener)
+		} else {
+			cfg.EventListener = listener
+		}
+		return cfg, nil
+	})
+}
+
+func (r *sloReporter) newListener() *lagListener {
+	l := &lagListener{reporter: r}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listeners[l] = struct{}{}
+	return l
+}
+
+// lagListener computes the lag of the events of a client when they are
+// acknowledged.
+type lagListener struct {
+	reporter *sloReporter
+
+	mu         sync.Mutex
+	timestamps []time.Time // Timestamps of the pending events, in order.
+}
+
+func (l *lagListener) AddEvent(event beat.Event, published bool) {
+	if !published {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.timestamps = append(l.timestamps, event.Timestamp)
+}
+
+func (l *lagListener) ACKEvents(n int) {
+	if n <= 0 {
+		return
+	}
+	l.mu.Lock()
+	if n > len(l.timestamps) {
+		n = len(l.timestamps)
+	}
+	if n == 0 {
+		l.mu.Unlock()
+		return
+	}
+	last := l.timestamps[n-1]
+	l.timestamps = l.timestamps[n:]
+	l.mu.Unlock()
+
+	if !last.IsZero() {
+		l.reporter.observe(l.reporter.now().Sub(last))
+	}
+}
+
+// oldest returns the oldest timestamp of the pending events, zero if there
+// is none.
+func (l *lagListener) oldest() time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var oldest time.Time
+	for _, ts := range l.timestamps {
+		if !ts.IsZero() && (oldest.IsZero() || ts.Before(oldest)) {
+			oldest = ts
+		}
+	}
+	return oldest
+}
+
+func (l *lagListener) ClientClosed() {
+	l.reporter.mu.Lock()
+	defer l.reporter.mu.Unlock()
+	delete(l.reporter.listeners, l)
+}
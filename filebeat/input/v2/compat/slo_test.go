@@ -0,0 +1,153 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package compat
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/management/status"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+type statusUpdate struct {
+	status status.Status
+	msg    string
+}
+
+type recordingReporter struct {
+	mu      sync.Mutex
+	updates []statusUpdate
+}
+
+func (r *recordingReporter) UpdateStatus(s status.Status, msg string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.updates = append(r.updates, statusUpdate{s, msg})
+}
+
+func (r *recordingReporter) last() statusUpdate {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.updates) == 0 {
+		return statusUpdate{status: status.Unknown}
+	}
+	return r.updates[len(r.updates)-1]
+}
+
+func (r *recordingReporter) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.updates)
+}
+
+func TestSLOReporter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	rec := &recordingReporter{}
+	slo := newSLOReporter(logp.NewLogger("test"), 5*time.Minute, rec)
+	slo.now = func() time.Time { return now }
+
+	slo.UpdateStatus(status.Running, "")
+	assert.Equal(t, statusUpdate{status.Running, ""}, rec.last())
+
+	// Within the SLO, nothing is reported.
+	listener := slo.newListener()
+	listener.AddEvent(beat.Event{Timestamp: now.Add(-time.Minute)}, true)
+	listener.ACKEvents(1)
+	assert.Equal(t, 1, rec.count())
+
+	// Events that were not published are not tracked.
+	listener.AddEvent(beat.Event{Timestamp: now.Add(-time.Hour)}, false)
+	listener.AddEvent(beat.Event{Timestamp: now.Add(-10 * time.Minute)}, true)
+	listener.AddEvent(beat.Event{Timestamp: now.Add(-7*time.Minute - 30*time.Second)}, true)
+	listener.ACKEvents(2)
+	assert.Equal(t, statusUpdate{status.Degraded, "Ingest lag of 7m30s exceeds the SLO of 5m0s"}, rec.last())
+	assert.Equal(t, 2, rec.count())
+
+	// The status is refreshed at most once per interval while violated.
+	listener.AddEvent(beat.Event{Timestamp: now.Add(-8 * time.Minute)}, true)
+	listener.ACKEvents(1)
+	assert.Equal(t, 2, rec.count())
+	now = now.Add(sloReportInterval)
+	listener.AddEvent(beat.Event{Timestamp: now.Add(-9 * time.Minute)}, true)
+	listener.ACKEvents(1)
+	assert.Equal(t, statusUpdate{status.Degraded, "Ingest lag of 9m0s exceeds the SLO of 5m0s"}, rec.last())
+
+	// A failed input is not masked by the SLO.
+	slo.UpdateStatus(status.Failed, "boom")
+	assert.Equal(t, statusUpdate{status.Failed, "boom"}, rec.last())
+	slo.UpdateStatus(status.Running, "")
+	assert.Equal(t, status.Degraded, rec.last().status)
+
+	// The status of the input is restored when the lag recovers.
+	listener.AddEvent(beat.Event{Timestamp: now.Add(-time.Second)}, true)
+	listener.ACKEvents(1)
+	assert.Equal(t, statusUpdate{status.Running, ""}, rec.last())
+}
+
+func TestLagListenerIgnoresMissingTimestamps(t *testing.T) {
+	rec := &recordingReporter{}
+	slo := newSLOReporter(logp.NewLogger("test"), time.Minute, rec)
+
+	listener := &lagListener{reporter: slo}
+	listener.AddEvent(beat.Event{}, true)
+	listener.ACKEvents(1)
+	// More ACKs than pending events.
+	listener.ACKEvents(3)
+	assert.Zero(t, rec.count())
+	assert.Empty(t, listener.timestamps)
+}
+
+func TestSLOReporterChecksPendingEvents(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	rec := &recordingReporter{}
+	slo := newSLOReporter(logp.NewLogger("test"), 5*time.Minute, rec)
+	slo.now = func() time.Time { return now }
+	slo.UpdateStatus(status.Running, "")
+
+	// Events that are never acknowledged violate the SLO once they are
+	// older than the maximum lag.
+	stuck := slo.newListener()
+	other := slo.newListener()
+	stuck.AddEvent(beat.Event{}, true)
+	stuck.AddEvent(beat.Event{Timestamp: now.Add(-time.Minute)}, true)
+	other.AddEvent(beat.Event{Timestamp: now.Add(-2 * time.Minute)}, true)
+	slo.check()
+	assert.Equal(t, statusUpdate{status.Running, ""}, rec.last())
+
+	now = now.Add(4 * time.Minute)
+	slo.check()
+	assert.Equal(t, statusUpdate{status.Degraded, "Ingest lag of 6m0s exceeds the SLO of 5m0s"}, rec.last())
+
+	// Acknowledging the events brings the lag back within the SLO.
+	other.ACKEvents(1)
+	stuck.ACKEvents(2)
+	assert.Equal(t, statusUpdate{status.Running, ""}, rec.last())
+	slo.check()
+	assert.Equal(t, statusUpdate{status.Running, ""}, rec.last())
+
+	// Closed clients are no longer checked.
+	stuck.AddEvent(beat.Event{Timestamp: now.Add(-time.Hour)}, true)
+	stuck.ClientClosed()
+	slo.check()
+	assert.Equal(t, statusUpdate{status.Running, ""}, rec.last())
+}
@@ -0,0 +1,147 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cursor
+
+import (
+	"sync"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/statestore"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+// checkpointConfig configures when the cursor updates of ACKed events are
+// written to the persistent store.
+type checkpointConfig struct {
+	// Interval is the maximum time ACKed cursor updates are kept in memory
+	// before being written. Updates are written as soon as their events are
+	// ACKed if the interval is 0.
+	Interval time.Duration `config:"interval" validate:"min=0"`
+
+	// MaxPending is the number of ACKed cursor updates that triggers a write
+	// before the interval has elapsed.
+	MaxPending uint `config:"max_pending" validate:"min=1"`
+
+	// Fsync syncs the persistent store to disk after each write.
+	Fsync bool `config:"fsync"`
+}
+
+func defaultCheckpointConfig() checkpointConfig {
+	return checkpointConfig{
+		Interval:   0,
+		MaxPending: 1024,
+		Fsync:      false,
+	}
+}
+
+// checkpointer collects the update operations of ACKed events and executes
+// them according to the checkpoint configuration. Only the most recent
+// update operation is executed, releasing all older operations of the
+// same resource.
+type checkpointer struct {
+	log    *logp.Logger
+	config checkpointConfig
+
+	mu      sync.Mutex
+	op      *updateOp
+	pending uint
+	closed  bool
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newCheckpointer(log *logp.Logger, config checkpointConfig) *checkpointer {
+	c := &checkpointer{
+		log:    log,
+		config: config,
+		done:   make(chan struct{}),
+	}
+	if config.Interval > 0 {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			c.run()
+		}()
+	}
+	return c
+}
+
+func (c *checkpointer) run() {
+	ticker := time.NewTicker(c.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.mu.Lock()
+			c.flush()
+			c.mu.Unlock()
+		}
+	}
+}
+
+// ack schedules op and the n-1 operations preceding it for execution. The
+// operations are executed immediately if no interval is configured, if too
+// many operations are pending or if the checkpointer has been closed.
+func (c *checkpointer) ack(op *updateOp, n uint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.op != nil && c.op.resource != op.resource {
+		c.flush()
+	}
+	c.op = op
+	c.pending += n
+
+	if c.closed || c.config.Interval <= 0 || c.pending >= c.config.MaxPending {
+		c.flush()
+	}
+}
+
+// flush executes the pending operations. The lock must be held by the caller.
+func (c *checkpointer) flush() {
+	if c.op == nil {
+		return
+	}
+
+	op, n := c.op, c.pending
+	c.op, c.pending = nil, 0
+
+	store := op.store
+	op.Execute(n)
+	if c.config.Fsync {
+		if err := store.persistentStore.Sync(); err != nil && !statestore.IsClosed(err) {
+			c.log.Errorf("Failed to sync the registry: %v", err)
+		}
+	}
+}
+
+// close stops the periodic writes and executes the pending operations.
+// Operations ACKed after close are executed immediately.
+func (c *checkpointer) close() {
+	c.mu.Lock()
+	c.closed = true
+	c.flush()
+	c.mu.Unlock()
+
+	close(c.done)
+	c.wg.Wait()
+}
@@ -0,0 +1,121 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cursor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+func TestCheckpointer(t *testing.T) {
+	persistedCursor := func(ts testStateStore) interface{} {
+		return ts.snapshot()["test::key"].Cursor
+	}
+
+	t.Run("write updates immediately by default", func(t *testing.T) {
+		ts := createSampleStore(t, nil)
+		store := testOpenStore(t, "test", ts)
+		defer store.Release()
+		res := store.Get("test::key")
+
+		c := newCheckpointer(logp.NewLogger("test"), defaultCheckpointConfig())
+		defer c.close()
+
+		op := mustCreateUpdateOp(t, store, res, "updated")
+		res.Release()
+		c.ack(op, 1)
+
+		assert.Equal(t, "updated", persistedCursor(ts))
+		assert.True(t, res.Finished())
+	})
+
+	t.Run("write updates once max_pending is reached", func(t *testing.T) {
+		ts := createSampleStore(t, nil)
+		store := testOpenStore(t, "test", ts)
+		defer store.Release()
+		res := store.Get("test::key")
+
+		c := newCheckpointer(logp.NewLogger("test"), checkpointConfig{Interval: time.Hour, MaxPending: 3})
+		defer c.close()
+
+		op1 := mustCreateUpdateOp(t, store, res, "first")
+		op2 := mustCreateUpdateOp(t, store, res, "second")
+		op3 := mustCreateUpdateOp(t, store, res, "third")
+		op4 := mustCreateUpdateOp(t, store, res, "fourth")
+		res.Release()
+
+		c.ack(op1, 1)
+		c.ack(op2, 1)
+		assert.Nil(t, persistedCursor(ts))
+		assert.False(t, res.Finished())
+
+		c.ack(op3, 1)
+		assert.Equal(t, "third", persistedCursor(ts))
+		assert.False(t, res.Finished())
+
+		c.ack(op4, 1)
+		assert.Equal(t, "third", persistedCursor(ts))
+		assert.Equal(t, "fourth", storeMemorySnapshot(store)["test::key"].Cursor)
+	})
+
+	t.Run("write updates periodically", func(t *testing.T) {
+		ts := createSampleStore(t, nil)
+		store := testOpenStore(t, "test", ts)
+		defer store.Release()
+		res := store.Get("test::key")
+
+		c := newCheckpointer(logp.NewLogger("test"), checkpointConfig{Interval: 10 * time.Millisecond, MaxPending: 100, Fsync: true})
+		defer c.close()
+
+		op := mustCreateUpdateOp(t, store, res, "updated")
+		res.Release()
+		c.ack(op, 1)
+
+		require.Eventually(t, res.Finished, 5*time.Second, time.Millisecond)
+		assert.Equal(t, "updated", persistedCursor(ts))
+	})
+
+	t.Run("close writes pending updates", func(t *testing.T) {
+		ts := createSampleStore(t, nil)
+		store := testOpenStore(t, "test", ts)
+		defer store.Release()
+		res := store.Get("test::key")
+
+		c := newCheckpointer(logp.NewLogger("test"), checkpointConfig{Interval: time.Hour, MaxPending: 100})
+
+		op1 := mustCreateUpdateOp(t, store, res, "first")
+		op2 := mustCreateUpdateOp(t, store, res, "second")
+		res.Release()
+
+		c.ack(op1, 1)
+		assert.Nil(t, persistedCursor(ts))
+
+		c.close()
+		assert.Equal(t, "first", persistedCursor(ts))
+
+		// updates ACKed after close are written immediately
+		c.ack(op2, 1)
+		assert.Equal(t, "second", persistedCursor(ts))
+		assert.True(t, res.Finished())
+	})
+}
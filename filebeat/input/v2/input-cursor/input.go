@@ -30,7 +30,7 @@ import (
 	input "github.com/elastic/beats/v7/filebeat/input/v2"
 	"github.com/elastic/beats/v7/libbeat/beat"
 	"github.com/elastic/beats/v7/libbeat/common/acker"
-	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
 )
 
 // Input interface for cursor based inputs. This interface must be implemented
@@ -63,6 +63,7 @@ type managedInput struct {
 	sources      []Source
 	input        Input
 	cleanTimeout time.Duration
+	checkpoint   checkpointConfig
 }
 
 // Name is required to implement the v2.Input interface
@@ -146,10 +147,12 @@ func (inp *managedInput) runSource(
 		}
 	}()
 
+	checkpointer := newCheckpointer(ctx.Logger, inp.checkpoint)
 	client, err := pipeline.ConnectWith(beat.ClientConfig{
-		EventListener: newInputACKHandler(ctx.Logger),
+		EventListener: newInputACKHandler(checkpointer),
 	})
 	if err != nil {
+		checkpointer.close()
 		return err
 	}
 	defer client.Close()
@@ -157,10 +160,15 @@ func (inp *managedInput) runSource(
 	resourceKey := inp.createSourceID(source)
 	resource, err := inp.manager.lock(ctx, resourceKey)
 	if err != nil {
+		checkpointer.close()
 		return err
 	}
 	defer releaseResource(resource)
 
+	// Write the pending cursor updates before another input can take over
+	// the resource.
+	defer checkpointer.close()
+
 	store.UpdateTTL(resource, inp.cleanTimeout)
 
 	cursor := makeCursor(store, resource)
@@ -175,7 +183,39 @@ func (inp *managedInput) createSourceID(s Source) string {
 	return fmt.Sprintf("%v::%v", inp.manager.Type, s.Name())
 }
 
-func newInputACKHandler(log *logp.Logger) beat.EventListener {
+// Cursors reports the ACKed cursor of each configured source that has
+// state in the registry, and the most recent cursor update that has not
+// been written to the registry yet.
+func (inp *managedInput) Cursors() map[string]interface{} {
+	cursors := map[string]interface{}{}
+	for _, source := range inp.sources {
+		key := inp.createSourceID(source)
+		resource := inp.manager.store.ephemeralStore.Find(key, false)
+		if resource == nil {
+			continue
+		}
+		resource.stateMutex.Lock()
+		st := resource.inSyncStateSnapshot()
+		var pending interface{}
+		if resource.activeCursorOperations > 0 {
+			pending = resource.pendingCursor
+		}
+		resource.stateMutex.Unlock()
+		resource.Release()
+
+		if st.Cursor == nil && pending == nil {
+			continue
+		}
+		cursor := mapstr.M{"cursor": st.Cursor, "updated": st.Updated}
+		if pending != nil {
+			cursor["pending"] = pending
+		}
+		cursors[key] = cursor
+	}
+	return cursors
+}
+
+func newInputACKHandler(checkpointer *checkpointer) beat.EventListener {
 	return acker.EventPrivateReporter(func(acked int, private []interface{}) {
 		var n uint
 		var last int
@@ -196,6 +236,6 @@ func newInputACKHandler(log *logp.Logger) beat.EventListener {
 		if n == 0 {
 			return
 		}
-		private[last].(*updateOp).Execute(n)
+		checkpointer.ack(private[last].(*updateOp), n)
 	})
 }
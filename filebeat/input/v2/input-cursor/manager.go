@@ -148,9 +148,10 @@ func (cim *InputManager) Create(config *conf.C) (v2.Input, error) {
 	}
 
 	settings := struct {
-		ID            string        `config:"id"`
-		CleanInactive time.Duration `config:"clean_inactive"`
-	}{ID: "", CleanInactive: cim.DefaultCleanTimeout}
+		ID            string           `config:"id"`
+		CleanInactive time.Duration    `config:"clean_inactive"`
+		Checkpoint    checkpointConfig `config:"checkpoint"`
+	}{ID: "", CleanInactive: cim.DefaultCleanTimeout, Checkpoint: defaultCheckpointConfig()}
 	if err := config.Unpack(&settings); err != nil {
 		return nil, err
 	}
@@ -172,6 +173,7 @@ func (cim *InputManager) Create(config *conf.C) (v2.Input, error) {
 		sources:      sources,
 		input:        inp,
 		cleanTimeout: settings.CleanInactive,
+		checkpoint:   settings.Checkpoint,
 	}, nil
 }
 
@@ -128,6 +128,14 @@ func TestManager_Create(t *testing.T) {
 		require.NoError(t, err)
 	})
 
+	t.Run("fail if checkpoint config is invalid", func(t *testing.T) {
+		manager := constInput(t, sourceList("test"), &fakeTestInput{})
+		_, err := manager.Create(conf.MustNewConfigFrom(map[string]interface{}{
+			"checkpoint.max_pending": 0,
+		}))
+		require.Error(t, err)
+	})
+
 	t.Run("configuring inputs with overlapping sources is allowed", func(t *testing.T) {
 		manager := simpleManagerWithConfigure(t, func(cfg *conf.C) ([]Source, Input, error) {
 			config := struct{ Sources []string }{}
@@ -406,6 +414,31 @@ func TestManager_InputsRun(t *testing.T) {
 		assert.Equal(t, []int{0, 1, 2, 3, 4, 5}, ids)
 	})
 
+	t.Run("report cursors of sources", func(t *testing.T) {
+		manager := constInput(t, sourceList("a", "b"), &fakeTestInput{
+			OnRun: func(_ input.Context, source Source, _ Cursor, pub Publisher) error {
+				if source.Name() == "b" {
+					return nil
+				}
+				return pub.Publish(beat.Event{}, map[string]interface{}{"offset": 42})
+			},
+		})
+
+		inp, err := manager.Create(conf.NewConfig())
+		require.NoError(t, err)
+		require.NoError(t, inp.Run(input.Context{
+			Logger:      logp.NewLogger("test"),
+			Cancelation: context.Background(),
+		}, pubtest.ConstClient(&pubtest.FakeClient{})))
+
+		cursors := inp.(input.CursorReporter).Cursors()
+		require.Len(t, cursors, 1)
+		cursor := cursors["test::a"].(mapstr.M)
+		// The fake client does not ACK events, the update is pending.
+		assert.Nil(t, cursor["cursor"])
+		assert.Equal(t, map[string]interface{}{"offset": int64(42)}, cursor["pending"])
+	})
+
 	t.Run("event ACK triggers execution of update operations", func(t *testing.T) {
 		defer resources.NewGoroutinesChecker().Check(t)
 
@@ -58,6 +58,23 @@ type updateOp struct {
 	delta     interface{}
 }
 
+// positioner is implemented by cursor states that identify the position of
+// the published event in the resource, e.g. its offset.
+type positioner interface {
+	Position() string
+}
+
+// IdempotencyKey returns the key of the resource followed by the position of
+// the event in it, or an empty string if the cursor state has no position.
+// It implements beat.IdempotencyKeyer.
+func (op *updateOp) IdempotencyKey() string {
+	pos, ok := op.delta.(positioner)
+	if !ok || op.resource == nil {
+		return ""
+	}
+	return op.resource.key + "::" + pos.Position()
+}
+
 // Publish publishes an event. Publish returns false if the inputs cancellation context has been marked as done.
 // If cursorUpdate is not nil, Publish updates the in memory state and create and updateOp for the pending update.
 // It overwrite event.Private with the update operation, before finally sending the event.
@@ -69,6 +69,12 @@ type Input interface {
 	Run(Context, beat.PipelineConnector) error
 }
 
+// CursorReporter is implemented by inputs that can report the state they
+// persist in the registry, keyed by the registry key of each source.
+type CursorReporter interface {
+	Cursors() map[string]interface{}
+}
+
 // Context provides the Input Run function with common environmental
 // information and services.
 type Context struct {
@@ -0,0 +1,139 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package winlog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/statestore"
+	"github.com/elastic/beats/v7/winlogbeat/checkpoint"
+)
+
+// bookmarksVersion is the version of the bookmarks file format.
+const bookmarksVersion = 1
+
+// BookmarksFile is the content of a file created by ExportBookmarks.
+type BookmarksFile struct {
+	Version    int        `json:"version"`
+	ExportedAt time.Time  `json:"exported_at"`
+	Bookmarks  []Bookmark `json:"bookmarks"`
+}
+
+// Bookmark is the checkpoint of a single winlog input source.
+type Bookmark struct {
+	// Key is the registry key of the source, it is derived from the input
+	// ID and the event log name.
+	Key          string        `json:"key"`
+	Name         string        `json:"name"`
+	RecordNumber uint64        `json:"record_number"`
+	Timestamp    time.Time     `json:"timestamp"`
+	Bookmark     string        `json:"bookmark,omitempty"`
+	TTL          time.Duration `json:"ttl"`
+}
+
+// registryState mirrors the document written to the registry by the cursor
+// input manager for winlog sources.
+type registryState struct {
+	TTL     time.Duration
+	Updated time.Time
+	Cursor  checkpoint.EventLogState
+}
+
+// ExportBookmarks returns the checkpoints of all winlog sources found in
+// store, sorted by key.
+func ExportBookmarks(store *statestore.Store) (BookmarksFile, error) {
+	f := BookmarksFile{
+		Version:    bookmarksVersion,
+		ExportedAt: time.Now().UTC(),
+		Bookmarks:  []Bookmark{},
+	}
+
+	keyPrefix := pluginName + "::"
+	err := store.Each(func(key string, dec statestore.ValueDecoder) (bool, error) {
+		if !strings.HasPrefix(key, keyPrefix) {
+			return true, nil
+		}
+
+		var st registryState
+		if err := dec.Decode(&st); err != nil {
+			return false, fmt.Errorf("failed to read registry state for %q: %w", key, err)
+		}
+		f.Bookmarks = append(f.Bookmarks, Bookmark{
+			Key:          key,
+			Name:         st.Cursor.Name,
+			RecordNumber: st.Cursor.RecordNumber,
+			Timestamp:    st.Cursor.Timestamp,
+			Bookmark:     st.Cursor.Bookmark,
+			TTL:          st.TTL,
+		})
+		return true, nil
+	})
+	if err != nil {
+		return f, err
+	}
+
+	sort.Slice(f.Bookmarks, func(i, j int) bool { return f.Bookmarks[i].Key < f.Bookmarks[j].Key })
+	return f, nil
+}
+
+// ImportBookmarks writes the checkpoints of f to store. Sources that already
+// have a checkpoint are only overwritten if overwrite is set. It returns the
+// keys of the imported and the skipped sources.
+func ImportBookmarks(store *statestore.Store, f BookmarksFile, overwrite bool) (imported, skipped []string, err error) {
+	if f.Version != bookmarksVersion {
+		return nil, nil, fmt.Errorf("unsupported bookmarks file version %d", f.Version)
+	}
+
+	now := time.Now().UTC()
+	for _, b := range f.Bookmarks {
+		if !strings.HasPrefix(b.Key, pluginName+"::") {
+			return imported, skipped, fmt.Errorf("invalid bookmark key %q", b.Key)
+		}
+		if b.Name == "" {
+			return imported, skipped, fmt.Errorf("bookmark %q has no event log name", b.Key)
+		}
+
+		exists, err := store.Has(b.Key)
+		if err != nil {
+			return imported, skipped, fmt.Errorf("failed to read registry state for %q: %w", b.Key, err)
+		}
+		if exists && !overwrite {
+			skipped = append(skipped, b.Key)
+			continue
+		}
+
+		st := registryState{
+			TTL:     b.TTL,
+			Updated: now,
+			Cursor: checkpoint.EventLogState{
+				Name:         b.Name,
+				RecordNumber: b.RecordNumber,
+				Timestamp:    b.Timestamp,
+				Bookmark:     b.Bookmark,
+			},
+		}
+		if err := store.Set(b.Key, st); err != nil {
+			return imported, skipped, fmt.Errorf("failed to write registry state for %q: %w", b.Key, err)
+		}
+		imported = append(imported, b.Key)
+	}
+	return imported, skipped, nil
+}
@@ -0,0 +1,111 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package winlog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/statestore"
+	"github.com/elastic/beats/v7/libbeat/statestore/storetest"
+	"github.com/elastic/beats/v7/winlogbeat/checkpoint"
+)
+
+func newTestStore(t *testing.T) *statestore.Store {
+	t.Helper()
+	registry := statestore.NewRegistry(storetest.NewMemoryStoreBackend())
+	t.Cleanup(func() { registry.Close() })
+	store, err := registry.Get("filebeat")
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestBookmarksRoundTrip(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	src := newTestStore(t)
+	// Same layout as written by the cursor input manager.
+	require.NoError(t, src.Set("winlog::ForwardedEvents", struct {
+		TTL     time.Duration
+		Updated time.Time
+		Cursor  interface{}
+	}{
+		TTL:     -1,
+		Updated: ts,
+		Cursor: checkpoint.EventLogState{
+			Name:         "ForwardedEvents",
+			RecordNumber: 42,
+			Timestamp:    ts,
+			Bookmark:     "<BookmarkList/>",
+		},
+	}))
+	require.NoError(t, src.Set("filestream::other", map[string]interface{}{"cursor": nil}))
+
+	f, err := ExportBookmarks(src)
+	require.NoError(t, err)
+	require.Len(t, f.Bookmarks, 1)
+	assert.Equal(t, Bookmark{
+		Key:          "winlog::ForwardedEvents",
+		Name:         "ForwardedEvents",
+		RecordNumber: 42,
+		Timestamp:    ts,
+		Bookmark:     "<BookmarkList/>",
+		TTL:          -1,
+	}, f.Bookmarks[0])
+
+	dst := newTestStore(t)
+	imported, skipped, err := ImportBookmarks(dst, f, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"winlog::ForwardedEvents"}, imported)
+	assert.Empty(t, skipped)
+
+	var st registryState
+	require.NoError(t, dst.Get("winlog::ForwardedEvents", &st))
+	assert.Equal(t, uint64(42), st.Cursor.RecordNumber)
+	assert.Equal(t, "<BookmarkList/>", st.Cursor.Bookmark)
+	assert.Equal(t, time.Duration(-1), st.TTL)
+
+	// Existing checkpoints are only replaced when overwrite is set.
+	f.Bookmarks[0].RecordNumber = 50
+	imported, skipped, err = ImportBookmarks(dst, f, false)
+	require.NoError(t, err)
+	assert.Empty(t, imported)
+	assert.Equal(t, []string{"winlog::ForwardedEvents"}, skipped)
+
+	_, _, err = ImportBookmarks(dst, f, true)
+	require.NoError(t, err)
+	require.NoError(t, dst.Get("winlog::ForwardedEvents", &st))
+	assert.Equal(t, uint64(50), st.Cursor.RecordNumber)
+}
+
+func TestImportBookmarksInvalid(t *testing.T) {
+	store := newTestStore(t)
+
+	_, _, err := ImportBookmarks(store, BookmarksFile{Version: 2}, false)
+	assert.ErrorContains(t, err, "unsupported bookmarks file version")
+
+	_, _, err = ImportBookmarks(store, BookmarksFile{
+		Version:   bookmarksVersion,
+		Bookmarks: []Bookmark{{Key: "filestream::foo", Name: "foo"}},
+	}, false)
+	assert.ErrorContains(t, err, "invalid bookmark key")
+}
@@ -169,7 +169,7 @@ runLoop:
 			}
 
 			for _, record := range records {
-				event := record.ToEvent()
+				event := record.ToFlatEvent()
 				if err := publisher.Publish(event, record.Offset); err != nil {
 					// Publisher indicates disconnect when returning an error.
 					// stop trying to publish records and quit
@@ -0,0 +1,79 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package winlog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/elastic/beats/v7/filebeat/lint"
+	win "github.com/elastic/beats/v7/winlogbeat/sys/wineventlog"
+	conf "github.com/elastic/elastic-agent-libs/config"
+)
+
+func init() {
+	lint.RegisterInputChecker(pluginName, checkChannel)
+}
+
+// checkChannel checks that the channel read by the input is registered on
+// the computer, or that the event log file exists.
+func checkChannel(cfg *conf.C) []lint.Finding {
+	var settings struct {
+		Name     string `config:"name"`
+		XMLQuery string `config:"xml_query"`
+	}
+	if err := cfg.Unpack(&settings); err != nil || settings.Name == "" || settings.XMLQuery != "" {
+		// Invalid configurations are reported by test config.
+		return nil
+	}
+
+	if filepath.IsAbs(settings.Name) {
+		if _, err := os.Stat(settings.Name); err != nil {
+			return []lint.Finding{{
+				Severity: lint.SeverityError,
+				Rule:     "winlog.file",
+				Path:     "name",
+				Message:  fmt.Sprintf("event log file %q can not be read: %v", settings.Name, err),
+			}}
+		}
+		return nil
+	}
+
+	channels, err := win.Channels()
+	if err != nil {
+		return []lint.Finding{{
+			Severity: lint.SeverityWarning,
+			Rule:     "winlog.channel",
+			Path:     "name",
+			Message:  fmt.Sprintf("failed to list the channels of the computer: %v", err),
+		}}
+	}
+	for _, channel := range channels {
+		if strings.EqualFold(channel, settings.Name) {
+			return nil
+		}
+	}
+	return []lint.Finding{{
+		Severity: lint.SeverityError,
+		Rule:     "winlog.channel",
+		Path:     "name",
+		Message:  fmt.Sprintf("channel %q is not registered on this computer", settings.Name),
+	}}
+}
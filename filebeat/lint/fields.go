@@ -0,0 +1,316 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/elastic/beats/v7/libbeat/mapping"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// fieldSet is a set of field keys. A field is in the set if it is one of the
+// keys, a group of keys, or a sub-field of a key, as object fields can hold
+// any sub-field.
+type fieldSet struct {
+	keys   map[string]bool
+	groups map[string]bool
+	// all is set when a processor can define any field, so references can
+	// not be checked anymore.
+	all bool
+}
+
+func newFieldSet() *fieldSet {
+	return &fieldSet{keys: map[string]bool{}, groups: map[string]bool{}}
+}
+
+func (s *fieldSet) add(key string) {
+	if key == "" {
+		s.all = true
+		return
+	}
+	s.keys[key] = true
+	for i := strings.LastIndexByte(key, '.'); i > 0; i = strings.LastIndexByte(key[:i], '.') {
+		s.groups[key[:i]] = true
+	}
+}
+
+func (s *fieldSet) has(key string) bool {
+	if s.all || s.keys[key] || s.groups[key] {
+		return true
+	}
+	for i := strings.LastIndexByte(key, '.'); i > 0; i = strings.LastIndexByte(key[:i], '.') {
+		if s.keys[key[:i]] {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *fieldSet) clone() *fieldSet {
+	c := newFieldSet()
+	c.all = s.all
+	for k := range s.keys {
+		c.keys[k] = true
+	}
+	for k := range s.groups {
+		c.groups[k] = true
+	}
+	return c
+}
+
+// knownFields are the fields of the beat, from its fields.yml.
+type knownFields struct {
+	fields *fieldSet
+}
+
+func loadKnownFields(data []byte) (*knownFields, error) {
+	fields, err := mapping.LoadFields(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the fields of the beat: %w", err)
+	}
+	set := newFieldSet()
+	for _, key := range fields.GetKeys() {
+		set.add(key)
+	}
+	set.add("@metadata")
+	return &knownFields{fields: set}, nil
+}
+
+// inputFields returns the custom fields set by the fields setting of an
+// input.
+func inputFields(cfg *conf.C) (*fieldSet, error) {
+	var settings struct {
+		Fields          mapstr.M `config:"fields"`
+		FieldsUnderRoot bool     `config:"fields_under_root"`
+	}
+	defined := newFieldSet()
+	if err := cfg.Unpack(&settings); err != nil {
+		return defined, err
+	}
+	for _, key := range flatKeys(settings.Fields) {
+		if settings.FieldsUnderRoot {
+			defined.add(key)
+		} else {
+			defined.add("fields." + key)
+		}
+	}
+	return defined, nil
+}
+
+// fieldRef is a field referenced by a processor, with the path of the
+// setting referencing it.
+type fieldRef struct {
+	path  string
+	field string
+}
+
+// checkProcessors checks that the fields referenced by the processors are
+// defined by the beat, the input or a previous processor.
+func (k *knownFields) checkProcessors(path string, processors []*conf.C, defined *fieldSet) []Finding {
+	if defined == nil {
+		defined = newFieldSet()
+	} else {
+		defined = defined.clone()
+	}
+
+	var findings []Finding
+	for i, cfg := range processors {
+		for _, name := range cfg.GetFields() {
+			procPath := joinPath(indexPath(path, i), name)
+			child, err := cfg.Child(name, -1)
+			if err != nil {
+				continue
+			}
+			refs, defines := processorFields(name, child)
+			if when, err := child.Child("when", -1); err == nil {
+				refs = append(refs, conditionFields("when", when)...)
+			}
+			for _, ref := range refs {
+				if strings.HasPrefix(ref.field, "/") || k.fields.has(ref.field) || defined.has(ref.field) {
+					continue
+				}
+				findings = append(findings, Finding{
+					Severity: SeverityWarning,
+					Rule:     "processors.unknown_field",
+					Path:     joinPath(procPath, ref.path),
+					Message: fmt.Sprintf("processor %s references the field %q, which is not defined by the beat, the input or a previous processor",
+						name, ref.field),
+				})
+			}
+			for _, key := range defines() {
+				defined.add(key)
+			}
+		}
+	}
+	return findings
+}
+
+// processorFields returns the fields referenced by a processor, and a
+// function returning the fields it defines.
+func processorFields(name string, cfg *conf.C) ([]fieldRef, func() []string) {
+	var settings struct {
+		Field        string   `config:"field"`
+		Fields       []string `config:"fields"`
+		Target       *string  `config:"target"`
+		TargetPrefix *string  `config:"target_prefix"`
+	}
+	var mappings struct {
+		Fields []struct {
+			From string `config:"from"`
+			To   string `config:"to"`
+		} `config:"fields"`
+	}
+	var addFields struct {
+		Target *string  `config:"target"`
+		Fields mapstr.M `config:"fields"`
+	}
+
+	var refs []fieldRef
+	defines := func() []string {
+		switch name {
+		case "rename", "copy_fields", "convert":
+			var keys []string
+			for _, m := range mappings.Fields {
+				if m.To != "" {
+					keys = append(keys, m.To)
+				}
+			}
+			return keys
+		case "add_fields":
+			target := "fields"
+			if addFields.Target != nil {
+				target = *addFields.Target
+			}
+			var keys []string
+			for _, key := range flatKeys(addFields.Fields) {
+				keys = append(keys, joinPath(target, key))
+			}
+			return keys
+		case "add_tags":
+			return []string{"tags"}
+		case "add_labels":
+			return []string{"labels"}
+		case "dissect":
+			if settings.TargetPrefix != nil {
+				return []string{*settings.TargetPrefix}
+			}
+			return []string{"dissect"}
+		case "decode_json_fields", "decode_avro", "decode_xml", "decode_xml_wineventlog", "decode_structured", "decode_protobuf", "decode_base64_field", "decode_cef", "decompress_gzip_field", "urldecode":
+			if settings.Target != nil {
+				return []string{*settings.Target}
+			}
+			return nil
+		case "script", "wasm", "include_fields", "extract_array", "syslog", "parse_aws_vpc_flow_log", "decode_duration":
+			// These can define any field.
+			return []string{""}
+		default:
+			return nil
+		}
+	}
+
+	if cfg.HasField("fields") {
+		if err := cfg.Unpack(&mappings); err == nil && len(mappings.Fields) > 0 && mappings.Fields[0].From != "" {
+			for i, m := range mappings.Fields {
+				refs = append(refs, fieldRef{path: joinPath(indexPath("fields", i), "from"), field: m.From})
+			}
+			return refs, defines
+		}
+		mappings.Fields = nil
+	}
+	if name == "add_fields" {
+		_ = cfg.Unpack(&addFields)
+		return nil, defines
+	}
+	if err := cfg.Unpack(&settings); err != nil {
+		return nil, defines
+	}
+	if settings.Field != "" {
+		refs = append(refs, fieldRef{path: "field", field: settings.Field})
+	}
+	for i, f := range settings.Fields {
+		refs = append(refs, fieldRef{path: indexPath("fields", i), field: f})
+	}
+	return refs, defines
+}
+
+// conditionFields returns the fields referenced by a condition. path is the
+// path of the condition.
+func conditionFields(path string, cond *conf.C) []fieldRef {
+	var refs []fieldRef
+	for _, op := range cond.GetFields() {
+		opPath := joinPath(path, op)
+		switch op {
+		case "and", "or":
+			n, _ := cond.CountField(op)
+			for i := 0; i < n; i++ {
+				if sub, err := cond.Child(op, i); err == nil {
+					refs = append(refs, conditionFields(indexPath(opPath, i), sub)...)
+				}
+			}
+		case "not":
+			if sub, err := cond.Child(op, -1); err == nil {
+				refs = append(refs, conditionFields(opPath, sub)...)
+			}
+		case "has_fields":
+			n, _ := cond.CountField(op)
+			for i := 0; i < n; i++ {
+				if f, err := cond.String(op, i); err == nil {
+					refs = append(refs, fieldRef{path: indexPath(opPath, i), field: f})
+				}
+			}
+		case "equals", "contains", "regexp", "range", "network":
+			var fields mapstr.M
+			sub, err := cond.Child(op, -1)
+			if err != nil || sub.Unpack(&fields) != nil {
+				continue
+			}
+			for _, key := range flatKeys(fields) {
+				field := key
+				if op == "range" {
+					field = trimRangeSuffix(key)
+				}
+				refs = append(refs, fieldRef{path: joinPath(opPath, key), field: field})
+			}
+		}
+	}
+	return refs
+}
+
+// flatKeys returns the sorted keys of the leaves of m.
+func flatKeys(m mapstr.M) []string {
+	flat := m.Flatten()
+	keys := make([]string, 0, len(flat))
+	for k := range flat {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func trimRangeSuffix(key string) string {
+	for _, suffix := range []string{".gte", ".gt", ".lte", ".lt"} {
+		if strings.HasSuffix(key, suffix) {
+			return strings.TrimSuffix(key, suffix)
+		}
+	}
+	return key
+}
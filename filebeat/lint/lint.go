@@ -0,0 +1,171 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package lint checks the semantics of the input configurations of Filebeat,
+// beyond what unpacking and validating the configuration does: whether the
+// files an input reads exist, whether its templates compile, or whether the
+// processors reference known fields.
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	conf "github.com/elastic/elastic-agent-libs/config"
+)
+
+// Severity of a finding.
+type Severity string
+
+const (
+	// SeverityError is used for findings that prevent the input from working.
+	SeverityError Severity = "error"
+	// SeverityWarning is used for findings that are likely a mistake.
+	SeverityWarning Severity = "warning"
+)
+
+// Finding is an issue found in the configuration.
+type Finding struct {
+	Severity Severity `json:"severity"`
+	Rule     string   `json:"rule"`
+	// Path is the path of the setting in the configuration, like
+	// filebeat.inputs.0.paths.1.
+	Path    string `json:"path"`
+	Input   string `json:"input,omitempty"`
+	ID      string `json:"id,omitempty"`
+	Message string `json:"message"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s: %s (%s)", f.Severity, f.Path, f.Message, f.Rule)
+}
+
+// Checker checks the configuration of an input. The paths of the findings
+// are relative to the configuration of the input.
+type Checker func(cfg *conf.C) []Finding
+
+var checkers = map[string][]Checker{}
+
+// RegisterInputChecker registers a checker for the inputs of the given type.
+// It must be called from an init function.
+func RegisterInputChecker(inputType string, checker Checker) {
+	checkers[inputType] = append(checkers[inputType], checker)
+}
+
+// Input is the configuration of an input, with its path in the configuration.
+type Input struct {
+	Path   string
+	Config *conf.C
+}
+
+// Linter checks the configuration of the inputs and the processors.
+type Linter struct {
+	fields *knownFields
+}
+
+// New creates a linter. fields is the content of the fields.yml of the beat,
+// used to check the fields referenced by processors. The check is skipped if
+// it is empty.
+func New(fields []byte) (*Linter, error) {
+	l := &Linter{}
+	if len(fields) > 0 {
+		known, err := loadKnownFields(fields)
+		if err != nil {
+			return nil, err
+		}
+		l.fields = known
+	}
+	return l, nil
+}
+
+// Lint checks the global processors and the inputs. The findings are sorted
+// by path.
+func (l *Linter) Lint(processors []*conf.C, inputs []Input) []Finding {
+	var findings []Finding
+	if l.fields != nil {
+		findings = append(findings, l.fields.checkProcessors("processors", processors, nil)...)
+	}
+	for _, in := range inputs {
+		findings = append(findings, l.lintInput(in)...)
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Path < findings[j].Path
+	})
+	return findings
+}
+
+func (l *Linter) lintInput(in Input) []Finding {
+	var settings struct {
+		Type       string    `config:"type"`
+		ID         string    `config:"id"`
+		Enabled    *bool     `config:"enabled"`
+		Processors []*conf.C `config:"processors"`
+	}
+	if err := in.Config.Unpack(&settings); err != nil {
+		return []Finding{{
+			Severity: SeverityError,
+			Rule:     "input.config",
+			Path:     in.Path,
+			Message:  err.Error(),
+		}}
+	}
+	if settings.Enabled != nil && !*settings.Enabled {
+		return nil
+	}
+
+	var findings []Finding
+	for _, check := range checkers[settings.Type] {
+		for _, f := range check(in.Config) {
+			f.Path = joinPath(in.Path, f.Path)
+			findings = append(findings, f)
+		}
+	}
+	if l.fields != nil {
+		defined, err := inputFields(in.Config)
+		if err != nil {
+			findings = append(findings, Finding{
+				Severity: SeverityError,
+				Rule:     "input.config",
+				Path:     joinPath(in.Path, "fields"),
+				Message:  err.Error(),
+			})
+		}
+		findings = append(findings, l.fields.checkProcessors(joinPath(in.Path, "processors"), settings.Processors, defined)...)
+	}
+
+	for i := range findings {
+		findings[i].Input = settings.Type
+		findings[i].ID = settings.ID
+	}
+	return findings
+}
+
+func joinPath(parent, child string) string {
+	switch {
+	case parent == "":
+		return child
+	case child == "":
+		return parent
+	default:
+		return parent + "." + child
+	}
+}
+
+func indexPath(parent string, i int) string {
+	return joinPath(parent, strconv.Itoa(i))
+}
@@ -0,0 +1,177 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	conf "github.com/elastic/elastic-agent-libs/config"
+)
+
+const testFields = `
+- key: base
+  fields:
+    - name: message
+      type: text
+    - name: log
+      type: group
+      fields:
+        - name: file.path
+          type: keyword
+    - name: event.dataset
+      type: keyword
+    - name: labels
+      type: object
+`
+
+func mustConfig(t *testing.T, v interface{}) *conf.C {
+	t.Helper()
+	cfg, err := conf.NewConfigFrom(v)
+	require.NoError(t, err)
+	return cfg
+}
+
+func mustConfigs(t *testing.T, vs ...interface{}) []*conf.C {
+	t.Helper()
+	var cfgs []*conf.C
+	for _, v := range vs {
+		cfgs = append(cfgs, mustConfig(t, v))
+	}
+	return cfgs
+}
+
+func TestLintPaths(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.log"), nil, 0o600))
+
+	linter, err := New(nil)
+	require.NoError(t, err)
+
+	findings := linter.Lint(nil, []Input{
+		{Path: "filebeat.inputs.0", Config: mustConfig(t, map[string]interface{}{
+			"type":  "filestream",
+			"id":    "my-id",
+			"paths": []string{filepath.Join(dir, "*.log"), filepath.Join(dir, "*.txt"), "[-"},
+		})},
+		{Path: "filebeat.inputs.1", Config: mustConfig(t, map[string]interface{}{
+			"type":    "log",
+			"enabled": false,
+			"paths":   []string{filepath.Join(dir, "*.txt")},
+		})},
+	})
+
+	require.Len(t, findings, 2)
+	assert.Equal(t, Finding{
+		Severity: SeverityWarning,
+		Rule:     "paths.no_match",
+		Path:     "filebeat.inputs.0.paths.1",
+		Input:    "filestream",
+		ID:       "my-id",
+		Message:  `glob pattern "` + filepath.Join(dir, "*.txt") + `" does not match any file`,
+	}, findings[0])
+	assert.Equal(t, SeverityError, findings[1].Severity)
+	assert.Equal(t, "paths.invalid_glob", findings[1].Rule)
+	assert.Equal(t, "filebeat.inputs.0.paths.2", findings[1].Path)
+}
+
+func TestLintInputChecker(t *testing.T) {
+	RegisterInputChecker("lint-test", func(cfg *conf.C) []Finding {
+		return []Finding{{Severity: SeverityError, Rule: "test.rule", Path: "url", Message: "bad url"}}
+	})
+	defer delete(checkers, "lint-test")
+
+	linter, err := New(nil)
+	require.NoError(t, err)
+	findings := linter.Lint(nil, []Input{
+		{Path: "inputs.d/a.yml:0", Config: mustConfig(t, map[string]interface{}{"type": "lint-test"})},
+	})
+	require.Len(t, findings, 1)
+	assert.Equal(t, "inputs.d/a.yml:0.url", findings[0].Path)
+	assert.Equal(t, "lint-test", findings[0].Input)
+}
+
+func TestLintProcessorFields(t *testing.T) {
+	linter, err := New([]byte(testFields))
+	require.NoError(t, err)
+
+	global := mustConfigs(t,
+		map[string]interface{}{"drop_fields": map[string]interface{}{
+			"fields": []string{"message", "log.file.path", "unknown.field", "/^tmp/"},
+		}},
+	)
+	input := mustConfig(t, map[string]interface{}{
+		"type":   "lint-none",
+		"fields": map[string]interface{}{"team": "a"},
+		"processors": []interface{}{
+			map[string]interface{}{"add_fields": map[string]interface{}{
+				"target": "service",
+				"fields": map[string]interface{}{"name": "web"},
+			}},
+			map[string]interface{}{"rename": map[string]interface{}{
+				"fields": []interface{}{
+					map[string]interface{}{"from": "service.name", "to": "service.id"},
+					map[string]interface{}{"from": "missing", "to": "other"},
+				},
+			}},
+			map[string]interface{}{"drop_fields": map[string]interface{}{
+				"fields": []string{"fields.team", "other", "service.id", "labels.anything"},
+				"when": map[string]interface{}{
+					"or": []interface{}{
+						map[string]interface{}{"equals": map[string]interface{}{"event.dataset": "x"}},
+						map[string]interface{}{"has_fields": []string{"nope"}},
+					},
+				},
+			}},
+		},
+	})
+
+	findings := linter.Lint(global, []Input{{Path: "filebeat.inputs.0", Config: input}})
+
+	var paths []string
+	for _, f := range findings {
+		assert.Equal(t, "processors.unknown_field", f.Rule)
+		assert.Equal(t, SeverityWarning, f.Severity)
+		paths = append(paths, f.Path)
+	}
+	assert.Equal(t, []string{
+		"filebeat.inputs.0.processors.1.rename.fields.1.from",
+		"filebeat.inputs.0.processors.2.drop_fields.when.or.1.has_fields.0",
+		"processors.0.drop_fields.fields.2",
+	}, paths)
+}
+
+func TestLintProcessorAnyField(t *testing.T) {
+	linter, err := New([]byte(testFields))
+	require.NoError(t, err)
+
+	findings := linter.Lint(mustConfigs(t,
+		map[string]interface{}{"decode_json_fields": map[string]interface{}{
+			"fields": []string{"message"},
+			"target": "",
+		}},
+		map[string]interface{}{"drop_fields": map[string]interface{}{
+			"fields": []string{"from.json"},
+		}},
+	), nil)
+	assert.Empty(t, findings)
+}
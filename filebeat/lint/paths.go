@@ -0,0 +1,69 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package lint
+
+import (
+	"fmt"
+	"path/filepath"
+
+	conf "github.com/elastic/elastic-agent-libs/config"
+)
+
+func init() {
+	RegisterInputChecker("log", checkPaths)
+	RegisterInputChecker("filestream", checkPaths)
+}
+
+// checkPaths checks that the glob patterns of an input are valid and match
+// at least one file. A pattern that matches nothing is only a warning, as
+// the files may be created later.
+func checkPaths(cfg *conf.C) []Finding {
+	var settings struct {
+		Paths []string `config:"paths"`
+	}
+	if err := cfg.Unpack(&settings); err != nil {
+		return []Finding{{
+			Severity: SeverityError,
+			Rule:     "paths.config",
+			Path:     "paths",
+			Message:  err.Error(),
+		}}
+	}
+
+	var findings []Finding
+	for i, pattern := range settings.Paths {
+		matches, err := filepath.Glob(pattern)
+		switch {
+		case err != nil:
+			findings = append(findings, Finding{
+				Severity: SeverityError,
+				Rule:     "paths.invalid_glob",
+				Path:     indexPath("paths", i),
+				Message:  fmt.Sprintf("invalid glob pattern %q: %v", pattern, err),
+			})
+		case len(matches) == 0:
+			findings = append(findings, Finding{
+				Severity: SeverityWarning,
+				Rule:     "paths.no_match",
+				Path:     indexPath("paths", i),
+				Message:  fmt.Sprintf("glob pattern %q does not match any file", pattern),
+			})
+		}
+	}
+	return findings
+}
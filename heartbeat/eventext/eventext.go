@@ -18,6 +18,8 @@
 package eventext
 
 import (
+	"context"
+
 	"github.com/elastic/beats/v7/libbeat/beat"
 	"github.com/elastic/elastic-agent-libs/mapstr"
 )
@@ -56,3 +58,32 @@ func IsEventCancelled(event *beat.Event) bool {
 	v, err := event.Meta.GetValue(EventCancelledMetaKey)
 	return err == nil && v == true
 }
+
+// DownDiagnosticsMetaKey is the path to the @metadata key holding the diagnostics
+// registered with SetDownDiagnostics.
+const DownDiagnosticsMetaKey = "__hb_down_diagnostics__"
+
+// SetDownDiagnostics registers a function gathering diagnostics for a failed check.
+// The fields it returns are merged into the summary event only if the check starts
+// a new down state, so costly diagnostics only run on transitions. The function
+// must return once its context is done.
+func SetDownDiagnostics(event *beat.Event, fn func(context.Context) mapstr.M) {
+	if event != nil {
+		SetMeta(event, DownDiagnosticsMetaKey, fn)
+	}
+}
+
+// TakeDownDiagnostics removes and returns the function registered with
+// SetDownDiagnostics, if any.
+func TakeDownDiagnostics(event *beat.Event) func(context.Context) mapstr.M {
+	if event == nil || event.Meta == nil {
+		return nil
+	}
+	v, err := event.Meta.GetValue(DownDiagnosticsMetaKey)
+	if err != nil {
+		return nil
+	}
+	_ = event.Meta.Delete(DownDiagnosticsMetaKey)
+	fn, _ := v.(func(context.Context) mapstr.M)
+	return fn
+}
@@ -50,6 +50,8 @@ type responseConfig struct {
 	IncludeBody         string `config:"include_body"`
 	IncludeBodyMaxBytes int    `config:"include_body_max_bytes"`
 	IncludeHeaders      bool   `config:"include_headers"`
+
+	Extract []extractConfig `config:"extract"`
 }
 
 type checkConfig struct {
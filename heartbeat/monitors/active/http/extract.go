@@ -0,0 +1,196 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package http
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PaesslerAG/gval"
+	"github.com/PaesslerAG/jsonpath"
+
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// extractConfig configures a value to extract from the response body into
+// http.response.extracted.
+type extractConfig struct {
+	Field string `config:"field" validate:"required"`
+	JSON  string `config:"json"`
+	Regex string `config:"regex"`
+	Type  string `config:"type"`
+}
+
+// Validate validates of the extractConfig object is valid or not
+func (c *extractConfig) Validate() error {
+	if (c.JSON == "") == (c.Regex == "") {
+		return fmt.Errorf("exactly one of 'json' or 'regex' must be set to extract field '%s'", c.Field)
+	}
+	switch strings.ToLower(c.Type) {
+	case "", "string", "integer", "float", "boolean":
+	default:
+		return fmt.Errorf("unknown type '%s' to extract field '%s', please use one of 'string', 'integer', 'float', 'boolean'", c.Type, c.Field)
+	}
+	_, err := compileExtractor(*c)
+	return err
+}
+
+type compiledExtractor struct {
+	field string
+	typ   string
+	json  gval.Evaluable
+	regex *regexp.Regexp
+}
+
+// bodyExtractor extracts values from response bodies.
+type bodyExtractor []compiledExtractor
+
+func makeBodyExtractor(configs []extractConfig) (bodyExtractor, error) {
+	extractors := make(bodyExtractor, 0, len(configs))
+	for _, c := range configs {
+		e, err := compileExtractor(c)
+		if err != nil {
+			return nil, err
+		}
+		extractors = append(extractors, e)
+	}
+	return extractors, nil
+}
+
+func compileExtractor(c extractConfig) (compiledExtractor, error) {
+	e := compiledExtractor{field: c.Field, typ: strings.ToLower(c.Type)}
+	var err error
+	if c.JSON != "" {
+		e.json, err = jsonpath.New(c.JSON)
+		if err != nil {
+			return e, fmt.Errorf("could not compile JSON path '%s' to extract field '%s': %w", c.JSON, c.Field, err)
+		}
+		return e, nil
+	}
+	e.regex, err = regexp.Compile(c.Regex)
+	if err != nil {
+		return e, fmt.Errorf("could not compile regex '%s' to extract field '%s': %w", c.Regex, c.Field, err)
+	}
+	return e, nil
+}
+
+func (be bodyExtractor) wantsBody() bool {
+	return len(be) > 0
+}
+
+// extract returns the values extracted from body. Values that cannot be
+// found or converted are skipped, extraction never fails a check.
+func (be bodyExtractor) extract(body string) mapstr.M {
+	if len(be) == 0 {
+		return nil
+	}
+
+	var (
+		decoded    interface{}
+		decodedErr error
+		decodeOnce bool
+	)
+	fields := mapstr.M{}
+	for _, e := range be {
+		var value interface{}
+		if e.json != nil {
+			if !decodeOnce {
+				decoded, decodedErr = decodeJson(body, false)
+				decodeOnce = true
+			}
+			if decodedErr != nil {
+				logp.L().Debugf("could not extract field '%s': %v", e.field, decodedErr)
+				continue
+			}
+			v, err := e.json(context.Background(), decoded)
+			if err != nil || v == nil {
+				continue
+			}
+			value = v
+		} else {
+			match := e.regex.FindStringSubmatch(body)
+			if match == nil {
+				continue
+			}
+			// Use the first capture group if there is one.
+			value = match[0]
+			if len(match) > 1 {
+				value = match[1]
+			}
+		}
+
+		value, err := convertExtracted(value, e.typ)
+		if err != nil {
+			logp.L().Debugf("could not extract field '%s': %v", e.field, err)
+			continue
+		}
+		_, _ = fields.Put(e.field, value)
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+func convertExtracted(v interface{}, typ string) (interface{}, error) {
+	if typ == "" {
+		return v, nil
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		switch v.(type) {
+		case map[string]interface{}, []interface{}:
+			return nil, fmt.Errorf("cannot convert %T to %s", v, typ)
+		}
+		s = fmt.Sprint(v)
+	}
+	s = strings.TrimSpace(s)
+
+	switch typ {
+	case "string":
+		return s, nil
+	case "integer":
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i, nil
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || f != float64(int64(f)) {
+			return nil, fmt.Errorf("cannot convert '%s' to integer", s)
+		}
+		return int64(f), nil
+	case "float":
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot convert '%s' to float: %w", s, err)
+		}
+		return f, nil
+	case "boolean":
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("cannot convert '%s' to boolean: %w", s, err)
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("unknown type '%s'", typ)
+}
@@ -0,0 +1,122 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package http
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func TestBodyExtractor(t *testing.T) {
+	tests := []struct {
+		name    string
+		configs []extractConfig
+		body    string
+		want    mapstr.M
+	}{
+		{
+			name: "json values keep their type",
+			configs: []extractConfig{
+				{Field: "version", JSON: "$.version"},
+				{Field: "lag", JSON: "$.replication.lag"},
+				{Field: "ok", JSON: "$.ok"},
+			},
+			body: `{"version": "1.2.3", "replication": {"lag": 1.5}, "ok": true}`,
+			want: mapstr.M{"version": "1.2.3", "lag": 1.5, "ok": true},
+		},
+		{
+			name: "regex uses the first capture group",
+			configs: []extractConfig{
+				{Field: "lag", Regex: `lag=(\d+)`, Type: "integer"},
+				{Field: "state", Regex: `state=\w+`},
+			},
+			body: "lag=42 state=primary",
+			want: mapstr.M{"lag": int64(42), "state": "state=primary"},
+		},
+		{
+			name: "converted json values",
+			configs: []extractConfig{
+				{Field: "count", JSON: "$.count", Type: "integer"},
+				{Field: "ratio", JSON: "$.ratio", Type: "float"},
+				{Field: "version", JSON: "$.version", Type: "string"},
+				{Field: "enabled", JSON: "$.enabled", Type: "boolean"},
+			},
+			body: `{"count": 3, "ratio": "0.25", "version": 7, "enabled": "true"}`,
+			want: mapstr.M{"count": int64(3), "ratio": 0.25, "version": "7", "enabled": true},
+		},
+		{
+			name: "values that can't be found or converted are skipped",
+			configs: []extractConfig{
+				{Field: "missing", JSON: "$.missing"},
+				{Field: "nomatch", Regex: `nomatch=(\d+)`},
+				{Field: "bad", JSON: "$.bad", Type: "integer"},
+				{Field: "good", JSON: "$.good"},
+			},
+			body: `{"bad": "abc", "good": "yes"}`,
+			want: mapstr.M{"good": "yes"},
+		},
+		{
+			name: "invalid json",
+			configs: []extractConfig{
+				{Field: "version", JSON: "$.version"},
+			},
+			body: "not json",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			extractor, err := makeBodyExtractor(tt.configs)
+			require.NoError(t, err)
+			assert.True(t, extractor.wantsBody())
+			assert.Equal(t, tt.want, extractor.extract(tt.body))
+		})
+	}
+}
+
+func TestExtractConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  extractConfig
+		wantErr string
+	}{
+		{"json", extractConfig{Field: "f", JSON: "$.a"}, ""},
+		{"regex", extractConfig{Field: "f", Regex: "a(b)", Type: "string"}, ""},
+		{"both", extractConfig{Field: "f", JSON: "$.a", Regex: "a"}, "exactly one of 'json' or 'regex'"},
+		{"none", extractConfig{Field: "f"}, "exactly one of 'json' or 'regex'"},
+		{"bad regex", extractConfig{Field: "f", Regex: "a("}, "could not compile regex"},
+		{"bad json path", extractConfig{Field: "f", JSON: "$.["}, "could not compile JSON path"},
+		{"bad type", extractConfig{Field: "f", JSON: "$.a", Type: "date"}, "unknown type 'date'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -74,6 +74,11 @@ func create(
 		return plugin.Plugin{}, err
 	}
 
+	extractor, err := makeBodyExtractor(config.Response.Extract)
+	if err != nil {
+		return plugin.Plugin{}, err
+	}
+
 	// Determine whether we're using a proxy or not and then use that to figure out how to
 	// run the job
 	var makeJob func(string) (jobs.Job, error)
@@ -87,7 +92,7 @@ func create(
 		}
 
 		makeJob = func(urlStr string) (jobs.Job, error) {
-			return newHTTPMonitorHostJob(urlStr, &config, transport, enc, body, validator)
+			return newHTTPMonitorHostJob(urlStr, &config, transport, enc, body, validator, extractor)
 		}
 	} else {
 		// preload TLS configuration
@@ -98,7 +103,7 @@ func create(
 		config.Transport.TLS = nil
 
 		makeJob = func(urlStr string) (jobs.Job, error) {
-			return newHTTPMonitorIPsJob(&config, urlStr, tls, enc, body, validator)
+			return newHTTPMonitorIPsJob(&config, urlStr, tls, enc, body, validator, extractor)
 		}
 	}
 
@@ -32,6 +32,7 @@ import (
 	"os"
 	"path"
 	"reflect"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
@@ -477,6 +478,47 @@ func TestJsonBody(t *testing.T) {
 	}
 }
 
+func TestExtract(t *testing.T) {
+	for _, status := range []int{200, 503} {
+		t.Run(strconv.Itoa(status), func(t *testing.T) {
+			body := `{"build": {"version": "1.4.2"}, "replication": "lag=12s"}`
+			server := httptest.NewServer(hbtest.CustomResponseHandler([]byte(body), status, nil))
+			defer server.Close()
+
+			configSrc := map[string]interface{}{
+				"hosts":                 server.URL,
+				"timeout":               "1s",
+				"response.include_body": "never",
+				"response.extract": []mapstr.M{
+					{"field": "build.version", "json": "$.build.version"},
+					{"field": "replication.lag_seconds", "regex": `lag=(\d+)s`, "type": "integer"},
+					{"field": "missing", "json": "$.missing"},
+				},
+			}
+
+			config, err := conf.NewConfigFrom(configSrc)
+			require.NoError(t, err)
+
+			p, err := create("extract", config)
+			require.NoError(t, err)
+
+			sched, _ := schedule.Parse("@every 1s")
+			job := wrappers.WrapCommon(p.Jobs, stdfields.StdMonitorFields{ID: "test", Type: "http", Schedule: sched, Timeout: 1}, nil)[0]
+
+			event := &beat.Event{}
+			_, err = job(event)
+			require.NoError(t, err)
+
+			extracted, err := event.GetValue("http.response.extracted")
+			require.NoError(t, err)
+			require.Equal(t, mapstr.M{
+				"build":       mapstr.M{"version": "1.4.2"},
+				"replication": mapstr.M{"lag_seconds": int64(12)},
+			}, extracted)
+		})
+	}
+}
+
 func runHTTPSServerCheck(
 	t *testing.T,
 	server *httptest.Server,
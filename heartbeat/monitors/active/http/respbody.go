@@ -43,10 +43,10 @@ const (
 	minBufferBodyBytes = 128
 )
 
-func processBody(resp *http.Response, config responseConfig, validator multiValidator) (mapstr.M, string, reason.Reason) {
+func processBody(resp *http.Response, config responseConfig, validator multiValidator, extractor bodyExtractor) (mapstr.M, mapstr.M, string, reason.Reason) {
 	// Determine how much of the body to actually buffer in memory
 	var bufferBodyBytes int
-	if validator.wantsBody() {
+	if validator.wantsBody() || extractor.wantsBody() {
 		bufferBodyBytes = maxBufferBodyBytes
 	} else if config.IncludeBody == "always" || config.IncludeBody == "on_error" {
 		// If the user has asked for bodies to be recorded we only need to buffer that much
@@ -60,7 +60,7 @@ func processBody(resp *http.Response, config responseConfig, validator multiVali
 	respBody, bodyLenBytes, bodyHash, respErr := readBody(resp, bufferBodyBytes)
 	// If we encounter an error while reading the body just fail early
 	if respErr != nil {
-		return nil, "", reason.IOFailed(fmt.Errorf("failed reading HTTP response body: %w", respErr))
+		return nil, nil, "", reason.IOFailed(fmt.Errorf("failed reading HTTP response body: %w", respErr))
 	}
 
 	// Run any validations
@@ -86,7 +86,7 @@ func processBody(resp *http.Response, config responseConfig, validator multiVali
 		bodyFields["content"] = respBody[0:sampleNumBytes]
 	}
 
-	return bodyFields, mime.Detect(respBody), errReason
+	return bodyFields, extractor.extract(respBody), mime.Detect(respBody), errReason
 }
 
 // readBody reads the first sampleSize bytes from the httpResponse,
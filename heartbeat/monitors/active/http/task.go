@@ -59,6 +59,7 @@ func newHTTPMonitorHostJob(
 	enc contentEncoder,
 	body []byte,
 	validator multiValidator,
+	extractor bodyExtractor,
 ) (jobs.Job, error) {
 
 	var reqFactory requestFactory = func() (*http.Request, error) { return buildRequest(addr, config, enc) }
@@ -77,7 +78,7 @@ func newHTTPMonitorHostJob(
 			return fmt.Errorf("could not make http request: %w", err)
 		}
 
-		_, err = execPing(event, client, req, body, config.Transport.Timeout, validator, config.Response)
+		_, err = execPing(event, client, req, body, config.Transport.Timeout, validator, extractor, config.Response)
 		if len(redirects) > 0 {
 			_, _ = event.PutValue("http.response.redirects", redirects)
 		}
@@ -92,6 +93,7 @@ func newHTTPMonitorIPsJob(
 	enc contentEncoder,
 	body []byte,
 	validator multiValidator,
+	extractor bodyExtractor,
 ) (jobs.Job, error) {
 
 	var reqFactory requestFactory = func() (*http.Request, error) { return buildRequest(addr, config, enc) }
@@ -101,7 +103,7 @@ func newHTTPMonitorIPsJob(
 		return nil, err
 	}
 
-	pingFactory := createPingFactory(config, port, tls, reqFactory, body, validator)
+	pingFactory := createPingFactory(config, port, tls, reqFactory, body, validator, extractor)
 	job, err := monitors.MakeByHostJob(hostname, config.Mode, monitors.NewStdResolver(), pingFactory)
 
 	return job, err
@@ -114,6 +116,7 @@ func createPingFactory(
 	reqFactory requestFactory,
 	body []byte,
 	validator multiValidator,
+	extractor bodyExtractor,
 ) func(*net.IPAddr) jobs.Job {
 	timeout := config.Transport.Timeout
 
@@ -173,7 +176,7 @@ func createPingFactory(
 			Transport:     httpcommon.HeaderRoundTripper(transport, map[string]string{"User-Agent": userAgent}),
 		}
 
-		end, err := execPing(event, client, req, body, timeout, validator, config.Response)
+		end, err := execPing(event, client, req, body, timeout, validator, extractor, config.Response)
 		cbMutex.Lock()
 		defer cbMutex.Unlock()
 
@@ -230,6 +233,7 @@ func execPing(
 	reqBody []byte,
 	timeout time.Duration,
 	validator multiValidator,
+	extractor bodyExtractor,
 	responseConfig responseConfig,
 ) (end time.Time, err error) {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
@@ -260,13 +264,17 @@ func execPing(
 		return time.Now(), errReason
 	}
 
-	bodyFields, mimeType, errReason := processBody(resp, responseConfig, validator)
+	bodyFields, extracted, mimeType, errReason := processBody(resp, responseConfig, validator, extractor)
 
 	responseFields := mapstr.M{
 		"status_code": resp.StatusCode,
 		"body":        bodyFields,
 	}
 
+	if len(extracted) > 0 {
+		responseFields["extracted"] = extracted
+	}
+
 	if mimeType != "" {
 		responseFields["mime_type"] = mimeType
 	}
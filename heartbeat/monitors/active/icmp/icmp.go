@@ -117,6 +117,9 @@ func (jf *jobFactory) pingIPFactory(config *Config) func(*net.IPAddr) jobs.Job {
 	return monitors.MakePingIPFactory(func(event *beat.Event, ip *net.IPAddr) error {
 		rtt, n, err := jf.loop.ping(ip, config.Timeout, config.Wait)
 		if err != nil {
+			if config.Traceroute.Enabled {
+				eventext.SetDownDiagnostics(event, config.Traceroute.Diagnostics(ip))
+			}
 			return err
 		}
 
@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/elastic/beats/v7/heartbeat/monitors"
+	"github.com/elastic/beats/v7/heartbeat/monitors/active/traceroute"
 	"github.com/elastic/elastic-agent-libs/transport"
 	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
 )
@@ -43,12 +44,16 @@ type config struct {
 	// validate connection
 	SendString    string `config:"check.send"`
 	ReceiveString string `config:"check.receive"`
+
+	// run a traceroute to the resolved IP when the monitor goes down
+	Traceroute traceroute.Config `config:"traceroute"`
 }
 
 func defaultConfig() config {
 	return config{
-		Timeout: 16 * time.Second,
-		Mode:    monitors.DefaultIPSettings,
+		Timeout:    16 * time.Second,
+		Mode:       monitors.DefaultIPSettings,
+		Traceroute: traceroute.DefaultConfig,
 	}
 }
 
@@ -164,7 +164,11 @@ func (jf *jobFactory) makeDirectEndpointJob(endpointURL *url.URL) (jobs.Job, err
 				// use address from resolved IP
 				ipPort := net.JoinHostPort(ip.String(), endpointURL.Port())
 
-				return jf.dial(event, ipPort, endpointURL)
+				err := jf.dial(event, ipPort, endpointURL)
+				if err != nil && jf.config.Traceroute.Enabled {
+					eventext.SetDownDiagnostics(event, jf.config.Traceroute.Diagnostics(ip))
+				}
+				return err
 			}))
 	if err != nil {
 		return nil, err
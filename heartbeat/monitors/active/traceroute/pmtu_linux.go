@@ -0,0 +1,178 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package traceroute
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"golang.org/x/net/ipv4"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// pmtuPort is the UDP port probed, the first port used by traceroute.
+	// Hosts answer with port unreachable once the probes reach them.
+	pmtuPort = 33434
+	// maxPMTUProbes bounds the number of probes, each one either fits or
+	// lowers the MTU to the one reported by a router.
+	maxPMTUProbes = 10
+	// maxIPPacket is the largest IP packet, that can exceed the MTU of the
+	// loopback interface.
+	maxIPPacket = 65535
+
+	udpHeaderLen = 8
+	// sizeofSockExtendedErr is the size of struct sock_extended_err.
+	sizeofSockExtendedErr = 16
+)
+
+// DiscoverPathMTU discovers the path MTU to ip by sending UDP datagrams that
+// must not be fragmented, as large as the known MTU, until no router reports
+// a lower MTU within timeout. It returns the MTU known when ctx is done. It
+// does not require any privileges.
+func DiscoverPathMTU(ctx context.Context, ip *net.IPAddr, timeout time.Duration) (int, error) {
+	family, level := unix.AF_INET, unix.IPPROTO_IP
+	discoverOpt, discoverDo := unix.IP_MTU_DISCOVER, unix.IP_PMTUDISC_DO
+	mtuOpt, recvErrOpt := unix.IP_MTU, unix.IP_RECVERR
+	hdrLen := ipv4.HeaderLen + udpHeaderLen
+	var sa unix.Sockaddr
+	if ip4 := ip.IP.To4(); ip4 != nil {
+		sa = &unix.SockaddrInet4{Port: pmtuPort, Addr: [4]byte(ip4)}
+	} else {
+		family, level = unix.AF_INET6, unix.IPPROTO_IPV6
+		discoverOpt, discoverDo = unix.IPV6_MTU_DISCOVER, unix.IPV6_PMTUDISC_DO
+		mtuOpt, recvErrOpt = unix.IPV6_MTU, unix.IPV6_RECVERR
+		hdrLen = ipv6HeaderLen + udpHeaderLen
+		sa6 := &unix.SockaddrInet6{Port: pmtuPort, Addr: [16]byte(ip.IP.To16())}
+		if ip.Zone != "" {
+			if iface, err := net.InterfaceByName(ip.Zone); err == nil {
+				sa6.ZoneId = uint32(iface.Index)
+			}
+		}
+		sa = sa6
+	}
+
+	fd, err := unix.Socket(family, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return 0, fmt.Errorf("error creating path MTU discovery socket: %w", err)
+	}
+	defer unix.Close(fd)
+
+	// Set the DF bit, and receive the MTU reported by routers in the error
+	// queue of the socket.
+	if err := unix.SetsockoptInt(fd, level, discoverOpt, discoverDo); err != nil {
+		return 0, fmt.Errorf("error enabling path MTU discovery: %w", err)
+	}
+	if err := unix.SetsockoptInt(fd, level, recvErrOpt, 1); err != nil {
+		return 0, fmt.Errorf("error enabling ICMP errors: %w", err)
+	}
+	if err := unix.Connect(fd, sa); err != nil {
+		return 0, fmt.Errorf("error connecting path MTU discovery socket: %w", err)
+	}
+
+	mtu, err := unix.GetsockoptInt(fd, level, mtuOpt)
+	if err != nil {
+		return 0, fmt.Errorf("error reading MTU: %w", err)
+	}
+	payload := make([]byte, maxIPPacket)
+	for i := 0; i < maxPMTUProbes && ctx.Err() == nil; i++ {
+		mtu = min(mtu, maxIPPacket)
+		err := unix.Send(fd, payload[:mtu-hdrLen], 0)
+		switch {
+		case errors.Is(err, unix.EMSGSIZE):
+			// The MTU was lowered since it was read.
+			if mtu, err = unix.GetsockoptInt(fd, level, mtuOpt); err != nil {
+				return 0, fmt.Errorf("error reading MTU: %w", err)
+			}
+			continue
+		case errors.Is(err, unix.ECONNREFUSED):
+			// A previous probe reached the host.
+			return mtu, nil
+		case err != nil:
+			return mtu, fmt.Errorf("error sending probe: %w", err)
+		}
+
+		wait := timeout
+		if deadline, ok := ctx.Deadline(); ok {
+			wait = min(wait, time.Until(deadline))
+		}
+		errno, info, err := waitICMPError(fd, level, recvErrOpt, wait)
+		if err != nil {
+			return mtu, err
+		}
+		switch errno {
+		case unix.EMSGSIZE:
+			mtu = int(info)
+		case 0, unix.ECONNREFUSED:
+			// The probe reached the host, or nothing dropped it before the
+			// timeout.
+			return mtu, nil
+		default:
+			return mtu, fmt.Errorf("error probing path MTU: %w", errno)
+		}
+	}
+	return mtu, ctx.Err()
+}
+
+// waitICMPError waits up to timeout for an ICMP error to be queued on the
+// socket and returns its errno and info, the MTU of a fragmentation needed
+// error. The errno is zero if no error was received.
+func waitICMPError(fd, level, recvErrOpt int, timeout time.Duration) (unix.Errno, uint32, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return 0, 0, nil
+		}
+		fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLERR}}
+		n, err := unix.Poll(fds, int(remaining.Milliseconds())+1)
+		if errors.Is(err, unix.EINTR) {
+			continue
+		}
+		if err != nil {
+			return 0, 0, fmt.Errorf("error waiting for ICMP errors: %w", err)
+		}
+		if n == 0 || fds[0].Revents&unix.POLLERR == 0 {
+			continue
+		}
+
+		oob := make([]byte, 512)
+		_, oobn, _, _, err := unix.Recvmsg(fd, nil, oob, unix.MSG_ERRQUEUE)
+		if err != nil {
+			return 0, 0, fmt.Errorf("error reading ICMP errors: %w", err)
+		}
+		msgs, err := unix.ParseSocketControlMessage(oob[:oobn])
+		if err != nil {
+			return 0, 0, fmt.Errorf("error parsing ICMP errors: %w", err)
+		}
+		for _, m := range msgs {
+			if int(m.Header.Level) != level || int(m.Header.Type) != recvErrOpt || len(m.Data) < sizeofSockExtendedErr {
+				continue
+			}
+			// struct sock_extended_err starts with ee_errno, ee_origin,
+			// ee_type, ee_code, ee_pad and ee_info.
+			errno := unix.Errno(binary.NativeEndian.Uint32(m.Data[0:4]))
+			info := binary.NativeEndian.Uint32(m.Data[8:12])
+			return errno, info, nil
+		}
+	}
+}
@@ -0,0 +1,267 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package traceroute implements a bounded ICMP traceroute used to attach
+// per-hop diagnostics to monitors when they go down.
+package traceroute
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+
+	"github.com/elastic/beats/v7/heartbeat/look"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+const (
+	// iana protocol numbers
+	protocolICMP     = 1
+	protocolIPv6ICMP = 58
+
+	ipv6HeaderLen = 40
+)
+
+// Config configures the traceroute run when a monitor goes down.
+type Config struct {
+	Enabled bool `config:"enabled"`
+	// MaxHops is the maximum TTL probed.
+	MaxHops int `config:"max_hops" validate:"min=1,max=255"`
+	// Timeout is the time to wait for the replies of the hops.
+	Timeout time.Duration `config:"timeout" validate:"positive"`
+}
+
+// DefaultConfig is the default traceroute configuration.
+var DefaultConfig = Config{
+	Enabled: false,
+	MaxHops: 30,
+	Timeout: time.Second,
+}
+
+// Hop is the result of probing a single TTL.
+type Hop struct {
+	TTL int
+	// IP is the address that answered the probe, nil if there was no answer
+	// before the timeout.
+	IP  net.IP
+	RTT time.Duration
+}
+
+// Result is the outcome of a traceroute run.
+type Result struct {
+	Hops []Hop
+	// Reached is true if the destination answered one of the probes.
+	Reached bool
+}
+
+// Fields returns the event fields for the result.
+func (r Result) Fields() mapstr.M {
+	hops := make([]mapstr.M, 0, len(r.Hops))
+	for _, h := range r.Hops {
+		hop := mapstr.M{"ttl": h.TTL}
+		if h.IP != nil {
+			hop["ip"] = h.IP.String()
+			hop["rtt"] = look.RTT(h.RTT)
+		}
+		hops = append(hops, hop)
+	}
+	return mapstr.M{
+		"hops":    hops,
+		"reached": r.Reached,
+	}
+}
+
+// Diagnostics returns a function that runs a traceroute and a path MTU
+// discovery to ip concurrently and returns the fields to attach to the event
+// under `traceroute`. Both stop when ctx is done.
+func (c Config) Diagnostics(ip *net.IPAddr) func(context.Context) mapstr.M {
+	return func(ctx context.Context) mapstr.M {
+		var (
+			mtu    int
+			mtuErr error
+			wg     sync.WaitGroup
+		)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mtu, mtuErr = DiscoverPathMTU(ctx, ip, c.Timeout)
+		}()
+		res, err := Run(ctx, ip, c.MaxHops, c.Timeout)
+		wg.Wait()
+
+		fields := res.Fields()
+		if err != nil {
+			fields["error"] = err.Error()
+		}
+		if mtuErr == nil {
+			fields["path_mtu"] = mtu
+		}
+		return mapstr.M{"traceroute": fields}
+	}
+}
+
+// Run sends ICMP echo requests to ip with all the TTLs up to maxHops at once,
+// and waits for the answers of the hops up to the destination. A run takes at
+// most timeout after the probes are sent, and stops earlier when ctx is done,
+// returning the hops answered so far. Raw ICMP sockets are required, so it
+// fails without the privileges needed for them.
+func Run(ctx context.Context, ip *net.IPAddr, maxHops int, timeout time.Duration) (Result, error) {
+	var res Result
+
+	isIPv4 := ip.IP.To4() != nil
+	network, proto := "ip4:icmp", protocolICMP
+	var echoType, replyType icmp.Type = ipv4.ICMPTypeEcho, ipv4.ICMPTypeEchoReply
+	if !isIPv4 {
+		network, proto = "ip6:ipv6-icmp", protocolIPv6ICMP
+		echoType, replyType = ipv6.ICMPTypeEchoRequest, ipv6.ICMPTypeEchoReply
+	}
+
+	conn, err := icmp.ListenPacket(network, "")
+	if err != nil {
+		return res, fmt.Errorf("traceroute requires privileges to open raw ICMP sockets: %w", err)
+	}
+	defer conn.Close()
+
+	//nolint:gosec // the ID only needs to be unlikely to collide with other probes
+	id := rand.Intn(0xffff)
+	hops := make([]Hop, maxHops+1)
+	sent := make([]time.Time, maxHops+1)
+	for ttl := 1; ttl <= maxHops; ttl++ {
+		if isIPv4 {
+			err = conn.IPv4PacketConn().SetTTL(ttl)
+		} else {
+			err = conn.IPv6PacketConn().SetHopLimit(ttl)
+		}
+		if err != nil {
+			return res, fmt.Errorf("error setting TTL: %w", err)
+		}
+
+		msg := icmp.Message{
+			Type: echoType,
+			Body: &icmp.Echo{ID: id, Seq: ttl, Data: []byte("heartbeat traceroute")},
+		}
+		b, err := msg.Marshal(nil)
+		if err != nil {
+			return res, err
+		}
+
+		hops[ttl].TTL = ttl
+		sent[ttl] = time.Now()
+		if _, err := conn.WriteTo(b, ip); err != nil {
+			return res, fmt.Errorf("error sending probe: %w", err)
+		}
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return res, err
+	}
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.SetReadDeadline(time.Now())
+	})
+	defer stop()
+
+	// last is the TTL of the destination, once it answered.
+	last := maxHops
+	answered := func() bool {
+		for _, hop := range hops[1 : last+1] {
+			if hop.IP == nil {
+				return false
+			}
+		}
+		return true
+	}
+	buf := make([]byte, 1500)
+	for !answered() {
+		n, peer, err := conn.ReadFrom(buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				break
+			}
+			return res, fmt.Errorf("error reading reply: %w", err)
+		}
+
+		m, err := icmp.ParseMessage(proto, buf[:n])
+		if err != nil {
+			continue
+		}
+
+		var (
+			ttl     int
+			matched bool
+		)
+		switch body := m.Body.(type) {
+		case *icmp.Echo:
+			ttl, matched = body.Seq, m.Type == replyType && body.ID == id
+			if matched && ttl <= maxHops {
+				res.Reached = true
+				last = min(last, ttl)
+			}
+		case *icmp.TimeExceeded:
+			ttl, matched = quotedProbe(body.Data, isIPv4, id)
+		case *icmp.DstUnreach:
+			ttl, matched = quotedProbe(body.Data, isIPv4, id)
+			if matched && ttl >= 1 && ttl <= maxHops {
+				last = min(last, ttl)
+			}
+		}
+		if !matched || ttl < 1 || ttl > maxHops || hops[ttl].IP != nil {
+			continue
+		}
+
+		hops[ttl].RTT = time.Since(sent[ttl])
+		if addr, ok := peer.(*net.IPAddr); ok {
+			hops[ttl].IP = addr.IP
+		}
+	}
+
+	res.Hops = hops[1 : last+1]
+	if !answered() && ctx.Err() != nil {
+		return res, fmt.Errorf("traceroute interrupted: %w", ctx.Err())
+	}
+	return res, nil
+}
+
+// quotedProbe returns the sequence number of the echo request with the given
+// ID quoted in an ICMP error message.
+func quotedProbe(data []byte, isIPv4 bool, id int) (seq int, ok bool) {
+	hdrLen := ipv6HeaderLen
+	if isIPv4 {
+		if len(data) < ipv4.HeaderLen {
+			return 0, false
+		}
+		hdrLen = int(data[0]&0x0f) << 2
+	}
+	// The quoted ICMP header is type, code, checksum, id and seq.
+	if len(data) < hdrLen+8 {
+		return 0, false
+	}
+	icmpHdr := data[hdrLen:]
+	if int(binary.BigEndian.Uint16(icmpHdr[4:6])) != id {
+		return 0, false
+	}
+	return int(binary.BigEndian.Uint16(icmpHdr[6:8])), true
+}
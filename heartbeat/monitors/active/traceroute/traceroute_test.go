@@ -0,0 +1,93 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package traceroute
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+
+	"github.com/elastic/beats/v7/heartbeat/look"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func TestQuotedProbe(t *testing.T) {
+	probe := func(typ icmp.Type, id, seq int) []byte {
+		b, err := (&icmp.Message{Type: typ, Body: &icmp.Echo{ID: id, Seq: seq}}).Marshal(nil)
+		require.NoError(t, err)
+		return b
+	}
+
+	ip4Header := make([]byte, ipv4.HeaderLen)
+	ip4Header[0] = 0x45 // version 4, 5 words header
+	ip4Quote := append(ip4Header, probe(ipv4.ICMPTypeEcho, 1234, 7)...)
+
+	assertQuoted := func(data []byte, isIPv4 bool, id int, seq int, ok bool) {
+		t.Helper()
+		gotSeq, gotOK := quotedProbe(data, isIPv4, id)
+		assert.Equal(t, ok, gotOK)
+		if ok {
+			assert.Equal(t, seq, gotSeq)
+		}
+	}
+	assertQuoted(ip4Quote, true, 1234, 7, true)
+	assertQuoted(ip4Quote, true, 4321, 0, false)
+	assertQuoted(ip4Quote[:ipv4.HeaderLen+4], true, 1234, 0, false)
+	assertQuoted(nil, true, 1234, 0, false)
+
+	ip6Quote := append(make([]byte, ipv6HeaderLen), probe(ipv6.ICMPTypeEchoRequest, 1234, 7)...)
+	assertQuoted(ip6Quote, false, 1234, 7, true)
+	assertQuoted(ip6Quote, false, 4321, 0, false)
+}
+
+func TestResultFields(t *testing.T) {
+	res := Result{
+		Hops: []Hop{
+			{TTL: 1, IP: net.ParseIP("192.168.1.1"), RTT: time.Millisecond},
+			{TTL: 2},
+			{TTL: 3, IP: net.ParseIP("10.0.0.1"), RTT: 2 * time.Millisecond},
+		},
+		Reached: true,
+	}
+
+	assert.Equal(t, mapstr.M{
+		"hops": []mapstr.M{
+			{"ttl": 1, "ip": "192.168.1.1", "rtt": look.RTT(time.Millisecond)},
+			{"ttl": 2},
+			{"ttl": 3, "ip": "10.0.0.1", "rtt": look.RTT(2 * time.Millisecond)},
+		},
+		"reached": true,
+	}, res.Fields())
+}
+
+func TestRunLoopback(t *testing.T) {
+	res, err := Run(context.Background(), &net.IPAddr{IP: net.IPv4(127, 0, 0, 1)}, 5, time.Second)
+	if err != nil {
+		t.Skipf("traceroute not available: %v", err)
+	}
+	assert.True(t, res.Reached)
+	require.Len(t, res.Hops, 1)
+	assert.Equal(t, "127.0.0.1", res.Hops[0].IP.String())
+}
@@ -0,0 +1,99 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package summarizer
+
+import (
+	"context"
+	"time"
+
+	"github.com/elastic/beats/v7/heartbeat/eventext"
+	"github.com/elastic/beats/v7/heartbeat/monitors/wrappers/monitorstate"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// DownDiagnosticsBudget bounds the time spent running the diagnostics of a
+// check, since the summary event waits for them.
+const DownDiagnosticsBudget = 5 * time.Second
+
+// downDiagnosticsGrace is the time left to the diagnostics to return their
+// fields once the budget is exhausted.
+const downDiagnosticsGrace = 500 * time.Millisecond
+
+// DownDiagnosticsPlugin runs the diagnostics registered by jobs with
+// eventext.SetDownDiagnostics when a check starts a new down state, and
+// attaches their fields to the summary event. It must run after the state
+// plugin, since it relies on the `state` field.
+type DownDiagnosticsPlugin struct {
+	diagnostics []func(context.Context) mapstr.M
+	budget      time.Duration
+}
+
+func (ddp *DownDiagnosticsPlugin) EachEvent(event *beat.Event, _ error) EachEventActions {
+	// Always remove the diagnostics from the event so they never get published.
+	if fn := eventext.TakeDownDiagnostics(event); fn != nil {
+		ddp.diagnostics = append(ddp.diagnostics, fn)
+	}
+	return 0
+}
+
+func (ddp *DownDiagnosticsPlugin) BeforeSummary(event *beat.Event) BeforeSummaryActions {
+	if len(ddp.diagnostics) == 0 {
+		return 0
+	}
+
+	v, _ := event.GetValue("state")
+	ms, ok := v.(*monitorstate.State)
+	// A state with a single check has just started.
+	if !ok || ms.Status != monitorstate.StatusDown || ms.Checks != 1 {
+		return 0
+	}
+
+	budget := ddp.budget
+	if budget == 0 {
+		budget = DownDiagnosticsBudget
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), budget)
+	defer cancel()
+
+	// The diagnostics run concurrently, the fields of the ones that do not
+	// return in time are dropped.
+	results := make(chan mapstr.M, len(ddp.diagnostics))
+	for _, fn := range ddp.diagnostics {
+		go func(fn func(context.Context) mapstr.M) {
+			results <- fn(ctx)
+		}(fn)
+	}
+	timeout := time.NewTimer(budget + downDiagnosticsGrace)
+	defer timeout.Stop()
+	for range ddp.diagnostics {
+		select {
+		case fields := <-results:
+			eventext.MergeEventFields(event, fields)
+		case <-timeout.C:
+			return 0
+		}
+	}
+	return 0
+}
+
+func (ddp *DownDiagnosticsPlugin) BeforeRetry() {
+	ddp.diagnostics = nil
+}
+
+func (ddp *DownDiagnosticsPlugin) BeforeEachEvent(event *beat.Event) {} // noop
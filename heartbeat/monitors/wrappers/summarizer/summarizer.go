@@ -105,6 +105,7 @@ func (s *Summarizer) setupPlugins() {
 		s.plugins = []SummarizerPlugin{
 			&LightweightDurationPlugin{},
 			NewLightweightStateStatusPlugin(s.mst, s.sf),
+			&DownDiagnosticsPlugin{},
 			NewLightweightErrPlugin(),
 		}
 	}
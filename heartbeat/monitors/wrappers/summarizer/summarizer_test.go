@@ -18,6 +18,7 @@
 package summarizer
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"testing"
@@ -25,6 +26,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/elastic/beats/v7/heartbeat/eventext"
 	"github.com/elastic/beats/v7/heartbeat/look"
 	"github.com/elastic/beats/v7/heartbeat/monitors/jobs"
 	"github.com/elastic/beats/v7/heartbeat/monitors/stdfields"
@@ -402,3 +404,92 @@ func (mp *MockPlugin) BeforeRetry() {
 func (mp *MockPlugin) BeforeEachEvent(e *beat.Event) {
 	mp.beforeEachEvent(e)
 }
+
+func TestSummarizerDownDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		maxAttempts    int
+		statusSequence string
+		// 'x' marks the events that must contain the diagnostics
+		expectedDiagnostics string
+	}{
+		{"start down", 1, "ddu", "x__"},
+		{"go down without retries", 1, "uuddu", "__x__"},
+		{"go down with retries", 2, "uudddu", "___x__"},
+		{"transient down", 2, "uudu", "____"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pos := 0
+			var job jobs.Job = func(event *beat.Event) ([]jobs.Job, error) {
+				var err error
+				if tt.statusSequence[pos] == 'd' {
+					err = fmt.Errorf("dummyerr")
+					eventext.SetDownDiagnostics(event, func(context.Context) mapstr.M {
+						return mapstr.M{"diag": true}
+					})
+				}
+				pos++
+				return nil, err
+			}
+
+			tracker := monitorstate.NewTracker(monitorstate.NilStateLoader, false)
+			sf := stdfields.StdMonitorFields{ID: "testmon", Name: "testmon", Type: "tcp", MaxAttempts: uint16(tt.maxAttempts)}
+
+			rcvdDiagnostics := ""
+			for pos < len(tt.statusSequence) {
+				s := NewSummarizer(job, sf, tracker)
+				s.retryDelay = 2 * time.Millisecond
+				events, _ := jobs.ExecJobAndConts(t, s.Wrap(job))
+				for _, event := range events {
+					if _, err := event.GetValue("diag"); err == nil {
+						rcvdDiagnostics += "x"
+					} else {
+						rcvdDiagnostics += "_"
+					}
+					_, err := event.Meta.GetValue(eventext.DownDiagnosticsMetaKey)
+					require.Error(t, err, "diagnostics must not be published")
+				}
+			}
+			require.Equal(t, tt.expectedDiagnostics, rcvdDiagnostics)
+		})
+	}
+}
+
+func TestDownDiagnosticsBudget(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	ddp := &DownDiagnosticsPlugin{
+		budget: 20 * time.Millisecond,
+		diagnostics: []func(context.Context) mapstr.M{
+			func(context.Context) mapstr.M { return mapstr.M{"fast": true} },
+			func(ctx context.Context) mapstr.M {
+				<-ctx.Done()
+				return mapstr.M{"partial": true}
+			},
+			func(context.Context) mapstr.M {
+				<-block
+				return mapstr.M{"stuck": true}
+			},
+		},
+	}
+	event := &beat.Event{Fields: mapstr.M{
+		"state": &monitorstate.State{Status: monitorstate.StatusDown, Checks: 1},
+	}}
+
+	start := time.Now()
+	ddp.BeforeSummary(event)
+	require.Less(t, time.Since(start), time.Second)
+
+	for field, expected := range map[string]bool{"fast": true, "partial": true, "stuck": false} {
+		_, err := event.GetValue(field)
+		require.Equal(t, expected, err == nil, field)
+	}
+}
@@ -17,15 +17,63 @@
 
 package api
 
-import "os"
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/elastic/beats/v7/libbeat/api/npipe"
+)
 
 // Config is the configuration for the API endpoint.
 type Config struct {
-	Enabled            bool   `config:"enabled"`
-	Host               string `config:"host"`
-	Port               int    `config:"port"`
-	User               string `config:"named_pipe.user"`
-	SecurityDescriptor string `config:"named_pipe.security_descriptor"`
+	Enabled            bool          `config:"enabled"`
+	Host               string        `config:"host"`
+	Port               int           `config:"port"`
+	User               string        `config:"named_pipe.user"`
+	SecurityDescriptor string        `config:"named_pipe.security_descriptor"`
+	Control            ControlConfig `config:"control"`
+}
+
+// ControlConfig is the configuration of the runtime control endpoints.
+type ControlConfig struct {
+	Enabled bool `config:"enabled"`
+	// Token must be sent as a bearer token to use the control endpoints.
+	Token string `config:"token"`
+}
+
+func (c *ControlConfig) Validate() error {
+	if c.Enabled && c.Token == "" {
+		return errors.New("a token is required when the control endpoints are enabled")
+	}
+	return nil
+}
+
+// Validate checks that the control endpoints are only enabled on a local
+// address: a unix socket, a named pipe or a loopback address.
+func (c *Config) Validate() error {
+	if !c.Control.Enabled || npipe.IsNPipe(c.Host) {
+		return nil
+	}
+	network, address, err := parse(c.Host, c.Port)
+	if err != nil {
+		return err
+	}
+	if network != "tcp" {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid host %s: %w", c.Host, err)
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("the control endpoints can only be enabled on a unix socket, a named pipe or a loopback address, got host %s", c.Host)
 }
 
 // DefaultConfig is the default configuration used by the API endpoint.
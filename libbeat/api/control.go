@@ -0,0 +1,319 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"runtime/pprof"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/multierr"
+
+	"github.com/elastic/beats/v7/libbeat/common/loglevel"
+	"github.com/elastic/beats/v7/libbeat/management/lifecycle"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+// InputController pauses and resumes inputs by ID.
+type InputController interface {
+	IDs() []string
+	Paused(id string) (paused, found bool)
+	Pause(id string) bool
+	Resume(id string) bool
+}
+
+// InputLifecycle stops, starts and reloads inputs by ID.
+type InputLifecycle interface {
+	IDs() []string
+	Get(id string) lifecycle.Input
+}
+
+// AttachControlHandlers attaches the endpoints that change the behaviour
+// of the beat at runtime, for debugging in production without restarts,
+// if they are enabled. They all require the configured bearer token.
+func AttachControlHandlers(api *Server, ns lookupFunc, inputs InputController, lc InputLifecycle) error {
+	if !api.config.Control.Enabled {
+		return nil
+	}
+
+	auth := func(methods ...string) func(http.HandlerFunc) http.Handler {
+		return func(h http.HandlerFunc) http.Handler {
+			return controlAuth(api.config.Control.Token, methods, h)
+		}
+	}
+	get, post := auth(http.MethodGet), auth(http.MethodPost)
+
+	return multierr.Combine(
+		api.AttachHandler("/control/log/level", auth(http.MethodGet, http.MethodPut)(logLevelHandler)),
+		api.AttachHandler("/control/gc", post(gcHandler)),
+		api.AttachHandler("/control/heap_profile", get(heapProfileHandler)),
+		api.AttachHandler("/control/queue", get(makeQueueHandler(ns))),
+		api.AttachHandler("/control/inputs", get(makeInputsHandler(inputs, lc))),
+		api.AttachHandler("/control/inputs/{id}/{action:pause|resume}", post(makeInputActionHandler(inputs))),
+		api.AttachHandler("/control/inputs/{id}/{action:start|stop|reload}", post(makeInputLifecycleHandler(lc))),
+		api.AttachHandler("/control/inputs/{id}/cursors", get(makeInputCursorsHandler(lc))),
+	)
+}
+
+// controlAuth checks the bearer token and the method of the requests.
+func controlAuth(token string, methods []string, h http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !found || subtle.ConstantTimeCompare([]byte(auth), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeControlError(w, http.StatusUnauthorized, "missing or invalid token")
+			return
+		}
+		for _, m := range methods {
+			if r.Method == m {
+				h(w, r)
+				return
+			}
+		}
+		w.Header().Set("Allow", strings.Join(methods, ", "))
+		writeControlError(w, http.StatusMethodNotAllowed, fmt.Sprintf("method %s not allowed", r.Method))
+	})
+}
+
+func writeControlJSON(w http.ResponseWriter, status int, data mapstr.M) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	fmt.Fprint(w, data.String())
+}
+
+func writeControlError(w http.ResponseWriter, status int, msg string) {
+	writeControlJSON(w, status, mapstr.M{"error": msg})
+}
+
+// logLevelHandler returns or changes the level of the logger. When a
+// selector is given, only the level of the loggers of this selector is
+// changed. An empty level resets the selector to the global level.
+func logLevelHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPut {
+		var body struct {
+			Level    string `json:"level"`
+			Selector string `json:"selector"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeControlError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+			return
+		}
+
+		log := logp.NewLogger("api")
+		switch {
+		case body.Selector != "" && body.Level == "":
+			loglevel.Default.Reset(body.Selector)
+			log.Infof("Log level of selector '%s' reset through the control API", body.Selector)
+		default:
+			var level logp.Level
+			if err := level.Unpack(body.Level); err != nil {
+				writeControlError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			if body.Selector == "" {
+				logp.SetLevel(level.ZapLevel())
+				log.Infof("Log level changed to %v through the control API", level)
+				break
+			}
+			if err := loglevel.Default.Set(body.Selector, level.ZapLevel()); err != nil {
+				writeControlError(w, http.StatusConflict, err.Error())
+				return
+			}
+			log.Infof("Log level of selector '%s' changed to %v through the control API", body.Selector, level)
+		}
+	}
+
+	selectors := mapstr.M{}
+	for selector, level := range loglevel.Default.Levels() {
+		selectors[selector] = level.String()
+	}
+	writeControlJSON(w, http.StatusOK, mapstr.M{
+		"level":     logp.GetLevel().String(),
+		"selectors": selectors,
+	})
+}
+
+// gcHandler runs a garbage collection and returns memory to the OS.
+func gcHandler(w http.ResponseWriter, _ *http.Request) {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	debug.FreeOSMemory()
+	runtime.ReadMemStats(&after)
+
+	writeControlJSON(w, http.StatusOK, mapstr.M{
+		"heap_alloc": mapstr.M{"before": before.HeapAlloc, "after": after.HeapAlloc},
+		"heap_sys":   mapstr.M{"before": before.HeapSys, "after": after.HeapSys},
+	})
+}
+
+// heapProfileHandler writes a heap profile in the format read by go tool
+// pprof. A garbage collection is run first when the gc parameter is set,
+// to report up to date live objects.
+func heapProfileHandler(w http.ResponseWriter, r *http.Request) {
+	if _, ok := r.URL.Query()["gc"]; ok {
+		runtime.GC()
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", `attachment; filename="heap"`)
+	if err := pprof.Lookup("heap").WriteTo(w, 0); err != nil {
+		writeControlError(w, http.StatusInternalServerError, fmt.Sprintf("failed to write heap profile: %v", err))
+	}
+}
+
+// makeQueueHandler returns the state of the queue and of the pipeline.
+func makeQueueHandler(ns lookupFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data := mapstr.M{}
+		if reg := ns("stats").GetRegistry().GetRegistry("libbeat.pipeline"); reg != nil {
+			data["pipeline"] = monitoring.CollectStructSnapshot(reg, monitoring.Full, false)
+		}
+		if reg := ns("state").GetRegistry().GetRegistry("queue"); reg != nil {
+			data["queue"] = monitoring.CollectStructSnapshot(reg, monitoring.Full, false)
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		prettyPrint(w, data, r.URL)
+	}
+}
+
+// makeInputsHandler lists the inputs that can be paused or whose lifecycle
+// can be controlled.
+func makeInputsHandler(inputs InputController, lc InputLifecycle) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		byID := map[string]mapstr.M{}
+		for _, id := range inputs.IDs() {
+			if paused, found := inputs.Paused(id); found {
+				byID[id] = mapstr.M{"id": id, "paused": paused}
+			}
+		}
+		for _, id := range lc.IDs() {
+			input := lc.Get(id)
+			if input == nil {
+				continue
+			}
+			entry, found := byID[id]
+			if !found {
+				entry = mapstr.M{"id": id}
+				byID[id] = entry
+			}
+			entry["type"] = input.Type()
+			entry["running"] = input.Running()
+		}
+
+		ids := make([]string, 0, len(byID))
+		for id := range byID {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		list := make([]mapstr.M, 0, len(ids))
+		for _, id := range ids {
+			list = append(list, byID[id])
+		}
+		writeControlJSON(w, http.StatusOK, mapstr.M{"inputs": list})
+	}
+}
+
+// makeInputActionHandler pauses or resumes an input.
+func makeInputActionHandler(inputs InputController) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, action := vars["id"], vars["action"]
+		var found bool
+		if action == "pause" {
+			found = inputs.Pause(id)
+		} else {
+			found = inputs.Resume(id)
+		}
+		if !found {
+			writeControlError(w, http.StatusNotFound, fmt.Sprintf("input %q not found", id))
+			return
+		}
+		logp.NewLogger("api").Infof("Input %q %sd through the control API", id, action)
+		paused, _ := inputs.Paused(id)
+		writeControlJSON(w, http.StatusOK, mapstr.M{"id": id, "paused": paused})
+	}
+}
+
+// makeInputLifecycleHandler stops, starts or reloads an input. Reloading
+// creates the input again from its configuration, for example after the
+// files it reads were rotated by hand.
+func makeInputLifecycleHandler(lc InputLifecycle) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, action := vars["id"], vars["action"]
+		input := lc.Get(id)
+		if input == nil {
+			writeControlError(w, http.StatusNotFound, fmt.Sprintf("input %q not found", id))
+			return
+		}
+
+		var err error
+		switch action {
+		case "start":
+			err = input.Start()
+		case "stop":
+			input.Stop()
+		case "reload":
+			err = input.Reload()
+		}
+		if err != nil {
+			writeControlError(w, http.StatusInternalServerError, fmt.Sprintf("failed to %s input %q: %v", action, id, err))
+			return
+		}
+		logp.NewLogger("api").Infof("Input %q %s through the control API", id, pastTense(action))
+		writeControlJSON(w, http.StatusOK, mapstr.M{"id": id, "running": input.Running()})
+	}
+}
+
+// makeInputCursorsHandler dumps the cursors the input has stored in the
+// registry.
+func makeInputCursorsHandler(lc InputLifecycle) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+		input := lc.Get(id)
+		if input == nil {
+			writeControlError(w, http.StatusNotFound, fmt.Sprintf("input %q not found", id))
+			return
+		}
+		cursors, ok := input.Cursors()
+		if !ok {
+			writeControlError(w, http.StatusNotImplemented, fmt.Sprintf("input %q of type %s does not report cursors", id, input.Type()))
+			return
+		}
+		writeControlJSON(w, http.StatusOK, mapstr.M{"id": id, "cursors": cursors})
+	}
+}
+
+func pastTense(action string) string {
+	switch action {
+	case "stop":
+		return "stopped"
+	case "reload":
+		return "reloaded"
+	default:
+		return action + "ed"
+	}
+}
@@ -0,0 +1,356 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/elastic/beats/v7/libbeat/common/loglevel"
+	"github.com/elastic/beats/v7/libbeat/management/lifecycle"
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+type testInputs map[string]bool
+
+func (in testInputs) IDs() []string {
+	var ids []string
+	for id := range in {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (in testInputs) Paused(id string) (bool, bool) {
+	paused, found := in[id]
+	return paused, found
+}
+
+func (in testInputs) Pause(id string) bool  { return in.set(id, true) }
+func (in testInputs) Resume(id string) bool { return in.set(id, false) }
+
+func (in testInputs) set(id string, paused bool) bool {
+	if _, found := in[id]; !found {
+		return false
+	}
+	in[id] = paused
+	return true
+}
+
+type testLifecycle map[string]*testInput
+
+func (lc testLifecycle) IDs() []string {
+	var ids []string
+	for id := range lc {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (lc testLifecycle) Get(id string) lifecycle.Input {
+	if input, found := lc[id]; found {
+		return input
+	}
+	return nil
+}
+
+type testInput struct {
+	running   bool
+	reloads   int
+	reloadErr error
+	cursors   map[string]interface{}
+}
+
+func (in *testInput) Type() string  { return "filestream" }
+func (in *testInput) Running() bool { return in.running }
+func (in *testInput) Start() error  { in.running = true; return nil }
+func (in *testInput) Stop()         { in.running = false }
+
+func (in *testInput) Reload() error {
+	in.running = false
+	if in.reloadErr != nil {
+		return in.reloadErr
+	}
+	in.reloads++
+	in.running = true
+	return nil
+}
+
+func (in *testInput) Cursors() (map[string]interface{}, bool) {
+	return in.cursors, in.cursors != nil
+}
+
+func newControlServer(t *testing.T, inputs InputController, lc InputLifecycle) *Server {
+	t.Helper()
+	cfg := config.MustNewConfigFrom(map[string]interface{}{
+		"host":            "http://localhost:0",
+		"control.enabled": true,
+		"control.token":   "secret",
+	})
+	s, err := New(nil, cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Stop() })
+
+	reg := monitoring.NewRegistry()
+	monitoring.NewInt(reg, "libbeat.pipeline.queue.filled.events").Set(42)
+	ns := func(string) *monitoring.Namespace {
+		n := &monitoring.Namespace{}
+		n.SetRegistry(reg)
+		return n
+	}
+	require.NoError(t, AttachControlHandlers(s, ns, inputs, lc))
+	return s
+}
+
+func controlRequest(t *testing.T, s *Server, method, path, token, body string) (int, map[string]interface{}) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	s.Router().ServeHTTP(w, req)
+
+	resp := w.Result()
+	defer resp.Body.Close()
+	var data map[string]interface{}
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&data))
+	}
+	return resp.StatusCode, data
+}
+
+func TestControlConfig(t *testing.T) {
+	cfg := config.MustNewConfigFrom(map[string]interface{}{
+		"host":            "http://localhost:0",
+		"control.enabled": true,
+	})
+	_, err := New(nil, cfg)
+	require.ErrorContains(t, err, "a token is required when the control endpoints are enabled")
+
+	for host, valid := range map[string]bool{
+		"localhost":                true,
+		"127.0.0.1":                true,
+		"http://[::1]:0":           true,
+		"unix:///tmp/beat.sock":    true,
+		"0.0.0.0":                  false,
+		"http://192.168.1.10:5066": false,
+	} {
+		cfg := Config{Host: host, Port: 5066, Control: ControlConfig{Enabled: true, Token: "secret"}}
+		if valid {
+			assert.NoError(t, cfg.Validate(), host)
+		} else {
+			assert.ErrorContains(t, cfg.Validate(), "can only be enabled on a unix socket, a named pipe or a loopback address", host)
+		}
+	}
+}
+
+func TestControlDisabled(t *testing.T) {
+	s, err := New(nil, config.MustNewConfigFrom(map[string]interface{}{"host": "http://localhost:0"}))
+	require.NoError(t, err)
+	defer s.Stop()
+	require.NoError(t, AttachControlHandlers(s, nil, testInputs{}, testLifecycle{}))
+
+	status, _ := controlRequest(t, s, http.MethodPost, "/control/gc", "", "")
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestControlAuth(t *testing.T) {
+	s := newControlServer(t, testInputs{}, testLifecycle{})
+
+	status, data := controlRequest(t, s, http.MethodPost, "/control/gc", "", "")
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Equal(t, "missing or invalid token", data["error"])
+
+	status, _ = controlRequest(t, s, http.MethodPost, "/control/gc", "wrong", "")
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	status, _ = controlRequest(t, s, http.MethodGet, "/control/gc", "secret", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, status)
+
+	status, data = controlRequest(t, s, http.MethodPost, "/control/gc", "secret", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, data, "heap_alloc")
+}
+
+func TestControlLogLevel(t *testing.T) {
+	require.NoError(t, logp.DevelopmentSetup(logp.WithLevel(logp.InfoLevel)))
+	s := newControlServer(t, testInputs{}, testLifecycle{})
+
+	status, data := controlRequest(t, s, http.MethodGet, "/control/log/level", "secret", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "info", data["level"])
+
+	status, data = controlRequest(t, s, http.MethodPut, "/control/log/level", "secret", `{"level": "debug"}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "debug", data["level"])
+	assert.Equal(t, zapcore.DebugLevel, logp.GetLevel())
+
+	status, _ = controlRequest(t, s, http.MethodPut, "/control/log/level", "secret", `{"level": "verbose"}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, zapcore.DebugLevel, logp.GetLevel())
+
+	// Selector levels are only available once installed in the logger.
+	status, _ = controlRequest(t, s, http.MethodPut, "/control/log/level", "secret", `{"level": "warning", "selector": "publisher"}`)
+	assert.Equal(t, http.StatusConflict, status)
+	require.NoError(t, loglevel.Default.Install())
+
+	status, data = controlRequest(t, s, http.MethodPut, "/control/log/level", "secret", `{"level": "warning", "selector": "publisher"}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "debug", data["level"])
+	assert.Equal(t, map[string]interface{}{"publisher": "warn"}, data["selectors"])
+	assert.Equal(t, zapcore.DebugLevel, logp.GetLevel())
+
+	status, data = controlRequest(t, s, http.MethodPut, "/control/log/level", "secret", `{"selector": "publisher"}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, data["selectors"])
+}
+
+func TestControlHeapProfile(t *testing.T) {
+	s := newControlServer(t, testInputs{}, testLifecycle{})
+
+	req := httptest.NewRequest(http.MethodGet, "/control/heap_profile?gc", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	s.Router().ServeHTTP(w, req)
+
+	resp := w.Result()
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	// Profiles are gzip compressed protocol buffers.
+	require.Greater(t, len(body), 2)
+	assert.Equal(t, []byte{0x1f, 0x8b}, body[:2])
+}
+
+func TestControlQueue(t *testing.T) {
+	s := newControlServer(t, testInputs{}, testLifecycle{})
+
+	status, data := controlRequest(t, s, http.MethodGet, "/control/queue", "secret", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]interface{}{
+		"queue": map[string]interface{}{
+			"filled": map[string]interface{}{"events": float64(42)},
+		},
+	}, data["pipeline"])
+}
+
+func TestControlInputs(t *testing.T) {
+	inputs := testInputs{"filestream-a": false, "filestream-b": false}
+	s := newControlServer(t, inputs, testLifecycle{})
+
+	status, data := controlRequest(t, s, http.MethodPost, "/control/inputs/filestream-a/pause", "secret", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]interface{}{"id": "filestream-a", "paused": true}, data)
+	assert.True(t, inputs["filestream-a"])
+
+	status, data = controlRequest(t, s, http.MethodGet, "/control/inputs", "secret", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"id": "filestream-a", "paused": true},
+		map[string]interface{}{"id": "filestream-b", "paused": false},
+	}, data["inputs"])
+
+	status, _ = controlRequest(t, s, http.MethodPost, "/control/inputs/filestream-a/resume", "secret", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.False(t, inputs["filestream-a"])
+
+	status, data = controlRequest(t, s, http.MethodPost, "/control/inputs/missing/pause", "secret", "")
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, `input "missing" not found`, data["error"])
+}
+
+func TestControlInputsLifecycle(t *testing.T) {
+	inputs := testInputs{"filestream-a": false}
+	lc := testLifecycle{
+		"filestream-a": &testInput{running: true},
+		"filestream-b": &testInput{running: true, reloadErr: errors.New("invalid configuration")},
+	}
+	s := newControlServer(t, inputs, lc)
+
+	status, data := controlRequest(t, s, http.MethodPost, "/control/inputs/filestream-a/stop", "secret", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]interface{}{"id": "filestream-a", "running": false}, data)
+
+	status, data = controlRequest(t, s, http.MethodGet, "/control/inputs", "secret", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"id": "filestream-a", "type": "filestream", "running": false, "paused": false},
+		map[string]interface{}{"id": "filestream-b", "type": "filestream", "running": true},
+	}, data["inputs"])
+
+	status, _ = controlRequest(t, s, http.MethodPost, "/control/inputs/filestream-a/start", "secret", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.True(t, lc["filestream-a"].running)
+
+	status, _ = controlRequest(t, s, http.MethodPost, "/control/inputs/filestream-a/reload", "secret", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 1, lc["filestream-a"].reloads)
+
+	status, data = controlRequest(t, s, http.MethodPost, "/control/inputs/filestream-b/reload", "secret", "")
+	assert.Equal(t, http.StatusInternalServerError, status)
+	assert.Equal(t, `failed to reload input "filestream-b": invalid configuration`, data["error"])
+
+	status, _ = controlRequest(t, s, http.MethodGet, "/control/inputs/filestream-a/stop", "secret", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, status)
+
+	status, data = controlRequest(t, s, http.MethodPost, "/control/inputs/missing/stop", "secret", "")
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, `input "missing" not found`, data["error"])
+}
+
+func TestControlInputCursors(t *testing.T) {
+	lc := testLifecycle{
+		"filestream-a": &testInput{cursors: map[string]interface{}{
+			"filestream::filestream-a::native::1-2": map[string]interface{}{"offset": 42},
+		}},
+		"other": &testInput{},
+	}
+	s := newControlServer(t, testInputs{}, lc)
+
+	status, data := controlRequest(t, s, http.MethodGet, "/control/inputs/filestream-a/cursors", "secret", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]interface{}{
+		"id": "filestream-a",
+		"cursors": map[string]interface{}{
+			"filestream::filestream-a::native::1-2": map[string]interface{}{"offset": float64(42)},
+		},
+	}, data)
+
+	status, data = controlRequest(t, s, http.MethodGet, "/control/inputs/other/cursors", "secret", "")
+	assert.Equal(t, http.StatusNotImplemented, status)
+	assert.Equal(t, `input "other" of type filestream does not report cursors`, data["error"])
+
+	status, _ = controlRequest(t, s, http.MethodGet, "/control/inputs/missing/cursors", "secret", "")
+	assert.Equal(t, http.StatusNotFound, status)
+}
@@ -0,0 +1,65 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/elastic/beats/v7/libbeat/management/status"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// makeHealthHandler reports the aggregated health of the inputs of the beat.
+// It responds with 503 Service Unavailable when an input failed, so it can
+// be used as a health check.
+func makeHealthHandler(health *status.Health) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		state := health.State()
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if state.Status == status.Failed {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+
+		units := make([]mapstr.M, 0, len(state.Units))
+		for _, u := range state.Units {
+			unit := mapstr.M{
+				"id":     u.ID,
+				"name":   u.Name,
+				"status": u.Status,
+				"since":  u.Since,
+			}
+			if u.Message != "" {
+				unit["message"] = u.Message
+			}
+			units = append(units, unit)
+		}
+		data := mapstr.M{
+			"status": state.Name(),
+			"inputs": mapstr.M{
+				"total":     state.Total,
+				"unhealthy": units,
+			},
+		}
+		if len(state.Reasons) > 0 {
+			data["reasons"] = state.Reasons
+		}
+
+		prettyPrint(w, data, r.URL)
+	}
+}
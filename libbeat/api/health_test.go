@@ -0,0 +1,65 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/management/status"
+)
+
+func TestHealthHandler(t *testing.T) {
+	health := status.NewHealth()
+	handler := makeHealthHandler(health)
+
+	get := func() (int, map[string]interface{}) {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return rec.Code, body
+	}
+
+	code, body := get()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "running", body["status"])
+
+	a, _ := health.Register("a", "winlog", nil)
+	a.UpdateStatus(status.Degraded, "dropped events")
+
+	code, body = get()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "degraded", body["status"])
+	assert.Equal(t, []interface{}{"winlog input 'a' is degraded: dropped events"}, body["reasons"])
+	inputs := body["inputs"].(map[string]interface{})
+	assert.EqualValues(t, 1, inputs["total"])
+	unhealthy := inputs["unhealthy"].([]interface{})
+	require.Len(t, unhealthy, 1)
+	assert.Equal(t, "dropped events", unhealthy[0].(map[string]interface{})["message"])
+
+	a.UpdateStatus(status.Failed, "crashed")
+	code, body = get()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "failed", body["status"])
+}
@@ -23,3 +23,14 @@ import "strings"
 func IsNPipe(s string) bool {
 	return strings.HasPrefix(s, "npipe:///") || strings.HasPrefix(s, `\\.\pipe\`)
 }
+
+// TransformString takes an input type name defined as a URI like
+// `npipe:///hello` and transforms it into // `\\.\pipe\hello`
+func TransformString(name string) string {
+	if strings.HasPrefix(name, "npipe:///") {
+		path := strings.TrimPrefix(name, "npipe:///")
+		return `\\.\pipe\` + path
+	}
+
+	return name
+}
@@ -0,0 +1,235 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package api
+
+import (
+	"bufio"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+const (
+	prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+	// prometheusPrefix is prepended to every exported metric name so that
+	// metrics from different beats scraped into the same Prometheus server
+	// share a common namespace and can be told apart by the beat label of
+	// beat_info.
+	prometheusPrefix = "beat_"
+)
+
+// promSample is a single sample of a metric family.
+type promSample struct {
+	labels [][2]string
+	value  float64
+}
+
+// promMetrics groups samples by metric family name.
+type promMetrics map[string][]promSample
+
+func (m promMetrics) add(name string, value float64, labels ...[2]string) {
+	m[name] = append(m[name], promSample{labels: labels, value: value})
+}
+
+// makePrometheusHandler returns a handler that renders the monitoring
+// registries in the Prometheus text exposition format. Metrics from the
+// 'stats' namespace are exported with their dotted registry path converted
+// to a metric name, per input metrics from the 'dataset' namespace are
+// exported under beat_input_* with the input type and id as labels.
+func makePrometheusHandler(info, stats, dataset *monitoring.Namespace) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		metrics := promMetrics{}
+		collectInfoMetrics(metrics, info.GetRegistry())
+		collectStatsMetrics(metrics, stats.GetRegistry())
+		collectInputMetrics(metrics, dataset.GetRegistry())
+
+		w.Header().Set("Content-Type", prometheusContentType)
+		bw := bufio.NewWriter(w)
+		writePrometheus(bw, metrics)
+		_ = bw.Flush()
+	}
+}
+
+// collectInfoMetrics exports the beat info as labels of the constant
+// beat_info metric, following the Prometheus convention for build
+// information.
+func collectInfoMetrics(metrics promMetrics, reg *monitoring.Registry) {
+	snapshot := monitoring.CollectFlatSnapshot(reg, monitoring.Full, false)
+	var labels [][2]string
+	for _, key := range []string{"beat", "name", "version", "uuid"} {
+		if v, ok := snapshot.Strings[key]; ok {
+			labels = append(labels, [2]string{key, v})
+		}
+	}
+	metrics.add(prometheusPrefix+"info", 1, labels...)
+}
+
+// dataStreamsKey is the stats path of the per index/data stream output
+// metrics. Data stream names are exported as a label rather than as part of
+// the metric name.
+const dataStreamsKey = "libbeat.output.data_streams"
+
+func collectStatsMetrics(metrics promMetrics, reg *monitoring.Registry) {
+	snapshot := monitoring.CollectFlatSnapshot(reg, monitoring.Full, false)
+	for k, v := range snapshot.Ints {
+		if strings.HasPrefix(k, dataStreamsKey+".") {
+			continue
+		}
+		metrics.add(prometheusPrefix+sanitizeMetricName(k), float64(v))
+	}
+	for k, v := range snapshot.Floats {
+		metrics.add(prometheusPrefix+sanitizeMetricName(k), v)
+	}
+	for k, v := range snapshot.Bools {
+		metrics.add(prometheusPrefix+sanitizeMetricName(k), boolToFloat(v))
+	}
+
+	if output := reg.GetRegistry("libbeat.output"); output != nil {
+		collectDataStreamMetrics(metrics, output)
+	}
+}
+
+func collectDataStreamMetrics(metrics promMetrics, reg *monitoring.Registry) {
+	snapshot := monitoring.CollectStructSnapshot(reg, monitoring.Full, false)
+	streams, ok := snapshot["data_streams"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	for name, ifc := range streams {
+		m, ok := ifc.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		labels := [][2]string{{"data_stream", name}}
+		collectNested(metrics, prometheusPrefix+sanitizeMetricName(dataStreamsKey), m, labels)
+	}
+}
+
+func collectInputMetrics(metrics promMetrics, reg *monitoring.Registry) {
+	snapshot := monitoring.CollectStructSnapshot(reg, monitoring.Full, false)
+	for _, ifc := range snapshot {
+		m, ok := ifc.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		// Only inputs registered through inputmon have both of these, and
+		// they are needed to tell input instances apart.
+		id, _ := m["id"].(string)
+		inputType, _ := m["input"].(string)
+		if id == "" || inputType == "" {
+			continue
+		}
+
+		labels := [][2]string{{"input", inputType}, {"id", id}}
+		collectNested(metrics, prometheusPrefix+"input", m, labels)
+	}
+}
+
+func collectNested(metrics promMetrics, prefix string, m map[string]interface{}, labels [][2]string) {
+	for k, v := range m {
+		name := prefix + "_" + sanitizeMetricName(k)
+		switch v := v.(type) {
+		case int64:
+			metrics.add(name, float64(v), labels...)
+		case float64:
+			metrics.add(name, v, labels...)
+		case bool:
+			metrics.add(name, boolToFloat(v), labels...)
+		case map[string]interface{}:
+			collectNested(metrics, name, v, labels)
+		}
+	}
+}
+
+func writePrometheus(w *bufio.Writer, metrics promMetrics) {
+	names := make([]string, 0, len(metrics))
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		samples := metrics[name]
+		sort.SliceStable(samples, func(i, j int) bool {
+			return labelString(samples[i].labels) < labelString(samples[j].labels)
+		})
+
+		w.WriteString("# TYPE ")
+		w.WriteString(name)
+		w.WriteString(" untyped\n")
+		for _, s := range samples {
+			w.WriteString(name)
+			w.WriteString(labelString(s.labels))
+			w.WriteByte(' ')
+			w.WriteString(strconv.FormatFloat(s.value, 'g', -1, 64))
+			w.WriteByte('\n')
+		}
+	}
+}
+
+func labelString(labels [][2]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, l := range labels {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(l[0])
+		b.WriteString(`="`)
+		b.WriteString(escapeLabelValue(l[1]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(v string) string {
+	return labelValueReplacer.Replace(v)
+}
+
+// sanitizeMetricName converts a registry key into a valid Prometheus metric
+// name by replacing every character outside of [a-zA-Z0-9_] with an
+// underscore.
+func sanitizeMetricName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,85 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+func TestPrometheusHandler(t *testing.T) {
+	info := monitoring.GetNamespace("test_prom_info")
+	stats := monitoring.GetNamespace("test_prom_stats")
+	dataset := monitoring.GetNamespace("test_prom_dataset")
+	info.SetRegistry(monitoring.NewRegistry())
+	stats.SetRegistry(monitoring.NewRegistry())
+	dataset.SetRegistry(monitoring.NewRegistry())
+
+	monitoring.NewString(info.GetRegistry(), "beat").Set("testbeat")
+	monitoring.NewString(info.GetRegistry(), "version").Set("9.9.9")
+
+	pipeline := stats.GetRegistry().NewRegistry("libbeat").NewRegistry("pipeline")
+	monitoring.NewInt(pipeline, "events.published").Set(42)
+	monitoring.NewFloat(stats.GetRegistry(), "system.load.1").Set(0.5)
+	output := stats.GetRegistry().GetRegistry("libbeat").NewRegistry("output")
+	monitoring.NewFunc(output, "data_streams", func(_ monitoring.Mode, V monitoring.Visitor) {
+		V.OnRegistryStart()
+		defer V.OnRegistryFinished()
+		monitoring.ReportNamespace(V, "logs-nginx.access-default", func() {
+			monitoring.ReportInt(V, "events", 3)
+		})
+	}, monitoring.Report)
+	monitoring.NewBool(stats.GetRegistry(), "output.ready").Set(true)
+
+	input := dataset.GetRegistry().NewRegistry("my-input")
+	monitoring.NewString(input, "input").Set("filestream")
+	monitoring.NewString(input, "id").Set(`my "input"`)
+	monitoring.NewUint(input, "events_processed_total").Set(7)
+
+	// Registries without id and input are not exported.
+	monitoring.NewInt(dataset.GetRegistry().NewRegistry("anonymous"), "events").Set(1)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rec := httptest.NewRecorder()
+	makePrometheusHandler(info, stats, dataset)(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, prometheusContentType, rec.Header().Get("Content-Type"))
+
+	body := rec.Body.String()
+	assert.Contains(t, body, "# TYPE beat_info untyped\n")
+	assert.Contains(t, body, `beat_info{beat="testbeat",version="9.9.9"} 1`+"\n")
+	assert.Contains(t, body, "beat_libbeat_pipeline_events_published 42\n")
+	assert.Contains(t, body, "beat_system_load_1 0.5\n")
+	assert.Contains(t, body, "beat_output_ready 1\n")
+	assert.Contains(t, body, `beat_input_events_processed_total{input="filestream",id="my \"input\""} 7`+"\n")
+	assert.NotContains(t, body, "beat_input_events{")
+	assert.Contains(t, body, `beat_libbeat_output_data_streams_events{data_stream="logs-nginx.access-default"} 3`+"\n")
+	assert.NotContains(t, body, "beat_libbeat_output_data_streams_logs")
+}
+
+func TestSanitizeMetricName(t *testing.T) {
+	assert.Equal(t, "libbeat_output_events_acked", sanitizeMetricName("libbeat.output.events.acked"))
+	assert.Equal(t, "system_cpu_total_pct", sanitizeMetricName("system.cpu.total-pct"))
+}
@@ -24,6 +24,7 @@ import (
 
 	"go.uber.org/multierr"
 
+	"github.com/elastic/beats/v7/libbeat/management/status"
 	"github.com/elastic/elastic-agent-libs/config"
 	"github.com/elastic/elastic-agent-libs/logp"
 	"github.com/elastic/elastic-agent-libs/mapstr"
@@ -44,6 +45,8 @@ func NewWithDefaultRoutes(log *logp.Logger, config *config.C, ns lookupFunc) (*S
 		api.AttachHandler("/state", makeAPIHandler(ns("state"))),
 		api.AttachHandler("/stats", makeAPIHandler(ns("stats"))),
 		api.AttachHandler("/dataset", makeAPIHandler(ns("dataset"))),
+		api.AttachHandler("/metrics", makePrometheusHandler(ns("info"), ns("stats"), ns("dataset"))),
+		api.AttachHandler("/health", makeHealthHandler(status.DefaultHealth)),
 	)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,253 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package audit records the actions taken by the Beat itself, such as
+// configuration changes, output changes and inputs being started or
+// stopped, to a dedicated log file and optionally to the output.
+//
+// Audit events are recorded with Record. Recording is a no-op until the
+// audit log has been enabled with Configure.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/file"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/paths"
+)
+
+var current atomic.Pointer[Logger]
+
+// Logger writes audit events to the audit log file and, if configured,
+// publishes them to the output.
+type Logger struct {
+	info       beat.Info
+	categories map[Category]bool
+	publish    bool
+
+	mu     sync.Mutex
+	writer *file.Rotator
+	client beat.Client
+	log    *logp.Logger
+}
+
+// record is a single line in the audit log file.
+type record struct {
+	Timestamp time.Time `json:"@timestamp"`
+	Category  Category  `json:"category"`
+	Action    string    `json:"action"`
+	Beat      beatInfo  `json:"beat"`
+	Data      mapstr.M  `json:"data,omitempty"`
+}
+
+type beatInfo struct {
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	ID      string `json:"id"`
+	Version string `json:"version"`
+	Host    string `json:"hostname"`
+}
+
+// Configure enables the audit log if cfg enables it, replacing and
+// closing a previously configured audit log.
+func Configure(info beat.Info, cfg *conf.C) error {
+	c := DefaultConfig()
+	if cfg != nil {
+		if err := cfg.Unpack(&c); err != nil {
+			return fmt.Errorf("error unpacking audit config: %w", err)
+		}
+	}
+	if !c.Enabled {
+		if old := current.Swap(nil); old != nil {
+			return old.Close()
+		}
+		return nil
+	}
+
+	l, err := New(info, c)
+	if err != nil {
+		return err
+	}
+	if old := current.Swap(l); old != nil {
+		_ = old.Close()
+	}
+	return nil
+}
+
+// New creates an audit logger writing to the file configured by c.
+func New(info beat.Info, c Config) (*Logger, error) {
+	dir := c.File.Path
+	if dir == "" {
+		dir = paths.Resolve(paths.Logs, "")
+	}
+	name := c.File.Name
+	if name == "" {
+		name = info.Beat + "-audit"
+	}
+
+	w, err := file.NewFileRotator(
+		filepath.Join(dir, name),
+		file.MaxSizeBytes(c.File.RotateEveryKb*1024),
+		file.MaxBackups(c.File.NumberOfFiles),
+		file.Permissions(os.FileMode(c.File.Permissions)),
+		file.Extension("ndjson"),
+		file.RotateOnStartup(c.File.RotateOnStartup),
+		file.WithLogger(logp.NewLogger("rotator").With(logp.Namespace("rotator"))),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create audit log file: %w", err)
+	}
+
+	categories := make(map[Category]bool, len(c.Categories))
+	for _, cat := range c.Categories {
+		categories[Category(cat)] = true
+	}
+	return &Logger{
+		info:       info,
+		categories: categories,
+		publish:    c.Publish,
+		writer:     w,
+		log:        logp.NewLogger("audit"),
+	}, nil
+}
+
+// Connect publishes the audit events to the output of pipeline, if the
+// audit log is enabled and configured to publish events.
+func Connect(pipeline beat.PipelineConnector) error {
+	l := current.Load()
+	if l == nil || !l.publish {
+		return nil
+	}
+	return l.Connect(pipeline)
+}
+
+// Connect publishes the events recorded by l to the output of pipeline.
+func (l *Logger) Connect(pipeline beat.PipelineConnector) error {
+	client, err := pipeline.ConnectWith(beat.ClientConfig{
+		PublishMode: beat.DropIfFull,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to connect audit log to the pipeline: %w", err)
+	}
+
+	l.mu.Lock()
+	old := l.client
+	l.client = client
+	l.mu.Unlock()
+	if old != nil {
+		_ = old.Close()
+	}
+	return nil
+}
+
+// Enabled reports whether events of category are recorded. It allows
+// callers to skip building the event data.
+func Enabled(category Category) bool {
+	l := current.Load()
+	return l != nil && l.categories[category]
+}
+
+// Record records an audit event if the audit log is enabled.
+func Record(category Category, action string, data mapstr.M) {
+	if l := current.Load(); l != nil {
+		l.Record(category, action, data)
+	}
+}
+
+// Record records an audit event if its category is enabled.
+func (l *Logger) Record(category Category, action string, data mapstr.M) {
+	if !l.categories[category] {
+		return
+	}
+
+	now := time.Now().UTC()
+	line, err := json.Marshal(record{
+		Timestamp: now,
+		Category:  category,
+		Action:    action,
+		Beat: beatInfo{
+			Type:    l.info.Beat,
+			Name:    l.info.Name,
+			ID:      l.info.ID.String(),
+			Version: l.info.Version,
+			Host:    l.info.Hostname,
+		},
+		Data: data,
+	})
+	if err != nil {
+		l.log.Errorf("Failed to encode audit event %s/%s: %v", category, action, err)
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.writer == nil {
+		return
+	}
+	if _, err := l.writer.Write(append(line, '\n')); err != nil {
+		l.log.Errorf("Failed to write audit event %s/%s: %v", category, action, err)
+	}
+	if l.client != nil {
+		l.client.Publish(beat.Event{
+			Timestamp: now,
+			Fields: mapstr.M{
+				"event": mapstr.M{
+					"kind":     "event",
+					"category": []string{"configuration"},
+					"action":   string(category) + "-" + action,
+					"dataset":  l.info.Beat + ".audit",
+				},
+				"audit": data,
+			},
+		})
+	}
+}
+
+// Close stops recording events, closing the audit log file and the
+// pipeline client.
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.client != nil {
+		_ = l.client.Close()
+		l.client = nil
+	}
+	if l.writer == nil {
+		return nil
+	}
+	err := l.writer.Close()
+	l.writer = nil
+	return err
+}
+
+// Close closes the audit log configured with Configure.
+func Close() error {
+	if l := current.Swap(nil); l != nil {
+		return l.Close()
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	pubtest "github.com/elastic/beats/v7/libbeat/publisher/testing"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func TestLogger(t *testing.T) {
+	dir := t.TempDir()
+	c := DefaultConfig()
+	c.Enabled = true
+	c.File.Path = dir
+	c.File.Name = "test-audit"
+
+	l, err := New(beat.Info{Beat: "testbeat", Name: "test", Version: "1.0.0"}, c)
+	require.NoError(t, err)
+
+	client := pubtest.NewChanClient(10)
+	require.NoError(t, l.Connect(pubtest.ConstClient(client)))
+
+	l.Record(CategoryInput, "started", mapstr.M{"runner": "input [type=log]"})
+	l.Record(CategoryStateStore, "set", mapstr.M{"key": "ignored"})
+	require.NoError(t, l.Close())
+	l.Record(CategoryInput, "stopped", nil)
+
+	files, err := filepath.Glob(filepath.Join(dir, "test-audit-*.ndjson"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	f, err := os.Open(files[0])
+	require.NoError(t, err)
+	defer f.Close()
+
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	require.Len(t, lines, 1, "only enabled categories of an open logger must be recorded")
+	assert.Equal(t, "input", lines[0]["category"])
+	assert.Equal(t, "started", lines[0]["action"])
+	assert.Equal(t, map[string]interface{}{"runner": "input [type=log]"}, lines[0]["data"])
+	assert.Equal(t, "testbeat", lines[0]["beat"].(map[string]interface{})["type"])
+
+	event := <-client.Channel
+	action, _ := event.Fields.GetValue("event.action")
+	assert.Equal(t, "input-started", action)
+	runner, _ := event.Fields.GetValue("audit.runner")
+	assert.Equal(t, "input [type=log]", runner)
+}
+
+func TestConfigure(t *testing.T) {
+	t.Cleanup(func() { _ = Close() })
+
+	require.NoError(t, Configure(beat.Info{Beat: "testbeat"}, nil))
+	assert.False(t, Enabled(CategoryConfig), "audit log must be disabled by default")
+	Record(CategoryConfig, "loaded", nil)
+
+	cfg := conf.MustNewConfigFrom(mapstr.M{
+		"enabled":    true,
+		"categories": []string{"state_store"},
+		"file.path":  t.TempDir(),
+	})
+	require.NoError(t, Configure(beat.Info{Beat: "testbeat"}, cfg))
+	assert.True(t, Enabled(CategoryStateStore))
+	assert.False(t, Enabled(CategoryConfig))
+
+	cfg = conf.MustNewConfigFrom(mapstr.M{"enabled": true, "categories": []string{"unknown"}})
+	assert.Error(t, Configure(beat.Info{Beat: "testbeat"}, cfg))
+}
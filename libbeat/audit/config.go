@@ -0,0 +1,87 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package audit
+
+import (
+	"fmt"
+
+	"github.com/elastic/elastic-agent-libs/file"
+)
+
+// Category groups related audit events. Each category can be enabled
+// separately.
+type Category string
+
+const (
+	// CategoryConfig covers loading and reloading of configuration files.
+	CategoryConfig Category = "config"
+	// CategoryOutput covers output changes.
+	CategoryOutput Category = "output"
+	// CategoryInput covers inputs and modules being started and stopped.
+	CategoryInput Category = "input"
+	// CategoryStateStore covers changes to the persistent state stores,
+	// such as the Filebeat registry. This category generates a large
+	// number of events and is not enabled by default.
+	CategoryStateStore Category = "state_store"
+)
+
+// Config configures the audit log.
+type Config struct {
+	Enabled    bool     `config:"enabled"`
+	Categories []string `config:"categories"`
+	// Publish sends audit events to the configured output in addition
+	// to the audit log file.
+	Publish bool       `config:"publish"`
+	File    fileConfig `config:"file"`
+}
+
+type fileConfig struct {
+	Path            string `config:"path"`
+	Name            string `config:"name"`
+	RotateEveryKb   uint   `config:"rotate_every_kb" validate:"min=1"`
+	NumberOfFiles   uint   `config:"number_of_files"`
+	Permissions     uint32 `config:"permissions"`
+	RotateOnStartup bool   `config:"rotate_on_startup"`
+}
+
+// DefaultConfig returns the default audit log configuration.
+func DefaultConfig() Config {
+	return Config{
+		Categories: []string{string(CategoryConfig), string(CategoryOutput), string(CategoryInput)},
+		File: fileConfig{
+			RotateEveryKb: 10 * 1024,
+			NumberOfFiles: 7,
+			Permissions:   0600,
+		},
+	}
+}
+
+func (c *Config) Validate() error {
+	for _, cat := range c.Categories {
+		switch Category(cat) {
+		case CategoryConfig, CategoryOutput, CategoryInput, CategoryStateStore:
+		default:
+			return fmt.Errorf("unknown audit category %q", cat)
+		}
+	}
+	if c.File.NumberOfFiles < 2 || c.File.NumberOfFiles > file.MaxBackupsLimit {
+		return fmt.Errorf("the number_of_files to keep should be between 2 and %v",
+			file.MaxBackupsLimit)
+	}
+	return nil
+}
@@ -33,6 +33,7 @@ import (
 
 	"github.com/elastic/beats/v7/libbeat/autodiscover"
 	"github.com/elastic/beats/v7/libbeat/autodiscover/template"
+	"github.com/elastic/beats/v7/libbeat/common/kubernetes/watchers"
 	"github.com/elastic/elastic-agent-autodiscover/bus"
 	"github.com/elastic/elastic-agent-autodiscover/kubernetes"
 	"github.com/elastic/elastic-agent-autodiscover/kubernetes/k8skeystore"
@@ -73,6 +74,9 @@ type Provider struct {
 	appenders    autodiscover.Appenders
 	logger       *logp.Logger
 	eventManager EventManager
+	// uniqueManager runs the leader election for the unique templates of
+	// a provider that is not unique itself, nil if there are none.
+	uniqueManager EventManager
 }
 
 // eventerManager implements start/stop methods for autodiscover provider with resource eventer
@@ -109,7 +113,7 @@ func AutodiscoverBuilder(
 		return nil, errWrap(err)
 	}
 
-	client, err := kubernetes.GetKubernetesClient(config.KubeConfig, config.KubeClientOptions)
+	client, err := watchers.Default.Client(config.KubeConfig, config.KubeClientOptions)
 	if err != nil {
 		return nil, errWrap(err)
 	}
@@ -144,6 +148,9 @@ func AutodiscoverBuilder(
 		p.eventManager, err = NewLeaderElectionManager(uuid, config, client, p.startLeading, p.stopLeading, logger)
 	} else {
 		p.eventManager, err = NewEventerManager(uuid, c, config, client, p.publish)
+		if err == nil && mapper.HasUnique() {
+			p.uniqueManager, err = NewLeaderElectionManager(uuid, config, client, p.startLeadingUnique, p.stopLeadingUnique, logger)
+		}
 	}
 
 	if err != nil {
@@ -156,10 +163,16 @@ func AutodiscoverBuilder(
 // Start for Runner interface.
 func (p *Provider) Start() {
 	p.eventManager.Start()
+	if p.uniqueManager != nil {
+		p.uniqueManager.Start()
+	}
 }
 
 // Stop signals the stop channel to force the watch loop routine to stop.
 func (p *Provider) Stop() {
+	if p.uniqueManager != nil {
+		p.uniqueManager.Stop()
+	}
 	p.eventManager.Stop()
 }
 
@@ -205,26 +218,33 @@ func (p *Provider) publish(events []bus.Event) {
 }
 
 func (p *Provider) startLeading(uuid string, eventID string) {
-	event := bus.Event{
-		"start":    true,
-		"provider": uuid,
-		"id":       eventID,
-		"unique":   "true",
-	}
-	if config := p.templates.GetConfig(event); config != nil {
-		event["config"] = config
-	}
-	p.bus.Publish(event)
+	p.publishLeaderEvent("start", uuid, eventID, p.templates.GetConfig)
 }
 
 func (p *Provider) stopLeading(uuid string, eventID string) {
+	p.publishLeaderEvent("stop", uuid, eventID, p.templates.GetConfig)
+}
+
+// startLeadingUnique starts the configs of the unique templates when this
+// instance is elected as leader.
+func (p *Provider) startLeadingUnique(uuid string, eventID string) {
+	p.publishLeaderEvent("start", uuid, eventID, p.templates.GetUniqueConfig)
+}
+
+// stopLeadingUnique stops the configs of the unique templates when this
+// instance loses the leadership.
+func (p *Provider) stopLeadingUnique(uuid string, eventID string) {
+	p.publishLeaderEvent("stop", uuid, eventID, p.templates.GetUniqueConfig)
+}
+
+func (p *Provider) publishLeaderEvent(action string, uuid string, eventID string, getConfig func(bus.Event) []*config.C) {
 	event := bus.Event{
-		"stop":     true,
+		action:     true,
 		"provider": uuid,
 		"id":       eventID,
 		"unique":   "true",
 	}
-	if config := p.templates.GetConfig(event); config != nil {
+	if config := getConfig(event); config != nil {
 		event["config"] = config
 	}
 	p.bus.Publish(event)
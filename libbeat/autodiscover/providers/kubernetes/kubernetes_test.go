@@ -30,7 +30,11 @@ import (
 	"k8s.io/client-go/kubernetes"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
 
+	"github.com/elastic/beats/v7/libbeat/autodiscover/template"
+	"github.com/elastic/elastic-agent-autodiscover/bus"
+	"github.com/elastic/elastic-agent-libs/config"
 	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
 )
 
 const namespace = "default"
@@ -234,3 +238,60 @@ func TestNewLeaderElectionManager(t *testing.T) {
 		<-waitForLosingLeader
 	}
 }
+
+// TestUniqueTemplates tests that the unique templates of a provider are only
+// used on leader election, and the other templates only on resource events.
+func TestUniqueTemplates(t *testing.T) {
+	var settings template.MapperSettings
+	cfg, err := config.NewConfigWithYAML([]byte(`
+- config:
+  - module: kubernetes
+    metricsets: [event]
+  unique: true
+- condition.equals:
+    kubernetes.namespace: default
+  config:
+  - module: nginx
+`), "")
+	require.NoError(t, err)
+	require.NoError(t, cfg.Unpack(&settings))
+	mapper, err := template.NewConfigMapper(settings, nil, nil)
+	require.NoError(t, err)
+	require.True(t, mapper.HasUnique())
+
+	p := &Provider{
+		bus:       bus.New(logp.NewLogger("bus"), "test"),
+		templates: mapper,
+		logger:    logp.NewLogger("kubernetes-test"),
+	}
+	listener := p.bus.Subscribe()
+	defer listener.Stop()
+
+	modulesOf := func(event bus.Event) []string {
+		var modules []string
+		configs, _ := event["config"].([]*config.C)
+		for _, c := range configs {
+			module, err := c.String("module", -1)
+			require.NoError(t, err)
+			modules = append(modules, module)
+		}
+		return modules
+	}
+
+	p.startLeadingUnique("uuid", "lease-1")
+	event := <-listener.Events()
+	require.Equal(t, true, event["start"])
+	require.Equal(t, []string{"kubernetes"}, modulesOf(event))
+
+	p.stopLeadingUnique("uuid", "lease-1")
+	event = <-listener.Events()
+	require.Equal(t, true, event["stop"])
+	require.Equal(t, "lease-1", event["id"])
+	require.Equal(t, []string{"kubernetes"}, modulesOf(event))
+
+	require.Equal(t, []string{"nginx"}, modulesOf(bus.Event{
+		"config": mapper.GetConfig(bus.Event{
+			"kubernetes": mapstr.M{"namespace": "default"},
+		}),
+	}))
+}
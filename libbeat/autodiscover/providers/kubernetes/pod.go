@@ -37,6 +37,8 @@ import (
 	conf "github.com/elastic/elastic-agent-libs/config"
 	"github.com/elastic/elastic-agent-libs/logp"
 	"github.com/elastic/elastic-agent-libs/mapstr"
+
+	"github.com/elastic/beats/v7/libbeat/common/kubernetes/watchers"
 )
 
 type pod struct {
@@ -88,12 +90,20 @@ func NewPodEventer(uuid uuid.UUID, cfg *conf.C, client k8s.Interface, publish fu
 
 	logger.Debugf("Initializing a new Kubernetes watcher using node: %v", config.Node)
 
-	watcher, err := kubernetes.NewNamedWatcher("pod", client, &kubernetes.Pod{}, kubernetes.WatchOptions{
+	// Watchers are shared with add_kubernetes_metadata processors and other
+	// eventers watching the same resources with the same options.
+	acquire := func(resource string, opts kubernetes.WatchOptions, create func(kubernetes.WatchOptions) (kubernetes.Watcher, error)) (kubernetes.Watcher, error) {
+		return watchers.Default.Acquire(watchers.NewKey(resource, client, opts), func() (kubernetes.Watcher, error) { return create(opts) })
+	}
+
+	watcher, err := acquire("pod", kubernetes.WatchOptions{
 		SyncTimeout:  config.SyncPeriod,
 		Node:         config.Node,
 		Namespace:    config.Namespace,
 		HonorReSyncs: true,
-	}, nil)
+	}, func(opts kubernetes.WatchOptions) (kubernetes.Watcher, error) {
+		return kubernetes.NewNamedWatcher("pod", client, &kubernetes.Pod{}, opts, nil)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("couldn't create watcher for %T due to error %w", &kubernetes.Pod{}, err)
 	}
@@ -115,18 +125,22 @@ func NewPodEventer(uuid uuid.UUID, cfg *conf.C, client k8s.Interface, publish fu
 			Node:         config.Node,
 			HonorReSyncs: true,
 		}
-		nodeWatcher, err = kubernetes.NewNamedWatcher("node", client, &kubernetes.Node{}, options, nil)
+		nodeWatcher, err = acquire("node", options, func(opts kubernetes.WatchOptions) (kubernetes.Watcher, error) {
+			return kubernetes.NewNamedWatcher("node", client, &kubernetes.Node{}, opts, nil)
+		})
 		if err != nil {
 			logger.Errorf("couldn't create watcher for %T due to error %+v", &kubernetes.Node{}, err)
 		}
 	}
 
 	if metaConf.Namespace.Enabled() || config.Hints.Enabled() {
-		namespaceWatcher, err = kubernetes.NewNamedWatcher("namespace", client, &kubernetes.Namespace{}, kubernetes.WatchOptions{
+		namespaceWatcher, err = acquire("namespace", kubernetes.WatchOptions{
 			SyncTimeout:  config.SyncPeriod,
 			Namespace:    config.Namespace,
 			HonorReSyncs: true,
-		}, nil)
+		}, func(opts kubernetes.WatchOptions) (kubernetes.Watcher, error) {
+			return kubernetes.NewNamedWatcher("namespace", client, &kubernetes.Namespace{}, opts, nil)
+		})
 		if err != nil {
 			logger.Errorf("couldn't create watcher for %T due to error %+v", &kubernetes.Namespace{}, err)
 		}
@@ -141,29 +155,33 @@ func NewPodEventer(uuid uuid.UUID, cfg *conf.C, client k8s.Interface, publish fu
 		if err != nil {
 			logger.Errorf("Error creating metadata client due to error %+v", err)
 		}
-		replicaSetWatcher, err = kubernetes.NewNamedMetadataWatcher(
-			"resource_metadata_enricher_rs",
-			client,
-			metadataClient,
-			schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"},
-			kubernetes.WatchOptions{
-				SyncTimeout:  config.SyncPeriod,
-				Namespace:    config.Namespace,
-				HonorReSyncs: true,
-			},
-			nil,
-			metadata.RemoveUnnecessaryReplicaSetData,
-		)
+		replicaSetWatcher, err = acquire("replicaset", kubernetes.WatchOptions{
+			SyncTimeout:  config.SyncPeriod,
+			Namespace:    config.Namespace,
+			HonorReSyncs: true,
+		}, func(opts kubernetes.WatchOptions) (kubernetes.Watcher, error) {
+			return kubernetes.NewNamedMetadataWatcher(
+				"resource_metadata_enricher_rs",
+				client,
+				metadataClient,
+				schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"},
+				opts,
+				nil,
+				metadata.RemoveUnnecessaryReplicaSetData,
+			)
+		})
 		if err != nil {
 			logger.Errorf("Error creating watcher for %T due to error %+v", &kubernetes.ReplicaSet{}, err)
 		}
 	}
 	if metaConf.CronJob {
-		jobWatcher, err = kubernetes.NewNamedWatcher("resource_metadata_enricher_job", client, &kubernetes.Job{}, kubernetes.WatchOptions{
+		jobWatcher, err = acquire("job", kubernetes.WatchOptions{
 			SyncTimeout:  config.SyncPeriod,
 			Namespace:    config.Namespace,
 			HonorReSyncs: true,
-		}, nil)
+		}, func(opts kubernetes.WatchOptions) (kubernetes.Watcher, error) {
+			return kubernetes.NewNamedWatcher("resource_metadata_enricher_job", client, &kubernetes.Job{}, opts, nil)
+		})
 		if err != nil {
 			logger.Errorf("Error creating watcher for %T due to error %+v", &kubernetes.Job{}, err)
 		}
@@ -45,12 +45,16 @@ type Mapper struct {
 type ConditionMap struct {
 	Condition conditions.Condition
 	Configs   []*conf.C
+	// Unique configs are only used by the instance elected as leader, so
+	// they run once per cluster.
+	Unique bool
 }
 
 // MapperSettings holds user settings to build Mapper
 type MapperSettings []*struct {
 	ConditionConfig *conditions.Config `config:"condition"`
 	Configs         []*conf.C          `config:"config"`
+	Unique          bool               `config:"unique"`
 }
 
 // NewConfigMapper builds a template Mapper from given settings
@@ -60,7 +64,7 @@ func NewConfigMapper(
 	keystoreProvider bus.KeystoreProvider,
 ) (mapper Mapper, err error) {
 	for _, c := range configs {
-		condMap := &ConditionMap{Configs: c.Configs}
+		condMap := &ConditionMap{Configs: c.Configs, Unique: c.Unique}
 		if c.ConditionConfig != nil {
 			condMap.Condition, err = conditions.NewCondition(c.ConditionConfig)
 			if err != nil {
@@ -87,8 +91,35 @@ func (e Event) GetValue(key string) (interface{}, error) {
 	return val, nil
 }
 
-// GetConfig returns a matching Config if any, nil otherwise
+// HasUnique returns true if any of the templates is unique.
+func (c Mapper) HasUnique() bool {
+	for _, mapping := range c.ConditionMaps {
+		if mapping.Unique {
+			return true
+		}
+	}
+	return false
+}
+
+// GetConfig returns a matching Config if any, nil otherwise. Unique templates
+// are only matched by events published on leader election, which have the
+// `unique` field set.
 func (c Mapper) GetConfig(event bus.Event) []*conf.C {
+	leader := event["unique"] == "true"
+	return c.getConfig(event, func(mapping *ConditionMap) bool {
+		return leader || !mapping.Unique
+	})
+}
+
+// GetUniqueConfig returns a matching Config of the unique templates if any,
+// nil otherwise.
+func (c Mapper) GetUniqueConfig(event bus.Event) []*conf.C {
+	return c.getConfig(event, func(mapping *ConditionMap) bool {
+		return mapping.Unique
+	})
+}
+
+func (c Mapper) getConfig(event bus.Event, include func(*ConditionMap) bool) []*conf.C {
 	var result []*conf.C
 	opts := []ucfg.Option{}
 	// add k8s keystore in options list with higher priority
@@ -103,6 +134,10 @@ func (c Mapper) GetConfig(event bus.Event) []*conf.C {
 		opts = append(opts, ucfg.Resolve(keystore.ResolverWrap(c.keystore)))
 	}
 	for _, mapping := range c.ConditionMaps {
+		if !include(mapping) {
+			continue
+		}
+
 		// An empty condition matches everything
 		conditionOk := mapping.Condition == nil || mapping.Condition.Check(Event(event))
 		if mapping.Configs != nil && !conditionOk {
@@ -337,3 +337,43 @@ func getTemporaryKeystoreFile() string {
 	}
 	return filepath.Join(path, "keystore")
 }
+
+func TestUniqueConfigsMapping(t *testing.T) {
+	var mappings MapperSettings
+	config, err := conf.NewConfigWithYAML([]byte(`
+- config:
+    - type: singleton
+  unique: true
+- config:
+    - type: everywhere`), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Unpack(&mappings); err != nil {
+		t.Fatal(err)
+	}
+
+	mapper, err := NewConfigMapper(mappings, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, mapper.HasUnique())
+
+	types := func(configs []*conf.C) []string {
+		var result []string
+		for _, c := range configs {
+			typ, err := c.String("type", -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result = append(result, typ)
+		}
+		return result
+	}
+
+	// Unique templates are not used for resource events.
+	assert.Equal(t, []string{"everywhere"}, types(mapper.GetConfig(bus.Event{"foo": 3})))
+	// All templates are used on leader election of a unique provider.
+	assert.Equal(t, []string{"singleton", "everywhere"}, types(mapper.GetConfig(bus.Event{"unique": "true"})))
+	assert.Equal(t, []string{"singleton"}, types(mapper.GetUniqueConfig(bus.Event{"unique": "true"})))
+}
@@ -86,6 +86,8 @@ type Beat struct {
 
 	API      *api.Server      // API server. This is nil unless the http endpoint is enabled.
 	Registry *reload.Registry // input, & output registry for configuration manager, should be instantiated in NewBeat
+
+	Tenants []Tenant // tenants run next to the main configuration, only set if the beat supports tenants
 }
 
 // GenerateUserAgent populates the UserAgent field on the beat.Info struct
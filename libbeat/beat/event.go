@@ -55,6 +55,43 @@ type Event struct {
 	Fields     mapstr.M
 	Private    interface{} // for beats private use
 	TimeSeries bool        // true if the event contains timeseries data
+
+	// Flat optionally holds fields that are not merged into Fields yet.
+	// Fields take precedence over Flat for keys present in both. The Event
+	// methods take Flat into account, code accessing Fields directly must
+	// call Materialize first.
+	Flat *FlatFields
+}
+
+// Materialize merges the Flat fields of the event into Fields.
+func (e *Event) Materialize() {
+	if e.Flat == nil {
+		return
+	}
+	if e.Flat.Len() > 0 {
+		if e.Fields == nil {
+			e.Fields = make(mapstr.M, e.Flat.Len())
+		}
+		e.Flat.mergeInto(e.Fields)
+	}
+	e.Flat = nil
+}
+
+// IdempotencyKeyer is implemented by the Private data of events that their
+// input can identify by their position in the source, like an offset in a
+// file or a record number in an event log. The key is stable across retries
+// and restarts, so outputs can use it to drop duplicated events.
+type IdempotencyKeyer interface {
+	IdempotencyKey() string
+}
+
+// IdempotencyKey returns the idempotency key of the event, or an empty string
+// if its input does not provide one. See IdempotencyKeyer.
+func (e *Event) IdempotencyKey() string {
+	if k, ok := e.Private.(IdempotencyKeyer); ok {
+		return k.IdempotencyKey()
+	}
+	return ""
 }
 
 var (
@@ -92,6 +129,16 @@ func (e *Event) GetValue(key string) (interface{}, error) {
 		return e.Meta.GetValue(subKey)
 	}
 
+	if e.Flat != nil {
+		// Fast path for reading a flat field.
+		if v, ok := e.Flat.Get(key); ok {
+			if has, err := e.Fields.HasKey(key); !has && (err == nil || errors.Is(err, mapstr.ErrKeyNotFound)) {
+				return v, nil
+			}
+		}
+		e.Materialize()
+	}
+
 	if e.Fields == nil {
 		return nil, mapstr.ErrKeyNotFound
 	}
@@ -107,6 +154,7 @@ func (e *Event) Clone() *Event {
 		Fields:     e.Fields.Clone(),
 		Private:    e.Private,
 		TimeSeries: e.TimeSeries,
+		Flat:       e.Flat.Clone(),
 	}
 }
 
@@ -193,6 +241,7 @@ func (e *Event) deepUpdate(d mapstr.M, mode updateMode) {
 		return
 	}
 
+	e.Materialize()
 	if e.Fields == nil {
 		e.Fields = mapstr.M{}
 	}
@@ -249,6 +298,7 @@ func (e *Event) PutValue(key string, v interface{}) (interface{}, error) {
 		return e.Meta.Put(subKey, v)
 	}
 
+	e.Materialize()
 	if e.Fields == nil {
 		e.Fields = mapstr.M{}
 	}
@@ -275,6 +325,7 @@ func (e *Event) Delete(key string) error {
 		return e.Meta.Delete(subKey)
 	}
 
+	e.Materialize()
 	if e.Fields == nil {
 		return mapstr.ErrKeyNotFound
 	}
@@ -308,6 +359,7 @@ func (e *Event) SetErrorWithOption(message string, addErrKey bool, data string,
 	if field != "" {
 		errorField["field"] = field
 	}
+	e.Materialize()
 	e.Fields[ErrorFieldKey] = errorField
 }
 
@@ -320,7 +372,13 @@ func (e *Event) String() string {
 	if e.Meta != nil {
 		m[MetadataFieldKey] = e.Meta
 	}
-	m.DeepUpdate(e.Fields)
+	if e.Flat != nil {
+		// Don't merge the flat fields into the maps shared with Fields.
+		m.DeepUpdate(e.Fields.Clone())
+		e.Flat.mergeInto(m)
+	} else {
+		m.DeepUpdate(e.Fields)
+	}
 	return m.String()
 }
 
@@ -338,6 +396,7 @@ func (e *Event) HasKey(key string) (bool, error) {
 		return e.Meta.HasKey(subKey)
 	}
 
+	e.Materialize()
 	if e.Fields == nil {
 		return false, nil
 	}
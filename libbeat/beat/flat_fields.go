@@ -0,0 +1,177 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package beat
+
+import (
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// FlatFields holds event fields as a table of dotted keys and values, e.g.
+// "log.file.path", instead of nested maps. Inputs can use it to build events
+// without allocating a map per level of nesting. The fields are merged into
+// the nested Fields of the event when the event is materialized, either by a
+// processor that does not support flat fields or before the event is queued.
+//
+// Keys are expected to be unique and not to be a prefix of another key.
+// FlatFields is not safe for concurrent use.
+type FlatFields struct {
+	keys   []string
+	values []interface{}
+}
+
+// NewFlatFields creates an empty table with room for capacity fields.
+func NewFlatFields(capacity int) *FlatFields {
+	return &FlatFields{
+		keys:   make([]string, 0, capacity),
+		values: make([]interface{}, 0, capacity),
+	}
+}
+
+// Len returns the number of fields.
+func (f *FlatFields) Len() int {
+	if f == nil {
+		return 0
+	}
+	return len(f.keys)
+}
+
+// Put sets the value of key, replacing any previous value.
+func (f *FlatFields) Put(key string, value interface{}) {
+	if i := f.index(key); i >= 0 {
+		f.values[i] = value
+		return
+	}
+	f.keys = append(f.keys, key)
+	f.values = append(f.values, value)
+}
+
+// Get returns the value of key.
+func (f *FlatFields) Get(key string) (interface{}, bool) {
+	if i := f.index(key); i >= 0 {
+		return f.values[i], true
+	}
+	return nil, false
+}
+
+// Delete removes key and returns whether it was present.
+func (f *FlatFields) Delete(key string) bool {
+	i := f.index(key)
+	if i < 0 {
+		return false
+	}
+	last := len(f.keys) - 1
+	f.keys[i], f.values[i] = f.keys[last], f.values[last]
+	f.keys[last], f.values[last] = "", nil
+	f.keys, f.values = f.keys[:last], f.values[:last]
+	return true
+}
+
+// Range calls fn for every field until it returns false.
+func (f *FlatFields) Range(fn func(key string, value interface{}) bool) {
+	if f == nil {
+		return
+	}
+	for i, key := range f.keys {
+		if !fn(key, f.values[i]) {
+			return
+		}
+	}
+}
+
+// Transform replaces every value by the result of fn. Fields for which fn
+// returns false are removed.
+func (f *FlatFields) Transform(fn func(key string, value interface{}) (interface{}, bool)) {
+	if f == nil {
+		return
+	}
+	n := 0
+	for i, key := range f.keys {
+		v, keep := fn(key, f.values[i])
+		if !keep {
+			continue
+		}
+		f.keys[n], f.values[n] = key, v
+		n++
+	}
+	for i := n; i < len(f.keys); i++ {
+		f.keys[i], f.values[i] = "", nil
+	}
+	f.keys, f.values = f.keys[:n], f.values[:n]
+}
+
+// Clone returns a copy of the table. Values are not copied.
+func (f *FlatFields) Clone() *FlatFields {
+	if f == nil {
+		return nil
+	}
+	return &FlatFields{
+		keys:   append([]string(nil), f.keys...),
+		values: append([]interface{}(nil), f.values...),
+	}
+}
+
+// MapStr returns the fields as nested maps.
+func (f *FlatFields) MapStr() mapstr.M {
+	m := mapstr.M{}
+	f.mergeInto(m)
+	return m
+}
+
+// mergeInto adds the fields to m. Fields already present in m take
+// precedence.
+func (f *FlatFields) mergeInto(m mapstr.M) {
+	f.Range(func(key string, value interface{}) bool {
+		// Put fails without changing m if a parent of the key is not a
+		// map.
+		if has, _ := m.HasKey(key); !has {
+			_, _ = m.Put(key, value)
+		}
+		return true
+	})
+}
+
+func (f *FlatFields) index(key string) int {
+	if f == nil {
+		return -1
+	}
+	for i, k := range f.keys {
+		if k == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// FlatFieldsProcessor is implemented by processors that can run on events
+// whose Flat fields are not materialized. Other processors always see the
+// fields of an event in Fields.
+type FlatFieldsProcessor interface {
+	Processor
+
+	// SupportsFlatFields returns true if the processor only accesses the
+	// event fields through the Event methods, which are aware of the Flat
+	// fields, or does not access the fields at all.
+	SupportsFlatFields() bool
+}
+
+// SupportsFlatFields returns true if the processor can run on events with
+// Flat fields that are not materialized.
+func SupportsFlatFields(p Processor) bool {
+	fp, ok := p.(FlatFieldsProcessor)
+	return ok && fp.SupportsFlatFields()
+}
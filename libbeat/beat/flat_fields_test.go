@@ -0,0 +1,177 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package beat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func TestFlatFields(t *testing.T) {
+	t.Run("put get delete", func(t *testing.T) {
+		f := NewFlatFields(2)
+		f.Put("log.offset", 1)
+		f.Put("log.file.path", "/var/log/syslog")
+		f.Put("log.offset", 2)
+		require.Equal(t, 2, f.Len())
+
+		v, ok := f.Get("log.offset")
+		require.True(t, ok)
+		require.Equal(t, 2, v)
+
+		require.True(t, f.Delete("log.offset"))
+		require.False(t, f.Delete("log.offset"))
+		_, ok = f.Get("log.offset")
+		require.False(t, ok)
+		require.Equal(t, 1, f.Len())
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		var f *FlatFields
+		require.Equal(t, 0, f.Len())
+		_, ok := f.Get("a")
+		require.False(t, ok)
+		require.Nil(t, f.Clone())
+	})
+
+	t.Run("transform", func(t *testing.T) {
+		f := NewFlatFields(3)
+		f.Put("a", 1)
+		f.Put("b", 2)
+		f.Put("c", 3)
+		f.Transform(func(key string, v interface{}) (interface{}, bool) {
+			if key == "b" {
+				return nil, false
+			}
+			return v.(int) * 10, true
+		})
+		require.Equal(t, mapstr.M{"a": 10, "c": 30}, f.MapStr())
+	})
+
+	t.Run("clone", func(t *testing.T) {
+		f := NewFlatFields(1)
+		f.Put("a", 1)
+		c := f.Clone()
+		c.Put("a", 2)
+		v, _ := f.Get("a")
+		require.Equal(t, 1, v)
+	})
+
+	t.Run("mapstr", func(t *testing.T) {
+		f := NewFlatFields(3)
+		f.Put("log.offset", 10)
+		f.Put("log.file.path", "/tmp/a")
+		f.Put("message", "hello")
+		require.Equal(t, mapstr.M{
+			"log": mapstr.M{
+				"offset": 10,
+				"file":   mapstr.M{"path": "/tmp/a"},
+			},
+			"message": "hello",
+		}, f.MapStr())
+	})
+}
+
+func TestEventFlatFields(t *testing.T) {
+	newEvent := func() *Event {
+		flat := NewFlatFields(3)
+		flat.Put("log.offset", 10)
+		flat.Put("log.file.path", "/tmp/a")
+		flat.Put("message", "flat")
+		return &Event{
+			Fields: mapstr.M{
+				"message": "nested",
+				"log":     mapstr.M{"level": "info"},
+			},
+			Flat: flat,
+		}
+	}
+
+	t.Run("GetValue reads flat fields without materializing", func(t *testing.T) {
+		event := newEvent()
+		v, err := event.GetValue("log.offset")
+		require.NoError(t, err)
+		require.Equal(t, 10, v)
+		require.NotNil(t, event.Flat)
+	})
+
+	t.Run("Fields take precedence", func(t *testing.T) {
+		event := newEvent()
+		v, err := event.GetValue("message")
+		require.NoError(t, err)
+		require.Equal(t, "nested", v)
+	})
+
+	t.Run("Materialize", func(t *testing.T) {
+		event := newEvent()
+		event.Materialize()
+		require.Nil(t, event.Flat)
+		require.Equal(t, mapstr.M{
+			"message": "nested",
+			"log": mapstr.M{
+				"level":  "info",
+				"offset": 10,
+				"file":   mapstr.M{"path": "/tmp/a"},
+			},
+		}, event.Fields)
+	})
+
+	t.Run("Materialize without Fields", func(t *testing.T) {
+		flat := NewFlatFields(1)
+		flat.Put("a.b", 1)
+		event := &Event{Flat: flat}
+		event.Materialize()
+		require.Equal(t, mapstr.M{"a": mapstr.M{"b": 1}}, event.Fields)
+	})
+
+	t.Run("writes materialize", func(t *testing.T) {
+		event := newEvent()
+		_, err := event.PutValue("log.offset", 20)
+		require.NoError(t, err)
+		require.Nil(t, event.Flat)
+		v, err := event.Fields.GetValue("log.offset")
+		require.NoError(t, err)
+		require.Equal(t, 20, v)
+
+		event = newEvent()
+		require.NoError(t, event.Delete("log.file.path"))
+		require.Nil(t, event.Flat)
+		has, _ := event.Fields.HasKey("log.file.path")
+		require.False(t, has)
+	})
+
+	t.Run("Clone", func(t *testing.T) {
+		event := newEvent()
+		clone := event.Clone()
+		clone.Flat.Put("log.offset", 20)
+		v, err := event.GetValue("log.offset")
+		require.NoError(t, err)
+		require.Equal(t, 10, v)
+	})
+
+	t.Run("String does not materialize", func(t *testing.T) {
+		event := newEvent()
+		require.Contains(t, event.String(), `"offset":10`)
+		require.NotNil(t, event.Flat)
+		_, err := event.Fields.GetValue("log.offset")
+		require.ErrorIs(t, err, mapstr.ErrKeyNotFound)
+	})
+}
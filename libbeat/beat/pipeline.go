@@ -60,6 +60,16 @@ type ClientConfig struct {
 
 	// ClientListener configures callbacks for monitoring pipeline clients
 	ClientListener ClientListener
+
+	// EventRejected, if set, is called for every published event that an
+	// output permanently gives up on, for example because it does not match
+	// the index mapping or has run out of retries. It receives the Private
+	// and Meta fields of the event after processing, the rest of the event
+	// is not retained. EventRejected is called before the event is ACKed,
+	// which allows stateful inputs to retry, skip or stop advancing their
+	// cursor. It is called from the output workers and must be thread-safe.
+	// Events buffered in the disk queue are not reported.
+	EventRejected func(private interface{}, meta mapstr.M, err error)
 }
 
 // EventListener can be registered with a Client when connecting to the pipeline.
@@ -0,0 +1,37 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package beat
+
+import "github.com/elastic/elastic-agent-libs/config"
+
+// Tenant is a logical tenant run by the beat next to its main configuration,
+// with its own settings, state and publisher pipeline.
+type Tenant struct {
+	ID string
+
+	// Config holds the settings of the tenant, like its inputs.
+	Config *config.C
+
+	// DataPath is the directory the tenant keeps its state in, like its
+	// registry.
+	DataPath string
+
+	// Publisher is the pipeline of the tenant, publishing events to its own
+	// output within its quota.
+	Publisher Pipeline
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/joeshaw/multierror"
 	"github.com/mitchellh/hashstructure"
 
+	"github.com/elastic/beats/v7/libbeat/audit"
 	"github.com/elastic/beats/v7/libbeat/beat"
 	"github.com/elastic/beats/v7/libbeat/common"
 	"github.com/elastic/beats/v7/libbeat/common/diagnostics"
@@ -33,6 +34,7 @@ import (
 	"github.com/elastic/beats/v7/libbeat/publisher/pipetool"
 	"github.com/elastic/elastic-agent-libs/config"
 	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
 )
 
 // RunnerList implements a reloadable.List of Runners
@@ -122,6 +124,7 @@ func (r *RunnerList) Reload(configs []*reload.ConfigWithMeta) error {
 			r.logger.Debugf("Runner: '%s' has stopped", runner)
 		}(runner)
 		moduleStops.Add(1)
+		audit.Record(audit.CategoryInput, "stopped", mapstr.M{"runner": runner.String(), "hash": hash})
 	}
 
 	// Wait for all runners to stop before starting new ones
@@ -138,6 +141,7 @@ func (r *RunnerList) Reload(configs []*reload.ConfigWithMeta) error {
 			} else {
 				r.logger.Errorf("Error creating runner from config: %s", err)
 			}
+			audit.Record(audit.CategoryInput, "start_failed", mapstr.M{"hash": hash, "error": err.Error()})
 
 			// If InputUnitID is not empty, then we're running under Elastic-Agent
 			// and we need to report the errors per unit.
@@ -162,6 +166,7 @@ func (r *RunnerList) Reload(configs []*reload.ConfigWithMeta) error {
 
 		runner.Start()
 		moduleStarts.Add(1)
+		audit.Record(audit.CategoryInput, "started", mapstr.M{"runner": runner.String(), "hash": hash})
 		if config.DiagCallback != nil {
 			if diag, ok := runner.(diagnostics.DiagnosticReporter); ok {
 				r.logger.Debugf("Runner '%s' has diagnostics, attempting to register", runner)
@@ -206,6 +211,7 @@ func (r *RunnerList) Stop() {
 			r.logger.Debugf("Stopping runner: %s", run)
 			run.Stop()
 			r.logger.Debugf("Stopped runner: %s", run)
+			audit.Record(audit.CategoryInput, "stopped", mapstr.M{"runner": run.String(), "hash": h})
 		}(hash, runner)
 	}
 
@@ -25,10 +25,12 @@ import (
 
 	"github.com/joeshaw/multierror"
 
+	"github.com/elastic/beats/v7/libbeat/audit"
 	"github.com/elastic/beats/v7/libbeat/beat"
 	"github.com/elastic/beats/v7/libbeat/common/reload"
 	"github.com/elastic/elastic-agent-libs/config"
 	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
 	"github.com/elastic/elastic-agent-libs/monitoring"
 	"github.com/elastic/elastic-agent-libs/paths"
 )
@@ -205,6 +207,7 @@ func (rl *Reloader) Run(runnerFactory RunnerFactory) {
 				continue
 			}
 			configReloads.Add(1)
+			audit.Record(audit.CategoryConfig, "reloaded", mapstr.M{"path": rl.path, "files": files})
 
 			// Load all config objects
 			configs, _ := rl.loadConfigs(files)
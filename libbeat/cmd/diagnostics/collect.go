@@ -0,0 +1,392 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package diagnostics
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"github.com/elastic/beats/v7/libbeat/api"
+	"github.com/elastic/beats/v7/libbeat/api/npipe"
+	"github.com/elastic/beats/v7/libbeat/cmd/instance"
+	"github.com/elastic/beats/v7/libbeat/common/cli"
+	"github.com/elastic/beats/v7/libbeat/version"
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/paths"
+)
+
+const (
+	// maxDataFileSize is the size above which files from the registry are
+	// not included in the bundle.
+	maxDataFileSize = 64 * 1024 * 1024
+
+	requestTimeout = 30 * time.Second
+)
+
+// endpoints queried on the HTTP monitoring server of the running beat. The
+// pprof endpoints are only available if http.pprof.enabled is set.
+var (
+	metricsEndpoints = map[string]string{
+		"metrics/info.json":      "/",
+		"metrics/stats.json":     "/stats",
+		"metrics/state.json":     "/state",
+		"metrics/inputs.json":    "/inputs/",
+		"metrics/prometheus.txt": "/metrics",
+	}
+	profileEndpoints = map[string]string{
+		"profiles/goroutine.txt": "/debug/pprof/goroutine?debug=2",
+		"profiles/heap.pprof":    "/debug/pprof/heap",
+	}
+)
+
+// GenCollectCmd returns the `diagnostics collect` command.
+func GenCollectCmd(settings instance.Settings) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "collect",
+		Short: "Collect a diagnostics bundle for support cases",
+		Long: `Collect gathers the configuration (with secrets redacted), the registry,
+the last lines of the internal logs, and metrics and profiles from the running
+beat into a single zip archive.`,
+		Run: cli.RunWith(func(cmd *cobra.Command, args []string) error {
+			output, _ := cmd.Flags().GetString("output")
+			logLines, _ := cmd.Flags().GetInt("log-lines")
+			return collect(settings, output, logLines)
+		}),
+	}
+	cmd.Flags().StringP("output", "o", "", "Path of the diagnostics archive (default: <beat>-diagnostics-<timestamp>.zip)")
+	cmd.Flags().Int("log-lines", 1000, "Number of lines to include from the most recent log file, 0 or less to include the whole file")
+	return cmd
+}
+
+func collect(settings instance.Settings, output string, logLines int) error {
+	// Don't resolve keystore references so that secrets never end in the
+	// bundle, not even before redaction.
+	settings.DisableConfigResolver = true
+	b, err := instance.NewInitializedBeat(settings)
+	if err != nil {
+		return fmt.Errorf("error initializing beat: %w", err)
+	}
+
+	if output == "" {
+		output = fmt.Sprintf("%s-diagnostics-%s.zip", settings.Name, time.Now().UTC().Format("2006-01-02T15-04-05Z"))
+	}
+	f, err := os.OpenFile(output, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create diagnostics archive: %w", err)
+	}
+	defer f.Close()
+
+	c := &collector{zip: zip.NewWriter(f)}
+	c.addJSON("beat.json", map[string]interface{}{
+		"beat":       b.Info.Beat,
+		"version":    version.GetDefaultVersion(),
+		"commit":     version.Commit(),
+		"build_time": version.BuildTime(),
+		"hostname":   b.Info.Hostname,
+		"os":         runtime.GOOS,
+		"arch":       runtime.GOARCH,
+		"collected":  time.Now().UTC(),
+	})
+	c.collectConfig(b.RawConfig)
+	c.collectDir("registry", paths.Resolve(paths.Data, "registry"))
+	c.collectFile("meta.json", paths.Resolve(paths.Data, "meta.json"))
+	c.collectLogs(paths.Resolve(paths.Logs, ""), settings.Name, logLines)
+	c.collectHTTP(b.Config.HTTP)
+	c.finish()
+
+	if err := c.zip.Close(); err != nil {
+		return fmt.Errorf("failed to write diagnostics archive: %w", err)
+	}
+	fmt.Fprintf(os.Stdout, "Diagnostics written to %s\n", output)
+	return nil
+}
+
+// collector writes the diagnostics archive. Failures to collect individual
+// items are recorded in errors.txt instead of aborting the collection, a
+// partial bundle is more useful than none.
+type collector struct {
+	zip    *zip.Writer
+	errors []string
+}
+
+func (c *collector) addError(format string, args ...interface{}) {
+	c.errors = append(c.errors, fmt.Sprintf(format, args...))
+}
+
+func (c *collector) add(name string, r io.Reader) {
+	w, err := c.zip.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		c.addError("%s: %v", name, err)
+		return
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		c.addError("%s: %v", name, err)
+	}
+}
+
+func (c *collector) addBytes(name string, data []byte) {
+	c.add(name, bytes.NewReader(data))
+}
+
+func (c *collector) addJSON(name string, v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		c.addError("%s: %v", name, err)
+		return
+	}
+	c.addBytes(name, data)
+}
+
+// collectConfig adds the configuration with the values of sensitive keys
+// (passwords, API keys, ...) masked.
+func (c *collector) collectConfig(cfg *config.C) {
+	var content map[string]interface{}
+	if err := cfg.Unpack(&content); err != nil {
+		c.addError("config.yml: %v", err)
+		return
+	}
+	config.ApplyLoggingMask(content)
+	data, err := yaml.Marshal(content)
+	if err != nil {
+		c.addError("config.yml: %v", err)
+		return
+	}
+	c.addBytes("config.yml", data)
+}
+
+func (c *collector) collectFile(name, path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			c.addError("%s: %v", name, err)
+		}
+		return
+	}
+	defer f.Close()
+	c.add(name, f)
+}
+
+// collectDir adds all regular files below root, skipping lock files and
+// files larger than maxDataFileSize.
+func (c *collector) collectDir(prefix, root string) {
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return
+	}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			c.addError("%s: %v", path, err)
+			return nil
+		}
+		if !d.Type().IsRegular() || strings.HasSuffix(d.Name(), ".lock") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			c.addError("%s: %v", path, err)
+			return nil
+		}
+		if info.Size() > maxDataFileSize {
+			c.addError("%s: skipped, file size %d exceeds %d bytes", path, info.Size(), maxDataFileSize)
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		c.collectFile(filepath.ToSlash(filepath.Join(prefix, rel)), path)
+		return nil
+	})
+	if err != nil {
+		c.addError("%s: %v", root, err)
+	}
+}
+
+// collectLogs adds the last n lines of the most recently modified log file
+// of the beat.
+func (c *collector) collectLogs(dir, beatName string, n int) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			c.addError("logs: %v", err)
+		}
+		return
+	}
+
+	type logFile struct {
+		path    string
+		modTime time.Time
+	}
+	var files []logFile
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasPrefix(e.Name(), beatName) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, logFile{path: filepath.Join(dir, e.Name()), modTime: info.ModTime()})
+	}
+	if len(files) == 0 {
+		return
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.After(files[j].modTime) })
+
+	lines, err := tailFile(files[0].path, n)
+	if err != nil {
+		c.addError("logs: %v", err)
+		return
+	}
+	c.addBytes("logs/"+filepath.Base(files[0].path), []byte(strings.Join(lines, "\n")))
+}
+
+// tailFile returns the last n lines of the file at path, or all its lines if
+// n is not positive.
+func tailFile(path string, n int) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	if n > 0 {
+		lines = make([]string, 0, n)
+	}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		if n > 0 && len(lines) == n {
+			lines = lines[1:]
+		}
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
+
+// collectHTTP queries the HTTP monitoring server of the running beat for
+// metrics and profiles.
+func (c *collector) collectHTTP(cfg *config.C) {
+	httpCfg := api.DefaultConfig
+	if cfg != nil {
+		if err := cfg.Unpack(&httpCfg); err != nil {
+			c.addError("http: %v", err)
+			return
+		}
+	}
+	if !httpCfg.Enabled {
+		c.addError("http: the HTTP monitoring endpoint is disabled, metrics and profiles are not collected")
+		return
+	}
+
+	client, base, err := newHTTPClient(httpCfg)
+	if err != nil {
+		c.addError("http: %v", err)
+		return
+	}
+	for name, path := range metricsEndpoints {
+		c.collectEndpoint(client, base, name, path)
+	}
+	for name, path := range profileEndpoints {
+		c.collectEndpoint(client, base, name, path)
+	}
+}
+
+func (c *collector) collectEndpoint(client *http.Client, base, name, path string) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+path, nil)
+	if err != nil {
+		c.addError("%s: %v", name, err)
+		return
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		c.addError("%s: %v", name, err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		c.addError("%s: unexpected status %s", name, resp.Status)
+		return
+	}
+	c.add(name, resp.Body)
+}
+
+func (c *collector) finish() {
+	if len(c.errors) > 0 {
+		c.addBytes("errors.txt", []byte(strings.Join(c.errors, "\n")+"\n"))
+	}
+}
+
+// newHTTPClient returns a client and the base URL to talk to the monitoring
+// server configured by cfg, which can listen on TCP, a unix socket or a
+// named pipe.
+func newHTTPClient(cfg api.Config) (*http.Client, string, error) {
+	if npipe.IsNPipe(cfg.Host) {
+		return &http.Client{
+			Transport: &http.Transport{DialContext: npipe.DialContext(npipe.TransformString(cfg.Host))},
+		}, "http://npipe", nil
+	}
+
+	u, err := url.Parse(cfg.Host)
+	if err != nil {
+		return nil, "", err
+	}
+	switch {
+	case u.Scheme == "unix":
+		socket := u.Path
+		return &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", socket)
+				},
+			},
+		}, "http://unix", nil
+	case u.Scheme == "http":
+		return &http.Client{}, "http://" + u.Host, nil
+	case u.Scheme == "" && u.Host == "":
+		return &http.Client{}, "http://" + net.JoinHostPort(cfg.Host, fmt.Sprint(cfg.Port)), nil
+	default:
+		return nil, "", fmt.Errorf("unknown scheme %s for host string %s", u.Scheme, cfg.Host)
+	}
+}
@@ -0,0 +1,148 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package diagnostics
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/config"
+)
+
+func readArchive(t *testing.T, buf *bytes.Buffer) map[string]string {
+	t.Helper()
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	files := map[string]string{}
+	for _, f := range r.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		files[f.Name] = string(data)
+	}
+	return files
+}
+
+func TestCollector(t *testing.T) {
+	dir := t.TempDir()
+
+	registry := filepath.Join(dir, "registry", "filebeat")
+	require.NoError(t, os.MkdirAll(registry, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(registry, "log.json"), []byte(`{"op":"set"}`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(registry, "registry.lock"), nil, 0o600))
+
+	logs := filepath.Join(dir, "logs")
+	require.NoError(t, os.Mkdir(logs, 0o700))
+	var lines []string
+	for i := 0; i < 10; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(logs, "testbeat-20240101.ndjson"), []byte(strings.Join(lines, "\n")), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(logs, "other.log"), []byte("other"), 0o600))
+
+	var buf bytes.Buffer
+	c := &collector{zip: zip.NewWriter(&buf)}
+	c.collectConfig(config.MustNewConfigFrom(map[string]interface{}{
+		"output.elasticsearch": map[string]interface{}{
+			"index":    "my-index",
+			"password": "secret",
+		},
+	}))
+	c.collectDir("registry", filepath.Join(dir, "registry"))
+	c.collectLogs(logs, "testbeat", 3)
+	c.collectHTTP(config.MustNewConfigFrom(map[string]interface{}{"enabled": false}))
+	c.finish()
+	require.NoError(t, c.zip.Close())
+
+	files := readArchive(t, &buf)
+	assert.Contains(t, files["config.yml"], "my-index")
+	assert.NotContains(t, files["config.yml"], "secret")
+	assert.Equal(t, `{"op":"set"}`, files["registry/filebeat/log.json"])
+	assert.NotContains(t, files, "registry/filebeat/registry.lock")
+	assert.Equal(t, "line 7\nline 8\nline 9", files["logs/testbeat-20240101.ndjson"])
+	assert.NotContains(t, files, "logs/other.log")
+	assert.Contains(t, files["errors.txt"], "HTTP monitoring endpoint is disabled")
+}
+
+func TestTailFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "testbeat.ndjson")
+	require.NoError(t, os.WriteFile(path, []byte("line 0\nline 1\nline 2\n"), 0o600))
+
+	for n, expected := range map[int][]string{
+		-1: {"line 0", "line 1", "line 2"},
+		0:  {"line 0", "line 1", "line 2"},
+		2:  {"line 1", "line 2"},
+		10: {"line 0", "line 1", "line 2"},
+	} {
+		t.Run(strconv.Itoa(n), func(t *testing.T) {
+			lines, err := tailFile(path, n)
+			require.NoError(t, err)
+			assert.Equal(t, expected, lines)
+		})
+	}
+}
+
+func TestCollectorHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/stats":
+			fmt.Fprint(w, `{"libbeat":{}}`)
+		case "/debug/pprof/goroutine":
+			fmt.Fprint(w, "goroutine 1")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(u.Port())
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	c := &collector{zip: zip.NewWriter(&buf)}
+	c.collectHTTP(config.MustNewConfigFrom(map[string]interface{}{
+		"enabled": true,
+		"host":    u.Hostname(),
+		"port":    port,
+	}))
+	c.finish()
+	require.NoError(t, c.zip.Close())
+
+	files := readArchive(t, &buf)
+	assert.Equal(t, `{"libbeat":{}}`, files["metrics/stats.json"])
+	assert.Equal(t, "goroutine 1", files["profiles/goroutine.txt"])
+	assert.Contains(t, files["errors.txt"], "profiles/heap.pprof: unexpected status 404")
+}
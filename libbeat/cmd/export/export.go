@@ -52,6 +52,12 @@ func newIdxmgmtClient(dir string, ver string) idxmgmt.FileClient {
 	return c
 }
 
+// NewFileClient returns a client writing the exported assets of each
+// component to a subdirectory of dir.
+func NewFileClient(dir string, ver string) (idxmgmt.FileClient, error) {
+	return newFileClient(dir, ver)
+}
+
 func newStdoutClient(ver string) (*stdoutClient, error) {
 	if ver == "" {
 		ver = version.GetDefaultVersion()
@@ -45,12 +45,14 @@ import (
 
 	"github.com/elastic/beats/v7/libbeat/api"
 	"github.com/elastic/beats/v7/libbeat/asset"
+	"github.com/elastic/beats/v7/libbeat/audit"
 	"github.com/elastic/beats/v7/libbeat/beat"
 	"github.com/elastic/beats/v7/libbeat/cfgfile"
 	"github.com/elastic/beats/v7/libbeat/cloudid"
 	"github.com/elastic/beats/v7/libbeat/cmd/instance/locks"
 	"github.com/elastic/beats/v7/libbeat/common"
 	"github.com/elastic/beats/v7/libbeat/common/fleetmode"
+	"github.com/elastic/beats/v7/libbeat/common/loglevel"
 	"github.com/elastic/beats/v7/libbeat/common/reload"
 	"github.com/elastic/beats/v7/libbeat/common/seccomp"
 	"github.com/elastic/beats/v7/libbeat/dashboards"
@@ -61,15 +63,18 @@ import (
 	"github.com/elastic/beats/v7/libbeat/instrumentation"
 	"github.com/elastic/beats/v7/libbeat/kibana"
 	"github.com/elastic/beats/v7/libbeat/management"
+	inputlifecycle "github.com/elastic/beats/v7/libbeat/management/lifecycle"
 	"github.com/elastic/beats/v7/libbeat/monitoring/report"
 	"github.com/elastic/beats/v7/libbeat/monitoring/report/log"
 	"github.com/elastic/beats/v7/libbeat/outputs"
 	"github.com/elastic/beats/v7/libbeat/outputs/elasticsearch"
 	"github.com/elastic/beats/v7/libbeat/plugin"
 	"github.com/elastic/beats/v7/libbeat/pprof"
+	"github.com/elastic/beats/v7/libbeat/publisher/pause"
 	"github.com/elastic/beats/v7/libbeat/publisher/pipeline"
 	"github.com/elastic/beats/v7/libbeat/publisher/processing"
 	"github.com/elastic/beats/v7/libbeat/publisher/queue/diskqueue"
+	"github.com/elastic/beats/v7/libbeat/tenant"
 	"github.com/elastic/beats/v7/libbeat/version"
 	"github.com/elastic/elastic-agent-libs/config"
 	"github.com/elastic/elastic-agent-libs/file"
@@ -105,6 +110,8 @@ type Beat struct {
 
 	InputQueueSize int // Size of the producer queue used by most queues.
 
+	supportsTenants bool // whether the beat runs the tenants setting
+
 	// shouldReexec is a flag to indicate the Beat should restart
 	shouldReexec bool
 }
@@ -132,10 +139,14 @@ type beatConfig struct {
 	MetricLogging   *config.C              `config:"logging.metrics"`
 	Keystore        *config.C              `config:"keystore"`
 	Instrumentation instrumentation.Config `config:"instrumentation"`
+	Audit           *config.C              `config:"audit"`
 
 	// output/publishing related configurations
 	Pipeline pipeline.Config `config:",inline"`
 
+	// Tenants run next to the main configuration, each with its own output.
+	Tenants []*config.C `config:"tenants"`
+
 	// monitoring settings
 	MonitoringBeatConfig monitoring.BeatConfig `config:",inline"`
 
@@ -632,6 +643,18 @@ func (b *Beat) createBeater(bt beat.Creator) (beat.Beater, error) {
 	b.Registry.MustRegisterOutput(b.makeOutputReloader(publisher.OutputReloader()))
 
 	b.Publisher = publisher
+	if err := audit.Connect(publisher); err != nil {
+		return nil, err
+	}
+	if outputEnabled {
+		audit.Record(audit.CategoryOutput, "configured", mapstr.M{"type": b.Config.Output.Name()})
+	}
+
+	b.Tenants, err = b.createTenants(reg, settings)
+	if err != nil {
+		return nil, err
+	}
+
 	beater, err := bt(&b.Beat, sub)
 	if err != nil {
 		return nil, err
@@ -645,6 +668,11 @@ func (b *Beat) launch(settings Settings, bt beat.Creator) error {
 		_ = logp.Sync()
 	}()
 	defer logp.Info("%s stopped.", b.Info.Beat)
+	defer func() {
+		if err := audit.Close(); err != nil {
+			logp.Warn("Failed to close audit log: %v", err)
+		}
+	}()
 
 	defer func() {
 		if err := b.processors.Close(); err != nil {
@@ -687,6 +715,9 @@ func (b *Beat) launch(settings Settings, bt beat.Creator) error {
 		if err != nil {
 			return fmt.Errorf("could not start the HTTP server for the API: %w", err)
 		}
+		if err := api.AttachControlHandlers(b.API, monitoring.GetNamespace, pause.Default, inputlifecycle.Default); err != nil {
+			return fmt.Errorf("failed to attach http handlers for the control API: %w", err)
+		}
 		b.API.Start()
 		defer func() {
 			_ = b.API.Stop()
@@ -754,6 +785,11 @@ func (b *Beat) launch(settings Settings, bt beat.Creator) error {
 			if c, ok := b.Publisher.(io.Closer); ok {
 				c.Close()
 			}
+			for _, t := range b.Tenants {
+				if c, ok := t.Publisher.(io.Closer); ok {
+					c.Close()
+				}
+			}
 			beater.Stop()
 		})
 	}
@@ -869,6 +905,9 @@ type SetupSettings struct {
 	ILMPolicy                 bool
 	EnableAllFilesets         bool
 	ForceEnableModuleFilesets bool
+	// FromDir is a directory of assets written by the export commands. When
+	// set, only these assets are loaded.
+	FromDir string
 }
 
 // Setup registers ES index template, kibana dashboards, ml jobs and pipelines.
@@ -883,6 +922,10 @@ func (b *Beat) Setup(settings Settings, bt beat.Creator, setup SetupSettings) er
 		// Tell the beat that we're in the setup command
 		b.InSetupCmd = true
 
+		if setup.FromDir != "" {
+			return b.setupFromDir(setup.FromDir)
+		}
+
 		if setup.ForceEnableModuleFilesets {
 			if err := b.Beat.BeatConfig.SetBool("config.modules.force_enable_module_filesets", -1, true); err != nil {
 				return fmt.Errorf("error setting force_enable_module_filesets config option %w", err)
@@ -976,6 +1019,28 @@ func (b *Beat) Setup(settings Settings, bt beat.Creator, setup SetupSettings) er
 	}())
 }
 
+// setupFromDir loads the assets exported to dir into Elasticsearch.
+//
+//nolint:forbidigo // required to give feedback to user
+func (b *Beat) setupFromDir(dir string) error {
+	outCfg := b.Config.Output
+	if !isElasticsearchOutput(outCfg.Name()) {
+		return fmt.Errorf("setup from directory requested but the Elasticsearch output is not configured/enabled")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	esClient, err := eslegclient.NewConnectedClient(ctx, outCfg.Config(), b.Info.Beat)
+	if err != nil {
+		return err
+	}
+	n, err := loadAssetsFromDir(esClient, dir)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Loaded %d assets from %s\n", n, dir)
+	return nil
+}
+
 // handleFlags converts -flag to --flags, parses the command line
 // flags, and it invokes the HandleFlags callback if implemented by
 // the Beat.
@@ -992,6 +1057,7 @@ func (b *Beat) configure(settings Settings) error {
 	var err error
 
 	b.InputQueueSize = settings.InputQueueSize
+	b.supportsTenants = settings.Tenants
 
 	cfg, err := cfgfile.Load("", settings.ConfigOverrides)
 	if err != nil {
@@ -1057,6 +1123,10 @@ func (b *Beat) configure(settings Settings) error {
 	if err := configure.LoggingWithTypedOutputs(b.Info.Beat, b.Config.Logging, b.Config.EventLogging, logp.TypeKey, logp.EventType); err != nil {
 		return fmt.Errorf("error initializing logging: %w", err)
 	}
+	// The level of single selectors can be changed through the control API.
+	if err := loglevel.Default.Install(); err != nil {
+		return fmt.Errorf("error initializing selector log levels: %w", err)
+	}
 
 	// log paths values to help with troubleshooting
 	logp.Info("%s", paths.Paths.String())
@@ -1112,6 +1182,14 @@ func (b *Beat) configure(settings Settings) error {
 	// build the user-agent string to be used by the outputs
 	b.GenerateUserAgent()
 
+	if err := audit.Configure(b.Info, b.Config.Audit); err != nil {
+		return fmt.Errorf("error initializing audit log: %w", err)
+	}
+	audit.Record(audit.CategoryConfig, "loaded", mapstr.M{
+		"path":       cfgfile.GetDefaultCfgfile(),
+		"management": b.Manager.Enabled(),
+	})
+
 	if err := b.Manager.CheckRawConfig(b.RawConfig); err != nil {
 		return err
 	}
@@ -1395,10 +1473,74 @@ func (b *Beat) makeOutputReloader(outReloader pipeline.OutputReloader) reload.Re
 			}
 		}
 
-		return outReloader.Reload(update, b.createOutput)
+		if err := outReloader.Reload(update, b.createOutput); err != nil {
+			return err
+		}
+		action := "reloaded"
+		if update.Config == nil {
+			action = "removed"
+		}
+		audit.Record(audit.CategoryOutput, action, mapstr.M{"type": b.Config.Output.Name()})
+		return nil
 	})
 }
 
+// createTenants creates the pipelines of the tenants, publishing to the
+// output of each tenant through its own queue.
+func (b *Beat) createTenants(reg *monitoring.Registry, settings pipeline.Settings) ([]beat.Tenant, error) {
+	if len(b.Config.Tenants) == 0 {
+		return nil, nil
+	}
+	if !b.supportsTenants {
+		return nil, fmt.Errorf("%s does not support tenants", b.Info.Beat)
+	}
+	if b.Manager.Enabled() {
+		return nil, errors.New("tenants are not supported when the beat is managed")
+	}
+
+	configs, err := tenant.Load(b.Config.Tenants)
+	if err != nil {
+		return nil, fmt.Errorf("error loading tenants: %w", err)
+	}
+
+	metrics := reg.GetRegistry("tenants")
+	if metrics == nil {
+		metrics = reg.NewRegistry("tenants")
+	}
+	state := monitoring.GetNamespace("state").GetRegistry()
+	telemetry := state.GetRegistry("tenants")
+	if telemetry == nil {
+		telemetry = state.NewRegistry("tenants")
+	}
+
+	tenants := make([]beat.Tenant, 0, len(configs))
+	for _, t := range configs {
+		monitors := pipeline.Monitors{
+			Metrics:   metrics.NewRegistry(t.ID),
+			Telemetry: telemetry.NewRegistry(t.ID),
+			Logger:    logp.L().Named("publisher").With("tenant", t.ID),
+			Tracer:    b.Instrumentation.Tracer(),
+		}
+		publisher, err := pipeline.LoadWithSettings(b.Info, monitors, pipeline.Config{Queue: t.Queue}, b.makeOutputFactory(t.Output), settings)
+		if err != nil {
+			for _, created := range tenants {
+				if c, ok := created.Publisher.(io.Closer); ok {
+					c.Close()
+				}
+			}
+			return nil, fmt.Errorf("error initializing publisher of tenant %s: %w", t.ID, err)
+		}
+		logp.Info("Tenant %s configured with output %s", t.ID, t.Output.Name())
+		tenants = append(tenants, beat.Tenant{
+			ID:        t.ID,
+			Config:    t.Settings,
+			DataPath:  t.DataPath(),
+			Publisher: tenant.WithQuota(publisher, t.Quota),
+		})
+	}
+	return tenants, nil
+}
+
 func (b *Beat) makeOutputFactory(
 	cfg config.Namespace,
 ) func(outputs.Observer) (string, outputs.Group, error) {
@@ -21,18 +21,24 @@ package instance
 
 import (
 	"bytes"
+	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/elastic/beats/v7/libbeat/cfgfile"
 	"github.com/elastic/beats/v7/libbeat/common/reload"
+	"github.com/elastic/beats/v7/libbeat/instrumentation"
+	"github.com/elastic/beats/v7/libbeat/management"
 	"github.com/elastic/beats/v7/libbeat/management/status"
 	"github.com/elastic/beats/v7/libbeat/outputs"
+	"github.com/elastic/beats/v7/libbeat/publisher/pipeline"
 	"github.com/elastic/beats/v7/libbeat/publisher/queue/memqueue"
 	"github.com/elastic/elastic-agent-client/v7/pkg/client"
 	"github.com/elastic/elastic-agent-libs/config"
 	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/monitoring"
 	"github.com/elastic/go-ucfg/yaml"
 
 	"github.com/gofrs/uuid/v5"
@@ -493,3 +499,31 @@ func (m mockManager) Status() status.Status                         { return sta
 func (m mockManager) Stop()                                         {}
 func (m mockManager) UnregisterAction(action client.Action)         {}
 func (m mockManager) UpdateStatus(status status.Status, msg string) {}
+
+func TestCreateTenants(t *testing.T) {
+	b, err := NewBeat("testbeat", "testidx", "0.9", false, nil)
+	require.NoError(t, err)
+	b.Manager, err = management.NewManager(config.NewConfig(), b.Registry)
+	require.NoError(t, err)
+	b.Instrumentation, err = instrumentation.New(config.NewConfig(), "testbeat", "0.9")
+	require.NoError(t, err)
+	b.Config.Tenants = []*config.C{config.MustNewConfigFrom(map[string]interface{}{
+		"id":                      "acme",
+		"output.console.enabled":  true,
+		"quota.events_per_second": 100,
+		"testbeat.inputs":         []interface{}{map[string]interface{}{"type": "test"}},
+	})}
+
+	_, err = b.createTenants(monitoring.NewRegistry(), pipeline.Settings{})
+	require.ErrorContains(t, err, "testbeat does not support tenants")
+
+	b.supportsTenants = true
+	tenants, err := b.createTenants(monitoring.NewRegistry(), pipeline.Settings{})
+	require.NoError(t, err)
+	require.Len(t, tenants, 1)
+	assert.Equal(t, "acme", tenants[0].ID)
+	assert.True(t, tenants[0].Config.HasField("testbeat"))
+	assert.Contains(t, tenants[0].DataPath, filepath.Join("tenants", "acme"))
+	require.Implements(t, (*io.Closer)(nil), tenants[0].Publisher)
+	assert.NoError(t, tenants[0].Publisher.(io.Closer).Close())
+}
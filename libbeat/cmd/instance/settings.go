@@ -51,6 +51,10 @@ type Settings struct {
 	// beat.DropIfFull PublishMode. Leave as zero for default.
 	InputQueueSize int
 
+	// Tenants enables the tenants setting. The beater must then run the
+	// tenants in beat.Beat.Tenants next to its main configuration.
+	Tenants bool
+
 	// Initialize functions that are called in-order to initialize unique items for the beat.
 	Initialize []func()
 }
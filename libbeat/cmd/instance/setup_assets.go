@@ -0,0 +1,77 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package instance
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// assetLoader is the subset of the Elasticsearch client used to load
+// exported assets.
+type assetLoader interface {
+	LoadJSON(path string, json map[string]interface{}) ([]byte, error)
+	IsServerless() bool
+}
+
+// exportedAssets lists the components written by the export commands and
+// the Elasticsearch API each is loaded with, in loading order. Policies and
+// pipelines are loaded first, as templates may reference them.
+var exportedAssets = []struct {
+	component string
+	path      string
+}{
+	{"policy", "/_ilm/policy/"},
+	{"pipeline", "/_ingest/pipeline/"},
+	{"template", "/_index_template/"},
+}
+
+// loadAssetsFromDir loads the ILM policies, ingest pipelines and index
+// templates found in the subdirectories of dir, as written by the export
+// commands. It returns the number of loaded assets.
+func loadAssetsFromDir(client assetLoader, dir string) (int, error) {
+	var loaded int
+	for _, asset := range exportedAssets {
+		if asset.component == "policy" && client.IsServerless() {
+			continue
+		}
+		files, err := filepath.Glob(filepath.Join(dir, asset.component, "*.json"))
+		if err != nil {
+			return loaded, err
+		}
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return loaded, fmt.Errorf("error reading %s: %w", file, err)
+			}
+			var body map[string]interface{}
+			if err := json.Unmarshal(data, &body); err != nil {
+				return loaded, fmt.Errorf("error decoding %s: %w", file, err)
+			}
+			name := strings.TrimSuffix(filepath.Base(file), ".json")
+			if _, err := client.LoadJSON(asset.path+name, body); err != nil {
+				return loaded, fmt.Errorf("error loading %s %s: %w", asset.component, name, err)
+			}
+			loaded++
+		}
+	}
+	return loaded, nil
+}
@@ -0,0 +1,82 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build !integration
+
+package instance
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testAssetLoader struct {
+	serverless bool
+	loaded     []string
+}
+
+func (l *testAssetLoader) LoadJSON(path string, _ map[string]interface{}) ([]byte, error) {
+	l.loaded = append(l.loaded, path)
+	return nil, nil
+}
+
+func (l *testAssetLoader) IsServerless() bool {
+	return l.serverless
+}
+
+func TestLoadAssetsFromDir(t *testing.T) {
+	dir := t.TempDir()
+	for _, file := range []string{
+		"template/filebeat-8.0.0.json",
+		"pipeline/filebeat-8.0.0-nginx-access-pipeline.json",
+		"policy/filebeat.json",
+	} {
+		path := filepath.Join(dir, file)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(`{}`), 0o644))
+	}
+
+	t.Run("all assets", func(t *testing.T) {
+		loader := &testAssetLoader{}
+		n, err := loadAssetsFromDir(loader, dir)
+		require.NoError(t, err)
+		assert.Equal(t, 3, n)
+		assert.Equal(t, []string{
+			"/_ilm/policy/filebeat",
+			"/_ingest/pipeline/filebeat-8.0.0-nginx-access-pipeline",
+			"/_index_template/filebeat-8.0.0",
+		}, loader.loaded)
+	})
+
+	t.Run("serverless skips policies", func(t *testing.T) {
+		loader := &testAssetLoader{serverless: true}
+		n, err := loadAssetsFromDir(loader, dir)
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+		assert.NotContains(t, loader.loaded, "/_ilm/policy/filebeat")
+	})
+
+	t.Run("invalid asset", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "pipeline", "broken.json"), []byte(`{`), 0o644))
+		_, err := loadAssetsFromDir(&testAssetLoader{}, dir)
+		assert.ErrorContains(t, err, "broken.json")
+	})
+}
@@ -36,13 +36,14 @@ import (
 // flags and runs subcommands
 type BeatsRootCmd struct {
 	cobra.Command
-	RunCmd        *cobra.Command
-	SetupCmd      *cobra.Command
-	VersionCmd    *cobra.Command
-	CompletionCmd *cobra.Command
-	ExportCmd     *cobra.Command
-	TestCmd       *cobra.Command
-	KeystoreCmd   *cobra.Command
+	RunCmd         *cobra.Command
+	SetupCmd       *cobra.Command
+	VersionCmd     *cobra.Command
+	CompletionCmd  *cobra.Command
+	ExportCmd      *cobra.Command
+	TestCmd        *cobra.Command
+	KeystoreCmd    *cobra.Command
+	DiagnosticsCmd *cobra.Command
 }
 
 // GenRootCmdWithSettings returns the root command to use for your beat. It take the
@@ -79,6 +80,7 @@ func GenRootCmdWithSettings(beatCreator beat.Creator, settings instance.Settings
 	rootCmd.TestCmd = genTestCmd(settings, beatCreator)
 	rootCmd.SetupCmd = genSetupCmd(settings, beatCreator)
 	rootCmd.KeystoreCmd = genKeystoreCmd(settings)
+	rootCmd.DiagnosticsCmd = genDiagnosticsCmd(settings)
 	rootCmd.VersionCmd = GenVersionCmd(settings)
 	rootCmd.CompletionCmd = genCompletionCmd(settings, rootCmd)
 
@@ -125,6 +127,7 @@ func GenRootCmdWithSettings(beatCreator beat.Creator, settings instance.Settings
 	rootCmd.AddCommand(rootCmd.ExportCmd)
 	rootCmd.AddCommand(rootCmd.TestCmd)
 	rootCmd.AddCommand(rootCmd.KeystoreCmd)
+	rootCmd.AddCommand(rootCmd.DiagnosticsCmd)
 
 	return rootCmd
 }
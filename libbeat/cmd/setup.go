@@ -41,6 +41,8 @@ const (
 	// in the modules that have been explicitly enabled.  The
 	// requires "modules" to be used.
 	ForceEnableModuleFilesets = "force-enable-module-filesets"
+	// FromDirKey loads the assets written by the export commands to a directory
+	FromDirKey = "from-dir"
 )
 
 func genSetupCmd(settings instance.Settings, beatCreator beat.Creator) *cobra.Command {
@@ -53,6 +55,9 @@ func genSetupCmd(settings instance.Settings, beatCreator beat.Creator) *cobra.Co
  * Kibana dashboards (where available).
  * Ingest pipelines (where available).
  * ILM policy (for Elasticsearch 6.5 and newer).
+
+With --from-dir, the assets written to a directory by the export commands
+are loaded instead.
 `,
 		Run: func(cmd *cobra.Command, args []string) {
 			beat, err := instance.NewBeat(settings.Name, settings.IndexPrefix, settings.Version, settings.ElasticLicensed, settings.Initialize)
@@ -88,6 +93,7 @@ func genSetupCmd(settings instance.Settings, beatCreator beat.Creator) *cobra.Co
 
 			// create the struct to pass on
 			s := instance.SetupSettings{}
+			s.FromDir, _ = cmd.Flags().GetString(FromDirKey)
 			for k, v := range registeredFlags {
 				if setupAll || v {
 					switch k {
@@ -122,6 +128,8 @@ func genSetupCmd(settings instance.Settings, beatCreator beat.Creator) *cobra.Co
 	cfgfile.AddAllowedBackwardsCompatibleFlag("enable-all-filesets")
 	setup.Flags().Bool("force-enable-module-filesets", false, "Behave as if all filesets, within enabled modules, are enabled")
 	cfgfile.AddAllowedBackwardsCompatibleFlag("force-enable-module-filesets")
+	setup.Flags().String(FromDirKey, "", "Load the index templates, ILM policies and ingest pipelines exported to a directory")
+	cfgfile.AddAllowedBackwardsCompatibleFlag(FromDirKey)
 
 	return &setup
 }
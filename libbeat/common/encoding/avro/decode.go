@@ -0,0 +1,324 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package avro
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+var errShortBuffer = errors.New("unexpected end of data")
+
+// Decode decodes a single datum of the given schema from the beginning of
+// data. It returns the decoded value and the number of bytes read.
+//
+// Records and maps are decoded to mapstr.M, arrays to []interface{}, enums
+// to their symbol, and unions to the value of their branch. Bytes and fixed
+// values are base64 encoded. The date and timestamp logical types are
+// decoded to time.Time, and decimals to their string representation.
+func Decode(s *Schema, data []byte) (interface{}, int, error) {
+	r := reader{buf: data}
+	v, err := r.value(s)
+	if err != nil {
+		return nil, r.pos, err
+	}
+	return v, r.pos, nil
+}
+
+type reader struct {
+	buf []byte
+	pos int
+
+	// depth is the nesting level of the value being decoded.
+	depth int
+	// empty counts the items of zero size decoded from arrays and maps.
+	empty int
+}
+
+// maxDepth bounds the nesting of the decoded values, recursive schemas
+// could otherwise exhaust the stack.
+const maxDepth = 64
+
+// maxEmptyItems bounds the number of items of zero size, like nulls, that
+// are decoded from the arrays and maps of a datum. They are not bound by the
+// size of the data.
+const maxEmptyItems = 1 << 16
+
+func (r *reader) value(s *Schema) (interface{}, error) {
+	if r.depth >= maxDepth {
+		return nil, fmt.Errorf("values nested deeper than %d levels", maxDepth)
+	}
+	r.depth++
+	defer func() { r.depth-- }()
+
+	switch s.Type {
+	case "null":
+		return nil, nil
+	case "boolean":
+		b, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		return b != 0, nil
+	case "int":
+		n, err := r.long()
+		if err != nil {
+			return nil, err
+		}
+		if n < math.MinInt32 || n > math.MaxInt32 {
+			return nil, fmt.Errorf("int value %d out of range", n)
+		}
+		if s.Logical == "date" {
+			return time.Unix(n*24*60*60, 0).UTC(), nil
+		}
+		return int32(n), nil
+	case "long":
+		n, err := r.long()
+		if err != nil {
+			return nil, err
+		}
+		switch s.Logical {
+		case "timestamp-millis", "local-timestamp-millis":
+			return time.UnixMilli(n).UTC(), nil
+		case "timestamp-micros", "local-timestamp-micros":
+			return time.UnixMicro(n).UTC(), nil
+		case "timestamp-nanos", "local-timestamp-nanos":
+			return time.Unix(0, n).UTC(), nil
+		}
+		return n, nil
+	case "float":
+		b, err := r.next(4)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float32frombits(binary.LittleEndian.Uint32(b)), nil
+	case "double":
+		b, err := r.next(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b)), nil
+	case "bytes":
+		b, err := r.bytes()
+		if err != nil {
+			return nil, err
+		}
+		return bytesValue(s, b), nil
+	case "string":
+		b, err := r.bytes()
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	case "fixed":
+		b, err := r.next(s.Size)
+		if err != nil {
+			return nil, err
+		}
+		return bytesValue(s, b), nil
+	case "enum":
+		i, err := r.long()
+		if err != nil {
+			return nil, err
+		}
+		if i < 0 || i >= int64(len(s.Symbols)) {
+			return nil, fmt.Errorf("enum %s index %d out of range", s.Name, i)
+		}
+		return s.Symbols[i], nil
+	case "union":
+		i, err := r.long()
+		if err != nil {
+			return nil, err
+		}
+		if i < 0 || i >= int64(len(s.Branches)) {
+			return nil, fmt.Errorf("union index %d out of range", i)
+		}
+		return r.value(s.Branches[i])
+	case "record":
+		m := make(mapstr.M, len(s.Fields))
+		for _, f := range s.Fields {
+			v, err := r.value(f.Type)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", s.Name, f.Name, err)
+			}
+			m[f.Name] = v
+		}
+		return m, nil
+	case "array":
+		list := []interface{}{}
+		err := r.blocks(minSize(s.Items, 0), func() error {
+			v, err := r.value(s.Items)
+			list = append(list, v)
+			return err
+		})
+		return list, err
+	case "map":
+		m := mapstr.M{}
+		// Keys are strings, at least one byte long.
+		err := r.blocks(1+minSize(s.Values, 0), func() error {
+			k, err := r.bytes()
+			if err != nil {
+				return err
+			}
+			v, err := r.value(s.Values)
+			m[string(k)] = v
+			return err
+		})
+		return m, err
+	}
+	return nil, fmt.Errorf("unsupported type %q", s.Type)
+}
+
+// blocks reads the blocks of an array or map whose items are encoded in at
+// least size bytes, calling item for each item.
+func (r *reader) blocks(size int, item func() error) error {
+	for {
+		n, err := r.long()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+		if n < 0 {
+			// A negative count is followed by the size of the block.
+			n = -n
+			if _, err := r.long(); err != nil {
+				return err
+			}
+		}
+		if err := r.checkCount(n, size); err != nil {
+			return err
+		}
+		for ; n > 0; n-- {
+			if err := item(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// checkCount verifies that n items encoded in at least size bytes each can
+// be read from the remaining data, so that corrupted counts are rejected
+// before looping on them.
+func (r *reader) checkCount(n int64, size int) error {
+	if n < 0 {
+		return fmt.Errorf("invalid item count %d", n)
+	}
+	if size == 0 {
+		if n > int64(maxEmptyItems-r.empty) {
+			return fmt.Errorf("more than %d items of zero size", maxEmptyItems)
+		}
+		r.empty += int(n)
+		return nil
+	}
+	if n > int64(len(r.buf)-r.pos)/int64(size) {
+		return fmt.Errorf("item count %d exceeds the data size", n)
+	}
+	return nil
+}
+
+// minSize returns the minimum number of bytes of the binary encoding of a
+// value of the schema.
+func minSize(s *Schema, depth int) int {
+	switch s.Type {
+	case "null":
+		return 0
+	case "float":
+		return 4
+	case "double":
+		return 8
+	case "fixed":
+		return s.Size
+	case "record":
+		// Records can only contain themselves through unions, arrays
+		// or maps, stop on invalid schemas.
+		if depth >= maxDepth {
+			return 0
+		}
+		size := 0
+		for _, f := range s.Fields {
+			size += minSize(f.Type, depth+1)
+		}
+		return size
+	}
+	// Booleans, varints and the lengths of bytes, strings, arrays and
+	// maps take at least one byte, like the index of unions.
+	return 1
+}
+
+func (r *reader) byte() (byte, error) {
+	if r.pos >= len(r.buf) {
+		return 0, errShortBuffer
+	}
+	b := r.buf[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *reader) next(n int) ([]byte, error) {
+	if n < 0 || n > len(r.buf)-r.pos {
+		return nil, errShortBuffer
+	}
+	b := r.buf[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+// long reads a zig-zag encoded variable length integer.
+func (r *reader) long() (int64, error) {
+	u, n := binary.Uvarint(r.buf[r.pos:])
+	if n <= 0 {
+		if n == 0 {
+			return 0, errShortBuffer
+		}
+		return 0, errors.New("varint overflows a 64-bit integer")
+	}
+	r.pos += n
+	return int64(u>>1) ^ -int64(u&1), nil
+}
+
+func (r *reader) bytes() ([]byte, error) {
+	n, err := r.long()
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 || n > int64(len(r.buf)-r.pos) {
+		return nil, errShortBuffer
+	}
+	return r.next(int(n))
+}
+
+func bytesValue(s *Schema, b []byte) interface{} {
+	if s.Logical == "decimal" {
+		// Two's-complement big-endian unscaled value.
+		unscaled := new(big.Int).SetBytes(b)
+		if len(b) > 0 && b[0]&0x80 != 0 {
+			unscaled.Sub(unscaled, new(big.Int).Lsh(big.NewInt(1), uint(len(b))*8))
+		}
+		return new(big.Rat).SetFrac(unscaled, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(s.Scale)), nil)).FloatString(s.Scale)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
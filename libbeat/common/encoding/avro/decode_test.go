@@ -0,0 +1,271 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package avro
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"hash/crc32"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// encoder writes Avro binary encoded values for the tests.
+type encoder struct {
+	bytes.Buffer
+}
+
+func (e *encoder) long(n int64) *encoder {
+	e.Write(binary.AppendUvarint(nil, uint64((n<<1)^(n>>63))))
+	return e
+}
+
+func (e *encoder) str(s string) *encoder {
+	e.long(int64(len(s)))
+	e.WriteString(s)
+	return e
+}
+
+func (e *encoder) double(f float64) *encoder {
+	e.Write(binary.LittleEndian.AppendUint64(nil, math.Float64bits(f)))
+	return e
+}
+
+const loginSchema = `{
+	"type": "record",
+	"name": "Login",
+	"namespace": "test.auth",
+	"fields": [
+		{"name": "user", "type": "string"},
+		{"name": "attempts", "type": "int"},
+		{"name": "result", "type": {"type": "enum", "name": "Result", "symbols": ["SUCCESS", "FAILURE"]}},
+		{"name": "roles", "type": {"type": "array", "items": "string"}},
+		{"name": "counters", "type": {"type": "map", "values": "long"}},
+		{"name": "comment", "type": ["null", "string"]},
+		{"name": "time", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+		{"name": "score", "type": "double"},
+		{"name": "amount", "type": {"type": "bytes", "logicalType": "decimal", "precision": 6, "scale": 2}},
+		{"name": "token", "type": {"type": "fixed", "name": "Token", "size": 2}},
+		{"name": "previous", "type": ["null", "Login"]}
+	]
+}`
+
+func encodeLogin(e *encoder, user string, previous bool) {
+	e.str(user)
+	e.long(3)
+	e.long(1)
+	e.long(2).str("admin").str("dev").long(0)
+	// A block with a negative count is followed by its size.
+	e.long(-1).long(9).str("failed").long(2).long(0)
+	e.long(1).str("first")
+	e.long(1685613600000)
+	e.double(0.5)
+	e.long(2).Write([]byte{0xfe, 0x0c}) // -500
+	e.Write([]byte{0xca, 0xfe})
+	if previous {
+		e.long(1)
+		encodeLogin(e, "bob", false)
+	} else {
+		e.long(0)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	s, err := ParseSchema(loginSchema)
+	require.NoError(t, err)
+
+	var e encoder
+	encodeLogin(&e, "alice", true)
+	v, n, err := Decode(s, e.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, e.Len(), n)
+
+	login := func(user string, previous interface{}) mapstr.M {
+		return mapstr.M{
+			"user":     user,
+			"attempts": int32(3),
+			"result":   "FAILURE",
+			"roles":    []interface{}{"admin", "dev"},
+			"counters": mapstr.M{"failed": int64(2)},
+			"comment":  "first",
+			"time":     time.UnixMilli(1685613600000).UTC(),
+			"score":    0.5,
+			"amount":   "-5.00",
+			"token":    "yv4=",
+			"previous": previous,
+		}
+	}
+	assert.Equal(t, login("alice", login("bob", nil)), v)
+
+	t.Run("truncated data", func(t *testing.T) {
+		for i := 0; i < e.Len(); i++ {
+			_, _, err := Decode(s, e.Bytes()[:i])
+			assert.Error(t, err, "length %d", i)
+		}
+	})
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		schema string
+		data   []byte
+	}{
+		"union index":       {`["null", "string"]`, new(encoder).long(2).Bytes()},
+		"enum index":        {`{"type": "enum", "name": "E", "symbols": ["A"]}`, new(encoder).long(-1).Bytes()},
+		"int out of range":  {`"int"`, new(encoder).long(math.MaxInt32 + 1).Bytes()},
+		"negative length":   {`"string"`, new(encoder).long(-2).Bytes()},
+		"block count":       {`{"type": "array", "items": "int"}`, new(encoder).long(1 << 20).Bytes()},
+		"block size":        {`{"type": "array", "items": "double"}`, new(encoder).long(2).double(1).Bytes()},
+		"empty items":       {`{"type": "array", "items": "null"}`, new(encoder).long(1 << 15).long(1 << 15).long(1).long(0).Bytes()},
+		"nesting":           {`{"type": "record", "name": "R", "fields": [{"name": "next", "type": ["null", "R"]}]}`, append(bytes.Repeat([]byte{2}, 100), 0)},
+		"varint overflow":   {`"long"`, bytes.Repeat([]byte{0xff}, 11)},
+		"fixed is too long": {`{"type": "fixed", "name": "F", "size": 4}`, []byte{1, 2}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			s, err := ParseSchema(tc.schema)
+			require.NoError(t, err)
+			_, _, err = Decode(s, tc.data)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseSchema(t *testing.T) {
+	s, err := ParseSchema(`{
+		"type": "record", "name": "a.Outer",
+		"fields": [
+			{"name": "inner", "type": {"type": "record", "name": "Inner", "fields": [{"name": "x", "type": "int"}]}},
+			{"name": "again", "type": "a.Inner"},
+			{"name": "short", "type": "Inner"},
+			{"name": "day", "type": {"type": "int", "logicalType": "date"}}
+		]
+	}`)
+	require.NoError(t, err)
+	assert.Equal(t, "a.Inner", s.Fields[0].Type.Name)
+	assert.Same(t, s.Fields[0].Type, s.Fields[1].Type)
+	assert.Same(t, s.Fields[0].Type, s.Fields[2].Type)
+
+	v, _, err := Decode(s, new(encoder).long(1).long(2).long(-3).long(19509).Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, mapstr.M{
+		"inner": mapstr.M{"x": int32(1)},
+		"again": mapstr.M{"x": int32(2)},
+		"short": mapstr.M{"x": int32(-3)},
+		"day":   time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC),
+	}, v)
+
+	for name, schema := range map[string]string{
+		"invalid json":    `{`,
+		"unknown type":    `"Missing"`,
+		"missing name":    `{"type": "record", "fields": []}`,
+		"missing fields":  `{"type": "record", "name": "R"}`,
+		"missing items":   `{"type": "array"}`,
+		"missing symbols": `{"type": "enum", "name": "E"}`,
+		"nested union":    `["null", ["int"]]`,
+		"duplicate name":  `{"type": "record", "name": "R", "fields": [{"name": "r", "type": {"type": "fixed", "name": "R", "size": 1}}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseSchema(schema)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestReadOCF(t *testing.T) {
+	const schema = `{"type": "record", "name": "R", "fields": [{"name": "n", "type": "long"}, {"name": "s", "type": "string"}]}`
+	sync := []byte("0123456789abcdef")
+
+	var block encoder
+	block.long(1).str("one")
+	block.long(2).str("two")
+
+	compress := map[string]func(t *testing.T, b []byte) []byte{
+		"null": func(_ *testing.T, b []byte) []byte { return b },
+		"deflate": func(t *testing.T, b []byte) []byte {
+			var buf bytes.Buffer
+			w, err := flate.NewWriter(&buf, flate.BestSpeed)
+			require.NoError(t, err)
+			_, _ = w.Write(b)
+			require.NoError(t, w.Close())
+			return buf.Bytes()
+		},
+		"snappy": func(_ *testing.T, b []byte) []byte {
+			return binary.BigEndian.AppendUint32(snappy.Encode(nil, b), crc32.ChecksumIEEE(b))
+		},
+		"zstandard": func(t *testing.T, b []byte) []byte {
+			enc, err := zstd.NewWriter(nil)
+			require.NoError(t, err)
+			defer enc.Close()
+			return enc.EncodeAll(b, nil)
+		},
+	}
+
+	for codec, fn := range compress {
+		t.Run(codec, func(t *testing.T) {
+			var e encoder
+			e.Write(ocfMagic)
+			e.long(2).str("avro.schema").str(schema).str("avro.codec").str(codec).long(0)
+			e.Write(sync)
+			// Two blocks with the same content.
+			for i := 0; i < 2; i++ {
+				data := fn(t, block.Bytes())
+				e.long(2).long(int64(len(data)))
+				e.Write(data)
+				e.Write(sync)
+			}
+
+			s, records, err := ReadOCF(e.Bytes())
+			require.NoError(t, err)
+			assert.Equal(t, "R", s.Name)
+			one := mapstr.M{"n": int64(1), "s": "one"}
+			two := mapstr.M{"n": int64(2), "s": "two"}
+			assert.Equal(t, []interface{}{one, two, one, two}, records)
+
+			// Corrupting the last sync marker fails.
+			corrupted := bytes.Clone(e.Bytes())
+			corrupted[len(corrupted)-1] = 'x'
+			_, _, err = ReadOCF(corrupted)
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("not a container file", func(t *testing.T) {
+		_, _, err := ReadOCF([]byte("{}"))
+		assert.ErrorIs(t, err, ErrNotOCF)
+	})
+
+	t.Run("unsupported codec", func(t *testing.T) {
+		var e encoder
+		e.Write(ocfMagic)
+		e.long(2).str("avro.schema").str(schema).str("avro.codec").str("bzip2").long(0)
+		e.Write(sync)
+		e.long(1).long(1).Write([]byte{0})
+		e.Write(sync)
+		_, _, err := ReadOCF(e.Bytes())
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,159 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package avro
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// ocfMagic starts every object container file.
+var ocfMagic = []byte{'O', 'b', 'j', 1}
+
+// maxBlockSize limits the size of a decompressed block of an object
+// container file.
+const maxBlockSize = 64 << 20
+
+// ErrNotOCF is returned by ReadOCF if the data is not an object container
+// file.
+var ErrNotOCF = errors.New("not an avro object container file")
+
+// ReadOCF decodes all the records of an object container file. It returns
+// the schema embedded in the file and the decoded records. The null,
+// deflate, snappy and zstandard codecs are supported.
+func ReadOCF(data []byte) (*Schema, []interface{}, error) {
+	if !bytes.HasPrefix(data, ocfMagic) {
+		return nil, nil, ErrNotOCF
+	}
+	r := reader{buf: data, pos: len(ocfMagic)}
+
+	var meta map[string][]byte
+	err := r.blocks(2, func() error {
+		k, err := r.bytes()
+		if err != nil {
+			return err
+		}
+		v, err := r.bytes()
+		if meta == nil {
+			meta = map[string][]byte{}
+		}
+		meta[string(k)] = v
+		return err
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read the header: %w", err)
+	}
+	schema, err := ParseSchema(string(meta["avro.schema"]))
+	if err != nil {
+		return nil, nil, err
+	}
+	codec := string(meta["avro.codec"])
+	sync, err := r.next(16)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read the header: %w", err)
+	}
+
+	var records []interface{}
+	for r.pos < len(r.buf) {
+		count, err := r.long()
+		if err != nil {
+			return nil, nil, err
+		}
+		block, err := r.bytes()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read block: %w", err)
+		}
+		marker, err := r.next(16)
+		if err != nil || !bytes.Equal(marker, sync) {
+			return nil, nil, errors.New("invalid sync marker")
+		}
+		if block, err = decompress(codec, block); err != nil {
+			return nil, nil, fmt.Errorf("failed to decompress block: %w", err)
+		}
+
+		br := reader{buf: block}
+		if err := br.checkCount(count, minSize(schema, 0)); err != nil {
+			return nil, nil, fmt.Errorf("invalid block: %w", err)
+		}
+		for i := int64(0); i < count; i++ {
+			v, err := br.value(schema)
+			if err != nil {
+				return nil, nil, err
+			}
+			records = append(records, v)
+		}
+	}
+	return schema, records, nil
+}
+
+func decompress(codec string, block []byte) ([]byte, error) {
+	switch codec {
+	case "", "null":
+		return block, nil
+	case "deflate":
+		return readAllLimited(flate.NewReader(bytes.NewReader(block)))
+	case "snappy":
+		// The compressed data is followed by the CRC32 checksum of the
+		// uncompressed data.
+		if len(block) < 4 {
+			return nil, errShortBuffer
+		}
+		n, err := snappy.DecodedLen(block[:len(block)-4])
+		if err != nil {
+			return nil, err
+		}
+		if n > maxBlockSize {
+			return nil, fmt.Errorf("block size %d exceeds the limit of %d bytes", n, maxBlockSize)
+		}
+		out, err := snappy.Decode(nil, block[:len(block)-4])
+		if err != nil {
+			return nil, err
+		}
+		if crc32.ChecksumIEEE(out) != binary.BigEndian.Uint32(block[len(block)-4:]) {
+			return nil, errors.New("checksum mismatch")
+		}
+		return out, nil
+	case "zstandard":
+		dec, err := zstd.NewReader(bytes.NewReader(block), zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		defer dec.Close()
+		return readAllLimited(dec)
+	}
+	return nil, fmt.Errorf("unsupported codec %q", codec)
+}
+
+func readAllLimited(r io.Reader) ([]byte, error) {
+	out, err := io.ReadAll(io.LimitReader(r, maxBlockSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(out) > maxBlockSize {
+		return nil, fmt.Errorf("block exceeds the limit of %d bytes", maxBlockSize)
+	}
+	return out, nil
+}
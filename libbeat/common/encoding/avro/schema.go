@@ -0,0 +1,234 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package avro decodes Avro binary encoded data and object container files
+// into maps, using the writer schema of the data.
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Schema is a parsed Avro schema.
+type Schema struct {
+	// Type is the primitive type name, or one of record, enum, array, map,
+	// union or fixed.
+	Type string
+	// Name is the full name of named types.
+	Name string
+	// Logical is the logicalType annotation, if any.
+	Logical string
+
+	Fields   []Field   // record
+	Symbols  []string  // enum
+	Items    *Schema   // array
+	Values   *Schema   // map
+	Branches []*Schema // union
+	Size     int       // fixed
+	Scale    int       // decimal
+}
+
+// Field is a field of a record.
+type Field struct {
+	Name string
+	Type *Schema
+}
+
+var primitives = map[string]bool{
+	"null": true, "boolean": true, "int": true, "long": true,
+	"float": true, "double": true, "bytes": true, "string": true,
+}
+
+// ParseSchema parses a schema in its JSON representation.
+func ParseSchema(s string) (*Schema, error) {
+	var raw interface{}
+	if err := json.Unmarshal([]byte(s), &raw); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	p := parser{named: map[string]*Schema{}}
+	return p.parse(raw, "")
+}
+
+type parser struct {
+	named map[string]*Schema
+}
+
+func (p *parser) parse(raw interface{}, namespace string) (*Schema, error) {
+	switch v := raw.(type) {
+	case string:
+		if primitives[v] {
+			return &Schema{Type: v}, nil
+		}
+		if s, ok := p.named[fullName(v, namespace)]; ok {
+			return s, nil
+		}
+		if s, ok := p.named[v]; ok {
+			return s, nil
+		}
+		return nil, fmt.Errorf("unknown type %q", v)
+	case []interface{}:
+		u := &Schema{Type: "union"}
+		for _, branch := range v {
+			s, err := p.parse(branch, namespace)
+			if err != nil {
+				return nil, err
+			}
+			if s.Type == "union" {
+				return nil, fmt.Errorf("unions cannot contain unions")
+			}
+			u.Branches = append(u.Branches, s)
+		}
+		if len(u.Branches) == 0 {
+			return nil, fmt.Errorf("unions must have at least one branch")
+		}
+		return u, nil
+	case map[string]interface{}:
+		return p.parseObject(v, namespace)
+	}
+	return nil, fmt.Errorf("invalid schema of type %T", raw)
+}
+
+func (p *parser) parseObject(v map[string]interface{}, namespace string) (*Schema, error) {
+	typ, ok := v["type"].(string)
+	if !ok {
+		// {"type": {...}} and {"type": [...]} are equivalent to the
+		// nested schema.
+		if nested, exists := v["type"]; exists {
+			return p.parse(nested, namespace)
+		}
+		return nil, fmt.Errorf("schema is missing the type")
+	}
+	logical, _ := v["logicalType"].(string)
+
+	switch typ {
+	case "record", "error", "enum", "fixed":
+	case "array":
+		items, ok := v["items"]
+		if !ok {
+			return nil, fmt.Errorf("array is missing the items")
+		}
+		s, err := p.parse(items, namespace)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "array", Items: s, Logical: logical}, nil
+	case "map":
+		values, ok := v["values"]
+		if !ok {
+			return nil, fmt.Errorf("map is missing the values")
+		}
+		s, err := p.parse(values, namespace)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "map", Values: s, Logical: logical}, nil
+	default:
+		if !primitives[typ] {
+			// A reference to a named type.
+			return p.parse(typ, namespace)
+		}
+		s := &Schema{Type: typ, Logical: logical}
+		if logical == "decimal" {
+			s.Scale = intValue(v["scale"])
+		}
+		return s, nil
+	}
+
+	name, _ := v["name"].(string)
+	if name == "" {
+		return nil, fmt.Errorf("%s is missing the name", typ)
+	}
+	if ns, ok := v["namespace"].(string); ok && !strings.Contains(name, ".") {
+		namespace = ns
+	}
+	name = fullName(name, namespace)
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		namespace = name[:i]
+	} else {
+		namespace = ""
+	}
+	if _, exists := p.named[name]; exists {
+		return nil, fmt.Errorf("type %q is defined twice", name)
+	}
+
+	s := &Schema{Name: name, Logical: logical}
+	// Register the type before parsing the fields so that records can
+	// refer to themselves.
+	p.named[name] = s
+
+	switch typ {
+	case "record", "error":
+		s.Type = "record"
+		fields, ok := v["fields"].([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("record %q is missing the fields", name)
+		}
+		for _, f := range fields {
+			fm, ok := f.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("record %q has an invalid field", name)
+			}
+			fname, _ := fm["name"].(string)
+			if fname == "" {
+				return nil, fmt.Errorf("record %q has a field without name", name)
+			}
+			ftype, err := p.parse(fm["type"], namespace)
+			if err != nil {
+				return nil, fmt.Errorf("field %q of record %q: %w", fname, name, err)
+			}
+			s.Fields = append(s.Fields, Field{Name: fname, Type: ftype})
+		}
+	case "enum":
+		s.Type = "enum"
+		symbols, ok := v["symbols"].([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("enum %q is missing the symbols", name)
+		}
+		for _, sym := range symbols {
+			str, ok := sym.(string)
+			if !ok {
+				return nil, fmt.Errorf("enum %q has an invalid symbol", name)
+			}
+			s.Symbols = append(s.Symbols, str)
+		}
+	case "fixed":
+		s.Type = "fixed"
+		size, ok := v["size"].(float64)
+		if !ok || size < 0 {
+			return nil, fmt.Errorf("fixed %q has an invalid size", name)
+		}
+		s.Size = int(size)
+		if logical == "decimal" {
+			s.Scale = intValue(v["scale"])
+		}
+	}
+	return s, nil
+}
+
+func fullName(name, namespace string) string {
+	if strings.Contains(name, ".") || namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
+
+func intValue(v interface{}) int {
+	f, _ := v.(float64)
+	return int(f)
+}
@@ -0,0 +1,239 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package protobuf decodes protobuf messages of types described by
+// compiled descriptor sets into maps, without generated code.
+package protobuf
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// ErrMissingRequired is returned by Decode, together with the decoded
+// message, when required fields of a proto2 message are not set.
+var ErrMissingRequired = errors.New("required fields are missing")
+
+// BytesMode defines how bytes fields are written.
+type BytesMode string
+
+const (
+	// BytesBase64 writes bytes fields as base64 encoded strings.
+	BytesBase64 BytesMode = "base64"
+	// BytesHex writes bytes fields as hex encoded strings.
+	BytesHex BytesMode = "hex"
+	// BytesString writes bytes fields as they are, as strings.
+	BytesString BytesMode = "string"
+)
+
+// LoadDescriptor reads a serialized FileDescriptorSet, as written by
+// `protoc --include_imports --descriptor_set_out`, and returns the
+// descriptor of the message type with the given fully qualified name.
+func LoadDescriptor(path, messageType string) (protoreflect.MessageDescriptor, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse descriptor set %s: %w", path, err)
+	}
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptor set %s: %w", path, err)
+	}
+	d, err := files.FindDescriptorByName(protoreflect.FullName(messageType))
+	if err != nil {
+		return nil, fmt.Errorf("message type %q not found in %s: %w", messageType, path, err)
+	}
+	desc, ok := d.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%q in %s is not a message type", messageType, path)
+	}
+	return desc, nil
+}
+
+// A Decoder decodes messages of a single type.
+type Decoder struct {
+	desc protoreflect.MessageDescriptor
+
+	bytesMode      BytesMode
+	enumsAsNumbers bool
+	jsonNames      bool
+	emitDefaults   bool
+}
+
+// NewDecoder returns a new decoder for messages described by desc.
+func NewDecoder(desc protoreflect.MessageDescriptor) *Decoder {
+	return &Decoder{desc: desc, bytesMode: BytesBase64}
+}
+
+// BytesAs sets how bytes fields are written. The default is BytesBase64.
+func (d *Decoder) BytesAs(mode BytesMode) { d.bytesMode = mode }
+
+// EnumsAsNumbers causes the Decoder to write enum values as numbers instead
+// of the names of the values.
+func (d *Decoder) EnumsAsNumbers() { d.enumsAsNumbers = true }
+
+// UseJSONNames causes the Decoder to use the lowerCamelCase JSON names of
+// fields instead of their names in the proto file.
+func (d *Decoder) UseJSONNames() { d.jsonNames = true }
+
+// EmitDefaults causes the Decoder to also write scalar fields that are not
+// set in the message, with their default values.
+func (d *Decoder) EmitDefaults() { d.emitDefaults = true }
+
+// Decode unmarshals data and returns a map containing the message. If
+// required fields are missing, the decoded message is returned with an
+// error wrapping ErrMissingRequired.
+func (d *Decoder) Decode(data []byte) (mapstr.M, error) {
+	msg := dynamicpb.NewMessage(d.desc)
+	if err := (proto.UnmarshalOptions{AllowPartial: true}).Unmarshal(data, msg); err != nil {
+		return nil, err
+	}
+	m := d.message(msg)
+	if err := proto.CheckInitialized(msg); err != nil {
+		return m, fmt.Errorf("%w: %v", ErrMissingRequired, err)
+	}
+	return m, nil
+}
+
+func (d *Decoder) message(msg protoreflect.Message) mapstr.M {
+	m := mapstr.M{}
+	msg.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		m[d.name(fd)] = d.field(fd, v)
+		return true
+	})
+	if d.emitDefaults {
+		fields := msg.Descriptor().Fields()
+		for i := 0; i < fields.Len(); i++ {
+			fd := fields.Get(i)
+			if msg.Has(fd) || fd.IsList() || fd.IsMap() || fd.Message() != nil || fd.ContainingOneof() != nil {
+				continue
+			}
+			m[d.name(fd)] = d.singular(fd, msg.Get(fd))
+		}
+	}
+	return m
+}
+
+func (d *Decoder) name(fd protoreflect.FieldDescriptor) string {
+	if d.jsonNames {
+		return fd.JSONName()
+	}
+	return string(fd.Name())
+}
+
+func (d *Decoder) field(fd protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	switch {
+	case fd.IsList():
+		list := v.List()
+		out := make([]interface{}, list.Len())
+		for i := range out {
+			out[i] = d.singular(fd, list.Get(i))
+		}
+		return out
+	case fd.IsMap():
+		out := mapstr.M{}
+		v.Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+			out[k.String()] = d.singular(fd.MapValue(), v)
+			return true
+		})
+		return out
+	}
+	return d.singular(fd, v)
+}
+
+func (d *Decoder) singular(fd protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return v.Bool()
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return v.Int()
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return v.Uint()
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return v.Float()
+	case protoreflect.StringKind:
+		return v.String()
+	case protoreflect.BytesKind:
+		return d.bytes(v.Bytes())
+	case protoreflect.EnumKind:
+		if !d.enumsAsNumbers {
+			if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+				return string(ev.Name())
+			}
+		}
+		return int64(v.Enum())
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return d.wellKnown(v.Message())
+	}
+	return v.Interface()
+}
+
+func (d *Decoder) bytes(b []byte) interface{} {
+	switch d.bytesMode {
+	case BytesHex:
+		return hex.EncodeToString(b)
+	case BytesString:
+		return string(b)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// wellKnown converts timestamps, durations and wrapper types to their
+// values. Other messages are converted to maps.
+func (d *Decoder) wellKnown(msg protoreflect.Message) interface{} {
+	desc := msg.Descriptor()
+	name := string(desc.FullName())
+	if !strings.HasPrefix(name, "google.protobuf.") {
+		return d.message(msg)
+	}
+
+	fields := desc.Fields()
+	switch name {
+	case "google.protobuf.Timestamp":
+		secs := msg.Get(fields.ByName("seconds")).Int()
+		nanos := msg.Get(fields.ByName("nanos")).Int()
+		return time.Unix(secs, nanos).UTC()
+	case "google.protobuf.Duration":
+		secs := msg.Get(fields.ByName("seconds")).Int()
+		nanos := msg.Get(fields.ByName("nanos")).Int()
+		return secs*int64(time.Second) + nanos
+	}
+	if strings.HasSuffix(name, "Value") && fields.Len() == 1 {
+		if fd := fields.ByName("value"); fd != nil {
+			return d.singular(fd, msg.Get(fd))
+		}
+	}
+	return d.message(msg)
+}
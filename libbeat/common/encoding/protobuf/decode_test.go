@@ -0,0 +1,149 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package protobuf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+const (
+	testDescriptor  = "testdata/login.desc"
+	testMessageType = "test.auth.Login"
+)
+
+// testMessage returns a serialized test.auth.Login message with fields set
+// by the given function.
+func testMessage(t *testing.T, desc protoreflect.MessageDescriptor, set func(msg *dynamicpb.Message)) []byte {
+	t.Helper()
+	msg := dynamicpb.NewMessage(desc)
+	set(msg)
+	data, err := proto.MarshalOptions{AllowPartial: true}.Marshal(msg)
+	require.NoError(t, err)
+	return data
+}
+
+func setField(msg protoreflect.Message, name string, v interface{}) {
+	msg.Set(msg.Descriptor().Fields().ByName(protoreflect.Name(name)), protoreflect.ValueOf(v))
+}
+
+func TestLoadDescriptor(t *testing.T) {
+	desc, err := LoadDescriptor(testDescriptor, testMessageType)
+	require.NoError(t, err)
+	assert.Equal(t, protoreflect.FullName(testMessageType), desc.FullName())
+
+	_, err = LoadDescriptor(testDescriptor, "test.auth.Logout")
+	assert.Error(t, err)
+
+	_, err = LoadDescriptor(testDescriptor, "test.auth.Login.Result")
+	assert.Error(t, err)
+
+	_, err = LoadDescriptor("testdata/login.proto", testMessageType)
+	assert.Error(t, err)
+}
+
+func TestDecode(t *testing.T) {
+	desc, err := LoadDescriptor(testDescriptor, testMessageType)
+	require.NoError(t, err)
+	fields := desc.Fields()
+
+	data := testMessage(t, desc, func(msg *dynamicpb.Message) {
+		setField(msg, "user_name", "alice")
+		setField(msg, "attempts", int64(3))
+		msg.Set(fields.ByName("result"), protoreflect.ValueOfEnum(1))
+		roles := msg.Mutable(fields.ByName("roles")).List()
+		roles.Append(protoreflect.ValueOfString("admin"))
+		roles.Append(protoreflect.ValueOfString("dev"))
+		counters := msg.Mutable(fields.ByName("counters")).Map()
+		counters.Set(protoreflect.ValueOfString("failed").MapKey(), protoreflect.ValueOfUint32(2))
+		setField(msg, "token", []byte{0xca, 0xfe})
+
+		ts := msg.Mutable(fields.ByName("time")).Message()
+		setField(ts, "seconds", int64(1685613600))
+		setField(ts, "nanos", int32(500))
+		elapsed := msg.Mutable(fields.ByName("elapsed")).Message()
+		setField(elapsed, "seconds", int64(2))
+		setField(elapsed, "nanos", int32(5))
+		comment := msg.Mutable(fields.ByName("comment")).Message()
+		setField(comment, "value", "first login")
+		source := msg.Mutable(fields.ByName("source")).Message()
+		setField(source, "ip", "10.0.0.1")
+	})
+
+	t.Run("default options", func(t *testing.T) {
+		m, err := NewDecoder(desc).Decode(data)
+		require.NoError(t, err)
+		assert.Equal(t, mapstr.M{
+			"user_name": "alice",
+			"attempts":  int64(3),
+			"result":    "FAILURE",
+			"roles":     []interface{}{"admin", "dev"},
+			"counters":  mapstr.M{"failed": uint64(2)},
+			"token":     "yv4=",
+			"time":      time.Unix(1685613600, 500).UTC(),
+			"elapsed":   int64(2*time.Second + 5),
+			"comment":   "first login",
+			"source":    mapstr.M{"ip": "10.0.0.1"},
+		}, m)
+	})
+
+	t.Run("options", func(t *testing.T) {
+		dec := NewDecoder(desc)
+		dec.BytesAs(BytesHex)
+		dec.EnumsAsNumbers()
+		dec.UseJSONNames()
+		dec.EmitDefaults()
+
+		m, err := dec.Decode(data)
+		require.NoError(t, err)
+		assert.Equal(t, "alice", m["userName"])
+		assert.Equal(t, int64(1), m["result"])
+		assert.Equal(t, "cafe", m["token"])
+		assert.Equal(t, mapstr.M{"ip": "10.0.0.1", "port": uint64(0)}, m["source"])
+	})
+
+	t.Run("bytes as string", func(t *testing.T) {
+		dec := NewDecoder(desc)
+		dec.BytesAs(BytesString)
+		m, err := dec.Decode(data)
+		require.NoError(t, err)
+		assert.Equal(t, "\xca\xfe", m["token"])
+	})
+
+	t.Run("missing required fields", func(t *testing.T) {
+		data := testMessage(t, desc, func(msg *dynamicpb.Message) {
+			setField(msg, "attempts", int64(1))
+		})
+		m, err := NewDecoder(desc).Decode(data)
+		assert.ErrorIs(t, err, ErrMissingRequired)
+		assert.Equal(t, mapstr.M{"attempts": int64(1)}, m)
+	})
+
+	t.Run("invalid message", func(t *testing.T) {
+		_, err := NewDecoder(desc).Decode([]byte{0xff, 0xff})
+		assert.Error(t, err)
+	})
+}
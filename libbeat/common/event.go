@@ -63,6 +63,23 @@ func (e *GenericEventConverter) Convert(m mapstr.M) mapstr.M {
 	return event
 }
 
+// ConvertValue normalizes the type of a single field value, as Convert does
+// for the values of a map. It returns false if the value must be dropped.
+func (e *GenericEventConverter) ConvertValue(key string, value interface{}) (interface{}, bool) {
+	v, errs := e.normalizeValue(value, key)
+	if len(errs) > 0 {
+		e.log.Warnf("Unsuccessful conversion to generic event: %v errors: %v, "+
+			"key=%v value=%#v", len(errs), errs, key, value)
+	}
+	if !e.keepNull && v == nil {
+		if e.log.IsDebug() {
+			e.log.Debugf("Dropped nil value from event where key=%v", key)
+		}
+		return nil, false
+	}
+	return v, true
+}
+
 // normalizeMap normalizes each element contained in the given map. If an error
 // occurs during normalization, processing of m will continue, and all errors
 // are returned at the end.
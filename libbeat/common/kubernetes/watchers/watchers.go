@@ -0,0 +1,347 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build !aix
+
+// Package watchers provides Kubernetes watchers that are shared by all
+// users in the process, so that processors and autodiscover providers
+// watching the same resources with the same options hold a single informer
+// cache and API watch.
+package watchers
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	k8s "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/elastic/elastic-agent-autodiscover/kubernetes"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+// Key identifies a shared watcher. Watchers are only shared between users
+// that request the same key.
+type Key struct {
+	// Resource is the kind of watched resource, e.g. "pod" or "node".
+	Resource string
+	// Client is the client used by the watcher. Use Registry.Client to
+	// share clients between users.
+	Client k8s.Interface
+	// Node and Namespace restrict the watched resources.
+	Node      string
+	Namespace string
+	// SyncTimeout is the timeout for the initial listing.
+	SyncTimeout time.Duration
+	// HonorReSyncs is whether resync events are delivered.
+	HonorReSyncs bool
+}
+
+// NewKey returns the key of a watcher of resource created with the given
+// client and watch options.
+func NewKey(resource string, client k8s.Interface, opts kubernetes.WatchOptions) Key {
+	return Key{
+		Resource:     resource,
+		Client:       client,
+		Node:         opts.Node,
+		Namespace:    opts.Namespace,
+		SyncTimeout:  opts.SyncTimeout,
+		HonorReSyncs: opts.HonorReSyncs,
+	}
+}
+
+func (k Key) String() string {
+	return fmt.Sprintf("%s[node=%q namespace=%q]", k.Resource, k.Node, k.Namespace)
+}
+
+// Registry holds the shared watchers of the process.
+type Registry struct {
+	mu       sync.Mutex
+	watchers map[Key]*shared
+	clients  map[clientKey]k8s.Interface
+	log      *logp.Logger
+
+	created  *monitoring.Uint
+	acquired *monitoring.Uint
+}
+
+// Default is the process wide registry.
+var Default = NewRegistry(monitoring.Default.NewRegistry("kubernetes.watchers", monitoring.DoNotReport))
+
+// NewRegistry returns a new Registry reporting its metrics to reg. If reg
+// is nil, no metrics are reported.
+func NewRegistry(reg *monitoring.Registry) *Registry {
+	if reg == nil {
+		reg = monitoring.NewRegistry()
+	}
+	r := &Registry{
+		watchers: make(map[Key]*shared),
+		clients:  make(map[clientKey]k8s.Interface),
+		log:      logp.NewLogger("kubernetes.watchers"),
+		created:  monitoring.NewUint(reg, "created_total"),
+		acquired: monitoring.NewUint(reg, "acquired_total"),
+	}
+	monitoring.NewFunc(reg, "active", r.report, monitoring.Report)
+	return r
+}
+
+type clientKey struct {
+	kubeConfig string
+	options    kubernetes.KubeClientOptions
+}
+
+// Client returns a Kubernetes client for the given configuration. Clients
+// are created once per configuration, so that the watchers of users with the
+// same configuration can be shared.
+func (r *Registry) Client(kubeConfig string, opts kubernetes.KubeClientOptions) (k8s.Interface, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := clientKey{kubeConfig: kubeConfig, options: opts}
+	if c, ok := r.clients[key]; ok {
+		return c, nil
+	}
+	c, err := kubernetes.GetKubernetesClient(kubeConfig, opts)
+	if err != nil {
+		return nil, err
+	}
+	r.clients[key] = c
+	return c, nil
+}
+
+// Acquire returns a watcher for key. If no watcher exists for key, one is
+// created with create. The returned watcher must be stopped when it is no
+// longer needed; the underlying watcher is stopped when all its users have
+// stopped.
+func (r *Registry) Acquire(key Key, create func() (kubernetes.Watcher, error)) (kubernetes.Watcher, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.watchers[key]
+	if !ok {
+		w, err := create()
+		if err != nil {
+			return nil, err
+		}
+		s = &shared{
+			key:      key,
+			registry: r,
+			watcher:  w,
+			handlers: make(map[*Watcher]kubernetes.ResourceEventHandler),
+		}
+		w.AddEventHandler(s)
+		r.watchers[key] = s
+		r.created.Inc()
+		r.log.Debugw("Created shared watcher.", "watcher", key.String())
+	} else {
+		r.log.Debugw("Reusing shared watcher.", "watcher", key.String())
+	}
+	r.acquired.Inc()
+	s.refs++
+	return &Watcher{shared: s, handler: kubernetes.NoOpEventHandlerFuncs{}}, nil
+}
+
+// release drops a reference to s, stopping it when it has no users.
+func (r *Registry) release(s *shared) {
+	r.mu.Lock()
+	s.refs--
+	stop := s.refs == 0
+	if stop {
+		delete(r.watchers, s.key)
+	}
+	r.mu.Unlock()
+
+	if stop {
+		r.log.Debugw("Stopping shared watcher.", "watcher", s.key.String())
+		s.watcher.Stop()
+	}
+}
+
+// report writes the active watchers to the monitoring visitor.
+func (r *Registry) report(_ monitoring.Mode, v monitoring.Visitor) {
+	r.mu.Lock()
+	watchers := make([]*shared, 0, len(r.watchers))
+	for _, s := range r.watchers {
+		watchers = append(watchers, s)
+	}
+	r.mu.Unlock()
+	sort.Slice(watchers, func(i, j int) bool {
+		return watchers[i].key.String() < watchers[j].key.String()
+	})
+
+	v.OnRegistryStart()
+	defer v.OnRegistryFinished()
+	v.OnKey("count")
+	v.OnInt(int64(len(watchers)))
+	for _, s := range watchers {
+		monitoring.ReportNamespace(v, s.key.String(), func() {
+			monitoring.ReportString(v, "resource", s.key.Resource)
+			monitoring.ReportInt(v, "users", int64(s.users()))
+			monitoring.ReportInt(v, "objects", int64(len(s.watcher.Store().ListKeys())))
+		})
+	}
+}
+
+// shared is a watcher shared by several users. It is the event handler of
+// the underlying watcher and dispatches its events to the handlers of all
+// users.
+type shared struct {
+	key      Key
+	registry *Registry
+	watcher  kubernetes.Watcher
+	refs     int // Protected by registry.mu.
+
+	startMu  sync.Mutex
+	started  bool
+	startErr error
+
+	mu       sync.RWMutex
+	handlers map[*Watcher]kubernetes.ResourceEventHandler
+}
+
+func (s *shared) start() error {
+	s.startMu.Lock()
+	defer s.startMu.Unlock()
+	if s.started {
+		return nil
+	}
+	s.startErr = s.watcher.Start()
+	s.started = s.startErr == nil
+	return s.startErr
+}
+
+func (s *shared) isStarted() bool {
+	s.startMu.Lock()
+	defer s.startMu.Unlock()
+	return s.started
+}
+
+func (s *shared) users() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.handlers)
+}
+
+// subscribe registers h for w's events. If the underlying watcher is already
+// running, the objects in its store are replayed to h as additions, so that
+// users joining late see the same state as the first user.
+func (s *shared) subscribe(w *Watcher, h kubernetes.ResourceEventHandler) {
+	s.mu.Lock()
+	s.handlers[w] = h
+	var objs []interface{}
+	if s.isStarted() {
+		objs = s.watcher.Store().List()
+	}
+	s.mu.Unlock()
+
+	// Handlers are called without holding the lock, so they can stop their
+	// watcher or subscribe other users.
+	for _, obj := range objs {
+		h.OnAdd(obj)
+	}
+}
+
+func (s *shared) unsubscribe(w *Watcher) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.handlers, w)
+}
+
+// currentHandlers returns a copy of the handlers of the users, to call them
+// without holding the lock.
+func (s *shared) currentHandlers() []kubernetes.ResourceEventHandler {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	handlers := make([]kubernetes.ResourceEventHandler, 0, len(s.handlers))
+	for _, h := range s.handlers {
+		handlers = append(handlers, h)
+	}
+	return handlers
+}
+
+func (s *shared) OnAdd(obj interface{}) {
+	for _, h := range s.currentHandlers() {
+		h.OnAdd(obj)
+	}
+}
+
+func (s *shared) OnUpdate(obj interface{}) {
+	for _, h := range s.currentHandlers() {
+		h.OnUpdate(obj)
+	}
+}
+
+func (s *shared) OnDelete(obj interface{}) {
+	for _, h := range s.currentHandlers() {
+		h.OnDelete(obj)
+	}
+}
+
+// Watcher is a user's handle on a shared watcher. It implements
+// kubernetes.Watcher.
+type Watcher struct {
+	shared   *shared
+	handler  kubernetes.ResourceEventHandler
+	stopOnce sync.Once
+}
+
+var _ kubernetes.Watcher = (*Watcher)(nil)
+
+// Start starts the underlying watcher if it is not already running.
+func (w *Watcher) Start() error {
+	return w.shared.start()
+}
+
+// Stop stops delivering events to this user. The underlying watcher is
+// stopped when all its users have stopped.
+func (w *Watcher) Stop() {
+	w.stopOnce.Do(func() {
+		w.shared.unsubscribe(w)
+		w.shared.registry.release(w.shared)
+	})
+}
+
+// AddEventHandler sets the event handler of this user.
+func (w *Watcher) AddEventHandler(h kubernetes.ResourceEventHandler) {
+	w.handler = h
+	w.shared.subscribe(w, h)
+}
+
+// GetEventHandler returns the event handler of this user.
+func (w *Watcher) GetEventHandler() kubernetes.ResourceEventHandler {
+	return w.handler
+}
+
+// Store returns the store of the underlying watcher.
+func (w *Watcher) Store() cache.Store {
+	return w.shared.watcher.Store()
+}
+
+// Client returns the client of the underlying watcher.
+func (w *Watcher) Client() k8s.Interface {
+	return w.shared.watcher.Client()
+}
+
+// CachedObject returns the object before the change of the update event
+// being handled.
+func (w *Watcher) CachedObject() runtime.Object {
+	return w.shared.watcher.CachedObject()
+}
@@ -0,0 +1,198 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build !aix
+
+package watchers
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8s "k8s.io/client-go/kubernetes"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/elastic/elastic-agent-autodiscover/kubernetes"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+// fakeWatcher is a kubernetes.Watcher that records its lifecycle.
+type fakeWatcher struct {
+	mu      sync.Mutex
+	handler kubernetes.ResourceEventHandler
+	store   cache.Store
+	starts  int
+	stops   int
+}
+
+func newFakeWatcher() *fakeWatcher {
+	return &fakeWatcher{
+		handler: kubernetes.NoOpEventHandlerFuncs{},
+		store:   cache.NewStore(cache.MetaNamespaceKeyFunc),
+	}
+}
+
+func (w *fakeWatcher) Start() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.starts++
+	return nil
+}
+
+func (w *fakeWatcher) Stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stops++
+}
+
+func (w *fakeWatcher) AddEventHandler(h kubernetes.ResourceEventHandler) { w.handler = h }
+func (w *fakeWatcher) GetEventHandler() kubernetes.ResourceEventHandler  { return w.handler }
+func (w *fakeWatcher) Store() cache.Store                                { return w.store }
+func (w *fakeWatcher) Client() k8s.Interface                             { return nil }
+func (w *fakeWatcher) CachedObject() runtime.Object                      { return nil }
+
+// add adds obj to the store and notifies the handler, as the informer does.
+func (w *fakeWatcher) add(obj interface{}) {
+	_ = w.store.Add(obj)
+	w.handler.OnAdd(obj)
+}
+
+type recorder struct {
+	mu    sync.Mutex
+	added []string
+}
+
+func (r *recorder) handler() kubernetes.ResourceEventHandler {
+	return kubernetes.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.added = append(r.added, obj.(*kubernetes.Pod).Name)
+		},
+	}
+}
+
+func pod(name string) *kubernetes.Pod {
+	p := &kubernetes.Pod{}
+	p.Name = name
+	p.Namespace = "default"
+	return p
+}
+
+func TestRegistryShare(t *testing.T) {
+	r := NewRegistry(monitoring.NewRegistry())
+	client := k8sfake.NewSimpleClientset()
+	key := NewKey("pod", client, kubernetes.WatchOptions{Node: "node-1"})
+
+	var created int
+	underlying := newFakeWatcher()
+	create := func() (kubernetes.Watcher, error) {
+		created++
+		return underlying, nil
+	}
+
+	first, err := r.Acquire(key, create)
+	require.NoError(t, err)
+	var firstEvents recorder
+	first.AddEventHandler(firstEvents.handler())
+	require.NoError(t, first.Start())
+	underlying.add(pod("a"))
+
+	// A second user with the same key shares the watcher and receives the
+	// existing objects when it subscribes.
+	second, err := r.Acquire(key, create)
+	require.NoError(t, err)
+	var secondEvents recorder
+	second.AddEventHandler(secondEvents.handler())
+	require.NoError(t, second.Start())
+	underlying.add(pod("b"))
+
+	assert.Equal(t, 1, created, "unexpected number of created watchers")
+	assert.Equal(t, 1, underlying.starts, "unexpected number of starts")
+	assert.Equal(t, []string{"a", "b"}, firstEvents.added)
+	assert.Equal(t, []string{"a", "b"}, secondEvents.added)
+
+	// A different key gets its own watcher.
+	other, err := r.Acquire(NewKey("pod", client, kubernetes.WatchOptions{Node: "node-2"}), func() (kubernetes.Watcher, error) {
+		return newFakeWatcher(), nil
+	})
+	require.NoError(t, err)
+	other.Stop()
+
+	// The underlying watcher is stopped with its last user.
+	first.Stop()
+	first.Stop()
+	underlying.add(pod("c"))
+	assert.Equal(t, 0, underlying.stops, "unexpected stop with remaining user")
+	assert.Equal(t, []string{"a", "b"}, firstEvents.added, "unexpected event after stop")
+	assert.Equal(t, []string{"a", "b", "c"}, secondEvents.added)
+
+	second.Stop()
+	assert.Equal(t, 1, underlying.stops, "expected watcher to be stopped")
+
+	// A new user after all users stopped gets a new watcher.
+	_, err = r.Acquire(key, create)
+	require.NoError(t, err)
+	assert.Equal(t, 2, created, "expected new watcher after release")
+}
+
+func TestRegistryCreateError(t *testing.T) {
+	r := NewRegistry(nil)
+	key := NewKey("node", k8sfake.NewSimpleClientset(), kubernetes.WatchOptions{})
+
+	_, err := r.Acquire(key, func() (kubernetes.Watcher, error) {
+		return nil, errors.New("boom")
+	})
+	assert.EqualError(t, err, "boom")
+	assert.Empty(t, r.watchers, "unexpected watcher after failed create")
+}
+
+func TestRegistryStopFromHandler(t *testing.T) {
+	r := NewRegistry(nil)
+	key := NewKey("pod", k8sfake.NewSimpleClientset(), kubernetes.WatchOptions{})
+	underlying := newFakeWatcher()
+	create := func() (kubernetes.Watcher, error) { return underlying, nil }
+
+	other, err := r.Acquire(key, create)
+	require.NoError(t, err)
+	var otherEvents recorder
+	other.AddEventHandler(otherEvents.handler())
+	require.NoError(t, other.Start())
+
+	// Handlers are called without holding locks of the shared watcher, a
+	// user can stop its watcher when handling an event.
+	w, err := r.Acquire(key, create)
+	require.NoError(t, err)
+	var stopped int
+	w.AddEventHandler(kubernetes.ResourceEventHandlerFuncs{
+		AddFunc: func(interface{}) {
+			stopped++
+			w.Stop()
+		},
+	})
+	underlying.add(pod("a"))
+	underlying.add(pod("b"))
+
+	assert.Equal(t, 1, stopped)
+	assert.Equal(t, []string{"a", "b"}, otherEvents.added)
+	assert.Equal(t, 0, underlying.stops, "unexpected stop with remaining user")
+}
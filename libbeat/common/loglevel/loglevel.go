@@ -0,0 +1,191 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package loglevel changes the log level of single logger selectors at
+// runtime, independently of the global level of the logger.
+package loglevel
+
+import (
+	"errors"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+// ErrNotInstalled is returned when selector levels are changed before they
+// were installed in the global logger.
+var ErrNotInstalled = errors.New("selector log levels are not installed")
+
+// Default is the selector levels of the global logger.
+var Default = &Selectors{}
+
+// Selectors holds the log levels that override the global level for some
+// selectors. The level of a selector also applies to the loggers named
+// after it, that is the level of "publisher" applies to
+// "publisher.pipeline" unless it has its own level.
+type Selectors struct {
+	installed atomic.Bool
+
+	mu     sync.RWMutex
+	levels map[string]zapcore.Level
+	// min is the lowest overridden level, it is only used to quickly drop
+	// entries that no selector enables.
+	min atomic.Int32
+}
+
+// Install wraps the core of the global logger so that the levels of the
+// selectors are applied. It must be called right after logging is
+// configured, loggers created earlier keep the previous core.
+func (s *Selectors) Install() error {
+	level := logp.GetLevel()
+	inner := logp.L().Core()
+
+	// The level of the previous logger now only filters through the
+	// wrapping core, which enforces the global level itself.
+	logp.SetLevel(zapcore.DebugLevel)
+
+	// All outputs are disabled, the wrapped core already writes to the
+	// configured ones. Configuring the logger resets the output of the
+	// standard logger, it is restored as configured before.
+	cfg := logp.DefaultConfig(logp.DefaultEnvironment)
+	cfg.Level = logp.Level(level)
+	cfg.ToFiles = false
+	cfg.ToStderr = false
+	stdlog := log.Writer()
+	err := logp.ConfigureWithOutputs(cfg, &core{Core: inner, selectors: s})
+	log.SetOutput(stdlog)
+	if err != nil {
+		logp.SetLevel(level)
+		return err
+	}
+
+	s.mu.Lock()
+	s.updateMin()
+	s.mu.Unlock()
+	s.installed.Store(true)
+	return nil
+}
+
+// Set overrides the level of the given selector.
+func (s *Selectors) Set(selector string, level zapcore.Level) error {
+	if !s.installed.Load() {
+		return ErrNotInstalled
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.levels == nil {
+		s.levels = map[string]zapcore.Level{}
+	}
+	s.levels[selector] = level
+	s.updateMin()
+	return nil
+}
+
+// Reset removes the level of the given selector, it uses the global level
+// again.
+func (s *Selectors) Reset(selector string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.levels, selector)
+	s.updateMin()
+}
+
+// Levels returns the overridden levels by selector.
+func (s *Selectors) Levels() map[string]zapcore.Level {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	levels := make(map[string]zapcore.Level, len(s.levels))
+	for selector, level := range s.levels {
+		levels[selector] = level
+	}
+	return levels
+}
+
+// updateMin must be called with s.mu held.
+func (s *Selectors) updateMin() {
+	lowest := zapcore.InvalidLevel
+	for _, level := range s.levels {
+		if lowest == zapcore.InvalidLevel || level < lowest {
+			lowest = level
+		}
+	}
+	s.min.Store(int32(lowest))
+}
+
+// enabled returns true if an entry of the given level is enabled by any
+// selector or by the global level.
+func (s *Selectors) enabled(level zapcore.Level) bool {
+	return level >= logp.GetLevel() || level >= zapcore.Level(s.min.Load())
+}
+
+// enabledFor returns true if an entry of the given level and logger name
+// is enabled.
+func (s *Selectors) enabledFor(name string, level zapcore.Level) bool {
+	if zapcore.Level(s.min.Load()) == zapcore.InvalidLevel {
+		return level >= logp.GetLevel()
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for name != "" {
+		if selectorLevel, ok := s.levels[name]; ok {
+			return level >= selectorLevel
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return level >= logp.GetLevel()
+}
+
+// core filters the entries of the wrapped core by the level of their
+// selector.
+type core struct {
+	zapcore.Core
+	selectors *Selectors
+}
+
+func (c *core) Enabled(level zapcore.Level) bool {
+	return c.selectors.enabled(level) && c.Core.Enabled(level)
+}
+
+func (c *core) With(fields []zapcore.Field) zapcore.Core {
+	return &core{Core: c.Core.With(fields), selectors: c.selectors}
+}
+
+func (c *core) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.selectors.enabledFor(ent.LoggerName, ent.Level) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
+
+func (c *core) Close() error {
+	if closer, ok := c.Core.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
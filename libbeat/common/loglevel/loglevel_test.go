@@ -0,0 +1,100 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package loglevel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+func TestSelectors(t *testing.T) {
+	require.NoError(t, logp.DevelopmentSetup(logp.ToObserverOutput(), logp.WithLevel(logp.InfoLevel)))
+	logs := logp.ObserverLogs()
+
+	s := &Selectors{}
+	assert.ErrorIs(t, s.Set("publisher", zapcore.DebugLevel), ErrNotInstalled)
+	require.NoError(t, s.Install())
+	assert.Equal(t, zapcore.InfoLevel, logp.GetLevel())
+
+	publisher := logp.NewLogger("publisher")
+	pipeline := logp.NewLogger("publisher.pipeline")
+	other := logp.NewLogger("other")
+	log := func() {
+		for _, l := range []*logp.Logger{publisher, pipeline, other} {
+			l.Debug("debug")
+			l.Info("info")
+			l.Warn("warn")
+		}
+	}
+	// messages returns the logged messages by selector and drops them.
+	messages := func() map[string][]string {
+		got := map[string][]string{}
+		for _, entry := range logs.TakeAll() {
+			got[entry.LoggerName] = append(got[entry.LoggerName], entry.Message)
+		}
+		return got
+	}
+
+	log()
+	assert.Equal(t, map[string][]string{
+		"publisher":          {"info", "warn"},
+		"publisher.pipeline": {"info", "warn"},
+		"other":              {"info", "warn"},
+	}, messages())
+
+	// The level of a selector applies to its children.
+	require.NoError(t, s.Set("publisher", zapcore.DebugLevel))
+	require.NoError(t, s.Set("other", zapcore.WarnLevel))
+	log()
+	assert.Equal(t, map[string][]string{
+		"publisher":          {"debug", "info", "warn"},
+		"publisher.pipeline": {"debug", "info", "warn"},
+		"other":              {"warn"},
+	}, messages())
+
+	require.NoError(t, s.Set("publisher.pipeline", zapcore.InfoLevel))
+	log()
+	assert.Equal(t, map[string][]string{
+		"publisher":          {"debug", "info", "warn"},
+		"publisher.pipeline": {"info", "warn"},
+		"other":              {"warn"},
+	}, messages())
+	assert.Equal(t, map[string]zapcore.Level{
+		"publisher":          zapcore.DebugLevel,
+		"publisher.pipeline": zapcore.InfoLevel,
+		"other":              zapcore.WarnLevel,
+	}, s.Levels())
+
+	// The global level still applies to the other selectors.
+	s.Reset("publisher")
+	s.Reset("publisher.pipeline")
+	s.Reset("other")
+	logp.SetLevel(zapcore.DebugLevel)
+	log()
+	assert.Equal(t, map[string][]string{
+		"publisher":          {"debug", "info", "warn"},
+		"publisher.pipeline": {"debug", "info", "warn"},
+		"other":              {"debug", "info", "warn"},
+	}, messages())
+	assert.Empty(t, s.Levels())
+}
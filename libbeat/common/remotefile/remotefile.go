@@ -0,0 +1,108 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package remotefile loads a file from the local file system or from an
+// URL, skipping the reads when the file didn't change.
+package remotefile
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Loader loads a file from a path or an URL. Local files are only read
+// again when their modification time or size change, URLs are requested
+// with the ETag and Last-Modified of the previous response.
+type Loader struct {
+	path    string
+	url     string
+	client  *http.Client
+	maxSize int64
+
+	modTime time.Time
+	size    int64
+	etag    string
+	lastMod string
+}
+
+// New returns a loader of the file at path, or of url if it is set. client
+// is used to request url. Downloads larger than maxSize bytes fail.
+func New(path, url string, client *http.Client, maxSize int64) *Loader {
+	return &Loader{path: path, url: url, client: client, maxSize: maxSize}
+}
+
+// Load returns the content of the file, or nil if it didn't change since the
+// previous call.
+func (l *Loader) Load() ([]byte, error) {
+	if l.url != "" {
+		return l.fetch()
+	}
+
+	info, err := os.Stat(l.path)
+	if err != nil {
+		return nil, err
+	}
+	if info.ModTime().Equal(l.modTime) && info.Size() == l.size {
+		return nil, nil
+	}
+	data, err := os.ReadFile(l.path)
+	if err != nil {
+		return nil, err
+	}
+	l.modTime, l.size = info.ModTime(), info.Size()
+	return data, nil
+}
+
+func (l *Loader) fetch() ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, l.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if l.etag != "" {
+		req.Header.Set("If-None-Match", l.etag)
+	}
+	if l.lastMod != "" {
+		req.Header.Set("If-Modified-Since", l.lastMod)
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, nil
+	case http.StatusOK:
+	default:
+		return nil, fmt.Errorf("unexpected status %s fetching %s", resp.Status, l.url)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, l.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > l.maxSize {
+		return nil, fmt.Errorf("%s exceeds the limit of %d bytes", l.url, l.maxSize)
+	}
+	l.etag, l.lastMod = resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	return data, nil
+}
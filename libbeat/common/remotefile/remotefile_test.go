@@ -0,0 +1,94 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package remotefile
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(path, []byte("a"), 0o600))
+	l := New(path, "", nil, 10)
+
+	data, err := l.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "a", string(data))
+
+	data, err = l.Load()
+	require.NoError(t, err)
+	assert.Nil(t, data, "an unchanged file must not be read again")
+
+	require.NoError(t, os.WriteFile(path, []byte("ab"), 0o600))
+	data, err = l.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "ab", string(data))
+
+	require.NoError(t, os.Remove(path))
+	_, err = l.Load()
+	assert.Error(t, err)
+}
+
+func TestLoadURL(t *testing.T) {
+	body := "a"
+	etag := `"1"`
+	lastMod := time.Now().UTC().Format(http.TimeFormat)
+	served := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("If-None-Match") == etag && r.Header.Get("If-Modified-Since") == lastMod {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		served++
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", lastMod)
+		w.Write([]byte(body)) //nolint:errcheck // It's a test.
+	}))
+	defer srv.Close()
+
+	l := New("", srv.URL, srv.Client(), 10)
+	data, err := l.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "a", string(data))
+
+	data, err = l.Load()
+	require.NoError(t, err)
+	assert.Nil(t, data, "an unmodified file must not be downloaded again")
+	assert.Equal(t, 1, served)
+
+	body, etag = strings.Repeat("a", 11), `"2"`
+	_, err = l.Load()
+	assert.ErrorContains(t, err, "exceeds the limit of 10 bytes")
+
+	l = New("", srv.URL+"/missing", srv.Client(), 10)
+	_, err = l.Load()
+	assert.ErrorContains(t, err, "unexpected status 404")
+}
@@ -0,0 +1,729 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package sqlite reads the tables of SQLite 3 database files without
+// depending on a SQLite driver. It supports scanning the rows of tables,
+// including the committed transactions still in the write-ahead log.
+package sqlite
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"unicode"
+)
+
+const (
+	headerSize    = 100
+	walHeaderSize = 32
+	walFrameSize  = 24
+
+	interiorTablePage = 0x05
+	leafTablePage     = 0x0d
+
+	// maxDepth bounds the depth of the b-trees that are followed so that a
+	// corrupted file cannot make a scan recurse forever.
+	maxDepth = 32
+
+	// maxCopyAttempts bounds the attempts to copy a database that keeps
+	// being written to.
+	maxCopyAttempts = 5
+)
+
+var magic = []byte("SQLite format 3\x00")
+
+// errChanged is returned when a database is written to while it is copied.
+var errChanged = errors.New("database changed while it was copied")
+
+// readFile reads the files of databases, it is replaced in tests.
+var readFile = os.ReadFile
+
+// DB is a read-only copy of a SQLite database file.
+type DB struct {
+	f        io.ReaderAt
+	pageSize int
+	usable   int
+
+	// wal holds the offsets of the last committed version of the pages
+	// that are found in the write-ahead log of the database.
+	wal    io.ReaderAt
+	walPgs map[uint32]int64
+}
+
+// Open copies the SQLite database at path in memory, together with its
+// write-ahead log if there is one, so that it can be read while the
+// database is being written to. The copy is retried if a transaction or a
+// checkpoint modified the files while they were copied.
+func Open(path string) (*DB, error) {
+	var err error
+	for i := 0; i < maxCopyAttempts; i++ {
+		var data, wal []byte
+		data, wal, err = copyFiles(path)
+		if errors.Is(err, errChanged) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		db, err := newDB(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SQLite database %v: %w", path, err)
+		}
+		if wal != nil {
+			if err := db.readWAL(bytes.NewReader(wal)); err != nil {
+				return nil, fmt.Errorf("failed to read write-ahead log of %v: %w", path, err)
+			}
+		}
+		return db, nil
+	}
+	return nil, fmt.Errorf("failed to copy SQLite database %v: %w", path, err)
+}
+
+// copyFiles reads the database at path and its write-ahead log. It returns
+// errChanged if they were modified while they were read: transactions in
+// rollback journal mode update the file change counter of the header, and
+// transactions and checkpoints in WAL mode append frames to the log or
+// restart it with a new header.
+func copyFiles(path string) (data, wal []byte, err error) {
+	before, err := readHeader(path, headerSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	wal, err = readFile(path + "-wal")
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+	data, err = readFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	after, err := readHeader(path, headerSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !bytes.Equal(before, after) || !bytes.HasPrefix(data, before) {
+		return nil, nil, errChanged
+	}
+	walAfter, err := readFile(path + "-wal")
+	switch {
+	case os.IsNotExist(err):
+		if wal != nil {
+			return nil, nil, errChanged
+		}
+	case err != nil:
+		return nil, nil, err
+	case len(walAfter) != len(wal) || !bytes.Equal(walAfter[:min(len(walAfter), walHeaderSize)], wal[:min(len(wal), walHeaderSize)]):
+		return nil, nil, errChanged
+	}
+	return data, wal, nil
+}
+
+// readHeader returns the first n bytes of the file at path, or less if the
+// file is shorter.
+func readHeader(path string, n int) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	buf := make([]byte, n)
+	read, err := io.ReadFull(f, buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return buf[:read], nil
+}
+
+func newDB(f io.ReaderAt) (*DB, error) {
+	var hdr [headerSize]byte
+	_, err := f.ReadAt(hdr[:], 0)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("not a SQLite database")
+		}
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	if !bytes.Equal(hdr[:len(magic)], magic) {
+		return nil, errors.New("not a SQLite database")
+	}
+
+	pageSize := int(binary.BigEndian.Uint16(hdr[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	if pageSize < 512 || pageSize&(pageSize-1) != 0 {
+		return nil, fmt.Errorf("invalid page size %d", pageSize)
+	}
+	if enc := binary.BigEndian.Uint32(hdr[56:60]); enc > 1 {
+		return nil, fmt.Errorf("unsupported text encoding %d", enc)
+	}
+
+	return &DB{
+		f:        f,
+		pageSize: pageSize,
+		usable:   pageSize - int(hdr[20]),
+	}, nil
+}
+
+// Close releases the copy of the database.
+func (db *DB) Close() error {
+	db.f, db.wal, db.walPgs = nil, nil, nil
+	return nil
+}
+
+// readWAL records the pages of the committed transactions found in the
+// write-ahead log, which take precedence over the pages of the database.
+func (db *DB) readWAL(wal io.ReaderAt) error {
+	var hdr [walHeaderSize]byte
+	_, err := wal.ReadAt(hdr[:], 0)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			// Empty log.
+			return nil
+		}
+		return err
+	}
+	if magic := binary.BigEndian.Uint32(hdr[0:4]); magic&^1 != 0x377f0682 {
+		return errors.New("invalid write-ahead log header")
+	}
+	if int(binary.BigEndian.Uint32(hdr[8:12])) != db.pageSize {
+		// The log belongs to a database that has been replaced.
+		return nil
+	}
+	salt := hdr[16:24]
+
+	pages := make(map[uint32]int64)
+	committed := make(map[uint32]int64)
+	frame := make([]byte, walFrameSize)
+	for off := int64(walHeaderSize); ; off += int64(walFrameSize + db.pageSize) {
+		_, err = wal.ReadAt(frame, off)
+		if err != nil {
+			break
+		}
+		// Frames that do not carry the salt of the header are left over
+		// from a previous generation of the log.
+		if !bytes.Equal(frame[8:16], salt) {
+			break
+		}
+		pages[binary.BigEndian.Uint32(frame[0:4])] = off + walFrameSize
+		if binary.BigEndian.Uint32(frame[4:8]) != 0 {
+			// Commit frame.
+			for pgno, pgOff := range pages {
+				committed[pgno] = pgOff
+			}
+		}
+	}
+
+	db.wal = wal
+	db.walPgs = committed
+	return nil
+}
+
+// page returns the contents of the page with the given number.
+func (db *DB) page(pgno uint32) ([]byte, error) {
+	if pgno == 0 {
+		return nil, errors.New("invalid page number 0")
+	}
+	buf := make([]byte, db.pageSize)
+	var err error
+	if off, ok := db.walPgs[pgno]; ok {
+		_, err = db.wal.ReadAt(buf, off)
+	} else {
+		_, err = db.f.ReadAt(buf, int64(pgno-1)*int64(db.pageSize))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read page %d: %w", pgno, err)
+	}
+	return buf, nil
+}
+
+// table returns the root page of the named table and its CREATE TABLE
+// statement.
+func (db *DB) table(name string) (uint32, string, error) {
+	var (
+		root uint32
+		sql  string
+	)
+	err := db.scanTree(1, 0, 0, func(_ int64, values []interface{}) error {
+		if len(values) < 4 {
+			return nil
+		}
+		typ, _ := values[0].(string)
+		tblName, _ := values[1].(string)
+		if typ != "table" || tblName != name {
+			return nil
+		}
+		page, ok := values[3].(int64)
+		if !ok || page <= 0 || page > math.MaxUint32 {
+			return fmt.Errorf("invalid root page for table %v", name)
+		}
+		root = uint32(page)
+		if len(values) > 4 {
+			sql, _ = values[4].(string)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, "", err
+	}
+	if root == 0 {
+		return 0, "", fmt.Errorf("table %v not found", name)
+	}
+	return root, sql, nil
+}
+
+// ScanTable calls fn with the rowid and the values of every row of the
+// named table whose rowid is at least minRowID, in rowid order. Values are
+// nil, int64, float64, string or []byte. The INTEGER PRIMARY KEY column is
+// an alias of the rowid, its value is nil.
+func (db *DB) ScanTable(name string, minRowID int64, fn func(rowid int64, values []interface{}) error) error {
+	root, _, err := db.table(name)
+	if err != nil {
+		return err
+	}
+	return db.scanTree(root, minRowID, 0, fn)
+}
+
+// Rows calls fn with the values of every row of the named table, by column
+// name. A nil first column is set to the rowid, as it is the INTEGER
+// PRIMARY KEY column whose value is not stored in the record.
+func (db *DB) Rows(name string, fn func(row map[string]interface{}) error) error {
+	root, sql, err := db.table(name)
+	if err != nil {
+		return err
+	}
+	columns := parseColumns(sql)
+	return db.scanTree(root, 0, 0, func(rowid int64, values []interface{}) error {
+		row := make(map[string]interface{}, len(columns))
+		for i, c := range columns {
+			if i < len(values) {
+				row[c] = values[i]
+			}
+			if row[c] == nil && i == 0 {
+				row[c] = rowid
+			}
+		}
+		return fn(row)
+	})
+}
+
+// MaxRowID returns the largest rowid of the named table, or 0 if the table
+// is empty.
+func (db *DB) MaxRowID(name string) (int64, error) {
+	pgno, _, err := db.table(name)
+	if err != nil {
+		return 0, err
+	}
+	for depth := 0; depth <= maxDepth; depth++ {
+		page, err := db.page(pgno)
+		if err != nil {
+			return 0, err
+		}
+		cells := int(binary.BigEndian.Uint16(page[3:5]))
+		switch page[0] {
+		case interiorTablePage:
+			pgno = binary.BigEndian.Uint32(page[8:12])
+		case leafTablePage:
+			if cells == 0 {
+				return 0, nil
+			}
+			if 8+2*cells > len(page) {
+				return 0, fmt.Errorf("page %d has too many cells", pgno)
+			}
+			off := int(binary.BigEndian.Uint16(page[8+2*(cells-1):]))
+			rowid, _, err := db.leafCell(page, off)
+			return rowid, err
+		default:
+			return 0, fmt.Errorf("page %d is not a table b-tree page (type %#x)", pgno, page[0])
+		}
+	}
+	return 0, errors.New("b-tree is too deep")
+}
+
+func (db *DB) scanTree(pgno uint32, minRowID int64, depth int, fn func(int64, []interface{}) error) error {
+	if depth > maxDepth {
+		return errors.New("b-tree is too deep")
+	}
+	page, err := db.page(pgno)
+	if err != nil {
+		return err
+	}
+	hdr := page
+	if pgno == 1 {
+		hdr = page[headerSize:]
+	}
+	if len(hdr) < 12 {
+		return fmt.Errorf("page %d is too short", pgno)
+	}
+
+	cells := int(binary.BigEndian.Uint16(hdr[3:5]))
+	switch hdr[0] {
+	case leafTablePage:
+		ptrs := hdr[8:]
+		if len(ptrs) < 2*cells {
+			return fmt.Errorf("page %d has too many cells", pgno)
+		}
+		for i := 0; i < cells; i++ {
+			off := int(binary.BigEndian.Uint16(ptrs[2*i:]))
+			rowid, payload, err := db.leafCell(page, off)
+			if err != nil {
+				return fmt.Errorf("failed to read cell %d of page %d: %w", i, pgno, err)
+			}
+			if rowid < minRowID {
+				continue
+			}
+			values, err := record(payload)
+			if err != nil {
+				return fmt.Errorf("failed to read record %d: %w", rowid, err)
+			}
+			err = fn(rowid, values)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case interiorTablePage:
+		ptrs := hdr[12:]
+		if len(ptrs) < 2*cells {
+			return fmt.Errorf("page %d has too many cells", pgno)
+		}
+		for i := 0; i < cells; i++ {
+			off := int(binary.BigEndian.Uint16(ptrs[2*i:]))
+			if off+4 > len(page) {
+				return fmt.Errorf("invalid cell offset %d in page %d", off, pgno)
+			}
+			child := binary.BigEndian.Uint32(page[off:])
+			key, n := varint(page[off+4:])
+			if n == 0 {
+				return fmt.Errorf("invalid cell %d in page %d", i, pgno)
+			}
+			// The key is the largest rowid of the left child.
+			if key < minRowID {
+				continue
+			}
+			err = db.scanTree(child, minRowID, depth+1, fn)
+			if err != nil {
+				return err
+			}
+		}
+		return db.scanTree(binary.BigEndian.Uint32(hdr[8:12]), minRowID, depth+1, fn)
+
+	default:
+		return fmt.Errorf("page %d is not a table b-tree page (type %#x)", pgno, hdr[0])
+	}
+}
+
+// leafCell returns the rowid and the complete payload of the table leaf
+// cell at offset off of page, following its overflow pages if needed.
+func (db *DB) leafCell(page []byte, off int) (int64, []byte, error) {
+	if off >= len(page) {
+		return 0, nil, errors.New("invalid cell offset")
+	}
+	size, n := varint(page[off:])
+	if n == 0 || size < 0 {
+		return 0, nil, errors.New("invalid payload size")
+	}
+	off += n
+	rowid, n := varint(page[off:])
+	if n == 0 {
+		return 0, nil, errors.New("invalid rowid")
+	}
+	off += n
+
+	local := db.localPayload(int(size))
+	if off+local > len(page) {
+		return 0, nil, errors.New("payload exceeds page")
+	}
+	payload := make([]byte, 0, size)
+	payload = append(payload, page[off:off+local]...)
+	if local == int(size) {
+		return rowid, payload, nil
+	}
+
+	if off+local+4 > len(page) {
+		return 0, nil, errors.New("missing overflow page")
+	}
+	next := binary.BigEndian.Uint32(page[off+local:])
+	for len(payload) < int(size) {
+		if next == 0 {
+			return 0, nil, errors.New("truncated overflow chain")
+		}
+		ovfl, err := db.page(next)
+		if err != nil {
+			return 0, nil, err
+		}
+		next = binary.BigEndian.Uint32(ovfl)
+		chunk := min(int(size)-len(payload), db.usable-4)
+		payload = append(payload, ovfl[4:4+chunk]...)
+	}
+	return rowid, payload, nil
+}
+
+// localPayload returns how many bytes of a table leaf payload of the given
+// size are stored in the b-tree page itself, see
+// https://www.sqlite.org/fileformat.html#b_tree_pages.
+func (db *DB) localPayload(size int) int {
+	x := db.usable - 35
+	if size <= x {
+		return size
+	}
+	m := ((db.usable-12)*32)/255 - 23
+	k := m + (size-m)%(db.usable-4)
+	if k <= x {
+		return k
+	}
+	return m
+}
+
+// record decodes the values of a record.
+func record(payload []byte) ([]interface{}, error) {
+	hdrSize, n := varint(payload)
+	if n == 0 || hdrSize < int64(n) || hdrSize > int64(len(payload)) {
+		return nil, errors.New("invalid record header")
+	}
+	types := payload[n:hdrSize]
+	body := payload[hdrSize:]
+
+	var values []interface{}
+	for len(types) > 0 {
+		typ, n := varint(types)
+		if n == 0 {
+			return nil, errors.New("invalid serial type")
+		}
+		types = types[n:]
+
+		var size int
+		switch {
+		case typ >= 12:
+			size = int((typ - 12) / 2)
+		case typ >= 1 && typ <= 4:
+			size = int(typ)
+		case typ == 5:
+			size = 6
+		case typ == 6 || typ == 7:
+			size = 8
+		}
+		if size > len(body) {
+			return nil, errors.New("record body is too short")
+		}
+		field := body[:size]
+		body = body[size:]
+
+		switch {
+		case typ == 0:
+			values = append(values, nil)
+		case typ >= 1 && typ <= 6:
+			// Big-endian two's complement integer of size bytes.
+			v := int64(int8(field[0]))
+			for _, b := range field[1:] {
+				v = v<<8 | int64(b)
+			}
+			values = append(values, v)
+		case typ == 7:
+			values = append(values, math.Float64frombits(binary.BigEndian.Uint64(field)))
+		case typ == 8:
+			values = append(values, int64(0))
+		case typ == 9:
+			values = append(values, int64(1))
+		case typ >= 12 && typ%2 == 0:
+			values = append(values, append([]byte(nil), field...))
+		case typ >= 13:
+			values = append(values, string(field))
+		default:
+			return nil, fmt.Errorf("unsupported serial type %d", typ)
+		}
+	}
+	return values, nil
+}
+
+// varint decodes a SQLite variable-length integer and returns it with the
+// number of bytes read, or 0 bytes if b is too short.
+func varint(b []byte) (int64, int) {
+	var v uint64
+	for i := 0; i < 9; i++ {
+		if i >= len(b) {
+			return 0, 0
+		}
+		if i == 8 {
+			v = v<<8 | uint64(b[i])
+			return int64(v), 9
+		}
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i]&0x80 == 0 {
+			return int64(v), i + 1
+		}
+	}
+	return 0, 0
+}
+
+// parseColumns returns the names of the columns of a CREATE TABLE statement.
+func parseColumns(sql string) []string {
+	tokens := tokenize(sql)
+	start := 0
+	for start < len(tokens) && !tokens[start].is(tokenPunct, "(") {
+		start++
+	}
+	if start == len(tokens) {
+		return nil
+	}
+
+	var columns []string
+	depth, definition := 0, true
+	for _, t := range tokens[start+1:] {
+		if t.kind == tokenPunct {
+			switch t.text {
+			case "(":
+				depth++
+			case ")":
+				if depth == 0 {
+					return columns
+				}
+				depth--
+			case ",":
+				definition = depth == 0
+			}
+			continue
+		}
+		if !definition || depth > 0 {
+			continue
+		}
+		// The first token of a definition is the name of the column,
+		// unless the definition is a table constraint.
+		definition = false
+		if t.kind == tokenWord {
+			switch strings.ToUpper(t.text) {
+			case "PRIMARY", "UNIQUE", "CHECK", "FOREIGN", "CONSTRAINT":
+				continue
+			}
+		}
+		columns = append(columns, t.text)
+	}
+	// The statement is not terminated.
+	return nil
+}
+
+type tokenKind int
+
+const (
+	tokenWord   tokenKind = iota // keywords, bare identifiers and numbers
+	tokenQuoted                  // identifiers quoted with "", `` or []
+	tokenString                  // string literals quoted with ''
+	tokenPunct                   // parentheses, commas and operators
+)
+
+// token is a token of a SQL statement. The text of quoted tokens is
+// unquoted.
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func (t token) is(kind tokenKind, text string) bool {
+	return t.kind == kind && t.text == text
+}
+
+// tokenize splits a SQL statement into tokens, following the rules of
+// https://www.sqlite.org/lang_keywords.html for quoting. Comments are
+// skipped.
+func tokenize(sql string) []token {
+	var tokens []token
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case isSpace(c):
+			i++
+		case strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				return tokens
+			}
+			i += end + 1
+		case strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				return tokens
+			}
+			i += end + 4
+		case c == '"' || c == '`' || c == '\'':
+			text, n := unquote(sql[i:], c)
+			kind := tokenQuoted
+			if c == '\'' {
+				kind = tokenString
+			}
+			tokens = append(tokens, token{kind: kind, text: text})
+			i += n
+		case c == '[':
+			end := strings.IndexByte(sql[i:], ']')
+			if end < 0 {
+				end = len(sql) - i
+				tokens = append(tokens, token{kind: tokenQuoted, text: sql[i+1:]})
+			} else {
+				tokens = append(tokens, token{kind: tokenQuoted, text: sql[i+1 : i+end]})
+			}
+			i += end + 1
+		case isWordChar(c):
+			j := i + 1
+			for j < len(sql) && isWordChar(sql[j]) {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: sql[i:j]})
+			i = j
+		default:
+			tokens = append(tokens, token{kind: tokenPunct, text: sql[i : i+1]})
+			i++
+		}
+	}
+	return tokens
+}
+
+// unquote returns the text quoted with q at the start of s, where q is
+// escaped by doubling it, and the length of the quoted text in s.
+func unquote(s string, q byte) (string, int) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		if s[i] != q {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == q {
+			b.WriteByte(q)
+			i++
+			continue
+		}
+		return b.String(), i + 1
+	}
+	// Unterminated quote.
+	return b.String(), len(s)
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// isWordChar reports whether c can be part of a keyword, a bare identifier
+// or a number. Non-ASCII bytes are accepted as part of identifiers.
+func isWordChar(c byte) bool {
+	return c == '_' || c == '$' || c == '.' || c >= 0x80 || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c))
+}
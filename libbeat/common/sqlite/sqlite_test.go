@@ -0,0 +1,218 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sqlite
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanTable(t *testing.T) {
+	db, err := Open("./testdata/logins.sqlite")
+	require.NoError(t, err)
+	defer db.Close()
+
+	var ids []int64
+	err = db.ScanTable("wtmp", 0, func(rowid int64, values []interface{}) error {
+		ids = append(ids, rowid)
+		require.Len(t, values, 8)
+		// ID is an alias of the rowid and stored as NULL.
+		assert.Nil(t, values[0])
+		switch rowid {
+		case 2:
+			assert.Equal(t, []interface{}{nil, int64(3), "vagrant", int64(1714557900123456), int64(1714558800000000), "pts/0", "10.0.2.2", "sshd"},
+				values)
+		case 20:
+			// Payload spilled to overflow pages.
+			assert.Equal(t, strings.Repeat("x", 600), values[7])
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, ids, 40)
+	for i, id := range ids {
+		assert.Equal(t, int64(i+1), id)
+	}
+
+	ids = nil
+	err = db.ScanTable("wtmp", 35, func(rowid int64, _ []interface{}) error {
+		ids = append(ids, rowid)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{35, 36, 37, 38, 39, 40}, ids)
+
+	maxID, err := db.MaxRowID("wtmp")
+	require.NoError(t, err)
+	assert.Equal(t, int64(40), maxID)
+
+	err = db.ScanTable("missing", 0, func(int64, []interface{}) error { return nil })
+	assert.ErrorContains(t, err, "table missing not found")
+}
+
+func TestRows(t *testing.T) {
+	db, err := Open("./testdata/logins.sqlite")
+	require.NoError(t, err)
+	defer db.Close()
+
+	var rows []map[string]interface{}
+	err = db.Rows("wtmp", func(row map[string]interface{}) error {
+		rows = append(rows, row)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 40)
+	// The ID column is set to the rowid.
+	assert.Equal(t, map[string]interface{}{
+		"ID":         int64(2),
+		"Type":       int64(3),
+		"User":       "vagrant",
+		"Login":      int64(1714557900123456),
+		"Logout":     int64(1714558800000000),
+		"TTY":        "pts/0",
+		"RemoteHost": "10.0.2.2",
+		"Service":    "sshd",
+	}, rows[1])
+}
+
+func TestOpenInvalid(t *testing.T) {
+	_, err := Open("./sqlite.go")
+	assert.ErrorContains(t, err, "not a SQLite database")
+
+	_, err = Open("./testdata/missing.sqlite")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestParseColumns(t *testing.T) {
+	assert.Equal(t,
+		[]string{"id", "name", "offset"},
+		parseColumns("CREATE TABLE in_tail_files (id INTEGER PRIMARY KEY, `name` TEXT NOT NULL, \"offset\" INTEGER, UNIQUE (name))"))
+	assert.Equal(t,
+		[]string{"a", "b, c", "primary", "d(e", "f", "g"},
+		parseColumns(`CREATE TABLE "t(" ( -- the first column
+			a DECIMAL(10, 2) DEFAULT (1 + 2),
+			"b, c" TEXT DEFAULT 'x, ''y'')' CHECK (length("b, c") IN (1, 2)),
+			"primary" INTEGER, /* quoted keyword, ( */
+			[d(e] BLOB,
+			f, g INT,
+			CONSTRAINT pk PRIMARY KEY (a, f),
+			FOREIGN KEY (g) REFERENCES other (id)
+		) WITHOUT ROWID`))
+	assert.Empty(t, parseColumns("CREATE TABLE broken"))
+	assert.Empty(t, parseColumns("CREATE TABLE unterminated (a, b"))
+}
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []token{
+		{tokenWord, "CREATE"},
+		{tokenQuoted, `a"b`},
+		{tokenPunct, "("},
+		{tokenString, "it's"},
+		{tokenPunct, ","},
+		{tokenQuoted, "c d"},
+		{tokenWord, "1.5"},
+		{tokenPunct, ")"},
+	}, tokenize(`CREATE "a""b" ('it''s', [c d] 1.5) -- end`))
+}
+
+func TestOpenCopiesDatabase(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"wal.sqlite", "wal.sqlite-wal"} {
+		data, err := os.ReadFile("./testdata/" + name)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), data, 0o600))
+	}
+
+	db, err := Open(filepath.Join(dir, "wal.sqlite"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	// A checkpoint followed by a restart of the write-ahead log does not
+	// affect the copy.
+	require.NoError(t, os.Truncate(filepath.Join(dir, "wal.sqlite-wal"), 0))
+	maxID, err := db.MaxRowID("wtmp")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), maxID)
+}
+
+func TestOpenRetriesChangedDatabase(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "wal.sqlite")
+	for _, name := range []string{"wal.sqlite", "wal.sqlite-wal"} {
+		data, err := os.ReadFile("./testdata/" + name)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), data, 0o600))
+	}
+	wal, err := os.ReadFile(path + "-wal")
+	require.NoError(t, err)
+
+	// Frames are appended to the write-ahead log while the database is
+	// read the first time.
+	var reads int
+	readFile = func(name string) ([]byte, error) {
+		if name == path {
+			reads++
+			if reads == 1 {
+				require.NoError(t, os.WriteFile(path+"-wal", append(wal, make([]byte, 100)...), 0o600))
+			}
+		}
+		return os.ReadFile(name)
+	}
+	defer func() { readFile = os.ReadFile }()
+
+	db, err := Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+	assert.Equal(t, 2, reads, "the copy must be retried")
+
+	// The database keeps changing.
+	reads = 0
+	readFile = func(name string) ([]byte, error) {
+		if name == path {
+			require.NoError(t, os.WriteFile(path+"-wal", append(wal, make([]byte, reads+1)...), 0o600))
+			reads++
+		}
+		return os.ReadFile(name)
+	}
+	_, err = Open(path)
+	assert.ErrorIs(t, err, errChanged)
+	assert.Equal(t, maxCopyAttempts, reads)
+}
+
+func TestVarint(t *testing.T) {
+	for _, test := range []struct {
+		in   []byte
+		want int64
+		n    int
+	}{
+		{[]byte{0x00}, 0, 1},
+		{[]byte{0x7f}, 127, 1},
+		{[]byte{0x81, 0x00}, 128, 2},
+		{[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, -1, 9},
+		{[]byte{0x81}, 0, 0},
+	} {
+		got, n := varint(test.in)
+		assert.Equal(t, test.want, got, "%x", test.in)
+		assert.Equal(t, test.n, n, "%x", test.in)
+	}
+}
@@ -89,7 +89,14 @@ func (conn *Connection) Bulk(
 	}
 	requ.requ = apmHttpV2.RequestWithContext(ctx, requ.requ)
 
-	return conn.sendBulkRequest(requ)
+	status, resp, err := conn.sendBulkRequest(requ)
+	if !conn.unsupportedEncoding(status, resp) {
+		return status, resp, err
+	}
+	if err := conn.fallbackToGzip(); err != nil {
+		return status, resp, err
+	}
+	return conn.Bulk(ctx, index, docType, params, body)
 }
 
 func newBulkRequest(
@@ -37,8 +37,10 @@ type config struct {
 	Password string `config:"password"`
 	APIKey   string `config:"api_key"`
 
-	CompressionLevel int  `config:"compression_level" validate:"min=0, max=9"`
-	EscapeHTML       bool `config:"escape_html"`
+	CompressionLevel int    `config:"compression_level" validate:"min=0, max=9"`
+	Compression      string `config:"compression"`
+	EscapeHTML       bool   `config:"escape_html"`
+	HTTP2            bool   `config:"http2"`
 
 	Transport httpcommon.HTTPTransportSettings `config:",inline"`
 }
@@ -61,6 +63,9 @@ func (c *config) Validate() error {
 	if c.APIKey != "" && (c.Username != "" || c.Password != "") {
 		return fmt.Errorf("cannot set both api_key and username/password")
 	}
+	if c.Compression != "" && c.Compression != "gzip" && c.Compression != "zstd" {
+		return fmt.Errorf("compression must be gzip or zstd, got %q", c.Compression)
+	}
 
 	return nil
 }
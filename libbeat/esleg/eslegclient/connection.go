@@ -86,7 +86,15 @@ type ConnectionSettings struct {
 
 	Parameters       map[string]string
 	CompressionLevel int
-	EscapeHTML       bool
+	// Compression is the Content-Encoding of request bodies if
+	// CompressionLevel is set: gzip (default) or zstd.
+	Compression string
+	EscapeHTML  bool
+
+	// HTTP2 enables HTTP/2 for HTTPS connections. If ClientPool is set,
+	// connections to the same URL share an HTTP client.
+	HTTP2      bool
+	ClientPool *HTTPClientPool
 
 	IdleConnTimeout time.Duration
 
@@ -131,15 +139,9 @@ func NewConnection(s ConnectionSettings) (*Connection, error) {
 	}
 	logger.Infof("elasticsearch url: %s", s.URL)
 
-	var encoder BodyEncoder
-	compression := s.CompressionLevel
-	if compression == 0 {
-		encoder = NewJSONEncoder(nil, s.EscapeHTML)
-	} else {
-		encoder, err = NewGzipEncoder(compression, nil, s.EscapeHTML)
-		if err != nil {
-			return nil, err
-		}
+	encoder, err := newBodyEncoder(s.Compression, s.CompressionLevel, s.EscapeHTML)
+	if err != nil {
+		return nil, err
 	}
 
 	// fall back to a default if nothing has configured the user-agent field
@@ -159,17 +161,36 @@ func NewConnection(s ConnectionSettings) (*Connection, error) {
 		s.Headers[productorigin.Header] = productorigin.Beats
 	}
 
-	httpClient, err := s.Transport.Client(
-		httpcommon.WithLogger(logger),
-		httpcommon.WithIOStats(s.Observer),
-		httpcommon.WithKeepaliveSettings{IdleConnTimeout: s.IdleConnTimeout},
-		httpcommon.WithModRoundtripper(func(rt http.RoundTripper) http.RoundTripper {
-			// when dropping the legacy client in favour of the official Go client, it should be instrumented
-			// eg, like in https://github.com/elastic/apm-server/blob/7.7/elasticsearch/client.go
-			return apmelasticsearch.WrapRoundTripper(rt)
-		}),
-		httpcommon.WithHeaderRoundTripper(map[string]string{"User-Agent": s.UserAgent}),
-	)
+	newHTTPClient := func() (*http.Client, error) {
+		opts := []httpcommon.TransportOption{
+			httpcommon.WithLogger(logger),
+			httpcommon.WithIOStats(s.Observer),
+			httpcommon.WithKeepaliveSettings{IdleConnTimeout: s.IdleConnTimeout},
+			httpcommon.WithModRoundtripper(func(rt http.RoundTripper) http.RoundTripper {
+				// when dropping the legacy client in favour of the official Go client, it should be instrumented
+				// eg, like in https://github.com/elastic/apm-server/blob/7.7/elasticsearch/client.go
+				return apmelasticsearch.WrapRoundTripper(rt)
+			}),
+			httpcommon.WithHeaderRoundTripper(map[string]string{"User-Agent": s.UserAgent}),
+		}
+		if s.HTTP2 {
+			tlsConfig, err := tlscommon.LoadTLSConfig(s.Transport.TLS)
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, httpcommon.WithTransportFunc(func(t *http.Transport) {
+				enableHTTP2(t, tlsConfig, s.Transport.Timeout, logger)
+			}))
+		}
+		return s.Transport.Client(opts...)
+	}
+
+	var httpClient *http.Client
+	if s.HTTP2 && s.ClientPool != nil {
+		httpClient, err = s.ClientPool.get(s.URL, newHTTPClient)
+	} else {
+		httpClient, err = newHTTPClient()
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -198,6 +219,53 @@ func NewConnection(s ConnectionSettings) (*Connection, error) {
 	return &conn, nil
 }
 
+func newBodyEncoder(compression string, level int, escapeHTML bool) (BodyEncoder, error) {
+	if level == 0 {
+		return NewJSONEncoder(nil, escapeHTML), nil
+	}
+	switch compression {
+	case "", "gzip":
+		return NewGzipEncoder(level, nil, escapeHTML)
+	case "zstd":
+		return NewZstdEncoder(level, nil, escapeHTML)
+	default:
+		return nil, fmt.Errorf("unsupported compression %q", compression)
+	}
+}
+
+// unsupportedEncoding returns true if Elasticsearch rejected a request
+// because it doesn't support the zstd Content-Encoding. Request bodies are
+// always valid JSON, so a parse error means the body was not decompressed.
+func (conn *Connection) unsupportedEncoding(status int, body []byte) bool {
+	if _, ok := conn.Encoder.(*zstdEncoder); !ok {
+		return false
+	}
+	switch status {
+	case http.StatusUnsupportedMediaType:
+		return true
+	case http.StatusBadRequest:
+		for _, marker := range []string{"Content-Encoding", "not_x_content_exception", "x_content_parse_exception", "json_parse_exception"} {
+			if bytes.Contains(body, []byte(marker)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fallbackToGzip replaces the zstd encoder with a gzip encoder of the same
+// level for all further requests.
+func (conn *Connection) fallbackToGzip() error {
+	encoder, err := NewGzipEncoder(conn.CompressionLevel, nil, conn.EscapeHTML)
+	if err != nil {
+		return err
+	}
+	conn.log.Warnf("Elasticsearch at %s doesn't support zstd compressed requests, falling back to gzip", conn.URL)
+	conn.Encoder = encoder
+	conn.Compression = "gzip"
+	return nil
+}
+
 // NewClients returns a list of Elasticsearch clients based on the given
 // configuration. It accepts the same configuration parameters as the Elasticsearch
 // output, except for the output specific configuration options.  If multiple hosts
@@ -237,6 +305,8 @@ func NewClients(cfg *cfg.C, beatname string) ([]Connection, error) {
 			Parameters:       params,
 			Headers:          config.Headers,
 			CompressionLevel: config.CompressionLevel,
+			Compression:      config.Compression,
+			HTTP2:            config.HTTP2,
 			Transport:        config.Transport,
 		})
 		if err != nil {
@@ -396,7 +466,14 @@ func (conn *Connection) RequestURL(
 		conn.log.Warnf("Failed to json encode body (%v): %#v", err, body)
 		return 0, nil, ErrJSONEncodeFailed
 	}
-	return conn.execRequest(method, url, conn.Encoder.Reader())
+	status, resp, err := conn.execRequest(method, url, conn.Encoder.Reader())
+	if !conn.unsupportedEncoding(status, resp) {
+		return status, resp, err
+	}
+	if err := conn.fallbackToGzip(); err != nil {
+		return status, resp, err
+	}
+	return conn.RequestURL(method, url, body)
 }
 
 func (conn *Connection) execRequest(
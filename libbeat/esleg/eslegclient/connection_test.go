@@ -28,11 +28,14 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/elastic/beats/v7/libbeat/common/productorigin"
 	"github.com/elastic/beats/v7/libbeat/version"
+	"github.com/elastic/elastic-agent-libs/transport/httpcommon"
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
 )
 
 func TestAPIKeyEncoding(t *testing.T) {
@@ -232,3 +235,67 @@ func BenchmarkExecHTTPRequest(b *testing.B) {
 		})
 	}
 }
+
+func TestHTTP2(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Proto", r.Proto)
+		_, _ = w.Write([]byte("{}"))
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	pool := NewHTTPClientPool()
+	settings := ConnectionSettings{
+		URL:        server.URL,
+		HTTP2:      true,
+		ClientPool: pool,
+		Transport: httpcommon.HTTPTransportSettings{
+			TLS:     &tlscommon.Config{VerificationMode: tlscommon.VerifyNone},
+			Timeout: 10 * time.Second,
+		},
+	}
+	conn, err := NewConnection(settings)
+	require.NoError(t, err)
+	other, err := NewConnection(settings)
+	require.NoError(t, err)
+	require.Same(t, conn.HTTP, other.HTTP, "connections to the same URL must share the client")
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	resp, err := conn.HTTP.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, 2, resp.ProtoMajor)
+	require.Equal(t, "HTTP/2.0", resp.Header.Get("X-Proto"))
+}
+
+func TestZstdFallback(t *testing.T) {
+	var encodings []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodings = append(encodings, r.Header.Get("Content-Encoding"))
+		if r.Header.Get("Content-Encoding") == "zstd" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			_, _ = w.Write([]byte(`{"error":"unsupported Content-Encoding"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"items":[]}`))
+	}))
+	defer server.Close()
+
+	conn, err := NewConnection(ConnectionSettings{
+		URL:              server.URL,
+		CompressionLevel: 1,
+		Compression:      "zstd",
+	})
+	require.NoError(t, err)
+
+	status, _, err := conn.Bulk(context.Background(), "index", "", nil, []interface{}{
+		map[string]interface{}{"index": map[string]interface{}{}},
+		map[string]interface{}{"field": "value"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, []string{"zstd", "gzip"}, encodings)
+	require.Equal(t, "gzip", conn.Compression)
+}
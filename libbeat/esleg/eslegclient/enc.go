@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
 
 	"github.com/elastic/beats/v7/libbeat/beat"
 	"github.com/elastic/beats/v7/libbeat/outputs/codec"
@@ -66,6 +67,14 @@ type gzipEncoder struct {
 	escapeHTML bool
 }
 
+type zstdEncoder struct {
+	buf    *bytes.Buffer
+	zstd   *zstd.Encoder
+	folder *gotype.Iterator
+
+	escapeHTML bool
+}
+
 type event struct {
 	Timestamp time.Time `struct:"@timestamp"`
 	Fields    mapstr.M  `struct:",inline"`
@@ -242,3 +251,90 @@ func (g *gzipEncoder) Add(meta, obj interface{}) error {
 	g.gzip.Flush()
 	return nil
 }
+
+// NewZstdEncoder returns an encoder that compresses the body with zstd. The
+// level is mapped to the closest zstd encoder level.
+func NewZstdEncoder(level int, buf *bytes.Buffer, escapeHTML bool) (*zstdEncoder, error) {
+	if buf == nil {
+		buf = bytes.NewBuffer(nil)
+	}
+	w, err := zstd.NewWriter(buf,
+		zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)),
+		zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+
+	z := &zstdEncoder{buf: buf, zstd: w, escapeHTML: escapeHTML}
+	z.resetState()
+	return z, nil
+}
+
+func (z *zstdEncoder) resetState() {
+	var err error
+	visitor := json.NewVisitor(z.zstd)
+	visitor.SetEscapeHTML(z.escapeHTML)
+
+	z.folder, err = gotype.NewIterator(visitor,
+		gotype.Folders(
+			codec.MakeTimestampEncoder(),
+			codec.MakeBCTimestampEncoder()))
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (z *zstdEncoder) Reset() {
+	z.buf.Reset()
+	z.zstd.Reset(z.buf)
+}
+
+func (z *zstdEncoder) Reader() io.Reader {
+	z.zstd.Close()
+	return z.buf
+}
+
+func (z *zstdEncoder) AddHeader(header *http.Header) {
+	header.Add("Content-Type", "application/json; charset=UTF-8")
+	header.Add("Content-Encoding", "zstd")
+}
+
+func (z *zstdEncoder) Marshal(obj interface{}) error {
+	z.Reset()
+	return z.AddRaw(obj)
+}
+
+func (z *zstdEncoder) AddRaw(obj interface{}) error {
+	var err error
+	switch v := obj.(type) {
+	case beat.Event:
+		err = z.folder.Fold(event{Timestamp: v.Timestamp, Fields: v.Fields})
+	case *beat.Event:
+		err = z.folder.Fold(event{Timestamp: v.Timestamp, Fields: v.Fields})
+	case RawEncoding:
+		_, err = z.zstd.Write(v.Encoding)
+	default:
+		err = z.folder.Fold(obj)
+	}
+
+	if err != nil {
+		z.resetState()
+	}
+
+	_, err = z.zstd.Write(nl)
+	if err != nil {
+		z.resetState()
+	}
+
+	return nil
+}
+
+// Add adds the document and its metadata. Unlike the gzip encoder the
+// stream is not flushed after every document, as every flush ends a zstd
+// block and noticeably reduces the compression ratio.
+func (z *zstdEncoder) Add(meta, obj interface{}) error {
+	if err := z.AddRaw(meta); err != nil {
+		return err
+	}
+	return z.AddRaw(obj)
+}
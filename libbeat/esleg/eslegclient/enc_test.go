@@ -18,10 +18,15 @@
 package eslegclient
 
 import (
+	"fmt"
+	"io"
+	"net/http"
 	"testing"
 	"time"
 
+	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/elastic/beats/v7/libbeat/beat"
 	"github.com/elastic/beats/v7/libbeat/monitoring/report"
@@ -61,3 +66,27 @@ func TestJSONEncoderMarshalMonitoringEvent(t *testing.T) {
 	assert.Equal(t, encoder.buf.String(), "{\"timestamp\":\"2017-11-07T12:00:00.000Z\",\"field1\":\"value1\"}\n",
 		"Unexpected marshaled format of report.Event")
 }
+
+func TestZstdEncoder(t *testing.T) {
+	encoder, err := NewZstdEncoder(3, nil, false)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		encoder.Reset()
+		require.NoError(t, encoder.AddRaw(mapstr.M{"index": mapstr.M{}}))
+		require.NoError(t, encoder.Add(mapstr.M{"index": mapstr.M{}}, mapstr.M{"field1": i}))
+
+		header := http.Header{}
+		encoder.AddHeader(&header)
+		assert.Equal(t, "zstd", header.Get("Content-Encoding"))
+
+		compressed, err := io.ReadAll(encoder.Reader())
+		require.NoError(t, err)
+		decoder, err := zstd.NewReader(nil)
+		require.NoError(t, err)
+		plain, err := decoder.DecodeAll(compressed, nil)
+		decoder.Close()
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("{\"index\":{}}\n{\"index\":{}}\n{\"field1\":%d}\n", i), string(plain))
+	}
+}
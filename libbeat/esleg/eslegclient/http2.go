@@ -0,0 +1,96 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package eslegclient
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2"
+
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/transport"
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+)
+
+const (
+	// http2ReadIdleTimeout is the time after which a health check ping is
+	// sent on an HTTP/2 connection without any frames received.
+	http2ReadIdleTimeout = 30 * time.Second
+	http2PingTimeout     = 15 * time.Second
+)
+
+// HTTPClientPool shares HTTP clients between the connections to the same
+// URL. With HTTP/2 the bulk requests of all output workers are multiplexed
+// over a single connection instead of each worker waiting for its own
+// round trips.
+type HTTPClientPool struct {
+	mu      sync.Mutex
+	clients map[string]*http.Client
+}
+
+// NewHTTPClientPool creates an empty pool.
+func NewHTTPClientPool() *HTTPClientPool {
+	return &HTTPClientPool{clients: map[string]*http.Client{}}
+}
+
+func (p *HTTPClientPool) get(url string, create func() (*http.Client, error)) (*http.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if client, ok := p.clients[url]; ok {
+		return client, nil
+	}
+	client, err := create()
+	if err != nil {
+		return nil, err
+	}
+	p.clients[url] = client
+	return client, nil
+}
+
+// enableHTTP2 configures the transport to negotiate HTTP/2 over TLS using
+// ALPN. The TLS connections are established with the configured TLS
+// settings on top of the transport's dialer, so IO statistics and logging
+// keep working. Servers that don't support HTTP/2 are talked to with
+// HTTP/1.1.
+func enableHTTP2(t *http.Transport, tlsConfig *tlscommon.TLSConfig, timeout time.Duration, logger *logp.Logger) {
+	dialer, err := transport.TLSDialerH2(transport.DialerFunc(t.DialContext), tlsConfig, timeout)
+	if err != nil {
+		logger.Warnf("HTTP/2 disabled, failed to create TLS dialer: %v", err)
+		return
+	}
+	t.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, addr, &tls.Config{
+			NextProtos: []string{http2.NextProtoTLS, "http/1.1"},
+		})
+	}
+	t.ForceAttemptHTTP2 = true
+
+	t2, err := http2.ConfigureTransports(t)
+	if err != nil {
+		logger.Warnf("HTTP/2 disabled: %v", err)
+		return
+	}
+	t2.ReadIdleTimeout = http2ReadIdleTimeout
+	t2.PingTimeout = http2PingTimeout
+}
@@ -71,6 +71,9 @@ const (
 
 var (
 	esMinDefaultILMVersion = version.MustNew("7.0.0")
+	// esMinDSLVersion is the first stateful Elasticsearch version with
+	// generally available data stream lifecycles.
+	esMinDSLVersion = version.MustNew("8.14.0")
 )
 
 /// ============ generic helpers
@@ -87,6 +90,16 @@ func checkILMEnabled(enabled bool, c VersionCheckerClient) (bool, error) {
 	return true, nil
 }
 
+// supportsDSL reports whether the cluster c is connected to can manage data
+// streams with data stream lifecycles.
+func supportsDSL(c ESClient) bool {
+	if c.IsServerless() {
+		return true
+	}
+	ver := c.GetVersion()
+	return !ver.LessThan(esMinDSLVersion)
+}
+
 func createPolicy(cfg Config, info beat.Info, defaultPolicy mapstr.M) (Policy, error) {
 	name, err := ApplyStaticFmtstr(info, cfg.PolicyName)
 	if err != nil {
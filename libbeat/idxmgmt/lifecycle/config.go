@@ -18,6 +18,8 @@
 package lifecycle
 
 import (
+	"fmt"
+
 	"github.com/elastic/beats/v7/libbeat/beat"
 	"github.com/elastic/beats/v7/libbeat/common/fmtstr"
 	"github.com/elastic/elastic-agent-libs/config"
@@ -54,6 +56,56 @@ func DefaultDSLName() DSLNameConfig {
 	}
 }
 
+// DSLPolicyConfig stores the settings the DSL policy is built from
+// when no policy file is configured.
+type DSLPolicyConfig struct {
+	// DataRetention is the minimum time documents are kept. Documents are
+	// kept forever if empty.
+	DataRetention string              `config:"data_retention"`
+	Downsampling  []DownsamplingRound `config:"downsampling"`
+}
+
+// DownsamplingRound downsamples the backing indices of a time series data
+// stream to FixedInterval once they are older than After.
+type DownsamplingRound struct {
+	After         string `config:"after" validate:"required"`
+	FixedInterval string `config:"fixed_interval" validate:"required"`
+}
+
+func DefaultDSLPolicyConfig() DSLPolicyConfig {
+	return DSLPolicyConfig{
+		DataRetention: "7d",
+	}
+}
+
+// Policy returns the body of the DSL policy.
+func (c DSLPolicyConfig) Policy() mapstr.M {
+	policy := mapstr.M{}
+	if c.DataRetention != "" {
+		policy["data_retention"] = c.DataRetention
+	}
+	if len(c.Downsampling) > 0 {
+		rounds := make([]mapstr.M, 0, len(c.Downsampling))
+		for _, r := range c.Downsampling {
+			rounds = append(rounds, mapstr.M{"after": r.After, "fixed_interval": r.FixedInterval})
+		}
+		policy["downsampling"] = rounds
+	}
+	return policy
+}
+
+// dslPolicy unpacks the DSL policy settings of cfg, which may be nil, and
+// returns the policy body.
+func dslPolicy(cfg *config.C) (mapstr.M, error) {
+	policyCfg := DefaultDSLPolicyConfig()
+	if cfg != nil {
+		if err := cfg.Unpack(&policyCfg); err != nil {
+			return nil, fmt.Errorf("error unpacking DSL policy: %w", err)
+		}
+	}
+	return policyCfg.Policy(), nil
+}
+
 // LifecycleConfig maps all possible ILM/DSL config values present in a config
 type LifecycleConfig struct {
 	ILM Config `config:"setup.ilm"`
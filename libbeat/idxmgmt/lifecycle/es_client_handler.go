@@ -45,18 +45,22 @@ func NewESClientHandler(c ESClient, info beat.Info, cfg RawConfig) (*ESClientHan
 		return nil, fmt.Errorf("ILM is enabled/configured but %s is connected to a serverless instance; ILM isn't supported on Serverless Elasticsearch. Configure DSL or set setup.ilm.enabled to false", info.Beat)
 	}
 
-	if !cfg.ILM.Enabled() && cfg.DSL.Enabled() && !c.IsServerless() {
-		return nil, fmt.Errorf("DSL is enabled/configured but %s is connected to a stateful instance; DSL is only supported on Serverless Elasticsearch. Configure ILM or set setup.dsl.enabled to false", info.Beat)
+	if !cfg.ILM.Enabled() && cfg.DSL.Enabled() && !supportsDSL(c) {
+		return nil, fmt.Errorf("DSL is enabled/configured but %s is connected to Elasticsearch %v; DSL is only supported on Serverless Elasticsearch and Elasticsearch %v or newer. Configure ILM or set setup.dsl.enabled to false", info.Beat, c.GetVersion(), esMinDSLVersion)
 	}
 
 	if cfg.ILM.Enabled() && cfg.DSL.Enabled() {
 		return nil, fmt.Errorf("only one lifecycle management type can be used, but both ILM and DSL are enabled")
 	}
 
+	// Serverless only supports DSL. Stateful clusters use ILM, unless DSL
+	// is explicitly enabled and supported by the cluster.
+	useDSL := c.IsServerless() || cfg.DSL.Enabled()
+
 	// set default based on ES connection, then unpack user config, if set
 	lifecycleCfg := Config{}
 	var err error
-	if c.IsServerless() {
+	if useDSL {
 		lifecycleCfg = DefaultDSLConfig(info).DSL
 		if cfg.DSL != nil {
 			err = cfg.DSL.Unpack(&lifecycleCfg)
@@ -91,9 +95,9 @@ func NewESClientHandler(c ESClient, info beat.Info, cfg RawConfig) (*ESClientHan
 		return nil, errors.New("could not generate usable policy name from config. Check setup.*.policy_name fields")
 	}
 	// deal with conflicts between policy name and template name
-	// under serverless, it doesn't make sense to have a policy name that differs from the template name
+	// under DSL, it doesn't make sense to have a policy name that differs from the template name
 	// if the user has set both to different values, throw a warning, as overwrite operations will probably fail
-	if c.IsServerless() {
+	if useDSL {
 		if cfg.TemplateName != "" && cfg.TemplateName != name {
 			logp.L().Warnf("setup.dsl.data_stream_pattern is %s, but setup.template.name is %s; under serverless, non-default template and DSL pattern names should be the same. Additional updates & overwrites to this config will not work.", name, cfg.TemplateName)
 		}
@@ -104,8 +108,11 @@ func NewESClientHandler(c ESClient, info beat.Info, cfg RawConfig) (*ESClientHan
 	mode := ILM
 	path := fmt.Sprintf("%s/%s", esILMPath, name)
 
-	if c.IsServerless() {
-		defaultPolicy = DefaultDSLPolicy
+	if useDSL {
+		defaultPolicy, err = dslPolicy(cfg.DSL)
+		if err != nil {
+			return nil, err
+		}
 		mode = DSL
 		path = fmt.Sprintf("/_data_stream/%s/_lifecycle", name)
 	}
@@ -31,11 +31,15 @@ import (
 
 type mockESClient struct {
 	serverless  bool
+	version     string
 	hasPolicy   bool
 	foundPolicy interface{}
 }
 
 func (client *mockESClient) GetVersion() version.V {
+	if client.version != "" {
+		return *version.MustNew(client.version)
+	}
 	return *version.MustNew("8.10.1")
 }
 
@@ -92,6 +96,7 @@ func TestESSetup(t *testing.T) {
 
 	cases := map[string]struct {
 		serverless      bool
+		version         string
 		serverHasPolicy bool
 		cfg             RawConfig
 		err             bool
@@ -126,6 +131,79 @@ func TestESSetup(t *testing.T) {
 			cfg:        defaultDSLCfg,
 			err:        true,
 		},
+		"stateful-with-dsl-support": {
+			serverless:      false,
+			version:         "8.14.0",
+			cfg:             defaultDSLCfg,
+			err:             false,
+			expectedPUTPath: "/_data_stream/test-9.9.9/_lifecycle",
+			expectedName:    "test-9.9.9",
+			expectedPolicy:  DefaultDSLPolicy,
+		},
+		"stateful-with-dsl-support-defaults-to-ilm": {
+			serverless:      false,
+			version:         "8.14.0",
+			cfg:             withDSLBlank,
+			err:             false,
+			expectedPUTPath: "/_ilm/policy/test",
+		},
+		"stateful-with-dsl-support-and-no-config": {
+			serverless:      false,
+			version:         "8.14.0",
+			cfg:             RawConfig{},
+			err:             false,
+			expectedPUTPath: "/_ilm/policy/test",
+			expectedName:    "test",
+			expectedPolicy:  DefaultILMPolicy,
+		},
+		"stateful-without-dsl-support-and-no-config": {
+			serverless:      false,
+			version:         "8.13.4",
+			cfg:             RawConfig{},
+			err:             false,
+			expectedPUTPath: "/_ilm/policy/test",
+			expectedName:    "test",
+			expectedPolicy:  DefaultILMPolicy,
+		},
+		"dsl-with-downsampling": {
+			serverless: true,
+			cfg: RawConfig{
+				DSL: config.MustNewConfigFrom(mapstr.M{
+					"enabled":        true,
+					"data_retention": "30d",
+					"downsampling": []mapstr.M{
+						{"after": "1d", "fixed_interval": "10m"},
+						{"after": "7d", "fixed_interval": "1h"},
+					},
+				}),
+			},
+			err: false,
+			expectedPolicy: mapstr.M{
+				"data_retention": "30d",
+				"downsampling": []mapstr.M{
+					{"after": "1d", "fixed_interval": "10m"},
+					{"after": "7d", "fixed_interval": "1h"},
+				},
+			},
+		},
+		"dsl-without-retention": {
+			serverless: true,
+			cfg: RawConfig{
+				DSL: config.MustNewConfigFrom(mapstr.M{"enabled": true, "data_retention": ""}),
+			},
+			err:            false,
+			expectedPolicy: mapstr.M{},
+		},
+		"dsl-with-invalid-downsampling": {
+			serverless: true,
+			cfg: RawConfig{
+				DSL: config.MustNewConfigFrom(mapstr.M{
+					"enabled":      true,
+					"downsampling": []mapstr.M{{"after": "1d"}},
+				}),
+			},
+			err: true,
+		},
 		"serverless-with-both-enabled": {
 			serverless: true,
 			cfg:        bothEnabledConfig,
@@ -196,7 +274,7 @@ func TestESSetup(t *testing.T) {
 
 	for name, test := range cases {
 		t.Run(name, func(t *testing.T) {
-			client := &mockESClient{serverless: test.serverless, foundPolicy: test.existingPolicy}
+			client := &mockESClient{serverless: test.serverless, version: test.version, foundPolicy: test.existingPolicy}
 			gotClient, err := NewESClientHandler(client, info, test.cfg)
 			if test.err {
 				require.Error(t, err, "expected an error")
@@ -80,7 +80,10 @@ func NewFileClientHandler(c FileClient, info beat.Info, cfg RawConfig) (*FileCli
 	mode := ILM
 
 	if cfg.DSL.Enabled() {
-		defaultPolicy = DefaultDSLPolicy
+		defaultPolicy, err = dslPolicy(cfg.DSL)
+		if err != nil {
+			return nil, err
+		}
 		mode = DSL
 	}
 
@@ -0,0 +1,124 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package lifecycle allows inputs to be stopped, started and reloaded at
+// runtime, for example from the control API, independently of the
+// configuration that created them.
+package lifecycle
+
+import (
+	"sort"
+	"sync"
+)
+
+// Default is the registry used by the inputs of the beat.
+var Default = NewRegistry()
+
+// Input is an input whose lifecycle can be controlled at runtime.
+type Input interface {
+	// Type returns the input type, e.g. filestream.
+	Type() string
+
+	// Running returns whether the input is running.
+	Running() bool
+
+	// Start starts the input if it is stopped.
+	Start() error
+
+	// Stop stops the input and waits until it has returned. The input
+	// stays registered and can be started again.
+	Stop()
+
+	// Reload stops the input, creates it again from its configuration and
+	// starts it.
+	Reload() error
+
+	// Cursors returns the persisted state of the input per registry key.
+	// It returns false if the input does not support reporting its
+	// cursors.
+	Cursors() (map[string]interface{}, bool)
+}
+
+// Registry holds the controllable inputs, by ID.
+type Registry struct {
+	mu     sync.Mutex
+	inputs map[string][]*entry
+}
+
+type entry struct {
+	input Input
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{inputs: make(map[string][]*entry)}
+}
+
+// Register adds an input to the registry and returns a function to call
+// once the input is removed by its owner. If multiple inputs are
+// registered with the same ID, the most recent one is controlled.
+func (r *Registry) Register(id string, input Input) (unregister func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e := &entry{input: input}
+	r.inputs[id] = append(r.inputs[id], e)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			entries := r.inputs[id]
+			for i, other := range entries {
+				if other == e {
+					entries = append(entries[:i:i], entries[i+1:]...)
+					break
+				}
+			}
+			if len(entries) == 0 {
+				delete(r.inputs, id)
+			} else {
+				r.inputs[id] = entries
+			}
+		})
+	}
+}
+
+// Get returns the input with the given ID, or nil if no such input is
+// registered.
+func (r *Registry) Get(id string) Input {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := r.inputs[id]
+	if len(entries) == 0 {
+		return nil
+	}
+	return entries[len(entries)-1].input
+}
+
+// IDs returns the sorted IDs of the registered inputs.
+func (r *Registry) IDs() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := make([]string, 0, len(r.inputs))
+	for id := range r.inputs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
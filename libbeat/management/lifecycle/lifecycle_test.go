@@ -0,0 +1,60 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package lifecycle
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testInput struct{ name string }
+
+func (in *testInput) Type() string                            { return in.name }
+func (in *testInput) Running() bool                           { return true }
+func (in *testInput) Start() error                            { return nil }
+func (in *testInput) Stop()                                   {}
+func (in *testInput) Reload() error                           { return nil }
+func (in *testInput) Cursors() (map[string]interface{}, bool) { return nil, false }
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	assert.Nil(t, r.Get("a"))
+	assert.Empty(t, r.IDs())
+
+	first, second, other := &testInput{"first"}, &testInput{"second"}, &testInput{"other"}
+	unregisterFirst := r.Register("a", first)
+	unregisterSecond := r.Register("a", second)
+	unregisterOther := r.Register("b", other)
+
+	assert.Equal(t, []string{"a", "b"}, r.IDs())
+	assert.Same(t, second, r.Get("a"))
+	assert.Same(t, other, r.Get("b"))
+
+	unregisterSecond()
+	assert.Same(t, first, r.Get("a"))
+
+	// Unregistering is idempotent.
+	unregisterSecond()
+	assert.Same(t, first, r.Get("a"))
+
+	unregisterFirst()
+	unregisterOther()
+	assert.Nil(t, r.Get("a"))
+	assert.Empty(t, r.IDs())
+}
@@ -0,0 +1,393 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package management
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/common/reload"
+	"github.com/elastic/beats/v7/libbeat/management/status"
+	"github.com/elastic/elastic-agent-client/v7/pkg/client"
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/paths"
+)
+
+// LocalConfig is the configuration of the local policy manager, set under
+// `management.local`. When enabled the beat reconciles its inputs and output
+// with the policy fragments found in Path instead of being managed by
+// Elastic Agent.
+type LocalConfig struct {
+	Enabled bool   `config:"enabled"`
+	Path    string `config:"path" validate:"required"`
+	Reload  struct {
+		Period time.Duration `config:"period" validate:"positive,nonzero"`
+	} `config:"reload"`
+	// StateFile is where the result of the last reconciliation is written
+	// to, relative paths are resolved against path.data.
+	StateFile string `config:"state_file"`
+}
+
+func defaultLocalConfig() LocalConfig {
+	c := LocalConfig{StateFile: "policy_state.json"}
+	c.Reload.Period = 10 * time.Second
+	return c
+}
+
+// policyFragment is the content of a single policy file. All fragments in the
+// policy directory are merged, inputs and processors are concatenated in
+// lexical file name order. Only one fragment may define the output.
+type policyFragment struct {
+	Inputs     []*config.C      `config:"inputs"`
+	Output     config.Namespace `config:"output"`
+	Processors []*config.C      `config:"processors"`
+}
+
+// PolicyState is the content of the local state file.
+type PolicyState struct {
+	Status    string    `json:"status"`
+	Message   string    `json:"message"`
+	Revision  string    `json:"revision,omitempty"`
+	Files     []string  `json:"files"`
+	Inputs    int       `json:"inputs"`
+	Output    string    `json:"output,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// localManager is a Manager that watches a directory of policy files and
+// reconciles the running inputs and output with them.
+type localManager struct {
+	config   LocalConfig
+	registry *reload.Registry
+	logger   *logp.Logger
+
+	done chan struct{}
+	wg   sync.WaitGroup
+
+	lock     sync.Mutex
+	state    PolicyState
+	stopFunc func()
+	stopOnce sync.Once
+
+	// revision of the last successfully applied policy and output.
+	revision   string
+	lastOutput string
+}
+
+func newLocalManager(cfg *config.C, registry *reload.Registry) (*localManager, error) {
+	c := defaultLocalConfig()
+	if err := cfg.Unpack(&c); err != nil {
+		return nil, fmt.Errorf("failed to unpack management.local config: %w", err)
+	}
+	if !filepath.IsAbs(c.StateFile) {
+		c.StateFile = paths.Resolve(paths.Data, c.StateFile)
+	}
+
+	return &localManager{
+		config:   c,
+		registry: registry,
+		logger:   logp.NewLogger("mgmt.local"),
+		done:     make(chan struct{}),
+		state: PolicyState{
+			Status: status.Unknown.String(),
+			Files:  []string{},
+		},
+	}, nil
+}
+
+func (m *localManager) Enabled() bool                       { return true }
+func (m *localManager) AgentInfo() client.AgentInfo         { return client.AgentInfo{} }
+func (m *localManager) CheckRawConfig(_ *config.C) error    { return nil }
+func (m *localManager) RegisterAction(_ client.Action)      {}
+func (m *localManager) UnregisterAction(_ client.Action)    {}
+func (m *localManager) SetPayload(_ map[string]interface{}) {}
+func (m *localManager) RegisterDiagnosticHook(_ string, _ string, _ string, _ string, _ client.DiagnosticHook) {
+}
+
+func (m *localManager) UpdateStatus(s status.Status, msg string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.state.Status == s.String() && m.state.Message == msg {
+		return
+	}
+	m.state.Status = s.String()
+	m.state.Message = msg
+	m.logger.Infof("Status change to %s: %s", s, msg)
+	m.writeStateLocked()
+}
+
+func (m *localManager) SetStopCallback(f func()) {
+	m.lock.Lock()
+	m.stopFunc = f
+	m.lock.Unlock()
+}
+
+// Start reconciles the configuration with the policy directory once and then
+// periodically every reload.period.
+func (m *localManager) Start() error {
+	m.logger.Infof("Watching policy directory %s", m.config.Path)
+	m.reconcile()
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		ticker := time.NewTicker(m.config.Reload.Period)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.done:
+				return
+			case <-ticker.C:
+				m.reconcile()
+			}
+		}
+	}()
+	return nil
+}
+
+func (m *localManager) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.done)
+		m.wg.Wait()
+
+		m.lock.Lock()
+		stopFunc := m.stopFunc
+		m.lock.Unlock()
+		if stopFunc != nil {
+			stopFunc()
+		}
+	})
+}
+
+// reconcile loads the policy files and reloads the inputs and output if the
+// policy changed since the last successful reconciliation.
+func (m *localManager) reconcile() {
+	files, revision, fragments, err := loadPolicy(m.config.Path)
+	if err != nil {
+		m.setResult(files, revision, 0, "", err)
+		return
+	}
+	if revision == m.revision {
+		return
+	}
+
+	inputs, output, err := mergeFragments(fragments)
+	if err != nil {
+		m.setResult(files, revision, 0, "", err)
+		return
+	}
+
+	outputName := ""
+	if output.IsSet() {
+		outputName = output.Name()
+		outputCfg, err := config.NewConfigFrom(map[string]interface{}{outputName: output.Config()})
+		if err != nil {
+			m.setResult(files, revision, len(inputs), outputName, err)
+			return
+		}
+		if err := m.reloadOutput(outputCfg); err != nil {
+			m.setResult(files, revision, len(inputs), outputName, err)
+			return
+		}
+	}
+
+	if err := m.reloadInputs(inputs); err != nil {
+		m.setResult(files, revision, len(inputs), outputName, err)
+		return
+	}
+
+	m.revision = revision
+	m.setResult(files, revision, len(inputs), outputName, nil)
+}
+
+func (m *localManager) reloadOutput(cfg *config.C) error {
+	// Compare the rendered configuration to avoid restarting the output
+	// when only inputs changed.
+	rendered := config.DebugString(cfg, false)
+	if rendered == m.lastOutput {
+		return nil
+	}
+
+	output := m.registry.GetReloadableOutput()
+	if output == nil {
+		return errors.New("failed to find beat reloadable type 'output'")
+	}
+	if err := output.Reload(&reload.ConfigWithMeta{Config: cfg}); err != nil {
+		return fmt.Errorf("failed to reload output: %w", err)
+	}
+	m.lastOutput = rendered
+	return nil
+}
+
+func (m *localManager) reloadInputs(inputs []*reload.ConfigWithMeta) error {
+	list := m.registry.GetInputList()
+	if list == nil {
+		return errors.New("failed to find beat reloadable type 'input'")
+	}
+	if err := list.Reload(inputs); err != nil {
+		return fmt.Errorf("failed to reload inputs: %w", err)
+	}
+	return nil
+}
+
+func (m *localManager) setResult(files []string, revision string, inputs int, output string, err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if files == nil {
+		files = []string{}
+	}
+	m.state.Files = files
+	m.state.Inputs = inputs
+	m.state.Output = output
+	if err != nil {
+		m.logger.Errorf("Failed to apply policy from %s: %v", m.config.Path, err)
+		m.state.Status = status.Degraded.String()
+		m.state.Message = "Failed to apply policy"
+		m.state.Error = err.Error()
+	} else {
+		m.logger.Infof("Applied policy revision %s (%d files, %d inputs)", revision, len(files), inputs)
+		m.state.Revision = revision
+		m.state.Status = status.Running.String()
+		m.state.Message = "Policy applied"
+		m.state.Error = ""
+	}
+	m.writeStateLocked()
+}
+
+// writeStateLocked atomically replaces the state file with the current
+// state. m.lock must be held.
+func (m *localManager) writeStateLocked() {
+	m.state.UpdatedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(m.state, "", "  ")
+	if err != nil {
+		m.logger.Errorf("Failed to encode policy state: %v", err)
+		return
+	}
+
+	tmp := m.config.StateFile + ".new"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		m.logger.Errorf("Failed to write policy state file: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, m.config.StateFile); err != nil {
+		m.logger.Errorf("Failed to write policy state file: %v", err)
+	}
+}
+
+// loadPolicy reads all *.yml and *.yaml files in dir in lexical order. The
+// returned revision is a hash over the names and contents of the files.
+func loadPolicy(dir string) ([]string, string, []policyFragment, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to read policy directory: %w", err)
+	}
+
+	var names []string
+	for _, e := range entries {
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		if e.IsDir() || (ext != ".yml" && ext != ".yaml") {
+			continue
+		}
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	fragments := make([]policyFragment, 0, len(names))
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return names, "", nil, fmt.Errorf("failed to read policy file %s: %w", name, err)
+		}
+		h.Write([]byte(name))
+		h.Write([]byte{0})
+		h.Write(data)
+
+		cfg, err := config.NewConfigWithYAML(data, path)
+		if err != nil {
+			return names, "", nil, fmt.Errorf("failed to parse policy file %s: %w", name, err)
+		}
+		var f policyFragment
+		if err := cfg.Unpack(&f); err != nil {
+			return names, "", nil, fmt.Errorf("invalid policy file %s: %w", name, err)
+		}
+		fragments = append(fragments, f)
+	}
+
+	return names, hex.EncodeToString(h.Sum(nil)), fragments, nil
+}
+
+// mergeFragments combines the policy fragments into the list of input
+// configurations and the output. Processors defined at the top level of any
+// fragment are appended to the processors of every input.
+func mergeFragments(fragments []policyFragment) ([]*reload.ConfigWithMeta, config.Namespace, error) {
+	var (
+		output     config.Namespace
+		processors []interface{}
+	)
+	for _, f := range fragments {
+		if f.Output.IsSet() {
+			if output.IsSet() {
+				return nil, output, errors.New("output is defined in more than one policy file")
+			}
+			output = f.Output
+		}
+		for _, p := range f.Processors {
+			var raw map[string]interface{}
+			if err := p.Unpack(&raw); err != nil {
+				return nil, output, fmt.Errorf("invalid processor configuration: %w", err)
+			}
+			processors = append(processors, raw)
+		}
+	}
+
+	var inputs []*reload.ConfigWithMeta
+	for _, f := range fragments {
+		for _, in := range f.Inputs {
+			if len(processors) > 0 {
+				var raw map[string]interface{}
+				if err := in.Unpack(&raw); err != nil {
+					return nil, output, fmt.Errorf("invalid input configuration: %w", err)
+				}
+				existing, _ := raw["processors"].([]interface{})
+				raw["processors"] = append(existing, processors...)
+
+				var err error
+				in, err = config.NewConfigFrom(raw)
+				if err != nil {
+					return nil, output, fmt.Errorf("invalid input configuration: %w", err)
+				}
+			}
+			inputs = append(inputs, &reload.ConfigWithMeta{Config: in})
+		}
+	}
+	return inputs, output, nil
+}
@@ -0,0 +1,168 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package management
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/common/reload"
+	"github.com/elastic/beats/v7/libbeat/management/status"
+	"github.com/elastic/elastic-agent-libs/config"
+)
+
+type fakeInputList struct {
+	calls   int
+	configs []*reload.ConfigWithMeta
+}
+
+func (f *fakeInputList) Reload(configs []*reload.ConfigWithMeta) error {
+	f.calls++
+	f.configs = configs
+	return nil
+}
+
+type fakeOutput struct {
+	calls  int
+	config *reload.ConfigWithMeta
+}
+
+func (f *fakeOutput) Reload(config *reload.ConfigWithMeta) error {
+	f.calls++
+	f.config = config
+	return nil
+}
+
+func newTestLocalManager(t *testing.T) (*localManager, string, *fakeInputList, *fakeOutput) {
+	t.Helper()
+	dir := t.TempDir()
+	policyDir := filepath.Join(dir, "policy.d")
+	require.NoError(t, os.Mkdir(policyDir, 0o700))
+
+	inputs := &fakeInputList{}
+	output := &fakeOutput{}
+	registry := reload.NewRegistry()
+	registry.MustRegisterInput(inputs)
+	registry.MustRegisterOutput(output)
+
+	m, err := NewManager(config.MustNewConfigFrom(map[string]interface{}{
+		"local": map[string]interface{}{
+			"path":       policyDir,
+			"state_file": filepath.Join(dir, "state.json"),
+		},
+	}), registry)
+	require.NoError(t, err)
+	require.IsType(t, &localManager{}, m)
+	return m.(*localManager), policyDir, inputs, output
+}
+
+func writePolicy(t *testing.T, dir, name, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+}
+
+func readState(t *testing.T, m *localManager) PolicyState {
+	t.Helper()
+	data, err := os.ReadFile(m.config.StateFile)
+	require.NoError(t, err)
+	var state PolicyState
+	require.NoError(t, json.Unmarshal(data, &state))
+	return state
+}
+
+func TestLocalManagerReconcile(t *testing.T) {
+	m, dir, inputs, output := newTestLocalManager(t)
+	assert.True(t, m.Enabled())
+
+	writePolicy(t, dir, "10-output.yml", `
+output.elasticsearch:
+  hosts: ["localhost:9200"]
+processors:
+  - add_fields:
+      fields: {team: edge}
+`)
+	writePolicy(t, dir, "20-inputs.yaml", `
+inputs:
+  - type: filestream
+    id: my-logs
+    paths: ["/var/log/*.log"]
+    processors:
+      - drop_fields:
+          fields: [agent]
+`)
+	writePolicy(t, dir, "README.md", "ignored")
+
+	m.reconcile()
+
+	require.Equal(t, 1, output.calls)
+	assert.True(t, output.config.Config.HasField("elasticsearch"))
+
+	require.Equal(t, 1, inputs.calls)
+	require.Len(t, inputs.configs, 1)
+	var in struct {
+		ID         string                   `config:"id"`
+		Processors []map[string]interface{} `config:"processors"`
+	}
+	require.NoError(t, inputs.configs[0].Config.Unpack(&in))
+	assert.Equal(t, "my-logs", in.ID)
+	require.Len(t, in.Processors, 2)
+	assert.Contains(t, in.Processors[0], "drop_fields")
+	assert.Contains(t, in.Processors[1], "add_fields")
+
+	state := readState(t, m)
+	assert.Equal(t, status.Running.String(), state.Status)
+	assert.Equal(t, []string{"10-output.yml", "20-inputs.yaml"}, state.Files)
+	assert.Equal(t, 1, state.Inputs)
+	assert.Equal(t, "elasticsearch", state.Output)
+	assert.NotEmpty(t, state.Revision)
+
+	// Unchanged policy is not reapplied.
+	m.reconcile()
+	assert.Equal(t, 1, inputs.calls)
+
+	// Changing inputs only doesn't restart the output.
+	writePolicy(t, dir, "20-inputs.yaml", `
+inputs:
+  - type: filestream
+    id: my-logs
+  - type: filestream
+    id: other-logs
+`)
+	m.reconcile()
+	assert.Equal(t, 2, inputs.calls)
+	assert.Len(t, inputs.configs, 2)
+	assert.Equal(t, 1, output.calls)
+}
+
+func TestLocalManagerInvalidPolicy(t *testing.T) {
+	m, dir, inputs, _ := newTestLocalManager(t)
+
+	writePolicy(t, dir, "a.yml", "output.console: {}\n")
+	writePolicy(t, dir, "b.yml", "output.elasticsearch: {}\n")
+	m.reconcile()
+
+	assert.Equal(t, 0, inputs.calls)
+	state := readState(t, m)
+	assert.Equal(t, status.Degraded.String(), state.Status)
+	assert.Contains(t, state.Error, "more than one policy file")
+}
@@ -88,12 +88,22 @@ var managerFactory ManagerFactory
 var managerFactoryLock sync.Mutex
 
 // NewManager creates the beats manager based on the given configuration
-// and registry. If management and x-pack are enabled this calls
-// NewV2AgentManager (see x-pack/libbeat/management/managerV2.go), otherwise
-// it returns a placeholder.
+// and registry. If management.local is enabled the beat is managed by the
+// policy files in the configured directory. If management and x-pack are
+// enabled this calls NewV2AgentManager (see
+// x-pack/libbeat/management/managerV2.go), otherwise it returns a placeholder.
 // Tests can call SetManagerFactory to instead use a mocked manager,
 // see x-pack/libbeat/management/tests/init.go.
 func NewManager(cfg *config.C, registry *reload.Registry) (Manager, error) {
+	if cfg != nil && cfg.HasField("local") {
+		local, err := cfg.Child("local", -1)
+		if err != nil {
+			return nil, err
+		}
+		if local.Enabled() {
+			return newLocalManager(local, registry)
+		}
+	}
 	if cfg.Enabled() {
 		managerFactoryLock.Lock()
 		defer managerFactoryLock.Unlock()
@@ -0,0 +1,176 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package status
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultHealth aggregates the statuses of the inputs of the beat.
+var DefaultHealth = NewHealth()
+
+// Health aggregates the statuses reported by the units of the beat, like
+// inputs, into a single health state.
+type Health struct {
+	mu    sync.Mutex
+	next  uint64
+	units map[uint64]*UnitHealth
+	now   func() time.Time
+}
+
+// UnitHealth is the last status reported by a unit.
+type UnitHealth struct {
+	ID      string    `json:"id"`
+	Name    string    `json:"name"`
+	Status  string    `json:"status"`
+	Message string    `json:"message,omitempty"`
+	Since   time.Time `json:"since"`
+
+	status Status
+}
+
+// HealthState is the aggregated health of the beat. Status is the worst
+// status reported by the units, and Units lists the units that are not
+// healthy, with the message they reported.
+type HealthState struct {
+	Status  Status       `json:"-"`
+	Total   int          `json:"total"`
+	Units   []UnitHealth `json:"units"`
+	Reasons []string     `json:"reasons,omitempty"`
+}
+
+// Name returns the name of the aggregated status, in lower case.
+func (s HealthState) Name() string {
+	return statusName(s.Status)
+}
+
+// NewHealth creates an empty health aggregator.
+func NewHealth() *Health {
+	return &Health{units: map[uint64]*UnitHealth{}, now: time.Now}
+}
+
+// Register adds a unit to the aggregator. The returned reporter records the
+// statuses of the unit and forwards them to next, if not nil. The unit is
+// removed from the aggregator when unregister is called.
+func (h *Health) Register(id, name string, next StatusReporter) (reporter StatusReporter, unregister func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := h.next
+	h.next++
+	h.units[key] = &UnitHealth{
+		ID:     id,
+		Name:   name,
+		Status: statusName(Running),
+		Since:  h.now(),
+		status: Running,
+	}
+
+	reporter = &healthReporter{health: h, key: key, next: next}
+	var once sync.Once
+	return reporter, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			delete(h.units, key)
+		})
+	}
+}
+
+func (h *Health) update(key uint64, status Status, msg string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	u, ok := h.units[key]
+	if !ok {
+		return
+	}
+	if u.status != status {
+		u.Since = h.now()
+	}
+	u.status = status
+	u.Status = statusName(status)
+	u.Message = msg
+}
+
+// State returns the aggregated health of the registered units. A beat
+// without units is running.
+func (h *Health) State() HealthState {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	state := HealthState{Status: Running, Total: len(h.units), Units: []UnitHealth{}}
+	for _, u := range h.units {
+		if severity(u.status) > severity(state.Status) {
+			state.Status = u.status
+		}
+		if severity(u.status) > severity(Running) {
+			state.Units = append(state.Units, *u)
+		}
+	}
+	sort.Slice(state.Units, func(i, j int) bool {
+		a, b := state.Units[i], state.Units[j]
+		if sa, sb := severity(a.status), severity(b.status); sa != sb {
+			return sa > sb
+		}
+		return a.ID < b.ID
+	})
+	for _, u := range state.Units {
+		reason := fmt.Sprintf("%s input '%s' is %s", u.Name, u.ID, u.Status)
+		if u.Message != "" {
+			reason += ": " + u.Message
+		}
+		state.Reasons = append(state.Reasons, reason)
+	}
+	return state
+}
+
+// severity orders the statuses by how unhealthy they are. Units that are
+// stopping or stopped, or that never reported a status, are healthy.
+func severity(s Status) int {
+	switch s {
+	case Failed:
+		return 3
+	case Degraded:
+		return 2
+	case Starting, Configuring:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func statusName(s Status) string {
+	return strings.ToLower(s.String())
+}
+
+type healthReporter struct {
+	health *Health
+	key    uint64
+	next   StatusReporter
+}
+
+func (r *healthReporter) UpdateStatus(status Status, msg string) {
+	r.health.update(r.key, status, msg)
+	if r.next != nil {
+		r.next.UpdateStatus(status, msg)
+	}
+}
@@ -0,0 +1,100 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package status
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordReporter struct {
+	status Status
+	msg    string
+}
+
+func (r *recordReporter) UpdateStatus(status Status, msg string) {
+	r.status, r.msg = status, msg
+}
+
+func TestHealthState(t *testing.T) {
+	h := NewHealth()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	h.now = func() time.Time { return now }
+
+	state := h.State()
+	assert.Equal(t, Running, state.Status)
+	assert.Equal(t, "running", state.Name())
+	assert.Empty(t, state.Units)
+	assert.Empty(t, state.Reasons)
+
+	next := &recordReporter{}
+	a, _ := h.Register("a", "winlog", next)
+	b, unregisterB := h.Register("b", "filestream", nil)
+	c, _ := h.Register("c", "filestream", nil)
+
+	a.UpdateStatus(Degraded, "dropped events")
+	assert.Equal(t, Degraded, next.status, "status must be forwarded")
+	assert.Equal(t, "dropped events", next.msg)
+
+	now = now.Add(time.Minute)
+	b.UpdateStatus(Failed, "bad config")
+	c.UpdateStatus(Running, "")
+
+	state = h.State()
+	assert.Equal(t, Failed, state.Status)
+	assert.Equal(t, 3, state.Total)
+	assert.Equal(t, []string{
+		"filestream input 'b' is failed: bad config",
+		"winlog input 'a' is degraded: dropped events",
+	}, state.Reasons)
+	if assert.Len(t, state.Units, 2) {
+		assert.Equal(t, "b", state.Units[0].ID)
+		assert.Equal(t, now, state.Units[0].Since)
+		assert.Equal(t, "a", state.Units[1].ID)
+		assert.Equal(t, "degraded", state.Units[1].Status)
+	}
+
+	unregisterB()
+	unregisterB()
+	b.UpdateStatus(Failed, "ignored after unregister")
+	state = h.State()
+	assert.Equal(t, Degraded, state.Status)
+	assert.Equal(t, 2, state.Total)
+
+	a.UpdateStatus(Stopped, "")
+	assert.Equal(t, Running, h.State().Status)
+}
+
+func TestHealthSinceOnlyChangesWithStatus(t *testing.T) {
+	h := NewHealth()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	h.now = func() time.Time { return now }
+
+	r, _ := h.Register("a", "winlog", nil)
+	r.UpdateStatus(Degraded, "first")
+	since := now
+
+	now = now.Add(time.Minute)
+	r.UpdateStatus(Degraded, "second")
+
+	state := h.State()
+	assert.Equal(t, since, state.Units[0].Since)
+	assert.Equal(t, "second", state.Units[0].Message)
+}
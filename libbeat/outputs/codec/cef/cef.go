@@ -0,0 +1,241 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package cef provides a codec that serializes events in the ArcSight Common
+// Event Format (CEF), making it possible to feed SIEM collectors that do not
+// understand JSON.
+package cef
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/common/fmtstr"
+	"github.com/elastic/beats/v7/libbeat/outputs/codec"
+	"github.com/elastic/elastic-agent-libs/config"
+)
+
+// Config is used to pass encoding parameters to New.
+type Config struct {
+	Vendor            string                    `config:"device.vendor"`
+	Product           string                    `config:"device.product"`
+	Version           string                    `config:"device.version"`
+	SignatureID       *fmtstr.EventFormatString `config:"signature_id"`
+	Name              *fmtstr.EventFormatString `config:"name"`
+	Severity          *fmtstr.EventFormatString `config:"severity"`
+	DefaultExtensions bool                      `config:"default_extensions"`
+	Extensions        map[string]string         `config:"extensions"`
+}
+
+// extension maps a CEF extension key to the event field holding its value.
+type extension struct {
+	key   string
+	field string
+}
+
+// defaultExtensions maps ECS fields to their CEF dictionary counterparts.
+var defaultExtensions = []extension{
+	{"rt", "@timestamp"},
+	{"msg", "message"},
+	{"act", "event.action"},
+	{"outcome", "event.outcome"},
+	{"cat", "event.category"},
+	{"src", "source.ip"},
+	{"spt", "source.port"},
+	{"smac", "source.mac"},
+	{"shost", "source.domain"},
+	{"suser", "source.user.name"},
+	{"dst", "destination.ip"},
+	{"dpt", "destination.port"},
+	{"dmac", "destination.mac"},
+	{"dhost", "destination.domain"},
+	{"duser", "destination.user.name"},
+	{"proto", "network.transport"},
+	{"request", "url.original"},
+	{"requestMethod", "http.request.method"},
+	{"requestClientApplication", "user_agent.original"},
+	{"sproc", "process.name"},
+	{"spid", "process.pid"},
+	{"fname", "file.name"},
+	{"filePath", "file.path"},
+	{"fsize", "file.size"},
+	{"dvchost", "host.hostname"},
+}
+
+var extensionKeyRegexp = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+var (
+	headerEscaper    = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\r", " ", "\n", " ")
+	extensionEscaper = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r", `\r`, "\n", `\n`)
+)
+
+func defaultConfig(info beat.Info) Config {
+	return Config{
+		Vendor:            "Elastic",
+		Product:           info.Beat,
+		Version:           info.Version,
+		SignatureID:       fmtstr.MustCompileEvent("%{[event.code]:0}"),
+		Name:              fmtstr.MustCompileEvent("%{[event.action]:event}"),
+		Severity:          fmtstr.MustCompileEvent("%{[event.severity]:5}"),
+		DefaultExtensions: true,
+	}
+}
+
+// Validate checks that all configured extension keys are valid CEF keys.
+func (c *Config) Validate() error {
+	for key := range c.Extensions {
+		if !extensionKeyRegexp.MatchString(key) {
+			return fmt.Errorf("invalid CEF extension key %q: only alphanumeric characters are allowed", key)
+		}
+	}
+	return nil
+}
+
+// Encoder serializes a beat.Event as a CEF message.
+type Encoder struct {
+	config     Config
+	extensions []extension
+}
+
+func init() {
+	codec.RegisterType("cef", func(info beat.Info, cfg *config.C) (codec.Codec, error) {
+		config := defaultConfig(info)
+		if cfg != nil {
+			if err := cfg.Unpack(&config); err != nil {
+				return nil, err
+			}
+		}
+
+		return New(config), nil
+	})
+}
+
+// New creates a new CEF Encoder.
+func New(config Config) *Encoder {
+	var extensions []extension
+	if config.DefaultExtensions {
+		for _, ext := range defaultExtensions {
+			if _, overridden := config.Extensions[ext.key]; !overridden {
+				extensions = append(extensions, ext)
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(config.Extensions))
+	for key := range config.Extensions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		extensions = append(extensions, extension{key: key, field: config.Extensions[key]})
+	}
+
+	return &Encoder{config: config, extensions: extensions}
+}
+
+// Encode serializes a beat event to CEF.
+func (e *Encoder) Encode(_ string, event *beat.Event) ([]byte, error) {
+	signatureID, err := e.config.SignatureID.Run(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format CEF signature ID: %w", err)
+	}
+	name, err := e.config.Name.Run(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format CEF name: %w", err)
+	}
+	severity, err := e.config.Severity.Run(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format CEF severity: %w", err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("CEF:0")
+	for _, v := range []string{e.config.Vendor, e.config.Product, e.config.Version, signatureID, name, severity} {
+		buf.WriteByte('|')
+		headerEscaper.WriteString(&buf, v) //nolint:errcheck // bytes.Buffer writes never fail.
+	}
+	buf.WriteByte('|')
+
+	first := true
+	for _, ext := range e.extensions {
+		v, err := event.GetValue(ext.field)
+		if err != nil {
+			continue
+		}
+		s, ok := stringValue(v)
+		if !ok {
+			continue
+		}
+		if !first {
+			buf.WriteByte(' ')
+		}
+		first = false
+		buf.WriteString(ext.key)
+		buf.WriteByte('=')
+		extensionEscaper.WriteString(&buf, s) //nolint:errcheck // bytes.Buffer writes never fail.
+	}
+
+	return buf.Bytes(), nil
+}
+
+// stringValue renders a field value as a CEF extension value. Timestamps
+// are written as milliseconds since the epoch, which every CEF date field
+// accepts. It returns false for values that should be omitted.
+func stringValue(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, v != ""
+	case time.Time:
+		return strconv.FormatInt(v.UnixMilli(), 10), true
+	case common.Time:
+		return strconv.FormatInt(time.Time(v).UnixMilli(), 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), true
+	case []string:
+		return strings.Join(v, ","), len(v) != 0
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, elem := range v {
+			if s, ok := stringValue(elem); ok {
+				parts = append(parts, s)
+			}
+		}
+		return strings.Join(parts, ","), len(parts) != 0
+	case fmt.Stringer:
+		return v.String(), true
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(v), true
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", false
+		}
+		return string(b), true
+	}
+}
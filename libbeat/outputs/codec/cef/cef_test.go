@@ -0,0 +1,140 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cef
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/outputs/codec"
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+var testEvent = beat.Event{
+	Timestamp: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+	Fields: mapstr.M{
+		"message": "login failed for user=alice\nretrying",
+		"event": mapstr.M{
+			"action":   "logon-failed",
+			"code":     4625,
+			"severity": 7,
+			"outcome":  "failure",
+			"category": []string{"authentication"},
+		},
+		"source": mapstr.M{
+			"ip":   "10.0.0.1",
+			"port": 51234,
+		},
+		"host": mapstr.M{
+			"hostname": `dc|01`,
+		},
+		"file": mapstr.M{
+			"path": `C:\Windows\System32`,
+		},
+	},
+}
+
+func createEncoder(settings map[string]interface{}) (codec.Codec, error) {
+	var cfg codec.Config
+	if err := config.MustNewConfigFrom(map[string]interface{}{"cef": settings}).Unpack(&cfg); err != nil {
+		return nil, err
+	}
+	return codec.CreateEncoder(beat.Info{Beat: "winlogbeat", Version: "8.15.0"}, cfg)
+}
+
+func newTestEncoder(t *testing.T, settings map[string]interface{}) codec.Codec {
+	t.Helper()
+
+	enc, err := createEncoder(settings)
+	require.NoError(t, err)
+	return enc
+}
+
+func TestEncodeDefaults(t *testing.T) {
+	enc := newTestEncoder(t, map[string]interface{}{})
+
+	out, err := enc.Encode("test", &testEvent)
+	require.NoError(t, err)
+
+	expected := `CEF:0|Elastic|winlogbeat|8.15.0|4625|logon-failed|7|` +
+		`rt=1709294400000 msg=login failed for user\=alice\nretrying act=logon-failed outcome=failure ` +
+		`cat=authentication src=10.0.0.1 spt=51234 filePath=C:\\Windows\\System32 dvchost=dc|01`
+	assert.Equal(t, expected, string(out))
+}
+
+func TestEncodeCustom(t *testing.T) {
+	enc := newTestEncoder(t, map[string]interface{}{
+		"device.vendor":      "ACME|Corp",
+		"device.product":     "shipper",
+		"device.version":     "1.0",
+		"signature_id":       "%{[event.action]}",
+		"name":               "%{[message]}",
+		"severity":           "High",
+		"default_extensions": false,
+		"extensions": map[string]interface{}{
+			"suser": "user.name",
+			"src":   "source.ip",
+		},
+	})
+
+	out, err := enc.Encode("test", &testEvent)
+	require.NoError(t, err)
+
+	// user.name is missing from the event so suser is omitted.
+	expected := `CEF:0|ACME\|Corp|shipper|1.0|logon-failed|login failed for user=alice retrying|High|src=10.0.0.1`
+	assert.Equal(t, expected, string(out))
+}
+
+func TestEncodeOverrideDefaultExtension(t *testing.T) {
+	enc := newTestEncoder(t, map[string]interface{}{
+		"extensions": map[string]interface{}{
+			"msg": "event.outcome",
+		},
+	})
+
+	event := beat.Event{
+		Timestamp: testEvent.Timestamp,
+		Fields:    mapstr.M{"message": "ignored", "event": mapstr.M{"outcome": "success"}},
+	}
+	out, err := enc.Encode("test", &event)
+	require.NoError(t, err)
+	assert.Equal(t, `CEF:0|Elastic|winlogbeat|8.15.0|0|event|5|rt=1709294400000 outcome=success msg=success`, string(out))
+}
+
+func TestEncodeMissingFormatField(t *testing.T) {
+	enc := newTestEncoder(t, map[string]interface{}{
+		"name": "%{[does.not.exist]}",
+	})
+
+	_, err := enc.Encode("test", &testEvent)
+	assert.Error(t, err)
+}
+
+func TestInvalidExtensionKey(t *testing.T) {
+	_, err := createEncoder(map[string]interface{}{
+		"extensions": map[string]interface{}{
+			"bad key": "message",
+		},
+	})
+	assert.ErrorContains(t, err, "invalid CEF extension key")
+}
@@ -0,0 +1,285 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package leef provides a codec that serializes events in the IBM Log Event
+// Extended Format (LEEF) consumed by QRadar and compatible collectors.
+package leef
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/common/fmtstr"
+	"github.com/elastic/beats/v7/libbeat/outputs/codec"
+	"github.com/elastic/elastic-agent-libs/config"
+)
+
+// devTimeLayout is the default devTime format expected by LEEF consumers,
+// so no devTimeFormat attribute needs to be sent.
+const devTimeLayout = "Jan 02 2006 15:04:05.000 MST"
+
+// Config is used to pass encoding parameters to New.
+type Config struct {
+	Version           string                    `config:"version"`
+	Delimiter         string                    `config:"delimiter"`
+	Vendor            string                    `config:"device.vendor"`
+	Product           string                    `config:"device.product"`
+	ProductVersion    string                    `config:"device.version"`
+	EventID           *fmtstr.EventFormatString `config:"event_id"`
+	DefaultAttributes bool                      `config:"default_attributes"`
+	Attributes        map[string]string         `config:"attributes"`
+}
+
+// attribute maps a LEEF attribute key to the event field holding its value.
+type attribute struct {
+	key   string
+	field string
+}
+
+// defaultAttributes maps ECS fields to the predefined LEEF event attributes.
+var defaultAttributes = []attribute{
+	{"devTime", "@timestamp"},
+	{"cat", "event.category"},
+	{"sev", "event.severity"},
+	{"src", "source.ip"},
+	{"srcPort", "source.port"},
+	{"srcMAC", "source.mac"},
+	{"srcBytes", "source.bytes"},
+	{"srcPackets", "source.packets"},
+	{"dst", "destination.ip"},
+	{"dstPort", "destination.port"},
+	{"dstMAC", "destination.mac"},
+	{"dstBytes", "destination.bytes"},
+	{"dstPackets", "destination.packets"},
+	{"usrName", "user.name"},
+	{"proto", "network.transport"},
+	{"url", "url.original"},
+	{"identHostName", "host.hostname"},
+}
+
+var (
+	attributeKeyRegexp = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+	hexDelimiterRegexp = regexp.MustCompile(`^0?x([0-9A-Fa-f]{2})$`)
+
+	headerEscaper = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\r", " ", "\n", " ")
+)
+
+func defaultConfig(info beat.Info) Config {
+	return Config{
+		Version:           "2.0",
+		Delimiter:         "\t",
+		Vendor:            "Elastic",
+		Product:           info.Beat,
+		ProductVersion:    info.Version,
+		EventID:           fmtstr.MustCompileEvent("%{[event.action]:event}"),
+		DefaultAttributes: true,
+	}
+}
+
+// Validate checks the LEEF version, delimiter and attribute keys.
+func (c *Config) Validate() error {
+	delim, err := parseDelimiter(c.Delimiter)
+	if err != nil {
+		return err
+	}
+	if delim == '|' || delim == '=' {
+		return fmt.Errorf("invalid LEEF delimiter %q: conflicts with the LEEF syntax", c.Delimiter)
+	}
+	switch c.Version {
+	case "1.0":
+		if delim != '\t' {
+			return errors.New("LEEF 1.0 only supports a tab delimiter")
+		}
+	case "2.0":
+	default:
+		return fmt.Errorf("unsupported LEEF version %q: must be 1.0 or 2.0", c.Version)
+	}
+	for key := range c.Attributes {
+		if !attributeKeyRegexp.MatchString(key) {
+			return fmt.Errorf("invalid LEEF attribute key %q: only alphanumeric characters are allowed", key)
+		}
+	}
+	return nil
+}
+
+// parseDelimiter accepts either a single character or its hex
+// representation in the x09 or 0x09 form used by the LEEF 2.0 header.
+func parseDelimiter(s string) (byte, error) {
+	if len(s) == 1 {
+		return s[0], nil
+	}
+	if m := hexDelimiterRegexp.FindStringSubmatch(s); m != nil {
+		v, err := strconv.ParseUint(m[1], 16, 8)
+		if err != nil {
+			return 0, err
+		}
+		return byte(v), nil
+	}
+	return 0, fmt.Errorf("invalid LEEF delimiter %q: must be a single character or a hex value like x09", s)
+}
+
+// Encoder serializes a beat.Event as a LEEF message.
+type Encoder struct {
+	config     Config
+	delimiter  byte
+	attributes []attribute
+	escaper    *strings.Replacer
+}
+
+func init() {
+	codec.RegisterType("leef", func(info beat.Info, cfg *config.C) (codec.Codec, error) {
+		config := defaultConfig(info)
+		if cfg != nil {
+			if err := cfg.Unpack(&config); err != nil {
+				return nil, err
+			}
+		}
+
+		return New(config)
+	})
+}
+
+// New creates a new LEEF Encoder.
+func New(config Config) (*Encoder, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	delim, _ := parseDelimiter(config.Delimiter)
+
+	var attributes []attribute
+	if config.DefaultAttributes {
+		for _, attr := range defaultAttributes {
+			if _, overridden := config.Attributes[attr.key]; !overridden {
+				attributes = append(attributes, attr)
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(config.Attributes))
+	for key := range config.Attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		attributes = append(attributes, attribute{key: key, field: config.Attributes[key]})
+	}
+
+	escapedDelim := `\` + string(delim)
+	if delim == '\t' {
+		escapedDelim = `\t`
+	}
+	return &Encoder{
+		config:     config,
+		delimiter:  delim,
+		attributes: attributes,
+		escaper:    strings.NewReplacer(string(delim), escapedDelim, "\r", `\r`, "\n", `\n`),
+	}, nil
+}
+
+// Encode serializes a beat event to LEEF.
+func (e *Encoder) Encode(_ string, event *beat.Event) ([]byte, error) {
+	eventID, err := e.config.EventID.Run(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format LEEF event ID: %w", err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("LEEF:")
+	buf.WriteString(e.config.Version)
+	for _, v := range []string{e.config.Vendor, e.config.Product, e.config.ProductVersion, eventID} {
+		buf.WriteByte('|')
+		headerEscaper.WriteString(&buf, v) //nolint:errcheck // bytes.Buffer writes never fail.
+	}
+	buf.WriteByte('|')
+	if e.config.Version == "2.0" {
+		if e.delimiter > ' ' && e.delimiter < 0x7f {
+			buf.WriteByte(e.delimiter)
+		} else {
+			fmt.Fprintf(&buf, "x%02x", e.delimiter)
+		}
+		buf.WriteByte('|')
+	}
+
+	first := true
+	for _, attr := range e.attributes {
+		v, err := event.GetValue(attr.field)
+		if err != nil {
+			continue
+		}
+		s, ok := stringValue(v)
+		if !ok {
+			continue
+		}
+		if !first {
+			buf.WriteByte(e.delimiter)
+		}
+		first = false
+		buf.WriteString(attr.key)
+		buf.WriteByte('=')
+		e.escaper.WriteString(&buf, s) //nolint:errcheck // bytes.Buffer writes never fail.
+	}
+
+	return buf.Bytes(), nil
+}
+
+// stringValue renders a field value as a LEEF attribute value. It returns
+// false for values that should be omitted.
+func stringValue(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, v != ""
+	case time.Time:
+		return v.UTC().Format(devTimeLayout), true
+	case common.Time:
+		return time.Time(v).UTC().Format(devTimeLayout), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), true
+	case []string:
+		return strings.Join(v, ","), len(v) != 0
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, elem := range v {
+			if s, ok := stringValue(elem); ok {
+				parts = append(parts, s)
+			}
+		}
+		return strings.Join(parts, ","), len(parts) != 0
+	case fmt.Stringer:
+		return v.String(), true
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(v), true
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", false
+		}
+		return string(b), true
+	}
+}
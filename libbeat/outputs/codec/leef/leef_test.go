@@ -0,0 +1,156 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package leef
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/outputs/codec"
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+var testEvent = beat.Event{
+	Timestamp: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+	Fields: mapstr.M{
+		"message": "connection\tdenied",
+		"event": mapstr.M{
+			"action":   "deny",
+			"severity": 7,
+		},
+		"source": mapstr.M{
+			"ip":   "10.0.0.1",
+			"port": 51234,
+		},
+		"destination": mapstr.M{
+			"ip":   "192.168.1.1",
+			"port": 443,
+		},
+		"user": mapstr.M{
+			"name": "alice",
+		},
+	},
+}
+
+func createEncoder(settings map[string]interface{}) (codec.Codec, error) {
+	var cfg codec.Config
+	if err := config.MustNewConfigFrom(map[string]interface{}{"leef": settings}).Unpack(&cfg); err != nil {
+		return nil, err
+	}
+	return codec.CreateEncoder(beat.Info{Beat: "packetbeat", Version: "8.15.0"}, cfg)
+}
+
+func newTestEncoder(t *testing.T, settings map[string]interface{}) codec.Codec {
+	t.Helper()
+
+	enc, err := createEncoder(settings)
+	require.NoError(t, err)
+	return enc
+}
+
+func TestEncodeDefaults(t *testing.T) {
+	enc := newTestEncoder(t, map[string]interface{}{})
+
+	out, err := enc.Encode("test", &testEvent)
+	require.NoError(t, err)
+
+	expected := "LEEF:2.0|Elastic|packetbeat|8.15.0|deny|x09|" +
+		"devTime=Mar 01 2024 12:00:00.000 UTC\tsev=7\tsrc=10.0.0.1\tsrcPort=51234\t" +
+		"dst=192.168.1.1\tdstPort=443\tusrName=alice"
+	assert.Equal(t, expected, string(out))
+}
+
+func TestEncodeCustomDelimiter(t *testing.T) {
+	enc := newTestEncoder(t, map[string]interface{}{
+		"delimiter":          "^",
+		"device.vendor":      `ACME\|Corp`,
+		"event_id":           "%{[event.action]}-%{[event.severity]}",
+		"default_attributes": false,
+		"attributes": map[string]interface{}{
+			"msg": "message",
+			"src": "source.ip",
+		},
+	})
+
+	out, err := enc.Encode("test", &testEvent)
+	require.NoError(t, err)
+
+	expected := "LEEF:2.0|ACME\\\\\\|Corp|packetbeat|8.15.0|deny-7|^|msg=connection\tdenied^src=10.0.0.1"
+	assert.Equal(t, expected, string(out))
+}
+
+func TestEncodeVersion1(t *testing.T) {
+	enc := newTestEncoder(t, map[string]interface{}{
+		"version":            "1.0",
+		"default_attributes": false,
+		"attributes": map[string]interface{}{
+			"msg": "message",
+		},
+	})
+
+	out, err := enc.Encode("test", &testEvent)
+	require.NoError(t, err)
+	assert.Equal(t, `LEEF:1.0|Elastic|packetbeat|8.15.0|deny|msg=connection\tdenied`, string(out))
+}
+
+func TestInvalidConfig(t *testing.T) {
+	tests := map[string]struct {
+		settings map[string]interface{}
+		err      string
+	}{
+		"unsupported version": {
+			settings: map[string]interface{}{"version": "3.0"},
+			err:      "unsupported LEEF version",
+		},
+		"version 1 delimiter": {
+			settings: map[string]interface{}{"version": "1.0", "delimiter": "^"},
+			err:      "LEEF 1.0 only supports a tab delimiter",
+		},
+		"bad delimiter": {
+			settings: map[string]interface{}{"delimiter": "ab"},
+			err:      "invalid LEEF delimiter",
+		},
+		"reserved delimiter": {
+			settings: map[string]interface{}{"delimiter": "x7c"},
+			err:      "conflicts with the LEEF syntax",
+		},
+		"bad attribute key": {
+			settings: map[string]interface{}{"attributes": map[string]interface{}{"a b": "message"}},
+			err:      "invalid LEEF attribute key",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := createEncoder(tc.settings)
+			assert.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestParseDelimiter(t *testing.T) {
+	for in, want := range map[string]byte{"\t": '\t', "^": '^', "x09": '\t', "0x5E": '^'} {
+		got, err := parseDelimiter(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+}
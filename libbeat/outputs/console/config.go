@@ -18,6 +18,8 @@
 package console
 
 import (
+	"errors"
+
 	"github.com/elastic/beats/v7/libbeat/outputs/codec"
 	"github.com/elastic/elastic-agent-libs/config"
 )
@@ -28,8 +30,30 @@ type Config struct {
 	// old pretty settings to use if no codec is configured
 	Pretty bool `config:"pretty"`
 
+	// Options of the JSON events written when no codec is configured.
+	SortKeys bool     `config:"sort_keys"`
+	Flatten  bool     `config:"flatten"`
+	Fields   []string `config:"fields"`
+	// MessageOnly writes the message field of the events only.
+	MessageOnly bool `config:"message_only"`
+
 	BatchSize int
 	Queue     config.Namespace `config:"queue"`
 }
 
 var defaultConfig = Config{}
+
+func (c *Config) Validate() error {
+	if c.Codec.Namespace.IsSet() && c.formatted() {
+		return errors.New("sort_keys, flatten, fields and message_only can't be used with a codec")
+	}
+	if c.MessageOnly && (c.SortKeys || c.Flatten || len(c.Fields) > 0 || c.Pretty) {
+		return errors.New("message_only can't be used with pretty, sort_keys, flatten or fields")
+	}
+	return nil
+}
+
+// formatted returns whether the events are written by the formatEncoder.
+func (c *Config) formatted() bool {
+	return c.SortKeys || c.Flatten || len(c.Fields) > 0 || c.MessageOnly
+}
@@ -64,6 +64,8 @@ func makeConsole(
 		if err != nil {
 			return outputs.Fail(err)
 		}
+	} else if config.formatted() {
+		enc = newFormatEncoder(beat.Version, config)
 	} else {
 		enc = json.New(beat.Version, json.Config{
 			Pretty:     config.Pretty,
@@ -25,8 +25,10 @@ import (
 	"io"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/elastic/beats/v7/libbeat/beat"
 	"github.com/elastic/beats/v7/libbeat/common/fmtstr"
@@ -36,6 +38,7 @@ import (
 	"github.com/elastic/beats/v7/libbeat/outputs/codec/json"
 	"github.com/elastic/beats/v7/libbeat/outputs/outest"
 	"github.com/elastic/beats/v7/libbeat/publisher"
+	"github.com/elastic/elastic-agent-libs/config"
 	"github.com/elastic/elastic-agent-libs/mapstr"
 )
 
@@ -108,6 +111,31 @@ func TestConsoleOutput(t *testing.T) {
 			},
 			"myevent\n",
 		},
+		{
+			"json event with sorted keys",
+			newFormatEncoder("1.2.3", Config{SortKeys: true}),
+			[]beat.Event{
+				{Fields: mapstr.M{"z": "last", "host": mapstr.M{"name": "web", "ip": "10.0.0.1"}}},
+			},
+			"{\"@metadata\":{\"beat\":\"test\",\"type\":\"_doc\",\"version\":\"1.2.3\"},\"@timestamp\":\"0001-01-01T00:00:00.000Z\",\"host\":{\"ip\":\"10.0.0.1\",\"name\":\"web\"},\"z\":\"last\"}\n",
+		},
+		{
+			"json event with flattened selected fields",
+			newFormatEncoder("1.2.3", Config{SortKeys: true, Flatten: true, Fields: []string{"message", "host.name", "@metadata.beat", "missing"}}),
+			[]beat.Event{
+				{Fields: mapstr.M{"message": "<hello>", "host": mapstr.M{"name": "web", "ip": "10.0.0.1"}}},
+			},
+			"{\"@metadata.beat\":\"test\",\"host.name\":\"web\",\"message\":\"<hello>\"}\n",
+		},
+		{
+			"message only",
+			newFormatEncoder("1.2.3", Config{MessageOnly: true}),
+			[]beat.Event{
+				{Fields: event("message", "first line\nsecond line")},
+				{Fields: event("message", "next")},
+			},
+			"first line\\nsecond line\nnext\n",
+		},
 	}
 
 	for _, test := range tests {
@@ -127,6 +155,67 @@ func TestConsoleOutput(t *testing.T) {
 	}
 }
 
+func TestFormatEncoder(t *testing.T) {
+	ts := time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.UTC)
+	e := &beat.Event{
+		Timestamp: ts,
+		Fields: mapstr.M{
+			"event":   mapstr.M{"created": ts.Add(time.Second)},
+			"counter": uint64(1<<63 + 1),
+		},
+	}
+
+	t.Run("timestamps", func(t *testing.T) {
+		line, err := newFormatEncoder("1.2.3", Config{Flatten: true}).Encode("test", e)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{
+			"@timestamp": "2024-05-06T07:08:09.123Z",
+			"@metadata.beat": "test",
+			"@metadata.type": "_doc",
+			"@metadata.version": "1.2.3",
+			"event.created": "2024-05-06T07:08:10.123Z",
+			"counter": 9223372036854775809
+		}`, string(line))
+	})
+
+	t.Run("sort_keys", func(t *testing.T) {
+		line, err := newFormatEncoder("1.2.3", Config{SortKeys: true, Fields: []string{"event", "counter"}}).Encode("test", e)
+		require.NoError(t, err)
+		assert.Equal(t, `{"counter":9223372036854775809,"event":{"created":"2024-05-06T07:08:10.123Z"}}`, string(line))
+	})
+
+	t.Run("message_only without message", func(t *testing.T) {
+		line, err := newFormatEncoder("1.2.3", Config{MessageOnly: true}).Encode("test", e)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{
+			"@timestamp": "2024-05-06T07:08:09.123Z",
+			"@metadata": {"beat": "test", "type": "_doc", "version": "1.2.3"},
+			"event": {"created": "2024-05-06T07:08:10.123Z"},
+			"counter": 9223372036854775809
+		}`, string(line))
+	})
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := map[string]struct {
+		config map[string]interface{}
+		valid  bool
+	}{
+		"defaults":                {config: map[string]interface{}{}, valid: true},
+		"flatten and fields":      {config: map[string]interface{}{"flatten": true, "fields": []string{"message"}}, valid: true},
+		"message only":            {config: map[string]interface{}{"message_only": true}, valid: true},
+		"message only and pretty": {config: map[string]interface{}{"message_only": true, "pretty": true}},
+		"sort keys with codec":    {config: map[string]interface{}{"sort_keys": true, "codec.json.pretty": true}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := defaultConfig
+			err := config.MustNewConfigFrom(test.config).Unpack(&c)
+			assert.Equal(t, test.valid, err == nil, err)
+		})
+	}
+}
+
 func run(codec codec.Codec, batches ...publisher.Batch) (string, error) {
 	return withStdout(func() {
 		c, _ := newConsole("test", outputs.NewNilObserver(), codec)
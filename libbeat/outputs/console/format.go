@@ -0,0 +1,165 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package console
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/outputs/codec"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/go-structform/gotype"
+	structjson "github.com/elastic/go-structform/json"
+)
+
+// formatEncoder encodes the events for tools reading the console output,
+// like jq. The fields can be selected and flattened, and the JSON keys
+// sorted. Values are encoded like by the json codec, with timestamps in
+// UTC with millisecond precision. In message only mode the message of the
+// events is written as is, one per line.
+type formatEncoder struct {
+	version     string
+	pretty      bool
+	sortKeys    bool
+	flatten     bool
+	fields      []string
+	messageOnly bool
+
+	buf    bytes.Buffer
+	folder *gotype.Iterator
+}
+
+// messageEscaper keeps the messages on a single line.
+var messageEscaper = strings.NewReplacer("\r", `\r`, "\n", `\n`)
+
+func newFormatEncoder(version string, config Config) *formatEncoder {
+	e := &formatEncoder{
+		version:     version,
+		pretty:      config.Pretty,
+		sortKeys:    config.SortKeys,
+		flatten:     config.Flatten,
+		fields:      config.Fields,
+		messageOnly: config.MessageOnly,
+	}
+	e.reset()
+	return e
+}
+
+func (e *formatEncoder) reset() {
+	visitor := structjson.NewVisitor(&e.buf)
+	visitor.SetEscapeHTML(false)
+	visitor.SetIgnoreInvalidFloat(true)
+
+	var err error
+	e.folder, err = gotype.NewIterator(visitor,
+		gotype.Folders(
+			codec.MakeTimestampEncoder(),
+			codec.MakeBCTimestampEncoder(),
+		),
+	)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (e *formatEncoder) Encode(index string, event *beat.Event) ([]byte, error) {
+	if e.messageOnly {
+		// Events without a message are written in full, not to lose them.
+		if msg, err := event.GetValue("message"); err == nil {
+			if s, ok := msg.(string); ok {
+				return []byte(messageEscaper.Replace(s)), nil
+			}
+		}
+	}
+
+	e.buf.Reset()
+	if err := e.folder.Fold(e.document(index, event)); err != nil {
+		e.reset()
+		return nil, err
+	}
+	doc := e.buf.Bytes()
+
+	if e.sortKeys {
+		// Maps are encoded with their keys sorted, numbers are kept
+		// as they are.
+		dec := json.NewDecoder(bytes.NewReader(doc))
+		dec.UseNumber()
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if e.pretty {
+			enc.SetIndent("", "  ")
+		}
+		if err := enc.Encode(v); err != nil {
+			return nil, err
+		}
+		return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+	}
+
+	if !e.pretty {
+		return doc, nil
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, doc, "", "  "); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// document returns the fields of the event written, with the same
+// @timestamp and @metadata fields as the json codec.
+func (e *formatEncoder) document(index string, event *beat.Event) mapstr.M {
+	meta := mapstr.M{
+		"beat":    index,
+		"type":    "_doc",
+		"version": e.version,
+	}
+	for k, v := range event.Meta {
+		meta[k] = v
+	}
+
+	doc := event.Fields.Clone()
+	if doc == nil {
+		doc = mapstr.M{}
+	}
+	doc[beat.TimestampFieldKey] = event.Timestamp
+	doc[beat.MetadataFieldKey] = meta
+
+	if len(e.fields) > 0 {
+		selected := mapstr.M{}
+		for _, name := range e.fields {
+			v, err := doc.GetValue(name)
+			if err != nil {
+				continue
+			}
+			_, _ = selected.Put(name, v)
+		}
+		doc = selected
+	}
+
+	if e.flatten {
+		return doc.Flatten()
+	}
+	return doc
+}
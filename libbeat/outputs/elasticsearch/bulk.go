@@ -19,11 +19,33 @@ package elasticsearch
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
+	"net/http"
 
 	"github.com/elastic/elastic-agent-libs/logp"
 )
 
+// Categories of the bulk item failures reported in the data stream metrics.
+const (
+	bulkErrMappingConflict   = "mapping_conflict"
+	bulkErrRejectedExecution = "es_rejected_execution"
+	bulkErrIndexClosed       = "index_closed"
+	bulkErrSecurity          = "security"
+	bulkErrOther             = "other"
+)
+
+// bulkErrorTypes maps the Elasticsearch error types to their category.
+var bulkErrorTypes = map[string]string{
+	"mapper_parsing_exception":         bulkErrMappingConflict,
+	"document_parsing_exception":       bulkErrMappingConflict,
+	"strict_dynamic_mapping_exception": bulkErrMappingConflict,
+	"mapper_exception":                 bulkErrMappingConflict,
+	"es_rejected_execution_exception":  bulkErrRejectedExecution,
+	"index_closed_exception":           bulkErrIndexClosed,
+	"security_exception":               bulkErrSecurity,
+}
+
 var (
 	errExpectedItemsArray    = errors.New("expected items array")
 	errExpectedItemObject    = errors.New("expected item response object")
@@ -153,3 +175,29 @@ func itemStatusInner(reader *jsonReader, logger *logp.Logger) (int, []byte, erro
 	}
 	return status, msg, nil
 }
+
+// bulkErrorCategory returns the category of a bulk item failure from the
+// type of its error, falling back to the item status if it has no error.
+// Conflicts are not failures, they are counted as duplicates.
+func bulkErrorCategory(status int, msg []byte) string {
+	var itemErr struct {
+		Type string `json:"type"`
+	}
+	if len(msg) > 0 && json.Unmarshal(msg, &itemErr) == nil {
+		if category, ok := bulkErrorTypes[itemErr.Type]; ok {
+			return category
+		}
+		if itemErr.Type != "" {
+			return bulkErrOther
+		}
+	}
+
+	switch status {
+	case http.StatusTooManyRequests:
+		return bulkErrRejectedExecution
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return bulkErrSecurity
+	default:
+		return bulkErrOther
+	}
+}
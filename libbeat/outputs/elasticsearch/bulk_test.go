@@ -129,3 +129,25 @@ func readStatusItem(in []byte) (int, string, error) {
 	code, msg, err := bulkReadItemStatus(logp.L(), reader)
 	return code, string(msg), err
 }
+
+func TestBulkErrorCategory(t *testing.T) {
+	tests := []struct {
+		status   int
+		msg      string
+		expected string
+	}{
+		{400, `{"type":"mapper_parsing_exception","reason":"failed to parse field [bar]"}`, bulkErrMappingConflict},
+		{400, `{"type":"document_parsing_exception","reason":"failed to parse"}`, bulkErrMappingConflict},
+		{429, `{"type":"es_rejected_execution_exception","reason":"rejected execution"}`, bulkErrRejectedExecution},
+		{400, `{"type":"index_closed_exception","reason":"closed"}`, bulkErrIndexClosed},
+		{403, `{"type":"security_exception","reason":"action unauthorized"}`, bulkErrSecurity},
+		{400, `{"type":"illegal_argument_exception","reason":"bad"}`, bulkErrOther},
+		{429, `"ups"`, bulkErrRejectedExecution},
+		{401, ``, bulkErrSecurity},
+		{503, ``, bulkErrOther},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, bulkErrorCategory(tt.status, []byte(tt.msg)), "status %d: %s", tt.status, tt.msg)
+	}
+}
@@ -88,6 +88,17 @@ type bulkResultStats struct {
 	nonIndexable int // number of events with permanent failures.
 	deadLetter   int // number of failed events ingested to the dead letter index.
 	tooMany      int // number of events receiving HTTP 429 Too Many Requests
+
+	// acked events, bytes, duplicates and failures per target index/data
+	// stream.
+	dataStreams map[string]*dataStreamStats
+}
+
+type dataStreamStats struct {
+	events     int
+	bytes      int
+	duplicates int
+	errors     map[string]int // failed items by error category
 }
 
 type bulkResult struct {
@@ -217,10 +228,13 @@ func (client *Client) Clone() *Client {
 		Parameters:        nil, // XXX: do not pass params?
 		Headers:           client.conn.Headers,
 		CompressionLevel:  client.conn.CompressionLevel,
+		Compression:       client.conn.Compression,
 		OnConnectCallback: nil,
 		Observer:          nil,
 		EscapeHTML:        false,
 		Transport:         client.conn.Transport,
+		HTTP2:             client.conn.HTTP2,
+		ClientPool:        client.conn.ClientPool,
 	}
 
 	// Without the following nil check on proxyURL, a nil Proxy field will try
@@ -349,6 +363,7 @@ func (client *Client) bulkEncodePublishRequest(version version.V, data []publish
 	for i := range data {
 		if data[i].EncodedEvent == nil {
 			client.log.Error("Elasticsearch output received unencoded publisher.Event")
+			data[i].Reject(fmt.Errorf("%w: event was not encoded", publisher.ErrEventRejected))
 			continue
 		}
 		event := data[i].EncodedEvent.(*encodedEvent)
@@ -356,11 +371,13 @@ func (client *Client) bulkEncodePublishRequest(version version.V, data []publish
 			// This means there was an error when encoding the event and it isn't
 			// ingestable, so report the error and continue.
 			client.log.Error(event.err)
+			data[i].Reject(fmt.Errorf("%w: %w", publisher.ErrEventRejected, event.err))
 			continue
 		}
 		meta, err := client.createEventBulkMeta(version, event)
 		if err != nil {
 			client.log.Errorf("Failed to encode event meta data: %+v", err)
+			data[i].Reject(fmt.Errorf("%w: failed to encode event meta data: %w", publisher.ErrEventRejected, err))
 			continue
 		}
 		if event.opType == events.OpTypeDelete {
@@ -484,6 +501,7 @@ func (client *Client) applyItemStatus(
 			stats.deadLetter++
 		} else {
 			stats.acked++
+			stats.addDataStreamEvent(encodedEvent.index, len(encodedEvent.encoding))
 		}
 		return false // no retry needed
 	}
@@ -492,9 +510,12 @@ func (client *Client) applyItemStatus(
 		// 409 is used to indicate there is already an event with the same ID, or
 		// with identical Time Series Data Stream dimensions when TSDS is active.
 		stats.duplicates++
+		stats.dataStream(encodedEvent.index).duplicates++
 		return false // no retry needed
 	}
 
+	stats.addDataStreamError(encodedEvent.index, bulkErrorCategory(itemStatus, itemMessage))
+
 	if itemStatus == http.StatusTooManyRequests {
 		stats.fails++
 		stats.tooMany++
@@ -509,6 +530,7 @@ func (client *Client) applyItemStatus(
 			client.pLogDeadLetter.Add()
 			client.log.Errorw(fmt.Sprintf("Can't deliver to dead letter index event '%s' (status=%v): %s", encodedEvent, itemStatus, itemMessage), logp.TypeKey, logp.EventType)
 			stats.nonIndexable++
+			event.Reject(fmt.Errorf("%w: cannot index event (status=%v): %s", publisher.ErrEventRejected, itemStatus, itemMessage))
 			return false
 		}
 		if client.deadLetterIndex == "" {
@@ -516,6 +538,7 @@ func (client *Client) applyItemStatus(
 			client.pLogIndex.Add()
 			client.log.Warnw(fmt.Sprintf("Cannot index event '%s' (status=%v): %s, dropping event!", encodedEvent, itemStatus, itemMessage), logp.TypeKey, logp.EventType)
 			stats.nonIndexable++
+			event.Reject(fmt.Errorf("%w: cannot index event (status=%v): %s", publisher.ErrEventRejected, itemStatus, itemMessage))
 			return false
 		}
 		// Send this failure to the dead letter index and "retry".
@@ -556,4 +579,42 @@ func (stats bulkResultStats) reportToObserver(ob outputs.Observer) {
 	ob.DeadLetterEvents(stats.deadLetter)
 
 	ob.ErrTooMany(stats.tooMany)
+
+	for name, ds := range stats.dataStreams {
+		if ds.events > 0 {
+			ob.DataStreamEvents(name, ds.events, ds.bytes)
+		}
+		if ds.duplicates > 0 {
+			ob.DataStreamDuplicates(name, ds.duplicates)
+		}
+		for category, n := range ds.errors {
+			ob.DataStreamErrors(name, category, n)
+		}
+	}
+}
+
+func (stats *bulkResultStats) dataStream(index string) *dataStreamStats {
+	if stats.dataStreams == nil {
+		stats.dataStreams = map[string]*dataStreamStats{}
+	}
+	ds, ok := stats.dataStreams[index]
+	if !ok {
+		ds = &dataStreamStats{}
+		stats.dataStreams[index] = ds
+	}
+	return ds
+}
+
+func (stats *bulkResultStats) addDataStreamEvent(index string, bytes int) {
+	ds := stats.dataStream(index)
+	ds.events++
+	ds.bytes += bytes
+}
+
+func (stats *bulkResultStats) addDataStreamError(index string, category string) {
+	ds := stats.dataStream(index)
+	if ds.errors == nil {
+		ds.errors = map[string]int{}
+	}
+	ds.errors[category]++
 }
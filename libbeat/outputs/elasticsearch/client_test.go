@@ -344,7 +344,7 @@ func TestCollectPublishFailMiddle(t *testing.T) {
 	if len(res) == 1 {
 		assert.Equal(t, eventFail, res[0])
 	}
-	assert.Equal(t, bulkResultStats{acked: 2, fails: 1, tooMany: 1}, stats)
+	assert.Equal(t, bulkResultStats{acked: 2, fails: 1, tooMany: 1, dataStreams: failedDataStream(ackedDataStreams(event1, event2), "", bulkErrRejectedExecution, 1)}, stats)
 }
 
 func TestCollectPublishFailDeadLetterSuccess(t *testing.T) {
@@ -405,10 +405,42 @@ func TestCollectPublishFailFatalErrorNotRetried(t *testing.T) {
 		status:   200,
 		response: response,
 	})
-	assert.Equal(t, bulkResultStats{acked: 0, nonIndexable: 1}, stats)
+	assert.Equal(t, bulkResultStats{acked: 0, nonIndexable: 1, dataStreams: failedDataStream(nil, deadLetterIndex, bulkErrOther, 1)}, stats)
 	assert.Equal(t, 0, len(res))
 }
 
+func TestCollectPublishFailRejectsDroppedEvents(t *testing.T) {
+	client, err := NewClient(clientSettings{observer: outputs.NewNilObserver()}, nil)
+	assert.NoError(t, err)
+
+	var rejected []error
+	onReject := func(err error) { rejected = append(rejected, err) }
+	response := []byte(`
+    { "items": [
+      {"create": {"status": 200}},
+      {"create": {"status": 400, "error": "mapper_parsing_exception"}},
+      {"create": {"status": 429, "error": "ups"}}
+    ]}
+  `)
+
+	event1 := encodeEvent(client, publisher.Event{Content: beat.Event{Fields: mapstr.M{"bar": 1}}, OnReject: onReject})
+	event2 := encodeEvent(client, publisher.Event{Content: beat.Event{Fields: mapstr.M{"bar": 2}}, OnReject: onReject})
+	event3 := encodeEvent(client, publisher.Event{Content: beat.Event{Fields: mapstr.M{"bar": 3}}, OnReject: onReject})
+
+	res, stats := client.bulkCollectPublishFails(bulkResult{
+		events:   []publisher.Event{event1, event2, event3},
+		status:   200,
+		response: response,
+	})
+	assert.Equal(t, 1, stats.acked)
+	assert.Equal(t, 1, stats.nonIndexable)
+	assert.Equal(t, 1, stats.fails)
+	assert.Len(t, res, 1)
+	require.Len(t, rejected, 1, "only the non-indexable event must be rejected")
+	assert.ErrorIs(t, rejected[0], publisher.ErrEventRejected)
+	assert.ErrorContains(t, rejected[0], "status=400")
+}
+
 func TestCollectPublishFailInvalidBulkIndexResponse(t *testing.T) {
 	client, err := NewClient(
 		clientSettings{observer: outputs.NewNilObserver()},
@@ -474,7 +506,7 @@ func TestCollectPublishFailDeadLetterIndex(t *testing.T) {
 		status:   200,
 		response: response,
 	})
-	assert.Equal(t, bulkResultStats{acked: 2, fails: 1, nonIndexable: 0}, stats)
+	assert.Equal(t, bulkResultStats{acked: 2, fails: 1, nonIndexable: 0, dataStreams: failedDataStream(ackedDataStreams(event1, event2), "", bulkErrOther, 1)}, stats)
 	assert.Equal(t, 1, len(res))
 	if len(res) == 1 {
 		assert.Equalf(t, eventFail, res[0], "bulkCollectPublishFails should return failed event")
@@ -531,7 +563,87 @@ func TestCollectPublishFailDrop(t *testing.T) {
 		response: response,
 	})
 	assert.Equal(t, 0, len(res))
-	assert.Equal(t, bulkResultStats{acked: 2, fails: 0, nonIndexable: 1}, stats)
+	assert.Equal(t, bulkResultStats{acked: 2, fails: 0, nonIndexable: 1, dataStreams: failedDataStream(ackedDataStreams(events[0], events[2]), "", bulkErrMappingConflict, 1)}, stats)
+}
+
+func TestCollectPublishDataStreams(t *testing.T) {
+	reg := monitoring.NewRegistry()
+	client, err := NewClient(
+		clientSettings{
+			observer: outputs.NewStats(reg),
+		},
+		nil,
+	)
+	assert.NoError(t, err)
+
+	response := []byte(`
+    { "items": [
+      {"create": {"status": 200}},
+      {"create": {"status": 201}},
+      {"create": {"status": 200}},
+      {"create": {"status": 429, "error": "ups"}},
+      {"create": {"status": 409, "error": {"type": "version_conflict_engine_exception"}}}
+    ]}
+  `)
+
+	withIndex := func(index string, e publisher.Event) publisher.Event {
+		e = encodeEvent(client, e)
+		e.EncodedEvent.(*encodedEvent).index = index
+		return e
+	}
+	logsA1 := withIndex("logs-a.b-default", publisher.Event{Content: beat.Event{Fields: mapstr.M{"field": 1}}})
+	logsA2 := withIndex("logs-a.b-default", publisher.Event{Content: beat.Event{Fields: mapstr.M{"field": 2}}})
+	metricsB := withIndex("metrics-b-default", publisher.Event{Content: beat.Event{Fields: mapstr.M{"field": 3}}})
+	eventFail := withIndex("metrics-b-default", publisher.Event{Content: beat.Event{Fields: mapstr.M{"field": 4}}})
+	duplicate := withIndex("logs-a.b-default", publisher.Event{Content: beat.Event{Fields: mapstr.M{"field": 5}}})
+	events := []publisher.Event{logsA1, logsA2, metricsB, eventFail, duplicate}
+
+	_, stats := client.bulkCollectPublishFails(bulkResult{
+		events:   events,
+		status:   200,
+		response: response,
+	})
+	stats.reportToObserver(client.observer)
+
+	size := func(e publisher.Event) int64 {
+		return int64(len(e.EncodedEvent.(*encodedEvent).encoding))
+	}
+	snapshot := monitoring.CollectStructSnapshot(reg, monitoring.Full, false)
+	assert.Equal(t, map[string]interface{}{
+		"logs-a.b-default": map[string]interface{}{
+			"events":     int64(2),
+			"bytes":      size(logsA1) + size(logsA2),
+			"duplicates": int64(1),
+		},
+		"metrics-b-default": map[string]interface{}{
+			"events": int64(1),
+			"bytes":  size(metricsB),
+			"errors": map[string]interface{}{
+				"es_rejected_execution": int64(1),
+			},
+		},
+	}, snapshot["data_streams"])
+}
+
+// ackedDataStreams returns the per data stream stats expected for the
+// given successfully ingested events.
+func ackedDataStreams(events ...publisher.Event) map[string]*dataStreamStats {
+	stats := bulkResultStats{}
+	for _, e := range events {
+		encoded := e.EncodedEvent.(*encodedEvent)
+		stats.addDataStreamEvent(encoded.index, len(encoded.encoding))
+	}
+	return stats.dataStreams
+}
+
+// failedDataStream adds n failures of the error category for the index to
+// the expected per data stream stats.
+func failedDataStream(streams map[string]*dataStreamStats, index, category string, n int) map[string]*dataStreamStats {
+	stats := bulkResultStats{dataStreams: streams}
+	for i := 0; i < n; i++ {
+		stats.addDataStreamError(index, category)
+	}
+	return stats.dataStreams
 }
 
 func TestCollectPublishFailAll(t *testing.T) {
@@ -561,7 +673,7 @@ func TestCollectPublishFailAll(t *testing.T) {
 	})
 	assert.Equal(t, 3, len(res))
 	assert.Equal(t, events, res)
-	assert.Equal(t, stats, bulkResultStats{fails: 3, tooMany: 3})
+	assert.Equal(t, stats, bulkResultStats{fails: 3, tooMany: 3, dataStreams: failedDataStream(nil, "", bulkErrRejectedExecution, 3)})
 }
 
 func TestCollectPipelinePublishFail(t *testing.T) {
@@ -36,7 +36,9 @@ type elasticsearchConfig struct {
 	APIKey             string            `config:"api_key"`
 	LoadBalance        bool              `config:"loadbalance"`
 	CompressionLevel   int               `config:"compression_level" validate:"min=0, max=9"`
+	Compression        string            `config:"compression"`
 	EscapeHTML         bool              `config:"escape_html"`
+	HTTP2              bool              `config:"http2"`
 	Kerberos           *kerberos.Config  `config:"kerberos"`
 	BulkMaxSize        int               `config:"bulk_max_size"`
 	MaxRetries         int               `config:"max_retries"`
@@ -45,6 +47,10 @@ type elasticsearchConfig struct {
 	AllowOlderVersion  bool              `config:"allow_older_versions"`
 	Queue              config.Namespace  `config:"queue"`
 
+	// IdempotencyKeys sets the document ID of the events that have none from
+	// the position of the event in its source.
+	IdempotencyKeys bool `config:"idempotency_keys"`
+
 	Transport httpcommon.HTTPTransportSettings `config:",inline"`
 }
 
@@ -67,6 +73,7 @@ var (
 		APIKey:           "",
 		MaxRetries:       3,
 		CompressionLevel: 1,
+		Compression:      "gzip",
 		EscapeHTML:       false,
 		Kerberos:         nil,
 		LoadBalance:      true,
@@ -90,6 +97,9 @@ func (c *elasticsearchConfig) Validate() error {
 	if c.APIKey != "" && (c.Username != "" || c.Password != "") {
 		return fmt.Errorf("cannot set both api_key and username/password")
 	}
+	if c.Compression != "" && c.Compression != "gzip" && c.Compression != "zstd" {
+		return fmt.Errorf("compression must be gzip or zstd, got %q", c.Compression)
+	}
 
 	return nil
 }
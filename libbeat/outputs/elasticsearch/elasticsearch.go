@@ -94,8 +94,19 @@ func makeES(
 		params = nil
 	}
 
+	var idempotencyBeatID string
+	if esConfig.IdempotencyKeys {
+		idempotencyBeatID = beatInfo.ID.String()
+	}
 	encoderFactory := newEventEncoderFactory(
-		esConfig.EscapeHTML, indexSelector, pipelineSelector)
+		esConfig.EscapeHTML, indexSelector, pipelineSelector, idempotencyBeatID)
+
+	// With HTTP/2 all workers of a host multiplex their requests over a
+	// shared connection.
+	var clientPool *eslegclient.HTTPClientPool
+	if esConfig.HTTP2 {
+		clientPool = eslegclient.NewHTTPClientPool()
+	}
 
 	clients := make([]outputs.NetworkClient, len(hosts))
 	for i, host := range hosts {
@@ -117,8 +128,11 @@ func makeES(
 				Parameters:       params,
 				Headers:          esConfig.Headers,
 				CompressionLevel: esConfig.CompressionLevel,
+				Compression:      esConfig.Compression,
 				Observer:         observer,
 				EscapeHTML:       esConfig.EscapeHTML,
+				HTTP2:            esConfig.HTTP2,
+				ClientPool:       clientPool,
 				Transport:        esConfig.Transport,
 				IdleConnTimeout:  esConfig.Transport.IdleConnTimeout,
 				UserAgent:        beatInfo.UserAgent,
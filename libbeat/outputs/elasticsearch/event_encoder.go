@@ -19,6 +19,7 @@ package elasticsearch
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"time"
 
@@ -33,10 +34,17 @@ import (
 )
 
 type eventEncoder struct {
+	// writer serializes the events, enc is only used for the events
+	// containing values the writer doesn't support.
+	writer           *eventWriter
 	buf              *bytes.Buffer
 	enc              eslegclient.BodyEncoder
 	pipelineSelector *outil.Selector
 	indexSelector    outputs.IndexSelector
+
+	// idempotencyBeatID is the ID of the Beat used to derive the document
+	// IDs from the idempotency keys of the events, empty if disabled.
+	idempotencyBeatID string
 }
 
 type encodedEvent struct {
@@ -66,23 +74,27 @@ func newEventEncoderFactory(
 	escapeHTML bool,
 	indexSelector outputs.IndexSelector,
 	pipelineSelector *outil.Selector,
+	idempotencyBeatID string,
 ) queue.EncoderFactory {
 	return func() queue.Encoder {
-		return newEventEncoder(escapeHTML, indexSelector, pipelineSelector)
+		return newEventEncoder(escapeHTML, indexSelector, pipelineSelector, idempotencyBeatID)
 	}
 }
 
 func newEventEncoder(escapeHTML bool,
 	indexSelector outputs.IndexSelector,
 	pipelineSelector *outil.Selector,
+	idempotencyBeatID string,
 ) queue.Encoder {
 	buf := bytes.NewBuffer(nil)
 	enc := eslegclient.NewJSONEncoder(buf, escapeHTML)
 	return &eventEncoder{
-		buf:              buf,
-		enc:              enc,
-		pipelineSelector: pipelineSelector,
-		indexSelector:    indexSelector,
+		writer:            newEventWriter(escapeHTML),
+		buf:               buf,
+		enc:               enc,
+		pipelineSelector:  pipelineSelector,
+		indexSelector:     indexSelector,
+		idempotencyBeatID: idempotencyBeatID,
 	}
 }
 
@@ -120,12 +132,18 @@ func (pe *eventEncoder) encodeRawEvent(e *beat.Event) *encodedEvent {
 	}
 
 	id, _ := events.GetMetaStringValue(*e, events.FieldMetaID)
+	if id == "" && pe.idempotencyBeatID != "" {
+		id = outputs.IdempotencyKey(pe.idempotencyBeatID, e)
+	}
 
-	err = pe.enc.Marshal(e)
+	bufBytes, err := pe.writer.write(e)
+	if errors.Is(err, errUnsupportedValue) {
+		err = pe.enc.Marshal(e)
+		bufBytes = pe.buf.Bytes()
+	}
 	if err != nil {
 		return &encodedEvent{err: fmt.Errorf("failed to encode event for output: %w", err)}
 	}
-	bufBytes := pe.buf.Bytes()
 	bytes := make([]byte, len(bufBytes))
 	copy(bytes, bufBytes)
 	return &encodedEvent{
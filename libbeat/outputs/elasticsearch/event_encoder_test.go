@@ -27,6 +27,7 @@ import (
 
 	"github.com/elastic/beats/v7/libbeat/beat"
 	"github.com/elastic/beats/v7/libbeat/beat/events"
+	"github.com/elastic/beats/v7/libbeat/outputs"
 	"github.com/elastic/beats/v7/libbeat/publisher"
 	"github.com/elastic/elastic-agent-libs/mapstr"
 )
@@ -40,7 +41,7 @@ func (testIndexSelector) Select(event *beat.Event) (string, error) {
 func TestEncodeEntry(t *testing.T) {
 	indexSelector := testIndexSelector{}
 
-	encoder := newEventEncoder(true, indexSelector, nil)
+	encoder := newEventEncoder(true, indexSelector, nil, "")
 
 	timestamp := time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
 	pubEvent := publisher.Event{
@@ -99,6 +100,40 @@ func TestEncodeEntry(t *testing.T) {
 	assert.Equal(t, "nested_value", eventContent.Nested.NestedField, "Encoded field should match original")
 }
 
+type testIdempotencyKeyer string
+
+func (k testIdempotencyKeyer) IdempotencyKey() string { return string(k) }
+
+func TestEncodeEntryIdempotencyKeys(t *testing.T) {
+	encoder := newEventEncoder(true, testIndexSelector{}, nil, "beat-id")
+	encode := func(e beat.Event) *encodedEvent {
+		encoded, _ := encoder.EncodeEntry(publisher.Event{Content: e})
+		return encoded.(publisher.Event).EncodedEvent.(*encodedEvent)
+	}
+
+	keyer := testIdempotencyKeyer("filestream::my-input::native::1-2::1024")
+	expected := outputs.IdempotencyKey("beat-id", &beat.Event{Fields: mapstr.M{"message": "a"}, Private: keyer})
+	require.NotEmpty(t, expected)
+
+	enc := encode(beat.Event{Fields: mapstr.M{"message": "a"}, Private: keyer})
+	assert.Equal(t, expected, enc.id, "id should be derived from the idempotency key")
+	assert.Equal(t, events.OpTypeDefault, enc.opType)
+
+	enc = encode(beat.Event{
+		Fields:  mapstr.M{"message": "a"},
+		Meta:    mapstr.M{events.FieldMetaID: "test_id"},
+		Private: keyer,
+	})
+	assert.Equal(t, "test_id", enc.id, "explicit ids take precedence")
+
+	enc = encode(beat.Event{Fields: mapstr.M{"message": "a"}})
+	assert.Empty(t, enc.id, "events without idempotency key have no id")
+
+	enc = newEventEncoder(true, testIndexSelector{}, nil, "").(*eventEncoder).encodeRawEvent(
+		&beat.Event{Fields: mapstr.M{"message": "a"}, Private: keyer})
+	assert.Empty(t, enc.id, "idempotency keys are disabled")
+}
+
 // encodeBatch encodes a publisher.Batch so it can be provided to
 // Client.Publish and other helpers.
 // This modifies the batch in place, but also returns its input batch
@@ -119,6 +154,7 @@ func encodeEvents(client *Client, events []publisher.Event) []publisher.Event {
 		client.conn.EscapeHTML,
 		client.indexSelector,
 		client.pipelineSelector,
+		"",
 	)
 	for i := range events {
 		// Skip encoding if there's already encoded data present
@@ -136,6 +172,7 @@ func encodeEvent(client *Client, event publisher.Event) publisher.Event {
 		client.conn.EscapeHTML,
 		client.indexSelector,
 		client.pipelineSelector,
+		"",
 	)
 	encoded, _ := encoder.EncodeEntry(event)
 	return encoded.(publisher.Event)
@@ -0,0 +1,328 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package elasticsearch
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/common/dtfmt"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// maxCachedKeys bounds the number of escaped field names kept by an
+// eventWriter, so that events with unbounded key sets (e.g. keys derived
+// from user input) cannot grow the cache forever.
+const maxCachedKeys = 4096
+
+// errUnsupportedValue is returned by the eventWriter when an event contains
+// a value it can't serialize directly. The event is then encoded with the
+// generic reflection based encoder instead.
+var errUnsupportedValue = errors.New("unsupported value type")
+
+const hexDigits = "0123456789abcdef"
+
+var (
+	jsonEscapes [utf8.RuneSelf]bool
+	htmlEscapes [utf8.RuneSelf]bool
+)
+
+func init() {
+	for i := 0; i < 0x20; i++ {
+		jsonEscapes[i] = true
+		htmlEscapes[i] = true
+	}
+	for _, c := range "\"\\" {
+		jsonEscapes[c] = true
+		htmlEscapes[c] = true
+	}
+	for _, c := range "&<>" {
+		htmlEscapes[c] = true
+	}
+}
+
+// eventWriter serializes events to JSON by walking their fields directly
+// into a reused buffer. It handles the value types produced by inputs and
+// processors, avoiding the reflection and intermediate allocations of the
+// generic encoder. The output is the same as the one of
+// eslegclient.NewJSONEncoder.
+//
+// An eventWriter is not safe for concurrent use.
+type eventWriter struct {
+	buf     []byte
+	escapes *[utf8.RuneSelf]bool
+
+	// keys caches the quoted and escaped field names followed by a colon.
+	keys map[string][]byte
+
+	timestamp *dtfmt.Formatter
+}
+
+func newEventWriter(escapeHTML bool) *eventWriter {
+	formatter, err := dtfmt.NewFormatter(common.TimestampFormat(false))
+	if err != nil {
+		panic(err)
+	}
+	w := &eventWriter{
+		escapes:   &jsonEscapes,
+		keys:      make(map[string][]byte),
+		timestamp: formatter,
+	}
+	if escapeHTML {
+		w.escapes = &htmlEscapes
+	}
+	return w
+}
+
+// write serializes the event followed by a newline. The returned slice is
+// only valid until the next call.
+func (w *eventWriter) write(e *beat.Event) ([]byte, error) {
+	w.buf = append(w.buf[:0], `{"@timestamp":`...)
+	w.appendTime(e.Timestamp)
+	for k, v := range e.Fields {
+		w.buf = append(w.buf, ',')
+		if err := w.appendField(k, v); err != nil {
+			return nil, err
+		}
+	}
+	w.buf = append(w.buf, '}', '\n')
+	return w.buf, nil
+}
+
+func (w *eventWriter) appendField(k string, v interface{}) error {
+	key, ok := w.keys[k]
+	if !ok {
+		start := len(w.buf)
+		w.appendString(k)
+		w.buf = append(w.buf, ':')
+		if len(w.keys) < maxCachedKeys {
+			w.keys[k] = append([]byte(nil), w.buf[start:]...)
+		}
+	} else {
+		w.buf = append(w.buf, key...)
+	}
+	return w.appendValue(v)
+}
+
+//nolint:gocyclo // A flat type switch is the fastest way to dispatch values.
+func (w *eventWriter) appendValue(v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		w.buf = append(w.buf, "null"...)
+	case string:
+		w.appendString(v)
+	case bool:
+		w.buf = strconv.AppendBool(w.buf, v)
+	case int:
+		w.buf = strconv.AppendInt(w.buf, int64(v), 10)
+	case int8:
+		w.buf = strconv.AppendInt(w.buf, int64(v), 10)
+	case int16:
+		w.buf = strconv.AppendInt(w.buf, int64(v), 10)
+	case int32:
+		w.buf = strconv.AppendInt(w.buf, int64(v), 10)
+	case int64:
+		w.buf = strconv.AppendInt(w.buf, v, 10)
+	case uint:
+		w.buf = strconv.AppendUint(w.buf, uint64(v), 10)
+	case uint8:
+		w.buf = strconv.AppendUint(w.buf, uint64(v), 10)
+	case uint16:
+		w.buf = strconv.AppendUint(w.buf, uint64(v), 10)
+	case uint32:
+		w.buf = strconv.AppendUint(w.buf, uint64(v), 10)
+	case uint64:
+		w.buf = strconv.AppendUint(w.buf, v, 10)
+	case float32:
+		return w.appendFloat(float64(v), 32)
+	case float64:
+		return w.appendFloat(v, 64)
+	case time.Time:
+		w.appendTime(v)
+	case common.Time:
+		w.appendTime(time.Time(v))
+	case mapstr.M:
+		return w.appendMap(v)
+	case map[string]interface{}:
+		return w.appendMap(v)
+	case map[string]string:
+		w.buf = append(w.buf, '{')
+		first := true
+		for k, s := range v {
+			if !first {
+				w.buf = append(w.buf, ',')
+			}
+			first = false
+			w.appendString(k)
+			w.buf = append(w.buf, ':')
+			w.appendString(s)
+		}
+		w.buf = append(w.buf, '}')
+	case []interface{}:
+		w.buf = append(w.buf, '[')
+		for i, elem := range v {
+			if i > 0 {
+				w.buf = append(w.buf, ',')
+			}
+			if err := w.appendValue(elem); err != nil {
+				return err
+			}
+		}
+		w.buf = append(w.buf, ']')
+	case []string:
+		w.buf = append(w.buf, '[')
+		for i, s := range v {
+			if i > 0 {
+				w.buf = append(w.buf, ',')
+			}
+			w.appendString(s)
+		}
+		w.buf = append(w.buf, ']')
+	case []mapstr.M:
+		w.buf = append(w.buf, '[')
+		for i, m := range v {
+			if i > 0 {
+				w.buf = append(w.buf, ',')
+			}
+			if err := w.appendMap(m); err != nil {
+				return err
+			}
+		}
+		w.buf = append(w.buf, ']')
+	case []int:
+		w.buf = append(w.buf, '[')
+		for i, n := range v {
+			if i > 0 {
+				w.buf = append(w.buf, ',')
+			}
+			w.buf = strconv.AppendInt(w.buf, int64(n), 10)
+		}
+		w.buf = append(w.buf, ']')
+	case []int64:
+		w.buf = append(w.buf, '[')
+		for i, n := range v {
+			if i > 0 {
+				w.buf = append(w.buf, ',')
+			}
+			w.buf = strconv.AppendInt(w.buf, n, 10)
+		}
+		w.buf = append(w.buf, ']')
+	case []float64:
+		w.buf = append(w.buf, '[')
+		for i, f := range v {
+			if i > 0 {
+				w.buf = append(w.buf, ',')
+			}
+			if err := w.appendFloat(f, 64); err != nil {
+				return err
+			}
+		}
+		w.buf = append(w.buf, ']')
+	default:
+		return errUnsupportedValue
+	}
+	return nil
+}
+
+func (w *eventWriter) appendMap(m map[string]interface{}) error {
+	w.buf = append(w.buf, '{')
+	first := true
+	for k, v := range m {
+		if !first {
+			w.buf = append(w.buf, ',')
+		}
+		first = false
+		if err := w.appendField(k, v); err != nil {
+			return err
+		}
+	}
+	w.buf = append(w.buf, '}')
+	return nil
+}
+
+func (w *eventWriter) appendFloat(f float64, bits int) error {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		// Let the generic encoder report the error.
+		return errUnsupportedValue
+	}
+	w.buf = strconv.AppendFloat(w.buf, f, 'g', -1, bits)
+	return nil
+}
+
+func (w *eventWriter) appendTime(t time.Time) {
+	w.buf = append(w.buf, '"')
+	// The format only contains fixed width numeric fields, so it never
+	// needs escaping.
+	w.buf, _ = w.timestamp.AppendTo(w.buf, t.UTC())
+	w.buf = append(w.buf, '"')
+}
+
+// appendString appends s as a JSON string, escaping it the same way as the
+// go-structform JSON visitor.
+func (w *eventWriter) appendString(s string) {
+	w.buf = append(w.buf, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if !w.escapes[b] {
+				i++
+				continue
+			}
+			w.buf = append(w.buf, s[start:i]...)
+			switch b {
+			case '\\', '"':
+				w.buf = append(w.buf, '\\', b)
+			case '\n':
+				w.buf = append(w.buf, '\\', 'n')
+			case '\r':
+				w.buf = append(w.buf, '\\', 'r')
+			case '\t':
+				w.buf = append(w.buf, '\\', 't')
+			default:
+				w.buf = append(w.buf, '\\', 'u', '0', '0', hexDigits[b>>4], hexDigits[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		c, size := utf8.DecodeRuneInString(s[i:])
+		if c == utf8.RuneError && size == 1 {
+			w.buf = append(w.buf, s[start:i]...)
+			w.buf = append(w.buf, `\ufffd`...)
+			i += size
+			start = i
+			continue
+		}
+		// U+2028 and U+2029 are valid in JSON but not in JavaScript.
+		if c == '\u2028' || c == '\u2029' {
+			w.buf = append(w.buf, s[start:i]...)
+			w.buf = append(w.buf, '\\', 'u', '2', '0', '2', hexDigits[c&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	w.buf = append(w.buf, s[start:]...)
+	w.buf = append(w.buf, '"')
+}
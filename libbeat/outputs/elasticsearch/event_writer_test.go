@@ -0,0 +1,172 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package elasticsearch
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/esleg/eslegclient"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func TestEventWriterMatchesEncoder(t *testing.T) {
+	timestamp := time.Date(2024, time.March, 4, 5, 6, 7, 890000000, time.FixedZone("", 3600))
+	values := map[string]interface{}{
+		"nil":          nil,
+		"string":       "text",
+		"escapes":      "quote \" backslash \\ tab \t newline \n cr \r ctrl \x01 html <a&b> sep \u2028\u2029 invalid \xff",
+		"bool":         true,
+		"int":          -42,
+		"int8":         int8(-8),
+		"int16":        int16(-16),
+		"int32":        int32(-32),
+		"int64":        int64(math.MinInt64),
+		"uint":         uint(42),
+		"uint8":        uint8(8),
+		"uint16":       uint16(16),
+		"uint32":       uint32(32),
+		"uint64":       uint64(math.MaxUint64),
+		"float32":      float32(1.5),
+		"float64":      1e21,
+		"time":         timestamp,
+		"common_time":  common.Time(timestamp),
+		"map":          mapstr.M{"a": mapstr.M{"b": "c"}},
+		"plain_map":    map[string]interface{}{"a": 1},
+		"string_map":   map[string]string{"a": "b"},
+		"list":         []interface{}{"a", 1, mapstr.M{"b": false}},
+		"strings":      []string{"a", "b"},
+		"maps":         []mapstr.M{{"a": 1}, {"b": 2}},
+		"ints":         []int{1, 2},
+		"int64s":       []int64{3, 4},
+		"floats":       []float64{0.5, 2},
+		"empty_map":    mapstr.M{},
+		"empty_list":   []interface{}{},
+		"escaped\"key": 1,
+	}
+
+	for _, escapeHTML := range []bool{false, true} {
+		writer := newEventWriter(escapeHTML)
+		var buf bytes.Buffer
+		enc := eslegclient.NewJSONEncoder(&buf, escapeHTML)
+
+		// Single field events have a deterministic field order, so the
+		// encodings can be compared byte by byte.
+		for name, value := range values {
+			event := &beat.Event{Timestamp: timestamp, Fields: mapstr.M{name: value}}
+			require.NoError(t, enc.Marshal(event))
+			got, err := writer.write(event)
+			require.NoError(t, err, name)
+			assert.Equal(t, buf.String(), string(got), "escapeHTML=%v field=%v", escapeHTML, name)
+		}
+
+		event := &beat.Event{Timestamp: timestamp, Fields: mapstr.M(values)}
+		require.NoError(t, enc.Marshal(event))
+		got, err := writer.write(event)
+		require.NoError(t, err)
+		var want, have interface{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &want))
+		require.NoError(t, json.Unmarshal(got, &have))
+		assert.Equal(t, want, have)
+	}
+}
+
+func TestEventWriterUnsupportedValues(t *testing.T) {
+	writer := newEventWriter(false)
+	for name, value := range map[string]interface{}{
+		"struct":   struct{ A int }{1},
+		"pointer":  new(string),
+		"nan":      math.NaN(),
+		"inf":      []float64{math.Inf(1)},
+		"bytes":    []byte("abc"),
+		"nested":   mapstr.M{"a": []interface{}{struct{}{}}},
+		"int_keys": map[int]string{1: "a"},
+	} {
+		_, err := writer.write(&beat.Event{Fields: mapstr.M{name: value}})
+		assert.ErrorIs(t, err, errUnsupportedValue, name)
+	}
+}
+
+func TestEncodeEntryFallback(t *testing.T) {
+	encoder := newEventEncoder(false, nil, nil, "")
+	event := beat.Event{
+		Timestamp: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+		Fields:    mapstr.M{"value": struct{ A int }{1}},
+	}
+	encoded := encoder.(*eventEncoder).encodeRawEvent(&event)
+	require.NoError(t, encoded.err)
+	assert.JSONEq(t, `{"@timestamp":"2024-01-01T00:00:00.000Z","value":{"a":1}}`, string(encoded.encoding))
+}
+
+func TestEventWriterKeyCacheIsBounded(t *testing.T) {
+	writer := newEventWriter(false)
+	fields := mapstr.M{}
+	for i := 0; i < maxCachedKeys+10; i++ {
+		fields["key"+strconv.Itoa(i)] = i
+	}
+	_, err := writer.write(&beat.Event{Fields: fields})
+	require.NoError(t, err)
+	assert.Len(t, writer.keys, maxCachedKeys)
+}
+
+func BenchmarkEncodeEvent(b *testing.B) {
+	event := &beat.Event{
+		Timestamp: time.Now(),
+		Fields: mapstr.M{
+			"message": "127.0.0.1 - - [04/Mar/2024:05:06:07 +0000] \"GET /index.html HTTP/1.1\" 200 1234",
+			"log": mapstr.M{
+				"file":   mapstr.M{"path": "/var/log/nginx/access.log"},
+				"offset": int64(123456),
+			},
+			"input": mapstr.M{"type": "filestream"},
+			"host":  mapstr.M{"name": "host", "ip": []string{"10.0.0.1", "fe80::1"}},
+			"agent": mapstr.M{"type": "filebeat", "version": "8.16.0"},
+			"ecs":   mapstr.M{"version": "8.0.0"},
+			"tags":  []string{"nginx", "access"},
+		},
+	}
+
+	b.Run("writer", func(b *testing.B) {
+		writer := newEventWriter(false)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := writer.write(event); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("structform", func(b *testing.B) {
+		var buf bytes.Buffer
+		enc := eslegclient.NewJSONEncoder(&buf, false)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := enc.Marshal(event); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
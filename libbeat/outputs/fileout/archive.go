@@ -0,0 +1,317 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package fileout
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/elastic/elastic-agent-libs/file"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+// Extension of the files written by the rotator.
+const rotatedExtension = ".ndjson"
+
+// compressedExtensions maps the compression algorithms to the extension
+// appended to the compressed files.
+var compressedExtensions = map[string]string{
+	compressionGzip: ".gz",
+	compressionZstd: ".zst",
+}
+
+// archiver compresses the files rotated by a file rotator and removes the
+// rotated files beyond the retention limits. The rotator names its files
+// <path>-<date>[-<index>].ndjson and always writes to the newest one.
+type archiver struct {
+	// mu serializes the runs of the archive loop and of a closed path.
+	mu sync.Mutex
+
+	log          *logp.Logger
+	path         string
+	compression  string
+	maxFiles     uint
+	maxAge       time.Duration
+	maxTotalSize int64
+	permissions  os.FileMode
+}
+
+// archivedFile is a rotated file, compressed or not.
+type archivedFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+func newArchiver(log *logp.Logger, path string, c fileOutConfig) *archiver {
+	return &archiver{
+		log:          log,
+		path:         path,
+		compression:  c.Compression,
+		maxFiles:     c.NumberOfFiles,
+		maxAge:       c.MaxAge,
+		maxTotalSize: int64(c.MaxTotalSize),
+		permissions:  os.FileMode(c.Permissions),
+	}
+}
+
+func (a *archiver) compressedExtension() string {
+	return compressedExtensions[a.compression]
+}
+
+// run compresses the rotated files and removes the files beyond the
+// retention limits. The file written by the rotator is left untouched,
+// unless all is set because the rotator is closed.
+func (a *archiver) run(now time.Time, all bool) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	plain, archived, err := a.list()
+	if err != nil {
+		return err
+	}
+	if !all && len(plain) > 0 {
+		plain = plain[:len(plain)-1]
+	}
+
+	var errs []error
+	if a.compressedExtension() == "" {
+		archived = append(archived, plain...)
+	} else {
+		for _, f := range plain {
+			compressed, err := a.compress(f)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to compress %v: %w", f.path, err))
+				continue
+			}
+			archived = append(archived, compressed)
+		}
+	}
+
+	errs = append(errs, a.purge(now, archived))
+	return errors.Join(errs...)
+}
+
+// list returns the uncompressed files of the rotator, oldest first, and
+// its compressed files.
+func (a *archiver) list() (plain, archived []archivedFile, err error) {
+	dir, prefix := filepath.Dir(a.path), filepath.Base(a.path)+"-"
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+
+	type rotated struct {
+		archivedFile
+		date  string
+		index int
+	}
+	var rotatedFiles []rotated
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		base, compressed := strings.CutSuffix(name, compressedExtensions[compressionGzip])
+		if !compressed {
+			base, compressed = strings.CutSuffix(name, compressedExtensions[compressionZstd])
+		}
+		date, index, ok := parseRotatedName(strings.TrimPrefix(base, prefix))
+		if !ok {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		f := archivedFile{path: filepath.Join(dir, name), size: info.Size(), modTime: info.ModTime()}
+		if compressed {
+			archived = append(archived, f)
+		} else {
+			rotatedFiles = append(rotatedFiles, rotated{archivedFile: f, date: date, index: index})
+		}
+	}
+
+	sort.Slice(rotatedFiles, func(i, j int) bool {
+		if rotatedFiles[i].date != rotatedFiles[j].date {
+			return rotatedFiles[i].date < rotatedFiles[j].date
+		}
+		return rotatedFiles[i].index < rotatedFiles[j].index
+	})
+	for _, f := range rotatedFiles {
+		plain = append(plain, f.archivedFile)
+	}
+	return plain, archived, nil
+}
+
+// parseRotatedName parses the <date>[-<index>].ndjson suffix of the name of
+// a rotated file.
+func parseRotatedName(s string) (date string, index int, ok bool) {
+	s, ok = strings.CutSuffix(s, rotatedExtension)
+	if !ok || len(s) < len(file.DateFormat) {
+		return "", 0, false
+	}
+	date, s = s[:len(file.DateFormat)], s[len(file.DateFormat):]
+	if _, err := time.Parse(file.DateFormat, date); err != nil {
+		return "", 0, false
+	}
+	if s == "" {
+		return date, 0, true
+	}
+	if s[0] != '-' {
+		return "", 0, false
+	}
+	index, err := strconv.Atoi(s[1:])
+	if err != nil || index < 0 {
+		return "", 0, false
+	}
+	return date, index, true
+}
+
+// compressedName returns a free name for the compressed file. The rotator
+// doesn't see the compressed files, so it can reuse the name of a file
+// that was compressed already.
+func (a *archiver) compressedName(path string) string {
+	ext := a.compressedExtension()
+	name := path + ext
+	if _, err := os.Stat(name); errors.Is(err, os.ErrNotExist) {
+		return name
+	}
+
+	base := strings.TrimSuffix(path, rotatedExtension)
+	_, index, _ := parseRotatedName(strings.TrimPrefix(filepath.Base(path), filepath.Base(a.path)+"-"))
+	if index > 0 {
+		base = base[:strings.LastIndexByte(base, '-')]
+	}
+	for i := index + 1; ; i++ {
+		name = base + "-" + strconv.Itoa(i) + rotatedExtension + ext
+		if _, err := os.Stat(name); errors.Is(err, os.ErrNotExist) {
+			return name
+		}
+	}
+}
+
+// compress compresses a rotated file and removes it. The compressed file
+// keeps the modification time of the rotated file for max_age.
+func (a *archiver) compress(f archivedFile) (archivedFile, error) {
+	dst := a.compressedName(f.path)
+	tmp := dst + ".tmp"
+	if err := a.compressTo(f.path, tmp); err != nil {
+		os.Remove(tmp)
+		return archivedFile{}, err
+	}
+	if err := os.Chtimes(tmp, f.modTime, f.modTime); err != nil {
+		os.Remove(tmp)
+		return archivedFile{}, err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return archivedFile{}, err
+	}
+	if err := os.Remove(f.path); err != nil {
+		return archivedFile{}, err
+	}
+
+	info, err := os.Stat(dst)
+	if err != nil {
+		return archivedFile{}, err
+	}
+	a.log.Debugf("Compressed rotated file %v to %v", f.path, dst)
+	return archivedFile{path: dst, size: info.Size(), modTime: f.modTime}, nil
+}
+
+func (a *archiver) compressTo(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, a.permissions)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	var w io.WriteCloser
+	switch a.compression {
+	case compressionGzip:
+		w = gzip.NewWriter(out)
+	case compressionZstd:
+		w, err = zstd.NewWriter(out, zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported compression %v", a.compression)
+	}
+
+	if _, err := io.Copy(w, in); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// purge removes the files beyond the number of files, age and total size
+// limits, keeping the newest files.
+func (a *archiver) purge(now time.Time, files []archivedFile) error {
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.After(files[j].modTime)
+	})
+
+	var errs []error
+	var total int64
+	for i, f := range files {
+		total += f.size
+		switch {
+		case a.maxFiles > 0 && uint(i) >= a.maxFiles,
+			a.maxAge > 0 && now.Sub(f.modTime) > a.maxAge,
+			a.maxTotalSize > 0 && total > a.maxTotalSize:
+		default:
+			continue
+		}
+
+		if err := os.Remove(f.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("failed to remove %v: %w", f.path, err))
+			continue
+		}
+		a.log.Debugf("Removed rotated file %v", f.path)
+	}
+	return errors.Join(errs...)
+}
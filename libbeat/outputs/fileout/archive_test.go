@@ -0,0 +1,167 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build !integration
+
+package fileout
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+func TestParseRotatedName(t *testing.T) {
+	tests := []struct {
+		name  string
+		date  string
+		index int
+		ok    bool
+	}{
+		{"20240501.ndjson", "20240501", 0, true},
+		{"20240501-3.ndjson", "20240501", 3, true},
+		{"20240501-3.ndjson.gz", "", 0, false},
+		{"20240501-x.ndjson", "", 0, false},
+		{"2024050.ndjson", "", 0, false},
+		{"other.ndjson", "", 0, false},
+	}
+	for _, test := range tests {
+		date, index, ok := parseRotatedName(test.name)
+		assert.Equal(t, test.ok, ok, test.name)
+		assert.Equal(t, test.date, date, test.name)
+		assert.Equal(t, test.index, index, test.name)
+	}
+}
+
+func TestArchiverCompress(t *testing.T) {
+	for _, compression := range []string{compressionGzip, compressionZstd} {
+		t.Run(compression, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "beat")
+			writeRotated(t, path+"-20240501.ndjson", "first", time.Now())
+			writeRotated(t, path+"-20240501-1.ndjson", "second", time.Now())
+			writeRotated(t, path+"-20240502.ndjson", "active", time.Now())
+			// A file compressed already with the name of a rotated file.
+			writeRotated(t, path+"-20240501.ndjson"+compressedExtensions[compression], "", time.Now())
+
+			a := newTestArchiver(path, fileOutConfig{Compression: compression})
+			require.NoError(t, a.run(time.Now(), false))
+
+			ext := compressedExtensions[compression]
+			assert.Equal(t, []string{
+				"beat-20240501-1.ndjson" + ext,
+				"beat-20240501-2.ndjson" + ext,
+				"beat-20240501.ndjson" + ext,
+				"beat-20240502.ndjson",
+			}, dirNames(t, dir))
+			assert.Equal(t, "first", readCompressed(t, compression, path+"-20240501-1.ndjson"+ext))
+			assert.Equal(t, "second", readCompressed(t, compression, path+"-20240501-2.ndjson"+ext))
+
+			require.NoError(t, a.run(time.Now(), true))
+			assert.Equal(t, "active", readCompressed(t, compression, path+"-20240502.ndjson"+ext))
+		})
+	}
+}
+
+func TestArchiverRetention(t *testing.T) {
+	now := time.Now()
+	tests := map[string]struct {
+		config fileOutConfig
+		want   []string
+	}{
+		"max age": {
+			config: fileOutConfig{MaxAge: 90 * time.Minute},
+			want:   []string{"beat-20240503.ndjson", "beat-20240504.ndjson"},
+		},
+		"max total size": {
+			config: fileOutConfig{MaxTotalSize: 25},
+			want:   []string{"beat-20240502.ndjson.gz", "beat-20240503.ndjson", "beat-20240504.ndjson"},
+		},
+		"number of files": {
+			config: fileOutConfig{NumberOfFiles: 1},
+			want:   []string{"beat-20240503.ndjson", "beat-20240504.ndjson"},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "beat")
+			writeRotated(t, path+"-20240501.ndjson.gz", "0123456789", now.Add(-3*time.Hour))
+			writeRotated(t, path+"-20240502.ndjson.gz", "0123456789", now.Add(-2*time.Hour))
+			writeRotated(t, path+"-20240503.ndjson", "0123456789", now.Add(-time.Hour))
+			writeRotated(t, path+"-20240504.ndjson", "0123456789", now)
+
+			a := newTestArchiver(path, test.config)
+			require.NoError(t, a.run(now, false))
+			assert.Equal(t, test.want, dirNames(t, dir))
+		})
+	}
+}
+
+func newTestArchiver(path string, c fileOutConfig) *archiver {
+	c.Permissions = 0600
+	return newArchiver(logp.NewLogger("file"), path, c)
+}
+
+func writeRotated(t *testing.T, path, content string, modTime time.Time) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func dirNames(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func readCompressed(t *testing.T, compression, path string) string {
+	t.Helper()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var r io.Reader
+	switch compression {
+	case compressionGzip:
+		gr, err := gzip.NewReader(f)
+		require.NoError(t, err)
+		r = gr
+	case compressionZstd:
+		zr, err := zstd.NewReader(f)
+		require.NoError(t, err)
+		defer zr.Close()
+		r = zr
+	}
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(b)
+}
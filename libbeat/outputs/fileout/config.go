@@ -18,22 +18,47 @@
 package fileout
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/elastic/beats/v7/libbeat/common/cfgtype"
 	"github.com/elastic/beats/v7/libbeat/outputs/codec"
 	"github.com/elastic/elastic-agent-libs/config"
 	"github.com/elastic/elastic-agent-libs/file"
 )
 
+// Compression algorithms of the rotated files.
+const (
+	compressionNone = "none"
+	compressionGzip = "gzip"
+	compressionZstd = "zstd"
+)
+
 type fileOutConfig struct {
 	Path            *PathFormatString `config:"path"`
 	Filename        string            `config:"filename"`
 	RotateEveryKb   uint              `config:"rotate_every_kb" validate:"min=1"`
+	RotateEvery     time.Duration     `config:"rotate_every"`
 	NumberOfFiles   uint              `config:"number_of_files"`
 	Codec           codec.Config      `config:"codec"`
 	Permissions     uint32            `config:"permissions"`
 	RotateOnStartup bool              `config:"rotate_on_startup"`
 	Queue           config.Namespace  `config:"queue"`
+
+	// Compression of the rotated files, "none", "gzip" or "zstd".
+	Compression string `config:"compression"`
+	// MaxAge and MaxTotalSize limit the age and total size of the rotated
+	// files, 0 disables the limit.
+	MaxAge       time.Duration    `config:"max_age"`
+	MaxTotalSize cfgtype.ByteSize `config:"max_total_size"`
+
+	// DynamicPath evaluates Path for each event, with the event timestamp
+	// and fields, instead of once at startup.
+	DynamicPath bool `config:"dynamic_path"`
+	// IdleTimeout closes the files of a dynamic path after a period
+	// without events.
+	IdleTimeout time.Duration `config:"idle_timeout" validate:"min=0"`
 }
 
 func defaultConfig() fileOutConfig {
@@ -43,6 +68,8 @@ func defaultConfig() fileOutConfig {
 		RotateEveryKb:   10 * 1024,
 		Permissions:     0600,
 		RotateOnStartup: true,
+		Compression:     compressionNone,
+		IdleTimeout:     5 * time.Minute,
 	}
 }
 
@@ -64,5 +91,27 @@ func (c *fileOutConfig) Validate() error {
 			file.MaxBackupsLimit)
 	}
 
+	if c.RotateEvery != 0 && c.RotateEvery < time.Second {
+		return errors.New("rotate_every must be at least 1s")
+	}
+
+	switch c.Compression {
+	case "", compressionNone, compressionGzip, compressionZstd:
+	default:
+		return fmt.Errorf("invalid compression %q, must be one of %q, %q or %q",
+			c.Compression, compressionNone, compressionGzip, compressionZstd)
+	}
+
+	if c.MaxAge < 0 || c.MaxTotalSize < 0 {
+		return errors.New("max_age and max_total_size must not be negative")
+	}
+
 	return nil
 }
+
+// archiveEnabled returns whether the rotated files are compressed or have
+// retention limits beyond number_of_files.
+func (c *fileOutConfig) archiveEnabled() bool {
+	_, compressed := compressedExtensions[c.Compression]
+	return compressed || c.MaxAge > 0 || c.MaxTotalSize > 0
+}
@@ -19,9 +19,13 @@ package fileout
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/elastic/beats/v7/libbeat/beat"
@@ -42,10 +46,34 @@ type fileOutput struct {
 	filePath string
 	beat     beat.Info
 	observer outputs.Observer
-	rotator  *file.Rotator
 	codec    codec.Codec
+	config   fileOutConfig
+
+	// writer writes the events when the path is static, with dynamic_path
+	// writers holds a writer per path.
+	writer  *fileWriter
+	writers map[string]*fileWriter
+
+	// archiveC triggers a run of the archivers, done is closed when the
+	// output is closed.
+	mu        sync.Mutex
+	archivers map[string]*archiver
+	archiveC  chan struct{}
+	done      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// fileWriter writes the events of a path to its rotated files.
+type fileWriter struct {
+	path      string
+	rotator   *file.Rotator
+	lastWrite time.Time
 }
 
+// archiveInterval is the interval at which the rotated files are checked
+// for compression and retention when no events are written.
+const archiveInterval = time.Minute
+
 // makeFileout instantiates a new file output instance.
 func makeFileout(
 	_ outputs.IndexManager,
@@ -71,47 +99,196 @@ func makeFileout(
 }
 
 func (out *fileOutput) init(beat beat.Info, c fileOutConfig) error {
-	var path string
-	configPath, runErr := c.Path.Run(time.Now().UTC())
-	if runErr != nil {
-		return runErr
+	out.config = c
+	out.archivers = map[string]*archiver{}
+
+	var err error
+	out.codec, err = codec.CreateEncoder(beat, c.Codec)
+	if err != nil {
+		return err
 	}
-	if c.Filename != "" {
-		path = filepath.Join(configPath, c.Filename)
+
+	if c.DynamicPath {
+		out.filePath = c.Path.String()
+		out.writers = map[string]*fileWriter{}
 	} else {
-		path = filepath.Join(configPath, out.beat.Beat)
+		configPath, runErr := c.Path.Run(time.Now().UTC())
+		if runErr != nil {
+			return runErr
+		}
+		out.filePath = out.filename(configPath)
+		out.writer, err = out.newWriter(out.filePath)
+		if err != nil {
+			return err
+		}
 	}
 
-	out.filePath = path
+	out.log.Infof("Initialized file output. "+
+		"path=%v max_size_bytes=%v max_backups=%v permissions=%v rotate_every=%v compression=%v",
+		out.filePath, c.RotateEveryKb*1024, c.NumberOfFiles, os.FileMode(c.Permissions), c.RotateEvery, c.Compression)
 
-	var err error
-	out.rotator, err = file.NewFileRotator(
-		path,
-		file.MaxSizeBytes(c.RotateEveryKb*1024),
-		file.MaxBackups(c.NumberOfFiles),
-		file.Permissions(os.FileMode(c.Permissions)),
-		file.RotateOnStartup(c.RotateOnStartup),
+	if out.config.archiveEnabled() {
+		out.archiveC = make(chan struct{}, 1)
+		out.done = make(chan struct{})
+		out.wg.Add(1)
+		go out.archiveLoop()
+		out.triggerArchive()
+	}
+
+	return nil
+}
+
+// filename returns the path of the files in a directory.
+func (out *fileOutput) filename(dir string) string {
+	if out.config.Filename != "" {
+		return filepath.Join(dir, out.config.Filename)
+	}
+	return filepath.Join(dir, out.beat.Beat)
+}
+
+func (out *fileOutput) newWriter(path string) (*fileWriter, error) {
+	opts := []file.RotatorOption{
+		file.MaxSizeBytes(out.config.RotateEveryKb * 1024),
+		file.MaxBackups(out.config.NumberOfFiles),
+		file.Permissions(os.FileMode(out.config.Permissions)),
+		file.RotateOnStartup(out.config.RotateOnStartup),
 		file.WithLogger(logp.NewLogger("rotator").With(logp.Namespace("rotator"))),
-	)
+	}
+	if out.config.RotateEvery > 0 {
+		opts = append(opts, file.Interval(out.config.RotateEvery))
+	}
+	rotator, err := file.NewFileRotator(path, opts...)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	out.codec, err = codec.CreateEncoder(beat, c.Codec)
+	if out.config.archiveEnabled() {
+		out.mu.Lock()
+		if _, ok := out.archivers[path]; !ok {
+			out.archivers[path] = newArchiver(out.log, path, out.config)
+		}
+		out.mu.Unlock()
+	}
+	return &fileWriter{path: path, rotator: rotator}, nil
+}
+
+// writerFor returns the writer of an event, creating it when the path of
+// the event is new.
+func (out *fileOutput) writerFor(event *beat.Event) (*fileWriter, error) {
+	if out.writer != nil {
+		return out.writer, nil
+	}
+
+	dir, err := out.config.Path.RunEvent(event)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to evaluate the path: %w", err)
+	}
+	if slices.Contains(strings.Split(filepath.ToSlash(dir), "/"), "..") {
+		return nil, fmt.Errorf("the path %v must not contain '..' elements", dir)
+	}
+	path := out.filename(dir)
+	if w, ok := out.writers[path]; ok {
+		return w, nil
 	}
 
-	out.log.Infof("Initialized file output. "+
-		"path=%v max_size_bytes=%v max_backups=%v permissions=%v",
-		path, c.RotateEveryKb*1024, c.NumberOfFiles, os.FileMode(c.Permissions))
+	w, err := out.newWriter(path)
+	if err != nil {
+		return nil, err
+	}
+	out.writers[path] = w
+	return w, nil
+}
 
-	return nil
+// closeIdle closes the writers of dynamic paths without events for
+// idle_timeout. All their files are archived.
+func (out *fileOutput) closeIdle(now time.Time) {
+	if out.config.IdleTimeout <= 0 {
+		return
+	}
+	for path, w := range out.writers {
+		if now.Sub(w.lastWrite) < out.config.IdleTimeout {
+			continue
+		}
+		if err := w.rotator.Close(); err != nil {
+			out.log.Warnf("Failed to close file %v: %+v", path, err)
+		}
+		delete(out.writers, path)
+		out.archiveClosed(path, now)
+	}
+}
+
+// archiveClosed runs the archiver of a path whose writer is closed, and
+// forgets about it.
+func (out *fileOutput) archiveClosed(path string, now time.Time) {
+	out.mu.Lock()
+	a, ok := out.archivers[path]
+	delete(out.archivers, path)
+	out.mu.Unlock()
+
+	if ok {
+		if err := a.run(now, true); err != nil {
+			out.log.Warnf("Failed to archive the rotated files of %v: %+v", path, err)
+		}
+	}
+}
+
+func (out *fileOutput) triggerArchive() {
+	if out.archiveC == nil {
+		return
+	}
+	select {
+	case out.archiveC <- struct{}{}:
+	default:
+	}
+}
+
+// archiveLoop compresses the rotated files and applies the retention
+// limits after events are written, and periodically for max_age.
+func (out *fileOutput) archiveLoop() {
+	defer out.wg.Done()
+
+	ticker := time.NewTicker(archiveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-out.done:
+			return
+		case <-out.archiveC:
+		case <-ticker.C:
+		}
+
+		out.mu.Lock()
+		archivers := make([]*archiver, 0, len(out.archivers))
+		for _, a := range out.archivers {
+			archivers = append(archivers, a)
+		}
+		out.mu.Unlock()
+
+		now := time.Now()
+		for _, a := range archivers {
+			if err := a.run(now, false); err != nil {
+				out.log.Warnf("Failed to archive the rotated files of %v: %+v", a.path, err)
+			}
+		}
+	}
 }
 
 // Implement Outputer
 func (out *fileOutput) Close() error {
-	return out.rotator.Close()
+	if out.done != nil {
+		close(out.done)
+		out.wg.Wait()
+	}
+
+	if out.writer != nil {
+		return out.writer.rotator.Close()
+	}
+
+	var errs []error
+	for _, w := range out.writers {
+		errs = append(errs, w.rotator.Close())
+	}
+	return errors.Join(errs...)
 }
 
 func (out *fileOutput) Publish(_ context.Context, batch publisher.Batch) error {
@@ -140,8 +317,20 @@ func (out *fileOutput) Publish(_ context.Context, batch publisher.Batch) error {
 			continue
 		}
 
+		w, err := out.writerFor(&event.Content)
+		if err != nil {
+			if event.Guaranteed() {
+				out.log.Errorf("Failed to open the file of the event: %+v", err)
+			} else {
+				out.log.Warnf("Failed to open the file of the event: %+v", err)
+			}
+
+			dropped++
+			continue
+		}
+
 		begin := time.Now()
-		if _, err = out.rotator.Write(append(serializedEvent, '\n')); err != nil {
+		if _, err = w.rotator.Write(append(serializedEvent, '\n')); err != nil {
 			st.WriteError(err)
 
 			if event.Guaranteed() {
@@ -153,6 +342,7 @@ func (out *fileOutput) Publish(_ context.Context, batch publisher.Batch) error {
 			dropped++
 			continue
 		}
+		w.lastWrite = begin
 
 		st.WriteBytes(len(serializedEvent) + 1)
 		took := time.Since(begin)
@@ -163,6 +353,11 @@ func (out *fileOutput) Publish(_ context.Context, batch publisher.Batch) error {
 
 	st.AckedEvents(len(events) - dropped)
 
+	if out.writers != nil {
+		out.closeIdle(time.Now())
+	}
+	out.triggerArchive()
+
 	return nil
 }
 
@@ -18,3 +18,55 @@
 //go:build !integration
 
 package fileout
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/outputs"
+	_ "github.com/elastic/beats/v7/libbeat/outputs/codec/json"
+	"github.com/elastic/beats/v7/libbeat/outputs/outest"
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func TestDynamicPath(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.MustNewConfigFrom(mapstr.M{
+		"path":         filepath.Join(dir, "%{[service.name]}", "%{+yyyy.MM.dd}"),
+		"dynamic_path": true,
+		"idle_timeout": "1ns",
+		"compression":  "gzip",
+	})
+	group, err := makeFileout(nil, beat.Info{Beat: "testbeat"}, outputs.NewNilObserver(), cfg)
+	require.NoError(t, err)
+	out := group.Clients[0]
+	defer out.Close()
+
+	ts := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	batch := outest.NewBatch(
+		beat.Event{Timestamp: ts, Fields: mapstr.M{"service": mapstr.M{"name": "web"}}},
+		beat.Event{Timestamp: ts, Fields: mapstr.M{"service": mapstr.M{"name": "db"}}},
+		beat.Event{Timestamp: ts, Fields: mapstr.M{"service": mapstr.M{"name": ".."}}},
+		beat.Event{Timestamp: ts},
+	)
+	require.NoError(t, out.Publish(context.Background(), batch))
+
+	// The writers are idle right away, so their files are closed and
+	// compressed.
+	for _, service := range []string{"web", "db"} {
+		files, err := filepath.Glob(filepath.Join(dir, service, "2024.05.01", "testbeat-*.ndjson.gz"))
+		require.NoError(t, err)
+		assert.Len(t, files, 1, service)
+	}
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
@@ -37,6 +37,7 @@ var isWindowsPath = os.PathSeparator == '\\'
 // the path separator so it is properly interpreted by the fmtstr processor
 type PathFormatString struct {
 	efs *fmtstr.EventFormatString
+	raw string
 }
 
 // Run executes the format string returning a new expanded string or an error
@@ -51,6 +52,20 @@ func (fs *PathFormatString) Run(timestamp time.Time) (string, error) {
 	return fs.efs.Run(placeholderEvent)
 }
 
+// RunEvent executes the format string with the timestamp and fields of an
+// event.
+func (fs *PathFormatString) RunEvent(event *beat.Event) (string, error) {
+	if fs.efs == nil {
+		return "", fmt.Errorf("path format string is nil; check if `path` option is configured correctly")
+	}
+	return fs.efs.Run(event)
+}
+
+// String returns the format string.
+func (fs *PathFormatString) String() string {
+	return fs.raw
+}
+
 // Unpack tries to initialize the PathFormatString from provided value
 // (which must be a string). Unpack method satisfies go-ucfg.Unpacker interface
 // required by config.C, in order to use PathFormatString with
@@ -61,6 +76,7 @@ func (fs *PathFormatString) Unpack(v interface{}) error {
 		return nil
 	}
 
+	fs.raw = path
 	if isWindowsPath {
 		path = strings.ReplaceAll(path, "\\", "\\\\")
 	}
@@ -0,0 +1,217 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package gelf
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/outputs"
+	"github.com/elastic/beats/v7/libbeat/publisher"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/transport"
+)
+
+const (
+	// chunkHeaderSize is the size of the magic bytes, message ID, sequence
+	// number and sequence count prefixed to every chunk.
+	chunkHeaderSize = 12
+	// maxChunks is the maximum number of chunks Graylog reassembles.
+	maxChunks = 128
+)
+
+var (
+	chunkMagic = []byte{0x1e, 0x0f}
+
+	errMessageTooLarge = errors.New("message exceeds the maximum number of GELF chunks")
+)
+
+type client struct {
+	log *logp.Logger
+	*transport.Client
+	observer    outputs.Observer
+	protocol    string
+	compression string
+	chunkSize   int
+	timeout     time.Duration
+	enc         *encoder
+}
+
+func newClient(
+	tc *transport.Client,
+	observer outputs.Observer,
+	config *gelfConfig,
+	enc *encoder,
+) *client {
+	return &client{
+		log:         logp.NewLogger("gelf"),
+		Client:      tc,
+		observer:    observer,
+		protocol:    config.Protocol,
+		compression: config.Compression,
+		chunkSize:   config.ChunkSize,
+		timeout:     config.Timeout,
+		enc:         enc,
+	}
+}
+
+func (c *client) Connect(ctx context.Context) error {
+	c.log.Debug("connect")
+	return c.Client.ConnectContext(ctx)
+}
+
+func (c *client) Close() error {
+	c.log.Debug("close connection")
+	return c.Client.Close()
+}
+
+func (c *client) Publish(_ context.Context, batch publisher.Batch) error {
+	events := batch.Events()
+	c.observer.NewBatch(len(events))
+
+	dropped := 0
+	for i := range events {
+		msg, err := c.enc.encode(&events[i].Content)
+		if err != nil {
+			c.log.Errorf("Failed to encode event: %v", err)
+			c.log.Debugf("Failed event: %v", events[i])
+			dropped++
+			continue
+		}
+
+		err = c.send(msg)
+		if errors.Is(err, errMessageTooLarge) {
+			c.log.Errorf("Dropping event: %v", err)
+			dropped++
+			continue
+		}
+		if err != nil {
+			c.observer.PermanentErrors(dropped)
+			c.observer.AckedEvents(i - dropped)
+			c.observer.RetryableErrors(len(events) - i)
+			batch.RetryEvents(events[i:])
+			return err
+		}
+	}
+
+	c.observer.PermanentErrors(dropped)
+	c.observer.AckedEvents(len(events) - dropped)
+	batch.ACK()
+	return nil
+}
+
+// send writes a single GELF message, framed with a null byte on TCP and
+// compressed and chunked as needed on UDP.
+func (c *client) send(msg []byte) error {
+	if c.timeout > 0 {
+		if err := c.SetWriteDeadline(time.Now().Add(c.timeout)); err != nil {
+			return err
+		}
+	}
+
+	if c.protocol == protocolTCP {
+		return c.write(append(msg, 0))
+	}
+
+	msg, err := compress(msg, c.compression)
+	if err != nil {
+		return err
+	}
+	datagrams, err := chunk(msg, c.chunkSize)
+	if err != nil {
+		return err
+	}
+	for _, d := range datagrams {
+		if err := c.write(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *client) write(b []byte) error {
+	n, err := c.Write(b)
+	if err == nil && n != len(b) {
+		err = io.ErrShortWrite
+	}
+	return err
+}
+
+func (c *client) String() string {
+	return "gelf(" + c.Client.String() + ")"
+}
+
+func compress(msg []byte, compression string) ([]byte, error) {
+	var (
+		buf bytes.Buffer
+		w   io.WriteCloser
+	)
+	switch compression {
+	case compressionGzip:
+		w = gzip.NewWriter(&buf)
+	case compressionZlib:
+		w = zlib.NewWriter(&buf)
+	default:
+		return msg, nil
+	}
+	if _, err := w.Write(msg); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// chunk splits a message into GELF chunks of at most size bytes. Messages
+// that fit into a single datagram are returned unchanged.
+func chunk(msg []byte, size int) ([][]byte, error) {
+	if len(msg) <= size {
+		return [][]byte{msg}, nil
+	}
+
+	dataSize := size - chunkHeaderSize
+	count := (len(msg) + dataSize - 1) / dataSize
+	if count > maxChunks {
+		return nil, fmt.Errorf("%w: %d bytes need %d chunks", errMessageTooLarge, len(msg), count)
+	}
+
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, err
+	}
+
+	chunks := make([][]byte, 0, count)
+	for seq := 0; seq < count; seq++ {
+		data := msg[seq*dataSize : min((seq+1)*dataSize, len(msg))]
+		c := make([]byte, 0, chunkHeaderSize+len(data))
+		c = append(c, chunkMagic...)
+		c = append(c, id[:]...)
+		c = append(c, byte(seq), byte(count))
+		c = append(c, data...)
+		chunks = append(chunks, c)
+	}
+	return chunks, nil
+}
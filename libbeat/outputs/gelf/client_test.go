@@ -0,0 +1,220 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package gelf
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/outputs"
+	"github.com/elastic/beats/v7/libbeat/outputs/outest"
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/transport"
+)
+
+func newTestClient(t *testing.T, addr string, settings map[string]interface{}) *client {
+	t.Helper()
+	settings["hosts"] = []string{addr}
+	cfg, err := readConfig(config.MustNewConfigFrom(settings))
+	require.NoError(t, err)
+
+	conn, err := transport.NewClient(transport.Config{Timeout: cfg.Timeout}, cfg.Protocol, addr, defaultPort)
+	require.NoError(t, err)
+	c := newClient(conn, outputs.NewNilObserver(), cfg, newEncoder("beathost", cfg))
+	require.NoError(t, c.Connect(context.Background()))
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func testEvent(message string) beat.Event {
+	return beat.Event{
+		Timestamp: time.Now(),
+		Fields:    mapstr.M{"message": message},
+	}
+}
+
+func TestPublishUDPChunked(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer pc.Close()
+
+	c := newTestClient(t, pc.LocalAddr().String(), map[string]interface{}{
+		"chunk_size":  128,
+		"compression": "none",
+	})
+
+	long := strings.Repeat("x", 1000)
+	batch := outest.NewBatch(testEvent(long))
+	require.NoError(t, c.Publish(context.Background(), batch))
+	assert.Equal(t, []outest.BatchSignal{{Tag: outest.BatchACK}}, batch.Signals)
+
+	var (
+		id     []byte
+		chunks = map[byte][]byte{}
+		count  byte
+	)
+	buf := make([]byte, 256)
+	require.NoError(t, pc.SetReadDeadline(time.Now().Add(5*time.Second)))
+	for count == 0 || len(chunks) < int(count) {
+		n, _, err := pc.ReadFrom(buf)
+		require.NoError(t, err)
+		require.LessOrEqual(t, n, 128)
+		require.Equal(t, chunkMagic, buf[:2])
+		if id == nil {
+			id = append([]byte{}, buf[2:10]...)
+		}
+		assert.Equal(t, id, buf[2:10])
+		count = buf[11]
+		chunks[buf[10]] = append([]byte{}, buf[12:n]...)
+	}
+
+	var msg []byte
+	for i := byte(0); i < count; i++ {
+		msg = append(msg, chunks[i]...)
+	}
+	assert.Equal(t, long, decode(t, msg)["short_message"])
+}
+
+func TestPublishUDPCompressed(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer pc.Close()
+
+	c := newTestClient(t, pc.LocalAddr().String(), map[string]interface{}{})
+	require.NoError(t, c.Publish(context.Background(), outest.NewBatch(testEvent("hello"))))
+
+	buf := make([]byte, 8192)
+	require.NoError(t, pc.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, _, err := pc.ReadFrom(buf)
+	require.NoError(t, err)
+
+	r, err := gzip.NewReader(bytes.NewReader(buf[:n]))
+	require.NoError(t, err)
+	msg, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", decode(t, msg)["short_message"])
+}
+
+func TestPublishUDPTooLarge(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer pc.Close()
+
+	c := newTestClient(t, pc.LocalAddr().String(), map[string]interface{}{
+		"chunk_size":  64,
+		"compression": "none",
+	})
+
+	batch := outest.NewBatch(testEvent(strings.Repeat("x", 64*maxChunks)))
+	require.NoError(t, c.Publish(context.Background(), batch))
+	assert.Equal(t, []outest.BatchSignal{{Tag: outest.BatchACK}}, batch.Signals)
+}
+
+func TestPublishTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		var msgs []string
+		for len(msgs) < 2 {
+			msg, err := r.ReadString(0)
+			if err != nil {
+				break
+			}
+			msgs = append(msgs, strings.TrimSuffix(msg, "\x00"))
+		}
+		received <- msgs
+	}()
+
+	c := newTestClient(t, l.Addr().String(), map[string]interface{}{"protocol": "tcp"})
+	batch := outest.NewBatch(testEvent("first"), testEvent("second"))
+	require.NoError(t, c.Publish(context.Background(), batch))
+	assert.Equal(t, []outest.BatchSignal{{Tag: outest.BatchACK}}, batch.Signals)
+
+	select {
+	case msgs := <-received:
+		require.Len(t, msgs, 2)
+		assert.Equal(t, "first", decode(t, []byte(msgs[0]))["short_message"])
+		assert.Equal(t, "second", decode(t, []byte(msgs[1]))["short_message"])
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for messages")
+	}
+}
+
+func TestPublishTCPRetry(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		conn, err := l.Accept()
+		if err == nil {
+			conn.Close()
+		}
+	}()
+
+	c := newTestClient(t, l.Addr().String(), map[string]interface{}{"protocol": "tcp"})
+	l.Close()
+
+	// Writes to a closed connection eventually fail, retry until they do.
+	var batch *outest.Batch
+	require.Eventually(t, func() bool {
+		batch = outest.NewBatch(testEvent("lost"))
+		return c.Publish(context.Background(), batch) != nil
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Len(t, batch.Signals, 1)
+	assert.Equal(t, outest.BatchRetryEvents, batch.Signals[0].Tag)
+}
+
+func TestChunk(t *testing.T) {
+	msg := bytes.Repeat([]byte("a"), 100)
+
+	chunks, err := chunk(msg, 100)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{msg}, chunks)
+
+	chunks, err = chunk(msg, 50)
+	require.NoError(t, err)
+	require.Len(t, chunks, 3)
+	for i, c := range chunks {
+		assert.Equal(t, byte(i), c[10])
+		assert.Equal(t, byte(3), c[11])
+	}
+	assert.Len(t, chunks[2], chunkHeaderSize+100-2*(50-chunkHeaderSize))
+
+	_, err = chunk(bytes.Repeat([]byte("a"), 129*(64-chunkHeaderSize)), 64)
+	assert.ErrorIs(t, err, errMessageTooLarge)
+}
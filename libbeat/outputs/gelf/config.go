@@ -0,0 +1,132 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package gelf
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+)
+
+const (
+	protocolUDP = "udp"
+	protocolTCP = "tcp"
+
+	compressionNone = "none"
+	compressionGzip = "gzip"
+	compressionZlib = "zlib"
+)
+
+type gelfConfig struct {
+	Hosts            []string          `config:"hosts" validate:"required"`
+	Protocol         string            `config:"protocol"`
+	LoadBalance      bool              `config:"loadbalance"`
+	Timeout          time.Duration     `config:"timeout"`
+	BulkMaxSize      int               `config:"bulk_max_size"`
+	MaxRetries       int               `config:"max_retries" validate:"min=-1"`
+	TLS              *tlscommon.Config `config:"ssl"`
+	Compression      string            `config:"compression"`
+	ChunkSize        int               `config:"chunk_size" validate:"min=64"`
+	Mapping          mappingConfig     `config:"mapping"`
+	AdditionalFields bool              `config:"additional_fields"`
+	ExcludeFields    []string          `config:"exclude_fields"`
+	Backoff          Backoff           `config:"backoff"`
+	Queue            config.Namespace  `config:"queue"`
+}
+
+// mappingConfig names the event fields used for the GELF message fields.
+type mappingConfig struct {
+	Host         string `config:"host"`
+	ShortMessage string `config:"short_message"`
+	FullMessage  string `config:"full_message"`
+	Level        string `config:"level"`
+	// DefaultLevel is the syslog severity used if the level field is
+	// missing or can not be interpreted.
+	DefaultLevel int `config:"default_level" validate:"min=0,max=7"`
+}
+
+type Backoff struct {
+	Init time.Duration
+	Max  time.Duration
+}
+
+func defaultConfig() gelfConfig {
+	return gelfConfig{
+		Protocol:    protocolUDP,
+		LoadBalance: true,
+		Timeout:     5 * time.Second,
+		BulkMaxSize: 2048,
+		MaxRetries:  3,
+		ChunkSize:   1420,
+		Mapping: mappingConfig{
+			Host:         "host.name",
+			ShortMessage: "message",
+			Level:        "log.level",
+			DefaultLevel: 6,
+		},
+		AdditionalFields: true,
+		Backoff: Backoff{
+			Init: 1 * time.Second,
+			Max:  60 * time.Second,
+		},
+	}
+}
+
+func readConfig(cfg *config.C) (*gelfConfig, error) {
+	c := defaultConfig()
+	if err := cfg.Unpack(&c); err != nil {
+		return nil, err
+	}
+	if c.Compression == "" {
+		c.Compression = compressionNone
+		if c.Protocol == protocolUDP {
+			c.Compression = compressionGzip
+		}
+	}
+	return &c, nil
+}
+
+func (c *gelfConfig) Validate() error {
+	switch c.Compression {
+	case "", compressionNone, compressionGzip, compressionZlib:
+	default:
+		return fmt.Errorf("unsupported compression %q: must be one of none, gzip or zlib", c.Compression)
+	}
+
+	switch c.Protocol {
+	case protocolUDP:
+		if c.TLS.IsEnabled() {
+			return errors.New("ssl is only supported with the tcp protocol")
+		}
+	case protocolTCP:
+		// Graylog's TCP input does not support compressed messages.
+		if c.Compression != "" && c.Compression != compressionNone {
+			return errors.New("compression is only supported with the udp protocol")
+		}
+	default:
+		return fmt.Errorf("unsupported protocol %q: must be udp or tcp", c.Protocol)
+	}
+
+	if c.Mapping.ShortMessage == "" {
+		return errors.New("mapping.short_message must be set")
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package gelf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/config"
+)
+
+func TestConfig(t *testing.T) {
+	tests := map[string]struct {
+		settings    map[string]interface{}
+		compression string
+		err         string
+	}{
+		"udp defaults to gzip": {
+			settings:    map[string]interface{}{},
+			compression: compressionGzip,
+		},
+		"tcp defaults to no compression": {
+			settings:    map[string]interface{}{"protocol": "tcp"},
+			compression: compressionNone,
+		},
+		"tcp with tls": {
+			settings:    map[string]interface{}{"protocol": "tcp", "ssl.verification_mode": "none"},
+			compression: compressionNone,
+		},
+		"udp with tls": {
+			settings: map[string]interface{}{"ssl.verification_mode": "none"},
+			err:      "ssl is only supported with the tcp protocol",
+		},
+		"tcp with compression": {
+			settings: map[string]interface{}{"protocol": "tcp", "compression": "gzip"},
+			err:      "compression is only supported with the udp protocol",
+		},
+		"invalid protocol": {
+			settings: map[string]interface{}{"protocol": "http"},
+			err:      "unsupported protocol",
+		},
+		"invalid compression": {
+			settings: map[string]interface{}{"compression": "lz4"},
+			err:      "unsupported compression",
+		},
+		"chunk size too small": {
+			settings: map[string]interface{}{"chunk_size": 12},
+			err:      "chunk_size",
+		},
+		"invalid default level": {
+			settings: map[string]interface{}{"mapping.default_level": 8},
+			err:      "default_level",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			tc.settings["hosts"] = []string{"localhost"}
+			cfg, err := readConfig(config.MustNewConfigFrom(tc.settings))
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.compression, cfg.Compression)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package gelf
+
+import (
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/outputs"
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/transport"
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+)
+
+const defaultPort = 12201
+
+func init() {
+	outputs.RegisterType("gelf", makeGELF)
+}
+
+// makeGELF creates an output that sends events as GELF messages to Graylog
+// over UDP or TCP.
+func makeGELF(
+	_ outputs.IndexManager,
+	beat beat.Info,
+	observer outputs.Observer,
+	cfg *config.C,
+) (outputs.Group, error) {
+	gelfConfig, err := readConfig(cfg)
+	if err != nil {
+		return outputs.Fail(err)
+	}
+
+	hosts, err := outputs.ReadHostList(cfg)
+	if err != nil {
+		return outputs.Fail(err)
+	}
+
+	tls, err := tlscommon.LoadTLSConfig(gelfConfig.TLS)
+	if err != nil {
+		return outputs.Fail(err)
+	}
+
+	transp := transport.Config{
+		Timeout: gelfConfig.Timeout,
+		TLS:     tls,
+		Stats:   observer,
+	}
+
+	enc := newEncoder(beat.Hostname, gelfConfig)
+	clients := make([]outputs.NetworkClient, len(hosts))
+	for i, host := range hosts {
+		conn, err := transport.NewClient(transp, gelfConfig.Protocol, host, defaultPort)
+		if err != nil {
+			return outputs.Fail(err)
+		}
+		client := newClient(conn, observer, gelfConfig, enc)
+		clients[i] = outputs.WithBackoff(client, gelfConfig.Backoff.Init, gelfConfig.Backoff.Max)
+	}
+
+	return outputs.SuccessNet(gelfConfig.Queue, gelfConfig.LoadBalance, gelfConfig.BulkMaxSize, gelfConfig.MaxRetries, nil, clients)
+}
@@ -0,0 +1,212 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package gelf
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common"
+)
+
+const gelfVersion = "1.1"
+
+// syslogLevels maps log level names to syslog severities.
+var syslogLevels = map[string]int{
+	"emergency":     0,
+	"emerg":         0,
+	"panic":         0,
+	"alert":         1,
+	"critical":      2,
+	"crit":          2,
+	"fatal":         2,
+	"error":         3,
+	"err":           3,
+	"warning":       4,
+	"warn":          4,
+	"notice":        5,
+	"informational": 6,
+	"info":          6,
+	"debug":         7,
+	"trace":         7,
+}
+
+// invalidFieldChars matches characters Graylog does not accept in
+// additional field names.
+var invalidFieldChars = regexp.MustCompile(`[^\w.\-]`)
+
+// encoder converts events to GELF messages.
+type encoder struct {
+	hostname   string
+	mapping    mappingConfig
+	additional bool
+	exclude    []string
+}
+
+func newEncoder(hostname string, config *gelfConfig) *encoder {
+	exclude := append([]string{}, config.ExcludeFields...)
+	for _, field := range []string{config.Mapping.Host, config.Mapping.ShortMessage, config.Mapping.FullMessage, config.Mapping.Level} {
+		if field != "" {
+			exclude = append(exclude, field)
+		}
+	}
+	return &encoder{
+		hostname:   hostname,
+		mapping:    config.Mapping,
+		additional: config.AdditionalFields,
+		exclude:    exclude,
+	}
+}
+
+// encode returns the JSON representation of the event as a GELF message.
+func (e *encoder) encode(event *beat.Event) ([]byte, error) {
+	msg := map[string]interface{}{
+		"version":       gelfVersion,
+		"host":          e.stringField(event, e.mapping.Host, e.hostname),
+		"short_message": e.stringField(event, e.mapping.ShortMessage, "-"),
+		"timestamp":     float64(event.Timestamp.UnixMilli()) / 1000,
+		"level":         e.level(event),
+	}
+	if e.mapping.FullMessage != "" {
+		if full := e.stringField(event, e.mapping.FullMessage, ""); full != "" {
+			msg["full_message"] = full
+		}
+	}
+
+	if e.additional {
+		for key, value := range event.Fields.Flatten() {
+			if e.excluded(key) {
+				continue
+			}
+			v, ok := fieldValue(value)
+			if !ok {
+				continue
+			}
+			name := "_" + invalidFieldChars.ReplaceAllString(key, "_")
+			if name == "_id" {
+				// _id is reserved by Graylog.
+				name = "__id"
+			}
+			msg[name] = v
+		}
+	}
+
+	return json.Marshal(msg)
+}
+
+func (e *encoder) stringField(event *beat.Event, field, fallback string) string {
+	if field == "" {
+		return fallback
+	}
+	v, err := event.GetValue(field)
+	if err != nil {
+		return fallback
+	}
+	s, ok := v.(string)
+	if !ok {
+		s = fmt.Sprint(v)
+	}
+	if s == "" {
+		return fallback
+	}
+	return s
+}
+
+// level returns the syslog severity of the event, read either from a
+// numeric level or from a level name like "warning".
+func (e *encoder) level(event *beat.Event) int {
+	if e.mapping.Level == "" {
+		return e.mapping.DefaultLevel
+	}
+	v, err := event.GetValue(e.mapping.Level)
+	if err != nil {
+		return e.mapping.DefaultLevel
+	}
+
+	var level int
+	switch v := v.(type) {
+	case string:
+		l, ok := syslogLevels[strings.ToLower(v)]
+		if !ok {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return e.mapping.DefaultLevel
+			}
+			l = n
+		}
+		level = l
+	case int:
+		level = v
+	case int64:
+		level = int(v)
+	case uint64:
+		level = int(v)
+	case float64:
+		level = int(v)
+	default:
+		return e.mapping.DefaultLevel
+	}
+	if level < 0 || level > 7 {
+		return e.mapping.DefaultLevel
+	}
+	return level
+}
+
+func (e *encoder) excluded(key string) bool {
+	for _, field := range e.exclude {
+		if key == field || strings.HasPrefix(key, field+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldValue converts a value to the string or number values accepted by
+// GELF additional fields. It returns false for values that must be omitted.
+func fieldValue(v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case nil:
+		return nil, false
+	case string:
+		return v, true
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return v, true
+	case float32:
+		return v, !math.IsNaN(float64(v)) && !math.IsInf(float64(v), 0)
+	case float64:
+		return v, !math.IsNaN(v) && !math.IsInf(v, 0)
+	case bool:
+		return strconv.FormatBool(v), true
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano), true
+	case common.Time:
+		return time.Time(v).UTC().Format(time.RFC3339Nano), true
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, false
+		}
+		return string(b), true
+	}
+}
@@ -0,0 +1,128 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package gelf
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func newTestEncoder(t *testing.T, settings map[string]interface{}) *encoder {
+	t.Helper()
+	settings["hosts"] = []string{"localhost"}
+	cfg, err := readConfig(config.MustNewConfigFrom(settings))
+	require.NoError(t, err)
+	return newEncoder("beathost", cfg)
+}
+
+func decode(t *testing.T, b []byte) map[string]interface{} {
+	t.Helper()
+	var msg map[string]interface{}
+	require.NoError(t, json.Unmarshal(b, &msg))
+	return msg
+}
+
+func TestEncode(t *testing.T) {
+	enc := newTestEncoder(t, map[string]interface{}{})
+
+	event := beat.Event{
+		Timestamp: time.Date(2024, 3, 1, 12, 0, 0, 123000000, time.UTC),
+		Fields: mapstr.M{
+			"message": "disk full",
+			"host":    mapstr.M{"name": "web-1"},
+			"log":     mapstr.M{"level": "WARN", "file": mapstr.M{"path": "/var/log/app.log"}},
+			"id":      "abc",
+			"tags":    []string{"a", "b"},
+			"ok":      true,
+			"bytes":   42,
+			"labels":  mapstr.M{"env name": "prod"},
+		},
+	}
+
+	b, err := enc.encode(&event)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"version":          "1.1",
+		"host":             "web-1",
+		"short_message":    "disk full",
+		"timestamp":        1709294400.123,
+		"level":            float64(4),
+		"_log.file.path":   "/var/log/app.log",
+		"__id":             "abc",
+		"_tags":            `["a","b"]`,
+		"_ok":              "true",
+		"_bytes":           float64(42),
+		"_labels.env_name": "prod",
+	}, decode(t, b))
+}
+
+func TestEncodeMapping(t *testing.T) {
+	enc := newTestEncoder(t, map[string]interface{}{
+		"mapping.host":          "agent.name",
+		"mapping.short_message": "event.action",
+		"mapping.full_message":  "message",
+		"mapping.level":         "event.severity",
+		"additional_fields":     true,
+		"exclude_fields":        []string{"agent"},
+	})
+
+	event := beat.Event{
+		Timestamp: time.Unix(1709294400, 0),
+		Fields: mapstr.M{
+			"message": "first line\nsecond line",
+			"event":   mapstr.M{"action": "login", "severity": 2},
+			"agent":   mapstr.M{"version": "8.15.0"},
+		},
+	}
+
+	b, err := enc.encode(&event)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"version":       "1.1",
+		"host":          "beathost",
+		"short_message": "login",
+		"full_message":  "first line\nsecond line",
+		"timestamp":     float64(1709294400),
+		"level":         float64(2),
+	}, decode(t, b))
+}
+
+func TestEncodeLevel(t *testing.T) {
+	enc := newTestEncoder(t, map[string]interface{}{"additional_fields": false})
+
+	for level, want := range map[interface{}]int{
+		"error":    3,
+		"Debug":    7,
+		"5":        5,
+		"bogus":    6,
+		int64(1):   1,
+		float64(9): 6,
+	} {
+		event := beat.Event{Fields: mapstr.M{"message": "m", "log": mapstr.M{"level": level}}}
+		assert.Equal(t, want, enc.level(&event), "level %v", level)
+	}
+	assert.Equal(t, 6, enc.level(&beat.Event{Fields: mapstr.M{}}))
+}
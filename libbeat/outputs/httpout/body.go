@@ -0,0 +1,109 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package httpout
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"text/template"
+)
+
+// bodyEncoder renders the JSON encoded events of a request into its body.
+type bodyEncoder struct {
+	format      string
+	tmpl        *template.Template
+	contentType string
+}
+
+// templateData is passed to body templates.
+type templateData struct {
+	// Events are the events of the request, in event mode it has a single
+	// element.
+	Events []map[string]interface{}
+	// Event is the first event of the request.
+	Event map[string]interface{}
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+func newBodyEncoder(cfg bodyConfig) (*bodyEncoder, error) {
+	e := &bodyEncoder{format: cfg.Format, contentType: cfg.ContentType}
+	switch cfg.Format {
+	case formatNDJSON:
+		if e.contentType == "" {
+			e.contentType = "application/x-ndjson"
+		}
+	case formatJSONArray:
+		if e.contentType == "" {
+			e.contentType = "application/json"
+		}
+	case formatTemplate:
+		tmpl, err := template.New("body").Option("missingkey=zero").Funcs(templateFuncs).Parse(cfg.Template)
+		if err != nil {
+			return nil, fmt.Errorf("invalid body.template: %w", err)
+		}
+		e.tmpl = tmpl
+		if e.contentType == "" {
+			e.contentType = "application/json"
+		}
+	default:
+		return nil, fmt.Errorf("unknown body format %q", cfg.Format)
+	}
+	return e, nil
+}
+
+// encode renders the body for the JSON encoded events.
+func (e *bodyEncoder) encode(docs [][]byte) ([]byte, error) {
+	var buf bytes.Buffer
+	switch e.format {
+	case formatNDJSON:
+		for _, doc := range docs {
+			buf.Write(doc)
+			buf.WriteByte('\n')
+		}
+	case formatJSONArray:
+		buf.WriteByte('[')
+		for i, doc := range docs {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.Write(doc)
+		}
+		buf.WriteByte(']')
+	case formatTemplate:
+		data := templateData{Events: make([]map[string]interface{}, len(docs))}
+		for i, doc := range docs {
+			if err := json.Unmarshal(doc, &data.Events[i]); err != nil {
+				return nil, err
+			}
+		}
+		if len(data.Events) > 0 {
+			data.Event = data.Events[0]
+		}
+		if err := e.tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("failed to render body template: %w", err)
+		}
+	}
+	return buf.Bytes(), nil
+}
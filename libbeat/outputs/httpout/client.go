@@ -0,0 +1,263 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package httpout
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+	"golang.org/x/time/rate"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/outputs"
+	"github.com/elastic/beats/v7/libbeat/outputs/codec"
+	"github.com/elastic/beats/v7/libbeat/outputs/codec/json"
+	"github.com/elastic/beats/v7/libbeat/publisher"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/transport/httpcommon"
+)
+
+// maxErrorBody limits how much of an error response is logged.
+const maxErrorBody = 1024
+
+var errNotConnected = errors.New("http output not connected")
+
+type client struct {
+	log      *logp.Logger
+	observer outputs.Observer
+	config   *httpOutputConfig
+	beat     beat.Info
+	codec    codec.Codec
+	body     *bodyEncoder
+	limiter  *rate.Limiter
+	retryOn  map[int]bool
+
+	http *http.Client
+}
+
+// publishResult classifies the outcome of a request.
+type publishResult int
+
+const (
+	resultOK publishResult = iota
+	resultRetry
+	resultDrop
+)
+
+// newLimiter returns the rate limiter shared by all workers of the output.
+func newLimiter(config rateLimitConfig) *rate.Limiter {
+	if config.Limit <= 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+	return rate.NewLimiter(rate.Limit(config.Limit), max(config.Burst, 1))
+}
+
+func newClient(beat beat.Info, observer outputs.Observer, config *httpOutputConfig, limiter *rate.Limiter) (*client, error) {
+	body, err := newBodyEncoder(config.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &client{
+		log:      logp.NewLogger("http"),
+		observer: observer,
+		config:   config,
+		beat:     beat,
+		codec:    json.New(beat.Version, json.Config{}),
+		body:     body,
+		limiter:  limiter,
+		retryOn:  map[int]bool{},
+	}
+	for _, status := range config.RetryOnStatus {
+		c.retryOn[status] = true
+	}
+	return c, nil
+}
+
+func (c *client) Connect(_ context.Context) error {
+	httpClient, err := c.config.Transport.Client(
+		httpcommon.WithLogger(c.log),
+		httpcommon.WithIOStats(c.observer),
+		httpcommon.WithAPMHTTPInstrumentation(),
+		httpcommon.WithHeaderRoundTripper(map[string]string{"User-Agent": c.beat.UserAgent}),
+	)
+	if err != nil {
+		return err
+	}
+
+	if o := c.config.OAuth2; o != nil {
+		creds := clientcredentials.Config{
+			ClientID:       o.ClientID,
+			ClientSecret:   o.ClientSecret,
+			TokenURL:       o.TokenURL,
+			Scopes:         o.Scopes,
+			EndpointParams: o.EndpointParams,
+		}
+		// The token source keeps using the context, so it must not be
+		// cancelled with the connection attempt.
+		httpClient = creds.Client(context.WithValue(context.Background(), oauth2.HTTPClient, httpClient))
+	}
+
+	c.http = httpClient
+	return nil
+}
+
+func (c *client) Close() error {
+	if c.http != nil {
+		c.http.CloseIdleConnections()
+	}
+	return nil
+}
+
+func (c *client) String() string {
+	return "http(" + c.config.URL + ")"
+}
+
+func (c *client) Publish(ctx context.Context, batch publisher.Batch) error {
+	events := batch.Events()
+	c.observer.NewBatch(len(events))
+
+	docs, events, dropped := c.encodeEvents(events)
+	if dropped > 0 {
+		c.observer.PermanentErrors(dropped)
+	}
+	if len(docs) == 0 {
+		batch.ACK()
+		return nil
+	}
+
+	if c.config.Mode == modeBatch {
+		result, err := c.send(ctx, docs)
+		return c.handleResult(batch, events, result, err)
+	}
+
+	for i := range docs {
+		result, err := c.send(ctx, docs[i:i+1])
+		if result == resultRetry {
+			return c.handleResult(batch, events[i:], result, err)
+		}
+		c.countResult(1, result)
+	}
+	batch.ACK()
+	return nil
+}
+
+// encodeEvents returns the JSON encoding of all events that can be encoded
+// together with the encoded events.
+func (c *client) encodeEvents(events []publisher.Event) ([][]byte, []publisher.Event, int) {
+	docs := make([][]byte, 0, len(events))
+	encoded := make([]publisher.Event, 0, len(events))
+	for i := range events {
+		doc, err := c.codec.Encode(c.beat.Beat, &events[i].Content)
+		if err != nil {
+			c.log.Errorf("Failed to encode event: %v", err)
+			c.log.Debugf("Failed event: %v", events[i])
+			continue
+		}
+		// The codec reuses its buffer.
+		docs = append(docs, bytes.Clone(doc))
+		encoded = append(encoded, events[i])
+	}
+	return docs, encoded, len(events) - len(encoded)
+}
+
+func (c *client) handleResult(batch publisher.Batch, events []publisher.Event, result publishResult, err error) error {
+	if result == resultRetry {
+		c.observer.RetryableErrors(len(events))
+		batch.RetryEvents(events)
+		return err
+	}
+	c.countResult(len(events), result)
+	batch.ACK()
+	return nil
+}
+
+func (c *client) countResult(n int, result publishResult) {
+	if result == resultOK {
+		c.observer.AckedEvents(n)
+	} else {
+		c.observer.PermanentErrors(n)
+	}
+}
+
+// send sends the events in a single request.
+func (c *client) send(ctx context.Context, docs [][]byte) (publishResult, error) {
+	body, err := c.body.encode(docs)
+	if err != nil {
+		c.log.Errorf("Dropping %d events: %v", len(docs), err)
+		return resultDrop, err
+	}
+
+	if c.http == nil {
+		return resultRetry, errNotConnected
+	}
+	if err := c.limiter.Wait(ctx); err != nil {
+		return resultRetry, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, c.config.Method, c.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return resultDrop, err
+	}
+	req.Header.Set("Content-Type", c.body.contentType)
+	for k, v := range c.config.Headers {
+		req.Header.Set(k, v)
+	}
+	switch {
+	case c.config.Username != "" || c.config.Password != "":
+		req.SetBasicAuth(c.config.Username, c.config.Password)
+	case c.config.APIKey != "":
+		if c.config.APIKeyHeader == "Authorization" {
+			req.Header.Set("Authorization", "ApiKey "+c.config.APIKey)
+		} else {
+			req.Header.Set(c.config.APIKeyHeader, c.config.APIKey)
+		}
+	}
+
+	begin := time.Now()
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return resultRetry, fmt.Errorf("failed to send request to %s: %w", c.config.URL, err)
+	}
+	defer resp.Body.Close()
+	c.observer.ReportLatency(time.Since(begin))
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return resultOK, nil
+	case c.retryOn[resp.StatusCode]:
+		if resp.StatusCode == http.StatusTooManyRequests {
+			c.observer.ErrTooMany(len(docs))
+		}
+		return resultRetry, fmt.Errorf("%s responded with %s: %s", c.config.URL, resp.Status, respBody)
+	default:
+		err := fmt.Errorf("%s responded with %s: %s", c.config.URL, resp.Status, respBody)
+		c.log.Errorf("Dropping %d events: %v", len(docs), err)
+		return resultDrop, err
+	}
+}
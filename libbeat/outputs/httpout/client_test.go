@@ -0,0 +1,235 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package httpout
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/outputs"
+	"github.com/elastic/beats/v7/libbeat/outputs/outest"
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+type recordedRequest struct {
+	method string
+	header http.Header
+	body   string
+}
+
+type testServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []recordedRequest
+	status   []int
+}
+
+func newTestServer(t *testing.T, status ...int) *testServer {
+	s := &testServer{status: status}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.requests = append(s.requests, recordedRequest{method: r.Method, header: r.Header, body: string(body)})
+		if len(s.status) > 0 {
+			w.WriteHeader(s.status[0])
+			s.status = s.status[1:]
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func newTestClient(t *testing.T, settings map[string]interface{}) *client {
+	t.Helper()
+	cfg, err := readConfig(config.MustNewConfigFrom(settings))
+	require.NoError(t, err)
+	c, err := newClient(beat.Info{Beat: "testbeat", Version: "9.9.9"}, outputs.NewNilObserver(), cfg, newLimiter(cfg.RateLimit))
+	require.NoError(t, err)
+	require.NoError(t, c.Connect(context.Background()))
+	return c
+}
+
+func testEvents(n int) []beat.Event {
+	events := make([]beat.Event, n)
+	for i := range events {
+		events[i] = beat.Event{
+			Timestamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			Fields:    mapstr.M{"message": "hello", "n": i},
+		}
+	}
+	return events
+}
+
+func TestPublishNDJSON(t *testing.T) {
+	srv := newTestServer(t)
+	c := newTestClient(t, mapstr.M{
+		"url":      srv.URL,
+		"username": "user",
+		"password": "pass",
+		"headers":  mapstr.M{"X-Custom": "value"},
+	})
+
+	batch := outest.NewBatch(testEvents(2)...)
+	require.NoError(t, c.Publish(context.Background(), batch))
+	require.Len(t, batch.Signals, 1)
+	assert.Equal(t, outest.BatchACK, batch.Signals[0].Tag)
+
+	require.Len(t, srv.requests, 1)
+	req := srv.requests[0]
+	assert.Equal(t, "POST", req.method)
+	assert.Equal(t, "application/x-ndjson", req.header.Get("Content-Type"))
+	assert.Equal(t, "value", req.header.Get("X-Custom"))
+	assert.Equal(t, "Basic "+base64.StdEncoding.EncodeToString([]byte("user:pass")), req.header.Get("Authorization"))
+
+	lines := strings.Split(strings.TrimSpace(req.body), "\n")
+	require.Len(t, lines, 2)
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &doc))
+	assert.Equal(t, "hello", doc["message"])
+	assert.Equal(t, float64(1), doc["n"])
+}
+
+func TestPublishJSONArray(t *testing.T) {
+	srv := newTestServer(t)
+	c := newTestClient(t, mapstr.M{
+		"url":            srv.URL,
+		"method":         "PUT",
+		"api_key":        "secret",
+		"api_key_header": "X-API-Key",
+		"body.format":    "json_array",
+	})
+
+	require.NoError(t, c.Publish(context.Background(), outest.NewBatch(testEvents(3)...)))
+	require.Len(t, srv.requests, 1)
+	req := srv.requests[0]
+	assert.Equal(t, "PUT", req.method)
+	assert.Equal(t, "secret", req.header.Get("X-API-Key"))
+
+	var docs []map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(req.body), &docs))
+	assert.Len(t, docs, 3)
+}
+
+func TestPublishTemplatePerEvent(t *testing.T) {
+	srv := newTestServer(t)
+	c := newTestClient(t, mapstr.M{
+		"url":               srv.URL,
+		"mode":              "event",
+		"api_key":           "secret",
+		"body.format":       "template",
+		"body.template":     `{"summary":"{{ .Event.message }} {{ .Event.n }}","raw":{{ json .Event }}}`,
+		"body.content_type": "application/vnd.ticket+json",
+	})
+
+	require.NoError(t, c.Publish(context.Background(), outest.NewBatch(testEvents(2)...)))
+	require.Len(t, srv.requests, 2)
+	for i, req := range srv.requests {
+		assert.Equal(t, "ApiKey secret", req.header.Get("Authorization"))
+		assert.Equal(t, "application/vnd.ticket+json", req.header.Get("Content-Type"))
+		var doc struct {
+			Summary string                 `json:"summary"`
+			Raw     map[string]interface{} `json:"raw"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(req.body), &doc))
+		assert.Equal(t, "hello "+string(rune('0'+i)), doc.Summary)
+		assert.Equal(t, "2024-01-01T00:00:00.000Z", doc.Raw["@timestamp"])
+	}
+}
+
+func TestPublishRetry(t *testing.T) {
+	srv := newTestServer(t, http.StatusServiceUnavailable)
+	c := newTestClient(t, mapstr.M{"url": srv.URL})
+
+	batch := outest.NewBatch(testEvents(2)...)
+	assert.Error(t, c.Publish(context.Background(), batch))
+	require.Len(t, batch.Signals, 1)
+	assert.Equal(t, outest.BatchRetryEvents, batch.Signals[0].Tag)
+	assert.Len(t, batch.Signals[0].Events, 2)
+
+	// Later events are retried in event mode, earlier ones were sent.
+	srv.mu.Lock()
+	srv.status = []int{http.StatusOK, http.StatusTooManyRequests}
+	srv.mu.Unlock()
+	c = newTestClient(t, mapstr.M{"url": srv.URL, "mode": "event"})
+	batch = outest.NewBatch(testEvents(3)...)
+	assert.Error(t, c.Publish(context.Background(), batch))
+	require.Len(t, batch.Signals, 1)
+	assert.Equal(t, outest.BatchRetryEvents, batch.Signals[0].Tag)
+	assert.Len(t, batch.Signals[0].Events, 2)
+}
+
+func TestPublishDropOnClientError(t *testing.T) {
+	srv := newTestServer(t, http.StatusBadRequest)
+	c := newTestClient(t, mapstr.M{"url": srv.URL})
+
+	batch := outest.NewBatch(testEvents(2)...)
+	require.NoError(t, c.Publish(context.Background(), batch))
+	require.Len(t, batch.Signals, 1)
+	assert.Equal(t, outest.BatchACK, batch.Signals[0].Tag)
+}
+
+func TestPublishOAuth2(t *testing.T) {
+	tokens := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"token","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer tokens.Close()
+
+	srv := newTestServer(t)
+	c := newTestClient(t, mapstr.M{
+		"url": srv.URL,
+		"oauth2": mapstr.M{
+			"client.id":     "id",
+			"client.secret": "secret",
+			"token_url":     tokens.URL,
+		},
+	})
+
+	require.NoError(t, c.Publish(context.Background(), outest.NewBatch(testEvents(1)...)))
+	require.Len(t, srv.requests, 1)
+	assert.Equal(t, "Bearer token", srv.requests[0].header.Get("Authorization"))
+}
+
+func TestRateLimit(t *testing.T) {
+	srv := newTestServer(t)
+	c := newTestClient(t, mapstr.M{
+		"url":              srv.URL,
+		"mode":             "event",
+		"rate_limit.limit": 20,
+		"rate_limit.burst": 1,
+	})
+
+	begin := time.Now()
+	require.NoError(t, c.Publish(context.Background(), outest.NewBatch(testEvents(5)...)))
+	assert.GreaterOrEqual(t, time.Since(begin), 150*time.Millisecond)
+	assert.Len(t, srv.requests, 5)
+}
@@ -0,0 +1,173 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package httpout
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/transport/httpcommon"
+)
+
+const (
+	modeBatch = "batch"
+	modeEvent = "event"
+
+	formatNDJSON    = "ndjson"
+	formatJSONArray = "json_array"
+	formatTemplate  = "template"
+)
+
+type httpOutputConfig struct {
+	URL           string            `config:"url" validate:"required"`
+	Method        string            `config:"method"`
+	Headers       map[string]string `config:"headers"`
+	Username      string            `config:"username"`
+	Password      string            `config:"password"`
+	APIKey        string            `config:"api_key"`
+	APIKeyHeader  string            `config:"api_key_header"`
+	OAuth2        *oauth2Config     `config:"oauth2"`
+	Mode          string            `config:"mode"`
+	Body          bodyConfig        `config:"body"`
+	BulkMaxSize   int               `config:"bulk_max_size"`
+	Workers       int               `config:"worker" validate:"min=1"`
+	MaxRetries    int               `config:"max_retries" validate:"min=-1"`
+	RetryOnStatus []int             `config:"retry_on_status"`
+	Backoff       Backoff           `config:"backoff"`
+	RateLimit     rateLimitConfig   `config:"rate_limit"`
+	Queue         config.Namespace  `config:"queue"`
+
+	Transport httpcommon.HTTPTransportSettings `config:",inline"`
+}
+
+type bodyConfig struct {
+	// Format is one of ndjson, json_array or template.
+	Format string `config:"format"`
+	// Template is a Go text/template rendered with .Events (and .Event in
+	// event mode) if Format is template.
+	Template    string `config:"template"`
+	ContentType string `config:"content_type"`
+}
+
+// oauth2Config configures the OAuth2 client credentials flow.
+type oauth2Config struct {
+	ClientID       string              `config:"client.id" validate:"required"`
+	ClientSecret   string              `config:"client.secret" validate:"required"`
+	TokenURL       string              `config:"token_url" validate:"required"`
+	Scopes         []string            `config:"scopes"`
+	EndpointParams map[string][]string `config:"endpoint_params"`
+}
+
+// rateLimitConfig limits the number of requests per second. A limit of 0
+// disables rate limiting.
+type rateLimitConfig struct {
+	Limit float64 `config:"limit" validate:"min=0"`
+	Burst int     `config:"burst" validate:"min=0"`
+}
+
+type Backoff struct {
+	Init time.Duration
+	Max  time.Duration
+}
+
+func defaultConfig() httpOutputConfig {
+	return httpOutputConfig{
+		Method:        "POST",
+		APIKeyHeader:  "Authorization",
+		Mode:          modeBatch,
+		Body:          bodyConfig{Format: formatNDJSON},
+		BulkMaxSize:   50,
+		Workers:       1,
+		MaxRetries:    3,
+		RetryOnStatus: []int{429, 500, 502, 503, 504},
+		Backoff: Backoff{
+			Init: 1 * time.Second,
+			Max:  60 * time.Second,
+		},
+		RateLimit: rateLimitConfig{Burst: 1},
+		Transport: httpcommon.DefaultHTTPTransportSettings(),
+	}
+}
+
+func readConfig(cfg *config.C) (*httpOutputConfig, error) {
+	c := defaultConfig()
+	if err := cfg.Unpack(&c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (c *httpOutputConfig) Validate() error {
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("url scheme must be http or https, got %q", u.Scheme)
+	}
+
+	switch c.Method = strings.ToUpper(c.Method); c.Method {
+	case "POST", "PUT", "PATCH":
+	default:
+		return fmt.Errorf("method must be POST, PUT or PATCH, got %q", c.Method)
+	}
+
+	switch c.Mode {
+	case modeBatch, modeEvent:
+	default:
+		return fmt.Errorf("mode must be batch or event, got %q", c.Mode)
+	}
+
+	switch c.Body.Format {
+	case formatNDJSON, formatJSONArray:
+	case formatTemplate:
+		if c.Body.Template == "" {
+			return errors.New("body.template is required if body.format is template")
+		}
+		if _, err := newBodyEncoder(c.Body); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("body.format must be ndjson, json_array or template, got %q", c.Body.Format)
+	}
+
+	auth := 0
+	if c.Username != "" || c.Password != "" {
+		auth++
+	}
+	if c.APIKey != "" {
+		auth++
+	}
+	if c.OAuth2 != nil {
+		auth++
+	}
+	if auth > 1 {
+		return errors.New("only one of username/password, api_key and oauth2 can be set")
+	}
+
+	for _, status := range c.RetryOnStatus {
+		if status < 100 || status > 599 {
+			return fmt.Errorf("invalid status code %d in retry_on_status", status)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package httpout
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func TestConfig(t *testing.T) {
+	for name, test := range map[string]struct {
+		config map[string]interface{}
+		err    string
+	}{
+		"minimal": {
+			config: mapstr.M{"url": "https://example.com/hook"},
+		},
+		"missing url": {
+			config: mapstr.M{},
+			err:    "accessing 'url'",
+		},
+		"invalid scheme": {
+			config: mapstr.M{"url": "ftp://example.com"},
+			err:    "url scheme must be http or https",
+		},
+		"invalid method": {
+			config: mapstr.M{"url": "http://example.com", "method": "GET"},
+			err:    "method must be POST, PUT or PATCH",
+		},
+		"lowercase method": {
+			config: mapstr.M{"url": "http://example.com", "method": "put"},
+		},
+		"template without template": {
+			config: mapstr.M{"url": "http://example.com", "body.format": "template"},
+			err:    "body.template is required",
+		},
+		"invalid template": {
+			config: mapstr.M{"url": "http://example.com", "body.format": "template", "body.template": "{{ .Event"},
+			err:    "invalid body.template",
+		},
+		"multiple auth": {
+			config: mapstr.M{"url": "http://example.com", "api_key": "key", "username": "user"},
+			err:    "only one of",
+		},
+		"oauth2": {
+			config: mapstr.M{"url": "http://example.com", "oauth2": mapstr.M{
+				"client.id": "id", "client.secret": "secret", "token_url": "http://example.com/token",
+			}},
+		},
+		"invalid retry status": {
+			config: mapstr.M{"url": "http://example.com", "retry_on_status": []int{42}},
+			err:    "invalid status code",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := readConfig(config.MustNewConfigFrom(test.config))
+			if test.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Contains(t, []string{"POST", "PUT"}, cfg.Method)
+		})
+	}
+}
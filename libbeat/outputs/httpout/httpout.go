@@ -0,0 +1,53 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package httpout
+
+import (
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/outputs"
+	"github.com/elastic/elastic-agent-libs/config"
+)
+
+func init() {
+	outputs.RegisterType("http", makeHTTP)
+}
+
+// makeHTTP creates an output that sends events to an HTTP endpoint, e.g. a
+// webhook of a SIEM or ticketing system.
+func makeHTTP(
+	_ outputs.IndexManager,
+	beat beat.Info,
+	observer outputs.Observer,
+	cfg *config.C,
+) (outputs.Group, error) {
+	httpConfig, err := readConfig(cfg)
+	if err != nil {
+		return outputs.Fail(err)
+	}
+
+	limiter := newLimiter(httpConfig.RateLimit)
+	clients := make([]outputs.NetworkClient, httpConfig.Workers)
+	for i := range clients {
+		client, err := newClient(beat, observer, httpConfig, limiter)
+		if err != nil {
+			return outputs.Fail(err)
+		}
+		clients[i] = outputs.WithBackoff(client, httpConfig.Backoff.Init, httpConfig.Backoff.Max)
+	}
+	return outputs.SuccessNet(httpConfig.Queue, true, httpConfig.BulkMaxSize, httpConfig.MaxRetries, nil, clients)
+}
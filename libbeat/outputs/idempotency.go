@@ -0,0 +1,46 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package outputs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+)
+
+// IdempotencyKey returns a key identifying the event by its position in the
+// source of its input, or an empty string if the input does not provide one.
+// Positions are only unique for a Beat, so the key is derived from the ID of
+// the Beat as well. Outputs use it to make retried events idempotent, e.g. as
+// document ID.
+//
+// The key does not depend on the fields of the event, so an event read again
+// after a restart gets the same key even if fields set when reading it, like
+// event.created, differ.
+func IdempotencyKey(beatID string, e *beat.Event) string {
+	key := e.IdempotencyKey()
+	if key == "" {
+		return ""
+	}
+	h := sha256.New()
+	h.Write([]byte(beatID))
+	h.Write([]byte{0})
+	h.Write([]byte(key))
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
@@ -0,0 +1,60 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package outputs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+type testKeyer string
+
+func (k testKeyer) IdempotencyKey() string { return string(k) }
+
+func TestIdempotencyKey(t *testing.T) {
+	event := beat.Event{
+		Fields:  mapstr.M{"message": "first line", "log": mapstr.M{"offset": 1024}},
+		Private: testKeyer("filestream::my-input::native::1-2::1024"),
+	}
+
+	key := IdempotencyKey("beat-1", &event)
+	assert.Len(t, key, 32)
+	assert.Equal(t, key, IdempotencyKey("beat-1", &event), "keys must be stable")
+	assert.NotEqual(t, key, IdempotencyKey("beat-2", &event), "keys must depend on the Beat")
+
+	other := beat.Event{Fields: event.Fields, Private: testKeyer("filestream::my-input::native::1-2::2048")}
+	assert.NotEqual(t, key, IdempotencyKey("beat-1", &other))
+
+	// Fields set when the event is read differ when it is read again.
+	reread := beat.Event{
+		Fields: mapstr.M{
+			"message": "first line",
+			"log":     mapstr.M{"offset": 1024},
+			"event":   mapstr.M{"created": "2024-01-02T03:04:05Z"},
+		},
+		Private: event.Private,
+	}
+	assert.Equal(t, key, IdempotencyKey("beat-1", &reread), "events read again must keep their key")
+
+	assert.Empty(t, IdempotencyKey("beat-1", &beat.Event{}))
+	assert.Empty(t, IdempotencyKey("beat-1", &beat.Event{Private: testKeyer("")}))
+}
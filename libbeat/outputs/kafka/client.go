@@ -55,6 +55,10 @@ type client struct {
 
 	recordHeaders []sarama.RecordHeader
 
+	// idempotencyBeatID is the ID of the Beat used to derive the idempotency
+	// key header of the messages, empty if disabled.
+	idempotencyBeatID string
+
 	wg sync.WaitGroup
 }
 
@@ -170,6 +174,7 @@ func (c *client) Publish(_ context.Context, batch publisher.Batch) error {
 		msg, err := c.getEventMessage(d)
 		if err != nil {
 			c.log.Errorf("Dropping event: %+v", err)
+			d.Reject(fmt.Errorf("%w: %w", publisher.ErrEventRejected, err))
 			ref.done()
 			c.observer.PermanentErrors(1)
 			continue
@@ -249,6 +254,10 @@ func (c *client) getEventMessage(data *publisher.Event) (*message, error) {
 		}
 	}
 
+	if c.idempotencyBeatID != "" {
+		msg.idempotencyKey = outputs.IdempotencyKey(c.idempotencyBeatID, event)
+	}
+
 	return msg, nil
 }
 
@@ -361,12 +370,14 @@ func (r *msgRef) fail(msg *message, err error) {
 	case errors.Is(err, sarama.ErrInvalidMessage):
 		r.client.log.Errorf("Kafka (topic=%v): dropping invalid message", msg.topic)
 		r.client.observer.PermanentErrors(1)
+		msg.data.Reject(fmt.Errorf("%w: %w", publisher.ErrEventRejected, err))
 
 	case errors.Is(err, sarama.ErrMessageSizeTooLarge) || errors.Is(err, sarama.ErrInvalidMessageSize):
 		r.client.log.Errorf("Kafka (topic=%v): dropping too large message of size %v.",
 			msg.topic,
 			len(msg.key)+len(msg.value))
 		r.client.observer.PermanentErrors(1)
+		msg.data.Reject(fmt.Errorf("%w: %w", publisher.ErrEventRejected, err))
 
 	case errors.Is(err, breaker.ErrBreakerOpen):
 		// Add this message to the failed list, but don't overwrite r.err since
@@ -78,6 +78,7 @@ type kafkaConfig struct {
 	Sasl               kafka.SaslConfig          `config:"sasl"`
 	EnableFAST         bool                      `config:"enable_krb5_fast"`
 	Queue              config.Namespace          `config:"queue"`
+	IdempotencyKeys    bool                      `config:"idempotency_keys"`
 
 	// Currently only used for validation. Those values are later
 	// unpacked into temporary structs whenever they're necessary.
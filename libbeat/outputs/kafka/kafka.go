@@ -78,6 +78,9 @@ func makeKafka(
 	if err != nil {
 		return outputs.Fail(err)
 	}
+	if kConfig.IdempotencyKeys {
+		client.idempotencyBeatID = beat.ID.String()
+	}
 
 	retry := 0
 	if kConfig.MaxRetries < 0 {
@@ -18,11 +18,20 @@
 package kafka
 
 import (
+	"errors"
 	"testing"
 
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
 	"github.com/elastic/beats/v7/libbeat/beat"
 	"github.com/elastic/beats/v7/libbeat/management"
+	"github.com/elastic/beats/v7/libbeat/outputs"
+	"github.com/elastic/beats/v7/libbeat/outputs/outest"
+	"github.com/elastic/beats/v7/libbeat/publisher"
 	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
 	"github.com/elastic/elastic-agent-libs/mapstr"
 )
 
@@ -92,3 +101,57 @@ func TestBuildTopicSelector(t *testing.T) {
 		}
 	})
 }
+
+func TestIdempotencyKeyHeader(t *testing.T) {
+	shared := make([]sarama.RecordHeader, 1, 4)
+	shared[0] = sarama.RecordHeader{Key: []byte("k"), Value: []byte("v")}
+	ref := &msgRef{client: &client{recordHeaders: shared}}
+
+	first := &message{ref: ref, idempotencyKey: "key-1"}
+	first.initProducerMessage()
+	second := &message{ref: ref, idempotencyKey: "key-2"}
+	second.initProducerMessage()
+	none := &message{ref: ref}
+	none.initProducerMessage()
+
+	assert.Equal(t, []sarama.RecordHeader{
+		{Key: []byte("k"), Value: []byte("v")},
+		{Key: []byte("idempotency_key"), Value: []byte("key-1")},
+	}, first.msg.Headers)
+	assert.Equal(t, []sarama.RecordHeader{
+		{Key: []byte("k"), Value: []byte("v")},
+		{Key: []byte("idempotency_key"), Value: []byte("key-2")},
+	}, second.msg.Headers)
+	assert.Equal(t, shared, none.msg.Headers)
+}
+
+func TestRejectPermanentErrors(t *testing.T) {
+	var rejected []error
+	event := func(i int) publisher.Event {
+		return publisher.Event{
+			Content:  beat.Event{Fields: mapstr.M{"i": i}},
+			OnReject: func(err error) { rejected = append(rejected, err) },
+		}
+	}
+	tooLarge, failed := event(1), event(2)
+
+	batch := outest.NewBatch()
+	ref := &msgRef{
+		client: &client{log: logp.NewLogger("kafka"), observer: outputs.NewNilObserver()},
+		count:  3,
+		total:  3,
+		batch:  batch,
+	}
+	ref.fail(&message{data: tooLarge}, sarama.ErrMessageSizeTooLarge)
+	ref.fail(&message{data: failed}, errors.New("connection reset"))
+	ref.done() // The third message is published.
+
+	require.Len(t, rejected, 1, "only the event that can't be published must be rejected")
+	assert.ErrorIs(t, rejected[0], publisher.ErrEventRejected)
+	assert.ErrorIs(t, rejected[0], sarama.ErrMessageSizeTooLarge)
+
+	require.Len(t, batch.Signals, 1)
+	assert.Equal(t, outest.BatchRetryEvents, batch.Signals[0].Tag)
+	require.Len(t, batch.Signals[0].Events, 1)
+	assert.Equal(t, failed.Content, batch.Signals[0].Events[0].Content)
+}
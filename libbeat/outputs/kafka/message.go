@@ -18,6 +18,7 @@
 package kafka
 
 import (
+	"slices"
 	"time"
 
 	"github.com/Shopify/sarama"
@@ -37,11 +38,16 @@ type message struct {
 	hash      uint32
 	partition int32
 
+	idempotencyKey string
+
 	data publisher.Event
 }
 
 var kafkaMessageKey interface{} = int(0)
 
+// idempotencyKeyHeader is the header holding the idempotency key of the event.
+var idempotencyKeyHeader = []byte("idempotency_key")
+
 func (m *message) initProducerMessage() {
 	m.msg = sarama.ProducerMessage{
 		Metadata:  m,
@@ -54,4 +60,11 @@ func (m *message) initProducerMessage() {
 	if m.ref != nil {
 		m.msg.Headers = m.ref.client.recordHeaders
 	}
+	if m.idempotencyKey != "" {
+		// Clip the headers shared by all messages before appending to them.
+		m.msg.Headers = append(slices.Clip(m.msg.Headers), sarama.RecordHeader{
+			Key:   idempotencyKeyHeader,
+			Value: []byte(m.idempotencyKey),
+		})
+	}
 }
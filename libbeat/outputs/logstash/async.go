@@ -39,6 +39,7 @@ type asyncClient struct {
 	observer outputs.Observer
 	client   *v2.AsyncClient
 	win      *window
+	health   *hostHealth
 
 	connect func() error
 
@@ -49,10 +50,15 @@ type msgRef struct {
 	client    *asyncClient
 	count     atomic.Uint32
 	batch     publisher.Batch
-	slice     []publisher.Event
-	err       error
 	win       *window
 	batchSize int
+	start     time.Time
+
+	// mu protects slice and err, updated by the ACK callbacks of the
+	// connection and by Publish if sending fails.
+	mu    sync.Mutex
+	slice []publisher.Event
+	err   error
 }
 
 func newAsyncClient(
@@ -71,6 +77,7 @@ func newAsyncClient(
 
 	if config.SlowStart {
 		c.win = newWindower(defaultStartMaxWindowSize, config.BulkMaxSize)
+		c.win.slowACK = config.Timeout / 2
 	}
 
 	if config.TTL != 0 {
@@ -95,6 +102,11 @@ func newAsyncClient(
 		if err == nil {
 			c.client, err = clientFactory(c.Client)
 		}
+		if err == nil && c.win != nil {
+			// ACKs of the previous connection don't tell anything about
+			// the window size the new connection can handle.
+			c.win.reset(defaultStartMaxWindowSize)
+		}
 		return err
 	}
 
@@ -152,8 +164,9 @@ func (c *asyncClient) Publish(_ context.Context, batch publisher.Batch) error {
 		slice:     events,
 		batchSize: len(events),
 		win:       c.win,
-		err:       nil,
+		start:     time.Now(),
 	}
+	c.health.begin()
 	defer ref.dec()
 
 	for len(events) > 0 {
@@ -174,6 +187,10 @@ func (c *asyncClient) Publish(_ context.Context, batch publisher.Batch) error {
 
 		events = events[n:]
 		if err != nil {
+			// Events not sent yet are retried together with the events
+			// Logstash didn't ACK.
+			ref.count.Inc()
+			ref.fail(0, err)
 			_ = c.Close()
 			return err
 		}
@@ -239,18 +256,22 @@ func (r *msgRef) callback(seq uint32, err error) {
 
 func (r *msgRef) done(n uint32) {
 	r.client.observer.AckedEvents(int(n))
+	r.mu.Lock()
 	r.slice = r.slice[n:]
+	r.mu.Unlock()
 	if r.win != nil {
-		r.win.tryGrowWindow(r.batchSize)
+		r.win.ackReceived(r.batchSize, time.Since(r.start))
 	}
 	r.dec()
 }
 
 func (r *msgRef) fail(n uint32, err error) {
+	r.mu.Lock()
 	if r.err == nil {
 		r.err = err
 	}
 	r.slice = r.slice[n:]
+	r.mu.Unlock()
 	if r.win != nil {
 		r.win.shrinkWindow()
 	}
@@ -266,16 +287,20 @@ func (r *msgRef) dec() {
 		return
 	}
 
-	if L := len(r.slice); L > 0 {
+	r.mu.Lock()
+	slice, err := r.slice, r.err
+	r.mu.Unlock()
+
+	if L := len(slice); L > 0 {
 		r.client.observer.RetryableErrors(L)
 	}
 
-	err := r.err
+	r.client.health.end(time.Since(r.start), err)
 	if err == nil {
 		r.batch.ACK()
 		return
 	}
 
-	r.batch.RetryEvents(r.slice)
+	r.batch.RetryEvents(slice)
 	r.client.log.Errorf("Failed to publish events caused by: %+v", err)
 }
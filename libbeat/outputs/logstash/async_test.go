@@ -21,13 +21,19 @@ package logstash
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
 	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/atomic"
 	"github.com/elastic/beats/v7/libbeat/outputs"
 	"github.com/elastic/beats/v7/libbeat/outputs/outest"
+	"github.com/elastic/elastic-agent-libs/logp"
 	"github.com/elastic/elastic-agent-libs/transport"
 )
 
@@ -50,6 +56,58 @@ func TestAsyncStructuredEvent(t *testing.T) {
 	testStructuredEvent(t, makeAsyncTestClient)
 }
 
+// TestAsyncSendErrorWithPendingACKs fails a send in Publish while the ACK
+// callbacks of the windows already sent run on the connection's goroutine,
+// run it with -race.
+func TestAsyncSendErrorWithPendingACKs(t *testing.T) {
+	observer := outputs.NewNilObserver()
+	client := &asyncClient{
+		log:      logp.NewLogger("test"),
+		observer: observer,
+		win:      newWindower(defaultStartMaxWindowSize, 100),
+		health:   newHostHealth("test", time.Second, observer),
+	}
+
+	for i := 0; i < 100; i++ {
+		events := make([]beat.Event, 10)
+		for j := range events {
+			events[j] = beat.Event{Timestamp: time.Now(), Fields: map[string]interface{}{"i": j}}
+		}
+		batch := outest.NewBatch(events...)
+		ref := &msgRef{
+			client:    client,
+			count:     atomic.MakeUint32(1),
+			batch:     batch,
+			slice:     batch.Events(),
+			batchSize: len(events),
+			win:       client.win,
+			start:     time.Now(),
+		}
+		client.health.begin()
+
+		// Two windows of 3 events sent, the ACKs are pending.
+		ref.count.Add(2)
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ref.callback(3, nil)
+			ref.callback(0, errors.New("connection reset"))
+		}()
+
+		// The third send fails, like in Publish.
+		ref.count.Inc()
+		ref.fail(0, errors.New("send failed"))
+		ref.dec()
+		wg.Wait()
+
+		require.Len(t, batch.Signals, 1)
+		assert.Equal(t, outest.BatchRetryEvents, batch.Signals[0].Tag)
+		assert.Len(t, batch.Signals[0].Events, 7, "the events of the failed windows must be retried")
+	}
+}
+
 func makeAsyncTestClient(conn *transport.Client) testClientDriver {
 	config := defaultConfig()
 	config.Timeout = 1 * time.Second
@@ -0,0 +1,196 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logstash
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/outputs"
+	"github.com/elastic/beats/v7/libbeat/publisher"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/testing"
+)
+
+var errNoHostAvailable = errors.New("no Logstash host available")
+
+// balancedClient publishes each batch to one of several Logstash hosts. The
+// host is chosen with the power of two choices: of two random hosts, the one
+// with the better health score gets the batch. Compared to round-robin this
+// shifts load away from slow or failing hosts while still spreading batches
+// between healthy ones.
+type balancedClient struct {
+	log   *logp.Logger
+	hosts []*balancedHost
+}
+
+type balancedHost struct {
+	client    outputs.NetworkClient
+	health    *hostHealth
+	connected bool
+}
+
+func newBalancedClient(hosts []*balancedHost) *balancedClient {
+	return &balancedClient{
+		log:   logp.NewLogger("logstash"),
+		hosts: hosts,
+	}
+}
+
+// Connect connects to all hosts. It only fails if no host is reachable,
+// hosts failing to connect are retried once their cooldown expired.
+func (b *balancedClient) Connect(ctx context.Context) error {
+	var errs []error
+	connected := false
+	for _, h := range b.hosts {
+		if !h.connected {
+			if err := h.client.Connect(ctx); err != nil {
+				h.health.markDown()
+				errs = append(errs, err)
+				continue
+			}
+			h.connected = true
+		}
+		connected = true
+	}
+	if !connected {
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
+func (b *balancedClient) Close() error {
+	var errs []error
+	for _, h := range b.hosts {
+		if h.connected {
+			h.connected = false
+			if err := h.client.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Publish sends the batch to the healthiest of two random hosts. If the host
+// fails the events are returned to the pipeline and the error is only
+// reported if no other host is available, so a degraded host doesn't make the
+// worker back off.
+func (b *balancedClient) Publish(ctx context.Context, batch publisher.Batch) error {
+	tried := make(map[*balancedHost]bool, len(b.hosts))
+	for {
+		h := b.pick(tried)
+		if h == nil {
+			batch.Retry()
+			return errNoHostAvailable
+		}
+		tried[h] = true
+
+		if !h.connected {
+			if err := h.client.Connect(ctx); err != nil {
+				b.log.Debugf("Failed to connect to %v: %v", h.client, err)
+				h.health.markDown()
+				continue
+			}
+			h.connected = true
+		}
+
+		err := h.client.Publish(ctx, batch)
+		if err == nil {
+			return nil
+		}
+		_ = h.client.Close()
+		h.connected = false
+		h.health.markDown()
+		if !b.hasAvailable(tried) {
+			return err
+		}
+		b.log.Errorf("Failed to publish events to %v, using other hosts: %v", h.client, err)
+		return nil
+	}
+}
+
+// pick returns the better of two random hosts that weren't tried yet. Hosts
+// cooling down after a failure are only picked if all hosts are cooling
+// down.
+func (b *balancedClient) pick(tried map[*balancedHost]bool) *balancedHost {
+	now := time.Now()
+	var available, cooling []*balancedHost
+	for _, h := range b.hosts {
+		switch {
+		case tried[h]:
+		case h.health.available(now):
+			available = append(available, h)
+		default:
+			cooling = append(cooling, h)
+		}
+	}
+	candidates := available
+	if len(candidates) == 0 {
+		candidates = cooling
+	}
+
+	switch len(candidates) {
+	case 0:
+		return nil
+	case 1:
+		return candidates[0]
+	}
+	i := rand.Intn(len(candidates))
+	j := rand.Intn(len(candidates) - 1)
+	if j >= i {
+		j++
+	}
+	if candidates[j].health.score() < candidates[i].health.score() {
+		return candidates[j]
+	}
+	return candidates[i]
+}
+
+// hasAvailable returns true if a host that wasn't tried yet is not cooling
+// down.
+func (b *balancedClient) hasAvailable(tried map[*balancedHost]bool) bool {
+	now := time.Now()
+	for _, h := range b.hosts {
+		if !tried[h] && h.health.available(now) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *balancedClient) String() string {
+	names := make([]string, len(b.hosts))
+	for i, h := range b.hosts {
+		names[i] = h.client.String()
+	}
+	return "balance(" + strings.Join(names, ",") + ")"
+}
+
+func (b *balancedClient) Test(d testing.Driver) {
+	for _, h := range b.hosts {
+		t, ok := h.client.(testing.Testable)
+		if !ok {
+			d.Fatal("output", errors.New("client doesn't support testing"))
+		}
+		d.Run(h.health.host, t.Test)
+	}
+}
@@ -0,0 +1,157 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build !integration
+
+package logstash
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/outputs"
+	"github.com/elastic/beats/v7/libbeat/outputs/outest"
+	"github.com/elastic/beats/v7/libbeat/publisher"
+	"github.com/elastic/elastic-agent-libs/transport"
+)
+
+type fakeHostClient struct {
+	name       string
+	connectErr error
+	publishErr error
+	published  int
+}
+
+func (c *fakeHostClient) Connect(context.Context) error { return c.connectErr }
+func (c *fakeHostClient) Close() error                  { return nil }
+func (c *fakeHostClient) String() string                { return c.name }
+
+func (c *fakeHostClient) Publish(_ context.Context, batch publisher.Batch) error {
+	c.published++
+	if c.publishErr != nil {
+		batch.Retry()
+		return c.publishErr
+	}
+	batch.ACK()
+	return nil
+}
+
+func newTestBalancer(clients ...*fakeHostClient) *balancedClient {
+	hosts := make([]*balancedHost, len(clients))
+	for i, c := range clients {
+		hosts[i] = &balancedHost{
+			client: c,
+			health: newHostHealth(c.name, time.Minute, outputs.NewNilObserver()),
+		}
+	}
+	return newBalancedClient(hosts)
+}
+
+func TestHostHealthScore(t *testing.T) {
+	fast := newHostHealth("fast", time.Minute, outputs.NewNilObserver())
+	slow := newHostHealth("slow", time.Minute, outputs.NewNilObserver())
+	failing := newHostHealth("failing", 0, outputs.NewNilObserver())
+
+	for i := 0; i < 10; i++ {
+		for _, h := range []*hostHealth{fast, slow, failing} {
+			h.begin()
+		}
+		fast.end(10*time.Millisecond, nil)
+		slow.end(100*time.Millisecond, nil)
+		failing.end(10*time.Millisecond, errors.New("oops"))
+	}
+
+	assert.Less(t, fast.score(), slow.score())
+	assert.Less(t, fast.score(), failing.score())
+	assert.InDelta(t, 10*time.Millisecond, time.Duration(fast.latency), float64(time.Millisecond))
+	assert.Equal(t, uint64(10), failing.failures)
+
+	// In-flight batches make a host less attractive.
+	before := fast.score()
+	fast.begin()
+	assert.Greater(t, fast.score(), before)
+}
+
+func TestBalancedClientPrefersHealthyHost(t *testing.T) {
+	good := &fakeHostClient{name: "good"}
+	bad := &fakeHostClient{name: "bad"}
+	b := newTestBalancer(good, bad)
+	require.NoError(t, b.Connect(context.Background()))
+
+	b.hosts[0].health.begin()
+	b.hosts[0].health.end(time.Millisecond, nil)
+	b.hosts[1].health.begin()
+	b.hosts[1].health.end(time.Second, nil)
+
+	for i := 0; i < 100; i++ {
+		require.NoError(t, b.Publish(context.Background(), outest.NewBatch(beat.Event{})))
+	}
+	assert.Equal(t, 100, good.published)
+	assert.Equal(t, 0, bad.published)
+}
+
+func TestBalancedClientFailover(t *testing.T) {
+	failing := &fakeHostClient{name: "failing", publishErr: errors.New("oops")}
+	healthy := &fakeHostClient{name: "healthy"}
+	b := newTestBalancer(failing, healthy)
+	require.NoError(t, b.Connect(context.Background()))
+
+	for i := 0; i < 20; i++ {
+		batch := outest.NewBatch(beat.Event{})
+		require.NoError(t, b.Publish(context.Background(), batch))
+	}
+	// After the first failure the host is cooling down and skipped.
+	assert.LessOrEqual(t, failing.published, 1)
+	assert.GreaterOrEqual(t, healthy.published, 19)
+
+	// Without any other host the error is returned.
+	healthy.publishErr = errors.New("oops")
+	err := b.Publish(context.Background(), outest.NewBatch(beat.Event{}))
+	assert.Error(t, err)
+}
+
+func TestBalancedClientConnect(t *testing.T) {
+	down := &fakeHostClient{name: "down", connectErr: errors.New("refused")}
+	up := &fakeHostClient{name: "up"}
+	b := newTestBalancer(down, up)
+	require.NoError(t, b.Connect(context.Background()), "one reachable host is enough")
+	assert.False(t, b.hosts[0].health.available(time.Now()))
+
+	b = newTestBalancer(down)
+	assert.Error(t, b.Connect(context.Background()))
+}
+
+func TestMakeBalancedClients(t *testing.T) {
+	cfg := defaultConfig()
+	// The host list is expanded by outputs.ReadHostList, once per worker.
+	hosts := []string{"a:5044", "b:5044", "a:5044", "b:5044", "a:5044", "b:5044"}
+
+	clients, err := makeBalancedClients(beat.Info{}, transport.Config{}, outputs.NewNilObserver(), &cfg, hosts)
+	require.NoError(t, err)
+
+	// One balanced client per worker, with one connection per host.
+	require.Len(t, clients, 3)
+	for _, c := range clients {
+		assert.Equal(t, "backoff(balance(async(tcp://a:5044),async(tcp://b:5044)))", c.String())
+	}
+}
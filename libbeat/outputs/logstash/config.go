@@ -44,6 +44,15 @@ type Config struct {
 	Backoff          Backoff               `config:"backoff"`
 	EscapeHTML       bool                  `config:"escape_html"`
 	Queue            config.Namespace      `config:"queue"`
+	HealthScoring    HealthScoring         `config:"health_scoring"`
+}
+
+// HealthScoring configures balancing batches between hosts based on their
+// latency and errors instead of round-robin. It requires loadbalance.
+type HealthScoring struct {
+	Enabled bool `config:"enabled"`
+	// Cooldown is how long a host is avoided after a failure.
+	Cooldown time.Duration `config:"cooldown" validate:"min=0"`
 }
 
 type Backoff struct {
@@ -66,6 +75,10 @@ func defaultConfig() Config {
 			Max:  60 * time.Second,
 		},
 		EscapeHTML: false,
+		HealthScoring: HealthScoring{
+			Enabled:  false,
+			Cooldown: 10 * time.Second,
+		},
 	}
 }
 
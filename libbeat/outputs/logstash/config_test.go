@@ -53,6 +53,9 @@ func TestConfig(t *testing.T) {
 				},
 				EscapeHTML: false,
 				Index:      "bar",
+				HealthScoring: HealthScoring{
+					Cooldown: 10 * time.Second,
+				},
 			},
 		},
 		"config given": {
@@ -77,6 +80,9 @@ func TestConfig(t *testing.T) {
 				},
 				EscapeHTML: false,
 				Index:      "beat-index",
+				HealthScoring: HealthScoring{
+					Cooldown: 10 * time.Second,
+				},
 			},
 		},
 		"removed config setting": {
@@ -0,0 +1,136 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logstash
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/outputs"
+)
+
+const (
+	// healthDecay is the weight of the latest batch in the moving averages
+	// of latency and error rate.
+	healthDecay = 0.2
+
+	// errorPenalty scales the score of a host by its error rate. A host
+	// failing every batch is scored like one being this many times slower.
+	errorPenalty = 10
+
+	// minScoreLatency is the latency assumed for hosts that respond faster,
+	// so that the number of in-flight batches still matters.
+	minScoreLatency = time.Millisecond
+)
+
+// hostHealth tracks the latency and errors of a Logstash host. It is shared
+// by all workers publishing to the host.
+type hostHealth struct {
+	host     string
+	observer outputs.Observer
+	cooldown time.Duration
+
+	mu        sync.Mutex
+	latency   float64 // nanoseconds
+	errorRate float64
+	inFlight  int
+	batches   uint64
+	failures  uint64
+	downUntil time.Time
+}
+
+func newHostHealth(host string, cooldown time.Duration, observer outputs.Observer) *hostHealth {
+	return &hostHealth{host: host, cooldown: cooldown, observer: observer}
+}
+
+// begin records a batch being sent to the host.
+func (h *hostHealth) begin() {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.inFlight++
+	h.reportLocked()
+}
+
+// end records a batch being ACKed or failed after took.
+func (h *hostHealth) end(took time.Duration, err error) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.inFlight--
+	h.batches++
+	failed := 0.0
+	if err != nil {
+		failed = 1
+		h.failures++
+		h.downUntil = time.Now().Add(h.cooldown)
+	}
+	if h.batches == 1 {
+		h.latency = float64(took)
+		h.errorRate = failed
+	} else {
+		h.latency += healthDecay * (float64(took) - h.latency)
+		h.errorRate += healthDecay * (failed - h.errorRate)
+	}
+	h.reportLocked()
+}
+
+// markDown excludes the host from balancing for the cooldown period, e.g.
+// after failing to connect.
+func (h *hostHealth) markDown() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.downUntil = time.Now().Add(h.cooldown)
+}
+
+// available returns false while the host is cooling down after a failure.
+func (h *hostHealth) available(now time.Time) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return !now.Before(h.downUntil)
+}
+
+// score returns the expected cost of sending a batch to the host. Lower is
+// better.
+func (h *hostHealth) score() float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.scoreLocked()
+}
+
+func (h *hostHealth) scoreLocked() float64 {
+	latency := math.Max(h.latency, float64(minScoreLatency))
+	return latency * float64(1+h.inFlight) * (1 + errorPenalty*h.errorRate)
+}
+
+func (h *hostHealth) reportLocked() {
+	h.observer.ReportHostHealth(h.host, outputs.HostHealth{
+		Score:     h.scoreLocked() / float64(time.Millisecond),
+		Latency:   time.Duration(h.latency),
+		ErrorRate: h.errorRate,
+		InFlight:  h.inFlight,
+		Batches:   h.batches,
+		Failures:  h.failures,
+	})
+}
@@ -63,27 +63,92 @@ func makeLogstash(
 		Stats:   observer,
 	}
 
-	clients := make([]outputs.NetworkClient, len(hosts))
-	for i, host := range hosts {
-		var client outputs.NetworkClient
-
-		conn, err := transport.NewClient(transp, "tcp", host, defaultPort)
+	if lsConfig.LoadBalance && lsConfig.HealthScoring.Enabled {
+		clients, err := makeBalancedClients(beat, transp, observer, lsConfig, hosts)
 		if err != nil {
 			return outputs.Fail(err)
 		}
+		return outputs.SuccessNet(lsConfig.Queue, lsConfig.LoadBalance, lsConfig.BulkMaxSize, lsConfig.MaxRetries, nil, clients)
+	}
 
-		if lsConfig.Pipelining > 0 {
-			client, err = newAsyncClient(beat, conn, observer, lsConfig)
-		} else {
-			client, err = newSyncClient(beat, conn, observer, lsConfig)
-		}
+	clients := make([]outputs.NetworkClient, len(hosts))
+	for i, host := range hosts {
+		client, err := makeClient(beat, transp, observer, lsConfig, host, nil)
 		if err != nil {
 			return outputs.Fail(err)
 		}
 
-		client = outputs.WithBackoff(client, lsConfig.Backoff.Init, lsConfig.Backoff.Max)
-		clients[i] = client
+		clients[i] = outputs.WithBackoff(client, lsConfig.Backoff.Init, lsConfig.Backoff.Max)
 	}
 
 	return outputs.SuccessNet(lsConfig.Queue, lsConfig.LoadBalance, lsConfig.BulkMaxSize, lsConfig.MaxRetries, nil, clients)
 }
+
+func makeClient(
+	beat beat.Info,
+	transp transport.Config,
+	observer outputs.Observer,
+	lsConfig *Config,
+	host string,
+	health *hostHealth,
+) (outputs.NetworkClient, error) {
+	conn, err := transport.NewClient(transp, "tcp", host, defaultPort)
+	if err != nil {
+		return nil, err
+	}
+
+	if lsConfig.Pipelining > 0 {
+		client, err := newAsyncClient(beat, conn, observer, lsConfig)
+		if err != nil {
+			return nil, err
+		}
+		client.health = health
+		return client, nil
+	}
+	client, err := newSyncClient(beat, conn, observer, lsConfig)
+	if err != nil {
+		return nil, err
+	}
+	client.health = health
+	return client, nil
+}
+
+// makeBalancedClients creates one balanced client per worker. hosts contains
+// every host once per worker, each entry gets one connection that is used by
+// the balanced client of its worker. The health of a host is shared between
+// workers.
+func makeBalancedClients(
+	beat beat.Info,
+	transp transport.Config,
+	observer outputs.Observer,
+	lsConfig *Config,
+	hosts []string,
+) ([]outputs.NetworkClient, error) {
+	health := map[string]*hostHealth{}
+	seen := map[string]int{}
+	var workers [][]*balancedHost
+	for _, host := range hosts {
+		if _, ok := health[host]; !ok {
+			health[host] = newHostHealth(host, lsConfig.HealthScoring.Cooldown, observer)
+		}
+
+		// The n-th entry of a host belongs to the n-th worker.
+		worker := seen[host]
+		seen[host]++
+		if worker == len(workers) {
+			workers = append(workers, nil)
+		}
+
+		client, err := makeClient(beat, transp, observer, lsConfig, host, health[host])
+		if err != nil {
+			return nil, err
+		}
+		workers[worker] = append(workers[worker], &balancedHost{client: client, health: health[host]})
+	}
+
+	clients := make([]outputs.NetworkClient, len(workers))
+	for i, balanced := range workers {
+		clients[i] = outputs.WithBackoff(newBalancedClient(balanced), lsConfig.Backoff.Init, lsConfig.Backoff.Max)
+	}
+	return clients, nil
+}
@@ -35,6 +35,7 @@ type syncClient struct {
 	client   *v2.SyncClient
 	observer outputs.Observer
 	win      *window
+	health   *hostHealth
 	ttl      time.Duration
 	ticker   *time.Ticker
 }
@@ -55,6 +56,7 @@ func newSyncClient(
 
 	if config.SlowStart {
 		c.win = newWindower(defaultStartMaxWindowSize, config.BulkMaxSize)
+		c.win.slowACK = config.Timeout / 2
 	}
 	if c.ttl > 0 {
 		c.ticker = time.NewTicker(c.ttl)
@@ -113,6 +115,8 @@ func (c *syncClient) Publish(_ context.Context, batch publisher.Batch) error {
 		return nil
 	}
 
+	c.health.begin()
+	start := time.Now()
 	for len(events) > 0 {
 
 		// check if we need to reconnect
@@ -120,13 +124,14 @@ func (c *syncClient) Publish(_ context.Context, batch publisher.Batch) error {
 			select {
 			case <-c.ticker.C:
 				if err := c.reconnect(); err != nil {
+					c.health.end(time.Since(start), err)
 					batch.Retry()
 					return err
 				}
 
 				// reset window size on reconnect
 				if c.win != nil {
-					c.win.windowSize = int32(defaultStartMaxWindowSize)
+					c.win.reset(defaultStartMaxWindowSize)
 				}
 			default:
 			}
@@ -164,11 +169,13 @@ func (c *syncClient) Publish(_ context.Context, batch publisher.Batch) error {
 			rest := len(events)
 			st.RetryableErrors(rest)
 
+			c.health.end(time.Since(start), err)
 			return err
 		}
 
 	}
 
+	c.health.end(time.Since(start), nil)
 	batch.ACK()
 	return nil
 }
@@ -184,12 +191,13 @@ func (c *syncClient) publishWindowed(events []publisher.Event) (int, error) {
 		events = events[:windowSize]
 	}
 
+	begin := time.Now()
 	n, err := c.sendEvents(events)
 	if err != nil {
 		return n, err
 	}
 
-	c.win.tryGrowWindow(batchSize)
+	c.win.ackReceived(batchSize, time.Since(begin))
 	return n, nil
 }
 
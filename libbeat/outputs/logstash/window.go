@@ -20,12 +20,17 @@ package logstash
 import (
 	"math"
 	"sync/atomic"
+	"time"
 )
 
 type window struct {
 	windowSize      int32
 	maxOkWindowSize int // max window size sending was successful for
 	maxWindowSize   int
+
+	// slowACK is the duration until ACK above which the window is not grown
+	// anymore. Zero disables the check.
+	slowACK time.Duration
 }
 
 func newWindower(start, max int) *window {
@@ -45,9 +50,24 @@ func (w *window) get() int {
 	return int(atomic.LoadInt32(&w.windowSize))
 }
 
+// reset restores the initial window size, e.g. after reconnecting.
+func (w *window) reset(start int) {
+	atomic.StoreInt32(&w.windowSize, int32(start))
+	w.maxOkWindowSize = 0
+}
+
+// ackReceived grows the window after a successful ACK that took the given
+// duration. If ACKs get close to the timeout the window is kept, a larger
+// window would likely make Logstash time out.
+func (w *window) ackReceived(batchSize int, took time.Duration) {
+	if w.slowACK > 0 && took > w.slowACK {
+		return
+	}
+	w.tryGrowWindow(batchSize)
+}
+
 // Increase window size by factor 1.5 until max window size
 // (window size grows exponentially)
-// TODO: use duration until ACK to estimate an ok max window size value
 func (w *window) tryGrowWindow(batchSize int) {
 	windowSize := w.get()
 
@@ -21,6 +21,7 @@ package logstash
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -79,3 +80,18 @@ func testGrowWindowSize(t *testing.T,
 	assert.Equal(t, expected, int(w.windowSize))
 	assert.Equal(t, expected, int(w.maxOkWindowSize))
 }
+
+func TestWindowNotGrownOnSlowACK(t *testing.T) {
+	w := newWindower(10, 1024)
+	w.slowACK = time.Second
+
+	w.ackReceived(100, 2*time.Second)
+	assert.Equal(t, 10, w.get())
+
+	w.ackReceived(100, time.Millisecond)
+	assert.Equal(t, 15, w.get())
+
+	w.reset(10)
+	assert.Equal(t, 10, w.get())
+	assert.Equal(t, 0, w.maxOkWindowSize)
+}
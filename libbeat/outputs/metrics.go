@@ -18,6 +18,7 @@
 package outputs
 
 import (
+	"sync"
 	"time"
 
 	"github.com/rcrowley/go-metrics"
@@ -78,6 +79,138 @@ type Stats struct {
 	readErrors *monitoring.Uint // total number of errors while waiting for response on output
 
 	sendLatencyMillis metrics.Sample
+
+	// Number of acked events, bytes, duplicates and failed events by error
+	// category per target index/data stream.
+	dataStreams *dataStreamStats
+
+	// Health of the hosts batches are balanced between.
+	hosts *hostHealthStats
+}
+
+// maxDataStreams limits the number of distinct indices/data streams tracked
+// by Stats. Events for indices beyond the limit are accounted for under
+// otherDataStreams so that a misconfigured index pattern can't blow up the
+// size of the monitoring registry.
+const (
+	maxDataStreams   = 1000
+	otherDataStreams = "_other"
+)
+
+type dataStreamCounters struct {
+	events     uint64
+	bytes      uint64
+	duplicates uint64
+	errors     map[string]uint64
+}
+
+type dataStreamStats struct {
+	mu      sync.Mutex
+	streams map[string]*dataStreamCounters
+}
+
+func (d *dataStreamStats) add(name string, events, bytes int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	c := d.counters(name)
+	c.events += uint64(events)
+	c.bytes += uint64(bytes)
+}
+
+func (d *dataStreamStats) addErrors(name, category string, n int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	c := d.counters(name)
+	if c.errors == nil {
+		c.errors = map[string]uint64{}
+	}
+	c.errors[category] += uint64(n)
+}
+
+func (d *dataStreamStats) addDuplicates(name string, n int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.counters(name).duplicates += uint64(n)
+}
+
+// counters returns the counters of the data stream, creating them if needed.
+// d.mu must be held.
+func (d *dataStreamStats) counters(name string) *dataStreamCounters {
+	c, ok := d.streams[name]
+	if !ok {
+		if len(d.streams) >= maxDataStreams {
+			name = otherDataStreams
+			c = d.streams[name]
+		}
+		if c == nil {
+			c = &dataStreamCounters{}
+			d.streams[name] = c
+		}
+	}
+	return c
+}
+
+// visit reports the counters keyed by the raw index name. Names are passed to
+// the visitor as a single key so dots in data stream names don't create
+// nested objects.
+func (d *dataStreamStats) visit(_ monitoring.Mode, V monitoring.Visitor) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	V.OnRegistryStart()
+	defer V.OnRegistryFinished()
+
+	for name, c := range d.streams {
+		monitoring.ReportNamespace(V, name, func() {
+			monitoring.ReportInt(V, "events", int64(c.events))
+			monitoring.ReportInt(V, "bytes", int64(c.bytes))
+			if c.duplicates > 0 {
+				monitoring.ReportInt(V, "duplicates", int64(c.duplicates))
+			}
+			if len(c.errors) > 0 {
+				monitoring.ReportNamespace(V, "errors", func() {
+					for category, n := range c.errors {
+						monitoring.ReportInt(V, category, int64(n))
+					}
+				})
+			}
+		})
+	}
+}
+
+type hostHealthStats struct {
+	mu    sync.Mutex
+	hosts map[string]HostHealth
+}
+
+func (h *hostHealthStats) set(host string, health HostHealth) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.hosts[host] = health
+}
+
+// visit reports the latest health snapshot keyed by host. Like data stream
+// names, host names are reported as a single key.
+func (h *hostHealthStats) visit(_ monitoring.Mode, V monitoring.Visitor) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	V.OnRegistryStart()
+	defer V.OnRegistryFinished()
+
+	for host, health := range h.hosts {
+		monitoring.ReportNamespace(V, host, func() {
+			monitoring.ReportFloat(V, "score", health.Score)
+			monitoring.ReportInt(V, "latency_ms", health.Latency.Milliseconds())
+			monitoring.ReportFloat(V, "error_rate", health.ErrorRate)
+			monitoring.ReportInt(V, "in_flight", int64(health.InFlight))
+			monitoring.ReportInt(V, "batches", int64(health.Batches))
+			monitoring.ReportInt(V, "failures", int64(health.Failures))
+		})
+	}
 }
 
 // NewStats creates a new Stats instance using a backing monitoring registry.
@@ -104,7 +237,12 @@ func NewStats(reg *monitoring.Registry) *Stats {
 		readErrors: monitoring.NewUint(reg, "read.errors"),
 
 		sendLatencyMillis: metrics.NewUniformSample(1024),
+
+		dataStreams: &dataStreamStats{streams: map[string]*dataStreamCounters{}},
+		hosts:       &hostHealthStats{hosts: map[string]HostHealth{}},
 	}
+	monitoring.NewFunc(reg, "data_streams", obj.dataStreams.visit, monitoring.Report)
+	monitoring.NewFunc(reg, "hosts", obj.hosts.visit, monitoring.Report)
 	_ = adapter.NewGoMetrics(reg, "write.latency", adapter.Accept).Register("histogram", metrics.NewHistogram(obj.sendLatencyMillis))
 	return obj
 }
@@ -178,6 +316,37 @@ func (s *Stats) ErrTooMany(n int) {
 	}
 }
 
+// DataStreamEvents updates the number of acked events and bytes for the
+// target index or data stream.
+func (s *Stats) DataStreamEvents(name string, events, bytes int) {
+	if s != nil {
+		s.dataStreams.add(name, events, bytes)
+	}
+}
+
+// DataStreamErrors updates the number of failed events of an error category
+// for the target index or data stream.
+func (s *Stats) DataStreamErrors(name, category string, n int) {
+	if s != nil {
+		s.dataStreams.addErrors(name, category, n)
+	}
+}
+
+// DataStreamDuplicates updates the number of events that were already
+// indexed in the target index or data stream.
+func (s *Stats) DataStreamDuplicates(name string, n int) {
+	if s != nil {
+		s.dataStreams.addDuplicates(name, n)
+	}
+}
+
+// ReportHostHealth updates the health metrics of a load balanced host.
+func (s *Stats) ReportHostHealth(host string, health HostHealth) {
+	if s != nil {
+		s.hosts.set(host, health)
+	}
+}
+
 // WriteError increases the write I/O error metrics.
 func (s *Stats) WriteError(err error) {
 	if s != nil {
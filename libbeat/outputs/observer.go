@@ -31,6 +31,12 @@ type Observer interface {
 	AckedEvents(int)      // report number of acked events
 	ErrTooMany(int)       // report too many requests response
 
+	DataStreamEvents(string, int, int)    // report number of acked events and bytes for a target index/data stream
+	DataStreamErrors(string, string, int) // report number of failed events by error category for a target index/data stream
+	DataStreamDuplicates(string, int)     // report number of events already indexed in a target index/data stream
+
+	ReportHostHealth(string, HostHealth) // report the health of a load balanced host
+
 	BatchSplit() // report a batch was split for being too large to ingest
 
 	WriteError(error) // report an I/O error on write
@@ -41,6 +47,17 @@ type Observer interface {
 	ReportLatency(time.Duration) // report the duration a send to the output takes
 }
 
+// HostHealth is a snapshot of the health of a host an output balances
+// batches between.
+type HostHealth struct {
+	Score     float64       // lower is better
+	Latency   time.Duration // moving average of the time until a batch is ACKed
+	ErrorRate float64       // moving average of the ratio of failed batches
+	InFlight  int           // batches sent and waiting for ACK
+	Batches   uint64        // total number of batches published
+	Failures  uint64        // total number of failed batches
+}
+
 type emptyObserver struct{}
 
 var nilObserver = (*emptyObserver)(nil)
@@ -50,16 +67,20 @@ func NewNilObserver() Observer {
 	return nilObserver
 }
 
-func (*emptyObserver) NewBatch(int)                  {}
-func (*emptyObserver) ReportLatency(_ time.Duration) {}
-func (*emptyObserver) AckedEvents(int)               {}
-func (*emptyObserver) DeadLetterEvents(int)          {}
-func (*emptyObserver) DuplicateEvents(int)           {}
-func (*emptyObserver) RetryableErrors(int)           {}
-func (*emptyObserver) PermanentErrors(int)           {}
-func (*emptyObserver) BatchSplit()                   {}
-func (*emptyObserver) WriteError(error)              {}
-func (*emptyObserver) WriteBytes(int)                {}
-func (*emptyObserver) ReadError(error)               {}
-func (*emptyObserver) ReadBytes(int)                 {}
-func (*emptyObserver) ErrTooMany(int)                {}
+func (*emptyObserver) NewBatch(int)                         {}
+func (*emptyObserver) ReportLatency(_ time.Duration)        {}
+func (*emptyObserver) AckedEvents(int)                      {}
+func (*emptyObserver) DeadLetterEvents(int)                 {}
+func (*emptyObserver) DuplicateEvents(int)                  {}
+func (*emptyObserver) RetryableErrors(int)                  {}
+func (*emptyObserver) PermanentErrors(int)                  {}
+func (*emptyObserver) BatchSplit()                          {}
+func (*emptyObserver) WriteError(error)                     {}
+func (*emptyObserver) WriteBytes(int)                       {}
+func (*emptyObserver) ReadError(error)                      {}
+func (*emptyObserver) ReadBytes(int)                        {}
+func (*emptyObserver) ErrTooMany(int)                       {}
+func (*emptyObserver) DataStreamEvents(string, int, int)    {}
+func (*emptyObserver) DataStreamErrors(string, string, int) {}
+func (*emptyObserver) DataStreamDuplicates(string, int)     {}
+func (*emptyObserver) ReportHostHealth(string, HostHealth)  {}
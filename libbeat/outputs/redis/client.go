@@ -19,9 +19,11 @@ package redis
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -29,6 +31,7 @@ import (
 	"github.com/gomodule/redigo/redis"
 
 	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common"
 	"github.com/elastic/beats/v7/libbeat/outputs"
 	"github.com/elastic/beats/v7/libbeat/outputs/codec"
 	"github.com/elastic/beats/v7/libbeat/outputs/outil"
@@ -53,6 +56,7 @@ type client struct {
 	observer outputs.Observer
 	index    string
 	dataType redisDataType
+	stream   streamConfig
 	db       int
 	key      outil.Selector
 	password string
@@ -66,6 +70,7 @@ type redisDataType uint16
 const (
 	redisListType redisDataType = iota
 	redisChannelType
+	redisStreamType
 )
 
 func newClient(
@@ -73,7 +78,7 @@ func newClient(
 	observer outputs.Observer,
 	timeout time.Duration,
 	pass string,
-	db int, key outil.Selector, dt redisDataType,
+	db int, key outil.Selector, dt redisDataType, stream streamConfig,
 	index string, codec codec.Codec,
 ) *client {
 	return &client{
@@ -85,6 +90,7 @@ func newClient(
 		index:    strings.ToLower(index),
 		db:       db,
 		dataType: dt,
+		stream:   stream,
 		key:      key,
 		codec:    codec,
 	}
@@ -164,39 +170,47 @@ func (c *client) String() string {
 func (c *client) makePublish(
 	conn redis.Conn,
 ) (publishFn, error) {
-	if c.dataType == redisChannelType {
+	switch c.dataType {
+	case redisChannelType:
 		return c.makePublishPUBLISH(conn)
+	case redisStreamType:
+		return c.makePublishXADD(conn)
 	}
 	return c.makePublishRPUSH(conn)
 }
 
-func (c *client) makePublishRPUSH(conn redis.Conn) (publishFn, error) {
-	if !c.key.IsConst() {
-		// TODO: more clever bulk handling batching events with same key
-		return c.publishEventsPipeline(conn, "RPUSH"), nil
-	}
-
-	var major, minor int
-	var versionRaw [][]byte
-
+// redisVersion returns the major and minor version of the Redis server.
+func redisVersion(conn redis.Conn) (major, minor int, err error) {
 	respRaw, err := conn.Do("INFO")
 	resp, err := redis.Bytes(respRaw, err)
 	if err != nil {
-		return nil, err
+		return 0, 0, err
 	}
 
-	versionRaw = versionRegex.FindSubmatch(resp)
+	versionRaw := versionRegex.FindSubmatch(resp)
 	if versionRaw == nil {
-		err = errors.New("unable to read redis_version")
-		return nil, err
+		return 0, 0, errors.New("unable to read redis_version")
 	}
 
 	major, err = strconv.Atoi(string(versionRaw[1]))
 	if err != nil {
-		return nil, err
+		return 0, 0, err
 	}
 
 	minor, err = strconv.Atoi(string(versionRaw[2]))
+	if err != nil {
+		return 0, 0, err
+	}
+	return major, minor, nil
+}
+
+func (c *client) makePublishRPUSH(conn redis.Conn) (publishFn, error) {
+	if !c.key.IsConst() {
+		// TODO: more clever bulk handling batching events with same key
+		return c.publishEventsPipeline(conn, "RPUSH"), nil
+	}
+
+	major, minor, err := redisVersion(conn)
 	if err != nil {
 		return nil, err
 	}
@@ -221,6 +235,20 @@ func (c *client) makePublishPUBLISH(conn redis.Conn) (publishFn, error) {
 	return c.publishEventsPipeline(conn, "PUBLISH"), nil
 }
 
+func (c *client) makePublishXADD(conn redis.Conn) (publishFn, error) {
+	// Streams were added in Redis 5.0.
+	//
+	// See: https://redis.io/commands/xadd
+	major, _, err := redisVersion(conn)
+	if err != nil {
+		return nil, err
+	}
+	if major < 5 {
+		return nil, fmt.Errorf("redis streams require Redis 5.0 or newer, found version %d", major)
+	}
+	return c.publishEventsStream(conn), nil
+}
+
 func (c *client) publishEventsBulk(conn redis.Conn, command string) publishFn {
 	// XXX: requires key.IsConst() == true
 	dest, _ := c.key.Select(&beat.Event{Fields: mapstr.M{}})
@@ -307,6 +335,139 @@ func (c *client) publishEventsPipeline(conn redis.Conn, command string) publishF
 	}
 }
 
+func (c *client) publishEventsStream(conn redis.Conn) publishFn {
+	return func(key outil.Selector, data []publisher.Event) ([]publisher.Event, error) {
+		sent := make([]publisher.Event, 0, len(data))
+		dropped := 0
+		for i := range data {
+			event := &data[i].Content
+			eventKey, err := key.Select(event)
+			if err != nil {
+				c.log.Errorf("Failed to set redis key: %+v", err)
+				dropped++
+				continue
+			}
+
+			fields, err := c.streamFields(event)
+			if err != nil {
+				c.log.Errorf("Encoding event failed with error: %+v. Look at the event log file to view the event", err)
+				c.log.Errorw(fmt.Sprintf("Failed event: %v", event), logp.TypeKey, logp.EventType)
+				dropped++
+				continue
+			}
+
+			args := append(streamArgs(eventKey, c.stream), fields...)
+			if err := conn.Send("XADD", args...); err != nil {
+				c.log.Errorf("Failed to execute XADD: %+v", err)
+				c.observer.PermanentErrors(dropped)
+				return append(sent, data[i:]...), err
+			}
+			sent = append(sent, data[i])
+		}
+		c.observer.PermanentErrors(dropped)
+		if len(sent) == 0 {
+			return nil, nil
+		}
+
+		if err := conn.Flush(); err != nil {
+			return sent, err
+		}
+
+		var failed []publisher.Event
+		var lastErr error
+		for i := range sent {
+			_, err := conn.Receive()
+			if err != nil {
+				if _, ok := err.(redis.Error); ok { //nolint:errorlint //this line checks against a type, not an instance of an error
+					c.log.Errorf("Failed to XADD event to stream with %+v", err)
+					failed = append(failed, sent[i])
+					lastErr = err
+				} else {
+					c.log.Errorf("Failed to XADD multiple events to stream with %+v", err)
+					failed = append(failed, sent[i:]...)
+					lastErr = err
+					break
+				}
+			}
+		}
+
+		c.observer.AckedEvents(len(sent) - len(failed))
+		return failed, lastErr
+	}
+}
+
+// streamArgs returns the XADD arguments preceding the entry fields.
+func streamArgs(key string, cfg streamConfig) []interface{} {
+	args := []interface{}{key}
+	if cfg.MaxLen > 0 {
+		args = append(args, "MAXLEN")
+		if cfg.Approximate {
+			args = append(args, "~")
+		}
+		args = append(args, cfg.MaxLen)
+	}
+	return append(args, "*")
+}
+
+// streamFields returns the field/value pairs of the stream entry of an event.
+// By default the encoded event is written to a single field, with the
+// configured event fields copied next to it. With flatten each event field
+// is written to its own entry field, named after its dotted path.
+func (c *client) streamFields(event *beat.Event) ([]interface{}, error) {
+	if c.stream.Flatten {
+		flat := event.Fields.Flatten()
+		keys := make([]string, 0, len(flat))
+		for k := range flat {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		fields := make([]interface{}, 0, 2*len(keys)+2)
+		fields = append(fields, beat.TimestampFieldKey, streamValue(event.Timestamp))
+		for _, k := range keys {
+			fields = append(fields, k, streamValue(flat[k]))
+		}
+		return fields, nil
+	}
+
+	serialized, err := c.codec.Encode(c.index, event)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, len(serialized))
+	copy(buf, serialized)
+
+	fields := make([]interface{}, 0, 2*len(c.stream.Fields)+2)
+	fields = append(fields, c.stream.Field, buf)
+	for _, name := range c.stream.Fields {
+		v, err := event.GetValue(name)
+		if err != nil || v == nil {
+			continue
+		}
+		fields = append(fields, name, streamValue(v))
+	}
+	return fields, nil
+}
+
+// streamValue converts an event value to a stream entry value. Strings are
+// written as is, timestamps in the format used by the codecs and other
+// values JSON encoded.
+func streamValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return v
+	case time.Time:
+		return v.UTC().Format("2006-01-02T15:04:05.000Z")
+	case common.Time:
+		return time.Time(v).UTC().Format("2006-01-02T15:04:05.000Z")
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return b
+}
+
 func serializeEvents(
 	log *logp.Logger,
 	to []interface{},
@@ -18,6 +18,7 @@
 package redis
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
@@ -40,10 +41,27 @@ type redisConfig struct {
 	Codec       codec.Config          `config:"codec"`
 	Db          int                   `config:"db"`
 	DataType    string                `config:"datatype"`
+	Stream      streamConfig          `config:"stream"`
 	Backoff     backoff               `config:"backoff"`
 	Queue       config.Namespace      `config:"queue"`
 }
 
+// streamConfig configures how events are added to a Redis stream when the
+// data type is "stream".
+type streamConfig struct {
+	// MaxLen trims the stream to about this number of entries, 0 disables
+	// trimming.
+	MaxLen      int64 `config:"maxlen" validate:"min=0"`
+	Approximate bool  `config:"approximate"`
+	// Field is the name of the entry field holding the encoded event.
+	Field string `config:"field"`
+	// Fields are copied from the event into entry fields of the same name.
+	Fields []string `config:"fields"`
+	// Flatten writes each event field to its own entry field instead of
+	// the encoded event.
+	Flatten bool `config:"flatten"`
+}
+
 type backoff struct {
 	Init time.Duration
 	Max  time.Duration
@@ -58,6 +76,10 @@ var (
 		TLS:         nil,
 		Db:          0,
 		DataType:    "list",
+		Stream: streamConfig{
+			Approximate: true,
+			Field:       "event",
+		},
 		Backoff: backoff{
 			Init: 1 * time.Second,
 			Max:  60 * time.Second,
@@ -68,6 +90,10 @@ var (
 func (c *redisConfig) Validate() error {
 	switch c.DataType {
 	case "", "list", "channel":
+	case "stream":
+		if c.Stream.Field == "" && !c.Stream.Flatten {
+			return errors.New("stream.field is required unless stream.flatten is enabled")
+		}
 	default:
 		return fmt.Errorf("redis data type %v not supported", c.DataType)
 	}
@@ -37,6 +37,9 @@ func TestValidate(t *testing.T) {
 		{"Invalid Datatype", redisConfig{Key: "test", DataType: "something"}, false},
 		{"List Datatype", redisConfig{Key: "test", DataType: "list"}, true},
 		{"Channel Datatype", redisConfig{Key: "test", DataType: "channel"}, true},
+		{"Stream Datatype", redisConfig{Key: "test", DataType: "stream", Stream: streamConfig{Field: "event"}}, true},
+		{"Stream Datatype flattened", redisConfig{Key: "test", DataType: "stream", Stream: streamConfig{Flatten: true}}, true},
+		{"Stream Datatype without field", redisConfig{Key: "test", DataType: "stream"}, false},
 	}
 
 	for _, test := range tests {
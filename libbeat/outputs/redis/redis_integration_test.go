@@ -300,6 +300,87 @@ func testPublishChannel(t *testing.T, cfg map[string]interface{}) {
 	}
 }
 
+func TestPublishStreamTCP(t *testing.T) {
+	key := "test_pubstream_tcp"
+	redisConfig := map[string]interface{}{
+		"hosts":    []string{getRedisAddr()},
+		"key":      key,
+		"datatype": "stream",
+		"timeout":  "5s",
+	}
+
+	entries := testPublishStream(t, redisConfig, 10, 100)
+	assert.Len(t, entries, 1000)
+	for i, entry := range entries {
+		evt := struct{ Message int }{}
+		err := json.Unmarshal([]byte(entry["event"]), &evt)
+		assert.NoError(t, err)
+		assert.Equal(t, i+1, evt.Message)
+		validateMeta(t, []byte(entry["event"]))
+	}
+}
+
+func TestPublishStreamTCPFlattenMaxLen(t *testing.T) {
+	key := "test_pubstream_flatten_tcp"
+	redisConfig := map[string]interface{}{
+		"hosts":    []string{getRedisAddr()},
+		"key":      key,
+		"datatype": "stream",
+		"timeout":  "5s",
+		"stream": map[string]interface{}{
+			"maxlen":      50,
+			"approximate": false,
+			"flatten":     true,
+		},
+	}
+
+	entries := testPublishStream(t, redisConfig, 2, 100)
+	assert.Len(t, entries, 50)
+	for i, entry := range entries {
+		assert.Equal(t, fmt.Sprint(151+i), entry["message"])
+		assert.NotEmpty(t, entry["@timestamp"])
+	}
+}
+
+// testPublishStream publishes the test events to the stream configured in
+// cfg and returns its entries.
+func testPublishStream(t *testing.T, cfg map[string]interface{}, batches, batchSize int) []map[string]string {
+	key := cfg["key"].(string)
+
+	conn, err := redis.Dial("tcp", getRedisAddr())
+	if err != nil {
+		t.Fatalf("redis.Dial failed %v", err)
+	}
+	defer conn.Close()
+	_, err = conn.Do("DEL", key)
+	if err != nil {
+		t.Fatalf("Could not execute command: %v", err)
+	}
+
+	out := newRedisTestingOutput(t, cfg)
+	err = sendTestEvents(out, batches, batchSize)
+	assert.NoError(t, err)
+
+	raw, err := redis.Values(conn.Do("XRANGE", key, "-", "+"))
+	if err != nil {
+		t.Fatalf("Could not read stream: %v", err)
+	}
+
+	entries := make([]map[string]string, 0, len(raw))
+	for _, r := range raw {
+		entry, err := redis.Values(r, nil)
+		if err != nil || len(entry) != 2 {
+			t.Fatalf("Unexpected stream entry: %v", r)
+		}
+		fields, err := redis.StringMap(entry[1], nil)
+		if err != nil {
+			t.Fatalf("Unexpected stream entry fields: %v", err)
+		}
+		entries = append(entries, fields)
+	}
+	return entries
+}
+
 func getEnv(name, or string) string {
 	if x := os.Getenv(name); x != "" {
 		return x
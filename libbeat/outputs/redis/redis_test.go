@@ -19,12 +19,14 @@ package redis
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/elastic/beats/v7/libbeat/beat"
 	"github.com/elastic/beats/v7/libbeat/outputs"
-	_ "github.com/elastic/beats/v7/libbeat/outputs/codec/json"
+	"github.com/elastic/beats/v7/libbeat/outputs/codec/json"
 	"github.com/elastic/elastic-agent-libs/config"
 	"github.com/elastic/elastic-agent-libs/mapstr"
 )
@@ -102,6 +104,31 @@ func TestMakeRedis(t *testing.T) {
 				clientPassword(1, "mypassword"),
 			),
 		},
+		"Stream datatype": {
+			config: map[string]interface{}{
+				"hosts":    []string{"localhost:6379"},
+				"datatype": "stream",
+				"stream":   map[string]interface{}{"maxlen": 1000, "fields": []string{"host.name"}},
+			},
+			valid: true,
+			checks: checks(clientsLen(1), func(t *testing.T, group outputs.Group) {
+				redisClient := group.Clients[0].(*backoffClient)
+				assert.Equal(t, redisStreamType, redisClient.client.dataType)
+				assert.Equal(t, streamConfig{
+					MaxLen:      1000,
+					Approximate: true,
+					Field:       "event",
+					Fields:      []string{"host.name"},
+				}, redisClient.client.stream)
+			}),
+		},
+		"Stream datatype without field": {
+			config: map[string]interface{}{
+				"hosts":    []string{"localhost:6379"},
+				"datatype": "stream",
+				"stream":   map[string]interface{}{"field": ""},
+			},
+		},
 	}
 	beatInfo := beat.Info{Beat: "libbeat", Version: "1.2.3"}
 	for name, test := range tests {
@@ -180,3 +207,68 @@ func TestKeySelection(t *testing.T) {
 		})
 	}
 }
+
+func TestStreamArgs(t *testing.T) {
+	tests := map[string]struct {
+		cfg  streamConfig
+		want []interface{}
+	}{
+		"no trimming": {
+			cfg:  streamConfig{Approximate: true},
+			want: []interface{}{"events", "*"},
+		},
+		"approximate trimming": {
+			cfg:  streamConfig{MaxLen: 100, Approximate: true},
+			want: []interface{}{"events", "MAXLEN", "~", int64(100), "*"},
+		},
+		"exact trimming": {
+			cfg:  streamConfig{MaxLen: 100},
+			want: []interface{}{"events", "MAXLEN", int64(100), "*"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.want, streamArgs("events", test.cfg))
+		})
+	}
+}
+
+func TestStreamFields(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 10, 20, 30, 0, time.UTC)
+	event := beat.Event{
+		Timestamp: ts,
+		Fields: mapstr.M{
+			"message": "hello",
+			"host":    mapstr.M{"name": "web-1"},
+			"log":     mapstr.M{"offset": 42},
+			"tags":    []string{"a", "b"},
+		},
+	}
+
+	t.Run("encoded event", func(t *testing.T) {
+		c := &client{
+			codec:  json.New("1.2.3", json.Config{}),
+			stream: streamConfig{Field: "event", Fields: []string{"host.name", "log.offset", "missing"}},
+		}
+		fields, err := c.streamFields(&event)
+		require.NoError(t, err)
+		require.Len(t, fields, 6)
+		assert.Equal(t, "event", fields[0])
+		assert.Contains(t, string(fields[1].([]byte)), `"message":"hello"`)
+		assert.Equal(t, []interface{}{"host.name", "web-1", "log.offset", []byte("42")}, fields[2:])
+	})
+
+	t.Run("flatten", func(t *testing.T) {
+		c := &client{stream: streamConfig{Flatten: true}}
+		fields, err := c.streamFields(&event)
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{
+			"@timestamp", "2024-05-01T10:20:30.000Z",
+			"host.name", "web-1",
+			"log.offset", []byte("42"),
+			"message", "hello",
+			"tags", []byte(`["a","b"]`),
+		}, fields)
+	})
+}
@@ -0,0 +1,124 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package syslogout
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/outputs"
+	"github.com/elastic/beats/v7/libbeat/publisher"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/transport"
+)
+
+type client struct {
+	log *logp.Logger
+	*transport.Client
+	observer  outputs.Observer
+	framing   string
+	timeout   time.Duration
+	formatter *formatter
+}
+
+func newClient(
+	tc *transport.Client,
+	observer outputs.Observer,
+	config *syslogConfig,
+	formatter *formatter,
+) *client {
+	return &client{
+		log:       logp.NewLogger("syslog"),
+		Client:    tc,
+		observer:  observer,
+		framing:   config.Framing,
+		timeout:   config.Timeout,
+		formatter: formatter,
+	}
+}
+
+func (c *client) Connect(ctx context.Context) error {
+	c.log.Debug("connect")
+	return c.Client.ConnectContext(ctx)
+}
+
+func (c *client) Close() error {
+	c.log.Debug("close connection")
+	return c.Client.Close()
+}
+
+func (c *client) Publish(_ context.Context, batch publisher.Batch) error {
+	events := batch.Events()
+	c.observer.NewBatch(len(events))
+
+	dropped := 0
+	for i := range events {
+		msg, err := c.formatter.formatEvent(&events[i].Content)
+		if err != nil {
+			c.log.Errorf("Failed to format event: %v", err)
+			c.log.Debugf("Failed event: %v", events[i])
+			dropped++
+			continue
+		}
+
+		if err := c.send(msg); err != nil {
+			c.observer.PermanentErrors(dropped)
+			c.observer.AckedEvents(i - dropped)
+			c.observer.RetryableErrors(len(events) - i)
+			batch.RetryEvents(events[i:])
+			return err
+		}
+	}
+
+	c.observer.PermanentErrors(dropped)
+	c.observer.AckedEvents(len(events) - dropped)
+	batch.ACK()
+	return nil
+}
+
+// send writes a single message, framed as configured on TCP and as a
+// single datagram on UDP.
+func (c *client) send(msg []byte) error {
+	if c.timeout > 0 {
+		if err := c.SetWriteDeadline(time.Now().Add(c.timeout)); err != nil {
+			return err
+		}
+	}
+
+	switch c.framing {
+	case framingOctetCounting:
+		frame := make([]byte, 0, len(msg)+8)
+		frame = strconv.AppendInt(frame, int64(len(msg)), 10)
+		frame = append(frame, ' ')
+		msg = append(frame, msg...)
+	case framingNonTransparent:
+		msg = append(msg, '\n')
+	}
+
+	n, err := c.Write(msg)
+	if err == nil && n != len(msg) {
+		err = io.ErrShortWrite
+	}
+	return err
+}
+
+func (c *client) String() string {
+	return "syslog(" + c.Client.String() + ")"
+}
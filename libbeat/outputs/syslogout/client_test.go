@@ -0,0 +1,184 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package syslogout
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/outputs"
+	"github.com/elastic/beats/v7/libbeat/outputs/outest"
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/transport"
+)
+
+func newTestClient(t *testing.T, addr string, settings map[string]interface{}) *client {
+	t.Helper()
+	settings["hosts"] = []string{addr}
+	cfg, err := readConfig(config.MustNewConfigFrom(settings), testInfo)
+	require.NoError(t, err)
+	f, err := newFormatter(testInfo, cfg)
+	require.NoError(t, err)
+
+	conn, err := transport.NewClient(transport.Config{Timeout: cfg.Timeout}, cfg.Protocol, addr, defaultPort)
+	require.NoError(t, err)
+	c := newClient(conn, outputs.NewNilObserver(), cfg, f)
+	require.NoError(t, c.Connect(context.Background()))
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func testEvent(message string) beat.Event {
+	return beat.Event{Timestamp: testTime, Fields: mapstr.M{"message": message}}
+}
+
+func TestPublishUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer pc.Close()
+
+	c := newTestClient(t, pc.LocalAddr().String(), map[string]interface{}{})
+	batch := outest.NewBatch(testEvent("first"), testEvent("second"))
+	require.NoError(t, c.Publish(context.Background(), batch))
+	assert.Equal(t, []outest.BatchSignal{{Tag: outest.BatchACK}}, batch.Signals)
+
+	buf := make([]byte, 1024)
+	require.NoError(t, pc.SetReadDeadline(time.Now().Add(5*time.Second)))
+	for _, want := range []string{"first", "second"} {
+		n, _, err := pc.ReadFrom(buf)
+		require.NoError(t, err)
+		assert.Equal(t, "<14>1 2024-03-01T12:00:00.123456Z beathost filebeat - - - "+want, string(buf[:n]))
+	}
+}
+
+func TestPublishTCP(t *testing.T) {
+	tests := map[string]struct {
+		settings map[string]interface{}
+		read     func(r *bufio.Reader) (string, error)
+	}{
+		"octet counting": {
+			settings: map[string]interface{}{"protocol": "tcp"},
+			read: func(r *bufio.Reader) (string, error) {
+				length, err := r.ReadString(' ')
+				if err != nil {
+					return "", err
+				}
+				n, err := strconv.Atoi(strings.TrimSuffix(length, " "))
+				if err != nil {
+					return "", err
+				}
+				buf := make([]byte, n)
+				_, err = io.ReadFull(r, buf)
+				return string(buf), err
+			},
+		},
+		"non transparent": {
+			settings: map[string]interface{}{"protocol": "tcp", "format": "rfc3164"},
+			read: func(r *bufio.Reader) (string, error) {
+				line, err := r.ReadString('\n')
+				return strings.TrimSuffix(line, "\n"), err
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			defer l.Close()
+
+			received := make(chan []string, 1)
+			go func() {
+				conn, err := l.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				var msgs []string
+				for len(msgs) < 2 {
+					msg, err := tc.read(r)
+					if err != nil {
+						break
+					}
+					msgs = append(msgs, msg)
+				}
+				received <- msgs
+			}()
+
+			c := newTestClient(t, l.Addr().String(), tc.settings)
+			batch := outest.NewBatch(testEvent("first line"), testEvent("second line"))
+			require.NoError(t, c.Publish(context.Background(), batch))
+			assert.Equal(t, []outest.BatchSignal{{Tag: outest.BatchACK}}, batch.Signals)
+
+			select {
+			case msgs := <-received:
+				require.Len(t, msgs, 2)
+				assert.True(t, strings.HasSuffix(msgs[0], " first line"), msgs[0])
+				assert.True(t, strings.HasSuffix(msgs[1], " second line"), msgs[1])
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for messages")
+			}
+		})
+	}
+}
+
+func TestPublishDropsUnformattable(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer pc.Close()
+
+	c := newTestClient(t, pc.LocalAddr().String(), map[string]interface{}{"message": "%{[missing]}"})
+	batch := outest.NewBatch(testEvent("dropped"))
+	require.NoError(t, c.Publish(context.Background(), batch))
+	assert.Equal(t, []outest.BatchSignal{{Tag: outest.BatchACK}}, batch.Signals)
+}
+
+func TestPublishTCPRetry(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		conn, err := l.Accept()
+		if err == nil {
+			conn.Close()
+		}
+	}()
+
+	c := newTestClient(t, l.Addr().String(), map[string]interface{}{"protocol": "tcp"})
+	l.Close()
+
+	// Writes to a closed connection eventually fail, retry until they do.
+	var batch *outest.Batch
+	require.Eventually(t, func() bool {
+		batch = outest.NewBatch(testEvent("lost"))
+		return c.Publish(context.Background(), batch) != nil
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Len(t, batch.Signals, 1)
+	assert.Equal(t, outest.BatchRetryEvents, batch.Signals[0].Tag)
+}
@@ -0,0 +1,186 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package syslogout
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/fmtstr"
+	"github.com/elastic/beats/v7/libbeat/outputs/codec"
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+)
+
+const (
+	protocolUDP = "udp"
+	protocolTCP = "tcp"
+
+	formatRFC3164 = "rfc3164"
+	formatRFC5424 = "rfc5424"
+
+	framingOctetCounting  = "octet_counting"
+	framingNonTransparent = "non_transparent"
+)
+
+type syslogConfig struct {
+	Hosts       []string                  `config:"hosts" validate:"required"`
+	Protocol    string                    `config:"protocol"`
+	Format      string                    `config:"format"`
+	Framing     string                    `config:"framing"`
+	LoadBalance bool                      `config:"loadbalance"`
+	Timeout     time.Duration             `config:"timeout"`
+	BulkMaxSize int                       `config:"bulk_max_size"`
+	MaxRetries  int                       `config:"max_retries" validate:"min=-1"`
+	TLS         *tlscommon.Config         `config:"ssl"`
+	Hostname    *fmtstr.EventFormatString `config:"hostname"`
+	AppName     *fmtstr.EventFormatString `config:"app_name"`
+	ProcID      *fmtstr.EventFormatString `config:"proc_id"`
+	MsgID       *fmtstr.EventFormatString `config:"msg_id"`
+	Message     *fmtstr.EventFormatString `config:"message"`
+	Codec       codec.Config              `config:"codec"`
+	Facility    priorityConfig            `config:"facility"`
+	Severity    priorityConfig            `config:"severity"`
+	Backoff     Backoff                   `config:"backoff"`
+	Queue       config.Namespace          `config:"queue"`
+}
+
+// priorityConfig configures how the facility or severity of a message is
+// derived from the event.
+type priorityConfig struct {
+	// Fields are checked in order, the first one holding a known name or
+	// code is used.
+	Fields []string `config:"fields"`
+	// Default is used if none of the fields can be resolved.
+	Default string `config:"default"`
+	// Mapping translates custom field values to facility or severity names
+	// or codes.
+	Mapping map[string]string `config:"mapping"`
+}
+
+type Backoff struct {
+	Init time.Duration
+	Max  time.Duration
+}
+
+func defaultConfig(info beat.Info) syslogConfig {
+	return syslogConfig{
+		Protocol:    protocolUDP,
+		Format:      formatRFC5424,
+		LoadBalance: true,
+		Timeout:     5 * time.Second,
+		BulkMaxSize: 2048,
+		MaxRetries:  3,
+		Hostname:    fmtstr.MustCompileEvent("%{[host.name]:" + info.Hostname + "}"),
+		AppName:     fmtstr.MustCompileEvent(info.Beat),
+		ProcID:      fmtstr.MustCompileEvent("%{[process.pid]:-}"),
+		MsgID:       fmtstr.MustCompileEvent("-"),
+		Message:     fmtstr.MustCompileEvent("%{[message]:}"),
+		Facility: priorityConfig{
+			Fields:  []string{"log.syslog.facility.code", "log.syslog.facility.name"},
+			Default: "user",
+		},
+		Severity: priorityConfig{
+			Fields:  []string{"log.syslog.severity.code", "log.syslog.severity.name", "log.level"},
+			Default: "informational",
+		},
+		Backoff: Backoff{
+			Init: 1 * time.Second,
+			Max:  60 * time.Second,
+		},
+	}
+}
+
+func readConfig(cfg *config.C, info beat.Info) (*syslogConfig, error) {
+	c := defaultConfig(info)
+	if err := cfg.Unpack(&c); err != nil {
+		return nil, err
+	}
+	if c.Framing == "" && c.Protocol == protocolTCP {
+		// RFC 6587 recommends octet counting, but most RFC 3164 receivers
+		// only understand newline delimited messages.
+		c.Framing = framingOctetCounting
+		if c.Format == formatRFC3164 {
+			c.Framing = framingNonTransparent
+		}
+	}
+	return &c, nil
+}
+
+func (c *syslogConfig) Validate() error {
+	switch c.Protocol {
+	case protocolUDP:
+		if c.TLS.IsEnabled() {
+			return errors.New("ssl is only supported with the tcp protocol")
+		}
+		if c.Framing != "" {
+			return errors.New("framing is only supported with the tcp protocol")
+		}
+	case protocolTCP:
+		switch c.Framing {
+		case "", framingOctetCounting, framingNonTransparent:
+		default:
+			return fmt.Errorf("unsupported framing %q: must be octet_counting or non_transparent", c.Framing)
+		}
+	default:
+		return fmt.Errorf("unsupported protocol %q: must be udp or tcp", c.Protocol)
+	}
+
+	switch c.Format {
+	case formatRFC3164, formatRFC5424:
+	default:
+		return fmt.Errorf("unsupported format %q: must be rfc3164 or rfc5424", c.Format)
+	}
+
+	if err := c.Facility.validate(facilities, 23); err != nil {
+		return fmt.Errorf("invalid facility: %w", err)
+	}
+	if err := c.Severity.validate(severities, 7); err != nil {
+		return fmt.Errorf("invalid severity: %w", err)
+	}
+	return nil
+}
+
+func (c *priorityConfig) validate(names map[string]int, max int) error {
+	if _, ok := lookupPriority(c.Default, names, max); !ok {
+		return fmt.Errorf("unknown default %q", c.Default)
+	}
+	for k, v := range c.Mapping {
+		if _, ok := lookupPriority(v, names, max); !ok {
+			return fmt.Errorf("unknown value %q in mapping for %q", v, k)
+		}
+	}
+	return nil
+}
+
+// lookupPriority resolves a facility or severity name or code.
+func lookupPriority(s string, names map[string]int, max int) (int, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if v, ok := names[s]; ok {
+		return v, true
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, false
+	}
+	return v, v >= 0 && v <= max
+}
@@ -0,0 +1,96 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package syslogout
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/config"
+)
+
+func TestConfig(t *testing.T) {
+	tests := map[string]struct {
+		settings map[string]interface{}
+		framing  string
+		err      string
+	}{
+		"udp": {
+			settings: map[string]interface{}{},
+		},
+		"tcp rfc5424 defaults to octet counting": {
+			settings: map[string]interface{}{"protocol": "tcp"},
+			framing:  framingOctetCounting,
+		},
+		"tcp rfc3164 defaults to non transparent": {
+			settings: map[string]interface{}{"protocol": "tcp", "format": "rfc3164"},
+			framing:  framingNonTransparent,
+		},
+		"tcp with tls": {
+			settings: map[string]interface{}{"protocol": "tcp", "framing": "non_transparent", "ssl.verification_mode": "none"},
+			framing:  framingNonTransparent,
+		},
+		"udp with tls": {
+			settings: map[string]interface{}{"ssl.verification_mode": "none"},
+			err:      "ssl is only supported with the tcp protocol",
+		},
+		"udp with framing": {
+			settings: map[string]interface{}{"framing": "octet_counting"},
+			err:      "framing is only supported with the tcp protocol",
+		},
+		"invalid framing": {
+			settings: map[string]interface{}{"protocol": "tcp", "framing": "crlf"},
+			err:      "unsupported framing",
+		},
+		"invalid protocol": {
+			settings: map[string]interface{}{"protocol": "http"},
+			err:      "unsupported protocol",
+		},
+		"invalid format": {
+			settings: map[string]interface{}{"format": "rfc5425"},
+			err:      "unsupported format",
+		},
+		"invalid facility default": {
+			settings: map[string]interface{}{"facility.default": "local8"},
+			err:      "invalid facility",
+		},
+		"facility code out of range": {
+			settings: map[string]interface{}{"facility.default": "24"},
+			err:      "invalid facility",
+		},
+		"invalid severity mapping": {
+			settings: map[string]interface{}{"severity.mapping": map[string]interface{}{"high": "urgent"}},
+			err:      "invalid severity",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			tc.settings["hosts"] = []string{"localhost"}
+			cfg, err := readConfig(config.MustNewConfigFrom(tc.settings), testInfo)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.framing, cfg.Framing)
+		})
+	}
+}
@@ -0,0 +1,282 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package syslogout
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/fmtstr"
+	"github.com/elastic/beats/v7/libbeat/outputs/codec"
+)
+
+var facilities = map[string]int{
+	"kern":         0,
+	"kernel":       0,
+	"user":         1,
+	"mail":         2,
+	"daemon":       3,
+	"auth":         4,
+	"syslog":       5,
+	"lpr":          6,
+	"news":         7,
+	"uucp":         8,
+	"cron":         9,
+	"authpriv":     10,
+	"ftp":          11,
+	"ntp":          12,
+	"security":     13,
+	"console":      14,
+	"solaris-cron": 15,
+	"local0":       16,
+	"local1":       17,
+	"local2":       18,
+	"local3":       19,
+	"local4":       20,
+	"local5":       21,
+	"local6":       22,
+	"local7":       23,
+}
+
+var severities = map[string]int{
+	"emergency":     0,
+	"emerg":         0,
+	"panic":         0,
+	"alert":         1,
+	"critical":      2,
+	"crit":          2,
+	"fatal":         2,
+	"error":         3,
+	"err":           3,
+	"warning":       4,
+	"warn":          4,
+	"notice":        5,
+	"informational": 6,
+	"info":          6,
+	"debug":         7,
+	"trace":         7,
+}
+
+// Maximum lengths of the RFC 5424 header fields.
+const (
+	maxHostnameLen = 255
+	maxAppNameLen  = 48
+	maxProcIDLen   = 128
+	maxMsgIDLen    = 32
+
+	// maxTagLen is the maximum length of the RFC 3164 tag.
+	maxTagLen = 32
+)
+
+// priority resolves the facility or severity of events.
+type priority struct {
+	fields  []string
+	def     int
+	mapping map[string]string
+	names   map[string]int
+	max     int
+}
+
+func newPriority(config priorityConfig, names map[string]int, max int) *priority {
+	def, _ := lookupPriority(config.Default, names, max)
+	mapping := make(map[string]string, len(config.Mapping))
+	for k, v := range config.Mapping {
+		mapping[strings.ToLower(k)] = v
+	}
+	return &priority{fields: config.Fields, def: def, mapping: mapping, names: names, max: max}
+}
+
+func (p *priority) value(event *beat.Event) int {
+	for _, field := range p.fields {
+		v, err := event.GetValue(field)
+		if err != nil {
+			continue
+		}
+		s := strings.ToLower(fmt.Sprint(v))
+		if mapped, ok := p.mapping[s]; ok {
+			s = mapped
+		}
+		if n, ok := lookupPriority(s, p.names, p.max); ok {
+			return n
+		}
+	}
+	return p.def
+}
+
+// formatter renders events as syslog messages.
+type formatter struct {
+	format   string
+	hostname *fmtstr.EventFormatString
+	appName  *fmtstr.EventFormatString
+	procID   *fmtstr.EventFormatString
+	msgID    *fmtstr.EventFormatString
+	message  *fmtstr.EventFormatString
+	codec    codec.Codec
+	index    string
+	facility *priority
+	severity *priority
+}
+
+func newFormatter(info beat.Info, config *syslogConfig) (*formatter, error) {
+	f := &formatter{
+		format:   config.Format,
+		hostname: config.Hostname,
+		appName:  config.AppName,
+		procID:   config.ProcID,
+		msgID:    config.MsgID,
+		message:  config.Message,
+		index:    info.Beat,
+		facility: newPriority(config.Facility, facilities, 23),
+		severity: newPriority(config.Severity, severities, 7),
+	}
+	if config.Codec.Namespace.IsSet() {
+		enc, err := codec.CreateEncoder(info, config.Codec)
+		if err != nil {
+			return nil, err
+		}
+		f.codec = enc
+	}
+	return f, nil
+}
+
+// formatEvent returns the syslog message for the event without any framing.
+func (f *formatter) formatEvent(event *beat.Event) ([]byte, error) {
+	body, err := f.body(event)
+	if err != nil {
+		return nil, err
+	}
+	hostname, err := f.hostname.Run(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format hostname: %w", err)
+	}
+	appName, err := f.appName.Run(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format app_name: %w", err)
+	}
+	procID, err := f.procID.Run(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format proc_id: %w", err)
+	}
+
+	pri := f.facility.value(event)*8 + f.severity.value(event)
+
+	var buf bytes.Buffer
+	buf.WriteByte('<')
+	buf.WriteString(strconv.Itoa(pri))
+	buf.WriteByte('>')
+
+	if f.format == formatRFC3164 {
+		buf.WriteString(event.Timestamp.Local().Format(time.Stamp))
+		buf.WriteByte(' ')
+		buf.WriteString(headerField(hostname, maxHostnameLen))
+		buf.WriteByte(' ')
+		buf.WriteString(tag(appName))
+		if procID = headerField(procID, maxProcIDLen); procID != "-" {
+			buf.WriteByte('[')
+			buf.WriteString(procID)
+			buf.WriteByte(']')
+		}
+		buf.WriteString(": ")
+		buf.Write(body)
+		return buf.Bytes(), nil
+	}
+
+	msgID, err := f.msgID.Run(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format msg_id: %w", err)
+	}
+	buf.WriteString("1 ")
+	buf.WriteString(event.Timestamp.UTC().Format("2006-01-02T15:04:05.000000Z07:00"))
+	for _, field := range []struct {
+		value string
+		max   int
+	}{
+		{hostname, maxHostnameLen},
+		{appName, maxAppNameLen},
+		{procID, maxProcIDLen},
+		{msgID, maxMsgIDLen},
+	} {
+		buf.WriteByte(' ')
+		buf.WriteString(headerField(field.value, field.max))
+	}
+	// No structured data.
+	buf.WriteString(" -")
+	if len(body) > 0 {
+		buf.WriteByte(' ')
+		buf.Write(body)
+	}
+	return buf.Bytes(), nil
+}
+
+func (f *formatter) body(event *beat.Event) ([]byte, error) {
+	if f.codec != nil {
+		b, err := f.codec.Encode(f.index, event)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode message: %w", err)
+		}
+		// Codecs may reuse their buffer.
+		return bytes.Clone(b), nil
+	}
+	b, err := f.message.RunBytes(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format message: %w", err)
+	}
+	return b, nil
+}
+
+// headerField restricts a header value to printable US-ASCII without
+// spaces and the maximum length of the field, using the nil value "-" for
+// empty values.
+func headerField(s string, max int) string {
+	s = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return '_'
+		}
+		return r
+	}, s)
+	if len(s) > max {
+		s = s[:max]
+	}
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// tag returns the RFC 3164 tag, which is limited to 32 characters. Only
+// alphanumeric characters and '-', '_' and '.' are kept as receivers
+// treat anything else as the end of the tag.
+func tag(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if b.Len() == maxTagLen {
+			break
+		}
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' || r == '.' {
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() == 0 {
+		return "-"
+	}
+	return b.String()
+}
@@ -0,0 +1,149 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package syslogout
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	_ "github.com/elastic/beats/v7/libbeat/outputs/codec/cef"
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+var testInfo = beat.Info{Beat: "filebeat", Version: "8.15.0", Hostname: "beathost"}
+
+func newTestFormatter(t *testing.T, settings map[string]interface{}) *formatter {
+	t.Helper()
+	settings["hosts"] = []string{"localhost"}
+	cfg, err := readConfig(config.MustNewConfigFrom(settings), testInfo)
+	require.NoError(t, err)
+	f, err := newFormatter(testInfo, cfg)
+	require.NoError(t, err)
+	return f
+}
+
+var testTime = time.Date(2024, 3, 1, 12, 0, 0, 123456000, time.UTC)
+
+func TestFormatRFC5424(t *testing.T) {
+	f := newTestFormatter(t, map[string]interface{}{
+		"msg_id": "%{[event.action]}",
+	})
+
+	event := beat.Event{
+		Timestamp: testTime,
+		Fields: mapstr.M{
+			"message": "user alice logged in",
+			"host":    mapstr.M{"name": "web 1"},
+			"process": mapstr.M{"pid": 4242},
+			"event":   mapstr.M{"action": "login"},
+			"log":     mapstr.M{"level": "WARN", "syslog": mapstr.M{"facility": mapstr.M{"name": "auth"}}},
+		},
+	}
+
+	msg, err := f.formatEvent(&event)
+	require.NoError(t, err)
+	assert.Equal(t, "<36>1 2024-03-01T12:00:00.123456Z web_1 filebeat 4242 login - user alice logged in", string(msg))
+}
+
+func TestFormatRFC5424Defaults(t *testing.T) {
+	f := newTestFormatter(t, map[string]interface{}{})
+
+	msg, err := f.formatEvent(&beat.Event{Timestamp: testTime, Fields: mapstr.M{}})
+	require.NoError(t, err)
+	assert.Equal(t, "<14>1 2024-03-01T12:00:00.123456Z beathost filebeat - - -", string(msg))
+}
+
+func TestFormatRFC3164(t *testing.T) {
+	f := newTestFormatter(t, map[string]interface{}{
+		"format":   "rfc3164",
+		"app_name": "%{[process.name]}",
+		"message":  "%{[event.action]}: %{[message]}",
+	})
+
+	event := beat.Event{
+		Timestamp: testTime,
+		Fields: mapstr.M{
+			"message": "disk full",
+			"event":   mapstr.M{"action": "alert"},
+			"process": mapstr.M{"name": "my app[x]", "pid": 7},
+			"log":     mapstr.M{"syslog": mapstr.M{"severity": mapstr.M{"code": 2}, "facility": mapstr.M{"code": 16}}},
+		},
+	}
+
+	msg, err := f.formatEvent(&event)
+	require.NoError(t, err)
+	expected := "<130>" + testTime.Local().Format(time.Stamp) + " beathost myappx[7]: alert: disk full"
+	assert.Equal(t, expected, string(msg))
+}
+
+func TestFormatCodec(t *testing.T) {
+	f := newTestFormatter(t, map[string]interface{}{
+		"codec.cef": map[string]interface{}{
+			"default_extensions": false,
+			"extensions":         map[string]interface{}{"msg": "message"},
+		},
+	})
+
+	event := beat.Event{Timestamp: testTime, Fields: mapstr.M{"message": "hello"}}
+	msg, err := f.formatEvent(&event)
+	require.NoError(t, err)
+	assert.Equal(t, "<14>1 2024-03-01T12:00:00.123456Z beathost filebeat - - - CEF:0|Elastic|filebeat|8.15.0|0|event|5|msg=hello", string(msg))
+}
+
+func TestFormatMissingField(t *testing.T) {
+	f := newTestFormatter(t, map[string]interface{}{"message": "%{[does.not.exist]}"})
+
+	_, err := f.formatEvent(&beat.Event{Timestamp: testTime, Fields: mapstr.M{}})
+	assert.ErrorContains(t, err, "failed to format message")
+}
+
+func TestPriority(t *testing.T) {
+	p := newPriority(priorityConfig{
+		Fields:  []string{"custom", "log.level"},
+		Default: "notice",
+		Mapping: map[string]string{"Sev-High": "error", "sev-low": "7"},
+	}, severities, 7)
+
+	for value, want := range map[interface{}]int{
+		"sev-high": 3,
+		"SEV-LOW":  7,
+		"critical": 2,
+		"4":        4,
+		9:          5,
+		"unknown":  5,
+	} {
+		event := beat.Event{Fields: mapstr.M{"custom": value}}
+		assert.Equal(t, want, p.value(&event), "value %v", value)
+	}
+
+	// Unresolvable fields fall through to the next field.
+	event := beat.Event{Fields: mapstr.M{"custom": "bogus", "log": mapstr.M{"level": "debug"}}}
+	assert.Equal(t, 7, p.value(&event))
+}
+
+func TestHeaderField(t *testing.T) {
+	assert.Equal(t, "-", headerField("", 10))
+	assert.Equal(t, "a_b_c", headerField("a b\tc", 10))
+	assert.Equal(t, "abc", headerField("abcdef", 3))
+	assert.Equal(t, "caf_", headerField("café", 10))
+}
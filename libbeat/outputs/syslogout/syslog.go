@@ -0,0 +1,80 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package syslogout
+
+import (
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/outputs"
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/transport"
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+)
+
+const defaultPort = 514
+
+func init() {
+	outputs.RegisterType("syslog", makeSyslog)
+}
+
+// makeSyslog creates an output that forwards events to a remote syslog
+// receiver over UDP or TCP.
+func makeSyslog(
+	_ outputs.IndexManager,
+	beat beat.Info,
+	observer outputs.Observer,
+	cfg *config.C,
+) (outputs.Group, error) {
+	syslogConfig, err := readConfig(cfg, beat)
+	if err != nil {
+		return outputs.Fail(err)
+	}
+
+	hosts, err := outputs.ReadHostList(cfg)
+	if err != nil {
+		return outputs.Fail(err)
+	}
+
+	tls, err := tlscommon.LoadTLSConfig(syslogConfig.TLS)
+	if err != nil {
+		return outputs.Fail(err)
+	}
+
+	transp := transport.Config{
+		Timeout: syslogConfig.Timeout,
+		TLS:     tls,
+		Stats:   observer,
+	}
+
+	clients := make([]outputs.NetworkClient, len(hosts))
+	for i, host := range hosts {
+		// Each client needs its own formatter as codecs are not safe for
+		// concurrent use.
+		formatter, err := newFormatter(beat, syslogConfig)
+		if err != nil {
+			return outputs.Fail(err)
+		}
+		conn, err := transport.NewClient(transp, syslogConfig.Protocol, host, defaultPort)
+		if err != nil {
+			return outputs.Fail(err)
+		}
+		client := newClient(conn, observer, syslogConfig, formatter)
+		clients[i] = outputs.WithBackoff(client, syslogConfig.Backoff.Init, syslogConfig.Backoff.Max)
+	}
+
+	return outputs.SuccessNet(syslogConfig.Queue, syslogConfig.LoadBalance, syslogConfig.BulkMaxSize, syslogConfig.MaxRetries, nil, clients)
+}
@@ -43,3 +43,5 @@ func (*dropEvent) Run(_ *beat.Event) (*beat.Event, error) {
 }
 
 func (*dropEvent) String() string { return "drop_event" }
+
+func (*dropEvent) SupportsFlatFields() bool { return true }
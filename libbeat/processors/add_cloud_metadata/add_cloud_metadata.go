@@ -19,6 +19,7 @@ package add_cloud_metadata
 
 import (
 	"fmt"
+	"os"
 	"sync"
 	"time"
 
@@ -55,6 +56,7 @@ type initData struct {
 	timeout   time.Duration
 	tlsConfig *tlscommon.TLSConfig
 	overwrite bool
+	cache     *resultCache // Nil if caching is disabled.
 }
 
 // New constructs a new add_cloud_metadata processor.
@@ -70,16 +72,22 @@ func New(c *cfg.C) (beat.Processor, error) {
 	}
 
 	initProviders := selectProviders(config.Providers, cloudMetaProviders)
-	fetchers, err := setupFetchers(initProviders, c)
+	fetchers, err := setupFetchers(initProviders, config.Endpoints, c)
 	if err != nil {
 		return nil, err
 	}
+	var cache *resultCache
+	if config.Cache.Enabled {
+		hostname, _ := os.Hostname()
+		cache = newResultCache(config.Cache, cacheKey(hostname, initProviders, config.Endpoints))
+	}
 	p := &addCloudMetadata{
 		initData: &initData{
 			fetchers:  fetchers,
 			timeout:   config.Timeout,
 			tlsConfig: tlsConfig,
 			overwrite: config.Overwrite,
+			cache:     cache,
 		},
 		logger: logp.NewLogger("add_cloud_metadata"),
 	}
@@ -95,7 +103,30 @@ func (r result) String() string {
 
 func (p *addCloudMetadata) init() {
 	p.initOnce.Do(func() {
+		if p.initData.cache != nil {
+			entry, err := p.initData.cache.load()
+			if err != nil {
+				p.logger.Warnf("add_cloud_metadata: failed to load cached result: %v", err)
+			}
+			if entry != nil {
+				if entry.Provider == "" {
+					p.logger.Info("add_cloud_metadata: hosting provider type not detected (cached).")
+					return
+				}
+				p.metadata = entry.Metadata
+				p.logger.Infof("add_cloud_metadata: hosting provider type detected as %v (cached), metadata=%v",
+					entry.Provider, entry.Metadata.String())
+				return
+			}
+		}
+
 		result := p.fetchMetadata()
+		if p.initData.cache != nil {
+			err := p.initData.cache.store(result)
+			if err != nil {
+				p.logger.Warnf("add_cloud_metadata: failed to cache result: %v", err)
+			}
+		}
 		if result == nil {
 			p.logger.Info("add_cloud_metadata: hosting provider type not detected.")
 			return
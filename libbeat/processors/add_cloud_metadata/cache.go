@@ -0,0 +1,178 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package add_cloud_metadata
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/paths"
+)
+
+// cacheFileName is the name of the cache file in the data path.
+const cacheFileName = "add_cloud_metadata.json"
+
+// cacheMu serializes access to cache files by processor instances in
+// the same process.
+var cacheMu sync.Mutex
+
+// resultCache persists detection results across restarts. A result with no
+// provider records that no hosting provider was detected, so that on-premise
+// hosts do not probe the metadata services at each startup.
+type resultCache struct {
+	path        string
+	key         string
+	ttl         time.Duration
+	negativeTTL time.Duration
+	now         func() time.Time
+}
+
+// cacheEntry is a cached detection result.
+type cacheEntry struct {
+	Provider  string    `json:"provider,omitempty"`
+	Metadata  mapstr.M  `json:"metadata,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func newResultCache(c cacheConfig, key string) *resultCache {
+	path := c.Path
+	if path == "" {
+		path = paths.Resolve(paths.Data, cacheFileName)
+	}
+	return &resultCache{
+		path:        path,
+		key:         key,
+		ttl:         c.TTL,
+		negativeTTL: c.NegativeTTL,
+		now:         time.Now,
+	}
+}
+
+// cacheKey returns the key of the cache entry for a host and probe
+// configuration. Entries are only reused when the host name, the probed
+// providers and their endpoints are unchanged, so a cloned VM image or a
+// renamed host is probed again.
+func cacheKey(hostname string, providers map[string]provider, endpoints map[string]string) string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	eps := make([]string, 0, len(endpoints))
+	for name, host := range endpoints {
+		eps = append(eps, name+"="+host)
+	}
+	sort.Strings(eps)
+
+	h := sha256.New()
+	//nolint:errcheck // hash.Hash writes never fail.
+	json.NewEncoder(h).Encode([]interface{}{hostname, names, eps})
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// load returns the unexpired cache entry, if any.
+func (c *resultCache) load() (*cacheEntry, error) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	entries, err := c.read()
+	if err != nil {
+		return nil, err
+	}
+	e, ok := entries[c.key]
+	if !ok {
+		return nil, nil
+	}
+	ttl := c.ttl
+	if e.Provider == "" {
+		ttl = c.negativeTTL
+	}
+	if c.now().Sub(e.Timestamp) >= ttl {
+		return nil, nil
+	}
+	return &e, nil
+}
+
+// store saves the detection result. A nil result is saved as a negative
+// entry.
+func (c *resultCache) store(res *result) error {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	entries, err := c.read()
+	if err != nil {
+		// Replace an unreadable cache file.
+		entries = nil
+	}
+	if entries == nil {
+		entries = make(map[string]cacheEntry)
+	}
+	e := cacheEntry{Timestamp: c.now()}
+	if res != nil {
+		e.Provider = res.provider
+		e.Metadata = res.metadata
+	}
+	entries[c.key] = e
+
+	b, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache: %w", err)
+	}
+	err = os.MkdirAll(filepath.Dir(c.path), 0o750)
+	if err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	tmp := c.path + ".tmp"
+	err = os.WriteFile(tmp, b, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	err = os.Rename(tmp, c.path)
+	if err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	return nil
+}
+
+// read returns the entries in the cache file. A missing file holds no
+// entries.
+func (c *resultCache) read() (map[string]cacheEntry, error) {
+	b, err := os.ReadFile(c.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read cache: %w", err)
+	}
+	var entries map[string]cacheEntry
+	err = json.Unmarshal(b, &entries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal cache: %w", err)
+	}
+	return entries, nil
+}
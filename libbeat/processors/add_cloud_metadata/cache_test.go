@@ -0,0 +1,155 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package add_cloud_metadata
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func TestCachePositiveResult(t *testing.T) {
+	logp.TestingSetup()
+
+	var hits atomic.Int64
+	handler := openstackNovaMetadataHandler()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		handler(w, r)
+	}))
+	defer server.Close()
+
+	config := conf.MustNewConfigFrom(map[string]interface{}{
+		"providers":     []string{"openstack"},
+		"endpoints":     map[string]interface{}{"nova": server.Listener.Addr().String()},
+		"cache.enabled": true,
+		"cache.path":    filepath.Join(t.TempDir(), cacheFileName),
+	})
+
+	want := mapstr.M{
+		"cloud": mapstr.M{
+			"provider":          "openstack",
+			"instance":          mapstr.M{"id": "i-0000ffac", "name": "testvm01.stack.cloud"},
+			"machine":           mapstr.M{"type": "m1.xlarge"},
+			"availability_zone": "az-test-2",
+			"service":           mapstr.M{"name": "Nova"},
+		},
+	}
+
+	got := runProcessor(t, config)
+	assert.Equal(t, want.StringToPrint(), got.StringToPrint())
+	probes := hits.Load()
+	require.NotZero(t, probes)
+
+	// A new processor uses the cached metadata without probing.
+	got = runProcessor(t, config)
+	assert.Equal(t, want.StringToPrint(), got.StringToPrint())
+	assert.Equal(t, probes, hits.Load(), "unexpected probe of metadata service")
+}
+
+func TestCacheNegativeResult(t *testing.T) {
+	logp.TestingSetup()
+
+	var hits atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		http.Error(w, "not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	config := conf.MustNewConfigFrom(map[string]interface{}{
+		"providers":     []string{"openstack"},
+		"endpoints":     map[string]interface{}{"openstack": server.Listener.Addr().String()},
+		"cache.enabled": true,
+		"cache.path":    filepath.Join(t.TempDir(), cacheFileName),
+	})
+
+	got := runProcessor(t, config)
+	assert.Empty(t, got)
+	probes := hits.Load()
+	require.NotZero(t, probes)
+
+	got = runProcessor(t, config)
+	assert.Empty(t, got)
+	assert.Equal(t, probes, hits.Load(), "unexpected probe of metadata service")
+}
+
+func TestCacheExpiry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg := cacheConfig{
+		Enabled:     true,
+		Path:        filepath.Join(t.TempDir(), cacheFileName),
+		TTL:         time.Hour,
+		NegativeTTL: time.Minute,
+	}
+	positive := newResultCache(cfg, "positive")
+	positive.now = func() time.Time { return now }
+	negative := newResultCache(cfg, "negative")
+	negative.now = func() time.Time { return now }
+
+	require.NoError(t, positive.store(&result{provider: "aws", metadata: mapstr.M{"cloud": mapstr.M{"provider": "aws"}}}))
+	require.NoError(t, negative.store(nil))
+
+	now = now.Add(30 * time.Minute)
+	e, err := positive.load()
+	require.NoError(t, err)
+	require.NotNil(t, e, "expected positive entry within ttl")
+	assert.Equal(t, "aws", e.Provider)
+	e, err = negative.load()
+	require.NoError(t, err)
+	assert.Nil(t, e, "expected negative entry to expire after negative_ttl")
+
+	now = now.Add(time.Hour)
+	e, err = positive.load()
+	require.NoError(t, err)
+	assert.Nil(t, e, "expected positive entry to expire after ttl")
+
+	other := newResultCache(cfg, cacheKey("other-host", nil, nil))
+	other.now = positive.now
+	e, err = other.load()
+	require.NoError(t, err)
+	assert.Nil(t, e, "expected no entry for a different key")
+}
+
+func TestEndpointsValidation(t *testing.T) {
+	_, err := New(conf.MustNewConfigFrom(map[string]interface{}{
+		"endpoints": map[string]interface{}{"unknown": "localhost:80"},
+	}))
+	assert.ErrorContains(t, err, "unknown provider 'unknown' in endpoints")
+}
+
+func runProcessor(t *testing.T, config *conf.C) mapstr.M {
+	t.Helper()
+
+	p, err := New(config)
+	require.NoError(t, err)
+	event, err := p.Run(&beat.Event{Fields: mapstr.M{}})
+	require.NoError(t, err)
+	return event.Fields
+}
@@ -29,6 +29,15 @@ type config struct {
 	TLS       *tlscommon.Config `config:"ssl"`       // TLS configuration
 	Overwrite bool              `config:"overwrite"` // Overwrite if cloud.* fields already exist.
 	Providers providerList      `config:"providers"` // List of providers to probe
+	Endpoints map[string]string `config:"endpoints"` // Metadata service host and port by provider name.
+	Cache     cacheConfig       `config:"cache"`     // Persistent cache of the detection result.
+}
+
+type cacheConfig struct {
+	Enabled     bool          `config:"enabled"`      // Persist the detection result across restarts.
+	Path        string        `config:"path"`         // Path of the cache file. Defaults to add_cloud_metadata.json in the data path.
+	TTL         time.Duration `config:"ttl"`          // Time a detected provider's metadata is reused for.
+	NegativeTTL time.Duration `config:"negative_ttl"` // Time a "no cloud provider" result is reused for.
 }
 
 type providerList []string
@@ -39,6 +48,10 @@ const (
 
 	// Default overwrite
 	defaultOverwrite = false
+
+	// Default cache TTLs
+	defaultCacheTTL         = 24 * time.Hour
+	defaultCacheNegativeTTL = 24 * time.Hour
 )
 
 func defaultConfig() config {
@@ -46,13 +59,31 @@ func defaultConfig() config {
 		Timeout:   defaultTimeout,
 		Overwrite: defaultOverwrite,
 		Providers: nil, // enable all local-only providers by default
+		Cache: cacheConfig{
+			TTL:         defaultCacheTTL,
+			NegativeTTL: defaultCacheNegativeTTL,
+		},
 	}
 }
 
 func (c *config) Validate() error {
 	// XXX: remove this check. A bug in go-ucfg prevents the correct validation
 	// on providerList
-	return c.Providers.Validate()
+	if err := c.Providers.Validate(); err != nil {
+		return err
+	}
+	for name, host := range c.Endpoints {
+		if _, ok := cloudMetaProviders[name]; !ok {
+			return fmt.Errorf("unknown provider '%v' in endpoints", name)
+		}
+		if host == "" {
+			return fmt.Errorf("empty endpoint for provider '%v'", name)
+		}
+	}
+	if c.Cache.Enabled && (c.Cache.TTL <= 0 || c.Cache.NegativeTTL <= 0) {
+		return fmt.Errorf("cache ttl and negative_ttl must be positive")
+	}
+	return nil
 }
 
 func (l providerList) Has(name string) bool {
@@ -81,7 +81,21 @@ var ec2MetadataFetcher = provider{
 			return meta
 		}
 
-		fetcher, err := newGenericMetadataFetcher(config, "aws", ec2Schema, fetchRawProviderMetadata)
+		var endpoint struct {
+			Host string `config:"host"` // Overrides the IMDS endpoint host and port.
+		}
+		if err := config.Unpack(&endpoint); err != nil {
+			return nil, fmt.Errorf("failed to unpack add_cloud_metadata config: %w", err)
+		}
+		fetch := fetchRawProviderMetadata
+		if endpoint.Host != "" {
+			opt := awscfg.WithEC2IMDSEndpoint("http://" + endpoint.Host)
+			fetch = func(ctx context.Context, client http.Client, result *result) {
+				fetchRawProviderMetadataWith(ctx, client, result, opt)
+			}
+		}
+
+		fetcher, err := newGenericMetadataFetcher(config, "aws", ec2Schema, fetch)
 		return fetcher, err
 	},
 }
@@ -91,11 +105,22 @@ func fetchRawProviderMetadata(
 	ctx context.Context,
 	client http.Client,
 	result *result,
+) {
+	fetchRawProviderMetadataWith(ctx, client, result)
+}
+
+// fetchRawProviderMetadataWith queries raw metadata from the EC2 metadata
+// service, applying opts when loading the AWS configuration.
+func fetchRawProviderMetadataWith(
+	ctx context.Context,
+	client http.Client,
+	result *result,
+	opts ...func(*awscfg.LoadOptions) error,
 ) {
 	logger := logp.NewLogger("add_cloud_metadata")
 
 	// LoadDefaultConfig loads the EC2 role credentials
-	awsConfig, err := awscfg.LoadDefaultConfig(context.TODO(), awscfg.WithHTTPClient(&client))
+	awsConfig, err := awscfg.LoadDefaultConfig(context.TODO(), append([]func(*awscfg.LoadOptions) error{awscfg.WithHTTPClient(&client)}, opts...)...)
 	if err != nil {
 		result.err = fmt.Errorf("failed loading AWS default configuration: %w", err)
 		return
@@ -114,7 +114,7 @@ func filterMetaProviders(filter func(string) bool, fetchers map[string]provider)
 	return out
 }
 
-func setupFetchers(providers map[string]provider, c *conf.C) ([]metadataFetcher, error) {
+func setupFetchers(providers map[string]provider, endpoints map[string]string, c *conf.C) ([]metadataFetcher, error) {
 	mf := make([]metadataFetcher, 0, len(providers))
 	visited := map[string]bool{}
 
@@ -129,7 +129,16 @@ func setupFetchers(providers map[string]provider, c *conf.C) ([]metadataFetcher,
 		}
 		visited[ff.Name] = true
 
-		fetcher, err := ff.Create(name, c)
+		pc := c
+		if host, ok := endpointFor(ff, endpoints); ok {
+			var err error
+			pc, err = withHost(c, host)
+			if err != nil {
+				return nil, fmt.Errorf("failed to set the %v endpoint: %w", name, err)
+			}
+		}
+
+		fetcher, err := ff.Create(name, pc)
 		if err != nil {
 			return nil, fmt.Errorf("failed to initialize the %v fetcher: %w", name, err)
 		}
@@ -139,6 +148,31 @@ func setupFetchers(providers map[string]provider, c *conf.C) ([]metadataFetcher,
 	return mf, nil
 }
 
+// endpointFor returns the configured metadata endpoint for the provider,
+// matching any of the provider's alias names.
+func endpointFor(ff provider, endpoints map[string]string) (string, bool) {
+	for name, host := range endpoints {
+		if cloudMetaProviders[name].Name == ff.Name {
+			return host, true
+		}
+	}
+	return "", false
+}
+
+// withHost returns a copy of c with the metadata service host set to host.
+func withHost(c *conf.C, host string) (*conf.C, error) {
+	pc := conf.NewConfig()
+	if c != nil {
+		if err := pc.Merge(c); err != nil {
+			return nil, err
+		}
+	}
+	if err := pc.SetString("host", -1, host); err != nil {
+		return nil, err
+	}
+	return pc, nil
+}
+
 // fetchMetadata attempts to fetch metadata in parallel from each of the
 // hosting providers supported by this processor. It will wait for the results to
 // be returned or for a timeout to occur then returns the first result that
@@ -70,6 +70,9 @@ func (p *addID) Run(event *beat.Event) (*beat.Event, error) {
 	return event, nil
 }
 
+// SupportsFlatFields returns true, the ID is set with the Event methods.
+func (p *addID) SupportsFlatFields() bool { return true }
+
 func (p *addID) String() string {
 	return fmt.Sprintf("%v=[target_field=[%v]]", processorName, p.config.TargetField)
 }
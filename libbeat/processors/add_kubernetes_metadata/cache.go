@@ -20,26 +20,54 @@
 package add_kubernetes_metadata
 
 import (
+	"container/list"
 	"sync"
 	"time"
 
 	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+var (
+	cacheMetrics   = monitoring.Default.NewRegistry("processor.add_kubernetes_metadata.cache", monitoring.DoNotReport)
+	cacheEntries   = monitoring.NewInt(cacheMetrics, "entries")
+	cacheEvictions = monitoring.NewUint(cacheMetrics, "evictions_total")
 )
 
 type cache struct {
 	sync.Mutex
-	timeout  time.Duration
-	deleted  map[string]time.Time // key ->  when should this obj be deleted
-	metadata map[string]mapstr.M
-	done     chan struct{}
+	timeout    time.Duration
+	maxEntries int                  // Maximum number of entries, zero for no limit.
+	deleted    map[string]time.Time // key ->  when should this obj be deleted
+	metadata   map[string]mapstr.M
+
+	// Eviction order of the keys of the entries. Entries of deleted objects
+	// are in deletedOrder, by deletion time, the others in setOrder, the
+	// least recently set first.
+	elements     map[string]*list.Element
+	deletedOrder *list.List
+	setOrder     *list.List
+
+	done chan struct{}
 }
 
 func newCache(cleanupTimeout time.Duration) *cache {
+	return newBoundedCache(cleanupTimeout, 0)
+}
+
+// newBoundedCache returns a cache holding at most maxEntries entries. When the
+// cache is full, entries of deleted objects are evicted first, followed by the
+// least recently set entries.
+func newBoundedCache(cleanupTimeout time.Duration, maxEntries int) *cache {
 	c := &cache{
-		timeout:  cleanupTimeout,
-		deleted:  make(map[string]time.Time),
-		metadata: make(map[string]mapstr.M),
-		done:     make(chan struct{}),
+		timeout:      cleanupTimeout,
+		maxEntries:   maxEntries,
+		deleted:      make(map[string]time.Time),
+		metadata:     make(map[string]mapstr.M),
+		elements:     make(map[string]*list.Element),
+		deletedOrder: list.New(),
+		setOrder:     list.New(),
+		done:         make(chan struct{}),
 	}
 	go c.cleanup()
 	return c
@@ -51,6 +79,9 @@ func (c *cache) get(key string) mapstr.M {
 	// add lifecycle if key was queried
 	if t, ok := c.deleted[key]; ok {
 		c.deleted[key] = t.Add(c.timeout)
+		if e, ok := c.elements[key]; ok {
+			c.deletedOrder.MoveToBack(e)
+		}
 	}
 	return c.metadata[key]
 }
@@ -59,13 +90,58 @@ func (c *cache) delete(key string) {
 	c.Lock()
 	defer c.Unlock()
 	c.deleted[key] = time.Now().Add(c.timeout)
+	if e, ok := c.elements[key]; ok {
+		c.unlink(e)
+		c.elements[key] = c.deletedOrder.PushBack(key)
+	}
 }
 
 func (c *cache) set(key string, data mapstr.M) {
 	c.Lock()
 	defer c.Unlock()
 	delete(c.deleted, key)
+	if e, ok := c.elements[key]; ok {
+		c.unlink(e)
+	} else {
+		if c.maxEntries > 0 && len(c.metadata) >= c.maxEntries {
+			c.evict()
+		}
+		cacheEntries.Inc()
+	}
 	c.metadata[key] = data
+	c.elements[key] = c.setOrder.PushBack(key)
+}
+
+// evict removes one entry, preferring entries of deleted objects. It must be
+// called with the lock held.
+func (c *cache) evict() {
+	e := c.deletedOrder.Front()
+	if e == nil {
+		e = c.setOrder.Front()
+	}
+	if e == nil {
+		return
+	}
+	c.remove(e.Value.(string))
+	cacheEvictions.Inc()
+}
+
+// remove removes the entry for key. It must be called with the lock held.
+func (c *cache) remove(key string) {
+	if e, ok := c.elements[key]; ok {
+		c.unlink(e)
+		cacheEntries.Dec()
+	}
+	delete(c.deleted, key)
+	delete(c.elements, key)
+	delete(c.metadata, key)
+}
+
+// unlink removes e from the eviction order.
+func (c *cache) unlink(e *list.Element) {
+	// Removing an element from a list it doesn't belong to is a no-op.
+	c.deletedOrder.Remove(e)
+	c.setOrder.Remove(e)
 }
 
 func (c *cache) cleanup() {
@@ -83,8 +159,7 @@ func (c *cache) cleanup() {
 			c.Lock()
 			for k, t := range c.deleted {
 				if now.After(t) {
-					delete(c.deleted, k)
-					delete(c.metadata, k)
+					c.remove(k)
 				}
 			}
 			c.Unlock()
@@ -94,4 +169,7 @@ func (c *cache) cleanup() {
 
 func (c *cache) stop() {
 	close(c.done)
+	c.Lock()
+	defer c.Unlock()
+	cacheEntries.Sub(int64(len(c.metadata)))
 }
@@ -0,0 +1,52 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build linux || darwin || windows
+
+package add_kubernetes_metadata
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func TestBoundedCache(t *testing.T) {
+	c := newBoundedCache(time.Minute, 2)
+	defer c.stop()
+
+	c.set("a", mapstr.M{"id": "a"})
+	c.set("b", mapstr.M{"id": "b"})
+
+	// Entries of deleted objects are evicted first.
+	c.delete("b")
+	c.set("c", mapstr.M{"id": "c"})
+	assert.NotNil(t, c.get("a"))
+	assert.Nil(t, c.get("b"), "expected deleted entry to be evicted")
+	assert.NotNil(t, c.get("c"))
+
+	// Otherwise the least recently set entry is evicted.
+	c.set("a", mapstr.M{"id": "a"})
+	c.set("d", mapstr.M{"id": "d"})
+	assert.NotNil(t, c.get("a"))
+	assert.Nil(t, c.get("c"), "expected least recently set entry to be evicted")
+	assert.NotNil(t, c.get("d"))
+	assert.Len(t, c.metadata, 2)
+}
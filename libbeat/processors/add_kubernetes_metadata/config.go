@@ -41,6 +41,9 @@ type kubeAnnotatorConfig struct {
 	Matchers        PluginConfig  `config:"matchers"`
 	DefaultMatchers Enabled       `config:"default_matchers"`
 	DefaultIndexers Enabled       `config:"default_indexers"`
+	// CacheMaxEntries limits the number of cached metadata entries. Zero
+	// means no limit.
+	CacheMaxEntries int `config:"cache_max_entries" validate:"min=0"`
 
 	AddResourceMetadata *metadata.AddResourceMetadataConfig `config:"add_resource_metadata"`
 }
@@ -36,6 +36,7 @@ import (
 	"github.com/elastic/elastic-agent-libs/mapstr"
 
 	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/kubernetes/watchers"
 	"github.com/elastic/beats/v7/libbeat/processors"
 )
 
@@ -114,7 +115,7 @@ func New(cfg *config.C) (beat.Processor, error) {
 	log := logp.NewLogger(selector).With("libbeat.processor", "add_kubernetes_metadata")
 	processor := &kubernetesAnnotator{
 		log:                 log,
-		cache:               newCache(config.CleanupTimeout),
+		cache:               newBoundedCache(config.CleanupTimeout, config.CacheMaxEntries),
 		kubernetesAvailable: false,
 	}
 
@@ -158,7 +159,7 @@ func (k *kubernetesAnnotator) init(config kubeAnnotatorConfig, cfg *config.C) {
 		if err != nil {
 			k.log.Errorf("couldn't set kubeadm variable for node due to error %+v", err)
 		}
-		client, err := kubernetes.GetKubernetesClient(config.KubeConfig, config.KubeClientOptions)
+		client, err := watchers.Default.Client(config.KubeConfig, config.KubeClientOptions)
 		if err != nil {
 			if kubernetes.IsInCluster(config.KubeConfig) {
 				k.log.Debugf("Could not create kubernetes client using in_cluster config: %+v", err)
@@ -197,12 +198,21 @@ func (k *kubernetesAnnotator) init(config kubeAnnotatorConfig, cfg *config.C) {
 			k.log.Debugf("Initializing a new Kubernetes watcher using host: %s", config.Node)
 		}
 
-		watcher, err := kubernetes.NewNamedWatcher("add_kubernetes_metadata_pod", client, &kubernetes.Pod{}, kubernetes.WatchOptions{
+		// Watchers are shared with other processors and autodiscover providers
+		// watching the same resources with the same options.
+		acquire := func(resource string, opts kubernetes.WatchOptions, create func(kubernetes.WatchOptions) (kubernetes.Watcher, error)) (kubernetes.Watcher, error) {
+			key := watchers.NewKey(resource, client, opts)
+			return watchers.Default.Acquire(key, func() (kubernetes.Watcher, error) { return create(opts) })
+		}
+
+		watcher, err := acquire("pod", kubernetes.WatchOptions{
 			SyncTimeout:  config.SyncPeriod,
 			Node:         config.Node,
 			Namespace:    config.Namespace,
 			HonorReSyncs: true,
-		}, nil)
+		}, func(opts kubernetes.WatchOptions) (kubernetes.Watcher, error) {
+			return kubernetes.NewNamedWatcher("add_kubernetes_metadata_pod", client, &kubernetes.Pod{}, opts, nil)
+		})
 		if err != nil {
 			k.log.Errorf("Couldn't create kubernetes watcher for %T", &kubernetes.Pod{})
 			return
@@ -211,22 +221,26 @@ func (k *kubernetesAnnotator) init(config kubeAnnotatorConfig, cfg *config.C) {
 		metaConf := config.AddResourceMetadata
 
 		if metaConf.Node.Enabled() {
-			nodeWatcher, err = kubernetes.NewNamedWatcher("add_kubernetes_metadata_node", client, &kubernetes.Node{}, kubernetes.WatchOptions{
+			nodeWatcher, err = acquire("node", kubernetes.WatchOptions{
 				SyncTimeout:  config.SyncPeriod,
 				Node:         config.Node,
 				HonorReSyncs: true,
-			}, nil)
+			}, func(opts kubernetes.WatchOptions) (kubernetes.Watcher, error) {
+				return kubernetes.NewNamedWatcher("add_kubernetes_metadata_node", client, &kubernetes.Node{}, opts, nil)
+			})
 			if err != nil {
 				k.log.Errorf("couldn't create watcher for %T due to error %+v", &kubernetes.Node{}, err)
 			}
 		}
 
 		if metaConf.Namespace.Enabled() {
-			namespaceWatcher, err = kubernetes.NewNamedWatcher("add_kubernetes_metadata_namespace", client, &kubernetes.Namespace{}, kubernetes.WatchOptions{
+			namespaceWatcher, err = acquire("namespace", kubernetes.WatchOptions{
 				SyncTimeout:  config.SyncPeriod,
 				Namespace:    config.Namespace,
 				HonorReSyncs: true,
-			}, nil)
+			}, func(opts kubernetes.WatchOptions) (kubernetes.Watcher, error) {
+				return kubernetes.NewNamedWatcher("add_kubernetes_metadata_namespace", client, &kubernetes.Namespace{}, opts, nil)
+			})
 			if err != nil {
 				k.log.Errorf("couldn't create watcher for %T due to error %+v", &kubernetes.Namespace{}, err)
 			}
@@ -241,30 +255,34 @@ func (k *kubernetesAnnotator) init(config kubeAnnotatorConfig, cfg *config.C) {
 			if err != nil {
 				k.log.Errorf("Error creating metadata client due to error %+v", err)
 			}
-			replicaSetWatcher, err = kubernetes.NewNamedMetadataWatcher(
-				"resource_metadata_enricher_rs",
-				client,
-				metadataClient,
-				schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"},
-				kubernetes.WatchOptions{
-					SyncTimeout:  config.SyncPeriod,
-					Namespace:    config.Namespace,
-					HonorReSyncs: true,
-				},
-				nil,
-				metadata.RemoveUnnecessaryReplicaSetData,
-			)
+			replicaSetWatcher, err = acquire("replicaset", kubernetes.WatchOptions{
+				SyncTimeout:  config.SyncPeriod,
+				Namespace:    config.Namespace,
+				HonorReSyncs: true,
+			}, func(opts kubernetes.WatchOptions) (kubernetes.Watcher, error) {
+				return kubernetes.NewNamedMetadataWatcher(
+					"resource_metadata_enricher_rs",
+					client,
+					metadataClient,
+					schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"},
+					opts,
+					nil,
+					metadata.RemoveUnnecessaryReplicaSetData,
+				)
+			})
 			if err != nil {
 				k.log.Errorf("Error creating watcher for %T due to error %+v", &kubernetes.ReplicaSet{}, err)
 			}
 			k.rsWatcher = replicaSetWatcher
 		}
 		if metaConf.CronJob {
-			jobWatcher, err = kubernetes.NewNamedWatcher("resource_metadata_enricher_job", client, &kubernetes.Job{}, kubernetes.WatchOptions{
+			jobWatcher, err = acquire("job", kubernetes.WatchOptions{
 				SyncTimeout:  config.SyncPeriod,
 				Namespace:    config.Namespace,
 				HonorReSyncs: true,
-			}, nil)
+			}, func(opts kubernetes.WatchOptions) (kubernetes.Watcher, error) {
+				return kubernetes.NewNamedWatcher("resource_metadata_enricher_job", client, &kubernetes.Job{}, opts, nil)
+			})
 			if err != nil {
 				k.log.Errorf("Error creating watcher for %T due to error %+v", &kubernetes.Job{}, err)
 			}
@@ -84,6 +84,12 @@ func (r *WhenProcessor) Run(event *beat.Event) (*beat.Event, error) {
 	return r.p.Run(event)
 }
 
+// SupportsFlatFields returns true if the wrapped processor supports events
+// with flat fields. Conditions only read fields through the Event methods.
+func (r *WhenProcessor) SupportsFlatFields() bool {
+	return beat.SupportsFlatFields(r.p)
+}
+
 func (r *WhenProcessor) String() string {
 	return fmt.Sprintf("%v, condition=%v", r.p.String(), r.condition.String())
 }
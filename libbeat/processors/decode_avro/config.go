@@ -0,0 +1,100 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package decode_avro
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/transport/httpcommon"
+)
+
+const (
+	// formatConfluent is the Confluent wire format: a zero magic byte and
+	// the big-endian schema ID followed by the binary encoded datum.
+	formatConfluent = "confluent"
+	// formatOCF is an object container file with an embedded schema.
+	formatOCF = "ocf"
+	// formatBinary is a binary encoded datum of the configured schema.
+	formatBinary = "binary"
+)
+
+type decodeAvroConfig struct {
+	Field          string         `config:"field" validate:"required"`
+	Target         *string        `config:"target_field"`
+	Format         string         `config:"format"`
+	Schema         string         `config:"schema"`
+	SchemaFile     string         `config:"schema_file"`
+	SchemaRegistry registryConfig `config:"schema_registry"`
+	Encoding       string         `config:"encoding"`
+	OverwriteKeys  bool           `config:"overwrite_keys"`
+	IgnoreMissing  bool           `config:"ignore_missing"`
+	IgnoreFailure  bool           `config:"ignore_failure"`
+}
+
+type registryConfig struct {
+	URL      string `config:"url"`
+	Username string `config:"username"`
+	Password string `config:"password"`
+	// RetryInterval is the time to wait before retrying to fetch a schema
+	// that could not be fetched.
+	RetryInterval time.Duration `config:"retry_interval" validate:"min=0"`
+
+	Transport httpcommon.HTTPTransportSettings `config:",inline"`
+}
+
+func defaultConfig() decodeAvroConfig {
+	return decodeAvroConfig{
+		Field:         "message",
+		Format:        formatConfluent,
+		Encoding:      "raw",
+		OverwriteKeys: true,
+		SchemaRegistry: registryConfig{
+			RetryInterval: time.Minute,
+			Transport:     httpcommon.DefaultHTTPTransportSettings(),
+		},
+	}
+}
+
+func (c *decodeAvroConfig) Validate() error {
+	if c.Schema != "" && c.SchemaFile != "" {
+		return fmt.Errorf("only one of schema and schema_file can be set")
+	}
+	if (c.Schema != "" || c.SchemaFile != "") && c.Format != formatBinary {
+		return fmt.Errorf("schema and schema_file can only be set when format is %q", formatBinary)
+	}
+	switch c.Format {
+	case formatConfluent:
+		if c.SchemaRegistry.URL == "" {
+			return fmt.Errorf("schema_registry.url is required when format is %q", formatConfluent)
+		}
+	case formatBinary:
+		if c.Schema == "" && c.SchemaFile == "" {
+			return fmt.Errorf("schema or schema_file is required when format is %q", formatBinary)
+		}
+	case formatOCF:
+	default:
+		return fmt.Errorf("invalid format %q, must be one of %q, %q or %q", c.Format, formatConfluent, formatOCF, formatBinary)
+	}
+	switch c.Encoding {
+	case "raw", "base64":
+	default:
+		return fmt.Errorf("invalid encoding %q, must be one of \"raw\" or \"base64\"", c.Encoding)
+	}
+	return nil
+}
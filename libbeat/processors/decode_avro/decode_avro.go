@@ -0,0 +1,207 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package decode_avro
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/encoding/avro"
+	"github.com/elastic/beats/v7/libbeat/common/jsontransform"
+	"github.com/elastic/beats/v7/libbeat/processors"
+	"github.com/elastic/beats/v7/libbeat/processors/checks"
+	jsprocessor "github.com/elastic/beats/v7/libbeat/processors/script/javascript/module/processor"
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+type decodeAvro struct {
+	decodeAvroConfig
+
+	schema   *avro.Schema
+	registry *registry
+	log      *logp.Logger
+}
+
+var errFieldIsNotBytes = errors.New("field value is not a string or bytes")
+
+const (
+	procName = "decode_avro"
+	logName  = "processor." + procName
+)
+
+func init() {
+	processors.RegisterPlugin(procName,
+		checks.ConfigChecked(New,
+			checks.AllowedFields(
+				"field", "target_field", "format",
+				"schema", "schema_file", "schema_registry",
+				"encoding", "overwrite_keys",
+				"ignore_missing", "ignore_failure", "when",
+			)))
+	jsprocessor.RegisterPlugin("DecodeAvro", New)
+}
+
+// New constructs a new decode_avro processor.
+func New(c *config.C) (beat.Processor, error) {
+	config := defaultConfig()
+
+	if err := c.Unpack(&config); err != nil {
+		return nil, fmt.Errorf("fail to unpack the "+procName+" processor configuration: %w", err)
+	}
+
+	return newDecodeAvro(config)
+}
+
+func newDecodeAvro(config decodeAvroConfig) (*decodeAvro, error) {
+	// Default target to overwriting field.
+	if config.Target == nil {
+		config.Target = &config.Field
+	}
+
+	p := &decodeAvro{
+		decodeAvroConfig: config,
+		log:              logp.NewLogger(logName),
+	}
+
+	schema := config.Schema
+	if config.SchemaFile != "" {
+		data, err := os.ReadFile(config.SchemaFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the "+procName+" processor schema: %w", err)
+		}
+		schema = string(data)
+	}
+	if schema != "" {
+		var err error
+		if p.schema, err = avro.ParseSchema(schema); err != nil {
+			return nil, fmt.Errorf("invalid "+procName+" processor schema: %w", err)
+		}
+	}
+
+	if config.Format == formatConfluent {
+		var err error
+		if p.registry, err = newRegistry(config.SchemaRegistry, p.log); err != nil {
+			return nil, fmt.Errorf("failed to create the "+procName+" processor schema registry client: %w", err)
+		}
+	}
+	return p, nil
+}
+
+func (p *decodeAvro) Run(event *beat.Event) (*beat.Event, error) {
+	if err := p.run(event); err != nil && !p.IgnoreFailure {
+		err = fmt.Errorf("failed in decode_avro on the %q field: %w", p.Field, err)
+		_, _ = event.PutValue("error.message", err.Error())
+		return event, err
+	}
+	return event, nil
+}
+
+func (p *decodeAvro) run(event *beat.Event) error {
+	data, err := event.GetValue(p.Field)
+	if err != nil {
+		if p.IgnoreMissing && errors.Is(err, mapstr.ErrKeyNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	var raw []byte
+	switch v := data.(type) {
+	case string:
+		raw = []byte(v)
+	case []byte:
+		raw = v
+	default:
+		return errFieldIsNotBytes
+	}
+	if p.Encoding == "base64" {
+		if raw, err = base64.StdEncoding.DecodeString(string(raw)); err != nil {
+			return fmt.Errorf("error decoding base64 message: %w", err)
+		}
+	}
+
+	value, err := p.decode(raw)
+	if err != nil {
+		return err
+	}
+
+	if *p.Target != "" {
+		if _, err = event.PutValue(*p.Target, value); err != nil {
+			return fmt.Errorf("failed to put decoded value into field %q: %w", *p.Target, err)
+		}
+		return nil
+	}
+	fields, ok := value.(mapstr.M)
+	if !ok {
+		return fmt.Errorf("decoded value of type %T cannot be written to the root of the event", value)
+	}
+	jsontransform.WriteJSONKeys(event, fields, false, p.OverwriteKeys, !p.IgnoreFailure)
+	return nil
+}
+
+// decode decodes raw according to the configured format. Object container
+// files with more than one record are decoded into a list of records.
+func (p *decodeAvro) decode(raw []byte) (interface{}, error) {
+	schema := p.schema
+	switch p.Format {
+	case formatOCF:
+		_, records, err := avro.ReadOCF(raw)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding avro container file: %w", err)
+		}
+		if len(records) == 1 {
+			return records[0], nil
+		}
+		return records, nil
+	case formatConfluent:
+		if len(raw) < 5 || raw[0] != 0 {
+			return nil, errors.New("message is not in the confluent wire format")
+		}
+		var err error
+		if schema, err = p.registry.schema(binary.BigEndian.Uint32(raw[1:5])); err != nil {
+			return nil, err
+		}
+		raw = raw[5:]
+	}
+
+	v, n, err := avro.Decode(schema, raw)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding avro message: %w", err)
+	}
+	if n != len(raw) {
+		return nil, fmt.Errorf("error decoding avro message: %d unexpected bytes after the message", len(raw)-n)
+	}
+	return v, nil
+}
+
+func (p *decodeAvro) Close() error {
+	if p.registry != nil {
+		p.registry.close()
+	}
+	return nil
+}
+
+func (p *decodeAvro) String() string {
+	return fmt.Sprintf("%s=[field=%s, format=%s]", procName, p.Field, p.Format)
+}
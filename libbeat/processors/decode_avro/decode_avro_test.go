@@ -0,0 +1,260 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package decode_avro
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+const testSchema = `{"type": "record", "name": "Login", "fields": [{"name": "user", "type": "string"}, {"name": "attempts", "type": "long"}]}`
+
+// testLogin is the binary encoding of {"user": "alice", "attempts": 3}.
+var testLogin = []byte{10, 'a', 'l', 'i', 'c', 'e', 6}
+
+func newTestProcessor(t *testing.T, settings map[string]interface{}) beat.Processor {
+	t.Helper()
+	p, err := New(config.MustNewConfigFrom(settings))
+	require.NoError(t, err)
+	return p
+}
+
+func TestDecodeAvroBinary(t *testing.T) {
+	p := newTestProcessor(t, map[string]interface{}{
+		"format":       "binary",
+		"schema":       testSchema,
+		"target_field": "login",
+	})
+	event, err := p.Run(&beat.Event{Fields: mapstr.M{"message": string(testLogin)}})
+	require.NoError(t, err)
+	login, err := event.GetValue("login")
+	require.NoError(t, err)
+	assert.Equal(t, mapstr.M{"user": "alice", "attempts": int64(3)}, login)
+
+	_, err = p.Run(&beat.Event{Fields: mapstr.M{"message": string(append(testLogin, 0))}})
+	assert.ErrorContains(t, err, "unexpected bytes")
+
+	t.Run("root target", func(t *testing.T) {
+		p := newTestProcessor(t, map[string]interface{}{
+			"format":       "binary",
+			"schema":       testSchema,
+			"target_field": "",
+			"encoding":     "base64",
+		})
+		event, err := p.Run(&beat.Event{Fields: mapstr.M{"message": base64.StdEncoding.EncodeToString(testLogin)}})
+		require.NoError(t, err)
+		assert.Equal(t, "alice", event.Fields["user"])
+		assert.Equal(t, int64(3), event.Fields["attempts"])
+	})
+}
+
+func TestDecodeAvroConfluent(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		user, pass, _ := r.BasicAuth()
+		switch {
+		case user != "beats" || pass != "secret":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/schemas/ids/7":
+			w.Header().Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+			_, _ = w.Write([]byte(`{"schema": "{\"type\": \"record\", \"name\": \"Login\", \"fields\": [{\"name\": \"user\", \"type\": \"string\"}, {\"name\": \"attempts\", \"type\": \"long\"}]}"}`))
+		case r.URL.Path == "/schemas/ids/8":
+			_, _ = w.Write([]byte(`{"schema": "syntax = \"proto3\";", "schemaType": "PROTOBUF"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error_code": 40403, "message": "Schema not found"}`))
+		}
+	}))
+	defer srv.Close()
+
+	p := newTestProcessor(t, map[string]interface{}{
+		"schema_registry.url":      srv.URL + "/",
+		"schema_registry.username": "beats",
+		"schema_registry.password": "secret",
+	})
+	defer p.(*decodeAvro).Close()
+
+	message := func(id uint32, data []byte) string {
+		return string(append(binary.BigEndian.AppendUint32([]byte{0}, id), data...))
+	}
+
+	for i := 0; i < 3; i++ {
+		event, err := p.Run(&beat.Event{Fields: mapstr.M{"message": message(7, testLogin)}})
+		require.NoError(t, err)
+		assert.Equal(t, mapstr.M{"user": "alice", "attempts": int64(3)}, event.Fields["message"])
+	}
+	assert.Equal(t, int32(1), requests.Load(), "schemas are cached")
+
+	// Failures are cached until the retry interval elapses.
+	for i := 0; i < 3; i++ {
+		_, err := p.Run(&beat.Event{Fields: mapstr.M{"message": message(9, testLogin)}})
+		assert.ErrorContains(t, err, "Schema not found")
+	}
+	assert.Equal(t, int32(2), requests.Load())
+
+	_, err := p.Run(&beat.Event{Fields: mapstr.M{"message": message(8, testLogin)}})
+	assert.ErrorContains(t, err, "unsupported schema type")
+
+	_, err = p.Run(&beat.Event{Fields: mapstr.M{"message": string(testLogin)}})
+	assert.ErrorContains(t, err, "confluent wire format")
+}
+
+func TestRegistryConcurrentFetch(t *testing.T) {
+	var requests atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path == "/schemas/ids/7" {
+			<-release
+		}
+		_, _ = w.Write([]byte(`{"schema": "\"string\""}`))
+	}))
+	defer srv.Close()
+
+	c := defaultConfig().SchemaRegistry
+	c.URL = srv.URL
+	r, err := newRegistry(c, logp.NewLogger("test"))
+	require.NoError(t, err)
+	defer r.close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := r.schema(7)
+			assert.NoError(t, err)
+		}()
+	}
+
+	// Other schemas are fetched while schema 7 is being fetched.
+	require.Eventually(t, func() bool { return requests.Load() == 1 }, 5*time.Second, time.Millisecond)
+	_, err = r.schema(8)
+	require.NoError(t, err)
+
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(2), requests.Load(), "concurrent lookups of a schema share one request")
+}
+
+func TestDecodeAvroOCF(t *testing.T) {
+	var file []byte
+	appendLong := func(n int64) { file = binary.AppendUvarint(file, uint64((n<<1)^(n>>63))) }
+	appendString := func(s string) {
+		appendLong(int64(len(s)))
+		file = append(file, s...)
+	}
+	sync := []byte("0123456789abcdef")
+
+	file = append(file, 'O', 'b', 'j', 1)
+	appendLong(1)
+	appendString("avro.schema")
+	appendString(testSchema)
+	appendLong(0)
+	file = append(file, sync...)
+	appendLong(2)
+	appendLong(int64(2 * len(testLogin)))
+	file = append(file, testLogin...)
+	file = append(file, testLogin...)
+	file = append(file, sync...)
+
+	p := newTestProcessor(t, map[string]interface{}{
+		"format":       "ocf",
+		"target_field": "logins",
+	})
+	event, err := p.Run(&beat.Event{Fields: mapstr.M{"message": file}})
+	require.NoError(t, err)
+	login := mapstr.M{"user": "alice", "attempts": int64(3)}
+	assert.Equal(t, []interface{}{login, login}, event.Fields["logins"])
+}
+
+func TestDecodeAvroErrors(t *testing.T) {
+	binarySettings := func(extra map[string]interface{}) map[string]interface{} {
+		s := map[string]interface{}{"format": "binary", "schema": testSchema}
+		for k, v := range extra {
+			s[k] = v
+		}
+		return s
+	}
+
+	t.Run("invalid message", func(t *testing.T) {
+		p := newTestProcessor(t, binarySettings(nil))
+		event, err := p.Run(&beat.Event{Fields: mapstr.M{"message": "\x02"}})
+		assert.Error(t, err)
+		msg, _ := event.GetValue("error.message")
+		assert.Contains(t, msg, "error decoding avro message")
+	})
+
+	t.Run("field is not a string", func(t *testing.T) {
+		p := newTestProcessor(t, binarySettings(nil))
+		_, err := p.Run(&beat.Event{Fields: mapstr.M{"message": 1}})
+		assert.ErrorIs(t, err, errFieldIsNotBytes)
+	})
+
+	t.Run("missing field", func(t *testing.T) {
+		p := newTestProcessor(t, binarySettings(map[string]interface{}{"ignore_missing": true}))
+		_, err := p.Run(&beat.Event{Fields: mapstr.M{}})
+		assert.NoError(t, err)
+	})
+
+	t.Run("ignore failure", func(t *testing.T) {
+		p := newTestProcessor(t, binarySettings(map[string]interface{}{"ignore_failure": true}))
+		event, err := p.Run(&beat.Event{Fields: mapstr.M{"message": "\x02"}})
+		assert.NoError(t, err)
+		assert.Equal(t, mapstr.M{"message": "\x02"}, event.Fields)
+	})
+
+	t.Run("non record value in root", func(t *testing.T) {
+		p := newTestProcessor(t, map[string]interface{}{"format": "binary", "schema": `"long"`, "target_field": ""})
+		_, err := p.Run(&beat.Event{Fields: mapstr.M{"message": "\x02"}})
+		assert.Error(t, err)
+	})
+}
+
+func TestDecodeAvroConfig(t *testing.T) {
+	for name, settings := range map[string]map[string]interface{}{
+		"confluent without registry": {},
+		"binary without schema":      {"format": "binary"},
+		"schema and file":            {"format": "binary", "schema": testSchema, "schema_file": "login.avsc"},
+		"schema with ocf":            {"format": "ocf", "schema": testSchema},
+		"invalid schema":             {"format": "binary", "schema": `{"type": "record"}`},
+		"missing schema file":        {"format": "binary", "schema_file": "missing.avsc"},
+		"unknown format":             {"format": "json"},
+		"invalid encoding":           {"format": "ocf", "encoding": "hex"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := New(config.MustNewConfigFrom(settings))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,152 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package decode_avro
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/elastic/beats/v7/libbeat/common/encoding/avro"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/transport/httpcommon"
+)
+
+// registry fetches schemas by ID from a Confluent compatible schema
+// registry. Schemas are immutable so they are cached forever. Concurrent
+// lookups of the same schema share a single request, and the cache is not
+// locked while it runs.
+type registry struct {
+	config   registryConfig
+	client   *http.Client
+	inflight singleflight.Group
+
+	mu       sync.Mutex
+	schemas  map[uint32]*avro.Schema
+	failures map[uint32]registryFailure
+}
+
+type registryFailure struct {
+	err  error
+	time time.Time
+}
+
+func newRegistry(config registryConfig, log *logp.Logger) (*registry, error) {
+	client, err := config.Transport.Client(httpcommon.WithLogger(log))
+	if err != nil {
+		return nil, err
+	}
+	return &registry{
+		config:   config,
+		client:   client,
+		schemas:  map[uint32]*avro.Schema{},
+		failures: map[uint32]registryFailure{},
+	}, nil
+}
+
+func (r *registry) schema(id uint32) (*avro.Schema, error) {
+	if s, found, err := r.cached(id); found {
+		return s, err
+	}
+
+	v, err, _ := r.inflight.Do(strconv.FormatUint(uint64(id), 10), func() (interface{}, error) {
+		// The schema may have been stored while waiting for the group.
+		if s, found, err := r.cached(id); found {
+			return s, err
+		}
+
+		s, err := r.fetch(id)
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if err != nil {
+			err = fmt.Errorf("failed to fetch schema %d: %w", id, err)
+			r.failures[id] = registryFailure{err: err, time: time.Now()}
+			return nil, err
+		}
+		delete(r.failures, id)
+		r.schemas[id] = s
+		return s, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*avro.Schema), nil
+}
+
+// cached returns the schema or the recent failure to fetch it, if any.
+func (r *registry) cached(id uint32) (s *avro.Schema, found bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if s, ok := r.schemas[id]; ok {
+		return s, true, nil
+	}
+	if f, ok := r.failures[id]; ok && time.Since(f.time) < r.config.RetryInterval {
+		return nil, true, f.err
+	}
+	return nil, false, nil
+}
+
+func (r *registry) fetch(id uint32) (*avro.Schema, error) {
+	url := fmt.Sprintf("%s/schemas/ids/%d", strings.TrimSuffix(r.config.URL, "/"), id)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json")
+	if r.config.Username != "" {
+		req.SetBasicAuth(r.config.Username, r.config.Password)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var result struct {
+		Schema     string `json:"schema"`
+		SchemaType string `json:"schemaType"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	if result.SchemaType != "" && result.SchemaType != "AVRO" {
+		return nil, fmt.Errorf("unsupported schema type %q", result.SchemaType)
+	}
+	return avro.ParseSchema(result.Schema)
+}
+
+func (r *registry) close() {
+	r.client.CloseIdleConnections()
+}
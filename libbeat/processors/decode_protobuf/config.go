@@ -0,0 +1,75 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package decode_protobuf
+
+import (
+	"fmt"
+
+	"github.com/elastic/beats/v7/libbeat/common/encoding/protobuf"
+)
+
+type decodeProtobufConfig struct {
+	Field          string  `config:"field" validate:"required"`
+	Target         *string `config:"target_field"`
+	DescriptorFile string  `config:"descriptor_file" validate:"required"`
+	MessageType    string  `config:"message_type" validate:"required"`
+	Encoding       string  `config:"encoding"`
+	Bytes          string  `config:"bytes"`
+	Enums          string  `config:"enums"`
+	FieldNames     string  `config:"field_names"`
+	EmitDefaults   bool    `config:"emit_defaults"`
+	OverwriteKeys  bool    `config:"overwrite_keys"`
+	DocumentID     string  `config:"document_id"`
+	IgnoreMissing  bool    `config:"ignore_missing"`
+	IgnoreFailure  bool    `config:"ignore_failure"`
+}
+
+func defaultConfig() decodeProtobufConfig {
+	return decodeProtobufConfig{
+		Field:         "message",
+		Encoding:      "raw",
+		Bytes:         string(protobuf.BytesBase64),
+		Enums:         "name",
+		FieldNames:    "proto",
+		OverwriteKeys: true,
+	}
+}
+
+func (c *decodeProtobufConfig) Validate() error {
+	switch c.Encoding {
+	case "raw", "base64":
+	default:
+		return fmt.Errorf("invalid encoding %q, must be one of \"raw\" or \"base64\"", c.Encoding)
+	}
+	switch protobuf.BytesMode(c.Bytes) {
+	case protobuf.BytesBase64, protobuf.BytesHex, protobuf.BytesString:
+	default:
+		return fmt.Errorf("invalid bytes %q, must be one of %q, %q or %q", c.Bytes, protobuf.BytesBase64, protobuf.BytesHex, protobuf.BytesString)
+	}
+	switch c.Enums {
+	case "name", "number":
+	default:
+		return fmt.Errorf("invalid enums %q, must be one of \"name\" or \"number\"", c.Enums)
+	}
+	switch c.FieldNames {
+	case "proto", "json":
+	default:
+		return fmt.Errorf("invalid field_names %q, must be one of \"proto\" or \"json\"", c.FieldNames)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package decode_protobuf
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/encoding/protobuf"
+	"github.com/elastic/beats/v7/libbeat/common/jsontransform"
+	"github.com/elastic/beats/v7/libbeat/processors"
+	"github.com/elastic/beats/v7/libbeat/processors/checks"
+	jsprocessor "github.com/elastic/beats/v7/libbeat/processors/script/javascript/module/processor"
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+type decodeProtobuf struct {
+	decodeProtobufConfig
+
+	decoder *protobuf.Decoder
+	log     *logp.Logger
+}
+
+var errFieldIsNotBytes = errors.New("field value is not a string or bytes")
+
+const (
+	procName = "decode_protobuf"
+	logName  = "processor." + procName
+)
+
+func init() {
+	processors.RegisterPlugin(procName,
+		checks.ConfigChecked(New,
+			checks.RequireFields("descriptor_file", "message_type"),
+			checks.AllowedFields(
+				"field", "target_field",
+				"descriptor_file", "message_type",
+				"encoding", "bytes", "enums", "field_names",
+				"emit_defaults", "overwrite_keys", "document_id",
+				"ignore_missing", "ignore_failure", "when",
+			)))
+	jsprocessor.RegisterPlugin("DecodeProtobuf", New)
+}
+
+// New constructs a new decode_protobuf processor.
+func New(c *config.C) (beat.Processor, error) {
+	config := defaultConfig()
+
+	if err := c.Unpack(&config); err != nil {
+		return nil, fmt.Errorf("fail to unpack the "+procName+" processor configuration: %w", err)
+	}
+
+	return newDecodeProtobuf(config)
+}
+
+func newDecodeProtobuf(config decodeProtobufConfig) (*decodeProtobuf, error) {
+	// Default target to overwriting field.
+	if config.Target == nil {
+		config.Target = &config.Field
+	}
+
+	desc, err := protobuf.LoadDescriptor(config.DescriptorFile, config.MessageType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the "+procName+" processor descriptor: %w", err)
+	}
+	dec := protobuf.NewDecoder(desc)
+	dec.BytesAs(protobuf.BytesMode(config.Bytes))
+	if config.Enums == "number" {
+		dec.EnumsAsNumbers()
+	}
+	if config.FieldNames == "json" {
+		dec.UseJSONNames()
+	}
+	if config.EmitDefaults {
+		dec.EmitDefaults()
+	}
+
+	return &decodeProtobuf{
+		decodeProtobufConfig: config,
+		decoder:              dec,
+		log:                  logp.NewLogger(logName),
+	}, nil
+}
+
+func (p *decodeProtobuf) Run(event *beat.Event) (*beat.Event, error) {
+	if err := p.run(event); err != nil && !p.IgnoreFailure {
+		err = fmt.Errorf("failed in decode_protobuf on the %q field: %w", p.Field, err)
+		_, _ = event.PutValue("error.message", err.Error())
+		return event, err
+	}
+	return event, nil
+}
+
+func (p *decodeProtobuf) run(event *beat.Event) error {
+	data, err := event.GetValue(p.Field)
+	if err != nil {
+		if p.IgnoreMissing && errors.Is(err, mapstr.ErrKeyNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	var raw []byte
+	switch v := data.(type) {
+	case string:
+		raw = []byte(v)
+	case []byte:
+		raw = v
+	default:
+		return errFieldIsNotBytes
+	}
+	if p.Encoding == "base64" {
+		if raw, err = base64.StdEncoding.DecodeString(string(raw)); err != nil {
+			return fmt.Errorf("error decoding base64 message: %w", err)
+		}
+	}
+
+	msg, err := p.decoder.Decode(raw)
+	if err != nil {
+		return fmt.Errorf("error decoding protobuf message: %w", err)
+	}
+
+	var id string
+	if tmp, err := msg.GetValue(p.DocumentID); err == nil {
+		if v, ok := tmp.(string); ok {
+			id = v
+			_ = msg.Delete(p.DocumentID)
+		}
+	}
+
+	if *p.Target != "" {
+		if _, err = event.PutValue(*p.Target, msg); err != nil {
+			return fmt.Errorf("failed to put decoded message into field %q: %w", *p.Target, err)
+		}
+	} else {
+		jsontransform.WriteJSONKeys(event, msg, false, p.OverwriteKeys, !p.IgnoreFailure)
+	}
+
+	if id != "" {
+		event.SetID(id)
+	}
+	return nil
+}
+
+func (p *decodeProtobuf) String() string {
+	json, _ := json.Marshal(p.decodeProtobufConfig)
+	return procName + "=" + string(json)
+}
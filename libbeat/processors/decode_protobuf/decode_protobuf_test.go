@@ -0,0 +1,207 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package decode_protobuf
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+const testDescriptor = "../../common/encoding/protobuf/testdata/login.desc"
+
+// testLogin is a serialized test.auth.Login message with user_name "alice",
+// result FAILURE and token 0xcafe.
+var testLogin = func() string {
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	b = protowire.AppendString(b, "alice")
+	b = protowire.AppendTag(b, 3, protowire.VarintType)
+	b = protowire.AppendVarint(b, 1)
+	b = protowire.AppendTag(b, 7, protowire.BytesType)
+	b = protowire.AppendBytes(b, []byte{0xca, 0xfe})
+	return string(b)
+}()
+
+func newTestProcessor(t *testing.T, settings map[string]interface{}) beat.Processor {
+	t.Helper()
+	c := config.MustNewConfigFrom(map[string]interface{}{
+		"descriptor_file": testDescriptor,
+		"message_type":    "test.auth.Login",
+	})
+	require.NoError(t, c.Merge(settings))
+	p, err := New(c)
+	require.NoError(t, err)
+	return p
+}
+
+func TestDecodeProtobuf(t *testing.T) {
+	testCases := []struct {
+		description string
+		settings    map[string]interface{}
+		input       mapstr.M
+		output      mapstr.M
+		id          string
+	}{
+		{
+			description: "replace the source field",
+			input:       mapstr.M{"message": testLogin},
+			output: mapstr.M{
+				"message": mapstr.M{"user_name": "alice", "result": "FAILURE", "token": "yv4="},
+			},
+		},
+		{
+			description: "target field and options",
+			settings: map[string]interface{}{
+				"target_field": "login",
+				"bytes":        "hex",
+				"enums":        "number",
+				"field_names":  "json",
+			},
+			input: mapstr.M{"message": testLogin},
+			output: mapstr.M{
+				"message": testLogin,
+				"login":   mapstr.M{"userName": "alice", "result": int64(1), "token": "cafe"},
+			},
+		},
+		{
+			description: "base64 encoded bytes field",
+			settings: map[string]interface{}{
+				"field":        "payload",
+				"target_field": "login",
+				"encoding":     "base64",
+			},
+			input: mapstr.M{"payload": []byte(base64.StdEncoding.EncodeToString([]byte(testLogin)))},
+			output: mapstr.M{
+				"payload": []byte(base64.StdEncoding.EncodeToString([]byte(testLogin))),
+				"login":   mapstr.M{"user_name": "alice", "result": "FAILURE", "token": "yv4="},
+			},
+		},
+		{
+			description: "decode into the root and use the document id",
+			settings: map[string]interface{}{
+				"target_field": "",
+				"document_id":  "user_name",
+			},
+			input: mapstr.M{"message": testLogin, "result": "SUCCESS"},
+			output: mapstr.M{
+				"message": testLogin,
+				"result":  "FAILURE",
+				"token":   "yv4=",
+			},
+			id: "alice",
+		},
+		{
+			description: "decode into the root without overwriting keys",
+			settings: map[string]interface{}{
+				"target_field":   "",
+				"overwrite_keys": false,
+			},
+			input: mapstr.M{"message": testLogin, "result": "SUCCESS"},
+			output: mapstr.M{
+				"message":   testLogin,
+				"user_name": "alice",
+				"result":    "SUCCESS",
+				"token":     "yv4=",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			p := newTestProcessor(t, tc.settings)
+			event, err := p.Run(&beat.Event{Fields: tc.input})
+			require.NoError(t, err)
+			assert.Equal(t, tc.output, event.Fields)
+			if tc.id != "" {
+				assert.Equal(t, tc.id, event.Meta["_id"])
+			}
+		})
+	}
+}
+
+func TestDecodeProtobufErrors(t *testing.T) {
+	t.Run("invalid message", func(t *testing.T) {
+		p := newTestProcessor(t, nil)
+		event, err := p.Run(&beat.Event{Fields: mapstr.M{"message": "\xff\xff"}})
+		assert.Error(t, err)
+		msg, _ := event.GetValue("error.message")
+		assert.Contains(t, msg, "error decoding protobuf message")
+	})
+
+	t.Run("missing required field", func(t *testing.T) {
+		p := newTestProcessor(t, map[string]interface{}{"target_field": "login"})
+		var b []byte
+		b = protowire.AppendTag(b, 2, protowire.VarintType)
+		b = protowire.AppendVarint(b, 1)
+		_, err := p.Run(&beat.Event{Fields: mapstr.M{"message": string(b)}})
+		assert.Error(t, err)
+	})
+
+	t.Run("field is not a string", func(t *testing.T) {
+		p := newTestProcessor(t, nil)
+		_, err := p.Run(&beat.Event{Fields: mapstr.M{"message": 1}})
+		assert.ErrorIs(t, err, errFieldIsNotBytes)
+	})
+
+	t.Run("missing field", func(t *testing.T) {
+		p := newTestProcessor(t, map[string]interface{}{"ignore_missing": true})
+		_, err := p.Run(&beat.Event{Fields: mapstr.M{}})
+		assert.NoError(t, err)
+
+		p = newTestProcessor(t, nil)
+		_, err = p.Run(&beat.Event{Fields: mapstr.M{}})
+		assert.Error(t, err)
+	})
+
+	t.Run("ignore failure", func(t *testing.T) {
+		p := newTestProcessor(t, map[string]interface{}{"ignore_failure": true, "encoding": "base64"})
+		event, err := p.Run(&beat.Event{Fields: mapstr.M{"message": "!"}})
+		assert.NoError(t, err)
+		assert.Equal(t, mapstr.M{"message": "!"}, event.Fields)
+	})
+}
+
+func TestDecodeProtobufConfig(t *testing.T) {
+	for name, settings := range map[string]map[string]interface{}{
+		"unknown message type": {"message_type": "test.auth.Logout"},
+		"missing descriptor":   {"descriptor_file": "missing.desc"},
+		"invalid encoding":     {"encoding": "hex"},
+		"invalid bytes":        {"bytes": "binary"},
+		"invalid enums":        {"enums": "string"},
+		"invalid field names":  {"field_names": "camel"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := config.MustNewConfigFrom(map[string]interface{}{
+				"descriptor_file": testDescriptor,
+				"message_type":    "test.auth.Login",
+			})
+			require.NoError(t, c.Merge(settings))
+			_, err := New(c)
+			assert.Error(t, err)
+		})
+	}
+}
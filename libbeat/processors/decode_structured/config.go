@@ -0,0 +1,87 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package decode_structured
+
+import (
+	"fmt"
+)
+
+const (
+	formatJSON     = "json"
+	formatProtobuf = "protobuf"
+
+	encodingRaw    = "raw"
+	encodingBase64 = "base64"
+)
+
+type config struct {
+	Field         string                 `config:"field" validate:"required"`
+	Target        *string                `config:"target_field"`
+	Format        string                 `config:"format"`
+	Schema        map[string]interface{} `config:"schema"`
+	SchemaFile    string                 `config:"schema_file"`
+	Protobuf      protobufConfig         `config:"protobuf"`
+	ErrorsField   string                 `config:"errors_field"`
+	OverwriteKeys bool                   `config:"overwrite_keys"`
+	IgnoreMissing bool                   `config:"ignore_missing"`
+	IgnoreFailure bool                   `config:"ignore_failure"`
+}
+
+type protobufConfig struct {
+	// DescriptorFile is a serialized FileDescriptorSet, as written by
+	// `protoc --include_imports --descriptor_set_out`.
+	DescriptorFile string `config:"descriptor_file"`
+	MessageType    string `config:"message_type"`
+	Encoding       string `config:"encoding"`
+}
+
+func defaultConfig() config {
+	return config{
+		Field:         "message",
+		Format:        formatJSON,
+		ErrorsField:   "error.message",
+		OverwriteKeys: true,
+		Protobuf: protobufConfig{
+			Encoding: encodingRaw,
+		},
+	}
+}
+
+func (c *config) Validate() error {
+	if c.Schema != nil && c.SchemaFile != "" {
+		return fmt.Errorf("only one of schema and schema_file can be set")
+	}
+	switch c.Format {
+	case formatJSON:
+		if c.Schema == nil && c.SchemaFile == "" {
+			return fmt.Errorf("schema or schema_file is required when format is %q", formatJSON)
+		}
+	case formatProtobuf:
+		if c.Protobuf.DescriptorFile == "" || c.Protobuf.MessageType == "" {
+			return fmt.Errorf("protobuf.descriptor_file and protobuf.message_type are required when format is %q", formatProtobuf)
+		}
+		switch c.Protobuf.Encoding {
+		case encodingRaw, encodingBase64:
+		default:
+			return fmt.Errorf("invalid protobuf.encoding %q, must be one of %q or %q", c.Protobuf.Encoding, encodingRaw, encodingBase64)
+		}
+	default:
+		return fmt.Errorf("invalid format %q, must be one of %q or %q", c.Format, formatJSON, formatProtobuf)
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package decode_structured
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/encoding/protobuf"
+	"github.com/elastic/beats/v7/libbeat/common/jsontransform"
+	"github.com/elastic/beats/v7/libbeat/processors"
+	"github.com/elastic/beats/v7/libbeat/processors/checks"
+	jsprocessor "github.com/elastic/beats/v7/libbeat/processors/script/javascript/module/processor"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+const (
+	procName = "decode_structured"
+	logName  = "processor." + procName
+)
+
+var errFieldIsNotString = errors.New("field value is not a string")
+
+func init() {
+	processors.RegisterPlugin(procName,
+		checks.ConfigChecked(New,
+			checks.RequireFields("field"),
+			checks.AllowedFields(
+				"field", "target_field", "format",
+				"schema", "schema_file", "protobuf",
+				"errors_field", "overwrite_keys",
+				"ignore_missing", "ignore_failure", "when",
+			)))
+	jsprocessor.RegisterPlugin("DecodeStructured", New)
+}
+
+type decodeStructured struct {
+	config
+
+	schema   *schema
+	protobuf *protobuf.Decoder
+	log      *logp.Logger
+}
+
+// New constructs a new decode_structured processor.
+func New(c *conf.C) (beat.Processor, error) {
+	config := defaultConfig()
+
+	if err := c.Unpack(&config); err != nil {
+		return nil, fmt.Errorf("fail to unpack the "+procName+" processor configuration: %w", err)
+	}
+
+	return newDecodeStructured(config)
+}
+
+func newDecodeStructured(c config) (*decodeStructured, error) {
+	// Default target to overwriting field.
+	if c.Target == nil {
+		c.Target = &c.Field
+	}
+
+	p := &decodeStructured{
+		config: c,
+		log:    logp.NewLogger(logName),
+	}
+
+	var err error
+	switch {
+	case c.SchemaFile != "":
+		p.schema, err = loadSchemaFile(c.SchemaFile)
+	case c.Schema != nil:
+		p.schema, err = compileSchema(c.Schema)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema in "+procName+" processor: %w", err)
+	}
+
+	if c.Format == formatProtobuf {
+		desc, err := protobuf.LoadDescriptor(c.Protobuf.DescriptorFile, c.Protobuf.MessageType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load protobuf descriptor in "+procName+" processor: %w", err)
+		}
+		p.protobuf = protobuf.NewDecoder(desc)
+	}
+	return p, nil
+}
+
+func (p *decodeStructured) Run(event *beat.Event) (*beat.Event, error) {
+	if err := p.run(event); err != nil && !p.IgnoreFailure {
+		err = fmt.Errorf("failed in decode_structured on the %q field: %w", p.Field, err)
+		_, _ = event.PutValue("error.message", err.Error())
+		return event, err
+	}
+	return event, nil
+}
+
+func (p *decodeStructured) run(event *beat.Event) error {
+	data, err := event.GetValue(p.Field)
+	if err != nil {
+		if p.IgnoreMissing && errors.Is(err, mapstr.ErrKeyNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	var errs []string
+	value, err := p.decode(data, &errs)
+	if err != nil {
+		return err
+	}
+	if p.schema != nil {
+		value = p.schema.apply("", value, &errs)
+	}
+
+	if *p.Target != "" {
+		if _, err = event.PutValue(*p.Target, value); err != nil {
+			return fmt.Errorf("failed to put value into field %q: %w", *p.Target, err)
+		}
+	} else {
+		fields, ok := value.(mapstr.M)
+		if !ok {
+			return fmt.Errorf("decoded value is %s, an object is required when target_field is empty", describe(value))
+		}
+		// Timestamps converted by the schema are not strings anymore.
+		if ts, ok := fields["@timestamp"].(time.Time); ok && p.OverwriteKeys {
+			event.Timestamp = ts
+			delete(fields, "@timestamp")
+		}
+		jsontransform.WriteJSONKeys(event, fields, false, p.OverwriteKeys, !p.IgnoreFailure)
+	}
+
+	if len(errs) > 0 {
+		if p.log.IsDebug() {
+			p.log.Debugf("Decoded %q field has %d validation errors: %v", p.Field, len(errs), errs)
+		}
+		if _, err = event.PutValue(p.ErrorsField, errs); err != nil {
+			return fmt.Errorf("failed to put validation errors into field %q: %w", p.ErrorsField, err)
+		}
+	}
+	return nil
+}
+
+func (p *decodeStructured) decode(data interface{}, errs *[]string) (interface{}, error) {
+	var raw []byte
+	switch v := data.(type) {
+	case string:
+		raw = []byte(v)
+	case []byte:
+		raw = v
+	default:
+		return nil, errFieldIsNotString
+	}
+
+	if p.protobuf != nil {
+		if p.Protobuf.Encoding == encodingBase64 {
+			decoded, err := base64.StdEncoding.DecodeString(string(raw))
+			if err != nil {
+				return nil, fmt.Errorf("error decoding base64 protobuf message: %w", err)
+			}
+			raw = decoded
+		}
+		m, err := p.protobuf.Decode(raw)
+		switch {
+		case errors.Is(err, protobuf.ErrMissingRequired):
+			addError(errs, "", "%v", err)
+		case err != nil:
+			return nil, fmt.Errorf("error decoding protobuf message: %w", err)
+		}
+		return m, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("error decoding JSON field: %w", err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("error decoding JSON field: unexpected data after the JSON value")
+	}
+	return v, nil
+}
+
+func (p *decodeStructured) String() string {
+	return fmt.Sprintf("%s=[field=%s, format=%s]", procName, p.Field, p.Format)
+}
@@ -0,0 +1,402 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package decode_structured
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+const testSchema = `{
+	"type": "object",
+	"required": ["user", "status"],
+	"additionalProperties": false,
+	"properties": {
+		"@timestamp": {"type": "string", "format": "date-time"},
+		"status": {"type": "string", "enum": ["ok", "failed"]},
+		"duration": {"type": "number", "minimum": 0},
+		"user": {"$ref": "#/definitions/user"},
+		"tags": {"type": "array", "items": {"type": "string", "pattern": "^[a-z]+$"}},
+		"source": {
+			"type": "object",
+			"properties": {
+				"ip": {"type": "string", "format": "ipv4"},
+				"port": {"type": ["integer", "null"], "maximum": 65535}
+			}
+		}
+	},
+	"definitions": {
+		"user": {
+			"type": "object",
+			"properties": {
+				"id": {"type": "integer"},
+				"admin": {"type": "boolean"}
+			}
+		}
+	}
+}`
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func TestDecodeStructuredJSON(t *testing.T) {
+	schemaFile := writeFile(t, "schema.json", []byte(testSchema))
+	target := "event"
+	root := ""
+
+	testCases := []struct {
+		description string
+		target      *string
+		message     string
+		output      mapstr.M
+		timestamp   time.Time
+		errors      []string
+	}{
+		{
+			description: "values are converted to the schema types",
+			target:      &target,
+			message:     `{"status":"ok","duration":"1.5","user":{"id":"42","admin":"true"},"tags":["a","b"],"source":{"ip":"10.0.0.1","port":8080.0}}`,
+			output: mapstr.M{
+				"status":   "ok",
+				"duration": 1.5,
+				"user":     mapstr.M{"id": int64(42), "admin": true},
+				"tags":     []interface{}{"a", "b"},
+				"source":   mapstr.M{"ip": "10.0.0.1", "port": int64(8080)},
+			},
+		},
+		{
+			description: "validation errors are captured",
+			target:      &target,
+			message:     `{"status":"unknown","duration":-1,"user":{"id":"abc"},"tags":["A"],"source":{"ip":"::1","port":null},"extra":true}`,
+			output: mapstr.M{
+				"status":   "unknown",
+				"duration": float64(-1),
+				"extra":    true,
+				"user":     mapstr.M{"id": "abc"},
+				"tags":     []interface{}{"A"},
+				"source":   mapstr.M{"ip": "::1", "port": nil},
+			},
+			errors: []string{
+				`/: property "extra" is not allowed`,
+				`/duration: value -1 is less than the minimum 0`,
+				`/source/ip: value "::1" is not a valid ipv4 address`,
+				`/status: value unknown is not one of the allowed values`,
+				`/tags/0: value "A" does not match the pattern "^[a-z]+$"`,
+				`/user/id: expected integer, got string "abc"`,
+			},
+		},
+		{
+			description: "missing required properties",
+			target:      &target,
+			message:     `{"status":"ok"}`,
+			output:      mapstr.M{"status": "ok"},
+			errors:      []string{`/: required property "user" is missing`},
+		},
+		{
+			description: "decode into the root of the event",
+			target:      &root,
+			message:     `{"@timestamp":"2023-06-01T10:00:00.5Z","status":"failed","user":{"id":1}}`,
+			output: mapstr.M{
+				"status": "failed",
+				"user":   mapstr.M{"id": int64(1)},
+			},
+			timestamp: time.Date(2023, 6, 1, 10, 0, 0, 500000000, time.UTC),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			c := defaultConfig()
+			c.SchemaFile = schemaFile
+			c.Target = tc.target
+			p, err := newDecodeStructured(c)
+			require.NoError(t, err)
+
+			event := &beat.Event{Fields: mapstr.M{"message": tc.message}}
+			out, err := p.Run(event)
+			require.NoError(t, err)
+
+			if *tc.target != "" {
+				got, err := out.GetValue(*tc.target)
+				require.NoError(t, err)
+				assert.Equal(t, tc.output, got)
+			} else {
+				for k, v := range tc.output {
+					assert.Equal(t, v, out.Fields[k], k)
+				}
+				assert.Equal(t, tc.timestamp, out.Timestamp)
+			}
+
+			errs, _ := out.GetValue("error.message")
+			if tc.errors == nil {
+				assert.Nil(t, errs)
+			} else {
+				assert.ElementsMatch(t, tc.errors, errs)
+			}
+		})
+	}
+}
+
+func TestDecodeStructuredFailures(t *testing.T) {
+	newProcessor := func(t *testing.T, settings map[string]interface{}) beat.Processor {
+		c := conf.MustNewConfigFrom(map[string]interface{}{
+			"schema": map[string]interface{}{"type": "object"},
+		})
+		require.NoError(t, c.Merge(settings))
+		p, err := New(c)
+		require.NoError(t, err)
+		return p
+	}
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		p := newProcessor(t, nil)
+		event, err := p.Run(&beat.Event{Fields: mapstr.M{"message": `{"a":`}})
+		assert.Error(t, err)
+		msg, _ := event.GetValue("error.message")
+		assert.Contains(t, msg, "error decoding JSON field")
+	})
+
+	t.Run("trailing data", func(t *testing.T) {
+		p := newProcessor(t, nil)
+		_, err := p.Run(&beat.Event{Fields: mapstr.M{"message": `{"a":1} {"b":2}`}})
+		assert.Error(t, err)
+	})
+
+	t.Run("missing field", func(t *testing.T) {
+		p := newProcessor(t, map[string]interface{}{"ignore_missing": true})
+		_, err := p.Run(&beat.Event{Fields: mapstr.M{}})
+		assert.NoError(t, err)
+
+		p = newProcessor(t, nil)
+		_, err = p.Run(&beat.Event{Fields: mapstr.M{}})
+		assert.Error(t, err)
+	})
+
+	t.Run("ignore failure", func(t *testing.T) {
+		p := newProcessor(t, map[string]interface{}{"ignore_failure": true})
+		event, err := p.Run(&beat.Event{Fields: mapstr.M{"message": "not json"}})
+		assert.NoError(t, err)
+		assert.Equal(t, mapstr.M{"message": "not json"}, event.Fields)
+	})
+
+	t.Run("errors field", func(t *testing.T) {
+		p := newProcessor(t, map[string]interface{}{
+			"errors_field": "decode.errors",
+			"target_field": "decoded",
+			"schema":       map[string]interface{}{"type": "integer"},
+		})
+		event, err := p.Run(&beat.Event{Fields: mapstr.M{"message": `"x"`}})
+		require.NoError(t, err)
+		errs, err := event.GetValue("decode.errors")
+		require.NoError(t, err)
+		assert.Equal(t, []string{`/: expected integer, got string "x"`}, errs)
+	})
+}
+
+func TestDecodeStructuredConfig(t *testing.T) {
+	for name, settings := range map[string]map[string]interface{}{
+		"no schema":          {},
+		"unknown format":     {"format": "xml", "schema": map[string]interface{}{}},
+		"unknown type":       {"schema": map[string]interface{}{"type": "date"}},
+		"unresolved ref":     {"schema": map[string]interface{}{"$ref": "#/definitions/missing"}},
+		"remote ref":         {"schema": map[string]interface{}{"$ref": "http://example.com/schema.json"}},
+		"protobuf no type":   {"format": "protobuf", "protobuf.descriptor_file": "message.desc"},
+		"protobuf encoding":  {"format": "protobuf", "protobuf.descriptor_file": "message.desc", "protobuf.message_type": "a.B", "protobuf.encoding": "hex"},
+		"schema and file":    {"schema": map[string]interface{}{}, "schema_file": "schema.json"},
+		"invalid pattern":    {"schema": map[string]interface{}{"pattern": "["}},
+		"negative maxLength": {"schema": map[string]interface{}{"maxLength": -1}},
+		"unknown keyword":    {"schema": map[string]interface{}{"oneOf": []interface{}{map[string]interface{}{"type": "string"}}}},
+		"nested keyword":     {"schema": map[string]interface{}{"properties": map[string]interface{}{"a": map[string]interface{}{"patternProperties": map[string]interface{}{}}}}},
+		"nested defs":        {"schema": map[string]interface{}{"properties": map[string]interface{}{"a": map[string]interface{}{"definitions": map[string]interface{}{}}}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := New(conf.MustNewConfigFrom(settings))
+			assert.Error(t, err)
+		})
+	}
+}
+
+// testDescriptorSet describes:
+//
+//	syntax = "proto2";
+//	package test;
+//	message Login {
+//	  enum Result { SUCCESS = 0; FAILURE = 1; }
+//	  required string user = 1;
+//	  optional int64 attempts = 2;
+//	  optional Result result = 3;
+//	  repeated string roles = 4;
+//	  optional google.protobuf.Timestamp time = 5;
+//	  map<string, uint32> counters = 6;
+//	}
+func testDescriptorSet(t *testing.T) []byte {
+	t.Helper()
+	label := func(l descriptorpb.FieldDescriptorProto_Label) *descriptorpb.FieldDescriptorProto_Label { return &l }
+	typ := func(t descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto_Type { return &t }
+	optional := label(descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL)
+
+	timestamp := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("google/protobuf/timestamp.proto"),
+		Package: proto.String("google.protobuf"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Timestamp"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{Name: proto.String("seconds"), Number: proto.Int32(1), Label: optional, Type: typ(descriptorpb.FieldDescriptorProto_TYPE_INT64), JsonName: proto.String("seconds")},
+				{Name: proto.String("nanos"), Number: proto.Int32(2), Label: optional, Type: typ(descriptorpb.FieldDescriptorProto_TYPE_INT32), JsonName: proto.String("nanos")},
+			},
+		}},
+	}
+	login := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("login.proto"),
+		Package:    proto.String("test"),
+		Syntax:     proto.String("proto2"),
+		Dependency: []string{"google/protobuf/timestamp.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Login"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{Name: proto.String("user"), Number: proto.Int32(1), Label: label(descriptorpb.FieldDescriptorProto_LABEL_REQUIRED), Type: typ(descriptorpb.FieldDescriptorProto_TYPE_STRING)},
+				{Name: proto.String("attempts"), Number: proto.Int32(2), Label: optional, Type: typ(descriptorpb.FieldDescriptorProto_TYPE_INT64)},
+				{Name: proto.String("result"), Number: proto.Int32(3), Label: optional, Type: typ(descriptorpb.FieldDescriptorProto_TYPE_ENUM), TypeName: proto.String(".test.Login.Result")},
+				{Name: proto.String("roles"), Number: proto.Int32(4), Label: label(descriptorpb.FieldDescriptorProto_LABEL_REPEATED), Type: typ(descriptorpb.FieldDescriptorProto_TYPE_STRING)},
+				{Name: proto.String("time"), Number: proto.Int32(5), Label: optional, Type: typ(descriptorpb.FieldDescriptorProto_TYPE_MESSAGE), TypeName: proto.String(".google.protobuf.Timestamp")},
+				{Name: proto.String("counters"), Number: proto.Int32(6), Label: label(descriptorpb.FieldDescriptorProto_LABEL_REPEATED), Type: typ(descriptorpb.FieldDescriptorProto_TYPE_MESSAGE), TypeName: proto.String(".test.Login.CountersEntry")},
+			},
+			NestedType: []*descriptorpb.DescriptorProto{{
+				Name: proto.String("CountersEntry"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{Name: proto.String("key"), Number: proto.Int32(1), Label: optional, Type: typ(descriptorpb.FieldDescriptorProto_TYPE_STRING)},
+					{Name: proto.String("value"), Number: proto.Int32(2), Label: optional, Type: typ(descriptorpb.FieldDescriptorProto_TYPE_UINT32)},
+				},
+				Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+			}},
+			EnumType: []*descriptorpb.EnumDescriptorProto{{
+				Name: proto.String("Result"),
+				Value: []*descriptorpb.EnumValueDescriptorProto{
+					{Name: proto.String("SUCCESS"), Number: proto.Int32(0)},
+					{Name: proto.String("FAILURE"), Number: proto.Int32(1)},
+				},
+			}},
+		}},
+	}
+	data, err := proto.Marshal(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{timestamp, login}})
+	require.NoError(t, err)
+	return data
+}
+
+func TestDecodeStructuredProtobuf(t *testing.T) {
+	descFile := writeFile(t, "login.desc", testDescriptorSet(t))
+
+	var msg []byte
+	msg = protowire.AppendTag(msg, 1, protowire.BytesType)
+	msg = protowire.AppendString(msg, "alice")
+	msg = protowire.AppendTag(msg, 2, protowire.VarintType)
+	msg = protowire.AppendVarint(msg, 3)
+	msg = protowire.AppendTag(msg, 3, protowire.VarintType)
+	msg = protowire.AppendVarint(msg, 1)
+	for _, role := range []string{"admin", "dev"} {
+		msg = protowire.AppendTag(msg, 4, protowire.BytesType)
+		msg = protowire.AppendString(msg, role)
+	}
+	var ts []byte
+	ts = protowire.AppendTag(ts, 1, protowire.VarintType)
+	ts = protowire.AppendVarint(ts, 1685613600)
+	msg = protowire.AppendTag(msg, 5, protowire.BytesType)
+	msg = protowire.AppendBytes(msg, ts)
+	var entry []byte
+	entry = protowire.AppendTag(entry, 1, protowire.BytesType)
+	entry = protowire.AppendString(entry, "failed")
+	entry = protowire.AppendTag(entry, 2, protowire.VarintType)
+	entry = protowire.AppendVarint(entry, 2)
+	msg = protowire.AppendTag(msg, 6, protowire.BytesType)
+	msg = protowire.AppendBytes(msg, entry)
+
+	target := "login"
+	c := defaultConfig()
+	c.Format = formatProtobuf
+	c.Target = &target
+	c.Protobuf.DescriptorFile = descFile
+	c.Protobuf.MessageType = "test.Login"
+	c.Schema = map[string]interface{}{
+		"properties": map[string]interface{}{
+			"attempts": map[string]interface{}{"type": "integer", "maximum": 2},
+		},
+	}
+	p, err := newDecodeStructured(c)
+	require.NoError(t, err)
+
+	event, err := p.Run(&beat.Event{Fields: mapstr.M{"message": string(msg)}})
+	require.NoError(t, err)
+	got, err := event.GetValue(target)
+	require.NoError(t, err)
+	assert.Equal(t, mapstr.M{
+		"user":     "alice",
+		"attempts": int64(3),
+		"result":   "FAILURE",
+		"roles":    []interface{}{"admin", "dev"},
+		"time":     time.Unix(1685613600, 0).UTC(),
+		"counters": mapstr.M{"failed": uint64(2)},
+	}, got)
+	errs, _ := event.GetValue("error.message")
+	assert.Equal(t, []string{"/attempts: value 3 is greater than the maximum 2"}, errs)
+
+	t.Run("missing required field", func(t *testing.T) {
+		var msg []byte
+		msg = protowire.AppendTag(msg, 2, protowire.VarintType)
+		msg = protowire.AppendVarint(msg, 1)
+
+		event, err := p.Run(&beat.Event{Fields: mapstr.M{"message": msg}})
+		require.NoError(t, err)
+		got, err := event.GetValue(target)
+		require.NoError(t, err)
+		assert.Equal(t, mapstr.M{"attempts": int64(1)}, got)
+		errs, _ := event.GetValue("error.message")
+		if assert.Len(t, errs, 1) {
+			assert.Contains(t, errs.([]string)[0], "test.Login.user")
+		}
+	})
+
+	t.Run("invalid message", func(t *testing.T) {
+		_, err := p.Run(&beat.Event{Fields: mapstr.M{"message": "\xff\xff"}})
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown message type", func(t *testing.T) {
+		c := c
+		c.Protobuf.MessageType = "test.Logout"
+		_, err := newDecodeStructured(c)
+		assert.Error(t, err)
+	})
+}
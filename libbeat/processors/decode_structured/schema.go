@@ -0,0 +1,553 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package decode_structured
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// schema is the compiled form of the subset of JSON Schema supported by
+// the processor: type, properties, required, additionalProperties, items,
+// enum, minimum, maximum, minLength, maxLength, pattern, format and local
+// $ref pointers into definitions or $defs. Other keywords, except
+// annotations like title or description, are rejected.
+type schema struct {
+	types      []string
+	properties map[string]*schema
+	required   []string
+	// additional is the schema of properties not listed in properties.
+	// If nil they accept any value, unless closed is set.
+	additional *schema
+	closed     bool
+	items      *schema
+	enum       []interface{}
+	minimum    *float64
+	maximum    *float64
+	minLength  *int
+	maxLength  *int
+	pattern    *regexp.Regexp
+	format     string
+
+	ref  string
+	root *schema
+	defs map[string]*schema
+}
+
+// anySchema accepts any value.
+var anySchema = &schema{}
+
+func loadSchemaFile(path string) (*schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON schema %s: %w", path, err)
+	}
+	return compileSchema(raw)
+}
+
+func compileSchema(raw map[string]interface{}) (*schema, error) {
+	root := &schema{defs: map[string]*schema{}}
+	root.root = root
+	for _, key := range []string{"definitions", "$defs"} {
+		defs, ok := raw[key].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for name, def := range defs {
+			m, ok := def.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s/%s: definition must be an object", key, name)
+			}
+			s, err := root.compile("#/"+key+"/"+name, m)
+			if err != nil {
+				return nil, err
+			}
+			root.defs["#/"+key+"/"+name] = s
+		}
+	}
+	if err := root.compileInto(root, "#", raw); err != nil {
+		return nil, err
+	}
+	return root, root.checkRefs(root, map[*schema]bool{})
+}
+
+func (r *schema) compile(path string, raw map[string]interface{}) (*schema, error) {
+	s := &schema{root: r}
+	return s, r.compileInto(s, path, raw)
+}
+
+func (r *schema) compileInto(s *schema, path string, raw map[string]interface{}) error {
+	for key, value := range raw {
+		var err error
+		switch key {
+		case "$ref":
+			ref, ok := value.(string)
+			if !ok || !strings.HasPrefix(ref, "#/") {
+				return fmt.Errorf("%s: only local $ref pointers are supported", path)
+			}
+			s.ref = ref
+		case "type":
+			s.types, err = stringList(value)
+			for _, t := range s.types {
+				switch t {
+				case "string", "integer", "number", "boolean", "object", "array", "null":
+				default:
+					err = fmt.Errorf("unknown type %q", t)
+				}
+			}
+		case "properties":
+			props, ok := value.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s/properties: must be an object", path)
+			}
+			s.properties = make(map[string]*schema, len(props))
+			for name, prop := range props {
+				m, ok := prop.(map[string]interface{})
+				if !ok {
+					return fmt.Errorf("%s/properties/%s: must be an object", path, name)
+				}
+				if s.properties[name], err = r.compile(path+"/properties/"+name, m); err != nil {
+					return err
+				}
+			}
+		case "required":
+			s.required, err = stringList(value)
+		case "additionalProperties":
+			switch v := value.(type) {
+			case bool:
+				s.closed = !v
+			case map[string]interface{}:
+				s.additional, err = r.compile(path+"/additionalProperties", v)
+			default:
+				err = fmt.Errorf("must be a boolean or an object")
+			}
+		case "items":
+			m, ok := value.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s/items: must be an object", path)
+			}
+			s.items, err = r.compile(path+"/items", m)
+		case "enum":
+			list, ok := value.([]interface{})
+			if !ok {
+				return fmt.Errorf("%s/enum: must be an array", path)
+			}
+			s.enum = list
+		case "minimum":
+			s.minimum, err = floatPtr(value)
+		case "maximum":
+			s.maximum, err = floatPtr(value)
+		case "minLength":
+			s.minLength, err = intPtr(value)
+		case "maxLength":
+			s.maxLength, err = intPtr(value)
+		case "pattern":
+			str, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("%s/pattern: must be a string", path)
+			}
+			s.pattern, err = regexp.Compile(str)
+		case "format":
+			s.format, _ = value.(string)
+		case "definitions", "$defs":
+			// Definitions are compiled by compileSchema, nested definitions
+			// could not be referenced.
+			if s != r {
+				err = fmt.Errorf("definitions are only supported at the root of the schema")
+			}
+		case "$schema", "$id", "$comment", "title", "description", "default", "examples", "deprecated", "readOnly", "writeOnly":
+			// Annotations do not change the validation.
+		default:
+			// Failing on keywords like oneOf or patternProperties avoids
+			// accepting events the schema was written to reject.
+			err = fmt.Errorf("unsupported keyword")
+		}
+		if err != nil {
+			return fmt.Errorf("%s/%s: %w", path, key, err)
+		}
+	}
+	return nil
+}
+
+// checkRefs verifies that all $ref pointers can be resolved and do not
+// point to themselves.
+func (r *schema) checkRefs(s *schema, seen map[*schema]bool) error {
+	if s == nil || seen[s] {
+		return nil
+	}
+	seen[s] = true
+	if s.ref != "" {
+		target, ok := r.defs[s.ref]
+		if !ok {
+			return fmt.Errorf("unresolved $ref %q", s.ref)
+		}
+		for hops := 0; target.ref != ""; hops++ {
+			if target, ok = r.defs[target.ref]; !ok || hops > len(r.defs) {
+				return fmt.Errorf("unresolved $ref %q", s.ref)
+			}
+		}
+	}
+	for _, p := range s.properties {
+		if err := r.checkRefs(p, seen); err != nil {
+			return err
+		}
+	}
+	for _, d := range r.defs {
+		if err := r.checkRefs(d, seen); err != nil {
+			return err
+		}
+	}
+	if err := r.checkRefs(s.items, seen); err != nil {
+		return err
+	}
+	return r.checkRefs(s.additional, seen)
+}
+
+func (s *schema) resolve() *schema {
+	for s.ref != "" {
+		s = s.root.defs[s.ref]
+	}
+	return s
+}
+
+// apply converts v to the types described by the schema and validates it.
+// Values that cannot be converted are kept as they are. Violations are
+// appended to errs, prefixed with the JSON pointer of the value.
+func (s *schema) apply(path string, v interface{}, errs *[]string) interface{} {
+	s = s.resolve()
+	v = normalize(v)
+
+	if len(s.types) == 0 {
+		v = s.convert(path, "", v, errs)
+	} else {
+		var converted bool
+		for _, t := range s.types {
+			if out, ok := convertType(t, v); ok {
+				v = s.convert(path, t, out, errs)
+				converted = true
+				break
+			}
+		}
+		if !converted {
+			addError(errs, path, "expected %s, got %s", strings.Join(s.types, " or "), describe(v))
+			return v
+		}
+	}
+
+	if len(s.enum) > 0 && !inEnum(s.enum, v) {
+		addError(errs, path, "value %v is not one of the allowed values", v)
+	}
+	return v
+}
+
+// convert validates the constraints of the schema on a value that was
+// already converted to the type t. If t is empty the type is inferred from
+// the value.
+func (s *schema) convert(path, t string, v interface{}, errs *[]string) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		return s.applyObject(path, val, errs)
+	case []interface{}:
+		items := s.items
+		if items == nil {
+			items = anySchema
+		}
+		for i, elem := range val {
+			val[i] = items.apply(path+"/"+strconv.Itoa(i), elem, errs)
+		}
+		return val
+	case string:
+		return s.applyString(path, val, errs)
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			v = i
+		} else if f, err := val.Float64(); err == nil {
+			v = f
+		}
+	}
+	if f, ok := toFloat(v); ok && t != "boolean" {
+		if s.minimum != nil && f < *s.minimum {
+			addError(errs, path, "value %v is less than the minimum %v", v, *s.minimum)
+		}
+		if s.maximum != nil && f > *s.maximum {
+			addError(errs, path, "value %v is greater than the maximum %v", v, *s.maximum)
+		}
+	}
+	return v
+}
+
+func (s *schema) applyObject(path string, m map[string]interface{}, errs *[]string) interface{} {
+	for _, name := range s.required {
+		if _, ok := m[name]; !ok {
+			addError(errs, path, "required property %q is missing", name)
+		}
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		prop, ok := s.properties[k]
+		if !ok {
+			if s.closed {
+				addError(errs, path, "property %q is not allowed", k)
+				continue
+			}
+			if prop = s.additional; prop == nil {
+				prop = anySchema
+			}
+		}
+		m[k] = prop.apply(path+"/"+escapePointer(k), m[k], errs)
+	}
+	return mapstr.M(m)
+}
+
+func (s *schema) applyString(path, str string, errs *[]string) interface{} {
+	if s.minLength != nil || s.maxLength != nil {
+		n := len([]rune(str))
+		if s.minLength != nil && n < *s.minLength {
+			addError(errs, path, "length %d is shorter than the minimum length %d", n, *s.minLength)
+		}
+		if s.maxLength != nil && n > *s.maxLength {
+			addError(errs, path, "length %d is longer than the maximum length %d", n, *s.maxLength)
+		}
+	}
+	if s.pattern != nil && !s.pattern.MatchString(str) {
+		addError(errs, path, "value %q does not match the pattern %q", str, s.pattern.String())
+	}
+
+	switch s.format {
+	case "date-time":
+		ts, err := time.Parse(time.RFC3339Nano, str)
+		if err != nil {
+			addError(errs, path, "value %q is not a valid date-time", str)
+			return str
+		}
+		return ts
+	case "ipv4":
+		if ip := net.ParseIP(str); ip == nil || ip.To4() == nil {
+			addError(errs, path, "value %q is not a valid ipv4 address", str)
+		}
+	case "ipv6":
+		if ip := net.ParseIP(str); ip == nil || ip.To4() != nil {
+			addError(errs, path, "value %q is not a valid ipv6 address", str)
+		}
+	}
+	return str
+}
+
+// convertType converts v into the JSON Schema type t, coercing strings,
+// numbers and booleans into each other where no information is lost.
+func convertType(t string, v interface{}) (interface{}, bool) {
+	switch t {
+	case "null":
+		return nil, v == nil
+	case "string":
+		switch val := v.(type) {
+		case string:
+			return val, true
+		case json.Number:
+			return val.String(), true
+		case bool:
+			return strconv.FormatBool(val), true
+		case time.Time:
+			return val.Format(time.RFC3339Nano), true
+		}
+		if f, ok := toFloat(v); ok {
+			return strconv.FormatFloat(f, 'f', -1, 64), true
+		}
+	case "integer":
+		switch val := v.(type) {
+		case string:
+			i, err := strconv.ParseInt(strings.TrimSpace(val), 10, 64)
+			return i, err == nil
+		case json.Number:
+			if i, err := val.Int64(); err == nil {
+				return i, true
+			}
+		case int64, uint64:
+			return val, true
+		}
+		if f, ok := toFloat(v); ok && f == math.Trunc(f) && math.Abs(f) < 1<<63 {
+			return int64(f), true
+		}
+	case "number":
+		switch val := v.(type) {
+		case string:
+			f, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+			return f, err == nil
+		case int64, uint64, float64:
+			return val, true
+		}
+		return toFloat(v)
+	case "boolean":
+		switch val := v.(type) {
+		case bool:
+			return val, true
+		case string:
+			b, err := strconv.ParseBool(strings.TrimSpace(val))
+			return b, err == nil
+		}
+	case "object":
+		m, ok := v.(map[string]interface{})
+		return m, ok
+	case "array":
+		l, ok := v.([]interface{})
+		return l, ok
+	}
+	return v, false
+}
+
+// normalize converts the values produced by the protobuf decoder and the
+// configuration parser to the types produced by the JSON decoder.
+func normalize(v interface{}) interface{} {
+	switch val := v.(type) {
+	case mapstr.M:
+		return map[string]interface{}(val)
+	case []byte:
+		return base64.StdEncoding.EncodeToString(val)
+	case int, int8, int16, int32, int64:
+		return reflect.ValueOf(v).Int()
+	case uint, uint8, uint16, uint32, uint64:
+		return reflect.ValueOf(v).Uint()
+	case float32:
+		return float64(val)
+	}
+	return v
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch val := v.(type) {
+	case json.Number:
+		f, err := val.Float64()
+		return f, err == nil
+	case float64:
+		return val, true
+	case float32:
+		return float64(val), true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	}
+	return 0, false
+}
+
+func inEnum(enum []interface{}, v interface{}) bool {
+	for _, e := range enum {
+		if reflect.DeepEqual(e, v) {
+			return true
+		}
+		a, aok := toFloat(normalize(e))
+		b, bok := toFloat(v)
+		if aok && bok && a == b {
+			return true
+		}
+		if es, ok := e.(string); ok {
+			if ts, ok := v.(time.Time); ok && es == ts.Format(time.RFC3339Nano) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func describe(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return fmt.Sprintf("string %q", val)
+	case bool:
+		return fmt.Sprintf("boolean %v", val)
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	if _, ok := toFloat(v); ok {
+		return fmt.Sprintf("number %v", v)
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func addError(errs *[]string, path, format string, args ...interface{}) {
+	if path == "" {
+		path = "/"
+	}
+	*errs = append(*errs, path+": "+fmt.Sprintf(format, args...))
+}
+
+func escapePointer(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
+
+func stringList(v interface{}) ([]string, error) {
+	switch val := v.(type) {
+	case string:
+		return []string{val}, nil
+	case []interface{}:
+		list := make([]string, 0, len(val))
+		for _, elem := range val {
+			s, ok := elem.(string)
+			if !ok {
+				return nil, fmt.Errorf("must be a list of strings")
+			}
+			list = append(list, s)
+		}
+		return list, nil
+	}
+	return nil, fmt.Errorf("must be a string or a list of strings")
+}
+
+func floatPtr(v interface{}) (*float64, error) {
+	f, ok := toFloat(normalize(v))
+	if !ok {
+		return nil, fmt.Errorf("must be a number")
+	}
+	return &f, nil
+}
+
+func intPtr(v interface{}) (*int, error) {
+	f, ok := toFloat(normalize(v))
+	if !ok || f < 0 || f != math.Trunc(f) {
+		return nil, fmt.Errorf("must be a non-negative integer")
+	}
+	i := int(f)
+	return &i, nil
+}
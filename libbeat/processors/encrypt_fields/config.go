@@ -0,0 +1,103 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package encrypt_fields
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+)
+
+// config for the encrypt_fields processor.
+type config struct {
+	Fields []string `config:"fields" validate:"required"`
+	// PublicKey is the PEM encoded RSA public key or certificate of the
+	// recipient, or the path of a file containing it.
+	PublicKey string `config:"public_key" validate:"required"`
+	// KeyID identifies the key pair. It defaults to the SHA-256 fingerprint
+	// of the public key.
+	KeyID         string `config:"key_id"`
+	TargetField   string `config:"target_field"`
+	IgnoreMissing bool   `config:"ignore_missing"`
+	FailOnError   bool   `config:"fail_on_error"`
+}
+
+func defaultConfig() config {
+	return config{
+		TargetField:   "encryption",
+		IgnoreMissing: true,
+		FailOnError:   true,
+	}
+}
+
+// loadPublicKey returns the RSA public key of the configuration and its
+// SHA-256 fingerprint.
+func loadPublicKey(s string) (*rsa.PublicKey, string, error) {
+	data := []byte(s)
+	if !tlscommon.IsPEMString(s) {
+		var err error
+		data, err = os.ReadFile(s)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read public key: %w", err)
+		}
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, "", errors.New("no PEM block found in public key")
+	}
+
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+		cert, err = x509.ParseCertificate(block.Bytes)
+		if err == nil {
+			key = cert.PublicKey
+		}
+	default:
+		return nil, "", fmt.Errorf("unsupported PEM block type %q in public key", block.Type)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse public key: %w", err)
+	}
+
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, "", fmt.Errorf("unsupported public key type %T, only RSA keys are supported", key)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(rsaKey)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode public key: %w", err)
+	}
+	sum := sha256.Sum256(der)
+	return rsaKey, hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,193 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package encrypt_fields
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/processors"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+const (
+	processorName = "encrypt_fields"
+
+	// algorithm describes the hybrid encryption scheme: a random AES-256
+	// key is generated for each event to encrypt the fields with AES-GCM,
+	// and is itself encrypted with RSA-OAEP (SHA-256) for the recipient.
+	algorithm = "RSA-OAEP-256+A256GCM"
+	keySize   = 32
+)
+
+func init() {
+	processors.RegisterPlugin(processorName, New)
+}
+
+type encryptFields struct {
+	config    config
+	publicKey *rsa.PublicKey
+	keyID     string
+	logger    *logp.Logger
+}
+
+// New constructs a new encrypt_fields processor.
+func New(cfg *conf.C) (beat.Processor, error) {
+	config := defaultConfig()
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, fmt.Errorf("failed to unpack %v processor configuration: %w", processorName, err)
+	}
+
+	publicKey, fingerprint, err := loadPublicKey(config.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("%v processor: %w", processorName, err)
+	}
+	keyID := config.KeyID
+	if keyID == "" {
+		keyID = fingerprint
+	}
+
+	return &encryptFields{
+		config:    config,
+		publicKey: publicKey,
+		keyID:     keyID,
+		logger:    logp.NewLogger(processorName),
+	}, nil
+}
+
+// Run replaces the value of each configured field with its ciphertext and
+// adds the encrypted data key and the key ID to the target field. Fields
+// that cannot be encrypted are removed from the event, their plaintext is
+// never published.
+func (p *encryptFields) Run(event *beat.Event) (*beat.Event, error) {
+	if err := p.encrypt(event); err != nil {
+		p.logger.Debugf("Failed to encrypt fields: %s", err)
+		if p.config.FailOnError {
+			return nil, err
+		}
+	}
+	return event, nil
+}
+
+// encrypt encrypts the configured fields of the event. Fields that fail to be
+// encrypted are removed and the first error is returned.
+func (p *encryptFields) encrypt(event *beat.Event) error {
+	var aead cipher.AEAD
+	var encrypted []string
+	var firstErr error
+	for _, field := range p.config.Fields {
+		err := p.encryptField(event, field, &aead)
+		if err == nil {
+			encrypted = append(encrypted, field)
+			continue
+		}
+		if p.config.IgnoreMissing && errors.Is(err, mapstr.ErrKeyNotFound) {
+			continue
+		}
+		_ = event.Delete(field)
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	if len(encrypted) != 0 {
+		if _, err := event.PutValue(p.config.TargetField+".fields", encrypted); err != nil {
+			return fmt.Errorf("failed to set '%s.fields': %w", p.config.TargetField, err)
+		}
+	}
+	return firstErr
+}
+
+// encryptField replaces the value of field with its ciphertext. The data key
+// is generated and stored in the target field on first use.
+func (p *encryptFields) encryptField(event *beat.Event, field string, aead *cipher.AEAD) error {
+	value, err := event.GetValue(field)
+	if err != nil {
+		return fmt.Errorf("could not fetch value for key '%s': %w", field, err)
+	}
+
+	plaintext, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode value of field '%s': %w", field, err)
+	}
+
+	if *aead == nil {
+		// The data key is only generated when there is something to
+		// encrypt.
+		key := make([]byte, keySize)
+		if _, err := rand.Read(key); err != nil {
+			return fmt.Errorf("failed to generate data key: %w", err)
+		}
+		a, err := newAEAD(key)
+		if err != nil {
+			return err
+		}
+		wrapped, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, p.publicKey, key, nil)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt data key: %w", err)
+		}
+		if _, err := event.PutValue(p.config.TargetField, mapstr.M{
+			"algorithm": algorithm,
+			"key_id":    p.keyID,
+			"key":       base64.StdEncoding.EncodeToString(wrapped),
+		}); err != nil {
+			return fmt.Errorf("failed to set '%s': %w", p.config.TargetField, err)
+		}
+		*aead = a
+	}
+
+	nonce := make([]byte, (*aead).NonceSize(), (*aead).NonceSize()+len(plaintext)+(*aead).Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	// The field name is authenticated so that ciphertexts cannot be
+	// moved between fields.
+	ciphertext := (*aead).Seal(nonce, nonce, plaintext, []byte(field))
+	if _, err := event.PutValue(field, base64.StdEncoding.EncodeToString(ciphertext)); err != nil {
+		return fmt.Errorf("failed to set encrypted value of field '%s': %w", field, err)
+	}
+	return nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return aead, nil
+}
+
+func (p *encryptFields) String() string {
+	return fmt.Sprintf("%v=[fields=%v, key_id=%v, target_field=%v]",
+		processorName, strings.Join(p.config.Fields, ","), p.keyID, p.config.TargetField)
+}
@@ -0,0 +1,220 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package encrypt_fields
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func newTestKey(t *testing.T) (*rsa.PrivateKey, string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	return key, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+// decrypt is what a consumer holding the private key does.
+func decrypt(t *testing.T, key *rsa.PrivateKey, event mapstr.M, field string) interface{} {
+	t.Helper()
+	wrapped, err := event.GetValue("encryption.key")
+	require.NoError(t, err)
+	b, err := base64.StdEncoding.DecodeString(wrapped.(string))
+	require.NoError(t, err)
+	dataKey, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, key, b, nil)
+	require.NoError(t, err)
+	aead, err := newAEAD(dataKey)
+	require.NoError(t, err)
+
+	v, err := event.GetValue(field)
+	require.NoError(t, err)
+	b, err = base64.StdEncoding.DecodeString(v.(string))
+	require.NoError(t, err)
+	plaintext, err := aead.Open(nil, b[:aead.NonceSize()], b[aead.NonceSize():], []byte(field))
+	require.NoError(t, err)
+
+	var value interface{}
+	require.NoError(t, json.Unmarshal(plaintext, &value))
+	return value
+}
+
+func TestNew(t *testing.T) {
+	_, publicKey := newTestKey(t)
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(keyFile, []byte(publicKey), 0o600))
+	notPEM := filepath.Join(t.TempDir(), "key.txt")
+	require.NoError(t, os.WriteFile(notPEM, []byte("not a key"), 0o600))
+
+	cases := map[string]struct {
+		config mapstr.M
+		err    string
+	}{
+		"inline_key": {
+			config: mapstr.M{"fields": []string{"message"}, "public_key": publicKey},
+		},
+		"key_file": {
+			config: mapstr.M{"fields": []string{"message"}, "public_key": keyFile},
+		},
+		"missing_fields": {
+			config: mapstr.M{"public_key": publicKey},
+			err:    "missing required field accessing 'fields'",
+		},
+		"missing_key_file": {
+			config: mapstr.M{"fields": []string{"message"}, "public_key": keyFile + ".missing"},
+			err:    "failed to read public key",
+		},
+		"not_pem": {
+			config: mapstr.M{"fields": []string{"message"}, "public_key": notPEM},
+			err:    "no PEM block found in public key",
+		},
+	}
+
+	for name, test := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := New(conf.MustNewConfigFrom(test.config))
+			if test.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, test.err)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	key, publicKey := newTestKey(t)
+
+	p, err := New(conf.MustNewConfigFrom(mapstr.M{
+		"fields":     []string{"message", "user.full_name", "labels", "missing"},
+		"public_key": publicKey,
+		"key_id":     "pii-2024",
+	}))
+	require.NoError(t, err)
+
+	event, err := p.Run(&beat.Event{Fields: mapstr.M{
+		"message": "Customer Jane Doe called",
+		"user":    mapstr.M{"full_name": "Jane Doe", "id": "42"},
+		"labels":  mapstr.M{"phone": "555-0100"},
+	}})
+	require.NoError(t, err)
+
+	assert.NotContains(t, event.Fields.StringToPrint(), "Jane")
+	assert.Equal(t, "42", event.Fields["user"].(mapstr.M)["id"])
+
+	encryption := event.Fields["encryption"].(mapstr.M)
+	assert.Equal(t, algorithm, encryption["algorithm"])
+	assert.Equal(t, "pii-2024", encryption["key_id"])
+	assert.Equal(t, []string{"message", "user.full_name", "labels"}, encryption["fields"])
+
+	assert.Equal(t, "Customer Jane Doe called", decrypt(t, key, event.Fields, "message"))
+	assert.Equal(t, "Jane Doe", decrypt(t, key, event.Fields, "user.full_name"))
+	assert.Equal(t, map[string]interface{}{"phone": "555-0100"}, decrypt(t, key, event.Fields, "labels"))
+}
+
+func TestRunDefaultKeyID(t *testing.T) {
+	_, publicKey := newTestKey(t)
+
+	p, err := New(conf.MustNewConfigFrom(mapstr.M{"fields": []string{"message"}, "public_key": publicKey}))
+	require.NoError(t, err)
+
+	event, err := p.Run(&beat.Event{Fields: mapstr.M{"message": "hello"}})
+	require.NoError(t, err)
+	keyID, err := event.GetValue("encryption.key_id")
+	require.NoError(t, err)
+	assert.Len(t, keyID, 64)
+}
+
+func TestRunMissingField(t *testing.T) {
+	_, publicKey := newTestKey(t)
+
+	// Missing fields are skipped by default.
+	p, err := New(conf.MustNewConfigFrom(mapstr.M{"fields": []string{"message", "secret"}, "public_key": publicKey}))
+	require.NoError(t, err)
+
+	event, err := p.Run(&beat.Event{Fields: mapstr.M{"message": "hello"}})
+	require.NoError(t, err)
+	require.NotNil(t, event)
+	assert.NotEqual(t, "hello", event.Fields["message"])
+	assert.NotContains(t, event.Fields, "secret")
+	fields, err := event.GetValue("encryption.fields")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"message"}, fields)
+
+	// Events without any of the fields are published unchanged.
+	event, err = p.Run(&beat.Event{Fields: mapstr.M{"other": "hello"}})
+	require.NoError(t, err)
+	assert.Equal(t, mapstr.M{"other": "hello"}, event.Fields)
+}
+
+func TestRunMissingFieldNotIgnored(t *testing.T) {
+	_, publicKey := newTestKey(t)
+
+	p, err := New(conf.MustNewConfigFrom(mapstr.M{
+		"fields":         []string{"message", "secret"},
+		"public_key":     publicKey,
+		"ignore_missing": false,
+	}))
+	require.NoError(t, err)
+
+	// The event is dropped rather than published with plaintext values.
+	event, err := p.Run(&beat.Event{Fields: mapstr.M{"message": "hello"}})
+	assert.ErrorContains(t, err, "could not fetch value for key 'secret'")
+	assert.Nil(t, event)
+}
+
+func TestRunErrorWithoutFailOnError(t *testing.T) {
+	_, publicKey := newTestKey(t)
+
+	p, err := New(conf.MustNewConfigFrom(mapstr.M{
+		"fields":        []string{"secret", "message"},
+		"public_key":    publicKey,
+		"fail_on_error": false,
+	}))
+	require.NoError(t, err)
+
+	// The secret cannot be encoded, it must be removed instead of being
+	// published as is.
+	event, err := p.Run(&beat.Event{Fields: mapstr.M{
+		"message": "hello",
+		"secret":  make(chan int),
+	}})
+	require.NoError(t, err)
+	require.NotNil(t, event)
+	assert.NotContains(t, event.Fields, "secret")
+	assert.NotEqual(t, "hello", event.Fields["message"])
+	fields, err := event.GetValue("encryption.fields")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"message"}, fields)
+}
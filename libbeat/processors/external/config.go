@@ -0,0 +1,79 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package external
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// policyPassThrough keeps the events unchanged when the sidecar fails.
+	policyPassThrough = "pass_through"
+	// policyDrop drops the events when the sidecar fails.
+	policyDrop = "drop"
+	// policyBlock retries until the sidecar processes the events.
+	policyBlock = "block"
+)
+
+type externalConfig struct {
+	// Address of the sidecar, host:port or unix:///path/to/socket.
+	Address string `config:"address" validate:"required"`
+	// Timeout is the deadline of a batch sent to the sidecar.
+	Timeout time.Duration `config:"timeout" validate:"positive"`
+	// BatchSize is the maximum number of events sent at once.
+	BatchSize int `config:"batch_size" validate:"min=1"`
+	// MaxInFlight is the number of batches sent concurrently, each over its
+	// own stream.
+	MaxInFlight   int    `config:"max_in_flight" validate:"min=1"`
+	FailurePolicy string `config:"failure_policy"`
+	// AddErrorKey adds the error to the events passed through on failures.
+	AddErrorKey bool          `config:"add_error_key"`
+	Backoff     backoffConfig `config:"backoff"`
+}
+
+type backoffConfig struct {
+	Init time.Duration `config:"init" validate:"positive"`
+	Max  time.Duration `config:"max" validate:"positive"`
+}
+
+func defaultConfig() externalConfig {
+	return externalConfig{
+		Timeout:       time.Second,
+		BatchSize:     100,
+		MaxInFlight:   4,
+		FailurePolicy: policyPassThrough,
+		Backoff: backoffConfig{
+			Init: time.Second,
+			Max:  time.Minute,
+		},
+	}
+}
+
+func (c *externalConfig) Validate() error {
+	switch c.FailurePolicy {
+	case policyPassThrough, policyDrop, policyBlock:
+	default:
+		return fmt.Errorf("invalid failure_policy %q, must be one of %s, %s or %s",
+			c.FailurePolicy, policyPassThrough, policyDrop, policyBlock)
+	}
+	if c.Backoff.Max < c.Backoff.Init {
+		return fmt.Errorf("backoff.max must be greater than or equal to backoff.init")
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package external
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+const (
+	timestampKey = "@timestamp"
+	metadataKey  = "@metadata"
+)
+
+// maxSafeInteger is the largest integer a double represents exactly.
+const maxSafeInteger = 1 << 53
+
+// encodeEvent converts the event to the struct sent to the sidecar. The
+// timestamp and the metadata are sent in the @timestamp and @metadata
+// fields.
+func encodeEvent(event *beat.Event) (*structpb.Value, error) {
+	doc := make(map[string]interface{}, len(event.Fields)+2)
+	for k, v := range event.Fields {
+		doc[k] = v
+	}
+	doc[timestampKey] = event.Timestamp.UTC().Format(time.RFC3339Nano)
+	if len(event.Meta) > 0 {
+		doc[metadataKey] = event.Meta
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode event: %w", err)
+	}
+	var value structpb.Value
+	if err := protojson.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("failed to encode event: %w", err)
+	}
+	return &value, nil
+}
+
+// decodeEvent updates the event with the struct returned by the sidecar for
+// the struct sent by encodeEvent. It returns nil if the sidecar returned null
+// to drop the event. The event is not changed if an error is returned.
+//
+// Only the fields changed by the sidecar are decoded, the others keep their
+// original values. This keeps the types that don't survive the conversion,
+// like integers larger than 2^53 or floats without fraction, as all numbers
+// are doubles in a struct.
+func decodeEvent(sent, value *structpb.Value, event *beat.Event) (*beat.Event, error) {
+	switch value.GetKind().(type) {
+	case *structpb.Value_NullValue:
+		return nil, nil
+	case *structpb.Value_StructValue:
+	default:
+		return nil, fmt.Errorf("sidecar returned a %T instead of an event", value.GetKind())
+	}
+	doc := value.GetStructValue()
+	sentDoc := sent.GetStructValue()
+
+	timestamp := event.Timestamp
+	if ts, found := doc.Fields[timestampKey]; found {
+		s, ok := ts.GetKind().(*structpb.Value_StringValue)
+		if !ok {
+			return nil, fmt.Errorf("sidecar returned a %T as %s", ts.GetKind(), timestampKey)
+		}
+		var err error
+		if timestamp, err = time.Parse(time.RFC3339Nano, s.StringValue); err != nil {
+			return nil, fmt.Errorf("sidecar returned an invalid %s: %w", timestampKey, err)
+		}
+	}
+	var meta mapstr.M
+	if m, found := doc.Fields[metadataKey]; found {
+		if _, ok := m.GetKind().(*structpb.Value_StructValue); !ok {
+			return nil, fmt.Errorf("sidecar returned a %T as %s", m.GetKind(), metadataKey)
+		}
+		meta = merge(event.Meta, sentDoc.GetFields()[metadataKey], m).(mapstr.M)
+	}
+
+	fields := mergeObject(event.Fields, sentDoc, doc)
+	delete(fields, timestampKey)
+	delete(fields, metadataKey)
+
+	event.Timestamp = timestamp
+	event.Meta = meta
+	event.Fields = fields
+	return event, nil
+}
+
+// merge returns the value returned by the sidecar, reusing the original
+// value where the sidecar did not change the value it was sent.
+func merge(orig interface{}, sent, value *structpb.Value) interface{} {
+	if sent != nil && proto.Equal(sent, value) {
+		return orig
+	}
+	if obj, sentObj := value.GetStructValue(), sent.GetStructValue(); obj != nil && sentObj != nil {
+		switch orig := orig.(type) {
+		case mapstr.M:
+			return mergeObject(orig, sentObj, obj)
+		case map[string]interface{}:
+			return mergeObject(orig, sentObj, obj)
+		}
+	}
+	return normalize(value.AsInterface())
+}
+
+// mergeObject returns the object returned by the sidecar, reusing the
+// original values of the keys the sidecar did not change.
+func mergeObject(orig map[string]interface{}, sent, obj *structpb.Struct) mapstr.M {
+	m := make(mapstr.M, len(obj.GetFields()))
+	for k, value := range obj.GetFields() {
+		m[k] = merge(orig[k], sent.GetFields()[k], value)
+	}
+	return m
+}
+
+// normalize converts the objects to mapstr.M and the numbers without
+// fraction back to integers, as all numbers are doubles in a struct.
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(mapstr.M, len(v))
+		for k, value := range v {
+			m[k] = normalize(value)
+		}
+		return m
+	case []interface{}:
+		for i, value := range v {
+			v[i] = normalize(value)
+		}
+		return v
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) <= maxSafeInteger {
+			return int64(v)
+		}
+		return v
+	default:
+		return v
+	}
+}
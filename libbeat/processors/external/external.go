@@ -0,0 +1,313 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package external
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/backoff"
+	"github.com/elastic/beats/v7/libbeat/processors"
+	"github.com/elastic/beats/v7/libbeat/processors/checks"
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+const (
+	procName = "external"
+	logName  = "processor." + procName
+
+	// processMethod is the bidirectional streaming gRPC method implemented
+	// by the sidecar. Each request message is a google.protobuf.ListValue
+	// of events, the sidecar answers each of them in order with a ListValue
+	// holding one value per event: the processed event, or null to drop it.
+	processMethod = "/elastic.beats.processor.external.v1.Processor/Process"
+)
+
+var (
+	errClosed = errors.New("processor closed")
+
+	processStream = &grpc.StreamDesc{
+		StreamName:    "Process",
+		ClientStreams: true,
+		ServerStreams: true,
+	}
+)
+
+func init() {
+	processors.RegisterPlugin(procName,
+		checks.ConfigChecked(New,
+			checks.RequireFields("address"),
+			checks.AllowedFields(
+				"address", "timeout", "batch_size", "max_in_flight",
+				"failure_policy", "add_error_key", "backoff", "when",
+			)))
+}
+
+// external sends the events to a sidecar over gRPC streams. Up to
+// max_in_flight workers each own a stream and send one batch at a time. An
+// idle worker sends an event right away, events of Run calls made while all
+// workers are busy are collected into the next batch.
+type external struct {
+	config externalConfig
+	log    *logp.Logger
+	conn   *grpc.ClientConn
+
+	requests chan *request
+
+	// ctx is cancelled on Close, to abort the calls and retries in progress.
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type request struct {
+	event  *beat.Event
+	result chan result
+}
+
+type result struct {
+	event *beat.Event
+	err   error
+}
+
+// New constructs a new external processor.
+func New(c *config.C) (beat.Processor, error) {
+	config := defaultConfig()
+	if err := c.Unpack(&config); err != nil {
+		return nil, fmt.Errorf("fail to unpack the "+procName+" processor configuration: %w", err)
+	}
+	return newExternal(config)
+}
+
+func newExternal(config externalConfig) (*external, error) {
+	conn, err := grpc.NewClient(config.Address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the "+procName+" processor client for %s: %w", config.Address, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &external{
+		config:   config,
+		log:      logp.NewLogger(logName),
+		conn:     conn,
+		requests: make(chan *request),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+	for i := 0; i < config.MaxInFlight; i++ {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			w := &worker{p: p}
+			defer w.reset()
+			w.run()
+		}()
+	}
+	return p, nil
+}
+
+func (p *external) Run(event *beat.Event) (*beat.Event, error) {
+	req := &request{event: event, result: make(chan result, 1)}
+	select {
+	case p.requests <- req:
+	case <-p.ctx.Done():
+		p.log.Debugf("Event not sent to the sidecar at %s: %v", p.config.Address, errClosed)
+		return p.failed(event, errClosed)
+	}
+	res := <-req.result
+	return res.event, res.err
+}
+
+// collect waits for a request and adds the requests already waiting to its
+// batch, up to the batch size. It returns nil once the processor is closed.
+func (p *external) collect() []*request {
+	var first *request
+	select {
+	case <-p.ctx.Done():
+		return nil
+	case first = <-p.requests:
+	}
+
+	batch := append(make([]*request, 0, p.config.BatchSize), first)
+	for len(batch) < p.config.BatchSize {
+		select {
+		case req := <-p.requests:
+			batch = append(batch, req)
+		default:
+			return batch
+		}
+	}
+	return batch
+}
+
+// worker sends batches to the sidecar over its own stream. The stream is
+// opened on the first batch and reopened after an error.
+type worker struct {
+	p      *external
+	stream grpc.ClientStream
+	cancel context.CancelFunc
+}
+
+// run processes batches until the processor is closed.
+func (w *worker) run() {
+	for {
+		batch := w.p.collect()
+		if batch == nil {
+			return
+		}
+		w.process(batch)
+	}
+}
+
+// process sends a batch to the sidecar and returns the results to the
+// waiting Run calls.
+func (w *worker) process(batch []*request) {
+	p := w.p
+	list := &structpb.ListValue{Values: make([]*structpb.Value, 0, len(batch))}
+	pending := make([]*request, 0, len(batch))
+	for _, req := range batch {
+		value, err := encodeEvent(req.event)
+		if err != nil {
+			req.result <- result{event: req.event, err: err}
+			continue
+		}
+		list.Values = append(list.Values, value)
+		pending = append(pending, req)
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	resp, err := w.call(list)
+	if err != nil && p.config.FailurePolicy == policyBlock {
+		b := backoff.NewEqualJitterBackoff(p.ctx.Done(), p.config.Backoff.Init, p.config.Backoff.Max)
+		for err != nil {
+			p.log.Warnf("Failed to process %d events with the sidecar at %s, retrying: %v", len(pending), p.config.Address, err)
+			if !b.Wait() {
+				err = errClosed
+				break
+			}
+			resp, err = w.call(list)
+		}
+	}
+	if err != nil {
+		p.log.Warnf("Failed to process %d events with the sidecar at %s: %v", len(pending), p.config.Address, err)
+		for _, req := range pending {
+			event, err := p.failed(req.event, err)
+			req.result <- result{event: event, err: err}
+		}
+		return
+	}
+
+	for i, req := range pending {
+		event, err := decodeEvent(list.Values[i], resp.Values[i], req.event)
+		if err != nil {
+			p.log.Warnf("Failed to decode an event returned by the sidecar at %s: %v", p.config.Address, err)
+			event, err = p.failed(req.event, err)
+		}
+		req.result <- result{event: event, err: err}
+	}
+}
+
+// call sends the events on the stream of the worker and waits for the
+// response within the timeout. The stream is closed on errors, as its
+// responses could no longer be matched to the batches.
+func (w *worker) call(list *structpb.ListValue) (*structpb.ListValue, error) {
+	p := w.p
+	if w.stream == nil {
+		ctx, cancel := context.WithCancel(p.ctx)
+		stream, err := p.conn.NewStream(ctx, processStream, processMethod)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		w.stream, w.cancel = stream, cancel
+	}
+
+	timer := time.AfterFunc(p.config.Timeout, w.cancel)
+	var resp structpb.ListValue
+	err := w.stream.SendMsg(list)
+	if err == nil {
+		err = w.stream.RecvMsg(&resp)
+	}
+	if !timer.Stop() {
+		w.reset()
+		if err != nil {
+			return nil, fmt.Errorf("no response from the sidecar within %v: %w", p.config.Timeout, context.DeadlineExceeded)
+		}
+	}
+	if err != nil {
+		w.reset()
+		return nil, err
+	}
+	if len(resp.Values) != len(list.Values) {
+		return nil, fmt.Errorf("sidecar returned %d values for %d events", len(resp.Values), len(list.Values))
+	}
+	return &resp, nil
+}
+
+// reset closes the stream of the worker.
+func (w *worker) reset() {
+	if w.stream == nil {
+		return
+	}
+	w.cancel()
+	w.stream, w.cancel = nil, nil
+}
+
+// failed applies the failure policy to an event that was not processed.
+// With the pass_through policy, the error is not returned so that the
+// following processors still run, it is only logged and optionally added to
+// the event. With the block policy, events that cannot be processed because
+// the processor is closed are returned with the error.
+func (p *external) failed(event *beat.Event, err error) (*beat.Event, error) {
+	err = fmt.Errorf("failed in "+procName+" processor: %w", err)
+	switch p.config.FailurePolicy {
+	case policyDrop:
+		return nil, err
+	case policyPassThrough:
+		if p.config.AddErrorKey {
+			_, _ = event.PutValue("error.message", err.Error())
+		}
+		return event, nil
+	default:
+		return event, err
+	}
+}
+
+// Close stops sending events to the sidecar. Events waiting for the sidecar
+// are handled according to the failure policy.
+func (p *external) Close() error {
+	p.cancel()
+	p.wg.Wait()
+	return p.conn.Close()
+}
+
+func (p *external) String() string {
+	return fmt.Sprintf("%s=[address=%s, timeout=%v, batch_size=%d, max_in_flight=%d, failure_policy=%s]",
+		procName, p.config.Address, p.config.Timeout, p.config.BatchSize, p.config.MaxInFlight, p.config.FailurePolicy)
+}
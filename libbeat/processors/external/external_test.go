@@ -0,0 +1,414 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package external
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/processors"
+	"github.com/elastic/beats/v7/libbeat/processors/actions"
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// sidecar is a test implementation of the Processor service.
+type sidecar struct {
+	batches atomic.Int64
+	process func(ctx context.Context, events []*structpb.Value) ([]*structpb.Value, error)
+}
+
+func (s *sidecar) handle(_ interface{}, stream grpc.ServerStream) error {
+	for {
+		var req structpb.ListValue
+		if err := stream.RecvMsg(&req); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		s.batches.Add(1)
+		values, err := s.process(stream.Context(), req.Values)
+		if err != nil {
+			return err
+		}
+		if err := stream.SendMsg(&structpb.ListValue{Values: values}); err != nil {
+			return err
+		}
+	}
+}
+
+// startSidecar serves the sidecar on a unix socket and returns its address.
+func startSidecar(t *testing.T, s *sidecar) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "sidecar.sock")
+	l, err := net.Listen("unix", path)
+	require.NoError(t, err)
+
+	server := grpc.NewServer()
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "elastic.beats.processor.external.v1.Processor",
+		HandlerType: (*interface{})(nil),
+		Streams: []grpc.StreamDesc{{
+			StreamName:    "Process",
+			Handler:       s.handle,
+			ClientStreams: true,
+			ServerStreams: true,
+		}},
+	}, s)
+	go server.Serve(l) //nolint:errcheck // Serve returns once stopped.
+	t.Cleanup(server.Stop)
+
+	return "unix://" + path
+}
+
+func enrich(_ context.Context, events []*structpb.Value) ([]*structpb.Value, error) {
+	out := make([]*structpb.Value, len(events))
+	for i, v := range events {
+		fields := v.GetStructValue()
+		if fields.Fields["drop"].GetBoolValue() {
+			out[i] = structpb.NewNullValue()
+			continue
+		}
+		fields.Fields["enriched"] = structpb.NewBoolValue(true)
+		out[i] = v
+	}
+	return out, nil
+}
+
+func newTestProcessor(t *testing.T, address string, settings map[string]interface{}) *external {
+	t.Helper()
+
+	c := defaultConfig()
+	settings["address"] = address
+	require.NoError(t, config.MustNewConfigFrom(settings).Unpack(&c))
+	p, err := newExternal(c)
+	require.NoError(t, err)
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+func testEvent(fields mapstr.M) *beat.Event {
+	return &beat.Event{
+		Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Fields:    fields,
+	}
+}
+
+func TestExternal(t *testing.T) {
+	t.Run("enriches events", func(t *testing.T) {
+		p := newTestProcessor(t, startSidecar(t, &sidecar{process: enrich}), map[string]interface{}{})
+
+		event, err := p.Run(testEvent(mapstr.M{"message": "hello", "count": 3}))
+		require.NoError(t, err)
+		require.NotNil(t, event)
+		assert.Equal(t, mapstr.M{"message": "hello", "count": 3, "enriched": true}, event.Fields)
+		assert.Equal(t, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), event.Timestamp)
+	})
+
+	t.Run("drops events the sidecar returns null for", func(t *testing.T) {
+		p := newTestProcessor(t, startSidecar(t, &sidecar{process: enrich}), map[string]interface{}{})
+
+		event, err := p.Run(testEvent(mapstr.M{"drop": true}))
+		require.NoError(t, err)
+		assert.Nil(t, event)
+	})
+
+	t.Run("batches events while the sidecar is busy", func(t *testing.T) {
+		release := make(chan struct{})
+		var events atomic.Int64
+		s := &sidecar{process: func(ctx context.Context, values []*structpb.Value) ([]*structpb.Value, error) {
+			events.Add(int64(len(values)))
+			<-release
+			return enrich(ctx, values)
+		}}
+		p := newTestProcessor(t, startSidecar(t, s), map[string]interface{}{
+			"batch_size":    10,
+			"max_in_flight": 1,
+		})
+
+		var wg sync.WaitGroup
+		run := func(i int) {
+			defer wg.Done()
+			event, err := p.Run(testEvent(mapstr.M{"n": i}))
+			assert.NoError(t, err)
+			if assert.NotNil(t, event) {
+				assert.Equal(t, i, event.Fields["n"])
+				assert.Equal(t, true, event.Fields["enriched"])
+			}
+		}
+
+		// The first event is sent alone, the next ones wait for the worker.
+		wg.Add(1)
+		go run(0)
+		require.Eventually(t, func() bool { return events.Load() == 1 }, 5*time.Second, time.Millisecond)
+		for i := 1; i <= 20; i++ {
+			wg.Add(1)
+			go run(i)
+		}
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+		assert.Equal(t, int64(3), s.batches.Load())
+	})
+
+	t.Run("does not delay a single caller", func(t *testing.T) {
+		s := &sidecar{process: enrich}
+		p := newTestProcessor(t, startSidecar(t, s), map[string]interface{}{})
+
+		const n = 2000
+		start := time.Now()
+		for i := 0; i < n; i++ {
+			event, err := p.Run(testEvent(mapstr.M{"n": i}))
+			require.NoError(t, err)
+			require.NotNil(t, event)
+		}
+		elapsed := time.Since(start)
+		t.Logf("processed %d events in %v (%.0f events/s)", n, elapsed, n/elapsed.Seconds())
+		assert.Less(t, elapsed, 5*time.Second)
+		assert.Equal(t, int64(n), s.batches.Load())
+	})
+
+	t.Run("failure policies", func(t *testing.T) {
+		failing := &sidecar{process: func(context.Context, []*structpb.Value) ([]*structpb.Value, error) {
+			return nil, errors.New("unavailable")
+		}}
+		address := startSidecar(t, failing)
+
+		p := newTestProcessor(t, address, map[string]interface{}{"failure_policy": policyPassThrough})
+		event, err := p.Run(testEvent(mapstr.M{"message": "hello"}))
+		assert.NoError(t, err)
+		require.NotNil(t, event)
+		assert.Equal(t, mapstr.M{"message": "hello"}, event.Fields)
+
+		p = newTestProcessor(t, address, map[string]interface{}{"failure_policy": policyPassThrough, "add_error_key": true})
+		event, err = p.Run(testEvent(mapstr.M{"message": "hello"}))
+		assert.NoError(t, err)
+		require.NotNil(t, event)
+		assert.Equal(t, "hello", event.Fields["message"])
+		msg, _ := event.GetValue("error.message")
+		assert.Contains(t, msg, "failed in external processor")
+
+		p = newTestProcessor(t, address, map[string]interface{}{"failure_policy": policyDrop})
+		event, err = p.Run(testEvent(mapstr.M{"message": "hello"}))
+		assert.Error(t, err)
+		assert.Nil(t, event)
+	})
+
+	t.Run("block retries until the sidecar succeeds", func(t *testing.T) {
+		var calls atomic.Int64
+		s := &sidecar{process: func(ctx context.Context, events []*structpb.Value) ([]*structpb.Value, error) {
+			if calls.Add(1) < 3 {
+				return nil, errors.New("unavailable")
+			}
+			return enrich(ctx, events)
+		}}
+		p := newTestProcessor(t, startSidecar(t, s), map[string]interface{}{
+			"failure_policy": policyBlock,
+			"backoff.init":   "1ms",
+			"backoff.max":    "10ms",
+		})
+
+		event, err := p.Run(testEvent(mapstr.M{"message": "hello"}))
+		require.NoError(t, err)
+		require.NotNil(t, event)
+		assert.Equal(t, true, event.Fields["enriched"])
+		assert.Equal(t, int64(3), calls.Load())
+	})
+
+	t.Run("close unblocks blocked events", func(t *testing.T) {
+		s := &sidecar{process: func(context.Context, []*structpb.Value) ([]*structpb.Value, error) {
+			return nil, errors.New("unavailable")
+		}}
+		p := newTestProcessor(t, startSidecar(t, s), map[string]interface{}{
+			"failure_policy": policyBlock,
+			"backoff.init":   "1ms",
+			"backoff.max":    "10ms",
+		})
+
+		done := make(chan error)
+		go func() {
+			_, err := p.Run(testEvent(mapstr.M{"message": "hello"}))
+			done <- err
+		}()
+		require.Eventually(t, func() bool { return s.batches.Load() > 1 }, 5*time.Second, time.Millisecond)
+		require.NoError(t, p.Close())
+
+		select {
+		case err := <-done:
+			assert.ErrorIs(t, err, errClosed)
+		case <-time.After(5 * time.Second):
+			t.Fatal("Run did not return after Close")
+		}
+
+		event, err := p.Run(testEvent(mapstr.M{"message": "hello"}))
+		assert.ErrorIs(t, err, errClosed)
+		assert.NotNil(t, event)
+	})
+
+	t.Run("times out slow sidecars", func(t *testing.T) {
+		s := &sidecar{process: func(ctx context.Context, _ []*structpb.Value) ([]*structpb.Value, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}}
+		p := newTestProcessor(t, startSidecar(t, s), map[string]interface{}{"timeout": "50ms", "add_error_key": true})
+
+		event, err := p.Run(testEvent(mapstr.M{"message": "hello"}))
+		assert.NoError(t, err)
+		require.NotNil(t, event)
+		msg, _ := event.GetValue("error.message")
+		assert.Contains(t, msg, "no response from the sidecar within 50ms")
+	})
+
+	t.Run("rejects responses of the wrong length", func(t *testing.T) {
+		s := &sidecar{process: func(context.Context, []*structpb.Value) ([]*structpb.Value, error) {
+			return nil, nil
+		}}
+		p := newTestProcessor(t, startSidecar(t, s), map[string]interface{}{"add_error_key": true})
+
+		event, err := p.Run(testEvent(mapstr.M{"message": "hello"}))
+		assert.NoError(t, err)
+		require.NotNil(t, event)
+		msg, _ := event.GetValue("error.message")
+		assert.Contains(t, msg, "sidecar returned 0 values for 1 events")
+	})
+
+	t.Run("pass through runs the following processors", func(t *testing.T) {
+		s := &sidecar{process: func(context.Context, []*structpb.Value) ([]*structpb.Value, error) {
+			return nil, errors.New("unavailable")
+		}}
+		p := newTestProcessor(t, startSidecar(t, s), map[string]interface{}{})
+		next, err := actions.CreateAddFields(config.MustNewConfigFrom(map[string]interface{}{
+			"target": "",
+			"fields": map[string]interface{}{"next": true},
+		}))
+		require.NoError(t, err)
+		list := &processors.Processors{List: []beat.Processor{p, next}}
+
+		event, err := list.Run(testEvent(mapstr.M{"message": "hello"}))
+		require.NoError(t, err)
+		require.NotNil(t, event)
+		assert.Equal(t, mapstr.M{"message": "hello", "next": true}, event.Fields)
+	})
+
+	t.Run("keeps the values not changed by the sidecar", func(t *testing.T) {
+		p := newTestProcessor(t, startSidecar(t, &sidecar{process: enrich}), map[string]interface{}{})
+
+		e := testEvent(mapstr.M{
+			"big":     int64(1<<60 + 1),
+			"ratio":   2.0,
+			"count":   uint16(3),
+			"nested":  mapstr.M{"id": uint64(1<<63 + 1), "score": 1.0},
+			"message": "hello",
+		})
+		e.Meta = mapstr.M{"_id": "abc", "offset": int64(1<<55 + 1)}
+		event, err := p.Run(e)
+		require.NoError(t, err)
+		require.NotNil(t, event)
+		assert.Equal(t, mapstr.M{
+			"big":      int64(1<<60 + 1),
+			"ratio":    2.0,
+			"count":    uint16(3),
+			"nested":   mapstr.M{"id": uint64(1<<63 + 1), "score": 1.0},
+			"message":  "hello",
+			"enriched": true,
+		}, event.Fields)
+		assert.Equal(t, mapstr.M{"_id": "abc", "offset": int64(1<<55 + 1)}, event.Meta)
+	})
+
+	t.Run("decodes the values changed by the sidecar", func(t *testing.T) {
+		s := &sidecar{process: func(_ context.Context, events []*structpb.Value) ([]*structpb.Value, error) {
+			for _, v := range events {
+				nested := v.GetStructValue().Fields["nested"].GetStructValue()
+				nested.Fields["score"] = structpb.NewNumberValue(1.5)
+				nested.Fields["added"] = structpb.NewNumberValue(7)
+				delete(v.GetStructValue().Fields, "removed")
+			}
+			return events, nil
+		}}
+		p := newTestProcessor(t, startSidecar(t, s), map[string]interface{}{})
+
+		event, err := p.Run(testEvent(mapstr.M{
+			"nested":  mapstr.M{"id": uint64(1<<63 + 1), "score": 1.0},
+			"removed": "yes",
+		}))
+		require.NoError(t, err)
+		require.NotNil(t, event)
+		assert.Equal(t, mapstr.M{
+			"nested": mapstr.M{"id": uint64(1<<63 + 1), "score": 1.5, "added": int64(7)},
+		}, event.Fields)
+	})
+}
+
+func TestConfig(t *testing.T) {
+	tests := map[string]struct {
+		settings map[string]interface{}
+		err      string
+	}{
+		"defaults": {
+			settings: map[string]interface{}{"address": "localhost:50051"},
+		},
+		"missing address": {
+			settings: map[string]interface{}{},
+			err:      "string value is not set accessing 'address'",
+		},
+		"invalid failure policy": {
+			settings: map[string]interface{}{"address": "localhost:50051", "failure_policy": "retry"},
+			err:      `invalid failure_policy "retry"`,
+		},
+		"invalid batch size": {
+			settings: map[string]interface{}{"address": "localhost:50051", "batch_size": 0},
+			err:      "requires value >= 1",
+		},
+		"invalid max in flight": {
+			settings: map[string]interface{}{"address": "localhost:50051", "max_in_flight": 0},
+			err:      "requires value >= 1",
+		},
+		"invalid backoff": {
+			settings: map[string]interface{}{"address": "localhost:50051", "backoff.init": "1m", "backoff.max": "1s"},
+			err:      "backoff.max must be greater than or equal to backoff.init",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			p, err := New(config.MustNewConfigFrom(test.settings))
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "external=[address=localhost:50051, timeout=1s, batch_size=100, max_in_flight=4, failure_policy=pass_through]", p.String())
+			assert.NoError(t, p.(*external).Close())
+		})
+	}
+}
@@ -0,0 +1,83 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package match_indicators
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/transport/httpcommon"
+)
+
+const (
+	typeIP     = "ip"
+	typeDomain = "domain"
+	typeHash   = "hash"
+)
+
+type config struct {
+	Fields          []string      `config:"fields" validate:"required"`
+	Feeds           []feedConfig  `config:"feeds" validate:"required"`
+	RefreshInterval time.Duration `config:"refresh_interval" validate:"min=0"`
+	MatchSubdomains bool          `config:"match_subdomains"`
+	Target          string        `config:"target_field" validate:"required"`
+	Tag             string        `config:"tag"`
+
+	Transport httpcommon.HTTPTransportSettings `config:",inline"`
+}
+
+// feedConfig configures a list of indicators of a single type, one per
+// line, read from a file or an URL.
+type feedConfig struct {
+	Name string `config:"name" validate:"required"`
+	Type string `config:"type" validate:"required"`
+	Path string `config:"path"`
+	URL  string `config:"url"`
+}
+
+func defaultConfig() config {
+	transport := httpcommon.DefaultHTTPTransportSettings()
+	transport.Timeout = 30 * time.Second
+	return config{
+		RefreshInterval: time.Hour,
+		MatchSubdomains: true,
+		Target:          "threat.enrichments",
+		Tag:             "threat_indicator_match",
+		Transport:       transport,
+	}
+}
+
+func (c *config) Validate() error {
+	names := map[string]bool{}
+	for _, f := range c.Feeds {
+		if names[f.Name] {
+			return fmt.Errorf("duplicate feed name %q", f.Name)
+		}
+		names[f.Name] = true
+
+		switch f.Type {
+		case typeIP, typeDomain, typeHash:
+		default:
+			return fmt.Errorf("feed %q has invalid type %q, must be one of %q, %q or %q", f.Name, f.Type, typeIP, typeDomain, typeHash)
+		}
+		if (f.Path == "") == (f.URL == "") {
+			return fmt.Errorf("feed %q must set exactly one of path or url", f.Name)
+		}
+	}
+	return nil
+}
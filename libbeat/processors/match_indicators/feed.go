@@ -0,0 +1,44 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package match_indicators
+
+import (
+	"net/http"
+
+	"github.com/elastic/beats/v7/libbeat/common/remotefile"
+)
+
+// maxFeedSize limits the size of a downloaded feed.
+const maxFeedSize = 256 << 20
+
+// feed loads the indicators of a feed from a file or an URL, only
+// returning them when they changed since the previous load.
+type feed struct {
+	config feedConfig
+	loader *remotefile.Loader
+}
+
+func newFeed(c feedConfig, client *http.Client) *feed {
+	return &feed{config: c, loader: remotefile.New(c.Path, c.URL, client, maxFeedSize)}
+}
+
+// load returns the content of the feed, or nil if it didn't change since
+// the previous call.
+func (f *feed) load() ([]byte, error) {
+	return f.loader.Load()
+}
@@ -0,0 +1,235 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package match_indicators
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+// indicators is an immutable set of indicators, mapping each of them to
+// the names of the feeds that contain it.
+type indicators struct {
+	ips      map[netip.Addr][]string
+	prefixes map[netip.Prefix][]string
+	// prefixLens are the distinct lengths of prefixes, longest first.
+	prefixLens []int
+	domains    map[string][]string
+	hashes     map[string][]string
+}
+
+// feedIndicators are the indicators of a single feed.
+type feedIndicators struct {
+	ips      []netip.Addr
+	prefixes []netip.Prefix
+	values   []string // domains or hashes
+}
+
+// parseFeed parses a list of indicators of type typ, one per line. Empty
+// lines and lines starting with # or // are ignored, and only the first
+// column of comma or whitespace separated lines is used. It returns the
+// number of invalid lines.
+func parseFeed(typ string, data []byte) (feedIndicators, int) {
+	var f feedIndicators
+	var invalid int
+	s := bufio.NewScanner(bytes.NewReader(data))
+	s.Buffer(nil, 1<<20)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+		if i := strings.IndexAny(line, ", \t;"); i >= 0 {
+			line = line[:i]
+		}
+
+		switch typ {
+		case typeIP:
+			if strings.Contains(line, "/") {
+				p, err := netip.ParsePrefix(line)
+				if err != nil {
+					invalid++
+					continue
+				}
+				f.prefixes = append(f.prefixes, p.Masked())
+				continue
+			}
+			ip, err := netip.ParseAddr(line)
+			if err != nil {
+				invalid++
+				continue
+			}
+			f.ips = append(f.ips, ip.Unmap())
+		case typeDomain:
+			domain := normalizeDomain(line)
+			if domain == "" || strings.ContainsAny(domain, "/:@") {
+				invalid++
+				continue
+			}
+			f.values = append(f.values, domain)
+		case typeHash:
+			hash := strings.ToLower(line)
+			if hashField(hash) == "" {
+				invalid++
+				continue
+			}
+			f.values = append(f.values, hash)
+		}
+	}
+	return f, invalid
+}
+
+func newIndicators(feeds map[string]feedIndicators, types map[string]string) *indicators {
+	set := &indicators{
+		ips:      map[netip.Addr][]string{},
+		prefixes: map[netip.Prefix][]string{},
+		domains:  map[string][]string{},
+		hashes:   map[string][]string{},
+	}
+	lens := map[int]bool{}
+
+	// Feeds are added in a stable order so that matches list them
+	// consistently.
+	names := make([]string, 0, len(feeds))
+	for name := range feeds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		f := feeds[name]
+		for _, ip := range f.ips {
+			set.ips[ip] = appendOnce(set.ips[ip], name)
+		}
+		for _, p := range f.prefixes {
+			set.prefixes[p] = appendOnce(set.prefixes[p], name)
+			lens[p.Bits()] = true
+		}
+		target := set.domains
+		if types[name] == typeHash {
+			target = set.hashes
+		}
+		for _, v := range f.values {
+			target[v] = appendOnce(target[v], name)
+		}
+	}
+	for l := range lens {
+		set.prefixLens = append(set.prefixLens, l)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(set.prefixLens)))
+	return set
+}
+
+func (s *indicators) size() int {
+	return len(s.ips) + len(s.prefixes) + len(s.domains) + len(s.hashes)
+}
+
+// match is an indicator that matched a value of an event.
+type match struct {
+	feeds []string
+	// indicatorType is the STIX type of the indicator, and field and value
+	// the ECS field of the indicator and its value.
+	indicatorType string
+	field         string
+	value         string
+}
+
+func (s *indicators) match(value string, matchSubdomains bool) []match {
+	value = strings.TrimSpace(value)
+	if ip, err := netip.ParseAddr(value); err == nil {
+		return s.matchIP(ip.Unmap())
+	}
+
+	var matches []match
+	if field := hashField(strings.ToLower(value)); field != "" {
+		if feeds, ok := s.hashes[strings.ToLower(value)]; ok {
+			matches = append(matches, match{feeds: feeds, indicatorType: "file", field: "file.hash." + field, value: strings.ToLower(value)})
+		}
+	}
+
+	domain := normalizeDomain(value)
+	for domain != "" {
+		if feeds, ok := s.domains[domain]; ok {
+			matches = append(matches, match{feeds: feeds, indicatorType: "domain-name", field: "url.domain", value: domain})
+		}
+		i := strings.IndexByte(domain, '.')
+		if !matchSubdomains || i < 0 {
+			break
+		}
+		domain = domain[i+1:]
+	}
+	return matches
+}
+
+func (s *indicators) matchIP(ip netip.Addr) []match {
+	typ := "ipv4-addr"
+	if ip.Is6() {
+		typ = "ipv6-addr"
+	}
+
+	var matches []match
+	if feeds, ok := s.ips[ip]; ok {
+		matches = append(matches, match{feeds: feeds, indicatorType: typ, field: "ip", value: ip.String()})
+	}
+	for _, bits := range s.prefixLens {
+		p, err := ip.Prefix(bits)
+		if err != nil {
+			continue
+		}
+		if feeds, ok := s.prefixes[p]; ok {
+			matches = append(matches, match{feeds: feeds, indicatorType: typ, field: "ip", value: ip.String()})
+		}
+	}
+	return matches
+}
+
+func normalizeDomain(s string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(s)), ".")
+}
+
+// hashField returns the ECS file.hash field of a hex encoded hash, or an
+// empty string if s is not a hash.
+func hashField(s string) string {
+	if _, err := hex.DecodeString(s); err != nil {
+		return ""
+	}
+	switch len(s) {
+	case 32:
+		return "md5"
+	case 40:
+		return "sha1"
+	case 64:
+		return "sha256"
+	case 128:
+		return "sha512"
+	}
+	return ""
+}
+
+func appendOnce(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
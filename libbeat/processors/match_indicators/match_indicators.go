@@ -0,0 +1,247 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package match_indicators
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/processors"
+	"github.com/elastic/beats/v7/libbeat/processors/checks"
+	jsprocessor "github.com/elastic/beats/v7/libbeat/processors/script/javascript/module/processor"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/transport/httpcommon"
+)
+
+const (
+	procName = "match_indicators"
+	logName  = "processor." + procName
+)
+
+func init() {
+	processors.RegisterPlugin(procName,
+		checks.ConfigChecked(New,
+			checks.RequireFields("fields", "feeds"),
+			checks.AllowedFields(
+				"fields", "feeds", "refresh_interval",
+				"match_subdomains", "target_field", "tag",
+				"timeout", "ssl", "proxy_url", "proxy_headers",
+				"proxy_disable", "when",
+			)))
+	jsprocessor.RegisterPlugin("MatchIndicators", New)
+}
+
+type matchIndicators struct {
+	config
+
+	indicators atomic.Pointer[indicators]
+	feeds      []*feed
+	client     *http.Client
+	log        *logp.Logger
+
+	// loaded are the last indicators successfully loaded from each feed.
+	loaded map[string]feedIndicators
+	types  map[string]string
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// New constructs a new match_indicators processor.
+func New(c *conf.C) (beat.Processor, error) {
+	config := defaultConfig()
+
+	if err := c.Unpack(&config); err != nil {
+		return nil, fmt.Errorf("fail to unpack the "+procName+" processor configuration: %w", err)
+	}
+
+	return newMatchIndicators(config)
+}
+
+func newMatchIndicators(c config) (*matchIndicators, error) {
+	p := &matchIndicators{
+		config: c,
+		log:    logp.NewLogger(logName),
+		loaded: map[string]feedIndicators{},
+		types:  map[string]string{},
+		done:   make(chan struct{}),
+	}
+
+	for _, fc := range c.Feeds {
+		if fc.URL != "" && p.client == nil {
+			client, err := c.Transport.Client(httpcommon.WithLogger(p.log))
+			if err != nil {
+				return nil, fmt.Errorf("failed to configure the "+procName+" processor: %w", err)
+			}
+			p.client = client
+		}
+		p.feeds = append(p.feeds, newFeed(fc, p.client))
+		p.types[fc.Name] = fc.Type
+	}
+
+	for _, f := range p.feeds {
+		if _, err := p.load(f); err != nil {
+			if f.config.Path != "" {
+				return nil, fmt.Errorf("failed to load feed %q of the "+procName+" processor: %w", f.config.Name, err)
+			}
+			// The feed is retried at the next refresh.
+			p.log.Warnf("Failed to fetch feed %q from %s: %v", f.config.Name, f.config.URL, err)
+		}
+	}
+	p.indicators.Store(newIndicators(p.loaded, p.types))
+	p.log.Infof("Loaded %d indicators from %d feeds", p.indicators.Load().size(), len(p.feeds))
+
+	if c.RefreshInterval > 0 {
+		go p.run()
+	}
+	return p, nil
+}
+
+// load loads the feed if it changed since it was last loaded.
+func (p *matchIndicators) load(f *feed) (changed bool, err error) {
+	data, err := f.load()
+	if err != nil || data == nil {
+		return false, err
+	}
+	parsed, invalid := parseFeed(f.config.Type, data)
+	if invalid > 0 {
+		p.log.Warnf("Ignored %d invalid %s indicators in feed %q", invalid, f.config.Type, f.config.Name)
+	}
+	p.loaded[f.config.Name] = parsed
+	return true, nil
+}
+
+func (p *matchIndicators) run() {
+	ticker := time.NewTicker(p.RefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			p.refresh()
+		}
+	}
+}
+
+// refresh reloads the feeds that changed and replaces the indicators.
+func (p *matchIndicators) refresh() {
+	changed := false
+	for _, f := range p.feeds {
+		feedChanged, err := p.load(f)
+		if err != nil {
+			p.log.Warnf("Failed to refresh feed %q, keeping its current indicators: %v", f.config.Name, err)
+			continue
+		}
+		changed = changed || feedChanged
+	}
+	if changed {
+		p.indicators.Store(newIndicators(p.loaded, p.types))
+		p.log.Debugf("Refreshed feeds, %d indicators loaded", p.indicators.Load().size())
+	}
+}
+
+func (p *matchIndicators) Run(event *beat.Event) (*beat.Event, error) {
+	set := p.indicators.Load()
+
+	var enrichments []mapstr.M
+	for _, field := range p.Fields {
+		v, err := event.GetValue(field)
+		if err != nil {
+			continue
+		}
+		for _, value := range values(v) {
+			for _, m := range set.match(value, p.MatchSubdomains) {
+				for _, feed := range m.feeds {
+					enrichments = append(enrichments, mapstr.M{
+						"indicator": mapstr.M{
+							"type":     m.indicatorType,
+							"provider": feed,
+							m.field:    m.value,
+						},
+						"matched": mapstr.M{
+							"atomic": value,
+							"field":  field,
+							"type":   "indicator_match_rule",
+						},
+					})
+				}
+			}
+		}
+	}
+	if len(enrichments) == 0 {
+		return event, nil
+	}
+
+	if existing, err := event.GetValue(p.Target); err == nil {
+		if list, ok := existing.([]mapstr.M); ok {
+			enrichments = append(list, enrichments...)
+		}
+	}
+	if _, err := event.PutValue(p.Target, enrichments); err != nil {
+		return event, fmt.Errorf("failed to put matched indicators into %q: %w", p.Target, err)
+	}
+	if p.Tag != "" {
+		if err := mapstr.AddTags(event.Fields, []string{p.Tag}); err != nil {
+			return event, err
+		}
+	}
+	return event, nil
+}
+
+// values returns the string values of an event field.
+func values(v interface{}) []string {
+	switch val := v.(type) {
+	case string:
+		return []string{val}
+	case []string:
+		return val
+	case net.IP:
+		return []string{val.String()}
+	case []interface{}:
+		var list []string
+		for _, elem := range val {
+			list = append(list, values(elem)...)
+		}
+		return list
+	case fmt.Stringer:
+		return []string{val.String()}
+	}
+	return nil
+}
+
+func (p *matchIndicators) Close() error {
+	p.closeOnce.Do(func() {
+		close(p.done)
+		if p.client != nil {
+			p.client.CloseIdleConnections()
+		}
+	})
+	return nil
+}
+
+func (p *matchIndicators) String() string {
+	return fmt.Sprintf("%s=[fields=%v, feeds=%d]", procName, p.Fields, len(p.Feeds))
+}
@@ -0,0 +1,263 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package match_indicators
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+const (
+	testSHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	testMD5    = "d41d8cd98f00b204e9800998ecf8427e"
+)
+
+func writeFeed(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func newTestProcessor(t *testing.T, settings map[string]interface{}) *matchIndicators {
+	t.Helper()
+	p, err := New(conf.MustNewConfigFrom(settings))
+	require.NoError(t, err)
+	t.Cleanup(func() { p.(*matchIndicators).Close() })
+	return p.(*matchIndicators)
+}
+
+func TestMatchIndicators(t *testing.T) {
+	dir := t.TempDir()
+	p := newTestProcessor(t, map[string]interface{}{
+		"fields": []string{"source.ip", "destination.ip", "related.ip", "dns.question.name", "file.hash.sha256", "file.hash.md5"},
+		"feeds": []map[string]interface{}{
+			{"name": "botnet", "type": "ip", "path": writeFeed(t, dir, "ips.txt", "# C2 servers\n198.51.100.7\n203.0.113.0/24, scanners\n2001:db8::/32\nnot-an-ip\n")},
+			{"name": "blocklist", "type": "ip", "path": writeFeed(t, dir, "block.txt", "198.51.100.7\n")},
+			{"name": "phishing", "type": "domain", "path": writeFeed(t, dir, "domains.txt", "evil.example.\n// comment\nBad.Example.org\n")},
+			{"name": "malware", "type": "hash", "path": writeFeed(t, dir, "hashes.txt", testSHA256+" empty file\n"+testMD5+"\nxyz\n")},
+		},
+		"refresh_interval": 0,
+	})
+
+	enrichment := func(typ, provider, field, value, atomic, matchedField string) mapstr.M {
+		return mapstr.M{
+			"indicator": mapstr.M{"type": typ, "provider": provider, field: value},
+			"matched":   mapstr.M{"atomic": atomic, "field": matchedField, "type": "indicator_match_rule"},
+		}
+	}
+
+	testCases := []struct {
+		description string
+		fields      mapstr.M
+		expected    []mapstr.M
+	}{
+		{
+			description: "no match",
+			fields:      mapstr.M{"source": mapstr.M{"ip": "192.0.2.1"}, "dns": mapstr.M{"question": mapstr.M{"name": "example.com"}}},
+		},
+		{
+			description: "ip in several feeds",
+			fields:      mapstr.M{"destination": mapstr.M{"ip": "198.51.100.7"}},
+			expected: []mapstr.M{
+				enrichment("ipv4-addr", "blocklist", "ip", "198.51.100.7", "198.51.100.7", "destination.ip"),
+				enrichment("ipv4-addr", "botnet", "ip", "198.51.100.7", "198.51.100.7", "destination.ip"),
+			},
+		},
+		{
+			description: "ip in a network",
+			fields:      mapstr.M{"source": mapstr.M{"ip": net.ParseIP("203.0.113.9")}},
+			expected: []mapstr.M{
+				enrichment("ipv4-addr", "botnet", "ip", "203.0.113.9", "203.0.113.9", "source.ip"),
+			},
+		},
+		{
+			description: "ipv6 in a list",
+			fields:      mapstr.M{"related": mapstr.M{"ip": []string{"192.0.2.1", "2001:db8::1"}}},
+			expected: []mapstr.M{
+				enrichment("ipv6-addr", "botnet", "ip", "2001:db8::1", "2001:db8::1", "related.ip"),
+			},
+		},
+		{
+			description: "subdomain",
+			fields:      mapstr.M{"dns": mapstr.M{"question": mapstr.M{"name": "login.BAD.example.org."}}},
+			expected: []mapstr.M{
+				enrichment("domain-name", "phishing", "url.domain", "bad.example.org", "login.BAD.example.org.", "dns.question.name"),
+			},
+		},
+		{
+			description: "hashes",
+			fields:      mapstr.M{"file": mapstr.M{"hash": mapstr.M{"sha256": testSHA256, "md5": "D41D8CD98F00B204E9800998ECF8427E"}}},
+			expected: []mapstr.M{
+				enrichment("file", "malware", "file.hash.sha256", testSHA256, testSHA256, "file.hash.sha256"),
+				enrichment("file", "malware", "file.hash.md5", testMD5, "D41D8CD98F00B204E9800998ECF8427E", "file.hash.md5"),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			event, err := p.Run(&beat.Event{Fields: tc.fields.Clone()})
+			require.NoError(t, err)
+			enrichments, _ := event.GetValue("threat.enrichments")
+			tags, _ := event.GetValue("tags")
+			if tc.expected == nil {
+				assert.Nil(t, enrichments)
+				assert.Nil(t, tags)
+				return
+			}
+			assert.Equal(t, tc.expected, enrichments)
+			assert.Equal(t, []string{"threat_indicator_match"}, tags)
+		})
+	}
+
+	t.Run("existing enrichments are kept", func(t *testing.T) {
+		existing := mapstr.M{"indicator": mapstr.M{"type": "url"}}
+		event, err := p.Run(&beat.Event{Fields: mapstr.M{
+			"source": mapstr.M{"ip": "198.51.100.7"},
+			"threat": mapstr.M{"enrichments": []mapstr.M{existing}},
+		}})
+		require.NoError(t, err)
+		enrichments, _ := event.GetValue("threat.enrichments")
+		assert.Len(t, enrichments, 3)
+		assert.Equal(t, existing, enrichments.([]mapstr.M)[0])
+	})
+}
+
+func TestMatchSubdomainsDisabled(t *testing.T) {
+	p := newTestProcessor(t, map[string]interface{}{
+		"fields":           []string{"url.domain"},
+		"feeds":            []map[string]interface{}{{"name": "phishing", "type": "domain", "path": writeFeed(t, t.TempDir(), "d.txt", "evil.example\n")}},
+		"match_subdomains": false,
+		"refresh_interval": 0,
+		"tag":              "",
+	})
+	event, err := p.Run(&beat.Event{Fields: mapstr.M{"url": mapstr.M{"domain": "www.evil.example"}}})
+	require.NoError(t, err)
+	assert.False(t, hasKey(event, "threat"))
+
+	event, err = p.Run(&beat.Event{Fields: mapstr.M{"url": mapstr.M{"domain": "evil.example"}}})
+	require.NoError(t, err)
+	assert.True(t, hasKey(event, "threat"))
+	assert.False(t, hasKey(event, "tags"))
+}
+
+func TestRefresh(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFeed(t, dir, "ips.txt", "198.51.100.7\n")
+
+	var body atomic.Value
+	body.Store("203.0.113.1\n")
+	var served atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := strconv.Quote(body.Load().(string))
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		served.Add(1)
+		w.Header().Set("ETag", etag)
+		_, _ = w.Write([]byte(body.Load().(string)))
+	}))
+	defer srv.Close()
+
+	p := newTestProcessor(t, map[string]interface{}{
+		"fields": []string{"source.ip"},
+		"feeds": []map[string]interface{}{
+			{"name": "file", "type": "ip", "path": path},
+			{"name": "remote", "type": "ip", "url": srv.URL},
+		},
+		"refresh_interval": 0,
+	})
+
+	matches := func(ip string) bool {
+		event, err := p.Run(&beat.Event{Fields: mapstr.M{"source": mapstr.M{"ip": ip}}})
+		require.NoError(t, err)
+		return hasKey(event, "threat")
+	}
+	assert.True(t, matches("198.51.100.7"))
+	assert.True(t, matches("203.0.113.1"))
+
+	set := p.indicators.Load()
+	p.refresh()
+	assert.Same(t, set, p.indicators.Load(), "unchanged feeds are not reloaded")
+	assert.Equal(t, int32(1), served.Load())
+
+	require.NoError(t, os.WriteFile(path, []byte("198.51.100.8\n"), 0o600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+	body.Store("203.0.113.2\n")
+	p.refresh()
+	assert.False(t, matches("198.51.100.7"))
+	assert.True(t, matches("198.51.100.8"))
+	assert.False(t, matches("203.0.113.1"))
+	assert.True(t, matches("203.0.113.2"))
+
+	// Feeds that fail to load keep their indicators.
+	require.NoError(t, os.Remove(path))
+	srv.Close()
+	p.refresh()
+	assert.True(t, matches("198.51.100.8"))
+	assert.True(t, matches("203.0.113.2"))
+}
+
+func TestConfig(t *testing.T) {
+	path := writeFeed(t, t.TempDir(), "ips.txt", "198.51.100.7\n")
+	for name, feeds := range map[string][]map[string]interface{}{
+		"invalid type":    {{"name": "a", "type": "url", "path": path}},
+		"no source":       {{"name": "a", "type": "ip"}},
+		"two sources":     {{"name": "a", "type": "ip", "path": path, "url": "http://localhost"}},
+		"duplicate names": {{"name": "a", "type": "ip", "path": path}, {"name": "a", "type": "ip", "path": path}},
+		"missing file":    {{"name": "a", "type": "ip", "path": path + ".missing"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := New(conf.MustNewConfigFrom(map[string]interface{}{
+				"fields": []string{"source.ip"},
+				"feeds":  feeds,
+			}))
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("unreachable url", func(t *testing.T) {
+		p := newTestProcessor(t, map[string]interface{}{
+			"fields":           []string{"source.ip"},
+			"feeds":            []map[string]interface{}{{"name": "a", "type": "ip", "url": "http://127.0.0.1:1/feed"}},
+			"refresh_interval": 0,
+		})
+		assert.Equal(t, 0, p.indicators.Load().size())
+	})
+}
+
+func hasKey(event *beat.Event, key string) bool {
+	ok, _ := event.Fields.HasKey(key)
+	return ok
+}
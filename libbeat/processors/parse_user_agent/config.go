@@ -0,0 +1,59 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package parse_user_agent
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/transport/httpcommon"
+)
+
+type config struct {
+	Field  string `config:"field" validate:"required"`
+	Target string `config:"target_field"`
+	// RegexesFile and RegexesURL locate a uap-core regexes.yaml file used
+	// instead of the bundled regexes.
+	RegexesFile    string        `config:"regexes_file"`
+	RegexesURL     string        `config:"regexes_url"`
+	ReloadInterval time.Duration `config:"reload_interval" validate:"min=0"`
+	CacheSize      int           `config:"cache_size" validate:"min=0"`
+	IgnoreMissing  bool          `config:"ignore_missing"`
+	IgnoreFailure  bool          `config:"ignore_failure"`
+
+	Transport httpcommon.HTTPTransportSettings `config:",inline"`
+}
+
+func defaultConfig() config {
+	transport := httpcommon.DefaultHTTPTransportSettings()
+	transport.Timeout = 30 * time.Second
+	return config{
+		Field:          "user_agent.original",
+		Target:         "user_agent",
+		ReloadInterval: time.Hour,
+		CacheSize:      1000,
+		Transport:      transport,
+	}
+}
+
+func (c *config) Validate() error {
+	if c.RegexesFile != "" && c.RegexesURL != "" {
+		return fmt.Errorf("only one of regexes_file and regexes_url can be set")
+	}
+	return nil
+}
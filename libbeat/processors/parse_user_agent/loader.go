@@ -0,0 +1,62 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package parse_user_agent
+
+import (
+	"net/http"
+
+	"github.com/elastic/beats/v7/libbeat/common/remotefile"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/transport/httpcommon"
+)
+
+// maxRegexesSize limits the size of a downloaded regexes file.
+const maxRegexesSize = 32 << 20
+
+// loader loads the regexes from a file or an URL, only returning them when
+// they changed since the previous load.
+type loader struct {
+	remote *remotefile.Loader
+	client *http.Client
+	log    *logp.Logger
+}
+
+func newLoader(c config, log *logp.Logger) (*loader, error) {
+	l := &loader{log: log}
+	if c.RegexesURL != "" {
+		client, err := c.Transport.Client(httpcommon.WithLogger(log))
+		if err != nil {
+			return nil, err
+		}
+		l.client = client
+	}
+	l.remote = remotefile.New(c.RegexesFile, c.RegexesURL, l.client, maxRegexesSize)
+	return l, nil
+}
+
+// load returns the content of the regexes file, or nil if it didn't change
+// since the previous call.
+func (l *loader) load() ([]byte, error) {
+	return l.remote.Load()
+}
+
+func (l *loader) close() {
+	if l.client != nil {
+		l.client.CloseIdleConnections()
+	}
+}
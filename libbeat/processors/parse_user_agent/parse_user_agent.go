@@ -0,0 +1,195 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package parse_user_agent
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/processors"
+	"github.com/elastic/beats/v7/libbeat/processors/checks"
+	jsprocessor "github.com/elastic/beats/v7/libbeat/processors/script/javascript/module/processor"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+const (
+	procName = "parse_user_agent"
+	logName  = "processor." + procName
+)
+
+var errFieldIsNotString = errors.New("field value is not a string")
+
+func init() {
+	processors.RegisterPlugin(procName,
+		checks.ConfigChecked(New,
+			checks.AllowedFields(
+				"field", "target_field",
+				"regexes_file", "regexes_url", "reload_interval",
+				"timeout", "ssl", "proxy_url", "proxy_headers", "proxy_disable",
+				"cache_size",
+				"ignore_missing", "ignore_failure", "when",
+			)))
+	jsprocessor.RegisterPlugin("ParseUserAgent", New)
+}
+
+type parseUserAgent struct {
+	config
+
+	parser atomic.Pointer[parser]
+	loader *loader
+	log    *logp.Logger
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// New constructs a new parse_user_agent processor.
+func New(c *conf.C) (beat.Processor, error) {
+	config := defaultConfig()
+
+	if err := c.Unpack(&config); err != nil {
+		return nil, fmt.Errorf("fail to unpack the "+procName+" processor configuration: %w", err)
+	}
+
+	return newParseUserAgent(config)
+}
+
+func newParseUserAgent(c config) (*parseUserAgent, error) {
+	p := &parseUserAgent{
+		config: c,
+		log:    logp.NewLogger(logName),
+		done:   make(chan struct{}),
+	}
+
+	defaults, _, err := newParser(defaultRegexes, c.CacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the default regexes: %w", err)
+	}
+	p.parser.Store(defaults)
+
+	if c.RegexesFile == "" && c.RegexesURL == "" {
+		return p, nil
+	}
+
+	if p.loader, err = newLoader(c, p.log); err != nil {
+		return nil, fmt.Errorf("failed to configure the "+procName+" processor: %w", err)
+	}
+	if err := p.reload(); err != nil {
+		if c.RegexesFile != "" {
+			return nil, fmt.Errorf("failed to load the "+procName+" processor regexes: %w", err)
+		}
+		// The bundled regexes are used until the URL can be reached.
+		p.log.Warnf("Failed to fetch regexes from %s, using the default regexes: %v", c.RegexesURL, err)
+	}
+	if c.ReloadInterval > 0 {
+		go p.run()
+	}
+	return p, nil
+}
+
+func (p *parseUserAgent) run() {
+	ticker := time.NewTicker(p.ReloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			if err := p.reload(); err != nil {
+				p.log.Warnf("Failed to reload regexes, keeping the current ones: %v", err)
+			}
+		}
+	}
+}
+
+// reload replaces the parser if the regexes changed.
+func (p *parseUserAgent) reload() error {
+	data, err := p.loader.load()
+	if err != nil || data == nil {
+		return err
+	}
+	parser, skipped, err := newParser(data, p.CacheSize)
+	if err != nil {
+		return err
+	}
+	if skipped > 0 {
+		p.log.Debugf("Skipped %d regexes that are not supported by the Go regexp syntax", skipped)
+	}
+	p.parser.Store(parser)
+	p.log.Infof("Loaded %d user agent, %d OS and %d device regexes",
+		len(parser.userAgents), len(parser.os), len(parser.devices))
+	return nil
+}
+
+func (p *parseUserAgent) Run(event *beat.Event) (*beat.Event, error) {
+	if err := p.parse(event); err != nil && !p.IgnoreFailure {
+		err = fmt.Errorf("failed in parse_user_agent on the %q field: %w", p.Field, err)
+		_, _ = event.PutValue("error.message", err.Error())
+		return event, err
+	}
+	return event, nil
+}
+
+func (p *parseUserAgent) parse(event *beat.Event) error {
+	v, err := event.GetValue(p.Field)
+	if err != nil {
+		if p.IgnoreMissing && errors.Is(err, mapstr.ErrKeyNotFound) {
+			return nil
+		}
+		return err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return errFieldIsNotString
+	}
+
+	fields := p.parser.Load().parse(s)
+	for k, v := range fields {
+		key := k
+		if p.Target != "" {
+			key = p.Target + "." + k
+		}
+		if m, ok := v.(mapstr.M); ok {
+			v = m.Clone()
+		}
+		if _, err := event.PutValue(key, v); err != nil {
+			return fmt.Errorf("failed to put %q: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func (p *parseUserAgent) Close() error {
+	p.closeOnce.Do(func() {
+		close(p.done)
+		if p.loader != nil {
+			p.loader.close()
+		}
+	})
+	return nil
+}
+
+func (p *parseUserAgent) String() string {
+	return fmt.Sprintf("%s=[field=%s, target_field=%s]", procName, p.Field, p.Target)
+}
@@ -0,0 +1,287 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package parse_user_agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func TestDefaultRegexes(t *testing.T) {
+	p, err := newParseUserAgent(defaultConfig())
+	require.NoError(t, err)
+	defer p.Close()
+
+	testCases := []struct {
+		userAgent string
+		expected  mapstr.M
+	}{
+		{
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/114.0.5735.110 Safari/537.36",
+			expected: mapstr.M{
+				"name":    "Chrome",
+				"version": "114.0.5735",
+				"os":      mapstr.M{"name": "Windows", "version": "10", "full": "Windows 10"},
+				"device":  mapstr.M{"name": "Other"},
+			},
+		},
+		{
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/114.0.0.0 Safari/537.36 Edg/114.0.1823.43",
+			expected: mapstr.M{
+				"name":    "Edge",
+				"version": "114.0.1823",
+				"os":      mapstr.M{"name": "Windows", "version": "10", "full": "Windows 10"},
+				"device":  mapstr.M{"name": "Other"},
+			},
+		},
+		{
+			userAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.5 Safari/605.1.15",
+			expected: mapstr.M{
+				"name":    "Safari",
+				"version": "16.5",
+				"os":      mapstr.M{"name": "Mac OS X", "version": "10.15.7", "full": "Mac OS X 10.15.7"},
+				"device":  mapstr.M{"name": "Mac"},
+			},
+		},
+		{
+			userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 16_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.5 Mobile/15E148 Safari/604.1",
+			expected: mapstr.M{
+				"name":    "Mobile Safari",
+				"version": "16.5",
+				"os":      mapstr.M{"name": "iOS", "version": "16.5", "full": "iOS 16.5"},
+				"device":  mapstr.M{"name": "iPhone"},
+			},
+		},
+		{
+			userAgent: "Mozilla/5.0 (Linux; Android 13; Pixel 7 Build/TQ3A.230605.012) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/114.0.5735.131 Mobile Safari/537.36",
+			expected: mapstr.M{
+				"name":    "Chrome Mobile",
+				"version": "114.0.5735",
+				"os":      mapstr.M{"name": "Android", "version": "13", "full": "Android 13"},
+				"device":  mapstr.M{"name": "Pixel 7"},
+			},
+		},
+		{
+			userAgent: "Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:109.0) Gecko/20100101 Firefox/114.0",
+			expected: mapstr.M{
+				"name":    "Firefox",
+				"version": "114.0",
+				"os":      mapstr.M{"name": "Ubuntu"},
+				"device":  mapstr.M{"name": "Other"},
+			},
+		},
+		{
+			userAgent: "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			expected: mapstr.M{
+				"name":    "Googlebot",
+				"version": "2.1",
+				"os":      mapstr.M{"name": "Other"},
+				"device":  mapstr.M{"name": "Spider"},
+			},
+		},
+		{
+			userAgent: "curl/8.1.2",
+			expected: mapstr.M{
+				"name":    "curl",
+				"version": "8.1.2",
+				"os":      mapstr.M{"name": "Other"},
+				"device":  mapstr.M{"name": "Other"},
+			},
+		},
+		{
+			userAgent: "unknown",
+			expected: mapstr.M{
+				"name":   "Other",
+				"os":     mapstr.M{"name": "Other"},
+				"device": mapstr.M{"name": "Other"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.userAgent, func(t *testing.T) {
+			// Run twice to also use the cached result.
+			for i := 0; i < 2; i++ {
+				event, err := p.Run(&beat.Event{Fields: mapstr.M{"user_agent": mapstr.M{"original": tc.userAgent}}})
+				require.NoError(t, err)
+				tc.expected["original"] = tc.userAgent
+				assert.Equal(t, tc.expected, event.Fields["user_agent"])
+			}
+		})
+	}
+}
+
+const customRegexes = `
+user_agent_parsers:
+  - regex: '(Beats)/(\d+)\.(\d+)'
+    family_replacement: 'Elastic $1'
+  - regex: '(?<=x)(Lookbehind)'
+os_parsers:
+  - regex: '\((\w+)\)'
+    os_v1_replacement: '1'
+device_parsers:
+  - regex: 'beats'
+    regex_flag: 'i'
+    device_replacement: 'Beat'
+`
+
+func TestRegexesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "regexes.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(customRegexes), 0o600))
+
+	c := defaultConfig()
+	c.Field = "message"
+	c.Target = ""
+	c.RegexesFile = path
+	c.ReloadInterval = 0
+	p, err := newParseUserAgent(c)
+	require.NoError(t, err)
+	defer p.Close()
+
+	event, err := p.Run(&beat.Event{Fields: mapstr.M{"message": "Beats/8.9 (Plan9)"}})
+	require.NoError(t, err)
+	assert.Equal(t, mapstr.M{
+		"message":  "Beats/8.9 (Plan9)",
+		"original": "Beats/8.9 (Plan9)",
+		"name":     "Elastic Beats",
+		"version":  "8.9",
+		"os":       mapstr.M{"name": "Plan9", "version": "1", "full": "Plan9 1"},
+		"device":   mapstr.M{"name": "Beat"},
+	}, event.Fields)
+
+	// Reloading an unchanged file keeps the parser.
+	parser := p.parser.Load()
+	require.NoError(t, p.reload())
+	assert.Same(t, parser, p.parser.Load())
+
+	// An updated file replaces it.
+	updated := []byte("user_agent_parsers:\n  - regex: '(Beats)/(\\d+)'\n    family_replacement: 'Agent'\n")
+	require.NoError(t, os.WriteFile(path, updated, 0o600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+	require.NoError(t, p.reload())
+	event, err = p.Run(&beat.Event{Fields: mapstr.M{"message": "Beats/8.9 (Plan9)"}})
+	require.NoError(t, err)
+	assert.Equal(t, "Agent", event.Fields["name"])
+	assert.Equal(t, "8", event.Fields["version"])
+
+	// An invalid file keeps the current parser.
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(2*time.Minute)))
+	assert.Error(t, p.reload())
+	event, err = p.Run(&beat.Event{Fields: mapstr.M{"message": "Beats/8.9 (Plan9)"}})
+	require.NoError(t, err)
+	assert.Equal(t, "Agent", event.Fields["name"])
+
+	t.Run("missing file", func(t *testing.T) {
+		c := c
+		c.RegexesFile = filepath.Join(t.TempDir(), "missing.yaml")
+		_, err := newParseUserAgent(c)
+		assert.Error(t, err)
+	})
+}
+
+func TestRegexesURL(t *testing.T) {
+	var requests, served atomic.Int32
+	var body atomic.Value
+	body.Store(customRegexes)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		etag := strconv.Quote(strconv.Itoa(len(body.Load().(string))))
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		served.Add(1)
+		w.Header().Set("ETag", etag)
+		_, _ = w.Write([]byte(body.Load().(string)))
+	}))
+	defer srv.Close()
+
+	p, err := New(conf.MustNewConfigFrom(map[string]interface{}{
+		"field":           "message",
+		"target_field":    "ua",
+		"regexes_url":     srv.URL,
+		"reload_interval": "10ms",
+	}))
+	require.NoError(t, err)
+	defer p.(*parseUserAgent).Close()
+
+	name := func() interface{} {
+		event, err := p.Run(&beat.Event{Fields: mapstr.M{"message": "Beats/8.9"}})
+		require.NoError(t, err)
+		v, _ := event.GetValue("ua.name")
+		return v
+	}
+	assert.Equal(t, "Elastic Beats", name())
+
+	require.Eventually(t, func() bool { return requests.Load() > 3 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(1), served.Load(), "unchanged regexes are not downloaded again")
+
+	body.Store("user_agent_parsers:\n  - regex: '(Beats)/(\\d+)'\n    family_replacement: 'Reloaded'\n")
+	require.Eventually(t, func() bool { return name() == "Reloaded" }, 5*time.Second, 10*time.Millisecond)
+
+	t.Run("unreachable URL uses the default regexes", func(t *testing.T) {
+		p, err := New(conf.MustNewConfigFrom(map[string]interface{}{
+			"regexes_url":     "http://127.0.0.1:1/regexes.yaml",
+			"reload_interval": 0,
+		}))
+		require.NoError(t, err)
+		defer p.(*parseUserAgent).Close()
+		event, err := p.Run(&beat.Event{Fields: mapstr.M{"user_agent.original": "curl/8.1.2"}})
+		require.NoError(t, err)
+		v, _ := event.GetValue("user_agent.name")
+		assert.Equal(t, "curl", v)
+	})
+}
+
+func TestParseUserAgentErrors(t *testing.T) {
+	c := defaultConfig()
+	p, err := newParseUserAgent(c)
+	require.NoError(t, err)
+
+	_, err = p.Run(&beat.Event{Fields: mapstr.M{}})
+	assert.Error(t, err)
+
+	_, err = p.Run(&beat.Event{Fields: mapstr.M{"user_agent": mapstr.M{"original": 1}}})
+	assert.ErrorIs(t, err, errFieldIsNotString)
+
+	c.IgnoreMissing = true
+	p, err = newParseUserAgent(c)
+	require.NoError(t, err)
+	_, err = p.Run(&beat.Event{Fields: mapstr.M{}})
+	assert.NoError(t, err)
+
+	_, err = New(conf.MustNewConfigFrom(map[string]interface{}{
+		"regexes_file": "regexes.yaml",
+		"regexes_url":  "http://localhost/regexes.yaml",
+	}))
+	assert.Error(t, err)
+}
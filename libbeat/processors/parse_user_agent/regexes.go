@@ -0,0 +1,226 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package parse_user_agent
+
+import (
+	_ "embed"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+//go:embed regexes.yml
+var defaultRegexes []byte
+
+// regexesFile is the format of the regexes.yaml file of uap-core.
+type regexesFile struct {
+	UserAgentParsers []regexDef `yaml:"user_agent_parsers"`
+	OSParsers        []regexDef `yaml:"os_parsers"`
+	DeviceParsers    []regexDef `yaml:"device_parsers"`
+}
+
+type regexDef struct {
+	Regex     string `yaml:"regex"`
+	RegexFlag string `yaml:"regex_flag"`
+
+	FamilyReplacement string `yaml:"family_replacement"`
+	V1Replacement     string `yaml:"v1_replacement"`
+	V2Replacement     string `yaml:"v2_replacement"`
+	V3Replacement     string `yaml:"v3_replacement"`
+
+	OSReplacement   string `yaml:"os_replacement"`
+	OSV1Replacement string `yaml:"os_v1_replacement"`
+	OSV2Replacement string `yaml:"os_v2_replacement"`
+	OSV3Replacement string `yaml:"os_v3_replacement"`
+	OSV4Replacement string `yaml:"os_v4_replacement"`
+
+	DeviceReplacement string `yaml:"device_replacement"`
+	BrandReplacement  string `yaml:"brand_replacement"`
+	ModelReplacement  string `yaml:"model_replacement"`
+}
+
+// matcher is a compiled regex with the replacements of its name and
+// versions, either $n references to the groups of the regex or fixed
+// values.
+type matcher struct {
+	re *regexp.Regexp
+	// name and versions are the replacements, empty replacements default
+	// to the groups of the regex starting at defaultGroup.
+	name     string
+	versions []string
+}
+
+type result struct {
+	name    string
+	version string
+}
+
+func (m *matcher) match(s string, defaultGroup int) (result, bool) {
+	groups := m.re.FindStringSubmatchIndex(s)
+	if groups == nil {
+		return result{}, false
+	}
+	group := func(i int) string {
+		if 2*i+1 >= len(groups) || groups[2*i] < 0 {
+			return ""
+		}
+		return s[groups[2*i]:groups[2*i+1]]
+	}
+	expand := func(replacement string, i int) string {
+		if replacement == "" {
+			return group(i)
+		}
+		if !strings.Contains(replacement, "$") {
+			return replacement
+		}
+		return strings.TrimSpace(string(m.re.ExpandString(nil, replacement, s, groups)))
+	}
+
+	r := result{name: expand(m.name, 1)}
+	var parts []string
+	for i, v := range m.versions {
+		part := expand(v, defaultGroup+i)
+		if part == "" {
+			break
+		}
+		parts = append(parts, part)
+	}
+	r.version = strings.Join(parts, ".")
+	return r, true
+}
+
+// parser parses user agent strings with the regexes of a uap-core file.
+type parser struct {
+	userAgents []*matcher
+	os         []*matcher
+	devices    []*matcher
+
+	cacheSize int
+	mu        sync.Mutex
+	cache     map[string]mapstr.M
+}
+
+// newParser compiles the regexes in data. Regexes that are not supported
+// by the Go regexp package are skipped, their number is returned.
+func newParser(data []byte, cacheSize int) (*parser, int, error) {
+	var f regexesFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse regexes: %w", err)
+	}
+	if len(f.UserAgentParsers) == 0 && len(f.OSParsers) == 0 && len(f.DeviceParsers) == 0 {
+		return nil, 0, fmt.Errorf("no regexes found")
+	}
+
+	p := &parser{cacheSize: cacheSize, cache: map[string]mapstr.M{}}
+	var skipped int
+	compile := func(defs []regexDef, name func(regexDef) string, versions func(regexDef) []string) []*matcher {
+		var matchers []*matcher
+		for _, def := range defs {
+			expr := def.Regex
+			if def.RegexFlag == "i" {
+				expr = "(?i)" + expr
+			}
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				skipped++
+				continue
+			}
+			matchers = append(matchers, &matcher{re: re, name: name(def), versions: versions(def)})
+		}
+		return matchers
+	}
+
+	p.userAgents = compile(f.UserAgentParsers,
+		func(d regexDef) string { return d.FamilyReplacement },
+		func(d regexDef) []string { return []string{d.V1Replacement, d.V2Replacement, d.V3Replacement} })
+	p.os = compile(f.OSParsers,
+		func(d regexDef) string { return d.OSReplacement },
+		func(d regexDef) []string {
+			return []string{d.OSV1Replacement, d.OSV2Replacement, d.OSV3Replacement, d.OSV4Replacement}
+		})
+	p.devices = compile(f.DeviceParsers,
+		func(d regexDef) string { return d.DeviceReplacement },
+		func(d regexDef) []string { return nil })
+	return p, skipped, nil
+}
+
+// parse returns the user_agent fields of s. The returned map must not be
+// modified.
+func (p *parser) parse(s string) mapstr.M {
+	p.mu.Lock()
+	fields, ok := p.cache[s]
+	p.mu.Unlock()
+	if ok {
+		return fields
+	}
+
+	fields = mapstr.M{"original": s}
+	fields["name"] = "Other"
+	for _, m := range p.userAgents {
+		if r, ok := m.match(s, 2); ok {
+			if r.name != "" {
+				fields["name"] = r.name
+			}
+			if r.version != "" {
+				fields["version"] = r.version
+			}
+			break
+		}
+	}
+
+	os := mapstr.M{"name": "Other"}
+	for _, m := range p.os {
+		if r, ok := m.match(s, 2); ok {
+			if r.name != "" {
+				os["name"] = r.name
+			}
+			if r.version != "" {
+				os["version"] = r.version
+				os["full"] = fmt.Sprintf("%s %s", os["name"], r.version)
+			}
+			break
+		}
+	}
+	fields["os"] = os
+
+	device := mapstr.M{"name": "Other"}
+	for _, m := range p.devices {
+		if r, ok := m.match(s, 1); ok {
+			if r.name != "" {
+				device["name"] = r.name
+			}
+			break
+		}
+	}
+	fields["device"] = device
+
+	if p.cacheSize > 0 {
+		p.mu.Lock()
+		if len(p.cache) >= p.cacheSize {
+			p.cache = map[string]mapstr.M{}
+		}
+		p.cache[s] = fields
+		p.mu.Unlock()
+	}
+	return fields
+}
@@ -164,6 +164,9 @@ func (procs *Processors) Close() error {
 func (procs *Processors) Run(event *beat.Event) (*beat.Event, error) {
 	var err error
 	for _, p := range procs.List {
+		if event.Flat != nil && !beat.SupportsFlatFields(p) {
+			event.Materialize()
+		}
 		event, err = p.Run(event)
 		if err != nil {
 			return event, fmt.Errorf("failed applying processor %v: %w", p, err)
@@ -176,6 +179,10 @@ func (procs *Processors) Run(event *beat.Event) (*beat.Event, error) {
 	return event, nil
 }
 
+// SupportsFlatFields returns true, the fields of the event are materialized
+// for the processors of the list that need it.
+func (procs *Processors) SupportsFlatFields() bool { return true }
+
 func (procs Processors) String() string {
 	var s []string
 	for _, p := range procs.List {
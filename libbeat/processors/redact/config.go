@@ -0,0 +1,60 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package redact
+
+import (
+	"errors"
+	"time"
+)
+
+// config for the redact processor.
+type config struct {
+	// PolicyFile is the path of the central redaction policy.
+	PolicyFile string       `config:"policy_file"`
+	Reload     reloadConfig `config:"reload"`
+	// Policy is applied on top of the policy file, so that each input can
+	// override it.
+	Policy policyConfig `config:"policy"`
+	// Disable lists patterns and dictionaries of the policy that are not
+	// applied.
+	Disable []string `config:"disable"`
+	// Fields limits the fields that patterns and dictionaries are applied
+	// to. All string fields are scanned when it is empty.
+	Fields []string `config:"fields"`
+}
+
+type reloadConfig struct {
+	Enabled bool          `config:"enabled"`
+	Period  time.Duration `config:"period" validate:"positive,nonzero"`
+}
+
+func (c *config) Validate() error {
+	if c.PolicyFile == "" && len(c.Policy.Patterns) == 0 && len(c.Policy.Dictionaries) == 0 && len(c.Policy.Fields.Deny) == 0 {
+		return errors.New("either policy_file or policy must be set")
+	}
+	return nil
+}
+
+func defaultConfig() config {
+	return config{
+		Reload: reloadConfig{
+			Enabled: true,
+			Period:  10 * time.Second,
+		},
+	}
+}
@@ -0,0 +1,259 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package redact
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	conf "github.com/elastic/elastic-agent-libs/config"
+)
+
+const defaultReplacement = "[REDACTED]"
+
+// Field actions for the denied fields.
+const (
+	actionMask   = "mask"
+	actionRemove = "remove"
+)
+
+// policyConfig is a redaction policy, as found in a policy file or inline in
+// the processor configuration.
+type policyConfig struct {
+	Patterns     []patternConfig    `config:"patterns"`
+	Dictionaries []dictionaryConfig `config:"dictionaries"`
+	Fields       fieldsConfig       `config:"fields"`
+	// Replacement is the default replacement of the redacted values.
+	Replacement string `config:"replacement"`
+}
+
+// patternConfig redacts the matches of a regular expression.
+type patternConfig struct {
+	Name        string `config:"name" validate:"required"`
+	Regex       string `config:"regex" validate:"required"`
+	Replacement string `config:"replacement"`
+}
+
+// dictionaryConfig redacts the occurrences of a list of words.
+type dictionaryConfig struct {
+	Name            string   `config:"name" validate:"required"`
+	Words           []string `config:"words"`
+	File            string   `config:"file"`
+	CaseInsensitive bool     `config:"case_insensitive"`
+	Replacement     string   `config:"replacement"`
+}
+
+// fieldsConfig holds the field allow and deny lists.
+type fieldsConfig struct {
+	// Allow lists the fields that are never redacted by patterns or
+	// dictionaries.
+	Allow []string `config:"allow"`
+	// Deny lists the fields whose value is always masked or removed.
+	Deny       []string `config:"deny"`
+	DenyAction string   `config:"deny_action"`
+}
+
+func (c *fieldsConfig) Validate() error {
+	switch c.DenyAction {
+	case "", actionMask, actionRemove:
+		return nil
+	default:
+		return fmt.Errorf("invalid deny_action %q, must be %q or %q", c.DenyAction, actionMask, actionRemove)
+	}
+}
+
+// merge returns the policy obtained by applying the override on top of p.
+// Patterns and dictionaries with the same name are replaced, others and
+// field lists are added.
+func (p policyConfig) merge(override policyConfig) policyConfig {
+	out := policyConfig{
+		Replacement: p.Replacement,
+		Fields: fieldsConfig{
+			Allow:      append(append([]string(nil), p.Fields.Allow...), override.Fields.Allow...),
+			Deny:       append(append([]string(nil), p.Fields.Deny...), override.Fields.Deny...),
+			DenyAction: p.Fields.DenyAction,
+		},
+	}
+	if override.Replacement != "" {
+		out.Replacement = override.Replacement
+	}
+	if override.Fields.DenyAction != "" {
+		out.Fields.DenyAction = override.Fields.DenyAction
+	}
+
+	patterns := make(map[string]bool)
+	for _, pat := range override.Patterns {
+		patterns[pat.Name] = true
+	}
+	for _, pat := range p.Patterns {
+		if !patterns[pat.Name] {
+			out.Patterns = append(out.Patterns, pat)
+		}
+	}
+	out.Patterns = append(out.Patterns, override.Patterns...)
+
+	dicts := make(map[string]bool)
+	for _, d := range override.Dictionaries {
+		dicts[d.Name] = true
+	}
+	for _, d := range p.Dictionaries {
+		if !dicts[d.Name] {
+			out.Dictionaries = append(out.Dictionaries, d)
+		}
+	}
+	out.Dictionaries = append(out.Dictionaries, override.Dictionaries...)
+
+	return out
+}
+
+// loadPolicyFile reads a policy from a YAML file.
+func loadPolicyFile(path string) (policyConfig, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return policyConfig{}, err
+	}
+	cfg, err := conf.NewConfigWithYAML(b, path)
+	if err != nil {
+		return policyConfig{}, fmt.Errorf("failed to parse policy file %v: %w", path, err)
+	}
+	var p policyConfig
+	err = cfg.Unpack(&p)
+	if err != nil {
+		return policyConfig{}, fmt.Errorf("failed to unpack policy file %v: %w", path, err)
+	}
+	return p, nil
+}
+
+// rule is a compiled pattern or dictionary.
+type rule struct {
+	name        string
+	re          *regexp.Regexp
+	replacement string
+}
+
+// policy is a compiled redaction policy.
+type policy struct {
+	rules      []rule
+	allow      map[string]bool
+	deny       []string
+	denyAction string
+	mask       string
+}
+
+// compile compiles the policy, skipping the patterns and dictionaries
+// whose name is in disabled.
+func (p policyConfig) compile(disabled []string) (*policy, error) {
+	skip := make(map[string]bool, len(disabled))
+	for _, name := range disabled {
+		skip[name] = true
+	}
+
+	out := &policy{
+		allow:      make(map[string]bool, len(p.Fields.Allow)),
+		deny:       p.Fields.Deny,
+		denyAction: p.Fields.DenyAction,
+		mask:       p.Replacement,
+	}
+	if out.mask == "" {
+		out.mask = defaultReplacement
+	}
+	if out.denyAction == "" {
+		out.denyAction = actionMask
+	}
+	for _, f := range p.Fields.Allow {
+		out.allow[f] = true
+	}
+
+	replacement := func(r string) string {
+		if r == "" {
+			return out.mask
+		}
+		return r
+	}
+
+	for _, pat := range p.Patterns {
+		if skip[pat.Name] {
+			continue
+		}
+		re, err := regexp.Compile(pat.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex of pattern %v: %w", pat.Name, err)
+		}
+		out.rules = append(out.rules, rule{name: pat.Name, re: re, replacement: replacement(pat.Replacement)})
+	}
+
+	for _, d := range p.Dictionaries {
+		if skip[d.Name] {
+			continue
+		}
+		words := d.Words
+		if d.File != "" {
+			fileWords, err := readWords(d.File)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read words of dictionary %v: %w", d.Name, err)
+			}
+			words = append(append([]string(nil), words...), fileWords...)
+		}
+		if len(words) == 0 {
+			return nil, fmt.Errorf("dictionary %v has no words", d.Name)
+		}
+		quoted := make([]string, len(words))
+		for i, w := range words {
+			quoted[i] = regexp.QuoteMeta(w)
+		}
+		expr := `\b(?:` + strings.Join(quoted, "|") + `)\b`
+		if d.CaseInsensitive {
+			expr = "(?i)" + expr
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dictionary %v: %w", d.Name, err)
+		}
+		out.rules = append(out.rules, rule{name: d.Name, re: re, replacement: replacement(d.Replacement)})
+	}
+
+	if len(out.rules) == 0 && len(out.deny) == 0 {
+		return nil, errors.New("the redaction policy is empty")
+	}
+	return out, nil
+}
+
+// readWords reads a file with a word per line. Empty lines and lines
+// starting with # are ignored.
+func readWords(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var words []string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		w := strings.TrimSpace(s.Text())
+		if w == "" || strings.HasPrefix(w, "#") {
+			continue
+		}
+		words = append(words, w)
+	}
+	return words, s.Err()
+}
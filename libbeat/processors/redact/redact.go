@@ -0,0 +1,310 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package redact
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	catomic "github.com/elastic/beats/v7/libbeat/common/atomic"
+	"github.com/elastic/beats/v7/libbeat/processors"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+// instanceID is used to assign each instance a unique monitoring namespace.
+var instanceID = catomic.MakeUint32(0)
+
+const (
+	processorName = "redact"
+	logName       = "processor." + processorName
+)
+
+func init() {
+	processors.RegisterPlugin(processorName, New)
+}
+
+type metrics struct {
+	EventsRedacted *monitoring.Int // Events with at least one redaction.
+	Redactions     *monitoring.Int // Matches of patterns and dictionaries.
+	FieldsMasked   *monitoring.Int
+	FieldsRemoved  *monitoring.Int
+	Reloads        *monitoring.Int
+	ReloadErrors   *monitoring.Int
+}
+
+type redact struct {
+	config config
+	policy atomic.Pointer[policy]
+
+	// reloadMu guards the policy file state. Events are not held back
+	// while the policy is reloaded.
+	reloadMu  sync.Mutex
+	nextCheck time.Time
+	modTime   time.Time
+	size      int64
+	now       func() time.Time
+
+	logger  *logp.Logger
+	metrics metrics
+}
+
+// New constructs a new redact processor.
+func New(cfg *conf.C) (beat.Processor, error) {
+	config := defaultConfig()
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, fmt.Errorf("could not unpack %v processor configuration: %w", processorName, err)
+	}
+
+	// Logging and metrics (each processor instance has a unique ID).
+	var (
+		id  = int(instanceID.Inc())
+		reg = monitoring.Default.NewRegistry(logName+"."+strconv.Itoa(id), monitoring.DoNotReport)
+	)
+	p := &redact{
+		config: config,
+		now:    time.Now,
+		logger: logp.NewLogger(logName).With("instance_id", id),
+		metrics: metrics{
+			EventsRedacted: monitoring.NewInt(reg, "events_redacted"),
+			Redactions:     monitoring.NewInt(reg, "redactions"),
+			FieldsMasked:   monitoring.NewInt(reg, "fields_masked"),
+			FieldsRemoved:  monitoring.NewInt(reg, "fields_removed"),
+			Reloads:        monitoring.NewInt(reg, "policy_reloads"),
+			ReloadErrors:   monitoring.NewInt(reg, "policy_reload_errors"),
+		},
+	}
+
+	var (
+		pol *policy
+		err error
+	)
+	if config.PolicyFile == "" {
+		pol, err = config.Policy.compile(config.Disable)
+	} else {
+		var info os.FileInfo
+		info, err = os.Stat(config.PolicyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read policy file: %w", err)
+		}
+		pol, err = p.loadPolicy()
+		p.modTime, p.size = info.ModTime(), info.Size()
+		p.nextCheck = p.now().Add(config.Reload.Period)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load redaction policy: %w", err)
+	}
+	p.policy.Store(pol)
+
+	return p, nil
+}
+
+// loadPolicy loads the policy file and applies the inline policy on top of it.
+func (p *redact) loadPolicy() (*policy, error) {
+	filePolicy, err := loadPolicyFile(p.config.PolicyFile)
+	if err != nil {
+		return nil, err
+	}
+	return filePolicy.merge(p.config.Policy).compile(p.config.Disable)
+}
+
+// maybeReload reloads the policy file if it changed since it was last
+// loaded. Invalid policies are reported and the previous one is kept.
+func (p *redact) maybeReload() {
+	if p.config.PolicyFile == "" || !p.config.Reload.Enabled {
+		return
+	}
+	if !p.reloadMu.TryLock() {
+		// Another event is checking the file.
+		return
+	}
+	defer p.reloadMu.Unlock()
+
+	now := p.now()
+	if now.Before(p.nextCheck) {
+		return
+	}
+	p.nextCheck = now.Add(p.config.Reload.Period)
+
+	info, err := os.Stat(p.config.PolicyFile)
+	if err != nil {
+		p.logger.Warnf("Failed to check redaction policy file: %v", err)
+		return
+	}
+	if info.ModTime().Equal(p.modTime) && info.Size() == p.size {
+		return
+	}
+	p.modTime, p.size = info.ModTime(), info.Size()
+
+	pol, err := p.loadPolicy()
+	if err != nil {
+		p.metrics.ReloadErrors.Inc()
+		p.logger.Errorf("Failed to reload redaction policy, keeping the previous one: %v", err)
+		return
+	}
+	p.policy.Store(pol)
+	p.metrics.Reloads.Inc()
+	p.logger.Infof("Reloaded redaction policy from %v", p.config.PolicyFile)
+}
+
+// Run redacts the event according to the policy.
+func (p *redact) Run(event *beat.Event) (*beat.Event, error) {
+	p.maybeReload()
+	pol := p.policy.Load()
+
+	var masked, removed, redactions int
+	for _, field := range pol.deny {
+		if _, err := event.GetValue(field); err != nil {
+			continue
+		}
+		switch pol.denyAction {
+		case actionRemove:
+			if err := event.Delete(field); err == nil {
+				removed++
+			}
+		default:
+			if _, err := event.PutValue(field, pol.mask); err == nil {
+				masked++
+			}
+		}
+	}
+
+	if len(pol.rules) != 0 {
+		if len(p.config.Fields) == 0 {
+			redactions = pol.redactMap(event.Fields, "")
+		} else {
+			for _, field := range p.config.Fields {
+				if pol.allow[field] {
+					continue
+				}
+				value, err := event.GetValue(field)
+				if err != nil {
+					continue
+				}
+				redacted, n := pol.redactValue(value, field)
+				if n > 0 {
+					if _, err := event.PutValue(field, redacted); err != nil {
+						return event, fmt.Errorf("failed to update field %v: %w", field, err)
+					}
+					redactions += n
+				}
+			}
+		}
+	}
+
+	if masked+removed+redactions > 0 {
+		p.metrics.EventsRedacted.Inc()
+		p.metrics.FieldsMasked.Add(int64(masked))
+		p.metrics.FieldsRemoved.Add(int64(removed))
+		p.metrics.Redactions.Add(int64(redactions))
+	}
+	return event, nil
+}
+
+// redactMap applies the rules to every string in m, except the allowed
+// fields, and returns the number of redactions.
+func (pol *policy) redactMap(m mapstr.M, prefix string) int {
+	var count int
+	for k, v := range m {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		if pol.allow[path] {
+			continue
+		}
+		redacted, n := pol.redactValue(v, path)
+		if n > 0 {
+			m[k] = redacted
+			count += n
+		}
+	}
+	return count
+}
+
+// redactValue returns the value with the rules applied and the number of
+// redactions. Maps are redacted in place.
+func (pol *policy) redactValue(v interface{}, path string) (interface{}, int) {
+	switch v := v.(type) {
+	case string:
+		s, n := pol.redactString(v)
+		return s, n
+	case []string:
+		var count int
+		var out []string
+		for i, s := range v {
+			r, n := pol.redactString(s)
+			if n == 0 {
+				continue
+			}
+			if out == nil {
+				out = append([]string(nil), v...)
+			}
+			out[i] = r
+			count += n
+		}
+		if out == nil {
+			return v, 0
+		}
+		return out, count
+	case []interface{}:
+		var count int
+		for i, e := range v {
+			r, n := pol.redactValue(e, path)
+			if n > 0 {
+				v[i] = r
+				count += n
+			}
+		}
+		return v, count
+	case mapstr.M:
+		return v, pol.redactMap(v, path)
+	case map[string]interface{}:
+		return v, pol.redactMap(v, path)
+	default:
+		return v, 0
+	}
+}
+
+func (pol *policy) redactString(s string) (string, int) {
+	var count int
+	for _, r := range pol.rules {
+		s = r.re.ReplaceAllStringFunc(s, func(string) string {
+			count++
+			return r.replacement
+		})
+	}
+	return s, count
+}
+
+func (p *redact) String() string {
+	var names []string
+	for _, r := range p.policy.Load().rules {
+		names = append(names, r.name)
+	}
+	return fmt.Sprintf("%v=[policy_file=%v,rules=[%v],fields=%v]",
+		processorName, p.config.PolicyFile, strings.Join(names, ","), p.config.Fields)
+}
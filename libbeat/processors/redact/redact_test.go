@@ -0,0 +1,257 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package redact
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+const testPolicy = `
+patterns:
+  - name: email
+    regex: '[\w.+-]+@[\w-]+\.[\w.]+'
+    replacement: '[EMAIL]'
+  - name: ssn
+    regex: '\b\d{3}-\d{2}-\d{4}\b'
+dictionaries:
+  - name: projects
+    words: [Bluebird, Nightjar]
+    case_insensitive: true
+fields:
+  allow: [user.email]
+  deny: [http.request.headers.authorization]
+`
+
+func writePolicy(t *testing.T, path, policy string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(policy), 0o600))
+}
+
+func newTestProcessor(t *testing.T, config mapstr.M) *redact {
+	t.Helper()
+	p, err := New(conf.MustNewConfigFrom(config))
+	require.NoError(t, err)
+	return p.(*redact)
+}
+
+func TestNew(t *testing.T) {
+	dir := t.TempDir()
+	policyFile := filepath.Join(dir, "policy.yml")
+	writePolicy(t, policyFile, testPolicy)
+	badFile := filepath.Join(dir, "bad.yml")
+	writePolicy(t, badFile, "patterns: [{name: bad, regex: '('}]")
+
+	cases := map[string]struct {
+		config mapstr.M
+		err    string
+	}{
+		"policy_file": {
+			mapstr.M{"policy_file": policyFile},
+			"",
+		},
+		"inline": {
+			mapstr.M{"policy.patterns": []mapstr.M{{"name": "digits", "regex": `\d+`}}},
+			"",
+		},
+		"no_policy": {
+			mapstr.M{},
+			"either policy_file or policy must be set",
+		},
+		"missing_file": {
+			mapstr.M{"policy_file": filepath.Join(dir, "missing.yml")},
+			"failed to read policy file",
+		},
+		"bad_regex": {
+			mapstr.M{"policy_file": badFile},
+			"invalid regex of pattern bad",
+		},
+		"bad_deny_action": {
+			mapstr.M{"policy.fields": mapstr.M{"deny": []string{"a"}, "deny_action": "drop"}},
+			`invalid deny_action "drop"`,
+		},
+		"all_disabled": {
+			mapstr.M{"policy.patterns": []mapstr.M{{"name": "digits", "regex": `\d+`}}, "disable": []string{"digits"}},
+			"the redaction policy is empty",
+		},
+	}
+
+	for name, test := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := New(conf.MustNewConfigFrom(test.config))
+			if test.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, test.err)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	policyFile := filepath.Join(t.TempDir(), "policy.yml")
+	writePolicy(t, policyFile, testPolicy)
+
+	event := func() *beat.Event {
+		return &beat.Event{Fields: mapstr.M{
+			"message": "Mail alice@example.com about BLUEBIRD, SSN 123-45-6789",
+			"user": mapstr.M{
+				"email": "alice@example.com",
+			},
+			"tags": []string{"bob@example.com", "plain"},
+			"http": mapstr.M{
+				"request": mapstr.M{
+					"headers": mapstr.M{
+						"authorization": "Bearer secret",
+					},
+				},
+			},
+			"list":  []interface{}{mapstr.M{"note": "nightjar"}, 42},
+			"count": 3,
+		}}
+	}
+
+	t.Run("all_fields", func(t *testing.T) {
+		p := newTestProcessor(t, mapstr.M{"policy_file": policyFile})
+
+		out, err := p.Run(event())
+		require.NoError(t, err)
+		assert.Equal(t, mapstr.M{
+			"message": "Mail [EMAIL] about [REDACTED], SSN [REDACTED]",
+			"user": mapstr.M{
+				"email": "alice@example.com",
+			},
+			"tags": []string{"[EMAIL]", "plain"},
+			"http": mapstr.M{
+				"request": mapstr.M{
+					"headers": mapstr.M{
+						"authorization": "[REDACTED]",
+					},
+				},
+			},
+			"list":  []interface{}{mapstr.M{"note": "[REDACTED]"}, 42},
+			"count": 3,
+		}, out.Fields)
+
+		assert.Equal(t, int64(1), p.metrics.EventsRedacted.Get())
+		assert.Equal(t, int64(5), p.metrics.Redactions.Get())
+		assert.Equal(t, int64(1), p.metrics.FieldsMasked.Get())
+		assert.Equal(t, int64(0), p.metrics.FieldsRemoved.Get())
+	})
+
+	t.Run("selected_fields", func(t *testing.T) {
+		p := newTestProcessor(t, mapstr.M{
+			"policy_file": policyFile,
+			"fields":      []string{"message", "user.email", "missing"},
+			"disable":     []string{"ssn"},
+		})
+
+		out, err := p.Run(event())
+		require.NoError(t, err)
+		assert.Equal(t, "Mail [EMAIL] about [REDACTED], SSN 123-45-6789", out.Fields["message"])
+		email, _ := out.Fields.GetValue("user.email")
+		assert.Equal(t, "alice@example.com", email)
+		assert.Equal(t, []string{"bob@example.com", "plain"}, out.Fields["tags"])
+	})
+
+	t.Run("inline_override", func(t *testing.T) {
+		p := newTestProcessor(t, mapstr.M{
+			"policy_file": policyFile,
+			"policy": mapstr.M{
+				"patterns": []mapstr.M{{"name": "ssn", "regex": `\d{3}-\d{2}-\d{4}`, "replacement": "[SSN]"}},
+				"fields":   mapstr.M{"deny_action": "remove"},
+			},
+		})
+
+		out, err := p.Run(event())
+		require.NoError(t, err)
+		assert.Equal(t, "Mail [EMAIL] about [REDACTED], SSN [SSN]", out.Fields["message"])
+		_, err = out.Fields.GetValue("http.request.headers.authorization")
+		assert.ErrorIs(t, err, mapstr.ErrKeyNotFound)
+		assert.Equal(t, int64(1), p.metrics.FieldsRemoved.Get())
+	})
+
+	t.Run("no_match", func(t *testing.T) {
+		p := newTestProcessor(t, mapstr.M{"policy_file": policyFile})
+
+		out, err := p.Run(&beat.Event{Fields: mapstr.M{"message": "nothing to see"}})
+		require.NoError(t, err)
+		assert.Equal(t, "nothing to see", out.Fields["message"])
+		assert.Equal(t, int64(0), p.metrics.EventsRedacted.Get())
+	})
+}
+
+func TestDictionaryFile(t *testing.T) {
+	dir := t.TempDir()
+	words := filepath.Join(dir, "words.txt")
+	require.NoError(t, os.WriteFile(words, []byte("# Code names\nfalcon\n\nkestrel\n"), 0o600))
+
+	p := newTestProcessor(t, mapstr.M{
+		"policy.dictionaries": []mapstr.M{{"name": "birds", "file": words, "replacement": "***"}},
+	})
+
+	out, err := p.Run(&beat.Event{Fields: mapstr.M{"message": "falcon and kestrel, not Falcon or falconry"}})
+	require.NoError(t, err)
+	assert.Equal(t, "*** and ***, not Falcon or falconry", out.Fields["message"])
+}
+
+func TestReload(t *testing.T) {
+	policyFile := filepath.Join(t.TempDir(), "policy.yml")
+	writePolicy(t, policyFile, "patterns: [{name: digits, regex: '\\d+'}]")
+
+	now := time.Now()
+	p := newTestProcessor(t, mapstr.M{"policy_file": policyFile, "reload.period": "1m"})
+	p.now = func() time.Time { return now }
+	p.nextCheck = now.Add(time.Minute)
+
+	run := func() string {
+		t.Helper()
+		out, err := p.Run(&beat.Event{Fields: mapstr.M{"message": "abc 123"}})
+		require.NoError(t, err)
+		return out.Fields["message"].(string)
+	}
+	assert.Equal(t, "abc [REDACTED]", run())
+
+	writePolicy(t, policyFile, "patterns: [{name: letters, regex: '[a-z]+'}]")
+	// Make sure the modification is seen even on coarse mtime resolution.
+	require.NoError(t, os.Chtimes(policyFile, now, now.Add(time.Hour)))
+
+	// Not checked before the reload period has elapsed.
+	assert.Equal(t, "abc [REDACTED]", run())
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, "[REDACTED] 123", run())
+	assert.Equal(t, int64(1), p.metrics.Reloads.Get())
+
+	// An invalid policy is reported and the previous one is kept.
+	writePolicy(t, policyFile, "patterns: [{name: bad, regex: '('}]")
+	require.NoError(t, os.Chtimes(policyFile, now, now.Add(2*time.Hour)))
+	now = now.Add(time.Minute)
+	assert.Equal(t, "[REDACTED] 123", run())
+	assert.Equal(t, int64(1), p.metrics.Reloads.Get())
+	assert.Equal(t, int64(1), p.metrics.ReloadErrors.Get())
+}
@@ -0,0 +1,69 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sample
+
+import (
+	"fmt"
+
+	"github.com/elastic/beats/v7/libbeat/conditions"
+)
+
+// config for the sample processor.
+type config struct {
+	// Percentage of the events that are kept when no rule matches.
+	Percentage float64 `config:"percentage"`
+	// KeyFields makes the sampling decision consistent for the events
+	// that share the same values of these fields.
+	KeyFields []string     `config:"key_fields"`
+	Rules     []ruleConfig `config:"rules"`
+}
+
+// ruleConfig is a sampling policy applied to the events matching a condition.
+type ruleConfig struct {
+	When       *conditions.Config `config:"when"`
+	Percentage *float64           `config:"percentage"`
+	KeyFields  []string           `config:"key_fields"`
+}
+
+func (c *config) Validate() error {
+	if err := validatePercentage(c.Percentage); err != nil {
+		return err
+	}
+	for i, r := range c.Rules {
+		if r.Percentage == nil {
+			return fmt.Errorf("rule %d: percentage is required", i)
+		}
+		if err := validatePercentage(*r.Percentage); err != nil {
+			return fmt.Errorf("rule %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func validatePercentage(p float64) error {
+	if p < 0 || p > 100 {
+		return fmt.Errorf("percentage %v must be between 0 and 100", p)
+	}
+	return nil
+}
+
+func defaultConfig() config {
+	return config{
+		Percentage: 100,
+	}
+}
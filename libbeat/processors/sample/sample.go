@@ -0,0 +1,208 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sample
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/atomic"
+	"github.com/elastic/beats/v7/libbeat/conditions"
+	"github.com/elastic/beats/v7/libbeat/processors"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+// instanceID is used to assign each instance a unique monitoring namespace.
+var instanceID = atomic.MakeUint32(0)
+
+const (
+	processorName = "sample"
+	logName       = "processor." + processorName
+
+	// sampleScale is the resolution of the sampling decisions, so that
+	// percentages can have two decimals.
+	sampleScale = 10000
+)
+
+func init() {
+	processors.RegisterPlugin(processorName, New)
+}
+
+type metrics struct {
+	Kept       *monitoring.Int
+	SampledOut *monitoring.Int
+}
+
+// policy is a sampling policy. Events are kept when their sampling value,
+// between 0 and sampleScale, is below the threshold.
+type policy struct {
+	condition  conditions.Condition // nil matches every event.
+	percentage float64
+	threshold  uint64
+	keyFields  []string
+}
+
+type sample struct {
+	rules    []policy
+	fallback policy
+
+	// random returns a value in [0, sampleScale) for head-based sampling.
+	random func() uint64
+
+	logger  *logp.Logger
+	metrics metrics
+}
+
+// New constructs a new sample processor.
+func New(cfg *conf.C) (beat.Processor, error) {
+	config := defaultConfig()
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, fmt.Errorf("could not unpack %v processor configuration: %w", processorName, err)
+	}
+
+	p := &sample{
+		fallback: newPolicy(nil, config.Percentage, config.KeyFields),
+		random:   func() uint64 { return rand.Uint64N(sampleScale) },
+	}
+	for i, r := range config.Rules {
+		var cond conditions.Condition
+		if r.When != nil {
+			var err error
+			cond, err = conditions.NewCondition(r.When)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create condition of rule %d: %w", i, err)
+			}
+		}
+		keyFields := r.KeyFields
+		if keyFields == nil {
+			keyFields = config.KeyFields
+		}
+		p.rules = append(p.rules, newPolicy(cond, *r.Percentage, keyFields))
+	}
+
+	// Logging and metrics (each processor instance has a unique ID).
+	var (
+		id  = int(instanceID.Inc())
+		reg = monitoring.Default.NewRegistry(logName+"."+strconv.Itoa(id), monitoring.DoNotReport)
+	)
+	p.logger = logp.NewLogger(logName).With("instance_id", id)
+	p.metrics = metrics{
+		Kept:       monitoring.NewInt(reg, "kept"),
+		SampledOut: monitoring.NewInt(reg, "sampled_out"),
+	}
+
+	return p, nil
+}
+
+func newPolicy(cond conditions.Condition, percentage float64, keyFields []string) policy {
+	return policy{
+		condition:  cond,
+		percentage: percentage,
+		threshold:  uint64(percentage * sampleScale / 100),
+		keyFields:  keyFields,
+	}
+}
+
+// Run keeps or drops the event according to the first sampling rule that
+// matches it, or to the top level policy if none does.
+func (p *sample) Run(event *beat.Event) (*beat.Event, error) {
+	policy := &p.fallback
+	for i := range p.rules {
+		if c := p.rules[i].condition; c == nil || c.Check(event) {
+			policy = &p.rules[i]
+			break
+		}
+	}
+
+	keep, err := p.keep(policy, event)
+	if err != nil {
+		return event, err
+	}
+	if !keep {
+		p.logger.Debugf("event [%v] sampled out by %v processor", event, processorName)
+		p.metrics.SampledOut.Inc()
+		return nil, nil
+	}
+	p.metrics.Kept.Inc()
+	return event, nil
+}
+
+func (p *sample) keep(policy *policy, event *beat.Event) (bool, error) {
+	switch policy.threshold {
+	case 0:
+		return false, nil
+	case sampleScale:
+		return true, nil
+	}
+
+	key, found, err := sampleKey(policy.keyFields, event)
+	if err != nil {
+		return true, err
+	}
+	if !found {
+		// Head-based sampling.
+		return p.random() < policy.threshold, nil
+	}
+	// Consistent sampling: every event with the same key gets the same
+	// decision.
+	return xxhash.Sum64String(key)%sampleScale < policy.threshold, nil
+}
+
+// sampleKey returns the key made of the values of fields in event, and
+// whether any of them was found.
+func sampleKey(fields []string, event *beat.Event) (string, bool, error) {
+	if len(fields) == 0 {
+		return "", false, nil
+	}
+
+	var (
+		b     strings.Builder
+		found bool
+	)
+	for _, field := range fields {
+		value, err := event.GetValue(field)
+		if err != nil {
+			if !errors.Is(err, mapstr.ErrKeyNotFound) {
+				return "", false, fmt.Errorf("error getting value of field '%v': %w", field, err)
+			}
+			value = ""
+		} else {
+			found = true
+		}
+		fmt.Fprintf(&b, "%v\x00", value)
+	}
+	return b.String(), found, nil
+}
+
+func (p *sample) String() string {
+	var rules []string
+	for _, r := range p.rules {
+		rules = append(rules, fmt.Sprintf("[when=%v,percentage=%v,key_fields=%v]", r.condition, r.percentage, r.keyFields))
+	}
+	return fmt.Sprintf("%v=[percentage=%v,key_fields=%v,rules=[%v]]",
+		processorName, p.fallback.percentage, p.fallback.keyFields, strings.Join(rules, ","))
+}
@@ -0,0 +1,169 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sample
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func TestNew(t *testing.T) {
+	cases := map[string]struct {
+		config mapstr.M
+		err    string
+	}{
+		"default": {
+			mapstr.M{},
+			"",
+		},
+		"percentage_out_of_range": {
+			mapstr.M{"percentage": 101},
+			"percentage 101 must be between 0 and 100",
+		},
+		"rule_without_percentage": {
+			mapstr.M{"rules": []mapstr.M{{"when.equals.log.level": "error"}}},
+			"rule 0: percentage is required",
+		},
+		"rule_bad_condition": {
+			mapstr.M{"rules": []mapstr.M{{"when.foo.bar": 1, "percentage": 10}}},
+			"failed to create condition of rule 0",
+		},
+	}
+
+	for name, test := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := New(conf.MustNewConfigFrom(test.config))
+			if test.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, test.err)
+			}
+		})
+	}
+}
+
+func TestSample(t *testing.T) {
+	p, err := New(conf.MustNewConfigFrom(mapstr.M{
+		"percentage": 50,
+		"rules": []mapstr.M{
+			{"when.equals.log.level": "error", "percentage": 100},
+			{"when.equals.log.level": "debug", "percentage": 10, "key_fields": []string{"container.id"}},
+			{"when.equals.log.level": "trace", "percentage": 0},
+		},
+	}))
+	require.NoError(t, err)
+	s := p.(*sample)
+
+	// Deterministic head-based sampling: values cycle over [0, sampleScale).
+	var n uint64
+	s.random = func() uint64 {
+		v := (n * 37) % sampleScale
+		n++
+		return v
+	}
+
+	run := func(fields mapstr.M) bool {
+		out, err := p.Run(&beat.Event{Fields: fields})
+		require.NoError(t, err)
+		return out != nil
+	}
+
+	t.Run("rule keeps all", func(t *testing.T) {
+		for i := 0; i < 100; i++ {
+			assert.True(t, run(mapstr.M{"log": mapstr.M{"level": "error"}}))
+		}
+	})
+
+	t.Run("rule drops all", func(t *testing.T) {
+		for i := 0; i < 100; i++ {
+			assert.False(t, run(mapstr.M{"log": mapstr.M{"level": "trace"}}))
+		}
+	})
+
+	t.Run("head based", func(t *testing.T) {
+		var kept int
+		for i := 0; i < sampleScale; i++ {
+			if run(mapstr.M{"log": mapstr.M{"level": "info"}}) {
+				kept++
+			}
+		}
+		assert.Equal(t, sampleScale/2, kept)
+	})
+
+	t.Run("key based", func(t *testing.T) {
+		var keptKeys int
+		for c := 0; c < 1000; c++ {
+			id := fmt.Sprintf("container-%d", c)
+			first := run(mapstr.M{"log": mapstr.M{"level": "debug"}, "container": mapstr.M{"id": id}})
+			for i := 0; i < 5; i++ {
+				assert.Equal(t, first, run(mapstr.M{"log": mapstr.M{"level": "debug"}, "container": mapstr.M{"id": id}}),
+					"inconsistent decision for %v", id)
+			}
+			if first {
+				keptKeys++
+			}
+		}
+		assert.InDelta(t, 100, keptKeys, 40)
+	})
+
+	t.Run("key based without key", func(t *testing.T) {
+		// Falls back to head-based sampling.
+		var kept int
+		for i := 0; i < sampleScale; i++ {
+			if run(mapstr.M{"log": mapstr.M{"level": "debug"}}) {
+				kept++
+			}
+		}
+		assert.Equal(t, sampleScale/10, kept)
+	})
+
+	assert.Equal(t, s.metrics.Kept.Get()+s.metrics.SampledOut.Get(), int64(100+100+sampleScale+6000+sampleScale))
+	assert.GreaterOrEqual(t, s.metrics.SampledOut.Get(), int64(100))
+}
+
+func TestSampleKeyFieldsInherited(t *testing.T) {
+	p, err := New(conf.MustNewConfigFrom(mapstr.M{
+		"percentage": 50,
+		"key_fields": []string{"trace.id"},
+		"rules": []mapstr.M{
+			{"when.has_fields": []string{"error"}, "percentage": 20},
+		},
+	}))
+	require.NoError(t, err)
+	s := p.(*sample)
+	s.random = func() uint64 { panic("unexpected head-based sampling") }
+
+	for i := 0; i < 100; i++ {
+		id := fmt.Sprintf("trace-%d", i)
+		fields := mapstr.M{"trace": mapstr.M{"id": id}, "error": mapstr.M{"message": "failed"}}
+		out, err := p.Run(&beat.Event{Fields: fields})
+		require.NoError(t, err)
+		want := out != nil
+
+		out, err = p.Run(&beat.Event{Fields: fields.Clone()})
+		require.NoError(t, err)
+		assert.Equal(t, want, out != nil, id)
+	}
+}
@@ -0,0 +1,69 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package schemavalidation
+
+import (
+	"fmt"
+)
+
+const (
+	// ActionTag keeps violating events and adds the configured tag.
+	ActionTag = "tag"
+	// ActionDrop drops violating events.
+	ActionDrop = "drop"
+	// ActionRoute sends violating events to the configured index.
+	ActionRoute = "route"
+)
+
+// Config configures the schema validation stage of the publisher pipeline,
+// set under `schema_validation`.
+type Config struct {
+	Enabled bool `config:"enabled"`
+	// Fields is the path to a fields.yml file to validate against. If empty
+	// the fields bundled with the beat (including ECS) are used.
+	Fields string `config:"fields"`
+	Action string `config:"action"`
+	Tag    string `config:"tag"`
+	// Index is the raw index violating events are sent to when Action is
+	// route.
+	Index string `config:"index"`
+	// MaxViolations limits the number of violations recorded per event.
+	MaxViolations int `config:"max_violations" validate:"min=1"`
+}
+
+// DefaultConfig returns the default schema validation configuration.
+func DefaultConfig() Config {
+	return Config{
+		Action:        ActionTag,
+		Tag:           "schema_violation",
+		MaxViolations: 10,
+	}
+}
+
+func (c *Config) Validate() error {
+	switch c.Action {
+	case ActionTag, ActionDrop:
+	case ActionRoute:
+		if c.Index == "" {
+			return fmt.Errorf("index is required when action is %q", ActionRoute)
+		}
+	default:
+		return fmt.Errorf("invalid action %q, must be one of %q, %q or %q", c.Action, ActionTag, ActionDrop, ActionRoute)
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package schemavalidation implements an optional publisher pipeline stage
+// that checks outgoing events against a fields.yml schema, so that unknown
+// fields and type conflicts are caught before they cause mapping explosions
+// or indexing failures in Elasticsearch.
+package schemavalidation
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/beat/events"
+	"github.com/elastic/beats/v7/libbeat/mapping"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// ViolationsKey is the metadata key the violations of an event are
+// stored under.
+const ViolationsKey = "schema_violations"
+
+type kind uint8
+
+const (
+	kindLeaf kind = iota
+	kindGroup
+	// kindOpen accepts any value, including sub-fields that are not
+	// defined in the schema (object, flattened and dynamic fields).
+	kindOpen
+)
+
+type field struct {
+	kind kind
+	typ  string
+	path string // alias target
+}
+
+type processor struct {
+	config Config
+	log    *logp.Logger
+
+	fields map[string]field
+	// prefixes of wildcard field names, e.g. "kubernetes.labels." for
+	// "kubernetes.labels.*".
+	openPrefixes []string
+}
+
+// New returns a processor that validates events against fields.
+func New(fields mapping.Fields, config Config) (beat.Processor, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	p := &processor{
+		config: config,
+		log:    logp.NewLogger("schema_validation"),
+		fields: map[string]field{},
+	}
+	p.compile("", fields)
+	return p, nil
+}
+
+func (p *processor) compile(prefix string, fields mapping.Fields) {
+	for _, f := range fields {
+		name := f.Name
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		// Dotted names implicitly define their parent objects.
+		for i := len(prefix) + 1; i < len(name); i++ {
+			if name[i] == '.' {
+				p.addGroup(name[:i])
+			}
+		}
+
+		if idx := strings.IndexByte(name, '*'); idx >= 0 {
+			p.openPrefixes = append(p.openPrefixes, name[:idx])
+			continue
+		}
+
+		switch {
+		case f.Type == "group" || f.Type == "nested" || len(f.Fields) > 0:
+			if f.Dynamic.Value == true || (f.Enabled != nil && !*f.Enabled) {
+				p.fields[name] = field{kind: kindOpen}
+				continue
+			}
+			p.addGroup(name)
+			p.compile(name, f.Fields)
+		case f.Type == "object" || f.Type == "flattened" || (f.Enabled != nil && !*f.Enabled):
+			p.fields[name] = field{kind: kindOpen}
+		default:
+			typ := f.Type
+			if typ == "" {
+				typ = "keyword"
+			}
+			if _, exists := p.fields[name]; !exists || f.Overwrite {
+				p.fields[name] = field{kind: kindLeaf, typ: typ, path: f.AliasPath}
+			}
+		}
+	}
+}
+
+func (p *processor) addGroup(name string) {
+	if _, exists := p.fields[name]; !exists {
+		p.fields[name] = field{kind: kindGroup}
+	}
+}
+
+func (p *processor) String() string {
+	return fmt.Sprintf("schema_validation=[action=%s]", p.config.Action)
+}
+
+func (p *processor) Run(event *beat.Event) (*beat.Event, error) {
+	v := validator{p: p}
+	v.walk("", event.Fields)
+	if len(v.violations) == 0 {
+		return event, nil
+	}
+
+	if p.log.IsDebug() {
+		p.log.Debugf("Event violates the schema: %s", strings.Join(v.violations, "; "))
+	}
+
+	switch p.config.Action {
+	case ActionDrop:
+		return nil, nil
+	case ActionRoute:
+		// The alias and index metadata take precedence over raw_index.
+		_ = event.Delete("@metadata." + events.FieldMetaAlias)
+		_ = event.Delete("@metadata." + events.FieldMetaIndex)
+		if _, err := event.PutValue("@metadata."+events.FieldMetaRawIndex, p.config.Index); err != nil {
+			return event, err
+		}
+	}
+	if _, err := event.PutValue("@metadata."+ViolationsKey, v.violations); err != nil {
+		return event, err
+	}
+	if event.Fields == nil {
+		event.Fields = mapstr.M{}
+	}
+	return event, mapstr.AddTags(event.Fields, []string{p.config.Tag})
+}
+
+type validator struct {
+	p          *processor
+	violations []string
+}
+
+func (v *validator) add(format string, args ...interface{}) bool {
+	if len(v.violations) >= v.p.config.MaxViolations {
+		return false
+	}
+	v.violations = append(v.violations, fmt.Sprintf(format, args...))
+	return true
+}
+
+// walk validates all keys of m. It returns false once the maximum number
+// of violations is reached.
+func (v *validator) walk(prefix string, m map[string]interface{}) bool {
+	for key, value := range m {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		if !v.check(path, value) {
+			return false
+		}
+	}
+	return true
+}
+
+func (v *validator) check(path string, value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	for _, prefix := range v.p.openPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+
+	f, known := v.p.fields[path]
+	if known && f.kind == kindOpen {
+		return true
+	}
+
+	switch val := value.(type) {
+	case mapstr.M:
+		return v.checkObject(path, f, known, val)
+	case map[string]interface{}:
+		return v.checkObject(path, f, known, val)
+	case []mapstr.M:
+		for _, elem := range val {
+			if !v.checkObject(path, f, known, elem) {
+				return false
+			}
+		}
+		return true
+	case string, []byte, net.IP:
+	default:
+		if rv := reflect.ValueOf(value); rv.Kind() == reflect.Slice {
+			for i := 0; i < rv.Len(); i++ {
+				if !v.check(path, rv.Index(i).Interface()) {
+					return false
+				}
+			}
+			return true
+		}
+	}
+
+	switch {
+	case !known:
+		return v.add("%s: field is not defined in the schema", path)
+	case f.kind == kindGroup:
+		return v.add("%s: expected an object, got %T", path, value)
+	case f.typ == "alias":
+		return v.add("%s: field is an alias of %s and cannot be written to", path, f.path)
+	case !isCompatible(f.typ, value):
+		return v.add("%s: value %v is not a valid %s", path, value, f.typ)
+	}
+	return true
+}
+
+func (v *validator) checkObject(path string, f field, known bool, m map[string]interface{}) bool {
+	switch {
+	case !known:
+		return v.add("%s: field is not defined in the schema", path)
+	case f.kind == kindLeaf && isScalarType(f.typ):
+		return v.add("%s: expected a %s value, got an object", path, f.typ)
+	case f.kind == kindLeaf:
+		// Types like geo_point or histogram are indexed from objects.
+		return true
+	}
+	return v.walk(path, m)
+}
+
+func isScalarType(typ string) bool {
+	switch typ {
+	case "keyword", "constant_keyword", "wildcard", "text", "match_only_text",
+		"date", "date_nanos", "ip", "boolean", "version", "alias":
+		return true
+	}
+	return isNumericType(typ)
+}
+
+func isNumericType(typ string) bool {
+	switch typ {
+	case "long", "integer", "short", "byte", "unsigned_long",
+		"double", "float", "half_float", "scaled_float":
+		return true
+	}
+	return false
+}
+
+// isCompatible returns false if Elasticsearch would reject value for a
+// field of type typ. Only types with strict parsing are checked, strings
+// are coerced into numbers and booleans like Elasticsearch does.
+func isCompatible(typ string, value interface{}) bool {
+	switch {
+	case isNumericType(typ):
+		switch val := value.(type) {
+		case string:
+			_, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+			return err == nil
+		case bool:
+			return false
+		}
+		switch reflect.ValueOf(value).Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			return true
+		}
+		return false
+	case typ == "boolean":
+		switch val := value.(type) {
+		case bool:
+			return true
+		case string:
+			return val == "true" || val == "false" || val == ""
+		}
+		return false
+	case typ == "ip":
+		switch val := value.(type) {
+		case net.IP:
+			return true
+		case string:
+			if idx := strings.IndexByte(val, '%'); idx >= 0 {
+				val = val[:idx]
+			}
+			return net.ParseIP(val) != nil
+		}
+		return false
+	}
+	return true
+}
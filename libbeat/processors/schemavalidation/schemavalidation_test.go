@@ -0,0 +1,177 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package schemavalidation
+
+import (
+	"net"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/mapping"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+var testFields = mapping.Fields{
+	{
+		Name: "process",
+		Type: "group",
+		Fields: mapping.Fields{
+			{Name: "pid", Type: "long"},
+			{Name: "name"},
+			{Name: "parent.pid", Type: "long"},
+		},
+	},
+	{Name: "source.ip", Type: "ip"},
+	{Name: "event.original", Type: "keyword"},
+	{Name: "tags", Type: "keyword"},
+	{Name: "labels", Type: "object", ObjectType: "keyword"},
+	{Name: "kubernetes.annotations.*", Type: "object"},
+	{Name: "host.location", Type: "geo_point"},
+	{Name: "user.active", Type: "boolean"},
+	{Name: "process.ppid", Type: "alias", AliasPath: "process.parent.pid"},
+	{Name: "custom", Type: "group", Dynamic: mapping.DynamicType{Value: true}},
+}
+
+func violations(t *testing.T, e *beat.Event) []string {
+	t.Helper()
+	v, err := e.GetValue("@metadata." + ViolationsKey)
+	require.NoError(t, err)
+	list, ok := v.([]string)
+	require.True(t, ok)
+	sort.Strings(list)
+	return list
+}
+
+func TestValidEvent(t *testing.T) {
+	p, err := New(testFields, DefaultConfig())
+	require.NoError(t, err)
+
+	in := &beat.Event{Fields: mapstr.M{
+		"process": mapstr.M{
+			"pid":    1234,
+			"name":   "bash",
+			"parent": map[string]interface{}{"pid": "1"},
+		},
+		"source":     mapstr.M{"ip": net.ParseIP("10.0.0.1")},
+		"event":      mapstr.M{"original": "raw"},
+		"tags":       []string{"a", "b"},
+		"labels":     mapstr.M{"env": "prod"},
+		"kubernetes": mapstr.M{"annotations": mapstr.M{"a": mapstr.M{"b": 1}}},
+		"host":       mapstr.M{"location": mapstr.M{"lat": 1.0, "lon": 2.0}},
+		"user":       mapstr.M{"active": "true"},
+		"custom":     mapstr.M{"anything": mapstr.M{"goes": true}},
+	}}
+	out, err := p.Run(in)
+	require.NoError(t, err)
+	require.NotNil(t, out)
+	assert.Nil(t, out.Meta)
+	assert.Equal(t, []string{"a", "b"}, out.Fields["tags"])
+}
+
+func TestViolations(t *testing.T) {
+	p, err := New(testFields, DefaultConfig())
+	require.NoError(t, err)
+
+	out, err := p.Run(&beat.Event{Fields: mapstr.M{
+		"process": mapstr.M{
+			"pid":     "not a number",
+			"unknown": 1,
+			"ppid":    1,
+		},
+		"source": mapstr.M{"ip": []string{"10.0.0.1", "not an ip"}},
+		"event":  mapstr.M{"original": mapstr.M{"nested": "x"}},
+		"user":   mapstr.M{"active": 1},
+		"labels": "scalar",
+		"other":  mapstr.M{"a": 1, "b": 2},
+	}})
+	require.NoError(t, err)
+	require.NotNil(t, out)
+
+	assert.Equal(t, []string{
+		"event.original: expected a keyword value, got an object",
+		"other: field is not defined in the schema",
+		"process.pid: value not a number is not a valid long",
+		"process.ppid: field is an alias of process.parent.pid and cannot be written to",
+		"process.unknown: field is not defined in the schema",
+		"source.ip: value not an ip is not a valid ip",
+		"user.active: value 1 is not a valid boolean",
+	}, violations(t, out))
+	assert.Equal(t, []string{"schema_violation"}, out.Fields["tags"])
+}
+
+func TestMaxViolations(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxViolations = 2
+	p, err := New(testFields, cfg)
+	require.NoError(t, err)
+
+	out, err := p.Run(&beat.Event{Fields: mapstr.M{"a": 1, "b": 2, "c": 3}})
+	require.NoError(t, err)
+	assert.Len(t, violations(t, out), 2)
+}
+
+func TestActions(t *testing.T) {
+	invalid := func() *beat.Event {
+		return &beat.Event{
+			Meta:   mapstr.M{"index": "logs-default"},
+			Fields: mapstr.M{"unknown": 1},
+		}
+	}
+
+	t.Run("drop", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Action = ActionDrop
+		p, err := New(testFields, cfg)
+		require.NoError(t, err)
+
+		out, err := p.Run(invalid())
+		require.NoError(t, err)
+		assert.Nil(t, out)
+	})
+
+	t.Run("route", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Action = ActionRoute
+		cfg.Index = "schema-violations"
+		p, err := New(testFields, cfg)
+		require.NoError(t, err)
+
+		out, err := p.Run(invalid())
+		require.NoError(t, err)
+		require.NotNil(t, out)
+		assert.Equal(t, "schema-violations", out.Meta["raw_index"])
+		assert.NotContains(t, out.Meta, "index")
+		assert.Equal(t, []string{"schema_violation"}, out.Fields["tags"])
+	})
+}
+
+func TestConfigValidate(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Action = ActionRoute
+	assert.Error(t, cfg.Validate())
+
+	cfg.Action = "unknown"
+	assert.Error(t, cfg.Validate())
+
+	cfg.Action = ActionDrop
+	assert.NoError(t, cfg.Validate())
+}
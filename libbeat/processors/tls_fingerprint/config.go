@@ -0,0 +1,74 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tls_fingerprint
+
+import "errors"
+
+type config struct {
+	Fields fieldsConfig `config:"fields"`
+	Target targetConfig `config:"target"`
+}
+
+// fieldsConfig holds the names of the fields containing the ClientHello
+// parameters. Numeric parameters can be integers, decimal strings or
+// hexadecimal strings prefixed with 0x.
+type fieldsConfig struct {
+	Version             string `config:"version"`
+	CipherSuites        string `config:"cipher_suites"`
+	Extensions          string `config:"extensions"`
+	SupportedGroups     string `config:"supported_groups"`
+	ECPointFormats      string `config:"ec_point_formats"`
+	SignatureAlgorithms string `config:"signature_algorithms"`
+	SupportedVersions   string `config:"supported_versions"`
+	ALPN                string `config:"alpn"`
+	ServerName          string `config:"server_name"`
+	Transport           string `config:"transport"`
+}
+
+type targetConfig struct {
+	JA3 string `config:"ja3"`
+	JA4 string `config:"ja4"`
+}
+
+func (c *config) Validate() error {
+	if c.Target.JA3 == "" && c.Target.JA4 == "" {
+		return errors.New("at least one of target.ja3 or target.ja4 must be set")
+	}
+	return nil
+}
+
+func defaultConfig() config {
+	return config{
+		Fields: fieldsConfig{
+			Version:             "tls.client.hello.version",
+			CipherSuites:        "tls.client.hello.cipher_suites",
+			Extensions:          "tls.client.hello.extensions",
+			SupportedGroups:     "tls.client.hello.supported_groups",
+			ECPointFormats:      "tls.client.hello.ec_point_formats",
+			SignatureAlgorithms: "tls.client.hello.signature_algorithms",
+			SupportedVersions:   "tls.client.hello.supported_versions",
+			ALPN:                "tls.client.hello.alpn",
+			ServerName:          "tls.client.server_name",
+			Transport:           "network.transport",
+		},
+		Target: targetConfig{
+			JA3: "tls.client.ja3",
+			JA4: "tls.client.ja4",
+		},
+	}
+}
@@ -0,0 +1,183 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tls_fingerprint
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	extensionServerName = 0x0000
+	extensionALPN       = 0x0010
+)
+
+// clientHello holds the ClientHello parameters used by the fingerprints.
+// GREASE values must already be removed.
+type clientHello struct {
+	version             uint16
+	cipherSuites        []uint16
+	extensions          []uint16
+	supportedGroups     []uint16
+	ecPointFormats      []uint16
+	signatureAlgorithms []uint16
+	supportedVersions   []uint16
+	alpn                string
+	serverName          bool
+	transport           byte
+}
+
+// ja3 returns the JA3 fingerprint of the hello message.
+// See https://github.com/salesforce/ja3.
+func ja3(h *clientHello) string {
+	parts := []string{
+		strconv.Itoa(int(h.version)),
+		joinDecimal(h.cipherSuites),
+		joinDecimal(h.extensions),
+		joinDecimal(h.supportedGroups),
+		joinDecimal(h.ecPointFormats),
+	}
+	sum := md5.Sum([]byte(strings.Join(parts, ",")))
+	return hex.EncodeToString(sum[:])
+}
+
+// ja4 returns the JA4 fingerprint of the hello message.
+// See https://github.com/FoxIO-LLC/ja4/blob/main/technical_details/JA4.md.
+func ja4(h *clientHello) string {
+	version := h.version
+	if len(h.supportedVersions) != 0 {
+		version = h.supportedVersions[0]
+		for _, v := range h.supportedVersions[1:] {
+			version = max(version, v)
+		}
+	}
+	sni := byte('i')
+	if h.serverName {
+		sni = 'd'
+	}
+
+	var exts []uint16
+	for _, e := range h.extensions {
+		if e == extensionServerName {
+			sni = 'd'
+		}
+		if e != extensionServerName && e != extensionALPN {
+			exts = append(exts, e)
+		}
+	}
+
+	a := fmt.Sprintf("%c%s%c%02d%02d%s",
+		h.transport, ja4Version(version), sni,
+		min(len(h.cipherSuites), 99), min(len(h.extensions), 99),
+		ja4ALPN(h.alpn))
+
+	b := ja4Hash(joinHex(sorted(h.cipherSuites)))
+	c := joinHex(sorted(exts))
+	if len(h.signatureAlgorithms) != 0 {
+		c += "_" + joinHex(h.signatureAlgorithms)
+	}
+	if len(exts) == 0 {
+		c = ""
+	}
+	return a + "_" + b + "_" + ja4Hash(c)
+}
+
+func ja4Version(v uint16) string {
+	switch v {
+	case 0x0304:
+		return "13"
+	case 0x0303:
+		return "12"
+	case 0x0302:
+		return "11"
+	case 0x0301:
+		return "10"
+	case 0x0300:
+		return "s3"
+	case 0x0002:
+		return "s2"
+	case 0xfeff:
+		return "d1"
+	case 0xfefd:
+		return "d2"
+	case 0xfefc:
+		return "d3"
+	default:
+		return "00"
+	}
+}
+
+// ja4ALPN returns the first and last characters of the first ALPN value,
+// or of its hex representation if either is not alphanumeric.
+func ja4ALPN(alpn string) string {
+	if alpn == "" {
+		return "00"
+	}
+	first, last := alpn[0], alpn[len(alpn)-1]
+	if isAlphanumeric(first) && isAlphanumeric(last) {
+		return string([]byte{first, last})
+	}
+	return hex.EncodeToString([]byte{first})[:1] + hex.EncodeToString([]byte{last})[1:]
+}
+
+func isAlphanumeric(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// ja4Hash returns the truncated SHA256 of s, or zeros if s is empty.
+func ja4Hash(s string) string {
+	if s == "" {
+		return "000000000000"
+	}
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+func joinDecimal(values []uint16) string {
+	s := make([]string, len(values))
+	for i, v := range values {
+		s[i] = strconv.Itoa(int(v))
+	}
+	return strings.Join(s, "-")
+}
+
+func joinHex(values []uint16) string {
+	s := make([]string, len(values))
+	for i, v := range values {
+		s[i] = fmt.Sprintf("%04x", v)
+	}
+	return strings.Join(s, ",")
+}
+
+func sorted(values []uint16) []uint16 {
+	s := append([]uint16(nil), values...)
+	sort.Slice(s, func(i, j int) bool { return s[i] < s[j] })
+	return s
+}
+
+// isGreaseValue reports whether num is a reserved GREASE value.
+// See https://tools.ietf.org/html/rfc8701.
+func isGreaseValue(num uint16) bool {
+	hi, lo := byte(num>>8), byte(num)
+	return hi == lo && lo&0xf == 0xa
+}
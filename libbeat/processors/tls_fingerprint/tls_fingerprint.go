@@ -0,0 +1,232 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tls_fingerprint
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/processors"
+	jsprocessor "github.com/elastic/beats/v7/libbeat/processors/script/javascript/module/processor"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+const logName = "processor.tls_fingerprint"
+
+func init() {
+	processors.RegisterPlugin("tls_fingerprint", New)
+	jsprocessor.RegisterPlugin("TLSFingerprint", New)
+}
+
+type processor struct {
+	config
+	log *logp.Logger
+}
+
+// New constructs a new processor that computes the JA3 and JA4 fingerprints
+// of a TLS ClientHello from its parameters stored on the event. It allows
+// correlating TLS data from third-party sources with Packetbeat events.
+func New(cfg *conf.C) (beat.Processor, error) {
+	c := defaultConfig()
+	if err := cfg.Unpack(&c); err != nil {
+		return nil, fmt.Errorf("fail to unpack the tls_fingerprint configuration: %w", err)
+	}
+
+	return &processor{
+		config: c,
+		log:    logp.NewLogger(logName),
+	}, nil
+}
+
+func (p *processor) String() string {
+	return fmt.Sprintf("tls_fingerprint=[ja3=%s, ja4=%s, fields=[version=%s, cipher_suites=%s, extensions=%s]]",
+		p.Target.JA3, p.Target.JA4, p.Fields.Version, p.Fields.CipherSuites, p.Fields.Extensions)
+}
+
+// Run adds the configured fingerprints to the event. Events that do not
+// hold the required ClientHello parameters are returned unchanged, and
+// existing fingerprints are not overwritten.
+func (p *processor) Run(event *beat.Event) (*beat.Event, error) {
+	hello, ok := p.clientHello(event)
+	if !ok {
+		return event, nil
+	}
+
+	if p.Target.JA3 != "" && hello.version != 0 {
+		if _, err := event.GetValue(p.Target.JA3); err != nil {
+			if _, err = event.PutValue(p.Target.JA3, ja3(hello)); err != nil {
+				return event, err
+			}
+		}
+	}
+	if p.Target.JA4 != "" {
+		if _, err := event.GetValue(p.Target.JA4); err != nil {
+			if _, err = event.PutValue(p.Target.JA4, ja4(hello)); err != nil {
+				return event, err
+			}
+		}
+	}
+	return event, nil
+}
+
+// clientHello reads the ClientHello parameters from the event. The cipher
+// suites are required, all other parameters are optional.
+func (p *processor) clientHello(event *beat.Event) (*clientHello, bool) {
+	var h clientHello
+	var ok bool
+	h.cipherSuites, ok = getUint16s(event, p.Fields.CipherSuites)
+	if !ok {
+		return nil, false
+	}
+	if v, err := event.GetValue(p.Fields.Version); err == nil {
+		h.version, _ = toUint16(v)
+	}
+	h.extensions, _ = getUint16s(event, p.Fields.Extensions)
+	h.supportedGroups, _ = getUint16s(event, p.Fields.SupportedGroups)
+	h.ecPointFormats, _ = getUint16s(event, p.Fields.ECPointFormats)
+	h.signatureAlgorithms, _ = getUint16s(event, p.Fields.SignatureAlgorithms)
+	h.supportedVersions, _ = getUint16s(event, p.Fields.SupportedVersions)
+
+	if v, err := event.GetValue(p.Fields.ALPN); err == nil {
+		switch v := v.(type) {
+		case string:
+			h.alpn = v
+		case []string:
+			if len(v) != 0 {
+				h.alpn = v[0]
+			}
+		case []interface{}:
+			if len(v) != 0 {
+				h.alpn, _ = v[0].(string)
+			}
+		}
+	}
+	if v, err := event.GetValue(p.Fields.ServerName); err == nil {
+		s, _ := v.(string)
+		h.serverName = s != ""
+	}
+
+	h.transport = 't'
+	if v, err := event.GetValue(p.Fields.Transport); err == nil {
+		s, _ := v.(string)
+		switch strings.ToLower(s) {
+		case "quic", "udp":
+			h.transport = 'q'
+		case "dtls":
+			h.transport = 'd'
+		}
+	}
+	return &h, true
+}
+
+// getUint16s returns the non-GREASE values of the list held by field. The
+// list can also be a string of values separated by dashes or commas.
+func getUint16s(event *beat.Event, field string) ([]uint16, bool) {
+	v, err := event.GetValue(field)
+	if err != nil {
+		return nil, false
+	}
+
+	var values []interface{}
+	switch v := v.(type) {
+	case []interface{}:
+		values = v
+	case string:
+		for _, s := range strings.FieldsFunc(v, func(r rune) bool { return r == '-' || r == ',' }) {
+			values = append(values, strings.TrimSpace(s))
+		}
+	case []string:
+		for _, s := range v {
+			values = append(values, s)
+		}
+	case []int:
+		for _, n := range v {
+			values = append(values, n)
+		}
+	case []int64:
+		for _, n := range v {
+			values = append(values, n)
+		}
+	case []uint16:
+		for _, n := range v {
+			values = append(values, n)
+		}
+	case []float64:
+		for _, n := range v {
+			values = append(values, n)
+		}
+	default:
+		return nil, false
+	}
+
+	out := make([]uint16, 0, len(values))
+	for _, v := range values {
+		n, ok := toUint16(v)
+		if !ok {
+			return nil, false
+		}
+		if !isGreaseValue(n) {
+			out = append(out, n)
+		}
+	}
+	return out, true
+}
+
+func toUint16(v interface{}) (uint16, bool) {
+	var n uint64
+	switch v := v.(type) {
+	case int:
+		if v < 0 {
+			return 0, false
+		}
+		n = uint64(v)
+	case int64:
+		if v < 0 {
+			return 0, false
+		}
+		n = uint64(v)
+	case uint16:
+		return v, true
+	case uint64:
+		n = v
+	case float64:
+		if v < 0 || v != float64(uint64(v)) {
+			return 0, false
+		}
+		n = uint64(v)
+	case string:
+		var err error
+		if strings.HasPrefix(v, "0x") || strings.HasPrefix(v, "0X") {
+			n, err = strconv.ParseUint(v[2:], 16, 16)
+		} else {
+			n, err = strconv.ParseUint(v, 10, 16)
+		}
+		if err != nil {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	if n > 0xffff {
+		return 0, false
+	}
+	return uint16(n), true
+}
@@ -0,0 +1,115 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tls_fingerprint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func TestNewDefaults(t *testing.T) {
+	_, err := New(conf.NewConfig())
+	require.NoError(t, err)
+
+	_, err = New(conf.MustNewConfigFrom(mapstr.M{"target": mapstr.M{"ja3": "", "ja4": ""}}))
+	assert.Error(t, err)
+}
+
+func TestRun(t *testing.T) {
+	p, err := New(conf.NewConfig())
+	require.NoError(t, err)
+
+	t.Run("ja3", func(t *testing.T) {
+		// Example from https://github.com/salesforce/ja3, with a GREASE
+		// cipher suite that must be ignored.
+		evt := &beat.Event{Fields: mapstr.M{
+			"tls": mapstr.M{"client": mapstr.M{"hello": mapstr.M{
+				"version":          769,
+				"cipher_suites":    []interface{}{0x0a0a, 47, 53, 5, 10, 49161, 49162, 49171, 49172, 50, 56, 19, 4},
+				"extensions":       "0-10-11",
+				"supported_groups": []string{"23", "24", "25"},
+				"ec_point_formats": []int{0},
+			}}},
+		}}
+		evt, err := p.Run(evt)
+		require.NoError(t, err)
+		ja3, _ := evt.GetValue("tls.client.ja3")
+		assert.Equal(t, "ada70206e40642a3e4461f35503241d5", ja3)
+	})
+
+	t.Run("ja4", func(t *testing.T) {
+		// Example from https://github.com/FoxIO-LLC/ja4.
+		evt := &beat.Event{Fields: mapstr.M{
+			"network": mapstr.M{"transport": "tcp"},
+			"tls": mapstr.M{"client": mapstr.M{
+				"server_name": "example.com",
+				"hello": mapstr.M{
+					"version": "0x0303",
+					"cipher_suites": []interface{}{
+						"0x1301", "0x1302", "0x1303", "0xc02b", "0xc02f", "0xc02c", "0xc030", "0xcca9",
+						"0xcca8", "0xc013", "0xc014", "0x009c", "0x009d", "0x002f", "0x0035",
+					},
+					"extensions": []interface{}{
+						0x2a2a, 0x0000, 0x0017, 0xff01, 0x000a, 0x000b, 0x0023, 0x0010, 0x0005,
+						0x000d, 0x0012, 0x0033, 0x002d, 0x002b, 0x001b, 0x0015, 0x4469,
+					},
+					"signature_algorithms": []interface{}{0x0403, 0x0804, 0x0401, 0x0503, 0x0805, 0x0501, 0x0806, 0x0601},
+					"supported_versions":   []interface{}{0x3a3a, 0x0304, 0x0303},
+					"alpn":                 []interface{}{"h2", "http/1.1"},
+				},
+			}},
+		}}
+		evt, err := p.Run(evt)
+		require.NoError(t, err)
+		ja4, _ := evt.GetValue("tls.client.ja4")
+		assert.Equal(t, "t13d1516h2_8daaf6152771_e5627efa2ab1", ja4)
+	})
+
+	t.Run("missing cipher suites", func(t *testing.T) {
+		evt := &beat.Event{Fields: mapstr.M{"tls": mapstr.M{"client": mapstr.M{"server_name": "example.com"}}}}
+		evt, err := p.Run(evt)
+		require.NoError(t, err)
+		assert.Equal(t, mapstr.M{"tls": mapstr.M{"client": mapstr.M{"server_name": "example.com"}}}, evt.Fields)
+	})
+
+	t.Run("existing fingerprint", func(t *testing.T) {
+		evt := &beat.Event{Fields: mapstr.M{"tls": mapstr.M{"client": mapstr.M{
+			"ja3":   "original",
+			"hello": mapstr.M{"version": 771, "cipher_suites": []int{4865}},
+		}}}}
+		evt, err := p.Run(evt)
+		require.NoError(t, err)
+		ja3, _ := evt.GetValue("tls.client.ja3")
+		assert.Equal(t, "original", ja3)
+		ja4, _ := evt.GetValue("tls.client.ja4")
+		assert.Equal(t, "t12i010000_"+ja4Hash("1301")+"_000000000000", ja4)
+	})
+}
+
+func TestJA4ALPN(t *testing.T) {
+	assert.Equal(t, "00", ja4ALPN(""))
+	assert.Equal(t, "h1", ja4ALPN("http/1.1"))
+	assert.Equal(t, "h2", ja4ALPN("h2"))
+	assert.Equal(t, "a9", ja4ALPN("\xab\xcd\xef\x99"))
+}
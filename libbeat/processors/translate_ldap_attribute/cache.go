@@ -0,0 +1,63 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package translate_ldap_attribute
+
+import (
+	"github.com/go-ldap/ldap/v3"
+
+	"github.com/elastic/beats/v7/libbeat/common"
+)
+
+// resultCache holds the entries found for recently searched values.
+// A nil *resultCache is valid and caches nothing.
+type resultCache struct {
+	entries  *common.Cache
+	capacity int
+}
+
+func newResultCache(c cacheConfig) *resultCache {
+	if !c.Enabled {
+		return nil
+	}
+	entries := common.NewCacheWithExpireOnAdd(c.TTL, 0)
+	entries.StartJanitor(c.TTL)
+	return &resultCache{entries: entries, capacity: c.Capacity}
+}
+
+func (c *resultCache) get(key string) *ldap.Entry {
+	if c == nil {
+		return nil
+	}
+	entry, _ := c.entries.Get(key).(*ldap.Entry)
+	return entry
+}
+
+// put stores entry under key. When the cache is full new entries are
+// dropped until existing ones expire.
+func (c *resultCache) put(key string, entry *ldap.Entry) {
+	if c == nil || c.entries.Size() >= c.capacity {
+		return
+	}
+	c.entries.Put(key, entry)
+}
+
+func (c *resultCache) close() {
+	if c != nil {
+		c.entries.StopJanitor()
+	}
+}
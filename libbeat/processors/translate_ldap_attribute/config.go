@@ -18,6 +18,9 @@
 package translate_ldap_attribute
 
 import (
+	"errors"
+	"time"
+
 	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
 )
 
@@ -32,14 +35,50 @@ type config struct {
 	LDAPMappedAttribute string            `config:"ldap_mapped_attribute" validate:"required"`
 	LDAPSearchTimeLimit int               `config:"ldap_search_time_limit"`
 	LDAPTLS             *tlscommon.Config `config:"ldap_ssl"`
+	LDAPPoolSize        int               `config:"ldap_pool_size" validate:"min=1"`
+	LDAPCache           cacheConfig       `config:"ldap_cache"`
+
+	// LDAPMappedAttributes, if set, overrides LDAPMappedAttribute and
+	// writes an object keyed by attribute name to the target field.
+	LDAPMappedAttributes []string `config:"ldap_mapped_attributes"`
+	// OUPathTarget is the field receiving the organizational unit path
+	// of the matched object.
+	OUPathTarget string `config:"ou_path_target"`
 
 	IgnoreMissing bool `config:"ignore_missing"`
 	IgnoreFailure bool `config:"ignore_failure"`
 }
 
+type cacheConfig struct {
+	Enabled  bool          `config:"enabled"`
+	TTL      time.Duration `config:"ttl"`
+	Capacity int           `config:"capacity" validate:"min=1"`
+}
+
+func (c *config) Validate() error {
+	if c.LDAPCache.Enabled && c.LDAPCache.TTL <= 0 {
+		return errors.New("ldap_cache.ttl must be greater than zero")
+	}
+	return nil
+}
+
+// mappedAttributes returns the LDAP attributes requested for each search.
+func (c *config) mappedAttributes() []string {
+	if len(c.LDAPMappedAttributes) != 0 {
+		return c.LDAPMappedAttributes
+	}
+	return []string{c.LDAPMappedAttribute}
+}
+
 func defaultConfig() config {
 	return config{
 		LDAPSearchAttribute: "objectGUID",
 		LDAPMappedAttribute: "cn",
-		LDAPSearchTimeLimit: 30}
+		LDAPSearchTimeLimit: 30,
+		LDAPPoolSize:        1,
+		LDAPCache: cacheConfig{
+			TTL:      10 * time.Minute,
+			Capacity: 10000,
+		},
+	}
 }
@@ -19,16 +19,19 @@ package translate_ldap_attribute
 
 import (
 	"crypto/tls"
+	"errors"
 	"fmt"
-	"sync"
 
 	"github.com/go-ldap/ldap/v3"
 )
 
-// ldapClient manages a single reusable LDAP connection
+var errNoEntries = errors.New("no entries found")
+
+// ldapClient manages a pool of reusable LDAP connections
 type ldapClient struct {
-	conn *ldap.Conn
-	mu   sync.Mutex
+	// pool holds up to poolSize connections. A nil element is a free
+	// slot for which no connection has been established yet.
+	pool chan *ldap.Conn
 	*ldapConfig
 }
 
@@ -38,28 +41,39 @@ type ldapConfig struct {
 	username        string
 	password        string
 	searchAttr      string
-	mappedAttr      string
+	mappedAttrs     []string
 	searchTimeLimit int
+	poolSize        int
 	tlsConfig       *tls.Config
 }
 
-// newLDAPClient initializes a new ldapClient with a single connection
+// newLDAPClient initializes a new ldapClient and establishes the first
+// connection of the pool so configuration errors are reported early.
 func newLDAPClient(config *ldapConfig) (*ldapClient, error) {
-	client := &ldapClient{ldapConfig: config}
+	size := config.poolSize
+	if size < 1 {
+		size = 1
+	}
+	client := &ldapClient{
+		pool:       make(chan *ldap.Conn, size),
+		ldapConfig: config,
+	}
 
 	// Establish initial connection
-	if err := client.connect(); err != nil {
+	conn, err := client.connect()
+	if err != nil {
 		return nil, err
 	}
+	client.pool <- conn
+	for i := 1; i < size; i++ {
+		client.pool <- nil
+	}
 
 	return client, nil
 }
 
 // connect establishes a new connection to the LDAP server
-func (client *ldapClient) connect() error {
-	client.mu.Lock()
-	defer client.mu.Unlock()
-
+func (client *ldapClient) connect() (*ldap.Conn, error) {
 	// Connect with or without TLS based on configuration
 	var opts []ldap.DialOpt
 	if client.tlsConfig != nil {
@@ -67,7 +81,7 @@ func (client *ldapClient) connect() error {
 	}
 	conn, err := ldap.DialURL(client.address, opts...)
 	if err != nil {
-		return fmt.Errorf("failed to dial LDAP server: %w", err)
+		return nil, fmt.Errorf("failed to dial LDAP server: %w", err)
 	}
 
 	if client.password != "" {
@@ -78,62 +92,75 @@ func (client *ldapClient) connect() error {
 
 	if err != nil {
 		conn.Close()
-		return fmt.Errorf("failed to bind to LDAP server: %w", err)
+		return nil, fmt.Errorf("failed to bind to LDAP server: %w", err)
 	}
 
-	client.conn = conn
-	return nil
+	return conn, nil
 }
 
-// reconnect checks the connection's health and reconnects if necessary
-func (client *ldapClient) reconnect() error {
-	client.mu.Lock()
-	defer client.mu.Unlock()
+// acquire takes a connection from the pool, reconnecting if the pooled
+// connection is missing or no longer alive. The returned connection must
+// be handed back with release.
+func (client *ldapClient) acquire() (*ldap.Conn, error) {
+	conn := <-client.pool
+	if conn != nil && !conn.IsClosing() {
+		return conn, nil
+	}
+	if conn != nil {
+		conn.Close()
+	}
+	conn, err := client.connect()
+	if err != nil {
+		client.pool <- nil
+		return nil, err
+	}
+	return conn, nil
+}
 
-	// Check if the connection is still alive
-	if client.conn.IsClosing() {
-		return client.connect()
+// release returns a connection to the pool. Connections that failed with
+// a network error are closed and replaced by a free slot.
+func (client *ldapClient) release(conn *ldap.Conn, err error) {
+	if err != nil && ldap.IsErrorWithCode(err, ldap.ErrorNetwork) {
+		conn.Close()
+		conn = nil
 	}
-	return nil
+	client.pool <- conn
 }
 
-// findObjectBy searches for an object and returns its mapped values.
-func (client *ldapClient) findObjectBy(searchBy string) ([]string, error) {
-	// Ensure the connection is alive or reconnect if necessary
-	if err := client.reconnect(); err != nil {
+// findObjectBy searches for an object and returns its entry holding the
+// mapped attributes.
+func (client *ldapClient) findObjectBy(searchBy string) (*ldap.Entry, error) {
+	conn, err := client.acquire()
+	if err != nil {
 		return nil, fmt.Errorf("failed to reconnect: %w", err)
 	}
 
-	client.mu.Lock()
-	defer client.mu.Unlock()
-
 	// Format the filter and perform the search
 	filter := fmt.Sprintf("(%s=%s)", client.searchAttr, searchBy)
 	searchRequest := ldap.NewSearchRequest(
 		client.baseDN,
 		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 1, client.searchTimeLimit, false,
-		filter, []string{client.mappedAttr}, nil,
+		filter, client.mappedAttrs, nil,
 	)
 
 	// Execute search
-	result, err := client.conn.Search(searchRequest)
+	result, err := conn.Search(searchRequest)
+	client.release(conn, err)
 	if err != nil {
 		return nil, fmt.Errorf("search failed: %w", err)
 	}
 	if len(result.Entries) == 0 {
-		return nil, fmt.Errorf("no entries found for search attribute %s", searchBy)
+		return nil, fmt.Errorf("%w for search attribute %s", errNoEntries, searchBy)
 	}
 
-	// Retrieve the CN attribute
-	cn := result.Entries[0].GetAttributeValues(client.mappedAttr)
-	return cn, nil
+	return result.Entries[0], nil
 }
 
-// close closes the LDAP connection
+// close closes all pooled LDAP connections
 func (client *ldapClient) close() {
-	client.mu.Lock()
-	defer client.mu.Unlock()
-	if client.conn != nil {
-		client.conn.Close()
+	for i := 0; i < cap(client.pool); i++ {
+		if conn := <-client.pool; conn != nil {
+			conn.Close()
+		}
 	}
 }
@@ -0,0 +1,105 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package translate_ldap_attribute
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+var errInvalidSID = errors.New("invalid security identifier")
+
+// searchValue returns the filter value used to search for value. Active
+// Directory stores objectSid as a binary attribute, so string SIDs such as
+// S-1-5-21-... are converted to their escaped binary form. Other values
+// are used as is.
+func searchValue(attr, value string) (string, error) {
+	if !strings.EqualFold(attr, "objectSid") || !strings.HasPrefix(strings.ToUpper(value), "S-") {
+		return value, nil
+	}
+	b, err := sidBytes(value)
+	if err != nil {
+		return "", err
+	}
+	var buf strings.Builder
+	for _, c := range b {
+		fmt.Fprintf(&buf, `\%02x`, c)
+	}
+	return buf.String(), nil
+}
+
+// sidBytes encodes a string SID (S-R-I-S-S...) in the binary layout used
+// by Windows: revision, sub-authority count, a 48-bit big endian identifier
+// authority and little endian 32-bit sub-authorities.
+func sidBytes(sid string) ([]byte, error) {
+	parts := strings.Split(sid, "-")
+	if len(parts) < 3 || !strings.EqualFold(parts[0], "S") {
+		return nil, fmt.Errorf("%w: %s", errInvalidSID, sid)
+	}
+	subAuths := parts[3:]
+	if len(subAuths) > 15 {
+		return nil, fmt.Errorf("%w: too many sub-authorities in %s", errInvalidSID, sid)
+	}
+	rev, err := strconv.ParseUint(parts[1], 10, 8)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errInvalidSID, sid)
+	}
+	auth, err := strconv.ParseUint(parts[2], 10, 48)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errInvalidSID, sid)
+	}
+
+	b := make([]byte, 8, 8+4*len(subAuths))
+	b[0] = byte(rev)
+	b[1] = byte(len(subAuths))
+	for i := 0; i < 6; i++ {
+		b[7-i] = byte(auth >> (8 * i))
+	}
+	for _, s := range subAuths {
+		v, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", errInvalidSID, sid)
+		}
+		b = binary.LittleEndian.AppendUint32(b, uint32(v))
+	}
+	return b, nil
+}
+
+// ouPath returns the organizational units of dn from the outermost to the
+// innermost, separated by slashes. For example the DN
+// CN=Jane,OU=Sales,OU=Staff,DC=example,DC=com has the path Staff/Sales.
+func ouPath(dn string) (string, error) {
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil {
+		return "", err
+	}
+	var ous []string
+	for i := len(parsed.RDNs) - 1; i >= 0; i-- {
+		for _, attr := range parsed.RDNs[i].Attributes {
+			if strings.EqualFold(attr.Type, "OU") {
+				ous = append(ous, attr.Value)
+			}
+		}
+	}
+	return strings.Join(ous, "/"), nil
+}
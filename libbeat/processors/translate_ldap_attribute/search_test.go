@@ -0,0 +1,80 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package translate_ldap_attribute
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchValue(t *testing.T) {
+	tests := []struct {
+		attr, value string
+		want        string
+		wantErr     error
+	}{
+		{attr: "objectSid", value: "S-1-5-32-544", want: `\01\02\00\00\00\00\00\05\20\00\00\00\20\02\00\00`},
+		{attr: "objectsid", value: "S-1-1-0", want: `\01\01\00\00\00\00\00\01\00\00\00\00`},
+		{attr: "objectSid", value: `\01\01\00\00\00\00\00\01\00\00\00\00`, want: `\01\01\00\00\00\00\00\01\00\00\00\00`},
+		{attr: "objectGUID", value: "S-1-5-32-544", want: "S-1-5-32-544"},
+		{attr: "objectSid", value: "S-1-5-x", wantErr: errInvalidSID},
+		{attr: "objectSid", value: "S-1", wantErr: errInvalidSID},
+	}
+	for _, tc := range tests {
+		got, err := searchValue(tc.attr, tc.value)
+		if tc.wantErr != nil {
+			assert.ErrorIs(t, err, tc.wantErr, tc.value)
+			continue
+		}
+		require.NoError(t, err, tc.value)
+		assert.Equal(t, tc.want, got, tc.value)
+	}
+}
+
+func TestOUPath(t *testing.T) {
+	path, err := ouPath("CN=Jane Doe,OU=Sales,OU=Staff,DC=example,DC=com")
+	require.NoError(t, err)
+	assert.Equal(t, "Staff/Sales", path)
+
+	path, err = ouPath("CN=Administrator,CN=Users,DC=example,DC=com")
+	require.NoError(t, err)
+	assert.Empty(t, path)
+
+	_, err = ouPath("not a dn")
+	assert.Error(t, err)
+}
+
+func TestResultCache(t *testing.T) {
+	var disabled *resultCache
+	disabled.put("a", &ldap.Entry{DN: "a"})
+	assert.Nil(t, disabled.get("a"))
+	disabled.close()
+
+	c := newResultCache(cacheConfig{Enabled: true, TTL: time.Minute, Capacity: 1})
+	defer c.close()
+	c.put("a", &ldap.Entry{DN: "a"})
+	c.put("b", &ldap.Entry{DN: "b"})
+	if assert.NotNil(t, c.get("a")) {
+		assert.Equal(t, "a", c.get("a").DN)
+	}
+	assert.Nil(t, c.get("b"), "entries beyond capacity must not be cached")
+}
@@ -21,6 +21,8 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/go-ldap/ldap/v3"
+
 	"github.com/elastic/beats/v7/libbeat/beat"
 	"github.com/elastic/beats/v7/libbeat/processors"
 	jsprocessor "github.com/elastic/beats/v7/libbeat/processors/script/javascript/module/processor"
@@ -42,6 +44,7 @@ func init() {
 type processor struct {
 	config
 	client *ldapClient
+	cache  *resultCache
 	log    *logp.Logger
 }
 
@@ -61,8 +64,9 @@ func newFromConfig(c config) (*processor, error) {
 		username:        c.LDAPBindUser,
 		password:        c.LDAPBindPassword,
 		searchAttr:      c.LDAPSearchAttribute,
-		mappedAttr:      c.LDAPMappedAttribute,
+		mappedAttrs:     c.mappedAttributes(),
 		searchTimeLimit: c.LDAPSearchTimeLimit,
+		poolSize:        c.LDAPPoolSize,
 	}
 	if c.LDAPTLS != nil {
 		tlsConfig, err := tlscommon.LoadTLSConfig(c.LDAPTLS)
@@ -78,6 +82,7 @@ func newFromConfig(c config) (*processor, error) {
 	return &processor{
 		config: c,
 		client: client,
+		cache:  newResultCache(c.LDAPCache),
 		log:    logp.NewLogger(logName),
 	}, nil
 }
@@ -101,12 +106,12 @@ func (p *processor) translateLDAPAttr(event *beat.Event) error {
 		return err
 	}
 
-	guidString, ok := v.(string)
+	value, ok := v.(string)
 	if !ok {
 		return errInvalidType
 	}
 
-	cn, err := p.client.findObjectBy(guidString)
+	entry, err := p.lookup(value)
 	if err != nil {
 		return err
 	}
@@ -115,11 +120,54 @@ func (p *processor) translateLDAPAttr(event *beat.Event) error {
 	if p.TargetField != "" {
 		field = p.TargetField
 	}
-	_, err = event.PutValue(field, cn)
-	return err
+	if len(p.LDAPMappedAttributes) == 0 {
+		_, err = event.PutValue(field, entry.GetAttributeValues(p.LDAPMappedAttribute))
+	} else {
+		attrs := make(mapstr.M, len(p.LDAPMappedAttributes))
+		for _, name := range p.LDAPMappedAttributes {
+			if values := entry.GetAttributeValues(name); len(values) != 0 {
+				attrs[name] = values
+			}
+		}
+		_, err = event.PutValue(field, attrs)
+	}
+	if err != nil {
+		return err
+	}
+
+	if p.OUPathTarget != "" {
+		path, err := ouPath(entry.DN)
+		if err != nil {
+			return fmt.Errorf("failed to parse DN %q: %w", entry.DN, err)
+		}
+		if path != "" {
+			_, err = event.PutValue(p.OUPathTarget, path)
+		}
+		return err
+	}
+	return nil
+}
+
+// lookup returns the LDAP entry matching value, using the result cache
+// when enabled.
+func (p *processor) lookup(value string) (*ldap.Entry, error) {
+	if entry := p.cache.get(value); entry != nil {
+		return entry, nil
+	}
+	search, err := searchValue(p.LDAPSearchAttribute, value)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := p.client.findObjectBy(search)
+	if err != nil {
+		return nil, err
+	}
+	p.cache.put(value, entry)
+	return entry, nil
 }
 
 func (p *processor) Close() error {
+	p.cache.close()
 	p.client.close()
 	return nil
 }
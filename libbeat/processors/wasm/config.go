@@ -0,0 +1,48 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package wasm
+
+import (
+	"fmt"
+	"time"
+)
+
+type wasmConfig struct {
+	Tag                string        `config:"tag"`                                   // Processor ID for errors.
+	File               string        `config:"file" validate:"required"`              // WebAssembly module file.
+	Function           string        `config:"function"`                              // Exported function processing the events.
+	Timeout            time.Duration `config:"timeout" validate:"min=0"`              // Execution timeout.
+	TagOnException     string        `config:"tag_on_exception"`                      // Tag to add to events when the module fails.
+	MaxCachedInstances int           `config:"max_cached_instances" validate:"min=0"` // Max. number of cached module instances.
+}
+
+func defaultConfig() wasmConfig {
+	return wasmConfig{
+		Function:           "process",
+		TagOnException:     "_wasm_exception",
+		MaxCachedInstances: 4,
+	}
+}
+
+func (c *wasmConfig) Validate() error {
+	switch c.Function {
+	case "", exportAlloc, exportFree:
+		return fmt.Errorf("invalid function %q", c.Function)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+;; Test module of the wasm processor, compiled with: wat2wasm process.wat
+;;
+;; Each exported function implements the processing ABI with a different
+;; behaviour, the tests select them with the function setting.
+(module
+  (memory (export "memory") 1)
+  (data (i32.const 16) "{\"message\":\"enriched\",\"@metadata\":{\"pipeline\":\"wasm\"}}")
+
+  ;; alloc returns the same buffer for every event, the processor writes one
+  ;; event at a time.
+  (func (export "alloc") (param $size i32) (result i32)
+    (if (i32.gt_u (local.get $size) (i32.const 60000))
+      (then unreachable))
+    i32.const 1024)
+
+  (func (export "free") (param $ptr i32) (param $len i32))
+
+  ;; identity returns the event unchanged.
+  (func (export "identity") (param $ptr i32) (param $len i32) (result i64)
+    (i64.or
+      (i64.shl (i64.extend_i32_u (local.get $ptr)) (i64.const 32))
+      (i64.extend_i32_u (local.get $len))))
+
+  ;; enrich replaces the fields and metadata of the event.
+  (func (export "enrich") (param $ptr i32) (param $len i32) (result i64)
+    i64.const 0x1000000036)
+
+  ;; drop drops the event.
+  (func (export "drop") (param $ptr i32) (param $len i32) (result i64)
+    i64.const 0)
+
+  ;; fail traps.
+  (func (export "fail") (param $ptr i32) (param $len i32) (result i64)
+    unreachable)
+
+  ;; spin never returns.
+  (func (export "spin") (param $ptr i32) (param $len i32) (result i64)
+    (loop $forever (br $forever))
+    unreachable)
+
+  ;; truncated returns the first 5 bytes of the enrich output.
+  (func (export "truncated") (param $ptr i32) (param $len i32) (result i64)
+    i64.const 0x1000000005)
+
+  ;; wrong_signature doesn't implement the ABI.
+  (func (export "wrong_signature") (param $ptr i32) (result i32)
+    local.get $ptr))
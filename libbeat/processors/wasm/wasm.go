@@ -0,0 +1,394 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package wasm implements a processor running the events through a
+// WebAssembly module.
+//
+// The module exchanges events with the processor as JSON documents, the event
+// fields with the @timestamp and @metadata keys, in its memory. It must
+// export:
+//
+//   - memory, its linear memory.
+//   - alloc(size i32) i32, returning a buffer of size bytes in which the
+//     processor writes the event.
+//   - the processing function, process by default, with the (ptr i32,
+//     len i32) i64 signature. It receives the buffer returned by alloc and
+//     returns the result, 0 to drop the event or the pointer in the upper 32
+//     bits and the length in the lower 32 bits of the JSON document that
+//     replaces the event. The @timestamp and @metadata of the event are kept
+//     when the document doesn't set them.
+//
+// The module can export free(ptr i32, len i32), which is called with the
+// buffers of the event and of the result once the processor is done with
+// them. It can import the WASI preview 1 functions and must be built as a
+// reactor: its _initialize function is called when it's instantiated, and
+// its _start function isn't.
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/common/jsontransform"
+	"github.com/elastic/beats/v7/libbeat/processors"
+	"github.com/elastic/beats/v7/libbeat/processors/checks"
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/paths"
+)
+
+const (
+	procName = "wasm"
+
+	exportMemory = "memory"
+	exportAlloc  = "alloc"
+	exportFree   = "free"
+)
+
+var errTimeout = errors.New("wasm processor execution timeout")
+
+func init() {
+	processors.RegisterPlugin(procName,
+		checks.ConfigChecked(New,
+			checks.AllowedFields(
+				"tag", "file", "function", "timeout",
+				"tag_on_exception", "max_cached_instances", "when",
+			)))
+}
+
+type wasmProcessor struct {
+	wasmConfig
+
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+	// instances caches the module instances, an instance runs a single
+	// event at a time.
+	instances chan *instance
+}
+
+// New constructs a new wasm processor.
+func New(c *config.C) (beat.Processor, error) {
+	conf := defaultConfig()
+	if err := c.Unpack(&conf); err != nil {
+		return nil, fmt.Errorf("fail to unpack the "+procName+" processor configuration: %w", err)
+	}
+
+	p, err := newWasmProcessor(conf)
+	if err != nil {
+		return nil, annotateError(conf.Tag, err)
+	}
+	return p, nil
+}
+
+func newWasmProcessor(c wasmConfig) (*wasmProcessor, error) {
+	path := paths.Resolve(paths.Config, c.File)
+	if common.IsStrictPerms() {
+		if err := common.OwnerHasExclusiveWritePerms(path); err != nil {
+			return nil, err
+		}
+	}
+	binary, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read module: %w", err)
+	}
+
+	ctx := context.Background()
+	// Closing the instances when their context is done interrupts modules
+	// running for longer than the timeout.
+	runtime := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().WithCloseOnContextDone(true))
+	p := &wasmProcessor{
+		wasmConfig: c,
+		runtime:    runtime,
+		instances:  make(chan *instance, c.MaxCachedInstances),
+	}
+	if err := p.init(ctx, binary); err != nil {
+		runtime.Close(ctx)
+		return nil, err
+	}
+	return p, nil
+}
+
+// init compiles the module, checks its exports and instantiates it once to
+// report instantiation errors early.
+func (p *wasmProcessor) init(ctx context.Context, binary []byte) error {
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, p.runtime); err != nil {
+		return fmt.Errorf("failed to instantiate WASI: %w", err)
+	}
+	var err error
+	p.compiled, err = p.runtime.CompileModule(ctx, binary)
+	if err != nil {
+		return fmt.Errorf("failed to compile module %s: %w", p.File, err)
+	}
+
+	if _, ok := p.compiled.ExportedMemories()[exportMemory]; !ok {
+		return fmt.Errorf("module %s doesn't export its memory", p.File)
+	}
+	i32, i64 := api.ValueTypeI32, api.ValueTypeI64
+	functions := p.compiled.ExportedFunctions()
+	for _, f := range []struct {
+		name            string
+		params, results []api.ValueType
+		optional        bool
+	}{
+		{name: exportAlloc, params: []api.ValueType{i32}, results: []api.ValueType{i32}},
+		{name: exportFree, params: []api.ValueType{i32, i32}, optional: true},
+		{name: p.Function, params: []api.ValueType{i32, i32}, results: []api.ValueType{i64}},
+	} {
+		def, ok := functions[f.name]
+		if !ok {
+			if f.optional {
+				continue
+			}
+			return fmt.Errorf("module %s doesn't export the %s function", p.File, f.name)
+		}
+		if !bytes.Equal(def.ParamTypes(), f.params) || !bytes.Equal(def.ResultTypes(), f.results) {
+			return fmt.Errorf("function %s of module %s must have the %s signature, not %s",
+				f.name, p.File, signature(f.params, f.results), signature(def.ParamTypes(), def.ResultTypes()))
+		}
+	}
+
+	inst, err := p.instantiate(ctx)
+	if err != nil {
+		return err
+	}
+	p.put(inst)
+	return nil
+}
+
+func signature(params, results []api.ValueType) string {
+	names := func(types []api.ValueType) []string {
+		s := make([]string, len(types))
+		for i, t := range types {
+			s[i] = api.ValueTypeName(t)
+		}
+		return s
+	}
+	return fmt.Sprintf("%v -> %v", names(params), names(results))
+}
+
+func (p *wasmProcessor) instantiate(ctx context.Context) (*instance, error) {
+	mod, err := p.runtime.InstantiateModule(ctx, p.compiled,
+		// Instances are anonymous so that the module can be instantiated
+		// more than once.
+		wazero.NewModuleConfig().WithName("").WithStartFunctions("_initialize"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to instantiate module %s: %w", p.File, err)
+	}
+	return &instance{
+		mod:     mod,
+		memory:  mod.Memory(),
+		alloc:   mod.ExportedFunction(exportAlloc),
+		free:    mod.ExportedFunction(exportFree),
+		process: mod.ExportedFunction(p.Function),
+	}, nil
+}
+
+// get returns a cached instance, or a new one if none is available.
+func (p *wasmProcessor) get(ctx context.Context) (*instance, error) {
+	select {
+	case inst := <-p.instances:
+		return inst, nil
+	default:
+		return p.instantiate(ctx)
+	}
+}
+
+// put caches an instance, or closes it if the cache is full.
+func (p *wasmProcessor) put(inst *instance) {
+	select {
+	case p.instances <- inst:
+	default:
+		inst.mod.Close(context.Background())
+	}
+}
+
+// Run executes the processing function of the module on the event.
+func (p *wasmProcessor) Run(event *beat.Event) (*beat.Event, error) {
+	out, err := p.run(event)
+	if err != nil {
+		err = annotateError(p.Tag, err)
+		if p.TagOnException != "" {
+			_ = mapstr.AddTags(event.Fields, []string{p.TagOnException})
+		}
+		_, _ = event.PutValue("error.message", err.Error())
+		return event, err
+	}
+	return out, nil
+}
+
+func (p *wasmProcessor) run(event *beat.Event) (*beat.Event, error) {
+	in, err := encodeEvent(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	ctx := context.Background()
+	if p.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+		defer cancel()
+	}
+	inst, err := p.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out, err := inst.call(ctx, in)
+	if err != nil {
+		// The state of the instance is unknown after a failure.
+		inst.mod.Close(context.Background())
+		var exitErr *sys.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == sys.ExitCodeDeadlineExceeded {
+			return nil, errTimeout
+		}
+		return nil, fmt.Errorf("failed in %s function: %w", p.Function, err)
+	}
+	p.put(inst)
+
+	if out == nil {
+		return nil, nil
+	}
+	if err := decodeEvent(out, event); err != nil {
+		return nil, fmt.Errorf("invalid result of %s function: %w", p.Function, err)
+	}
+	return event, nil
+}
+
+// Close releases the module and its instances.
+func (p *wasmProcessor) Close() error {
+	return p.runtime.Close(context.Background())
+}
+
+func (p *wasmProcessor) String() string {
+	return procName + "=[id=" + p.Tag + ", file=" + p.File + ", function=" + p.Function + "]"
+}
+
+func annotateError(id string, err error) error {
+	if id != "" {
+		return fmt.Errorf("failed in processor.wasm with id=%v: %w", id, err)
+	}
+	return fmt.Errorf("failed in processor.wasm: %w", err)
+}
+
+type instance struct {
+	mod                  api.Module
+	memory               api.Memory
+	alloc, free, process api.Function
+}
+
+// call passes the event document to the processing function and returns a
+// copy of the document it returns, or nil if the event is dropped.
+func (i *instance) call(ctx context.Context, in []byte) ([]byte, error) {
+	res, err := i.alloc.Call(ctx, uint64(len(in)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to allocate %d bytes: %w", len(in), err)
+	}
+	inPtr := uint32(res[0])
+	if !i.memory.Write(inPtr, in) {
+		return nil, fmt.Errorf("alloc returned a buffer out of memory bounds")
+	}
+
+	res, err = i.process.Call(ctx, uint64(inPtr), uint64(len(in)))
+	if err != nil {
+		return nil, err
+	}
+	outPtr, outLen := uint32(res[0]>>32), uint32(res[0])
+	var out []byte
+	if res[0] != 0 {
+		view, ok := i.memory.Read(outPtr, outLen)
+		if !ok {
+			return nil, fmt.Errorf("result out of memory bounds")
+		}
+		out = bytes.Clone(view)
+	}
+
+	if i.free != nil {
+		if _, err := i.free.Call(ctx, uint64(inPtr), uint64(len(in))); err != nil {
+			return nil, fmt.Errorf("failed to free the event: %w", err)
+		}
+		if out != nil && outPtr != inPtr {
+			if _, err := i.free.Call(ctx, uint64(outPtr), uint64(outLen)); err != nil {
+				return nil, fmt.Errorf("failed to free the result: %w", err)
+			}
+		}
+	}
+	return out, nil
+}
+
+// encodeEvent returns the JSON document of the event passed to the module.
+func encodeEvent(event *beat.Event) ([]byte, error) {
+	doc := make(map[string]interface{}, len(event.Fields)+2)
+	for k, v := range event.Fields {
+		doc[k] = v
+	}
+	doc["@timestamp"] = event.Timestamp.UTC().Format(time.RFC3339Nano)
+	if len(event.Meta) > 0 {
+		doc["@metadata"] = event.Meta
+	}
+	return json.Marshal(doc)
+}
+
+// decodeEvent replaces the event with the JSON document returned by the
+// module.
+func decodeEvent(data []byte, event *beat.Event) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc mapstr.M
+	if err := dec.Decode(&doc); err != nil {
+		return err
+	}
+	if doc == nil {
+		return errors.New("result is not an object")
+	}
+	jsontransform.TransformNumbers(doc)
+
+	timestamp := event.Timestamp
+	if v, ok := doc["@timestamp"]; ok {
+		s, _ := v.(string)
+		ts, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return fmt.Errorf("invalid @timestamp %v", v)
+		}
+		timestamp = ts
+		delete(doc, "@timestamp")
+	}
+	meta := event.Meta
+	if v, ok := doc["@metadata"]; ok {
+		m, ok := v.(map[string]interface{})
+		if !ok && v != nil {
+			return fmt.Errorf("invalid @metadata %v", v)
+		}
+		meta = m
+		delete(doc, "@metadata")
+	}
+
+	event.Timestamp = timestamp
+	event.Meta = meta
+	event.Fields = doc
+	return nil
+}
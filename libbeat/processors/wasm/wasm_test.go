@@ -0,0 +1,166 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package wasm
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/processors"
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// testModule is compiled from testdata/process.wat.
+const testModule = "testdata/process.wasm"
+
+func newTestProcessor(t *testing.T, settings map[string]interface{}) beat.Processor {
+	t.Helper()
+	settings["file"] = testModule
+	p, err := New(config.MustNewConfigFrom(settings))
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, processors.Close(p)) })
+	return p
+}
+
+func testEvent() *beat.Event {
+	return &beat.Event{
+		Timestamp: time.Date(2024, 3, 1, 10, 0, 0, 123456789, time.UTC),
+		Meta:      mapstr.M{"pipeline": "logs"},
+		Fields: mapstr.M{
+			"message": "hello",
+			"http":    mapstr.M{"response": mapstr.M{"status_code": 200, "bytes": 1.5}},
+			"tags":    []string{"edge"},
+		},
+	}
+}
+
+func TestWasmIdentity(t *testing.T) {
+	p := newTestProcessor(t, map[string]interface{}{"function": "identity"})
+
+	// The instances are reused by the events and shared by goroutines.
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				event, err := p.Run(testEvent())
+				if !assert.NoError(t, err) {
+					return
+				}
+				assert.Equal(t, testEvent().Timestamp, event.Timestamp)
+				assert.Equal(t, mapstr.M{"pipeline": "logs"}, event.Meta)
+				assert.Equal(t, mapstr.M{
+					"message": "hello",
+					"http":    map[string]interface{}{"response": map[string]interface{}{"status_code": int64(200), "bytes": 1.5}},
+					"tags":    []interface{}{"edge"},
+				}, event.Fields)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestWasmEnrich(t *testing.T) {
+	p := newTestProcessor(t, map[string]interface{}{"function": "enrich"})
+	event, err := p.Run(testEvent())
+	require.NoError(t, err)
+	assert.Equal(t, testEvent().Timestamp, event.Timestamp, "the timestamp is kept")
+	assert.Equal(t, mapstr.M{"pipeline": "wasm"}, event.Meta)
+	assert.Equal(t, mapstr.M{"message": "enriched"}, event.Fields)
+}
+
+func TestWasmDrop(t *testing.T) {
+	p := newTestProcessor(t, map[string]interface{}{"function": "drop"})
+	event, err := p.Run(testEvent())
+	require.NoError(t, err)
+	assert.Nil(t, event)
+}
+
+func TestWasmErrors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		settings map[string]interface{}
+		err      string
+	}{
+		"trap": {
+			settings: map[string]interface{}{"function": "fail", "tag": "enrich"},
+			err:      "failed in processor.wasm with id=enrich: failed in fail function: wasm error: unreachable",
+		},
+		"timeout": {
+			settings: map[string]interface{}{"function": "spin", "timeout": "50ms"},
+			err:      "failed in processor.wasm: wasm processor execution timeout",
+		},
+		"invalid result": {
+			settings: map[string]interface{}{"function": "truncated"},
+			err:      "failed in processor.wasm: invalid result of truncated function: unexpected EOF",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			p := newTestProcessor(t, tc.settings)
+			for i := 0; i < 2; i++ {
+				event, err := p.Run(testEvent())
+				require.Error(t, err)
+				assert.ErrorContains(t, err, tc.err)
+				require.NotNil(t, event, "the event is kept on errors")
+				assert.Equal(t, "hello", event.Fields["message"])
+				assert.Equal(t, []string{"edge", "_wasm_exception"}, event.Fields["tags"])
+				msg, _ := event.GetValue("error.message")
+				assert.Equal(t, err.Error(), msg)
+			}
+		})
+	}
+}
+
+func TestWasmInvalidModule(t *testing.T) {
+	for name, tc := range map[string]struct {
+		settings map[string]interface{}
+		err      string
+	}{
+		"missing function": {
+			settings: map[string]interface{}{"file": testModule},
+			err:      "doesn't export the process function",
+		},
+		"wrong signature": {
+			settings: map[string]interface{}{"file": testModule, "function": "wrong_signature"},
+			err:      "function wrong_signature of module testdata/process.wasm must have the [i32 i32] -> [i64] signature, not [i32] -> [i32]",
+		},
+		"reserved function": {
+			settings: map[string]interface{}{"file": testModule, "function": "alloc"},
+			err:      `invalid function "alloc"`,
+		},
+		"not a module": {
+			settings: map[string]interface{}{"file": "testdata/process.wat"},
+			err:      "failed to compile module testdata/process.wat",
+		},
+		"missing file": {
+			settings: map[string]interface{}{},
+			err:      "string value is not set accessing 'file'",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := New(config.MustNewConfigFrom(tc.settings))
+			assert.ErrorContains(t, err, tc.err)
+		})
+	}
+}
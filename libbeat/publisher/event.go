@@ -18,6 +18,8 @@
 package publisher
 
 import (
+	"errors"
+
 	"github.com/elastic/beats/v7/libbeat/beat"
 	"github.com/elastic/elastic-agent-libs/mapstr"
 )
@@ -65,8 +67,16 @@ type Event struct {
 	// to free the unencoded data. The updated event will be provided to
 	// output workers when calling Publish.
 	EncodedEvent interface{}
+
+	// OnReject is set by the pipeline if the publishing client asked to be
+	// informed about events the outputs permanently give up on.
+	OnReject func(err error)
 }
 
+// ErrEventRejected is the error reported by Event.Reject for events that
+// will not be delivered.
+var ErrEventRejected = errors.New("event rejected")
+
 // EventFlags provides additional flags/option types  for used with the outputs.
 type EventFlags uint8
 
@@ -104,6 +114,15 @@ const (
 	GuaranteedSend EventFlags = 0x01
 )
 
+// Reject reports that the output permanently gave up on the event. err
+// should wrap ErrEventRejected. Outputs must call Reject before ACKing
+// the batch containing the event.
+func (e *Event) Reject(err error) {
+	if e.OnReject != nil {
+		e.OnReject(err)
+	}
+}
+
 // Guaranteed checks if the event must not be dropped by the output or the
 // publisher pipeline.
 func (e *Event) Guaranteed() bool {
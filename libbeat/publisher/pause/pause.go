@@ -0,0 +1,227 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package pause allows publishing of components, like inputs, to be
+// paused and resumed at runtime. A paused component blocks when it
+// publishes events, which applies back pressure to it as if the queue
+// was full.
+package pause
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/publisher/pipetool"
+)
+
+// Default is the registry used by the inputs of the beat.
+var Default = NewRegistry()
+
+// Gate blocks publishing while it is paused.
+type Gate struct {
+	mu      sync.Mutex
+	paused  bool
+	resumed chan struct{} // Closed when the gate is resumed.
+}
+
+// Pause pauses the gate. It is a no-op if the gate is already paused.
+func (g *Gate) Pause() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.paused {
+		g.paused = true
+		g.resumed = make(chan struct{})
+	}
+}
+
+// Resume resumes the gate, releasing the publishers waiting for it.
+func (g *Gate) Resume() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.paused {
+		g.paused = false
+		close(g.resumed)
+	}
+}
+
+// Paused returns whether the gate is paused.
+func (g *Gate) Paused() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.paused
+}
+
+// Resumed returns a channel that is closed when the gate is resumed, or
+// nil if it is not paused.
+func (g *Gate) Resumed() <-chan struct{} {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.paused {
+		return nil
+	}
+	return g.resumed
+}
+
+// Registry holds the gates of the running components, by ID.
+type Registry struct {
+	mu    sync.Mutex
+	gates map[string]*registeredGate
+}
+
+type registeredGate struct {
+	gate *Gate
+	refs int
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{gates: make(map[string]*registeredGate)}
+}
+
+// Register returns the gate of the component with the given ID, and a
+// function to call once the component is stopped. Components registered
+// with the same ID share their gate. The gate is resumed once all of them
+// are unregistered.
+func (r *Registry) Register(id string) (gate *Gate, unregister func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, found := r.gates[id]
+	if !found {
+		entry = &registeredGate{gate: &Gate{}}
+		r.gates[id] = entry
+	}
+	entry.refs++
+
+	var once sync.Once
+	return entry.gate, func() {
+		once.Do(func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			entry.refs--
+			if entry.refs == 0 {
+				delete(r.gates, id)
+				entry.gate.Resume()
+			}
+		})
+	}
+}
+
+// Get returns the gate of the component with the given ID, or nil if no
+// such component is registered.
+func (r *Registry) Get(id string) *Gate {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if entry, found := r.gates[id]; found {
+		return entry.gate
+	}
+	return nil
+}
+
+// Pause pauses the component with the given ID. It returns false if no
+// such component is registered.
+func (r *Registry) Pause(id string) bool {
+	gate := r.Get(id)
+	if gate == nil {
+		return false
+	}
+	gate.Pause()
+	return true
+}
+
+// Resume resumes the component with the given ID. It returns false if no
+// such component is registered.
+func (r *Registry) Resume(id string) bool {
+	gate := r.Get(id)
+	if gate == nil {
+		return false
+	}
+	gate.Resume()
+	return true
+}
+
+// Paused returns whether the component with the given ID is paused, and
+// whether it is registered.
+func (r *Registry) Paused(id string) (paused, found bool) {
+	gate := r.Get(id)
+	if gate == nil {
+		return false, false
+	}
+	return gate.Paused(), true
+}
+
+// IDs returns the sorted IDs of the registered components.
+func (r *Registry) IDs() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := make([]string, 0, len(r.gates))
+	for id := range r.gates {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// WithGate returns a pipeline connector whose clients block when they
+// publish events while the gate is paused. Blocked clients drop their
+// events and return when they are closed or when done is closed.
+func WithGate(pipeline beat.PipelineConnector, gate *Gate, done <-chan struct{}) beat.PipelineConnector {
+	return pipetool.WithClientWrapper(pipeline, func(client beat.Client) beat.Client {
+		return &gatedClient{client: client, gate: gate, done: done, closed: make(chan struct{})}
+	})
+}
+
+type gatedClient struct {
+	client    beat.Client
+	gate      *Gate
+	done      <-chan struct{}
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func (c *gatedClient) wait() bool {
+	resumed := c.gate.Resumed()
+	if resumed == nil {
+		return true
+	}
+	select {
+	case <-resumed:
+		return true
+	case <-c.closed:
+		return false
+	case <-c.done:
+		return false
+	}
+}
+
+func (c *gatedClient) Publish(event beat.Event) {
+	if c.wait() {
+		c.client.Publish(event)
+	}
+}
+
+func (c *gatedClient) PublishAll(events []beat.Event) {
+	if c.wait() {
+		c.client.PublishAll(events)
+	}
+}
+
+func (c *gatedClient) Close() error {
+	c.closeOnce.Do(func() { close(c.closed) })
+	return c.client.Close()
+}
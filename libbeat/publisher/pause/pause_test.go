@@ -0,0 +1,149 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package pause
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+)
+
+type testPipeline struct {
+	mu     sync.Mutex
+	events []beat.Event
+}
+
+func (p *testPipeline) Connect() (beat.Client, error) { return p.ConnectWith(beat.ClientConfig{}) }
+
+func (p *testPipeline) ConnectWith(beat.ClientConfig) (beat.Client, error) {
+	return &testClient{pipeline: p}, nil
+}
+
+func (p *testPipeline) count() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.events)
+}
+
+type testClient struct {
+	pipeline *testPipeline
+}
+
+func (c *testClient) Publish(event beat.Event) { c.PublishAll([]beat.Event{event}) }
+
+func (c *testClient) PublishAll(events []beat.Event) {
+	c.pipeline.mu.Lock()
+	defer c.pipeline.mu.Unlock()
+	c.pipeline.events = append(c.pipeline.events, events...)
+}
+
+func (c *testClient) Close() error { return nil }
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+
+	gate, unregister := r.Register("input-1")
+	other, unregisterOther := r.Register("input-1")
+	assert.Same(t, gate, other)
+	_, unregister2 := r.Register("input-2")
+	assert.Equal(t, []string{"input-1", "input-2"}, r.IDs())
+	assert.Same(t, gate, r.Get("input-1"))
+	assert.Nil(t, r.Get("input-3"))
+
+	assert.True(t, r.Pause("input-1"))
+	assert.False(t, r.Pause("input-3"))
+	paused, found := r.Paused("input-1")
+	assert.True(t, paused)
+	assert.True(t, found)
+	_, found = r.Paused("input-3")
+	assert.False(t, found)
+
+	unregister()
+	unregister() // No-op.
+	assert.True(t, gate.Paused(), "gate is still used by another input")
+	unregisterOther()
+	assert.False(t, gate.Paused(), "gate is resumed once unregistered")
+	unregister2()
+	assert.Empty(t, r.IDs())
+}
+
+func TestWithGate(t *testing.T) {
+	pipeline := &testPipeline{}
+	gate := &Gate{}
+	client, err := WithGate(pipeline, gate, nil).Connect()
+	require.NoError(t, err)
+
+	client.Publish(beat.Event{})
+	assert.Equal(t, 1, pipeline.count())
+
+	gate.Pause()
+	published := make(chan struct{})
+	go func() {
+		client.PublishAll([]beat.Event{{}, {}})
+		close(published)
+	}()
+	select {
+	case <-published:
+		t.Fatal("events published while paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	gate.Resume()
+	<-published
+	assert.Equal(t, 3, pipeline.count())
+}
+
+func TestWithGateClose(t *testing.T) {
+	pipeline := &testPipeline{}
+	gate := &Gate{}
+	client, err := WithGate(pipeline, gate, nil).Connect()
+	require.NoError(t, err)
+
+	gate.Pause()
+	published := make(chan struct{})
+	go func() {
+		client.Publish(beat.Event{})
+		close(published)
+	}()
+	require.NoError(t, client.Close())
+	<-published
+	assert.Equal(t, 0, pipeline.count(), "events are dropped when the client is closed")
+}
+
+func TestWithGateDone(t *testing.T) {
+	pipeline := &testPipeline{}
+	gate := &Gate{}
+	done := make(chan struct{})
+	client, err := WithGate(pipeline, gate, done).Connect()
+	require.NoError(t, err)
+
+	gate.Pause()
+	published := make(chan struct{})
+	go func() {
+		client.Publish(beat.Event{})
+		close(published)
+	}()
+	close(done)
+	<-published
+	assert.Equal(t, 0, pipeline.count(), "events are dropped when the input is stopped")
+}
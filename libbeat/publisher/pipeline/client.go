@@ -27,6 +27,7 @@ import (
 	"github.com/elastic/beats/v7/libbeat/publisher"
 	"github.com/elastic/beats/v7/libbeat/publisher/queue"
 	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
 )
 
 // client connects a beat with the processors and pipeline queue.
@@ -41,12 +42,17 @@ type client struct {
 	canDrop    bool
 
 	// Open state, signaling, and sync primitives for coordinating client Close.
-	isOpen    atomic.Bool // set to false during shutdown, such that no new events will be accepted anymore.
-	closeOnce sync.Once   // closeOnce ensure that the client shutdown sequence is only executed once
+	isOpen    atomic.Bool   // set to false during shutdown, such that no new events will be accepted anymore.
+	closeOnce sync.Once     // closeOnce ensure that the client shutdown sequence is only executed once
+	done      chan struct{} // closed on Close, releases a Publish waiting for the intake to resume
+
+	// intake is waited on before publishing, while the pipeline is paused.
+	intake *intakeGate
 
 	observer       observer
 	eventListener  beat.EventListener
 	clientListener beat.ClientListener
+	eventRejected  func(interface{}, mapstr.M, error)
 }
 
 type clientCloseWaiter struct {
@@ -88,6 +94,12 @@ func (c *client) publish(e beat.Event) {
 		return
 	}
 
+	if !c.waitIntake() {
+		// intake is paused and the client can drop events, or is closing
+		c.onDroppedOnPublish(e)
+		return
+	}
+
 	if c.processors != nil {
 		var err error
 
@@ -111,10 +123,18 @@ func (c *client) publish(e beat.Event) {
 	}
 
 	e = *event
+	// Queues and outputs only handle the nested fields.
+	e.Materialize()
 	pubEvent := publisher.Event{
 		Content: e,
 		Flags:   c.eventFlags,
 	}
+	if c.eventRejected != nil {
+		// Outputs may encode the event early and clear its content. Only
+		// keep what identifies the event, not its fields.
+		onRejected, private, meta := c.eventRejected, e.Private, e.Meta
+		pubEvent.OnReject = func(err error) { onRejected(private, meta, err) }
+	}
 
 	var published bool
 	if c.canDrop {
@@ -130,9 +150,35 @@ func (c *client) publish(e beat.Event) {
 	}
 }
 
+// waitIntake blocks while the intake of the pipeline is paused. It returns
+// false if the event must be dropped, because the client can drop events or
+// is closed.
+func (c *client) waitIntake() bool {
+	if c.intake == nil {
+		return true
+	}
+	resumed := c.intake.wait()
+	if resumed == nil {
+		return true
+	}
+	if c.canDrop {
+		return false
+	}
+
+	select {
+	case <-resumed:
+		return true
+	case <-c.done:
+		return false
+	}
+}
+
 func (c *client) Close() error {
 	if c.isOpen.Swap(false) {
 		// Only do shutdown handling the first time Close is called
+		if c.done != nil {
+			close(c.done)
+		}
 		c.onClosing()
 
 		c.logger.Debug("client: closing acker")
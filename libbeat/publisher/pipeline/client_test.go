@@ -275,6 +275,51 @@ func TestClientWaitClose(t *testing.T) {
 	})
 }
 
+func TestClientEventRejected(t *testing.T) {
+	logp.TestingSetup()
+
+	q := memqueue.NewQueue(logp.L(), nil, memqueue.Settings{Events: 1}, 0, nil)
+	pipeline := makePipeline(t, Settings{}, q)
+	defer pipeline.Close()
+
+	type rejection struct {
+		private interface{}
+		meta    mapstr.M
+		err     error
+	}
+	rejections := make(chan rejection, 1)
+	client, err := pipeline.ConnectWith(beat.ClientConfig{
+		EventRejected: func(private interface{}, meta mapstr.M, err error) {
+			rejections <- rejection{private: private, meta: meta, err: err}
+		},
+	})
+	require.NoError(t, err)
+	defer client.Close()
+
+	output := newMockClient(func(batch publisher.Batch) error {
+		batch.Drop()
+		return nil
+	})
+	defer output.Close()
+	pipeline.outputController.Set(outputs.Group{Clients: []outputs.Client{output}})
+	defer pipeline.outputController.Set(outputs.Group{})
+
+	client.Publish(beat.Event{
+		Private: "cursor",
+		Meta:    mapstr.M{"_id": "1"},
+		Fields:  mapstr.M{"message": "rejected"},
+	})
+
+	select {
+	case r := <-rejections:
+		assert.ErrorIs(t, r.err, publisher.ErrEventRejected)
+		assert.Equal(t, "cursor", r.private)
+		assert.Equal(t, mapstr.M{"_id": "1"}, r.meta)
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected the dropped event to be reported as rejected")
+	}
+}
+
 func TestMonitoring(t *testing.T) {
 	const (
 		maxEvents  = 123
@@ -35,6 +35,9 @@ type Config struct {
 
 	// Event queue
 	Queue config.Namespace `config:"queue"`
+
+	// ResourceGuard pauses the intake of events on disk or memory pressure.
+	ResourceGuard ResourceGuardConfig `config:"resource_guard"`
 }
 
 // validateClientConfig checks a ClientConfig can be used with (*Pipeline).ConnectWith.
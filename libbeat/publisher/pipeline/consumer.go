@@ -186,8 +186,9 @@ outerLoop:
 				c.retryObserver.eventsDropped(countDropped)
 
 				if !alive {
+					// The events have already been rejected by reduceTTL.
 					log.Info("Drop batch")
-					req.batch.Drop()
+					req.batch.discard()
 					continue
 				}
 			}
@@ -214,8 +215,9 @@ func (c *eventConsumer) retry(batch *ttlBatch, decreaseTTL bool) {
 	case c.retryChan <- retryRequest{batch: batch, decreaseTTL: decreaseTTL}:
 		// The batch is back in eventConsumer's retry queue
 	case <-c.done:
-		// The consumer has already shut down, drop the batch
-		batch.Drop()
+		// The consumer has already shut down, drop the batch. The events
+		// are not rejected by the output, so they are not reported as such.
+		batch.discard()
 	}
 }
 
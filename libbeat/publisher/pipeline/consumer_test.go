@@ -22,6 +22,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/elastic/beats/v7/libbeat/publisher"
 	"github.com/elastic/elastic-agent-libs/logp"
 )
 
@@ -49,3 +50,25 @@ func TestNoBatchAssemblyOnNilTarget(t *testing.T) {
 	_, ok := <-c.queueReader.req
 	assert.False(t, ok, "The queue reader shouldn't get a read request when the target is nil")
 }
+
+func TestRetryAfterShutdownDoesNotRejectEvents(t *testing.T) {
+	c := &eventConsumer{
+		logger: logp.NewLogger("eventConsumer test"),
+		done:   make(chan struct{}),
+	}
+	close(c.done)
+
+	rejected := false
+	doneCalled := false
+	batch := &ttlBatch{
+		done:    func() { doneCalled = true },
+		retryer: c,
+		events: []publisher.Event{{
+			OnReject: func(error) { rejected = true },
+		}},
+	}
+	batch.Retry()
+
+	assert.True(t, doneCalled, "The batch should be released when the consumer is shut down")
+	assert.False(t, rejected, "Events in flight at shutdown must not be reported as rejected")
+}
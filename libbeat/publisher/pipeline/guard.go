@@ -0,0 +1,218 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package pipeline
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/common/cfgtype"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/paths"
+)
+
+// Reasons the resource guard pauses the intake for.
+const (
+	pauseReasonDisk   = "disk"
+	pauseReasonMemory = "memory"
+)
+
+// ResourceGuardConfig configures the resource guard, which pauses the
+// intake of events while the disk of the data path or the memory used
+// are above their high watermark, until they are below the low watermark.
+type ResourceGuardConfig struct {
+	Enabled       bool              `config:"enabled"`
+	CheckInterval time.Duration     `config:"check_interval"`
+	Disk          DiskGuardConfig   `config:"disk"`
+	Memory        MemoryGuardConfig `config:"memory"`
+}
+
+// DiskGuardConfig sets the watermarks of the fraction of the disk used.
+type DiskGuardConfig struct {
+	// Path is on the monitored disk, it defaults to the data path.
+	Path          string  `config:"path"`
+	HighWatermark float64 `config:"high_watermark"`
+	LowWatermark  float64 `config:"low_watermark"`
+}
+
+// MemoryGuardConfig sets the watermarks of the memory used by the Go
+// runtime, 0 disables the memory guard.
+type MemoryGuardConfig struct {
+	HighWatermark cfgtype.ByteSize `config:"high_watermark"`
+	LowWatermark  cfgtype.ByteSize `config:"low_watermark"`
+}
+
+const (
+	defaultGuardCheckInterval = 5 * time.Second
+	defaultDiskHighWatermark  = 0.95
+	defaultDiskLowWatermark   = 0.90
+)
+
+func (c *ResourceGuardConfig) Validate() error {
+	if c.CheckInterval < 0 {
+		return errors.New("resource_guard.check_interval must not be negative")
+	}
+	disk := c.Disk.withDefaults()
+	if disk.HighWatermark > 1 || disk.LowWatermark < 0 || disk.LowWatermark >= disk.HighWatermark {
+		return errors.New("resource_guard.disk watermarks must be between 0 and 1, with the low watermark below the high watermark")
+	}
+	if c.Memory.HighWatermark < 0 || c.Memory.LowWatermark < 0 ||
+		(c.Memory.HighWatermark > 0 && c.Memory.LowWatermark >= c.Memory.HighWatermark) {
+		return errors.New("resource_guard.memory.low_watermark must be below resource_guard.memory.high_watermark")
+	}
+	return nil
+}
+
+func (c DiskGuardConfig) withDefaults() DiskGuardConfig {
+	if c.HighWatermark == 0 {
+		c.HighWatermark = defaultDiskHighWatermark
+	}
+	if c.LowWatermark == 0 {
+		c.LowWatermark = defaultDiskLowWatermark
+		if c.LowWatermark >= c.HighWatermark {
+			c.LowWatermark = c.HighWatermark * 0.9
+		}
+	}
+	return c
+}
+
+// resourceGuard periodically checks the disk and memory used, and pauses
+// the intake of the pipeline when they are too high. The intake is resumed
+// once they are below the low watermark, so it doesn't flap around the
+// high watermark.
+type resourceGuard struct {
+	log      *logp.Logger
+	gate     *intakeGate
+	interval time.Duration
+
+	diskPath string
+	disk     DiskGuardConfig
+	memory   MemoryGuardConfig
+
+	// diskUsage and memoryUsage are replaced in tests.
+	diskUsage   func(path string) (float64, error)
+	memoryUsage func() uint64
+	diskErr     bool
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newResourceGuard(log *logp.Logger, gate *intakeGate, config ResourceGuardConfig) *resourceGuard {
+	g := &resourceGuard{
+		log:         log,
+		gate:        gate,
+		interval:    config.CheckInterval,
+		diskPath:    config.Disk.Path,
+		disk:        config.Disk.withDefaults(),
+		memory:      config.Memory,
+		diskUsage:   diskUsage,
+		memoryUsage: memoryUsage,
+		done:        make(chan struct{}),
+	}
+	if g.interval == 0 {
+		g.interval = defaultGuardCheckInterval
+	}
+	if g.diskPath == "" {
+		g.diskPath = paths.Resolve(paths.Data, "")
+	}
+	if g.memory.HighWatermark > 0 && g.memory.LowWatermark == 0 {
+		g.memory.LowWatermark = g.memory.HighWatermark * 9 / 10
+	}
+	return g
+}
+
+func (g *resourceGuard) start() {
+	g.log.Infof("Resource guard started. path=%v disk_high_watermark=%v disk_low_watermark=%v memory_high_watermark=%v memory_low_watermark=%v",
+		g.diskPath, g.disk.HighWatermark, g.disk.LowWatermark, g.memory.HighWatermark, g.memory.LowWatermark)
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+
+		ticker := time.NewTicker(g.interval)
+		defer ticker.Stop()
+		for {
+			g.check()
+			select {
+			case <-g.done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (g *resourceGuard) stop() {
+	close(g.done)
+	g.wg.Wait()
+}
+
+func (g *resourceGuard) check() {
+	used, err := g.diskUsage(g.diskPath)
+	switch {
+	case err != nil:
+		// Don't log the same failure at every check.
+		if !g.diskErr {
+			g.log.Warnf("Resource guard failed to read the disk usage of %v: %v", g.diskPath, err)
+		}
+		g.diskErr = true
+		// The disk usage is checked again at the next interval, the intake
+		// is not kept paused on a value that can't be refreshed.
+		if g.gate.resume(pauseReasonDisk) {
+			g.log.Warnf("Resuming the intake of events, the disk usage of %v is unknown", g.diskPath)
+		}
+	case used >= g.disk.HighWatermark:
+		g.diskErr = false
+		if g.gate.pause(pauseReasonDisk) {
+			g.log.Warnf("Pausing the intake of events, %.1f%% of the disk of %v is used", used*100, g.diskPath)
+		}
+	case used < g.disk.LowWatermark:
+		g.diskErr = false
+		if g.gate.resume(pauseReasonDisk) {
+			g.log.Infof("Resuming the intake of events, %.1f%% of the disk of %v is used", used*100, g.diskPath)
+		}
+	default:
+		g.diskErr = false
+	}
+
+	if g.memory.HighWatermark <= 0 {
+		return
+	}
+	mem := g.memoryUsage()
+	switch {
+	case mem >= uint64(g.memory.HighWatermark):
+		if g.gate.pause(pauseReasonMemory) {
+			g.log.Warnf("Pausing the intake of events, %d bytes of memory are used", mem)
+		}
+	case mem < uint64(g.memory.LowWatermark):
+		if g.gate.resume(pauseReasonMemory) {
+			g.log.Infof("Resuming the intake of events, %d bytes of memory are used", mem)
+		}
+	}
+}
+
+// memoryUsage returns the memory obtained from the OS by the Go runtime,
+// and not released to it yet.
+func memoryUsage() uint64 {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.Sys - m.HeapReleased
+}
@@ -0,0 +1,38 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build darwin || freebsd || linux || openbsd || windows
+
+package pipeline
+
+import (
+	"errors"
+
+	"github.com/elastic/elastic-agent-system-metrics/metric/system/filesystem"
+)
+
+// diskUsage returns the fraction of the disk of path that is used.
+func diskUsage(path string) (float64, error) {
+	fs := filesystem.FSStat{Directory: path}
+	if err := fs.GetUsage(); err != nil {
+		return 0, err
+	}
+	if !fs.Used.Pct.Exists() {
+		return 0, errors.New("the disk usage is not available")
+	}
+	return fs.Used.Pct.ValueOr(0), nil
+}
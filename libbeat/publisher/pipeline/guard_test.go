@@ -0,0 +1,192 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package pipeline
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/publisher/queue/memqueue"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+func TestResourceGuardConfig(t *testing.T) {
+	tests := map[string]struct {
+		config map[string]interface{}
+		valid  bool
+	}{
+		"defaults": {
+			config: map[string]interface{}{"resource_guard.enabled": true},
+			valid:  true,
+		},
+		"disk watermarks": {
+			config: map[string]interface{}{"resource_guard.disk.high_watermark": 0.8, "resource_guard.disk.low_watermark": 0.7},
+			valid:  true,
+		},
+		"only disk high watermark": {
+			config: map[string]interface{}{"resource_guard.disk.high_watermark": 0.5},
+			valid:  true,
+		},
+		"disk low watermark above high watermark": {
+			config: map[string]interface{}{"resource_guard.disk.high_watermark": 0.8, "resource_guard.disk.low_watermark": 0.9},
+		},
+		"disk high watermark above 1": {
+			config: map[string]interface{}{"resource_guard.disk.high_watermark": 1.5},
+		},
+		"memory watermarks": {
+			config: map[string]interface{}{"resource_guard.memory.high_watermark": "1GiB", "resource_guard.memory.low_watermark": "800MiB"},
+			valid:  true,
+		},
+		"memory low watermark above high watermark": {
+			config: map[string]interface{}{"resource_guard.memory.high_watermark": "1GiB", "resource_guard.memory.low_watermark": "2GiB"},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var config Config
+			err := conf.MustNewConfigFrom(test.config).Unpack(&config)
+			assert.Equal(t, test.valid, err == nil, err)
+		})
+	}
+}
+
+func TestResourceGuardHysteresis(t *testing.T) {
+	gate := newIntakeGate(nilObserver)
+	guard := newResourceGuard(logp.NewLogger("test"), gate, ResourceGuardConfig{
+		Disk:   DiskGuardConfig{Path: t.TempDir()},
+		Memory: MemoryGuardConfig{HighWatermark: 1000},
+	})
+
+	var disk float64
+	var diskErr error
+	var memory uint64
+	guard.diskUsage = func(string) (float64, error) { return disk, diskErr }
+	guard.memoryUsage = func() uint64 { return memory }
+
+	steps := []struct {
+		disk    float64
+		diskErr error
+		memory  uint64
+		paused  []string
+	}{
+		{disk: 0.5, memory: 100, paused: []string{}},
+		{disk: 0.96, memory: 100, paused: []string{pauseReasonDisk}},
+		// Below the high watermark, but not below the low watermark.
+		{disk: 0.92, memory: 100, paused: []string{pauseReasonDisk}},
+		{disk: 0.92, memory: 1000, paused: []string{pauseReasonDisk, pauseReasonMemory}},
+		{disk: 0.85, memory: 950, paused: []string{pauseReasonMemory}},
+		{disk: 0.85, memory: 800, paused: []string{}},
+		{disk: 0.96, memory: 100, paused: []string{pauseReasonDisk}},
+		// The intake is not kept paused when the disk usage can't be read.
+		{diskErr: errors.New("stale file handle"), memory: 100, paused: []string{}},
+		{disk: 0.96, memory: 100, paused: []string{pauseReasonDisk}},
+	}
+	for i, step := range steps {
+		disk, diskErr, memory = step.disk, step.diskErr, step.memory
+		guard.check()
+		assert.ElementsMatch(t, step.paused, gate.paused(), "step %d", i)
+	}
+}
+
+func TestPipelinePause(t *testing.T) {
+	logp.TestingSetup()
+
+	q := memqueue.NewQueue(logp.L(), nil, memqueue.Settings{Events: 10}, 0, nil)
+	pipeline := makePipeline(t, Settings{}, q)
+	defer pipeline.Close()
+
+	client, err := pipeline.Connect()
+	require.NoError(t, err)
+	defer client.Close()
+
+	dropped := &testClientListener{}
+	dropClient, err := pipeline.ConnectWith(beat.ClientConfig{
+		PublishMode:    beat.DropIfFull,
+		ClientListener: dropped,
+	})
+	require.NoError(t, err)
+	defer dropClient.Close()
+
+	pipeline.Pause("test")
+	pipeline.Pause("other")
+	assert.ElementsMatch(t, []string{"test", "other"}, pipeline.Paused())
+
+	published := make(chan struct{})
+	go func() {
+		client.Publish(beat.Event{})
+		close(published)
+	}()
+
+	dropClient.Publish(beat.Event{})
+	assert.Equal(t, 1, dropped.dropped)
+
+	pipeline.Resume("test")
+	select {
+	case <-published:
+		t.Fatal("event published while the intake is paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	pipeline.Resume("other")
+	assert.Empty(t, pipeline.Paused())
+	select {
+	case <-published:
+	case <-time.After(10 * time.Second):
+		t.Fatal("event not published after the intake was resumed")
+	}
+}
+
+func TestPipelinePauseClientClose(t *testing.T) {
+	logp.TestingSetup()
+
+	pipeline := makePipeline(t, Settings{}, makeTestQueue())
+	defer pipeline.Close()
+
+	client, err := pipeline.Connect()
+	require.NoError(t, err)
+
+	pipeline.Pause("test")
+	published := make(chan struct{})
+	go func() {
+		client.Publish(beat.Event{})
+		close(published)
+	}()
+
+	// Closing the client releases the Publish call waiting for the intake.
+	client.Close()
+	select {
+	case <-published:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Publish still blocked after the client was closed")
+	}
+}
+
+type testClientListener struct {
+	dropped int
+}
+
+func (l *testClientListener) Closing()                    {}
+func (l *testClientListener) Closed()                     {}
+func (l *testClientListener) Published()                  {}
+func (l *testClientListener) DroppedOnPublish(beat.Event) { l.dropped++ }
@@ -0,0 +1,106 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package pipeline
+
+import (
+	"sync"
+
+	"github.com/elastic/beats/v7/libbeat/publisher/pause"
+)
+
+// intakeGate pauses the intake of events by the pipeline clients. The
+// intake is paused while at least one reason to pause it is set.
+type intakeGate struct {
+	mu       sync.Mutex
+	reasons  map[string]struct{}
+	gate     pause.Gate
+	observer observer
+}
+
+func newIntakeGate(observer observer) *intakeGate {
+	return &intakeGate{
+		reasons:  map[string]struct{}{},
+		observer: observer,
+	}
+}
+
+// pause pauses the intake for reason. It returns false if the intake was
+// already paused for this reason.
+func (g *intakeGate) pause(reason string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, ok := g.reasons[reason]; ok {
+		return false
+	}
+	if len(g.reasons) == 0 {
+		g.gate.Pause()
+		g.observer.intakePaused()
+	}
+	g.reasons[reason] = struct{}{}
+	return true
+}
+
+// resume removes reason to pause the intake, the intake is resumed when no
+// other reason is left. It returns false if the intake was not paused for
+// this reason.
+func (g *intakeGate) resume(reason string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, ok := g.reasons[reason]; !ok {
+		return false
+	}
+	delete(g.reasons, reason)
+	if len(g.reasons) == 0 {
+		g.gate.Resume()
+		g.observer.intakeResumed()
+	}
+	return true
+}
+
+// resumeAll resumes the intake regardless of the reasons to pause it.
+func (g *intakeGate) resumeAll() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(g.reasons) == 0 {
+		return
+	}
+	g.reasons = map[string]struct{}{}
+	g.gate.Resume()
+	g.observer.intakeResumed()
+}
+
+// paused returns the reasons the intake is paused for.
+func (g *intakeGate) paused() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	reasons := make([]string, 0, len(g.reasons))
+	for r := range g.reasons {
+		reasons = append(reasons, r)
+	}
+	return reasons
+}
+
+// wait returns a channel that is closed when the intake is resumed, or nil
+// if it is not paused.
+func (g *intakeGate) wait() <-chan struct{} {
+	return g.gate.Resumed()
+}
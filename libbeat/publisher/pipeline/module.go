@@ -91,6 +91,7 @@ func LoadWithSettings(
 	}
 
 	name := beatInfo.Name
+	settings.ResourceGuard = config.ResourceGuard
 
 	out, err := loadOutput(monitors, makeOutput)
 	if err != nil {
@@ -34,6 +34,9 @@ type pipelineObserver interface {
 	clientConnected()
 	// An open pipeline client received a Close() call.
 	clientClosed()
+	// The intake of events was paused or resumed.
+	intakePaused()
+	intakeResumed()
 }
 
 type clientObserver interface {
@@ -70,6 +73,10 @@ type metricsObserverVars struct {
 	// clients metrics
 	clients *monitoring.Uint
 
+	// intake metrics
+	intakePaused *monitoring.Uint
+	intakePauses *monitoring.Uint
+
 	// eventsTotal publish/dropped stats
 	eventsTotal, eventsFiltered, eventsPublished, eventsFailed *monitoring.Uint
 	eventsDropped, eventsRetry                                 *monitoring.Uint // (retryer) drop/retry counters
@@ -93,6 +100,13 @@ func newMetricsObserver(metrics *monitoring.Registry) *metricsObserver {
 			// (Gauge) clients measures the number of open pipeline clients.
 			clients: monitoring.NewUint(reg, "clients"),
 
+			// (Gauge) intake.paused is 1 while the intake of events is paused,
+			// for example by the resource guard.
+			intakePaused: monitoring.NewUint(reg, "intake.paused"),
+
+			// intake.pauses counts the times the intake of events was paused.
+			intakePauses: monitoring.NewUint(reg, "intake.pauses"),
+
 			// events.total counts all created events.
 			eventsTotal: monitoring.NewUint(reg, "events.total"),
 
@@ -151,6 +165,19 @@ func (o *metricsObserver) clientConnected() { o.vars.clients.Inc() }
 // (client) client finished processing close
 func (o *metricsObserver) clientClosed() { o.vars.clients.Dec() }
 
+//
+// intake pause/resume
+//
+
+// (pipeline) the intake of events was paused
+func (o *metricsObserver) intakePaused() {
+	o.vars.intakePaused.Set(1)
+	o.vars.intakePauses.Inc()
+}
+
+// (pipeline) the intake of events was resumed
+func (o *metricsObserver) intakeResumed() { o.vars.intakePaused.Set(0) }
+
 //
 // client publish events
 //
@@ -204,6 +231,8 @@ var nilObserver observer = (*emptyObserver)(nil)
 func (*emptyObserver) cleanup()            {}
 func (*emptyObserver) clientConnected()    {}
 func (*emptyObserver) clientClosed()       {}
+func (*emptyObserver) intakePaused()       {}
+func (*emptyObserver) intakeResumed()      {}
 func (*emptyObserver) newEvent()           {}
 func (*emptyObserver) filteredEvent()      {}
 func (*emptyObserver) publishedEvent()     {}
@@ -34,6 +34,7 @@ import (
 	"github.com/elastic/beats/v7/libbeat/publisher/queue"
 	"github.com/elastic/beats/v7/libbeat/publisher/queue/diskqueue"
 	"github.com/elastic/beats/v7/libbeat/publisher/queue/memqueue"
+	"github.com/elastic/beats/v7/libbeat/publisher/queue/spillqueue"
 	conf "github.com/elastic/elastic-agent-libs/config"
 	"github.com/elastic/elastic-agent-libs/logp"
 )
@@ -68,6 +69,10 @@ type Pipeline struct {
 	waitCloseTimeout time.Duration
 
 	processors processing.Supporter
+
+	// intake pauses the clients, guard pauses it on resource pressure.
+	intake *intakeGate
+	guard  *resourceGuard
 }
 
 // Settings is used to pass additional settings to a newly created pipeline instance.
@@ -81,6 +86,9 @@ type Settings struct {
 	Processors processing.Supporter
 
 	InputQueueSize int
+
+	// ResourceGuard pauses the intake of events on disk or memory pressure.
+	ResourceGuard ResourceGuardConfig
 }
 
 // WaitCloseMode enumerates the possible behaviors of WaitClose in a pipeline.
@@ -134,6 +142,7 @@ func New(
 	if monitors.Metrics != nil {
 		p.observer = newMetricsObserver(monitors.Metrics)
 	}
+	p.intake = newIntakeGate(p.observer)
 
 	// Convert the raw queue config to a parsed Settings object that will
 	// be used during queue creation. This lets us fail immediately on startup
@@ -154,6 +163,11 @@ func New(
 	p.outputController = output
 	p.outputController.Set(out)
 
+	if settings.ResourceGuard.Enabled {
+		p.guard = newResourceGuard(monitors.Logger, p.intake, settings.ResourceGuard)
+		p.guard.start()
+	}
+
 	return p, nil
 }
 
@@ -166,6 +180,12 @@ func (p *Pipeline) Close() error {
 
 	log.Debug("close pipeline")
 
+	if p.guard != nil {
+		p.guard.stop()
+	}
+	// Release the clients waiting for the intake to resume.
+	p.intake.resumeAll()
+
 	// Note: active clients are not closed / disconnected.
 	p.outputController.WaitClose(p.waitCloseTimeout)
 
@@ -173,6 +193,29 @@ func (p *Pipeline) Close() error {
 	return nil
 }
 
+// Pause pauses the intake of events for reason. While the intake is paused
+// the clients block on Publish, or drop the events if they are connected
+// with DropIfFull, so the inputs stop reading new data.
+func (p *Pipeline) Pause(reason string) {
+	if p.intake.pause(reason) {
+		p.monitors.Logger.Infof("Pipeline intake paused: %v", reason)
+	}
+}
+
+// Resume removes a reason to pause the intake of events set by Pause. The
+// intake is resumed once no reason is left.
+func (p *Pipeline) Resume(reason string) {
+	if p.intake.resume(reason) {
+		p.monitors.Logger.Infof("Pipeline intake resumed: %v", reason)
+	}
+}
+
+// Paused returns the reasons the intake of events is paused for, it is
+// empty when the intake is not paused.
+func (p *Pipeline) Paused() []string {
+	return p.intake.paused()
+}
+
 // Connect creates a new client with default settings.
 func (p *Pipeline) Connect() (beat.Client, error) {
 	return p.ConnectWith(beat.ClientConfig{})
@@ -213,10 +256,13 @@ func (p *Pipeline) ConnectWith(cfg beat.ClientConfig) (beat.Client, error) {
 		logger:         p.monitors.Logger,
 		isOpen:         atomic.MakeBool(true),
 		clientListener: cfg.ClientListener,
+		eventRejected:  cfg.EventRejected,
 		processors:     processors,
 		eventFlags:     eventFlags,
 		canDrop:        canDrop,
 		observer:       p.observer,
+		intake:         p.intake,
+		done:           make(chan struct{}),
 	}
 
 	ackHandler := cfg.EventListener
@@ -287,6 +333,12 @@ func queueFactoryForUserConfig(queueType string, userConfig *conf.C) (queue.Queu
 			return nil, err
 		}
 		return diskqueue.FactoryForSettings(settings), nil
+	case spillqueue.QueueType:
+		settings, err := spillqueue.SettingsForUserConfig(userConfig)
+		if err != nil {
+			return nil, err
+		}
+		return spillqueue.FactoryForSettings(settings), nil
 	default:
 		return nil, fmt.Errorf("unrecognized queue type '%v'", queueType)
 	}
@@ -18,6 +18,7 @@
 package pipeline
 
 import (
+	"fmt"
 	"sync/atomic"
 
 	"github.com/elastic/beats/v7/libbeat/publisher"
@@ -98,7 +99,19 @@ func (b *ttlBatch) ACK() {
 	b.done()
 }
 
+// Drop is called by the output when it permanently refuses the events of the
+// batch. The events are reported as rejected.
 func (b *ttlBatch) Drop() {
+	err := fmt.Errorf("%w: batch dropped by output", publisher.ErrEventRejected)
+	for i := range b.events {
+		b.events[i].Reject(err)
+	}
+	b.discard()
+}
+
+// discard releases the batch without reporting its events as rejected, e.g.
+// when the pipeline shuts down with events in flight.
+func (b *ttlBatch) discard() {
 	// Help the garbage collector clean up the event data a little faster
 	b.events = nil
 	b.done()
@@ -179,10 +192,13 @@ func (b *ttlBatch) reduceTTL() bool {
 	}
 
 	// filter for events with guaranteed send flags
+	err := fmt.Errorf("%w: retry limit reached", publisher.ErrEventRejected)
 	events := b.events[:0]
 	for _, event := range b.events {
 		if event.Guaranteed() {
 			events = append(events, event)
+		} else {
+			event.Reject(err)
 		}
 	}
 	b.events = events
@@ -112,6 +112,36 @@ func TestBatchCallsDoneAndFreesEvents(t *testing.T) {
 	require.True(t, doneCalled, "Calling batch.Drop should invoke the done callback")
 }
 
+func TestBatchRejectsDroppedEvents(t *testing.T) {
+	var rejected []string
+	event := func(name string, flags publisher.EventFlags) publisher.Event {
+		return publisher.Event{
+			Flags: flags,
+			OnReject: func(err error) {
+				assert.ErrorIs(t, err, publisher.ErrEventRejected)
+				rejected = append(rejected, name)
+			},
+		}
+	}
+
+	batch := &ttlBatch{
+		done:   func() {},
+		events: []publisher.Event{event("a", 0), event("b", 0)},
+	}
+	batch.Drop()
+	assert.Equal(t, []string{"a", "b"}, rejected, "Calling batch.Drop should reject all events")
+
+	rejected = nil
+	batch = &ttlBatch{
+		done:   func() {},
+		ttl:    1,
+		events: []publisher.Event{event("a", 0), event("b", publisher.GuaranteedSend)},
+	}
+	require.True(t, batch.reduceTTL())
+	assert.Equal(t, []string{"a"}, rejected, "Expiring the batch TTL should reject events without send guarantees")
+	assert.Len(t, batch.events, 1)
+}
+
 func TestNewBatchFreesEvents(t *testing.T) {
 	queueBatch := &mockQueueBatch{}
 	_ = newBatch(nil, queueBatch, 0)
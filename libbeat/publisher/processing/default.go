@@ -29,6 +29,7 @@ import (
 	"github.com/elastic/beats/v7/libbeat/mapping"
 	"github.com/elastic/beats/v7/libbeat/processors"
 	"github.com/elastic/beats/v7/libbeat/processors/actions"
+	"github.com/elastic/beats/v7/libbeat/processors/schemavalidation"
 	"github.com/elastic/beats/v7/libbeat/processors/timeseries"
 	"github.com/elastic/elastic-agent-libs/config"
 	"github.com/elastic/elastic-agent-libs/logp"
@@ -57,6 +58,10 @@ type builder struct {
 	timeSeries       bool
 	timeseriesFields mapping.Fields
 
+	// Events are validated against a fields schema if configured (disabled
+	// by default)
+	schemaValidation beat.Processor
+
 	// global pipeline processors
 	processors *group
 
@@ -107,7 +112,10 @@ func MakeDefaultSupport(
 			mapstr.EventMetadata `config:",inline"`      // Fields and tags to add to each event.
 			Processors           processors.PluginConfig `config:"processors"`
 			TimeSeries           bool                    `config:"timeseries.enabled"`
-		}{}
+			SchemaValidation     schemavalidation.Config `config:"schema_validation"`
+		}{
+			SchemaValidation: schemavalidation.DefaultConfig(),
+		}
 		if err := beatCfg.Unpack(&cfg); err != nil {
 			return nil, err
 		}
@@ -128,8 +136,40 @@ func MakeDefaultSupport(
 			return nil, fmt.Errorf("error initializing processors: %w", err)
 		}
 
-		return newBuilder(info, log, processors, cfg.EventMetadata, modifiers, !normalize, cfg.TimeSeries)
+		b, err := newBuilder(info, log, processors, cfg.EventMetadata, modifiers, !normalize, cfg.TimeSeries)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.SchemaValidation.Enabled {
+			b.schemaValidation, err = newSchemaValidation(info, cfg.SchemaValidation)
+			if err != nil {
+				return nil, fmt.Errorf("error initializing schema validation: %w", err)
+			}
+		}
+		return b, nil
+	}
+}
+
+// newSchemaValidation creates the schema validation processor from the
+// configured fields file or the fields bundled with the beat.
+func newSchemaValidation(info beat.Info, cfg schemavalidation.Config) (beat.Processor, error) {
+	var (
+		fields mapping.Fields
+		err    error
+	)
+	if cfg.Fields != "" {
+		fields, err = mapping.LoadFieldsYaml(cfg.Fields)
+	} else {
+		var rawFields []byte
+		rawFields, err = asset.GetFields(info.Beat)
+		if err == nil {
+			fields, err = mapping.LoadFields(rawFields)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load fields: %w", err)
 	}
+	return schemavalidation.New(fields, cfg)
 }
 
 // WithFields creates a modifier with the given default builtin fields.
@@ -284,8 +324,9 @@ func (b *builder) Processors() []string {
 //  7. (P) add builtins
 //  8. (P) pipeline processors list
 //  9. (P) timeseries mangling
-//  10. (P) (if publish/debug enabled) log event
-//  11. (P) (if output disabled) dropEvent
+//  10. (P) schema validation
+//  11. (P) (if publish/debug enabled) log event
+//  12. (P) (if output disabled) dropEvent
 func (b *builder) Create(cfg beat.ProcessingConfig, drop bool) (beat.Processor, error) {
 	var (
 		// pipeline processors
@@ -387,12 +428,17 @@ func (b *builder) Create(cfg beat.ProcessingConfig, drop bool) (beat.Processor,
 		processors.add(timeseries.NewTimeSeriesProcessor(b.timeseriesFields))
 	}
 
-	// setup 10: debug print final event (P)
+	// setup 10: validate the event against the fields schema (P)
+	if b.schemaValidation != nil {
+		processors.add(b.schemaValidation)
+	}
+
+	// setup 11: debug print final event (P)
 	if b.log.IsDebug() || management.UnderAgent() {
 		processors.add(debugPrintProcessor(b.info, b.log))
 	}
 
-	// setup 11: drop all events if outputs are disabled (P)
+	// setup 12: drop all events if outputs are disabled (P)
 	if drop {
 		processors.add(dropDisabledProcessor)
 	}
@@ -19,6 +19,8 @@ package processing
 
 import (
 	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -414,6 +416,40 @@ func TestAlwaysDrop(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestSchemaValidation(t *testing.T) {
+	fieldsFile := filepath.Join(t.TempDir(), "fields.yml")
+	require.NoError(t, os.WriteFile(fieldsFile, []byte(`
+- key: test
+  fields:
+    - name: message
+      type: text
+    - name: tags
+      type: keyword
+`), 0o600))
+
+	s, err := MakeDefaultSupport(true, nil)(beat.Info{}, logp.L(), config.MustNewConfigFrom(map[string]interface{}{
+		"schema_validation": map[string]interface{}{
+			"enabled": true,
+			"fields":  fieldsFile,
+		},
+	}))
+	require.NoError(t, err)
+
+	prog, err := s.Create(beat.ProcessingConfig{}, false)
+	require.NoError(t, err)
+
+	actual, err := prog.Run(&beat.Event{Fields: mapstr.M{"message": "hello"}})
+	require.NoError(t, err)
+	assert.Equal(t, mapstr.M{"message": "hello"}, actual.Fields)
+
+	actual, err = prog.Run(&beat.Event{Fields: mapstr.M{"message": "hello", "unknown": 1}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"schema_violation"}, actual.Fields["tags"])
+	assert.Equal(t, []string{"unknown: field is not defined in the schema"}, actual.Meta["schema_violations"])
+
+	require.NoError(t, s.Close())
+}
+
 func TestDynamicFields(t *testing.T) {
 	factory, err := MakeDefaultSupport(true, nil)(beat.Info{}, logp.L(), config.NewConfig())
 	require.NoError(t, err)
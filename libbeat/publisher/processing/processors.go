@@ -42,6 +42,9 @@ type group struct {
 type processorFn struct {
 	name string
 	fn   func(event *beat.Event) (*beat.Event, error)
+
+	// flat is set if fn can handle events with flat fields.
+	flat bool
 }
 
 func newGeneralizeProcessor(keepNull bool) *processorFn {
@@ -49,7 +52,7 @@ func newGeneralizeProcessor(keepNull bool) *processorFn {
 	g := common.NewGenericEventConverter(keepNull)
 	return newProcessor("generalizeEvent", func(event *beat.Event) (*beat.Event, error) {
 		// Filter out empty events. Empty events are still reported by ACK callbacks.
-		if len(event.Fields) == 0 {
+		if len(event.Fields) == 0 && event.Flat.Len() == 0 {
 			return nil, nil
 		}
 
@@ -65,13 +68,17 @@ func newGeneralizeProcessor(keepNull bool) *processorFn {
 		}
 
 		event.Fields = fields
+		// The flat fields are normalized in place, they are merged into
+		// Fields by the first processor that needs it, or when the event
+		// is published.
+		event.Flat.Transform(g.ConvertValue)
 		return event, nil
-	})
+	}).withFlatFields()
 }
 
 var dropDisabledProcessor = newProcessor("dropDisabled", func(event *beat.Event) (*beat.Event, error) {
 	return nil, nil
-})
+}).withFlatFields()
 
 func newGroup(title string, log *logp.Logger) *group {
 	return &group{
@@ -117,6 +124,10 @@ func (p *group) All() []beat.Processor {
 	return p.list
 }
 
+// SupportsFlatFields returns true, the group materializes the event fields
+// for the processors that need it.
+func (p *group) SupportsFlatFields() bool { return true }
+
 func (p *group) Run(event *beat.Event) (*beat.Event, error) {
 	if p == nil || len(p.list) == 0 {
 		return event, nil
@@ -125,6 +136,9 @@ func (p *group) Run(event *beat.Event) (*beat.Event, error) {
 	for _, sub := range p.list {
 		var err error
 
+		if event.Flat != nil && !beat.SupportsFlatFields(sub) {
+			event.Materialize()
+		}
 		event, err = sub.Run(event)
 		if err != nil {
 			// XXX: We don't drop the event, but continue filtering here if the most
@@ -154,15 +168,22 @@ func newAnnotateProcessor(name string, fn func(*beat.Event)) *processorFn {
 	})
 }
 
+// withFlatFields marks p as able to handle events with flat fields.
+func (p *processorFn) withFlatFields() *processorFn {
+	p.flat = true
+	return p
+}
+
 func (p *processorFn) String() string                         { return p.name }
 func (p *processorFn) Run(e *beat.Event) (*beat.Event, error) { return p.fn(e) }
+func (p *processorFn) SupportsFlatFields() bool               { return p.flat }
 
 func clientEventMeta(meta mapstr.M, needsCopy bool) *processorFn {
 	fn := func(event *beat.Event) { addMeta(event, meta) }
 	if needsCopy {
 		fn = func(event *beat.Event) { addMeta(event, meta.Clone()) }
 	}
-	return newAnnotateProcessor("@metadata", fn)
+	return newAnnotateProcessor("@metadata", fn).withFlatFields()
 }
 
 func addMeta(event *beat.Event, meta mapstr.M) {
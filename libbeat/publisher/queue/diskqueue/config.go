@@ -23,6 +23,8 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/klauspost/compress/zstd"
+
 	"github.com/elastic/beats/v7/libbeat/common/cfgtype"
 	"github.com/elastic/elastic-agent-libs/config"
 	"github.com/elastic/elastic-agent-libs/paths"
@@ -69,6 +71,30 @@ type Settings struct {
 
 	// UseCompression enables or disables LZ4 compression
 	UseCompression bool
+
+	// DictionaryCompression configures zstd compression of the individual
+	// events with dictionaries trained per stream.
+	DictionaryCompression DictionaryCompressionSettings
+}
+
+// DictionaryCompressionSettings configures the dictionary compression of
+// events in the queue.
+type DictionaryCompressionSettings struct {
+	Enabled bool `config:"enabled"`
+
+	// Level is the zstd encoder level: fastest, default, better or best.
+	Level string `config:"level"`
+
+	// DictionarySize is the maximum size of a trained dictionary.
+	DictionarySize int `config:"dictionary_size" validate:"min=256"`
+
+	// TrainingSamples is the number of events of a stream that are sampled
+	// before its dictionary is trained.
+	TrainingSamples int `config:"training_samples" validate:"min=10"`
+
+	// MaxDictionaries limits the number of streams a dictionary is trained
+	// for, events of other streams are compressed without dictionary.
+	MaxDictionaries int `config:"max_dictionaries" validate:"min=1"`
 }
 
 // userConfig holds the parameters for a disk queue that are configurable
@@ -83,6 +109,8 @@ type userConfig struct {
 
 	RetryInterval    *time.Duration `config:"retry_interval" validate:"positive"`
 	MaxRetryInterval *time.Duration `config:"max_retry_interval" validate:"positive"`
+
+	DictionaryCompression DictionaryCompressionSettings `config:"dictionary_compression"`
 }
 
 func (c *userConfig) Validate() error {
@@ -115,9 +143,26 @@ func (c *userConfig) Validate() error {
 			*c.MaxRetryInterval, *c.RetryInterval)
 	}
 
+	if level := c.DictionaryCompression.Level; level != "" {
+		if ok, _ := zstd.EncoderLevelFromString(level); !ok {
+			return fmt.Errorf(
+				"disk queue dictionary_compression.level (%v) must be one of fastest, default, better or best",
+				level)
+		}
+	}
+
 	return nil
 }
 
+func defaultDictionaryCompressionSettings() DictionaryCompressionSettings {
+	return DictionaryCompressionSettings{
+		Level:           "default",
+		DictionarySize:  64 * 1024,
+		TrainingSamples: 1000,
+		MaxDictionaries: 64,
+	}
+}
+
 // DefaultSettings returns a Settings object with reasonable default values
 // for all important fields.
 func DefaultSettings() Settings {
@@ -130,13 +175,17 @@ func DefaultSettings() Settings {
 
 		RetryInterval:    1 * time.Second,
 		MaxRetryInterval: 30 * time.Second,
+
+		DictionaryCompression: defaultDictionaryCompressionSettings(),
 	}
 }
 
 // SettingsForUserConfig returns a Settings struct initialized with the
 // end-user-configurable settings in the given config tree.
 func SettingsForUserConfig(config *config.C) (Settings, error) {
-	userConfig := userConfig{}
+	userConfig := userConfig{
+		DictionaryCompression: defaultDictionaryCompressionSettings(),
+	}
 	if err := config.Unpack(&userConfig); err != nil {
 		return Settings{}, fmt.Errorf("couldn't unpack disk queue config: %w", err)
 	}
@@ -166,6 +215,8 @@ func SettingsForUserConfig(config *config.C) (Settings, error) {
 		settings.MaxRetryInterval = *userConfig.MaxRetryInterval
 	}
 
+	settings.DictionaryCompression = userConfig.DictionaryCompression
+
 	return settings, nil
 }
 
@@ -184,6 +235,10 @@ func (settings Settings) stateFilePath() string {
 	return filepath.Join(settings.directoryPath(), "state.dat")
 }
 
+func (settings Settings) dictionaryPath() string {
+	return filepath.Join(settings.directoryPath(), "dictionaries")
+}
+
 func (settings Settings) segmentPath(segmentID segmentID) string {
 	return filepath.Join(
 		settings.directoryPath(),
@@ -272,6 +272,11 @@ func (dq *diskQueue) handleShutdown() {
 	}
 	dq.blockedProducers = nil
 
+	// Stop training dictionaries, nothing more will be written.
+	if dq.dictionaries != nil {
+		dq.dictionaries.Close()
+	}
+
 	// The reader and writer loops are now shut down, and the deleter loop is
 	// idle. The remaining cleanup is in finalizing the read position in the
 	// queue (the first event that hasn't been acknowledged by consumers), and
@@ -0,0 +1,457 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package diskqueue
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/beat/events"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+const (
+	// Dictionary IDs below 32768 are reserved by the zstd format.
+	firstDictionaryID = 32768
+
+	// Length of the n-grams and chunks used to select the dictionary
+	// content from the samples.
+	dictionaryGramSize  = 8
+	dictionaryChunkSize = 64
+)
+
+// dictCompressor compresses serialized events with zstd, using a dictionary
+// per stream once enough events of the stream have been sampled. Trained
+// dictionaries are stored in the dictionaries directory of the queue, since
+// they are needed to decode the segments written with them after a restart.
+//
+// All methods are safe for concurrent use.
+type dictCompressor struct {
+	logger   *logp.Logger
+	settings DictionaryCompressionSettings
+	dir      string
+	level    zstd.EncoderLevel
+
+	lock sync.RWMutex
+	// plain is used for events of streams without a dictionary.
+	plain    *zstd.Encoder
+	encoders map[string]*zstd.Encoder
+	decoder  *zstd.Decoder
+	dicts    [][]byte
+	nextID   uint32
+
+	// samples collected per stream that doesn't have a dictionary yet. A
+	// nil entry means the stream is being trained.
+	samples map[string]*streamSamples
+
+	trainChan chan trainRequest
+	done      chan struct{}
+	wg        sync.WaitGroup
+}
+
+type streamSamples struct {
+	data [][]byte
+}
+
+type trainRequest struct {
+	stream  string
+	samples [][]byte
+}
+
+func newDictCompressor(logger *logp.Logger, settings DictionaryCompressionSettings, dir string) (*dictCompressor, error) {
+	level := zstd.SpeedDefault
+	if settings.Level != "" {
+		var ok bool
+		if ok, level = zstd.EncoderLevelFromString(settings.Level); !ok {
+			return nil, fmt.Errorf("unknown compression level %q", settings.Level)
+		}
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("couldn't create dictionary directory: %w", err)
+	}
+
+	c := &dictCompressor{
+		logger:    logger.Named("dictionaries"),
+		settings:  settings,
+		dir:       dir,
+		level:     level,
+		encoders:  map[string]*zstd.Encoder{},
+		nextID:    firstDictionaryID,
+		samples:   map[string]*streamSamples{},
+		trainChan: make(chan trainRequest, 1),
+		done:      make(chan struct{}),
+	}
+
+	var err error
+	c.plain, err = c.newEncoder(nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.loadDictionaries(); err != nil {
+		return nil, err
+	}
+	if c.decoder == nil {
+		if c.decoder, err = zstd.NewReader(nil, zstd.WithDecoderConcurrency(1)); err != nil {
+			return nil, err
+		}
+	}
+
+	c.wg.Add(1)
+	go c.trainLoop()
+	return c, nil
+}
+
+// Close stops the background trainer. Events can still be compressed and
+// decompressed afterwards, but no new dictionaries are trained.
+func (c *dictCompressor) Close() {
+	close(c.done)
+	c.wg.Wait()
+}
+
+func (c *dictCompressor) newEncoder(dict []byte) (*zstd.Encoder, error) {
+	opts := []zstd.EOption{
+		zstd.WithEncoderLevel(c.level),
+		// Frames are already protected by the frame checksum of the queue.
+		zstd.WithEncoderCRC(false),
+	}
+	if dict != nil {
+		opts = append(opts, zstd.WithEncoderDict(dict))
+	}
+	return zstd.NewWriter(nil, opts...)
+}
+
+// compress returns the zstd frame for the serialized event of the given
+// stream, and samples the event for training if the stream has no
+// dictionary yet.
+func (c *dictCompressor) compress(stream string, data []byte) []byte {
+	c.lock.RLock()
+	enc, ok := c.encoders[stream]
+	if !ok {
+		enc = c.plain
+	}
+	c.lock.RUnlock()
+
+	if !ok {
+		c.sample(stream, data)
+	}
+	return enc.EncodeAll(data, make([]byte, 0, len(data)/2))
+}
+
+// decompress decodes a zstd frame written by compress into dst.
+func (c *dictCompressor) decompress(data, dst []byte) ([]byte, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.decoder.DecodeAll(data, dst[:0])
+}
+
+func (c *dictCompressor) sample(stream string, data []byte) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if _, ok := c.encoders[stream]; ok {
+		return
+	}
+	s, ok := c.samples[stream]
+	if !ok {
+		if len(c.encoders)+len(c.samples) >= c.settings.MaxDictionaries {
+			return
+		}
+		s = &streamSamples{}
+		c.samples[stream] = s
+	}
+	if s == nil {
+		// Training is in progress.
+		return
+	}
+
+	// The caller owns data, keep a copy.
+	s.data = append(s.data, append([]byte(nil), data...))
+	if len(s.data) < c.settings.TrainingSamples {
+		return
+	}
+	select {
+	case c.trainChan <- trainRequest{stream: stream, samples: s.data}:
+		c.samples[stream] = nil
+	default:
+		// The trainer is busy, retry with the next event.
+	}
+}
+
+func (c *dictCompressor) trainLoop() {
+	defer c.wg.Done()
+	for {
+		select {
+		case <-c.done:
+			return
+		case req := <-c.trainChan:
+			if err := c.train(req.stream, req.samples); err != nil {
+				c.logger.Warnf("Failed to train dictionary for stream '%s', events are compressed without dictionary: %v", req.stream, err)
+			}
+		}
+	}
+}
+
+// train builds a dictionary from the samples, stores it and starts using it
+// for the stream. If training fails the stream keeps using the plain encoder.
+func (c *dictCompressor) train(stream string, samples [][]byte) error {
+	c.lock.RLock()
+	id := c.nextID
+	c.lock.RUnlock()
+
+	dict, err := buildDictionary(id, samples, c.settings.DictionarySize, c.level)
+	if err != nil {
+		return err
+	}
+	enc, err := c.newEncoder(dict)
+	if err != nil {
+		return err
+	}
+
+	// The dictionary must be on disk before any frame is written with it.
+	path := filepath.Join(c.dir, dictionaryFileName(id, stream))
+	tmp := path + ".new"
+	if err := os.WriteFile(tmp, dict, 0o600); err != nil {
+		return fmt.Errorf("couldn't write dictionary: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("couldn't write dictionary: %w", err)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if err := c.addDictionaryLocked(dict); err != nil {
+		return err
+	}
+	c.encoders[stream] = enc
+	delete(c.samples, stream)
+
+	if c.logger.IsDebug() {
+		var raw, plain, compressed int
+		for _, s := range samples {
+			raw += len(s)
+			plain += len(c.plain.EncodeAll(s, nil))
+			compressed += len(enc.EncodeAll(s, nil))
+		}
+		c.logger.Debugf(
+			"Trained dictionary %d for stream '%s' from %d samples: %d bytes compress to %d bytes (%d bytes without dictionary)",
+			id, stream, len(samples), raw, compressed, plain)
+	}
+	return nil
+}
+
+func buildDictionary(id uint32, samples [][]byte, size int, level zstd.EncoderLevel) (dict []byte, err error) {
+	// BuildDict panics on degenerate input, e.g. if the samples are
+	// entirely covered by the dictionary content.
+	defer func() {
+		if r := recover(); r != nil {
+			dict, err = nil, fmt.Errorf("couldn't build dictionary: %v", r)
+		}
+	}()
+	return zstd.BuildDict(zstd.BuildDictOptions{
+		ID:       id,
+		Contents: samples,
+		History:  buildDictionaryContent(samples, size),
+		Offsets:  [3]int{1, 4, 8},
+		Level:    level,
+	})
+}
+
+// addDictionaryLocked makes the dictionary available for decoding. c.lock
+// must be held.
+func (c *dictCompressor) addDictionaryLocked(dict []byte) error {
+	dicts := append(c.dicts, dict)
+	decoder, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1), zstd.WithDecoderDicts(dicts...))
+	if err != nil {
+		return fmt.Errorf("couldn't load dictionary: %w", err)
+	}
+	if c.decoder != nil {
+		c.decoder.Close()
+	}
+	c.decoder = decoder
+	c.dicts = dicts
+
+	if info, err := zstd.InspectDictionary(dict); err == nil && info.ID() >= c.nextID {
+		c.nextID = info.ID() + 1
+	}
+	return nil
+}
+
+// loadDictionaries loads the dictionaries of previous sessions, the newest
+// dictionary of each stream is used for encoding again.
+func (c *dictCompressor) loadDictionaries() error {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return fmt.Errorf("couldn't read dictionary directory: %w", err)
+	}
+
+	type dictFile struct {
+		id     uint32
+		stream string
+		name   string
+	}
+	var files []dictFile
+	for _, e := range entries {
+		id, stream, ok := parseDictionaryFileName(e.Name())
+		if !ok || e.IsDir() {
+			continue
+		}
+		files = append(files, dictFile{id: id, stream: stream, name: e.Name()})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].id < files[j].id })
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, f := range files {
+		dict, err := os.ReadFile(filepath.Join(c.dir, f.name))
+		if err != nil {
+			return fmt.Errorf("couldn't read dictionary %s: %w", f.name, err)
+		}
+		if err := c.addDictionaryLocked(dict); err != nil {
+			return fmt.Errorf("couldn't load dictionary %s: %w", f.name, err)
+		}
+		enc, err := c.newEncoder(dict)
+		if err != nil {
+			return fmt.Errorf("couldn't load dictionary %s: %w", f.name, err)
+		}
+		c.encoders[f.stream] = enc
+	}
+	if len(files) > 0 {
+		c.logger.Infof("Loaded %d compression dictionaries", len(files))
+	}
+	return nil
+}
+
+func dictionaryFileName(id uint32, stream string) string {
+	return fmt.Sprintf("%d-%s.dict", id, hex.EncodeToString([]byte(stream)))
+}
+
+func parseDictionaryFileName(name string) (uint32, string, bool) {
+	base, found := strings.CutSuffix(name, ".dict")
+	if !found {
+		return 0, "", false
+	}
+	idStr, streamHex, found := strings.Cut(base, "-")
+	if !found {
+		return 0, "", false
+	}
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		return 0, "", false
+	}
+	stream, err := hex.DecodeString(streamHex)
+	if err != nil {
+		return 0, "", false
+	}
+	return uint32(id), string(stream), true
+}
+
+// buildDictionaryContent selects the chunks of the samples that share the
+// most content with other samples, up to size bytes. The most common chunks
+// are placed at the end of the content, where they are cheapest to
+// reference.
+func buildDictionaryContent(samples [][]byte, size int) []byte {
+	// Count the number of samples each n-gram occurs in.
+	counts := map[uint64]int{}
+	for _, s := range samples {
+		seen := map[uint64]struct{}{}
+		for i := 0; i+dictionaryGramSize <= len(s); i++ {
+			g := binary.LittleEndian.Uint64(s[i:])
+			if _, ok := seen[g]; !ok {
+				seen[g] = struct{}{}
+				counts[g]++
+			}
+		}
+	}
+
+	type chunk struct {
+		data  []byte
+		score int
+	}
+	var chunks []chunk
+	unique := map[string]struct{}{}
+	for _, s := range samples {
+		for start := 0; start < len(s); start += dictionaryChunkSize {
+			end := start + dictionaryChunkSize
+			if end > len(s) {
+				end = len(s)
+			}
+			data := s[start:end]
+			if _, ok := unique[string(data)]; ok {
+				continue
+			}
+			unique[string(data)] = struct{}{}
+
+			score := 0
+			for i := 0; i+dictionaryGramSize <= len(data); i++ {
+				// n-grams that occur in a single sample don't help.
+				score += counts[binary.LittleEndian.Uint64(data[i:])] - 1
+			}
+			if score > 0 {
+				chunks = append(chunks, chunk{data: data, score: score})
+			}
+		}
+	}
+	sort.SliceStable(chunks, func(i, j int) bool { return chunks[i].score > chunks[j].score })
+
+	var selected [][]byte
+	total := 0
+	for _, c := range chunks {
+		if total+len(c.data) > size {
+			break
+		}
+		selected = append(selected, c.data)
+		total += len(c.data)
+	}
+
+	content := make([]byte, 0, total)
+	for i := len(selected) - 1; i >= 0; i-- {
+		content = append(content, selected[i]...)
+	}
+	return content
+}
+
+// streamKey returns the name of the stream the event belongs to, events of
+// the same stream share a dictionary.
+func streamKey(event beat.Event) string {
+	for _, key := range []string{events.FieldMetaIndex, events.FieldMetaRawIndex} {
+		if idx, err := events.GetMetaStringValue(event, key); err == nil {
+			return idx
+		}
+	}
+	for _, key := range []string{"data_stream.dataset", "event.dataset", "input.type"} {
+		if v, err := event.Fields.GetValue(key); err == nil {
+			if s, ok := v.(string); ok {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+var errNoDictionaryCompressor = errors.New("segment uses dictionary compression, but it is not enabled")
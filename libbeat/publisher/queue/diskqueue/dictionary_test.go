@@ -0,0 +1,124 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package diskqueue
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func testLogLine(i int) []byte {
+	return []byte(fmt.Sprintf(
+		`{"@timestamp":"2023-06-01T12:%02d:%02d.000Z","log":{"level":"info","logger":"http.server"},"message":"GET /api/v1/items/%d HTTP/1.1 200 %d","http":{"request":{"method":"GET"},"response":{"status_code":200}},"url":{"path":"/api/v1/items/%d"}}`,
+		i/60%60, i%60, i, 1000+i*7, i))
+}
+
+func newTestDictCompressor(t *testing.T, dir string) *dictCompressor {
+	t.Helper()
+	settings := defaultDictionaryCompressionSettings()
+	settings.TrainingSamples = 100
+	c, err := newDictCompressor(logp.NewLogger("test"), settings, dir)
+	require.NoError(t, err)
+	return c
+}
+
+func TestDictCompressorTraining(t *testing.T) {
+	dir := t.TempDir()
+	c := newTestDictCompressor(t, dir)
+
+	var plainSize int
+	for i := 0; i < 100; i++ {
+		plainSize += len(c.compress("nginx.access", testLogLine(i)))
+	}
+	require.Eventually(t, func() bool {
+		c.lock.RLock()
+		defer c.lock.RUnlock()
+		_, ok := c.encoders["nginx.access"]
+		return ok
+	}, 5*time.Second, 10*time.Millisecond)
+
+	var dictSize int
+	var frames [][]byte
+	for i := 100; i < 200; i++ {
+		frame := c.compress("nginx.access", testLogLine(i))
+		dictSize += len(frame)
+		frames = append(frames, frame)
+	}
+	assert.Less(t, dictSize*2, plainSize, "dictionary should at least halve the size of the events")
+	c.Close()
+
+	// Dictionaries are loaded again, so earlier frames can be decoded.
+	c = newTestDictCompressor(t, dir)
+	defer c.Close()
+	for i, frame := range frames {
+		plain, err := c.decompress(frame, nil)
+		require.NoError(t, err)
+		assert.Equal(t, testLogLine(100+i), plain)
+	}
+	c.lock.RLock()
+	assert.Contains(t, c.encoders, "nginx.access")
+	c.lock.RUnlock()
+}
+
+func TestDictCompressorMaxDictionaries(t *testing.T) {
+	settings := defaultDictionaryCompressionSettings()
+	settings.MaxDictionaries = 1
+	c, err := newDictCompressor(logp.NewLogger("test"), settings, t.TempDir())
+	require.NoError(t, err)
+	defer c.Close()
+
+	c.compress("a", testLogLine(1))
+	c.compress("b", testLogLine(2))
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	assert.Contains(t, c.samples, "a")
+	assert.NotContains(t, c.samples, "b")
+}
+
+func TestDictionaryFileName(t *testing.T) {
+	name := dictionaryFileName(32768, "logs-nginx.access-default")
+	id, stream, ok := parseDictionaryFileName(name)
+	require.True(t, ok)
+	assert.Equal(t, uint32(32768), id)
+	assert.Equal(t, "logs-nginx.access-default", stream)
+
+	_, _, ok = parseDictionaryFileName("32768-xyz.dict")
+	assert.False(t, ok)
+	_, _, ok = parseDictionaryFileName("1.seg")
+	assert.False(t, ok)
+}
+
+func TestStreamKey(t *testing.T) {
+	assert.Equal(t, "logs-default", streamKey(beat.Event{
+		Meta:   mapstr.M{"index": "logs-default"},
+		Fields: mapstr.M{"event": mapstr.M{"dataset": "nginx.access"}},
+	}))
+	assert.Equal(t, "nginx.access", streamKey(beat.Event{
+		Fields: mapstr.M{"event": mapstr.M{"dataset": "nginx.access"}},
+	}))
+	assert.Equal(t, "", streamKey(beat.Event{Fields: mapstr.M{"message": "hello"}}))
+}
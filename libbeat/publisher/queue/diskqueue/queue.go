@@ -43,6 +43,10 @@ type diskQueue struct {
 	// frame.
 	acks *diskQueueACKs
 
+	// If set, events are compressed with per stream dictionaries. It is
+	// also created for decoding if dictionaries of a previous session exist.
+	dictionaries *dictCompressor
+
 	// The queue's helper loops, each of which is run in its own goroutine.
 	readerLoop  *readerLoop
 	writerLoop  *writerLoop
@@ -211,6 +215,14 @@ func NewQueue(
 		encoder = encoderFactory()
 	}
 
+	var dictionaries *dictCompressor
+	if _, statErr := os.Stat(settings.dictionaryPath()); settings.DictionaryCompression.Enabled || statErr == nil {
+		dictionaries, err = newDictCompressor(logger, settings.DictionaryCompression, settings.dictionaryPath())
+		if err != nil {
+			return nil, fmt.Errorf("couldn't initialize dictionary compression: %w", err)
+		}
+	}
+
 	queue := &diskQueue{
 		logger:   logger,
 		observer: observer,
@@ -225,7 +237,9 @@ func NewQueue(
 
 		acks: newDiskQueueACKs(logger, nextReadPosition, positionFile),
 
-		readerLoop:  newReaderLoop(settings, encoder),
+		dictionaries: dictionaries,
+
+		readerLoop:  newReaderLoop(settings, encoder, dictionaries),
 		writerLoop:  newWriterLoop(logger, settings),
 		deleterLoop: newDeleterLoop(settings),
 
@@ -269,10 +283,14 @@ func (dq *diskQueue) BufferConfig() queue.BufferConfig {
 }
 
 func (dq *diskQueue) Producer(cfg queue.ProducerConfig) queue.Producer {
+	encoder := newEventEncoder(SerializationCBOR)
+	if dq.settings.DictionaryCompression.Enabled {
+		encoder.dictionaries = dq.dictionaries
+	}
 	return &diskQueueProducer{
 		queue:   dq,
 		config:  cfg,
-		encoder: newEventEncoder(SerializationCBOR),
+		encoder: encoder,
 		done:    make(chan struct{}),
 	}
 }
@@ -73,9 +73,17 @@ func TestProduceConsumer(t *testing.T) {
 	}
 
 	t.Run("direct", testWith(makeTestQueue()))
+	t.Run("dictionary_compression", testWith(makeTestQueueWithSettings(func(settings *Settings) {
+		settings.DictionaryCompression.Enabled = true
+		settings.DictionaryCompression.TrainingSamples = 10
+	})))
 }
 
 func makeTestQueue() queuetest.QueueFactory {
+	return makeTestQueueWithSettings(func(*Settings) {})
+}
+
+func makeTestQueueWithSettings(configure func(*Settings)) queuetest.QueueFactory {
 	return func(t *testing.T) queue.Queue {
 		dir, err := ioutil.TempDir("", "diskqueue_test")
 		if err != nil {
@@ -83,6 +91,7 @@ func makeTestQueue() queuetest.QueueFactory {
 		}
 		settings := DefaultSettings()
 		settings.Path = dir
+		configure(&settings)
 		queue, _ := NewQueue(logp.L(), nil, settings, nil)
 		return testQueue{
 			diskQueue: queue,
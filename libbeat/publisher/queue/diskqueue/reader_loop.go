@@ -76,14 +76,16 @@ type readerLoop struct {
 	outputEncoder queue.Encoder
 }
 
-func newReaderLoop(settings Settings, outputEncoder queue.Encoder) *readerLoop {
+func newReaderLoop(settings Settings, outputEncoder queue.Encoder, dictionaries *dictCompressor) *readerLoop {
+	decoder := newEventDecoder()
+	decoder.dictionaries = dictionaries
 	return &readerLoop{
 		settings: settings,
 
 		requestChan:   make(chan readerLoopRequest, 1),
 		responseChan:  make(chan readerLoopResponse),
 		output:        make(chan *readFrame, settings.ReadAheadLimit),
-		decoder:       newEventDecoder(),
+		decoder:       decoder,
 		outputEncoder: outputEncoder,
 	}
 }
@@ -108,10 +110,11 @@ func (rl *readerLoop) processRequest(request readerLoopRequest) readerLoopRespon
 
 	// Open the file and seek to the starting position.
 	handle, err := request.segment.getReader(rl.settings)
-	rl.decoder.serializationFormat = handle.serializationFormat
 	if err != nil {
 		return readerLoopResponse{err: err}
 	}
+	rl.decoder.serializationFormat = handle.serializationFormat
+	rl.decoder.dictCompressed = handle.dictCompressed
 	defer handle.Close()
 
 	_, err = handle.Seek(int64(request.startPosition), io.SeekStart)
@@ -151,9 +151,10 @@ const currentSegmentVersion = 2
 const segmentHeaderSize = 12
 
 const (
-	ENABLE_ENCRYPTION  uint32 = 1 << iota // 0x1
-	ENABLE_COMPRESSION                    // 0x2
-	ENABLE_PROTOBUF                       // 0x4
+	ENABLE_ENCRYPTION             uint32 = 1 << iota // 0x1
+	ENABLE_COMPRESSION                               // 0x2
+	ENABLE_PROTOBUF                                  // 0x4
+	ENABLE_DICTIONARY_COMPRESSION                    // 0x8
 )
 
 // Sort order: we store loaded segments in ascending order by their id.
@@ -263,6 +264,7 @@ func (segment *queueSegment) getReader(queueSettings Settings) (*segmentReader,
 			return nil, fmt.Errorf("couldn't create encryption reader: %w", err)
 		}
 	}
+	sr.dictCompressed = (header.options & ENABLE_DICTIONARY_COMPRESSION) == ENABLE_DICTIONARY_COMPRESSION
 	if (header.options & ENABLE_COMPRESSION) == ENABLE_COMPRESSION {
 		if sr.er != nil {
 			sr.cr = NewCompressionReader(sr.er)
@@ -295,6 +297,10 @@ func (segment *queueSegment) getWriter(queueSettings Settings) (*segmentWriter,
 		options = options | ENABLE_COMPRESSION
 	}
 
+	if queueSettings.DictionaryCompression.Enabled {
+		options = options | ENABLE_DICTIONARY_COMPRESSION
+	}
+
 	sw := &segmentWriter{}
 	sw.dst = file
 
@@ -477,6 +483,9 @@ type segmentReader struct {
 	er                  *EncryptionReader
 	cr                  *CompressionReader
 	serializationFormat SerializationFormat
+	// dictCompressed is set if every frame is a zstd frame, see
+	// dictCompressor.
+	dictCompressed bool
 }
 
 func (r *segmentReader) Read(p []byte) (int, error) {
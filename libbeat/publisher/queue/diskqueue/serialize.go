@@ -45,6 +45,9 @@ type eventEncoder struct {
 	buf                 bytes.Buffer
 	folder              *gotype.Iterator
 	serializationFormat SerializationFormat
+
+	// If set, serialized events are compressed with dictionaries.
+	dictionaries *dictCompressor
 }
 
 type eventDecoder struct {
@@ -53,6 +56,12 @@ type eventDecoder struct {
 	cborlParser         *cborl.Parser
 	unfolder            *gotype.Unfolder
 	serializationFormat SerializationFormat
+
+	// dictCompressed is set if the frames of the current segment are
+	// compressed with dictionaries, they are decompressed into plain.
+	dictCompressed bool
+	dictionaries   *dictCompressor
+	plain          []byte
 }
 
 type entry struct {
@@ -93,7 +102,11 @@ func (e *eventEncoder) encode(evt interface{}) ([]byte, error) {
 		if e.serializationFormat != SerializationCBOR {
 			return nil, fmt.Errorf("incompatible serialization for type %T. Only CBOR is supported", v)
 		}
-		return e.encode_publisher_event(v)
+		serialized, err := e.encode_publisher_event(v)
+		if err != nil || e.dictionaries == nil {
+			return serialized, err
+		}
+		return e.dictionaries.compress(streamKey(v.Content), serialized), nil
 	default:
 		return nil, fmt.Errorf("no known serialization format for type %T", v)
 	}
@@ -160,6 +173,19 @@ func (d *eventDecoder) decodeJSONAndCBOR() (publisher.Event, error) {
 
 	var to entry
 
+	buf := d.buf
+	if d.dictCompressed {
+		if d.dictionaries == nil {
+			return publisher.Event{}, errNoDictionaryCompressor
+		}
+		var err error
+		d.plain, err = d.dictionaries.decompress(d.buf, d.plain)
+		if err != nil {
+			return publisher.Event{}, fmt.Errorf("couldn't decompress event: %w", err)
+		}
+		buf = d.plain
+	}
+
 	err := d.unfolder.SetTarget(&to)
 	if err != nil {
 		return publisher.Event{}, err
@@ -168,9 +194,9 @@ func (d *eventDecoder) decodeJSONAndCBOR() (publisher.Event, error) {
 
 	switch d.serializationFormat {
 	case SerializationJSON:
-		err = d.jsonParser.Parse(d.buf)
+		err = d.jsonParser.Parse(buf)
 	case SerializationCBOR:
-		err = d.cborlParser.Parse(d.buf)
+		err = d.cborlParser.Parse(buf)
 	default:
 		err = fmt.Errorf("unknown serialization format: %d", d.serializationFormat)
 	}
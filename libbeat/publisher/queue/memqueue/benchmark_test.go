@@ -0,0 +1,83 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Usage:
+//
+// go test -run none -bench Queue -count 10 | tee results.txt && benchstat results.txt
+
+package memqueue
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/elastic/beats/v7/libbeat/publisher/queue"
+)
+
+// benchmarkQueue publishes b.N events from several producers and consumes
+// them in batches until they are all acknowledged.
+func benchmarkQueue(b *testing.B, lanes, producers int) {
+	q := NewShardedQueue(nil, nil, Settings{
+		Events:        4096,
+		MaxGetRequest: 512,
+		Lanes:         lanes,
+	}, 0, nil)
+	defer q.Close()
+
+	var wg sync.WaitGroup
+	b.ResetTimer()
+	for i := 0; i < producers; i++ {
+		count := b.N / producers
+		if i == 0 {
+			count += b.N % producers
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p := q.Producer(queue.ProducerConfig{})
+			for j := 0; j < count; j++ {
+				p.Publish(j)
+			}
+		}()
+	}
+
+	for consumed := 0; consumed < b.N; {
+		batch, err := q.Get(512)
+		if err != nil {
+			b.Fatal(err)
+		}
+		consumed += batch.Count()
+		batch.Done()
+	}
+	wg.Wait()
+}
+
+func BenchmarkQueue(b *testing.B) {
+	for _, bc := range []struct {
+		name             string
+		lanes, producers int
+	}{
+		{"unsharded/1_producer", 1, 1},
+		{"unsharded/8_producers", 1, 8},
+		{"sharded/1_producer", 4, 1},
+		{"sharded/8_producers", 4, 8},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			benchmarkQueue(b, bc.lanes, bc.producers)
+		})
+	}
+}
@@ -105,6 +105,10 @@ type Settings struct {
 	// If positive, the amount of time the queue will wait to fill up
 	// a batch if a Get request asks for more events than we have.
 	FlushTimeout time.Duration
+
+	// The number of independent lanes the queue is split into. Values
+	// below 2 create a single broker.
+	Lanes int
 }
 
 type queueEntry struct {
@@ -145,7 +149,7 @@ func FactoryForSettings(settings Settings) queue.QueueFactory {
 		inputQueueSize int,
 		encoderFactory queue.EncoderFactory,
 	) (queue.Queue, error) {
-		return NewQueue(logger, observer, settings, inputQueueSize, encoderFactory), nil
+		return NewShardedQueue(logger, observer, settings, inputQueueSize, encoderFactory), nil
 	}
 }
 
@@ -160,8 +164,12 @@ func NewQueue(
 	encoderFactory queue.EncoderFactory,
 ) *broker {
 	b := newQueue(logger, observer, settings, inputQueueSize, encoderFactory)
+	b.start()
+	return b
+}
 
-	// Start the queue workers
+// start starts the queue workers.
+func (b *broker) start() {
 	b.wg.Add(2)
 	go func() {
 		defer b.wg.Done()
@@ -171,8 +179,6 @@ func NewQueue(
 		defer b.wg.Done()
 		b.ackLoop.run()
 	}()
-
-	return b
 }
 
 // newQueue does most of the work of creating a queue from the given
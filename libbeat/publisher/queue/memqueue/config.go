@@ -25,6 +25,9 @@ import (
 	c "github.com/elastic/elastic-agent-libs/config"
 )
 
+// minLaneEvents is the minimum size of each lane of a sharded queue.
+const minLaneEvents = 32
+
 type config struct {
 	Events int `config:"events" validate:"min=32"`
 	// This field is named MaxGetRequest because its logical effect is to give
@@ -33,18 +36,25 @@ type config struct {
 	// since it used to control buffer size in the internal buffer chain.
 	MaxGetRequest int           `config:"flush.min_events" validate:"min=0"`
 	FlushTimeout  time.Duration `config:"flush.timeout"`
+	// Lanes splits the queue into independent lanes to reduce contention
+	// between producers on hosts with many cores.
+	Lanes int `config:"lanes" validate:"min=1"`
 }
 
 var defaultConfig = config{
 	Events:        3200,
 	MaxGetRequest: 1600,
 	FlushTimeout:  10 * time.Second,
+	Lanes:         1,
 }
 
 func (c *config) Validate() error {
 	if c.MaxGetRequest > c.Events {
 		return errors.New("flush.min_events must be less events")
 	}
+	if c.Events/c.Lanes < minLaneEvents {
+		return fmt.Errorf("events must be at least %d per lane", minLaneEvents)
+	}
 	return nil
 }
 
@@ -62,5 +72,6 @@ func SettingsForUserConfig(cfg *c.C) (Settings, error) {
 		Events:        config.Events,
 		MaxGetRequest: config.MaxGetRequest,
 		FlushTimeout:  config.FlushTimeout,
+		Lanes:         config.Lanes,
 	}, nil
 }
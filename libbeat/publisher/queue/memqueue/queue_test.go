@@ -20,6 +20,7 @@ package memqueue
 import (
 	"flag"
 	"fmt"
+	"io"
 	"math"
 	"math/rand"
 	"sync"
@@ -73,6 +74,8 @@ func TestProduceConsumer(t *testing.T) {
 
 	t.Run("direct", testWith(makeTestQueue(bufferSize, 0, 0)))
 	t.Run("flush", testWith(makeTestQueue(bufferSize, batchSize/2, 100*time.Millisecond)))
+	t.Run("sharded direct", testWith(makeTestShardedQueue(bufferSize, 0, 0, 4)))
+	t.Run("sharded flush", testWith(makeTestShardedQueue(bufferSize, batchSize/2, 100*time.Millisecond, 4)))
 }
 
 // TestProducerDoesNotBlockWhenQueueClosed ensures the producer Publish
@@ -237,6 +240,90 @@ func makeTestQueue(sz, minEvents int, flushTimeout time.Duration) queuetest.Queu
 	}
 }
 
+func makeTestShardedQueue(sz, minEvents int, flushTimeout time.Duration, lanes int) queuetest.QueueFactory {
+	return func(_ *testing.T) queue.Queue {
+		return NewShardedQueue(nil, nil, Settings{
+			Events:        sz,
+			MaxGetRequest: minEvents,
+			FlushTimeout:  flushTimeout,
+			Lanes:         lanes,
+		}, 0, nil)
+	}
+}
+
+func TestShardedQueue(t *testing.T) {
+	q := NewShardedQueue(nil, nil, Settings{
+		Events:        100,
+		MaxGetRequest: 10,
+		Lanes:         4,
+	}, 0, nil)
+	defer q.Close()
+
+	sharded, ok := q.(*shardedQueue)
+	require.True(t, ok, "a queue with several lanes must be sharded")
+	require.Len(t, sharded.lanes, 4)
+	assert.Equal(t, 100, q.BufferConfig().MaxEvents)
+	for _, lane := range sharded.lanes {
+		assert.Equal(t, 25, lane.settings.Events)
+		assert.Equal(t, 3, lane.settings.MaxGetRequest)
+	}
+
+	// Producers are spread over the lanes, and consumers get events from
+	// any lane.
+	var acked atomic.Int64
+	lanes := map[*broker]bool{}
+	for i := 0; i < 4; i++ {
+		p := q.Producer(queue.ProducerConfig{ACK: func(count int) { acked.Add(int64(count)) }})
+		lanes[p.(*ackProducer).broker] = true
+		_, ok := p.Publish(i)
+		require.True(t, ok)
+	}
+	assert.Len(t, lanes, 4, "every lane must get a producer")
+
+	var got []int
+	for len(got) < 4 {
+		batch, err := q.Get(10)
+		require.NoError(t, err)
+		for i := 0; i < batch.Count(); i++ {
+			got = append(got, batch.Entry(i).(int))
+		}
+		batch.Done()
+	}
+	assert.ElementsMatch(t, []int{0, 1, 2, 3}, got)
+	assert.Eventually(t, func() bool { return acked.Load() == 4 }, time.Second, time.Millisecond)
+}
+
+func TestShardedQueueSingleLane(t *testing.T) {
+	q := NewShardedQueue(nil, nil, Settings{Events: 10, Lanes: 1}, 0, nil)
+	defer q.Close()
+	_, ok := q.(*broker)
+	assert.True(t, ok, "a queue with one lane must not be sharded")
+}
+
+func TestShardedQueueClose(t *testing.T) {
+	q := NewShardedQueue(nil, nil, Settings{Events: 64, Lanes: 2}, 0, nil)
+	for i := 0; i < 2; i++ {
+		_, ok := q.Producer(queue.ProducerConfig{ACK: func(int) {}}).Publish(i)
+		require.True(t, ok)
+	}
+	require.NoError(t, q.Close())
+
+	// The queue is done once the events of all the lanes are acknowledged.
+	for consumed := 0; consumed < 2; {
+		batch, err := q.Get(10)
+		require.NoError(t, err)
+		consumed += batch.Count()
+		batch.Done()
+	}
+	select {
+	case <-q.Done():
+	case <-time.After(time.Second):
+		t.Fatal("the sharded queue must be done after all its lanes")
+	}
+	_, err := q.Get(1)
+	assert.ErrorIs(t, err, io.EOF)
+}
+
 func TestAdjustInputQueueSize(t *testing.T) {
 	t.Run("zero yields default value (main queue size=0)", func(t *testing.T) {
 		assert.Equal(t, minInputQueueSize, AdjustInputQueueSize(0, 0))
@@ -0,0 +1,141 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package memqueue
+
+import (
+	"io"
+	"sync/atomic"
+
+	"github.com/elastic/beats/v7/libbeat/publisher/queue"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+// shardedQueue splits the memory queue into independent lanes, each with
+// its own broker and run loop, so that producers on different lanes don't
+// contend on a single channel. Each producer is pinned to a lane, which
+// preserves the ordering and acknowledgment of its events. Consumers take
+// batches from whichever lane has events available.
+type shardedQueue struct {
+	lanes []*broker
+
+	// getChan is shared by the run loops of all the lanes. A run loop only
+	// receives get requests while its lane has events that weren't
+	// consumed yet, so a request is served by any lane with events.
+	getChan chan getRequest
+
+	// nextLane assigns lanes to new producers in round-robin order.
+	nextLane atomic.Uint64
+
+	done chan struct{}
+}
+
+// NewShardedQueue creates a memory queue with settings.Lanes lanes. The
+// queue size and maximum get request of the settings are divided between
+// the lanes.
+func NewShardedQueue(
+	logger *logp.Logger,
+	observer queue.Observer,
+	settings Settings,
+	inputQueueSize int,
+	encoderFactory queue.EncoderFactory,
+) queue.Queue {
+	if settings.Lanes <= 1 {
+		return NewQueue(logger, observer, settings, inputQueueSize, encoderFactory)
+	}
+	if observer == nil {
+		observer = queue.NewQueueObserver(nil)
+	}
+	if logger == nil {
+		logger = logp.NewLogger("memqueue")
+	}
+
+	n := settings.Lanes
+	laneSettings := settings
+	laneSettings.Events = (settings.Events + n - 1) / n
+	if settings.MaxGetRequest > 1 {
+		laneSettings.MaxGetRequest = (settings.MaxGetRequest + n - 1) / n
+		if laneSettings.MaxGetRequest < 2 {
+			// Don't fall back to the synchronous mode of small values.
+			laneSettings.MaxGetRequest = 2
+		}
+	}
+
+	q := &shardedQueue{
+		lanes:   make([]*broker, n),
+		getChan: make(chan getRequest),
+		done:    make(chan struct{}),
+	}
+	for i := range q.lanes {
+		lane := newQueue(logger.With("lane", i), observer, laneSettings, inputQueueSize, encoderFactory)
+		lane.getChan = q.getChan
+		lane.start()
+		q.lanes[i] = lane
+	}
+	// Every lane reports its own size, report the total instead.
+	observer.MaxEvents(laneSettings.Events * n)
+
+	go func() {
+		for _, lane := range q.lanes {
+			<-lane.Done()
+		}
+		close(q.done)
+	}()
+
+	return q
+}
+
+func (q *shardedQueue) Close() error {
+	for _, lane := range q.lanes {
+		lane.Close()
+	}
+	return nil
+}
+
+func (q *shardedQueue) Done() <-chan struct{} {
+	return q.done
+}
+
+func (q *shardedQueue) QueueType() string {
+	return QueueType
+}
+
+func (q *shardedQueue) BufferConfig() queue.BufferConfig {
+	var events int
+	for _, lane := range q.lanes {
+		events += len(lane.buf)
+	}
+	return queue.BufferConfig{MaxEvents: events}
+}
+
+func (q *shardedQueue) Producer(cfg queue.ProducerConfig) queue.Producer {
+	lane := q.nextLane.Add(1) % uint64(len(q.lanes))
+	return q.lanes[lane].Producer(cfg)
+}
+
+// Get returns a batch from any lane with events available.
+func (q *shardedQueue) Get(count int) (queue.Batch, error) {
+	responseChan := make(chan *batch, 1)
+	select {
+	case <-q.done:
+		return nil, io.EOF
+	case q.getChan <- getRequest{entryCount: count, responseChan: responseChan}:
+	}
+
+	// if request has been sent, we have to wait for a response
+	return <-responseChan, nil
+}
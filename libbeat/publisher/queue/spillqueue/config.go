@@ -0,0 +1,71 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package spillqueue
+
+import (
+	"fmt"
+
+	"github.com/elastic/beats/v7/libbeat/publisher/queue/diskqueue"
+	"github.com/elastic/beats/v7/libbeat/publisher/queue/memqueue"
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/paths"
+)
+
+// Settings contains the configuration of the memory queue events are
+// buffered in and the disk queue they spill over to.
+type Settings struct {
+	Mem  memqueue.Settings
+	Disk diskqueue.Settings
+}
+
+type userConfig struct {
+	Mem  *config.C `config:"mem"`
+	Disk *config.C `config:"disk"`
+}
+
+// SettingsForUserConfig returns a Settings struct initialized with the
+// end-user-configurable settings in the given config tree. The `mem` and
+// `disk` sections accept the settings of the memory and disk queue.
+func SettingsForUserConfig(cfg *config.C) (Settings, error) {
+	var user userConfig
+	if cfg != nil {
+		if err := cfg.Unpack(&user); err != nil {
+			return Settings{}, fmt.Errorf("couldn't unpack spill queue config: %w", err)
+		}
+	}
+
+	mem, err := memqueue.SettingsForUserConfig(user.Mem)
+	if err != nil {
+		return Settings{}, err
+	}
+
+	diskConfig := user.Disk
+	if diskConfig == nil {
+		diskConfig = config.NewConfig()
+	}
+	disk, err := diskqueue.SettingsForUserConfig(diskConfig)
+	if err != nil {
+		return Settings{}, err
+	}
+	if disk.Path == "" {
+		// Don't share the directory with a disk queue configured before.
+		disk.Path = paths.Resolve(paths.Data, "spillqueue")
+	}
+
+	return Settings{Mem: mem, Disk: disk}, nil
+}
@@ -0,0 +1,95 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package spillqueue
+
+import (
+	"sync"
+
+	"github.com/elastic/beats/v7/libbeat/publisher/queue"
+)
+
+// drainer reads events from the disk queue and publishes them to the memory
+// queue. The disk batches are only acknowledged once the memory queue
+// acknowledged all their events, so spilled events survive a restart until
+// the output processed them.
+type drainer struct {
+	q         *spillQueue
+	batchSize int
+	producer  queue.Producer
+
+	mu      sync.Mutex
+	pending []queue.Batch
+	acked   int
+
+	wg sync.WaitGroup
+}
+
+func newDrainer(q *spillQueue, batchSize int) *drainer {
+	d := &drainer{q: q, batchSize: batchSize}
+	d.producer = q.mem.Producer(queue.ProducerConfig{ACK: d.onACK})
+	d.wg.Add(1)
+	return d
+}
+
+func (d *drainer) run() {
+	defer d.wg.Done()
+	defer d.producer.Close()
+
+	for {
+		batch, err := d.q.disk.Get(d.batchSize)
+		if err != nil {
+			// The disk queue was closed.
+			return
+		}
+		d.q.drained(batch.Count())
+
+		d.mu.Lock()
+		d.pending = append(d.pending, batch)
+		d.mu.Unlock()
+
+		for i := 0; i < batch.Count(); i++ {
+			if !d.q.acquire() {
+				return
+			}
+			if _, ok := d.producer.Publish(batch.Entry(i)); !ok {
+				return
+			}
+		}
+		batch.FreeEntries()
+	}
+}
+
+// onACK acknowledges the disk batches whose events were all acknowledged by
+// the memory queue.
+func (d *drainer) onACK(n int) {
+	d.q.release(n)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.acked += n
+	for len(d.pending) > 0 && d.pending[0].Count() <= d.acked {
+		d.acked -= d.pending[0].Count()
+		d.pending[0].Done()
+		d.pending = d.pending[1:]
+	}
+}
+
+func (d *drainer) wait() {
+	d.wg.Wait()
+}
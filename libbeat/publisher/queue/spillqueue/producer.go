@@ -0,0 +1,158 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package spillqueue
+
+import (
+	"sync"
+
+	"github.com/elastic/beats/v7/libbeat/publisher/queue"
+)
+
+// producer publishes events to the memory queue, or to the disk queue if the
+// memory queue is full or spilled events are waiting on disk.
+//
+// The memory queue acknowledges events once the output processed them, the
+// disk queue once they are persisted. Acknowledgements are reported to the
+// pipeline in publish order, so the producer keeps track of which queue
+// each run of events went to.
+type producer struct {
+	q    *spillQueue
+	mem  queue.Producer
+	disk queue.Producer
+	ack  func(int)
+
+	// pubMu serializes publishing so runs are recorded in publish order. It
+	// is held while blocked on the disk queue, so ACKs use mu instead.
+	pubMu sync.Mutex
+
+	mu    sync.Mutex
+	runs  []run
+	acked [2]int // acknowledged but not yet reported, per target
+}
+
+type target int
+
+const (
+	targetMem target = iota
+	targetDisk
+)
+
+// run is a sequence of events published to the same queue.
+type run struct {
+	target target
+	count  int
+}
+
+func newProducer(q *spillQueue, cfg queue.ProducerConfig) *producer {
+	p := &producer{q: q, ack: cfg.ACK}
+	// The memory queue's ACKs are always needed to track its free room.
+	memCfg := queue.ProducerConfig{ACK: func(n int) {
+		q.release(n)
+		p.onACK(targetMem, n)
+	}}
+	var diskCfg queue.ProducerConfig
+	if cfg.ACK != nil {
+		diskCfg.ACK = func(n int) { p.onACK(targetDisk, n) }
+	}
+	p.mem = q.mem.Producer(memCfg)
+	p.disk = q.disk.Producer(diskCfg)
+	return p
+}
+
+func (p *producer) Publish(entry queue.Entry) (queue.EntryID, bool) {
+	return p.publish(entry, p.disk.Publish)
+}
+
+func (p *producer) TryPublish(entry queue.Entry) (queue.EntryID, bool) {
+	return p.publish(entry, p.disk.TryPublish)
+}
+
+// publish adds the event to the memory queue if it has room and falls back
+// to publishing to disk with publishDisk.
+func (p *producer) publish(entry queue.Entry, publishDisk func(queue.Entry) (queue.EntryID, bool)) (queue.EntryID, bool) {
+	p.pubMu.Lock()
+	defer p.pubMu.Unlock()
+
+	if !p.q.shouldSpill() && p.q.tryAcquire() {
+		id, ok := p.mem.Publish(entry)
+		if ok {
+			p.record(targetMem)
+		} else {
+			p.q.release(1)
+		}
+		return id, ok
+	}
+	id, ok := publishDisk(entry)
+	if ok {
+		p.q.spilled()
+		p.record(targetDisk)
+	}
+	return id, ok
+}
+
+// record adds a published event to the runs. The event might already be
+// acknowledged, so pending ACKs are released too.
+func (p *producer) record(t target) {
+	if p.ack == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if n := len(p.runs); n > 0 && p.runs[n-1].target == t {
+		p.runs[n-1].count++
+	} else {
+		p.runs = append(p.runs, run{target: t, count: 1})
+	}
+	p.releaseLocked()
+}
+
+func (p *producer) onACK(t target, n int) {
+	if p.ack == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.acked[t] += n
+	p.releaseLocked()
+}
+
+// releaseLocked reports the acknowledged events at the start of the runs.
+func (p *producer) releaseLocked() {
+	reported := 0
+	for len(p.runs) > 0 {
+		r := &p.runs[0]
+		done := min(r.count, p.acked[r.target])
+		r.count -= done
+		p.acked[r.target] -= done
+		reported += done
+		if r.count > 0 {
+			break
+		}
+		p.runs = p.runs[1:]
+	}
+	if reported > 0 {
+		p.ack(reported)
+	}
+}
+
+func (p *producer) Close() {
+	p.mem.Close()
+	p.disk.Close()
+}
@@ -0,0 +1,201 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package spillqueue
+
+import (
+	"sync"
+
+	"github.com/elastic/beats/v7/libbeat/publisher/queue"
+	"github.com/elastic/beats/v7/libbeat/publisher/queue/diskqueue"
+	"github.com/elastic/beats/v7/libbeat/publisher/queue/memqueue"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+const QueueType = "spill"
+
+// spillQueue buffers events in a memory queue. When the memory queue is full,
+// events are written to a disk queue instead of blocking the producers. The
+// spilled events are moved back to the memory queue as the output catches up.
+// Consumers only read from the memory queue.
+type spillQueue struct {
+	logger *logp.Logger
+	mem    queue.Queue
+	disk   queue.Queue
+
+	// drain moves events from the disk queue back to the memory queue.
+	drain *drainer
+
+	// slots has a token for every event in the memory queue. The memory
+	// queue's TryPublish blocks while its input channel has room, so the
+	// spill queue tracks itself whether the memory queue is full.
+	slots   chan struct{}
+	closing chan struct{}
+
+	mu sync.Mutex
+	// onDisk is the number of events written to the disk queue and not yet
+	// read by the drainer. While it is positive new events are spilled too,
+	// so events don't overtake spilled ones.
+	onDisk int
+	// spilling is used to log transitions between spilling and not.
+	spilling bool
+
+	done chan struct{}
+}
+
+// FactoryForSettings is a simple wrapper around NewQueue so a concrete
+// Settings object can be wrapped in a queue-agnostic interface for
+// later use by the pipeline.
+func FactoryForSettings(settings Settings) queue.QueueFactory {
+	return func(
+		logger *logp.Logger,
+		observer queue.Observer,
+		inputQueueSize int,
+		encoderFactory queue.EncoderFactory,
+	) (queue.Queue, error) {
+		return NewQueue(logger, observer, settings, inputQueueSize, encoderFactory)
+	}
+}
+
+// NewQueue creates a memory queue spilling over to a disk queue. Only the
+// memory queue reports to the observer, the disk queue's events are counted
+// once they are moved back to memory.
+func NewQueue(
+	logger *logp.Logger,
+	observer queue.Observer,
+	settings Settings,
+	inputQueueSize int,
+	encoderFactory queue.EncoderFactory,
+) (queue.Queue, error) {
+	if logger == nil {
+		logger = logp.NewLogger("publisher")
+	}
+	logger = logger.Named("spillqueue")
+
+	// Events on disk are encoded by the memory queue once drained.
+	disk, err := diskqueue.NewQueue(logger, nil, settings.Disk, nil)
+	if err != nil {
+		return nil, err
+	}
+	mem := memqueue.NewQueue(logger, observer, settings.Mem, inputQueueSize, encoderFactory)
+
+	q := &spillQueue{
+		logger:  logger,
+		mem:     mem,
+		disk:    disk,
+		slots:   make(chan struct{}, settings.Mem.Events),
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	q.drain = newDrainer(q, settings.Mem.MaxGetRequest)
+	go q.drain.run()
+	go func() {
+		<-q.mem.Done()
+		<-q.disk.Done()
+		q.drain.wait()
+		close(q.done)
+	}()
+	return q, nil
+}
+
+// Close closes both queues. Events that weren't moved back to memory stay on
+// disk and are drained after a restart.
+func (q *spillQueue) Close() error {
+	close(q.closing)
+	err := q.disk.Close()
+	if memErr := q.mem.Close(); err == nil {
+		err = memErr
+	}
+	return err
+}
+
+func (q *spillQueue) Done() <-chan struct{} {
+	return q.done
+}
+
+func (q *spillQueue) QueueType() string {
+	return QueueType
+}
+
+// BufferConfig reports no fixed limit, like the disk queue.
+func (q *spillQueue) BufferConfig() queue.BufferConfig {
+	return queue.BufferConfig{MaxEvents: 0}
+}
+
+func (q *spillQueue) Producer(cfg queue.ProducerConfig) queue.Producer {
+	return newProducer(q, cfg)
+}
+
+func (q *spillQueue) Get(eventCount int) (queue.Batch, error) {
+	return q.mem.Get(eventCount)
+}
+
+// tryAcquire reserves room for an event in the memory queue if available.
+func (q *spillQueue) tryAcquire() bool {
+	select {
+	case q.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// acquire waits for room in the memory queue. It returns false if the queue
+// is closed.
+func (q *spillQueue) acquire() bool {
+	select {
+	case q.slots <- struct{}{}:
+		return true
+	case <-q.closing:
+		return false
+	}
+}
+
+// release frees the room of n events removed from the memory queue.
+func (q *spillQueue) release(n int) {
+	for i := 0; i < n; i++ {
+		<-q.slots
+	}
+}
+
+// shouldSpill returns true if events are currently written to disk.
+func (q *spillQueue) shouldSpill() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.onDisk > 0
+}
+
+func (q *spillQueue) spilled() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.onDisk++
+	if !q.spilling {
+		q.spilling = true
+		q.logger.Info("Memory queue is full, spilling events to disk")
+	}
+}
+
+func (q *spillQueue) drained(n int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	// Events from a previous run aren't counted, so don't go below zero.
+	q.onDisk = max(q.onDisk-n, 0)
+	if q.onDisk == 0 && q.spilling {
+		q.spilling = false
+		q.logger.Info("Spilled events moved back to the memory queue")
+	}
+}
@@ -0,0 +1,147 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package spillqueue
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/publisher"
+	"github.com/elastic/beats/v7/libbeat/publisher/queue"
+	"github.com/elastic/beats/v7/libbeat/publisher/queue/diskqueue"
+	"github.com/elastic/beats/v7/libbeat/publisher/queue/memqueue"
+	"github.com/elastic/beats/v7/libbeat/publisher/queue/queuetest"
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func testSettings(t *testing.T, memEvents int) Settings {
+	disk := diskqueue.DefaultSettings()
+	disk.Path = t.TempDir()
+	return Settings{
+		Mem: memqueue.Settings{
+			Events:        memEvents,
+			MaxGetRequest: 1,
+		},
+		Disk: disk,
+	}
+}
+
+func makeTestQueue(memEvents int) queuetest.QueueFactory {
+	return func(t *testing.T) queue.Queue {
+		q, err := NewQueue(nil, nil, testSettings(t, memEvents), 0, nil)
+		require.NoError(t, err)
+		return q
+	}
+}
+
+func TestProduceConsumer(t *testing.T) {
+	events := 1000
+	batchSize := 50
+
+	t.Run("single", func(t *testing.T) {
+		queuetest.TestSingleProducerConsumer(t, events, batchSize, makeTestQueue(4))
+	})
+	t.Run("multi", func(t *testing.T) {
+		queuetest.TestMultiProducerConsumer(t, events, batchSize, makeTestQueue(4))
+	})
+}
+
+func TestSpillAndDrain(t *testing.T) {
+	q, err := NewQueue(nil, nil, testSettings(t, 2), 0, nil)
+	require.NoError(t, err)
+	defer q.Close()
+
+	var mu sync.Mutex
+	acked := 0
+	p := q.Producer(queue.ProducerConfig{ACK: func(n int) {
+		mu.Lock()
+		acked += n
+		mu.Unlock()
+	}})
+
+	// Publishing doesn't block although the memory queue only holds two
+	// events.
+	for i := 0; i < 10; i++ {
+		_, ok := p.TryPublish(queuetest.MakeEvent(mapstr.M{"n": i}))
+		require.True(t, ok, "event %d not published", i)
+	}
+
+	var got []int
+	for len(got) < 10 {
+		batch, err := q.Get(10)
+		require.NoError(t, err)
+		for i := 0; i < batch.Count(); i++ {
+			event, ok := batch.Entry(i).(publisher.Event)
+			require.True(t, ok)
+			// Events read back from disk don't keep their numeric types.
+			n, _ := event.Content.Fields.GetValue("n")
+			got = append(got, int(reflect.ValueOf(n).Convert(reflect.TypeOf(0)).Int()))
+		}
+		batch.Done()
+	}
+	assert.ElementsMatch(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, got)
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return acked == 10
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestProducerACKOrder(t *testing.T) {
+	var reported []int
+	p := &producer{ack: func(n int) { reported = append(reported, n) }}
+
+	p.record(targetMem)
+	p.record(targetDisk)
+	p.record(targetDisk)
+	p.record(targetMem)
+
+	// Disk events are persisted before the first memory event is ACKed by
+	// the output, they must not be reported before it.
+	p.onACK(targetDisk, 2)
+	assert.Empty(t, reported)
+
+	p.onACK(targetMem, 1)
+	assert.Equal(t, []int{3}, reported)
+
+	p.onACK(targetMem, 1)
+	assert.Equal(t, []int{3, 1}, reported)
+	assert.Empty(t, p.runs)
+}
+
+func TestSettingsForUserConfig(t *testing.T) {
+	settings, err := SettingsForUserConfig(config.MustNewConfigFrom(mapstr.M{
+		"mem.events":    4096,
+		"disk.max_size": "100MB",
+		"disk.path":     "/tmp/spill",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, 4096, settings.Mem.Events)
+	assert.Equal(t, uint64(100*1000*1000), settings.Disk.MaxBufferSize)
+	assert.Equal(t, "/tmp/spill", settings.Disk.Path)
+
+	_, err = SettingsForUserConfig(config.MustNewConfigFrom(mapstr.M{"mem.events": 4096}))
+	assert.Error(t, err, "disk.max_size is required")
+}
@@ -34,6 +34,10 @@ type Message struct {
 	Fields  mapstr.M  // optional fields that can be added by reader
 	Meta    mapstr.M  // deprecated
 	Private interface{}
+
+	// Flat holds optional fields added by readers in flat form, see
+	// beat.FlatFields.
+	Flat *beat.FlatFields
 }
 
 // IsEmpty returns true in case the message is empty
@@ -46,7 +50,7 @@ func (m *Message) IsEmpty() bool {
 	}
 
 	// Content length can be 0 because of JSON events. Content and Fields must be empty.
-	if len(m.Content) == 0 && len(m.Fields) == 0 {
+	if len(m.Content) == 0 && len(m.Fields) == 0 && m.Flat.Len() == 0 {
 		return true
 	}
 
@@ -95,5 +99,6 @@ func (m *Message) ToEvent() beat.Event {
 		Meta:      m.Meta,
 		Fields:    m.Fields,
 		Private:   m.Private,
+		Flat:      m.Flat,
 	}
 }
@@ -141,6 +141,11 @@ func NewConfig(pCfg CommonConfig, parsers []config.Namespace) (*Config, error) {
 
 }
 
+// Empty returns true if no parsers are configured.
+func (c *Config) Empty() bool {
+	return len(c.parsers) == 0
+}
+
 func (c *Config) Create(in reader.Reader) Parser {
 	p := in
 	for _, ns := range c.parsers {
@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"strconv"
 
+	"github.com/elastic/beats/v7/libbeat/beat"
 	"github.com/elastic/beats/v7/libbeat/common/file"
 	"github.com/elastic/elastic-agent-libs/mapstr"
 )
@@ -45,3 +46,9 @@ func setFileSystemMetadata(fi file.ExtendedFileInfo, fields mapstr.M) error {
 
 	return nil
 }
+
+func setFlatFileSystemMetadata(fi file.ExtendedFileInfo, fields *beat.FlatFields) {
+	osstate := fi.GetOSState()
+	fields.Put(deviceIDKey, strconv.FormatUint(osstate.Device, 10))
+	fields.Put(inodeKey, osstate.InodeString())
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"strconv"
 
+	"github.com/elastic/beats/v7/libbeat/beat"
 	"github.com/elastic/beats/v7/libbeat/common/file"
 	"github.com/elastic/elastic-agent-libs/mapstr"
 )
@@ -48,3 +49,10 @@ func setFileSystemMetadata(fi file.ExtendedFileInfo, fields mapstr.M) error {
 
 	return nil
 }
+
+func setFlatFileSystemMetadata(fi file.ExtendedFileInfo, fields *beat.FlatFields) {
+	osstate := fi.GetOSState()
+	fields.Put(idxhiKey, strconv.FormatUint(osstate.IdxHi, 10))
+	fields.Put(idxloKey, strconv.FormatUint(osstate.IdxLo, 10))
+	fields.Put(volKey, strconv.FormatUint(osstate.Vol, 10))
+}
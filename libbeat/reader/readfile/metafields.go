@@ -20,6 +20,7 @@ package readfile
 import (
 	"fmt"
 
+	"github.com/elastic/beats/v7/libbeat/beat"
 	"github.com/elastic/beats/v7/libbeat/common/file"
 	"github.com/elastic/beats/v7/libbeat/reader"
 	"github.com/elastic/elastic-agent-libs/mapstr"
@@ -33,12 +34,20 @@ type FileMetaReader struct {
 	fi          file.ExtendedFileInfo
 	fingerprint string
 	offset      int64
+	flat        bool
 }
 
 // New creates a new Encode reader from input reader by applying
 // the given codec.
 func NewFilemeta(r reader.Reader, path string, fi file.ExtendedFileInfo, fingerprint string, offset int64) reader.Reader {
-	return &FileMetaReader{r, path, fi, fingerprint, offset}
+	return &FileMetaReader{r, path, fi, fingerprint, offset, false}
+}
+
+// NewFlatFilemeta is like NewFilemeta, but adds the file metadata to the
+// flat fields of the messages. It must only be used when the messages are
+// not processed by parsers, which expect the metadata in Fields.
+func NewFlatFilemeta(r reader.Reader, path string, fi file.ExtendedFileInfo, fingerprint string, offset int64) reader.Reader {
+	return &FileMetaReader{r, path, fi, fingerprint, offset, true}
 }
 
 // Next reads the next line from it's initial io.Reader
@@ -52,6 +61,20 @@ func (r *FileMetaReader) Next() (reader.Message, error) {
 		return message, err
 	}
 
+	if r.flat {
+		if message.Flat == nil {
+			message.Flat = beat.NewFlatFields(6)
+		}
+		message.Flat.Put("log.offset", r.offset)
+		message.Flat.Put("log.file.path", r.path)
+		setFlatFileSystemMetadata(r.fi, message.Flat)
+		if r.fingerprint != "" {
+			message.Flat.Put("log.file.fingerprint", r.fingerprint)
+		}
+		r.offset += int64(message.Bytes)
+		return message, err
+	}
+
 	message.Fields.DeepUpdate(mapstr.M{
 		"log": mapstr.M{
 			"offset": r.offset,
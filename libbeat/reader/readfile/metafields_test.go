@@ -52,7 +52,7 @@ func TestMetaFields(t *testing.T) {
 	path := "test/path"
 	offset := int64(0)
 
-	in := &FileMetaReader{msgReader(messages), path, createTestFileInfo(), "hash", offset}
+	in := &FileMetaReader{msgReader(messages), path, createTestFileInfo(), "hash", offset, false}
 	for {
 		msg, err := in.Next()
 		if errors.Is(err, io.EOF) {
@@ -80,6 +80,41 @@ func TestMetaFields(t *testing.T) {
 	}
 }
 
+func TestFlatMetaFields(t *testing.T) {
+	messages := []reader.Message{
+		{
+			Content: []byte("my line"),
+			Bytes:   7,
+		},
+		{
+			Content: []byte(""),
+			Bytes:   10,
+		},
+	}
+
+	path := "test/path"
+	in := NewFlatFilemeta(msgReader(messages), path, createTestFileInfo(), "hash", 0)
+
+	msg, err := in.Next()
+	require.NoError(t, err)
+	require.Empty(t, msg.Fields)
+	expectedFields := mapstr.M{
+		"log": mapstr.M{
+			"file": mapstr.M{
+				"path":        path,
+				"fingerprint": "hash",
+			},
+			"offset": int64(0),
+		},
+	}
+	checkFields(t, expectedFields, msg.Flat.MapStr())
+
+	msg, err = in.Next()
+	require.NoError(t, err)
+	require.Nil(t, msg.Flat, "empty messages are not enriched")
+	require.Equal(t, int64(17), in.(*FileMetaReader).offset)
+}
+
 func msgReader(m []reader.Message) reader.Reader {
 	return &messageReader{
 		messages: m,
@@ -227,6 +227,19 @@ func (s *diskstore) Close() error {
 	return nil
 }
 
+// Sync syncs the update log file to disk. Checkpoints are always synced when
+// written, such that Sync does nothing if no operation was logged since the
+// last checkpoint.
+func (s *diskstore) Sync() error {
+	if s.logFile == nil {
+		return nil
+	}
+	if err := s.logBuf.Flush(); err != nil {
+		return err
+	}
+	return syncFile(s.logFile)
+}
+
 // log operation adds another entry to the update log file.
 // The log file is marked as invalid if the write fails. This will trigger a
 // checkpoint operation in the future.
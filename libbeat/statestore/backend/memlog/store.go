@@ -216,6 +216,15 @@ func (s *store) Checkpoint() error {
 	return s.disk.WriteCheckpoint(s.mem.table)
 }
 
+// Sync ensures that all operations logged so far have been written to
+// stable storage.
+func (s *store) Sync() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.disk.Sync()
+}
+
 // lopOperation ensures that the diskstore reflects the recent changes to the
 // in memory store by either triggering a checkpoint operations or adding the
 // operation type to the update log file.
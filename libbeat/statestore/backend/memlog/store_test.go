@@ -53,3 +53,23 @@ func TestRecoverFromCorruption(t *testing.T) {
 	require.NoError(t, err, "Stat on the log file must succeed")
 	require.Equal(t, int64(0), file.Size(), "expecting the log file to be truncated")
 }
+
+func TestStoreSync(t *testing.T) {
+	registry, err := New(logp.NewLogger("test"), Settings{Root: t.TempDir()})
+	require.NoError(t, err, "New must succeed")
+	defer registry.Close()
+
+	backend, err := registry.Access("test")
+	require.NoError(t, err, "Access must succeed")
+	defer backend.Close()
+	store := backend.(*store)
+
+	require.NoError(t, store.Sync(), "Sync without logged operations must succeed")
+
+	require.NoError(t, store.Set("key", mapstr.M{"field": 42}))
+	require.NoError(t, store.Sync(), "Sync must succeed")
+
+	file, err := os.Stat(filepath.Join(registry.settings.Root, "test", "log.json"))
+	require.NoError(t, err, "Stat on the log file must succeed")
+	require.NotZero(t, file.Size(), "expecting the operation to be written to the log file")
+}
@@ -0,0 +1,231 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package otelstorage implements a statestore backend persisting the stores
+// through a client of an OpenTelemetry collector storage extension, like the
+// file_storage extension, when a Beat runs as a collector receiver.
+//
+// The storage clients are key-value stores without iteration, so every store
+// keeps its key-value pairs in memory and writes each pair, with its key, as
+// a JSON document in a numbered slot under the "<store>/slot/<n>" key. The
+// number of slots is written under the "<store>/slots" key when it grows, and
+// the slots of removed pairs are reused, so an update only writes the slot of
+// the pair.
+package otelstorage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"go.opentelemetry.io/collector/extension/experimental/storage"
+
+	"github.com/elastic/beats/v7/libbeat/common/transform/typeconv"
+	"github.com/elastic/beats/v7/libbeat/statestore/backend"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// Registry provides access to the stores persisted by a storage client.
+type Registry struct {
+	log    *logp.Logger
+	client storage.Client
+}
+
+type store struct {
+	client storage.Client
+	name   string
+
+	mu      sync.RWMutex
+	entries map[string]entry
+	slots   int   // Number of slots, written under the slots key.
+	free    []int // Slots without pair, reused by new pairs.
+}
+
+type entry struct {
+	slot  int
+	value mapstr.M
+}
+
+// slotDoc is the document written in a slot.
+type slotDoc struct {
+	Key   string   `json:"key"`
+	Value mapstr.M `json:"value"`
+}
+
+var errKeyUnknown = errors.New("key unknown")
+
+// New creates a registry persisting its stores through client. The client is
+// closed when the registry is closed.
+func New(log *logp.Logger, client storage.Client) *Registry {
+	return &Registry{log: log, client: client}
+}
+
+// Access loads the key-value pairs of a store.
+func (r *Registry) Access(name string) (backend.Store, error) {
+	ctx := context.Background()
+	s := &store{
+		client:  r.client,
+		name:    name,
+		entries: map[string]entry{},
+	}
+
+	data, err := r.client.Get(ctx, s.slotsKey())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the slots of store %s: %w", name, err)
+	}
+	if data == nil {
+		return s, nil
+	}
+	s.slots, err = strconv.Atoi(string(data))
+	if err != nil || s.slots < 0 {
+		return nil, fmt.Errorf("invalid number of slots of store %s: %q", name, data)
+	}
+
+	ops := make([]storage.Operation, s.slots)
+	for i := range ops {
+		ops[i] = storage.GetOperation(s.slotKey(i))
+	}
+	if err := r.client.Batch(ctx, ops...); err != nil {
+		return nil, fmt.Errorf("failed to read the slots of store %s: %w", name, err)
+	}
+	for i, op := range ops {
+		if op.Value == nil {
+			s.free = append(s.free, i)
+			continue
+		}
+		var doc slotDoc
+		if err := json.Unmarshal(op.Value, &doc); err != nil {
+			return nil, fmt.Errorf("invalid slot %d of store %s: %w", i, name, err)
+		}
+		s.entries[doc.Key] = entry{slot: i, value: doc.Value}
+	}
+	return s, nil
+}
+
+// Close closes the storage client.
+func (r *Registry) Close() error {
+	return r.client.Close(context.Background())
+}
+
+func (s *store) slotsKey() string {
+	return s.name + "/slots"
+}
+
+func (s *store) slotKey(slot int) string {
+	return s.name + "/slot/" + strconv.Itoa(slot)
+}
+
+// Close releases the key-value pairs held in memory, the storage client is
+// closed by the registry.
+func (s *store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = nil
+	return nil
+}
+
+// Has checks if the key is known.
+func (s *store) Has(key string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.entries[key]
+	return ok, nil
+}
+
+// Get retrieves and decodes the key-value pair into to.
+func (s *store) Get(key string, to interface{}) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	e, ok := s.entries[key]
+	if !ok {
+		return errKeyUnknown
+	}
+	return e.Decode(to)
+}
+
+// Set inserts or overwrites a key-value pair. The pair is only updated in
+// memory once it is written.
+func (s *store) Set(key string, value interface{}) error {
+	var tmp mapstr.M
+	if err := typeconv.Convert(&tmp, value); err != nil {
+		return err
+	}
+	data, err := json.Marshal(slotDoc{Key: key, Value: tmp})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, exists := s.entries[key]
+	slots, free := s.slots, s.free
+	if !exists {
+		if n := len(free); n > 0 {
+			e.slot, free = free[n-1], free[:n-1]
+		} else {
+			e.slot = slots
+			slots++
+		}
+	}
+	ops := []storage.Operation{storage.SetOperation(s.slotKey(e.slot), data)}
+	if slots != s.slots {
+		ops = append(ops, storage.SetOperation(s.slotsKey(), []byte(strconv.Itoa(slots))))
+	}
+	if err := s.client.Batch(context.Background(), ops...); err != nil {
+		return err
+	}
+	s.slots, s.free = slots, free
+	s.entries[key] = entry{slot: e.slot, value: tmp}
+	return nil
+}
+
+// Remove removes a key-value pair. Unknown keys are ignored.
+func (s *store) Remove(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, exists := s.entries[key]
+	if !exists {
+		return nil
+	}
+	if err := s.client.Delete(context.Background(), s.slotKey(e.slot)); err != nil {
+		return err
+	}
+	delete(s.entries, key)
+	s.free = append(s.free, e.slot)
+	return nil
+}
+
+// Each iterates over all key-value pairs in the store.
+func (s *store) Each(fn func(string, backend.ValueDecoder) (bool, error)) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for key, e := range s.entries {
+		cont, err := fn(key, e)
+		if !cont || err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e entry) Decode(to interface{}) error {
+	return typeconv.Convert(to, e.value)
+}
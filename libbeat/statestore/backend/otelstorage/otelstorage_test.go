@@ -0,0 +1,233 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package otelstorage
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/extension/experimental/storage"
+
+	"github.com/elastic/beats/v7/libbeat/statestore/backend"
+	"github.com/elastic/beats/v7/libbeat/statestore/internal/storecompliance"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func init() {
+	logp.DevelopmentSetup()
+}
+
+func TestCompliance(t *testing.T) {
+	storecompliance.TestBackendCompliance(t, func(testPath string) (backend.Registry, error) {
+		return New(logp.NewLogger("test"), newMemClient(map[string][]byte{})), nil
+	})
+}
+
+func TestPersistence(t *testing.T) {
+	data := map[string][]byte{}
+
+	reg := New(logp.NewLogger("test"), newMemClient(data))
+	store, err := reg.Access("test")
+	require.NoError(t, err)
+	require.NoError(t, store.Set("a", mapstr.M{"offset": 1}))
+	require.NoError(t, store.Set("b", mapstr.M{"offset": 2}))
+	require.NoError(t, store.Set("a", mapstr.M{"offset": 3}))
+	require.NoError(t, store.Remove("b"))
+	require.NoError(t, store.Set("c", mapstr.M{"offset": 4}))
+	require.NoError(t, store.Close())
+	require.NoError(t, reg.Close())
+
+	// The slot of the removed pair is reused.
+	assert.Equal(t, "2", string(data["test/slots"]))
+	assert.JSONEq(t, `{"key":"a","value":{"offset":3}}`, string(data["test/slot/0"]))
+	assert.JSONEq(t, `{"key":"c","value":{"offset":4}}`, string(data["test/slot/1"]))
+
+	// A registry using a new client of the same storage, like after a
+	// restart of the collector, loads the entries.
+	reg = New(logp.NewLogger("test"), newMemClient(data))
+	defer reg.Close()
+	store, err = reg.Access("test")
+	require.NoError(t, err)
+	defer store.Close()
+
+	type cursor struct {
+		Offset int64 `struct:"offset"`
+	}
+	got := map[string]cursor{}
+	err = store.Each(func(key string, dec backend.ValueDecoder) (bool, error) {
+		var v cursor
+		err := dec.Decode(&v)
+		got[key] = v
+		return true, err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]cursor{"a": {Offset: 3}, "c": {Offset: 4}}, got)
+
+	// Stores are independent.
+	other, err := reg.Access("other")
+	require.NoError(t, err)
+	defer other.Close()
+	has, err := other.Has("a")
+	require.NoError(t, err)
+	assert.False(t, has)
+}
+
+func TestFreeSlots(t *testing.T) {
+	data := map[string][]byte{
+		"test/slots":  []byte("3"),
+		"test/slot/1": []byte(`{"key":"b","value":{"offset":1}}`),
+	}
+	reg := New(logp.NewLogger("test"), newMemClient(data))
+	defer reg.Close()
+	store, err := reg.Access("test")
+	require.NoError(t, err)
+	defer store.Close()
+
+	has, err := store.Has("b")
+	require.NoError(t, err)
+	assert.True(t, has)
+
+	// New pairs use the empty slots before growing the store.
+	require.NoError(t, store.Set("c", mapstr.M{"offset": 2}))
+	require.NoError(t, store.Set("d", mapstr.M{"offset": 3}))
+	require.NoError(t, store.Set("e", mapstr.M{"offset": 4}))
+	assert.Equal(t, "4", string(data["test/slots"]))
+	assert.Contains(t, string(data["test/slot/0"]), `"key":"d"`)
+	assert.Contains(t, string(data["test/slot/2"]), `"key":"c"`)
+	assert.Contains(t, string(data["test/slot/3"]), `"key":"e"`)
+}
+
+func TestSetAfterFailedBatch(t *testing.T) {
+	data := map[string][]byte{}
+	client := newMemClient(data)
+	reg := New(logp.NewLogger("test"), client)
+	store, err := reg.Access("test")
+	require.NoError(t, err)
+	require.NoError(t, store.Set("a", mapstr.M{"offset": 1}))
+
+	client.failNext = errors.New("disk full")
+	assert.ErrorContains(t, store.Set("b", mapstr.M{"offset": 2}), "disk full")
+	has, err := store.Has("b")
+	require.NoError(t, err)
+	assert.False(t, has, "a pair that failed to be written must not be kept")
+
+	client.failNext = errors.New("disk full")
+	assert.ErrorContains(t, store.Set("a", mapstr.M{"offset": 3}), "disk full")
+	var v mapstr.M
+	require.NoError(t, store.Get("a", &v))
+	assert.EqualValues(t, 1, v["offset"], "a pair that failed to be written must keep its value")
+
+	client.failNext = errors.New("disk full")
+	assert.ErrorContains(t, store.Remove("a"), "disk full")
+	has, err = store.Has("a")
+	require.NoError(t, err)
+	assert.True(t, has, "a pair that failed to be removed must be kept")
+
+	require.NoError(t, store.Set("b", mapstr.M{"offset": 2}))
+	require.NoError(t, store.Close())
+	require.NoError(t, reg.Close())
+
+	reg = New(logp.NewLogger("test"), newMemClient(data))
+	defer reg.Close()
+	store, err = reg.Access("test")
+	require.NoError(t, err)
+	defer store.Close()
+	for key, offset := range map[string]float64{"a": 1, "b": 2} {
+		var v mapstr.M
+		require.NoError(t, store.Get(key, &v), "key %s must survive a restart", key)
+		assert.Equal(t, offset, v["offset"])
+	}
+}
+
+func TestInvalidSlots(t *testing.T) {
+	data := map[string][]byte{"test/slots": []byte("x")}
+	reg := New(logp.NewLogger("test"), newMemClient(data))
+	defer reg.Close()
+	_, err := reg.Access("test")
+	assert.ErrorContains(t, err, "invalid number of slots of store test")
+
+	data = map[string][]byte{"test/slots": []byte("1"), "test/slot/0": []byte("{")}
+	reg = New(logp.NewLogger("test"), newMemClient(data))
+	defer reg.Close()
+	_, err = reg.Access("test")
+	assert.ErrorContains(t, err, "invalid slot 0 of store test")
+}
+
+// memClient is a storage client keeping the values in a map, like the clients
+// of the collector storage extensions it returns nil for missing keys.
+type memClient struct {
+	mu       sync.Mutex
+	data     map[string][]byte
+	closed   bool
+	failNext error // Returned by the next operation, without applying it.
+}
+
+var _ storage.Client = (*memClient)(nil)
+
+func newMemClient(data map[string][]byte) *memClient {
+	return &memClient{data: data}
+}
+
+func (c *memClient) Get(ctx context.Context, key string) ([]byte, error) {
+	op := storage.GetOperation(key)
+	err := c.Batch(ctx, op)
+	return op.Value, err
+}
+
+func (c *memClient) Set(ctx context.Context, key string, value []byte) error {
+	return c.Batch(ctx, storage.SetOperation(key, value))
+}
+
+func (c *memClient) Delete(ctx context.Context, key string) error {
+	return c.Batch(ctx, storage.DeleteOperation(key))
+}
+
+func (c *memClient) Batch(_ context.Context, ops ...storage.Operation) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return errors.New("client closed")
+	}
+	if err := c.failNext; err != nil {
+		c.failNext = nil
+		return err
+	}
+	for _, op := range ops {
+		switch op.Type {
+		case storage.Get:
+			op.Value = c.data[op.Key]
+		case storage.Set:
+			c.data[op.Key] = append([]byte(nil), op.Value...)
+		case storage.Delete:
+			delete(c.data, op.Key)
+		}
+	}
+	return nil
+}
+
+func (c *memClient) Close(context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return nil
+}
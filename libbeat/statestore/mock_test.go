@@ -87,3 +87,14 @@ func (m *mockStore) Each(fn func(string, backend.ValueDecoder) (bool, error)) er
 	args := m.Called(fn)
 	return args.Error(0)
 }
+
+// mockSyncStore is a mockStore supporting Sync.
+type mockSyncStore struct {
+	*mockStore
+}
+
+func (m mockSyncStore) OnSync() *mock.Call { return m.On("Sync") }
+func (m mockSyncStore) Sync() error {
+	args := m.Called()
+	return args.Error(0)
+}
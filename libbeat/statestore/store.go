@@ -20,7 +20,9 @@ package statestore
 import (
 	"sync/atomic"
 
+	"github.com/elastic/beats/v7/libbeat/audit"
 	"github.com/elastic/beats/v7/libbeat/statestore/backend"
+	"github.com/elastic/elastic-agent-libs/mapstr"
 	"github.com/elastic/go-concert/unison"
 )
 
@@ -121,6 +123,9 @@ func (s *Store) Set(key string, from interface{}) error {
 	if err := s.shared.backend.Set((key), from); err != nil {
 		return &ErrorOperation{name: s.shared.name, operation: operation, cause: err}
 	}
+	if audit.Enabled(audit.CategoryStateStore) {
+		audit.Record(audit.CategoryStateStore, "set", mapstr.M{"store": s.shared.name, "key": key})
+	}
 	return nil
 }
 
@@ -138,6 +143,30 @@ func (s *Store) Remove(key string) error {
 	if err := s.shared.backend.Remove((key)); err != nil {
 		return &ErrorOperation{name: s.shared.name, operation: operation, cause: err}
 	}
+	if audit.Enabled(audit.CategoryStateStore) {
+		audit.Record(audit.CategoryStateStore, "removed", mapstr.M{"store": s.shared.name, "key": key})
+	}
+	return nil
+}
+
+// Sync ensures that all updates to the store have been written to stable
+// storage. Sync does nothing if the storage backend does not support syncing.
+// Sync returns an error if the store has been closed or the storage backend
+// failed.
+func (s *Store) Sync() error {
+	const operation = "store/sync"
+	if err := s.active.Add(1); err != nil {
+		return &ErrorClosed{operation: operation, name: s.shared.name}
+	}
+	defer s.active.Done()
+
+	syncer, ok := s.shared.backend.(interface{ Sync() error })
+	if !ok {
+		return nil
+	}
+	if err := syncer.Sync(); err != nil {
+		return &ErrorOperation{name: s.shared.name, operation: operation, cause: err}
+	}
 	return nil
 }
 
@@ -181,6 +181,32 @@ func TestStore_Each(t *testing.T) {
 	})
 }
 
+func TestStore_Sync(t *testing.T) {
+	t.Run("fails if store has been closed", func(t *testing.T) {
+		assertClosed(t, makeClosedTestStore(t).Sync())
+	})
+	t.Run("ignored if backend does not support sync", func(t *testing.T) {
+		store := makeTestStore(t, nil)
+		defer store.Close()
+
+		assert.NoError(t, store.Sync())
+	})
+	t.Run("error is passed through", func(t *testing.T) {
+		ms := mockSyncStore{newMockStore()}
+		ms.OnSync().Return(errors.New("oops"))
+		defer ms.AssertExpectations(t)
+
+		mr := newMockRegistry()
+		mr.OnAccess("test").Once().Return(ms, nil)
+		ms.OnClose().Return(nil)
+		store, err := NewRegistry(mr).Get("test")
+		require.NoError(t, err)
+		defer store.Close()
+
+		assert.Error(t, store.Sync())
+	})
+}
+
 func makeTestStore(t *testing.T, data map[string]interface{}) *Store {
 	memstore := &storetest.MapStore{Table: data}
 	reg := NewRegistry(&storetest.MemoryStore{
@@ -151,7 +151,9 @@ func (l *ESLoader) Load(config TemplateConfig, info beat.Info, fields []byte, mi
 		// for serverless, we can update the lifecycle policy safely
 		// Note that updating the lifecycle will delete older documents
 		// if the policy requires it; i.e, changing the data_retention from 10d to 7d
-		// will delete the documents older than 7 days.
+		// will delete the documents older than 7 days. On stateful clusters the
+		// lifecycle of an existing data stream is never overwritten, as it may
+		// have been set by the user or by a previous ILM setup.
 		if l.client.IsServerless() {
 			l.log.Infof("overwriting lifecycle policy")
 			err = l.lifecycleClient.CreatePolicyFromConfig()
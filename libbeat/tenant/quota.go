@@ -0,0 +1,96 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tenant
+
+import (
+	"context"
+	"io"
+	"math"
+
+	"golang.org/x/time/rate"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+)
+
+// quotaPipeline limits the rate at which the clients of a tenant publish
+// events. Publishing blocks while the tenant is above its quota, as if its
+// queue was full.
+type quotaPipeline struct {
+	parent  beat.Pipeline
+	limiter *rate.Limiter
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+type quotaClient struct {
+	beat.Client
+	pipeline *quotaPipeline
+}
+
+// WithQuota applies the quota to the pipeline of a tenant. Closing the
+// returned pipeline unblocks the clients waiting for the quota and closes
+// the pipeline of the tenant.
+func WithQuota(pipeline beat.Pipeline, quota QuotaConfig) beat.Pipeline {
+	if quota.EventsPerSecond == 0 {
+		return pipeline
+	}
+	burst := quota.Burst
+	if burst == 0 {
+		burst = int(math.Max(1, math.Ceil(quota.EventsPerSecond)))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &quotaPipeline{
+		parent:  pipeline,
+		limiter: rate.NewLimiter(rate.Limit(quota.EventsPerSecond), burst),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+func (p *quotaPipeline) Connect() (beat.Client, error) {
+	return p.ConnectWith(beat.ClientConfig{})
+}
+
+func (p *quotaPipeline) ConnectWith(cfg beat.ClientConfig) (beat.Client, error) {
+	client, err := p.parent.ConnectWith(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &quotaClient{Client: client, pipeline: p}, nil
+}
+
+func (p *quotaPipeline) Close() error {
+	p.cancel()
+	if c, ok := p.parent.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func (c *quotaClient) Publish(event beat.Event) {
+	// Wait only fails once the pipeline is closed, the event is then
+	// handed to the closed client that drops it.
+	_ = c.pipeline.limiter.Wait(c.pipeline.ctx)
+	c.Client.Publish(event)
+}
+
+func (c *quotaClient) PublishAll(events []beat.Event) {
+	for _, event := range events {
+		c.Publish(event)
+	}
+}
@@ -0,0 +1,132 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package tenant configures the logical tenants a beat runs next to its
+// main configuration, in the same process. Each tenant has its own beat
+// settings, data directory, output and queue, and quotas limiting its share
+// of the process.
+package tenant
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/paths"
+)
+
+// Config is the configuration of a tenant. The beat settings of the tenant,
+// like its inputs, are set next to these options or in the file given by
+// path.
+type Config struct {
+	ID     string           `config:"id" validate:"required"`
+	Path   string           `config:"path"`
+	Output config.Namespace `config:"output"`
+	Queue  config.Namespace `config:"queue"`
+	Quota  QuotaConfig      `config:"quota"`
+}
+
+// QuotaConfig limits the resources used by a tenant. The memory used by a
+// tenant is limited by the size of its queue.
+type QuotaConfig struct {
+	// EventsPerSecond is the maximum rate at which the inputs of the tenant
+	// publish events. It is not limited if 0.
+	EventsPerSecond float64 `config:"events_per_second" validate:"min=0"`
+
+	// Burst is the number of events that can be published at once above
+	// the rate. It defaults to the events per second.
+	Burst int `config:"burst" validate:"min=0"`
+}
+
+// The ID is used as directory name.
+var validID = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+func (c *Config) Validate() error {
+	if !validID.MatchString(c.ID) {
+		return fmt.Errorf("invalid tenant ID %q, only letters, digits, '-' and '_' are allowed", c.ID)
+	}
+	if !c.Output.IsSet() {
+		return fmt.Errorf("tenant %s has no output", c.ID)
+	}
+	return nil
+}
+
+// Tenant is the loaded configuration of a tenant.
+type Tenant struct {
+	Config
+
+	// Settings holds the complete configuration of the tenant, including
+	// the beat settings read from Path.
+	Settings *config.C
+}
+
+// DataPath returns the directory the tenant keeps its state in.
+func (t Tenant) DataPath() string {
+	return paths.Resolve(paths.Data, filepath.Join("tenants", t.ID))
+}
+
+// Load reads the configurations of the tenants. The IDs of the tenants must
+// be unique.
+func Load(raw []*config.C) ([]Tenant, error) {
+	tenants := make([]Tenant, 0, len(raw))
+	seen := map[string]bool{}
+	for _, cfg := range raw {
+		settings, err := loadSettings(cfg)
+		if err != nil {
+			return nil, err
+		}
+		t := Tenant{Settings: settings}
+		if err := settings.Unpack(&t.Config); err != nil {
+			return nil, err
+		}
+		if seen[t.ID] {
+			return nil, fmt.Errorf("tenant ID %s is used more than once", t.ID)
+		}
+		seen[t.ID] = true
+		tenants = append(tenants, t)
+	}
+	return tenants, nil
+}
+
+// loadSettings merges the settings from the file given by path into the
+// tenant configuration.
+func loadSettings(cfg *config.C) (*config.C, error) {
+	var tmp struct {
+		Path string `config:"path"`
+	}
+	if err := cfg.Unpack(&tmp); err != nil {
+		return nil, err
+	}
+
+	settings := config.NewConfig()
+	if err := settings.Merge(cfg); err != nil {
+		return nil, err
+	}
+	if tmp.Path == "" {
+		return settings, nil
+	}
+	fragment, err := common.LoadFile(paths.Resolve(paths.Config, tmp.Path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tenant settings: %w", err)
+	}
+	if err := settings.Merge(fragment); err != nil {
+		return nil, fmt.Errorf("failed to load tenant settings from %s: %w", tmp.Path, err)
+	}
+	return settings, nil
+}
@@ -0,0 +1,159 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tenant
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	pubtest "github.com/elastic/beats/v7/libbeat/publisher/testing"
+	"github.com/elastic/elastic-agent-libs/config"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "acme.yml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+filebeat.inputs:
+  - type: filestream
+    id: acme-logs
+output.console.enabled: true
+`), 0o600))
+
+	tenants, err := Load([]*config.C{
+		config.MustNewConfigFrom(map[string]interface{}{
+			"id":                      "acme",
+			"path":                    path,
+			"quota.events_per_second": 100,
+			"queue.mem.events":        512,
+			"filebeat.registry.flush": "1s",
+		}),
+		config.MustNewConfigFrom(map[string]interface{}{
+			"id":               "globex",
+			"output.file.path": "/tmp",
+			"filebeat.inputs":  []interface{}{map[string]interface{}{"type": "filestream", "id": "globex-logs"}},
+		}),
+	})
+	require.NoError(t, err)
+	require.Len(t, tenants, 2)
+
+	acme := tenants[0]
+	assert.Equal(t, "acme", acme.ID)
+	assert.Equal(t, "console", acme.Output.Name())
+	assert.Equal(t, "mem", acme.Queue.Name())
+	assert.Equal(t, 100.0, acme.Quota.EventsPerSecond)
+	filebeat, err := acme.Settings.Child("filebeat", -1)
+	require.NoError(t, err)
+	inputs, err := filebeat.CountField("inputs")
+	require.NoError(t, err)
+	assert.Equal(t, 1, inputs)
+	flush, err := filebeat.String("registry.flush", -1)
+	require.NoError(t, err)
+	assert.Equal(t, "1s", flush)
+
+	assert.Equal(t, "globex", tenants[1].ID)
+	assert.Equal(t, "file", tenants[1].Output.Name())
+	assert.False(t, tenants[1].Queue.IsSet())
+	assert.Contains(t, tenants[1].DataPath(), filepath.Join("tenants", "globex"))
+}
+
+func TestLoadErrors(t *testing.T) {
+	cases := map[string]struct {
+		configs []map[string]interface{}
+		err     string
+	}{
+		"missing ID": {
+			configs: []map[string]interface{}{{"output.console.enabled": true}},
+			err:     "string value is not set accessing 'id'",
+		},
+		"invalid ID": {
+			configs: []map[string]interface{}{{"id": "../acme", "output.console.enabled": true}},
+			err:     `invalid tenant ID "../acme"`,
+		},
+		"missing output": {
+			configs: []map[string]interface{}{{"id": "acme"}},
+			err:     "tenant acme has no output",
+		},
+		"duplicated ID": {
+			configs: []map[string]interface{}{
+				{"id": "acme", "output.console.enabled": true},
+				{"id": "acme", "output.console.enabled": true},
+			},
+			err: "tenant ID acme is used more than once",
+		},
+		"missing settings file": {
+			configs: []map[string]interface{}{{"id": "acme", "path": "/does/not/exist.yml"}},
+			err:     "failed to load tenant settings",
+		},
+		"negative quota": {
+			configs: []map[string]interface{}{{"id": "acme", "output.console.enabled": true, "quota.events_per_second": -1}},
+			err:     "requires value >= 0",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var raw []*config.C
+			for _, c := range tc.configs {
+				raw = append(raw, config.MustNewConfigFrom(c))
+			}
+			_, err := Load(raw)
+			assert.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestWithQuota(t *testing.T) {
+	t.Run("no quota", func(t *testing.T) {
+		pipeline := pubtest.ConstClient(pubtest.ChClient(make(chan beat.Event)))
+		assert.Equal(t, pipeline, WithQuota(pipeline, QuotaConfig{}))
+	})
+
+	t.Run("blocks above the quota until closed", func(t *testing.T) {
+		ch := make(chan beat.Event, 10)
+		pipeline := WithQuota(pubtest.ConstClient(pubtest.ChClient(ch)), QuotaConfig{EventsPerSecond: 0.001, Burst: 2})
+		client, err := pipeline.Connect()
+		require.NoError(t, err)
+
+		client.PublishAll([]beat.Event{{}, {}})
+		assert.Len(t, ch, 2)
+
+		published := make(chan struct{})
+		go func() {
+			client.Publish(beat.Event{})
+			close(published)
+		}()
+		select {
+		case <-published:
+			t.Fatal("event published above the quota")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		require.NoError(t, pipeline.(interface{ Close() error }).Close())
+		select {
+		case <-published:
+		case <-time.After(5 * time.Second):
+			t.Fatal("publish still blocked after close")
+		}
+	})
+}
@@ -0,0 +1,123 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build linux
+
+package systemd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/coreos/go-systemd/v22/dbus"
+)
+
+// Conn is a connection to systemd over dbus. Units are listed with the best
+// method supported by systemd, and the connection is reopened on the next
+// call after it is lost, like when dbus or systemd restart.
+type Conn struct {
+	listUnits UnitFetcher
+
+	mu   sync.Mutex
+	conn *dbus.Conn
+}
+
+// NewConn connects to systemd and finds the method to list units.
+func NewConn(ctx context.Context) (*Conn, error) {
+	c := &Conn{}
+	if _, err := c.get(ctx); err != nil {
+		return nil, err
+	}
+
+	listUnits, err := IntrospectForUnitMethods()
+	if err != nil {
+		c.Close()
+		return nil, fmt.Errorf("error finding ListUnits Method: %w", err)
+	}
+	c.listUnits = listUnits
+	return c, nil
+}
+
+// get returns the connection, reconnecting if it was lost.
+func (c *Conn) get(ctx context.Context) (*dbus.Conn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn != nil {
+		if c.conn.Connected() {
+			return c.conn, nil
+		}
+		c.conn.Close()
+		c.conn = nil
+	}
+
+	conn, err := dbus.NewWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to dbus: %w", err)
+	}
+	c.conn = conn
+	return conn, nil
+}
+
+// ListUnits returns the units matching any of the states and patterns. All
+// the units are returned if no states or patterns are given.
+func (c *Conn) ListUnits(ctx context.Context, states, patterns []string) ([]dbus.UnitStatus, error) {
+	conn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.listUnits(ctx, conn, states, patterns)
+}
+
+// GetAllProperties returns the properties of the unit and of its type.
+func (c *Conn) GetAllProperties(ctx context.Context, unit string) (map[string]interface{}, error) {
+	conn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return conn.GetAllPropertiesContext(ctx, unit)
+}
+
+// GetUnitProperties returns the properties of the org.freedesktop.systemd1.Unit
+// interface of the unit.
+func (c *Conn) GetUnitProperties(ctx context.Context, unit string) (map[string]interface{}, error) {
+	conn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return conn.GetUnitPropertiesContext(ctx, unit)
+}
+
+// GetUnitTypeProperties returns the properties of the interface of the type
+// of the unit, like Service for org.freedesktop.systemd1.Service.
+func (c *Conn) GetUnitTypeProperties(ctx context.Context, unit, unitType string) (map[string]interface{}, error) {
+	conn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return conn.GetUnitTypePropertiesContext(ctx, unit, unitType)
+}
+
+// Close closes the connection.
+func (c *Conn) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+}
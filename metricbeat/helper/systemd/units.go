@@ -17,7 +17,8 @@
 
 //go:build linux
 
-package service
+// Package systemd contains helpers to query systemd units over dbus.
+package systemd
 
 import (
 	"context"
@@ -32,17 +33,19 @@ import (
 	dbusRaw "github.com/godbus/dbus/v5"
 )
 
-type unitFetcher func(conn *dbus.Conn, states, patterns []string) ([]dbus.UnitStatus, error)
+// UnitFetcher lists the units matching the states and patterns.
+type UnitFetcher func(ctx context.Context, conn *dbus.Conn, states, patterns []string) ([]dbus.UnitStatus, error)
 
-// instrospectForUnitMethods determines what methods are available via dbus for listing systemd units.
+// IntrospectForUnitMethods determines what methods are available via dbus for listing systemd units.
 // We have a number of functions, some better than others, for getting and filtering unit lists.
 // This will attempt to find the most optimal method, and move down to methods that require more work.
-func instrospectForUnitMethods() (unitFetcher, error) {
+func IntrospectForUnitMethods() (UnitFetcher, error) {
 	//setup a dbus connection
 	conn, err := dbusRaw.SystemBusPrivate()
 	if err != nil {
 		return nil, fmt.Errorf("error getting connection to system bus: %w", err)
 	}
+	defer conn.Close()
 
 	auth := dbusRaw.AuthExternal(strconv.Itoa(os.Getuid()))
 	err = conn.Auth([]dbusRaw.Auth{auth})
@@ -119,13 +122,13 @@ func parseXMLAndReturnMethods(str string) (map[string]bool, error) {
 }
 
 // listUnitsByPatternWrapper is a bare wrapper for the unitFetcher type
-func listUnitsByPatternWrapper(conn *dbus.Conn, states, patterns []string) ([]dbus.UnitStatus, error) {
-	return conn.ListUnitsByPatternsContext(context.Background(), states, patterns)
+func listUnitsByPatternWrapper(ctx context.Context, conn *dbus.Conn, states, patterns []string) ([]dbus.UnitStatus, error) {
+	return conn.ListUnitsByPatternsContext(ctx, states, patterns)
 }
 
 // listUnitsFilteredWrapper wraps the dbus ListUnitsFiltered method
-func listUnitsFilteredWrapper(conn *dbus.Conn, states, patterns []string) ([]dbus.UnitStatus, error) {
-	units, err := conn.ListUnitsFilteredContext(context.Background(), states)
+func listUnitsFilteredWrapper(ctx context.Context, conn *dbus.Conn, states, patterns []string) ([]dbus.UnitStatus, error) {
+	units, err := conn.ListUnitsFilteredContext(ctx, states)
 	if err != nil {
 		return nil, fmt.Errorf("ListUnitsFiltered error: %w", err)
 	}
@@ -134,8 +137,8 @@ func listUnitsFilteredWrapper(conn *dbus.Conn, states, patterns []string) ([]dbu
 }
 
 // listUnitsWrapper wraps the dbus ListUnits method
-func listUnitsWrapper(conn *dbus.Conn, states, patterns []string) ([]dbus.UnitStatus, error) {
-	units, err := conn.ListUnitsContext(context.Background())
+func listUnitsWrapper(ctx context.Context, conn *dbus.Conn, states, patterns []string) ([]dbus.UnitStatus, error) {
+	units, err := conn.ListUnitsContext(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("ListUnits error: %w", err)
 	}
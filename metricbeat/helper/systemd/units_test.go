@@ -0,0 +1,81 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build linux
+
+package systemd
+
+import (
+	"testing"
+
+	"github.com/coreos/go-systemd/v22/dbus"
+	"github.com/stretchr/testify/assert"
+)
+
+var exampleUnits = []dbus.UnitStatus{
+	dbus.UnitStatus{
+		Name:      "sshd.service",
+		LoadState: "active",
+	},
+	dbus.UnitStatus{
+		Name:      "metricbeat.service",
+		LoadState: "active",
+	},
+	dbus.UnitStatus{
+		Name: "filebeat.service",
+	},
+}
+
+func TestFilterEmpty(t *testing.T) {
+
+	filtersBad := []string{
+		"asdf",
+	}
+	shouldNotMatch, err := matchUnitPatterns(filtersBad, exampleUnits)
+	assert.NoError(t, err)
+	assert.Empty(t, shouldNotMatch)
+}
+
+func TestFilterMatches(t *testing.T) {
+	filtersMatch := []string{
+		"ssh*",
+	}
+
+	shouldMatch, err := matchUnitPatterns(filtersMatch, exampleUnits)
+	assert.NoError(t, err)
+	assert.Len(t, shouldMatch, 1)
+}
+
+func TestNoFilter(t *testing.T) {
+	shouldReturnResults, err := matchUnitPatterns([]string{}, exampleUnits)
+	assert.NoError(t, err)
+	assert.Len(t, shouldReturnResults, 3)
+}
+
+func TestUnitStateFilter(t *testing.T) {
+	stateFilter := []string{
+		"active",
+	}
+	shouldReturnResults := matchUnitState(stateFilter, exampleUnits)
+	assert.Len(t, shouldReturnResults, 2)
+
+}
+
+func TestUnitStateNoFilter(t *testing.T) {
+	shouldReturnResults := matchUnitState([]string{}, exampleUnits)
+	assert.Len(t, shouldReturnResults, 3)
+}
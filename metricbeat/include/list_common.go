@@ -37,6 +37,7 @@ import (
 	_ "github.com/elastic/beats/v7/metricbeat/module/ceph/mgr_osd_perf"
 	_ "github.com/elastic/beats/v7/metricbeat/module/ceph/mgr_osd_pool_stats"
 	_ "github.com/elastic/beats/v7/metricbeat/module/ceph/mgr_osd_tree"
+	_ "github.com/elastic/beats/v7/metricbeat/module/ceph/mgr_pg_state"
 	_ "github.com/elastic/beats/v7/metricbeat/module/ceph/mgr_pool_disk"
 	_ "github.com/elastic/beats/v7/metricbeat/module/ceph/monitor_health"
 	_ "github.com/elastic/beats/v7/metricbeat/module/ceph/osd_df"
@@ -135,6 +136,8 @@ import (
 	_ "github.com/elastic/beats/v7/metricbeat/module/nginx/stubstatus"
 	_ "github.com/elastic/beats/v7/metricbeat/module/openmetrics"
 	_ "github.com/elastic/beats/v7/metricbeat/module/openmetrics/collector"
+	_ "github.com/elastic/beats/v7/metricbeat/module/otlp"
+	_ "github.com/elastic/beats/v7/metricbeat/module/otlp/metrics"
 	_ "github.com/elastic/beats/v7/metricbeat/module/php_fpm"
 	_ "github.com/elastic/beats/v7/metricbeat/module/php_fpm/pool"
 	_ "github.com/elastic/beats/v7/metricbeat/module/php_fpm/process"
@@ -176,6 +179,8 @@ import (
 	_ "github.com/elastic/beats/v7/metricbeat/module/system/socket_summary"
 	_ "github.com/elastic/beats/v7/metricbeat/module/system/uptime"
 	_ "github.com/elastic/beats/v7/metricbeat/module/system/users"
+	_ "github.com/elastic/beats/v7/metricbeat/module/systemd"
+	_ "github.com/elastic/beats/v7/metricbeat/module/systemd/units"
 	_ "github.com/elastic/beats/v7/metricbeat/module/traefik"
 	_ "github.com/elastic/beats/v7/metricbeat/module/traefik/health"
 	_ "github.com/elastic/beats/v7/metricbeat/module/uwsgi"
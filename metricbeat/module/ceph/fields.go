@@ -32,5 +32,5 @@ func init() {
 // AssetCeph returns asset data.
 // This is the base64 encoded zlib format compressed contents of module/ceph.
 func AssetCeph() string {
-	return "eJzEm89v47oRx+/5KwY5tUDitlcfCuwvYIM2m2Czix6KQkuTI4k1RRIkFT//9w+kZNnWb9u0V6f3bO93PhoOh8Mh8whr3C6Bos7vABx3Apdw/wl1fn8HwNBSw7XjSi7hn3cAAP4rKBQrBd4B2FwZl1AlU54tISXC+k8NCiQWl5AR/xt0jsvMLuG/99aK+we4z53T9/+7A0g5CmaXQfkRJCmwYfGP22qvYlSp6096iPzzy/+jX0CVdIRLCy5HKNAZTv1/EwcbNAiWGqKRQWpUAZ++vH5d1AKHGEcoorQOTcK4XTdf9mGNoPlnQOfYT7unDXMIRN4JF2QlcLHaOrRHv9lxCSWz1hcjaP75sFOFoAoqDQ6sqVs/T5UpiFtCF2AH6ZQjIirgD68YB660yKKy/bTIzkdrR1qORLg8Rqx1lE6PNvWOhgiRWEdc2e+vNW43yrDTXPZS6UKlO+a2JqR4gTRHurYL1IrmkcbumWh4R2O5klNmjSolW7wTUWIk4404BO15ALGH4u1oCI5B2rHZsd0fm/1xNiNiO/pjsdk3bOePRVjVal+MBoQhacrpwiBhyUlZZDgzTcNV/gFvFFxuVJnlunSg0YBFqoZip2bdGO7w5rDB6hm0wbNKJ9oHBNJY43voQq60Pc15V+OpvDQHqOBWC0JxEVbXyBg7cdAZyLJYDWThhkGt/o/UxVpBOxS1/CwUQxxXvSCWEoEsSYUibiC+NRqKsv3tqbhdhB0lw8wQhuwqg7YTnxi0huE6g9ZQzBi0BuX3DVqDOzxoOlsw4sjNM6bOwNsdqV11tggbgJujkeMNwhBciPKbw7n95mAIzBf9N+cqm43BAFYoeDCUdJj4DyNNzdcsVNZ4+LtxBKrKzoQ61/pbrjYWcrWBgsgt6MwCMQhc1lAqnXz1Dl/kYq8eIDtd7ynLFmkp+lP3SimBpE01YfzJelHoiB5alEjMVax64QnTZZEoy2KtEq1g8Mo+Eqa2ezuSUl8bxoemqoCa8Pz5OgrF5e+Aevo2CmWwIFojS3R2a7LvX54/vL5++TzIF3PHHrTaNUZTFGYmidWyszjc/2t2uCt0Y72UPq7LGzxHZMNdnhPYlGV+f5NeQvXy9tlXZmHdlHTffVVptbnuTve5pIe0vN3emBdHHu6pP0B9GNsFVUXBXSKIQ0m3SXHeHPoUVKBW8Ymu6F/7K6NEa7G91OYHLzLbZOs9Zaz3lPPf80ybnfeU3SZqE8tKVU3L6WbVVEQrVbUpYwdyQBys+Wa08l492XDl5OXPnCxBeWC2UMFRuoSrxBDXz17tA0fDJ4jA099e/C6sU173ue2QYd9762nIjL6mf2rXLuFjaNmHhtBA6+XQ6EETLYJVr+ZQzjE83g+bb/i7f1GlMex75WC7qfvOEWz/J3S5xo2357AziBevkj1C56yPYTZFKSj6lE4mUpI7NbuSOLkdf6xfZz9GZqe2/Rml7uSBy08o6+7P7sTi+aW/Lu44By47J/laOWPC6v7V16vob/6vj1Pmq4PXaKarU9dps+FINZrVcJ46bVQQ65JSM+Kwf5lj3QVq6n15gbDJUcKGWOjT3hm/ZOHu2v1GCpx6XeuUwaqmWQiVRT3B/rfK9gfYbYA5Z+qHcAW3NCrdM7c0Hp61Lird29uPeHBXvzhxKeDkpDsj+Ih1nbl20D1I2PS29PQTZ1/IK8vAL8VQWpIhcFn54rgVeIXNaNcHoTWnGLYVr5NuGmuDuweG75xiQgWxEa8aNHa9xgNwITAjIvwfcElFyRByxh7AWgbo6GJkjfPhOtf5F7TymxCpzhjelSgHXNZcK7olVRW4I1RnXheLwbY/Mhrh01kiyyLSLLLtgwcQivq0UnUseWiBDw/eULF6rRPJ1ijWGiTD3ix46XaoyXheKOS6yfx5s9SnDKxKukZ34yTYsjuYDr2Zq1oeSYjKsgfIlXUPYJRyI3lxq/Hcjs9syKGuUM4FM9h/PneWk2rDO2EQ3HvAoiYmzOjVFqgqCtLvC2pKmycb5FneP6f7JvPc1SuIQ4/4fvXUAzvP08chWOwK7kzhH9wO3Og465iQ28qidVyISt2HgVTuL/943KJ9gL8/SvXX/lxueEHMNiFpyiV321iO97uiytu+gjVIGJf1TYlwj7u2O5jcDY4Ewhm3NnNsJD3QkNnYFzX3d2VDSuDOr3elYLBCKLUfJaY2/YfY1ynnvCMqZQjKDZrg6zmFXEpc3rkkfSGPJgZlna/Chrpa9FuprLPARunvjbb2br+wPrFdS8GD3WBFPexiDJqsD4Ru+VcMHZZ5m17PGffG3LdwaB2mSX1Vbp63bvAXCxf46ArNv/XqiOvPAAAA//+0W4ni"
+	return "eJzEm02P47gRhu/9Kwp9SpAeJ7n6EGB2doBtZGe6sb2LHIJAS5NliTFFEiRlx/8+IPVhW6I+bEtewBgMbPf7PiwWqWJJ/gQ7PK6Bos6eABx3Atfw/AV19vwEwNBSw7XjSq7hH08AAP4jyBUrBD4B2EwZl1Altzxdw5YI6981KJBYXENK/HfQOS5Tu4Z/P1srnl/gOXNOP//nCWDLUTC7DsqfQJIcGxb/ljtqr2JUoat3IkT+9bv/o9+BKukIlxZchpCjM5z6/xMHBzQIlhqikcHWqBy+fH3/aVUJnGNcoIjCOjQJ43bXfBjDGkDzrx6dyzgBxGHOgciecEE2Alebo0N78Z2aSyiZtj4YQPOvz7UqBFVQ2xDAirr19a0yOXFr6ALUkE45ImYF/NUrzgNXWGSzsv1mkd2OVmNVf5NkSITLntpYN+RaR+n6bFN7NESIxDriini8dng8KMOuC9lbqQul7lDYahLHc6QZ0p1doVY0i7JcP3ffiIY9GsuVHLM1qpBstSeiwJnMG3EI2tMA5p6Kj4spuASpIeqM6njHczOeZwMcvfpDuRmbttvnIlzVqlgMJoQh2y2nK4OEJVftIv070zhcGR/wpuAyo4o004UDjQYsUiXZIOvBcIcPhw2uN9D6QSZKJxpNYpFOJb4mhFxpe13wFuMJ8pOAcm61IBRX4eo6M0YtDjoFWeQbNMMMavNfpM4uRVHJT0IxxHHVkixBLCUCWbIVirie/NZoKEp3J24XoaZkmBrCkC0yabX4yKQ1DMtMWkMxYdIalD9u0hrc/knT6YoRRx6+Y+oUvG9E4owsHAAejtYcO4bhQpY/HM6dDgd9YL7ofzhX0RwMerBCXY2r8G/iZ3gq3AjAexoqazz/3jACVYV0M7l/ZOpgIVMHyIk8gk4tEIPAZQWltqND7/DNXOxVE2TH6z1l2WpbiPjWvVFKIJHXmb9aUJZBR/TcUSIxi7h64RHrIk+UZXamULeSwSv7TBg77tUkhV4axqemKoGa9PztfRCKyz8C6vX7IJTBnGiNLNHpo8l++frt8/v71x97+eY8sQetdo1Rm+WpSXpabVe3USzierRvt0E31EuJcd3f4Lkg6+/yXMGmLPPnm+09VG8fP/rKLFw3JT11X9W2bBlXwDeQntPyy8PR1DzycK/xBPWr3q6oynPuEkEcSnpM8tvW0JegApWK3+hyO2BKtBbHez0/e5HJlq1xyrnGKYdML8cpZxrnmU4nl5Uqm5b27oxWSpSjmDmRA2JvzTehlffuyforJy9/42IJyj2rhQqO0iVcJYa4OHt5Dhwy+BJE4PWvb+BVziepL2znDKfeW6QhMzhM/6pCu4YfvELZEOppvZybnjXRZnD1ag7lFOPhfth041/8QJXGcO6Vdor1SO9ruve/vNCIeXsNO4N4z+oNV8mI0NTFes5Tn0Pu4Xn33arc530YRNhUcO5dpU15Iq3movXZBW+U8AUE3yEQ6vge/0I7J48LZ3ur9atkfM9ZQcRZXHxNrkeZgEgG/Vy3n2y/hxrTg7QgmhNMN9q16+3tvvJOp+z3rgLTzpfaOaSr3/xnqX9jSlcvICW5U5ML36vvHl3qVxdr30gbYIvfUtfU3TRnQzfUq2Zlnc/f3uLHuE5wrlo+XYCfgt6Y62nou83sI//nD2P2YZXMZ10unXFb35ebzzXc/h83FcS6pNCMOGxPZenMujv32Hh5jnDIUMKBWIhp1+b31Jld3+8kx7HhWqcMhoumXQmVzvrAxc8qPT1v0QYY6tzG4HJu6ax037il8+FZ62al+/j4dT64xZ/zuRdwdNFdT/gzsa6z1mpvX26y7fyXOP9wm++kgb+oQ2FJ6vvpZSwuO9cL9E66MfAkUjFsKy6z3TRuHdnaj+GeU0yoINYu4Os1XoALgSkRYSTAJRUFQ8gYewFrGaCjqyjbaY1MDX5/ik9CDikSXGGvRJHj8FNwj6QqE3eA6lSPxGwWZWush/h0msgin4o1Ym0v2+s6BaGo31bKBjsP94miGKGA6StWl7qB3prFSoOk+NTGm+P03ux4XijsdaP758O2PmVgU9Adugdvgi3f3u3Q2yzqPLAhKsteIFPWvYBRyg3si0eNtzYoJ0P2NTEzLphBGTW/KUiVcS0MgvsIWNTENzj948FAVZ6TeCyoKWyWHJCnWXtRlkyxxTwhIiEOQRwi4rU7Q+2ymeYhOHYFayv8H7fOzndXm9tyCVrHhSjVQRmQyv3p75+OaF/gb5+k+nOURRueE3NM/GN+krvjXIH3h8Ay2r7E9t1bLqsHe8LPDirf3s3d4EAiXD8jHqeW9EB9tp1nb+9cEadHu8OWwJ3/qUghGGwQCu1niamDjKIsU875QJTKEArFBi30N8cLuS1xGZp5eTQxvjcUcjgcqLmNbGVPbZRYV+7qK+xga+/xF9ZXVrcUPFjUa7kuRq9ldf/ytrJ0cqesOe9GWYbq20vOeR/wPPW/6yc7p0XrAT+wuSNGCzT/dpsLrv8PALsLTV0="
 }
@@ -0,0 +1,116 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package mgr
+
+import (
+	"net"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// discoveryPeriod is the period of refresh of the addresses of the mgr
+// daemons.
+const discoveryPeriod = 5 * time.Minute
+
+// discovery keeps the addresses of the mgr daemons of a cluster, only one
+// of them being active and serving the API at a time.
+type discovery struct {
+	mu        sync.Mutex
+	configURI string
+	addrs     []string
+	updated   time.Time
+	now       func() time.Time
+}
+
+type mgrMetadata struct {
+	Name     string `json:"name"`
+	Addr     string `json:"addr"`
+	Hostname string `json:"hostname"`
+}
+
+func newDiscovery(uri string) *discovery {
+	return &discovery{configURI: uri, now: time.Now}
+}
+
+func (d *discovery) due() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.updated.IsZero() || d.now().Sub(d.updated) >= discoveryPeriod
+}
+
+// update sets the addresses of the mgr daemons from the response of the
+// `mgr metadata` command.
+func (d *discovery) update(content []byte) error {
+	var mgrs []mgrMetadata
+	if err := UnmarshalResponse(content, &mgrs); err != nil {
+		return err
+	}
+
+	addrs := make([]string, 0, len(mgrs))
+	for _, mgr := range mgrs {
+		addr := mgr.Addr
+		if addr == "" {
+			addr = mgr.Hostname
+		}
+		if addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+	sort.Strings(addrs)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.addrs = addrs
+	d.updated = d.now()
+	return nil
+}
+
+// candidates returns the URIs of the API on the other mgr daemons, the
+// configured URI being the last resort. They only differ from the current
+// URI by their host.
+func (d *discovery) candidates(current string) []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	u, err := url.Parse(current)
+	if err != nil {
+		return nil
+	}
+
+	var uris []string
+	seen := map[string]bool{current: true}
+	add := func(uri string) {
+		if !seen[uri] {
+			seen[uri] = true
+			uris = append(uris, uri)
+		}
+	}
+	for _, addr := range d.addrs {
+		c := *u
+		if port := u.Port(); port != "" {
+			c.Host = net.JoinHostPort(addr, port)
+		} else {
+			c.Host = addr
+		}
+		add(c.String())
+	}
+	add(d.configURI)
+	return uris
+}
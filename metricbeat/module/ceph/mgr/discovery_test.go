@@ -0,0 +1,59 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package mgr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const mgrMetadataResponse = `{
+    "failed": [],
+    "finished": [
+        {
+            "command": "mgr metadata format=json",
+            "outb": "[{\"name\": \"a\", \"addr\": \"10.0.0.2\", \"hostname\": \"ceph-a\"}, {\"name\": \"b\", \"hostname\": \"ceph-b\"}]",
+            "outs": ""
+        }
+    ],
+    "has_failed": false
+}`
+
+func TestDiscovery(t *testing.T) {
+	now := time.Now()
+	d := newDiscovery("https://ceph-a:8003/request?wait=1")
+	d.now = func() time.Time { return now }
+
+	assert.True(t, d.due(), "the mgr daemons must be discovered on first use")
+	assert.Equal(t, []string{"https://ceph-a:8003/request?wait=1"}, d.candidates("https://10.0.0.9:8003/request?wait=1"),
+		"the configured URI must be a candidate before the discovery")
+
+	require.NoError(t, d.update([]byte(mgrMetadataResponse)))
+	assert.False(t, d.due())
+
+	assert.Equal(t, []string{
+		"https://ceph-b:8003/request?wait=1",
+		"https://ceph-a:8003/request?wait=1",
+	}, d.candidates("https://10.0.0.2:8003/request?wait=1"))
+
+	now = now.Add(discoveryPeriod)
+	assert.True(t, d.due(), "the mgr daemons must be discovered again after the discovery period")
+}
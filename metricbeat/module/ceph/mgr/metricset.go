@@ -24,15 +24,29 @@ import (
 	"github.com/elastic/beats/v7/metricbeat/mb"
 )
 
+type config struct {
+	// DiscoverMgr enables the failover to the other mgr daemons of the
+	// cluster when the configured one is not the active one anymore.
+	DiscoverMgr bool `config:"discover_mgr"`
+}
+
 type MetricSet struct {
 	mb.BaseMetricSet
 	*helper.HTTP
+
+	body      []byte
+	discovery *discovery
 }
 
 var _ mb.MetricSet = new(MetricSet)
 
 // NewMetricSet creates an metric set that can be used to build other metricsets that query Ceph mgr API.
 func NewMetricSet(base mb.BaseMetricSet) (*MetricSet, error) {
+	var cfg config
+	if err := base.Module().UnpackConfig(&cfg); err != nil {
+		return nil, err
+	}
+
 	http, err := helper.NewHTTP(base)
 	if err != nil {
 		return nil, err
@@ -40,13 +54,71 @@ func NewMetricSet(base mb.BaseMetricSet) (*MetricSet, error) {
 	http.SetMethod("POST")
 	http.SetHeader("Content-Type", "application/json")
 	http.SetHeader("Accept", "application/json")
-	return &MetricSet{
-		base,
-		http,
-	}, nil
+
+	m := &MetricSet{
+		BaseMetricSet: base,
+		HTTP:          http,
+	}
+	if cfg.DiscoverMgr {
+		m.discovery = newDiscovery(http.GetURI())
+	}
+	return m, nil
 }
 
 func (m *MetricSet) WithPrefix(prefix string) *MetricSet {
-	m.HTTP.SetBody([]byte(fmt.Sprintf(`{"prefix": "%s", "format": "json"}`, prefix)))
+	m.body = commandBody(prefix)
+	m.HTTP.SetBody(m.body)
 	return m
 }
+
+// FetchContent runs the command of the metricset on the mgr API. With
+// discover_mgr enabled, the command is run on the other mgr daemons of the
+// cluster when it fails on the current one, and the first one succeeding
+// becomes the current one.
+func (m *MetricSet) FetchContent() ([]byte, error) {
+	content, err := m.HTTP.FetchContent()
+	if m.discovery == nil {
+		return content, err
+	}
+	if err == nil {
+		m.refreshMgrs()
+		return content, nil
+	}
+
+	current := m.HTTP.GetURI()
+	for _, uri := range m.discovery.candidates(current) {
+		m.HTTP.SetURI(uri)
+		content, candidateErr := m.HTTP.FetchContent()
+		if candidateErr == nil {
+			m.Logger().Infof("Ceph mgr at %s failed (%v), switched to the mgr at %s", current, err, uri)
+			m.refreshMgrs()
+			return content, nil
+		}
+	}
+	m.HTTP.SetURI(current)
+	return nil, err
+}
+
+// refreshMgrs updates the addresses of the mgr daemons of the cluster from
+// the current one, when they are due for a refresh.
+func (m *MetricSet) refreshMgrs() {
+	if !m.discovery.due() {
+		return
+	}
+
+	m.HTTP.SetBody(commandBody("mgr metadata"))
+	defer m.HTTP.SetBody(m.body)
+
+	content, err := m.HTTP.FetchContent()
+	if err != nil {
+		m.Logger().Debugf("failed to discover the Ceph mgr daemons: %v", err)
+		return
+	}
+	if err := m.discovery.update(content); err != nil {
+		m.Logger().Debugf("failed to discover the Ceph mgr daemons: %v", err)
+	}
+}
+
+func commandBody(prefix string) []byte {
+	return []byte(fmt.Sprintf(`{"prefix": "%s", "format": "json"}`, prefix))
+}
@@ -18,8 +18,6 @@
 package mgr_cluster_health
 
 import (
-	"fmt"
-
 	"github.com/elastic/beats/v7/metricbeat/mb"
 	"github.com/elastic/beats/v7/metricbeat/mb/parse"
 	"github.com/elastic/beats/v7/metricbeat/module/ceph/mgr"
@@ -65,14 +63,12 @@ func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
 // format. It publishes the event which is then forwarded to the output. In case
 // of an error set the Error field of mb.Event or simply call report.Error().
 func (m *MetricSet) Fetch(reporter mb.ReporterV2) error {
-	m.HTTP.SetBody([]byte(fmt.Sprintf(`{"prefix": "%s", "format": "json"}`, cephStatusPrefix)))
-	statusContent, err := m.HTTP.FetchContent()
+	statusContent, err := m.WithPrefix(cephStatusPrefix).FetchContent()
 	if err != nil {
 		return err
 	}
 
-	m.HTTP.SetBody([]byte(fmt.Sprintf(`{"prefix": "%s", "format": "json"}`, cephTimeSyncStatusPrefix)))
-	timeStatusContent, err := m.HTTP.FetchContent()
+	timeStatusContent, err := m.WithPrefix(cephTimeSyncStatusPrefix).FetchContent()
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,70 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package mgr_pg_state
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/elastic/beats/v7/metricbeat/module/ceph/mgr"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+type pgSummary struct {
+	NumPgByState []struct {
+		Name string `json:"name"`
+		Num  int64  `json:"num"`
+	} `json:"num_pg_by_state"`
+	NumPgs int64 `json:"num_pgs"`
+}
+
+// PgStatResponse is the response of the `pg stat` command. Ceph Octopus and
+// later nest the summary under pg_summary.
+type PgStatResponse struct {
+	pgSummary
+	PgSummary *pgSummary `json:"pg_summary"`
+}
+
+func eventsMapping(content []byte) ([]mapstr.M, error) {
+	var response PgStatResponse
+	err := mgr.UnmarshalResponse(content, &response)
+	if err != nil {
+		return nil, fmt.Errorf("could not get response data: %w", err)
+	}
+
+	summary := response.pgSummary
+	if response.PgSummary != nil {
+		summary = *response.PgSummary
+	}
+
+	var events []mapstr.M
+	for _, pgState := range summary.NumPgByState {
+		states := strings.Split(pgState.Name, "+")
+		sort.Strings(states)
+
+		event := mapstr.M{
+			"state":  pgState.Name,
+			"states": states,
+			"count":  pgState.Num,
+			"total":  summary.NumPgs,
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
@@ -0,0 +1,96 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package mgr_pg_state
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mbtest "github.com/elastic/beats/v7/metricbeat/mb/testing"
+	_ "github.com/elastic/beats/v7/metricbeat/module/ceph"
+)
+
+func TestDataFiles(t *testing.T) {
+	mbtest.TestDataFiles(t, "ceph", "mgr_pg_state")
+}
+
+func TestFetchFailsOverToActiveMgr(t *testing.T) {
+	active := newMgrServer(t, "127.0.0.1", 0, 10)
+	port := active.Listener.Addr().(*net.TCPAddr).Port
+	standby := newMgrServer(t, "127.0.0.2", port, 20)
+	defer standby.Close()
+
+	f := mbtest.NewReportingMetricSetV2Error(t, map[string]interface{}{
+		"module":       "ceph",
+		"metricsets":   []string{"mgr_pg_state"},
+		"hosts":        []string{active.URL},
+		"discover_mgr": true,
+	})
+
+	events, errs := mbtest.ReportingFetchV2Error(f)
+	require.Empty(t, errs)
+	require.Len(t, events, 1)
+	assert.EqualValues(t, 10, events[0].MetricSetFields["count"])
+
+	// the standby becomes the active mgr
+	active.Close()
+
+	events, errs = mbtest.ReportingFetchV2Error(f)
+	require.Empty(t, errs)
+	require.Len(t, events, 1)
+	assert.EqualValues(t, 20, events[0].MetricSetFields["count"])
+}
+
+// newMgrServer starts a server answering the `pg stat` command with count
+// active+clean placement groups, and the `mgr metadata` command with the
+// addresses of two mgr daemons.
+func newMgrServer(t *testing.T, ip string, port int, count int) *httptest.Server {
+	l, err := net.Listen("tcp", net.JoinHostPort(ip, strconv.Itoa(port)))
+	if err != nil {
+		t.Skipf("cannot listen on %s: %v", ip, err)
+	}
+
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var command struct {
+			Prefix string `json:"prefix"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&command)
+
+		outb := fmt.Sprintf(`{"pg_summary": {"num_pg_by_state": [{"name": "active+clean", "num": %d}], "num_pgs": %d}}`, count, count)
+		if command.Prefix == "mgr metadata" {
+			outb = `[{"name": "a", "addr": "127.0.0.1"}, {"name": "b", "addr": "127.0.0.2"}]`
+		}
+		response, _ := json.Marshal(map[string]interface{}{
+			"has_failed": false,
+			"finished":   []map[string]string{{"command": command.Prefix, "outb": outb}},
+		})
+		_, _ = w.Write(response)
+	}))
+	s.Listener.Close()
+	s.Listener = l
+	s.Start()
+	return s
+}
@@ -18,6 +18,7 @@
 package monitor_health
 
 import (
+	"github.com/elastic/beats/v7/libbeat/common/cfgwarn"
 	"github.com/elastic/beats/v7/metricbeat/helper"
 	"github.com/elastic/beats/v7/metricbeat/mb"
 	"github.com/elastic/beats/v7/metricbeat/mb/parse"
@@ -48,6 +49,8 @@ type MetricSet struct {
 }
 
 func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
+	cfgwarn.Deprecate("", "The ceph %s metricset uses the ceph-rest-api, which was removed in Ceph Nautilus. Use the mgr_ metricsets instead.", base.Name())
+
 	http, err := helper.NewHTTP(base)
 	if err != nil {
 		return nil, err
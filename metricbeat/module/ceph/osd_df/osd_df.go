@@ -20,6 +20,7 @@ package osd_df
 import (
 	"fmt"
 
+	"github.com/elastic/beats/v7/libbeat/common/cfgwarn"
 	"github.com/elastic/beats/v7/metricbeat/helper"
 	"github.com/elastic/beats/v7/metricbeat/mb"
 	"github.com/elastic/beats/v7/metricbeat/mb/parse"
@@ -52,6 +53,8 @@ type MetricSet struct {
 
 // New creates a new instance of the osd_df MetricSet.
 func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
+	cfgwarn.Deprecate("", "The ceph %s metricset uses the ceph-rest-api, which was removed in Ceph Nautilus. Use the mgr_ metricsets instead.", base.Name())
+
 	http, err := helper.NewHTTP(base)
 	if err != nil {
 		return nil, err
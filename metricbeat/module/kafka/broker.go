@@ -74,6 +74,10 @@ type MemberDescription struct {
 
 const noID = -1
 
+// fetchTimestampMaxBytes limits the size of the responses fetching the
+// timestamp of a message.
+const fetchTimestampMaxBytes = 64 * 1024
+
 // NewBroker creates a new unconnected kafka Broker connection instance.
 func NewBroker(host string, settings BrokerSettings) *Broker {
 	cfg := sarama.NewConfig()
@@ -290,6 +294,70 @@ func (b *Broker) FetchPartitionOffsetFromTheLeader(topic string, partitionID int
 	return offset, nil
 }
 
+// FetchOffsetTimestamp fetches the timestamp of the message at offset in a
+// partition from its leader. It is the log append time of the message if the
+// topic uses log append times, and its create time otherwise.
+func (b *Broker) FetchOffsetTimestamp(topic string, partitionID int32, offset int64) (time.Time, error) {
+	leader, err := b.client.Leader(topic, partitionID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// Fetch requests v4 return record batches with their timestamps. The
+	// batch holding offset is returned even if it is larger than MaxBytes.
+	req := &sarama.FetchRequest{
+		Version:   4,
+		MinBytes:  1,
+		MaxBytes:  fetchTimestampMaxBytes,
+		Isolation: sarama.ReadUncommitted,
+	}
+	req.AddBlock(topic, partitionID, offset, fetchTimestampMaxBytes)
+	resp, err := leader.Fetch(req)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	block := resp.GetBlock(topic, partitionID)
+	if block == nil {
+		return time.Time{}, fmt.Errorf("no fetch response for topic %s partition %d", topic, partitionID)
+	}
+	if block.Err != sarama.ErrNoError {
+		return time.Time{}, block.Err
+	}
+	for _, records := range block.RecordsSet {
+		if ts, ok := recordsTimestamp(records, offset); ok {
+			return ts, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("no message found at offset %d of topic %s partition %d", offset, topic, partitionID)
+}
+
+// recordsTimestamp returns the timestamp of the first message of records
+// at or after offset.
+func recordsTimestamp(records *sarama.Records, offset int64) (time.Time, bool) {
+	if batch := records.RecordBatch; batch != nil {
+		for _, r := range batch.Records {
+			if batch.FirstOffset+r.OffsetDelta < offset {
+				continue
+			}
+			if batch.LogAppendTime {
+				return batch.MaxTimestamp, true
+			}
+			return batch.FirstTimestamp.Add(r.TimestampDelta), true
+		}
+	}
+	if set := records.MsgSet; set != nil {
+		for _, block := range set.Messages {
+			for _, msg := range block.Messages() {
+				if msg.Offset >= offset {
+					return msg.Msg.Timestamp, true
+				}
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
 // ID returns the broker ID or -1 if the broker id is unknown.
 func (b *Broker) ID() int32 {
 	if b.id == noID {
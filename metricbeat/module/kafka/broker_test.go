@@ -20,9 +20,11 @@ package kafka
 import (
 	"net"
 	"testing"
+	"time"
 
 	"errors"
 
+	"github.com/Shopify/sarama"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -158,3 +160,35 @@ func TestFindMatchingAddress(t *testing.T) {
 		})
 	}
 }
+
+func TestRecordsTimestamp(t *testing.T) {
+	first := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	batch := &sarama.RecordBatch{
+		FirstOffset:    100,
+		FirstTimestamp: first,
+		MaxTimestamp:   first.Add(time.Minute),
+		Records: []*sarama.Record{
+			{OffsetDelta: 0, TimestampDelta: 0},
+			{OffsetDelta: 1, TimestampDelta: 10 * time.Second},
+			{OffsetDelta: 3, TimestampDelta: time.Minute},
+		},
+	}
+	records := &sarama.Records{RecordBatch: batch}
+
+	ts, ok := recordsTimestamp(records, 101)
+	assert.True(t, ok)
+	assert.Equal(t, first.Add(10*time.Second), ts)
+
+	// Offset 102 was compacted, the next message is returned.
+	ts, ok = recordsTimestamp(records, 102)
+	assert.True(t, ok)
+	assert.Equal(t, first.Add(time.Minute), ts)
+
+	_, ok = recordsTimestamp(records, 104)
+	assert.False(t, ok)
+
+	batch.LogAppendTime = true
+	ts, ok = recordsTimestamp(records, 100)
+	assert.True(t, ok)
+	assert.Equal(t, first.Add(time.Minute), ts)
+}
@@ -37,8 +37,9 @@ func init() {
 type MetricSet struct {
 	*kafka.MetricSet
 
-	topics nameSet
-	groups nameSet
+	topics  nameSet
+	groups  nameSet
+	lagTime bool
 }
 
 type groupAssignment struct {
@@ -61,8 +62,9 @@ func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
 	}
 
 	config := struct {
-		Groups []string `config:"groups"`
-		Topics []string `config:"topics"`
+		Groups          []string `config:"groups"`
+		Topics          []string `config:"topics"`
+		EstimateLagTime bool     `config:"estimate_lag_time"`
 	}{}
 	if err := base.Module().UnpackConfig(&config); err != nil {
 		return nil, err
@@ -72,6 +74,7 @@ func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
 		MetricSet: ms,
 		groups:    makeNameSet(config.Groups...),
 		topics:    makeNameSet(config.Topics...),
+		lagTime:   config.EstimateLagTime,
 	}, nil
 }
 
@@ -110,7 +113,7 @@ func (m *MetricSet) Fetch(r mb.ReporterV2) error {
 			MetricSetFields: event,
 		})
 	}
-	err = fetchGroupInfo(emitEvent, broker, m.groups.pred(), m.topics.pred())
+	err = fetchGroupInfo(emitEvent, broker, m.groups.pred(), m.topics.pred(), m.lagTime)
 	if err != nil {
 		return fmt.Errorf("error in fetch: %w", err)
 	}
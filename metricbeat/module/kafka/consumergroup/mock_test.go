@@ -20,6 +20,7 @@ package consumergroup
 import (
 	"fmt"
 	"math/rand"
+	"time"
 
 	"github.com/Shopify/sarama"
 
@@ -31,6 +32,7 @@ type mockClient struct {
 	describeGroups                  func(group []string) (map[string]kafka.GroupDescription, error)
 	fetchGroupOffsets               func(group string) (*sarama.OffsetFetchResponse, error)
 	getPartitionOffsetFromTheLeader func(topic string, partitionID int32) (int64, error)
+	fetchOffsetTimestamp            func(topic string, partitionID int32, offset int64) (time.Time, error)
 }
 
 type mockState struct {
@@ -152,3 +154,6 @@ func (c *mockClient) FetchGroupOffsets(group string, partitions map[string][]int
 func (c *mockClient) FetchPartitionOffsetFromTheLeader(topic string, partitionID int32) (int64, error) {
 	return c.getPartitionOffsetFromTheLeader(topic, partitionID)
 }
+func (c *mockClient) FetchOffsetTimestamp(topic string, partitionID int32, offset int64) (time.Time, error) {
+	return c.fetchOffsetTimestamp(topic, partitionID, offset)
+}
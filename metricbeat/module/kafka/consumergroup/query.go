@@ -18,6 +18,8 @@
 package consumergroup
 
 import (
+	"time"
+
 	"github.com/Shopify/sarama"
 
 	"github.com/elastic/beats/v7/metricbeat/module/kafka"
@@ -30,12 +32,14 @@ type client interface {
 	DescribeGroups(group []string) (map[string]kafka.GroupDescription, error)
 	FetchGroupOffsets(group string, partitions map[string][]int32) (*sarama.OffsetFetchResponse, error)
 	FetchPartitionOffsetFromTheLeader(topic string, partitionID int32) (int64, error)
+	FetchOffsetTimestamp(topic string, partitionID int32, offset int64) (time.Time, error)
 }
 
 func fetchGroupInfo(
 	emit func(mapstr.M),
 	b client,
 	groupsFilter, topicsFilter func(string) bool,
+	lagTime bool,
 ) error {
 	type result struct {
 		err    error
@@ -132,6 +136,12 @@ func fetchGroupInfo(
 					},
 				}
 
+				if lagTime {
+					if ms, ok := consumerLagTime(b, topic, partition, info.Offset, consumerLag); ok {
+						event["consumer_lag_time"] = mapstr.M{"ms": ms}
+					}
+				}
+
 				if asgnTopic, ok := ret.assign[topic]; ok {
 					if assignment, found := asgnTopic[partition]; found {
 						event["client"] = mapstr.M{
@@ -152,6 +162,33 @@ func fetchGroupInfo(
 	return err
 }
 
+// consumerLagTime estimates how far behind the consumer of a partition is in
+// time, in milliseconds, as the age of the next message to consume.
+func consumerLagTime(b client, topic string, partition int32, offset, lag int64) (int64, bool) {
+	if offset < 0 {
+		// No committed offset.
+		return 0, false
+	}
+	if lag <= 0 {
+		return 0, true
+	}
+	ts, err := b.FetchOffsetTimestamp(topic, partition, offset)
+	if err != nil {
+		logp.Err("failed to fetch timestamp of offset %v for (topic, partition): ('%v', %v): %v", offset, topic, partition, err)
+		return 0, false
+	}
+	if ts.IsZero() {
+		// Messages without timestamp, produced with Kafka < 0.10.
+		return 0, false
+	}
+	age := time.Since(ts)
+	if age < 0 {
+		// Clock skew between the broker or producer and this host.
+		age = 0
+	}
+	return age.Milliseconds(), true
+}
+
 func getPartitionOffsetFromTheLeader(b client, topic string, partitionID int32) (int64, error) {
 	offset, err := b.FetchPartitionOffsetFromTheLeader(topic, partitionID)
 	if err != nil {
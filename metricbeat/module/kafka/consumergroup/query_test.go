@@ -22,8 +22,10 @@ import (
 	"io"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/elastic/elastic-agent-libs/mapstr"
 )
@@ -219,7 +221,7 @@ func TestFetchGroupInfo(t *testing.T) {
 
 		groups := makeNameSet(test.groups...).pred()
 		topics := makeNameSet(test.topics...).pred()
-		err := fetchGroupInfo(collectEvents, test.client, groups, topics)
+		err := fetchGroupInfo(collectEvents, test.client, groups, topics, false)
 		if err != nil {
 			switch {
 			case test.err == nil:
@@ -291,3 +293,36 @@ func clientMeta(id int) mapstr.M {
 		"id": fmt.Sprintf("consumer-%v", id),
 	}
 }
+
+func TestFetchGroupInfoLagTime(t *testing.T) {
+	client := defaultMockClient(mockState{
+		partitions: map[string]map[string][]int64{
+			"group1": {"topic1": {10, 42}},
+		},
+		groups: map[string][]map[string][]int32{
+			"group1": {{"topic1": {0, 1}}},
+		},
+	}).with(func(c *mockClient) {
+		c.fetchOffsetTimestamp = func(topic string, partitionID int32, offset int64) (time.Time, error) {
+			assert.Equal(t, int64(10), offset, "timestamp fetched for partition without lag")
+			return time.Now().Add(-time.Minute), nil
+		}
+	})
+
+	var events []mapstr.M
+	err := fetchGroupInfo(func(event mapstr.M) { events = append(events, event) }, client, nil, nil, true)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	for _, event := range events {
+		lagTime, err := event.GetValue("consumer_lag_time.ms")
+		require.NoError(t, err)
+		switch event["partition"] {
+		case int32(0):
+			assert.GreaterOrEqual(t, lagTime, int64(time.Minute/time.Millisecond))
+			assert.Less(t, lagTime, int64(2*time.Minute/time.Millisecond))
+		case int32(1):
+			assert.Equal(t, int64(0), lagTime)
+		}
+	}
+}
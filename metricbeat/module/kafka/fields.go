@@ -32,5 +32,5 @@ func init() {
 // AssetKafka returns asset data.
 // This is the base64 encoded zlib format compressed contents of module/kafka.
 func AssetKafka() string {
-	return "eJzUWk1z2zYTvvtX7OTkHMLcfXhn3iadjpumyaTpTKcXDgQsRdQgwACgbPnXdwCQFL9EEqLcNjrZFHafB/uFxVJv4AGPd/BAsgdyA2C5FXgHrz64/1/dADA0VPPSciXv4H83AAD+OygUqwTeAJhcaZtSJTO+v4OMCOOeahRIDN7B3qnNOApm7rz4G5CkwBOk+9hj6ZZqVZX1kwncvpquqp1WD6jbx1P6zuoMnx+8BninpKkK1PCTE4V7mSldECcAOTkg7BAlaCQMMq0KuK3FciKZ4HLfU2lzBNro81ReJ50Fw71098NZ73GzH6EGELNb6myLs5tJHMKYRmMmwR7w+Kj0kMg6PMIOqC03yFqIkc+sKjlN3N8jv42hZ2C/Oj1e5zkM1FrphCo2RhpYdBHGqwKnKhmjlURb7mSTnv9ikT43aoCzWRS/u3QCa95+PbDfJf9WIXAGKvMRW57QpX8QbLjMI+TgP0MHiGT+vwCaXKUg1LFboNWcmpDgodTV3/z88Y+ObFvgdmjJyrwudkjkXEZ9dAvA5sSCzbkBPKC0wI1DIxYZWLU6WRtQjd8qNDahOZESRfKtwgoTw59xjsnXHMGtaRxRawEvva46DQmUWrGKYpIRLpClJerUIFVytsY4HppYzyMIQq2n0WugRA2TmgKxTChiZ5llaGl+OS8quHOT19IaymmrNF6BXd9uS6RkVexQz5jrCjZaz2HWNNFMSsGpP40TgYShTlEgdf8PVY0YhfXQrPeu2wBfSSqQyDSWRi13DToGjXFUnpV6QCxRJ4wbqqREapdo/KnUBy8DVCh3StfKNgTrmA4+lVwv1pgTlbD+Zbi4lk1JcVzPppF4ETrmKGmMj3wO1b7dxkWofZKJyuTpRMiNs0btwa++JEDrBg9twmWyO1o0TWldguWSqoLLPTipUGXdhr3Ci0moysaxUJXdq2uz0PgXUossjkojdTUqBRpD9mhSPtuO9JxRy2yDv044XAB6BfdfgHotd0dCb3XvCrgGqrnhxvXa7T17ottuv/tO+23f66wqrwWXvKiKkFHEwmPOad6fGxiUzPTbJwNWARlfcdYERgjDWvtiG0cOqF1InNo5L9+wY5ApDQRMiZRnnNZ3sw39LlWabaFXazgRPHGZ5BpJMLZwNfeDxmo+z9w9VvWcHJvc5CkVZHYQ5IOLPPngakNpLLOE1DYsKVVFwUdXh7MbVllm0HUsXsrtt+1mIin4IeF2+A+dWeNqO68vou1F8FTWQjEND/zKC2rqsIRGTzXPKeoPZ5vPBRPJFUPC4Uj1fbt4Eij4bt2AYYDUWr/2P5dWdQZIO3Tp5/r6eQZF/3yJ2iytjFVFNw4sAUYsAWN1d0A8idyITaR3pAUE2fuC1+7+bajNlAhahZONGF+EGM8y1CgpuoP1EVEO5m61MYlkQwPPb2Zy6rp+K+PZsi8FLYe3J4bdoey8ff1FapLOMENW8Pm/MXwvkTX3MxdZLsJ8D1F3NC3JgfRUqsFcusFCFI74vguk7t/DbTCcQWsdvcA24ez1tLG6NHJlhua6lEhP1VnAAt1Rnm7fPpcWtSRi8AamBuhWodE8e0vBnVISX2xnauAlcXogXJCdwFqvaUa6e35A2ZnjR8aoxEecCY+JBF9B1n1+9YqbwjN8ETCk2TGbYC9D6JNXvExo0pnnSsDF/jy9H3IHS6zXwuTxBYz0Sxhpcga34Wry+ryn6lHqC7D4EjRP0zhfbqU5Spou0dopJcYXypXM7iXjlLiLAM8aA7ibJpdUVAxZ84KLyzeOTDtuRle54fb+ty+rdmLSBQ+/yCZsO2Jfpni2MYAr+P/HthcIB7C/ObtjbyJd2wwNL0giRxifa6mpEUb73Xc6wiDNkZHuKtcepv4Gu3QLssoSAaRQlfRVMsi6I1fp4eR9cVBBLM1Tw58xJYfFu+65QYU51+6tAi7I09pL9mrgmZekVGmWGpRs1ezo/PDDYW8dwqQarT5eTMRqjqxWVY+wthLyVeM/RCgMgeqR3L/srNg0aeww/glADGBEeiwBLv10YJXfT8ZtCvpVfiFgSiUNXs4gyG+gwFX6SPhijLWQ928/gRMAy4tY/0a/JemPOMMLE1VZP3ywHVaRPOr52Sqrl6eDeNUbjL8DAAD//1EPWgk="
+	return "eJzUms2O2zgSx+9+ikJOHWCj3PuwwG4SLHqz2QSZDDDIRaHJksRpinRIqrudpx+QImXJpqwPu4MJ3BfLrPr/WCSLZKlfwT3ub+GeFPdkA2C5FXgLL9677y82AAwN1XxnuZK38M8NAID/DWrFGoEbAFMpbXOqZMHLWyiIMO6pRoHE4C2Uzm3BUTBz681fgSQ1HiTdx+53rqlWzS48SegO3fRdbbW6R909Tvkb9dn+/dt7gDdKmqZGDf9xKHAnC6Vr4joPFXlA2CJK0EgYFFrVcBPMKiKZ4LIcuLQVAo3+PMrLrNfguC/9/nA2eBz7I9SRxNku9brF2SapQxjTaMyRWSt2j/tHpdkqPcIeUFtukHUSm2Ntq3acZq6/m2npM7JfnB/vc0wDtVY6o4rhZiKikzLeFThX2anajmjL3VzJOLtA6VN0A5ydVfG9yzlbGL/eY4DfJf/eIHAGqvAztnMPXPoHXmUGR7sGfw4OEMn8t1Y0O4FbkxDC3K3Rak5Nu8DbVBd++e+HP3q2XYLboiUz13W9RSIHvxwxfHANwFbEgq24AXxAaYEb0CiIRQZWHZmPhfggqvF7g8ZmtCJSosi+N9hgZvgPPEfypUJwbeJABC/grY8MkzP8FGCnFWsoZgXhAlm+Q50bpEqyKQ5NrOdoDSH4iX4N7FBD0lMLVghF7FmyAi2t1nNRwd0weS/RJzhvjcYr0A3jNgUlm3qL+ky4VlL0YzSf4WxoFpPsBKd+N84EEoY6R4HUfTdTRG17iO390F0g30gqkMh8KUawuwaOQWNcJH4odY+4Q50xbqiSEqmdwviq1HtvA1Qot0sHZxdM1lMcfNpxjfNR2vbPw+KObEqK/XyaaPEsOGYv6XyUsIbC2F7GIlSZFaIxVZ6YcicMQpXgW6+ZoOGAhzbjMtvuLZqYWqdkuaSq5rIEZ+WlfYe9w9UQqrHLKFRjS3VtCo1/IrXIlqFEq6uh1GgMKdHkXM4ejGBzmfx1psMK0SsM/wrVaw33QulLh3eGXJSKN9xlZ+3unp04bXe//aLnbX9QmpVeay553dR+cgGx8FhxWg3rBgYlM8PjkwGrgJxeccZGqs/m5rLJg3c2xUceUJOyf5zz9pGOQaE0EDA7pLzgNNzNVu9NGqnS7BK84OEAeGBJsi4EXJq44v0gRs0nMXePVYNBXkhRk6dckHJKvCZPfnJFFTi1mVLqDiw5VXXNrZnSjB1WRWHQQrBy/e1OMwsRfJHwcvn3vVrjXOkFSTQKd7GOybR94FvOUI/K0c1xCp2RWIelpDFHXS4t52ZSzpL8qTw4lupDSfVt1zgp1I5dUuykwHCkFHsbx59Lq3oFpC265efO9Z2TJEE93F8WdZY2xqremnO+gBFLwFjdLxAnlaNZYnkvjIAgpU94Xe9f+3wHlAjatDsbMT4JMV4UqFFSV9y2j66+Pay7hWC6ilvn/miQJjuTW15jVptVnRpOafd5ZyyvfSec3+GGyQ1sseKSpfr/j0QASNnVuCQ+2e4cZFV02q/at5+vqBXwYqhcEQNSuTSbwcf25mhdo28YcLtIfHMnDJRkK5AdnCejmKxdXxI7n1A76NeHce6XtmPjJFJ7HU3iHOeZGTz/MoaXElm85br16dapr3yGc2EHeWSdSlhnk9bUWj7hfdNC3b2FmzZwBq11eC1txtnLzsUoRqWMvRLIwNWoYI319rgUv0qVS4taEnGY5H6Eg0A/l0fp1FAt3rZSTpZvWWd2kjXz9IFw4VZs8Gti0ij5A8pDv7OFc1TiI56ZHokFPgPW/f3fOw60EXYUsxc2wZ4H6KNgs4A2Kaqu3SYFtWI8D2/Z3Pa8dNTa+u0zBOl/3rF7H3fTXvBeZqMQoSD9DBSfW89pjFEeLl1dM5/C2iolTq/lM8nuJHOvANC4nTUoud2USyoahiy+JuTylYPpivbod+Obu98+z+qJCZX9n9sJ272o6MxGEUcPBtcY/3fdWaDdgH39wW17ieUagcJrJr2ZWpwD/U/BKlUI6n77RQtBJG4Z+bZxh+zc1wHOUbhLrFWWCCC1aqRLltDaui1X6f2MS2SfYEtcKcrwH5iTh3JKeazcY8aOe7OEa/I0JRxLFbOFT2Z21G0LQLmrms2qwI2XkJz2+tcsgUOj1fvVIFZzZMFVKAReCuSzxt8IqK3Gh8LmGqQrDtbSZRLjcPqPFEsEFyyPKcEzq8LHd9a4H4IbE/qh7nxBhM1OSYPrCVr7CxC4yh8Jt1PineTd64/gDHyJYaHW4ndNsX7ojaB97aQa60sYtke1kCOUMmZFvet4rH8kjPp6fw0AIDe8LA=="
 }
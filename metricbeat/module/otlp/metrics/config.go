@@ -0,0 +1,36 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metrics
+
+import "github.com/elastic/elastic-agent-libs/transport/tlscommon"
+
+type config struct {
+	Host string                  `config:"host"`
+	Port int                     `config:"port"`
+	TLS  *tlscommon.ServerConfig `config:"ssl"`
+	// MaxMessageSize is the maximum size in bytes of a received message.
+	MaxMessageSize int `config:"max_message_size" validate:"min=0"`
+}
+
+func defaultConfig() config {
+	return config{
+		Host:           "localhost",
+		Port:           4317,
+		MaxMessageSize: 4 * 1024 * 1024,
+	}
+}
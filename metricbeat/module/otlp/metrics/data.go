@@ -0,0 +1,226 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metrics
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+
+	"github.com/elastic/beats/v7/metricbeat/mb"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// resourceToRoot maps resource attributes defined by the OpenTelemetry
+// semantic conventions to their ECS fields.
+var resourceToRoot = map[string]string{
+	"service.name":        "service.name",
+	"service.version":     "service.version",
+	"service.instance.id": "service.id",
+}
+
+// eventsFromMetrics converts OTLP metrics to events. Data points of the same
+// resource and scope that have the same attributes and timestamp are grouped
+// in a single event, under otlp.metrics.<metric name>.
+func eventsFromMetrics(md pmetric.Metrics) []mb.Event {
+	var events []mb.Event
+	rms := md.ResourceMetrics()
+	for i := 0; i < rms.Len(); i++ {
+		rm := rms.At(i)
+		resource := labels(rm.Resource().Attributes())
+		root := mapstr.M{}
+		rm.Resource().Attributes().Range(func(k string, v pcommon.Value) bool {
+			if field, ok := resourceToRoot[k]; ok {
+				_, _ = root.Put(field, v.AsString())
+			}
+			return true
+		})
+
+		sms := rm.ScopeMetrics()
+		for j := 0; j < sms.Len(); j++ {
+			sm := sms.At(j)
+			g := newGrouper(root, resource, sm.Scope())
+			ms := sm.Metrics()
+			for k := 0; k < ms.Len(); k++ {
+				g.addMetric(ms.At(k))
+			}
+			events = append(events, g.events...)
+		}
+	}
+	return events
+}
+
+// grouper groups the data points of a scope by attributes and timestamp.
+type grouper struct {
+	root     mapstr.M
+	resource mapstr.M
+	scope    mapstr.M
+	index    map[string]int
+	events   []mb.Event
+}
+
+func newGrouper(root, resource mapstr.M, scope pcommon.InstrumentationScope) *grouper {
+	g := &grouper{
+		root:     root,
+		resource: resource,
+		index:    map[string]int{},
+	}
+	if scope.Name() != "" {
+		g.scope = mapstr.M{"name": scope.Name()}
+		if scope.Version() != "" {
+			g.scope["version"] = scope.Version()
+		}
+	}
+	return g
+}
+
+func (g *grouper) addMetric(metric pmetric.Metric) {
+	name := metric.Name()
+	switch metric.Type() {
+	case pmetric.MetricTypeGauge:
+		g.addNumberDataPoints(name, metric.Gauge().DataPoints())
+	case pmetric.MetricTypeSum:
+		g.addNumberDataPoints(name, metric.Sum().DataPoints())
+	case pmetric.MetricTypeHistogram:
+		dps := metric.Histogram().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			if dp.Flags().NoRecordedValue() {
+				continue
+			}
+			value := mapstr.M{
+				"count":           dp.Count(),
+				"bucket_counts":   dp.BucketCounts().AsRaw(),
+				"explicit_bounds": dp.ExplicitBounds().AsRaw(),
+			}
+			addOptional(value, "sum", dp.HasSum(), dp.Sum())
+			addOptional(value, "min", dp.HasMin(), dp.Min())
+			addOptional(value, "max", dp.HasMax(), dp.Max())
+			g.add(name, dp.Attributes(), dp.Timestamp(), value)
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		dps := metric.ExponentialHistogram().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			if dp.Flags().NoRecordedValue() {
+				continue
+			}
+			value := mapstr.M{"count": dp.Count()}
+			addOptional(value, "sum", dp.HasSum(), dp.Sum())
+			addOptional(value, "min", dp.HasMin(), dp.Min())
+			addOptional(value, "max", dp.HasMax(), dp.Max())
+			g.add(name, dp.Attributes(), dp.Timestamp(), value)
+		}
+	case pmetric.MetricTypeSummary:
+		dps := metric.Summary().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			if dp.Flags().NoRecordedValue() {
+				continue
+			}
+			value := mapstr.M{"count": dp.Count()}
+			addOptional(value, "sum", true, dp.Sum())
+			quantiles := dp.QuantileValues()
+			if quantiles.Len() > 0 {
+				q := mapstr.M{}
+				for j := 0; j < quantiles.Len(); j++ {
+					addOptional(q, quantileKey(quantiles.At(j).Quantile()), true, quantiles.At(j).Value())
+				}
+				value["quantiles"] = q
+			}
+			g.add(name, dp.Attributes(), dp.Timestamp(), value)
+		}
+	}
+}
+
+func (g *grouper) addNumberDataPoints(name string, dps pmetric.NumberDataPointSlice) {
+	for i := 0; i < dps.Len(); i++ {
+		dp := dps.At(i)
+		if dp.Flags().NoRecordedValue() {
+			continue
+		}
+		switch dp.ValueType() {
+		case pmetric.NumberDataPointValueTypeInt:
+			g.add(name, dp.Attributes(), dp.Timestamp(), dp.IntValue())
+		case pmetric.NumberDataPointValueTypeDouble:
+			v := dp.DoubleValue()
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				continue
+			}
+			g.add(name, dp.Attributes(), dp.Timestamp(), v)
+		}
+	}
+}
+
+func (g *grouper) add(name string, attrs pcommon.Map, ts pcommon.Timestamp, value interface{}) {
+	l := labels(attrs)
+	key := l.String() + strconv.FormatUint(uint64(ts), 10)
+	i, ok := g.index[key]
+	if !ok {
+		e := mb.Event{
+			RootFields:   g.root.Clone(),
+			ModuleFields: mapstr.M{"metrics": mapstr.M{}},
+		}
+		if ts != 0 {
+			e.Timestamp = ts.AsTime()
+		}
+		if len(l) > 0 {
+			e.ModuleFields["labels"] = l
+		}
+		if len(g.resource) > 0 {
+			e.ModuleFields["resource"] = mapstr.M{"attributes": g.resource.Clone()}
+		}
+		if g.scope != nil {
+			e.ModuleFields["scope"] = g.scope.Clone()
+		}
+		i = len(g.events)
+		g.index[key] = i
+		g.events = append(g.events, e)
+	}
+	// Metric names are not split on dots here, they are used as they are
+	// like in the prometheus remote_write metricset.
+	g.events[i].ModuleFields["metrics"].(mapstr.M)[name] = value
+}
+
+// labels converts OTLP attributes to labels. Dots in attribute names are
+// replaced with underscores so that attributes like "http.method" and
+// "http.method.original" don't conflict in the mapping.
+func labels(attrs pcommon.Map) mapstr.M {
+	l := make(mapstr.M, attrs.Len())
+	attrs.Range(func(k string, v pcommon.Value) bool {
+		l[strings.ReplaceAll(k, ".", "_")] = v.AsRaw()
+		return true
+	})
+	return l
+}
+
+func addOptional(m mapstr.M, key string, ok bool, v float64) {
+	if !ok || math.IsNaN(v) || math.IsInf(v, 0) {
+		return
+	}
+	m[key] = v
+}
+
+// quantileKey returns the name of a summary quantile, for example p99 for the
+// 0.99 quantile or p99_9 for the 0.999 quantile.
+func quantileKey(q float64) string {
+	return "p" + strings.ReplaceAll(strconv.FormatFloat(math.Round(q*1e6)/1e4, 'f', -1, 64), ".", "_")
+}
@@ -0,0 +1,132 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metrics
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func testMetrics(ts time.Time) pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("service.name", "checkout")
+	rm.Resource().Attributes().PutStr("host.name", "edge-1")
+	sm := rm.ScopeMetrics().AppendEmpty()
+	sm.Scope().SetName("checkout/metrics")
+	sm.Scope().SetVersion("1.2.0")
+
+	gauge := sm.Metrics().AppendEmpty()
+	gauge.SetName("queue.size")
+	dp := gauge.SetEmptyGauge().DataPoints().AppendEmpty()
+	dp.SetTimestamp(pcommon.NewTimestampFromTime(ts))
+	dp.Attributes().PutStr("queue.name", "orders")
+	dp.SetIntValue(12)
+
+	sum := sm.Metrics().AppendEmpty()
+	sum.SetName("requests")
+	sum.SetEmptySum().SetIsMonotonic(true)
+	dp = sum.Sum().DataPoints().AppendEmpty()
+	dp.SetTimestamp(pcommon.NewTimestampFromTime(ts))
+	dp.Attributes().PutStr("queue.name", "orders")
+	dp.SetDoubleValue(3.5)
+	dp = sum.Sum().DataPoints().AppendEmpty()
+	dp.SetTimestamp(pcommon.NewTimestampFromTime(ts))
+	dp.SetDoubleValue(math.NaN())
+
+	hist := sm.Metrics().AppendEmpty()
+	hist.SetName("latency")
+	hdp := hist.SetEmptyHistogram().DataPoints().AppendEmpty()
+	hdp.SetTimestamp(pcommon.NewTimestampFromTime(ts))
+	hdp.SetCount(3)
+	hdp.SetSum(0.6)
+	hdp.ExplicitBounds().FromRaw([]float64{0.1, 1})
+	hdp.BucketCounts().FromRaw([]uint64{1, 2, 0})
+
+	summary := sm.Metrics().AppendEmpty()
+	summary.SetName("gc.pause")
+	sdp := summary.SetEmptySummary().DataPoints().AppendEmpty()
+	sdp.SetTimestamp(pcommon.NewTimestampFromTime(ts))
+	sdp.SetCount(10)
+	sdp.SetSum(2)
+	q := sdp.QuantileValues().AppendEmpty()
+	q.SetQuantile(0.999)
+	q.SetValue(0.5)
+	return md
+}
+
+func TestEventsFromMetrics(t *testing.T) {
+	ts := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	events := eventsFromMetrics(testMetrics(ts))
+	require.Len(t, events, 2)
+
+	resource := mapstr.M{"attributes": mapstr.M{"service_name": "checkout", "host_name": "edge-1"}}
+	scope := mapstr.M{"name": "checkout/metrics", "version": "1.2.0"}
+	root := mapstr.M{"service": mapstr.M{"name": "checkout"}}
+
+	assert.Equal(t, ts, events[0].Timestamp.UTC())
+	assert.Equal(t, root, events[0].RootFields)
+	assert.Equal(t, mapstr.M{
+		"metrics": mapstr.M{
+			"queue.size": int64(12),
+			"requests":   3.5,
+		},
+		"labels":   mapstr.M{"queue_name": "orders"},
+		"resource": resource,
+		"scope":    scope,
+	}, events[0].ModuleFields)
+
+	assert.Equal(t, root, events[1].RootFields)
+	assert.Equal(t, mapstr.M{
+		"metrics": mapstr.M{
+			"latency": mapstr.M{
+				"count":           uint64(3),
+				"sum":             0.6,
+				"bucket_counts":   []uint64{1, 2, 0},
+				"explicit_bounds": []float64{0.1, 1},
+			},
+			"gc.pause": mapstr.M{
+				"count":     uint64(10),
+				"sum":       2.0,
+				"quantiles": mapstr.M{"p99_9": 0.5},
+			},
+		},
+		"resource": resource,
+		"scope":    scope,
+	}, events[1].ModuleFields)
+}
+
+func TestQuantileKey(t *testing.T) {
+	for q, want := range map[float64]string{
+		0:     "p0",
+		0.5:   "p50",
+		0.99:  "p99",
+		0.999: "p99_9",
+		1:     "p100",
+	} {
+		assert.Equal(t, want, quantileKey(q))
+	}
+}
@@ -0,0 +1,123 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+
+	"github.com/elastic/beats/v7/libbeat/common/cfgwarn"
+	"github.com/elastic/beats/v7/metricbeat/mb"
+	"github.com/elastic/beats/v7/metricbeat/mb/parse"
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+)
+
+func init() {
+	mb.Registry.MustAddMetricSet("otlp", "metrics", New,
+		mb.WithHostParser(parse.EmptyHostParser),
+		mb.DefaultMetricSet(),
+	)
+}
+
+// MetricSet receives metrics pushed by OpenTelemetry SDKs and collectors
+// with OTLP/gRPC.
+type MetricSet struct {
+	pmetricotlp.UnimplementedGRPCServer
+	mb.BaseMetricSet
+	addr   string
+	server *grpc.Server
+	events chan mb.Event
+}
+
+// New creates a new instance of the MetricSet.
+func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
+	cfgwarn.Beta("The otlp metrics metricset is beta.")
+
+	config := defaultConfig()
+	if err := base.Module().UnpackConfig(&config); err != nil {
+		return nil, err
+	}
+
+	opts := []grpc.ServerOption{grpc.MaxRecvMsgSize(config.MaxMessageSize)}
+	tlsConfig, err := tlscommon.LoadTLSServerConfig(config.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS configuration: %w", err)
+	}
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig.BuildServerConfig(config.Host))))
+	}
+
+	m := &MetricSet{
+		BaseMetricSet: base,
+		addr:          net.JoinHostPort(config.Host, strconv.Itoa(config.Port)),
+		server:        grpc.NewServer(opts...),
+		events:        make(chan mb.Event),
+	}
+	pmetricotlp.RegisterGRPCServer(m.server, m)
+	return m, nil
+}
+
+// Run starts the OTLP/gRPC server and reports the received metrics until the
+// metricset is stopped.
+func (m *MetricSet) Run(reporter mb.PushReporterV2) {
+	listener, err := net.Listen("tcp", m.addr)
+	if err != nil {
+		reporter.Error(fmt.Errorf("failed to listen on %s: %w", m.addr, err))
+		return
+	}
+	m.Logger().Infof("Listening for OTLP metrics on %s", listener.Addr())
+
+	go func() {
+		if err := m.server.Serve(listener); err != nil {
+			m.Logger().Errorf("OTLP server stopped: %v", err)
+		}
+	}()
+	// Stop cancels the context of the running requests, so the handlers
+	// blocked on the events channel return.
+	defer m.server.Stop()
+
+	for {
+		select {
+		case <-reporter.Done():
+			return
+		case e := <-m.events:
+			reporter.Event(e)
+		}
+	}
+}
+
+// Export implements the OTLP metrics service. It converts the metrics in the
+// request to events and passes them to Run for reporting.
+func (m *MetricSet) Export(ctx context.Context, req pmetricotlp.ExportRequest) (pmetricotlp.ExportResponse, error) {
+	for _, e := range eventsFromMetrics(req.Metrics()) {
+		select {
+		case <-ctx.Done():
+			return pmetricotlp.NewExportResponse(), status.Error(codes.Unavailable, "metricset is stopping")
+		case m.events <- e:
+		}
+	}
+	return pmetricotlp.NewExportResponse(), nil
+}
@@ -32,5 +32,5 @@ func init() {
 // AssetSystem returns asset data.
 // This is the base64 encoded zlib format compressed contents of module/system.
 func AssetSystem() string {
-	return "eJzsfXtvIzmS5//9KYg6DNq1J8uP7umd9R8HVJe374yrGhvlqtkFFgsVlRmSOGaS2SRTsvrTL/jIN/MlpWS5YWPQ021LwV8Eg8FgRDB4jp5ge4PkViqIfkBIEUXhBr17NL949wNCIchAkFgRzm7Q//kBIYTsH5FUWCUSRaAECeQEUfIE6OPDN4RZiCKIuNiiROIlTJBaYYWwABRwSiFQEKKF4BFSK0A8BoEVYUuHYvoDQnLFhZoFnC3I8gYpkcAPCAmggCXcoCX+AaEFARrKGwPoHDEcwQ2KBQ9ASvM7hNQ21h8WPIndbzy86J8H+7WUk6n7Q3GE4iiab8h+m47zBNsNF2Hh9w2j6Z+vK0jBWnJT9BsXCJ5xFBv5i4QxwpbvprXRgziZxoGqjS8DTCGcLSjHxT8uuIiwukExiACYGgDPfgEvAfGFmVZFIkAyBqbQfGumLmOBsADMbyiWCsEamJpWKBKJ1pgmgIhETIOi5A8IU0osieYg0pECLkAaNSIKCcyWIEvUjO5cIsXRlV9AUmGhZhpwTU5hefI6pGB43qyAlfjdYDNtQkFYH99q/gvMkVtyRaA8CJKYQIgIQxHW/7CfOfvy4fP7aWntZCYADVk63+3XvqOAM4UJk4jyAFNHre+K0vNdE1Zx9A5ZOBTnmk4BilYlh0DLGGGtqEsKZjwtMYyihCpivlewPulP2eBks1VhosgICUu/TlmhnC0rf2jhRv9o6B81KrswclSlT/4v9JBpgPQCUlxhWtFF1KWPqFUne6D/qkdFOFBkDR6zUZpuL+xEgjg+6i6rR5gBhmSMA2iYkhIHigRPchyN0OBwxBOm9gTm1PwUhfsEggEdwsWIAu6U8AB0jARwehLmDFG+OY8F4YKobbpJgOzDzdEkvStKEtITlLlB1QP48RS5ByC+wUSdoCwZ0sDQGWcoJPLpfT8+jmkjhuETv5+ekCWINQn0aUy73yvMQqr/Y4VFuNEHOMIUCJHEqnM9it+PJ/rRUEu+UK9pXjTe3Th86bnZAbmCl/Ble5glwtacJkxhsbUmwDm6ayJUgqn5xmZFqD0jr7axFonkojaYOVgW5MXVCkS6BXIxrX3hwxoTiucUEGd0qzfPb4w89xLkMe3iKxNQxEOgM3v08kqoHuzpISRzHNWU00MduteQsEWoAaJPhCXPvbDhUmhjPGQ4gh1xrf7wAvItyB5wzJE8SITQGhRQHjztBkvTmTUcy3eWVbzaShJgalHe3WpoLqoQkeVKuX/CMwSJAhtkiI1yC8ChnKAVsABQpL+gVpjVBuEM0qDGVJOdkvA7CjBDcoWFXSQSR5XPOFa/7yaolKfRhWXEkknMCqsVXiGQ90MVxZCgUBAntbiUBiQVLu41u8R5TIByRNvpC3/GAqQ7EBmjyKWa2g9zdp5HUGv08s1Kog2hFK3wGhBGEX4mURK5KCxfoO9Xl5d/Qf9ih/tuaNeIFSK1RbqYakXeIoWftDbmsV2mOMJBYHYCu92v60Q9WDSUnYNcryFahO5ZPdgoJzWyW56YhW4mrSjyLIWyFIAVCGM4rNyKuYMJIgv0U42si6gLQFihXy7/oqFNtF5Z5crsSJxMU2l+t9ozB3T1t8bJ+XNFlf5ccZvXGxH5swQgXtFB8u2o7OHw7cA5znnqhbJQPQRpku8SWbbNjnoXUjCKc3f/H9oKNTklf889o17+ifakTlIEQzNHJ8vI0I3+NBnZa7c/TZb6b/knin+Hff80ORl9839VbO7qAZwmk6/VDTg1afbxAiZpIET6StbM4drDe8Vj+FoLuL+WYpFTLrN4HYUJJ5jfP+m8+EtnJ3ffEV8a+a6b3Fs6sCgTraeE/1AVxZD0gyZRyD/o/0R391lBas9K+PRneI5iYIowqz2XIb4aPt2GPZM97FA2EASPn1tNIfwo3QhZjtVUnkd4ixhXaG5Ko9cktNs4pjQXeo2mi9F3MCQAh1OT8Bhx8RhPqeBhmLQhCrieIa0yMgm0hi8SSrcd+DaCKDg4QDPKjgiNBOdb1T+jlrqCvi/tAN6QMTDKsNG9S03aFBepDoUqfqCEQHHhKLmkL3GaxhCWMom0ZMynkCR/GD/0r1fXvWbw5QWkcShg48goJdZTTDWq3WIzalW5AtIqtB0EExGqzwQBZ6HMa9y1WTErttfEvhhEu2Y7ncVDA/RjDLneB+8u7mV5E28CyeMxnZcqRo1DOy6x4EsBUtYdBmBK8Hi7j8eQ+ybu9kyd5nAvAKdEZ3OiRvXvMrSasBZSHW6huuTFz/s5XodzoqWJ7RUVbm9NcU4zu/zz5b/9UpvlBaFQuiiFdnINczK1ApX8T2PUqWRMH2njMF6gOboX5K24tusJiwVZEwpLCG0AgjA7zNQLPYQ1CWDkQreyp1q+c/n9IoT1hf7r1XcvIj3uAaBoGlUo8Kx+/j5FdwxJHgEKsARzg+0/CAv5RqL7R3teMuUzaZnG94RlQv+OsETY7NNWu/XezOzsEs7sJUyltwG+gRCdwfMUwbMCwTA1sOR7/7SYU90s5qTBndxZFoawtvmGdm1u/FNiVstYRq1i8wnjIci0/MquyYk+lgarTORYe7xzwqxQ+cICmqAFpyEIOUFyG1HCnuTEHNKtTjcoPDfI/NzsLFVHtFpMVrAyRu5+RAsBL2o42g2ERjcbVQFKjqomn+oAyaXmOZpXAtxH9dELcmuXViLhuIcsPeBAeC/vJFRAt8X0MzWUep/eyx1wKmUpFTyC4ip1USO8XApY4ixspP14s4IrhaD5V/eudN01cPD3fCnl60aiBU9Y2LJ89ljSR7Tg6O/cGFO3Ibfxox3KAUvLrz516YI0SpCLFoU8kNWIp29qUbuJb5V3F/oazvqqQ9lea0xsZaFVAeoV+WIAjTnoAOiz+cdDaG3rmQEa00Qamb6vH08px+E+NurjwzdDA+E1CLyEPa3Ku6t3Qy29/hNhy9kCB4qLG3R1eTnM2n8qwDfOdNavJCIsUeBfw+/+ekpI/+qwNhicd1cnhfbKA9eP29QEvJROeHQBhSRLEvTL9dfZeamp8CvMGBy9mHY1aNWePJkP+Tk67C2emnW2XXn28iEtiVo4yfX7GSGUdLSzjdnXXBOl9hk86pnmm95ie8E64rHZHgLzVLvxqNycZweuYvOtkION/BAW0CTMPhxwZtMu823qTgY4WNkuXLWh58liAUKiMwnZgdiJBgcqwXRacUP8Rws9QO+GSXur1EczXMowZ7WT/MkfTHtpn52A3Q4VdSQfDLW8mx+E5hikp7frqNHq0nuXLTqC2+wYKsizsFDuFBLgLLY09jokWtPNbd05qA24+3Ju3bHQ/FcesXIz5L1KqX+qn0QhxMDC7Ix6/2gDn+ZmcAgKEyonKDa2GgUrCJ6yaEFhoX1vUAn08gc9J26/XbpTiEgUYBok1IQ05lhPS0EW5eRyOdz9GaI8Y2aCIRex4MFFBBFhCz6py0L/cFEc0HytCM6coXLLl1k6sihTzwLoKYL6AVH/3DN0//ifiBhGMZJJVLXSqQ4R5tqlpSp0nwUXJu778Ht9YbtZ5JlauK/3VYsG84b6mDjUaeZQz6NsPVlXW6VdZUUbXLVszTYvFrAgzzfo3X8Ztv676gOWg0pa8wyV3LfS7hSRigTS5hAhTFOIGkep92k5+jQ0PPPCwYWcmb6q9FJm3Xhnw/C+lEXMs/zDxXt6KzVpFHxWhwhqw8XTXqcbR6NwvHG/KVZUllp8pn83VbCLSgTveMWUoFYDD7zmIFkF36eykifqqAmeanmYLDX99UIk7EURCgiArIs9exsFGePgCUatl8nBONo9BXY4JCJD0lMwhE1BCC4OIxZL2tV9W0SELXvM1bEwSWBhNyLCpqHgcdz/ZDsIEWEBj0yZhJs70xdkA/q8YIftIbFDAuSJWvJ2gJVu4Jhu8La+m15q1+kWiw1hxkv+9fEWzSHAiQTnEGsHXEDMhcpjgM019JX9aCaTKMI9om7ZZjEHhfvtV5/djmQLzNhSe4dLyueYZqbdePtEbXvuPySe/ot3uvj8n1BzCjom7O7BpnVBNDSNDsYc7evHjuGScMzhvt12DzejRMHIY34iCtoHJkE06ix+/OzhNCu4LD2FgHbyuhyNgtcV5y8l4BArPCn2WJ8UH36odH5H43pdmBJctRgxVquM76nnqxFZCmwZdS9K1Eesvu2A9i/BGvjOQxFN3NCLrZv9+jf7cB/vMSAW2p3Zcdzl7uPWv9pnRJZEM9eNzzuw9ryXtQtKHbOdV7w40mnhWH0xFqLgUUgJG1nnFgmlKOBRhFl4rsnbc5ri9tmKIqiJSwWYbcoTtsRimUQmHiohxgK7vdZbFUGWjAuY4Tlfww26vvz5b34LLEHssLRtG5Xd1nWw2VXB9G5N2HIWEmFupGx3GB3Yur/Zt7+c7akBwNZEcKZnDq2xIHhOQTZrge0sqE267woPLtyaRL8JgF8fbyc2MmuN/v0j+k+/CSs3cUTjxeU+Pnw7lzEEZEGCYkAuzi+ADo22NV7DR11xoZ6hE8+d2NIzOm3386tgbTMF40QfCG3WnFGDtcFM+9CP0R5nL5pk3d38Cb1w5GrQk0ZJHJrd+04VDi6SRIRi4WK/3mH/okfJBFkcICQypnibn1wUj1OTnd5Lrl9B9Qu3oaXGq5Kw59GonPKYj0dlTOePSJWaj1ZF3NIDAx3ZLvh7Y1QBW504JF6b/myd3hZ5+t7mKqML6074EHT93+xCbUU4qH/i/LP57tD9qGu/69qvPJXF6BjJoUwD0n4NnpfHVlgWc5g2gVtJrn/kUUQU+rjCYgnoTHkKWjLK2Lor6ekSM7wEoUcxNpMok801CQB3pEqRvM9aE7sosC0mI7JbU4WUL5bQ0kL+ApKEemk9gkKP5A+YVqzF4BffumYkbbjunF4kQRktmzRc36hK6/T2oN0exfPxZ1qvv5AmmLFDHzOJbCoGLrUvQf1t2a1pVHJxn0YxJwibRbPBIkxLJmwtxH893N3+9wXh01JbFVexwVmx8YOTRodBCzALgGotMXfTZzstvkr2vp4GKi7+fMiJLQ0xJyTGlfYL5pC2KWjResDhgXAuQJmKtaw/jFTcFFlrt9HT26bUVuBAmNK2DWm5Qz9ERkjBCtcb+OyLx7TaMALS/3Z2/d44fqljLrfa6NAWo2VldRBopuxXn7UdCLPx6PFKOSSvqOrNDIbDCdJNQlM8T8tU+kpkVASGZAeErCrDH6fZoXTxN0Ih+wwX7gSbxqXtoULvb2aTsPkaIguhjoWn35Q1bVrFXBRkbJeOx1B/DWR3TVyUZSAN/d6eOiURUVPJF82b+n7nCE3ajpKWSXVAz858XpKlWFaBdoCZtuXBSh8Gw+o5FCuE2dacGrpEscK1UNxYotCkDyWKAm0tCtP5bA5I4LSdpeC84Qp44Ft4Oy/JNC+qF5DBI/PNzY5kzIW5oGlOA1g+2crJCMrP4KY/7lvZ9VgBeUa4dgTUpwVLSK5IrN0/XCPIODvX4nCUjQAllAbghedrCh7PUGenlrdAHXH/HgJGTpvubs2xiJuNGlPquJEIS8kDYuzwhqiV9YW0mP2RlzsTsxKAiGQ/KntZVlO9u7WhZNdJKKVuqBm+0ypdL1U8byl9KYooxmp1OCFp6qkj4/So2hHG/VomcxsF+lHa29a2R8UgkZnRjiE0R3e2BiEJ3383cXTMtSoHOVti6cWsaoKrGVwtHYBaDUoNUfN0BnGSTxSS2nVOKNhXwF0nFAMXy6csMecWuZfmhyA9IJjNgzMluD4oGLO74Vk6LBtKyAn6+NujsW5fvvqJ6r9LhVlowaT98ugWLTAROSlnBGPBtaQJZ5jSamzKScfcgXMBlTQil95VSCcsK6zfAFmu1BR9+VqA4aUrAFMX3quAkqBk4Q0nb/DSe8RHec/c8gQYIbsrSChMhGnmiZZkDUwf5wkPG9Yas7kJFoLIeP3H9aRImmibL5apwYv0Xu9C8c6oN0eHgzjBQaBMIx4chkRPxEQjOs9lUtwZlpyZQNM/mnrTOdoNOwPq2h1QD+OHqivm7jblt6rtrQAabO9OEPyLVv887GKDG6n5bHMrk+ZeZCuXTUapxqOFub5updk286iUicRLmNaaAdXhNRjxFoQSra/dWYgwxDDjrvFeT1D+EF8ZVVOMrxesvsJpznmNA6aQZyrj6gEQxAEnT290JkOctq8fNonet/Lq4HYVWwldT1EdcSr98Dpxpm/fHGxSnduyy5Q2vr1XB7erAAvoeovqiJPqg9dq94OFnDrHImm3/y3T2XeLM+OYPdhNbkQCkc6ucQNXfIMELBOKhT5aNpKy3P9Y7Oyn/R8BkiciAInkiic0NId7wJTyAKvGgnCfTH5PuMKHF8nXSpavUTDW4cTUf+3RQErdeVz0JUXCUj9Su2R2qtEZliiEBbGxk2YpF5Wj6RK7T3omS3No2X1gaXGiS2ya3KjLPIN2zDMHyj4WXHDMG4mWmnWmkZeSWKeFkqB0sNB58c2SjBMnFBvDihJperBfI630ZLkqhnRaxSvUCa/XbF02y7dhvZoM7NCFKtRUJMzEK09BGKaCh7MlSGVOyYQlPJFuzTUSJqwS5ysvYvtmsV9qQ3x7pzWHFlNe+etMjbm5t8ZUGqNTWjB6UZRNTLNx00vbiAIojmVvDbGsq5XgSlEIjy4ErSuyaVbntomCw4bODJPE8xRy+lNsVKu4se3pPSC1gq0T0PMKJ6YlnnnUYdFqlwrmTmt1aYZsUJ0IZPbCvua/KvHDb6FZsYxhIUxsETI6qyzR94V9NJ+QDg/DN1GmDKB428q5YuvrQYJp9KCPIZiCVz2aXPyRqfwaDkiZeOtG0PAYw4Ojhs5SY2gsLjD95/f1Lk/Fn76hB8lb/IQ+iGuoH1M3JN1CnFenEAUslW1xbedAf1AqbMKsnGUxwJTblhG72CuyeHXZcTBBvQ8nqFflUvHHP6F8DQJdXaKuM1+RjV9OlI1fhrHx0+WJ8vHT5TBGmpp11NnoiBHsyYW1fo96VaXmp9/JfZG0HLRGXfqUmkQrCWnPlY8kiRKqMAOeyIZMiBPcmyE4FTbeDEEXGz0MgWdp/5ZQ2rC0a8QKiVcctFTn9kq+ulRq+lKD545v+vOW4sp+XjjF5W4IHSekV3xUt+Bsl9pLauNfTMJ3+NyD2Gw2H71q0Afwm3FaUjzz6kqlAl2bEM1z+o1h89YeRT8driaVe1n2JlbClKu0JBItEhakpQzmpTfXMzp1D+w1IqzPrlo3TGGDew10WMynPf82TOMziRRVOFNfk0mKeNh3Ugv4xp7U7NZYakzql0knvoks3+UaLuZDKOg4vDSr5BAuuzJ/o6vTmb3l9364XnUmAnebja9Ns1G8jjiybvVLIr4sP/vql6btLYdD7Q0C0p9So4D9d/BMNWuRskxXOUOAg5X5aGUnb4lhE9m9lbdewkTDvFR7GTMtMLY9ad4c1QM5qsMd0giiqS3habqihPqY1a6bdQMYL7acd6VR821jreJZehWyb24gZzjCz6fD9AqyEs5ie++xObc3J06R67z+wLpx5fbUmtu0L86C1F7QzH9Mt+j37opyvbjYRLYK6atE9t3UtfQWmNDk8EUF5VtDLn1XuXNtL5CcVeb0PdrUGujkPwJM0/0h6sI3R1aWlD2+MW2b5GrFqd+SFnGuyHL1MkD1yEOQHt/oZJf0n304OwGbTiAjVeXWlbpQyi+QEiSOIczCzdb4U1hDU/yubxqN8k1LYG94qWCunk1zXhxd68iowxeUrs/4EX4edfhclfqMznk06ujcXFEbMPrsifTIpAyFoIlqne2NRG8eo6LQBPUuVkPQe3HLzan5fitIn2VIm1+UHEHzIITpb26f2nM+UiO9MX2nTFin6jfWOpGYy/c1YZWE0nJQ3FdYJ+9qpuVyTuGqQmu//nAQB1NL7XV4XHLzenwuuXl1XpfcnNJZo2p1zcpupHhWW/7mVFI/fTTz/uZzehh58znffM5X4HP6YDydapTR5RgOFmx8Ov1oY1UEnUHHRqrDJXPyLiJfVOTT5vY1Et7JHXw6zYBjdd7GjDhq2jMVxCdpKko2Qh8dvn58yB8krPVjHMLoqZqGok2ocuyxEY0097GeRkyvwU44YVXlVDMYXVLa+fyYSes0jUZ1IpvvWXkPC62s2yqF6mMuZYZbk/cllu1Lr3mDz7v7li4SFQg9lHREID0Q2XdMZ5hx/2tvZWzHWUAfGGfbiCcyD7aYtJ0pNbfvrpq7KOcCAmCKbs+NCTr79OVbs9ZQIlXp9YwoXkh0JlcRRO99HXP7C29B6LF3o98IhfM5Dp5Kb2Q74Xz68i1jdweujKyPzM+D3jXNwGPP0YqAwCJYkQDTmRXV7LT2i2IFTBZ0TGE7lzJ706lgPO2G0HwTcxRxyc1pSiuPOfWWWyPJsjx3k1v6IvTrsaTZG9ZFc1Faec0hvOqK3ElSL2A2myXlN6heGe2gHRGOYwhPi+NH8keh5/q5hYjc/2mkstkUj2tzYryE2QIntCOme4BL79pG4KzlaPnIrgRZLkGY4G/clusx0Afqwz+5mL0Cvg3QDsbRu8/6U+/sf0q00irE8oauLkJiX4mnW9PYVVXb3ec/pkm4St+3Mp39QlJsedpTo+SsMRZ1gEYSekDzT9NNgqfva2fXKXD6+OMOfPCkvaT6UIzwpHBy3ZeVtuc5erFyjG3RVRHqFSIwk/aZRLRKlmDk8n6CGG/ObI3ruAopZ3rkk5Ha3yst8/kC4UyQXnkNuw+xwfHJ8PqYZfh3nL2EwZoECs9PaMf/XIhRB5gxrmzvsYBiEkHYi9OUyzl9qj1ZgoZV/v9KefCE7u7fCv4PVfC/Q72/vch4KhprQ+u1J2G0rVmAEDYEqm24cRMwpWhulCrUi69xcNSSwRokJsKPc1E3F8DdxX36aDpnpm2XlrbrwkDpHoybvkqQvzfhWuaYt7k4JUHteaCWt4vQMEPgAPzjWhuD7B0jATHFgR7f2Jo363Ac6+BjYdz4eQzi3Oqpnm/3VGnvAPrOKGqERo3F78lUpyk5NJLX1/rqeP1v3lpfvXW8eet40wfXzs2sjtfHLrsF/baE35bwvnz8ORZl7gPYZ8hkEkW4dK9fEUVBc2jjxY/1D3gXaIvv6khkD7JU3iYCmb0M7B5YW3FZ8lIF6E1ZD1rsA+pbmm0SbpRoh9tdPZflsA1cImt4Cx2hLWMjIcmjcpWnlHthIWHtrvT+QEyjwCEoJAWIDyGSlPAwNIrHMfhfttwLjKU7CMsfPJqT8WfIkh2EJAQ8vkg00SYU6E79KNEaxBYljJInoC50SZR9eg3HMWCB5olpB2Occ9MYGlMkiUpciIQoFOGtS0r5WdvgJ/DU4+/PXkr4XB94GsWN7hndogVPmHFEOA1BuLdL/79NnblH3RoeYdWjHAT6YWHHWDwdYJVZsmNBN+9MuxfLQChMzMtn9m2xBr4S9sT4ppp6HIGxjJdCL+yVPdub53tpyH50EX0lCKy1XysQkSkiP1y1EoBrPkplw//q/1CjV17uosUTpky6qNAmzg1rPW/XP6pCpOtJTv8+2ipsP7bCdWK6zbZRh7A57GlCzTUN3nH8VB6OKJpv0d3F/bTmowlcCmgO9rsq3x/uRel/evXAH8bsUm+cty+0QaumPRmrWpf+PcY1ZT1EbZ2d6IHAd+VvDwBfV+ZJ39DSLQPwS2DLghk2LRnHQ/HRXaLXxJElPkHEYvny4e4WYSHw1j58EiYsxEwhv3dA5FNaDzuS5SuYPleEZQdpGf+QHr4ZoTBJps8akcawtWEySfHxRWLIht0isRfyxh/fXfTrHN+sr3ruoCWoXWqUt5NWowibp6kF3hgUdouUXpTG3o6rOYU6CUO8qDQrTkNTV4euLq9/Pp9vFaQQ2uDp9XkAX8nhcydsB9HWhgkTM9PjdqDN7BOIiu3y703ZjjMHhfvtWcUYQfpgsB1NHmHTMvdYCttUndHinW4czoy27TOapoJKG1PbmHsPl+6FA4ZM5vtzKZP5+TAmZ5KwWmDWjhnWwdQGNKWPCkdxOiA1SR3rPq8wW8LUvQeeQjHVbnbvwSxMPcOJPUno/ymJkrjcJrqIGp4hmAU83EtOj3f/9+P/+3SLNJ38AX6H8EeJIn0oqT7pjUoHEqJs6fH+c1acL0233myvPuoaWMjFLBYgoWrsB40egqkwHYIie4Zst6OLszbZq9G19/j9Wtt9dgniZNrybPCAA8TDt37vA5d7tnZcoe0/funaa+06QHVwk3mZmvLRvUYt3Cu0uZzeE1PYE0BtuHhqxNGrdMURyV8+aeoK1KtU5dgXR2w5VUOZdAFVjIMn2L1IfTAuN14XMp6ofaF5h22qty6Ou9dEeSahOGbdt+Ia2D7H/q8fHxwVmTt4dmvbL6kSEgHNh1JMSe32f4zVKls406bvR2Rpi9xukBJJQzfyvI1JRGrPDPVFoD+3z+CUB5hOSdVU2OFrv4ZnHMUUbtDVv11PL6fX0yvEBbq+vLy6ubz99W83H37999ubv/31p19ubq6GufWfNA5094BwGAqQ0hXsB5ihufZf0N3D+mc92N3D+pfsQ314i7nw79seFc/4u66+NNgLvh6qA5OAiCs4AYF/MUBGlrjj7igidwz0l/mKyyEOXAbsX385v766Or+6+tfzn36Zss3U/WUa8FrUtwPzw9cvSEDARejd9EU6J1N0p7SLzucKmyek1wQjAWsQsr493z0gyvlTY0lnRQygaDiLaSJnnA1xpzN57My+9oJhsYDA1XXG5zZ8GHJzCjiDr59u36eesZOFnjR7XZYzQBGv1/hRPAc6Rb9xkSKbGAKa2v++MsfudwvOp3MspktOMVtOuVhO32n5viv+ohYr/5pd4+IChaBARMQF1y15FPAIpCvfZgiiOYQhhCjg8TYLimJVe+rTfGGlVHxzcREnc0oCmSwW5Nng6K3LMxCidr1tj8DTv2ty7kPzlE379m02J0YDnboh14qjA3FalxHXapm79rjmbw7a4lIyAY8izHYF4QnC7IYiCikZtPA6ps280OZ4QyXSrTjg2Y+hWxLwDEFi7gbtIw/zos9glfB/a/jAjSG1jqEXCaWzAapQ9oGba5Mezd+R5+/7libxBeIxsMx/JnlBkgsQ7OVB44bCg87gRF2RPxg9Zsx61NVJ6IxJtB7LCVOwBN9d4B43FDQwI8NmdAWnk0gFrZndvbFkQxjnxx82U4Hfw9xxXvQJbPe56Xhpp1kgXWfvHgL7XG79VzxKpgGfCZpjaeto89AMpvr4oN0ze6nY3LW1AbXYXEogf8AUfeRCgIzNw4OKp+8ASTBFPRfaYl7IrbxgoC5IvP75QgXxLILI1XDkb8NzZis4ps13FusrDQ2L9jQrVPvsop4BIC7iFW6/tN480z3RGsR2rbtJcsNCqFU+ndpm+bZy0GRDxmYgtSfdcu9nVw6AT0NrszNVeCC1R0DkyltSMjLAPAdYGHaQNAPKJcw2uLHT80HQVhBqGzHLkcy8ybAybkWi04CdAemDWm7ZTDZXyh8NdIqjL2YBwfoUMGscfTAvCDNzUg0FHR10BmQI6mr858VQX/dBTbFUMxz4MjBHBZ3i6INZ25qj7CDdJo+wpQ9xdkgLR3Vfv93+SdxXzcgLuq9JeIrua/vsop7u67GdvybULf+SrY64ci1xcJTguyXxvdya0fVmYMtUVeynXCxhz1RbYgMk08hfzeBJDaTLJ/1q5c+ExYmapR+KCKXEXz7Qo5j1/jHllbASqXqpWCJByE7Z71Ao9okvlxCeZ09Pg5SEs2oAuU3GDeG0nUt8854RDox3VAm1m4Z7jPuBFVMjlC+JtlzVIVraU+zJ8+2viXRVnIZ6Hwl4krB7otBfz2qECtrQMAG+WpF95iBTvr6lKeX0hBfJnHMKtfhAJxL9NXP1IrCWCaeZoVaJ7FMq5p+R9MW2StFfC4aAj60VhdmwBjr0jJKX/OOwtlntXE++AiQ4V+ihn02wczQbmHLt3EI/lNKCLiedP3VWAZT/y/8EAAD//yjo1H4="
+	return "eJzsfXtvIzey7//6FMQsFvEc2JrHJsG588cFsjPIvcZN4kE82XOAxUKmuksS191kh2RLo3z6i2KT/WS/pJYs53hnkE1sqepXxWKxWCwWb8gj7D8QtVca4hkhmukIPpBX9+YHr2aEhKACyRLNBP9A/veMEEKyXxKlqU4ViUFLFqhrErFHIB8//0YoD0kMsZB7kiq6hmuiN1QTKoEEIoog0BCSlRQx0RsgIgFJNeNri2I+I0RthNSLQPAVW38gWqYwI0RCBFTBB7KmM0JWDKJQfTCAbginMXwgiRQBKGV+RojeJ/hhKdLE/sQjC/79nH3NSTK3vyhzKHNBuSH/qePzCPudkGHp5y3c8O+XDTiwRo0wJz8KSeArjROjf5lyzvj61bzBPUjSeRLoErmMvwpoBOFiFQla/uVKyJjqDyQBGQDXI+BlX6BrIGJlhlWzGIhKgGuy3BNdFoHxAMxPIqo0gS1wXSDHP182TJEtjVIgTBGOoCL2B4SOEk/jJUjHKRASlDEjpomkfA1uTK1QaDtviRbknV9BSlOpFwi49L1MT2F18Hq0gCTIbgO8Iu+OmmGTGsIm/8zyn2CM7JQrAxVBkCYMQsI4iSn+I/vM1a8//Px6Xpk7uQsYNXUesq89kEBwTRlXJBIBjSy1oTMKx7uhrDL3Hl1YFDdIpwQFTckiICshCUVDXUfohaTRGCVxGmlmvmchF+NZdziE+IUoC8LK878QJRJ8XftFhzT4F6F/RFTZxChQVT75F/I5twDlBaSFplHNFnvtsdsmB6D/glwJDTTbgsdtVIbbCztVIM+Pus/rMW6AEZXQAOYDJNAseFReGUZbBK4YNBYp10cCs2Z+icp9BMkhGiPFhAru1fAIdJwFcHnmKziJxO4mkUxIpvdukQA1RJqzafpQlCyMLlDnBlX+tXbg5zPkAYDEjjJ9gbrkBIGRK8FJyNTj62FynE+1Y/HJ3y9PyQrklgW4G8Pwe0N5GOF/bKgMd7iBY1yDlGmie+ej/P18Vj0ZaiVW+jmNC+I9TMKnHpsDkGug0eWNDOOE8a2IUq6p3GcuwAa6WyZ1SiPzjd2GRdkeebNPUCVKyAazHVUVfQm9AemWQCHnjS/8sKUsossIiODRnghOfuPs6yBFns0Anp+CYhFCtMi2Xl4NNZM9A5SEajGU3aaO3CEkmiHEESQ/MZ5+zb/YhY3GcBJkNIYDcW3+8ALyTcgBcHCPSIJUSpxiQSSCx8NgIZ0FC6fVVbLZK4ZpDqRObj8hNJtViNl6o+0/4SsEqYYsyZAY45ZAQ3VNNoAJsxi/rTeUN5gIDi6pMUeycxY+kIByojbo7NGLKBrXPmNFfThMUU6myZWFsAqN3X7qhZcPXZIelRQKkrSRl8LRw4SrOi7PgzJN6Tt96c9EgrIbIhzvjVB6blYjLvhNkUFt0CsWK0V2LIrIhm6BUBLTryxOY5uFFSvy8O7t27+S/zB2qx4M7QaxUqa2TJdGaMh7oukjWmOR2+VaEBoEZiXIQrFt3U8RHxaE0uKU+5NczyFbRO54M9morhtk9yI1Ex0VV6KviiOUtQSqQeIPeKa38tnBNWEr8rcGWTPG5gSGavL9278iNDyWsYnt3I8k6dxp8yGzniWQd//ZOjhuCOz3n3lW6c+Vt3m+GZE/SwLiT73B/x+wVX7ZcE6z4XyiU6gBisRYEBTJxDYr6m0YgTGc27v/Qi+Uk63Q/wv5pYiMBsUnGEldepCSf98rhl3jL1aQsQv9ZQpy1Gp/oWMzeMm/UPwHrPuXKcnki/+zEvPQCOAyhXyuYcClaXNIFHDtStaUr2TNbK49suf/gn//Qr40Eu7PpVjknEcFY1fxs2E7amE+nwYHr7Xng3TA8nk2cJOviE+N/NBF7my4L3rdcjrB+hImjjp+QBKl8wf8T3J7lxekDqyEP/yMYuQRYV57rkL6bvxwG/GQZQHai0qBZHT6s1UH4RtjD4xGdnnGUw2mSEz3hAtNlqY0esvCbBmnUVQovUHT5uh7BMKDkLk58PBKc9jkMZFSKcJAJooEAjP8aDIqDbAiYJVG0b4H304yDScHaLgciBCFmy/3GtRQgC4U9H3pAPCGjIFRhY1nNuZEPjviYnVWpBYHKgi0kJaSPfRl1tI4oUqlMY6d+RRR7A8Th3737v2gEXx6BeEYa+DT6MgRG6imBtV+teEozGtXQDqVdoBiYhbhniAQPFR2ebNuBbn3LbyoA3g6iIZ9H0YmTg3QjzEUuKLfvrkrAewCKRJ1QoyIA+PYRIq1BFXC5CAA11Ik+2MihiI2sbdnmjTHRwG2zCKCxZLpKVWUEyZIGJXUhFvAePr9foHX4rzGug+aXVERxpMnQkS5X/727f/6flYXY8UiqFyUOmigHwoyjQKV4ldT1KnkQnuVP/3CgSFYtnUv6RtLQjhJeSLZlkWwhjA7c2A8YzP3Qg9hywKYuNAtx4hka3cuH96EsH2Dv3334EWEfE8ABcnWocBX/e3DnNxyokQMJKAKcGjIfzEeip0id/fGYLNSHlem8ZDyXOkPhCosxMFLgFrYtZlncRMTPLuEqXEZEDsIyRV8nRP4qkFyGplNuno99yrBVGAvEsG4nlYXhjD6fEO7MTb+ITGzZaht94Go+XzGRYhHg1mFTDYnr3FbGmxylVOMeJeMZ0oVqwzQNVmJKASpronaxxHjj+rabNIzm24xeGGQqWm1aonWi8lKXsbo3Y9oJQGGKvcUjqPbQSC6xaQGUAlUkbyzAVZozRqCH5IJG4aiOVJjhldZb93aShWcd5OFDEfCe/ogoQa6wNr8F4d8pXCdntUxjwkHrElllEoRQXmW2qwRXa8lrGmeNsIMhpnBtULQ4qtHRhCHJw5+KaZSMW8UWYmUh3MvL2PSR0zpM3pw8oswR/V2Qe6SBwPKFqttn49182lqF5QxgkK1JBTFTfuuoe1x8Z367kPfY+ruTzZSyLw+0eoAcUY+GUBk3gfQ5/PPh9CAI1cGaBKlyui0FLo5lJGg4azPyDq4Yg4faRC6BYn1yMd5lVfvXs186urw9PgrxteLFcUE0gesfJ6NUtpPJfg4AEW/kpjxVMPcj/S7S0L6ncWqWsC+uyi07zxw/bjxdH3+VDZRwZwBJiHLDwmGnfU3xfnuEsTJR2AKid5dhEjvppLJfOjVbKDbnvAWz6wOJevcc4x/fshINNJJtt/PBKmks+1tkI/tU9Qzgmfd0/yGS+wgWGfcNptBKR21I2sLsthwlZtvhQKyzA/jQZSG+YcDwbPTqeXehZMBDTZZF64G62W6WoFU5EqBiz7nVjU0wBP8eS0M8erJMAjPoSmjpY+GnRNYcAc8/7gX5CVtTAdZXzYAXrh1fzIAyQ+GmlMaKgMNwkSZ87rENedR+3VNpb5h7Bz/PhsYIExJoJI+SxPlVhMJ1mPjjTvM0KKlm9u6S9A7sPfl7LzjofmvImNlR8h7lRL/1j9JQkiAh/ke9e4+S3zGeEcwBE1ZpK5JYsJrEmwgeMyzBaWJ9jDvV/oTbfSsuv1+6VZjHjqgUZBGJqWxpDgsJV1UD5er6e6fIS5OzEwy5A0W/ryJIWZ8Ja6busA/QpYZmq+VwZk9VOH5ck/HVlXqeQLdIajPhuzPHSd39/9NmBGUEpXGdS/tbIhx2y7NmdBdnly4tt+H35sT246iyM3Cfn2oWbS4t0Eurt/NDTSSprujjVnaIouTQ+1oMtjnJRJW7OsH8uqfxnP/69WsA7JZPA2VIrbCcIopjX0FzRkihO4IEXG4oTW9T5012+GpcfIFXH1B1zmmrU0uFMIMNaU2nqcGbKKzcXifyiPmPmsc3AudqWmr4h1wDnon5OOsb2Z2sH+wNErbG/uTckVlpcWn+72p313VMnjnK6YEvRm54f2y8YAfUlkpUn22zVB1t2sIEFVp+uuFyPiTIpQQANuWe/Z6UaIiExo8wqT1MgUYS3ugwk6HROZIBiqG8TlIKeRp1JKRtnXfGSLG1z2QcKzOhUkBD/sRMT4PpUgSCE+CiPFAxKZMwo6d6QuyA9wvZGwHaOyUAEWq16IbYK0bOI12dF8fP0LeYuj0icod4yYU//v9J7KEgKYKbECMAbiEREhd5ADba+idAqxzXag0jumArFu+WCxB09kgrfxsVyQTHCIWLcg6Eksa5a7dRPtM7weuPyyZ/4d3uMTy3xDocQN2+zk71gWpvMx0MCW3Lx972KXhlOx++9TPbhFh4e20PH9iGroZsyCeUtDbjz97JHXM7B2YXuvuYPJgaZSiLvsTLEegIdX0utxj/br88IP92dw3kY6PumjEaFXJhCRUb3K5556vxmyNNz0Ez1+UaHKsv+3QF+j1jNEB7zyU0SQsPFD85jeHSJ8cwZBip75D+a4P57s+iCNP44Xtxlf7diYwRt5rkONGu6h4saRd4ZgF60VirqxNej+ngGH5gsLME2b3LR7zQEexdLrjtfzjDZJ58zFcci0RLRLyC5bTAM9dxdweKyy0SBZ8bmR78F0KAY5b4HDuVUkQ41VG8Krj4GmI11WwWjOmPLxB8iZhgXkb85JHWQHX9nTErNyeTC6V6zQ2KWIFCZXUhh/eQhG25tiWkS7FFj6Q92+//U+vyFgQfYC3w68d6uqCXTiSmxtkDGDwnDpk0lzS2R/AHfh2+EqYLY+LIy0A+JZJwXHkyJZKhhao2q0Arw4CwVXOZ8BFJl9w8qME+Pv9p+ssWZ2tg3f35L9bzDtJvaIfnar8+Pm3G5VAwFYsKOcok+JObN08favvoM4EnQ5qwIB0XBMujUF3y4I6WJMtnZt9xYnQ5v0qEWyW383ePjI+xPqLNl3XgV5eMq92U3u5L4tTf+UpTfApJTzFKe3lFItZRKVNh3vZ/hW55IosMwiZSiK6LzZzuMRYl+2uatttXa9yW7qMPCsNe97Rcn+qO+hSl1ZL0VeK0v6elvtf6V2tSj/Wuort1voS/IK/XUgdcDbhTonXcOge3g59+p4r63y2bCy64c+YddYldS9WFTA/m4ONsetR33rXt155iq17R7gryzxGx8YCXAsLuw0uq3tDVflYNzvTrtUbfBRxzDT5uKFyDeSqVGuQz4ecMtXmK/a/Y8rpGiTZUHMNP8br1aE9E7G7TIfktfMc9iTebgCYahuVQr9SKW8K/1xK/hUUC3Fq3YMm9+wPmNe8hUfv3Y/g9Y2I60Fvg16iQJs7otdFIUOnti5vDRqtolb5TDf6J7IEwzv0CZNWejKV8VY6uozwZZ/wRtDtmzuX2L3G25UrIXdUYpFwqfjkn59vP/3rDRPz0jmva3BvWs7MRzq0gPIAIjzYNdf1FwdNvoow1XS9oVeZ/AXLazwjMgn30NQMaoF1N7Zzw7wVMqY+ToRzBTrYlFvmKC1MlTyGjbId0il15zpZaDEGkVFSsKFycjxIOVMQ/tvV+9dmO+oCc7VHpxN1OC2UB04DDb0qRpcOBHo4w69yrFYHhGIsmv0dxsMJ3CKBFG9c5c5QjUyKwJDsgeDYr8LDvFbTd/6Ix5juM0LaHaxL1WfHcri+mSjD/BB3F0WqY+VpwWVSHsbEbBZk6pBOJMCPVnxhiauqDhQ2gOKDI/WIxUzPscfaUZA6ljWx0hkXVznWAz3f83lJOoHqtPGJiSWQYIObwbAmPsFkL9+bXUOfKvBhuBOpAkmfShUl2qgKXJlxbZPUdfiUQtS23k7uwDfxDp6S7qgYJ5CRVRWLW8YJxc3urKIKNFWPZlKSGKovA7v/2W+5CYxNDPNMf2MLiLuFjJDasARDWup5A4bfoDosZaNAlbsNcyxg9FdJiRq3MB851Vm7KfmzviOs6faT2RbhpBLY98JKowhVSgTM+OEd06hlpoyam6rFP7d4uoPlFkzxbzShjurtpyyVvNxXqGv0qUZuV7jspUqXHdVAZRVhJv50SkLqLpCxdlRvkmN/rNJllgX6RhGzNc3adoxSmeF2DqVZuostSMXE8auJpYMz00FuO0zLybWDS9JWQD6HMmI4gyQtBoooDJ1TPPPANJVtDoMRGBp73uTCTnIvzR8Ct0FAZ4lVARK7WBqj1zuRH4flrLAFyscf70148OsXv3Xg75WmeAsCwbgWgtGerCiTBSnrBBMpUNNMcBpF9dyU1Y65FmgTKi4j565vuAHL7xrsAB9rm5Nfv5RgeOlKoJFN79VAKaznK5618iYvqe5aloouI3aCoZLtrSwSphJTD5Ss2RY45gOYCOdecrfcJCFTHoLMZf3H++syaWyICXLtHF5M+d6l4q1T95I261KQpDQItOlNRMOQ4aS4RkQ3xUCVV4Y1PmXHOPlHW7u+7pWhd3UY4vwaM+b2k5O3bu2dAFp870EQ/JMW/3w+xAe3UvP55k4hMU/bRq7bKTVkzGBu33fS7Br5Mi6zV5nzNjK9TrwDoSLb93YvxDjhlAvbi3AgKH+Kr/8ZzMGwBuLoOPOaBkzpnKmKawBAkCccPFzozAmx6+g/bhC9zwdOp7YKumFwzjmUfni9OO0h4OkG1YYthwypxXZKBZbQDYVzzkH1wZt1YQxWam4Di1TNDhzOoUuc4WPWYDu4MQukG10TQm/EjkhYpxGVuLVsJZVJ/0252SHGPxKUSGWAOceNSKMQ41UM3yIR0MpxZ69Ofk+FpqdXyZfaKV+rYrIomEb+m6D4Jw/nqbUNzOgTmXIXR2JIlg01uaKKhLBiWe6klWTFONru9fu0Z05pTq27H7ir17QHm6Zc0Z48AwbmeQBl8JQD81aiRTbDBl0Ntc5LJUGOmSuxbCUbJKlViuk0RuJUaTTO93jWsmHrTTml06leqS94vloVdQSmbfOVqQMmqtRzie1DY7gIZWCMjoxAaVM7z3gqUmXnXCthxmt5vuokNi8Ct2htoJpMHG6t5tRqKlUhG35misotjZRxOpUJg5Oi6mJayZqpbVQBEU3UYAvJRNcbKbSOIDy7EtBWVNuoLjExkWPDsg+KF5yuZy1kK717tTC+3V2N0hvYZ09+w9cNTU2XQMytiVWnXyq5O5zilRHC7M4GmCRmLXx9oMZPPjGLIhxUNi52pgiZXNWm6OvSOloMSCvZ9oEyZQB54m+5t2Oqtu9HKYY/pWJKUfVkepnPfF9w4mO9k0oldAo9OMfw2VIjV84ZGo8LHCV8bXV9ZOoBe3C3fmgI4gbqexeGuCXERnWa4BUpnXX9zsYAP6g0jdAxCJ7nAJ20HRz7xCuL+O5tz8Zk+OZkWOVSq2ryARVbkOTdW2Ktc5AY31+oGN+PE+Nvby9Ujr+9HSdIW/+SUV5uAikMDnKPs8q5n+7o3wmAt4bOM/WjyBy0sjAaOPOJYnEaacpBpKrlJOTFEbw4gj+fI6jgyqb2j3i7zz+1Z22Y7FHWrA1J27SusLdHqe7xCnv0NZ+Nm4UvR1znPOKyN4TU7EA7HCjll+qVkGqw7c5289qHPOLuzB6NGcuuW2aDa9BHyJtLWjE83CPRWgU6uhAaFboZN27dWfTLkeq6di8Lf24KQG2lJda4pDxwpQz4SJJro+3Cg+waEcX0EtqG6eFsH0gt7Wo6lZbtgLrP38ZZfK6Rsgnn5oucSCxCGI9v6kHNb43ZMfNcJr32DWT1LlfOaagYJzHQaWRpN8kcwwAp3XHW2czpKrvl93q8XVmoU4/Gl7bRKF9HnNi2nNaFvFx5jrUvpJ2knaJ5GwR4GwUMNcABvr+RKcttVXACNNiYEa2t5K1kTXljb1jWeQlzZJSaXcZ0BcZYjfcSqJ4sUB0fkMYQz01BTevdykFu1e3uuogMFLzchd+WRi33rbWKV+4q5OvRAsf06+UIvYG8hDMXHcLJJTfT8CKlLuoPzLprleBkJFdFXxw8vG4laRpov7ZXlN1KXtIaZrZKx1epGrqoo92sKIvS0xcVVG8N2eO72p1rM5Dkqjamr/FifStdicvF4GNLQ1rszmwsTjyxw8MuUJuNiMJenFi98DRAkfMYpOd3OhZoTL/6cPYCNp1AutG2xQENKE2jLpXyS6IlwyadebrZuGkSwRba8ndd8UJZkEjsWj8zQOcNQQrzbBvzMne0kUnZl4xuCP+Yfp2UfWFKQ7gLEc9aPnIQdyHicdwXjyyKJoeAREGOQIKLx6QokCCEHgTNf/HhiSFWu0uL/fCqaPZShWt+Yd2XwZm9kWGa15ttoYuRWulNGTup3YXHjUUEZXWGm62msioBZSvh45V18aGmK5ezBldXWvf1h5MEmGr3XCIutXs+MZfaPbuoS+0uaa9R97pmZrdSvGpMf7Mrae4+2mV/iTlfYs6XmPN5xpw+GI+XmmW0ZwwnSzaWBL/UqLGugt6kYyvV8Zq5+BBRrGr66Qr7WgkfFA4+XmbC8fGEGUekvcAXQC7RVVg1GGhI3Twukr/RaP3CYYJeqmvIRYawIbHHR7TSPMZ7Gnt4Dn7CKquup4bD6NPSwfvHXFuX6TTqA9l+z8q7WegUPSu4qL9vM+LwviJy9vht8Ujv7V1HF4kahAFGOiGQAYjMY0+woFz4H8AbbBUTTqAfuOD7GK8l5skWc2yHvdbsU7TmLsoNPsXGdbS/MWHJ1U+//tZuNRFTuvJ6Rpys8JnwTQzxa1/H3OHKwwPHMysP2yXeLPEZs3z0C+X89OtvubgHSGV0fWZ5PuOqaRhPPUYbBpLKYMMCGi0yVS0ua70oV8Dkd3QdbBtS5s9clZxntiC038ScRF1qd5naKnJOg/XWSrKqz8P0xvhz86SMe9xFZea1km3MyPyTYzT1BG6zXVN+h+rV0QHWEVNMIl6WxNiivghMbzKIxP4fIlXtrriV6EHaSegaFiuaRvpgvRx66R3jUep2KjYCd5G2lmy9BmmSv0nXWY+BPtIe/i3k4hnIHdN/C9kjOHn1M37qVfaf+JhEgn2L84auNkOSPZwfYQcARbSYeSlmVUh4aG3ftzKd/UJWbnk6QL+oWbVg/GxqNQzNP7FrhBZ2VhXXKah7D/MAOUSqn0QQkZZ2rseK0vU8x7ldX+uyaKsI0TNIylX2ciTZpGtAjajXWETeNhak1VsetmZIpRbI+WK0VhiJIYb/QnNFevU1Sl4chouR9T4/4T9w9FIOWxZofGXj0kJn4/wDyrFTi+k9FkSUxRAOktRJuYweG0+W9J++VoD+PRLBI7m9eyn4P1XBv7//dacs5tLMxVhsllpvPAmDvmYFEkMzLUxFjgkT8Ibj0hhViJOvlTnpOMEapSYmDlbSgQrAl37sO/ICH70Gidq2XRii6AjB8QaVguK9CdsyB1ezREQs2M9ndYqtbxeNdAQWwD/ek9vSO0YSkogGyN/4mhfvcB7v4BNh2vw5duTO7BTH2z5VOjiBfjCKBqEBXu5sQs2ZeFokrkFFC4fu2dzekeOEra/O1//mpfXVS+url9ZXLa2vpmlmdb4+dlH0MoVfpvBEU/jPMSkdBLszmKs0jmnlXr9mOgLUvfkAuW9+wDtBO2JXS8Lt/fPzu2J34l4Gtg+sbYSqRKkScFFGpnTWPTW7NNyq0Q7ovn1ZAdvAZaqBt8BhBZsISZGVcwobg4WFEUwOBImOQqEigOQUKnGEx6HRAs/ApgeT0R2F5Q8RL9n0I5SRHYUkBDq9SpBoGwpyq79RZAtYYcgj9giRTV0ynT29hieVVJJlatrBYASBzW4CRiOimE5tioRpEtO9PZTyi7ajj+Cpxz9ePEf4Bjc8reomd/hE4Uqk3KRxRITvY5lHCsn/y47O7KNuqh3+SaCfFnZC5eMJZllGdiro5p1p+2IZSCwlwZKS7G2xFrlS/sjFjk8vWC5LqRc23oc1kgb4lAg+YWgy+loy2GJcK/HAziLyw8XbZ7QRo9QW/C/+D7VG5RWhPmIJrEIRSm3iLFscF+oeoZkNC7O719FOZfuxla4TR/t8GbUI5638TaoZwmn4O31Yopihvn1zN5/VmUrKwmPirtr3x0dR+M+ZT1x/GrMDCv79hRbtC7Ok1dzLFadzqqbja8p6mN5bPzEAge/K3xEAsEgcxyK7SlhVgZe/2vNggbAFnw7FR3uJHomTjPg1YRmWX3+4/USolHSPPkRCmPKQck286LBCw9XDzgZOhh5sJddni7AyJh38TxnhG+alQcJ1QDFlHFsXJnMoPhGmkkoM2dDy6GCPxf4QTs/fXvTr5W/mV/PsoCOpXWmUd5BVYxEbYpR0Z1BkS6TyojT+dlrLKZSUES8bDd6JNHV15N3b99/e4AmEg9AFD+cnhKfCJ3gZoomxMXeGQfWeBz1oHVIFsua7/GtTvuIsQdPZINDlHIF9PM5yU2dYtNBT89Iy1RS04BMJGi6MtR3DDanYsG4Az6PZubVwBMt0ebyUKl3eDOeIH1woxgM/z7AJpsHQlD5qGieOYWQOdZAyCTaUr2FObitQcOGzaw8WGdjIEB8szirhsGNlmlTbRJdRw1cIFoEIj9LT/e3/+fh/f8In4kMoHuC3CPGJc9yU2I2OF0XKmc5Kj48fs/J4Id1ms70m1y3wEKtdJSjQx3APwVSYjkHhsvnKy7fumxpcrbdxDqj5Hn8+EDUqPmdUxoXPinU8G9zq2hsIsbnroPeBHWdbzdl9hXY4f1srn0PwU3TMTSvuuSkf7eP6/5k7v95GcSCAv+dTWH26012dJrvX3ctbe9mTKvV01e72mSV4kloFGxnon/v0pzE2IcSACdm0Uh+qAJ6fZ4wZj8d29atL6tYtM3M53obZsgjIn6V6bOVwNY59kLKQqp217grU1iDqRPzUC0e0tLZdnmpUKa6LyE/HZeT1kckiH4vmFNuWb12X21WXYVJNMm1N5qQpMMOYQD7pa6EdLhQuOS5Lscn/ynz4gbqcM+9wAOMK2gelYcz3Vv/j9sjVi0Pbnk/4pjwKbkFyVWzfKCfEOkx4/HogAZKOEY7n9saUN7sKLHZB9n6GlzBJcS5t9uecXtA5nWGQbn5xMVtcLK8/L66uvywXn//4cLlYzBqPdpgX/26Rg9zckZAxnIk0Cft4wO4K8ASHm7unjyjs5u7psrqpKqajbniKoLN2jiZe1W8+PwQfRW0bpJNJQSJzeAcK/6pBjqxxU7uTqNxUwF/nOF3hpHI7cBXYp8vz+Wx2Ppt9Ov9wScUzNVdoJBM6jPnu+1fMWJeKOT/6ytqEkhs8p5TIFQbtgZEnjmctY1y/+bYTNGEs5WOR+qkB8pgFuAA1kAIO0cfB1cdxE6zX2OPqvM70vAwfMqlHAb/A99vlr9YzNrpAo5UbY+Lh2oncz/GLwxXElPwtlUXEIQ4QLO23GboV5GwtJV2Fim5kHIoNlWpDz1C/Z/UfmpUpvXa9jEsqwiAHlXATXC+LJ5HE9Wh6WBMKAskKGANGIpm+2nrgOrBmwfqBhzxPF9NpWqxiHmXFes1fNEd1c5cRUS0BKCXVAAv2NM4vWJwx4cpWszz7trKJboGmuRGzFcdWb05iM7ijKWdO1vZvXPuTgz5xtphIJkkoDoVwBGEOo0hYzAUcz2z6hDZTN7JTdCcHvMCBmsC4QKHXBo3RB+6DTwc3CfdTwwW3htR6RGPabjCgKVihpffanpv0TV8njutjU5PwVDBc0Gr9Z7NsAjsQE44c5UGHLYkHTWKPhnyl27EQ+H2Qe4EFF0QdpHtYjmekb8C1FrgHyoJpHbbTbTlw6xLonNkdzVKJ0M5PNnFh5FF6TLvgCOxw2/SctNOukL6xt4fC/tnd+q8+lLQBn9/JKsTLUtRCM2GMQyN0z/RiUL1OywTU8AeS8f+Akr+kUpClGLPChSrmHKAMdFLPFHvMafaaTQXkU54+fZzmUYrbuJgcju3Z8FKUGRyUtCpx/03rtqqnfvqt22XhOqBU6UPYHAn7WtqTFv+uysXoxkhGLC6mi1Jr2nb9dtagrQ85dgVsf9Kvd79+5SfwIVpXP9PEgww9Ap497E30/QTA7RxgTewgbUaxzCB4Dnl+StoGIfYRwZYkIK4Zjl1unK15F9gViA919iqCDMSbQ1sOX2YF0dN7YEYOH+Y1F9omzVDQyaErkCHUzfjPm1HPfahx+jUIo8e3hrYcPszY15zkC9KNbDBcxJa0YOnE19HpYUIH5365QzHxc27eoft6v3xT97Vg79F9vV8ew309tfPXRt3xj0UtszYmTb6mGjuIfpRF/NjZYtDuzSA2tqmUd5lYAh0VKGAFFi0FTTLfqQH7+thHG5e5SIs8sDclPI65O32gxzIY5v33m60rFztF0UmzIhgHynp1f0Ci2K3cbICdV0dPQ5ZxKZoB5C4dc3a8sCJqZbtnhIFxSs0gzI8n90rUp0ZiueGC7Yvo2J5iZJ2X10VmUjt1zNFHA45J2JEU+LiVXG8NTvHuXJERBFdWnHdqikUpZ20aBZYkKyljCMVQEnxMn9AflT1TaGR0a8ThCo20iD2xbSd9q5MhksduFTVrlB00c0ix8mMIGSjfvtZDupIyJ3d+fUJpo2DglGsPBDaH+rSgmZOuVt82gSaEEEIIIZP/BwAegyLW"
 }
@@ -29,6 +29,7 @@ type Config struct {
 	EnvWhitelist    []string                 `config:"process.env.whitelist"`
 	CacheCmdLine    bool                     `config:"process.cmdline.cache.enabled"`
 	IncludeTop      process.IncludeTopConfig `config:"process.include_top_n"`
+	IncludeOther    bool                     `config:"process.include_top_n.other"`
 	IncludeCPUTicks bool                     `config:"process.include_cpu_ticks"`
 	IncludePerCPU   bool                     `config:"process.include_per_cpu"`
 	CPUTicks        *bool                    `config:"cpu_ticks"` // Deprecated
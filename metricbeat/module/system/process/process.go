@@ -31,6 +31,7 @@ import (
 	"github.com/elastic/elastic-agent-system-metrics/metric/system/cgroup"
 	"github.com/elastic/elastic-agent-system-metrics/metric/system/process"
 	"github.com/elastic/elastic-agent-system-metrics/metric/system/resolve"
+	sysinfo "github.com/elastic/go-sysinfo"
 )
 
 var debugf = logp.NewLogger("system.process").Debugf
@@ -48,6 +49,10 @@ type MetricSet struct {
 	stats  *process.Stats
 	perCPU bool
 	setpid int
+	// top is set when the top N processes are selected by the metricset
+	// instead of the process library, to sum the others in a single event.
+	top    process.IncludeTopConfig
+	hostID string
 }
 
 // New creates and returns a new MetricSet.
@@ -93,6 +98,17 @@ func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
 
 	m.setpid = config.Pid
 
+	if config.IncludeOther && topN(config.IncludeTop) {
+		m.top = config.IncludeTop
+		m.stats.IncludeTop = process.IncludeTopConfig{}
+	}
+
+	if h, err := sysinfo.Host(); err == nil {
+		m.hostID = h.Info().UniqueID
+	} else {
+		debugf("process.entity_id will not be reported, failed to get host info: %v", err)
+	}
+
 	// If hostfs is set, we may not want to force the hierarchy override, as the user could be expecting a custom path.
 	if !sys.IsSet() {
 		override, isset := os.LookupEnv("LIBBEAT_MONITORING_CGROUPS_HIERARCHY_OVERRIDE")
@@ -122,6 +138,15 @@ func (m *MetricSet) Fetch(r mb.ReporterV2) error {
 			err = mb.PartialMetricsError{Err: err}
 		}
 
+		if m.hostID != "" {
+			for _, root := range roots {
+				addEntityID(m.hostID, root)
+			}
+		}
+		if topN(m.top) {
+			procs, roots = includeTopWithOther(m.top, procs, roots)
+		}
+
 		for evtI := range procs {
 			isOpen := r.Event(mb.Event{
 				MetricSetFields: procs[evtI],
@@ -140,6 +165,9 @@ func (m *MetricSet) Fetch(r mb.ReporterV2) error {
 		} else if (err != nil && errors.Is(err, process.NonFatalErr{})) {
 			err = mb.PartialMetricsError{Err: err}
 		}
+		if m.hostID != "" && root != nil {
+			addEntityID(m.hostID, root)
+		}
 		// if error is non-fatal, emit partial metrics.
 		r.Event(mb.Event{
 			MetricSetFields: proc,
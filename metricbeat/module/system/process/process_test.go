@@ -25,10 +25,12 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	mbtest "github.com/elastic/beats/v7/metricbeat/mb/testing"
 	_ "github.com/elastic/beats/v7/metricbeat/module/system"
 	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
 	"github.com/elastic/elastic-agent-system-metrics/metric/system/process"
 )
 
@@ -69,6 +71,95 @@ func TestFetchSinglePid(t *testing.T) {
 	assert.NotEmpty(t, events[0].MetricSetFields["cpu"])
 }
 
+func TestFetchTopWithOther(t *testing.T) {
+	logp.DevelopmentSetup()
+
+	cfg := getConfig()
+	cfg["process.include_top_n"] = map[string]interface{}{"by_cpu": 2, "other": true}
+
+	f := mbtest.NewReportingMetricSetV2Error(t, cfg)
+	events, errs := mbtest.ReportingFetchV2Error(f)
+	for _, err := range errs {
+		assert.ErrorIsf(t, err, process.NonFatalErr{}, "Expected non-fatal error, got %v", err)
+	}
+	require.NotEmpty(t, events)
+	require.LessOrEqual(t, len(events), 3)
+
+	if len(events) == 3 {
+		other := events[2]
+		name, _ := other.RootFields.GetValue("process.name")
+		assert.Equal(t, "other", name)
+		count, err := other.MetricSetFields.GetValue("other.count")
+		require.NoError(t, err)
+		assert.Greater(t, count, 0)
+	}
+}
+
+func TestIncludeTopWithOther(t *testing.T) {
+	proc := func(cpu float64, rss uint64) mapstr.M {
+		return mapstr.M{
+			"cpu":         mapstr.M{"total": mapstr.M{"pct": cpu, "norm": mapstr.M{"pct": cpu / 2}}},
+			"memory":      mapstr.M{"rss": mapstr.M{"bytes": rss, "pct": 0.1}, "size": rss * 2},
+			"num_threads": 1,
+		}
+	}
+	root := func(pid int) mapstr.M {
+		return mapstr.M{"process": mapstr.M{"pid": pid}}
+	}
+	procs := []mapstr.M{proc(0.1, 10), proc(0.9, 20), proc(0.2, 500), proc(0.3, 30)}
+	roots := []mapstr.M{root(1), root(2), root(3), root(4)}
+
+	cfg := process.IncludeTopConfig{Enabled: true, ByCPU: 1, ByMemory: 1}
+	gotProcs, gotRoots := includeTopWithOther(cfg, procs, roots)
+	require.Len(t, gotProcs, 3)
+	require.Len(t, gotRoots, 3)
+
+	assert.Equal(t, roots[1], gotRoots[0])
+	assert.Equal(t, roots[2], gotRoots[1])
+	assert.Equal(t, mapstr.M{
+		"other":       mapstr.M{"count": 2},
+		"cpu":         mapstr.M{"total": mapstr.M{"pct": 0.4, "norm": mapstr.M{"pct": 0.2}}},
+		"memory":      mapstr.M{"rss": mapstr.M{"bytes": uint64(40), "pct": 0.2}, "size": uint64(80)},
+		"num_threads": uint64(2),
+	}, gotProcs[2])
+	assert.Equal(t, mapstr.M{
+		"process": mapstr.M{
+			"name":   "other",
+			"cpu":    mapstr.M{"pct": 0.2},
+			"memory": mapstr.M{"pct": 0.2},
+		},
+	}, gotRoots[2])
+
+	// No rollup event when every process is in the top N.
+	cfg = process.IncludeTopConfig{Enabled: true, ByCPU: 10}
+	gotProcs, _ = includeTopWithOther(cfg, procs, roots)
+	assert.Len(t, gotProcs, 4)
+}
+
+func TestEntityID(t *testing.T) {
+	newRoot := func(pid int, start string) mapstr.M {
+		return mapstr.M{"process": map[string]interface{}{
+			"pid": pid,
+			"cpu": map[string]interface{}{"start_time": start},
+		}}
+	}
+	entityID := func(root mapstr.M) interface{} {
+		addEntityID("host-a", root)
+		id, _ := root.GetValue("process.entity_id")
+		return id
+	}
+
+	const start = "2024-05-06T07:08:09.123Z"
+	id := entityID(newRoot(42, start))
+	require.NotEmpty(t, id)
+	assert.Equal(t, id, entityID(newRoot(42, start)), "identity must be stable")
+	assert.NotEqual(t, id, entityID(newRoot(42, "2024-05-06T07:08:10.123Z")), "reused pid must get a new identity")
+	assert.NotEqual(t, id, entityID(newRoot(43, start)))
+
+	root := mapstr.M{"process": map[string]interface{}{"pid": 42}}
+	assert.Nil(t, entityID(root), "no identity without a start time")
+}
+
 func TestData(t *testing.T) {
 	f := mbtest.NewReportingMetricSetV2Error(t, getConfig())
 
@@ -0,0 +1,226 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build darwin || freebsd || linux || windows || aix
+
+package process
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"sort"
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-system-metrics/metric/system/process"
+)
+
+// otherProcessName is the process.name of the event that sums the metrics of
+// the processes left out by process.include_top_n.
+const otherProcessName = "other"
+
+// topN reports whether cfg selects a subset of the processes.
+func topN(cfg process.IncludeTopConfig) bool {
+	return cfg.Enabled && (cfg.ByCPU > 0 || cfg.ByMemory > 0)
+}
+
+// includeTopWithOther keeps the top processes by CPU and by memory, in the
+// same way as the include_top_n option of the process library, and sums the
+// metrics of the remaining processes into a single "other" event. The
+// returned slices keep the order of the original events.
+func includeTopWithOther(cfg process.IncludeTopConfig, procs, roots []mapstr.M) ([]mapstr.M, []mapstr.M) {
+	keep := make([]bool, len(procs))
+	selectTop := func(n int, field string) {
+		if n <= 0 {
+			return
+		}
+		idx := make([]int, len(procs))
+		for i := range idx {
+			idx[i] = i
+		}
+		sort.SliceStable(idx, func(i, j int) bool {
+			return numericValue(procs[idx[i]], field) > numericValue(procs[idx[j]], field)
+		})
+		if n > len(idx) {
+			n = len(idx)
+		}
+		for _, i := range idx[:n] {
+			keep[i] = true
+		}
+	}
+	selectTop(cfg.ByCPU, "cpu.total.pct")
+	selectTop(cfg.ByMemory, "memory.rss.bytes")
+
+	var (
+		topProcs = make([]mapstr.M, 0, len(procs))
+		topRoots = make([]mapstr.M, 0, len(procs))
+		other    = otherRollup{}
+	)
+	for i := range procs {
+		if keep[i] {
+			topProcs = append(topProcs, procs[i])
+			topRoots = append(topRoots, roots[i])
+			continue
+		}
+		other.add(procs[i])
+	}
+	if other.count == 0 {
+		return topProcs, topRoots
+	}
+	return append(topProcs, other.fields()), append(topRoots, other.rootFields())
+}
+
+// otherRollupFields are the metrics summed in the "other" event.
+var otherRollupFields = [...]string{
+	"cpu.total.pct",
+	"cpu.total.norm.pct",
+	"cpu.total.value",
+	"memory.rss.bytes",
+	"memory.rss.pct",
+	"memory.size",
+	"num_threads",
+}
+
+// otherRollup accumulates the metrics of processes that are not reported
+// individually.
+type otherRollup struct {
+	count int
+	sums  [len(otherRollupFields)]float64
+	set   [len(otherRollupFields)]bool
+}
+
+func (o *otherRollup) add(proc mapstr.M) {
+	o.count++
+	for i, field := range otherRollupFields {
+		if v, ok := toFloat(proc, field); ok {
+			o.sums[i] += v
+			o.set[i] = true
+		}
+	}
+}
+
+func (o *otherRollup) fields() mapstr.M {
+	event := mapstr.M{
+		"other": mapstr.M{"count": o.count},
+	}
+	for i, field := range otherRollupFields {
+		if !o.set[i] {
+			continue
+		}
+		v := o.sums[i]
+		switch field {
+		case "memory.rss.bytes", "memory.size", "num_threads":
+			_, _ = event.Put(field, uint64(v))
+		case "cpu.total.pct", "cpu.total.norm.pct", "memory.rss.pct":
+			_, _ = event.Put(field, round(v))
+		default:
+			_, _ = event.Put(field, v)
+		}
+	}
+	return event
+}
+
+func (o *otherRollup) rootFields() mapstr.M {
+	root := mapstr.M{
+		"process": mapstr.M{"name": otherProcessName},
+	}
+	if v, ok := o.sum("cpu.total.norm.pct"); ok {
+		_, _ = root.Put("process.cpu.pct", round(v))
+	}
+	if v, ok := o.sum("memory.rss.pct"); ok {
+		_, _ = root.Put("process.memory.pct", round(v))
+	}
+	return root
+}
+
+func (o *otherRollup) sum(field string) (float64, bool) {
+	for i, f := range otherRollupFields {
+		if f == field {
+			return o.sums[i], o.set[i]
+		}
+	}
+	return 0, false
+}
+
+// round rounds percentages to four decimal places, like the values reported
+// for single processes.
+func round(v float64) float64 {
+	return float64(int64(v*10000+0.5)) / 10000
+}
+
+func numericValue(m mapstr.M, key string) float64 {
+	v, _ := toFloat(m, key)
+	return v
+}
+
+func toFloat(m mapstr.M, key string) (float64, bool) {
+	v, err := m.GetValue(key)
+	if err != nil {
+		return 0, false
+	}
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// entityID returns a stable identifier for the process with the given pid and
+// start time on the host with the given unique ID. The pid alone is not enough
+// to identify a process over time since pids are reused.
+func entityID(hostID string, pid int, start time.Time) string {
+	h := sha256.New()
+	h.Write([]byte(hostID))
+	_ = binary.Write(h, binary.LittleEndian, int64(pid))
+	_ = binary.Write(h, binary.LittleEndian, start.UnixNano())
+	return base64.RawStdEncoding.EncodeToString(h.Sum(nil)[:12])
+}
+
+// addEntityID sets process.entity_id on a root event that has a pid and a
+// start time.
+func addEntityID(hostID string, root mapstr.M) {
+	pid, ok := toFloat(root, "process.pid")
+	if !ok {
+		return
+	}
+	v, err := root.GetValue("process.cpu.start_time")
+	if err != nil {
+		return
+	}
+	s, ok := v.(string)
+	if !ok {
+		return
+	}
+	start, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return
+	}
+	_, _ = root.Put("process.entity_id", entityID(hostID, int(pid), start))
+}
@@ -20,13 +20,14 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
 
-	"github.com/coreos/go-systemd/v22/dbus"
 	"github.com/mitchellh/mapstructure"
 
 	"github.com/elastic/beats/v7/libbeat/common/cfgwarn"
+	"github.com/elastic/beats/v7/metricbeat/helper/systemd"
 	"github.com/elastic/beats/v7/metricbeat/mb"
 )
 
@@ -50,9 +51,8 @@ func init() {
 // interface methods except for Fetch.
 type MetricSet struct {
 	mb.BaseMetricSet
-	conn     *dbus.Conn
-	cfg      Config
-	unitList unitFetcher
+	conn *systemd.Conn
+	cfg  Config
 }
 
 // New creates a new instance of the MetricSet. New is responsible for unpacking
@@ -65,21 +65,15 @@ func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
 		return nil, err
 	}
 
-	conn, err := dbus.New()
+	conn, err := systemd.NewConn(context.Background())
 	if err != nil {
-		return nil, fmt.Errorf("error connecting to dbus: %w", err)
-	}
-
-	unitFunction, err := instrospectForUnitMethods()
-	if err != nil {
-		return nil, fmt.Errorf("error finding ListUnits Method: %w", err)
+		return nil, err
 	}
 
 	return &MetricSet{
 		BaseMetricSet: base,
 		conn:          conn,
 		cfg:           config,
-		unitList:      unitFunction,
 	}, nil
 }
 
@@ -88,7 +82,7 @@ func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
 // of an error set the Error field of mb.Event or simply call report.Error().
 func (m *MetricSet) Fetch(report mb.ReporterV2) error {
 
-	units, err := m.unitList(m.conn, m.cfg.StateFilter, m.cfg.PatternFilter)
+	units, err := m.conn.ListUnits(context.Background(), m.cfg.StateFilter, m.cfg.PatternFilter)
 	if err != nil {
 		return fmt.Errorf("error getting list of running units: %w", err)
 	}
@@ -130,9 +124,15 @@ func (m *MetricSet) Fetch(report mb.ReporterV2) error {
 	return nil
 }
 
+// Close closes the connection to dbus.
+func (m *MetricSet) Close() error {
+	m.conn.Close()
+	return nil
+}
+
 // Get Properties for a given unit, cast to a struct
-func getProps(conn *dbus.Conn, unit string) (Properties, error) {
-	rawProps, err := conn.GetAllProperties(unit)
+func getProps(conn *systemd.Conn, unit string) (Properties, error) {
+	rawProps, err := conn.GetAllProperties(context.Background(), unit)
 	if err != nil {
 		return Properties{}, fmt.Errorf("error getting list of running units: %w", err)
 	}
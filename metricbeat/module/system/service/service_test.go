@@ -30,20 +30,6 @@ import (
 	"github.com/elastic/elastic-agent-libs/mapstr"
 )
 
-var exampleUnits = []dbus.UnitStatus{
-	dbus.UnitStatus{
-		Name:      "sshd.service",
-		LoadState: "active",
-	},
-	dbus.UnitStatus{
-		Name:      "metricbeat.service",
-		LoadState: "active",
-	},
-	dbus.UnitStatus{
-		Name: "filebeat.service",
-	},
-}
-
 func TestFormProps(t *testing.T) {
 	testUnit := dbus.UnitStatus{
 		Name:        "test.service",
@@ -87,43 +73,3 @@ func TestFormProps(t *testing.T) {
 	assert.Equal(t, event.MetricSetFields["state_since"], testEvent["state_since"])
 	assert.NotEmpty(t, event.RootFields)
 }
-
-func TestFilterEmpty(t *testing.T) {
-
-	filtersBad := []string{
-		"asdf",
-	}
-	shouldNotMatch, err := matchUnitPatterns(filtersBad, exampleUnits)
-	assert.NoError(t, err)
-	assert.Empty(t, shouldNotMatch)
-}
-
-func TestFilterMatches(t *testing.T) {
-	filtersMatch := []string{
-		"ssh*",
-	}
-
-	shouldMatch, err := matchUnitPatterns(filtersMatch, exampleUnits)
-	assert.NoError(t, err)
-	assert.Len(t, shouldMatch, 1)
-}
-
-func TestNoFilter(t *testing.T) {
-	shouldReturnResults, err := matchUnitPatterns([]string{}, exampleUnits)
-	assert.NoError(t, err)
-	assert.Len(t, shouldReturnResults, 3)
-}
-
-func TestUnitStateFilter(t *testing.T) {
-	stateFilter := []string{
-		"active",
-	}
-	shouldReturnResults := matchUnitState(stateFilter, exampleUnits)
-	assert.Len(t, shouldReturnResults, 2)
-
-}
-
-func TestUnitStateNoFilter(t *testing.T) {
-	shouldReturnResults := matchUnitState([]string{}, exampleUnits)
-	assert.Len(t, shouldReturnResults, 3)
-}
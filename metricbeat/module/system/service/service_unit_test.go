@@ -27,6 +27,8 @@ import (
 
 	"github.com/coreos/go-systemd/v22/dbus"
 	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/metricbeat/helper/systemd"
 )
 
 func TestDbusEnvConnection(t *testing.T) {
@@ -42,7 +44,7 @@ func TestDbusEnvConnection(t *testing.T) {
 
 	// call internal dbus functions
 	// This calls a lower-level bus library
-	conn, err := instrospectForUnitMethods()
+	conn, err := systemd.IntrospectForUnitMethods()
 	require.NoError(t, err)
 	require.NotNil(t, conn)
 
@@ -0,0 +1,132 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build linux
+
+package units
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/coreos/go-systemd/v22/dbus"
+
+	"github.com/elastic/beats/v7/metricbeat/mb"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// Properties is the subset of unit properties reported by the metricset.
+type Properties struct {
+	FragmentPath string
+	// timestamps
+	ActiveEnterTimestamp   uint64
+	InactiveEnterTimestamp uint64
+	InactiveExitTimestamp  uint64
+	ActiveExitTimestamp    uint64
+	// service
+	NRestarts uint32
+	Result    string
+	// accounting
+	CPUAccounting    bool
+	MemoryAccounting bool
+	TasksAccounting  bool
+	// metrics
+	CPUUsageNSec  uint64
+	MemoryCurrent uint64
+	TasksCurrent  uint64
+}
+
+// unitEvent builds the event for a unit and its properties.
+func unitEvent(unit dbus.UnitStatus, props Properties) mb.Event {
+	unitType := ""
+	if idx := strings.LastIndexByte(unit.Name, '.'); idx >= 0 {
+		unitType = unit.Name[idx+1:]
+	}
+
+	fields := mapstr.M{
+		"name":         unit.Name,
+		"type":         unitType,
+		"load_state":   unit.LoadState,
+		"active_state": unit.ActiveState,
+		"sub_state":    unit.SubState,
+	}
+
+	if ts := stateSince(props, unit.ActiveState); ts > 0 {
+		fields["state_since"] = time.UnixMicro(int64(ts)).UTC()
+	}
+
+	if unitType == "service" {
+		fields["restarts"] = props.NRestarts
+		if props.Result != "" {
+			fields["result"] = props.Result
+		}
+	}
+
+	// Accounting values are only meaningful while the unit has processes.
+	if unit.ActiveState == "active" && unit.SubState != "exited" {
+		if resources := resourceUsage(props); len(resources) > 0 {
+			fields["resources"] = resources
+		}
+	}
+
+	return mb.Event{
+		MetricSetFields: fields,
+		RootFields: mapstr.M{
+			"systemd": mapstr.M{
+				"unit":          unit.Name,
+				"fragment_path": props.FragmentPath,
+			},
+		},
+	}
+}
+
+// resourceUsage returns the cgroup accounting metrics that are enabled and
+// set for the unit. systemd reports unset values as the maximum uint64.
+func resourceUsage(props Properties) mapstr.M {
+	metrics := mapstr.M{}
+	if props.CPUAccounting && props.CPUUsageNSec != math.MaxUint64 {
+		metrics.Put("cpu.usage.ns", props.CPUUsageNSec)
+	}
+	if props.MemoryAccounting && props.MemoryCurrent != math.MaxUint64 {
+		metrics.Put("memory.usage.bytes", props.MemoryCurrent)
+	}
+	if props.TasksAccounting && props.TasksCurrent != math.MaxUint64 {
+		metrics.Put("tasks.count", props.TasksCurrent)
+	}
+	return metrics
+}
+
+// stateSince returns the timestamp, in microseconds, of the last change to
+// the given active state, as reported by `systemctl status`.
+func stateSince(props Properties, state string) uint64 {
+	var ts uint64
+	switch state {
+	case "reloading", "active":
+		ts = props.ActiveEnterTimestamp
+	case "failed", "inactive":
+		ts = props.InactiveEnterTimestamp
+	case "activating":
+		ts = props.InactiveExitTimestamp
+	default:
+		ts = props.ActiveExitTimestamp
+	}
+	if ts == math.MaxUint64 {
+		return 0
+	}
+	return ts
+}
@@ -0,0 +1,110 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build linux
+
+package units
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-systemd/v22/dbus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func TestUnitEvent(t *testing.T) {
+	unit := dbus.UnitStatus{
+		Name:        "test.service",
+		LoadState:   "loaded",
+		ActiveState: "active",
+		SubState:    "running",
+	}
+	props := Properties{
+		FragmentPath:         "/lib/systemd/system/test.service",
+		ActiveEnterTimestamp: 1571850129000000,
+		NRestarts:            3,
+		Result:               "success",
+		CPUAccounting:        true,
+		CPUUsageNSec:         2000,
+		MemoryAccounting:     true,
+		MemoryCurrent:        math.MaxUint64,
+		TasksAccounting:      false,
+		TasksCurrent:         5,
+	}
+
+	event := unitEvent(unit, props)
+
+	assert.Equal(t, mapstr.M{
+		"name":         "test.service",
+		"type":         "service",
+		"load_state":   "loaded",
+		"active_state": "active",
+		"sub_state":    "running",
+		"state_since":  time.UnixMicro(1571850129000000).UTC(),
+		"restarts":     uint32(3),
+		"result":       "success",
+		"resources": mapstr.M{
+			"cpu": mapstr.M{"usage": mapstr.M{"ns": uint64(2000)}},
+		},
+	}, event.MetricSetFields)
+	assert.Equal(t, mapstr.M{
+		"systemd": mapstr.M{
+			"unit":          "test.service",
+			"fragment_path": "/lib/systemd/system/test.service",
+		},
+	}, event.RootFields)
+}
+
+func TestUnitEventInactiveSocket(t *testing.T) {
+	unit := dbus.UnitStatus{
+		Name:        "test.socket",
+		LoadState:   "loaded",
+		ActiveState: "failed",
+		SubState:    "failed",
+	}
+	props := Properties{
+		InactiveEnterTimestamp: math.MaxUint64,
+		CPUAccounting:          true,
+		CPUUsageNSec:           2000,
+	}
+
+	event := unitEvent(unit, props)
+
+	assert.Equal(t, mapstr.M{
+		"name":         "test.socket",
+		"type":         "socket",
+		"load_state":   "loaded",
+		"active_state": "failed",
+		"sub_state":    "failed",
+	}, event.MetricSetFields)
+}
+
+func TestUnitTypeInterface(t *testing.T) {
+	for unit, expected := range map[string]string{
+		"sshd.service":      "Service",
+		"dbus.socket":       "Socket",
+		"user.slice":        "Slice",
+		"multi-user.target": "",
+		"nodot":             "",
+	} {
+		assert.Equal(t, expected, unitTypeInterface(unit), unit)
+	}
+}
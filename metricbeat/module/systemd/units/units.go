@@ -0,0 +1,159 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build linux
+
+package units
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/elastic/beats/v7/libbeat/common/cfgwarn"
+	"github.com/elastic/beats/v7/metricbeat/helper/systemd"
+	"github.com/elastic/beats/v7/metricbeat/mb"
+)
+
+// Config stores the configuration of the metricset.
+type Config struct {
+	Patterns []string `config:"units.patterns"`
+	States   []string `config:"units.states"`
+}
+
+var defaultConfig = Config{
+	Patterns: []string{"*.service"},
+}
+
+// init registers the MetricSet with the central registry as soon as the program
+// starts. The New function will be called later to instantiate an instance of
+// the MetricSet for each host defined in the module's configuration. After the
+// MetricSet has been created then Fetch will begin to be called periodically.
+func init() {
+	mb.Registry.MustAddMetricSet("systemd", "units", New)
+}
+
+// MetricSet holds any configuration or state information. It must implement
+// the mb.MetricSet interface. And this is best achieved by embedding
+// mb.BaseMetricSet because it implements all of the required mb.MetricSet
+// interface methods except for Fetch.
+type MetricSet struct {
+	mb.BaseMetricSet
+	conn *systemd.Conn
+	cfg  Config
+}
+
+// New creates a new instance of the MetricSet. New is responsible for unpacking
+// any MetricSet specific configuration options if there are any.
+func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
+	cfgwarn.Beta("The systemd units metricset is beta.")
+
+	config := defaultConfig
+	if err := base.Module().UnpackConfig(&config); err != nil {
+		return nil, err
+	}
+
+	// Older versions of systemd don't have ListUnitsByPatterns, the
+	// connection falls back to filtering the units itself.
+	conn, err := systemd.NewConn(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return &MetricSet{
+		BaseMetricSet: base,
+		conn:          conn,
+		cfg:           config,
+	}, nil
+}
+
+// Fetch reports one event per systemd unit matching the configured
+// patterns and states.
+func (m *MetricSet) Fetch(ctx context.Context, report mb.ReporterV2) error {
+	units, err := m.conn.ListUnits(ctx, m.cfg.States, m.cfg.Patterns)
+	if err != nil {
+		return fmt.Errorf("error listing systemd units: %w", err)
+	}
+
+	for _, unit := range units {
+		// Units that are referenced by others but that don't exist.
+		if unit.LoadState == "not-found" {
+			continue
+		}
+
+		props, err := m.getProps(ctx, unit.Name)
+		if err != nil {
+			m.Logger().Errorf("Error getting properties for systemd unit %s: %v", unit.Name, err)
+			continue
+		}
+
+		if !report.Event(unitEvent(unit, props)) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Close closes the connection to dbus.
+func (m *MetricSet) Close() error {
+	m.conn.Close()
+	return nil
+}
+
+func (m *MetricSet) getProps(ctx context.Context, unit string) (Properties, error) {
+	var props Properties
+
+	raw, err := m.conn.GetUnitProperties(ctx, unit)
+	if err != nil {
+		return props, fmt.Errorf("error getting unit properties: %w", err)
+	}
+
+	// Type specific properties like NRestarts or the cgroup accounting are
+	// only exposed by the interface of the unit type.
+	if unitType := unitTypeInterface(unit); unitType != "" {
+		typeProps, err := m.conn.GetUnitTypeProperties(ctx, unit, unitType)
+		if err != nil {
+			return props, fmt.Errorf("error getting %s properties: %w", unitType, err)
+		}
+		for k, v := range typeProps {
+			raw[k] = v
+		}
+	}
+
+	if err := mapstructure.Decode(raw, &props); err != nil {
+		return props, fmt.Errorf("error decoding properties: %w", err)
+	}
+	return props, nil
+}
+
+// unitTypeInterface returns the name of the dbus interface with the type
+// specific properties of the unit, or an empty string for unit types
+// without resource accounting.
+func unitTypeInterface(unit string) string {
+	idx := strings.LastIndexByte(unit, '.')
+	if idx < 0 {
+		return ""
+	}
+	switch unitType := unit[idx+1:]; unitType {
+	case "service", "socket", "mount", "swap", "slice", "scope":
+		return strings.ToUpper(unitType[:1]) + unitType[1:]
+	default:
+		return ""
+	}
+}
@@ -26,10 +26,8 @@ import (
 	"github.com/elastic/beats/v7/metricbeat/mb"
 	"github.com/elastic/beats/v7/metricbeat/module/vsphere"
 
-	"github.com/vmware/govmomi"
 	"github.com/vmware/govmomi/performance"
 	"github.com/vmware/govmomi/property"
-	"github.com/vmware/govmomi/view"
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
 )
@@ -91,38 +89,13 @@ func (m *DataStoreMetricSet) Fetch(ctx context.Context, reporter mb.ReporterV2)
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	client, err := govmomi.NewClient(ctx, m.HostURL, m.Insecure)
-	if err != nil {
-		return fmt.Errorf("error in NewClient: %w", err)
-	}
-	defer func() {
-		if err := client.Logout(ctx); err != nil {
-			m.Logger().Errorf("error trying to logout from vSphere: %v", err)
-		}
-	}()
-
-	c := client.Client
-
-	// Create a view of Datastore objects
-	mgr := view.NewManager(c)
-
-	v, err := mgr.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"Datastore"}, true)
-	if err != nil {
-		return fmt.Errorf("error in creating container view: %w", err)
-	}
-
-	defer func() {
-		if err := v.Destroy(ctx); err != nil {
-			m.Logger().Debugf("error trying to destroy view from vSphere: %v", err)
-		}
-	}()
-
 	// Retrieve summary property for all datastores
 	var dst []mo.Datastore
-	err = v.Retrieve(ctx, []string{"Datastore"}, []string{"summary", "host", "vm", "overallStatus", "triggeredAlarmState"}, &dst)
+	c, release, err := m.Inventory(ctx, "Datastore", []string{"summary", "host", "vm", "overallStatus", "triggeredAlarmState"}, &dst)
 	if err != nil {
-		return fmt.Errorf("error in Retrieve: %w", err)
+		return err
 	}
+	defer release()
 
 	// Create a performance manager
 	perfManager := performance.NewManager(c)
@@ -133,7 +106,7 @@ func (m *DataStoreMetricSet) Fetch(ctx context.Context, reporter mb.ReporterV2)
 		return fmt.Errorf("failed to retrieve metrics: %w", err)
 	}
 
-	pc := property.DefaultCollector(client.Client)
+	pc := property.DefaultCollector(c)
 	for i := range dst {
 		if ctx.Err() != nil {
 			return ctx.Err()
@@ -26,10 +26,8 @@ import (
 	"github.com/elastic/beats/v7/metricbeat/mb"
 	"github.com/elastic/beats/v7/metricbeat/module/vsphere"
 
-	"github.com/vmware/govmomi"
 	"github.com/vmware/govmomi/performance"
 	"github.com/vmware/govmomi/property"
-	"github.com/vmware/govmomi/view"
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
 )
@@ -104,39 +102,13 @@ func (m *HostMetricSet) Fetch(ctx context.Context, reporter mb.ReporterV2) error
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	client, err := govmomi.NewClient(ctx, m.HostURL, m.Insecure)
-	if err != nil {
-		return fmt.Errorf("error in NewClient: %w", err)
-	}
-
-	defer func() {
-		if err := client.Logout(ctx); err != nil {
-			m.Logger().Errorf("error trying to logout from vSphere: %v", err)
-		}
-	}()
-
-	c := client.Client
-
-	// Create a view of HostSystem objects.
-	mgr := view.NewManager(c)
-
-	v, err := mgr.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"HostSystem"}, true)
-	if err != nil {
-		return fmt.Errorf("error in CreateContainerView: %w", err)
-	}
-
-	defer func() {
-		if err := v.Destroy(ctx); err != nil {
-			m.Logger().Errorf("error trying to destroy view from vSphere: %v", err)
-		}
-	}()
-
 	// Retrieve summary property for all hosts.
 	var hst []mo.HostSystem
-	err = v.Retrieve(ctx, []string{"HostSystem"}, []string{"summary", "network", "name", "vm", "datastore", "triggeredAlarmState"}, &hst)
+	c, release, err := m.Inventory(ctx, "HostSystem", []string{"summary", "network", "name", "vm", "datastore", "triggeredAlarmState"}, &hst)
 	if err != nil {
-		return fmt.Errorf("error in Retrieve: %w", err)
+		return err
 	}
+	defer release()
 
 	// Create a performance manager
 	perfManager := performance.NewManager(c)
@@ -0,0 +1,363 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// inventory keeps a local copy of the requested properties of every managed
+// object of a given kind. It owns a vSphere session and a PropertyCollector
+// with a filter over a container view of the whole inventory, so that after
+// the first call to update only the properties that changed since the
+// previous call are transferred.
+type inventory struct {
+	kind  string
+	props []string
+
+	client  *govmomi.Client
+	view    *view.ContainerView
+	pc      *property.Collector
+	version string
+
+	objects map[types.ManagedObjectReference]mo.Reference
+}
+
+func newInventory(ctx context.Context, u *url.URL, insecure bool, kind string, props []string) (*inventory, error) {
+	client, err := govmomi.NewClient(ctx, u, insecure)
+	if err != nil {
+		return nil, fmt.Errorf("error in NewClient: %w", err)
+	}
+
+	inv := &inventory{
+		kind:    kind,
+		props:   props,
+		client:  client,
+		objects: map[types.ManagedObjectReference]mo.Reference{},
+	}
+
+	c := client.Client
+	inv.view, err = view.NewManager(c).CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{kind}, true)
+	if err != nil {
+		_ = inv.close(ctx)
+		return nil, fmt.Errorf("error in CreateContainerView: %w", err)
+	}
+
+	inv.pc, err = property.DefaultCollector(c).Create(ctx)
+	if err != nil {
+		_ = inv.close(ctx)
+		return nil, fmt.Errorf("error creating property collector: %w", err)
+	}
+
+	_, err = inv.pc.CreateFilter(ctx, types.CreateFilter{
+		Spec: types.PropertyFilterSpec{
+			ObjectSet: []types.ObjectSpec{{
+				Obj:  inv.view.Reference(),
+				Skip: types.NewBool(true),
+				SelectSet: []types.BaseSelectionSpec{
+					&types.TraversalSpec{
+						Type: inv.view.Reference().Type,
+						Path: "view",
+					},
+				},
+			}},
+			PropSet: []types.PropertySpec{{
+				Type:    kind,
+				PathSet: props,
+			}},
+		},
+	})
+	if err != nil {
+		_ = inv.close(ctx)
+		return nil, fmt.Errorf("error creating property filter: %w", err)
+	}
+
+	return inv, nil
+}
+
+// update applies all pending changes reported by the property collector to
+// the local copy. It does not block waiting for new changes.
+func (inv *inventory) update(ctx context.Context) error {
+	var stale []types.ManagedObjectReference
+	for {
+		res, err := methods.WaitForUpdatesEx(ctx, inv.client.Client, &types.WaitForUpdatesEx{
+			This:    inv.pc.Reference(),
+			Version: inv.version,
+			Options: &types.WaitOptions{MaxWaitSeconds: types.NewInt32(0)},
+		})
+		if err != nil {
+			return fmt.Errorf("error waiting for property updates: %w", err)
+		}
+
+		set := res.Returnval
+		if set == nil {
+			break
+		}
+		inv.version = set.Version
+
+		for _, fs := range set.FilterSet {
+			stale = append(stale, inv.apply(fs.ObjectSet)...)
+		}
+
+		if set.Truncated == nil || !*set.Truncated {
+			break
+		}
+	}
+
+	if len(stale) == 0 {
+		return nil
+	}
+	return inv.reload(ctx, stale)
+}
+
+// apply applies a set of object updates and returns the objects whose
+// changes could not be applied in place and have to be retrieved again.
+func (inv *inventory) apply(updates []types.ObjectUpdate) []types.ManagedObjectReference {
+	var stale []types.ManagedObjectReference
+	for _, u := range updates {
+		switch u.Kind {
+		case types.ObjectUpdateKindLeave:
+			delete(inv.objects, u.Obj)
+		case types.ObjectUpdateKindEnter, types.ObjectUpdateKindModify:
+			obj, ok := inv.objects[u.Obj]
+			if !ok {
+				obj, ok = newObject(u.Obj)
+				if !ok {
+					continue
+				}
+				inv.objects[u.Obj] = obj
+			}
+			if !applyChanges(obj, u.ChangeSet) {
+				stale = append(stale, u.Obj)
+			}
+		}
+	}
+	return stale
+}
+
+// reload retrieves again all the properties of the given objects.
+func (inv *inventory) reload(ctx context.Context, refs []types.ManagedObjectReference) error {
+	var content []types.ObjectContent
+	if err := inv.pc.Retrieve(ctx, refs, inv.props, &content); err != nil {
+		return fmt.Errorf("error retrieving %s properties: %w", inv.kind, err)
+	}
+
+	for _, oc := range content {
+		obj, err := mo.ObjectContentToType(oc, true)
+		if err != nil {
+			return fmt.Errorf("error loading %s properties: %w", inv.kind, err)
+		}
+		if ref, ok := obj.(mo.Reference); ok {
+			inv.objects[oc.Obj] = ref
+		}
+	}
+	return nil
+}
+
+// load stores a copy of all the known objects into dst, which must be a
+// pointer to a slice of the managed object type of the inventory kind.
+func (inv *inventory) load(dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("expected pointer to slice, got %T", dst)
+	}
+	slice := rv.Elem()
+	elemType := slice.Type().Elem()
+
+	refs := make([]types.ManagedObjectReference, 0, len(inv.objects))
+	for ref := range inv.objects {
+		refs = append(refs, ref)
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].Value < refs[j].Value })
+
+	out := reflect.MakeSlice(slice.Type(), 0, len(refs))
+	for _, ref := range refs {
+		v := reflect.ValueOf(inv.objects[ref]).Elem()
+		if v.Type() != elemType {
+			return fmt.Errorf("cannot load %s into %s", v.Type(), elemType)
+		}
+		out = reflect.Append(out, v)
+	}
+	slice.Set(out)
+	return nil
+}
+
+func (inv *inventory) close(ctx context.Context) error {
+	var errs []string
+	if inv.pc != nil {
+		if err := inv.pc.Destroy(ctx); err != nil {
+			errs = append(errs, fmt.Sprintf("destroying property collector: %v", err))
+		}
+	}
+	if inv.view != nil {
+		if err := inv.view.Destroy(ctx); err != nil {
+			errs = append(errs, fmt.Sprintf("destroying view: %v", err))
+		}
+	}
+	if err := inv.client.Logout(ctx); err != nil {
+		errs = append(errs, fmt.Sprintf("logging out: %v", err))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("error closing vSphere session: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// newObject returns a pointer to an empty managed object of the type
+// referenced by ref.
+func newObject(ref types.ManagedObjectReference) (mo.Reference, bool) {
+	obj, err := mo.ObjectContentToType(types.ObjectContent{Obj: ref}, true)
+	if err != nil {
+		return nil, false
+	}
+	r, ok := obj.(mo.Reference)
+	return r, ok
+}
+
+// applyChanges applies the property changes to obj. It returns false if
+// some change could not be applied, for example additions or removals of
+// single elements of array properties, which are not supported by
+// mo.ApplyPropertyChange.
+func applyChanges(obj mo.Reference, changes []types.PropertyChange) (ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			ok = false
+		}
+	}()
+
+	for _, c := range changes {
+		if strings.Contains(c.Name, "[") {
+			return false
+		}
+	}
+	mo.ApplyPropertyChange(obj, changes)
+	return true
+}
+
+// Inventory retrieves the given properties of every managed object of the
+// given kind into dst and returns a client that can be used for further
+// requests during the current fetch. The returned release function must be
+// called when the client is no longer needed.
+//
+// When incremental updates are enabled the session and a PropertyCollector
+// filter are kept between calls, and only the properties that changed since
+// the previous call are requested from vCenter. Otherwise a new session is
+// created and all the properties are retrieved on every call.
+func (m *MetricSet) Inventory(ctx context.Context, kind string, props []string, dst interface{}) (*vim25.Client, func(), error) {
+	if !m.IncrementalUpdates {
+		return m.retrieveAll(ctx, kind, props, dst)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	err := m.updateInventory(ctx, kind, props)
+	if err != nil && m.inventory != nil {
+		// The session may have expired, try again once with a new one.
+		m.Logger().Debugf("Recreating vSphere session after failed update: %v", err)
+		m.closeInventory(ctx)
+		err = m.updateInventory(ctx, kind, props)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := m.inventory.load(dst); err != nil {
+		return nil, nil, err
+	}
+	return m.inventory.client.Client, func() {}, nil
+}
+
+func (m *MetricSet) updateInventory(ctx context.Context, kind string, props []string) error {
+	if m.inventory == nil {
+		inv, err := newInventory(ctx, m.HostURL, m.Insecure, kind, props)
+		if err != nil {
+			return err
+		}
+		m.inventory = inv
+	}
+	return m.inventory.update(ctx)
+}
+
+func (m *MetricSet) closeInventory(ctx context.Context) {
+	if m.inventory == nil {
+		return
+	}
+	if err := m.inventory.close(ctx); err != nil {
+		m.Logger().Debug(err)
+	}
+	m.inventory = nil
+}
+
+func (m *MetricSet) retrieveAll(ctx context.Context, kind string, props []string, dst interface{}) (*vim25.Client, func(), error) {
+	client, err := govmomi.NewClient(ctx, m.HostURL, m.Insecure)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error in NewClient: %w", err)
+	}
+
+	logout := func() {
+		if err := client.Logout(ctx); err != nil {
+			m.Logger().Errorf("error trying to logout from vSphere: %v", err)
+		}
+	}
+
+	c := client.Client
+	v, err := view.NewManager(c).CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{kind}, true)
+	if err != nil {
+		logout()
+		return nil, nil, fmt.Errorf("error in CreateContainerView: %w", err)
+	}
+
+	release := func() {
+		if err := v.Destroy(ctx); err != nil {
+			m.Logger().Debugf("error trying to destroy view from vSphere: %v", err)
+		}
+		logout()
+	}
+
+	if err := v.Retrieve(ctx, []string{kind}, props, dst); err != nil {
+		release()
+		return nil, nil, fmt.Errorf("error in Retrieve: %w", err)
+	}
+	return c, release, nil
+}
+
+// Close closes the vSphere session kept for incremental updates, if any.
+func (m *MetricSet) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.inventory == nil {
+		return nil
+	}
+	err := m.inventory.close(context.Background())
+	m.inventory = nil
+	return err
+}
@@ -0,0 +1,101 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package vsphere
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestInventoryUpdates(t *testing.T) {
+	model := simulator.VPX()
+	require.NoError(t, model.Create(), "failed to create model")
+	t.Cleanup(func() { model.Remove() })
+
+	ts := model.Service.NewServer()
+	t.Cleanup(func() { ts.Close() })
+
+	ctx := context.Background()
+	inv, err := newInventory(ctx, ts.URL, true, "VirtualMachine", []string{"name", "summary"})
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, inv.close(ctx)) })
+
+	require.NoError(t, inv.update(ctx))
+	var vms []mo.VirtualMachine
+	require.NoError(t, inv.load(&vms))
+	machines := model.Count().Machine
+	require.Len(t, vms, machines)
+	assert.NotNil(t, vms[0].Summary.Config.Name)
+
+	// Nothing changed, so nothing should be reported.
+	version := inv.version
+	require.NoError(t, inv.update(ctx))
+	assert.Equal(t, version, inv.version)
+
+	client, err := govmomi.NewClient(ctx, ts.URL, true)
+	require.NoError(t, err)
+	vm, err := find.NewFinder(client.Client).VirtualMachine(ctx, vms[0].Name)
+	require.NoError(t, err)
+
+	task, err := vm.Rename(ctx, "renamed")
+	require.NoError(t, err)
+	require.NoError(t, task.Wait(ctx))
+
+	require.NoError(t, inv.update(ctx))
+	require.NoError(t, inv.load(&vms))
+	var names []string
+	for _, vm := range vms {
+		names = append(names, vm.Name)
+	}
+	assert.Contains(t, names, "renamed")
+
+	task, err = vm.PowerOff(ctx)
+	require.NoError(t, err)
+	require.NoError(t, task.Wait(ctx))
+	task, err = vm.Destroy(ctx)
+	require.NoError(t, err)
+	require.NoError(t, task.Wait(ctx))
+
+	require.NoError(t, inv.update(ctx))
+	require.NoError(t, inv.load(&vms))
+	assert.Len(t, vms, machines-1)
+}
+
+func TestInventoryLoadType(t *testing.T) {
+	inv := &inventory{objects: map[types.ManagedObjectReference]mo.Reference{}}
+	ref := types.ManagedObjectReference{Type: "HostSystem", Value: "host-1"}
+	obj, ok := newObject(ref)
+	require.True(t, ok)
+	inv.objects[ref] = obj
+
+	var hosts []mo.HostSystem
+	require.NoError(t, inv.load(&hosts))
+	require.Len(t, hosts, 1)
+	assert.Equal(t, ref, hosts[0].Reference())
+
+	var vms []mo.VirtualMachine
+	assert.Error(t, inv.load(&vms))
+}
@@ -19,6 +19,7 @@ package vsphere
 
 import (
 	"net/url"
+	"sync"
 
 	"github.com/elastic/beats/v7/metricbeat/mb"
 	"github.com/elastic/beats/v7/metricbeat/mb/parse"
@@ -32,15 +33,22 @@ var HostParser = parse.URLHostParserBuilder{
 // MetricSet type defines all fields of the MetricSet.
 type MetricSet struct {
 	mb.BaseMetricSet
-	Insecure bool
-	HostURL  *url.URL
+	Insecure           bool
+	HostURL            *url.URL
+	IncrementalUpdates bool
+
+	mu        sync.Mutex
+	inventory *inventory
 }
 
 // NewMetricSet creates a new instance of the MetricSet.
 func NewMetricSet(base mb.BaseMetricSet) (*MetricSet, error) {
 	config := struct {
-		Insecure bool `config:"insecure"`
-	}{}
+		Insecure           bool `config:"insecure"`
+		IncrementalUpdates bool `config:"incremental_updates"`
+	}{
+		IncrementalUpdates: true,
+	}
 
 	if err := base.Module().UnpackConfig(&config); err != nil {
 		return nil, err
@@ -52,8 +60,9 @@ func NewMetricSet(base mb.BaseMetricSet) (*MetricSet, error) {
 	}
 
 	return &MetricSet{
-		BaseMetricSet: base,
-		HostURL:       u,
-		Insecure:      config.Insecure,
+		BaseMetricSet:      base,
+		HostURL:            u,
+		Insecure:           config.Insecure,
+		IncrementalUpdates: config.IncrementalUpdates,
 	}, nil
 }
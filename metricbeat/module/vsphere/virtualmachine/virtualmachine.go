@@ -28,10 +28,8 @@ import (
 	"github.com/elastic/beats/v7/metricbeat/module/vsphere"
 	"github.com/elastic/elastic-agent-libs/mapstr"
 
-	"github.com/vmware/govmomi"
 	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/property"
-	"github.com/vmware/govmomi/view"
 	"github.com/vmware/govmomi/vim25"
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
@@ -107,18 +105,13 @@ func (m *MetricSet) Fetch(ctx context.Context, reporter mb.ReporterV2) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	client, err := govmomi.NewClient(ctx, m.HostURL, m.Insecure)
+	// Retrieve summary property for all machines
+	var vmt []mo.VirtualMachine
+	c, release, err := m.Inventory(ctx, "VirtualMachine", []string{"summary", "datastore", "triggeredAlarmState", "snapshot"}, &vmt)
 	if err != nil {
-		return fmt.Errorf("virtualmachine: error in NewClient: %w", err)
+		return fmt.Errorf("virtualmachine: %w", err)
 	}
-
-	defer func() {
-		if err := client.Logout(ctx); err != nil {
-			m.Logger().Errorf("error trying to logout from vSphere: %v", err)
-		}
-	}()
-
-	c := client.Client
+	defer release()
 
 	// Get custom fields (attributes) names if get_custom_fields is true.
 	customFieldsMap := make(map[int32]string)
@@ -130,27 +123,6 @@ func (m *MetricSet) Fetch(ctx context.Context, reporter mb.ReporterV2) error {
 		}
 	}
 
-	// Create view of VirtualMachine objects
-	mgr := view.NewManager(c)
-
-	v, err := mgr.CreateContainerView(ctx, c.ServiceContent.RootFolder, []string{"VirtualMachine"}, true)
-	if err != nil {
-		return fmt.Errorf("virtualmachine: error in CreateContainerView: %w", err)
-	}
-
-	defer func() {
-		if err := v.Destroy(ctx); err != nil {
-			m.Logger().Debugf("Error destroying view from vsphere %v", err)
-		}
-	}()
-
-	// Retrieve summary property for all machines
-	var vmt []mo.VirtualMachine
-	err = v.Retrieve(ctx, []string{"VirtualMachine"}, []string{"summary", "datastore", "triggeredAlarmState", "snapshot"}, &vmt)
-	if err != nil {
-		return fmt.Errorf("virtualmachine: error in Retrieve: %w", err)
-	}
-
 	pc := property.DefaultCollector(c)
 	for _, vm := range vmt {
 		var hostID, hostName string
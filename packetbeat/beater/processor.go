@@ -43,17 +43,21 @@ import (
 type processor struct {
 	wg              sync.WaitGroup
 	publisher       *publish.TransactionPublisher
+	watcher         *procs.ProcessesWatcher
 	flows           *flows.Flows
 	sniffer         *sniffer.Sniffer
+	protocols       []*protos.ProtocolsStruct
 	shutdownTimeout time.Duration
 	err             chan error
 }
 
-func newProcessor(shutdownTimeout time.Duration, publisher *publish.TransactionPublisher, flows *flows.Flows, sniffer *sniffer.Sniffer, err chan error) *processor {
+func newProcessor(shutdownTimeout time.Duration, publisher *publish.TransactionPublisher, watcher *procs.ProcessesWatcher, flows *flows.Flows, sniffer *sniffer.Sniffer, protocols []*protos.ProtocolsStruct, err chan error) *processor {
 	return &processor{
 		publisher:       publisher,
+		watcher:         watcher,
 		flows:           flows,
 		sniffer:         sniffer,
+		protocols:       protocols,
 		err:             err,
 		shutdownTimeout: shutdownTimeout,
 	}
@@ -86,6 +90,10 @@ func (p *processor) Stop() {
 		p.flows.Stop()
 	}
 	p.wg.Wait()
+	for _, protocols := range p.protocols {
+		protocols.Close()
+	}
+	p.watcher.Stop()
 	// wait for shutdownTimeout to let the publisher flush
 	// whatever pending events
 	if p.shutdownTimeout > 0 {
@@ -169,12 +177,12 @@ func (p *processorFactory) Create(pipeline beat.PipelineConnector, cfg *conf.C)
 	if err != nil {
 		return nil, err
 	}
-	sniffer, err := setupSniffer(id, config, publisher, &watch, flows)
+	sniffer, protocols, err := setupSniffer(id, config, publisher, &watch, flows)
 	if err != nil {
 		return nil, err
 	}
 
-	return newProcessor(config.ShutdownTimeout, publisher, flows, sniffer, p.err), nil
+	return newProcessor(config.ShutdownTimeout, publisher, &watch, flows, sniffer, protocols, p.err), nil
 }
 
 // setupFlows returns a *flows.Flows that will publish to the provided pipeline,
@@ -208,10 +216,10 @@ func setupFlows(pipeline beat.Pipeline, watch *procs.ProcessesWatcher, cfg confi
 	return flows.NewFlows(client.PublishAll, watch, cfg.Flows)
 }
 
-func setupSniffer(id string, cfg config.Config, pub *publish.TransactionPublisher, watch *procs.ProcessesWatcher, flows *flows.Flows) (*sniffer.Sniffer, error) {
+func setupSniffer(id string, cfg config.Config, pub *publish.TransactionPublisher, watch *procs.ProcessesWatcher, flows *flows.Flows) (*sniffer.Sniffer, []*protos.ProtocolsStruct, error) {
 	icmp, err := cfg.ICMP()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Ensure interfaces are uniquely represented so we don't listen on the
@@ -222,7 +230,7 @@ func setupSniffer(id string, cfg config.Config, pub *publish.TransactionPublishe
 		// Currently we hash on all fields in the config. We can revise this in future.
 		h, err := hashstructure.Hash(iface, nil)
 		if err != nil {
-			return nil, fmt.Errorf("could not deduplicate interface configurations: %w", err)
+			return nil, nil, fmt.Errorf("could not deduplicate interface configurations: %w", err)
 		}
 		if seen[h] {
 			continue
@@ -233,11 +241,19 @@ func setupSniffer(id string, cfg config.Config, pub *publish.TransactionPublishe
 
 	logp.Debug("main", "Initializing protocol plugins")
 	decoders := make(map[string]sniffer.Decoders)
+	all := make([]*protos.ProtocolsStruct, 0, len(interfaces))
+	closeAll := func() {
+		for _, protocols := range all {
+			protocols.Close()
+		}
+	}
 	for i, iface := range interfaces {
 		protocols := protos.NewProtocols()
+		all = append(all, protocols)
 		err = protocols.InitFiltered(false, iface.Device, pub, watch, cfg.Protocols, cfg.ProtocolsList)
 		if err != nil {
-			return nil, fmt.Errorf("failed to initialize protocol analyzers for %s: %w", iface.Device, err)
+			closeAll()
+			return nil, nil, fmt.Errorf("failed to initialize protocol analyzers for %s: %w", iface.Device, err)
 		}
 		decoders[iface.Device] = sniffer.DecodersFor(id, pub, protocols, watch, flows, cfg)
 		if iface.BpfFilter != "" || cfg.Flows.IsEnabled() {
@@ -246,7 +262,12 @@ func setupSniffer(id string, cfg config.Config, pub *publish.TransactionPublishe
 		interfaces[i].BpfFilter = protocols.BpfFilter(iface.WithVlans, icmp.Enabled())
 	}
 
-	return sniffer.New(id, false, "", decoders, interfaces)
+	s, err := sniffer.New(id, false, "", decoders, interfaces)
+	if err != nil {
+		closeAll()
+		return nil, nil, err
+	}
+	return s, all, nil
 }
 
 // CheckConfig performs a dry-run creation of a Packetbeat pipeline based
@@ -102,8 +102,15 @@ func mergeProcsConfig(one, two procs.ProcsConfig) procs.ProcsConfig {
 		refreshPidsFreq = two.RefreshPidsFreq
 	}
 
+	// Use the ebpf backend if any of the configs asks for it.
+	backend := one.Backend
+	if two.Backend == procs.BackendEBPF {
+		backend = two.Backend
+	}
+
 	return procs.ProcsConfig{
 		Enabled:         true,
+		Backend:         backend,
 		MaxProcReadFreq: maxProcReadFreq,
 		RefreshPidsFreq: refreshPidsFreq,
 		Monitored:       append(one.Monitored, two.Monitored...),
@@ -146,6 +146,15 @@ type Flows struct {
 	Index string `config:"index"`
 	// DeltaFlowReports when enabled will report flow network stats(bytes, packets) as delta values
 	EnableDeltaFlowReports bool `config:"enable_delta_flow_reports"`
+	// IPFIX configures exporting flows to an IPFIX collector in addition to publishing them
+	IPFIX *IPFIX `config:"ipfix"`
+}
+
+type IPFIX struct {
+	Enabled             *bool         `config:"enabled"`
+	Host                string        `config:"host"`
+	ObservationDomainID uint32        `config:"observation_domain_id"`
+	TemplateRefresh     time.Duration `config:"template_refresh"`
 }
 
 type ProtocolCommon struct {
@@ -159,6 +168,10 @@ func (f *Flows) IsEnabled() bool {
 	return f != nil && (f.Enabled == nil || *f.Enabled)
 }
 
+func (i *IPFIX) IsEnabled() bool {
+	return i != nil && (i.Enabled == nil || *i.Enabled)
+}
+
 func (i InterfaceConfig) Validate() error {
 	if i.Type != "af_packet" && i.FanoutGroup != nil {
 		return errFanoutGroupAFPacketOnly
@@ -41,10 +41,12 @@ type Flows struct {
 	worker     *worker
 	table      *flowMetaTable
 	counterReg *counterReg
+	ipfix      *ipfixExporter
 }
 
 // NewFlows returns a Flows publishing to pub after enrichment by the given
 // process watcher. Publication timeout and period are specified by config.
+// If configured, flows are also exported to an IPFIX collector.
 func NewFlows(pub Reporter, watcher *procs.ProcessesWatcher, config *config.Flows) (*Flows, error) {
 	duration := func(s string, d time.Duration) (time.Duration, error) {
 		if s == "" {
@@ -71,9 +73,21 @@ func NewFlows(pub Reporter, watcher *procs.ProcessesWatcher, config *config.Flow
 
 	counter := &counterReg{}
 
-	worker, err := newFlowsWorker(pub, watcher, table, counter, timeout, period, config.EnableDeltaFlowReports)
+	var ipfix *ipfixExporter
+	if config.IPFIX.IsEnabled() {
+		ipfix, err = newIPFIXExporter(config.IPFIX, config.EnableDeltaFlowReports)
+		if err != nil {
+			logp.Err("failed to configure IPFIX export: %v", err)
+			return nil, err
+		}
+	}
+
+	worker, err := newFlowsWorker(pub, watcher, table, counter, timeout, period, config.EnableDeltaFlowReports, ipfix)
 	if err != nil {
 		logp.Err("failed to configure flows processing intervals: %v", err)
+		if ipfix != nil {
+			ipfix.close()
+		}
 		return nil, err
 	}
 
@@ -81,6 +95,7 @@ func NewFlows(pub Reporter, watcher *procs.ProcessesWatcher, config *config.Flow
 		table:      table,
 		worker:     worker,
 		counterReg: counter,
+		ipfix:      ipfix,
 	}, nil
 }
 
@@ -109,6 +124,9 @@ func (f *Flows) Start() {
 
 func (f *Flows) Stop() {
 	f.worker.stop()
+	if f.ipfix != nil {
+		f.ipfix.close()
+	}
 }
 
 func (f *Flows) NewInt(name string) (*Int, error) {
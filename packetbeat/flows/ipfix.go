@@ -0,0 +1,377 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package flows
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/packetbeat/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// IPFIX export (RFC 7011) of the flow reports over UDP. Each bidirectional
+// flow report is exported as up to two unidirectional data records, one per
+// direction that saw traffic.
+
+var ErrIPFIXHostMissing = errors.New("ipfix host must be set")
+
+const (
+	ipfixVersion       = 10
+	ipfixHeaderLen     = 16
+	ipfixSetHeaderLen  = 4
+	ipfixTemplateSetID = 2
+	ipfixTemplateIPv4  = 256
+	ipfixTemplateIPv6  = 257
+
+	// ipfixMaxMessageSize keeps datagrams below common path MTUs.
+	ipfixMaxMessageSize = 1400
+
+	defaultIPFIXTemplateRefresh = time.Minute
+)
+
+// Information elements (RFC 7012) used by the templates.
+const (
+	ieOctetDeltaCount          = 1
+	iePacketDeltaCount         = 2
+	ieProtocolIdentifier       = 4
+	ieSourceTransportPort      = 7
+	ieSourceIPv4Address        = 8
+	ieDestinationTransportPort = 11
+	ieDestinationIPv4Address   = 12
+	ieSourceIPv6Address        = 27
+	ieDestinationIPv6Address   = 28
+	ieOctetTotalCount          = 85
+	iePacketTotalCount         = 86
+	ieFlowEndReason            = 136
+	ieFlowStartMilliseconds    = 152
+	ieFlowEndMilliseconds      = 153
+)
+
+// flowEndReason values.
+const (
+	ipfixIdleTimeout   = 1
+	ipfixActiveTimeout = 2
+)
+
+var ipfixProtocols = map[string]uint8{
+	"icmp":      1,
+	"tcp":       6,
+	"udp":       17,
+	"ipv6-icmp": 58,
+}
+
+type ipfixField struct {
+	id, length uint16
+}
+
+type ipfixTemplate struct {
+	id     uint16
+	fields []ipfixField
+}
+
+// recordLen returns the size of a data record of the template.
+func (t ipfixTemplate) recordLen() int {
+	n := 0
+	for _, f := range t.fields {
+		n += int(f.length)
+	}
+	return n
+}
+
+// ipfixRecord is a unidirectional flow record. The order of the encoded
+// values must match the fields of the templates.
+type ipfixRecord struct {
+	start, end       time.Time
+	src, dst         net.IP
+	srcPort, dstPort uint16
+	proto            uint8
+	bytes, packets   uint64
+	final            bool
+}
+
+type ipfixExporter struct {
+	conn            net.Conn
+	domainID        uint32
+	templateRefresh time.Duration
+
+	// templates and records are indexed by IP family, 0 for IPv4 and
+	// 1 for IPv6.
+	templates     [2]ipfixTemplate
+	records       [2][]ipfixRecord
+	lastTemplates time.Time
+	seq           uint32
+}
+
+// newIPFIXExporter returns an exporter sending IPFIX messages to the collector
+// configured in cfg. If delta is set the flow counters are exported as delta
+// counts, otherwise as total counts.
+func newIPFIXExporter(cfg *config.IPFIX, delta bool) (*ipfixExporter, error) {
+	if cfg.Host == "" {
+		return nil, ErrIPFIXHostMissing
+	}
+	conn, err := net.Dial("udp", cfg.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	refresh := cfg.TemplateRefresh
+	if refresh <= 0 {
+		refresh = defaultIPFIXTemplateRefresh
+	}
+	return &ipfixExporter{
+		conn:            conn,
+		domainID:        cfg.ObservationDomainID,
+		templateRefresh: refresh,
+		templates: [2]ipfixTemplate{
+			{id: ipfixTemplateIPv4, fields: ipfixTemplateFields(false, delta)},
+			{id: ipfixTemplateIPv6, fields: ipfixTemplateFields(true, delta)},
+		},
+	}, nil
+}
+
+func ipfixTemplateFields(ipv6, delta bool) []ipfixField {
+	src, dst, addrLen := uint16(ieSourceIPv4Address), uint16(ieDestinationIPv4Address), uint16(net.IPv4len)
+	if ipv6 {
+		src, dst, addrLen = ieSourceIPv6Address, ieDestinationIPv6Address, net.IPv6len
+	}
+	octets, packets := uint16(ieOctetTotalCount), uint16(iePacketTotalCount)
+	if delta {
+		octets, packets = ieOctetDeltaCount, iePacketDeltaCount
+	}
+	return []ipfixField{
+		{ieFlowStartMilliseconds, 8},
+		{ieFlowEndMilliseconds, 8},
+		{src, addrLen},
+		{dst, addrLen},
+		{ieSourceTransportPort, 2},
+		{ieDestinationTransportPort, 2},
+		{ieProtocolIdentifier, 1},
+		{octets, 8},
+		{packets, 8},
+		{ieFlowEndReason, 1},
+	}
+}
+
+// add buffers the records of a flow event until the next flush. Events
+// without IP addresses are not exported.
+func (e *ipfixExporter) add(event beat.Event) {
+	fields := event.Fields
+	evt, _ := fields["event"].(mapstr.M)
+	flow, _ := fields["flow"].(mapstr.M)
+	network, _ := fields["network"].(mapstr.M)
+	source, _ := fields["source"].(mapstr.M)
+	dest, _ := fields["destination"].(mapstr.M)
+
+	srcIP, dstIP := innerIP(source["ip"]), innerIP(dest["ip"])
+	if srcIP == nil || dstIP == nil {
+		return
+	}
+
+	fwd := ipfixRecord{src: srcIP, dst: dstIP}
+	if v, ok := evt["start"].(common.Time); ok {
+		fwd.start = time.Time(v)
+	}
+	if v, ok := evt["end"].(common.Time); ok {
+		fwd.end = time.Time(v)
+	}
+	fwd.final, _ = flow["final"].(bool)
+	if transport, ok := network["transport"].(string); ok {
+		fwd.proto = ipfixProtocols[transport]
+	}
+	fwd.srcPort, _ = source["port"].(uint16)
+	fwd.dstPort, _ = dest["port"].(uint16)
+
+	rev := fwd
+	rev.src, rev.dst = fwd.dst, fwd.src
+	rev.srcPort, rev.dstPort = fwd.dstPort, fwd.srcPort
+
+	fwd.bytes, _ = source["bytes"].(uint64)
+	fwd.packets, _ = source["packets"].(uint64)
+	rev.bytes, _ = dest["bytes"].(uint64)
+	rev.packets, _ = dest["packets"].(uint64)
+
+	family := 0
+	if srcIP.To4() == nil {
+		family = 1
+	}
+	for _, r := range []ipfixRecord{fwd, rev} {
+		if r.packets > 0 {
+			e.records[family] = append(e.records[family], r)
+		}
+	}
+}
+
+// innerIP returns the innermost address of a flow endpoint. Flows of
+// tunneled traffic report the outer address first.
+func innerIP(v interface{}) net.IP {
+	switch ip := v.(type) {
+	case string:
+		return net.ParseIP(ip)
+	case []string:
+		if len(ip) > 0 {
+			return net.ParseIP(ip[len(ip)-1])
+		}
+	}
+	return nil
+}
+
+// flush sends the buffered records to the collector, split into as many
+// messages as needed. Templates are sent with the first message and then
+// every templateRefresh, as the collector may have missed them when using
+// UDP.
+func (e *ipfixExporter) flush(ts time.Time) error {
+	defer func() {
+		e.records[0] = e.records[0][:0]
+		e.records[1] = e.records[1][:0]
+	}()
+
+	msg := &ipfixMessage{}
+	msg.init()
+	if e.lastTemplates.IsZero() || ts.Sub(e.lastTemplates) >= e.templateRefresh {
+		msg.appendTemplates(e.templates[:])
+		e.lastTemplates = ts
+	}
+
+	for family, records := range e.records {
+		tmpl := e.templates[family]
+		for _, r := range records {
+			if !msg.fits(tmpl.id, tmpl.recordLen()) {
+				if err := e.send(msg, ts); err != nil {
+					return err
+				}
+				msg.init()
+			}
+			msg.appendRecord(tmpl.id, r)
+		}
+	}
+
+	if msg.isEmpty() {
+		return nil
+	}
+	return e.send(msg, ts)
+}
+
+func (e *ipfixExporter) send(msg *ipfixMessage, ts time.Time) error {
+	buf := msg.finish(uint32(ts.Unix()), e.seq, e.domainID)
+	// The sequence number counts the data records sent before the message.
+	e.seq += msg.records
+	_, err := e.conn.Write(buf)
+	return err
+}
+
+func (e *ipfixExporter) close() error {
+	return e.conn.Close()
+}
+
+// ipfixMessage encodes a single IPFIX message.
+type ipfixMessage struct {
+	buf     []byte
+	setID   uint16 // ID of the set being written, 0 if none
+	setOff  int
+	records uint32
+}
+
+func (m *ipfixMessage) init() {
+	m.buf = make([]byte, ipfixHeaderLen, ipfixMaxMessageSize)
+	m.setID = 0
+	m.records = 0
+}
+
+func (m *ipfixMessage) isEmpty() bool {
+	return len(m.buf) == ipfixHeaderLen
+}
+
+// fits checks if a record of n bytes for the set id fits in the message.
+func (m *ipfixMessage) fits(id uint16, n int) bool {
+	if m.setID != id {
+		n += ipfixSetHeaderLen
+	}
+	return len(m.buf)+n <= ipfixMaxMessageSize
+}
+
+func (m *ipfixMessage) openSet(id uint16) {
+	if m.setID == id {
+		return
+	}
+	m.closeSet()
+	m.setID = id
+	m.setOff = len(m.buf)
+	m.buf = binary.BigEndian.AppendUint16(m.buf, id)
+	m.buf = binary.BigEndian.AppendUint16(m.buf, 0) // length, set in closeSet
+}
+
+func (m *ipfixMessage) closeSet() {
+	if m.setID == 0 {
+		return
+	}
+	binary.BigEndian.PutUint16(m.buf[m.setOff+2:], uint16(len(m.buf)-m.setOff))
+	m.setID = 0
+}
+
+func (m *ipfixMessage) appendTemplates(templates []ipfixTemplate) {
+	m.openSet(ipfixTemplateSetID)
+	for _, t := range templates {
+		m.buf = binary.BigEndian.AppendUint16(m.buf, t.id)
+		m.buf = binary.BigEndian.AppendUint16(m.buf, uint16(len(t.fields)))
+		for _, f := range t.fields {
+			m.buf = binary.BigEndian.AppendUint16(m.buf, f.id)
+			m.buf = binary.BigEndian.AppendUint16(m.buf, f.length)
+		}
+	}
+}
+
+func (m *ipfixMessage) appendRecord(id uint16, r ipfixRecord) {
+	m.openSet(id)
+
+	m.buf = binary.BigEndian.AppendUint64(m.buf, uint64(r.start.UnixMilli()))
+	m.buf = binary.BigEndian.AppendUint64(m.buf, uint64(r.end.UnixMilli()))
+	if id == ipfixTemplateIPv4 {
+		m.buf = append(m.buf, r.src.To4()...)
+		m.buf = append(m.buf, r.dst.To4()...)
+	} else {
+		m.buf = append(m.buf, r.src.To16()...)
+		m.buf = append(m.buf, r.dst.To16()...)
+	}
+	m.buf = binary.BigEndian.AppendUint16(m.buf, r.srcPort)
+	m.buf = binary.BigEndian.AppendUint16(m.buf, r.dstPort)
+	m.buf = append(m.buf, r.proto)
+	m.buf = binary.BigEndian.AppendUint64(m.buf, r.bytes)
+	m.buf = binary.BigEndian.AppendUint64(m.buf, r.packets)
+	if r.final {
+		m.buf = append(m.buf, ipfixIdleTimeout)
+	} else {
+		m.buf = append(m.buf, ipfixActiveTimeout)
+	}
+	m.records++
+}
+
+// finish closes the open set and writes the message header.
+func (m *ipfixMessage) finish(exportTime, seq, domainID uint32) []byte {
+	m.closeSet()
+	binary.BigEndian.PutUint16(m.buf[0:], ipfixVersion)
+	binary.BigEndian.PutUint16(m.buf[2:], uint16(len(m.buf)))
+	binary.BigEndian.PutUint32(m.buf[4:], exportTime)
+	binary.BigEndian.PutUint32(m.buf[8:], seq)
+	binary.BigEndian.PutUint32(m.buf[12:], domainID)
+	return m.buf
+}
@@ -0,0 +1,177 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build !integration
+
+package flows
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/packetbeat/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func testFlowEvent(srcIP, dstIP string, final bool) beat.Event {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	return beat.Event{
+		Timestamp: start,
+		Fields: mapstr.M{
+			"event": mapstr.M{
+				"start": common.Time(start),
+				"end":   common.Time(start.Add(time.Second)),
+			},
+			"flow": mapstr.M{"final": final},
+			"network": mapstr.M{
+				"transport": "tcp",
+			},
+			"source": mapstr.M{
+				"ip":      srcIP,
+				"port":    uint16(40000),
+				"bytes":   uint64(100),
+				"packets": uint64(2),
+			},
+			"destination": mapstr.M{
+				"ip":      dstIP,
+				"port":    uint16(443),
+				"bytes":   uint64(1000),
+				"packets": uint64(3),
+			},
+		},
+	}
+}
+
+func TestIPFIXExport(t *testing.T) {
+	collector, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer collector.Close()
+
+	exporter, err := newIPFIXExporter(&config.IPFIX{
+		Host:                collector.LocalAddr().String(),
+		ObservationDomainID: 7,
+	}, false)
+	require.NoError(t, err)
+	defer exporter.close()
+
+	ts := time.Now()
+	exporter.add(testFlowEvent("192.0.2.1", "192.0.2.2", true))
+	exporter.add(testFlowEvent("2001:db8::1", "2001:db8::2", false))
+	require.NoError(t, exporter.flush(ts))
+
+	buf := make([]byte, 65535)
+	collector.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := collector.ReadFrom(buf)
+	require.NoError(t, err)
+	msg := buf[:n]
+
+	// header
+	assert.EqualValues(t, ipfixVersion, binary.BigEndian.Uint16(msg[0:]))
+	assert.EqualValues(t, n, binary.BigEndian.Uint16(msg[2:]))
+	assert.EqualValues(t, ts.Unix(), binary.BigEndian.Uint32(msg[4:]))
+	assert.EqualValues(t, 0, binary.BigEndian.Uint32(msg[8:]))
+	assert.EqualValues(t, 7, binary.BigEndian.Uint32(msg[12:]))
+
+	sets := map[uint16][]byte{}
+	for off := ipfixHeaderLen; off < n; {
+		id := binary.BigEndian.Uint16(msg[off:])
+		length := int(binary.BigEndian.Uint16(msg[off+2:]))
+		require.GreaterOrEqual(t, length, ipfixSetHeaderLen)
+		sets[id] = msg[off+ipfixSetHeaderLen : off+length]
+		off += length
+	}
+	require.Contains(t, sets, uint16(ipfixTemplateSetID))
+	require.Contains(t, sets, uint16(ipfixTemplateIPv4))
+	require.Contains(t, sets, uint16(ipfixTemplateIPv6))
+
+	// two records per flow, one for each direction
+	v4 := sets[ipfixTemplateIPv4]
+	recLen := exporter.templates[0].recordLen()
+	require.Len(t, v4, 2*recLen)
+	assert.Equal(t, net.ParseIP("192.0.2.1").To4(), net.IP(v4[16:20]))
+	assert.Equal(t, net.ParseIP("192.0.2.2").To4(), net.IP(v4[20:24]))
+	assert.EqualValues(t, 40000, binary.BigEndian.Uint16(v4[24:]))
+	assert.EqualValues(t, 443, binary.BigEndian.Uint16(v4[26:]))
+	assert.EqualValues(t, 6, v4[28])
+	assert.EqualValues(t, 100, binary.BigEndian.Uint64(v4[29:]))
+	assert.EqualValues(t, 2, binary.BigEndian.Uint64(v4[37:]))
+	assert.EqualValues(t, ipfixIdleTimeout, v4[45])
+
+	rev := v4[recLen:]
+	assert.Equal(t, net.ParseIP("192.0.2.2").To4(), net.IP(rev[16:20]))
+	assert.EqualValues(t, 443, binary.BigEndian.Uint16(rev[24:]))
+	assert.EqualValues(t, 1000, binary.BigEndian.Uint64(rev[29:]))
+
+	assert.Len(t, sets[ipfixTemplateIPv6], 2*exporter.templates[1].recordLen())
+
+	// templates are not resent before the refresh interval, and the
+	// sequence number counts the records already exported
+	exporter.add(testFlowEvent("192.0.2.1", "192.0.2.2", true))
+	require.NoError(t, exporter.flush(ts.Add(time.Second)))
+	n, _, err = collector.ReadFrom(buf)
+	require.NoError(t, err)
+	msg = buf[:n]
+	assert.EqualValues(t, 4, binary.BigEndian.Uint32(msg[8:]))
+	assert.EqualValues(t, ipfixTemplateIPv4, binary.BigEndian.Uint16(msg[ipfixHeaderLen:]))
+}
+
+func TestIPFIXMessageSplit(t *testing.T) {
+	collector, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer collector.Close()
+
+	exporter, err := newIPFIXExporter(&config.IPFIX{Host: collector.LocalAddr().String()}, true)
+	require.NoError(t, err)
+	defer exporter.close()
+
+	const flows = 100
+	for i := 0; i < flows; i++ {
+		exporter.add(testFlowEvent("192.0.2.1", "192.0.2.2", false))
+	}
+	require.NoError(t, exporter.flush(time.Now()))
+
+	buf := make([]byte, 65535)
+	collector.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var records int
+	for records < 2*flows {
+		n, _, err := collector.ReadFrom(buf)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, n, ipfixMaxMessageSize)
+		assert.EqualValues(t, records, binary.BigEndian.Uint32(buf[8:]))
+		for off := ipfixHeaderLen; off < n; {
+			id := binary.BigEndian.Uint16(buf[off:])
+			length := int(binary.BigEndian.Uint16(buf[off+2:]))
+			if id == ipfixTemplateIPv4 {
+				records += (length - ipfixSetHeaderLen) / exporter.templates[0].recordLen()
+			}
+			off += length
+		}
+	}
+	assert.Equal(t, 2*flows, records)
+}
+
+func TestIPFIXHostMissing(t *testing.T) {
+	_, err := newIPFIXExporter(&config.IPFIX{}, false)
+	assert.ErrorIs(t, err, ErrIPFIXHostMissing)
+}
@@ -127,7 +127,8 @@ func (w *worker) periodically(tick time.Duration, fn func() error) {
 // reporting will be done at flow lifetime end.
 // Flows are published via the pub Reporter after being enriched with process information
 // by watcher.
-func newFlowsWorker(pub Reporter, watcher *procs.ProcessesWatcher, table *flowMetaTable, counters *counterReg, timeout, period time.Duration, enableDeltaFlowReports bool) (*worker, error) {
+// If ipfix is not nil, flows are also exported to an IPFIX collector.
+func newFlowsWorker(pub Reporter, watcher *procs.ProcessesWatcher, table *flowMetaTable, counters *counterReg, timeout, period time.Duration, enableDeltaFlowReports bool, ipfix *ipfixExporter) (*worker, error) {
 	if timeout < time.Second {
 		return nil, ErrInvalidTimeout
 	}
@@ -166,6 +167,7 @@ func newFlowsWorker(pub Reporter, watcher *procs.ProcessesWatcher, table *flowMe
 		counters:                 counters,
 		timeout:                  timeout,
 		enableDeltaFlowReporting: enableDeltaFlowReports,
+		ipfix:                    ipfix,
 	}
 	processor.spool.init(pub, defaultBatchSize)
 
@@ -228,6 +230,7 @@ type flowsProcessor struct {
 	counters                 *counterReg
 	timeout                  time.Duration
 	enableDeltaFlowReporting bool
+	ipfix                    *ipfixExporter
 }
 
 func (fw *flowsProcessor) execute(w *worker, checkTimeout, handleReports, lastReport bool) {
@@ -280,12 +283,22 @@ func (fw *flowsProcessor) execute(w *worker, checkTimeout, handleReports, lastRe
 	}
 
 	fw.spool.flush()
+
+	if fw.ipfix != nil {
+		if err := fw.ipfix.flush(ts); err != nil {
+			logp.Err("failed to export flows to IPFIX collector: %v", err)
+		}
+	}
 }
 
 func (fw *flowsProcessor) report(w *worker, ts time.Time, flow *biFlow, isOver bool, intNames, uintNames, floatNames []string) {
 	event := createEvent(fw.watcher, ts, flow, isOver, intNames, uintNames, floatNames, fw.enableDeltaFlowReporting)
 
 	debugf("add event: %v", event)
+	if fw.ipfix != nil {
+		// read the event before publishing it, as the publisher owns it
+		fw.ipfix.add(event)
+	}
 	fw.spool.publish(event)
 }
 
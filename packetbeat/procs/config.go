@@ -17,10 +17,20 @@
 
 package procs
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
+
+// Process monitor backends.
+const (
+	BackendDefault = "default" // Reads the OS socket tables on lookup misses.
+	BackendEBPF    = "ebpf"    // Tracks TCP sockets from kernel events (Linux only).
+)
 
 type ProcsConfig struct {
 	Enabled         bool          `config:"enabled"`
+	Backend         string        `config:"backend"`
 	MaxProcReadFreq time.Duration `config:"max_proc_read_freq"`
 	Monitored       []ProcConfig  `config:"monitored"`
 	RefreshPidsFreq time.Duration `config:"refresh_pids_freq"`
@@ -30,3 +40,13 @@ type ProcConfig struct {
 	Process     string `config:"process"`
 	CmdlineGrep string `config:"cmdline_grep"`
 }
+
+// Validate validates the config.
+func (c *ProcsConfig) Validate() error {
+	switch c.Backend {
+	case "", BackendDefault, BackendEBPF:
+		return nil
+	default:
+		return fmt.Errorf("invalid procs.backend value %q", c.Backend)
+	}
+}
@@ -18,6 +18,7 @@
 package procs
 
 import (
+	"fmt"
 	"net"
 	"strings"
 	"sync"
@@ -51,6 +52,10 @@ type ProcessesWatcher struct {
 
 	// watcher is the OS-dependent engine for the ProcessWatcher.
 	watcher processWatcher
+
+	// sockets, if set, resolves local endpoints before falling back to
+	// the OS socket tables read by watcher.
+	sockets socketMapper
 }
 
 // endpoint is a network address/port number complex.
@@ -124,9 +129,24 @@ func (proc *ProcessesWatcher) init(config ProcsConfig, watcher processWatcher) e
 
 	proc.monitored = config.Monitored
 
+	if proc.enabled && config.Backend == BackendEBPF {
+		proc.sockets, err = newEBPFSocketMapper()
+		if err != nil {
+			return fmt.Errorf("failed to start the ebpf process monitor backend: %w", err)
+		}
+	}
+
 	return nil
 }
 
+// Stop stops the process monitor backend, if any.
+func (proc *ProcessesWatcher) Stop() {
+	if proc.sockets != nil {
+		proc.sockets.close()
+		proc.sockets = nil
+	}
+}
+
 // FindProcessesTupleTCP looks up local process information for the source and
 // destination addresses of TCP tuple
 func (proc *ProcessesWatcher) FindProcessesTupleTCP(tuple *common.IPPortTuple) (procTuple *common.ProcessTuple) {
@@ -192,6 +212,19 @@ func (proc *ProcessesWatcher) findProc(address net.IP, port uint16, transport ap
 		return nil
 	}
 
+	if proc.sockets != nil {
+		if pid, found := proc.sockets.lookup(transport, endpoint{address.String(), port}); found {
+			// The process cache is otherwise only expired when the
+			// socket tables are read.
+			if p, ok := proc.processCache[pid]; ok && time.Now().After(p.expires) {
+				delete(proc.processCache, pid)
+			}
+			if p := proc.getProcessInfo(pid); p != nil {
+				return p
+			}
+		}
+	}
+
 	p, exists := lookupMapping(address, port, procMap)
 	if exists {
 		return p.proc
@@ -0,0 +1,128 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build linux && (amd64 || arm64)
+
+package procs
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/ebpf"
+	"github.com/elastic/ebpfevents"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+// socketPruneInterval is the interval at which the sockets of the processes
+// that exited are removed.
+const socketPruneInterval = 30 * time.Second
+
+// ebpfClients numbers the subscriptions to the ebpf watcher, as a new
+// process watcher is created on each configuration reload.
+var ebpfClients atomic.Uint64
+
+// ebpfSocketMapper maintains a socket-to-PID map from the TCP socket events
+// reported by the ebpf watcher.
+type ebpfSocketMapper struct {
+	*socketMap
+	watcher    *ebpf.Watcher
+	clientName string
+	done       chan struct{}
+}
+
+func newEBPFSocketMapper() (socketMapper, error) {
+	watcher, err := ebpf.GetWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &ebpfSocketMapper{
+		socketMap:  newSocketMap(procAlive),
+		watcher:    watcher,
+		clientName: fmt.Sprintf("packetbeat-procs-%d", ebpfClients.Add(1)),
+		done:       make(chan struct{}),
+	}
+
+	mask := ebpf.EventMask(ebpfevents.EventTypeNetworkConnectionAccepted | ebpfevents.EventTypeNetworkConnectionAttempted | ebpfevents.EventTypeNetworkConnectionClosed)
+	records := watcher.Subscribe(m.clientName, mask)
+	go m.consumeEvents(records)
+
+	logp.Info("Process watcher using the ebpf backend")
+	return m, nil
+}
+
+func (m *ebpfSocketMapper) consumeEvents(records <-chan ebpfevents.Record) {
+	defer m.watcher.Unsubscribe(m.clientName)
+
+	prune := time.NewTicker(socketPruneInterval)
+	defer prune.Stop()
+	for {
+		select {
+		case <-prune.C:
+			m.prune()
+		case rec, ok := <-records:
+			if !ok {
+				return
+			}
+			if rec.Error != nil {
+				logp.Err("ebpf watcher error: %v", rec.Error)
+				continue
+			}
+			m.handleEvent(rec.Event)
+		case <-m.done:
+			return
+		}
+	}
+}
+
+func (m *ebpfSocketMapper) handleEvent(event *ebpfevents.Event) {
+	body, ok := event.Body.(*ebpfevents.NetEvent)
+	if !ok {
+		return
+	}
+	if body.Net.Transport != ebpfevents.TransportTCP {
+		return
+	}
+
+	// The source of the socket events is always the local end.
+	e := endpoint{
+		address: body.Net.SourceAddress.Unmap().String(),
+		port:    body.Net.SourcePort,
+	}
+	pid := int(body.Pids.Tgid)
+
+	switch event.Type {
+	case ebpfevents.EventTypeNetworkConnectionAccepted, ebpfevents.EventTypeNetworkConnectionAttempted:
+		m.add(e, pid)
+	case ebpfevents.EventTypeNetworkConnectionClosed:
+		m.remove(e, pid)
+	}
+}
+
+func (m *ebpfSocketMapper) close() {
+	close(m.done)
+}
+
+// procAlive reports whether the process pid exists in /proc.
+func procAlive(pid int) bool {
+	_, err := os.Stat("/proc/" + strconv.Itoa(pid))
+	return err == nil
+}
@@ -0,0 +1,64 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build linux && (amd64 || arm64)
+
+package procs
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/v7/packetbeat/protos/applayer"
+	"github.com/elastic/ebpfevents"
+)
+
+func TestEBPFSocketMapperHandleEvent(t *testing.T) {
+	m := &ebpfSocketMapper{socketMap: newSocketMap(nil)}
+	event := func(typ ebpfevents.EventType, transport ebpfevents.Transport, port uint16, pid uint32) *ebpfevents.Event {
+		return &ebpfevents.Event{
+			Header: ebpfevents.Header{Type: typ},
+			Body: &ebpfevents.NetEvent{
+				Pids: ebpfevents.PidInfo{Tgid: pid},
+				Net: ebpfevents.NetInfo{
+					Transport:     transport,
+					SourceAddress: netip.MustParseAddr("::ffff:10.0.0.1"),
+					SourcePort:    port,
+				},
+			},
+		}
+	}
+	e := endpoint{address: "10.0.0.1", port: 53}
+
+	m.handleEvent(event(ebpfevents.EventTypeNetworkConnectionAccepted, ebpfevents.TransportTCP, 53, 20))
+	pid, found := m.lookup(applayer.TransportTCP, e)
+	assert.True(t, found)
+	assert.Equal(t, 20, pid)
+	_, found = m.lookup(applayer.TransportUDP, e)
+	assert.False(t, found, "TCP sockets must not be mapped as UDP")
+
+	// Only TCP sockets are tracked, UDP is looked up in /proc.
+	m.handleEvent(event(ebpfevents.EventTypeNetworkConnectionAttempted, ebpfevents.TransportTCP+1, 80, 30))
+	_, found = m.lookup(applayer.TransportTCP, endpoint{address: "10.0.0.1", port: 80})
+	assert.False(t, found, "other transports must be ignored")
+
+	m.handleEvent(event(ebpfevents.EventTypeNetworkConnectionClosed, ebpfevents.TransportTCP, 53, 20))
+	_, found = m.lookup(applayer.TransportTCP, e)
+	assert.False(t, found)
+}
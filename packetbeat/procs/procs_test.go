@@ -304,3 +304,72 @@ func TestFindProcessTuple(t *testing.T) {
 		})
 	}
 }
+
+func TestFindProcessTupleSocketMap(t *testing.T) {
+	logp.TestingSetup()
+	w := newMockWatcher(
+		[]net.IP{net.ParseIP("192.168.1.1")},
+		[]runningProcess{
+			{
+				process: process{name: "curl", pid: 101},
+				ports:   []endpoint{{address: "192.168.1.1", port: 40000}},
+				proto:   applayer.TransportTCP,
+			},
+			{
+				process: process{name: "bind", pid: 333},
+				ports:   []endpoint{{address: anyIPv4, port: 53}},
+				proto:   applayer.TransportUDP,
+			},
+		})
+	// The process tracked from socket events is known to the OS too.
+	w.pidToProcess[200] = &process{name: "nginx", pid: 200}
+
+	procs := ProcessesWatcher{}
+	err := procs.init(ProcsConfig{Enabled: true}, w)
+	assert.NoError(t, err)
+	sockets := newSocketMap(nil)
+	procs.sockets = sockets
+	sockets.add(endpoint{address: "192.168.1.1", port: 443}, 200)
+
+	find := func(port uint16, transport applayer.Transport) string {
+		tuple := common.NewIPPortTuple(4,
+			net.ParseIP("192.168.1.1"), port,
+			net.ParseIP("1.2.3.4"), 12345)
+		return procs.FindProcessesTuple(&tuple, transport).Src.Name
+	}
+
+	// Found from socket events.
+	assert.Equal(t, "nginx", find(443, applayer.TransportTCP))
+	// Not tracked, found from the OS socket tables.
+	assert.Equal(t, "curl", find(40000, applayer.TransportTCP))
+	assert.Equal(t, "bind", find(53, applayer.TransportUDP))
+
+	// A socket closed by another process than its owner is kept.
+	sockets.remove(endpoint{address: "192.168.1.1", port: 443}, 201)
+	assert.Equal(t, "nginx", find(443, applayer.TransportTCP))
+	sockets.remove(endpoint{address: "192.168.1.1", port: 443}, 200)
+	assert.Equal(t, "", find(443, applayer.TransportTCP))
+
+	procs.Stop()
+	assert.Nil(t, procs.sockets)
+}
+
+func TestSocketMapPrune(t *testing.T) {
+	alive := map[int]bool{100: true, 200: true}
+	sockets := newSocketMap(func(pid int) bool { return alive[pid] })
+	sockets.add(endpoint{address: "10.0.0.1", port: 443}, 100)
+	sockets.add(endpoint{address: "10.0.0.1", port: 8080}, 200)
+	sockets.add(endpoint{address: "10.0.0.1", port: 8081}, 200)
+
+	// The close events of the sockets of 200 were lost.
+	delete(alive, 200)
+	sockets.prune()
+
+	pid, found := sockets.lookup(applayer.TransportTCP, endpoint{address: "10.0.0.1", port: 443})
+	assert.True(t, found)
+	assert.Equal(t, 100, pid)
+	_, found = sockets.lookup(applayer.TransportTCP, endpoint{address: "10.0.0.1", port: 8080})
+	assert.False(t, found)
+	_, found = sockets.lookup(applayer.TransportTCP, endpoint{address: "10.0.0.1", port: 8081})
+	assert.False(t, found)
+}
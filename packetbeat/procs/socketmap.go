@@ -0,0 +1,100 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package procs
+
+import (
+	"sync"
+
+	"github.com/elastic/beats/v7/packetbeat/protos/applayer"
+)
+
+// socketMapper resolves local endpoints to the PID of the process owning the
+// socket without reading the OS socket tables.
+type socketMapper interface {
+	// lookup returns the PID owning the local endpoint for transport.
+	lookup(transport applayer.Transport, e endpoint) (pid int, found bool)
+
+	// close stops tracking sockets.
+	close()
+}
+
+// socketMap is a socket-to-PID map of the TCP sockets, maintained from
+// socket events.
+type socketMap struct {
+	mu      sync.Mutex
+	sockets map[endpoint]int
+
+	// alive reports whether a process still exists. Sockets of processes
+	// that exited are removed by prune, in case their close event was lost.
+	alive func(pid int) bool
+}
+
+func newSocketMap(alive func(pid int) bool) *socketMap {
+	return &socketMap{
+		sockets: make(map[endpoint]int),
+		alive:   alive,
+	}
+}
+
+// add records that pid owns the local endpoint e.
+func (m *socketMap) add(e endpoint, pid int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sockets[e] = pid
+}
+
+// remove forgets the local endpoint e if it is owned by pid. A socket closed
+// by a process after the endpoint was reused by another one is ignored.
+func (m *socketMap) remove(e endpoint, pid int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if owner, ok := m.sockets[e]; ok && owner == pid {
+		delete(m.sockets, e)
+	}
+}
+
+// prune removes the sockets of the processes that no longer exist.
+func (m *socketMap) prune() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	alive := make(map[int]bool)
+	for e, pid := range m.sockets {
+		ok, checked := alive[pid]
+		if !checked {
+			ok = m.alive(pid)
+			alive[pid] = ok
+		}
+		if !ok {
+			delete(m.sockets, e)
+		}
+	}
+}
+
+// lookup returns the PID owning a local TCP endpoint. Other transports are
+// not tracked.
+func (m *socketMap) lookup(transport applayer.Transport, e endpoint) (int, bool) {
+	if transport != applayer.TransportTCP {
+		return 0, false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pid, found := m.sockets[e]
+	return pid, found
+}
+
+func (m *socketMap) close() {}
@@ -24,12 +24,14 @@ import (
 
 type dnsConfig struct {
 	config.ProtocolCommon `config:",inline"`
-	IncludeAuthorities    bool `config:"include_authorities"`
-	IncludeAdditionals    bool `config:"include_additionals"`
+	IncludeAuthorities    bool            `config:"include_authorities"`
+	IncludeAdditionals    bool            `config:"include_additionals"`
+	Detection             detectionConfig `config:"detection"`
 }
 
 var defaultConfig = dnsConfig{
 	ProtocolCommon: config.ProtocolCommon{
 		TransactionTimeout: protos.DefaultTransactionExpiration,
 	},
+	Detection: defaultDetectionConfig,
 }
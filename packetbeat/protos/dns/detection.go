@@ -0,0 +1,185 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package dns
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	mkdns "github.com/miekg/dns"
+	"golang.org/x/net/publicsuffix"
+
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/packetbeat/protos"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// maxNXDomainHistory bounds the number of NXDOMAIN responses remembered per
+// client.
+const maxNXDomainHistory = 1024
+
+type detectionConfig struct {
+	Enabled           bool          `config:"enabled"`
+	Window            time.Duration `config:"window"`
+	NXDomainThreshold int           `config:"nxdomain_threshold"`
+	EntropyThreshold  float64       `config:"entropy_threshold"`
+	MinLabelLength    int           `config:"min_label_length"`
+}
+
+var defaultDetectionConfig = detectionConfig{
+	Window:            time.Minute,
+	NXDomainThreshold: 10,
+	EntropyThreshold:  3.5,
+	MinLabelLength:    8,
+}
+
+func (c *detectionConfig) Validate() error {
+	if c.Window <= 0 {
+		return errors.New("detection.window must be greater than zero")
+	}
+	if c.NXDomainThreshold <= 0 {
+		return errors.New("detection.nxdomain_threshold must be greater than zero")
+	}
+	if c.EntropyThreshold <= 0 {
+		return errors.New("detection.entropy_threshold must be greater than zero")
+	}
+	if c.MinLabelLength < 0 {
+		return errors.New("detection.min_label_length must not be negative")
+	}
+	return nil
+}
+
+// detector keeps track of the NXDOMAIN responses received by each client to
+// flag bursts of failed lookups, and scores query names by their entropy.
+// Both are common traits of malware using domain generation algorithms.
+type detector struct {
+	config detectionConfig
+
+	mu      sync.Mutex
+	clients *common.Cache // Maps client IPs to *clientActivity.
+}
+
+type clientActivity struct {
+	nxdomains []time.Time // Timestamps of the NXDOMAIN responses in the window.
+}
+
+func newDetector(config detectionConfig) *detector {
+	d := &detector{
+		config:  config,
+		clients: common.NewCache(config.Window, protos.DefaultTransactionHashSize),
+	}
+	d.clients.StartJanitor(config.Window)
+	return d
+}
+
+// close stops the expiration of the client activity.
+func (d *detector) close() {
+	d.clients.StopJanitor()
+}
+
+// nxdomainCount records the response received by client at ts and returns the
+// number of NXDOMAIN responses the client received within the window.
+func (d *detector) nxdomainCount(client string, ts time.Time, rcode int) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var activity *clientActivity
+	if v := d.clients.Get(client); v != nil {
+		activity = v.(*clientActivity)
+	} else {
+		if rcode != mkdns.RcodeNameError {
+			return 0
+		}
+		activity = &clientActivity{}
+		d.clients.Put(client, activity)
+	}
+
+	cutoff := ts.Add(-d.config.Window)
+	i := 0
+	for i < len(activity.nxdomains) && !activity.nxdomains[i].After(cutoff) {
+		i++
+	}
+	activity.nxdomains = activity.nxdomains[i:]
+
+	if rcode == mkdns.RcodeNameError {
+		if len(activity.nxdomains) == maxNXDomainHistory {
+			activity.nxdomains = activity.nxdomains[1:]
+		}
+		activity.nxdomains = append(activity.nxdomains, ts)
+	}
+	return len(activity.nxdomains)
+}
+
+// enrich adds the detection fields for a query of name by client that was
+// answered with rcode at ts. An rcode of -1 means that no response was seen.
+func (d *detector) enrich(m mapstr.M, client, name string, ts time.Time, rcode int) {
+	var nxdomains int
+	if client != "" && rcode >= 0 {
+		nxdomains = d.nxdomainCount(client, ts, rcode)
+	}
+	entropy := nameEntropy(name, d.config.MinLabelLength)
+
+	burstScore := math.Min(float64(nxdomains)/float64(d.config.NXDomainThreshold), 1)
+	entropyScore := math.Min(entropy/d.config.EntropyThreshold, 1)
+
+	m["detection"] = mapstr.M{
+		"nxdomain_count": nxdomains,
+		"nxdomain_burst": nxdomains >= d.config.NXDomainThreshold,
+		"entropy":        math.Round(entropy*1000) / 1000,
+		"high_entropy":   entropy >= d.config.EntropyThreshold,
+		"score":          math.Round((burstScore+entropyScore)/2*1000) / 1000,
+	}
+}
+
+// nameEntropy returns the Shannon entropy, in bits per character, of the
+// longest label of name below its public suffix. Labels shorter than minLength
+// have an entropy of 0, as short names are not random enough to tell.
+func nameEntropy(name string, minLength int) float64 {
+	name = strings.ToLower(strings.TrimRight(name, "."))
+	if suffix, _ := publicsuffix.PublicSuffix(name); suffix != name {
+		name = strings.TrimSuffix(name, "."+suffix)
+	}
+
+	var label string
+	for _, l := range strings.Split(name, ".") {
+		if len(l) > len(label) {
+			label = l
+		}
+	}
+	if label == "" || len(label) < minLength {
+		return 0
+	}
+
+	var counts [256]int
+	for i := 0; i < len(label); i++ {
+		counts[label[i]]++
+	}
+	var entropy float64
+	n := float64(len(label))
+	for _, c := range counts {
+		if c == 0 {
+			continue
+		}
+		p := float64(c) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
@@ -0,0 +1,137 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build !integration
+
+package dns
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	mkdns "github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/packetbeat/procs"
+	conf "github.com/elastic/elastic-agent-libs/config"
+)
+
+func newDetectionDNS(t *testing.T, store *eventStore) *dnsPlugin {
+	t.Helper()
+
+	cfg, err := conf.NewConfigFrom(map[string]interface{}{
+		"ports": []int{serverPort},
+		"detection": map[string]interface{}{
+			"enabled":            true,
+			"nxdomain_threshold": 3,
+		},
+	})
+	require.NoError(t, err)
+	dns, err := New(false, func(e beat.Event) { store.publish(e) }, &procs.ProcessesWatcher{}, cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, dns.(*dnsPlugin).Close()) })
+	return dns.(*dnsPlugin)
+}
+
+func publishAnswer(dns *dnsPlugin, client, name string, ts time.Time, rcode int) {
+	ipPort := common.NewIPPortTuple(4,
+		net.ParseIP(client), clientPort,
+		net.ParseIP(serverIP), serverPort)
+	tuple := dnsTupleFromIPPort(&ipPort, transportUDP, 1)
+	trans := newTransaction(ts, tuple, common.ProcessTuple{})
+
+	req := new(mkdns.Msg)
+	req.SetQuestion(name, mkdns.TypeA)
+	resp := new(mkdns.Msg)
+	resp.SetRcode(req, rcode)
+	trans.request = &dnsMessage{ts: ts, data: req}
+	trans.response = &dnsMessage{ts: ts, data: resp}
+	dns.publishTransaction(trans)
+}
+
+func TestDetectionNXDomainBurst(t *testing.T) {
+	results := &eventStore{}
+	dns := newDetectionDNS(t, results)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		publishAnswer(dns, clientIP, "missing.example.com.", start.Add(time.Duration(i)*time.Second), mkdns.RcodeNameError)
+	}
+	// Another client is tracked separately.
+	publishAnswer(dns, "10.0.0.2", "missing.example.com.", start, mkdns.RcodeNameError)
+	// Successful answers don't count but are still enriched.
+	publishAnswer(dns, clientIP, "www.example.com.", start.Add(3*time.Second), mkdns.RcodeSuccess)
+	// The first NXDOMAIN response is out of the window by now.
+	publishAnswer(dns, clientIP, "www.example.com.", start.Add(time.Minute+500*time.Millisecond), mkdns.RcodeSuccess)
+
+	var counts []interface{}
+	var bursts []interface{}
+	for !results.empty() {
+		m := expectResult(t, results)
+		counts = append(counts, mapValue(t, m, "dns.detection.nxdomain_count"))
+		bursts = append(bursts, mapValue(t, m, "dns.detection.nxdomain_burst"))
+	}
+	assert.Equal(t, []interface{}{1, 2, 3, 1, 3, 2}, counts)
+	assert.Equal(t, []interface{}{false, false, true, false, true, false}, bursts)
+}
+
+func TestDetectionEntropy(t *testing.T) {
+	results := &eventStore{}
+	dns := newDetectionDNS(t, results)
+
+	publishAnswer(dns, clientIP, "www.elastic.co.", time.Now(), mkdns.RcodeSuccess)
+	m := expectResult(t, results)
+	assert.Equal(t, 0.0, mapValue(t, m, "dns.detection.entropy"))
+	assert.Equal(t, false, mapValue(t, m, "dns.detection.high_entropy"))
+	assert.Equal(t, 0.0, mapValue(t, m, "dns.detection.score"))
+
+	publishAnswer(dns, clientIP, "x7kq2vbz9fjw4tmr.com.", time.Now(), mkdns.RcodeNameError)
+	m = expectResult(t, results)
+	assert.Equal(t, 4.0, mapValue(t, m, "dns.detection.entropy"))
+	assert.Equal(t, true, mapValue(t, m, "dns.detection.high_entropy"))
+	assert.Equal(t, 0.667, mapValue(t, m, "dns.detection.score"))
+}
+
+func TestDetectionDisabled(t *testing.T) {
+	results := &eventStore{}
+	dns := newDNS(results, testing.Verbose())
+
+	publishAnswer(dns, clientIP, "x7kq2vbz9fjw4tmr.com.", time.Now(), mkdns.RcodeNameError)
+	m := expectResult(t, results)
+	assert.Nil(t, mapValue(t, m, "dns.detection"))
+}
+
+func TestNameEntropy(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		entropy float64
+	}{
+		{name: "", entropy: 0},
+		{name: "com.", entropy: 0},
+		{name: "example.com.", entropy: 0},
+		{name: "aaaaaaaaaa.com.", entropy: 0},
+		{name: "abcdefgh.co.uk.", entropy: 3},
+		{name: "www.abcdefghabcdefgh.com", entropy: 3},
+		{name: "ABCDEFGHIJKLMNOP.example.org.", entropy: 4},
+	} {
+		assert.InDelta(t, tc.entropy, nameEntropy(tc.name, 8), 1e-9, tc.name)
+	}
+}
@@ -52,6 +52,9 @@ type dnsPlugin struct {
 	includeAuthorities bool
 	includeAdditionals bool
 
+	// Flags NXDOMAIN bursts and high-entropy query names. Nil if disabled.
+	detector *detector
+
 	// Cache of active DNS transactions. The map key is the HashableDnsTuple
 	// associated with the request.
 	transactions       *common.Cache
@@ -262,6 +265,18 @@ func (dns *dnsPlugin) setFromConfig(config *dnsConfig) {
 	dns.includeAuthorities = config.IncludeAuthorities
 	dns.includeAdditionals = config.IncludeAdditionals
 	dns.transactionTimeout = config.TransactionTimeout
+	if config.Detection.Enabled {
+		dns.detector = newDetector(config.Detection)
+	}
+}
+
+// Close stops the background goroutines of the DNS analyzer.
+func (dns *dnsPlugin) Close() error {
+	dns.transactions.StopJanitor()
+	if dns.detector != nil {
+		dns.detector.close()
+	}
+	return nil
 }
 
 func newTransaction(ts time.Time, tuple dnsTuple, cmd common.ProcessTuple) *dnsTransaction {
@@ -432,9 +447,26 @@ func (dns *dnsPlugin) publishTransaction(t *dnsTransaction) {
 		}
 	}
 
+	if dns.detector != nil {
+		dns.addDetection(dnsEvent, t)
+	}
+
 	dns.results(evt)
 }
 
+// addDetection adds the detection fields of the transaction's query to the
+// DNS event.
+func (dns *dnsPlugin) addDetection(m mapstr.M, t *dnsTransaction) {
+	msg, ts, rcode := t.request, t.ts, -1
+	if t.response != nil {
+		msg, ts, rcode = t.response, t.response.ts, t.response.data.Rcode
+	}
+	if len(msg.data.Question) == 0 {
+		return
+	}
+	dns.detector.enrich(m, t.src.IP, msg.data.Question[0].Name, ts, rcode)
+}
+
 func (dns *dnsPlugin) expireTransaction(t *dnsTransaction) {
 	t.notes = append(t.notes, noResponse.Error())
 	dns.logger.Debugf("%v %s", noResponse, &t.tuple)
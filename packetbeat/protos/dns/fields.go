@@ -32,5 +32,5 @@ func init() {
 // AssetDns returns asset data.
 // This is the base64 encoded zlib format compressed contents of protos/dns.
 func AssetDns() string {
-	return "eJzMl0tv3LYTwO/7KQZ7+Sf410pToJc9FEhrFwhQGEGc9tDLhiJHK9YUR50h11Y+fUFK2pW8j7iP2PHFC2o4/M2Twwu4xW4FxssCINjgcAXLy+ub5QLAoGi2bbDkV3B5fXMhLWpbWQ24RR+gsuiMFAsYfq0WAAAX4FWDo8r0F7oWV7Bhiu2wMpWf7qmc2kihYqiJbVDBbnEnM+opiRwqP1mfcf4w+QDwJnFntdDDd9ZvINQqQKgRGKUlb9KaIG+RwQooDyNBBxXxTGHaZahR1mdkiIIGrM/rf0aUBFEsThjGqCOLJb9WW2WdKt0XMe+uxlAjw3DcNpNxBxLbljhkG8fzB/aZ1mxY749HmGJQLKP5onHSzqaEM5ZRB8lLQ7wCQRtZIoKaKewt3nnAdQW8P+0OylDKnTQ3JQT6YPXaqKC+cE6OmIOJmrxYgyyTlJV5xHZ4Jw3QNepb6zdrYyXF/Ynilc+aBmymRezGqxAZYaucNSqdBFSNxcTdSXMCR69VQLM+4o//1h7yrstElWUJ8P3r76DsAsoIyti6Du6Q5yFhDJE9miMm7PoEBmfWrYuyJo+nQD/UCFhVqFM3hEDthcMturELvcAPv1y+hKQFyCM0xAhOleiKGc/PxID3qmkdfpO5077/v04NDpYVUVEqLjbklN8UxJtimepiOV2Y60tYqRayAoMBubG+d1qvGzQ1KFAxNamlYlOiMWhAU9sNvpsp7JUl6TqEdvXqVRtLZ7XEqrL3mWAiPliyAtWoT+QLTUW8PeJq5eUOWdaaog8HKeLIbx6XH8laH5sSOaEzCkXWuVaJjaQSDcr6/VXw0XgphsM/9tddsTiCN9wzFuUAjso/UIdHpq8Hxay6ESTFQYGxOrc1ztcYoNL15GYTzF97lz8MRo9+zKF74mdy6h5g59iHSk8IQqM6IM7/PAUo0+2nXUxZabDFfhCgwwtRk6/sJvKuQb1T+hZDiSqcd1GRVs4V9nSUCAR3tdU1hNrKQ3dAi5y8IUerYPhRaGo+A5RidA4ofU8W5mp8EIFDqqMw1zfnEbRTIucYskCCSD35X4C8vT4CYozt7/onq7jdif+05PbIz1Rye4DPlNyB4FOV3P7gr6TkJkDPVXIThK+l5IoQ3OMTNdgmpUtA3iqXMAQ1eSPjnHkkZCnPSgStdD0bcQFKrNJkZANITdGZJGasaMUGTQG/I1OaQiMKNKj8fpbN3uj3zF9JfTr3RxXHa/KsLx68JD7jjIzRy5TjmDVaX2SJhJ2rqep9M+xIRSYzdX259XmnvNkHf6pzF+UDG6gNxRbz4+9cSl2lVBrkjubK8tvlceWG/u4w/7YCwdDPtYGVF9U32igoKaVvrn46YQjehzVrMicr9Oo+oE/tanxoQJI+bIN7s358c/nb1fubE8ZF067Ffjp8r5xr4u8xvxmI/yfw6+U7aFXnSBlIiuCF9f2L5GWxWPwVAAD//ysvXY8="
+	return "eJzMWFtv3DYTffevGPjlS/DZSlygL34o4NYuYCB1gji9oC/rETmSWFOkQlK7Vn59MdRlpV1p7TRX+MELihyec+bCIU/hnppzkMYfAQQVNJ3D8eXN7fERgCQvnKqCsuYcLm9uT31FQmVKAK3JBMgUaemTI+h+nR8BAJyCwZJ6k/wXmorOIXe2rrqR8fzxmkxj7hOsQ2GdChjUmoY5vZ3UWk1oRuMTnD+NPgBcMO5oFlrwjTI5hAIDhILAka+skTzmya3JgfKABnoEDWTWTQzyKmlLVCbShNqTBGWitfc1eRYrOVog5kjUzitrVrhGpTHVX4TepqBQkINuuzXB+5pcA76uKutC5Njv32GfWI3EWj2eQEWSV47klyAy+EloxQEnlSMRfBzq/BUsVLXzNQFODLaMBwV0k8DbZTlsVBf1Il0OCDJBiZXEgF+U64C5D0lhjVeSnB+FrB8HDsAAb5GAKEjcK5OvpPLs96/kr7jX2GETK17lBkPtCNaolUR2AtisTybXLNIJrjYCA8nVjB6fl481uomIMuV8gB/PfoC0CeR7oI4q3cCG3NQljkLtDMkZCkOdoKDlqtK1X1lDS0DfFQSUZSS4GkKw1ammNem+Cj2jd68unwNbAWsISusINKakk5FFgF+tA3rAstJ0Eunwuv+fcYGD48zaJEWX5FajyRPr8uSY8+J4PDC1x7A4F6IBSYFcqUybtK1tELYkD5mzJZdUKlOSkiQIWzWddhODrTGeXYRQnb94UdWpVsLXWaYeIoLR9I7JOWCJH6xJhE3q+xmp0fgNOb8StjZhtL4NEW1NviT7ND6YranLlBxDd+Rt7QRxhbVOehDWBFRmexTcSeOTbvO79nhMjmbgdeeMIr8Hzqb/kAhPg3dhAJ3DpgfCfkCQSjAXdPEYA0JRjE42zxFlTSv5rjNa6MlBxN9I1C2AQdhdowsTocQGrIv/jA2QEigjdM1RKamithGw+weisCZTee2GAvUGxT2FlDAclihhNy+J8W6nlQgWNoUSBYRC+V05oCLHnvWzWdD9SIQtHwHEsXUIEH/nEI/ZuOOBfVSzYG5uD0MQGr0/hCFOYBDcE3wCkOubGSBSKmaL+qtl3LDjf025LeRvlHJbAI+k3N7Er5Vy242/k5QbAfpWKTeC8L2kXBKCXoKxH6hBlVyhA7k1aobhSVgjfd9n7mOK4ZYSCBTFpMUFSCnjzkgF8IWtteTqL5UX6CTJBP4mZ7kLrclDSWi2vWxUo10zMdiFc7tVMp+TB7XYuUk8IgbP7gRL+zarZ5/EGQybv8TeSvl+BSfZ2PfQpVusHYBGbp0/tjl4eY+DrUKypniPXYLPcK44lLp5s7Fy/PJ4P1jYuLQf28xfZ+AptH1tcGg8toW29uS5s7+9+mWBCD2ElRNWLmbo1UMgw+Wqv2gAz94vg1taP19c/nH19naBXC2rlVcfaD5gFkC8pXhnsO5/Hn6/fAMVNtqiBDYEz5RpbyTP51pMbs2jGIl5aLuNTz5EboYD5Oavy9e/XVzfDOJwRgpSa5KQNuOr4EaFYuapYUAHG2Wk3Zx0xwPHcSw61lACr/kK5imAyrZ8QE2jmgzfNecCdkaCtHY+fGyUTVX4s3tlic3mzBZR5Ttw3HaTZObDobZTmu6GNaFw5Aur5d1BGmSCs1UzMtLi9wI1yVWmLYankbgt0BhroLPI8kOqguezD0SBDkUgd9IXB45R8qG9X3aDE3v87KJIRsSQkrYbiNbiVQ66uxy84uWeC7EL/IZTTDQHuCuVWcU9VppMHoo7KHBN8RrZAuW9Xx7UqFB5sVoS6nM4urP9NA93k5/qYC+so8/hXrYDKYUNkYGXsdqfQaFMmDzXsNca2KCHEiVx5paoN7jzolH7rsmNVQRyMtS1Z6hzbu6LMoHr+LzIUuCaHObUh05fKiYmY46AI43d48ZsLkTYnZlOyPGax5Q+aftwgVVFEjDAWXL07wB3S/FW"
 }
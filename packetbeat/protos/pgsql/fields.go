@@ -32,5 +32,5 @@ func init() {
 // AssetPgsql returns asset data.
 // This is the base64 encoded zlib format compressed contents of protos/pgsql.
 func AssetPgsql() string {
-	return "eJzEkcFu6jAURPf5ihHrBx+QBRJCPAkJtRTYI0gmwapjB1+bKn9fhaTF0GbBqllF1z4zR9djvLNJUZdy1gnglddMMVpb8aXj9m01SoCckjlVe2VNimkCALcLY6mZqUJl4IXGo1DUuUwS9H/p9f4Y5lDxVtR+vqmZonQ21P0kJmKKzlm3z2zO76MHq92JkVMHoAUmEdEVamvKZKCiosihfK6lZyZDmcILnfLNU6FfUKxfWxF11NxfDjowWlPXudhsXjcPs/+z3Wz1MFvPXpbzn7ImVPtu/0Oi07ugZQF/IraL1WK+wznQNVAFJGQZRYqg/8GflHRvCiUQenh7hUyojnR3cbboXx+OPjjD/JeFto7OfvyZYdsd+30GAAD//yg57bo="
+	return "eJzEkkFr20AQhe/6FY+ca/8AHwIhuBAwbeq4ZyNrn+Ql0q48M+tE/74ocuuNU0NzKtJBzO5775vRzPDMYYG+0UNbAOat5QI3j1GtET79WN0UgKNW4nvzMSxwWwDA+cJMe1a+9hV4ZDDUnq3TeYHT1+Lt/gyh7HgOGh8bei7QSEz9qZIrchVFomyr6Pjn6IJqs2fGNAkwCuaZYgpsY2iKKxEdVcvmcyknzfyap/JI8TZ8yvS3KMfvo6rftdweyzYxG9P4zrBcr7+vL2pf7zZ3q4va4923h/uPsCF122n+10BvswPgoYbtiaflanm/wSFRBvgamqqKqnVqv8D2XqctgFcoDRbfRCF1O8o7u1if9gVCSxLo/jLQkVHiy38jHLNzvg+AaqWxY7BMOW3dM4eXKO7fyMeVGP8K4tRCL+xLoTv7g6+sktFhN6AM4KsxOLqpzXdmwkOi2hw/w+iZmShKIUI0CPsoRjcvfg0AvpUtyg=="
 }
@@ -21,6 +21,8 @@ import (
 	"errors"
 	"strings"
 
+	"github.com/hashicorp/golang-lru/simplelru"
+
 	"github.com/elastic/beats/v7/libbeat/common"
 )
 
@@ -59,6 +61,23 @@ func (pgsql *pgsqlPlugin) parseMessageStart(s *pgsqlStream) (bool, bool) {
 
 	m := s.message
 
+	if s.expectSSLResponse && len(s.data[s.parseOffset:]) > 0 {
+		// SSLRequest was received in the other stream
+		typ := s.data[s.parseOffset]
+		if typ == 'N' || typ == 'S' {
+			// one byte reply to SSLRequest
+			pgsql.detailf("Reply for SSLRequest %c", typ)
+			m.start = s.parseOffset
+			s.parseOffset++
+			m.end = s.parseOffset
+			m.isSSLResponse = true
+			m.sslAccepted = typ == 'S'
+			m.size = uint64(m.end - m.start)
+
+			return true, true
+		}
+	}
+
 	for len(s.data[s.parseOffset:]) >= 5 {
 		isSpecial, length, command := pgsql.isSpecialCommand(s.data[s.parseOffset:])
 		if !isSpecial {
@@ -68,8 +87,9 @@ func (pgsql *pgsqlPlugin) parseMessageStart(s *pgsqlStream) (bool, bool) {
 		// In case of Commands: StartupMessage, SSLRequest, CancelRequest that don't have
 		// their type in the first byte
 
-		// check buffer available
-		if len(s.data[s.parseOffset:]) <= length {
+		// check buffer available, the length of these commands includes
+		// the whole message as there is no type byte
+		if len(s.data[s.parseOffset:]) < length {
 			pgsql.detailf("Wait for more data 1")
 			return true, false
 		}
@@ -96,23 +116,6 @@ func (pgsql *pgsqlPlugin) parseCommand(s *pgsqlStream) (bool, bool) {
 	// read type
 	typ := byte(s.data[s.parseOffset])
 
-	if s.expectSSLResponse {
-		// SSLRequest was received in the other stream
-		if typ == 'N' || typ == 'S' {
-			m := s.message
-
-			// one byte reply to SSLRequest
-			pgsql.detailf("Reply for SSLRequest %c", typ)
-			m.start = s.parseOffset
-			s.parseOffset++
-			m.end = s.parseOffset
-			m.isSSLResponse = true
-			m.size = uint64(m.end - m.start)
-
-			return true, true
-		}
-	}
-
 	// read length
 	length := readLength(s.data[s.parseOffset+1:])
 	if length < 4 {
@@ -135,6 +138,10 @@ func (pgsql *pgsqlPlugin) parseCommand(s *pgsqlStream) (bool, bool) {
 	case 'I':
 		return pgsql.parseEmptyQueryResponse(s)
 	case 'C':
+		if s.frontend {
+			// Close sent by the frontend, not CommandComplete
+			return pgsql.parseExtCloseReq(s)
+		}
 		return pgsql.parseCommandComplete(s, length)
 	case 'Z':
 		return pgsql.parseReadyForQuery(s, length)
@@ -142,7 +149,9 @@ func (pgsql *pgsqlPlugin) parseCommand(s *pgsqlStream) (bool, bool) {
 		return pgsql.parseErrorResponse(s, length)
 	case 'P':
 		return pgsql.parseExtReq(s, length)
-	case '1':
+	case 'B':
+		return pgsql.parseExtBindReq(s)
+	case '1', '2':
 		return pgsql.parseExtResp(s, length)
 	default:
 		if !pgsqlValidType(typ) {
@@ -157,6 +166,7 @@ func (pgsql *pgsqlPlugin) parseSimpleQuery(s *pgsqlStream, length int) (bool, bo
 	m := s.message
 	m.start = s.parseOffset
 	m.isRequest = true
+	s.frontend = true
 
 	s.parseOffset++ // type
 	s.parseOffset += length
@@ -293,6 +303,7 @@ func (pgsql *pgsqlPlugin) parseExtReq(s *pgsqlStream, length int) (bool, bool) {
 	m := s.message
 	m.start = s.parseOffset
 	m.isRequest = true
+	s.frontend = true
 
 	s.parseOffset++ // type
 	s.parseOffset += length
@@ -300,11 +311,13 @@ func (pgsql *pgsqlPlugin) parseExtReq(s *pgsqlStream, length int) (bool, bool) {
 	m.size = uint64(m.end - m.start)
 	m.toExport = true
 
-	query, err := common.ReadString(s.data[m.start+6:])
+	name, query, err := readParse(s.data[m.start+5 : m.end])
 	if err != nil {
 		pgsql.detailf("Invalid extended query request")
 		return false, false
 	}
+	s.storeStatement(name, query)
+	m.statement = name
 	m.query = query
 	pgsql.detailf("Parse in an extended query request: %s", m.query)
 
@@ -316,8 +329,84 @@ func (pgsql *pgsqlPlugin) parseExtReq(s *pgsqlStream, length int) (bool, bool) {
 	return pgsql.parseMessageExtendedQuery(s)
 }
 
+func (pgsql *pgsqlPlugin) parseExtBindReq(s *pgsqlStream) (bool, bool) {
+	// Ready for query -> Bind of a statement prepared earlier in the
+	// connection. The query is resolved from the statement name.
+	pgsql.detailf("Bind")
+
+	m := s.message
+	m.start = s.parseOffset
+	m.isRequest = true
+	s.frontend = true
+
+	s.parseState = pgsqlExtendedQueryState
+	return pgsql.parseMessageExtendedQuery(s)
+}
+
+func (pgsql *pgsqlPlugin) parseExtCloseReq(s *pgsqlStream) (bool, bool) {
+	// Ready for query -> Close of a prepared statement or portal
+	pgsql.detailf("Close")
+
+	m := s.message
+	m.start = s.parseOffset
+	m.isRequest = true
+
+	s.parseState = pgsqlExtendedQueryState
+	return pgsql.parseMessageExtendedQuery(s)
+}
+
+func (pgsql *pgsqlPlugin) parseBind(s *pgsqlStream, buf []byte) error {
+	m := s.message
+
+	// read portal name (string)
+	portal, err := common.ReadString(buf)
+	if err != nil {
+		return err
+	}
+
+	// read statement name (string)
+	name, err := common.ReadString(buf[len(portal)+1:])
+	if err != nil {
+		return err
+	}
+	pgsql.detailf("Bind portal=%s, statement=%s", portal, name)
+
+	if m.query != "" {
+		// statement parsed in the same request
+		return nil
+	}
+	if s.statements == nil {
+		s.statements = newStatements()
+	}
+	v, found := s.statements.Get(name)
+	if !found {
+		pgsql.debugf("Bind of unknown prepared statement %q", name)
+		return nil
+	}
+	query := v.(string)
+	m.statement = name
+	m.query = query
+	m.toExport = !strings.HasPrefix(query, "SET ")
+	return nil
+}
+
+func (pgsql *pgsqlPlugin) parseClose(s *pgsqlStream, buf []byte) {
+	if len(buf) < 2 || buf[0] != 'S' {
+		// only closing prepared statements is of interest, not portals
+		return
+	}
+	name, err := common.ReadString(buf[1:])
+	if err != nil {
+		return
+	}
+	pgsql.detailf("Close statement %s", name)
+	if s.statements != nil {
+		s.statements.Remove(name)
+	}
+}
+
 func (pgsql *pgsqlPlugin) parseExtResp(s *pgsqlStream, length int) (bool, bool) {
-	// Sync -> Parse completion for an extended query response
+	// Sync -> Parse (or Bind) completion for an extended query response
 	pgsql.detailf("ParseCompletion")
 
 	m := s.message
@@ -500,13 +589,35 @@ func (pgsql *pgsqlPlugin) parseMessageData(s *pgsqlStream) (bool, bool) {
 			pgsql.detailf("Rows: %s", m.rows)
 
 			return true, true
-		case '2':
-			// Parse completion -> Bind completion for an extended query response
+		case '2', '3', 'n', 's', 't':
+			// BindComplete, CloseComplete, NoData, PortalSuspended and
+			// ParameterDescription of an extended query response
+
+			// skip type
+			s.parseOffset++
+			s.parseOffset += length
+		case 'E':
+			// ErrorResponse in an extended query response, the backend
+			// discards the rest of the request until Sync
+
+			m.isOK = false
+			m.isError = true
+
+			// skip type
+			s.parseOffset++
+			pgsql.parseError(s, s.data[s.parseOffset+4:s.parseOffset+length])
+			s.parseOffset += length
+		case 'Z':
+			// ReadyForQuery -> extended query response without CommandComplete
 
 			// skip type
 			s.parseOffset++
 			s.parseOffset += length
+			m.end = s.parseOffset
+			m.size = uint64(m.end - m.start)
 			s.parseState = pgsqlStartState
+
+			return true, true
 		case 'T':
 			return pgsql.parseRowDescription(s, length)
 		default:
@@ -548,8 +659,8 @@ func (pgsql *pgsqlPlugin) parseDataRow(s *pgsqlStream, buf []byte) error {
 
 		// read column value (byten)
 		var columnValue []byte
-		if m.fieldsFormat[i] == 0 {
-			// field value in text format
+		if i >= len(m.fieldsFormat) || m.fieldsFormat[i] == 0 {
+			// field value in text format, or no RowDescription was seen
 			if columnLength > 0 {
 				columnValue = buf[off : off+columnLength]
 				off += columnLength
@@ -609,13 +720,45 @@ func (pgsql *pgsqlPlugin) parseMessageExtendedQuery(s *pgsqlStream) (bool, bool)
 		}
 
 		switch typ {
+		case 'P':
+			// Parse of another statement in the same request
+
+			name, query, err := readParse(s.data[s.parseOffset+5 : s.parseOffset+length+1])
+			if err != nil {
+				pgsql.detailf("Invalid extended query request")
+				return false, false
+			}
+			s.storeStatement(name, query)
+
+			// skip type
+			s.parseOffset++
+			s.parseOffset += length
 		case 'B':
 			// Parse -> Bind
 
+			err := pgsql.parseBind(s, s.data[s.parseOffset+5:s.parseOffset+length+1])
+			if err != nil {
+				pgsql.detailf("Invalid bind message")
+				return false, false
+			}
+
+			// skip type
+			s.parseOffset++
+			s.parseOffset += length
+		case 'C':
+			// Close of a prepared statement or portal
+
+			pgsql.parseClose(s, s.data[s.parseOffset+5:s.parseOffset+length+1])
+
+			// skip type
+			s.parseOffset++
+			s.parseOffset += length
+		case 'H':
+			// Flush
+
 			// skip type
 			s.parseOffset++
 			s.parseOffset += length
-			// TODO: pgsql.parseBind(s)
 		case 'D':
 			// Bind -> Describe
 
@@ -680,6 +823,55 @@ func (pgsql *pgsqlPlugin) isSpecialCommand(data []byte) (bool, int, int) {
 	return false, 0, 0
 }
 
+// readParse reads the statement name and the query of a Parse message.
+func readParse(buf []byte) (string, string, error) {
+	name, err := common.ReadString(buf)
+	if err != nil {
+		return "", "", err
+	}
+	query, err := common.ReadString(buf[len(name)+1:])
+	if err != nil {
+		return "", "", err
+	}
+	return name, query, nil
+}
+
+// maxStatements bounds the number of prepared statements remembered per
+// connection. The least recently used ones are forgotten first, so Binds of
+// statements evicted by clients that never close them are not correlated.
+const maxStatements = 1024
+
+func newStatements() *simplelru.LRU {
+	// NewLRU only fails on a non-positive size.
+	statements, _ := simplelru.NewLRU(maxStatements, nil)
+	return statements
+}
+
+// storeStatement records a prepared statement, so that later Bind messages
+// referring to it by name can be correlated to its SQL. The unnamed
+// statement is overwritten by every Parse.
+func (s *pgsqlStream) storeStatement(name, query string) {
+	if s.statements == nil {
+		s.statements = newStatements()
+	}
+	s.statements.Add(name, query)
+}
+
+// isTLSRecord checks if data starts with a TLS record header. Valid pgsql
+// messages never start with these content types: startup packets begin
+// with their length and the other messages with an ASCII type.
+func isTLSRecord(data []byte) bool {
+	if len(data) < 3 {
+		return false
+	}
+	switch data[0] {
+	case 0x14, 0x15, 0x16, 0x17: // change_cipher_spec, alert, handshake, application_data
+	default:
+		return false
+	}
+	return data[1] == 3 && data[2] <= 4
+}
+
 // length field in pgsql counts total length of length field + payload, not
 // including the message identifier. => Always check buffer size >= length + 1
 func readLength(b []byte) int {
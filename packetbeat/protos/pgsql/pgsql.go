@@ -22,6 +22,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/hashicorp/golang-lru/simplelru"
+
 	"github.com/elastic/beats/v7/libbeat/common"
 	conf "github.com/elastic/elastic-agent-libs/config"
 	"github.com/elastic/elastic-agent-libs/logp"
@@ -63,11 +65,13 @@ type pgsqlMessage struct {
 	end           int
 	isSSLResponse bool
 	isSSLRequest  bool
+	sslAccepted   bool
 	toExport      bool
 
 	ts             time.Time
 	isRequest      bool
 	query          string
+	statement      string
 	size           uint64
 	fields         []string
 	fieldsFormat   []byte
@@ -113,6 +117,14 @@ type pgsqlStream struct {
 	seenSSLRequest    bool
 	expectSSLResponse bool
 
+	// frontend is set once the stream sent a message only a client can
+	// send. It disambiguates Close from CommandComplete.
+	frontend bool
+
+	// statements maps the names of the prepared statements created in
+	// the connection to their SQL. It is shared by both streams.
+	statements *simplelru.LRU
+
 	message *pgsqlMessage
 }
 
@@ -130,7 +142,10 @@ const (
 
 var errInvalidLength = errors.New("invalid length")
 
-var unmatchedResponses = monitoring.NewInt(nil, "pgsql.unmatched_responses")
+var (
+	unmatchedResponses = monitoring.NewInt(nil, "pgsql.unmatched_responses")
+	encryptedSessions  = monitoring.NewInt(nil, "pgsql.encrypted_sessions")
+)
 
 func init() {
 	protos.Register("pgsql", New)
@@ -231,6 +246,14 @@ func getQueryMethod(q string) string {
 
 type pgsqlPrivateData struct {
 	data [2]*pgsqlStream
+
+	// statements holds the prepared statements of the connection.
+	statements *simplelru.LRU
+
+	// encrypted is set once the connection is known to carry TLS,
+	// either negotiated via SSLRequest or resumed/started directly.
+	// Encrypted payloads are not parsed.
+	encrypted bool
 }
 
 func (pgsql *pgsqlPlugin) ConnectionTimeout() time.Duration {
@@ -249,10 +272,25 @@ func (pgsql *pgsqlPlugin) Parse(pkt *protos.Packet, tcptuple *common.TCPTuple,
 		}
 	}
 
+	if priv.encrypted {
+		pgsql.detailf("Ignore encrypted Postgresql payload")
+		return priv
+	}
+
+	if priv.statements == nil {
+		priv.statements = newStatements()
+	}
+
 	if priv.data[dir] == nil {
+		if isTLSRecord(pkt.Payload) {
+			// The connection is already encrypted, e.g. a resumed TLS
+			// session or a direct TLS handshake without SSLRequest.
+			return pgsql.markEncrypted(priv)
+		}
 		priv.data[dir] = &pgsqlStream{
-			data:    pkt.Payload,
-			message: &pgsqlMessage{ts: pkt.Ts},
+			data:       pkt.Payload,
+			statements: priv.statements,
+			message:    &pgsqlMessage{ts: pkt.Ts},
 		}
 		pgsql.detailf("New stream created")
 	} else {
@@ -298,6 +336,10 @@ func (pgsql *pgsqlPlugin) Parse(pkt *protos.Packet, tcptuple *common.TCPTuple,
 				// SSL request answered
 				stream.expectSSLResponse = false
 				priv.data[1-dir].seenSSLRequest = false
+				if stream.message.sslAccepted {
+					// everything after the 'S' reply is TLS
+					return pgsql.markEncrypted(priv)
+				}
 			} else {
 				if stream.message.toExport {
 					pgsql.handlePgsql(pgsql, stream.message, tcptuple, dir, msg)
@@ -315,6 +357,17 @@ func (pgsql *pgsqlPlugin) Parse(pkt *protos.Packet, tcptuple *common.TCPTuple,
 	return priv
 }
 
+// markEncrypted flags the connection as encrypted and drops any buffered
+// data, so that the TLS records are not mis-parsed as Postgresql messages.
+func (pgsql *pgsqlPlugin) markEncrypted(priv pgsqlPrivateData) pgsqlPrivateData {
+	pgsql.debugf("Postgresql connection is encrypted, ignoring the rest of the session")
+	encryptedSessions.Add(1)
+	priv.encrypted = true
+	priv.data[0] = nil
+	priv.data[1] = nil
+	return priv
+}
+
 func messageHasEnoughData(msg *pgsqlMessage) bool {
 	if msg == nil {
 		return false
@@ -411,6 +464,9 @@ func (pgsql *pgsqlPlugin) receivedPgsqlRequest(msg *pgsqlMessage) {
 		}
 
 		trans.pgsql = mapstr.M{}
+		if msg.statement != "" {
+			trans.pgsql["statement"] = msg.statement
+		}
 		trans.query = query
 		trans.method = getQueryMethod(query)
 		trans.bytesIn = msg.size
@@ -20,7 +20,9 @@
 package pgsql
 
 import (
+	"encoding/binary"
 	"encoding/hex"
+	"fmt"
 	"net"
 	"testing"
 	"time"
@@ -385,3 +387,157 @@ func Test_gap_in_response(t *testing.T) {
 		assert.Equal(t, m, "Packet loss while capturing the response")
 	}
 }
+
+// pgsqlFrame builds a pgsql message of type typ with the given payload.
+func pgsqlFrame(typ byte, payload ...[]byte) []byte {
+	var body []byte
+	for _, p := range payload {
+		body = append(body, p...)
+	}
+	msg := []byte{typ, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(msg[1:], uint32(len(body)+4))
+	return append(msg, body...)
+}
+
+func cstring(s string) []byte {
+	return append([]byte(s), 0)
+}
+
+// Test that a statement prepared with a Parse message is correlated by name
+// with the Bind messages of later extended query requests.
+func TestPgsqlParser_preparedStatement(t *testing.T) {
+	logp.TestingSetup(logp.WithSelectors("pgsql", "pgsqldetailed"))
+
+	store := &eventStore{}
+	pgsql := pgsqlModForTests(store)
+	tcptuple := testTCPTuple()
+
+	noParams := []byte{0, 0}
+	bind := pgsqlFrame('B', cstring(""), cstring("stmt1"), noParams, noParams, noParams)
+	describe := pgsqlFrame('D', []byte{'P'}, cstring(""))
+	execute := pgsqlFrame('E', cstring(""), []byte{0, 0, 0, 0})
+	sync := pgsqlFrame('S')
+
+	// row description with a single text column "n", one row and completion
+	rowDesc := pgsqlFrame('T', []byte{0, 1}, cstring("n"),
+		[]byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 23, 0, 4, 0xff, 0xff, 0xff, 0xff, 0, 0})
+	dataRow := pgsqlFrame('D', []byte{0, 1, 0, 0, 0, 1, '1'})
+	complete := pgsqlFrame('C', cstring("SELECT 1"))
+	ready := pgsqlFrame('Z', []byte{'I'})
+
+	var req []byte
+	req = append(req, pgsqlFrame('P', cstring("stmt1"), cstring("SELECT 1 AS n"), noParams)...)
+	req = append(req, bind...)
+	req = append(req, describe...)
+	req = append(req, execute...)
+	req = append(req, sync...)
+
+	var resp []byte
+	resp = append(resp, pgsqlFrame('1')...)
+	resp = append(resp, pgsqlFrame('2')...)
+	resp = append(resp, rowDesc...)
+	resp = append(resp, dataRow...)
+	resp = append(resp, complete...)
+	resp = append(resp, ready...)
+
+	var private protos.ProtocolData
+	private = pgsql.Parse(&protos.Packet{Payload: req}, tcptuple, 0, private)
+	private = pgsql.Parse(&protos.Packet{Payload: resp}, tcptuple, 1, private)
+
+	trans := expectTransaction(t, store)
+	if assert.NotNil(t, trans) {
+		assert.Equal(t, "SELECT 1 AS n", trans["query"])
+		assert.Equal(t, "stmt1", trans["pgsql"].(mapstr.M)["statement"])
+		assert.Equal(t, 1, trans["pgsql"].(mapstr.M)["num_rows"])
+	}
+
+	// execute the prepared statement again, without Parse
+	req = nil
+	req = append(req, bind...)
+	req = append(req, describe...)
+	req = append(req, execute...)
+	req = append(req, sync...)
+
+	resp = nil
+	resp = append(resp, pgsqlFrame('2')...)
+	resp = append(resp, rowDesc...)
+	resp = append(resp, dataRow...)
+	resp = append(resp, complete...)
+	resp = append(resp, ready...)
+
+	private = pgsql.Parse(&protos.Packet{Payload: req}, tcptuple, 0, private)
+	private = pgsql.Parse(&protos.Packet{Payload: resp}, tcptuple, 1, private)
+
+	trans = expectTransaction(t, store)
+	if assert.NotNil(t, trans) {
+		assert.Equal(t, "SELECT 1 AS n", trans["query"])
+		assert.Equal(t, "SELECT", trans["method"])
+		assert.Equal(t, "stmt1", trans["pgsql"].(mapstr.M)["statement"])
+	}
+
+	// after closing the statement, a Bind can not be correlated anymore
+	req = nil
+	req = append(req, pgsqlFrame('C', []byte{'S'}, cstring("stmt1"))...)
+	req = append(req, sync...)
+	private = pgsql.Parse(&protos.Packet{Payload: req}, tcptuple, 0, private)
+	assert.False(t, private.(pgsqlPrivateData).statements.Contains("stmt1"))
+}
+
+// Test that TLS encrypted connections are flagged and not parsed.
+func TestPgsqlParser_encrypted(t *testing.T) {
+	logp.TestingSetup(logp.WithSelectors("pgsql", "pgsqldetailed"))
+
+	tlsRecord, err := hex.DecodeString("1603010200010001fc0303")
+	assert.NoError(t, err)
+
+	t.Run("negotiated", func(t *testing.T) {
+		pgsql := pgsqlModForTests(nil)
+		tcptuple := testTCPTuple()
+
+		sslRequest, err := hex.DecodeString("0000000804d2162f")
+		assert.NoError(t, err)
+
+		var private protos.ProtocolData
+		private = pgsql.Parse(&protos.Packet{Payload: sslRequest}, tcptuple, 0, private)
+		assert.False(t, private.(pgsqlPrivateData).encrypted)
+		private = pgsql.Parse(&protos.Packet{Payload: []byte{'S'}}, tcptuple, 1, private)
+		assert.True(t, private.(pgsqlPrivateData).encrypted)
+
+		private = pgsql.Parse(&protos.Packet{Payload: tlsRecord}, tcptuple, 0, private)
+		assert.Nil(t, private.(pgsqlPrivateData).data[0])
+	})
+
+	t.Run("resumed", func(t *testing.T) {
+		pgsql := pgsqlModForTests(nil)
+		tcptuple := testTCPTuple()
+
+		var private protos.ProtocolData
+		private = pgsql.Parse(&protos.Packet{Payload: tlsRecord}, tcptuple, 0, private)
+		assert.True(t, private.(pgsqlPrivateData).encrypted)
+		assert.Nil(t, private.(pgsqlPrivateData).data[0])
+	})
+
+	t.Run("refused", func(t *testing.T) {
+		pgsql := pgsqlModForTests(nil)
+		tcptuple := testTCPTuple()
+
+		sslRequest, err := hex.DecodeString("0000000804d2162f")
+		assert.NoError(t, err)
+
+		var private protos.ProtocolData
+		private = pgsql.Parse(&protos.Packet{Payload: sslRequest}, tcptuple, 0, private)
+		private = pgsql.Parse(&protos.Packet{Payload: []byte{'N'}}, tcptuple, 1, private)
+		assert.False(t, private.(pgsqlPrivateData).encrypted)
+	})
+}
+
+// Test that the prepared statements remembered per connection are bounded.
+func TestPgsqlStatementsBounded(t *testing.T) {
+	s := &pgsqlStream{}
+	for i := 0; i <= maxStatements; i++ {
+		s.storeStatement(fmt.Sprintf("stmt%d", i), "SELECT 1")
+	}
+	assert.Equal(t, maxStatements, s.statements.Len())
+	assert.False(t, s.statements.Contains("stmt0"), "the least recently used statement must be evicted")
+	assert.True(t, s.statements.Contains(fmt.Sprintf("stmt%d", maxStatements)))
+}
@@ -20,6 +20,7 @@ package protos
 import (
 	"errors"
 	"fmt"
+	"io"
 	"sort"
 	"strings"
 	"time"
@@ -240,6 +241,19 @@ func (s ProtocolsStruct) register(proto Protocol, client beat.Client, plugin Plu
 	}
 }
 
+// Close closes the protocol plugins implementing io.Closer, like the ones
+// running background goroutines. It must be called once no more packets are
+// passed to the plugins.
+func (s ProtocolsStruct) Close() {
+	for proto, instance := range s.all {
+		if c, ok := instance.plugin.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				logp.Warn("Failed to close the %s protocol plugin: %v", proto, err)
+			}
+		}
+	}
+}
+
 func (s ProtocolsStruct) GetTCP(proto Protocol) TCPPlugin {
 	plugin, exists := s.tcp[proto]
 	if !exists {
@@ -203,6 +203,26 @@ func TestGetUDP(t *testing.T) {
 	assert.Contains(t, udp.GetPorts(), 53)
 }
 
+type closerProtocol struct {
+	UDPProtocol
+	closed bool
+}
+
+func (proto *closerProtocol) Close() error {
+	proto.closed = true
+	return nil
+}
+
+func TestClose(t *testing.T) {
+	p := NewProtocols()
+	closer := &closerProtocol{UDPProtocol: UDPProtocol{Ports: []int{5353}}}
+	p.register(1, nil, &TCPProtocol{Ports: []int{80}})
+	p.register(2, nil, closer)
+
+	p.Close()
+	assert.True(t, closer.closed)
+}
+
 func TestValidateProtocolDevice(t *testing.T) {
 	tcs := []struct {
 		testCase, device string
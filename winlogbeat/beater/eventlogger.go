@@ -211,7 +211,7 @@ runLoop:
 
 			eventACKer.Add(len(records))
 			for _, lr := range records {
-				client.Publish(lr.ToEvent())
+				client.Publish(lr.ToFlatEvent())
 			}
 		}
 	}
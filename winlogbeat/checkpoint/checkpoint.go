@@ -26,6 +26,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"sync"
 	"time"
 
@@ -65,6 +66,18 @@ type EventLogState struct {
 	Bookmark     string    `yaml:"bookmark,omitempty"`
 }
 
+// Position returns the record number as position of the event in its
+// event log.
+func (s EventLogState) Position() string {
+	return strconv.FormatUint(s.RecordNumber, 10)
+}
+
+// IdempotencyKey returns the name of the event log followed by the record
+// number. It implements beat.IdempotencyKeyer.
+func (s EventLogState) IdempotencyKey() string {
+	return s.Name + "::" + s.Position()
+}
+
 // NewCheckpoint creates and returns a new Checkpoint. This method loads state
 // information from disk if it exists and starts a goroutine for persisting
 // state information to disk. Shutdown should be called when finished to
@@ -0,0 +1,252 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build windows
+
+package eventlog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/windows/registry"
+
+	win "github.com/elastic/beats/v7/winlogbeat/sys/wineventlog"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+// archivePruneInterval is the minimum time between two attempts to delete
+// the archives that were read.
+const archivePruneInterval = time.Minute
+
+// ArchivesConfig configures the reading of the archive files of a channel.
+type ArchivesConfig struct {
+	// Enabled reads the events that were moved to an archive file when the
+	// channel is full and its retention policy is AutoBackupLogFiles.
+	Enabled bool `config:"enabled"`
+	// DeleteAfter is the age after which the archive files whose events
+	// were all read are deleted. Zero keeps the archive files.
+	DeleteAfter time.Duration `config:"delete_after" validate:"min=0"`
+}
+
+// archiveFile is an Archive-*.evtx file and the range of record numbers it
+// contains.
+type archiveFile struct {
+	path    string
+	first   uint64
+	last    uint64
+	modTime time.Time
+}
+
+// archives finds the archive files created by Windows when a channel whose
+// retention policy is AutoBackupLogFiles is full. The archive files are in
+// the directory of the channel's log file and are named
+// Archive-<log file name>-<timestamp>.evtx.
+type archives struct {
+	config    ArchivesConfig
+	pattern   string                 // Glob pattern of the archive files.
+	files     map[string]archiveFile // Record ranges of the known archive files, by path.
+	lastPrune time.Time
+	logPrefix string
+}
+
+// newArchives returns the archives of channel, or nil if the channel is not
+// configured to archive its events.
+func newArchives(channel string, config ArchivesConfig, logPrefix string) (*archives, error) {
+	h, err := win.EvtOpenChannelConfig(0, channel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the configuration of channel %v: %w", channel, err)
+	}
+	defer win.Close(h)
+
+	v, err := win.EvtGetChannelConfigProperty(h, win.EvtChannelLoggingConfigAutoBackup)
+	if err != nil {
+		return nil, err
+	}
+	if autoBackup, _ := v.(bool); !autoBackup {
+		return nil, nil
+	}
+
+	v, err = win.EvtGetChannelConfigProperty(h, win.EvtChannelLoggingConfigLogFilePath)
+	if err != nil {
+		return nil, err
+	}
+	logFile, _ := v.(string)
+	if logFile == "" {
+		return nil, fmt.Errorf("channel %v has no log file", channel)
+	}
+	// The path usually contains environment variables like %SystemRoot%.
+	if logFile, err = registry.ExpandString(logFile); err != nil {
+		return nil, fmt.Errorf("failed to expand the log file path of channel %v: %w", channel, err)
+	}
+
+	name := strings.TrimSuffix(filepath.Base(logFile), filepath.Ext(logFile))
+	return &archives{
+		config:    config,
+		pattern:   filepath.Join(filepath.Dir(logFile), "Archive-"+name+"-*.evtx"),
+		files:     make(map[string]archiveFile),
+		logPrefix: logPrefix,
+	}, nil
+}
+
+// lookup returns the archive files containing records in the range
+// [from, to], ordered by record number.
+func (a *archives) lookup(from, to uint64) ([]archiveFile, error) {
+	files, err := a.list()
+	if err != nil {
+		return nil, err
+	}
+	var found []archiveFile
+	for _, f := range files {
+		if f.last >= from && f.first <= to {
+			found = append(found, f)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].first < found[j].first })
+	return found, nil
+}
+
+// list returns the archive files, reading the record range of new files.
+func (a *archives) list() ([]archiveFile, error) {
+	paths, err := filepath.Glob(a.pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]archiveFile, 0, len(paths))
+	known := make(map[string]archiveFile, len(paths))
+	for _, path := range paths {
+		f, found := a.files[path]
+		if !found {
+			f, err = openArchiveFile(path)
+			if err != nil {
+				logp.Warn("%s Ignoring archive file %v: %v", a.logPrefix, path, err)
+				continue
+			}
+		}
+		known[path] = f
+		files = append(files, f)
+	}
+	a.files = known
+	return files, nil
+}
+
+// prune deletes the archive files older than DeleteAfter whose records were
+// all read. lastRead is the record number of the last event read.
+func (a *archives) prune(lastRead uint64) {
+	if a.config.DeleteAfter <= 0 || time.Since(a.lastPrune) < archivePruneInterval {
+		return
+	}
+	a.lastPrune = time.Now()
+
+	files, err := a.list()
+	if err != nil {
+		logp.Warn("%s Failed to list archive files: %v", a.logPrefix, err)
+		return
+	}
+	for _, f := range files {
+		if f.last > lastRead || time.Since(f.modTime) < a.config.DeleteAfter {
+			continue
+		}
+		if err := os.Remove(f.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			logp.Warn("%s Failed to delete archive file %v: %v", a.logPrefix, f.path, err)
+			continue
+		}
+		debugf("%s Deleted archive file %v", a.logPrefix, f.path)
+		delete(a.files, f.path)
+	}
+}
+
+func openArchiveFile(path string) (archiveFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return archiveFile{}, err
+	}
+
+	h, err := win.EvtOpenLog(0, path, win.EvtOpenFilePath)
+	if err != nil {
+		return archiveFile{}, err
+	}
+	defer win.Close(h)
+
+	oldest, err := logInfoUint64(h, win.EvtLogOldestRecordNumber)
+	if err != nil {
+		return archiveFile{}, err
+	}
+	count, err := logInfoUint64(h, win.EvtLogNumberOfLogRecords)
+	if err != nil {
+		return archiveFile{}, err
+	}
+	if count == 0 {
+		return archiveFile{}, errors.New("the file has no records")
+	}
+	return archiveFile{
+		path:    path,
+		first:   oldest,
+		last:    oldest + count - 1,
+		modTime: info.ModTime(),
+	}, nil
+}
+
+// oldestRecordNumber returns the record number of the oldest event of the
+// channel.
+func oldestRecordNumber(channel string) (uint64, error) {
+	h, err := win.EvtOpenLog(0, channel, win.EvtOpenChannelPath)
+	if err != nil {
+		return 0, err
+	}
+	defer win.Close(h)
+	return logInfoUint64(h, win.EvtLogOldestRecordNumber)
+}
+
+func logInfoUint64(h win.EvtHandle, propertyID win.EvtLogPropertyID) (uint64, error) {
+	v, err := win.EvtGetLogInfo(h, propertyID)
+	if err != nil {
+		return 0, err
+	}
+	switch t := v.(type) {
+	case uint64:
+		return t, nil
+	case nil:
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("unexpected data type: %T", v)
+	}
+}
+
+// archiveBackfill reads the events missed because they were archived
+// before being read. The live events read when the gap was detected are
+// returned once the backfill is done.
+type archiveBackfill struct {
+	files  []archiveFile // Remaining archive files.
+	from   uint64        // First missing record number.
+	to     uint64        // Last missing record number.
+	handle win.EvtHandle // Query of files[0], zero if not open.
+	live   []Record
+}
+
+func (b *archiveBackfill) close() {
+	if b.handle != 0 {
+		win.Close(b.handle)
+		b.handle = 0
+	}
+}
@@ -0,0 +1,225 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package eventlog
+
+import (
+	_ "embed"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/elastic/elastic-agent-libs/config"
+)
+
+// defaultEventDataTypes contains the coercion rules shipped with the beat.
+//
+//go:embed event_data_types.yml
+var defaultEventDataTypes []byte
+
+// EventDataTypesConfig configures the conversion of winlog.event_data values,
+// which are always rendered as strings, to their data types. It is disabled by
+// default because the ingest pipelines of the modules expect string values.
+type EventDataTypesConfig struct {
+	Enabled  bool                `config:"enabled"`
+	Defaults bool                `config:"defaults"` // Apply the rules shipped with the beat.
+	Rules    []EventDataTypeRule `config:"rules"`    // User rules, they take precedence over the defaults.
+}
+
+// EventDataTypeRule maps event_data fields to data types for the events of a
+// provider. When EventIDs is empty the rule applies to all events of the
+// provider.
+type EventDataTypeRule struct {
+	Provider string            `config:"provider" validate:"required"`
+	EventIDs []uint32          `config:"event_id"`
+	Fields   map[string]string `config:"fields" validate:"required"`
+}
+
+func defaultEventDataTypesConfig() EventDataTypesConfig {
+	return EventDataTypesConfig{Defaults: true}
+}
+
+// Validate validates the EventDataTypesConfig.
+func (c EventDataTypesConfig) Validate() error {
+	for _, r := range c.Rules {
+		for field, typ := range r.Fields {
+			if _, found := eventDataConverters[typ]; !found {
+				return fmt.Errorf("invalid type %q for event_data field %q of provider %q", typ, field, r.Provider)
+			}
+		}
+	}
+	return nil
+}
+
+// eventDataConverter converts a rendered event_data value. It returns false
+// when the value cannot be converted.
+type eventDataConverter func(string) (interface{}, bool)
+
+var eventDataConverters = map[string]eventDataConverter{
+	"keyword": func(v string) (interface{}, bool) { return v, true },
+	"long":    convertLong,
+	"double":  convertDouble,
+	"boolean": convertBoolean,
+	"ip":      convertIP,
+}
+
+func convertLong(v string) (interface{}, bool) {
+	// Windows renders many numeric values in hexadecimal (e.g. 0xc000006d).
+	if i, err := strconv.ParseInt(v, 0, 64); err == nil {
+		return i, true
+	}
+	if u, err := strconv.ParseUint(v, 0, 64); err == nil {
+		return u, true
+	}
+	return nil, false
+}
+
+func convertDouble(v string) (interface{}, bool) {
+	f, err := strconv.ParseFloat(v, 64)
+	return f, err == nil
+}
+
+func convertBoolean(v string) (interface{}, bool) {
+	switch strings.ToLower(v) {
+	case "true", "yes", "1", "%%1842":
+		return true, true
+	case "false", "no", "0", "%%1843":
+		return false, true
+	}
+	return nil, false
+}
+
+func convertIP(v string) (interface{}, bool) {
+	// Windows prefixes IPv4 addresses of dual stack sockets.
+	v = strings.TrimPrefix(v, "::ffff:")
+	ip := net.ParseIP(v)
+	if ip == nil {
+		return nil, false
+	}
+	return ip.String(), true
+}
+
+type eventDataTypeKey struct {
+	provider string
+	eventID  uint32
+}
+
+// eventDataSchema holds the compiled coercion rules.
+type eventDataSchema struct {
+	events    map[eventDataTypeKey]map[string]eventDataConverter // Rules for specific event IDs.
+	providers map[string]map[string]eventDataConverter           // Rules for all events of a provider.
+}
+
+// newEventDataSchema compiles the rules of c. It returns nil when the
+// conversion is disabled or there are no rules to apply.
+func newEventDataSchema(c EventDataTypesConfig) (*eventDataSchema, error) {
+	if !c.Enabled {
+		return nil, nil
+	}
+
+	var rules []EventDataTypeRule
+	if c.Defaults {
+		var defaults struct {
+			Rules []EventDataTypeRule `config:"rules"`
+		}
+		cfg, err := config.NewConfigWithYAML(defaultEventDataTypes, "event_data_types.yml")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse default event_data types: %w", err)
+		}
+		if err = cfg.Unpack(&defaults); err != nil {
+			return nil, fmt.Errorf("failed to unpack default event_data types: %w", err)
+		}
+		rules = append(rules, defaults.Rules...)
+	}
+	// User rules are added last so they override the defaults.
+	rules = append(rules, c.Rules...)
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	s := &eventDataSchema{
+		events:    map[eventDataTypeKey]map[string]eventDataConverter{},
+		providers: map[string]map[string]eventDataConverter{},
+	}
+	for _, r := range rules {
+		var targets []map[string]eventDataConverter
+		if len(r.EventIDs) == 0 {
+			fields, found := s.providers[r.Provider]
+			if !found {
+				fields = map[string]eventDataConverter{}
+				s.providers[r.Provider] = fields
+			}
+			targets = append(targets, fields)
+		}
+		for _, id := range r.EventIDs {
+			k := eventDataTypeKey{provider: r.Provider, eventID: id}
+			fields, found := s.events[k]
+			if !found {
+				fields = map[string]eventDataConverter{}
+				s.events[k] = fields
+			}
+			targets = append(targets, fields)
+		}
+
+		for field, typ := range r.Fields {
+			conv, found := eventDataConverters[typ]
+			if !found {
+				return nil, fmt.Errorf("invalid type %q for event_data field %q of provider %q", typ, field, r.Provider)
+			}
+			for _, fields := range targets {
+				fields[field] = conv
+			}
+		}
+	}
+	return s, nil
+}
+
+// apply converts the values of data in place. Rules for the event ID take
+// precedence over the rules for the whole provider. Placeholder values ("-")
+// of converted fields are removed, values that cannot be converted are kept
+// unchanged.
+func (s *eventDataSchema) apply(provider string, eventID uint32, data map[string]interface{}) {
+	if s == nil || len(data) == 0 {
+		return
+	}
+	eventFields := s.events[eventDataTypeKey{provider: provider, eventID: eventID}]
+	providerFields := s.providers[provider]
+	if eventFields == nil && providerFields == nil {
+		return
+	}
+
+	for k, v := range data {
+		conv, found := eventFields[k]
+		if !found {
+			if conv, found = providerFields[k]; !found {
+				continue
+			}
+		}
+		str, ok := v.(string)
+		if !ok {
+			continue
+		}
+		if str == "-" {
+			delete(data, k)
+			continue
+		}
+		if converted, ok := conv(str); ok {
+			data[k] = converted
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package eventlog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/winlogbeat/sys/winevent"
+	"github.com/elastic/elastic-agent-libs/config"
+)
+
+func TestEventDataSchemaDisabled(t *testing.T) {
+	s, err := newEventDataSchema(defaultEventDataTypesConfig())
+	require.NoError(t, err)
+	assert.Nil(t, s)
+}
+
+func TestEventDataSchemaDefaults(t *testing.T) {
+	c := defaultEventDataTypesConfig()
+	c.Enabled = true
+	s, err := newEventDataSchema(c)
+	require.NoError(t, err)
+
+	data := map[string]interface{}{
+		"IpAddress":      "::ffff:10.0.0.1",
+		"IpPort":         "-",
+		"LogonType":      "3",
+		"TargetLogonId":  "0x3e7",
+		"ElevatedToken":  "%%1842",
+		"TargetUserName": "alice",
+	}
+	s.apply("Microsoft-Windows-Security-Auditing", 4624, data)
+	assert.Equal(t, map[string]interface{}{
+		"IpAddress":      "10.0.0.1",
+		"LogonType":      int64(3),
+		"TargetLogonId":  int64(0x3e7),
+		"ElevatedToken":  true,
+		"TargetUserName": "alice",
+	}, data)
+
+	// Other providers are not modified.
+	data = map[string]interface{}{"LogonType": "3"}
+	s.apply("Microsoft-Windows-Other", 4624, data)
+	assert.Equal(t, "3", data["LogonType"])
+}
+
+func TestEventDataSchemaOverrides(t *testing.T) {
+	cfg := config.MustNewConfigFrom(`
+enabled: true
+defaults: true
+rules:
+  - provider: Microsoft-Windows-Security-Auditing
+    event_id: [4624]
+    fields:
+      LogonType: keyword
+      Ratio: double
+`)
+	c := defaultEventDataTypesConfig()
+	require.NoError(t, cfg.Unpack(&c))
+	s, err := newEventDataSchema(c)
+	require.NoError(t, err)
+
+	data := map[string]interface{}{"LogonType": "3", "Ratio": "0.5", "IpPort": "not-a-number"}
+	s.apply("Microsoft-Windows-Security-Auditing", 4624, data)
+	assert.Equal(t, map[string]interface{}{
+		"LogonType": "3",
+		"Ratio":     0.5,
+		"IpPort":    "not-a-number",
+	}, data)
+
+	// The override only applies to the configured event ID.
+	data = map[string]interface{}{"LogonType": "3"}
+	s.apply("Microsoft-Windows-Security-Auditing", 4625, data)
+	assert.Equal(t, int64(3), data["LogonType"])
+}
+
+func TestEventDataTypesConfigValidate(t *testing.T) {
+	cfg := config.MustNewConfigFrom(`
+enabled: true
+rules:
+  - provider: Foo
+    fields:
+      Bar: integer
+`)
+	c := defaultEventDataTypesConfig()
+	err := cfg.Unpack(&c)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid type "integer"`)
+}
+
+func TestRecordEventDataTypes(t *testing.T) {
+	s, err := newEventDataSchema(EventDataTypesConfig{
+		Enabled: true,
+		Rules: []EventDataTypeRule{{
+			Provider: "Foo",
+			Fields:   map[string]string{"Count": "long"},
+		}},
+	})
+	require.NoError(t, err)
+
+	r := Record{eventDataSchema: s}
+	r.Provider.Name = "Foo"
+	r.EventData.Pairs = []winevent.KeyValue{{Key: "Count", Value: "42"}}
+
+	v, err := r.ToEvent().Fields.GetValue("winlog.event_data.Count")
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), v)
+}
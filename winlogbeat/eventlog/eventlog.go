@@ -18,6 +18,9 @@
 package eventlog
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
 	"time"
 
 	"github.com/elastic/beats/v7/libbeat/beat"
@@ -66,6 +69,19 @@ type EventLog interface {
 	IsFile() bool
 }
 
+// RawXMLConfig controls the inclusion of the full fidelity event XML, as
+// forwarded by Windows Event Forwarding, in the winlog.xml fields. Unlike
+// include_xml, the rendered message is kept in the message field and the XML
+// can be compressed to reduce the event size.
+type RawXMLConfig struct {
+	Enabled  bool `config:"enabled"`
+	Compress bool `config:"compress"`
+}
+
+// rawXMLEncodingGzip is the value of winlog.xml.encoding when the XML is
+// gzip compressed and base64 encoded.
+const rawXMLEncodingGzip = "gzip+base64"
+
 // Record represents a single event from the log.
 type Record struct {
 	winevent.Event
@@ -73,51 +89,99 @@ type Record struct {
 	API    string                   // The event log API type used to read the record.
 	XML    string                   // XML representation of the event.
 	Offset checkpoint.EventLogState // Position of the record within its source stream.
+
+	RawXML         string // Raw XML of the event when raw_xml is enabled, encoded according to RawXMLEncoding.
+	RawXMLEncoding string // Encoding of RawXML, empty when RawXML is plain XML.
+
+	eventDataSchema *eventDataSchema // Data types of the event_data fields.
+}
+
+// setRawXML sets the raw XML of the record according to cfg.
+func (e *Record) setRawXML(cfg RawXMLConfig, x []byte) error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if !cfg.Compress {
+		e.RawXML = string(x)
+		return nil
+	}
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(x); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	e.RawXML = base64.StdEncoding.EncodeToString(buf.Bytes())
+	e.RawXMLEncoding = rawXMLEncodingGzip
+	return nil
 }
 
 // ToEvent returns a new beat.Event containing the data from this Record.
 func (e Record) ToEvent() beat.Event {
+	event := e.ToFlatEvent()
+	event.Materialize()
+	return event
+}
+
+// ToFlatEvent is like ToEvent, but keeps the ECS fields of the event in its
+// Flat fields. They are only nested when a processor or the output needs
+// them, see beat.FlatFields.
+func (e Record) ToFlatEvent() beat.Event {
 	win := e.Fields()
 
 	_ = win.Delete("time_created")
 	_, _ = win.Put("api", e.API)
 
-	m := mapstr.M{
-		"winlog": win,
+	if data, ok := win["event_data"].(mapstr.M); ok {
+		e.eventDataSchema.apply(e.Provider.Name, e.EventIdentifier.ID, data)
 	}
 
-	// ECS data
-	_, _ = m.Put("event.created", time.Now())
+	flat := beat.NewFlatFields(16)
+	flat.Put("event.created", time.Now())
 
 	eventCode, _ := win.GetValue("event_id")
-	_, _ = m.Put("event.code", eventCode)
-	_, _ = m.Put("event.kind", "event")
-	_, _ = m.Put("event.provider", e.Provider.Name)
-
-	rename(m, "winlog.outcome", "event.outcome")
-	rename(m, "winlog.level", "log.level")
-	rename(m, "winlog.message", "message")
-	rename(m, "winlog.error.code", "error.code")
-	rename(m, "winlog.error.message", "error.message")
-
-	winevent.AddOptional(m, "log.file.path", e.File)
-	winevent.AddOptional(m, "event.original", e.XML)
-	winevent.AddOptional(m, "event.action", e.Task)
-	winevent.AddOptional(m, "host.name", e.Computer)
+	flat.Put("event.code", eventCode)
+	flat.Put("event.kind", "event")
+	flat.Put("event.provider", e.Provider.Name)
+
+	moveFlat(win, flat, "outcome", "event.outcome")
+	moveFlat(win, flat, "level", "log.level")
+	moveFlat(win, flat, "message", "message")
+	moveFlat(win, flat, "error.code", "error.code")
+	moveFlat(win, flat, "error.message", "error.message")
+
+	addOptionalFlat(flat, "log.file.path", e.File)
+	addOptionalFlat(flat, "event.original", e.XML)
+	addOptionalFlat(flat, "event.action", e.Task)
+	addOptionalFlat(flat, "host.name", e.Computer)
+	winevent.AddOptional(win, "xml.content", e.RawXML)
+	winevent.AddOptional(win, "xml.encoding", e.RawXMLEncoding)
 
 	return beat.Event{
 		Timestamp: e.TimeCreated.SystemTime,
-		Fields:    m,
+		Fields:    mapstr.M{"winlog": win},
+		Flat:      flat,
 		Private:   e.Offset,
 	}
 }
 
-// rename will rename a map entry overriding any previous value
-func rename(m mapstr.M, oldKey, newKey string) {
-	v, err := m.GetValue(oldKey)
+// moveFlat moves a winlog entry to the flat fields, overriding any previous
+// value.
+func moveFlat(win mapstr.M, flat *beat.FlatFields, oldKey, newKey string) {
+	v, err := win.GetValue(oldKey)
 	if err != nil {
 		return
 	}
-	_, _ = m.Put(newKey, v)
-	_ = m.Delete(oldKey)
+	flat.Put(newKey, v)
+	_ = win.Delete(oldKey)
+}
+
+// addOptionalFlat adds the value to the flat fields if it is not empty.
+func addOptionalFlat(flat *beat.FlatFields, key string, v string) {
+	if v != "" {
+		flat.Put(key, v)
+	}
 }
@@ -0,0 +1,75 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package eventlog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const rawXMLEvent = `<Event xmlns='http://schemas.microsoft.com/win/2004/08/events/event'><System><EventID>4624</EventID></System><RenderingInfo Culture='en-US'><Message>An account was successfully logged on.</Message></RenderingInfo></Event>`
+
+func TestRecordRawXML(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		var r Record
+		require.NoError(t, r.setRawXML(RawXMLConfig{}, []byte(rawXMLEvent)))
+
+		_, err := r.ToEvent().Fields.GetValue("winlog.xml")
+		assert.Error(t, err)
+	})
+
+	t.Run("plain", func(t *testing.T) {
+		var r Record
+		r.Message = "An account was successfully logged on."
+		require.NoError(t, r.setRawXML(RawXMLConfig{Enabled: true}, []byte(rawXMLEvent)))
+
+		fields := r.ToEvent().Fields
+		content, err := fields.GetValue("winlog.xml.content")
+		require.NoError(t, err)
+		assert.Equal(t, rawXMLEvent, content)
+		_, err = fields.GetValue("winlog.xml.encoding")
+		assert.Error(t, err)
+		assert.Equal(t, r.Message, fields["message"])
+	})
+
+	t.Run("compressed", func(t *testing.T) {
+		var r Record
+		require.NoError(t, r.setRawXML(RawXMLConfig{Enabled: true, Compress: true}, []byte(rawXMLEvent)))
+
+		fields := r.ToEvent().Fields
+		encoding, err := fields.GetValue("winlog.xml.encoding")
+		require.NoError(t, err)
+		assert.Equal(t, rawXMLEncodingGzip, encoding)
+
+		content, err := fields.GetValue("winlog.xml.content")
+		require.NoError(t, err)
+		compressed, err := base64.StdEncoding.DecodeString(content.(string))
+		require.NoError(t, err)
+		zr, err := gzip.NewReader(bytes.NewReader(compressed))
+		require.NoError(t, err)
+		xml, err := io.ReadAll(zr)
+		require.NoError(t, err)
+		assert.Equal(t, rawXMLEvent, string(xml))
+	})
+}
@@ -0,0 +1,112 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package eventlog
+
+import "sync"
+
+// orderedPool runs a function over its inputs with a pool of workers, and
+// returns the outputs in the order of the inputs as soon as the outputs of
+// all the inputs before them are available. A slow input only delays the
+// inputs after it.
+type orderedPool[In, Out any] struct {
+	tasks   chan *poolTask[In, Out]
+	pending []*poolTask[In, Out] // Submitted tasks, in order.
+	workers sync.WaitGroup
+}
+
+type poolTask[In, Out any] struct {
+	in   In
+	out  Out
+	done chan struct{}
+}
+
+// newOrderedPool starts workers goroutines. Each one calls newWorker once
+// to get the function it runs, so that the function can own buffers that
+// cannot be shared. Up to queueSize inputs wait for a worker.
+func newOrderedPool[In, Out any](workers, queueSize int, newWorker func() func(In) Out) *orderedPool[In, Out] {
+	p := &orderedPool[In, Out]{tasks: make(chan *poolTask[In, Out], queueSize)}
+	for i := 0; i < workers; i++ {
+		p.workers.Add(1)
+		go func() {
+			defer p.workers.Done()
+			run := newWorker()
+			for t := range p.tasks {
+				t.out = run(t.in)
+				close(t.done)
+			}
+		}()
+	}
+	return p
+}
+
+// Submit queues the inputs. It blocks while the queue is full.
+func (p *orderedPool[In, Out]) Submit(inputs ...In) {
+	for _, in := range inputs {
+		t := &poolTask[In, Out]{in: in, done: make(chan struct{})}
+		p.pending = append(p.pending, t)
+		p.tasks <- t
+	}
+}
+
+// Pending returns the number of submitted inputs whose output was not
+// returned yet.
+func (p *orderedPool[In, Out]) Pending() int {
+	return len(p.pending)
+}
+
+// Next waits for the output of the oldest pending input and returns it
+// along with the outputs available after it, in order. It returns nil if
+// there are no pending inputs.
+func (p *orderedPool[In, Out]) Next() []Out {
+	if len(p.pending) == 0 {
+		return nil
+	}
+	<-p.pending[0].done
+
+	var outs []Out
+	for len(p.pending) > 0 {
+		t := p.pending[0]
+		select {
+		case <-t.done:
+		default:
+			return outs
+		}
+		outs = append(outs, t.out)
+		p.pending[0] = nil
+		p.pending = p.pending[1:]
+	}
+	return outs
+}
+
+// All waits for the outputs of all the pending inputs and returns them in
+// order.
+func (p *orderedPool[In, Out]) All() []Out {
+	var outs []Out
+	for p.Pending() > 0 {
+		outs = append(outs, p.Next()...)
+	}
+	return outs
+}
+
+// Stop waits for the pending inputs to be processed and stops the workers.
+// Their outputs are discarded.
+func (p *orderedPool[In, Out]) Stop() {
+	close(p.tasks)
+	p.workers.Wait()
+	p.pending = nil
+}
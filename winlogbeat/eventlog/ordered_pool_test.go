@@ -0,0 +1,106 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package eventlog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testPool returns a pool multiplying the inputs 0 to n-1 by 10 once they
+// are released.
+func testPool(workers, n int) (pool *orderedPool[int, int], release []chan struct{}) {
+	release = make([]chan struct{}, n)
+	for i := range release {
+		release[i] = make(chan struct{})
+	}
+	pool = newOrderedPool(workers, n, func() func(int) int {
+		return func(i int) int {
+			<-release[i]
+			return i * 10
+		}
+	})
+	return pool, release
+}
+
+// waitProcessed waits for the pending input at index i to be processed.
+func waitProcessed(pool *orderedPool[int, int], i int) {
+	<-pool.pending[i].done
+}
+
+func TestOrderedPoolKeepsOrder(t *testing.T) {
+	pool, release := testPool(4, 4)
+	defer pool.Stop()
+	pool.Submit(0, 1, 2, 3)
+
+	for _, i := range []int{3, 2, 1} {
+		close(release[i])
+		waitProcessed(pool, i)
+	}
+	next := make(chan []int)
+	go func() { next <- pool.Next() }()
+	select {
+	case outs := <-next:
+		t.Fatalf("Next returned %v before the first input was processed", outs)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release[0])
+	assert.Equal(t, []int{0, 10, 20, 30}, <-next)
+	assert.Equal(t, 0, pool.Pending())
+	assert.Nil(t, pool.Next())
+}
+
+func TestOrderedPoolReturnsAvailablePrefix(t *testing.T) {
+	pool, release := testPool(2, 4)
+	defer pool.Stop()
+	pool.Submit(0, 1, 2, 3)
+
+	close(release[0])
+	close(release[1])
+	waitProcessed(pool, 0)
+	waitProcessed(pool, 1)
+	assert.Equal(t, []int{0, 10}, pool.Next())
+	assert.Equal(t, 2, pool.Pending())
+
+	close(release[3])
+	close(release[2])
+	assert.Equal(t, []int{20, 30}, pool.All())
+	assert.Equal(t, 0, pool.Pending())
+}
+
+func TestOrderedPoolStop(t *testing.T) {
+	pool, release := testPool(2, 3)
+	pool.Submit(0, 1, 2)
+	tasks := append([]*poolTask[int, int](nil), pool.pending...)
+	for _, c := range release {
+		close(c)
+	}
+
+	pool.Stop()
+	assert.Equal(t, 0, pool.Pending())
+	for i, task := range tasks {
+		select {
+		case <-task.done:
+		default:
+			t.Errorf("input %d must be processed before Stop returns", i)
+		}
+	}
+}
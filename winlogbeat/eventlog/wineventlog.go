@@ -85,13 +85,31 @@ func init() {
 }
 
 type winEventLogConfig struct {
-	ConfigCommon  `config:",inline"`
-	BatchReadSize int                `config:"batch_read_size"` // Maximum number of events that Read will return.
-	IncludeXML    bool               `config:"include_xml"`
-	Forwarded     *bool              `config:"forwarded"`
-	SimpleQuery   query              `config:",inline"`
-	NoMoreEvents  NoMoreEventsAction `config:"no_more_events"` // Action to take when no more events are available - wait or stop.
-	EventLanguage uint32             `config:"language"`
+	ConfigCommon   `config:",inline"`
+	BatchReadSize  int                  `config:"batch_read_size"` // Maximum number of events that Read will return.
+	IncludeXML     bool                 `config:"include_xml"`
+	RawXML         RawXMLConfig         `config:"raw_xml"`
+	EventDataTypes EventDataTypesConfig `config:"event_data_types"`
+	Forwarded      *bool                `config:"forwarded"`
+	SimpleQuery    query                `config:",inline"`
+	NoMoreEvents   NoMoreEventsAction   `config:"no_more_events"` // Action to take when no more events are available - wait or stop.
+	EventLanguage  uint32               `config:"language"`
+
+	// NoMessageRendering disables the rendering of the event messages and
+	// of the names of the task, opcode and keywords. Events only contain the
+	// fields of the System and EventData or UserData elements.
+	NoMessageRendering bool `config:"no_message_rendering"`
+
+	// Archives configures the reading of the events archived by Windows
+	// when the channel is full.
+	Archives ArchivesConfig `config:"archives"`
+
+	// RenderWorkers is the number of goroutines rendering events. With
+	// one worker or less, events are rendered by the reading goroutine.
+	RenderWorkers int `config:"render_workers" validate:"min=0"`
+	// RenderQueueSize is the number of events that can wait for a render
+	// worker. It defaults to batch_read_size.
+	RenderQueueSize int `config:"render_queue_size" validate:"min=0"`
 }
 
 // query contains parameters used to customize the event log data that is
@@ -136,7 +154,8 @@ func (a NoMoreEventsAction) String() string { return noMoreEventsActionNames[a]
 
 // defaultWinEventLogConfig is the default configuration for new wineventlog readers.
 var defaultWinEventLogConfig = winEventLogConfig{
-	BatchReadSize: 100,
+	BatchReadSize:  100,
+	EventDataTypes: defaultEventDataTypesConfig(),
 }
 
 // Validate validates the winEventLogConfig data and returns an error describing
@@ -170,6 +189,15 @@ func (c *winEventLogConfig) Validate() error {
 		errs = append(errs, fmt.Errorf("event log is missing a 'name'"))
 	}
 
+	if c.Archives.Enabled {
+		switch {
+		case c.XMLQuery != "":
+			errs = append(errs, fmt.Errorf("xml_query cannot be used with 'archives.enabled'"))
+		case filepath.IsAbs(c.Name):
+			errs = append(errs, fmt.Errorf("'archives.enabled' cannot be used to read a file"))
+		}
+	}
+
 	return errs.Err()
 }
 
@@ -188,11 +216,17 @@ type winEventLog struct {
 	maxRead      int                      // Maximum number returned in one Read.
 	lastRead     checkpoint.EventLogState // Record number of the last read event.
 
-	render    func(event win.EvtHandle, out io.Writer) error // Function for rendering the event to XML.
-	message   func(event win.EvtHandle) (string, error)      // Message fallback function.
-	renderBuf []byte                                         // Buffer used for rendering event.
-	outputBuf *sys.ByteBuffer                                // Buffer for receiving XML
-	cache     *messageFilesCache                             // Cached mapping of source name to event message file handles.
+	render   func(event win.EvtHandle, buf []byte, out io.Writer) error // Function for rendering the event to XML.
+	message  func(event win.EvtHandle, buf []byte) (string, error)      // Message fallback function.
+	renderer *renderer                                                  // Buffers used when rendering inline.
+	cache    *messageFilesCache                                         // Cached mapping of source name to event message file handles.
+
+	pool *orderedPool[win.EvtHandle, renderResult] // Render workers, nil when rendering inline.
+
+	eventDataSchema *eventDataSchema // Data types of the event_data fields.
+
+	archives *archives        // Archive files of the channel, nil if not read.
+	backfill *archiveBackfill // Archived events being read, nil if none.
 
 	winMetaCache // Cached WinMeta tables by provider.
 
@@ -257,6 +291,11 @@ func newWinEventLog(options *conf.C) (EventLog, error) {
 		c.Name = filepath.Clean(c.Name)
 	}
 
+	schema, err := newEventDataSchema(c.EventDataTypes)
+	if err != nil {
+		return nil, err
+	}
+
 	l := &winEventLog{
 		id:           id,
 		config:       c,
@@ -264,27 +303,43 @@ func newWinEventLog(options *conf.C) (EventLog, error) {
 		channelName:  c.Name,
 		file:         filepath.IsAbs(c.Name),
 		maxRead:      c.BatchReadSize,
-		renderBuf:    make([]byte, renderBufferSize),
-		outputBuf:    sys.NewByteBuffer(renderBufferSize),
+		renderer:     newRenderer(),
 		cache:        newMessageFilesCache(id, eventMetadataHandle, freeHandle),
 		winMetaCache: newWinMetaCache(metaTTL),
 		logPrefix:    fmt.Sprintf("WinEventLog[%s]", id),
+
+		eventDataSchema: schema,
+	}
+
+	if c.Archives.Enabled {
+		l.archives, err = newArchives(c.Name, c.Archives, l.logPrefix)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the archives of %v: %w", c.Name, err)
+		}
+		if l.archives == nil {
+			logp.Warn("%s archives.enabled has no effect, the channel is not "+
+				"configured to archive its events (AutoBackupLogFiles).", l.logPrefix)
+		}
 	}
 
 	// Forwarded events should be rendered using RenderEventXML. It is more
 	// efficient and does not attempt to use local message files for rendering
 	// the event's message.
 	switch {
+	case c.NoMessageRendering:
+		l.render = func(event win.EvtHandle, buf []byte, out io.Writer) error {
+			return win.RenderEventXML(event, buf, out)
+		}
 	case l.isForwarded():
-		l.render = func(event win.EvtHandle, out io.Writer) error {
-			return win.RenderEventXML(event, l.renderBuf, out)
+		l.render = func(event win.EvtHandle, buf []byte, out io.Writer) error {
+			return win.RenderEventXML(event, buf, out)
 		}
 	default:
-		l.render = func(event win.EvtHandle, out io.Writer) error {
-			return win.RenderEvent(event, c.EventLanguage, l.renderBuf, l.cache.get, out)
+		l.render = func(event win.EvtHandle, buf []byte, out io.Writer) error {
+			return win.RenderEvent(event, c.EventLanguage, buf, l.cache.get, out)
 		}
-		l.message = func(event win.EvtHandle) (string, error) {
-			return win.Message(event, l.renderBuf, l.cache.get)
+		l.message = func(event win.EvtHandle, buf []byte) (string, error) {
+			return win.Message(event, buf, l.cache.get)
 		}
 	}
 
@@ -319,6 +374,8 @@ func (l *winEventLog) Open(state checkpoint.EventLogState) error {
 	if l.metrics == nil {
 		l.metrics = newInputMetrics(l.channelName, l.id)
 	}
+	// The last read record number is used to detect the archived events.
+	l.lastRead = state
 	if len(state.Bookmark) > 0 {
 		bookmark, err = win.CreateBookmarkFromXML(state.Bookmark)
 	} else if state.RecordNumber > 0 && l.channelName != "" {
@@ -429,6 +486,16 @@ func (l *winEventLog) openChannel(bookmark win.EvtHandle) error {
 }
 
 func (l *winEventLog) Read() ([]Record, error) {
+	if l.backfill != nil {
+		records := l.readBackfill()
+		l.metrics.log(records)
+		return records, nil
+	}
+
+	if l.config.RenderWorkers > 1 && l.archives == nil {
+		return l.readRendered()
+	}
+
 	handles, _, err := l.eventHandles(l.maxRead)
 	if err != nil || len(handles) == 0 {
 		return nil, err
@@ -438,49 +505,293 @@ func (l *winEventLog) Read() ([]Record, error) {
 	var records []Record
 	defer func() {
 		l.metrics.log(records)
-		for _, h := range handles {
-			win.Close(h)
-		}
 	}()
 	detailf("%s EventHandles returned %d handles", l.logPrefix, len(handles))
 
-	for _, h := range handles {
-		l.outputBuf.Reset()
-		err := l.render(h, l.outputBuf)
-		l.metrics.logError(err)
-		if err != nil && l.outputBuf.Len() == 0 {
-			logp.Err("%s Dropping event with rendering error. %v", l.logPrefix, err)
-			l.metrics.logDropped(err)
-			incrementMetric(dropReasons, err)
+	results := l.renderAll(handles)
+	for _, res := range results {
+		if !res.ok {
 			continue
 		}
+		records = append(records, res.record)
+	}
 
-		r := l.buildRecordFromXML(l.outputBuf.Bytes(), err)
-		r.Offset = checkpoint.EventLogState{
-			Name:         l.id,
-			RecordNumber: r.RecordID,
-			Timestamp:    r.TimeCreated.SystemTime,
+	if l.archives != nil && len(records) > 0 {
+		if l.startBackfill(records) {
+			records = l.readBackfill()
+			return records, nil
 		}
-		if r.Offset.Bookmark, err = l.createBookmarkFromEvent(h); err != nil {
-			l.metrics.logError(err)
-			logp.Warn("%s failed creating bookmark: %v", l.logPrefix, err)
+		l.archives.prune(l.lastRead.RecordNumber)
+	}
+	if len(records) > 0 {
+		l.lastRead = records[len(records)-1].Offset
+	}
+
+	debugf("%s Read() is returning %d records", l.logPrefix, len(records))
+	return records, nil
+}
+
+// readRendered returns the events rendered by the render workers, in order,
+// as soon as the events before them are rendered. New events are read while
+// fewer than batch_read_size events are being rendered, so a slow event only
+// delays the events after it.
+func (l *winEventLog) readRendered() ([]Record, error) {
+	//nolint:prealloc // Avoid unnecessary preallocation for each reader every second when event log is inactive.
+	var records []Record
+	defer func() {
+		l.metrics.log(records)
+	}()
+
+	var pending int
+	if l.pool != nil {
+		pending = l.pool.Pending()
+	}
+	if pending < l.maxRead {
+		handles, _, err := l.eventHandles(l.maxRead - pending)
+		if l.pool == nil {
+			// Not started yet, or stopped by the recovery of eventHandles.
+			l.startRenderWorkers()
 		}
-		if r.Message == "" && l.message != nil {
-			r.Message, err = l.message(h)
-			if err != nil {
-				l.metrics.logError(err)
-				logp.Warn("%s error salvaging message (event id=%d qualifier=%d provider=%q created at %s will be included without a message): %v",
-					l.logPrefix, r.EventIdentifier.ID, r.EventIdentifier.Qualifiers, r.Provider.Name, r.TimeCreated.SystemTime, err)
-			}
+		switch {
+		case errors.Is(err, io.EOF) && l.pool.Pending() > 0:
+			// Return the events being rendered first.
+		case err != nil:
+			// The events being rendered are read again when the
+			// subscription is reopened after the last returned event.
+			l.pool.All()
+			return nil, err
+		}
+		detailf("%s EventHandles returned %d handles", l.logPrefix, len(handles))
+		l.pool.Submit(handles...)
+	}
+
+	for _, res := range l.pool.Next() {
+		if !res.ok {
+			continue
 		}
-		records = append(records, r)
-		l.lastRead = r.Offset
+		records = append(records, res.record)
+	}
+	if len(records) > 0 {
+		l.lastRead = records[len(records)-1].Offset
 	}
 
 	debugf("%s Read() is returning %d records", l.logPrefix, len(records))
 	return records, nil
 }
 
+// startBackfill starts reading the archive files if the events between the
+// last read event and the first event of live were archived before being
+// read. It returns false if no events are missing.
+func (l *winEventLog) startBackfill(live []Record) bool {
+	last := l.lastRead.RecordNumber
+	first := live[0].RecordID
+	if last == 0 || first <= last+1 {
+		return false
+	}
+
+	// The events in between may also have been filtered out by the query,
+	// they are only missing if they are no longer in the channel.
+	oldest, err := oldestRecordNumber(l.channelName)
+	if err != nil {
+		l.metrics.logError(err)
+		logp.Warn("%s Failed to get the oldest record number: %v", l.logPrefix, err)
+		return false
+	}
+	if oldest <= last+1 {
+		return false
+	}
+
+	from, to := last+1, min(oldest, first)-1
+	files, err := l.archives.lookup(from, to)
+	if err != nil {
+		l.metrics.logError(err)
+		logp.Warn("%s Failed to list archive files, events %d to %d may be missing: %v", l.logPrefix, from, to, err)
+		return false
+	}
+	if len(files) == 0 {
+		logp.Warn("%s Events %d to %d were removed from the channel before being read "+
+			"and are not in an archive file", l.logPrefix, from, to)
+		return false
+	}
+
+	logp.Info("%s Reading events %d to %d from %d archive files", l.logPrefix, from, to, len(files))
+	l.backfill = &archiveBackfill{files: files, from: from, to: to, live: live}
+	return true
+}
+
+// readBackfill returns the next batch of archived events. The live events
+// are returned after the last archived events.
+func (l *winEventLog) readBackfill() []Record {
+	b := l.backfill
+	records, err := l.readArchives(b)
+	if err != nil {
+		l.metrics.logError(err)
+		logp.Warn("%s Failed to read archived events, events %d to %d may be missing: %v", l.logPrefix, b.from, b.to, err)
+	}
+	if err != nil || len(b.files) == 0 {
+		records = append(records, b.live...)
+		b.close()
+		l.backfill = nil
+	}
+	if len(records) > 0 {
+		l.lastRead = records[len(records)-1].Offset
+	}
+	return records
+}
+
+// readArchives reads the next archived events of b, skipping the events
+// that are not missing.
+func (l *winEventLog) readArchives(b *archiveBackfill) ([]Record, error) {
+	for len(b.files) > 0 {
+		file := b.files[0]
+		if b.handle == 0 {
+			query, err := win.Query{
+				Log:         file.path,
+				IgnoreOlder: l.config.SimpleQuery.IgnoreOlder,
+				Level:       l.config.SimpleQuery.Level,
+				EventID:     l.config.SimpleQuery.EventID,
+				Provider:    l.config.SimpleQuery.Provider,
+			}.Build()
+			if err != nil {
+				return nil, err
+			}
+			b.handle, err = win.EvtQuery(0, file.path, query, win.EvtQueryFilePath|win.EvtQueryForwardDirection)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get handle to archive file %v: %w", file.path, err)
+			}
+		}
+
+		handles, err := win.EventHandles(b.handle, l.maxRead)
+		if errors.Is(err, win.ERROR_NO_MORE_ITEMS) || (err == nil && len(handles) == 0) {
+			b.close()
+			b.files = b.files[1:]
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		results := l.renderAll(handles)
+		var records []Record
+		for _, res := range results {
+			rec := res.record
+			if !res.ok || rec.RecordID < b.from || rec.RecordID > b.to {
+				continue
+			}
+			rec.File = file.path
+			// The bookmark of an archived event refers to the archive file,
+			// the checkpoint only keeps its record number in the channel.
+			rec.Offset.Bookmark = ""
+			records = append(records, rec)
+		}
+		if len(records) > 0 {
+			return records, nil
+		}
+	}
+	return nil, nil
+}
+
+// renderResult is the outcome of rendering a single event.
+type renderResult struct {
+	record Record
+	ok     bool // False if the event was dropped.
+}
+
+// renderer holds the buffers used to render events. Buffers cannot be
+// shared, so every render worker owns a renderer.
+type renderer struct {
+	renderBuf []byte          // Buffer used for rendering event.
+	outputBuf *sys.ByteBuffer // Buffer for receiving XML
+}
+
+func newRenderer() *renderer {
+	return &renderer{
+		renderBuf: make([]byte, renderBufferSize),
+		outputBuf: sys.NewByteBuffer(renderBufferSize),
+	}
+}
+
+// renderAll renders the events of handles and closes them, preserving
+// their order. The events are rendered concurrently by the render workers if
+// configured.
+func (l *winEventLog) renderAll(handles []win.EvtHandle) []renderResult {
+	if l.config.RenderWorkers <= 1 {
+		results := make([]renderResult, len(handles))
+		for i, h := range handles {
+			results[i] = l.renderEvent(h, l.renderer)
+			win.Close(h)
+		}
+		return results
+	}
+
+	if l.pool == nil {
+		l.startRenderWorkers()
+	}
+	l.pool.Submit(handles...)
+	return l.pool.All()
+}
+
+// startRenderWorkers starts the render workers. They run until
+// stopRenderWorkers is called.
+func (l *winEventLog) startRenderWorkers() {
+	size := l.config.RenderQueueSize
+	if size == 0 {
+		size = l.maxRead
+	}
+	l.pool = newOrderedPool(l.config.RenderWorkers, size, func() func(win.EvtHandle) renderResult {
+		r := newRenderer()
+		return func(h win.EvtHandle) renderResult {
+			defer win.Close(h)
+			return l.renderEvent(h, r)
+		}
+	})
+	debugf("%s Started %d render workers", l.logPrefix, l.config.RenderWorkers)
+}
+
+// stopRenderWorkers stops the render workers. The events being rendered are
+// discarded.
+func (l *winEventLog) stopRenderWorkers() {
+	if l.pool == nil {
+		return
+	}
+	l.pool.Stop()
+	l.pool = nil
+}
+
+// renderEvent renders the event of h into a Record using the buffers of r.
+// It is safe to call concurrently with distinct renderers.
+func (l *winEventLog) renderEvent(h win.EvtHandle, r *renderer) renderResult {
+	r.outputBuf.Reset()
+	err := l.render(h, r.renderBuf, r.outputBuf)
+	l.metrics.logError(err)
+	if err != nil && r.outputBuf.Len() == 0 {
+		logp.Err("%s Dropping event with rendering error. %v", l.logPrefix, err)
+		l.metrics.logDropped(err)
+		incrementMetric(dropReasons, err)
+		return renderResult{}
+	}
+
+	rec := l.buildRecordFromXML(r.outputBuf.Bytes(), err)
+	rec.Offset = checkpoint.EventLogState{
+		Name:         l.id,
+		RecordNumber: rec.RecordID,
+		Timestamp:    rec.TimeCreated.SystemTime,
+	}
+	if rec.Offset.Bookmark, err = l.createBookmarkFromEvent(h, r); err != nil {
+		l.metrics.logError(err)
+		logp.Warn("%s failed creating bookmark: %v", l.logPrefix, err)
+	}
+	if rec.Message == "" && l.message != nil {
+		rec.Message, err = l.message(h, r.renderBuf)
+		if err != nil {
+			l.metrics.logError(err)
+			logp.Warn("%s error salvaging message (event id=%d qualifier=%d provider=%q created at %s will be included without a message): %v",
+				l.logPrefix, rec.EventIdentifier.ID, rec.EventIdentifier.Qualifiers, rec.Provider.Name, rec.TimeCreated.SystemTime, err)
+		}
+	}
+	return renderResult{record: rec, ok: true}
+}
+
 func (l *winEventLog) eventHandles(maxRead int) ([]win.EvtHandle, int, error) {
 	handles, err := win.EventHandles(l.subscription, maxRead)
 	switch err { //nolint:errorlint // This is an errno or nil.
@@ -537,8 +848,13 @@ func (l *winEventLog) buildRecordFromXML(x []byte, recoveredErr error) Record {
 		e.RenderErr = append(e.RenderErr, recoveredErr.Error())
 	}
 
-	// Get basic string values for raw fields.
-	winevent.EnrichRawValuesWithNames(l.winMeta(e.Provider.Name), &e)
+	if l.config.NoMessageRendering {
+		// Forwarded events may contain a rendered message.
+		e.Message = ""
+	} else {
+		// Get basic string values for raw fields.
+		winevent.EnrichRawValuesWithNames(l.winMeta(e.Provider.Name), &e)
+	}
 	if e.Level == "" {
 		// Fallback on LevelRaw if the Level is not set in the RenderingInfo.
 		e.Level = win.EventLevel(e.LevelRaw).String()
@@ -561,18 +877,24 @@ func (l *winEventLog) buildRecordFromXML(x []byte, recoveredErr error) Record {
 		r.XML = string(x)
 	}
 
+	r.eventDataSchema = l.eventDataSchema
+
+	if err := r.setRawXML(l.config.RawXML, x); err != nil {
+		logp.Warn("%s failed to encode raw XML: %v", l.logPrefix, err)
+	}
+
 	return r
 }
 
-func (l *winEventLog) createBookmarkFromEvent(evtHandle win.EvtHandle) (string, error) {
+func (l *winEventLog) createBookmarkFromEvent(evtHandle win.EvtHandle, r *renderer) (string, error) {
 	bmHandle, err := win.CreateBookmarkFromEvent(evtHandle)
 	if err != nil {
 		return "", err
 	}
-	l.outputBuf.Reset()
-	err = win.RenderBookmarkXML(bmHandle, l.renderBuf, l.outputBuf)
+	r.outputBuf.Reset()
+	err = win.RenderBookmarkXML(bmHandle, r.renderBuf, r.outputBuf)
 	win.Close(bmHandle)
-	return string(l.outputBuf.Bytes()), err
+	return string(r.outputBuf.Bytes()), err
 }
 
 func (l *winEventLog) Reset() error {
@@ -582,6 +904,11 @@ func (l *winEventLog) Reset() error {
 
 func (l *winEventLog) Close() error {
 	debugf("%s Closing handle", l.logPrefix)
+	if l.backfill != nil {
+		l.backfill.close()
+		l.backfill = nil
+	}
+	l.stopRenderWorkers()
 	l.metrics.close()
 	return win.Close(l.subscription)
 }
@@ -30,6 +30,7 @@ import (
 
 	"github.com/elastic/beats/v7/libbeat/common/cfgwarn"
 	"github.com/elastic/beats/v7/winlogbeat/checkpoint"
+	"github.com/elastic/beats/v7/winlogbeat/sys"
 	win "github.com/elastic/beats/v7/winlogbeat/sys/wineventlog"
 	conf "github.com/elastic/elastic-agent-libs/config"
 	"github.com/elastic/elastic-agent-libs/logp"
@@ -64,6 +65,11 @@ type winEventLogExp struct {
 	iterator *win.EventIterator
 	renderer *win.Renderer
 
+	renderBuf []byte          // Buffer used for rendering raw XML.
+	outputBuf *sys.ByteBuffer // Buffer for receiving raw XML.
+
+	eventDataSchema *eventDataSchema // Data types of the event_data fields.
+
 	metrics *inputMetrics
 }
 
@@ -115,7 +121,16 @@ func newWinEventLogExp(options *conf.C) (EventLog, error) {
 		log = logp.NewLogger("wineventlog").With("id", id).With("channel", c.Name)
 	}
 
-	renderer, err := win.NewRenderer(win.NilHandle, log)
+	schema, err := newEventDataSchema(c.EventDataTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	var renderOpts []win.RendererOption
+	if c.NoMessageRendering {
+		renderOpts = append(renderOpts, win.WithoutMessage())
+	}
+	renderer, err := win.NewRenderer(win.NilHandle, log, renderOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -129,6 +144,8 @@ func newWinEventLogExp(options *conf.C) (EventLog, error) {
 		maxRead:     c.BatchReadSize,
 		renderer:    renderer,
 		log:         log,
+
+		eventDataSchema: schema,
 	}
 
 	return l, nil
@@ -323,6 +340,15 @@ func (l *winEventLogExp) processHandle(h win.EvtHandle) (*Record, error) {
 	r := &Record{
 		API:   winEventLogExpAPIName,
 		Event: *evt,
+
+		eventDataSchema: l.eventDataSchema,
+	}
+
+	if l.config.RawXML.Enabled {
+		if err := l.renderRawXML(r, h); err != nil {
+			l.metrics.logError(err)
+			l.log.Warnw("Failed rendering raw XML.", "error", err)
+		}
 	}
 
 	if l.file {
@@ -342,6 +368,20 @@ func (l *winEventLogExp) processHandle(h win.EvtHandle) (*Record, error) {
 	return r, nil
 }
 
+// renderRawXML renders the event XML including the RenderingInfo, as it
+// would be forwarded by Windows Event Forwarding, into r.
+func (l *winEventLogExp) renderRawXML(r *Record, h win.EvtHandle) error {
+	if l.outputBuf == nil {
+		l.renderBuf = make([]byte, renderBufferSize)
+		l.outputBuf = sys.NewByteBuffer(renderBufferSize)
+	}
+	l.outputBuf.Reset()
+	if err := win.RenderEvent(h, l.config.EventLanguage, l.renderBuf, nil, l.outputBuf); err != nil {
+		return fmt.Errorf("failed to render event XML: %w", err)
+	}
+	return r.setRawXML(l.config.RawXML, l.outputBuf.Bytes())
+}
+
 func (l *winEventLogExp) createBookmarkFromEvent(evtHandle win.EvtHandle) (string, error) {
 	bookmark, err := win.NewBookmarkFromEvent(evtHandle)
 	if err != nil {
@@ -153,6 +153,37 @@ func TestWinEventLogConfig_Validate(t *testing.T) {
 			WantErr: true,
 			Desc:    "missing name",
 		},
+		{
+			In: winEventLogConfig{
+				ConfigCommon: ConfigCommon{
+					ID:       "test",
+					XMLQuery: customXMLQuery,
+				},
+				Archives: ArchivesConfig{Enabled: true},
+			},
+			WantErr: true,
+			Desc:    "archives: conflicting keys (xml query and archives.enabled)",
+		},
+		{
+			In: winEventLogConfig{
+				ConfigCommon: ConfigCommon{
+					Name: `C:\logs\security.evtx`,
+				},
+				Archives: ArchivesConfig{Enabled: true},
+			},
+			WantErr: true,
+			Desc:    "archives: reading a file",
+		},
+		{
+			In: winEventLogConfig{
+				ConfigCommon: ConfigCommon{
+					Name: "Security",
+				},
+				Archives: ArchivesConfig{Enabled: true},
+			},
+			WantErr: false,
+			Desc:    "archives: all good",
+		},
 	}
 
 	for _, tc := range tests {
@@ -205,6 +236,22 @@ func testWindowsEventLog(t *testing.T, api string) {
 		}
 	})
 
+	t.Run("no_message_rendering", func(t *testing.T) {
+		log := openLog(t, map[string]interface{}{"name": providerName, "batch_read_size": 1, "no_message_rendering": true})
+		defer log.Close()
+
+		records, err := log.Read()
+		require.NoError(t, err)
+		require.NotEmpty(t, records)
+
+		r := records[0]
+		assert.Empty(t, r.Message)
+		assert.Equal(t, providerName, r.Provider.Name)
+		assert.NotZero(t, r.EventIdentifier.ID)
+		assert.NotEmpty(t, r.Level)
+		assert.NotEmpty(t, r.EventData.Pairs)
+	})
+
 	// Test reading from an event log using a custom XML query.
 	t.Run("custom_xml_query", func(t *testing.T) {
 		cfg := map[string]interface{}{
@@ -48,11 +48,24 @@ type Renderer struct {
 	session       EvtHandle // Session handle if working with remote log.
 	systemContext EvtHandle // Render context for system values.
 	userContext   EvtHandle // Render context for user values (event data).
+	noMessage     bool      // Do not render the message and the names of raw values.
 	log           *logp.Logger
 }
 
+// RendererOption represents a configuration of the Renderer.
+type RendererOption func(*Renderer)
+
+// WithoutMessage configures the Renderer to not render the event messages nor
+// the names of the task, opcode and keywords, which is the most expensive
+// part of rendering an event.
+func WithoutMessage() RendererOption {
+	return func(r *Renderer) {
+		r.noMessage = true
+	}
+}
+
 // NewRenderer returns a new Renderer.
-func NewRenderer(session EvtHandle, log *logp.Logger) (*Renderer, error) {
+func NewRenderer(session EvtHandle, log *logp.Logger, options ...RendererOption) (*Renderer, error) {
 	systemContext, err := _EvtCreateRenderContext(0, nil, EvtRenderContextSystem)
 	if err != nil {
 		return nil, fmt.Errorf("failed in EvtCreateRenderContext for system context: %w", err)
@@ -63,13 +76,17 @@ func NewRenderer(session EvtHandle, log *logp.Logger) (*Renderer, error) {
 		return nil, fmt.Errorf("failed in EvtCreateRenderContext for user context: %w", err)
 	}
 
-	return &Renderer{
+	r := &Renderer{
 		metadataCache: map[string]*PublisherMetadataStore{},
 		session:       session,
 		systemContext: systemContext,
 		userContext:   userContext,
 		log:           log.Named("renderer"),
-	}, nil
+	}
+	for _, opt := range options {
+		opt(r)
+	}
+	return r, nil
 }
 
 // Close closes all handles held by the Renderer.
@@ -107,8 +124,12 @@ func (r *Renderer) Render(handle EvtHandle) (*winevent.Event, error) {
 		errs = append(errs, err)
 	}
 
-	// Associate raw system properties to names (e.g. level=2 to Error).
-	winevent.EnrichRawValuesWithNames(&md.WinMeta, event)
+	if r.noMessage {
+		event.Level = EventLevel(event.LevelRaw).String()
+	} else {
+		// Associate raw system properties to names (e.g. level=2 to Error).
+		winevent.EnrichRawValuesWithNames(&md.WinMeta, event)
+	}
 
 	eventData, fingerprint, err := r.renderUser(handle, event)
 	if err != nil {
@@ -121,8 +142,10 @@ func (r *Renderer) Render(handle EvtHandle) (*winevent.Event, error) {
 	// Associate key names with the event data values.
 	r.addEventData(eventMeta, eventData, event)
 
-	if event.Message, err = r.formatMessage(md, eventMeta, handle, eventData, uint16(event.EventIdentifier.ID)); err != nil {
-		errs = append(errs, fmt.Errorf("failed to get the event message string: %w", err))
+	if !r.noMessage {
+		if event.Message, err = r.formatMessage(md, eventMeta, handle, eventData, uint16(event.EventIdentifier.ID)); err != nil {
+			errs = append(errs, fmt.Errorf("failed to get the event message string: %w", err))
+		}
 	}
 
 	if len(errs) > 0 {
@@ -148,6 +148,35 @@ func TestRenderer(t *testing.T) {
 			logAsJSON(t, events)
 		}
 	})
+
+	t.Run("without_message", func(t *testing.T) {
+		log := openLog(t, winErrorReportingFile)
+		defer log.Close()
+
+		r, err := NewRenderer(NilHandle, logp.L(), WithoutMessage())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Close()
+
+		events := renderAllEvents(t, log, r, false)
+		if !assert.Len(t, events, 1) {
+			return
+		}
+		e := events[0]
+
+		assert.EqualValues(t, 1001, e.EventIdentifier.ID)
+		assert.Equal(t, "Windows Error Reporting", e.Provider.Name)
+		assert.Equal(t, "Information", e.Level)
+		assert.Empty(t, e.Keywords)
+		assert.Empty(t, e.Task)
+		assert.Len(t, e.EventData.Pairs, 23)
+		assert.Empty(t, e.Message)
+
+		if t.Failed() {
+			logAsJSON(t, events)
+		}
+	})
 }
 
 func TestTemplateFunc(t *testing.T) {
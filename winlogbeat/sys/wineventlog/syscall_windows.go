@@ -611,6 +611,84 @@ func EvtGetObjectArrayProperty(arrayHandle EvtObjectArrayPropertyHandle, propert
 	return value, nil
 }
 
+// EvtLogPropertyID defines the identifiers of the properties of a channel or
+// log file. This maps to EVT_LOG_PROPERTY_ID in Windows.
+type EvtLogPropertyID uint32
+
+const (
+	EvtLogCreationTime EvtLogPropertyID = iota
+	EvtLogLastAccessTime
+	EvtLogLastWriteTime
+	EvtLogFileSize
+	EvtLogAttributes
+	EvtLogNumberOfLogRecords
+	EvtLogOldestRecordNumber
+	EvtLogFull
+)
+
+// EvtGetLogInfo returns the value of a property of the channel or log file
+// opened with EvtOpenLog.
+func EvtGetLogInfo(logHandle EvtHandle, propertyID EvtLogPropertyID) (interface{}, error) {
+	var bufferUsed uint32
+	err := _EvtGetLogInfo(logHandle, propertyID, 0, nil, &bufferUsed)
+	if err != windows.ERROR_INSUFFICIENT_BUFFER { //nolint:errorlint // Bad linter! This is always errno or nil.
+		return nil, fmt.Errorf("failed in EvtGetLogInfo, expected ERROR_INSUFFICIENT_BUFFER: %w", err)
+	}
+
+	buf := make([]byte, bufferUsed)
+	pEvtVariant := (*EvtVariant)(unsafe.Pointer(&buf[0]))
+	err = _EvtGetLogInfo(logHandle, propertyID, uint32(len(buf)), pEvtVariant, &bufferUsed)
+	if err != nil {
+		return nil, fmt.Errorf("failed in EvtGetLogInfo: %w", err)
+	}
+	return pEvtVariant.Data(buf)
+}
+
+// EvtChannelConfigPropertyID defines the identifiers of the configuration
+// properties of a channel. This maps to EVT_CHANNEL_CONFIG_PROPERTY_ID in
+// Windows.
+type EvtChannelConfigPropertyID uint32
+
+const (
+	EvtChannelConfigEnabled EvtChannelConfigPropertyID = iota
+	EvtChannelConfigIsolation
+	EvtChannelConfigType
+	EvtChannelConfigOwningPublisher
+	EvtChannelConfigClassicEventlog
+	EvtChannelConfigAccess
+	EvtChannelLoggingConfigRetention
+	EvtChannelLoggingConfigAutoBackup
+	EvtChannelLoggingConfigMaxSize
+	EvtChannelLoggingConfigLogFilePath
+)
+
+// EvtOpenChannelConfig gets a handle to the configuration of a channel.
+func EvtOpenChannelConfig(session EvtHandle, channelPath string) (EvtHandle, error) {
+	path, err := syscall.UTF16PtrFromString(channelPath)
+	if err != nil {
+		return 0, err
+	}
+	return _EvtOpenChannelConfig(session, path, 0)
+}
+
+// EvtGetChannelConfigProperty returns the value of a configuration property
+// of the channel opened with EvtOpenChannelConfig.
+func EvtGetChannelConfigProperty(channelConfig EvtHandle, propertyID EvtChannelConfigPropertyID) (interface{}, error) {
+	var bufferUsed uint32
+	err := _EvtGetChannelConfigProperty(channelConfig, propertyID, 0, 0, nil, &bufferUsed)
+	if err != windows.ERROR_INSUFFICIENT_BUFFER { //nolint:errorlint // Bad linter! This is always errno or nil.
+		return nil, fmt.Errorf("failed in EvtGetChannelConfigProperty, expected ERROR_INSUFFICIENT_BUFFER: %w", err)
+	}
+
+	buf := make([]byte, bufferUsed)
+	pEvtVariant := (*EvtVariant)(unsafe.Pointer(&buf[0]))
+	err = _EvtGetChannelConfigProperty(channelConfig, propertyID, 0, uint32(len(buf)), pEvtVariant, &bufferUsed)
+	if err != nil {
+		return nil, fmt.Errorf("failed in EvtGetChannelConfigProperty: %w", err)
+	}
+	return pEvtVariant.Data(buf)
+}
+
 type EvtObjectArrayPropertyHandle uint32
 
 func (h EvtObjectArrayPropertyHandle) Close() error {
@@ -683,5 +761,8 @@ func EvtClearLog(session EvtHandle, channelPath string, targetFilePath string) e
 //sys   _EvtNextEventMetadata(enumerator EvtHandle, flags uint32) (handle EvtHandle, err error) = wevtapi.EvtNextEventMetadata
 //sys   _EvtGetObjectArrayProperty(objectArray EvtObjectArrayPropertyHandle, propertyID EvtPublisherMetadataPropertyID, arrayIndex uint32, flags uint32, bufferSize uint32, evtVariant *EvtVariant, bufferUsed *uint32) (err error) = wevtapi.EvtGetObjectArrayProperty
 //sys   _EvtGetObjectArraySize(objectArray EvtObjectArrayPropertyHandle, arraySize *uint32) (err error) = wevtapi.EvtGetObjectArraySize
+//sys   _EvtGetLogInfo(log EvtHandle, propertyID EvtLogPropertyID, bufferSize uint32, variant *EvtVariant, bufferUsed *uint32) (err error) = wevtapi.EvtGetLogInfo
+//sys   _EvtOpenChannelConfig(session EvtHandle, channelPath *uint16, flags uint32) (handle EvtHandle, err error) = wevtapi.EvtOpenChannelConfig
+//sys   _EvtGetChannelConfigProperty(channelConfig EvtHandle, propertyID EvtChannelConfigPropertyID, flags uint32, bufferSize uint32, variant *EvtVariant, bufferUsed *uint32) (err error) = wevtapi.EvtGetChannelConfigProperty
 //sys   _EvtOpenPublisherEnum(session EvtHandle, flags uint32) (handle EvtHandle, err error) = wevtapi.EvtOpenPublisherEnum
 //sys   _EvtNextPublisherId(enumerator EvtHandle, bufferSize uint32, buffer *uint16, bufferUsed *uint32) (err error) = wevtapi.EvtNextPublisherId
@@ -74,7 +74,9 @@ var (
 	procEvtCreateBookmark               = modwevtapi.NewProc("EvtCreateBookmark")
 	procEvtCreateRenderContext          = modwevtapi.NewProc("EvtCreateRenderContext")
 	procEvtFormatMessage                = modwevtapi.NewProc("EvtFormatMessage")
+	procEvtGetChannelConfigProperty     = modwevtapi.NewProc("EvtGetChannelConfigProperty")
 	procEvtGetEventMetadataProperty     = modwevtapi.NewProc("EvtGetEventMetadataProperty")
+	procEvtGetLogInfo                   = modwevtapi.NewProc("EvtGetLogInfo")
 	procEvtGetObjectArrayProperty       = modwevtapi.NewProc("EvtGetObjectArrayProperty")
 	procEvtGetObjectArraySize           = modwevtapi.NewProc("EvtGetObjectArraySize")
 	procEvtGetPublisherMetadataProperty = modwevtapi.NewProc("EvtGetPublisherMetadataProperty")
@@ -82,6 +84,7 @@ var (
 	procEvtNextChannelPath              = modwevtapi.NewProc("EvtNextChannelPath")
 	procEvtNextEventMetadata            = modwevtapi.NewProc("EvtNextEventMetadata")
 	procEvtNextPublisherId              = modwevtapi.NewProc("EvtNextPublisherId")
+	procEvtOpenChannelConfig            = modwevtapi.NewProc("EvtOpenChannelConfig")
 	procEvtOpenChannelEnum              = modwevtapi.NewProc("EvtOpenChannelEnum")
 	procEvtOpenEventMetadataEnum        = modwevtapi.NewProc("EvtOpenEventMetadataEnum")
 	procEvtOpenLog                      = modwevtapi.NewProc("EvtOpenLog")
@@ -136,6 +139,14 @@ func _EvtFormatMessage(publisherMetadata EvtHandle, event EvtHandle, messageID u
 	return
 }
 
+func _EvtGetChannelConfigProperty(channelConfig EvtHandle, propertyID EvtChannelConfigPropertyID, flags uint32, bufferSize uint32, variant *EvtVariant, bufferUsed *uint32) (err error) {
+	r1, _, e1 := syscall.Syscall6(procEvtGetChannelConfigProperty.Addr(), 6, uintptr(channelConfig), uintptr(propertyID), uintptr(flags), uintptr(bufferSize), uintptr(unsafe.Pointer(variant)), uintptr(unsafe.Pointer(bufferUsed)))
+	if r1 == 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
 func _EvtGetEventMetadataProperty(eventMetadata EvtHandle, propertyID EvtEventMetadataPropertyID, flags uint32, bufferSize uint32, variant *EvtVariant, bufferUsed *uint32) (err error) {
 	r1, _, e1 := syscall.Syscall6(procEvtGetEventMetadataProperty.Addr(), 6, uintptr(eventMetadata), uintptr(propertyID), uintptr(flags), uintptr(bufferSize), uintptr(unsafe.Pointer(variant)), uintptr(unsafe.Pointer(bufferUsed)))
 	if r1 == 0 {
@@ -144,6 +155,14 @@ func _EvtGetEventMetadataProperty(eventMetadata EvtHandle, propertyID EvtEventMe
 	return
 }
 
+func _EvtGetLogInfo(log EvtHandle, propertyID EvtLogPropertyID, bufferSize uint32, variant *EvtVariant, bufferUsed *uint32) (err error) {
+	r1, _, e1 := syscall.Syscall6(procEvtGetLogInfo.Addr(), 5, uintptr(log), uintptr(propertyID), uintptr(bufferSize), uintptr(unsafe.Pointer(variant)), uintptr(unsafe.Pointer(bufferUsed)), 0)
+	if r1 == 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
 func _EvtGetObjectArrayProperty(objectArray EvtObjectArrayPropertyHandle, propertyID EvtPublisherMetadataPropertyID, arrayIndex uint32, flags uint32, bufferSize uint32, evtVariant *EvtVariant, bufferUsed *uint32) (err error) {
 	r1, _, e1 := syscall.Syscall9(procEvtGetObjectArrayProperty.Addr(), 7, uintptr(objectArray), uintptr(propertyID), uintptr(arrayIndex), uintptr(flags), uintptr(bufferSize), uintptr(unsafe.Pointer(evtVariant)), uintptr(unsafe.Pointer(bufferUsed)), 0, 0)
 	if r1 == 0 {
@@ -201,6 +220,15 @@ func _EvtNextPublisherId(enumerator EvtHandle, bufferSize uint32, buffer *uint16
 	return
 }
 
+func _EvtOpenChannelConfig(session EvtHandle, channelPath *uint16, flags uint32) (handle EvtHandle, err error) {
+	r0, _, e1 := syscall.Syscall(procEvtOpenChannelConfig.Addr(), 3, uintptr(session), uintptr(unsafe.Pointer(channelPath)), uintptr(flags))
+	handle = EvtHandle(r0)
+	if handle == 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
 func _EvtOpenChannelEnum(session EvtHandle, flags uint32) (handle EvtHandle, err error) {
 	r0, _, e1 := syscall.Syscall(procEvtOpenChannelEnum.Addr(), 2, uintptr(session), uintptr(flags), 0)
 	handle = EvtHandle(r0)
@@ -13,6 +13,7 @@ import (
 	// Import packages that perform 'func init()'.
 	_ "github.com/elastic/beats/v7/x-pack/auditbeat/module/system"
 	_ "github.com/elastic/beats/v7/x-pack/auditbeat/module/system/host"
+	_ "github.com/elastic/beats/v7/x-pack/auditbeat/module/system/kernel_module"
 	_ "github.com/elastic/beats/v7/x-pack/auditbeat/module/system/login"
 	_ "github.com/elastic/beats/v7/x-pack/auditbeat/module/system/package"
 	_ "github.com/elastic/beats/v7/x-pack/auditbeat/module/system/process"
@@ -19,5 +19,5 @@ func init() {
 // AssetSystem returns asset data.
 // This is the base64 encoded zlib format compressed contents of module/system.
 func AssetSystem() string {
-	return "eJy8Wm1v27oV/u5fcRAMaILrKotvExT+MMBt7pZg7W0wp0C3uzubEo8lLhSpkVQSFfvxA6kXSzZlW4k7A0VjmXyec57z4kPJb+EBiynoQhtMRwCGGY5TOJm7CycjAIo6UiwzTIop/GkEAHCfoEYgCsEkCCuGnGqIUaAiBimEhbteYkIqac4xGAEo5Eg0TiFEQ0ZQbZyORgBvQZAUp4CPKIzjMEWGU4iVzDP3vl5s/65XS8ViJtylesMDFk9S0eqax3b7+uL2gVw5Ox1nAPcJ0xARASECgRXjCBkxCZxiEAewPH8k6pzL2P4LLpZn4wZNKgdjTaohK9cjmWZSoDBgEmJA51nGGVK3hBJDamyBhjPxsDwL2lrkGtXBUqAwzBQLRoercXsNuWD/yZEXwKgFWhVMxM5KawNIAQQSqU0AtwasSjLNchtpooHA/Gb2dnJ5BQnRyVqUUgi7C26vxyWQ/YMIWr6xdgcdHwyqlAnCh7twX+2saS1BR8tMyQi1PlhOkygkNIhIRkLGmWGoA1ytMDLsEStajo/Ip4DPBgXFXcKzWEiFCxLKR5zCxR8n73zuuARkukwgNNaXNr91qqmtB1QCORgJGaqVVKn9P2VaMykaVaIEowcNqypBK5+qj/GZpJkt9Te/nXyc3S0+3P35ZAzuz/nf54vZ9efbX09+f1OtzogxqMQU/nVqV/w2e/uPxe8//fef9KezPzS+rEjOzcLJOYUV4Rr3auqsNqZR70dpSoCzlBmb1jrPUFl9a12auHbltiXbSLnWD1JSANE6r7P3/yVlR8tWrW2mc2+R3BCdoG663jNGuSEhR9v6bEYV2rV0wmOpmElSR6VdwdoNj4Tn6JZ0VEnwGVBEkiIFymLUploZjKp17fpaexBy8oCTcDG5vFrjeeK84c6HT7O//jIJm4bjcWfUw/Tz+3cvYfr5/buhTJcXk5cwXV5MDmXSCZlMBrkzv5lNJgd7ohMyUK75zWyAUhZ/MdwDt2cYx7D0KjkOzy3H8QKlFkO1GphSjmNYPl1eTF4QkcuLyfmwmDiewVFxPIfH5fk5uRrkyrdvVzudaBxwk11Acsr8c6qn+XYbYKuJS73+hvE18h68+rW0AEuIpDCEiXoC5+XQxYQdC4jdF7R2bc7g9WvTxtYYmhmWYoe4tJRLEXcul4RToLlyvJ0Pmchys6iXCCKkxkgKqjurZG7ay4i+JoV3RaYwYtqJctH5fIde9vXVeQNMtE0IPG6HUpoexykxOITzg5QGLJaPp4oeKvYdqYcslJIjEUP45miArao0sLNPw+EzwBr2XQoM7FuPAZtlc4ABv7aOQjV8+0QwBnfu+TC/32mQXK00mkBjdEj27bHpfm2HRbUZsCP61srj6XFTofmYmC/oL+SA22sfBVFRwgxGJldHdKgDW51kn99fLa7enfmMSIkvii/g/jz7CIRShVqjN3Ys8xBtXNzDcXu3m0JqD8Vm597DspS61btb7RpIKHPjikVmaFupPbWU3zvdfrvVs9ttheJWAu9Sfa8mX+YN6Ni2FyKKKuraKDRRchZ4Lck4Mda3o1pSg1YWRCiM1GPIw1yYfAxPTFD5pHssOrou7tZPaclnEtkr33qoVyRlvDgqeQlZ0SukCTFjoBgyIsawUoihpvsUeUSlN7+wX2tXheknLO9fHI/v3lMsb3R9m2SnKZb1qI7b3XCqEeGXj3OQOrAXWsI3hUGiBxLjqybACmNnIyECmNCGcI4UpAKFqXxEWvO/bjrcvO+4T8Cd8u26E1lbu/cWZDVobEWmuRVZIUHZyYholPDlSW/PeKGLdy1yH4+vEl9JtcOrKt7HZKsg++aQY1K1BxAfH2cRiuN6V0F6x46yxo5yZqjpKszew4Nm3w86mR1EZsG8JHmaElW8ALDc6MPMFT9mWL7+7dN2f22en7QphjRXC7B3RLOLdPmIZHtGO7yf/qjpBOBr92HLlkpsE/H1bN1jyJorPi7XX2wse8koU8d27I2GRKZooTEyspva7ZtcyI842wDcKRkrkoKRoHIBxACXMeuZZ2xCLlq5elTFqztM7gFe+w4TfBHwiYn8eQym9RgrxkjqMtt7MmLrzFRbKMN/Y2SGGbh0cHuGoaIk1evnm0xDRpR74HYaYiGrBx55GfFMMdvFyl0b87O/kmF3Ne+LwkGRgCb/t0sbdpbcmp4JgzFuVslA+r7yy4jWHuf6jsr7Y1sD7g5vE7VqNZwKaaoBsrrCjEa+GhxJzzkBjhXJ2ZbZFjaAO6k1C3n74RssdUKofFo0evRgnnacdpOxLUxRPl92GO5XDmfjtbYLyjQJOdLluAd1KeSa2XKUxU6JiFHJXLt5XBRSoPstBZcxMHHmxuw+xEgVmWmDPiUouiFzsbG2n6OJzt1lChox1T2gRtZZYo8/KByHO/OUiFvRb02NRJtFlFiH+ktna5wrXwcF+979+qPo9Jja0SeinQFQGRCM/hcAAP//VUidAg=="
+	return "eJzUW2Fv2zjS/u5fMSheoAnWVd9422LhDwuk7d42uO022GRxe7e3Z9PiWOKFInUklcbF/fjDUJQtObRsZbUHHJCisUw9zzPD4XA4Ul7AHW7mYDfWYTEBcMJJnMOzG3/h2QSAo02NKJ3Qag7fTgAAbnO0CMwguBxhLVByCxkqNMwhh9XGX68xodC8kphMAAxKZBbnsELHJhBunE8mAC9AsQLngPeonOdwmxLnkBldlf5zMxhgN1obkQnlv25uuMPNZ214uBbRTj+f/H2g116n50zgNhcWUqZghcBgLSRCyVwOZ5hkCSxf3jPzUuqM/iUXy/PpFk0bD0OSGshgeqqLUitUDlzOHNiqLKVA7odz5liDrdBJoe6W50nbF5VFc7IrUDnhNgvBh3vj6j1USvyrQrkBwQlovREq8ypJA2gFDHJtXQJXDshLuigrmmlmgcHNh8sXs9dvIGc234IGR9BdcPV+WgPRL0zx+gMZmXRscGgKoZgcbsJtuLPxPxF0fFkanaK1J7vT5QYZT1JWspWQwgm0Ca7XmDpxj4FW4j3KOeCDQ8WR96gWmdIGF2yl73EOF/8/exUzxwegsN5vFh3Z0uYnr23X1h0ahRKchhLNWpuC/i+EtUI36wEgzTG9s7AOARpsCl/jAytKWurPf3327vJ68fb6T8+m4H+9+evN4vL9x6sfn/32PIwumXNo1Bz+cUYjfr188bfFb1/9++/8q/P/C0M4rlkl3cIv1DmsmbR41KdetXPI/2CfMpCiEI7C2lYlGvJv45ftvHbdTUs2QELLf1CwDTBrqwL/u67s+LK11vbD+eAi+cBsjrZZIviAaeXYSiKlPiTvWp/Smcy0ES4vPJX1C5ZuuGeyQj9ki0iXc3wAVKnmyIGLDK0LI/36219fOwtWkt3hbLWYvX4TvonP8545b3+4/PN3s9U24UTMmRxg+vqbV09h+vqbV0OZXl/MnsL0+mJ2KpPN2Wz2agjJzYfL2exkS2zOBrrr5sPlAE8R/mK4BV8vBtowNLzIisWA2PIcT/DUYqivBoaUt2NYPL2+mD1hRl5fzF4OmxPPM3hWPM/p8/LwkL8ZZMovv7zpNWJrgK/sElZxEa9TO6jfPiow2iKpONpefIzUm8zpZ0kAS0i1ckyopgKXvlQDoagsYOTAZp8CeFyDA8SSdFtlVTpRNDVPW6nUKutcrgnnwCvjeTtfClVWbtEMUUxpi6lW3HZG6cq1hzH7nm2iI0qDqaBqZw4Xne97/EU/P3trQKi2hCRi9kprd8BwzhwO4XyrtQPCivGE2UMjviCPkK20lsjUEL4bdCDWIQyoQt9yxASQsC9aYUIfO1jxZXOCgB9bR6EGvvlMqqbgzz1vb257Ben12qJLLKanRN8RTbc7HYRKEdAz+6RyPH98CGgxJsHH44Cr9zEKZtJcOExdZXBEsjZsOMk+fPNm8ebVeUxEwdJxuD9evgPGuUFrMTp3oowQiXIIx9V1P4Xu5qR45j7CstS2lbtb6RrYSlfUMkDQJbVU/Kml3nc6GI9z9k4hFeSPArjP60d98ulmCzql9MLUJsy6dQZdmp8nUSWlZI5sG1VJAxoUpKictlOoVpVy1RQ+C8X1Z3tA0eh+IcCg5CNL4dMN/HKAes0KITejkteQgd4gz5mbAseVYGoKa4O4svyYR+7RdDoHY+gKmHHCun8xHt9tZLE8t4GmXwpZOZ6QTzf+bjiziPDduxvQNqELLcd3fbCo+6OTY9mkh3fZQepNKgykZnRU1wYqFX4PXto1ap9eLO63IY/5s9ebfY3Jrua+vuQeaLvG33Yna9P9vNQdynDB6sqkGIufg7nkibZ+3CmI0VjxBU8pgprCebVxaAewY6HNxrM0vim8oCnVSR4spsrgepHqKnTsj0jrFfBjVayo07wGg2s0qFK01N3cKWnm2Dc5VQaiLZP69kp3ZQA18psYj6mnfupitRlj9qjg3XbWakkWOJaoOAWsVuCoERksiWixjrkx48jjTSHU3Usp7nE5hSU5Q6hsCdrAsvYNfYx5J8T8iJoCoi8eOKaSmV0/u8czJh1RyjvfEq+K7lyFdT4F1sohqw1ll5Iee0Rk0Yl717b+XQe2v+TocjRtPR7dthLdQQl2DK/cEh2sJcss0NmoMynhsLYsjS6NQMfMhkJJV26h1wtnEJfT/dK2UlZkCnkdaGttUuTRKKuH/UFezP02cM+k4J6I0UmlySNayY03NiSSaEVCT+y0cc3K9ShCZclk346SpXcs+327eMDo378VCGUdk7Leww0W+h55w/8/snk3avu27XYzbg8adht3QGrt3M2VkDSSiJ1j7dnXLfIYz4h56/q4VWG+x2QLkDE2OvyPSdVuJsT4pEhRjWtdgIyxhTU2Sv+voQuYBxuBp5Z4J5ERWJSkKgpmNk8ArG+MYVZGjjktP//0QzLZ52g/v39yfq0xevMr7R6Ba1fQ5Xs1wPDMKhS9Y6L3HB834YibLqGkaNJrYPTMX2gu0h0+SaYgM5VSVHlubUngUsqQfLO9owH4u1QLxebNyz2WWhvL+hUZ0d3DYwa3Zqy7HfYE9BGD24eDRv8UrGPGP85fG13ARXJQgx1dhEdt1Gx9FlfgtNu+zzKigm2ECnWKim0U7GEeCsCjUq63AqSwtHMHHeVeDXIoSFrS9qqNXgcd1bVVttcJ7zAyQ8E8OrGHhfIo/6Pqoz9nDrJ6vxJp8+4eaI7NfrmyWlYuvC8XTu2NI9rPUePCmMns2JIahzCTVQUqZw+R+zzyCODAPn8SNT1o6njAUyA/pMC/qyb42B74CZls3rrbps5a0SEl8fp/BC29Z4F9TW2/TI7lqh7qJQEc3expEB0GgUUesZy+0fcu7z7vHfXdz913JffpKsFHZ7t6H+fKxuX6nubyIBkXZkwyMuy5hVwXCFwYTA/vljZHKcfkvjY6M6ygfqapFDAHUmdCxdkpIBetWB1TyPfhBRHiaK+HBD4p+EGo6mEKrvUWaoaptnWVFxcbKx1qV+nVPzF1wwQuPVzvkqWHjn6U3a5eauaUzPj3Zc9WuNHhfUX67rmF0gg6uNSZ4/y04qR3NR+bhZNmYhf/sS27b8nt6KkBmaGJfD+A/tDyK5m1EeOeXDEuG8D+6d3OWhgNZ0qHU1lzRTiLcj14JiOP+UabyctHsgk2gWttrVjJ9ruzsLQ54/rzohm6PIB51jHaN8PEtt6vMfwfKZxPd75dcGGp0uLL6QHUpdI7ZjhrFjtnKkOjK0sdcKY29NyA/hRC6gyEOvfPxA4hpmZTujbo5xwVdNT7DELaX6JLX/rLHCxi8bjuC7Oimyihjicqz+HbnDXieXJwniWzbpHmZFBsPnsquxMnmx41c7bp5JjG0M/MegGQ5kxlyJPJfwYAW2PJ6A=="
 }
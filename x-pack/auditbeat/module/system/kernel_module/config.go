@@ -0,0 +1,52 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+//go:build linux
+
+package kernel_module
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// backendAuto uses kprobes when available, and falls back to polling.
+	backendAuto = "auto"
+	// backendKprobes requires kprobes.
+	backendKprobes = "kprobes"
+	// backendProcfs only polls /proc/modules.
+	backendProcfs = "procfs"
+)
+
+// config defines the kernel_module metricset's configuration options.
+type config struct {
+	StatePeriod             time.Duration `config:"state.period"`
+	KernelModuleStatePeriod time.Duration `config:"kernel_module.state.period"`
+	Backend                 string        `config:"kernel_module.backend"`
+}
+
+func (c *config) Validate() error {
+	switch c.Backend {
+	case backendAuto, backendKprobes, backendProcfs:
+		return nil
+	default:
+		return fmt.Errorf("invalid kernel_module.backend %q, must be one of %s, %s or %s",
+			c.Backend, backendAuto, backendKprobes, backendProcfs)
+	}
+}
+
+func (c *config) effectiveStatePeriod() time.Duration {
+	if c.KernelModuleStatePeriod != 0 {
+		return c.KernelModuleStatePeriod
+	}
+	return c.StatePeriod
+}
+
+func defaultConfig() config {
+	return config{
+		StatePeriod: 12 * time.Hour,
+		Backend:     backendAuto,
+	}
+}
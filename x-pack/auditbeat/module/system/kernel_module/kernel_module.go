@@ -0,0 +1,530 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+//go:build linux
+
+package kernel_module
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/gofrs/uuid/v5"
+
+	"github.com/elastic/beats/v7/auditbeat/ab"
+	"github.com/elastic/beats/v7/auditbeat/datastore"
+	"github.com/elastic/beats/v7/libbeat/common/cfgwarn"
+	"github.com/elastic/beats/v7/metricbeat/mb"
+	"github.com/elastic/beats/v7/x-pack/auditbeat/cache"
+	"github.com/elastic/beats/v7/x-pack/auditbeat/module/system"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+const (
+	metricsetName = "kernel_module"
+	namespace     = "system.audit.kernel_module"
+
+	bucketName              = "kernel_module.v1"
+	bucketKeyModules        = "modules"
+	bucketKeyStateTimestamp = "state_timestamp"
+
+	eventTypeState = "state"
+	eventTypeEvent = "event"
+)
+
+type eventAction uint8
+
+const (
+	eventActionExistingModule eventAction = iota
+	eventActionModuleLoaded
+	eventActionModuleUnloaded
+)
+
+func (action eventAction) String() string {
+	switch action {
+	case eventActionExistingModule:
+		return "existing_module"
+	case eventActionModuleLoaded:
+		return "module_loaded"
+	case eventActionModuleUnloaded:
+		return "module_unloaded"
+	default:
+		return ""
+	}
+}
+
+func (action eventAction) Type() string {
+	switch action {
+	case eventActionExistingModule:
+		return "info"
+	case eventActionModuleLoaded:
+		return "start"
+	case eventActionModuleUnloaded:
+		return "end"
+	default:
+		return "info"
+	}
+}
+
+func init() {
+	ab.Registry.MustAddMetricSet(system.ModuleName, metricsetName, New,
+		mb.DefaultMetricSet(),
+		mb.WithNamespace(namespace),
+	)
+}
+
+// MetricSet collects data about the loaded kernel modules.
+type MetricSet struct {
+	system.SystemMetricSet
+	config    config
+	log       *logp.Logger
+	cache     *cache.Cache
+	bucket    datastore.Bucket
+	lastState time.Time
+}
+
+// KernelModule represents a loaded kernel module.
+type KernelModule struct {
+	Name       string
+	Size       uint64
+	RefCount   int64
+	UsedBy     []string
+	State      string
+	Version    string
+	SrcVersion string
+	Taints     []string
+	Signed     *bool
+}
+
+// Hash creates a hash for KernelModule from the fields identifying it. A
+// module that is unloaded and loaded again with different code hashes
+// differently, changes of its taints or size are not reported as a new module.
+//
+//nolint:errcheck // Writing to the hash never returns an error.
+func (mod KernelModule) Hash() uint64 {
+	h := xxhash.New()
+	h.WriteString(mod.Name)
+	h.WriteString("\x00")
+	h.WriteString(mod.Version)
+	h.WriteString("\x00")
+	h.WriteString(mod.SrcVersion)
+	return h.Sum64()
+}
+
+func (mod KernelModule) hasTaint(name string) bool {
+	return slices.Contains(mod.Taints, name)
+}
+
+func (mod KernelModule) toMapStr() mapstr.M {
+	evt := mapstr.M{
+		"name":    mod.Name,
+		"size":    mod.Size,
+		"state":   mod.State,
+		"tainted": len(mod.Taints) > 0,
+	}
+
+	if mod.RefCount >= 0 {
+		evt["ref_count"] = mod.RefCount
+	}
+	if len(mod.UsedBy) > 0 {
+		evt["used_by"] = mod.UsedBy
+	}
+	if mod.Version != "" {
+		evt["version"] = mod.Version
+	}
+	if mod.SrcVersion != "" {
+		evt["srcversion"] = mod.SrcVersion
+	}
+	if len(mod.Taints) > 0 {
+		evt["taints"] = mod.Taints
+	}
+	if mod.Signed != nil {
+		evt["signed"] = *mod.Signed
+	}
+
+	return evt
+}
+
+// entityID creates an ID that uniquely identifies this module across machines.
+func (mod KernelModule) entityID(hostID string) string {
+	h := system.NewEntityHash()
+	h.Write([]byte(hostID))
+	h.Write([]byte(mod.Name))
+	h.Write([]byte(mod.SrcVersion))
+	return h.Sum()
+}
+
+// New constructs a new MetricSet.
+func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
+	cfgwarn.Beta("The %v/%v dataset is beta", system.ModuleName, metricsetName)
+
+	config := defaultConfig()
+	if err := base.Module().UnpackConfig(&config); err != nil {
+		return nil, fmt.Errorf("failed to unpack the %v/%v config: %w", system.ModuleName, metricsetName, err)
+	}
+
+	bucket, err := datastore.OpenBucket(bucketName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open persistent datastore: %w", err)
+	}
+
+	ms := &MetricSet{
+		SystemMetricSet: system.NewSystemMetricSet(base),
+		config:          config,
+		log:             logp.NewLogger(metricsetName),
+		cache:           cache.New(),
+		bucket:          bucket,
+	}
+
+	ms.lastState, err = loadStateTimestamp(bucket)
+	if err != nil {
+		bucket.Close()
+		return nil, fmt.Errorf("failed to load state timestamp from bucket %v: %w", bucketName, err)
+	}
+	if !ms.lastState.IsZero() {
+		ms.log.Debugf("Last state was sent at %v. Next state update by %v.", ms.lastState, ms.lastState.Add(ms.config.effectiveStatePeriod()))
+	} else {
+		ms.log.Debug("No state timestamp found.")
+	}
+
+	modules, err := loadModules(bucket)
+	if err != nil {
+		bucket.Close()
+		return nil, fmt.Errorf("failed to load persisted kernel module metadata from disk: %w", err)
+	}
+	ms.log.Debugf("Loaded %d kernel modules from disk", len(modules))
+
+	ms.cache.DiffAndUpdateCache(convertToCacheable(modules))
+
+	return ms, nil
+}
+
+// Close cleans up the MetricSet when it finishes.
+func (ms *MetricSet) Close() error {
+	if ms.bucket != nil {
+		return ms.bucket.Close()
+	}
+	return nil
+}
+
+// fetch collects data about the loaded kernel modules. It is invoked periodically.
+func (ms *MetricSet) fetch(report mb.ReporterV2) {
+	needsStateUpdate := time.Since(ms.lastState) > ms.config.effectiveStatePeriod()
+	if needsStateUpdate {
+		ms.log.Debug("Sending state")
+		err := ms.reportState(report)
+		if err != nil {
+			ms.log.Error(err)
+			report.Error(err)
+		}
+		ms.log.Debugf("Next state update by %v", ms.lastState.Add(ms.config.effectiveStatePeriod()))
+	}
+
+	err := ms.reportChanges(report)
+	if err != nil {
+		ms.log.Error(err)
+		report.Error(err)
+	}
+}
+
+// Run reports the loaded kernel modules every period. When kprobes are
+// available, modules are also reported as soon as they are loaded or
+// unloaded, including modules that do not stay loaded until the next check.
+func (ms *MetricSet) Run(report mb.PushReporterV2) {
+	var (
+		events <-chan interface{}
+		errs   <-chan error
+		lost   <-chan uint64
+	)
+	if ms.config.Backend != backendProcfs {
+		probes, err := startModuleProbes()
+		switch {
+		case err == nil:
+			defer probes.Close()
+			events, errs, lost = probes.C(), probes.ErrC(), probes.LostC()
+			ms.log.Info("Tracing kernel modules with kprobes")
+		case ms.config.Backend == backendKprobes:
+			err = fmt.Errorf("failed to set up kprobes: %w", err)
+			ms.log.Error(err)
+			report.Error(err)
+			return
+		default:
+			ms.log.Warnf("Kprobes are not available, only polling %v: %v", procModulesPath, err)
+		}
+	}
+
+	ticker := time.NewTicker(ms.Module().Config().Period)
+	defer ticker.Stop()
+	ms.fetch(report)
+	for {
+		select {
+		case <-report.Done():
+			return
+		case <-ticker.C:
+			ms.fetch(report)
+		case ev := <-events:
+			if err := ms.reportProbeEvent(report, ev.(*moduleProbeEvent)); err != nil {
+				ms.log.Error(err)
+				report.Error(err)
+			}
+		case err := <-errs:
+			ms.log.Warnf("Error reading kprobe events: %v", err)
+		case n := <-lost:
+			ms.log.Warnf("Lost %d kernel module events", n)
+		}
+	}
+}
+
+// reportProbeEvent reports a module loaded or unloaded as traced by the
+// kprobes. The module is still listed in /proc/modules when it is freed,
+// so it is left out of the list before the list is compared to the cache.
+// A module that is no longer listed when it is loaded, or that was never
+// listed when it is unloaded, is reported from the probe alone.
+func (ms *MetricSet) reportProbeEvent(report mb.ReporterV2, ev *moduleProbeEvent) error {
+	modules, err := listKernelModules()
+	if err != nil {
+		return fmt.Errorf("failed to get kernel modules: %w", err)
+	}
+	listed := slices.ContainsFunc(modules, func(mod *KernelModule) bool { return mod.Name == ev.Name })
+	if ev.Action == eventActionModuleUnloaded {
+		modules = slices.DeleteFunc(modules, func(mod *KernelModule) bool { return mod.Name == ev.Name })
+	}
+
+	newInCache, missingFromCache := ms.cache.DiffAndUpdateCache(convertToCacheable(modules))
+
+	reported := false
+	reportChange := func(mod *KernelModule, action eventAction) {
+		event := ms.moduleEvent(mod, eventTypeEvent, action)
+		if mod.Name == ev.Name && action == ev.Action {
+			_, _ = event.RootFields.Put("process.pid", ev.Meta.PID)
+			reported = true
+		}
+		report.Event(event)
+	}
+	for _, mod := range convertToKernelModule(missingFromCache) {
+		reportChange(mod, eventActionModuleUnloaded)
+	}
+	for _, mod := range convertToKernelModule(newInCache) {
+		reportChange(mod, eventActionModuleLoaded)
+	}
+	if !reported && (ev.Action == eventActionModuleUnloaded || !listed) {
+		reportChange(&KernelModule{Name: ev.Name, RefCount: -1}, ev.Action)
+	}
+
+	if len(newInCache) > 0 || len(missingFromCache) > 0 {
+		return storeModules(ms.bucket, modules)
+	}
+	return nil
+}
+
+// reportState reports all loaded kernel modules.
+func (ms *MetricSet) reportState(report mb.ReporterV2) error {
+	ms.lastState = time.Now()
+
+	modules, err := listKernelModules()
+	if err != nil {
+		return fmt.Errorf("failed to get kernel modules: %w", err)
+	}
+
+	stateID, err := uuid.NewV4()
+	if err != nil {
+		return fmt.Errorf("error generating state ID: %w", err)
+	}
+
+	// Modules that were loaded since the last check are reported as loaded
+	// rather than as existing, unless this is the first state ever sent.
+	firstState := ms.cache.IsEmpty()
+	newInCache, missingFromCache := ms.cache.DiffAndUpdateCache(convertToCacheable(modules))
+	if firstState {
+		newInCache = nil
+	}
+	loaded := make(map[uint64]struct{}, len(newInCache))
+	for _, mod := range convertToKernelModule(newInCache) {
+		loaded[mod.Hash()] = struct{}{}
+		report.Event(ms.moduleEvent(mod, eventTypeEvent, eventActionModuleLoaded))
+	}
+	for _, mod := range convertToKernelModule(missingFromCache) {
+		report.Event(ms.moduleEvent(mod, eventTypeEvent, eventActionModuleUnloaded))
+	}
+
+	for _, mod := range modules {
+		if _, found := loaded[mod.Hash()]; found {
+			continue
+		}
+		event := ms.moduleEvent(mod, eventTypeState, eventActionExistingModule)
+		_, _ = event.RootFields.Put("event.id", stateID.String())
+		report.Event(event)
+	}
+
+	if err = storeStateTimestamp(ms.bucket, ms.lastState); err != nil {
+		return fmt.Errorf("error persisting state timestamp: %w", err)
+	}
+	return storeModules(ms.bucket, modules)
+}
+
+// reportChanges detects and reports kernel modules that were loaded or
+// unloaded since the last call.
+func (ms *MetricSet) reportChanges(report mb.ReporterV2) error {
+	modules, err := listKernelModules()
+	if err != nil {
+		return fmt.Errorf("failed to get kernel modules: %w", err)
+	}
+
+	newInCache, missingFromCache := ms.cache.DiffAndUpdateCache(convertToCacheable(modules))
+
+	for _, mod := range convertToKernelModule(missingFromCache) {
+		report.Event(ms.moduleEvent(mod, eventTypeEvent, eventActionModuleUnloaded))
+	}
+	for _, mod := range convertToKernelModule(newInCache) {
+		report.Event(ms.moduleEvent(mod, eventTypeEvent, eventActionModuleLoaded))
+	}
+
+	if len(newInCache) > 0 || len(missingFromCache) > 0 {
+		return storeModules(ms.bucket, modules)
+	}
+
+	return nil
+}
+
+func (ms *MetricSet) moduleEvent(mod *KernelModule, eventType string, action eventAction) mb.Event {
+	event := mb.Event{
+		RootFields: mapstr.M{
+			"event": mapstr.M{
+				"kind":     eventType,
+				"category": []string{"driver"},
+				"type":     []string{action.Type()},
+				"action":   action.String(),
+			},
+			"message": moduleMessage(mod, action),
+		},
+		MetricSetFields: mod.toMapStr(),
+	}
+
+	if ms.HostID() != "" {
+		event.MetricSetFields["entity_id"] = mod.entityID(ms.HostID())
+	}
+
+	return event
+}
+
+func moduleMessage(mod *KernelModule, action eventAction) string {
+	var actionString string
+	switch action {
+	case eventActionExistingModule:
+		actionString = "is loaded"
+	case eventActionModuleLoaded:
+		actionString = "was loaded"
+	case eventActionModuleUnloaded:
+		actionString = "was unloaded"
+	}
+
+	msg := fmt.Sprintf("Kernel module %v %v", mod.Name, actionString)
+	if len(mod.Taints) > 0 {
+		msg += fmt.Sprintf(" (tainted: %v)", strings.Join(mod.Taints, ", "))
+	}
+	return msg
+}
+
+func convertToCacheable(modules []*KernelModule) []cache.Cacheable {
+	c := make([]cache.Cacheable, 0, len(modules))
+
+	for _, m := range modules {
+		c = append(c, m)
+	}
+
+	return c
+}
+
+func convertToKernelModule(cacheValues []interface{}) []*KernelModule {
+	modules := make([]*KernelModule, 0, len(cacheValues))
+
+	for _, c := range cacheValues {
+		modules = append(modules, c.(*KernelModule))
+	}
+
+	return modules
+}
+
+// loadStateTimestamp loads the timestamp of the last state update from
+// the given datastore bucket.
+func loadStateTimestamp(bucket datastore.Bucket) (time.Time, error) {
+	var stateTimestamp time.Time
+	err := bucket.Load(bucketKeyStateTimestamp, func(blob []byte) error {
+		if len(blob) > 0 {
+			return stateTimestamp.UnmarshalBinary(blob)
+		}
+		return nil
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return stateTimestamp, nil
+}
+
+// storeStateTimestamp stores the timestamp of the last state update to
+// the given datastore bucket.
+func storeStateTimestamp(bucket datastore.Bucket, t time.Time) error {
+	data, err := t.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	if err = bucket.Store(bucketKeyStateTimestamp, data); err != nil {
+		return fmt.Errorf("error writing state timestamp to disk: %w", err)
+	}
+	return nil
+}
+
+// loadModules loads the persisted kernel modules from the given datastore
+// bucket. Persisting them allows reporting modules that were loaded or
+// unloaded while Auditbeat was not running.
+func loadModules(bucket datastore.Bucket) ([]*KernelModule, error) {
+	var data []byte
+	err := bucket.Load(bucketKeyModules, func(blob []byte) error {
+		data = blob
+		return nil
+	})
+	if err != nil || len(data) == 0 {
+		return nil, err
+	}
+
+	var modules []*KernelModule
+	dec := gob.NewDecoder(bytes.NewReader(data))
+	for {
+		mod := new(KernelModule)
+		if err = dec.Decode(mod); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("error decoding kernel modules: %w", err)
+		}
+		modules = append(modules, mod)
+	}
+	return modules, nil
+}
+
+// storeModules stores the kernel modules to the given datastore bucket.
+func storeModules(bucket datastore.Bucket, modules []*KernelModule) error {
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	for _, mod := range modules {
+		if err := enc.Encode(*mod); err != nil {
+			return fmt.Errorf("error encoding kernel modules: %w", err)
+		}
+	}
+
+	if err := bucket.Store(bucketKeyModules, buf.Bytes()); err != nil {
+		return fmt.Errorf("error writing kernel modules to disk: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+//go:build linux
+
+package kernel_module
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/auditbeat/ab"
+	"github.com/elastic/beats/v7/auditbeat/core"
+	abtest "github.com/elastic/beats/v7/auditbeat/testing"
+	"github.com/elastic/beats/v7/metricbeat/mb"
+	mbtest "github.com/elastic/beats/v7/metricbeat/mb/testing"
+	"github.com/elastic/beats/v7/x-pack/auditbeat/module/system"
+)
+
+func TestData(t *testing.T) {
+	defer abtest.SetupDataDir(t)()
+	setTestdataPaths(t, "testdata/modules.loaded")
+
+	f := mbtest.NewPushMetricSetV2WithRegistry(t, getConfig(), ab.Registry)
+	defer deleteBucket(t, f)
+
+	events, errs := fetchEvents(f)
+	if len(errs) > 0 {
+		t.Fatalf("received error: %+v", errs[0])
+	}
+
+	if len(events) == 0 {
+		t.Fatal("no events were generated")
+	}
+
+	fullEvent := mbtest.StandardizeEvent(f, events[len(events)-1], core.AddDatasetToEvent)
+	mbtest.WriteEventToDataJSON(t, fullEvent, "")
+}
+
+func TestParseProcModulesLine(t *testing.T) {
+	mod, err := parseProcModulesLine("nf_tables 344064 2 nft_chain_nat,nft_ct,[permanent], Live 0xffffffffc0a62000 (OE)")
+	require.NoError(t, err)
+	assert.Equal(t, "nf_tables", mod.Name)
+	assert.EqualValues(t, 344064, mod.Size)
+	assert.EqualValues(t, 2, mod.RefCount)
+	assert.Equal(t, []string{"nft_chain_nat", "nft_ct"}, mod.UsedBy)
+	assert.Equal(t, "live", mod.State)
+	assert.Equal(t, []string{"out_of_tree", "unsigned"}, mod.Taints)
+
+	mod, err = parseProcModulesLine("zram 36864 - - Loading 0x0000000000000000")
+	require.NoError(t, err)
+	assert.EqualValues(t, -1, mod.RefCount)
+	assert.Empty(t, mod.UsedBy)
+	assert.Equal(t, "loading", mod.State)
+	assert.Empty(t, mod.Taints)
+
+	_, err = parseProcModulesLine("broken line")
+	assert.Error(t, err)
+}
+
+func TestListKernelModules(t *testing.T) {
+	setTestdataPaths(t, "testdata/modules.loaded")
+
+	modules, err := listKernelModules()
+	require.NoError(t, err)
+	require.Len(t, modules, 3)
+
+	hidemod := modules[2]
+	assert.Equal(t, "hidemod", hidemod.Name)
+	assert.Equal(t, "1.0", hidemod.Version)
+	assert.Equal(t, "5F4E4A6C0D1AE1BF8C01A3D", hidemod.SrcVersion)
+	assert.Equal(t, []string{"proprietary", "out_of_tree", "unsigned"}, hidemod.Taints)
+	require.NotNil(t, hidemod.Signed)
+	assert.False(t, *hidemod.Signed)
+
+	require.NotNil(t, modules[1].Signed)
+	assert.True(t, *modules[1].Signed)
+
+	// Without module signing support the signature status is unknown.
+	sysModulePath = t.TempDir()
+	modules, err = listKernelModules()
+	require.NoError(t, err)
+	assert.Nil(t, modules[0].Signed)
+}
+
+func TestModuleLoadedAndUnloaded(t *testing.T) {
+	defer abtest.SetupDataDir(t)()
+	setTestdataPaths(t, "testdata/modules")
+
+	f := mbtest.NewPushMetricSetV2WithRegistry(t, getConfig(), ab.Registry)
+	defer deleteBucket(t, f)
+
+	events, errs := fetchEvents(f)
+	require.Empty(t, errs)
+	require.Len(t, events, 3)
+	for _, e := range events {
+		action, _ := e.RootFields.GetValue("event.action")
+		assert.Equal(t, "existing_module", action)
+	}
+
+	procModulesPath = "testdata/modules.loaded"
+	events, errs = fetchEvents(f)
+	require.Empty(t, errs)
+	require.Len(t, events, 2)
+
+	byAction := map[string]mb.Event{}
+	for _, e := range events {
+		action, _ := e.RootFields.GetValue("event.action")
+		byAction[action.(string)] = e
+	}
+
+	unloaded, found := byAction["module_unloaded"]
+	require.True(t, found)
+	assert.Equal(t, "ext4", unloaded.MetricSetFields["name"])
+	eventType, _ := unloaded.RootFields.GetValue("event.type")
+	assert.Equal(t, []string{"end"}, eventType)
+
+	loaded, found := byAction["module_loaded"]
+	require.True(t, found)
+	assert.Equal(t, "hidemod", loaded.MetricSetFields["name"])
+	assert.Equal(t, true, loaded.MetricSetFields["tainted"])
+	assert.Equal(t, false, loaded.MetricSetFields["signed"])
+	assert.Equal(t, "Kernel module hidemod was loaded (tainted: proprietary, out_of_tree, unsigned)", loaded.RootFields["message"])
+
+	// No changes, no events.
+	events, errs = fetchEvents(f)
+	require.Empty(t, errs)
+	assert.Empty(t, events)
+}
+
+func TestKernelModuleHash(t *testing.T) {
+	mod := KernelModule{Name: "hidemod", Version: "1.0", SrcVersion: "ABC", Size: 16384}
+
+	changed := mod
+	changed.Size = 20480
+	changed.Taints = []string{"unsigned"}
+	changed.RefCount = 2
+	assert.Equal(t, mod.Hash(), changed.Hash(), "only the identity of the module is hashed")
+
+	reloaded := mod
+	reloaded.SrcVersion = "DEF"
+	assert.NotEqual(t, mod.Hash(), reloaded.Hash())
+}
+
+func TestReportProbeEvent(t *testing.T) {
+	defer abtest.SetupDataDir(t)()
+	setTestdataPaths(t, "testdata/modules")
+
+	f := mbtest.NewPushMetricSetV2WithRegistry(t, getConfig(), ab.Registry)
+	defer deleteBucket(t, f)
+	ms := f.(*MetricSet)
+
+	_, errs := fetchEvents(f)
+	require.Empty(t, errs)
+
+	probeEvents := func(name string, action eventAction, pid uint32) map[string]mb.Event {
+		t.Helper()
+		r := &mbtest.CapturingReporterV2{}
+		ev := &moduleProbeEvent{Name: name, Action: action}
+		ev.Meta.PID = pid
+		require.NoError(t, ms.reportProbeEvent(r, ev))
+		require.Empty(t, r.GetErrors())
+		events := map[string]mb.Event{}
+		for _, e := range r.GetEvents() {
+			action, _ := e.RootFields.GetValue("event.action")
+			events[e.MetricSetFields["name"].(string)+"/"+action.(string)] = e
+		}
+		return events
+	}
+
+	// The loaded module is reported with its metadata and the process that
+	// loaded it, along with the other changes.
+	procModulesPath = "testdata/modules.loaded"
+	events := probeEvents("hidemod", eventActionModuleLoaded, 42)
+	require.Len(t, events, 2)
+	assert.Contains(t, events, "ext4/module_unloaded")
+	loaded := events["hidemod/module_loaded"]
+	assert.Equal(t, true, loaded.MetricSetFields["tainted"])
+	pid, _ := loaded.RootFields.GetValue("process.pid")
+	assert.EqualValues(t, 42, pid)
+
+	// A module that is gone before it is listed is reported from the probe.
+	events = probeEvents("ghost", eventActionModuleLoaded, 43)
+	require.Len(t, events, 1)
+	assert.Contains(t, events, "ghost/module_loaded")
+	events = probeEvents("ghost", eventActionModuleUnloaded, 43)
+	require.Len(t, events, 1)
+	assert.Contains(t, events, "ghost/module_unloaded")
+
+	// A module being freed is reported as unloaded while still listed.
+	events = probeEvents("hidemod", eventActionModuleUnloaded, 44)
+	require.Len(t, events, 1)
+	unloaded := events["hidemod/module_unloaded"]
+	assert.Equal(t, true, unloaded.MetricSetFields["tainted"])
+	pid, _ = unloaded.RootFields.GetValue("process.pid")
+	assert.EqualValues(t, 44, pid)
+}
+
+func setTestdataPaths(t *testing.T, modules string) {
+	procModulesPathOld, sysModulePathOld := procModulesPath, sysModulePath
+	t.Cleanup(func() {
+		procModulesPath, sysModulePath = procModulesPathOld, sysModulePathOld
+	})
+	procModulesPath = modules
+	sysModulePath = "testdata/sys/module"
+}
+
+func getConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"module":   system.ModuleName,
+		"datasets": []string{"kernel_module"},
+	}
+}
+
+func fetchEvents(metricSet mb.MetricSet) ([]mb.Event, []error) {
+	r := &mbtest.CapturingReporterV2{}
+	metricSet.(*MetricSet).fetch(r)
+	return r.GetEvents(), r.GetErrors()
+}
+
+func deleteBucket(t *testing.T, metricSet mb.MetricSet) {
+	if err := metricSet.(*MetricSet).bucket.DeleteBucket(); err != nil {
+		t.Fatal(err)
+	}
+}
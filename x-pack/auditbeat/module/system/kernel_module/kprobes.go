@@ -0,0 +1,172 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+//go:build linux
+
+package kernel_module
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/elastic/beats/v7/auditbeat/tracing"
+	"github.com/elastic/go-perf"
+)
+
+const probeGroupPrefix = "auditbeat_kmod_"
+
+// moduleNameFetchargs fetches the name of the struct module passed as first
+// argument to the probed functions. The name follows the state and the list
+// head of the module, at the same offset on all 64-bit architectures.
+var moduleNameFetchargs = map[string]string{
+	"amd64": "name=+24(%di):string",
+	"arm64": "name=+24(%x0):string",
+}
+
+// moduleProbeDefs are the kernel functions called when a module is loaded,
+// before its init function runs, and when a module is freed, either after it
+// was unloaded or after its init function failed.
+var moduleProbeDefs = []struct {
+	function string
+	action   eventAction
+}{
+	{"do_init_module", eventActionModuleLoaded},
+	{"free_module", eventActionModuleUnloaded},
+}
+
+// moduleProbeEvent is a module being loaded or unloaded, as captured by the
+// kprobes.
+type moduleProbeEvent struct {
+	Meta   tracing.Metadata `kprobe:"metadata"`
+	Name   string           `kprobe:"name"`
+	Action eventAction
+}
+
+// moduleProbes installs the kprobes tracing the loading and unloading of
+// kernel modules and receives their events.
+type moduleProbes struct {
+	traceFS *tracing.TraceFS
+	group   string
+	probes  []tracing.Probe
+	channel *tracing.PerfChannel
+}
+
+func startModuleProbes() (_ *moduleProbes, err error) {
+	fetchargs, found := moduleNameFetchargs[runtime.GOARCH]
+	if !found {
+		return nil, fmt.Errorf("kprobes are not supported on %s", runtime.GOARCH)
+	}
+	traceFS, err := tracing.NewTraceFS()
+	if err != nil {
+		return nil, fmt.Errorf("tracefs/debugfs is not mounted or not writeable: %w", err)
+	}
+
+	p := &moduleProbes{
+		traceFS: traceFS,
+		group:   probeGroupPrefix + strconv.Itoa(os.Getpid()),
+	}
+	defer func() {
+		if err != nil {
+			p.Close()
+		}
+	}()
+	if err = p.removeStale(); err != nil {
+		return nil, fmt.Errorf("unable to remove existing kprobes: %w", err)
+	}
+
+	p.channel, err = tracing.NewPerfChannel(
+		tracing.WithTID(perf.AllThreads),
+		tracing.WithTimestamp())
+	if err != nil {
+		return nil, fmt.Errorf("unable to create perf channel: %w", err)
+	}
+
+	for _, def := range moduleProbeDefs {
+		probe := tracing.Probe{
+			Group:     p.group,
+			Name:      def.function,
+			Address:   def.function,
+			Fetchargs: fetchargs,
+		}
+		if err = traceFS.AddKProbe(probe); err != nil {
+			return nil, fmt.Errorf("unable to register probe %s: %w", probe.String(), err)
+		}
+		p.probes = append(p.probes, probe)
+
+		format, err := traceFS.LoadProbeFormat(probe)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load format of probe %s: %w", probe.String(), err)
+		}
+		action := def.action
+		decoder, err := tracing.NewStructDecoder(format, func() interface{} {
+			return &moduleProbeEvent{Action: action}
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to create decoder for probe %s: %w", probe.String(), err)
+		}
+		if err = p.channel.MonitorProbe(format, decoder); err != nil {
+			return nil, fmt.Errorf("unable to monitor probe %s: %w", probe.String(), err)
+		}
+	}
+
+	if err = p.channel.Run(); err != nil {
+		return nil, fmt.Errorf("unable to start perf channel: %w", err)
+	}
+	return p, nil
+}
+
+// removeStale removes the probes left by this process or by Auditbeat
+// processes that are no longer running.
+func (p *moduleProbes) removeStale() error {
+	probes, err := p.traceFS.ListKProbes()
+	if err != nil {
+		return err
+	}
+	for _, probe := range probes {
+		if !strings.HasPrefix(probe.Group, probeGroupPrefix) {
+			continue
+		}
+		if probe.Group != p.group {
+			pid, err := strconv.Atoi(probe.Group[len(probeGroupPrefix):])
+			if err != nil || syscall.Kill(pid, 0) != syscall.ESRCH {
+				continue
+			}
+		}
+		if err := p.traceFS.RemoveKProbe(probe); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// C returns the channel of *moduleProbeEvent.
+func (p *moduleProbes) C() <-chan interface{} {
+	return p.channel.C()
+}
+
+func (p *moduleProbes) ErrC() <-chan error {
+	return p.channel.ErrC()
+}
+
+func (p *moduleProbes) LostC() <-chan uint64 {
+	return p.channel.LostC()
+}
+
+// Close stops receiving events and removes the probes.
+func (p *moduleProbes) Close() error {
+	var errs []error
+	if p.channel != nil {
+		errs = append(errs, p.channel.Close())
+	}
+	for _, probe := range p.probes {
+		errs = append(errs, p.traceFS.RemoveKProbe(probe))
+	}
+	p.probes = nil
+	return errors.Join(errs...)
+}
@@ -0,0 +1,133 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+//go:build linux
+
+package kernel_module
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var (
+	procModulesPath = "/proc/modules"
+	sysModulePath   = "/sys/module"
+)
+
+// taintFlags maps the per-module taint letters of the kernel to their names.
+// Letters for taints that only apply to the whole kernel are not listed.
+var taintFlags = map[byte]string{
+	'P': "proprietary",
+	'F': "forced",
+	'C': "staging",
+	'O': "out_of_tree",
+	'E': "unsigned",
+	'K': "livepatch",
+	'X': "auxiliary",
+	'T': "randstruct",
+	'N': "test",
+}
+
+// listKernelModules returns the modules currently loaded in the kernel as
+// listed by /proc/modules, enriched with metadata from /sys/module.
+func listKernelModules() ([]*KernelModule, error) {
+	f, err := os.Open(procModulesPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// Without module signing support the kernel never sets the unsigned
+	// taint, so the signature status is only reported when it is known.
+	_, err = os.Stat(filepath.Join(sysModulePath, "module", "parameters", "sig_enforce"))
+	sigSupported := err == nil
+
+	var modules []*KernelModule
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			continue
+		}
+		mod, err := parseProcModulesLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %v: %w", procModulesPath, err)
+		}
+		mod.Version = readSysModuleFile(mod.Name, "version")
+		mod.SrcVersion = readSysModuleFile(mod.Name, "srcversion")
+		if sigSupported {
+			signed := !mod.hasTaint("unsigned")
+			mod.Signed = &signed
+		}
+		modules = append(modules, mod)
+	}
+	if err = s.Err(); err != nil {
+		return nil, fmt.Errorf("error reading %v: %w", procModulesPath, err)
+	}
+	return modules, nil
+}
+
+// parseProcModulesLine parses a line of /proc/modules, e.g.
+//
+//	nf_tables 344064 1 nft_chain_nat, Live 0xffffffffc0a62000 (OE)
+func parseProcModulesLine(line string) (*KernelModule, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 5 {
+		return nil, fmt.Errorf("unexpected line %q", line)
+	}
+
+	size, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid size in line %q: %w", line, err)
+	}
+	refCount, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		// The reference count is "-" for modules built without unload support.
+		refCount = -1
+	}
+
+	mod := &KernelModule{
+		Name:     fields[0],
+		Size:     size,
+		RefCount: refCount,
+		State:    strings.ToLower(fields[4]),
+	}
+
+	if fields[3] != "-" {
+		for _, holder := range strings.Split(fields[3], ",") {
+			// Skip empty entries and markers like [permanent].
+			if holder == "" || strings.HasPrefix(holder, "[") {
+				continue
+			}
+			mod.UsedBy = append(mod.UsedBy, holder)
+		}
+	}
+
+	if len(fields) > 6 {
+		taints := strings.Trim(fields[6], "()")
+		for i := 0; i < len(taints); i++ {
+			if name, found := taintFlags[taints[i]]; found {
+				mod.Taints = append(mod.Taints, name)
+			}
+		}
+	}
+
+	return mod, nil
+}
+
+// readSysModuleFile returns the trimmed content of the given attribute in
+// the module's /sys/module directory, or an empty string if it does not
+// exist or cannot be read.
+func readSysModuleFile(module, attr string) string {
+	data, err := os.ReadFile(filepath.Join(sysModulePath, module, attr))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -6,15 +6,48 @@
 
 package login
 
+import (
+	"fmt"
+	"slices"
+)
+
+// Sources of login records.
+const (
+	sourceUtmp   = "utmp"
+	sourceWtmpdb = "wtmpdb"
+	sourceLogind = "logind"
+)
+
 // config defines the metricset's configuration options.
 type config struct {
-	WtmpFilePattern string `config:"login.wtmp_file_pattern"`
-	BtmpFilePattern string `config:"login.btmp_file_pattern"`
+	Sources         []string `config:"login.sources"`
+	WtmpFilePattern string   `config:"login.wtmp_file_pattern"`
+	BtmpFilePattern string   `config:"login.btmp_file_pattern"`
+	WtmpdbFile      string   `config:"login.wtmpdb_file"`
+}
+
+// Validate validates the config.
+func (c *config) Validate() error {
+	for _, source := range c.Sources {
+		switch source {
+		case sourceUtmp, sourceWtmpdb, sourceLogind:
+		default:
+			return fmt.Errorf("invalid login.sources value %q", source)
+		}
+	}
+	return nil
+}
+
+// hasSource returns whether source is one of the configured sources.
+func (c *config) hasSource(source string) bool {
+	return slices.Contains(c.Sources, source)
 }
 
 func defaultConfig() config {
 	return config{
+		Sources:         []string{sourceUtmp, sourceWtmpdb},
 		WtmpFilePattern: "/var/log/wtmp*",
 		BtmpFilePattern: "/var/log/btmp*",
+		WtmpdbFile:      "/var/lib/wtmpdb/wtmp.db",
 	}
 }
@@ -81,12 +81,16 @@ func init() {
 	)
 }
 
-// MetricSet collects login records from /var/log/wtmp.
+// MetricSet collects login records from /var/log/wtmp, wtmpdb and
+// systemd-logind.
 type MetricSet struct {
 	mb.BaseMetricSet
-	config     config
-	log        *logp.Logger
-	utmpReader *UtmpFileReader
+	config       config
+	log          *logp.Logger
+	bucket       datastore.Bucket
+	utmpReader   *UtmpFileReader
+	wtmpdbReader *WtmpdbReader
+	logindReader *LogindReader
 }
 
 // New constructs a new MetricSet.
@@ -107,11 +111,31 @@ func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
 		BaseMetricSet: base,
 		config:        config,
 		log:           logp.NewLogger(metricsetName),
+		bucket:        bucket,
 	}
 
-	ms.utmpReader, err = NewUtmpFileReader(ms.log, bucket, config)
-	if err != nil {
-		return nil, err
+	if config.hasSource(sourceUtmp) {
+		ms.utmpReader, err = NewUtmpFileReader(ms.log, bucket, config)
+		if err != nil {
+			bucket.Close()
+			return nil, err
+		}
+	}
+
+	if config.hasSource(sourceWtmpdb) {
+		ms.wtmpdbReader, err = NewWtmpdbReader(ms.log, bucket, config)
+		if err != nil {
+			bucket.Close()
+			return nil, err
+		}
+	}
+
+	if config.hasSource(sourceLogind) {
+		ms.logindReader, err = NewLogindReader(ms.log)
+		if err != nil {
+			bucket.Close()
+			return nil, err
+		}
 	}
 
 	return ms, nil
@@ -119,26 +143,62 @@ func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
 
 // Close cleans up the MetricSet when it finishes.
 func (ms *MetricSet) Close() error {
-	return ms.utmpReader.Close()
+	if ms.logindReader != nil {
+		ms.logindReader.Close()
+	}
+	return ms.bucket.Close()
 }
 
-// Fetch collects any new login records from /var/log/wtmp. It is invoked periodically.
+// Fetch collects any new login records from the configured sources. It is invoked periodically.
 func (ms *MetricSet) Fetch(report mb.ReporterV2) {
-	count := ms.readAndEmit(report)
+	if ms.utmpReader != nil {
+		count := ms.readAndEmit(report)
+
+		ms.log.Debugf("%d new login records.", count)
 
-	ms.log.Debugf("%d new login records.", count)
+		// Save new state to disk
+		if count > 0 {
+			err := ms.utmpReader.saveStateToDisk()
+			if err != nil {
+				ms.log.Error(err)
+				report.Error(err)
+			}
+		}
+	}
 
-	// Save new state to disk
-	if count > 0 {
-		err := ms.utmpReader.saveStateToDisk()
+	if ms.wtmpdbReader != nil {
+		loginRecords, err := ms.wtmpdbReader.ReadNew()
 		if err != nil {
 			ms.log.Error(err)
 			report.Error(err)
 		}
+
+		ms.log.Debugf("%d new wtmpdb login records.", len(loginRecords))
+
+		for i := range loginRecords {
+			report.Event(ms.loginEvent(&loginRecords[i]))
+		}
+		if len(loginRecords) > 0 {
+			err = ms.wtmpdbReader.saveStateToDisk()
+			if err != nil {
+				ms.log.Error(err)
+				report.Error(err)
+			}
+		}
+	}
+
+	if ms.logindReader != nil {
+		loginRecords := ms.logindReader.ReadNew()
+
+		ms.log.Debugf("%d new systemd-logind login records.", len(loginRecords))
+
+		for i := range loginRecords {
+			report.Event(ms.loginEvent(&loginRecords[i]))
+		}
 	}
 }
 
-// readAndEmit reads and emits login events and returns the number of events.
+// readAndEmit reads and emits UTMP login events and returns the number of events.
 func (ms *MetricSet) readAndEmit(report mb.ReporterV2) int {
 	loginRecordC, errorC := ms.utmpReader.ReadNew()
 
@@ -196,7 +256,7 @@ func (ms *MetricSet) loginEvent(loginRecord *LoginRecord) mb.Event {
 		event.RootFields.Put("related.ip", []string{loginRecord.IP.String()})
 	}
 
-	if loginRecord.Hostname != "" && loginRecord.Hostname != loginRecord.IP.String() {
+	if loginRecord.Hostname != "" && (loginRecord.IP == nil || loginRecord.Hostname != loginRecord.IP.String()) {
 		event.RootFields.Put("source.domain", loginRecord.Hostname)
 	}
 
@@ -281,8 +281,9 @@ func checkFieldValue(t *testing.T, mapstr mapstr.M, fieldName string, fieldValue
 
 func getBaseConfig() map[string]interface{} {
 	return map[string]interface{}{
-		"module":   system.ModuleName,
-		"datasets": []string{"login"},
+		"module":        system.ModuleName,
+		"datasets":      []string{"login"},
+		"login.sources": []string{"utmp"},
 	}
 }
 
@@ -288,6 +288,7 @@ func getBaseConfig() map[string]interface{} {
 	return map[string]interface{}{
 		"module":        system.ModuleName,
 		"datasets":      []string{"login"},
+		"login.sources": []string{"utmp"},
 		"logging.level": "debug",
 	}
 }
@@ -0,0 +1,250 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+//go:build linux
+
+package login
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-systemd/v22/login1"
+	"github.com/godbus/dbus/v5"
+
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+const (
+	logindOrigin = "logind"
+
+	logindManager          = "org.freedesktop.login1.Manager"
+	logindSessionNew       = "SessionNew"
+	logindSessionRemoved   = "SessionRemoved"
+	logindPrepareShutdown  = "PrepareForShutdown"
+	logindPropertyTimeout  = 5 * time.Second
+	logindUserSessionClass = "user"
+)
+
+// logindConn is the subset of *login1.Conn used by the logind reader.
+type logindConn interface {
+	ListSessionsContext(ctx context.Context) ([]login1.Session, error)
+	GetSessionPropertiesContext(ctx context.Context, path dbus.ObjectPath) (map[string]dbus.Variant, error)
+	Subscribe(members ...string) chan *dbus.Signal
+	Close()
+}
+
+// LogindReader collects login records from the systemd-logind D-Bus API.
+// Session signals are received as they happen and queued until the next
+// call to ReadNew, so that short sessions between two fetches are not
+// missed.
+type LogindReader struct {
+	log  *logp.Logger
+	conn logindConn
+
+	mu       sync.Mutex
+	sessions map[string]LoginRecord // Open user sessions, by session ID.
+	pending  []LoginRecord
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewLogindReader connects to systemd-logind on the system bus.
+func NewLogindReader(log *logp.Logger) (*LogindReader, error) {
+	conn, err := login1.New()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to systemd-logind: %w", err)
+	}
+	return newLogindReader(log, conn)
+}
+
+func newLogindReader(log *logp.Logger, conn logindConn) (*LogindReader, error) {
+	r := &LogindReader{
+		log:      log,
+		conn:     conn,
+		sessions: make(map[string]LoginRecord),
+		done:     make(chan struct{}),
+	}
+
+	signals := conn.Subscribe(logindSessionNew, logindSessionRemoved, logindPrepareShutdown)
+
+	// Sessions that already exist are only tracked to enrich their logout.
+	ctx, cancel := context.WithTimeout(context.Background(), logindPropertyTimeout)
+	defer cancel()
+	sessions, err := conn.ListSessionsContext(ctx)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to list systemd-logind sessions: %w", err)
+	}
+	for _, s := range sessions {
+		record, err := r.sessionRecord(s.Path)
+		if err != nil {
+			r.log.Debugf("Failed to get properties of session %v: %v", s.ID, err)
+			continue
+		}
+		if record != nil {
+			r.sessions[s.ID] = *record
+		}
+	}
+
+	r.wg.Add(1)
+	go r.run(signals)
+
+	return r, nil
+}
+
+// Close disconnects from systemd-logind.
+func (r *LogindReader) Close() error {
+	close(r.done)
+	r.conn.Close()
+	r.wg.Wait()
+	return nil
+}
+
+// ReadNew returns the login records received since the last call.
+func (r *LogindReader) ReadNew() []LoginRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	records := r.pending
+	r.pending = nil
+	return records
+}
+
+func (r *LogindReader) run(signals <-chan *dbus.Signal) {
+	defer r.wg.Done()
+	defer logp.Recover("A panic occurred while collecting systemd-logind sessions")
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case signal, ok := <-signals:
+			if !ok {
+				return
+			}
+			r.handleSignal(signal)
+		}
+	}
+}
+
+func (r *LogindReader) handleSignal(signal *dbus.Signal) {
+	switch signal.Name {
+	case logindManager + "." + logindSessionNew:
+		id, path, ok := sessionSignalArgs(signal)
+		if !ok {
+			return
+		}
+		record, err := r.sessionRecord(path)
+		if err != nil {
+			r.log.Warnf("Failed to get properties of new session %v: %v", id, err)
+			return
+		}
+		if record == nil {
+			return
+		}
+		r.mu.Lock()
+		r.sessions[id] = *record
+		r.pending = append(r.pending, *record)
+		r.mu.Unlock()
+
+	case logindManager + "." + logindSessionRemoved:
+		id, _, ok := sessionSignalArgs(signal)
+		if !ok {
+			return
+		}
+		r.mu.Lock()
+		record, found := r.sessions[id]
+		if found {
+			delete(r.sessions, id)
+			record.Type = userLogoutRecord
+			record.Timestamp = time.Now().UTC()
+			r.pending = append(r.pending, record)
+		}
+		r.mu.Unlock()
+
+	case logindManager + "." + logindPrepareShutdown:
+		if len(signal.Body) == 0 {
+			return
+		}
+		if start, _ := signal.Body[0].(bool); !start {
+			return
+		}
+		r.mu.Lock()
+		r.pending = append(r.pending, LoginRecord{
+			Type:      shutdownRecord,
+			UID:       -1,
+			PID:       -1,
+			Timestamp: time.Now().UTC(),
+			Origin:    logindOrigin,
+		})
+		r.mu.Unlock()
+	}
+}
+
+// sessionSignalArgs returns the session ID and object path carried by the
+// SessionNew and SessionRemoved signals.
+func sessionSignalArgs(signal *dbus.Signal) (string, dbus.ObjectPath, bool) {
+	if len(signal.Body) < 2 {
+		return "", "", false
+	}
+	id, ok := signal.Body[0].(string)
+	if !ok {
+		return "", "", false
+	}
+	path, ok := signal.Body[1].(dbus.ObjectPath)
+	return id, path, ok
+}
+
+// sessionRecord returns the login record of the session at path, or nil if
+// it is not a user session (e.g. a display manager greeter).
+func (r *LogindReader) sessionRecord(path dbus.ObjectPath) (*LoginRecord, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), logindPropertyTimeout)
+	defer cancel()
+	props, err := r.conn.GetSessionPropertiesContext(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return logindSessionRecord(props), nil
+}
+
+// logindSessionRecord converts the properties of a org.freedesktop.login1.Session
+// object into a login record.
+func logindSessionRecord(props map[string]dbus.Variant) *LoginRecord {
+	if class, _ := props["Class"].Value().(string); class != logindUserSessionClass {
+		return nil
+	}
+
+	record := &LoginRecord{
+		Type:   userLoginRecord,
+		UID:    -1,
+		PID:    -1,
+		Origin: logindOrigin,
+	}
+	record.Username, _ = props["Name"].Value().(string)
+	record.TTY, _ = props["TTY"].Value().(string)
+	record.Hostname, _ = props["RemoteHost"].Value().(string)
+	if ip := net.ParseIP(record.Hostname); ip != nil {
+		record.IP = &ip
+	}
+	if leader, ok := props["Leader"].Value().(uint32); ok && leader != 0 {
+		record.PID = int(leader)
+	}
+	// User is a (uid, object path) structure.
+	if user, ok := props["User"].Value().([]interface{}); ok && len(user) > 0 {
+		if uid, ok := user[0].(uint32); ok {
+			record.UID = int(uid)
+		}
+	}
+	if usec, ok := props["Timestamp"].Value().(uint64); ok && usec != 0 {
+		record.Timestamp = time.UnixMicro(int64(usec)).UTC()
+	} else {
+		record.Timestamp = time.Now().UTC()
+	}
+
+	return record
+}
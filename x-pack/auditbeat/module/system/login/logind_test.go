@@ -0,0 +1,108 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+//go:build linux
+
+package login
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-systemd/v22/login1"
+	"github.com/godbus/dbus/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+type fakeLogindConn struct {
+	sessions []login1.Session
+	props    map[dbus.ObjectPath]map[string]dbus.Variant
+	signals  chan *dbus.Signal
+}
+
+func (c *fakeLogindConn) ListSessionsContext(context.Context) ([]login1.Session, error) {
+	return c.sessions, nil
+}
+
+func (c *fakeLogindConn) GetSessionPropertiesContext(_ context.Context, path dbus.ObjectPath) (map[string]dbus.Variant, error) {
+	props, ok := c.props[path]
+	if !ok {
+		return nil, errors.New("no such session")
+	}
+	return props, nil
+}
+
+func (c *fakeLogindConn) Subscribe(...string) chan *dbus.Signal { return c.signals }
+
+func (c *fakeLogindConn) Close() {}
+
+func sessionProps(class, name, tty, remoteHost string, uid, leader uint32) map[string]dbus.Variant {
+	return map[string]dbus.Variant{
+		"Class":      dbus.MakeVariant(class),
+		"Name":       dbus.MakeVariant(name),
+		"TTY":        dbus.MakeVariant(tty),
+		"RemoteHost": dbus.MakeVariant(remoteHost),
+		"Leader":     dbus.MakeVariant(leader),
+		"User":       dbus.MakeVariant([]interface{}{uid, dbus.ObjectPath("/org/freedesktop/login1/user/_1000")}),
+		"Timestamp":  dbus.MakeVariant(uint64(1714557900123456)),
+	}
+}
+
+func TestLogindReader(t *testing.T) {
+	conn := &fakeLogindConn{
+		sessions: []login1.Session{{ID: "1", Path: "/org/freedesktop/login1/session/_31"}},
+		props: map[dbus.ObjectPath]map[string]dbus.Variant{
+			"/org/freedesktop/login1/session/_31": sessionProps("user", "root", "tty1", "", 0, 800),
+			"/org/freedesktop/login1/session/_32": sessionProps("user", "vagrant", "pts/0", "10.0.2.2", 1000, 900),
+			"/org/freedesktop/login1/session/c1":  sessionProps("greeter", "gdm", "", "", 120, 700),
+		},
+		signals: make(chan *dbus.Signal),
+	}
+	r, err := newLogindReader(logp.NewLogger("test"), conn)
+	require.NoError(t, err)
+	defer r.Close()
+
+	signal := func(member string, body ...interface{}) {
+		conn.signals <- &dbus.Signal{Name: logindManager + "." + member, Body: body}
+	}
+	signal(logindSessionNew, "2", dbus.ObjectPath("/org/freedesktop/login1/session/_32"))
+	signal(logindSessionNew, "c1", dbus.ObjectPath("/org/freedesktop/login1/session/c1"))
+	signal(logindSessionRemoved, "2", dbus.ObjectPath("/org/freedesktop/login1/session/_32"))
+	signal(logindSessionRemoved, "1", dbus.ObjectPath("/org/freedesktop/login1/session/_31"))
+	signal(logindSessionRemoved, "unknown", dbus.ObjectPath("/org/freedesktop/login1/session/_99"))
+	signal(logindPrepareShutdown, true)
+
+	var records []LoginRecord
+	require.Eventually(t, func() bool {
+		records = append(records, r.ReadNew()...)
+		return len(records) == 4
+	}, 5*time.Second, 10*time.Millisecond)
+
+	login := records[0]
+	assert.Equal(t, userLoginRecord, login.Type)
+	assert.Equal(t, "vagrant", login.Username)
+	assert.Equal(t, 1000, login.UID)
+	assert.Equal(t, 900, login.PID)
+	assert.Equal(t, "pts/0", login.TTY)
+	assert.Equal(t, "10.0.2.2", login.IP.String())
+	assert.Equal(t, logindOrigin, login.Origin)
+	assert.True(t, login.Timestamp.Equal(time.Date(2024, 5, 1, 10, 5, 0, 123456000, time.UTC)))
+
+	assert.Equal(t, userLogoutRecord, records[1].Type)
+	assert.Equal(t, "vagrant", records[1].Username)
+
+	// Sessions that existed at start are only reported when they end.
+	assert.Equal(t, userLogoutRecord, records[2].Type)
+	assert.Equal(t, "root", records[2].Username)
+	assert.Equal(t, 0, records[2].UID)
+
+	assert.Equal(t, shutdownRecord, records[3].Type)
+
+	assert.Empty(t, r.ReadNew())
+}
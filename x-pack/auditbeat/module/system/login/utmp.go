@@ -81,14 +81,6 @@ func NewUtmpFileReader(log *logp.Logger, bucket datastore.Bucket, config config)
 	return r, nil
 }
 
-// Close performs any cleanup tasks when the UTMP reader is done.
-func (r *UtmpFileReader) Close() error {
-	if r.bucket != nil {
-		return r.bucket.Close()
-	}
-	return nil
-}
-
 // ReadNew returns any new UTMP entries in any files matching the configured pattern.
 func (r *UtmpFileReader) ReadNew() (<-chan LoginRecord, <-chan error) {
 	loginRecordC := make(chan LoginRecord)
@@ -0,0 +1,266 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+//go:build linux
+
+package login
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/elastic/beats/v7/auditbeat/datastore"
+	"github.com/elastic/beats/v7/libbeat/common/sqlite"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+const (
+	bucketKeyWtmpdbState = "wtmpdb_state"
+
+	wtmpdbTable = "wtmp"
+)
+
+// Values of the Type column of the wtmpdb table. See wtmpdb.h.
+const (
+	wtmpdbBootTime    = 1
+	wtmpdbUserProcess = 3
+)
+
+// wtmpdbState is the state of the wtmpdb reader that is kept across restarts.
+type wtmpdbState struct {
+	// LastID is the ID of the last row that was read.
+	LastID int64
+	// Open holds the IDs of the rows read so far without a logout time.
+	Open map[int64]struct{}
+}
+
+// wtmpdbRow is a row of the wtmpdb table.
+type wtmpdbRow struct {
+	ID         int64
+	Type       int64
+	User       string
+	Login      time.Time
+	Logout     time.Time
+	TTY        string
+	RemoteHost string
+}
+
+// WtmpdbReader reads login records from the SQLite database written by
+// wtmpdb, which replaces wtmp on distributions that dropped it. Rows are
+// inserted at login time and updated with the logout time once the
+// session ends, so the reader keeps track of the sessions it has seen
+// open.
+type WtmpdbReader struct {
+	log    *logp.Logger
+	bucket datastore.Bucket
+	path   string
+	state  wtmpdbState
+}
+
+// NewWtmpdbReader creates a wtmpdb reader and restores its state from disk.
+func NewWtmpdbReader(log *logp.Logger, bucket datastore.Bucket, config config) (*WtmpdbReader, error) {
+	r := &WtmpdbReader{
+		log:    log,
+		bucket: bucket,
+		path:   config.WtmpdbFile,
+		state:  wtmpdbState{Open: make(map[int64]struct{})},
+	}
+
+	err := r.bucket.Load(bucketKeyWtmpdbState, func(blob []byte) error {
+		if len(blob) == 0 {
+			return nil
+		}
+		return gob.NewDecoder(bytes.NewReader(blob)).Decode(&r.state)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore wtmpdb state from disk: %w", err)
+	}
+	if r.state.Open == nil {
+		r.state.Open = make(map[int64]struct{})
+	}
+
+	return r, nil
+}
+
+// ReadNew returns the login records for the rows added to the database
+// and for the sessions that ended since the last call.
+func (r *WtmpdbReader) ReadNew() ([]LoginRecord, error) {
+	db, err := sqlite.Open(r.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			r.log.Debugf("wtmpdb database %v does not exist.", r.path)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open wtmpdb database: %w", err)
+	}
+	defer db.Close()
+
+	maxID, err := db.MaxRowID(wtmpdbTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read wtmpdb database %v: %w", r.path, err)
+	}
+	if maxID < r.state.LastID {
+		// IDs only grow, so the database has been recreated.
+		r.log.Warnf("wtmpdb database %v was replaced (last ID %d, saved ID %d) - reading whole database.",
+			r.path, maxID, r.state.LastID)
+		r.state = wtmpdbState{Open: make(map[int64]struct{})}
+	}
+
+	from := r.state.LastID + 1
+	for id := range r.state.Open {
+		from = min(from, id)
+	}
+
+	var rows []wtmpdbRow
+	err = db.ScanTable(wtmpdbTable, from, func(rowid int64, values []interface{}) error {
+		rows = append(rows, newWtmpdbRow(rowid, values))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read wtmpdb database %v: %w", r.path, err)
+	}
+
+	return r.process(rows), nil
+}
+
+// process converts rows, in ID order, into login records and updates the
+// state of the reader.
+func (r *WtmpdbReader) process(rows []wtmpdbRow) []LoginRecord {
+	sort.Slice(rows, func(i, j int) bool { return rows[i].ID < rows[j].ID })
+
+	var records []LoginRecord
+	seen := make(map[int64]struct{}, len(r.state.Open))
+	for _, row := range rows {
+		if row.ID > r.state.LastID {
+			r.state.LastID = row.ID
+
+			login := r.loginRecord(row)
+			if login == nil {
+				continue
+			}
+			records = append(records, *login)
+			if row.Logout.IsZero() {
+				r.state.Open[row.ID] = struct{}{}
+				seen[row.ID] = struct{}{}
+				continue
+			}
+		} else if _, open := r.state.Open[row.ID]; open {
+			seen[row.ID] = struct{}{}
+			if row.Logout.IsZero() {
+				continue
+			}
+		} else {
+			continue
+		}
+
+		delete(r.state.Open, row.ID)
+		if logout := r.logoutRecord(row); logout != nil {
+			records = append(records, *logout)
+		}
+	}
+
+	// Sessions whose row is gone, e.g. after the database was rotated,
+	// will never see their logout.
+	for id := range r.state.Open {
+		if _, ok := seen[id]; !ok {
+			delete(r.state.Open, id)
+		}
+	}
+
+	return records
+}
+
+func (r *WtmpdbReader) loginRecord(row wtmpdbRow) *LoginRecord {
+	record := &LoginRecord{
+		Timestamp: row.Login,
+		UID:       -1,
+		PID:       -1,
+		Origin:    r.path,
+	}
+
+	switch row.Type {
+	case wtmpdbBootTime:
+		record.Type = bootRecord
+	case wtmpdbUserProcess:
+		record.Type = userLoginRecord
+		record.Username = row.User
+		record.UID = lookupUsername(row.User)
+		record.TTY = row.TTY
+		record.Hostname = row.RemoteHost
+		if ip := net.ParseIP(row.RemoteHost); ip != nil {
+			record.IP = &ip
+		}
+	default:
+		r.log.Debugf("Ignoring wtmpdb row of type %v.", row.Type)
+		return nil
+	}
+
+	return record
+}
+
+func (r *WtmpdbReader) logoutRecord(row wtmpdbRow) *LoginRecord {
+	record := r.loginRecord(row)
+	if record == nil {
+		return nil
+	}
+	record.Timestamp = row.Logout
+
+	switch record.Type {
+	case bootRecord:
+		// The logout time of a boot entry is the time of the shutdown.
+		record.Type = shutdownRecord
+	case userLoginRecord:
+		record.Type = userLogoutRecord
+	}
+
+	return record
+}
+
+// newWtmpdbRow converts the values of a row of the wtmp table, which has the
+// columns ID, Type, User, Login, Logout, TTY, RemoteHost and Service. ID is
+// the rowid and times are in microseconds since the epoch.
+func newWtmpdbRow(rowid int64, values []interface{}) wtmpdbRow {
+	row := wtmpdbRow{ID: rowid}
+	column := func(i int) interface{} {
+		if i < len(values) {
+			return values[i]
+		}
+		return nil
+	}
+	usec := func(v interface{}) time.Time {
+		if n, ok := v.(int64); ok && n > 0 {
+			return time.UnixMicro(n).UTC()
+		}
+		return time.Time{}
+	}
+
+	row.Type, _ = column(1).(int64)
+	row.User, _ = column(2).(string)
+	row.Login = usec(column(3))
+	row.Logout = usec(column(4))
+	row.TTY, _ = column(5).(string)
+	row.RemoteHost, _ = column(6).(string)
+	return row
+}
+
+func (r *WtmpdbReader) saveStateToDisk() error {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(r.state)
+	if err != nil {
+		return fmt.Errorf("error encoding wtmpdb state: %w", err)
+	}
+
+	err = r.bucket.Store(bucketKeyWtmpdbState, buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("error writing wtmpdb state to disk: %w", err)
+	}
+
+	r.log.Debugf("Wrote wtmpdb state to disk (last ID %d, %d open sessions)", r.state.LastID, len(r.state.Open))
+	return nil
+}
@@ -0,0 +1,142 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+//go:build linux
+
+package login
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/auditbeat/ab"
+	abtest "github.com/elastic/beats/v7/auditbeat/testing"
+	"github.com/elastic/beats/v7/metricbeat/mb"
+	mbtest "github.com/elastic/beats/v7/metricbeat/mb/testing"
+	"github.com/elastic/beats/v7/x-pack/auditbeat/module/system"
+)
+
+func TestWtmpdb(t *testing.T) {
+	defer abtest.SetupDataDir(t)()
+
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "wtmp.db")
+	copyWtmpdb(t, "./testdata/logins.sqlite", dbPath)
+
+	config := map[string]interface{}{
+		"module":            system.ModuleName,
+		"datasets":          []string{"login"},
+		"login.sources":     []string{"wtmpdb"},
+		"login.wtmpdb_file": dbPath,
+	}
+	f := mbtest.NewReportingMetricSetV2WithRegistry(t, config, ab.Registry)
+	defer func() {
+		if err := f.(*MetricSet).bucket.DeleteBucket(); err != nil {
+			t.Fatalf("received error: %+v", err)
+		}
+	}()
+
+	events, errs := mbtest.ReportingFetchV2(f)
+	require.Empty(t, errs)
+	// Boot, login of vagrant and root, logout of vagrant, and one login and
+	// logout for each of the 37 other users.
+	require.Len(t, events, 4+2*37)
+
+	assertEventFields(t, events[0], map[string]interface{}{
+		"event.action":   "boot",
+		"event.category": []string{"host"},
+		"event.origin":   dbPath,
+	})
+	assert.True(t, events[0].Timestamp.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)))
+
+	assertEventFields(t, events[1], map[string]interface{}{
+		"event.action":  "user_login",
+		"event.outcome": "success",
+		"user.name":     "vagrant",
+		"user.terminal": "pts/0",
+		"source.ip":     "10.0.2.2",
+	})
+	assert.True(t, events[1].Timestamp.Equal(time.Date(2024, 5, 1, 10, 5, 0, 123456000, time.UTC)))
+	assertEventFields(t, events[2], map[string]interface{}{
+		"event.action": "user_logout",
+		"event.type":   []string{"end"},
+		"user.name":    "vagrant",
+	})
+	assert.True(t, events[2].Timestamp.Equal(time.Date(2024, 5, 1, 10, 20, 0, 0, time.UTC)))
+	assertEventFields(t, events[3], map[string]interface{}{
+		"event.action":  "user_login",
+		"user.name":     "root",
+		"user.terminal": "tty1",
+	})
+
+	// Nothing changed.
+	events, errs = mbtest.ReportingFetchV2(f)
+	require.Empty(t, errs)
+	assert.Empty(t, events)
+
+	// root logged out, alice logged in and the system was shut down.
+	copyWtmpdb(t, "./testdata/logins-later.sqlite", dbPath)
+	events, errs = mbtest.ReportingFetchV2(f)
+	require.Empty(t, errs)
+	require.Len(t, events, 3)
+	assertEventFields(t, events[0], map[string]interface{}{
+		"event.action": "shutdown",
+		"event.type":   []string{"end"},
+	})
+	assert.True(t, events[0].Timestamp.Equal(time.Date(2024, 5, 1, 18, 0, 0, 0, time.UTC)))
+	assertEventFields(t, events[1], map[string]interface{}{
+		"event.action": "user_logout",
+		"user.name":    "root",
+	})
+	assertEventFields(t, events[2], map[string]interface{}{
+		"event.action":  "user_login",
+		"user.name":     "alice",
+		"source.domain": "example.com",
+	})
+	assert.Equal(t, map[int64]struct{}{41: {}}, f.(*MetricSet).wtmpdbReader.state.Open)
+	assert.Equal(t, int64(41), f.(*MetricSet).wtmpdbReader.state.LastID)
+}
+
+func TestWtmpdbMissing(t *testing.T) {
+	defer abtest.SetupDataDir(t)()
+
+	config := map[string]interface{}{
+		"module":            system.ModuleName,
+		"datasets":          []string{"login"},
+		"login.sources":     []string{"wtmpdb"},
+		"login.wtmpdb_file": filepath.Join(t.TempDir(), "wtmp.db"),
+	}
+	f := mbtest.NewReportingMetricSetV2WithRegistry(t, config, ab.Registry)
+	defer f.(*MetricSet).bucket.DeleteBucket() //nolint:errcheck // Test cleanup.
+
+	events, errs := mbtest.ReportingFetchV2(f)
+	assert.Empty(t, errs)
+	assert.Empty(t, events)
+}
+
+func assertEventFields(t *testing.T, event mb.Event, fields map[string]interface{}) {
+	t.Helper()
+	for name, want := range fields {
+		got, err := event.RootFields.GetValue(name)
+		if !assert.NoError(t, err, name) {
+			continue
+		}
+		if s, ok := got.(interface{ String() string }); ok {
+			got = s.String()
+		}
+		assert.Equal(t, want, got, name)
+	}
+}
+
+func copyWtmpdb(t *testing.T, src, dst string) {
+	t.Helper()
+	b, err := os.ReadFile(src)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(dst, b, 0o644))
+}
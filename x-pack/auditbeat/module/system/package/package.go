@@ -50,9 +50,14 @@ const (
 )
 
 var (
-	rpmPath            = "/var/lib/rpm"
+	// rpmDBPaths are the possible locations of the RPM database. Recent RPM
+	// versions, used with dnf5, keep it under /usr/lib/sysimage.
+	rpmDBPaths         = []string{"/var/lib/rpm", "/usr/lib/sysimage/rpm"}
 	dpkgPath           = "/var/lib/dpkg"
 	homebrewCellarPath = []string{"/usr/local/Cellar", "/opt/homebrew/Cellar"}
+	apkDBPath          = "/lib/apk/db/installed"
+	snapPaths          = []string{"/snap", "/var/lib/snapd/snap"}
+	flatpakPath        = "/var/lib/flatpak"
 )
 
 type eventAction uint8
@@ -137,6 +142,7 @@ func (pkg Package) Hash() uint64 {
 	h.WriteString(pkg.Name)
 	h.WriteString(pkg.Version)
 	h.WriteString(pkg.Release)
+	h.WriteString(pkg.Type)
 	binary.Write(h, binary.LittleEndian, pkg.Size)
 	return h.Sum64()
 }
@@ -326,8 +332,8 @@ func (ms *MetricSet) reportChanges(report mb.ReporterV2) error {
 	newPackages := convertToPackage(newInCache)
 	missingPackages := convertToPackage(missingFromCache)
 
-	// Package names of updated packages
-	updated := make(map[string]struct{})
+	// Package names and types of updated packages
+	updated := make(map[[2]string]struct{})
 
 	for _, missingPkg := range missingPackages {
 		found := false
@@ -335,9 +341,9 @@ func (ms *MetricSet) reportChanges(report mb.ReporterV2) error {
 		// Using an inner loop is less efficient than using a map, but in this case
 		// we do not expect a lot of installed or removed packages all at once.
 		for _, newPkg := range newPackages {
-			if missingPkg.Name == newPkg.Name {
+			if missingPkg.Name == newPkg.Name && missingPkg.Type == newPkg.Type {
 				found = true
-				updated[newPkg.Name] = struct{}{}
+				updated[[2]string{newPkg.Name, newPkg.Type}] = struct{}{}
 				report.Event(ms.packageEvent(newPkg, eventTypeEvent, eventActionPackageUpdated))
 				break
 			}
@@ -349,7 +355,7 @@ func (ms *MetricSet) reportChanges(report mb.ReporterV2) error {
 	}
 
 	for _, newPkg := range newPackages {
-		if _, contains := updated[newPkg.Name]; !contains {
+		if _, contains := updated[[2]string{newPkg.Name, newPkg.Type}]; !contains {
 			report.Event(ms.packageEvent(newPkg, eventTypeEvent, eventActionPackageInstalled))
 		}
 	}
@@ -493,8 +499,11 @@ func storePackages(bucket datastore.Bucket, packages []*Package) error {
 func (ms *MetricSet) getPackages() ([]*Package, error) {
 	packages := []*Package{}
 	var foundPackageManager bool
-	_, statErr := os.Stat(rpmPath)
-	if statErr == nil {
+	rpmPath, err := firstExistingPath(rpmDBPaths)
+	if err != nil {
+		return nil, err
+	}
+	if rpmPath != "" {
 		foundPackageManager = true
 		if ms.config.PackageSuidDrop != nil {
 
@@ -548,12 +557,9 @@ func (ms *MetricSet) getPackages() ([]*Package, error) {
 			}
 			packages = append(packages, rpmPackages...)
 		}
-
-	} else if !os.IsNotExist(statErr) {
-		return nil, fmt.Errorf("error opening %v: %w", rpmPath, statErr)
 	}
 
-	_, statErr = os.Stat(dpkgPath)
+	_, statErr := os.Stat(dpkgPath)
 	if statErr == nil {
 		foundPackageManager = true
 
@@ -586,9 +592,56 @@ func (ms *MetricSet) getPackages() ([]*Package, error) {
 		break
 	}
 
+	_, statErr = os.Stat(apkDBPath)
+	if statErr == nil {
+		foundPackageManager = true
+
+		apkPackages, err := listApkPackages(apkDBPath)
+		if err != nil {
+			return nil, fmt.Errorf("error getting APK packages: %w", err)
+		}
+		ms.log.Debugf("APK packages: %v", len(apkPackages))
+
+		packages = append(packages, apkPackages...)
+	} else if !os.IsNotExist(statErr) {
+		return nil, fmt.Errorf("error opening %v: %w", apkDBPath, statErr)
+	}
+
+	snapPath, err := firstExistingPath(snapPaths)
+	if err != nil {
+		return nil, err
+	}
+	if snapPath != "" {
+		foundPackageManager = true
+
+		snapPackages, err := listSnapPackages(snapPath)
+		if err != nil {
+			return nil, fmt.Errorf("error getting snap packages: %w", err)
+		}
+		ms.log.Debugf("Snap packages: %v", len(snapPackages))
+
+		packages = append(packages, snapPackages...)
+	}
+
+	_, statErr = os.Stat(flatpakPath)
+	if statErr == nil {
+		foundPackageManager = true
+
+		flatpakPackages, err := listFlatpakPackages(flatpakPath)
+		if err != nil {
+			return nil, fmt.Errorf("error getting Flatpak packages: %w", err)
+		}
+		ms.log.Debugf("Flatpak packages: %v", len(flatpakPackages))
+
+		packages = append(packages, flatpakPackages...)
+	} else if !os.IsNotExist(statErr) {
+		return nil, fmt.Errorf("error opening %v: %w", flatpakPath, statErr)
+	}
+
 	if !foundPackageManager && !ms.suppressNoPackageWarnings {
-		ms.log.Warnf("No supported package managers found. None of %v, %v, %v exist.",
-			rpmPath, dpkgPath, strings.Join(homebrewCellarPath, ","))
+		ms.log.Warnf("No supported package managers found. None of %v, %v, %v, %v, %v, %v exist.",
+			strings.Join(rpmDBPaths, ","), dpkgPath, strings.Join(homebrewCellarPath, ","),
+			apkDBPath, strings.Join(snapPaths, ","), flatpakPath)
 
 		// Only warn once at the start of Auditbeat.
 		ms.suppressNoPackageWarnings = true
@@ -597,6 +650,21 @@ func (ms *MetricSet) getPackages() ([]*Package, error) {
 	return packages, nil
 }
 
+// firstExistingPath returns the first of paths that exists, or an empty string
+// if none of them exists.
+func firstExistingPath(paths []string) (string, error) {
+	for _, path := range paths {
+		_, err := os.Stat(path)
+		if err == nil {
+			return path, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("error opening %v: %w", path, err)
+		}
+	}
+	return "", nil
+}
+
 func (ms *MetricSet) listDebPackages() ([]*Package, error) {
 	dpkgStatusFile := filepath.Join(dpkgPath, "status")
 
@@ -0,0 +1,81 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+//go:build !windows
+
+package pkg
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// listApkPackages lists the packages in the database of the Alpine Package
+// Keeper. The database is a text file with a block of single letter fields
+// for each installed package.
+func listApkPackages(dbPath string) ([]*Package, error) {
+	file, err := os.Open(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening %s: %w", dbPath, err)
+	}
+	defer file.Close()
+
+	var packages []*Package
+	var pkg *Package
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			// empty line signals new package
+			if pkg != nil && pkg.Name != "" {
+				packages = append(packages, pkg)
+			}
+			pkg = nil
+			continue
+		}
+		if len(line) < 2 || line[1] != ':' {
+			return nil, fmt.Errorf("the following line was unexpected (no ':' found): '%s'", line)
+		}
+
+		if pkg == nil {
+			pkg = &Package{
+				Type: "apk",
+			}
+		}
+
+		value := line[2:]
+		switch line[0] {
+		case 'P':
+			pkg.Name = value
+		case 'V':
+			pkg.Version = value
+		case 'A':
+			pkg.Arch = value
+		case 'I':
+			// Installed size in bytes.
+			if size, err := strconv.ParseUint(value, 10, 64); err == nil {
+				pkg.Size = size
+			}
+		case 'T':
+			pkg.Summary = value
+		case 'U':
+			pkg.URL = value
+		case 'L':
+			pkg.License = value
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error scanning file %v: %w", dbPath, err)
+	}
+
+	// Append last package if file ends without newline
+	if pkg != nil && pkg.Name != "" {
+		packages = append(packages, pkg)
+	}
+
+	return packages, nil
+}
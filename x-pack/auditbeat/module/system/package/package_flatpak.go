@@ -0,0 +1,122 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+//go:build !windows
+
+package pkg
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// appStream represents the fields of the AppStream metadata of a Flatpak
+// used for a package.
+type appStream struct {
+	Summaries []struct {
+		Lang  string `xml:"lang,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"summary"`
+	URLs []struct {
+		Type  string `xml:"type,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"url"`
+	License  string `xml:"project_license"`
+	Releases []struct {
+		Version string `xml:"version,attr"`
+	} `xml:"releases>release"`
+}
+
+// listFlatpakPackages lists the applications and runtimes of the Flatpak
+// installation at installPath. Each deployed branch is reported as a package
+// with the branch as the release. The version is read from the AppStream
+// metadata of the deployment, and falls back to its commit.
+func listFlatpakPackages(installPath string) ([]*Package, error) {
+	var packages []*Package
+	for _, kind := range []string{"app", "runtime"} {
+		// Deployments are laid out as <kind>/<id>/<arch>/<branch>/active.
+		matches, err := filepath.Glob(filepath.Join(installPath, kind, "*", "*", "*", "active"))
+		if err != nil {
+			return nil, err
+		}
+		for _, activePath := range matches {
+			branchPath := filepath.Dir(activePath)
+			archPath := filepath.Dir(branchPath)
+			pkg := &Package{
+				Name:    filepath.Base(filepath.Dir(archPath)),
+				Arch:    filepath.Base(archPath),
+				Release: filepath.Base(branchPath),
+				Type:    "flatpak",
+			}
+
+			commit, err := os.Readlink(activePath)
+			if err != nil {
+				return nil, fmt.Errorf("error reading %s: %w", activePath, err)
+			}
+			if info, err := os.Stat(activePath); err == nil {
+				pkg.InstallTime = info.ModTime()
+			}
+
+			if meta, err := readAppStream(activePath, pkg.Name); err != nil {
+				pkg.error = err
+			} else if meta != nil {
+				for _, s := range meta.Summaries {
+					if s.Lang == "" {
+						pkg.Summary = s.Value
+						break
+					}
+				}
+				for _, u := range meta.URLs {
+					if u.Type == "homepage" {
+						pkg.URL = u.Value
+						break
+					}
+				}
+				pkg.License = meta.License
+				if len(meta.Releases) > 0 {
+					pkg.Version = meta.Releases[0].Version
+				}
+			}
+			if pkg.Version == "" {
+				commit = filepath.Base(commit)
+				if len(commit) > 12 {
+					commit = commit[:12]
+				}
+				pkg.Version = commit
+			}
+
+			packages = append(packages, pkg)
+		}
+	}
+	return packages, nil
+}
+
+// readAppStream reads the AppStream metadata of the Flatpak deployment at
+// deployPath. It returns nil if the deployment has no metadata.
+func readAppStream(deployPath, id string) (*appStream, error) {
+	candidates := []string{
+		filepath.Join(deployPath, "files", "share", "metainfo", id+".metainfo.xml"),
+		filepath.Join(deployPath, "files", "share", "metainfo", id+".appdata.xml"),
+		filepath.Join(deployPath, "files", "share", "appdata", id+".appdata.xml"),
+	}
+	for _, path := range candidates {
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("error reading %v: %w", path, err)
+		}
+		var meta appStream
+		if err = xml.Unmarshal(contents, &meta); err != nil {
+			return nil, fmt.Errorf("error unmarshalling XML in %v: %w", path, err)
+		}
+		return &meta, nil
+	}
+	return nil, nil
+}
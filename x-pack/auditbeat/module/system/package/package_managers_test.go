@@ -0,0 +1,160 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+//go:build !windows
+
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/auditbeat/ab"
+	"github.com/elastic/beats/v7/auditbeat/core"
+	abtest "github.com/elastic/beats/v7/auditbeat/testing"
+	mbtest "github.com/elastic/beats/v7/metricbeat/mb/testing"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+func TestApk(t *testing.T) {
+	packages, err := listApkPackages("testdata/apk/installed")
+	require.NoError(t, err)
+	require.Len(t, packages, 2)
+
+	assert.Equal(t, &Package{
+		Name:    "musl",
+		Version: "1.2.4-r2",
+		Arch:    "x86_64",
+		License: "MIT",
+		Size:    622592,
+		Summary: "the musl c library (libc) implementation",
+		URL:     "https://musl.libc.org/",
+		Type:    "apk",
+	}, packages[0])
+	assert.Equal(t, "busybox", packages[1].Name)
+	assert.Equal(t, "1.36.1-r15", packages[1].Version)
+}
+
+func TestSnap(t *testing.T) {
+	oldPath := snapBlobsPath
+	defer func() {
+		snapBlobsPath = oldPath
+	}()
+	snapBlobsPath = "testdata/snapd-snaps"
+
+	packages, err := listSnapPackages("testdata/snap")
+	require.NoError(t, err)
+	require.Len(t, packages, 1)
+
+	pkg := packages[0]
+	require.NoError(t, pkg.error)
+	assert.Equal(t, "hello", pkg.Name)
+	assert.Equal(t, "2.10", pkg.Version)
+	assert.Equal(t, "29", pkg.Release)
+	assert.Equal(t, `GNU Hello, the "hello world" snap`, pkg.Summary)
+	assert.Equal(t, "GPL-3.0", pkg.License)
+	assert.Equal(t, uint64(4), pkg.Size)
+	assert.False(t, pkg.InstallTime.IsZero())
+	assert.Equal(t, "snap", pkg.Type)
+}
+
+func TestFlatpak(t *testing.T) {
+	packages, err := listFlatpakPackages("testdata/flatpak")
+	require.NoError(t, err)
+	require.Len(t, packages, 2)
+
+	app := packages[0]
+	require.NoError(t, app.error)
+	assert.Equal(t, "org.example.Hello", app.Name)
+	assert.Equal(t, "1.4.0", app.Version)
+	assert.Equal(t, "stable", app.Release)
+	assert.Equal(t, "x86_64", app.Arch)
+	assert.Equal(t, "Say hello", app.Summary)
+	assert.Equal(t, "https://example.org/hello", app.URL)
+	assert.Equal(t, "MIT", app.License)
+	assert.Equal(t, "flatpak", app.Type)
+
+	// Runtimes without AppStream metadata use their commit as version.
+	runtime := packages[1]
+	require.NoError(t, runtime.error)
+	assert.Equal(t, "org.example.Platform", runtime.Name)
+	assert.Equal(t, "0f1e2d3c4b5a", runtime.Version)
+	assert.Equal(t, "23.08", runtime.Release)
+}
+
+func TestPackageManagersChanges(t *testing.T) {
+	logp.TestingSetup()
+
+	defer abtest.SetupDataDir(t)()
+
+	// Disable all except apk and snap
+	rpmPathOld, dpkgPathOld, brewPathOld := rpmDBPaths, dpkgPath, homebrewCellarPath
+	apkPathOld, snapPathOld, snapBlobsPathOld, flatpakPathOld := apkDBPath, snapPaths, snapBlobsPath, flatpakPath
+	defer func() {
+		rpmDBPaths, dpkgPath, homebrewCellarPath = rpmPathOld, dpkgPathOld, brewPathOld
+		apkDBPath, snapPaths, snapBlobsPath, flatpakPath = apkPathOld, snapPathOld, snapBlobsPathOld, flatpakPathOld
+	}()
+	rpmDBPaths = []string{"/does/not/exist"}
+	dpkgPath = "/does/not/exist"
+	homebrewCellarPath = []string{"/does/not/exist"}
+	flatpakPath = "/does/not/exist"
+	snapBlobsPath = "/does/not/exist"
+
+	dir := t.TempDir()
+	apkDBPath = filepath.Join(dir, "installed")
+	snapPaths = []string{filepath.Join(dir, "snap")}
+	writeApkDB := func(musl string) {
+		require.NoError(t, os.WriteFile(apkDBPath, []byte("P:musl\nV:"+musl+"\n\nP:hello\nV:1.0\n"), 0o644))
+	}
+	writeApkDB("1.2.4-r2")
+	// A snap with the same name as an APK package.
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "snap", "hello", "29", "meta"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "snap", "hello", "29", "meta", "snap.yaml"), []byte("version: \"2.10\"\n"), 0o644))
+	require.NoError(t, os.Symlink("29", filepath.Join(dir, "snap", "hello", "current")))
+
+	f := mbtest.NewReportingMetricSetV2WithRegistry(t, getConfig(), ab.Registry)
+	defer deleteBucket(t, f)
+
+	events, errs := mbtest.ReportingFetchV2(f)
+	require.Empty(t, errs)
+	require.Len(t, events, 3)
+
+	// Update the APK package and remove the snap with the same name
+	// as an APK package.
+	writeApkDB("1.2.5-r0")
+	require.NoError(t, os.RemoveAll(filepath.Join(dir, "snap", "hello")))
+
+	events, errs = mbtest.ReportingFetchV2(f)
+	require.Empty(t, errs)
+	require.Len(t, events, 2)
+
+	actions := map[string]string{}
+	for _, e := range events {
+		event := mbtest.StandardizeEvent(f, e, core.AddDatasetToEvent)
+		name, _ := event.GetValue("package.name")
+		typ, _ := event.GetValue("package.type")
+		action, _ := event.GetValue("event.action")
+		actions[name.(string)+"/"+typ.(string)] = action.(string)
+	}
+	assert.Equal(t, map[string]string{
+		"musl/apk":   "package_updated",
+		"hello/snap": "package_removed",
+	}, actions)
+}
+
+// disableApkSnapFlatpak points the APK, snap and Flatpak paths to locations
+// that don't exist for the duration of the test.
+func disableApkSnapFlatpak(t *testing.T) {
+	apkPathOld, snapPathOld, flatpakPathOld := apkDBPath, snapPaths, flatpakPath
+	t.Cleanup(func() {
+		apkDBPath, snapPaths, flatpakPath = apkPathOld, snapPathOld, flatpakPathOld
+	})
+	apkDBPath = "/does/not/exist"
+	snapPaths = []string{"/does/not/exist"}
+	flatpakPath = "/does/not/exist"
+}
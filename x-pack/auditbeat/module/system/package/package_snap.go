@@ -0,0 +1,85 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+//go:build !windows
+
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// snapBlobsPath is the directory holding the squashfs images of the
+// installed snap revisions.
+var snapBlobsPath = "/var/lib/snapd/snaps"
+
+// snapMeta represents the fields of meta/snap.yaml used for a package.
+type snapMeta struct {
+	Name    string `yaml:"name"`
+	Version string `yaml:"version"`
+	Summary string `yaml:"summary"`
+	License string `yaml:"license"`
+}
+
+// listSnapPackages lists the snaps mounted under snapPath. Only the current
+// revision of each snap is reported, its revision is reported as the release.
+func listSnapPackages(snapPath string) ([]*Package, error) {
+	snapDirs, err := os.ReadDir(snapPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var packages []*Package
+	for _, snapDir := range snapDirs {
+		if !snapDir.IsDir() {
+			continue
+		}
+		currentPath := filepath.Join(snapPath, snapDir.Name(), "current")
+		revision, err := os.Readlink(currentPath)
+		if err != nil {
+			// Not a snap, like /snap/bin.
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("error reading %s: %w", currentPath, err)
+		}
+		revision = filepath.Base(revision)
+
+		pkg := &Package{
+			Name:    snapDir.Name(),
+			Release: revision,
+			Type:    "snap",
+		}
+
+		metaPath := filepath.Join(currentPath, "meta", "snap.yaml")
+		contents, err := os.ReadFile(metaPath)
+		if err != nil {
+			pkg.error = fmt.Errorf("error reading %v: %w", metaPath, err)
+		} else {
+			var meta snapMeta
+			if err = yaml.Unmarshal(contents, &meta); err != nil {
+				pkg.error = fmt.Errorf("error unmarshalling YAML in %v: %w", metaPath, err)
+			} else {
+				pkg.Version = meta.Version
+				pkg.Summary = meta.Summary
+				pkg.License = meta.License
+			}
+		}
+
+		blobPath := filepath.Join(snapBlobsPath, pkg.Name+"_"+revision+".snap")
+		if info, err := os.Stat(blobPath); err == nil {
+			pkg.Size = uint64(info.Size())
+			pkg.InstallTime = info.ModTime()
+		}
+
+		packages = append(packages, pkg)
+	}
+	return packages, nil
+}
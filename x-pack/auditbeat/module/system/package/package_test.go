@@ -53,16 +53,17 @@ func TestDpkg(t *testing.T) {
 	defer abtest.SetupDataDir(t)()
 
 	// Disable all except dpkg
-	rpmPathOld := rpmPath
+	rpmPathOld := rpmDBPaths
 	dpkgPathOld := dpkgPath
 	brewPathOld := homebrewCellarPath
 	defer func() {
-		rpmPath = rpmPathOld
+		rpmDBPaths = rpmPathOld
 		dpkgPath = dpkgPathOld
 		homebrewCellarPath = brewPathOld
 	}()
-	rpmPath = "/does/not/exist"
+	rpmDBPaths = []string{"/does/not/exist"}
 	homebrewCellarPath = []string{"/does/not/exist"}
+	disableApkSnapFlatpak(t)
 
 	var err error
 	dpkgPath, err = filepath.Abs("testdata/dpkg/")
@@ -112,16 +113,17 @@ func TestDpkgInstalledSize(t *testing.T) {
 	defer abtest.SetupDataDir(t)()
 
 	// Disable all except dpkg
-	rpmPathOld := rpmPath
+	rpmPathOld := rpmDBPaths
 	dpkgPathOld := dpkgPath
 	brewPathOld := homebrewCellarPath
 	defer func() {
-		rpmPath = rpmPathOld
+		rpmDBPaths = rpmPathOld
 		dpkgPath = dpkgPathOld
 		homebrewCellarPath = brewPathOld
 	}()
-	rpmPath = "/does/not/exist"
+	rpmDBPaths = []string{"/does/not/exist"}
 	homebrewCellarPath = []string{"/does/not/exist"}
+	disableApkSnapFlatpak(t)
 
 	var err error
 	dpkgPath, err = filepath.Abs("testdata/dpkg-size/")
@@ -7,18 +7,17 @@
 package pkg
 
 import (
-	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func TestRPMPackages(t *testing.T) {
-	_, err := os.Stat(rpmPath)
-	if os.IsNotExist(err) {
-		t.Skipf("RPM test only on systems where %v exists", rpmPath)
-	} else if err != nil {
+	rpmPath, err := firstExistingPath(rpmDBPaths)
+	if err != nil {
 		t.Fatal(err)
+	} else if rpmPath == "" {
+		t.Skipf("RPM test only on systems where one of %v exists", rpmDBPaths)
 	}
 
 	// Control using the exec command
@@ -5,6 +5,7 @@
 package process
 
 import (
+	"errors"
 	"time"
 
 	"github.com/elastic/beats/v7/auditbeat/helper/hasher"
@@ -15,11 +16,23 @@ type Config struct {
 	StatePeriod        time.Duration `config:"state.period"`
 	ProcessStatePeriod time.Duration `config:"process.state.period"`
 
+	// InventoryPeriod is the interval at which a complete process inventory
+	// is sent in addition to the state and the start and stop events. Zero
+	// disables the inventory.
+	InventoryPeriod   time.Duration `config:"process.inventory.period"`
+	InventoryPageSize int           `config:"process.inventory.page_size"`
+
 	HasherConfig hasher.Config `config:"process.hash"`
 }
 
 // Validate validates the config.
 func (c *Config) Validate() error {
+	if c.InventoryPeriod < 0 {
+		return errors.New("process.inventory.period must not be negative")
+	}
+	if c.InventoryPageSize <= 0 {
+		return errors.New("process.inventory.page_size must be greater than zero")
+	}
 	return c.HasherConfig.Validate()
 }
 
@@ -33,6 +46,8 @@ func (c *Config) effectiveStatePeriod() time.Duration {
 var defaultConfig = Config{
 	StatePeriod: 12 * time.Hour,
 
+	InventoryPageSize: 500,
+
 	HasherConfig: hasher.Config{
 		HashTypes:           []hasher.HashType{hasher.SHA1},
 		MaxFileSize:         "100 MiB",
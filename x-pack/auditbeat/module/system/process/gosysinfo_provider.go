@@ -9,6 +9,7 @@ import (
 	"os"
 	"os/user"
 	"runtime"
+	"sort"
 	"strconv"
 	"time"
 
@@ -40,6 +41,9 @@ type SysInfoMetricSet struct {
 	bucket    datastore.Bucket
 	lastState time.Time
 
+	// lastInventory is the time the last process inventory was sent.
+	lastInventory time.Time
+
 	suppressPermissionWarnings bool
 }
 
@@ -122,6 +126,7 @@ func NewFromSysInfo(base mb.BaseMetricSet, ms MetricSet) (mb.MetricSet, error) {
 		cache:           cache.New(),
 		bucket:          bucket,
 		lastState:       lastState,
+		lastInventory:   time.Now(),
 		hasher:          hasher,
 	}
 
@@ -154,6 +159,14 @@ func (ms *SysInfoMetricSet) Fetch(report mb.ReporterV2) {
 		ms.log.Error(err)
 		report.Error(err)
 	}
+
+	if ms.config.InventoryPeriod > 0 && time.Since(ms.lastInventory) >= ms.config.InventoryPeriod {
+		err = ms.reportInventory(report)
+		if err != nil {
+			ms.log.Error(err)
+			report.Error(err)
+		}
+	}
 }
 
 // reportState reports all running processes on the system.
@@ -244,6 +257,108 @@ func (ms *SysInfoMetricSet) reportChanges(report mb.ReporterV2) error {
 	return nil
 }
 
+// reportInventory reports a complete inventory of the running processes,
+// split into events of at most process.inventory.page_size processes. All
+// the pages of an inventory share the same event.id, so that consumers can
+// rebuild the process state from a single inventory.
+func (ms *SysInfoMetricSet) reportInventory(report mb.ReporterV2) error {
+	ms.lastInventory = time.Now()
+
+	processes, err := ms.getProcesses()
+	if err != nil {
+		return fmt.Errorf("failed to get processes: %w", err)
+	}
+
+	inventoryID, err := uuid.NewV4()
+	if err != nil {
+		return fmt.Errorf("error generating inventory ID: %w", err)
+	}
+
+	for _, event := range ms.inventoryEvents(inventoryID.String(), processes) {
+		if !report.Event(event) {
+			return nil
+		}
+	}
+	ms.log.Debugf("Sent inventory of %v processes. Next inventory by %v",
+		len(processes), ms.lastInventory.Add(ms.config.InventoryPeriod))
+
+	return nil
+}
+
+// inventoryEvents builds the events of an inventory of processes, ordered
+// by PID.
+func (ms *SysInfoMetricSet) inventoryEvents(id string, processes []*Process) []mb.Event {
+	sort.Slice(processes, func(i, j int) bool {
+		return processes[i].Info.PID < processes[j].Info.PID
+	})
+
+	pageSize := ms.config.InventoryPageSize
+	pages := (len(processes) + pageSize - 1) / pageSize
+	if pages == 0 {
+		// Always send one page, so that an empty inventory is not taken
+		// for a lost one.
+		pages = 1
+	}
+
+	events := make([]mb.Event, 0, pages)
+	for page := 0; page < pages; page++ {
+		start := page * pageSize
+		end := min(start+pageSize, len(processes))
+
+		entries := make([]mapstr.M, 0, end-start)
+		for _, p := range processes[start:end] {
+			entries = append(entries, ms.inventoryEntry(p))
+		}
+
+		events = append(events, mb.Event{
+			RootFields: mapstr.M{
+				"event": mapstr.M{
+					"kind":     eventTypeState,
+					"category": []string{"process"},
+					"type":     []string{eventActionProcessInventory.Type()},
+					"action":   eventActionProcessInventory.String(),
+					"id":       id,
+				},
+				"message": fmt.Sprintf("Process inventory page %d of %d (%d processes)",
+					page+1, pages, len(processes)),
+			},
+			MetricSetFields: mapstr.M{
+				"inventory": mapstr.M{
+					"page":      page + 1,
+					"pages":     pages,
+					"total":     len(processes),
+					"processes": entries,
+				},
+			},
+		})
+	}
+
+	return events
+}
+
+// inventoryEntry returns the summary of a process that is included in an
+// inventory. Unlike processEvent it does not hash the executable or look up
+// the user, so that inventories stay cheap to produce.
+func (ms *SysInfoMetricSet) inventoryEntry(process *Process) mapstr.M {
+	entry := mapstr.M{
+		"pid":    process.Info.PID,
+		"parent": mapstr.M{"pid": process.Info.PPID},
+		"name":   process.Info.Name,
+		"start":  process.Info.StartTime,
+	}
+	putIfNotEmpty(&entry, "executable", process.Info.Exe)
+	if len(process.Info.Args) > 0 {
+		entry.Put("args", process.Info.Args)
+	}
+	if process.UserInfo != nil {
+		putIfNotEmpty(&entry, "user.id", process.UserInfo.UID)
+	}
+	if ms.HostID() != "" {
+		entry.Put("entity_id", entityID(ms.HostID(), process.Info.PID, process.Info.StartTime))
+	}
+	return entry
+}
+
 // enrichProcess enriches a process with user lookup information
 // and executable file hash.
 func (ms *SysInfoMetricSet) enrichProcess(process *Process) {
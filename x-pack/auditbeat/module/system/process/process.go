@@ -37,6 +37,7 @@ const (
 	eventActionProcessStarted
 	eventActionProcessStopped
 	eventActionProcessError
+	eventActionProcessInventory
 )
 
 func (action eventAction) String() string {
@@ -49,6 +50,8 @@ func (action eventAction) String() string {
 		return "process_stopped"
 	case eventActionProcessError:
 		return "process_error"
+	case eventActionProcessInventory:
+		return "process_inventory"
 	default:
 		return ""
 	}
@@ -64,6 +67,8 @@ func (action eventAction) Type() string {
 		return "end"
 	case eventActionProcessError:
 		return "info"
+	case eventActionProcessInventory:
+		return "info"
 	default:
 		return "info"
 	}
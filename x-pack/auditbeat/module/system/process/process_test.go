@@ -155,3 +155,70 @@ func TestPutIfNotEmpty(t *testing.T) {
 		assert.False(t, hasKey)
 	}
 }
+
+func TestInventoryEvents(t *testing.T) {
+	ms, ok := mbtest.NewReportingMetricSetV2WithRegistry(t, getConfig(), ab.Registry).(*SysInfoMetricSet)
+	assert.True(t, ok)
+	ms.config.InventoryPageSize = 2
+
+	var processes []*Process
+	for _, pid := range []int{30, 10, 20, 50, 40} {
+		p := testProcess()
+		p.Info.PID = pid
+		processes = append(processes, p)
+	}
+
+	events := ms.inventoryEvents("inventory-id", processes)
+	if !assert.Len(t, events, 3) {
+		return
+	}
+
+	var pids []int
+	for i, event := range events {
+		assert.Equal(t, "state", mustGet(t, event.RootFields, "event.kind"))
+		assert.Equal(t, "process_inventory", mustGet(t, event.RootFields, "event.action"))
+		assert.Equal(t, "inventory-id", mustGet(t, event.RootFields, "event.id"))
+		assert.Equal(t, i+1, mustGet(t, event.MetricSetFields, "inventory.page"))
+		assert.Equal(t, 3, mustGet(t, event.MetricSetFields, "inventory.pages"))
+		assert.Equal(t, 5, mustGet(t, event.MetricSetFields, "inventory.total"))
+
+		entries, ok := mustGet(t, event.MetricSetFields, "inventory.processes").([]mapstr.M)
+		if !assert.True(t, ok) {
+			return
+		}
+		for _, entry := range entries {
+			pids = append(pids, mustGet(t, entry, "pid").(int))
+			assert.Equal(t, 9085, mustGet(t, entry, "parent.pid"))
+			assert.Equal(t, "/bin/zsh", mustGet(t, entry, "executable"))
+			assert.Equal(t, "1000", mustGet(t, entry, "user.id"))
+		}
+	}
+	assert.Equal(t, []int{10, 20, 30, 40, 50}, pids)
+
+	events = ms.inventoryEvents("empty", nil)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, 0, mustGet(t, events[0].MetricSetFields, "inventory.total"))
+		assert.Equal(t, 1, mustGet(t, events[0].MetricSetFields, "inventory.pages"))
+	}
+}
+
+func TestInventoryConfig(t *testing.T) {
+	c := defaultConfig
+	assert.NoError(t, c.Validate())
+
+	c.InventoryPageSize = 0
+	assert.Error(t, c.Validate())
+
+	c = defaultConfig
+	c.InventoryPeriod = -time.Minute
+	assert.Error(t, c.Validate())
+}
+
+func mustGet(t *testing.T, m mapstr.M, key string) interface{} {
+	t.Helper()
+	v, err := m.GetValue(key)
+	if err != nil {
+		t.Fatalf("failed to get %v: %v", key, err)
+	}
+	return v
+}
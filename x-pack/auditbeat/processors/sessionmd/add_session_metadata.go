@@ -10,9 +10,11 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"slices"
 	"strconv"
 
 	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/atomic"
 	"github.com/elastic/beats/v7/libbeat/processors"
 	"github.com/elastic/beats/v7/x-pack/auditbeat/processors/sessionmd/processdb"
 	"github.com/elastic/beats/v7/x-pack/auditbeat/processors/sessionmd/procfs"
@@ -23,6 +25,7 @@ import (
 	cfg "github.com/elastic/elastic-agent-libs/config"
 	"github.com/elastic/elastic-agent-libs/logp"
 	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/monitoring"
 )
 
 const (
@@ -30,8 +33,22 @@ const (
 	logName           = "processor." + processorName
 	procfsType        = "procfs"
 	kernelTracingType = "kernel_tracing"
+	cgroupType        = "cgroup"
 )
 
+// sourceFields are the fields holding the source of an event, matched
+// against the sources allowlist.
+var sourceFields = []string{"event.module", "event.dataset", "input.type"}
+
+// localSources are the sources enriched when none are configured. Their
+// events are about the processes of the host the beat runs on, the process
+// IDs of events from other sources, like logs forwarded from other hosts, can
+// refer to unrelated local processes.
+var localSources = []string{"auditd", "system", "journald"}
+
+// instanceID is used to assign each instance a unique monitoring namespace.
+var instanceID = atomic.MakeUint32(0)
+
 // InitializeModule initializes this module.
 func InitializeModule() {
 	processors.RegisterPlugin(processorName, New)
@@ -46,6 +63,14 @@ type addSessionMetadata struct {
 	provider     provider.Provider
 	backend      string
 	providerType string
+	procRoot     string
+	metrics      metrics
+}
+
+type metrics struct {
+	TreeRepairs        *monitoring.Int // Processes whose entry leader was found by a repair.
+	TreeRepairFailures *monitoring.Int // Repairs that did not find the entry leader.
+	TreeRepairReads    *monitoring.Int // Ancestors read from procfs by repairs.
 }
 
 func New(cfg *cfg.C) (beat.Processor, error) {
@@ -55,9 +80,19 @@ func New(cfg *cfg.C) (beat.Processor, error) {
 	}
 
 	logger := logp.NewLogger(logName)
+	// Each processor instance has a unique monitoring namespace.
+	reg := monitoring.Default.NewRegistry(logName+"."+strconv.Itoa(int(instanceID.Inc())), monitoring.DoNotReport)
 
 	ctx, cancel := context.WithCancel(context.Background())
-	reader := procfs.NewProcfsReader(*logger)
+	var reader procfs.Reader = procfs.NewProcfsReader(*logger)
+	if c.Backend == cgroupType {
+		if !procfs.IsCgroupV2(c.CgroupPath) {
+			// Keep running, events are still enriched with the information
+			// available in them.
+			logger.Warnf("backend=cgroup: no cgroup v2 hierarchy mounted at %s, process information will be incomplete", c.CgroupPath)
+		}
+		reader = procfs.NewCgroupReader(*logger, c.CgroupPath, "/proc", c.CgroupFreeze)
+	}
 	db, err := processdb.NewDB(reader, *logger)
 	if err != nil {
 		cancel()
@@ -94,6 +129,15 @@ func New(cfg *cfg.C) (beat.Processor, error) {
 			return nil, fmt.Errorf("failed to create procfs provider: %w", err)
 		}
 		pType = procfsType
+	case "cgroup":
+		backfilledPIDs := db.ScrapeProcfs()
+		logger.Infof("backfilled %d processes from cgroup hierarchy", len(backfilledPIDs))
+		p, err = procfsprovider.NewProvider(ctx, logger, db, reader, c.PIDField)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to create cgroup provider: %w", err)
+		}
+		pType = cgroupType
 	case "kernel_tracing":
 		p, err = kerneltracingprovider.NewProvider(ctx, logger)
 		if err != nil {
@@ -114,6 +158,12 @@ func New(cfg *cfg.C) (beat.Processor, error) {
 		provider:     p,
 		backend:      c.Backend,
 		providerType: pType,
+		procRoot:     "/proc",
+		metrics: metrics{
+			TreeRepairs:        monitoring.NewInt(reg, "tree_repairs"),
+			TreeRepairFailures: monitoring.NewInt(reg, "tree_repair_failures"),
+			TreeRepairReads:    monitoring.NewInt(reg, "tree_repair_procfs_reads"),
+		},
 	}, nil
 }
 
@@ -124,6 +174,10 @@ func (p *addSessionMetadata) Run(ev *beat.Event) (*beat.Event, error) {
 		return ev, nil //nolint:nilerr // Running on events without PID is expected
 	}
 
+	if !p.fromAllowedSource(ev) {
+		return ev, nil
+	}
+
 	// Do not enrich failed syscalls, as there was no actual process change related to it
 	v, err := ev.GetValue("auditd.result")
 	if err == nil && v == "fail" {
@@ -147,6 +201,25 @@ func (p *addSessionMetadata) Run(ev *beat.Event) (*beat.Event, error) {
 	return result, nil
 }
 
+// fromAllowedSource returns whether the event comes from one of the
+// configured sources, or from a local source when no sources are configured.
+func (p *addSessionMetadata) fromAllowedSource(ev *beat.Event) bool {
+	sources := p.config.Sources
+	if len(sources) == 0 {
+		sources = localSources
+	}
+	for _, field := range sourceFields {
+		v, err := ev.GetValue(field)
+		if err != nil {
+			continue
+		}
+		if source, ok := v.(string); ok && slices.Contains(sources, source) {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *addSessionMetadata) Close() error {
 	p.db.Close()
 	p.cancel()
@@ -185,9 +258,18 @@ func (p *addSessionMetadata) enrich(ev *beat.Event) (*beat.Event, error) {
 			p.logger.Debugw("PID not found in provider", "pid", pid, "error", err)
 			return nil, e
 		}
+		if fullProcess.EntryLeader.PID == 0 && p.config.RepairProcessTree {
+			fullProcess = p.repairProcessTree(pid, fullProcess)
+		}
 	}
 	processMap := fullProcess.ToMap()
 
+	// The state is only captured for interactive sessions, where it is
+	// relevant for forensics.
+	if fullProcess.Interactive != nil && *fullProcess.Interactive {
+		p.captureProcessState(pid, processMap)
+	}
+
 	if b, err := ev.Fields.HasKey("process"); !b || err != nil {
 		return nil, fmt.Errorf("no process field in event")
 	}
@@ -205,6 +287,31 @@ func (p *addSessionMetadata) enrich(ev *beat.Event) (*beat.Event, error) {
 	return result, nil
 }
 
+// repairProcessTree reads the missing ancestors of a process whose entry
+// leader is unknown and returns the process with its repaired tree.
+func (p *addSessionMetadata) repairProcessTree(pid uint32, process types.Process) types.Process {
+	result := p.db.RepairProcessTree(pid)
+	if !result.Attempted {
+		return process
+	}
+	p.metrics.TreeRepairReads.Add(int64(result.Added))
+	if !result.Repaired {
+		p.metrics.TreeRepairFailures.Inc()
+		if result.Added == 0 {
+			return process
+		}
+	} else {
+		p.metrics.TreeRepairs.Inc()
+	}
+
+	repaired, err := p.db.GetProcess(pid)
+	if err != nil {
+		// The process exited and was removed in the meantime.
+		return process
+	}
+	return repaired
+}
+
 // pidToUInt32 converts PID value to uint32
 func pidToUInt32(value interface{}) (pid uint32, err error) {
 	switch v := value.(type) {
@@ -393,3 +393,67 @@ func TestFilter(t *testing.T) {
 		})
 	}
 }
+
+func TestFromAllowedSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources []string
+		fields  mapstr.M
+		allowed bool
+	}{
+		{
+			name:    "no sources",
+			fields:  mapstr.M{"input": mapstr.M{"type": "journald"}},
+			allowed: true,
+		},
+		{
+			name:    "no sources auditd",
+			fields:  mapstr.M{"event": mapstr.M{"module": "auditd"}},
+			allowed: true,
+		},
+		{
+			name:    "no sources not local",
+			fields:  mapstr.M{"input": mapstr.M{"type": "filestream"}},
+			allowed: false,
+		},
+		{
+			name:    "no sources no source",
+			fields:  mapstr.M{"process": mapstr.M{"pid": 100}},
+			allowed: false,
+		},
+		{
+			name:    "event.module",
+			sources: []string{"auditd"},
+			fields:  mapstr.M{"event": mapstr.M{"module": "auditd"}},
+			allowed: true,
+		},
+		{
+			name:    "input.type",
+			sources: []string{"auditd", "journald"},
+			fields:  mapstr.M{"input": mapstr.M{"type": "journald"}},
+			allowed: true,
+		},
+		{
+			name:    "not allowed",
+			sources: []string{"auditd"},
+			fields:  mapstr.M{"input": mapstr.M{"type": "filestream"}},
+			allowed: false,
+		},
+		{
+			name:    "no source",
+			sources: []string{"auditd"},
+			fields:  mapstr.M{"process": mapstr.M{"pid": 100}},
+			allowed: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := addSessionMetadata{
+				logger: logger,
+				config: config{Sources: tt.sources},
+			}
+			require.Equal(t, tt.allowed, s.fromAllowedSource(&beat.Event{Fields: tt.fields}))
+		})
+	}
+}
@@ -8,13 +8,34 @@ package sessionmd
 
 // Config for add_session_metadata processor.
 type config struct {
-	Backend  string `config:"backend"`
-	PIDField string `config:"pid_field"`
+	Backend      string `config:"backend"`
+	PIDField     string `config:"pid_field"`
+	CgroupPath   string `config:"cgroup_path"`   // Mount point of the cgroup v2 hierarchy, used by the cgroup backend.
+	CgroupFreeze bool   `config:"cgroup_freeze"` // Freeze cgroups while reading their processes, used by the cgroup backend.
+
+	// Sources of the events to enrich, matched against the event.module,
+	// event.dataset and input.type fields. Only the events of the local
+	// sources, auditd, system and journald, are enriched when empty.
+	Sources []string `config:"sources"`
+
+	// Capture the working directory and interesting open files of interactive
+	// processes from procfs when the event is enriched.
+	IncludeWorkingDirectory bool `config:"include_working_directory"`
+	IncludeOpenFiles        bool `config:"include_open_files"`
+	MaxOpenFiles            int  `config:"max_open_files" validate:"min=1"`
+
+	// Read the missing ancestors of a process from procfs when its entry
+	// leader is unknown, used by the procfs and cgroup backends.
+	RepairProcessTree bool `config:"repair_process_tree"`
 }
 
 func defaultConfig() config {
 	return config{
-		Backend:  "auto",
-		PIDField: "process.pid",
+		Backend:      "auto",
+		PIDField:     "process.pid",
+		CgroupPath:   "/sys/fs/cgroup",
+		MaxOpenFiles: 10,
+
+		RepairProcessTree: true,
 	}
 }
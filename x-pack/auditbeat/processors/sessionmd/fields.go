@@ -19,5 +19,5 @@ func init() {
 // AssetFieldsYml returns asset data.
 // This is the base64 encoded zlib format compressed contents of fields.yml.
 func AssetFieldsYml() string {
-	return "eJztXW1z2zYS/t5fgfHdjOOrRMuKncTu5IMvL3ee6YuvTnrTNj0ZIiEJNUmwBGhFN/fjbxcgQVKkXEW2E73gepPYErnYfXYXeIBdMn8hSSp8JiUZcRYG8FcqIvLm1RW5ZankIiYvvKO+1+sG7Nb76qsuuWGzM8J8+RUhiquQneHF8EvApJ/yRMEt+iPyVsvz4Csj+Qx+6pKYRnBLPiZ8YqVcVj4apyJLzkhf/1ITvP9uwiQrdPVFrCiPCY9HIo0oXkLoUGSK0GII0Jno/9VvpDGZsDAhM5GBlDRlIVWMREyl3Jc1eVOuJqU4woNDNCEXqsGiJBRjuFdKOmaeHolcF8MnPLgmYqRYTKSiM5RN1KQYinAlWTgiNA5yiRytSjgLiBL6wnEohjQ0ihNQy6oLynn7BsFZAgBq0HLERjQL1UDfc0ZUmhl9Sz+UnmCxSmeDkNGApR5NxzLXI2S3LISvP4LmASu0MyNBCExFajUexyJlA8D9Fr476vWP8y/qjjtPUzoDJCySMFgWwfCyg8ikisdjAzZaTYdShBm4JKH4ichFEv0l+8j8TNFhyKx3CfkOpA/RwaFiqYEPRlLMV0SyWHLFb1nVsTl2BKTRKMEI3P917zCT6eGQx4dSTvY6ZK8b4p+ZZCn+fdTz8L+jZ3u/7Vsba1CPaCjZ3fgOfJHFagmUQxGPW5H8lsVjAAWgRDCKQEPZgCmAXAHl3YTnia1DHvABW0ZZqAPpj4ylM0Qdfk5Yisjgb8PMv2GQQTENZxJuhxyYiKkVGdF4VvqOTAFs1OGWBwZ07ctK/pHvIDoqN0TGTzS2EnkccN8kG9gkM5lwn4sMrPHBaVzNmq46Xgl+X0SgfTAIecyWcMCUh4FPbZxHMBAfVHOoHEaBgDJE9d0QZf5kIOJwNqh8W/fk2ywMSa4VQa3Ao1QZCBHN0r0dQMkPswAdVM0PO2ieJ3P50cGZhVAYxTqgEh1XImrxzD0yqJo+pBtisKXEZs1KPoNfIAIGPHiseel9zCERYF7HkUCnVOdGNbOq6QQXoqkImI1YhUkG/5cJ81FCQIYzLSCgihIpstQHRwxhVZIIeI6WtFJBxBTdDhMD5KnJ0QCWJ502rEyl7pjFLKUYGe/fX7zukKuZjECFfOUk/4APZacUm8KtEyr1TKFHzrSp4awwVic/hF8iYh0AcF1z3STklYglrCG+nqFpviCBlKyBHIBAdTyDVkmK6eszDKMIYmdMK9F6efGapAzsJBSnEIxPiRcWioGYHE3frhfgRvALjzCo/VTAJzojAcpybhIxVwJjdyIkhHojQP2+f3xyEpyOTk+fPj8JVo1I+AXWb+oZ33o8qQcnrNCsFpj2glroXVwSGgSpts3M5UYeeXJxeXuM/oO/nx1499USdXis7MGM0ENpITZ1zEcSTMMcyVUiP9EwYzKfyRAW8NYT5o2t/+RMKhYFB8AI5CTAP6MOucmGLGSqA5NsyBKR6p9wqaJhBxkgTISsDNbvYcI6q657egULURlFdFia8M51K+BabT0vJ9qHw/fhl5nzOS5VndxqXGouWaqz+UrwaEbqPdrUvXjm1gOjt3VS6aA6TIAy49K1WjoZUyrM/6GN+R5EF9OAGWwlPXkMuaF50zKKDgXkjmVi9cz+94SBLrWV0GxO4hgYgaEHFLdedkAiYUsVVhaOYmEqr4GlHIUAiWApTtN6/1QZgWHWhsyqIlIgkBejGvv3JxSFgWqwJcUFpvA67gZTEYaaI5mBtP8RWVhepAL2pPkQ/ATDW6Fz632nxWQJQYb6Vqz15ueccxKLuFvFo5BhqDfy+TbQ8kkSFeMlKZDIM2C/VHDCQAA2sVCwosNNIoH95DxSsKJSSxArYJT05d3PmBRvX5MeeaLhOMBVZppyvbTnM0MVxvKOvr4DYDvwyLmVOKT+DUYrRlYVMqm4ZrZtwBE+avgCiY4VWguxFo2ay7rd4X5qujxsQj/CDqFIIRQyx9yRCmFshaHBfpzSSF+HPoXw4SFNm1CtOpcn4KFYOTru6Pia0fE8MJP5kGyQ8Mp5itnCQjKolLcfstilK2jQouP+cf8+ms4xT5dRLqPWMqPm4nRjE0yfpi2RXEHpv5qmmBPolRotzI/oGrrv93tHz7q9k27/6bvei7PeydnTY+/FydNfVttgtlt0+8C+2P+JpyqjYaXIMTcnlF/oI3rkbgSU0CrLhPrMM1tubjgq0DeshqS8TCIT0VYoHkmWnD/fqWFM62mEK5SU35kPWEXfDqu5cW13UO5rucTjaX1zywHyfWNs84PKRdEXjKK1nEyTFDbUIpOuDvjwdcAatq4G+DlrgBZ6d1x756SUMhoOtujMtmLP+h/camVxgmlg31gaHgb3XGU95HwoXXWPusA2jrp9ENc/Pj3qP3/xvNt/dgq/nR73joGInBydnjx7/uJZ96jX693T4qZ3VrX54bPxaiJgKiuO1UIx5vGd0FGPcdhNwh8rgYKH1QMqB9UGqdXP8PXUn1+guZA087PdhXLcEJuhq8e3xbF5SbTMZ0okRpeWTfRbgCdHoYNybQeUTooF9pGX+uz2uoNkLQIt9ThWpp31bkFf7JyCGJZ6kciVzFUHh3BYVorLTRqS10xRHiIDhGusTKvX365JyG+wVQtbtKbF2YQtGejbFpgB976r6GlKDEkScixgABBDAVO3vbW+fbvW5972y6r7r9uX7ZyF5kvBkMVsBHCB0W/+9a0WJnSlJs1CrLxChIIWksdAbLna3y/pMpLqBPgzh+UDpeHJCB7AsD+AtmNpZsjUlDGYgqei6JTTJB2EjbHWIHy9gHtWIvPGNUvMSdY1uLUdt8olTxrBVJIBWVvzzMpgJByQH378sxHb+0eurfjmyMWIbWXrg9IlF5jYVPflSKbhluWZFlZRsMsPY+psueg/K6cjTIJ50xbOCqbgcb180bs92DoLI7TM+2qkVk25fsAijKS3LNgm4lE1aP2Zh9F2p6hHxWTHPWbbcFAqs6SoWuScX27JXNJi2PrPKUrNllBQDH9nfmvkXzQa+ts6E0z/BzaI4EJX69uwyWb6LuTixouVDfSwEWWQ6xDR30W6hMmLDnQwd7QMEmfRECtNRXAZZhKkHKtDVErhc10hs0dkuQYV4tjskdmXuXRc0CMe23GK5hRCw7EABjOJuK9rX8ANh0AkAyvWnKMFmW/yO8ITnRGNeMhpahvyKjkBqZ/5E9w57AFYV709PfZeouRhb6/UFrcNWlZg2HqHQLhTpFdshOUww3m/5XH2EQI6jYG0FTTUHN+tfCx0tz8Ro3v4c087tAK03X5pXlbUT41XG3VV7asPFqMPuYxvcqIPpF3ZnlELSvwhgzn1VOEwQPxN63fEUqyHJhRJrqqKpEU/UqkIZJIyDU+6wDnSBdZcR71d0OkHiQMfgK8NVPKbqlQ1b3Z+LIh10ymd2YmylBrwETgaowafwKGRMA1Mkbc379mjlTy7U8TGUZo6Hq7G9eVqXBBgNwDjIOAprPIiXYYRfLG4RNflChOr8Hx5ZR6aw4mI2CENYRZcHqHbwS1NH7+6BgPxVMS4ThIYkGPJgAyxzAJsyCOvaKIy24FLiYxpIieiRC43vSqGGhamKT6OoEsRegm2dz1I8e3y/N0/X+pqRiiAjGBN46wobmD57f3Vmx9fZsMsVtknFOBqBzGuwPngBc4Gvq7I+TmLnDX43YOOG/GgY/vR+GPNS64P1PWBflpEunaJu+DZ7IJFiylreaxY09M93eaebnNPty2dLu7ptmWRWs9+3JqKW9Cdt8ie9V94dqI7b4HFu32cfFf/zj05iOvOc915rjvPdeetXXfeHCqb35230KD1Zx670Z23yGTHPUpUNqs7r6b7NraDzRvo2sE2ux3sbn+6drDNbQereXanVlK3htbxcO1gn7UdrIa9awdrQcgVtFxByxW07nx5g3lZzWafP9SMWMszh6qGriPJdSStTUdSW2CuZ7W2TdMNO7RpM8Fx5s/KmXMXOGLoiKEjhkskyha0xTQtWWeKuBOtMA1bd/sQrXjp6RaUgltMWeds243yb9NYl2+Ah5ogE/B8mtAhD2FxZ9JjoxFblhauEhAFs9Y5xnQfU3V8s0HON9l5rQlLgeapOvw74poHlSxgwvwbaZM4t6mxd/l179X55eDvl2/x+UP88ernq8H56+8uvi+fQUyoAvYBSv7nCV7x63n3l8FvX//vQ/D1wV8fAFqtPIzwWDNbAS0lIY8MVZMZDIow2384q3BvHfXGzsPor59ko1JmUfP5ls+O6BbW+EvTXHV/s6v7izzp6vqbW9fPfboj5MjRIuL+4YIvcSK5BScs6360shNnKu4wxei1Dacoa398shvnJu7ApACi9oSMe73Tw7/eqQVh94Knz/mCpzkHuFc8bcQrntw/9ulaqtatpWo+Jt1rnu4GaMOpepsx68nZ65q6FijXAuVaoD4hYdzrnpbHKj9KdnzM8bF142N5aK5nk3u7rm6X47JqM7Jq/tPNTbINe7RkKZtcZfezVnYX+GQbAstF0heNpE2YVregr2GxRZtwwLMTXQ8LbXYF2yqZoe7FqpX6l3uxqnuxqnux6va9WLUx621BJ9hikzaBg+xIn9hCox0L2ZojlW18AKtponsQa7MfxPozj7oHsjb3gaw53+7YqurW03lE3CHslzyEdS9cbcMoS4pScH5UKjd369Viy1ruubaRmToquvFU1HHPLeKe2pkizKJ4merBwieS0IW5FfjmG5tFuWh8ukg/3OLpM2Ab9VMeqEmFihSfS/5fZjIMRMVj1iFSmN6jWxpmOsZDmPRkHgEfeZRF+VfaE1biGKCLycUPpibgEe6BClPdb2Q+wWkM/PeS7O/jGjuAWTLJ1P5+g1G8WJrfIqSpmD4KniiX5GypQHEO0wnj44naDFD7S086274pcrsg4rY9j77t+T+7GV/0"
+	return "eJzsXXuTEzmS/59PkdF3EYZdd9FtuhnwBn9ww3DXEfPo24a92J2Zs+WqtK1tlVQjqWx8cR9+I1Wql10GYwztRwUR0G1XpfIp/ZSZEv8GiVYhGgNjjiIyMNYqhh++v4MZasOVhBfBZS+4OI9wFjx6dA73uOgDhuYRgOVWYJ8efgQQoQk1TyxX0n0Ebx294BF4yv1HAOcgWYz9fMxHAAWV28pHE63SpA8990uNcOfdFA3mvIZKWsYlcDlWOmY0NrCRSi2wfIjgkSMCUH+RSZiiSGChUgiV1iiYRYjRah6aGr05t9OSHPDoKYngiTplMRBqAjEawyYYuJFgmA+f8GgIamxRgrFsQbTBTvOhgFuDYgxMRp4iJ6kSjhFY5R6cCDViIpMYxkoX7HIlg457yy4S7GdK8xobs1TYgXunD1an6D4v7VBaAqXVi4FAFqEOmJ4Yz4fAGYo+4AeLMsKcu2yke1zMlS44nkilccBGaoZ9uLzoXfkv6oZ7rTVbgBoXmmR6ksYoremSZrTlcpIpm6RmI6NEahESRp8oTxKcSvADhqllI4GFdQF+YgsYkYGFRZ2pL9HKYmjBoDTc8hlWDet1B4AfWJyQH3d+PXuaGv10xOVTY6ZnXTg7F/R3alDTv5cXAf25fH72e/7ykqrHTBj8uH4HoUql3UDLQslJoyZ/RDmxU1IlKSN3NLIdMFJyRSnvptwHtnP5EUJqcJwK50h/pKgXpHWlIUFNIUS/jdLwHi0wycTCcANKwlTNC5Ixk4vSdjBH7XiY8ShTurNlJf7gJ6Wx8kKc2YnJgiKXEQ+z4FVjMKlJeMhVaoCFls+4Xaya6mor9YcqjpmMBoJL3MAAcy6ikBV+HqfC8kE1hsphLH7ITZq/HTMbTgdKisWg8m3dkm9TIcBzBcQV2CmzWThgVDVvF7gMRRqRgarxUQzq42QpPro0swATojRAxTvuVNxgmS+IoGr4wLkgZ9NQRM1WNkNpuV0MeLSBwbaal95L/keKwCMaacxRu9ioRlY1nBA4eSAprPBYS0HGDZgEQz6mqXu0cHaImGVgVKpD7MIotWBI4V5b+UQLFMhzMnuoUhFRbKQGI5iiCxssQ+l8ghI1sxjB+/c3b7pwtzCxkvnKCf/5/uaN6ZZkNTCYMuNmCjdy6kQVi1xYF/yhihMlyTPoOdYg9fdKGqvT0M3QzC9IYgHpiua4Aeb8WUlINIVviORGMbd8wireenvzBjSmBoEZmCP5p6EHc8aA5doMc4ZAzVCD5TF2gYVaGZNFZCJKsrGS3Cqa/afKWLPqoGEvvLq+jl6OX7589t11tK1H6sUgRsuCzLYBT+rOGSqd85Q5Jk+aXO/mFlgUaTKdn8szevD45nZ2Rfa7uZ09fxJ8KZfEQ53B3UUPRYQbyhEpQif7yKBxCNKzBH9jIkXjZzLsA5fcPsYgX+UAzMJYjKMnXTBmGtHfcRfu0xEKtF2wKDBR2v1ESxUTXQiVNEpg6aw/K4v96rrnVjBBzFhaynL39rzl6upsp+Riot2dfne/zLxewlLVdaWyVgTLwVKdzbdSj0OkwVebutfP3G5gsjb9kjnV00QwS0vXduHkKAYV5L9rYX5mMebTQDbYVnxyadFNvLNNGB0pJZDJJn46/zNFO8XaSphtTqTEkNYgq4BJqAwIZopCVBaOfGEqn+F2QUS4HKOmadrtnyojIKFVgQUrSpsAbsYFRXo0nDIihhoinPEQC6vTblArIWiZstlA9LwhzTIDxkZcOjxkbIRaF0THqiZlt0FkwyMHiSrSVuTM5pzXIJU8rzxR0MigN+H5JqX5SZIY4yUoMIQzFqByTBgpNCCVhSmbIagE5YqmQCMrAGJFGQXNd+/+TkHx9g1cwGOnjie0ysw1d0u7VctvVt7ouTdQ6ycBvC4ojlh4T95K8VxVmbHcIdsmxQEfr9iCgE5BtOZiDRytLuvFDvdzw2W3Af0Vdgh5CBGRiscRcicoZCBkQmS6n2gWOxnJpobHXDC9qqpt5/KEaZS2heMtHN8zOO4dM+HRJ0B4JZ9COIDZPhiry/mx5oe5EXkULDN91bvqfQmnS8izjag2ovYyopb89GADzGXT6qw3BldU2q/GKW1xaa2thkyeolvhvdO7uHx+fnF93nv27uJF/+K6/+wqeHH97B+dHUo027EtOn/j2qZMFNLRrOcfzJJe5RcuRU/YDRIeOZZNwkIMsi03zzCqRCLDNC+DKEvZFESZKEZDk+/UKGnjphFuKdL8m37AqvaLYR02LohWH8EP3FjjuQ06O/exw3eq1ose0Iv2cjJNNM6o8NLWAXdfB6zptq0BfssaYKH60l82UP2D7fkfLF2rkYnBEeVsK/Lsf+LWMUsTTPDJpWE3evcsuyGXXenu/PL8+rx3ed676F31rl5e9r578d157/nL3lXv5dXF1Xnv2fXly+vn3714fn55cXHxhRKvWmdbmXcfjXdTpW2RVhNqwuVHVccC5NJY5HIrpVCyesDMwMd7XSufncN3mNw/4BA1FZaru1BOFd/MHsUUU0mbe6LgqrOEWFWS8ZI/XFlm3iqda6FLKd6iA8rNKGvkg1cudzvsEliLkUk3TkEzfwpnKC11Tv2RoiHZ8ty+Z12NqZGqkMCNGMAbtIwL2kdEWGb7C77+NATB7xGGrkVrnucmipKBe22NGH8awrsKn+55liSCUwFDaRgpOy1frW/fhi7vXXxZNf+wedn2KNQvBSOUOOaWvPCH//7REVOuUqNTQcXYCZdowHAZInDb6ZRwmbZmiTKGjwTZ0mVGKAGDf6RMUGlmhHaOKMHOVd4p57Z6hssJVWVU6Fb83OkBMJiUYhaZrCG8WqO3yiOPV5ypoOqN6z/2K0NG4Qn88tdPjVgLqPKRgvzqyLk7NZWtn5QmuaHAZq4vx6BTtylzWlRFoS4/8qn+GvGXvD+foSALgmXR9GLde9nzmxe9G6kOu2s9tCBc89SqKMMdFmEMm2F0TMCjKtD+I4+M25OCHhWRW+xRYo8DzmmZNMmrFh7zmyOZSxoE2/85xdrFBgyq0T8xbPT8m5WG/qbOhKz/gxpEaKGr9W14ouD7Lsz6xoutBQyoEWXgeYjZP5XeQOR1CR1KjzoaINN4hLp0rgyZRJpT+yMzRoXctYAWKTLPgaeaZVqXe2Q6xlOnBT3mshgnb04BJiZKczuNOXUULAgbjrgsuAdSYKJVlLp0KUJMGZ0xi7ngTBcNeZWYMGDScEo7hzNrF3cXZw6pniXWPL04y5WebRscrShD611IBDKCVzimcliGeX/kMv0A96gligKGZum75Ynlajf2JB19gT3PyApVRRfbL4fL8vppZtWVuqqz1W+eLsBvnsZfPNBPmLZFz2ihFPlb2ru4fGlpbQBuu07fMWoUC0gYgVxbJcnyfqSSkQBuXMrcFzhpUmM5j2674MIPDM5QM+Fdy/ylStUui+3TglQ3nbNFMVGWVCM+HiNVucitgcWOqynGwdmyZS+3suxJAZsW0tQhTVvjerga11zpey4ng4hrDK3SmyCCB/NLMp1nGAqGc8/0mlpxzqdTFeNTJniIm2toNpgxbXanizXVNZQzrpWkdA3MmOZU9YARlVnkxATwPUtsWnTgMjCSJWaqSs150atkmHUfuV4IGsGVItwSXLy1k0N4t6/f/dcrV80QKmSCahr9vLhB5bf3dz/89VU6SqVNP6MAV0vEtAXOnRc4V/TbFjm/ZZGzpv72oONBHHSs2axIVG9gsK3mpfVZi9yD2z7QU+8DrXtkEV27c8nDbpeoqeewCxYNouxlWrHGZyV9twGjvvDdxE97uq093XaUp9tq4bLbgP4KsPAhT7fVNLXjNNGO+nFrLB5Bd946efZ/4TmJ7rw1Eq9aZ1uZDzGd/LH+nbpWPhuDtN15bXde253XduftXXfeklYOvztvrUD7jzxOoztvncgt9iixx2F159V4P8Z2sGUB23aww24H+7g923aww20Hq1n2pFbSdg2tr6FtO9g3bQer6b5tB2toB6sAkA3U4XNFTQy3Ba22oHWUBS1/Wc1h5x9qQqyux3uQc6hyuJKc+0rctjfTtTfTffJmuibH3M9qbROnB5a0aRKhxczfFDN7E1QW4LryW2DYAsMWGJbA8AjaYlYl2WeIeBKtMCuyrlrklJJoXh3HUApuEGWfo+00yr+rwp54vGXxZqeEBIKQJWzEBbccTYDjMW4KC7dxiBxZuxij/pQxVMcnCYtNtq810QHE7L8PpH9j7uqonjZAOMXw3hRB7GVa1lHn17PvX98O/uP2LZ3wpB/v/n43eP3mp5ufyzOICbMWtezD/z6mJ359ff6Pwe9//v/foj8/+fcdqNYxby1GX1m1DASng0JyAiZNUNP/TJWrpzBvXesrO49Mje4kGzMmjfHhNXqENf5StLa6f9jV/XWWbOv6h1vX9zY9EXDUwqIcFrUZyW+akTyCDMu+p1ZOIqfSJlOyZMoxZFH2Pn1yGnmTNmHiEyZ+Y9de7/TVrndq0HB7wdO3vOBpyQDtFU8HccXTktXalqq2perBW6qWfbKIsN055WFf87SkoAOH6k3C7Cdmr3NaSdxvwGrbAtW2QJ1aC9RSwOw2qL8CRHzI656WdOVTyS0ea/HYvuEx75o7LnLsqMm9mdd2l9PucvZ8l9PsqQccZAd2tGQjmdrK7jet7K6xyTE4VutJD+pJhzCtHkFfw3qJDiHBcxJdD2tlXrXQtlIfQcF26QrFul4+O9/XXqzaXqzaXqzaXqy6dxer1sfz552OCoLsf2NYE78nBkLatrHmtrHD3vke4wGsVRHbg1iHfRDrUxZtD2Qd7oGsJdue2KrarqfL62mbhH3IJGx74WrDhasmTfJSsE+VmpUp6ks084lpardbrwZZVuefPdhzHSMybaHowUPRFnseEfZ0xlQijaX5AhN2KAy9FGpciSJPmi64cYdbApcDLrx+ziM7rUCR/HPD/4+SpUwSKTnBLhgC/tzAjInU+bjA0BrvAR94nMb+K9IaKyhO+Awl3PyS1QQC4AEGMHf9Rtkn1LijJLyCToeqOgOV2iS1nc4KonixMb4llWo1/yr6JLrg0VKuxSWdTpFPpvYwlNq72lSpx74pOvFdEO2C2m3PN9320B2qAzpAkLOfuYIDxI3qvKFzDWhca2Rx+sDAY6PCe7TG5W/yWcg8IeeoY8XlOm2X7uqyKbUwzqeY6cqXbg2g1DycYhTAL5SAYlGUPzbMTkHioBSgrEC5N+nUYxR0PqUIgOb4GOde2OB1Szp5Wz9/4afsYIkgUSnebwrjjwTy0oDvFkmxmShs0IVhZoMhtR0McxsMl/mg86E74uOW2WkDH7RO5O5A5sx+Bi5VhPAYg0mQs9r/9bL37Or69+GTYI1XDqxOZcjs5g0Md2gJJVGLQOlRght3G1/BpYE5tbXnxOmNYcw+VN0p+Jjr/GsAjKBmog=="
 }
@@ -0,0 +1,118 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+//go:build linux
+
+package sessionmd
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+const (
+	openFileSocket   = "socket"
+	openFileTerminal = "terminal"
+)
+
+// openFile is an open file of a process that is of interest for forensics.
+type openFile struct {
+	fd   int
+	typ  string
+	path string
+}
+
+// openFileType returns the type of the file that a /proc/<pid>/fd link points
+// to, or an empty string if the file is not of interest.
+func openFileType(target string) string {
+	switch {
+	case strings.HasPrefix(target, "socket:["):
+		return openFileSocket
+	case strings.HasPrefix(target, "/dev/pts/"),
+		strings.HasPrefix(target, "/dev/tty"),
+		target == "/dev/console":
+		return openFileTerminal
+	}
+	return ""
+}
+
+// captureOpenFiles returns the sockets and terminals opened by pid, ordered by
+// file descriptor and limited to max entries. truncated is set if more files
+// were found.
+func captureOpenFiles(procRoot string, pid uint32, max int) (files []openFile, truncated bool, err error) {
+	fdDir := filepath.Join(procRoot, strconv.FormatUint(uint64(pid), 10), "fd")
+	entries, err := os.ReadDir(fdDir)
+	if err != nil {
+		return nil, false, err
+	}
+
+	fds := make([]int, 0, len(entries))
+	for _, e := range entries {
+		fd, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		fds = append(fds, fd)
+	}
+	sort.Ints(fds)
+
+	for _, fd := range fds {
+		target, err := os.Readlink(filepath.Join(fdDir, strconv.Itoa(fd)))
+		if err != nil {
+			// The file might have been closed in the meantime.
+			continue
+		}
+		typ := openFileType(target)
+		if typ == "" {
+			continue
+		}
+		if len(files) == max {
+			return files, true, nil
+		}
+		files = append(files, openFile{fd: fd, typ: typ, path: target})
+	}
+	return files, false, nil
+}
+
+// captureWorkingDirectory returns the current working directory of pid.
+func captureWorkingDirectory(procRoot string, pid uint32) (string, error) {
+	return os.Readlink(filepath.Join(procRoot, strconv.FormatUint(uint64(pid), 10), "cwd"))
+}
+
+// captureProcessState adds the working directory and open files of pid,
+// according to the configuration, to processMap. Errors are ignored as the
+// process might have exited already.
+func (p *addSessionMetadata) captureProcessState(pid uint32, processMap mapstr.M) {
+	if p.config.IncludeWorkingDirectory {
+		if cwd, err := captureWorkingDirectory(p.procRoot, pid); err == nil {
+			_, _ = processMap.Put("working_directory", cwd)
+		} else {
+			p.logger.Debugw("failed to capture working directory", "pid", pid, "error", err)
+		}
+	}
+
+	if p.config.IncludeOpenFiles {
+		files, truncated, err := captureOpenFiles(p.procRoot, pid, p.config.MaxOpenFiles)
+		if err != nil {
+			p.logger.Debugw("failed to capture open files", "pid", pid, "error", err)
+			return
+		}
+		if len(files) == 0 {
+			return
+		}
+		list := make([]mapstr.M, 0, len(files))
+		for _, f := range files {
+			list = append(list, mapstr.M{"fd": f.fd, "type": f.typ, "path": f.path})
+		}
+		_, _ = processMap.Put("open_files", list)
+		if truncated {
+			_, _ = processMap.Put("open_files_truncated", true)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+//go:build linux
+
+package sessionmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func newTestProcRoot(t *testing.T) string {
+	t.Helper()
+	procRoot := t.TempDir()
+	fdDir := filepath.Join(procRoot, "100", "fd")
+	require.NoError(t, os.MkdirAll(fdDir, 0o755))
+	for fd, target := range map[string]string{
+		"0":  "/dev/pts/3",
+		"1":  "/dev/pts/3",
+		"3":  "/var/log/app.log",
+		"4":  "socket:[12345]",
+		"5":  "pipe:[999]",
+		"10": "socket:[12346]",
+	} {
+		require.NoError(t, os.Symlink(target, filepath.Join(fdDir, fd)))
+	}
+	require.NoError(t, os.Symlink("/home/alice", filepath.Join(procRoot, "100", "cwd")))
+	return procRoot
+}
+
+func TestCaptureOpenFiles(t *testing.T) {
+	procRoot := newTestProcRoot(t)
+
+	files, truncated, err := captureOpenFiles(procRoot, 100, 10)
+	require.NoError(t, err)
+	assert.False(t, truncated)
+	assert.Equal(t, []openFile{
+		{fd: 0, typ: openFileTerminal, path: "/dev/pts/3"},
+		{fd: 1, typ: openFileTerminal, path: "/dev/pts/3"},
+		{fd: 4, typ: openFileSocket, path: "socket:[12345]"},
+		{fd: 10, typ: openFileSocket, path: "socket:[12346]"},
+	}, files)
+
+	files, truncated, err = captureOpenFiles(procRoot, 100, 2)
+	require.NoError(t, err)
+	assert.True(t, truncated)
+	assert.Len(t, files, 2)
+
+	_, _, err = captureOpenFiles(procRoot, 101, 10)
+	assert.Error(t, err)
+}
+
+func TestCaptureProcessState(t *testing.T) {
+	c := defaultConfig()
+	c.IncludeWorkingDirectory = true
+	c.IncludeOpenFiles = true
+	c.MaxOpenFiles = 3
+	p := addSessionMetadata{
+		config:   c,
+		logger:   logp.NewLogger("test"),
+		procRoot: newTestProcRoot(t),
+	}
+
+	m := mapstr.M{}
+	p.captureProcessState(100, m)
+	assert.Equal(t, mapstr.M{
+		"working_directory": "/home/alice",
+		"open_files": []mapstr.M{
+			{"fd": 0, "type": "terminal", "path": "/dev/pts/3"},
+			{"fd": 1, "type": "terminal", "path": "/dev/pts/3"},
+			{"fd": 4, "type": "socket", "path": "socket:[12345]"},
+		},
+		"open_files_truncated": true,
+	}, m)
+
+	// Exited processes are ignored.
+	m = mapstr.M{}
+	p.captureProcessState(101, m)
+	assert.Empty(t, m)
+}
@@ -189,6 +189,7 @@ type DB struct {
 	procfs                   procfs.Reader
 	stopChan                 chan struct{}
 	removalCandidates        rcHeap
+	repairAttempts           map[uint32]time.Time
 }
 
 func NewDB(reader procfs.Reader, logger logp.Logger) (*DB, error) {
@@ -204,6 +205,7 @@ func NewDB(reader procfs.Reader, logger logp.Logger) (*DB, error) {
 		procfs:                   reader,
 		stopChan:                 make(chan struct{}),
 		removalCandidates:        make(rcHeap, 0),
+		repairAttempts:           make(map[uint32]time.Time),
 	}
 	db.startReaper()
 	return &db, nil
@@ -706,15 +708,7 @@ func (db *DB) ScrapeProcfs() []uint32 {
 
 	pids := make([]uint32, 0)
 	for _, procInfo := range procs {
-		process := Process{
-			PIDs:     pidInfoFromProto(procInfo.PIDs),
-			Creds:    credInfoFromProto(procInfo.Creds),
-			CTTY:     ttyDevFromProto(procInfo.CTTY),
-			Argv:     procInfo.Argv,
-			Cwd:      procInfo.Cwd,
-			Env:      procInfo.Env,
-			Filename: procInfo.Filename,
-		}
+		process := processFromProcfs(procInfo)
 
 		db.insertProcess(process)
 		pids = append(pids, process.PIDs.Tgid)
@@ -723,6 +717,18 @@ func (db *DB) ScrapeProcfs() []uint32 {
 	return pids
 }
 
+func processFromProcfs(procInfo procfs.ProcessInfo) Process {
+	return Process{
+		PIDs:     pidInfoFromProto(procInfo.PIDs),
+		Creds:    credInfoFromProto(procInfo.Creds),
+		CTTY:     ttyDevFromProto(procInfo.CTTY),
+		Argv:     procInfo.Argv,
+		Cwd:      procInfo.Cwd,
+		Env:      procInfo.Env,
+		Filename: procInfo.Filename,
+	}
+}
+
 func stringStartsWithEntryInList(str string, list []string) bool {
 	for _, entry := range list {
 		if strings.HasPrefix(str, entry) {
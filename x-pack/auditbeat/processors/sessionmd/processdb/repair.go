@@ -0,0 +1,84 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+//go:build linux
+
+package processdb
+
+import (
+	"time"
+)
+
+const (
+	maxRepairDepth      = 32               // maximum number of ancestors read from procfs by a repair
+	repairRetryInterval = 30 * time.Second // minimum time between two repairs of the same process
+	maxRepairAttempts   = 4096             // maximum number of repair attempts remembered
+)
+
+// RepairResult is the outcome of RepairProcessTree.
+type RepairResult struct {
+	Attempted bool // False if the process is unknown, complete or was repaired recently.
+	Added     int  // Number of ancestors read from procfs.
+	Repaired  bool // True if the entry leader of the process was found.
+}
+
+// RepairProcessTree reconstructs the process tree of pid when its entry
+// leader is unknown, e.g. because some of its ancestors started before the
+// DB was populated or their events were lost. The missing ancestors are read
+// from procfs, walking up the parents until an ancestor with a known entry
+// leader, and the entry leaders are evaluated again from the oldest ancestor
+// down to pid. The walk is bounded to maxRepairDepth ancestors and a process
+// is repaired at most once per repairRetryInterval.
+func (db *DB) RepairProcessTree(pid uint32) RepairResult {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	process, ok := db.processes[pid]
+	if !ok {
+		return RepairResult{}
+	}
+	if _, ok := db.entryLeaderRelationships[pid]; ok {
+		return RepairResult{}
+	}
+	if last, ok := db.repairAttempts[pid]; ok && time.Since(last) < repairRetryInterval {
+		return RepairResult{}
+	}
+	if len(db.repairAttempts) >= maxRepairAttempts {
+		clear(db.repairAttempts)
+	}
+	db.repairAttempts[pid] = time.Now()
+
+	var (
+		ancestors []Process // Closest ancestor first.
+		added     int
+	)
+	for ppid := process.PIDs.Ppid; ppid != 0 && len(ancestors) < maxRepairDepth; {
+		if _, ok := db.entryLeaderRelationships[ppid]; ok {
+			break
+		}
+		ancestor, ok := db.processes[ppid]
+		if !ok {
+			procInfo, err := db.procfs.GetProcess(ppid)
+			if err != nil {
+				db.logger.Debugf("repair %d: failed to read ancestor %d from procfs: %v", pid, ppid, err)
+				break
+			}
+			ancestor = processFromProcfs(procInfo)
+			added++
+		}
+		ancestors = append(ancestors, ancestor)
+		ppid = ancestor.PIDs.Ppid
+	}
+
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		db.insertProcess(ancestors[i])
+	}
+	if entryLeaderPID := db.evaluateEntryLeader(process); entryLeaderPID != nil {
+		db.entryLeaderRelationships[pid] = *entryLeaderPID
+	}
+
+	_, repaired := db.entryLeaderRelationships[pid]
+	db.logger.Debugf("repair %d: read %d ancestors from procfs, entry leader found: %v", pid, added, repaired)
+	return RepairResult{Attempted: true, Added: added, Repaired: repaired}
+}
@@ -0,0 +1,78 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+//go:build linux
+
+package processdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/x-pack/auditbeat/processors/sessionmd/procfs"
+	"github.com/elastic/beats/v7/x-pack/auditbeat/processors/sessionmd/types"
+)
+
+// TestRepairProcessTree repairs the tree of vim when only vim is known:
+//
+//	systemd            (pid 1 sid 1)
+//	\___ sshd          (pid 100 sid 100)
+//	     \___ bash     (pid 1000 sid 1000)
+//	          \___ vim (pid 1001 sid 1000)
+func TestRepairProcessTree(t *testing.T) {
+	reader := procfs.NewMockReader()
+	populateProcfsWithInit(reader)
+	reader.AddEntry(100, procfs.ProcessInfo{
+		PIDs:     types.PIDInfo{Tgid: 100, Sid: 100, Pgid: 100, Ppid: 1},
+		Filename: sshdPath,
+	})
+	reader.AddEntry(1000, procfs.ProcessInfo{
+		PIDs:     types.PIDInfo{Tgid: 1000, Sid: 1000, Pgid: 1000, Ppid: 100},
+		Filename: bashPath,
+		CTTY:     types.TTYDev{Major: 136, Minor: 0},
+	})
+	db, err := NewDB(reader, *logger)
+	require.Nil(t, err)
+
+	// Only the last process is known, e.g. its ancestors started before
+	// the DB was populated.
+	db.InsertExec(types.ProcessExecEvent{
+		Filename: "/usr/bin/vim",
+		PIDs:     types.PIDInfo{Tgid: 1001, Sid: 1000, Pgid: 1001, Ppid: 1000},
+		CTTY:     types.TTYDev{Major: 136, Minor: 0},
+	})
+	process, err := db.GetProcess(1001)
+	require.Nil(t, err)
+	requireEntryLeaderUnset(t, process)
+
+	result := db.RepairProcessTree(1001)
+	require.Equal(t, RepairResult{Attempted: true, Added: 3, Repaired: true}, result)
+	requireParent(t, db, 1001, 1000)
+	requireEntryLeader(t, db, 1001, 1000, Sshd)
+	requireEntryLeader(t, db, 100, 100, Init)
+
+	// The tree is complete.
+	require.False(t, db.RepairProcessTree(1001).Attempted)
+}
+
+func TestRepairProcessTreeMissingAncestors(t *testing.T) {
+	reader := procfs.NewMockReader()
+	db, err := NewDB(reader, *logger)
+	require.Nil(t, err)
+
+	db.InsertExec(types.ProcessExecEvent{
+		Filename: grepPath,
+		PIDs:     types.PIDInfo{Tgid: 1001, Sid: 1000, Pgid: 1001, Ppid: 1000},
+	})
+
+	result := db.RepairProcessTree(1001)
+	require.Equal(t, RepairResult{Attempted: true}, result)
+
+	// Repairs of the same process are throttled.
+	require.False(t, db.RepairProcessTree(1001).Attempted)
+
+	// Unknown processes are not repaired.
+	require.False(t, db.RepairProcessTree(2000).Attempted)
+}
@@ -0,0 +1,359 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+//go:build linux
+
+package procfs
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/procfs"
+
+	"github.com/elastic/beats/v7/x-pack/auditbeat/processors/sessionmd/timeutils"
+	"github.com/elastic/beats/v7/x-pack/auditbeat/processors/sessionmd/types"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+const (
+	// freezeTimeout is how long to wait for a cgroup to be frozen before
+	// reading its processes anyway.
+	freezeTimeout = 100 * time.Millisecond
+	freezePoll    = 5 * time.Millisecond
+)
+
+// CgroupReader is a Reader that discovers processes from the cgroup v2
+// hierarchy instead of listing procfs, for environments where /proc does not
+// show all processes of the PID namespace (e.g. hardened containers). Only
+// /proc/<pid>/status is required for a process, everything else is read on a
+// best effort basis.
+type CgroupReader struct {
+	logger     logp.Logger
+	cgroupRoot string
+	procRoot   string
+	freeze     bool
+
+	// selfCgroup is the cgroup of the beat, empty if it is unknown.
+	selfCgroup string
+}
+
+// NewCgroupReader returns a reader for the cgroup v2 hierarchy mounted at
+// cgroupRoot. When freeze is set, every cgroup that doesn't contain the beat
+// itself is frozen while its processes are read to get a consistent snapshot.
+func NewCgroupReader(logger logp.Logger, cgroupRoot, procRoot string, freeze bool) CgroupReader {
+	selfCgroup, err := readCgroupFile("/proc/self/cgroup")
+	if err != nil && freeze {
+		logger.Warnf("cgroups won't be frozen, the cgroup of the beat is unknown: %v", err)
+	}
+	return CgroupReader{
+		logger:     logger,
+		cgroupRoot: cgroupRoot,
+		procRoot:   procRoot,
+		freeze:     freeze,
+		selfCgroup: selfCgroup,
+	}
+}
+
+// IsCgroupV2 returns true if a cgroup v2 (unified) hierarchy is mounted at root.
+func IsCgroupV2(root string) bool {
+	_, err := os.Stat(filepath.Join(root, "cgroup.controllers"))
+	return err == nil
+}
+
+func (r CgroupReader) GetProcess(pid uint32) (ProcessInfo, error) {
+	info, err := r.readProcess(pid)
+	if err != nil {
+		return ProcessInfo{}, err
+	}
+	if path, err := r.cgroupOf(pid); err == nil {
+		info.CGroupPath = path
+	}
+	return info, nil
+}
+
+// GetAllProcesses returns the processes of all cgroups below the root.
+// Processes that cannot be read are skipped, an error is only returned if the
+// hierarchy cannot be walked at all.
+func (r CgroupReader) GetAllProcesses() ([]ProcessInfo, error) {
+	if !IsCgroupV2(r.cgroupRoot) {
+		return nil, fmt.Errorf("no cgroup v2 hierarchy mounted at %s", r.cgroupRoot)
+	}
+
+	ret := make([]ProcessInfo, 0)
+	err := filepath.WalkDir(r.cgroupRoot, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable cgroups are skipped, but don't stop the walk.
+			r.logger.Debugf("failed to read cgroup %s: %v", path, err)
+			if d != nil && d.IsDir() && path != r.cgroupRoot {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		ret = append(ret, r.readCgroup(path)...)
+		return nil
+	})
+	if err != nil {
+		return ret, err
+	}
+	return ret, nil
+}
+
+// readCgroup reads the processes that are direct members of the cgroup at path.
+func (r CgroupReader) readCgroup(path string) []ProcessInfo {
+	pids, err := readCgroupProcs(path)
+	if err != nil {
+		r.logger.Debugf("failed to read processes of cgroup %s: %v", path, err)
+		return nil
+	}
+	if len(pids) == 0 {
+		return nil
+	}
+
+	cgroupPath := "/" + strings.TrimPrefix(strings.TrimPrefix(path, r.cgroupRoot), "/")
+	if r.freeze && r.canFreeze(cgroupPath) {
+		thaw, err := freezeCgroup(path)
+		defer thaw()
+		if err != nil {
+			r.logger.Debugf("failed to freeze cgroup %s: %v", path, err)
+		} else if frozen, err := readCgroupProcs(path); err == nil {
+			// Processes might have changed before the cgroup was frozen.
+			pids = frozen
+		}
+	}
+
+	ret := make([]ProcessInfo, 0, len(pids))
+	for _, pid := range pids {
+		info, err := r.readProcess(pid)
+		if err != nil {
+			r.logger.Debugf("failed to read process info for %v: %v", pid, err)
+			continue
+		}
+		info.CGroupPath = cgroupPath
+		ret = append(ret, info)
+	}
+	return ret
+}
+
+// readProcess reads the process information, /proc/<pid>/status is required
+// while stat, cmdline, exe and cwd are optional.
+func (r CgroupReader) readProcess(pid uint32) (ProcessInfo, error) {
+	status, err := readStatus(filepath.Join(r.procRoot, strconv.FormatUint(uint64(pid), 10), "status"))
+	if err != nil {
+		return ProcessInfo{}, err
+	}
+
+	info := ProcessInfo{
+		PIDs: types.PIDInfo{
+			Tid:  pid,
+			Tgid: pid,
+			Ppid: status.ppid,
+			Pgid: status.pgid,
+			Sid:  status.sid,
+		},
+		Creds: types.CredInfo{
+			Ruid: status.uids[0],
+			Euid: status.uids[1],
+			Suid: status.uids[2],
+			Rgid: status.gids[0],
+			Egid: status.gids[1],
+			Sgid: status.gids[2],
+		},
+		Filename: status.name,
+	}
+
+	procFS, err := procfs.NewFS(r.procRoot)
+	if err != nil {
+		return info, nil //nolint:nilerr // Everything beyond status is best effort.
+	}
+	proc, err := procFS.Proc(int(pid))
+	if err != nil {
+		return info, nil //nolint:nilerr // Everything beyond status is best effort.
+	}
+	if stat, err := proc.Stat(); err == nil {
+		info.PIDs.StartTimeNS = timeutils.TicksToNs(stat.Starttime)
+		info.PIDs.Pgid = uint32(stat.PGRP)
+		info.PIDs.Sid = uint32(stat.Session)
+		info.CTTY = types.TTYDev{
+			Major: MajorTTY(uint32(stat.TTY)),
+			Minor: MinorTTY(uint32(stat.TTY)),
+		}
+	}
+	if argv, err := proc.CmdLine(); err == nil {
+		info.Argv = argv
+	}
+	if exe, err := proc.Executable(); err == nil && exe != "" {
+		info.Filename = exe
+	}
+	if cwd, err := proc.Cwd(); err == nil {
+		info.Cwd = cwd
+	}
+	return info, nil
+}
+
+// canFreeze returns whether the cgroup at path, relative to the root of the
+// hierarchy, can be frozen. Freezing a cgroup freezes all its descendants, so
+// the cgroup of the beat and its ancestors must never be frozen, the beat
+// would not be able to thaw them. The root cgroup can't be frozen.
+func (r CgroupReader) canFreeze(path string) bool {
+	// In a cgroup namespace, the cgroup of the beat is reported as the root
+	// and its position in the hierarchy is unknown.
+	if r.selfCgroup == "" || r.selfCgroup == "/" || path == "/" {
+		return false
+	}
+	return path != r.selfCgroup && !strings.HasPrefix(r.selfCgroup, path+"/")
+}
+
+// cgroupOf returns the cgroup v2 path of pid.
+func (r CgroupReader) cgroupOf(pid uint32) (string, error) {
+	return readCgroupFile(filepath.Join(r.procRoot, strconv.FormatUint(uint64(pid), 10), "cgroup"))
+}
+
+// readCgroupFile returns the cgroup v2 path listed in a /proc/<pid>/cgroup
+// file.
+func readCgroupFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		// The unified hierarchy is reported as "0::<path>".
+		if path, found := strings.CutPrefix(line, "0::"); found {
+			return path, nil
+		}
+	}
+	return "", errors.New("process is not in a cgroup v2 hierarchy")
+}
+
+type procStatus struct {
+	name       string
+	ppid       uint32
+	pgid, sid  uint32
+	uids, gids [3]uint32
+	hasID      bool
+}
+
+// readStatus parses the fields of /proc/<pid>/status needed to register a
+// process. The NSpgid and NSsid fields are only present in recent kernels,
+// they are overwritten by /proc/<pid>/stat if it is readable.
+func readStatus(path string) (procStatus, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return procStatus{}, err
+	}
+
+	var s procStatus
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		key, value, found := strings.Cut(sc.Text(), ":")
+		if !found {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			continue
+		}
+		// Namespaced IDs are listed from the outermost to the innermost
+		// namespace, the first one is in the namespace of the reader.
+		switch key {
+		case "Name":
+			s.name = strings.TrimSpace(value)
+		case "PPid":
+			s.ppid = parseUint32(fields[0])
+		case "NSpgid":
+			s.pgid = parseUint32(fields[0])
+		case "NSsid":
+			s.sid = parseUint32(fields[0])
+		case "Uid":
+			s.uids, s.hasID = parseIDs(fields), true
+		case "Gid":
+			s.gids = parseIDs(fields)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return procStatus{}, err
+	}
+	if !s.hasID {
+		return procStatus{}, fmt.Errorf("invalid status file %s", path)
+	}
+	return s, nil
+}
+
+func parseIDs(fields []string) [3]uint32 {
+	var ids [3]uint32
+	for i := 0; i < len(ids) && i < len(fields); i++ {
+		ids[i] = parseUint32(fields[i])
+	}
+	return ids
+}
+
+func parseUint32(s string) uint32 {
+	v, _ := strconv.ParseUint(s, 10, 32)
+	return uint32(v)
+}
+
+func readCgroupProcs(path string) ([]uint32, error) {
+	data, err := os.ReadFile(filepath.Join(path, "cgroup.procs"))
+	if err != nil {
+		return nil, err
+	}
+	var pids []uint32
+	for _, line := range strings.Fields(string(data)) {
+		pid, err := strconv.ParseUint(line, 10, 32)
+		if err != nil {
+			continue
+		}
+		pids = append(pids, uint32(pid))
+	}
+	return pids, nil
+}
+
+// freezeCgroup freezes the cgroup at path and waits until it is frozen. The
+// returned function thaws the cgroup, it must be called even if an error is
+// returned, as the cgroup might be frozen anyway.
+func freezeCgroup(path string) (func(), error) {
+	freezeFile := filepath.Join(path, "cgroup.freeze")
+	thaw := func() { _ = writeControlFile(freezeFile, "0") }
+	if err := writeControlFile(freezeFile, "1"); err != nil {
+		return thaw, err
+	}
+
+	deadline := time.Now().Add(freezeTimeout)
+	for time.Now().Before(deadline) {
+		events, err := os.ReadFile(filepath.Join(path, "cgroup.events"))
+		if err != nil {
+			break
+		}
+		if strings.Contains(string(events), "frozen 1") {
+			return thaw, nil
+		}
+		time.Sleep(freezePoll)
+	}
+	// Reading the processes is still useful without a consistent snapshot.
+	return thaw, nil
+}
+
+// writeControlFile writes value to an existing cgroup control file.
+func writeControlFile(path, value string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(value)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,139 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+//go:build linux
+
+package procfs
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestCgroupReader(t *testing.T) {
+	dir := t.TempDir()
+	cgroupRoot := filepath.Join(dir, "cgroup")
+	procRoot := filepath.Join(dir, "proc")
+
+	writeFile(t, filepath.Join(cgroupRoot, "cgroup.controllers"), "cpu pids\n")
+	writeFile(t, filepath.Join(cgroupRoot, "cgroup.procs"), "")
+	writeFile(t, filepath.Join(cgroupRoot, "app", "cgroup.procs"), "10\n11\n")
+	// The status of 12 isn't readable, it is skipped.
+	writeFile(t, filepath.Join(cgroupRoot, "app", "worker", "cgroup.procs"), "12\n")
+
+	writeFile(t, filepath.Join(procRoot, "10", "status"), `Name:	nginx
+Tgid:	10
+PPid:	1
+NSpgid:	10
+NSsid:	10
+Uid:	0	0	0	0
+Gid:	0	0	0	0
+`)
+	writeFile(t, filepath.Join(procRoot, "11", "status"), `Name:	nginx
+PPid:	10
+NSpgid:	10
+NSsid:	10
+Uid:	101	101	101	101
+Gid:	102	102	102	102
+`)
+	writeFile(t, filepath.Join(procRoot, "11", "cgroup"), "0::/app\n")
+
+	r := NewCgroupReader(*logp.NewLogger("test"), cgroupRoot, procRoot, false)
+	procs, err := r.GetAllProcesses()
+	require.NoError(t, err)
+	require.Len(t, procs, 2)
+	sort.Slice(procs, func(i, j int) bool { return procs[i].PIDs.Tgid < procs[j].PIDs.Tgid })
+
+	assert.Equal(t, uint32(10), procs[0].PIDs.Tgid)
+	assert.Equal(t, uint32(1), procs[0].PIDs.Ppid)
+	assert.Equal(t, uint32(10), procs[0].PIDs.Sid)
+	assert.Equal(t, "/app", procs[0].CGroupPath)
+	assert.Equal(t, "nginx", procs[0].Filename)
+
+	assert.Equal(t, uint32(10), procs[1].PIDs.Ppid)
+	assert.Equal(t, uint32(101), procs[1].Creds.Euid)
+	assert.Equal(t, uint32(102), procs[1].Creds.Egid)
+
+	p, err := r.GetProcess(11)
+	require.NoError(t, err)
+	assert.Equal(t, "/app", p.CGroupPath)
+
+	_, err = r.GetProcess(12)
+	assert.Error(t, err)
+}
+
+func TestCgroupReaderNotV2(t *testing.T) {
+	r := NewCgroupReader(*logp.NewLogger("test"), t.TempDir(), t.TempDir(), false)
+	_, err := r.GetAllProcesses()
+	assert.ErrorContains(t, err, "no cgroup v2 hierarchy")
+}
+
+func TestCgroupReaderFreeze(t *testing.T) {
+	dir := t.TempDir()
+	cgroupRoot := filepath.Join(dir, "cgroup")
+	procRoot := filepath.Join(dir, "proc")
+
+	writeFile(t, filepath.Join(cgroupRoot, "cgroup.controllers"), "cpu pids\n")
+	writeFile(t, filepath.Join(cgroupRoot, "cgroup.procs"), "1\n")
+	for _, cgroup := range []string{"system", "system/beat", "system/beat/worker", "app"} {
+		writeFile(t, filepath.Join(cgroupRoot, cgroup, "cgroup.procs"), "1\n")
+		writeFile(t, filepath.Join(cgroupRoot, cgroup, "cgroup.freeze"), "untouched")
+	}
+
+	r := NewCgroupReader(*logp.NewLogger("test"), cgroupRoot, procRoot, true)
+	r.selfCgroup = "/system/beat"
+	_, err := r.GetAllProcesses()
+	require.NoError(t, err)
+
+	for cgroup, want := range map[string]string{
+		// Ancestors of the cgroup of the beat and the cgroup itself are
+		// never frozen.
+		"system":      "untouched",
+		"system/beat": "untouched",
+		// Other cgroups are thawed after being read.
+		"system/beat/worker": "0",
+		"app":                "0",
+	} {
+		got, err := os.ReadFile(filepath.Join(cgroupRoot, cgroup, "cgroup.freeze"))
+		require.NoError(t, err)
+		assert.Equal(t, want, string(got), cgroup)
+	}
+	_, err = os.Stat(filepath.Join(cgroupRoot, "cgroup.freeze"))
+	assert.ErrorIs(t, err, os.ErrNotExist, "the root cgroup must not be frozen")
+}
+
+func TestCgroupReaderCanFreeze(t *testing.T) {
+	tests := []struct {
+		selfCgroup string
+		path       string
+		want       bool
+	}{
+		{selfCgroup: "/system/beat", path: "/", want: false},
+		{selfCgroup: "/system/beat", path: "/system", want: false},
+		{selfCgroup: "/system/beat", path: "/system/beat", want: false},
+		{selfCgroup: "/system/beat", path: "/system/beat/worker", want: true},
+		{selfCgroup: "/system/beat", path: "/system/beats", want: true},
+		{selfCgroup: "/system/beat", path: "/app", want: true},
+		// The position of the beat in the hierarchy is unknown.
+		{selfCgroup: "/", path: "/app", want: false},
+		{selfCgroup: "", path: "/app", want: false},
+	}
+	for _, tc := range tests {
+		r := CgroupReader{selfCgroup: tc.selfCgroup}
+		assert.Equal(t, tc.want, r.canFreeze(tc.path), "self %s, path %s", tc.selfCgroup, tc.path)
+	}
+}
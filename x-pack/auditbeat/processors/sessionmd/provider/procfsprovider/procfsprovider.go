@@ -48,11 +48,12 @@ func (p prvdr) GetProcess(pid uint32) (*types.Process, error) {
 }
 
 // Sync updates the process information database using on the syscall event data and by scraping procfs.
+// Events without syscall data, like logs carrying the pid of their writer, only add the process from procfs if it is unknown.
 // As process information will not be available in procfs after a process has exited, the provider is susceptible to missing information in short-lived events.
 func (p prvdr) Sync(ev *beat.Event, pid uint32) error {
 	syscall, err := ev.GetValue(syscallField)
 	if err != nil {
-		return fmt.Errorf("event not supported, no syscall data")
+		return p.syncProcess(pid)
 	}
 
 	switch syscall {
@@ -134,3 +135,24 @@ func (p prvdr) Sync(ev *beat.Event, pid uint32) error {
 	}
 	return nil
 }
+
+// syncProcess adds the process to the DB from procfs if it is not known yet.
+func (p prvdr) syncProcess(pid uint32) error {
+	if p.db.HasProcess(pid) {
+		return nil
+	}
+	procInfo, err := p.reader.GetProcess(pid)
+	if err != nil {
+		return fmt.Errorf("process %d not found in procfs: %w", pid, err)
+	}
+	p.db.InsertExec(types.ProcessExecEvent{
+		PIDs:     procInfo.PIDs,
+		Creds:    procInfo.Creds,
+		CTTY:     procInfo.CTTY,
+		CWD:      procInfo.Cwd,
+		Argv:     procInfo.Argv,
+		Env:      procInfo.Env,
+		Filename: procInfo.Filename,
+	})
+	return nil
+}
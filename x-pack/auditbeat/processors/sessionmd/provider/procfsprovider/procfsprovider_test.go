@@ -480,3 +480,59 @@ func TestSetSidSessionLeaderNotScraped(t *testing.T) {
 
 	require.Equal(t, expected.PIDs.Sid, actual.SessionLeader.PID)
 }
+
+func TestEventWithoutSyscall(t *testing.T) {
+	var pid uint32 = 100
+	event := beat.Event{
+		Timestamp: timestamp,
+		Fields: mapstr.M{
+			"input": mapstr.M{
+				"type": "journald",
+			},
+			"message": "Accepted publickey for user",
+			"process": mapstr.M{
+				"pid": 100,
+			},
+		},
+	}
+	parent := procfs.ProcessInfo{
+		PIDs: types.PIDInfo{
+			Tid:  80,
+			Tgid: 80,
+			Sid:  80,
+		},
+	}
+	process := procfs.ProcessInfo{
+		PIDs: types.PIDInfo{
+			Tid:  100,
+			Tgid: 100,
+			Ppid: 80,
+			Pgid: 100,
+			Sid:  80,
+		},
+		Filename: "/usr/sbin/sshd",
+	}
+
+	reader := procfs.NewMockReader()
+	db, err := processdb.NewDB(reader, logger)
+	require.Nil(t, err)
+	reader.AddEntry(parent.PIDs.Tgid, parent)
+	db.ScrapeProcfs()
+	reader.AddEntry(process.PIDs.Tgid, process)
+
+	provider, err := NewProvider(context.TODO(), &logger, db, reader, "process.pid")
+	require.Nil(t, err, "error creating provider")
+
+	err = provider.Sync(&event, pid)
+	require.Nil(t, err)
+
+	actual, err := db.GetProcess(pid)
+	require.Nil(t, err, "pid not found in db")
+	require.Equal(t, pid, actual.PID)
+	require.Equal(t, "/usr/sbin/sshd", actual.Executable)
+	require.Equal(t, uint32(80), actual.Parent.PID)
+
+	// Unknown processes that exited are reported.
+	err = provider.Sync(&event, 200)
+	require.Error(t, err)
+}
@@ -0,0 +1,65 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/elastic/beats/v7/libbeat/cmd/instance"
+	"github.com/elastic/beats/v7/libbeat/common/cli"
+	"github.com/elastic/beats/v7/x-pack/filebeat/fbreceiver"
+)
+
+func genExportOTelCmd(settings instance.Settings) *cobra.Command {
+	exportCmd := &cobra.Command{
+		Use:   "otel",
+		Short: "Export the configuration of an OpenTelemetry collector running Filebeat",
+		Long: `Export translates the Filebeat configuration into the configuration of an
+OpenTelemetry collector running Filebeat as a filebeatreceiver. The modules
+enabled in filebeat.modules, in the modules.d directory or with --modules are
+unrolled into the inputs of their filesets, and the Elasticsearch output is
+translated into an elasticsearch exporter. The configuration is commented with
+the Filebeat settings each section is translated from.`,
+		Run: cli.RunWith(func(cmd *cobra.Command, args []string) error {
+			// Keep variables unresolved, secrets from the keystore must not
+			// be written in the collector configuration.
+			settings.DisableConfigResolver = true
+			b, err := instance.NewInitializedBeat(settings)
+			if err != nil {
+				return fmt.Errorf("failed to initialize 'export' command: %w", err)
+			}
+
+			collector, err := fbreceiver.ToCollectorConfig(b.RawConfig, b.Info)
+			if err != nil {
+				return fmt.Errorf("error translating the configuration: %w", err)
+			}
+			res, err := fbreceiver.MarshalCollectorConfig(collector)
+			if err != nil {
+				return fmt.Errorf("error converting the collector configuration to YAML: %w", err)
+			}
+
+			path, _ := cmd.Flags().GetString("write-otel-config")
+			if path == "" {
+				fmt.Fprint(cmd.OutOrStdout(), string(res))
+				return nil
+			}
+			// The configuration can contain credentials.
+			if err := os.WriteFile(path, res, 0o600); err != nil {
+				return fmt.Errorf("error writing the collector configuration: %w", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Collector configuration written to %s\n", path)
+			return nil
+		}),
+	}
+
+	exportCmd.Flags().AddGoFlag(flag.CommandLine.Lookup("modules"))
+	exportCmd.Flags().String("write-otel-config", "", "Write the collector configuration to this file instead of stdout")
+
+	return exportCmd
+}
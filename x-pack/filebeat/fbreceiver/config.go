@@ -4,11 +4,20 @@
 
 package fbreceiver
 
-import "fmt"
+import (
+	"fmt"
+
+	"go.opentelemetry.io/collector/component"
+)
 
 // Config is config settings for filebeat receiver.  The structure of
 // which is the same as the filebeat.yml configuration file.
 type Config struct {
+	// Storage is the ID of the storage extension, like file_storage, the
+	// registry is stored in. The registry is stored in files under the
+	// registry path if it is not set.
+	Storage *component.ID `mapstructure:"storage"`
+
 	Beatconfig map[string]interface{} `mapstructure:",remain"`
 }
 
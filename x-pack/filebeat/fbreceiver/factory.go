@@ -47,19 +47,28 @@ func createReceiver(_ context.Context, set receiver.Settings, baseCfg component.
 		return nil, fmt.Errorf("error creating %s: %w", Name, err)
 	}
 
+	fb := &filebeatReceiver{
+		beat:      &b.Beat,
+		id:        set.ID,
+		storageID: cfg.Storage,
+		done:      make(chan struct{}),
+	}
 	beatCreator := beater.New(inputs.Init)
+	if cfg.Storage != nil {
+		beatCreator = beater.NewWithRegistryBackend(inputs.Init, fb.openRegistry)
+	}
 
 	beatConfig, err := b.BeatConfig()
 	if err != nil {
 		return nil, fmt.Errorf("error getting beat config: %w", err)
 	}
 
-	fbBeater, err := beatCreator(&b.Beat, beatConfig)
+	fb.beater, err = beatCreator(&b.Beat, beatConfig)
 	if err != nil {
 		return nil, fmt.Errorf("error getting %s creator:%w", Name, err)
 	}
 
-	return &filebeatReceiver{beat: &b.Beat, beater: fbBeater}, nil
+	return fb, nil
 }
 
 func defaultProcessors() []mapstr.M {
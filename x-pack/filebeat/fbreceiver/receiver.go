@@ -6,25 +6,85 @@ package fbreceiver
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/elastic/beats/v7/filebeat/config"
 	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/statestore/backend"
+	"github.com/elastic/beats/v7/libbeat/statestore/backend/otelstorage"
+	"github.com/elastic/elastic-agent-libs/logp"
 
 	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/extension/experimental/storage"
 )
 
 type filebeatReceiver struct {
 	beat   *beat.Beat
 	beater beat.Beater
+
+	id        component.ID
+	storageID *component.ID
+	client    storage.Client // Client of the storage extension, set by Start.
+
+	running bool
+	done    chan struct{} // Closed once the beater returns.
 }
 
 func (fb *filebeatReceiver) Start(ctx context.Context, host component.Host) error {
+	if fb.storageID != nil {
+		client, err := storageClient(ctx, host, *fb.storageID, fb.id)
+		if err != nil {
+			return err
+		}
+		fb.client = client
+	}
+	fb.running = true
 	go func() {
+		defer close(fb.done)
 		_ = fb.beater.Run(fb.beat)
 	}()
 	return nil
 }
 
+// Shutdown stops the beater and waits for it to return, so that the registry
+// is written and closed before the storage extension is shut down.
 func (fb *filebeatReceiver) Shutdown(ctx context.Context) error {
 	fb.beater.Stop()
+	if !fb.running {
+		return nil
+	}
+	select {
+	case <-fb.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 	return nil
 }
+
+// openRegistry opens the registry stored by the client of the storage
+// extension.
+func (fb *filebeatReceiver) openRegistry(logger *logp.Logger, _ config.Registry) (backend.Registry, error) {
+	if fb.client == nil {
+		return nil, fmt.Errorf("storage extension %s is not started", fb.storageID)
+	}
+	return otelstorage.New(logger, fb.client), nil
+}
+
+func storageClient(ctx context.Context, host component.Host, storageID, id component.ID) (storage.Client, error) {
+	if host == nil {
+		return nil, fmt.Errorf("storage extension %s not found", storageID)
+	}
+	ext, found := host.GetExtensions()[storageID]
+	if !found {
+		return nil, fmt.Errorf("storage extension %s not found", storageID)
+	}
+	storageExt, ok := ext.(storage.Extension)
+	if !ok {
+		return nil, fmt.Errorf("extension %s is not a storage extension", storageID)
+	}
+	client, err := storageExt.GetClient(ctx, component.KindReceiver, id, "")
+	if err != nil {
+		return nil, fmt.Errorf("error getting a client of storage extension %s: %w", storageID, err)
+	}
+	return client, nil
+}
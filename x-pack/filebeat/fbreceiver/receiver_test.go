@@ -7,15 +7,27 @@ package fbreceiver
 import (
 	"bytes"
 	"context"
+	"errors"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/extension/experimental/storage"
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/receiver"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+
+	"github.com/elastic/beats/v7/filebeat/config"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/statestore"
+	"github.com/elastic/beats/v7/libbeat/statestore/backend"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
 )
 
 func TestNewReceiver(t *testing.T) {
@@ -89,3 +101,150 @@ found:
 	err = r.Shutdown(context.Background())
 	assert.NoError(t, err, "Error shutting down filebeatreceiver")
 }
+
+func TestReceiverStorage(t *testing.T) {
+	storageID := component.MustNewID("file_storage")
+	ext := &memStorage{data: map[string][]byte{}}
+	host := &storageHost{extensions: map[component.ID]component.Component{storageID: ext}}
+
+	r := &filebeatReceiver{
+		beat:      &beat.Beat{},
+		id:        component.MustNewID(Name),
+		storageID: &storageID,
+		done:      make(chan struct{}),
+	}
+	stored := make(chan struct{})
+	r.beater = &registryBeater{
+		openRegistry: r.openRegistry,
+		stored:       stored,
+		stop:         make(chan struct{}),
+	}
+
+	require.NoError(t, r.Start(context.Background(), host), "Error starting filebeatreceiver")
+	select {
+	case <-stored:
+	case <-time.After(time.Minute):
+		t.Fatal("timeout waiting for the beater to store its state")
+	}
+	require.NoError(t, r.Shutdown(context.Background()), "Error shutting down filebeatreceiver")
+
+	ext.mu.Lock()
+	defer ext.mu.Unlock()
+	assert.Equal(t, r.id, ext.clientID, "expected a client for the receiver")
+	assert.True(t, ext.closed, "expected the client to be closed before Shutdown returns")
+	assert.Equal(t, "1", string(ext.data["filebeat/slots"]))
+	assert.JSONEq(t, `{"key":"filestream::test","value":{"cursor":{"offset":13}}}`, string(ext.data["filebeat/slot/0"]))
+}
+
+func TestReceiverStorageNotFound(t *testing.T) {
+	storageID := component.MustNewID("file_storage")
+	r := &filebeatReceiver{id: component.MustNewID(Name), storageID: &storageID, done: make(chan struct{})}
+
+	err := r.Start(context.Background(), &storageHost{})
+	assert.ErrorContains(t, err, "storage extension file_storage not found")
+
+	err = r.Start(context.Background(), &storageHost{extensions: map[component.ID]component.Component{
+		storageID: struct {
+			component.StartFunc
+			component.ShutdownFunc
+		}{},
+	}})
+	assert.ErrorContains(t, err, "extension file_storage is not a storage extension")
+}
+
+type storageHost struct {
+	extensions map[component.ID]component.Component
+}
+
+func (h *storageHost) GetExtensions() map[component.ID]component.Component {
+	return h.extensions
+}
+
+// memStorage is a storage extension with a single client, keeping the values
+// in a map.
+type memStorage struct {
+	mu       sync.Mutex
+	data     map[string][]byte
+	clientID component.ID
+	closed   bool
+}
+
+func (s *memStorage) Start(context.Context, component.Host) error { return nil }
+func (s *memStorage) Shutdown(context.Context) error              { return nil }
+
+func (s *memStorage) GetClient(_ context.Context, _ component.Kind, id component.ID, _ string) (storage.Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clientID = id
+	return s, nil
+}
+
+func (s *memStorage) Get(ctx context.Context, key string) ([]byte, error) {
+	op := storage.GetOperation(key)
+	err := s.Batch(ctx, op)
+	return op.Value, err
+}
+
+func (s *memStorage) Set(ctx context.Context, key string, value []byte) error {
+	return s.Batch(ctx, storage.SetOperation(key, value))
+}
+
+func (s *memStorage) Delete(ctx context.Context, key string) error {
+	return s.Batch(ctx, storage.DeleteOperation(key))
+}
+
+func (s *memStorage) Batch(_ context.Context, ops ...storage.Operation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return errors.New("client closed")
+	}
+	for _, op := range ops {
+		switch op.Type {
+		case storage.Get:
+			op.Value = s.data[op.Key]
+		case storage.Set:
+			s.data[op.Key] = append([]byte(nil), op.Value...)
+		case storage.Delete:
+			delete(s.data, op.Key)
+		}
+	}
+	return nil
+}
+
+func (s *memStorage) Close(context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+// registryBeater stores a cursor in the registry when it runs, like an input,
+// and closes the registry when it is stopped.
+type registryBeater struct {
+	openRegistry func(*logp.Logger, config.Registry) (backend.Registry, error)
+	stored       chan struct{}
+	stop         chan struct{}
+}
+
+func (b *registryBeater) Run(*beat.Beat) error {
+	reg, err := b.openRegistry(logp.NewLogger("test"), config.Registry{})
+	if err != nil {
+		return err
+	}
+	registry := statestore.NewRegistry(reg)
+	defer registry.Close()
+	store, err := registry.Get("filebeat")
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	err = store.Set("filestream::test", mapstr.M{"cursor": mapstr.M{"offset": 13}})
+	close(b.stored)
+	<-b.stop
+	return err
+}
+
+func (b *registryBeater) Stop() {
+	close(b.stop)
+}
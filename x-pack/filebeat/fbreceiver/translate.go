@@ -0,0 +1,293 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package fbreceiver
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/beats/v7/filebeat/fileset"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/cfgfile"
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/paths"
+)
+
+// exporterName is the name of the exporter the events of the receiver are
+// sent to in the generated collector configuration.
+const exporterName = "elasticsearch"
+
+// ToCollectorConfig translates a Filebeat configuration into the
+// configuration of an OpenTelemetry collector running Filebeat as a
+// filebeatreceiver.
+//
+// The modules enabled in filebeat.modules, in the modules.d directory or with
+// the --modules flag are unrolled into the inputs of their filesets, with the
+// default paths and the ingest pipelines of the filesets. The Elasticsearch
+// output is translated into an elasticsearch exporter, the receiver publishes
+// its events to the collector with the otelconsumer output.
+func ToCollectorConfig(cfg *config.C, info beat.Info) (mapstr.M, error) {
+	var beatConfig mapstr.M
+	if err := cfg.Unpack(&beatConfig); err != nil {
+		return nil, fmt.Errorf("error unpacking the configuration: %w", err)
+	}
+
+	inputs, err := moduleInputs(cfg, info)
+	if err != nil {
+		return nil, err
+	}
+	if len(inputs) > 0 {
+		var configured []interface{}
+		if v, err := beatConfig.GetValue("filebeat.inputs"); err == nil {
+			configured, _ = v.([]interface{})
+		}
+		_, _ = beatConfig.Put("filebeat.inputs", append(configured, inputs...))
+	}
+	_ = beatConfig.Delete("filebeat.modules")
+	_ = beatConfig.Delete("filebeat.config.modules")
+
+	exporter, err := elasticsearchExporter(cfg)
+	if err != nil {
+		return nil, err
+	}
+	beatConfig["output"] = mapstr.M{"otelconsumer": mapstr.M{}}
+
+	return mapstr.M{
+		"receivers": mapstr.M{
+			Name: beatConfig,
+		},
+		"exporters": mapstr.M{
+			exporterName: exporter,
+		},
+		"service": mapstr.M{
+			"pipelines": mapstr.M{
+				"logs": mapstr.M{
+					"receivers": []string{Name},
+					"exporters": []string{exporterName},
+				},
+			},
+		},
+	}, nil
+}
+
+// moduleInputs returns the configurations of the inputs of the filesets of
+// the enabled modules.
+func moduleInputs(cfg *config.C, info beat.Info) ([]interface{}, error) {
+	var filebeatConfig struct {
+		Modules       []*config.C `config:"filebeat.modules"`
+		ConfigModules *config.C   `config:"filebeat.config.modules"`
+	}
+	if err := cfg.Unpack(&filebeatConfig); err != nil {
+		return nil, fmt.Errorf("error reading modules configuration: %w", err)
+	}
+
+	modules := filebeatConfig.Modules
+	if filebeatConfig.ConfigModules.Enabled() {
+		dynamic := cfgfile.DefaultDynamicConfig
+		if err := filebeatConfig.ConfigModules.Unpack(&dynamic); err != nil {
+			return nil, fmt.Errorf("error reading filebeat.config.modules: %w", err)
+		}
+		path := dynamic.Path
+		if !filepath.IsAbs(path) {
+			path = paths.Resolve(paths.Config, path)
+		}
+		files, err := filepath.Glob(path)
+		if err != nil {
+			return nil, fmt.Errorf("error listing module configuration files %s: %w", path, err)
+		}
+		for _, file := range files {
+			list, err := cfgfile.LoadList(file)
+			if err != nil {
+				return nil, err
+			}
+			modules = append(modules, list...)
+		}
+	}
+
+	if len(modules) > 0 {
+		// The registry is silently empty if the module directory is
+		// missing, don't drop the modules from the configuration.
+		modulesPath := paths.Resolve(paths.Home, "module")
+		if _, err := os.Stat(modulesPath); err != nil {
+			return nil, fmt.Errorf("modules are configured but can't be read: %w", err)
+		}
+	}
+	registry, err := fileset.NewModuleRegistry(modules, info, true, fileset.FilesetOverrides{})
+	if err != nil {
+		return nil, fmt.Errorf("error loading modules: %w", err)
+	}
+	configs, err := registry.GetInputConfigs()
+	if err != nil {
+		return nil, err
+	}
+
+	inputs := make([]interface{}, 0, len(configs))
+	for _, c := range configs {
+		var input map[string]interface{}
+		if err := c.Unpack(&input); err != nil {
+			return nil, fmt.Errorf("error unpacking module input configuration: %w", err)
+		}
+		inputs = append(inputs, input)
+	}
+	return inputs, nil
+}
+
+// elasticsearchExporter returns the configuration of an elasticsearch
+// exporter equivalent to the Elasticsearch output of cfg.
+func elasticsearchExporter(cfg *config.C) (mapstr.M, error) {
+	var outputConfig struct {
+		Output config.Namespace `config:"output"`
+	}
+	if err := cfg.Unpack(&outputConfig); err != nil {
+		return nil, fmt.Errorf("error reading output configuration: %w", err)
+	}
+	if !outputConfig.Output.IsSet() {
+		return nil, fmt.Errorf("no output is configured")
+	}
+	if name := outputConfig.Output.Name(); name != "elasticsearch" {
+		return nil, fmt.Errorf("the %s output can't be translated, only the elasticsearch output is supported", name)
+	}
+
+	var es struct {
+		Hosts    []string `config:"hosts"`
+		Protocol string   `config:"protocol"`
+		Path     string   `config:"path"`
+		Username string   `config:"username"`
+		Password string   `config:"password"`
+		APIKey   string   `config:"api_key"`
+		Pipeline string   `config:"pipeline"`
+		SSL      struct {
+			CertificateAuthorities []string `config:"certificate_authorities"`
+			Certificate            string   `config:"certificate"`
+			Key                    string   `config:"key"`
+			VerificationMode       string   `config:"verification_mode"`
+		} `config:"ssl"`
+	}
+	if err := outputConfig.Output.Config().Unpack(&es); err != nil {
+		return nil, fmt.Errorf("error reading elasticsearch output configuration: %w", err)
+	}
+
+	endpoints := make([]string, 0, len(es.Hosts))
+	for _, host := range es.Hosts {
+		endpoint, err := common.MakeURL(es.Protocol, es.Path, host, 9200)
+		if err != nil {
+			return nil, fmt.Errorf("invalid elasticsearch host %q: %w", host, err)
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+
+	exporter := mapstr.M{
+		"endpoints": endpoints,
+		// Filebeat events are indexed as they are, like by the output.
+		"mapping": mapstr.M{"mode": "bodymap"},
+	}
+	if es.Username != "" {
+		exporter["user"] = es.Username
+		exporter["password"] = es.Password
+	}
+	if es.APIKey != "" {
+		// The exporter expects the API key encoded like in the
+		// Authorization header.
+		exporter["api_key"] = base64.StdEncoding.EncodeToString([]byte(es.APIKey))
+	}
+	if es.Pipeline != "" {
+		exporter["pipeline"] = es.Pipeline
+	}
+
+	tls := mapstr.M{}
+	if len(es.SSL.CertificateAuthorities) > 0 {
+		tls["ca_file"] = es.SSL.CertificateAuthorities[0]
+	}
+	if es.SSL.Certificate != "" {
+		tls["cert_file"] = es.SSL.Certificate
+		tls["key_file"] = es.SSL.Key
+	}
+	if es.SSL.VerificationMode == "none" {
+		tls["insecure_skip_verify"] = true
+	}
+	if len(tls) > 0 {
+		exporter["tls"] = tls
+	}
+	return exporter, nil
+}
+
+// collectorComments maps the keys of the collector configuration to the keys
+// of the Filebeat configuration they are translated from.
+var collectorComments = map[string]string{
+	"receivers." + Name:                        "The Filebeat configuration, filebeat.modules are unrolled into filebeat.inputs.",
+	"receivers." + Name + ".output":            "Replaces output.elasticsearch, events are published to the exporters of the pipeline.",
+	"exporters." + exporterName:                "Translated from output.elasticsearch.",
+	"exporters." + exporterName + ".endpoints": "From output.elasticsearch.hosts, protocol and path.",
+	"exporters." + exporterName + ".user":      "From output.elasticsearch.username.",
+	"exporters." + exporterName + ".password":  "From output.elasticsearch.password.",
+	"exporters." + exporterName + ".api_key":   "From output.elasticsearch.api_key, base64 encoded.",
+	"exporters." + exporterName + ".pipeline":  "From output.elasticsearch.pipeline.",
+	"exporters." + exporterName + ".mapping":   "Index the events as they are published by Filebeat.",
+	"exporters." + exporterName + ".tls":       "From output.elasticsearch.ssl.",
+	"service.pipelines.logs":                   "Sends the events of the receiver to the exporter.",
+}
+
+// MarshalCollectorConfig encodes a collector configuration returned by
+// ToCollectorConfig in YAML, with comments mapping its sections back to the
+// keys of the Filebeat configuration, so it can be reviewed before use.
+func MarshalCollectorConfig(collector mapstr.M) ([]byte, error) {
+	var doc yaml.Node
+	if err := doc.Encode(collector); err != nil {
+		return nil, err
+	}
+	for path, comment := range collectorComments {
+		if key, _ := lookupNode(&doc, path); key != nil {
+			key.HeadComment = comment
+		}
+	}
+
+	// Tell where the inputs of the modules come from.
+	if _, inputs := lookupNode(&doc, "receivers."+Name+".filebeat.inputs"); inputs != nil && inputs.Kind == yaml.SequenceNode {
+		for _, input := range inputs.Content {
+			var fields struct {
+				Module  string `yaml:"_module_name"`
+				Fileset string `yaml:"_fileset_name"`
+			}
+			if err := input.Decode(&fields); err != nil || fields.Module == "" {
+				continue
+			}
+			input.HeadComment = fmt.Sprintf("From filebeat.modules, module %s, fileset %s.", fields.Module, fields.Fileset)
+		}
+	}
+
+	return yaml.Marshal(&doc)
+}
+
+// lookupNode returns the key and value nodes at the dotted path of a YAML
+// mapping.
+func lookupNode(node *yaml.Node, path string) (key, value *yaml.Node) {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	value = node
+	for _, name := range strings.Split(path, ".") {
+		if value.Kind != yaml.MappingNode {
+			return nil, nil
+		}
+		key = nil
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			if value.Content[i].Value == name {
+				key, value = value.Content[i], value.Content[i+1]
+				break
+			}
+		}
+		if key == nil {
+			return nil, nil
+		}
+	}
+	return key, value
+}
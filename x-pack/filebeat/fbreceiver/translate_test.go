@@ -0,0 +1,163 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package fbreceiver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/paths"
+)
+
+func TestToCollectorConfig(t *testing.T) {
+	// Use the modules of the OSS Filebeat.
+	home := paths.Paths.Home
+	paths.Paths.Home = "../../../filebeat"
+	defer func() { paths.Paths.Home = home }()
+
+	cfg := config.MustNewConfigFrom(map[string]interface{}{
+		"filebeat.inputs": []map[string]interface{}{
+			{"type": "filestream", "id": "app", "paths": []string{"/var/log/app.log"}},
+		},
+		"filebeat.modules": []map[string]interface{}{
+			{"module": "nginx", "access.enabled": true, "error.enabled": false},
+		},
+		"output.elasticsearch": map[string]interface{}{
+			"hosts":                       []string{"localhost:9200", "https://es.example.com"},
+			"username":                    "elastic",
+			"password":                    "changeme",
+			"ssl.certificate_authorities": []string{"/etc/ca.pem"},
+		},
+		"processors": []map[string]interface{}{
+			{"add_host_metadata": nil},
+		},
+	})
+
+	collector, err := ToCollectorConfig(cfg, beat.Info{Beat: "filebeat", IndexPrefix: "filebeat", Version: "8.99.0"})
+	require.NoError(t, err)
+
+	receiver, err := collector.GetValue("receivers." + Name)
+	require.NoError(t, err)
+	receiverConfig := receiver.(mapstr.M)
+
+	output, err := receiverConfig.GetValue("output")
+	require.NoError(t, err)
+	assert.Equal(t, mapstr.M{"otelconsumer": mapstr.M{}}, output)
+	has, _ := receiverConfig.HasKey("filebeat.modules")
+	assert.False(t, has, "modules must be unrolled")
+	has, _ = receiverConfig.HasKey("processors")
+	assert.True(t, has, "other settings must be kept")
+
+	v, err := receiverConfig.GetValue("filebeat.inputs")
+	require.NoError(t, err)
+	inputs := v.([]interface{})
+	require.Len(t, inputs, 2)
+	assert.Equal(t, "app", mapstr.M(inputs[0].(map[string]interface{}))["id"])
+
+	nginx := mapstr.M(inputs[1].(map[string]interface{}))
+	assert.Equal(t, "nginx", nginx["_module_name"])
+	assert.Equal(t, "access", nginx["_fileset_name"])
+	assert.Equal(t, "filebeat-8.99.0-nginx-access-pipeline", nginx["pipeline"])
+	assert.NotEmpty(t, nginx["paths"], "the default paths of the fileset must be set")
+
+	exporter, err := collector.GetValue("exporters." + exporterName)
+	require.NoError(t, err)
+	assert.Equal(t, mapstr.M{
+		"endpoints": []string{"http://localhost:9200", "https://es.example.com:9200"},
+		"user":      "elastic",
+		"password":  "changeme",
+		"mapping":   mapstr.M{"mode": "bodymap"},
+		"tls":       mapstr.M{"ca_file": "/etc/ca.pem"},
+	}, exporter)
+
+	pipeline, err := collector.GetValue("service.pipelines.logs")
+	require.NoError(t, err)
+	assert.Equal(t, mapstr.M{
+		"receivers": []string{Name},
+		"exporters": []string{exporterName},
+	}, pipeline)
+}
+
+func TestToCollectorConfigAPIKey(t *testing.T) {
+	cfg := config.MustNewConfigFrom(map[string]interface{}{
+		"filebeat.inputs":              []map[string]interface{}{{"type": "filestream", "id": "app"}},
+		"output.elasticsearch.hosts":   []string{"localhost:9200"},
+		"output.elasticsearch.api_key": "id:key",
+	})
+
+	collector, err := ToCollectorConfig(cfg, beat.Info{})
+	require.NoError(t, err)
+	apiKey, err := collector.GetValue("exporters." + exporterName + ".api_key")
+	require.NoError(t, err)
+	assert.Equal(t, "aWQ6a2V5", apiKey)
+}
+
+func TestToCollectorConfigUnsupportedOutput(t *testing.T) {
+	cfg := config.MustNewConfigFrom(map[string]interface{}{
+		"filebeat.inputs":       []map[string]interface{}{{"type": "filestream", "id": "app"}},
+		"output.logstash.hosts": []string{"localhost:5044"},
+	})
+
+	_, err := ToCollectorConfig(cfg, beat.Info{})
+	assert.ErrorContains(t, err, "the logstash output can't be translated")
+}
+
+func TestToCollectorConfigMissingModules(t *testing.T) {
+	home := paths.Paths.Home
+	paths.Paths.Home = t.TempDir()
+	defer func() { paths.Paths.Home = home }()
+
+	cfg := config.MustNewConfigFrom(map[string]interface{}{
+		"filebeat.modules":           []map[string]interface{}{{"module": "nginx"}},
+		"output.elasticsearch.hosts": []string{"localhost:9200"},
+	})
+
+	_, err := ToCollectorConfig(cfg, beat.Info{})
+	assert.ErrorContains(t, err, "modules are configured but can't be read")
+}
+
+func TestMarshalCollectorConfig(t *testing.T) {
+	home := paths.Paths.Home
+	paths.Paths.Home = "../../../filebeat"
+	defer func() { paths.Paths.Home = home }()
+
+	cfg := config.MustNewConfigFrom(map[string]interface{}{
+		"filebeat.modules": []map[string]interface{}{
+			{"module": "nginx", "access.enabled": true, "error.enabled": false},
+		},
+		"output.elasticsearch.hosts":   []string{"localhost:9200"},
+		"output.elasticsearch.api_key": "id:key",
+	})
+	collector, err := ToCollectorConfig(cfg, beat.Info{Beat: "filebeat", IndexPrefix: "filebeat", Version: "8.99.0"})
+	require.NoError(t, err)
+
+	res, err := MarshalCollectorConfig(collector)
+	require.NoError(t, err)
+	for _, comment := range []string{
+		"# From filebeat.modules, module nginx, fileset access.",
+		"# Replaces output.elasticsearch, events are published to the exporters of the pipeline.",
+		"# Translated from output.elasticsearch.",
+		"# From output.elasticsearch.hosts, protocol and path.",
+		"# From output.elasticsearch.api_key, base64 encoded.",
+	} {
+		assert.Contains(t, string(res), comment)
+	}
+
+	// The comments don't change the configuration.
+	var decoded map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(res, &decoded))
+	roundTrip, err := config.NewConfigFrom(decoded)
+	require.NoError(t, err)
+	var got, want mapstr.M
+	require.NoError(t, roundTrip.Unpack(&got))
+	require.NoError(t, config.MustNewConfigFrom(collector).Unpack(&want))
+	assert.Equal(t, want, got)
+}
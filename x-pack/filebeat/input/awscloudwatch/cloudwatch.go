@@ -96,7 +96,7 @@ func (p *cloudwatchPoller) getLogEventsFromCloudWatch(svc *cloudwatchlogs.Client
 		p.log.Debug("done sleeping")
 
 		p.log.Debugf("Processing #%v events", len(logEvents))
-		logProcessor.processLogEvents(logEvents, logGroupId, p.region)
+		logProcessor.processLogEvents(logEvents, logGroupId, p.region, nil)
 	}
 	return nil
 }
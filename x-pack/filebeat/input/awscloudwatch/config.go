@@ -6,8 +6,12 @@ package awscloudwatch
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+
 	"github.com/elastic/beats/v7/filebeat/harvester"
 	awscommon "github.com/elastic/beats/v7/x-pack/libbeat/common/aws"
 )
@@ -27,6 +31,8 @@ type config struct {
 	APISleep                           time.Duration       `config:"api_sleep" validate:"min=0,nonzero"`
 	Latency                            time.Duration       `config:"latency"`
 	NumberOfWorkers                    int                 `config:"number_of_workers"`
+	Mode                               string              `config:"mode"`
+	Subscription                       subscriptionConfig  `config:"subscription"`
 	AWSConfig                          awscommon.ConfigAWS `config:",inline"`
 }
 
@@ -40,6 +46,7 @@ func defaultConfig() config {
 		APITimeout:      120 * time.Second,
 		APISleep:        200 * time.Millisecond, // FilterLogEvents has a limit of 5 transactions per second (TPS)/account/Region: 1s / 5 = 200 ms
 		NumberOfWorkers: 1,
+		Mode:            modePolling,
 	}
 }
 
@@ -49,6 +56,14 @@ func (c *config) Validate() error {
 			"either 'beginning' or 'end'")
 	}
 
+	switch c.Mode {
+	case modePolling:
+	case modeSubscription:
+		return c.Subscription.Validate()
+	default:
+		return fmt.Errorf("mode config parameter can only be either '%s' or '%s'", modePolling, modeSubscription)
+	}
+
 	if c.LogGroupARN == "" && c.LogGroupName == "" && c.LogGroupNamePrefix == "" {
 		return errors.New("log_group_arn, log_group_name and log_group_name_prefix config parameter " +
 			"cannot all be empty")
@@ -64,3 +79,23 @@ func (c *config) Validate() error {
 	}
 	return nil
 }
+
+func (c *subscriptionConfig) Validate() error {
+	if c.StreamARN == "" {
+		return errors.New("subscription.stream_arn is required in subscription mode")
+	}
+	parsedArn, err := arn.Parse(c.StreamARN)
+	if err != nil {
+		return fmt.Errorf("failed to parse subscription.stream_arn: %w", err)
+	}
+	if parsedArn.Service != "kinesis" || !strings.HasPrefix(parsedArn.Resource, "stream/") {
+		return fmt.Errorf("subscription.stream_arn %q is not a Kinesis data stream ARN", c.StreamARN)
+	}
+	if parsedArn.Region == "" {
+		return errors.New("failed to parse subscription.stream_arn: missing region")
+	}
+	if c.ConsumerName == "" {
+		return errors.New("subscription.consumer_name is required in subscription mode")
+	}
+	return nil
+}
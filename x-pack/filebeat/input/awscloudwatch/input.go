@@ -13,9 +13,12 @@ import (
 	awssdk "github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/aws/arn"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
 
+	"github.com/elastic/beats/v7/filebeat/beater"
 	v2 "github.com/elastic/beats/v7/filebeat/input/v2"
 	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/acker"
 	"github.com/elastic/beats/v7/libbeat/common/cfgwarn"
 	"github.com/elastic/beats/v7/libbeat/feature"
 	awscommon "github.com/elastic/beats/v7/x-pack/libbeat/common/aws"
@@ -27,17 +30,18 @@ const (
 	inputName = "aws-cloudwatch"
 )
 
-func Plugin() v2.Plugin {
+func Plugin(store beater.StateStore) v2.Plugin {
 	return v2.Plugin{
 		Name:       inputName,
 		Stability:  feature.Stable,
 		Deprecated: false,
 		Info:       "Collect logs from cloudwatch",
-		Manager:    &cloudwatchInputManager{},
+		Manager:    &cloudwatchInputManager{store: store},
 	}
 }
 
 type cloudwatchInputManager struct {
+	store beater.StateStore
 }
 
 func (im *cloudwatchInputManager) Init(grp unison.Group) error {
@@ -50,7 +54,7 @@ func (im *cloudwatchInputManager) Create(cfg *conf.C) (v2.Input, error) {
 		return nil, err
 	}
 
-	return newInput(config)
+	return newInput(config, im.store)
 }
 
 // cloudwatchInput is an input for reading logs from CloudWatch periodically.
@@ -58,9 +62,10 @@ type cloudwatchInput struct {
 	config    config
 	awsConfig awssdk.Config
 	metrics   *inputMetrics
+	store     beater.StateStore // Persists the positions of the shards in subscription mode.
 }
 
-func newInput(config config) (*cloudwatchInput, error) {
+func newInput(config config, store beater.StateStore) (*cloudwatchInput, error) {
 	cfgwarn.Beta("aws-cloudwatch input type is used")
 
 	// perform AWS configuration validation
@@ -72,6 +77,7 @@ func newInput(config config) (*cloudwatchInput, error) {
 	return &cloudwatchInput{
 		config:    config,
 		awsConfig: awsConfig,
+		store:     store,
 	}, nil
 }
 
@@ -84,6 +90,10 @@ func (in *cloudwatchInput) Test(ctx v2.TestContext) error {
 func (in *cloudwatchInput) Run(inputContext v2.Context, pipeline beat.Pipeline) error {
 	ctx := v2.GoContextFromCanceler(inputContext.Cancelation)
 
+	if in.config.Mode == modeSubscription {
+		return in.runSubscription(ctx, inputContext, pipeline)
+	}
+
 	// Create client for publishing events and receive notification of their ACKs.
 	client, err := pipeline.ConnectWith(beat.ClientConfig{})
 	if err != nil {
@@ -165,6 +175,55 @@ func fromConfig(cfg config, awsCfg awssdk.Config) (logGroupIDs []string, region
 	return logGroupIDs, region, nil
 }
 
+// runSubscription consumes CloudWatch Logs delivered to a Kinesis data stream
+// by subscription filters. The sequence number of the last record of each
+// shard whose events are acknowledged is persisted in the registry, the
+// shards are read after it when the input is restarted.
+func (in *cloudwatchInput) runSubscription(ctx context.Context, inputContext v2.Context, pipeline beat.Pipeline) error {
+	// The stream ARN has been validated while unpacking the configuration.
+	parsedArn, err := arn.Parse(in.config.Subscription.StreamARN)
+	if err != nil {
+		return fmt.Errorf("failed to parse stream ARN: %w", err)
+	}
+	in.awsConfig.Region = parsedArn.Region
+	svc := kinesis.NewFromConfig(in.awsConfig, func(o *kinesis.Options) {
+		if in.config.AWSConfig.FIPSEnabled {
+			o.EndpointOptions.UseFIPSEndpoint = awssdk.FIPSEndpointStateEnabled
+		}
+	})
+
+	log := inputContext.Logger
+	store, err := in.store.Access()
+	if err != nil {
+		return fmt.Errorf("can't access persistent store: %w", err)
+	}
+	defer store.Close()
+	checkpoints := newShardCheckpoints(log, store, in.config.Subscription)
+
+	client, err := pipeline.ConnectWith(beat.ClientConfig{
+		EventListener: acker.ConnectionOnly(acker.EventPrivateReporter(func(_ int, privates []interface{}) {
+			checkpoints.acked(privates)
+		})),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create pipeline client: %w", err)
+	}
+	defer client.Close()
+
+	in.metrics = newInputMetrics(inputContext.ID, nil)
+	defer in.metrics.Close()
+	logProcessor := newLogProcessor(log.Named("log_processor"), in.metrics, client, ctx)
+	consumer := newSubscriptionConsumer(
+		log.Named("subscription_consumer"),
+		in.metrics,
+		parsedArn.Region,
+		in.config,
+		svc,
+		logProcessor,
+		checkpoints)
+	return consumer.run(ctx)
+}
+
 // getLogGroupNames uses DescribeLogGroups API to retrieve LogGroupArn entries that matches the provided logGroupNamePrefix
 func getLogGroupNames(svc *cloudwatchlogs.Client, logGroupNamePrefix string, withLinkedAccount bool) ([]string, error) {
 	// construct DescribeLogGroupsInput
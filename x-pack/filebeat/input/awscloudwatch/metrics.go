@@ -16,6 +16,7 @@ type inputMetrics struct {
 	logGroupsTotal               *monitoring.Uint // Logs collected from number of CloudWatch log groups.
 	cloudwatchEventsCreatedTotal *monitoring.Uint // Number of events created from processing logs from CloudWatch.
 	apiCallsTotal                *monitoring.Uint // Number of API calls made total.
+	kinesisRecordsReceivedTotal  *monitoring.Uint // Number of Kinesis records received in subscription mode.
 }
 
 // Close removes the metrics from the registry.
@@ -32,6 +33,7 @@ func newInputMetrics(id string, optionalParent *monitoring.Registry) *inputMetri
 		logGroupsTotal:               monitoring.NewUint(reg, "log_groups_total"),
 		cloudwatchEventsCreatedTotal: monitoring.NewUint(reg, "cloudwatch_events_created_total"),
 		apiCallsTotal:                monitoring.NewUint(reg, "api_calls_total"),
+		kinesisRecordsReceivedTotal:  monitoring.NewUint(reg, "kinesis_records_received_total"),
 	}
 	return out
 }
@@ -32,9 +32,12 @@ func newLogProcessor(log *logp.Logger, metrics *inputMetrics, publisher beat.Cli
 	}
 }
 
-func (p *logProcessor) processLogEvents(logEvents []types.FilteredLogEvent, logGroupId string, regionName string) {
+// processLogEvents publishes the log events. private is set as the private
+// data of the events, it is returned when they are acknowledged.
+func (p *logProcessor) processLogEvents(logEvents []types.FilteredLogEvent, logGroupId string, regionName string, private interface{}) {
 	for _, logEvent := range logEvents {
 		event := createEvent(logEvent, logGroupId, regionName)
+		event.Private = private
 		p.metrics.cloudwatchEventsCreatedTotal.Inc()
 		p.publisher.Publish(event)
 	}
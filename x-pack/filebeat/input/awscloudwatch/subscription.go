@@ -0,0 +1,443 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package awscloudwatch
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	kinesistypes "github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+
+	"github.com/elastic/beats/v7/libbeat/common/backoff"
+	"github.com/elastic/beats/v7/libbeat/statestore"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+const (
+	modePolling      = "polling"
+	modeSubscription = "subscription"
+
+	// consumerPollInterval is the interval used to check whether a newly
+	// registered stream consumer became active.
+	consumerPollInterval = 5 * time.Second
+
+	subscriptionStatePrefix = "filebeat::aws-cloudwatch::subscription::"
+)
+
+// subscriptionConfig configures the consumption of CloudWatch Logs
+// subscription filters delivered to a Kinesis data stream.
+type subscriptionConfig struct {
+	StreamARN    string `config:"stream_arn"`
+	ConsumerName string `config:"consumer_name"`
+}
+
+// kinesisAPI is the subset of the Kinesis API used by the subscription consumer.
+type kinesisAPI interface {
+	DescribeStreamConsumer(context.Context, *kinesis.DescribeStreamConsumerInput, ...func(*kinesis.Options)) (*kinesis.DescribeStreamConsumerOutput, error)
+	RegisterStreamConsumer(context.Context, *kinesis.RegisterStreamConsumerInput, ...func(*kinesis.Options)) (*kinesis.RegisterStreamConsumerOutput, error)
+	ListShards(context.Context, *kinesis.ListShardsInput, ...func(*kinesis.Options)) (*kinesis.ListShardsOutput, error)
+}
+
+// shardStream is the event stream returned by SubscribeToShard.
+type shardStream interface {
+	Events() <-chan kinesistypes.SubscribeToShardEventStream
+	Close() error
+	Err() error
+}
+
+// subscriptionConsumer reads CloudWatch Logs subscription filter records from
+// all shards of a Kinesis data stream using enhanced fan-out.
+type subscriptionConsumer struct {
+	log       *logp.Logger
+	metrics   *inputMetrics
+	config    config
+	region    string
+	api       kinesisAPI
+	subscribe func(context.Context, *kinesis.SubscribeToShardInput) (shardStream, error)
+	processor *logProcessor
+
+	checkpoints *shardCheckpoints // Persisted positions of the shards, nil if not persisted.
+	consumerARN string
+
+	mu      sync.Mutex
+	started map[string]bool
+	wg      sync.WaitGroup
+}
+
+func newSubscriptionConsumer(log *logp.Logger, metrics *inputMetrics, region string, config config, svc *kinesis.Client, processor *logProcessor, checkpoints *shardCheckpoints) *subscriptionConsumer {
+	if metrics == nil {
+		metrics = newInputMetrics("", nil)
+	}
+	return &subscriptionConsumer{
+		log:     log,
+		metrics: metrics,
+		config:  config,
+		region:  region,
+		api:     svc,
+		subscribe: func(ctx context.Context, in *kinesis.SubscribeToShardInput) (shardStream, error) {
+			out, err := svc.SubscribeToShard(ctx, in)
+			if err != nil {
+				return nil, err
+			}
+			return out.GetStream(), nil
+		},
+		processor:   processor,
+		checkpoints: checkpoints,
+		started:     map[string]bool{},
+	}
+}
+
+// run registers the stream consumer if needed and consumes all shards of the
+// stream until ctx is cancelled.
+func (c *subscriptionConsumer) run(ctx context.Context) error {
+	consumerARN, err := c.ensureStreamConsumer(ctx)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	c.consumerARN = consumerARN
+
+	shards, err := c.listShards(ctx)
+	if err != nil {
+		return err
+	}
+
+	initial := &kinesistypes.StartingPosition{Type: kinesistypes.ShardIteratorTypeTrimHorizon}
+	if c.config.StartPosition == "end" {
+		initial = &kinesistypes.StartingPosition{Type: kinesistypes.ShardIteratorTypeLatest}
+	}
+	for _, shardID := range shards {
+		c.startShard(ctx, shardID, initial)
+	}
+	c.wg.Wait()
+	return nil
+}
+
+func (c *subscriptionConsumer) ensureStreamConsumer(ctx context.Context) (string, error) {
+	var consumerARN string
+	desc, err := c.api.DescribeStreamConsumer(ctx, &kinesis.DescribeStreamConsumerInput{
+		StreamARN:    awssdk.String(c.config.Subscription.StreamARN),
+		ConsumerName: awssdk.String(c.config.Subscription.ConsumerName),
+	})
+	c.metrics.apiCallsTotal.Inc()
+	var errNotFound *kinesistypes.ResourceNotFoundException
+	switch {
+	case errors.As(err, &errNotFound):
+		c.log.Infof("Registering stream consumer %s", c.config.Subscription.ConsumerName)
+		reg, err := c.api.RegisterStreamConsumer(ctx, &kinesis.RegisterStreamConsumerInput{
+			StreamARN:    awssdk.String(c.config.Subscription.StreamARN),
+			ConsumerName: awssdk.String(c.config.Subscription.ConsumerName),
+		})
+		c.metrics.apiCallsTotal.Inc()
+		if err != nil {
+			return "", fmt.Errorf("failed to register stream consumer: %w", err)
+		}
+		consumerARN = awssdk.ToString(reg.Consumer.ConsumerARN)
+		if reg.Consumer.ConsumerStatus == kinesistypes.ConsumerStatusActive {
+			return consumerARN, nil
+		}
+	case err != nil:
+		return "", fmt.Errorf("failed to describe stream consumer: %w", err)
+	default:
+		consumerARN = awssdk.ToString(desc.ConsumerDescription.ConsumerARN)
+		if desc.ConsumerDescription.ConsumerStatus == kinesistypes.ConsumerStatusActive {
+			return consumerARN, nil
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(consumerPollInterval):
+		}
+		desc, err := c.api.DescribeStreamConsumer(ctx, &kinesis.DescribeStreamConsumerInput{
+			ConsumerARN: awssdk.String(consumerARN),
+		})
+		c.metrics.apiCallsTotal.Inc()
+		if err != nil {
+			return "", fmt.Errorf("failed to describe stream consumer: %w", err)
+		}
+		if desc.ConsumerDescription.ConsumerStatus == kinesistypes.ConsumerStatusActive {
+			return consumerARN, nil
+		}
+	}
+}
+
+func (c *subscriptionConsumer) listShards(ctx context.Context) ([]string, error) {
+	var shards []string
+	in := &kinesis.ListShardsInput{StreamARN: awssdk.String(c.config.Subscription.StreamARN)}
+	for {
+		out, err := c.api.ListShards(ctx, in)
+		c.metrics.apiCallsTotal.Inc()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list shards: %w", err)
+		}
+		for _, shard := range out.Shards {
+			shards = append(shards, awssdk.ToString(shard.ShardId))
+		}
+		if out.NextToken == nil {
+			return shards, nil
+		}
+		// StreamARN must not be set together with NextToken.
+		in = &kinesis.ListShardsInput{NextToken: out.NextToken}
+	}
+}
+
+// startShard starts consuming a shard unless it is already being consumed.
+// The shard is read after its persisted sequence number if any, from pos
+// otherwise.
+func (c *subscriptionConsumer) startShard(ctx context.Context, shardID string, pos *kinesistypes.StartingPosition) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.started[shardID] {
+		return
+	}
+	c.started[shardID] = true
+
+	if c.checkpoints != nil {
+		if seq := c.checkpoints.get(shardID); seq != "" {
+			c.log.Debugw("Resuming shard", "shard_id", shardID, "sequence_number", seq)
+			pos = &kinesistypes.StartingPosition{
+				Type:           kinesistypes.ShardIteratorTypeAfterSequenceNumber,
+				SequenceNumber: awssdk.String(seq),
+			}
+		}
+	}
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.consumeShard(ctx, shardID, pos)
+	}()
+}
+
+// consumeShard subscribes to a shard until the shard is closed or ctx is
+// cancelled. Subscriptions expire after 5 minutes, so the consumer
+// resubscribes from the last continuation sequence number.
+func (c *subscriptionConsumer) consumeShard(ctx context.Context, shardID string, pos *kinesistypes.StartingPosition) {
+	log := c.log.With("shard_id", shardID)
+	b := backoff.NewEqualJitterBackoff(ctx.Done(), time.Second, time.Minute)
+	for ctx.Err() == nil {
+		stream, err := c.subscribe(ctx, &kinesis.SubscribeToShardInput{
+			ConsumerARN:      awssdk.String(c.consumerARN),
+			ShardId:          awssdk.String(shardID),
+			StartingPosition: pos,
+		})
+		c.metrics.apiCallsTotal.Inc()
+		if err != nil {
+			log.Warnw("Failed to subscribe to shard", "error", err)
+			b.Wait()
+			continue
+		}
+
+		next, closed, err := c.readShardStream(ctx, shardID, stream)
+		if closed {
+			log.Debug("Shard is closed")
+			return
+		}
+		if next != "" {
+			pos = &kinesistypes.StartingPosition{
+				Type:           kinesistypes.ShardIteratorTypeAfterSequenceNumber,
+				SequenceNumber: awssdk.String(next),
+			}
+			b.Reset()
+		}
+		if err != nil {
+			log.Warnw("Shard subscription failed", "error", err)
+			b.Wait()
+		}
+	}
+}
+
+// readShardStream processes the events of a shard subscription. It returns the
+// last continuation sequence number and whether the shard has been closed.
+func (c *subscriptionConsumer) readShardStream(ctx context.Context, shardID string, stream shardStream) (next string, closed bool, err error) {
+	defer stream.Close()
+	for {
+		select {
+		case <-ctx.Done():
+			return next, false, nil
+		case ev, ok := <-stream.Events():
+			if !ok {
+				return next, false, stream.Err()
+			}
+			shardEvent, ok := ev.(*kinesistypes.SubscribeToShardEventStreamMemberSubscribeToShardEvent)
+			if !ok {
+				continue
+			}
+			for _, record := range shardEvent.Value.Records {
+				c.processRecord(shardID, record)
+			}
+			if shardEvent.Value.ContinuationSequenceNumber == nil {
+				// The shard has been split or merged, continue with its children.
+				for _, child := range shardEvent.Value.ChildShards {
+					c.startShard(ctx, awssdk.ToString(child.ShardId), &kinesistypes.StartingPosition{
+						Type: kinesistypes.ShardIteratorTypeTrimHorizon,
+					})
+				}
+				return next, true, nil
+			}
+			next = *shardEvent.Value.ContinuationSequenceNumber
+		}
+	}
+}
+
+func (c *subscriptionConsumer) processRecord(shardID string, record kinesistypes.Record) {
+	c.metrics.kinesisRecordsReceivedTotal.Inc()
+	msg, err := decodeSubscriptionRecord(record.Data)
+	if err != nil {
+		c.log.Warnw("Failed to decode subscription filter record", "error", err,
+			"sequence_number", awssdk.ToString(record.SequenceNumber))
+		return
+	}
+	if msg.MessageType != "DATA_MESSAGE" {
+		return
+	}
+
+	// Subscription filter records do not contain the ingestion time, use the
+	// time the record was added to the stream instead.
+	ingestionTime := time.Now()
+	if record.ApproximateArrivalTimestamp != nil {
+		ingestionTime = *record.ApproximateArrivalTimestamp
+	}
+	logEvents := make([]types.FilteredLogEvent, 0, len(msg.LogEvents))
+	for _, e := range msg.LogEvents {
+		logEvents = append(logEvents, types.FilteredLogEvent{
+			EventId:       awssdk.String(e.ID),
+			Timestamp:     awssdk.Int64(e.Timestamp),
+			Message:       awssdk.String(e.Message),
+			LogStreamName: awssdk.String(msg.LogStream),
+			IngestionTime: awssdk.Int64(unixMsFromTime(ingestionTime)),
+		})
+	}
+	c.metrics.logEventsReceivedTotal.Add(uint64(len(logEvents)))
+	c.processor.processLogEvents(logEvents, msg.LogGroup, c.region, shardCheckpoint{
+		ShardID:        shardID,
+		SequenceNumber: awssdk.ToString(record.SequenceNumber),
+	})
+}
+
+// shardCheckpoint is the position of a shard, the sequence number of the
+// last record whose events are acknowledged.
+type shardCheckpoint struct {
+	ShardID        string `json:"shard_id" struct:"shard_id"`
+	SequenceNumber string `json:"sequence_number" struct:"sequence_number"`
+}
+
+// shardCheckpoints persists the positions of the shards of a stream consumer
+// in the registry.
+type shardCheckpoints struct {
+	log       *logp.Logger
+	keyPrefix string
+
+	mu    sync.Mutex
+	store *statestore.Store
+}
+
+func newShardCheckpoints(log *logp.Logger, store *statestore.Store, config subscriptionConfig) *shardCheckpoints {
+	return &shardCheckpoints{
+		log:       log,
+		keyPrefix: subscriptionStatePrefix + config.StreamARN + "::" + config.ConsumerName + "::",
+		store:     store,
+	}
+}
+
+// get returns the persisted sequence number of a shard, or an empty string
+// if the shard has not been read yet.
+func (s *shardCheckpoints) get(shardID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := s.keyPrefix + shardID
+	if ok, err := s.store.Has(key); err != nil || !ok {
+		return ""
+	}
+	var cp shardCheckpoint
+	if err := s.store.Get(key, &cp); err != nil {
+		s.log.Warnw("Failed to read shard checkpoint, reading the shard from the start position", "shard_id", shardID, "error", err)
+		return ""
+	}
+	return cp.SequenceNumber
+}
+
+// acked persists the positions of the shards of acknowledged events. The
+// events of a shard are acknowledged in order, so the last one wins.
+func (s *shardCheckpoints) acked(privates []interface{}) {
+	last := map[string]shardCheckpoint{}
+	for _, private := range privates {
+		if cp, ok := private.(shardCheckpoint); ok {
+			last[cp.ShardID] = cp
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for shardID, cp := range last {
+		if err := s.store.Set(s.keyPrefix+shardID, cp); err != nil {
+			s.log.Errorw("Failed to persist shard checkpoint", "shard_id", shardID, "error", err)
+		}
+	}
+}
+
+// subscriptionMessage is the payload written by CloudWatch Logs subscription
+// filters.
+type subscriptionMessage struct {
+	MessageType string `json:"messageType"`
+	Owner       string `json:"owner"`
+	LogGroup    string `json:"logGroup"`
+	LogStream   string `json:"logStream"`
+	LogEvents   []struct {
+		ID        string `json:"id"`
+		Timestamp int64  `json:"timestamp"`
+		Message   string `json:"message"`
+	} `json:"logEvents"`
+}
+
+// decodeSubscriptionRecord decodes a subscription filter record. Records are
+// gzip compressed and may additionally be base64 encoded when they have been
+// forwarded by Firehose or a Lambda function.
+func decodeSubscriptionRecord(data []byte) (*subscriptionMessage, error) {
+	if !isGzip(data) {
+		decoded := make([]byte, base64.StdEncoding.DecodedLen(len(data)))
+		n, err := base64.StdEncoding.Decode(decoded, bytes.TrimSpace(data))
+		if err == nil {
+			data = decoded[:n]
+		}
+	}
+	if isGzip(data) {
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gzip record: %w", err)
+		}
+		defer r.Close()
+		data, err = io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress record: %w", err)
+		}
+	}
+
+	var msg subscriptionMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal record: %w", err)
+	}
+	return &msg, nil
+}
+
+func isGzip(data []byte) bool {
+	return len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b
+}
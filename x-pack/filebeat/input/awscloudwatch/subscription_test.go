@@ -0,0 +1,326 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package awscloudwatch
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"sync"
+	"testing"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	kinesistypes "github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/filebeat/beater"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/statestore"
+	"github.com/elastic/beats/v7/libbeat/statestore/storetest"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+const testSubscriptionPayload = `{
+	"messageType": "DATA_MESSAGE",
+	"owner": "123456789012",
+	"logGroup": "/aws/lambda/test",
+	"logStream": "2024/01/01/[$LATEST]abc",
+	"subscriptionFilters": ["filter"],
+	"logEvents": [
+		{"id": "1", "timestamp": 1704067200000, "message": "first"},
+		{"id": "2", "timestamp": 1704067201000, "message": "second"}
+	]
+}`
+
+func gzipData(t *testing.T, data string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(data))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestDecodeSubscriptionRecord(t *testing.T) {
+	compressed := gzipData(t, testSubscriptionPayload)
+	tests := map[string][]byte{
+		"gzip":        compressed,
+		"base64 gzip": []byte(base64.StdEncoding.EncodeToString(compressed)),
+		"plain json":  []byte(testSubscriptionPayload),
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			msg, err := decodeSubscriptionRecord(data)
+			require.NoError(t, err)
+			assert.Equal(t, "DATA_MESSAGE", msg.MessageType)
+			assert.Equal(t, "/aws/lambda/test", msg.LogGroup)
+			require.Len(t, msg.LogEvents, 2)
+			assert.Equal(t, "second", msg.LogEvents[1].Message)
+		})
+	}
+
+	_, err := decodeSubscriptionRecord([]byte("not a record"))
+	assert.Error(t, err)
+}
+
+func TestSubscriptionConfigValidate(t *testing.T) {
+	tests := map[string]struct {
+		config  subscriptionConfig
+		wantErr bool
+	}{
+		"valid": {
+			config: subscriptionConfig{
+				StreamARN:    "arn:aws:kinesis:us-east-1:123456789012:stream/logs",
+				ConsumerName: "filebeat",
+			},
+		},
+		"missing consumer": {
+			config:  subscriptionConfig{StreamARN: "arn:aws:kinesis:us-east-1:123456789012:stream/logs"},
+			wantErr: true,
+		},
+		"not a stream": {
+			config: subscriptionConfig{
+				StreamARN:    "arn:aws:logs:us-east-1:123456789012:log-group:test",
+				ConsumerName: "filebeat",
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := tc.config.Validate()
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestSubscriptionConsumer(t *testing.T) {
+	compressed := gzipData(t, testSubscriptionPayload)
+	control := gzipData(t, `{"messageType": "CONTROL_MESSAGE"}`)
+
+	api := &fakeKinesisAPI{shards: []string{"shard-0"}}
+	streams := map[string][]kinesistypes.SubscribeToShardEvent{
+		"shard-0": {{
+			Records:                    []kinesistypes.Record{{Data: compressed}, {Data: control}},
+			ContinuationSequenceNumber: awssdk.String("10"),
+		}, {
+			ChildShards: []kinesistypes.ChildShard{{ShardId: awssdk.String("shard-1")}},
+		}},
+		"shard-1": {{
+			Records: []kinesistypes.Record{{Data: compressed}},
+		}},
+	}
+
+	var mu sync.Mutex
+	var subscriptions []*kinesis.SubscribeToShardInput
+	publisher := &fakePublisher{}
+	c := &subscriptionConsumer{
+		log:     logp.NewLogger("test"),
+		metrics: newInputMetrics("", nil),
+		config: config{
+			StartPosition: "beginning",
+			Subscription: subscriptionConfig{
+				StreamARN:    "arn:aws:kinesis:us-east-1:123456789012:stream/logs",
+				ConsumerName: "filebeat",
+			},
+		},
+		region: "us-east-1",
+		api:    api,
+		subscribe: func(_ context.Context, in *kinesis.SubscribeToShardInput) (shardStream, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			subscriptions = append(subscriptions, in)
+			return newFakeShardStream(streams[*in.ShardId]), nil
+		},
+		started: map[string]bool{},
+	}
+	c.processor = newLogProcessor(c.log, c.metrics, publisher, context.Background())
+
+	require.NoError(t, c.run(context.Background()))
+
+	assert.True(t, api.registered)
+	assert.EqualValues(t, 3, c.metrics.kinesisRecordsReceivedTotal.Get())
+	require.Len(t, publisher.events, 4)
+	logGroup, _ := publisher.events[0].Fields.GetValue("awscloudwatch.log_group")
+	assert.Equal(t, "/aws/lambda/test", logGroup)
+	assert.Equal(t, time.UnixMilli(1704067200000).UTC(), publisher.events[0].Timestamp)
+
+	require.Len(t, subscriptions, 2)
+	assert.Equal(t, kinesistypes.ShardIteratorTypeTrimHorizon, subscriptions[1].StartingPosition.Type)
+	assert.Equal(t, "arn:aws:kinesis:us-east-1:123456789012:stream/logs/consumer/filebeat", *subscriptions[0].ConsumerARN)
+}
+
+func TestSubscriptionConsumerResumesFromCheckpoint(t *testing.T) {
+	cfg := subscriptionConfig{
+		StreamARN:    "arn:aws:kinesis:us-east-1:123456789012:stream/logs",
+		ConsumerName: "filebeat",
+	}
+	checkpoints := newShardCheckpoints(logp.NewLogger("test"), openTestStore(t), cfg)
+	checkpoints.acked([]interface{}{shardCheckpoint{ShardID: "shard-0", SequenceNumber: "5"}})
+
+	var subscriptions []*kinesis.SubscribeToShardInput
+	c := &subscriptionConsumer{
+		log:     logp.NewLogger("test"),
+		metrics: newInputMetrics("", nil),
+		config:  config{StartPosition: "end", Subscription: cfg},
+		api:     &fakeKinesisAPI{shards: []string{"shard-0", "shard-1"}},
+		subscribe: func(_ context.Context, in *kinesis.SubscribeToShardInput) (shardStream, error) {
+			subscriptions = append(subscriptions, in)
+			// Close the shard.
+			return newFakeShardStream([]kinesistypes.SubscribeToShardEvent{{}}), nil
+		},
+		checkpoints: checkpoints,
+		started:     map[string]bool{},
+	}
+	c.processor = newLogProcessor(c.log, c.metrics, &fakePublisher{}, context.Background())
+	// Start the shards one at a time to keep the order of the subscriptions.
+	c.consumerARN = "consumer"
+	c.startShard(context.Background(), "shard-0", &kinesistypes.StartingPosition{Type: kinesistypes.ShardIteratorTypeLatest})
+	c.wg.Wait()
+	c.startShard(context.Background(), "shard-1", &kinesistypes.StartingPosition{Type: kinesistypes.ShardIteratorTypeLatest})
+	c.wg.Wait()
+
+	require.Len(t, subscriptions, 2)
+	assert.Equal(t, kinesistypes.ShardIteratorTypeAfterSequenceNumber, subscriptions[0].StartingPosition.Type)
+	assert.Equal(t, "5", awssdk.ToString(subscriptions[0].StartingPosition.SequenceNumber))
+	assert.Equal(t, kinesistypes.ShardIteratorTypeLatest, subscriptions[1].StartingPosition.Type)
+}
+
+func TestShardCheckpoints(t *testing.T) {
+	store := openTestStore(t)
+	cfg := subscriptionConfig{
+		StreamARN:    "arn:aws:kinesis:us-east-1:123456789012:stream/logs",
+		ConsumerName: "filebeat",
+	}
+	checkpoints := newShardCheckpoints(logp.NewLogger("test"), store, cfg)
+	assert.Empty(t, checkpoints.get("shard-0"))
+
+	checkpoints.acked([]interface{}{
+		shardCheckpoint{ShardID: "shard-0", SequenceNumber: "1"},
+		nil, // Event published in polling mode.
+		shardCheckpoint{ShardID: "shard-1", SequenceNumber: "7"},
+		shardCheckpoint{ShardID: "shard-0", SequenceNumber: "2"},
+	})
+	assert.Equal(t, "2", checkpoints.get("shard-0"))
+	assert.Equal(t, "7", checkpoints.get("shard-1"))
+
+	// The positions are persisted per consumer.
+	cfg.ConsumerName = "other"
+	assert.Empty(t, newShardCheckpoints(logp.NewLogger("test"), store, cfg).get("shard-0"))
+}
+
+type testInputStore struct {
+	registry *statestore.Registry
+}
+
+func openTestStatestore() beater.StateStore {
+	return &testInputStore{
+		registry: statestore.NewRegistry(storetest.NewMemoryStoreBackend()),
+	}
+}
+
+func (s *testInputStore) Close() {
+	_ = s.registry.Close()
+}
+
+func (s *testInputStore) Access() (*statestore.Store, error) {
+	return s.registry.Get("filebeat")
+}
+
+func (s *testInputStore) CleanupInterval() time.Duration {
+	return 24 * time.Hour
+}
+
+func openTestStore(t *testing.T) *statestore.Store {
+	t.Helper()
+	stateStore := openTestStatestore()
+	t.Cleanup(stateStore.(*testInputStore).Close)
+	store, err := stateStore.Access()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+type fakeKinesisAPI struct {
+	shards     []string
+	registered bool
+}
+
+func (f *fakeKinesisAPI) DescribeStreamConsumer(_ context.Context, in *kinesis.DescribeStreamConsumerInput, _ ...func(*kinesis.Options)) (*kinesis.DescribeStreamConsumerOutput, error) {
+	if !f.registered {
+		return nil, &kinesistypes.ResourceNotFoundException{}
+	}
+	return &kinesis.DescribeStreamConsumerOutput{
+		ConsumerDescription: &kinesistypes.ConsumerDescription{
+			ConsumerARN:    in.ConsumerARN,
+			ConsumerStatus: kinesistypes.ConsumerStatusActive,
+		},
+	}, nil
+}
+
+func (f *fakeKinesisAPI) RegisterStreamConsumer(_ context.Context, in *kinesis.RegisterStreamConsumerInput, _ ...func(*kinesis.Options)) (*kinesis.RegisterStreamConsumerOutput, error) {
+	f.registered = true
+	return &kinesis.RegisterStreamConsumerOutput{
+		Consumer: &kinesistypes.Consumer{
+			ConsumerARN:    awssdk.String(*in.StreamARN + "/consumer/" + *in.ConsumerName),
+			ConsumerStatus: kinesistypes.ConsumerStatusActive,
+		},
+	}, nil
+}
+
+func (f *fakeKinesisAPI) ListShards(context.Context, *kinesis.ListShardsInput, ...func(*kinesis.Options)) (*kinesis.ListShardsOutput, error) {
+	out := &kinesis.ListShardsOutput{}
+	for _, id := range f.shards {
+		out.Shards = append(out.Shards, kinesistypes.Shard{ShardId: awssdk.String(id)})
+	}
+	return out, nil
+}
+
+type fakeShardStream struct {
+	events chan kinesistypes.SubscribeToShardEventStream
+}
+
+func newFakeShardStream(events []kinesistypes.SubscribeToShardEvent) *fakeShardStream {
+	s := &fakeShardStream{events: make(chan kinesistypes.SubscribeToShardEventStream, len(events))}
+	for _, ev := range events {
+		s.events <- &kinesistypes.SubscribeToShardEventStreamMemberSubscribeToShardEvent{Value: ev}
+	}
+	close(s.events)
+	return s
+}
+
+func (s *fakeShardStream) Events() <-chan kinesistypes.SubscribeToShardEventStream { return s.events }
+func (s *fakeShardStream) Close() error                                            { return nil }
+func (s *fakeShardStream) Err() error                                              { return nil }
+
+type fakePublisher struct {
+	mu     sync.Mutex
+	events []beat.Event
+}
+
+func (p *fakePublisher) Publish(event beat.Event) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+}
+
+func (p *fakePublisher) PublishAll(events []beat.Event) {
+	for _, event := range events {
+		p.Publish(event)
+	}
+}
+
+func (p *fakePublisher) Close() error { return nil }
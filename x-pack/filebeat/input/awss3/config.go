@@ -31,6 +31,7 @@ type config struct {
 	SQSWaitTime        time.Duration        `config:"sqs.wait_time"`         // The max duration for which the SQS ReceiveMessage call waits for a message to arrive in the queue before returning.
 	SQSMaxReceiveCount int                  `config:"sqs.max_receive_count"` // The max number of times a message should be received (retried) before deleting it.
 	SQSScript          *scriptConfig        `config:"sqs.notification_parsing_script"`
+	SQSFormat          string               `config:"sqs.notification_format"` // The format of the SQS messages, s3 or crowdstrike_fdr.
 	QueueURL           string               `config:"queue_url"`
 	RegionName         string               `config:"region"`
 	BucketARN          string               `config:"bucket_arn"`
@@ -55,6 +56,7 @@ func defaultConfig() config {
 		BucketListPrefix:   "",
 		SQSWaitTime:        20 * time.Second,
 		SQSMaxReceiveCount: 5,
+		SQSFormat:          notificationFormatS3,
 		NumberOfWorkers:    5,
 		PathStyle:          false,
 	}
@@ -104,6 +106,19 @@ func (c *config) Validate() error {
 			c.APITimeout, c.SQSWaitTime)
 	}
 
+	switch c.SQSFormat {
+	case notificationFormatS3:
+	case notificationFormatFDR:
+		if c.QueueURL == "" {
+			return fmt.Errorf("sqs.notification_format <%v> can only be used with queue_url", c.SQSFormat)
+		}
+		if c.SQSScript != nil {
+			return fmt.Errorf("sqs.notification_format <%v> cannot be used with sqs.notification_parsing_script", c.SQSFormat)
+		}
+	default:
+		return fmt.Errorf("invalid sqs.notification_format <%v>", c.SQSFormat)
+	}
+
 	if c.AWSConfig.FIPSEnabled && c.NonAWSBucketName != "" {
 		return errors.New("fips_enabled cannot be used with a non-AWS S3 bucket")
 	}
@@ -38,6 +38,7 @@ func TestConfig(t *testing.T) {
 			APITimeout:         120 * time.Second,
 			VisibilityTimeout:  300 * time.Second,
 			SQSMaxReceiveCount: 5,
+			SQSFormat:          notificationFormatS3,
 			SQSWaitTime:        20 * time.Second,
 			BucketListInterval: 120 * time.Second,
 			BucketListPrefix:   "",
@@ -220,6 +221,49 @@ func TestConfig(t *testing.T) {
 			expectedErr: "queue_url <https://example.com>, bucket_arn <arn:aws:s3:::aBucket>, access_point_arn <>, non_aws_bucket_name <> cannot be set at the same time",
 			expectedCfg: nil,
 		},
+		{
+			name:           "crowdstrike_fdr notification format",
+			queueURL:       queueURL,
+			s3Bucket:       "",
+			s3AccessPoint:  "",
+			nonAWSS3Bucket: "",
+			config: mapstr.M{
+				"queue_url":               queueURL,
+				"sqs.notification_format": "crowdstrike_fdr",
+			},
+			expectedErr: "",
+			expectedCfg: func(queueURL, s3Bucket, s3AccessPoint, nonAWSS3Bucket string) config {
+				c := makeConfig(queueURL, "", "", "")
+				c.SQSFormat = notificationFormatFDR
+				return c
+			},
+		},
+		{
+			name:           "error on crowdstrike_fdr notification format without queueURL",
+			queueURL:       "",
+			s3Bucket:       s3Bucket,
+			s3AccessPoint:  "",
+			nonAWSS3Bucket: "",
+			config: mapstr.M{
+				"bucket_arn":              s3Bucket,
+				"sqs.notification_format": "crowdstrike_fdr",
+			},
+			expectedErr: "sqs.notification_format <crowdstrike_fdr> can only be used with queue_url",
+			expectedCfg: nil,
+		},
+		{
+			name:           "error on invalid notification format",
+			queueURL:       queueURL,
+			s3Bucket:       "",
+			s3AccessPoint:  "",
+			nonAWSS3Bucket: "",
+			config: mapstr.M{
+				"queue_url":               queueURL,
+				"sqs.notification_format": "gcs",
+			},
+			expectedErr: "invalid sqs.notification_format <gcs>",
+			expectedCfg: nil,
+		},
 		{
 			name:           "error on both queueURL and NonAWSS3Bucket",
 			queueURL:       queueURL,
@@ -0,0 +1,263 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package awss3
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+
+	"github.com/elastic/beats/v7/filebeat/beater"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/statestore"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+const (
+	notificationFormatS3  = "s3"
+	notificationFormatFDR = "crowdstrike_fdr"
+
+	awsS3FDRStatePrefix = "filebeat::aws-s3::fdr::"
+
+	// fdrCheckpointTTL is how long the progress of a manifest is kept.
+	// It matches the longest retention of SQS messages, a manifest is
+	// not delivered again after it.
+	fdrCheckpointTTL = 14 * 24 * time.Hour
+)
+
+// fdrManifest is the SQS notification CrowdStrike Falcon Data Replicator
+// sends for each batch of files written to its bucket.
+type fdrManifest struct {
+	CID        string    `json:"cid"`
+	Timestamp  int64     `json:"timestamp"`
+	FileCount  int       `json:"fileCount"`
+	TotalSize  int64     `json:"totalSize"`
+	Bucket     string    `json:"bucket"`
+	PathPrefix string    `json:"pathPrefix"`
+	Files      []fdrFile `json:"files"`
+}
+
+type fdrFile struct {
+	Path     string `json:"path"`
+	Size     int64  `json:"size"`
+	Checksum string `json:"checksum"`
+}
+
+// parseFDRManifest returns the ID of an FDR manifest and the S3 objects it
+// lists, in the order of their paths. FDR splits a batch in numbered
+// part files, reading them in order keeps the events of the batch in
+// order.
+func parseFDRManifest(body string) (string, []s3EventV2, error) {
+	var m fdrManifest
+	dec := json.NewDecoder(strings.NewReader(body))
+	if err := dec.Decode(&m); err != nil {
+		return "", nil, fmt.Errorf("failed to decode SQS message body as an FDR manifest: %w", err)
+	}
+	switch {
+	case m.Bucket == "":
+		return "", nil, errors.New("the message is an invalid FDR manifest: missing bucket field")
+	case m.Files == nil:
+		return "", nil, errors.New("the message is an invalid FDR manifest: missing files field")
+	case m.FileCount != len(m.Files):
+		return "", nil, fmt.Errorf("the message is an invalid FDR manifest: fileCount is %d but %d files are listed", m.FileCount, len(m.Files))
+	}
+
+	files := append([]fdrFile(nil), m.Files...)
+	sort.SliceStable(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+
+	out := make([]s3EventV2, 0, len(files))
+	for _, f := range files {
+		var e s3EventV2
+		// FDR manifests do not hold the region of the bucket, the
+		// region of the input is used.
+		e.Provider = "aws"
+		e.EventSource = "aws:s3"
+		e.EventName = "ObjectCreated:Put"
+		e.S3.Bucket.Name = m.Bucket
+		e.S3.Bucket.ARN = "arn:aws:s3:::" + m.Bucket
+		e.S3.Object.Key = f.Path
+		out = append(out, e)
+	}
+
+	prefix := m.PathPrefix
+	if prefix == "" && len(files) != 0 {
+		prefix = path.Dir(files[0].Path)
+	}
+	return m.CID + "/" + m.Bucket + "/" + prefix, out, nil
+}
+
+// fdrCheckpoint is the progress of a manifest whose processing failed.
+type fdrCheckpoint struct {
+	// Files are the keys of the files whose events were all published
+	// and acknowledged.
+	Files   []string  `json:"files" struct:"files"`
+	Updated time.Time `json:"updated" struct:"updated"`
+}
+
+// fdrCheckpoints tracks the files of the FDR manifests that were
+// completely processed, so a manifest returned to the queue after an
+// error does not publish the events of these files again.
+type fdrCheckpoints struct {
+	mu     sync.Mutex
+	store  *statestore.Store
+	prefix string
+	log    *logp.Logger
+	now    func() time.Time
+}
+
+func newFDRCheckpoints(log *logp.Logger, stateStore beater.StateStore, queueURL string) (*fdrCheckpoints, error) {
+	store, err := stateStore.Access()
+	if err != nil {
+		return nil, fmt.Errorf("can't access persistent store: %w", err)
+	}
+	c := &fdrCheckpoints{
+		store:  store,
+		prefix: awsS3FDRStatePrefix + queueURL + "::",
+		log:    log,
+		now:    time.Now,
+	}
+	c.cleanUp()
+	return c, nil
+}
+
+// completed returns the keys of the files of a manifest already processed.
+func (c *fdrCheckpoints) completed(manifestID string) map[string]bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var cp fdrCheckpoint
+	key := c.prefix + manifestID
+	if ok, err := c.store.Has(key); err != nil || !ok {
+		return nil
+	}
+	if err := c.store.Get(key, &cp); err != nil {
+		c.log.Warnw("Failed to read FDR manifest checkpoint.", "manifest", manifestID, "error", err)
+		return nil
+	}
+	done := make(map[string]bool, len(cp.Files))
+	for _, f := range cp.Files {
+		done[f] = true
+	}
+	return done
+}
+
+// add records processed files of a manifest.
+func (c *fdrCheckpoints) add(manifestID string, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var cp fdrCheckpoint
+	key := c.prefix + manifestID
+	if ok, _ := c.store.Has(key); ok {
+		if err := c.store.Get(key, &cp); err != nil {
+			c.log.Warnw("Failed to read FDR manifest checkpoint.", "manifest", manifestID, "error", err)
+		}
+	}
+	cp.Files = append(cp.Files, keys...)
+	cp.Updated = c.now()
+	return c.store.Set(key, cp)
+}
+
+// remove drops the progress of a manifest once its message is deleted.
+func (c *fdrCheckpoints) remove(manifestID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := c.prefix + manifestID
+	if ok, err := c.store.Has(key); err != nil || !ok {
+		return err
+	}
+	return c.store.Remove(key)
+}
+
+// cleanUp removes the checkpoints of the manifests that can no longer
+// be delivered.
+func (c *fdrCheckpoints) cleanUp() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var stale []string
+	err := c.store.Each(func(key string, dec statestore.ValueDecoder) (bool, error) {
+		if !strings.HasPrefix(key, c.prefix) {
+			return true, nil
+		}
+		var cp fdrCheckpoint
+		if err := dec.Decode(&cp); err != nil || c.now().Sub(cp.Updated) > fdrCheckpointTTL {
+			stale = append(stale, key)
+		}
+		return true, nil
+	})
+	if err != nil {
+		c.log.Warnw("Failed to list FDR manifest checkpoints.", "error", err)
+		return
+	}
+	for _, key := range stale {
+		if err := c.store.Remove(key); err != nil {
+			c.log.Warnw("Failed to remove stale FDR manifest checkpoint.", "key", key, "error", err)
+		}
+	}
+}
+
+func (c *fdrCheckpoints) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.store.Close()
+}
+
+// processFDRManifest processes the files of an FDR manifest, skipping
+// the ones completed by a previous delivery of the manifest. It returns
+// the ID of the manifest and the keys of the files it completed.
+func (p *sqsS3EventProcessor) processFDRManifest(
+	ctx context.Context,
+	log *logp.Logger,
+	body string,
+	eventCallback func(beat.Event),
+) (string, []string, []finalizerFunc, error) {
+	manifestID, s3Events, err := parseFDRManifest(body)
+	if err != nil {
+		p.log.Debugw("Invalid SQS message body.", "sqs_message_body", body)
+		return "", nil, nil, &nonRetryableError{err}
+	}
+	log = log.With("fdr_manifest", manifestID)
+	log.Debugf("FDR manifest contained %d files.", len(s3Events))
+
+	done := p.fdr.completed(manifestID)
+	var (
+		errs       []error
+		finalizers []finalizerFunc
+		completed  []string
+	)
+	for i, event := range s3Events {
+		key := event.S3.Object.Key
+		if done[key] {
+			log.Debugw("Skipping FDR file completed by a previous delivery of the manifest.", "key", key)
+			continue
+		}
+		s3Processor := p.s3HandlerFactory.Create(ctx, event)
+		if s3Processor == nil {
+			continue
+		}
+		if err := s3Processor.ProcessS3Object(log, eventCallback); err != nil {
+			errs = append(errs, fmt.Errorf(
+				"failed processing FDR file %q in bucket %q (file %d of %d in manifest): %w",
+				key, event.S3.Bucket.Name, i+1, len(s3Events), err))
+			continue
+		}
+		finalizers = append(finalizers, s3Processor.FinalizeS3Object)
+		completed = append(completed, key)
+	}
+	return manifestID, completed, finalizers, multierr.Combine(errs...)
+}
@@ -0,0 +1,137 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package awss3
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+const testFDRManifest = `{
+  "cid": "1234567890abcdef",
+  "timestamp": 1492726639137,
+  "fileCount": 3,
+  "totalSize": 300,
+  "bucket": "cs-prod-cannon",
+  "pathPrefix": "data/f0714ca5",
+  "files": [
+    {"path": "data/f0714ca5/part-00002.gz", "size": 100, "checksum": "c"},
+    {"path": "data/f0714ca5/part-00000.gz", "size": 100, "checksum": "a"},
+    {"path": "data/f0714ca5/part-00001.gz", "size": 100, "checksum": "b"}
+  ]
+}`
+
+func TestParseFDRManifest(t *testing.T) {
+	id, events, err := parseFDRManifest(testFDRManifest)
+	require.NoError(t, err)
+	assert.Equal(t, "1234567890abcdef/cs-prod-cannon/data/f0714ca5", id)
+	require.Len(t, events, 3)
+	for i, e := range events {
+		assert.Equal(t, "cs-prod-cannon", e.S3.Bucket.Name)
+		assert.Equal(t, "data/f0714ca5/part-0000"+string(rune('0'+i))+".gz", e.S3.Object.Key)
+		assert.Equal(t, "aws:s3", e.EventSource)
+	}
+
+	_, _, err = parseFDRManifest(`{"bucket": "b", "fileCount": 2, "files": [{"path": "x"}]}`)
+	assert.ErrorContains(t, err, "fileCount is 2 but 1 files are listed")
+	_, _, err = parseFDRManifest(`{"Records": []}`)
+	assert.ErrorContains(t, err, "missing bucket field")
+}
+
+// fakeFDRHandlers processes the FDR files, failing the ones listed in
+// fail.
+type fakeFDRHandlers struct {
+	fail      map[string]bool
+	processed []string
+}
+
+func (f *fakeFDRHandlers) Create(_ context.Context, obj s3EventV2) s3ObjectHandler {
+	return &fakeFDRHandler{handlers: f, key: obj.S3.Object.Key}
+}
+
+type fakeFDRHandler struct {
+	handlers *fakeFDRHandlers
+	key      string
+}
+
+func (h *fakeFDRHandler) ProcessS3Object(_ *logp.Logger, eventCallback func(e beat.Event)) error {
+	h.handlers.processed = append(h.handlers.processed, h.key)
+	eventCallback(beat.Event{})
+	if h.handlers.fail[h.key] {
+		return errors.New("fake download error")
+	}
+	return nil
+}
+
+func (h *fakeFDRHandler) FinalizeS3Object() error { return nil }
+
+func TestSQSFDRManifestCheckpoints(t *testing.T) {
+	logp.TestingSetup()
+
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	defer ctrl.Finish()
+	mockAPI := NewMockSQSAPI(ctrl)
+
+	checkpoints, err := newFDRCheckpoints(logp.NewLogger(inputName), openTestStatestore(), "https://sqs.us-east-1.amazonaws.com/123/fdr")
+	require.NoError(t, err)
+	defer checkpoints.Close()
+
+	handlers := &fakeFDRHandlers{fail: map[string]bool{"data/f0714ca5/part-00001.gz": true}}
+	p := newSQSS3EventProcessor(logp.NewLogger(inputName), nil, mockAPI, nil, time.Minute, 5, handlers)
+	p.fdr = checkpoints
+
+	body := testFDRManifest
+	id, receipt := "msg-1", "receipt-1"
+	msg := types.Message{Body: &body, MessageId: &id, ReceiptHandle: &receipt}
+
+	// The failed file returns the message to the queue, the other files
+	// are recorded once acknowledged.
+	result := p.ProcessSQS(ctx, &msg, func(beat.Event) {})
+	require.Error(t, result.processingErr)
+	assert.Equal(t, []string{"data/f0714ca5/part-00000.gz", "data/f0714ca5/part-00001.gz", "data/f0714ca5/part-00002.gz"}, handlers.processed)
+	assert.Equal(t, 3, result.eventCount)
+	result.Done()
+	assert.Equal(t, map[string]bool{"data/f0714ca5/part-00000.gz": true, "data/f0714ca5/part-00002.gz": true}, checkpoints.completed(result.manifestID))
+
+	// The next delivery only processes the failed file and deletes the
+	// message along with the checkpoint.
+	handlers.processed = nil
+	handlers.fail = nil
+	mockAPI.EXPECT().DeleteMessage(gomock.Any(), gomock.Eq(&msg)).Return(nil)
+	result = p.ProcessSQS(ctx, &msg, func(beat.Event) {})
+	require.NoError(t, result.processingErr)
+	assert.Equal(t, []string{"data/f0714ca5/part-00001.gz"}, handlers.processed)
+	result.Done()
+	assert.Empty(t, checkpoints.completed(result.manifestID))
+}
+
+func TestFDRCheckpointsCleanUp(t *testing.T) {
+	logp.TestingSetup()
+	store := openTestStatestore()
+
+	checkpoints, err := newFDRCheckpoints(logp.NewLogger(inputName), store, "queue")
+	require.NoError(t, err)
+	checkpoints.now = func() time.Time { return time.Now().Add(-fdrCheckpointTTL - time.Hour) }
+	require.NoError(t, checkpoints.add("old", []string{"a"}))
+	checkpoints.now = time.Now
+	require.NoError(t, checkpoints.add("recent", []string{"b"}))
+	checkpoints.Close()
+
+	checkpoints, err = newFDRCheckpoints(logp.NewLogger(inputName), store, "queue")
+	require.NoError(t, err)
+	defer checkpoints.Close()
+	assert.Empty(t, checkpoints.completed("old"))
+	assert.Equal(t, map[string]bool{"b": true}, checkpoints.completed("recent"))
+}
@@ -53,7 +53,7 @@ func (im *s3InputManager) Create(cfg *conf.C) (v2.Input, error) {
 	}
 
 	if config.QueueURL != "" {
-		return newSQSReaderInput(config, awsConfig), nil
+		return newSQSReaderInput(config, awsConfig, im.store), nil
 	}
 
 	if config.BucketARN != "" || config.AccessPointARN != "" || config.NonAWSBucketName != "" {
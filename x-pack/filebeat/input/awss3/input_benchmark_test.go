@@ -219,7 +219,7 @@ func benchmarkInputSQS(t *testing.T, workerCount int) testing.BenchmarkResult {
 
 		config := makeBenchmarkConfig(t)
 		config.NumberOfWorkers = workerCount
-		sqsReader := newSQSReaderInput(config, aws.Config{})
+		sqsReader := newSQSReaderInput(config, aws.Config{}, nil)
 		sqsReader.log = log.Named("sqs")
 		sqsReader.pipeline = newFakePipeline()
 		sqsReader.metrics = newInputMetrics("test_id", monitoring.NewRegistry(), workerCount)
@@ -244,7 +244,7 @@ func TestInputRunSQSOnLocalstack(t *testing.T) {
 	})
 
 	// Initialize s3Input with the test config
-	s3Input := newSQSReaderInput(config, awsCfg)
+	s3Input := newSQSReaderInput(config, awsCfg, nil)
 	// Run S3 Input with desired context
 	var errGroup errgroup.Group
 	errGroup.Go(func() error {
@@ -147,7 +147,7 @@ func TestRegionSelection(t *testing.T) {
 				RegionName: test.regionName,
 				AWSConfig:  awscommon.ConfigAWS{Endpoint: test.endpoint},
 			}
-			in := newSQSReaderInput(config, awssdk.Config{})
+			in := newSQSReaderInput(config, awssdk.Config{}, nil)
 			inputCtx := v2.Context{
 				Logger: logp.NewLogger("awss3_test"),
 				ID:     "test_id",
@@ -14,6 +14,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 
+	"github.com/elastic/beats/v7/filebeat/beater"
 	v2 "github.com/elastic/beats/v7/filebeat/input/v2"
 	"github.com/elastic/beats/v7/libbeat/beat"
 	"github.com/elastic/elastic-agent-libs/logp"
@@ -28,6 +29,10 @@ type sqsReaderInput struct {
 	log        *logp.Logger
 	metrics    *inputMetrics
 
+	// store persists the progress of FDR manifests.
+	store beater.StateStore
+	fdr   *fdrCheckpoints
+
 	// The Beats pipeline, used to create clients for event publication when
 	// creating the worker goroutines.
 	pipeline beat.Pipeline
@@ -45,10 +50,11 @@ type sqsReaderInput struct {
 }
 
 // Simple wrapper to handle creation of internal channels
-func newSQSReaderInput(config config, awsConfig awssdk.Config) *sqsReaderInput {
+func newSQSReaderInput(config config, awsConfig awssdk.Config, store beater.StateStore) *sqsReaderInput {
 	return &sqsReaderInput{
 		config:           config,
 		awsConfig:        awsConfig,
+		store:            store,
 		workRequestChan:  make(chan struct{}, config.NumberOfWorkers),
 		workResponseChan: make(chan types.Message),
 	}
@@ -124,12 +130,16 @@ func (in *sqsReaderInput) setup(
 	return nil
 }
 
-// Release internal resources created during setup (currently just metrics).
-// This is its own function so tests can handle the run loop in isolation.
+// Release internal resources created during setup (metrics and the FDR
+// checkpoints store). This is its own function so tests can handle the run
+// loop in isolation.
 func (in *sqsReaderInput) cleanup() {
 	if in.metrics != nil {
 		in.metrics.Close()
 	}
+	if in.fdr != nil {
+		in.fdr.Close()
+	}
 }
 
 // Create the main goroutines for the input (workers, message count monitor)
@@ -279,7 +289,15 @@ func (in *sqsReaderInput) createEventProcessor() (sqsProcessor, error) {
 	if err != nil {
 		return nil, err
 	}
-	return newSQSS3EventProcessor(in.log.Named("sqs_s3_event"), in.metrics, in.sqs, script, in.config.VisibilityTimeout, in.config.SQSMaxReceiveCount, s3EventHandlerFactory), nil
+	p := newSQSS3EventProcessor(in.log.Named("sqs_s3_event"), in.metrics, in.sqs, script, in.config.VisibilityTimeout, in.config.SQSMaxReceiveCount, s3EventHandlerFactory)
+	if in.config.SQSFormat == notificationFormatFDR {
+		in.fdr, err = newFDRCheckpoints(in.log.Named("fdr"), in.store, in.config.QueueURL)
+		if err != nil {
+			return nil, err
+		}
+		p.fdr = in.fdr
+	}
+	return p, nil
 }
 
 // Read all pending requests and return their count. If block is true,
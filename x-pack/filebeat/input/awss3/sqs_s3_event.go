@@ -91,6 +91,10 @@ type sqsS3EventProcessor struct {
 	warnOnce             sync.Once
 	metrics              *inputMetrics
 	script               *script
+
+	// fdr tracks the progress of CrowdStrike FDR manifests, it is set
+	// when the queue holds FDR manifests instead of S3 notifications.
+	fdr *fdrCheckpoints
 }
 
 func newSQSS3EventProcessor(
@@ -128,6 +132,12 @@ type sqsProcessingResult struct {
 	// Finalizer callbacks for the returned S3 events, invoked via
 	// finalizeS3Objects after all events are acknowledged.
 	finalizers []finalizerFunc
+
+	// manifestID identifies the FDR manifest of the message and
+	// completedKeys are the files of the manifest completed by this
+	// delivery.
+	manifestID    string
+	completedKeys []string
 }
 
 type finalizerFunc func() error
@@ -161,10 +171,21 @@ func (p *sqsS3EventProcessor) ProcessSQS(ctx context.Context, msg *types.Message
 	}
 
 	eventCount := 0
-	finalizers, processingErr := p.processS3Events(ctx, log, *msg.Body, func(e beat.Event) {
+	countingCallback := func(e beat.Event) {
 		eventCount++
 		eventCallback(e)
-	})
+	}
+	var (
+		finalizers    []finalizerFunc
+		processingErr error
+		manifestID    string
+		completedKeys []string
+	)
+	if p.fdr != nil {
+		manifestID, completedKeys, finalizers, processingErr = p.processFDRManifest(ctx, log, *msg.Body, countingCallback)
+	} else {
+		finalizers, processingErr = p.processS3Events(ctx, log, *msg.Body, countingCallback)
+	}
 
 	return sqsProcessingResult{
 		msg:             msg,
@@ -174,6 +195,8 @@ func (p *sqsS3EventProcessor) ProcessSQS(ctx context.Context, msg *types.Message
 		keepaliveCancel: keepaliveCancel,
 		processingErr:   processingErr,
 		finalizers:      finalizers,
+		manifestID:      manifestID,
+		completedKeys:   completedKeys,
 	}
 }
 
@@ -198,6 +221,7 @@ func (r sqsProcessingResult) Done() {
 			// tests don't have to initialize irrelevant fields
 			p.metrics.sqsMessagesDeletedTotal.Inc()
 		}
+		r.removeCheckpoint()
 		// SQS message finished and deleted, finalize s3 objects
 		if finalizeErr := r.finalizeS3Objects(); finalizeErr != nil {
 			p.log.Errorf("failed finalizing message from SQS queue (manual cleanup is required): %v", finalizeErr.Error())
@@ -221,10 +245,19 @@ func (r sqsProcessingResult) Done() {
 			return
 		}
 		p.metrics.sqsMessagesDeletedTotal.Inc()
+		r.removeCheckpoint()
 		p.log.Errorf("failed processing SQS message (message was deleted): %w", processingErr)
 		return
 	}
 
+	// All the events of the message are acknowledged, record the FDR
+	// files that were completed so the next delivery skips them.
+	if p.fdr != nil && r.manifestID != "" {
+		if err := p.fdr.add(r.manifestID, r.completedKeys); err != nil {
+			p.log.Errorf("failed recording the completed files of FDR manifest %q (they may be reprocessed): %v", r.manifestID, err)
+		}
+	}
+
 	// An error that may be resolved by letting the visibility timeout
 	// expire thereby putting the message back on SQS. If a dead letter
 	// queue is enabled then the message will eventually placed on the DLQ
@@ -233,6 +266,18 @@ func (r sqsProcessingResult) Done() {
 	p.log.Errorf("failed processing SQS message (it will return to queue after visibility timeout): %w", processingErr)
 }
 
+// removeCheckpoint drops the progress of the FDR manifest of a deleted
+// message.
+func (r sqsProcessingResult) removeCheckpoint() {
+	p := r.processor
+	if p.fdr == nil || r.manifestID == "" {
+		return
+	}
+	if err := p.fdr.remove(r.manifestID); err != nil {
+		p.log.Warnf("failed removing the checkpoint of FDR manifest %q: %v", r.manifestID, err)
+	}
+}
+
 func (p *sqsS3EventProcessor) keepalive(ctx context.Context, log *logp.Logger, msg *types.Message) {
 	t := time.NewTicker(p.sqsVisibilityTimeout / 2)
 	defer t.Stop()
@@ -93,7 +93,7 @@ func TestSQSReceiver(t *testing.T) {
 				})
 
 		// Execute sqsReader and verify calls/state.
-		sqsReader := newSQSReaderInput(config{NumberOfWorkers: workerCount}, aws.Config{})
+		sqsReader := newSQSReaderInput(config{NumberOfWorkers: workerCount}, aws.Config{}, nil)
 		sqsReader.log = logger
 		sqsReader.sqs = mockSQS
 		sqsReader.metrics = newInputMetrics("", nil, 0)
@@ -141,7 +141,7 @@ func TestSQSReceiver(t *testing.T) {
 			}).AnyTimes()
 
 		// Execute SQSReader and verify calls/state.
-		sqsReader := newSQSReaderInput(config{NumberOfWorkers: workerCount}, aws.Config{})
+		sqsReader := newSQSReaderInput(config{NumberOfWorkers: workerCount}, aws.Config{}, nil)
 		sqsReader.log = logp.NewLogger(inputName)
 		sqsReader.sqs = mockSQS
 		sqsReader.msgHandler = mockMsgHandler
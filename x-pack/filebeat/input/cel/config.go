@@ -162,6 +162,9 @@ func (c retryConfig) getWaitMax() time.Duration {
 type rateLimitConfig struct {
 	Limit *float64 `config:"limit"`
 	Burst *int     `config:"burst"`
+
+	// SharedKey is the key of a rate limit shared by all inputs using it.
+	SharedKey string `config:"shared_key"`
 }
 
 func (c rateLimitConfig) Validate() error {
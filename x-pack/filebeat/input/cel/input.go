@@ -47,6 +47,7 @@ import (
 	"github.com/elastic/beats/v7/libbeat/version"
 	"github.com/elastic/beats/v7/x-pack/filebeat/input/internal/httplog"
 	"github.com/elastic/beats/v7/x-pack/filebeat/input/internal/httpmon"
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/internal/ratelimit"
 	"github.com/elastic/elastic-agent-libs/logp"
 	"github.com/elastic/elastic-agent-libs/mapstr"
 	"github.com/elastic/elastic-agent-libs/monitoring"
@@ -152,7 +153,11 @@ func (i input) run(env v2.Context, src *source, cursor map[string]interface{}, p
 		return err
 	}
 
-	limiter := newRateLimiterFromConfig(cfg.Resource)
+	limiter, shared, release, err := newRateLimiterFromConfig(cfg.Resource)
+	if err != nil {
+		return err
+	}
+	defer release()
 
 	patterns, err := regexpsFromConfig(cfg)
 	if err != nil {
@@ -227,6 +232,11 @@ func (i input) run(env v2.Context, src *source, cursor map[string]interface{}, p
 		// Keep track of whether CEL is degraded for this periodic run.
 		var isDegraded bool
 		for {
+			if shared != nil {
+				// Other inputs sharing the rate limit may have
+				// exhausted the quota.
+				waitUntil = shared.PauseUntil(waitUntil)
+			}
 			if wait := time.Until(waitUntil); wait > 0 {
 				// We have a special-case wait for when we have a zero limit.
 				// x/time/rate allow a burst through even when the limit is zero
@@ -938,7 +948,10 @@ func (t userAgentDecorator) RoundTrip(r *http.Request) (*http.Response, error) {
 	return t.Transport.RoundTrip(r)
 }
 
-func newRateLimiterFromConfig(cfg *ResourceConfig) *rate.Limiter {
+// newRateLimiterFromConfig returns the rate limiter of the input. If the
+// rate limit is shared with other inputs, the shared limiter is also returned.
+// The returned release function must be called when the input stops.
+func newRateLimiterFromConfig(cfg *ResourceConfig) (*rate.Limiter, *ratelimit.Limiter, func(), error) {
 	r := rate.Inf
 	b := 1
 	if cfg != nil && cfg.RateLimit != nil {
@@ -949,7 +962,21 @@ func newRateLimiterFromConfig(cfg *ResourceConfig) *rate.Limiter {
 			b = *cfg.RateLimit.Burst
 		}
 	}
-	return rate.NewLimiter(r, b)
+
+	if cfg != nil && cfg.RateLimit != nil && cfg.RateLimit.SharedKey != "" {
+		shared := ratelimit.Get(cfg.RateLimit.SharedKey)
+		if cfg.RateLimit.Limit == nil && cfg.RateLimit.Burst == nil {
+			// Use the settings of the other inputs sharing the limiter.
+			return shared.Limiter, shared, func() {}, nil
+		}
+		release, err := shared.Configure(r, b)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("shared rate limit %q: %w", cfg.RateLimit.SharedKey, err)
+		}
+		return shared.Limiter, shared, release, nil
+	}
+
+	return rate.NewLimiter(r, b), nil, func() {}, nil
 }
 
 func regexpsFromConfig(cfg config) (map[string]*regexp.Regexp, error) {
@@ -23,6 +23,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/icholy/digest"
+	"golang.org/x/time/rate"
 
 	v2 "github.com/elastic/beats/v7/filebeat/input/v2"
 	inputcursor "github.com/elastic/beats/v7/filebeat/input/v2/input-cursor"
@@ -2255,3 +2256,45 @@ func TestRedactor(t *testing.T) {
 		})
 	}
 }
+
+func TestSharedRateLimiter(t *testing.T) {
+	limit := 5.0
+	burst := 2
+	cfg := &ResourceConfig{RateLimit: &rateLimitConfig{Limit: &limit, Burst: &burst, SharedKey: t.Name()}}
+
+	first, shared, release, err := newRateLimiterFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer release()
+	if shared == nil {
+		t.Fatal("expected shared limiter")
+	}
+	second, _, _, err := newRateLimiterFromConfig(&ResourceConfig{RateLimit: &rateLimitConfig{SharedKey: t.Name()}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first != second {
+		t.Error("inputs with the same shared key must use the same limiter")
+	}
+	if first.Limit() != rate.Limit(limit) || first.Burst() != burst {
+		t.Errorf("unexpected shared limit: got limit=%v burst=%d", first.Limit(), first.Burst())
+	}
+
+	conflicting := 10.0
+	_, _, _, err = newRateLimiterFromConfig(&ResourceConfig{RateLimit: &rateLimitConfig{Limit: &conflicting, Burst: &burst, SharedKey: t.Name()}})
+	if err == nil {
+		t.Error("expected error for conflicting shared rate limit settings")
+	}
+	if first.Limit() != rate.Limit(limit) {
+		t.Errorf("conflicting settings changed the shared limit: got limit=%v", first.Limit())
+	}
+
+	own, shared, _, err := newRateLimiterFromConfig(&ResourceConfig{RateLimit: &rateLimitConfig{Limit: &limit}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if shared != nil || own == first {
+		t.Error("inputs without a shared key must use their own limiter")
+	}
+}
@@ -12,6 +12,8 @@ import (
 	"github.com/elastic/beats/v7/x-pack/filebeat/input/entityanalytics"
 	"github.com/elastic/beats/v7/x-pack/filebeat/input/http_endpoint"
 	"github.com/elastic/beats/v7/x-pack/filebeat/input/httpjson"
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/jetstream"
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/ldap"
 	"github.com/elastic/beats/v7/x-pack/filebeat/input/lumberjack"
 	"github.com/elastic/beats/v7/x-pack/filebeat/input/o365audit"
 	"github.com/elastic/beats/v7/x-pack/filebeat/input/salesforce"
@@ -23,6 +25,8 @@ func xpackInputs(info beat.Info, log *logp.Logger, store beater.StateStore) []v2
 		entityanalytics.Plugin(log),
 		http_endpoint.Plugin(),
 		httpjson.Plugin(log, store),
+		jetstream.Plugin(),
+		ldap.Plugin(log, store),
 		o365audit.Plugin(log, store),
 		awss3.Plugin(store),
 		lumberjack.Plugin(),
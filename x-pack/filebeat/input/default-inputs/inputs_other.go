@@ -21,10 +21,14 @@ import (
 	"github.com/elastic/beats/v7/x-pack/filebeat/input/gcs"
 	"github.com/elastic/beats/v7/x-pack/filebeat/input/http_endpoint"
 	"github.com/elastic/beats/v7/x-pack/filebeat/input/httpjson"
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/jetstream"
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/ldap"
 	"github.com/elastic/beats/v7/x-pack/filebeat/input/lumberjack"
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/msgraph"
 	"github.com/elastic/beats/v7/x-pack/filebeat/input/netflow"
 	"github.com/elastic/beats/v7/x-pack/filebeat/input/o365audit"
 	"github.com/elastic/beats/v7/x-pack/filebeat/input/salesforce"
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/sql"
 	"github.com/elastic/beats/v7/x-pack/filebeat/input/streaming"
 	"github.com/elastic/elastic-agent-libs/logp"
 )
@@ -39,11 +43,15 @@ func xpackInputs(info beat.Info, log *logp.Logger, store beater.StateStore) []v2
 		gcs.Plugin(log, store),
 		http_endpoint.Plugin(),
 		httpjson.Plugin(log, store),
+		jetstream.Plugin(),
+		ldap.Plugin(log, store),
+		msgraph.Plugin(log, store),
 		o365audit.Plugin(log, store),
 		awss3.Plugin(store),
-		awscloudwatch.Plugin(),
+		awscloudwatch.Plugin(store),
 		lumberjack.Plugin(),
 		salesforce.Plugin(log, store),
+		sql.Plugin(log, store),
 		streaming.Plugin(log, store),
 		streaming.PluginWebsocketAlias(log, store),
 		netflow.Plugin(log),
@@ -0,0 +1,113 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+package googleworkspace
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/common"
+)
+
+// defaultConfig returns a default configuration.
+func defaultConfig() conf {
+	return conf{
+		Customer:       "my_customer",
+		SyncInterval:   24 * time.Hour,
+		UpdateInterval: 15 * time.Minute,
+	}
+}
+
+// conf contains parameters needed to configure the input.
+type conf struct {
+	// Customer is the Google Workspace customer ID. The
+	// default, "my_customer", refers to the customer of
+	// the delegated account.
+	Customer string `config:"customer"`
+
+	// DelegatedAccount is the email address of the
+	// administrator the service account impersonates.
+	DelegatedAccount string `config:"delegated_account" validate:"required"`
+
+	// CredentialsFile and CredentialsJSON hold the
+	// service account key with domain-wide delegation.
+	CredentialsFile string          `config:"credentials_file"`
+	CredentialsJSON common.JSONBlob `config:"credentials_json"`
+
+	// Dataset specifies the datasets to collect from
+	// the API. It can be ""/"all", "users", or "groups".
+	Dataset string `config:"dataset"`
+
+	// SyncInterval is the time between full
+	// synchronisation operations.
+	SyncInterval time.Duration `config:"sync_interval"`
+
+	// UpdateInterval is the time between
+	// incremental updated. The Directory API can't
+	// list only the changed users and groups, so
+	// an update lists all of them like a full sync.
+	UpdateInterval time.Duration `config:"update_interval"`
+}
+
+var (
+	errInvalidSyncInterval   = errors.New("zero or negative sync_interval")
+	errInvalidUpdateInterval = errors.New("zero or negative update_interval")
+	errSyncBeforeUpdate      = errors.New("sync_interval not longer than update_interval")
+	errMissingCredentials    = errors.New("one of credentials_file or credentials_json must be set")
+)
+
+// Validate runs validation against the config.
+func (c *conf) Validate() error {
+	switch {
+	case c.SyncInterval <= 0:
+		return errInvalidSyncInterval
+	case c.UpdateInterval <= 0:
+		return errInvalidUpdateInterval
+	case c.SyncInterval <= c.UpdateInterval:
+		return errSyncBeforeUpdate
+	}
+	switch strings.ToLower(c.Dataset) {
+	case "", "all", "users", "groups":
+	default:
+		return errors.New("dataset must be 'all', 'users', 'groups' or empty")
+	}
+	switch {
+	case c.CredentialsFile != "":
+		if _, err := os.Stat(c.CredentialsFile); err != nil {
+			return fmt.Errorf("credentials_file is configured, but the file %q cannot be read: %w", c.CredentialsFile, err)
+		}
+	case len(c.CredentialsJSON) == 0:
+		return errMissingCredentials
+	}
+	return nil
+}
+
+// credentials returns the configured service account key.
+func (c *conf) credentials() ([]byte, error) {
+	if len(c.CredentialsJSON) != 0 {
+		return c.CredentialsJSON, nil
+	}
+	return os.ReadFile(c.CredentialsFile)
+}
+
+func (c *conf) wantUsers() bool {
+	switch strings.ToLower(c.Dataset) {
+	case "", "all", "users":
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *conf) wantGroups() bool {
+	switch strings.ToLower(c.Dataset) {
+	case "", "all", "groups":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,559 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// Package googleworkspace provides a user and group identity asset provider
+// for Google Workspace.
+package googleworkspace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2/google"
+	admin "google.golang.org/api/admin/directory/v1"
+	"google.golang.org/api/option"
+
+	v2 "github.com/elastic/beats/v7/filebeat/input/v2"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/entityanalytics/internal/kvstore"
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/entityanalytics/provider"
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/go-concert/ctxtool"
+)
+
+func init() {
+	err := provider.Register(Name, New)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Name of this provider.
+const Name = "google-workspace"
+
+// FullName of this provider, including the input name. Prefer using this
+// value for full context, especially if the input name isn't present in an
+// adjacent log field.
+const FullName = "entity-analytics-" + Name
+
+// googleWorkspaceInput implements the provider.Provider interface.
+type googleWorkspaceInput struct {
+	*kvstore.Manager
+
+	cfg conf
+
+	svc *admin.Service
+
+	metrics *inputMetrics
+	logger  *logp.Logger
+}
+
+// New creates a new instance of a Google Workspace identity provider.
+func New(logger *logp.Logger) (provider.Provider, error) {
+	p := googleWorkspaceInput{
+		cfg: defaultConfig(),
+	}
+	p.Manager = &kvstore.Manager{
+		Logger:    logger,
+		Type:      FullName,
+		Configure: p.configure,
+	}
+
+	return &p, nil
+}
+
+// configure configures this provider using the given configuration.
+func (p *googleWorkspaceInput) configure(cfg *config.C) (kvstore.Input, error) {
+	err := cfg.Unpack(&p.cfg)
+	if err != nil {
+		return nil, fmt.Errorf("unable to unpack %s input config: %w", Name, err)
+	}
+	return p, nil
+}
+
+// Name returns the name of this provider.
+func (p *googleWorkspaceInput) Name() string {
+	return FullName
+}
+
+func (*googleWorkspaceInput) Test(v2.TestContext) error { return nil }
+
+// Run will start data collection on this provider.
+func (p *googleWorkspaceInput) Run(inputCtx v2.Context, store *kvstore.Store, client beat.Client) error {
+	p.logger = inputCtx.Logger.With("provider", Name, "customer", p.cfg.Customer)
+	p.metrics = newMetrics(inputCtx.ID, nil)
+	defer p.metrics.Close()
+
+	lastSyncTime, _ := getLastSync(store)
+	syncWaitTime := time.Until(lastSyncTime.Add(p.cfg.SyncInterval))
+	lastUpdateTime, _ := getLastUpdate(store)
+	updateWaitTime := time.Until(lastUpdateTime.Add(p.cfg.UpdateInterval))
+
+	syncTimer := time.NewTimer(syncWaitTime)
+	updateTimer := time.NewTimer(updateWaitTime)
+
+	var err error
+	p.svc, err = newService(ctxtool.FromCanceller(inputCtx.Cancelation), p.cfg)
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-inputCtx.Cancelation.Done():
+			if !errors.Is(inputCtx.Cancelation.Err(), context.Canceled) {
+				return inputCtx.Cancelation.Err()
+			}
+			return nil
+		case <-syncTimer.C:
+			start := time.Now()
+			if err := p.runFullSync(inputCtx, store, client); err != nil {
+				p.logger.Errorw("Error running full sync", "error", err)
+				p.metrics.syncError.Inc()
+			}
+			p.metrics.syncTotal.Inc()
+			p.metrics.syncProcessingTime.Update(time.Since(start).Nanoseconds())
+
+			syncTimer.Reset(p.cfg.SyncInterval)
+			p.logger.Debugf("Next sync expected at: %v", time.Now().Add(p.cfg.SyncInterval))
+
+			// Reset the update timer and wait the configured interval. If the
+			// update timer has already fired, then drain the timer's channel
+			// before resetting.
+			if !updateTimer.Stop() {
+				<-updateTimer.C
+			}
+			updateTimer.Reset(p.cfg.UpdateInterval)
+			p.logger.Debugf("Next update expected at: %v", time.Now().Add(p.cfg.UpdateInterval))
+		case <-updateTimer.C:
+			start := time.Now()
+			if err := p.runIncrementalUpdate(inputCtx, store, client); err != nil {
+				p.logger.Errorw("Error running incremental update", "error", err)
+				p.metrics.updateError.Inc()
+			}
+			p.metrics.updateTotal.Inc()
+			p.metrics.updateProcessingTime.Update(time.Since(start).Nanoseconds())
+			updateTimer.Reset(p.cfg.UpdateInterval)
+			p.logger.Debugf("Next update expected at: %v", time.Now().Add(p.cfg.UpdateInterval))
+		}
+	}
+}
+
+// newService returns a Directory API service authenticated with the
+// configured service account, impersonating the delegated account.
+func newService(ctx context.Context, cfg conf) (*admin.Service, error) {
+	creds, err := cfg.credentials()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read credentials: %w", err)
+	}
+	jwt, err := google.JWTConfigFromJSON(creds,
+		admin.AdminDirectoryUserReadonlyScope,
+		admin.AdminDirectoryGroupReadonlyScope,
+		admin.AdminDirectoryGroupMemberReadonlyScope,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse credentials: %w", err)
+	}
+	jwt.Subject = cfg.DelegatedAccount
+	return admin.NewService(ctx, option.WithHTTPClient(jwt.Client(ctx)))
+}
+
+// runFullSync performs a full synchronization. It will fetch user and group
+// identities from Google Workspace and publish all known users and groups
+// (regardless if they have been modified) and any deleted users and groups
+// to the given beat.Client.
+func (p *googleWorkspaceInput) runFullSync(inputCtx v2.Context, store *kvstore.Store, client beat.Client) error {
+	p.logger.Debugf("Running full sync...")
+
+	p.logger.Debugf("Opening new transaction...")
+	state, err := newStateStore(store)
+	if err != nil {
+		return fmt.Errorf("unable to begin transaction: %w", err)
+	}
+	p.logger.Debugf("Transaction opened")
+	defer func() { // If commit is successful, call to this close will be no-op.
+		closeErr := state.close(false)
+		if closeErr != nil {
+			p.logger.Errorw("Error rolling back full sync transaction", "error", closeErr)
+		}
+	}()
+
+	ctx := ctxtool.FromCanceller(inputCtx.Cancelation)
+	p.logger.Debugf("Starting fetch...")
+	var deletedUsers []*User
+	if p.cfg.wantUsers() {
+		deletedUsers, err = p.doFetchUsers(ctx, state, true)
+		if err != nil {
+			return err
+		}
+	}
+	var deletedGroups []*Group
+	if p.cfg.wantGroups() {
+		deletedGroups, err = p.doFetchGroups(ctx, state, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(state.users) != 0 || len(state.groups) != 0 || len(deletedUsers) != 0 || len(deletedGroups) != 0 {
+		tracker := kvstore.NewTxTracker(ctx)
+
+		start := time.Now()
+		p.publishMarker(start, start, inputCtx.ID, true, client, tracker)
+		for _, u := range state.users {
+			p.publishUser(u, inputCtx.ID, client, tracker)
+		}
+		for _, u := range deletedUsers {
+			p.publishUser(u, inputCtx.ID, client, tracker)
+		}
+		for _, g := range state.groups {
+			p.publishGroup(g, inputCtx.ID, client, tracker)
+		}
+		for _, g := range deletedGroups {
+			p.publishGroup(g, inputCtx.ID, client, tracker)
+		}
+
+		end := time.Now()
+		p.publishMarker(end, end, inputCtx.ID, false, client, tracker)
+
+		tracker.Wait()
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	state.lastSync = time.Now()
+	err = state.close(true)
+	if err != nil {
+		return fmt.Errorf("unable to commit state: %w", err)
+	}
+
+	return nil
+}
+
+// runIncrementalUpdate will run an incremental update. The process is similar
+// to full synchronization, except only users and groups which have changed
+// (newly discovered, modified, or deleted) will be published. The Directory
+// API has no updatedMin parameter or sync token for users and groups, so all
+// of them are still listed and compared with the stored state.
+func (p *googleWorkspaceInput) runIncrementalUpdate(inputCtx v2.Context, store *kvstore.Store, client beat.Client) error {
+	p.logger.Debugf("Running incremental update...")
+
+	state, err := newStateStore(store)
+	if err != nil {
+		return fmt.Errorf("unable to begin transaction: %w", err)
+	}
+	defer func() { // If commit is successful, call to this close will be no-op.
+		closeErr := state.close(false)
+		if closeErr != nil {
+			p.logger.Errorw("Error rolling back incremental update transaction", "error", closeErr)
+		}
+	}()
+
+	ctx := ctxtool.FromCanceller(inputCtx.Cancelation)
+	var updatedUsers []*User
+	if p.cfg.wantUsers() {
+		updatedUsers, err = p.doFetchUsers(ctx, state, false)
+		if err != nil {
+			return err
+		}
+	}
+	var updatedGroups []*Group
+	if p.cfg.wantGroups() {
+		updatedGroups, err = p.doFetchGroups(ctx, state, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	var tracker *kvstore.TxTracker
+	if len(updatedUsers) != 0 || len(updatedGroups) != 0 {
+		tracker = kvstore.NewTxTracker(ctx)
+		for _, u := range updatedUsers {
+			p.publishUser(u, inputCtx.ID, client, tracker)
+		}
+		for _, g := range updatedGroups {
+			p.publishGroup(g, inputCtx.ID, client, tracker)
+		}
+		tracker.Wait()
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	state.lastUpdate = time.Now()
+	if err = state.close(true); err != nil {
+		return fmt.Errorf("unable to commit state: %w", err)
+	}
+
+	return nil
+}
+
+// doFetchUsers handles fetching user identities from Google Workspace. The
+// Directory API does not provide a change feed, so all users are listed and
+// compared with the stored state; users that are no longer listed are deleted.
+// If fullSync is true, only the deleted users are returned, otherwise all
+// changed users are returned.
+func (p *googleWorkspaceInput) doFetchUsers(ctx context.Context, state *stateStore, fullSync bool) ([]*User, error) {
+	var users []*User
+	seen := make(map[string]bool)
+	err := p.svc.Users.List().Customer(p.cfg.Customer).Pages(ctx, func(page *admin.Users) error {
+		p.logger.Debugf("received batch of %d users from API", len(page.Users))
+		for _, u := range page.Users {
+			seen[u.Id] = true
+			stored, changed := state.storeUser(u)
+			if changed && !fullSync {
+				users = append(users, stored)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	for id := range state.users {
+		if seen[id] {
+			continue
+		}
+		if u := state.deleteUser(id); u != nil {
+			users = append(users, u)
+		}
+	}
+
+	p.logger.Debugf("received %d modified user records from API", len(users))
+	return users, nil
+}
+
+// doFetchGroups handles fetching group identities and their memberships from
+// Google Workspace. As with users, all groups are listed and compared with the
+// stored state. If fullSync is true, only the deleted groups are returned,
+// otherwise all changed groups are returned.
+func (p *googleWorkspaceInput) doFetchGroups(ctx context.Context, state *stateStore, fullSync bool) ([]*Group, error) {
+	var groups []*Group
+	seen := make(map[string]bool)
+	err := p.svc.Groups.List().Customer(p.cfg.Customer).Pages(ctx, func(page *admin.Groups) error {
+		p.logger.Debugf("received batch of %d groups from API", len(page.Groups))
+		for _, g := range page.Groups {
+			members, err := p.doFetchGroupMembers(ctx, g.Id)
+			if err != nil {
+				return err
+			}
+			seen[g.Id] = true
+			stored, changed := state.storeGroup(g, members)
+			if changed && !fullSync {
+				groups = append(groups, stored)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list groups: %w", err)
+	}
+
+	for id := range state.groups {
+		if seen[id] {
+			continue
+		}
+		if g := state.deleteGroup(id); g != nil {
+			groups = append(groups, g)
+		}
+	}
+
+	p.logger.Debugf("received %d modified group records from API", len(groups))
+	return groups, nil
+}
+
+// doFetchGroupMembers returns all members of the group with the given ID.
+func (p *googleWorkspaceInput) doFetchGroupMembers(ctx context.Context, group string) ([]GroupMember, error) {
+	var members []GroupMember
+	err := p.svc.Members.List(group).Pages(ctx, func(page *admin.Members) error {
+		for _, m := range page.Members {
+			members = append(members, GroupMember{
+				ID:    m.Id,
+				Email: m.Email,
+				Role:  m.Role,
+				Type:  m.Type,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get members of group %s: %w", group, err)
+	}
+	return members, nil
+}
+
+// publishMarker will publish a write marker document using the given beat.Client.
+// If start is true, then it will be a start marker, otherwise an end marker.
+func (p *googleWorkspaceInput) publishMarker(ts, eventTime time.Time, inputID string, start bool, client beat.Client, tracker *kvstore.TxTracker) {
+	fields := mapstr.M{}
+	_, _ = fields.Put("labels.identity_source", inputID)
+
+	if start {
+		_, _ = fields.Put("event.action", "started")
+		_, _ = fields.Put("event.start", eventTime)
+	} else {
+		_, _ = fields.Put("event.action", "completed")
+		_, _ = fields.Put("event.end", eventTime)
+	}
+
+	event := beat.Event{
+		Timestamp: ts,
+		Fields:    fields,
+		Private:   tracker,
+	}
+	tracker.Add()
+	if start {
+		p.logger.Debug("Publishing start write marker")
+	} else {
+		p.logger.Debug("Publishing end write marker")
+	}
+
+	client.Publish(event)
+}
+
+// publishUser will publish a user document using the given beat.Client.
+func (p *googleWorkspaceInput) publishUser(u *User, inputID string, client beat.Client, tracker *kvstore.TxTracker) {
+	userDoc := mapstr.M{}
+
+	_, _ = userDoc.Put("labels.identity_source", inputID)
+	putUserFields(userDoc, u.Properties)
+
+	switch u.State {
+	case Deleted:
+		_, _ = userDoc.Put("event.action", "user-deleted")
+	case Discovered:
+		_, _ = userDoc.Put("event.action", "user-discovered")
+	case Modified:
+		_, _ = userDoc.Put("event.action", "user-modified")
+	}
+
+	event := beat.Event{
+		Timestamp: time.Now(),
+		Fields:    userDoc,
+		Private:   tracker,
+	}
+	tracker.Add()
+
+	p.logger.Debugf("Publishing user %q", u.Properties.Id)
+
+	client.Publish(event)
+}
+
+// publishGroup will publish a group document using the given beat.Client.
+func (p *googleWorkspaceInput) publishGroup(g *Group, inputID string, client beat.Client, tracker *kvstore.TxTracker) {
+	groupDoc := mapstr.M{}
+
+	_, _ = groupDoc.Put("labels.identity_source", inputID)
+	putGroupFields(groupDoc, g.Properties)
+	_, _ = groupDoc.Put("members", g.Members)
+	if len(g.MembersAdded) != 0 {
+		_, _ = groupDoc.Put("members_added", g.MembersAdded)
+	}
+	if len(g.MembersRemoved) != 0 {
+		_, _ = groupDoc.Put("members_removed", g.MembersRemoved)
+	}
+
+	switch g.State {
+	case Deleted:
+		_, _ = groupDoc.Put("event.action", "group-deleted")
+	case Discovered:
+		_, _ = groupDoc.Put("event.action", "group-discovered")
+	case Modified:
+		_, _ = groupDoc.Put("event.action", "group-modified")
+	}
+
+	event := beat.Event{
+		Timestamp: time.Now(),
+		Fields:    groupDoc,
+		Private:   tracker,
+	}
+	tracker.Add()
+
+	p.logger.Debugf("Publishing group %q", g.Properties.Id)
+
+	client.Publish(event)
+}
+
+// putUserFields maps the Directory API user resource to the user fields
+// of the document.
+func putUserFields(doc mapstr.M, u *admin.User) {
+	_, _ = doc.Put("user.id", u.Id)
+	if u.PrimaryEmail != "" {
+		_, _ = doc.Put("user.email", u.PrimaryEmail)
+		if _, domain, ok := strings.Cut(u.PrimaryEmail, "@"); ok {
+			_, _ = doc.Put("user.domain", domain)
+		}
+	}
+	if u.Name != nil && u.Name.FullName != "" {
+		_, _ = doc.Put("user.full_name", u.Name.FullName)
+	}
+
+	_, _ = doc.Put("google_workspace.user.is_admin", u.IsAdmin)
+	_, _ = doc.Put("google_workspace.user.is_delegated_admin", u.IsDelegatedAdmin)
+	_, _ = doc.Put("google_workspace.user.is_enrolled_in_2sv", u.IsEnrolledIn2Sv)
+	_, _ = doc.Put("google_workspace.user.is_enforced_in_2sv", u.IsEnforcedIn2Sv)
+	_, _ = doc.Put("google_workspace.user.suspended", u.Suspended)
+	_, _ = doc.Put("google_workspace.user.archived", u.Archived)
+	if u.SuspensionReason != "" {
+		_, _ = doc.Put("google_workspace.user.suspension_reason", u.SuspensionReason)
+	}
+	if u.OrgUnitPath != "" {
+		_, _ = doc.Put("google_workspace.user.org_unit_path", u.OrgUnitPath)
+	}
+	if u.CustomerId != "" {
+		_, _ = doc.Put("google_workspace.user.customer_id", u.CustomerId)
+	}
+	if len(u.Aliases) != 0 {
+		_, _ = doc.Put("google_workspace.user.aliases", u.Aliases)
+	}
+	if t, ok := parseTime(u.CreationTime); ok {
+		_, _ = doc.Put("google_workspace.user.creation_time", t)
+	}
+	if t, ok := parseTime(u.LastLoginTime); ok {
+		_, _ = doc.Put("google_workspace.user.last_login_time", t)
+	}
+}
+
+// putGroupFields maps the Directory API group resource to the group fields
+// of the document.
+func putGroupFields(doc mapstr.M, g *admin.Group) {
+	_, _ = doc.Put("group.id", g.Id)
+	if g.Name != "" {
+		_, _ = doc.Put("group.name", g.Name)
+	}
+	if g.Email != "" {
+		_, _ = doc.Put("google_workspace.group.email", g.Email)
+		if _, domain, ok := strings.Cut(g.Email, "@"); ok {
+			_, _ = doc.Put("group.domain", domain)
+		}
+	}
+
+	if g.Description != "" {
+		_, _ = doc.Put("google_workspace.group.description", g.Description)
+	}
+	_, _ = doc.Put("google_workspace.group.direct_members_count", g.DirectMembersCount)
+	_, _ = doc.Put("google_workspace.group.admin_created", g.AdminCreated)
+	if len(g.Aliases) != 0 {
+		_, _ = doc.Put("google_workspace.group.aliases", g.Aliases)
+	}
+}
+
+// parseTime parses a Directory API timestamp. Users that never logged in
+// have a zero Unix time as their last login time, which is not a valid time.
+func parseTime(s string) (time.Time, bool) {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil || t.Unix() <= 0 {
+		return time.Time{}, false
+	}
+	return t, true
+}
@@ -0,0 +1,261 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package googleworkspace
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	admin "google.golang.org/api/admin/directory/v1"
+	"google.golang.org/api/option"
+
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/entityanalytics/internal/kvstore"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// directory is a minimal fake of the Directory API.
+type directory struct {
+	mu      sync.Mutex
+	users   []*admin.User
+	groups  []*admin.Group
+	members map[string][]*admin.Member
+}
+
+func (d *directory) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var body any
+	switch p := r.URL.Path; {
+	case strings.HasSuffix(p, "/users"):
+		body = admin.Users{Users: d.users}
+	case strings.HasSuffix(p, "/groups"):
+		body = admin.Groups{Groups: d.groups}
+	case strings.HasSuffix(p, "/members"):
+		id := strings.TrimSuffix(p, "/members")
+		id = id[strings.LastIndex(id, "/")+1:]
+		body = admin.Members{Members: d.members[id]}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	//nolint:errcheck // ignore
+	json.NewEncoder(w).Encode(body)
+}
+
+func TestGoogleWorkspaceDoFetch(t *testing.T) {
+	dbFilename := t.Name() + ".db"
+	store := testSetupStore(t, dbFilename)
+	t.Cleanup(func() {
+		testCleanupStore(store, dbFilename)
+	})
+
+	dir := &directory{
+		users: []*admin.User{
+			{Id: "u1", PrimaryEmail: "alice@example.com", Etag: "1"},
+			{Id: "u2", PrimaryEmail: "bob@example.com", Etag: "1"},
+		},
+		groups: []*admin.Group{
+			{Id: "g1", Name: "Engineering", Email: "eng@example.com", Etag: "1"},
+		},
+		members: map[string][]*admin.Member{
+			"g1": {{Id: "u1", Email: "alice@example.com", Role: "OWNER", Type: "USER"}},
+		},
+	}
+	srv := httptest.NewServer(dir)
+	t.Cleanup(srv.Close)
+
+	ctx := context.Background()
+	svc, err := admin.NewService(ctx, option.WithEndpoint(srv.URL), option.WithHTTPClient(srv.Client()))
+	if err != nil {
+		t.Fatalf("failed to create service: %v", err)
+	}
+	a := googleWorkspaceInput{
+		cfg:    defaultConfig(),
+		svc:    svc,
+		logger: logp.L(),
+	}
+
+	// Initial full sync discovers all entities and returns no deletions.
+	ss, err := newStateStore(store)
+	if err != nil {
+		t.Fatalf("unexpected error making state store: %v", err)
+	}
+	deletedUsers, err := a.doFetchUsers(ctx, ss, true)
+	if err != nil {
+		t.Fatalf("unexpected error fetching users: %v", err)
+	}
+	deletedGroups, err := a.doFetchGroups(ctx, ss, true)
+	if err != nil {
+		t.Fatalf("unexpected error fetching groups: %v", err)
+	}
+	if len(deletedUsers) != 0 || len(deletedGroups) != 0 {
+		t.Errorf("unexpected deletions on first sync: users=%d groups=%d", len(deletedUsers), len(deletedGroups))
+	}
+	if len(ss.users) != 2 || len(ss.groups) != 1 {
+		t.Errorf("unexpected state: users=%d groups=%d", len(ss.users), len(ss.groups))
+	}
+	if err = ss.close(true); err != nil {
+		t.Fatalf("unexpected error closing state store: %v", err)
+	}
+
+	// Modify a user, delete a user and change group membership.
+	dir.mu.Lock()
+	dir.users = []*admin.User{{Id: "u1", PrimaryEmail: "alice@example.com", Etag: "2"}}
+	dir.members["g1"] = []*admin.Member{{Id: "u3", Email: "carol@example.com", Role: "MEMBER", Type: "USER"}}
+	dir.mu.Unlock()
+
+	ss, err = newStateStore(store)
+	if err != nil {
+		t.Fatalf("unexpected error making state store: %v", err)
+	}
+	users, err := a.doFetchUsers(ctx, ss, false)
+	if err != nil {
+		t.Fatalf("unexpected error fetching users: %v", err)
+	}
+	groups, err := a.doFetchGroups(ctx, ss, false)
+	if err != nil {
+		t.Fatalf("unexpected error fetching groups: %v", err)
+	}
+
+	gotUsers := make(map[string]State)
+	for _, u := range users {
+		gotUsers[u.Properties.Id] = u.State
+	}
+	wantUsers := map[string]State{"u1": Modified, "u2": Deleted}
+	if !cmp.Equal(gotUsers, wantUsers) {
+		t.Errorf("unexpected user changes:\n--- want\n+++ got\n%s", cmp.Diff(wantUsers, gotUsers))
+	}
+
+	if len(groups) != 1 {
+		t.Fatalf("unexpected number of changed groups: got:%d want:1", len(groups))
+	}
+	g := groups[0]
+	if g.State != Modified {
+		t.Errorf("unexpected group state: got:%s want:%s", g.State, Modified)
+	}
+	wantAdded := []GroupMember{{ID: "u3", Email: "carol@example.com", Role: "MEMBER", Type: "USER"}}
+	if !cmp.Equal(g.MembersAdded, wantAdded) {
+		t.Errorf("unexpected members added:\n--- want\n+++ got\n%s", cmp.Diff(wantAdded, g.MembersAdded))
+	}
+	wantRemoved := []GroupMember{{ID: "u1", Email: "alice@example.com", Role: "OWNER", Type: "USER"}}
+	if !cmp.Equal(g.MembersRemoved, wantRemoved) {
+		t.Errorf("unexpected members removed:\n--- want\n+++ got\n%s", cmp.Diff(wantRemoved, g.MembersRemoved))
+	}
+	if err = ss.close(true); err != nil {
+		t.Fatalf("unexpected error closing state store: %v", err)
+	}
+
+	// A further update with no changes publishes nothing.
+	ss, err = newStateStore(store)
+	if err != nil {
+		t.Fatalf("unexpected error making state store: %v", err)
+	}
+	defer ss.close(false)
+	if len(ss.users) != 1 {
+		t.Errorf("unexpected number of stored users: got:%d want:1", len(ss.users))
+	}
+	users, err = a.doFetchUsers(ctx, ss, false)
+	if err != nil {
+		t.Fatalf("unexpected error fetching users: %v", err)
+	}
+	groups, err = a.doFetchGroups(ctx, ss, false)
+	if err != nil {
+		t.Fatalf("unexpected error fetching groups: %v", err)
+	}
+	if len(users) != 0 || len(groups) != 0 {
+		t.Errorf("unexpected changes: users=%d groups=%d", len(users), len(groups))
+	}
+}
+
+func testSetupStore(t *testing.T, path string) *kvstore.Store {
+	t.Helper()
+
+	store, err := kvstore.NewStore(logp.L(), path, 0644)
+	if err != nil {
+		t.Fatalf("unexpected error making store: %v", err)
+	}
+	return store
+}
+
+func testCleanupStore(store *kvstore.Store, path string) {
+	_ = store.Close()
+	_ = os.Remove(path)
+}
+
+func TestPutFields(t *testing.T) {
+	user := mapstr.M{}
+	putUserFields(user, &admin.User{
+		Id:              "108976543210987654321",
+		PrimaryEmail:    "carol@example.com",
+		Name:            &admin.UserName{FullName: "Carol Smith"},
+		IsAdmin:         true,
+		IsEnrolledIn2Sv: true,
+		OrgUnitPath:     "/Engineering",
+		CreationTime:    "2023-06-01T10:00:00.000Z",
+		LastLoginTime:   "1970-01-01T00:00:00.000Z",
+		Etag:            `"etag"`,
+		HashFunction:    "SHA-1",
+	})
+	wantUser := mapstr.M{
+		"user": mapstr.M{
+			"id":        "108976543210987654321",
+			"email":     "carol@example.com",
+			"domain":    "example.com",
+			"full_name": "Carol Smith",
+		},
+		"google_workspace": mapstr.M{
+			"user": mapstr.M{
+				"is_admin":           true,
+				"is_delegated_admin": false,
+				"is_enrolled_in_2sv": true,
+				"is_enforced_in_2sv": false,
+				"suspended":          false,
+				"archived":           false,
+				"org_unit_path":      "/Engineering",
+				"creation_time":      time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+	if diff := cmp.Diff(wantUser, user); diff != "" {
+		t.Errorf("unexpected user fields (-want +got):\n%s", diff)
+	}
+
+	group := mapstr.M{}
+	putGroupFields(group, &admin.Group{
+		Id:                 "03x8tuhj0ggo8bs",
+		Email:              "engineering@example.com",
+		Name:               "Engineering",
+		DirectMembersCount: 2,
+		Etag:               `"etag"`,
+	})
+	wantGroup := mapstr.M{
+		"group": mapstr.M{
+			"id":     "03x8tuhj0ggo8bs",
+			"name":   "Engineering",
+			"domain": "example.com",
+		},
+		"google_workspace": mapstr.M{
+			"group": mapstr.M{
+				"email":                "engineering@example.com",
+				"direct_members_count": int64(2),
+				"admin_created":        false,
+			},
+		},
+	}
+	if diff := cmp.Diff(wantGroup, group); diff != "" {
+		t.Errorf("unexpected group fields (-want +got):\n%s", diff)
+	}
+}
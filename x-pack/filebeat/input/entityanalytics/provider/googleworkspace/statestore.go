@@ -0,0 +1,321 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+package googleworkspace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	admin "google.golang.org/api/admin/directory/v1"
+
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/entityanalytics/internal/kvstore"
+)
+
+var (
+	usersBucket  = []byte("users")
+	groupsBucket = []byte("groups")
+	stateBucket  = []byte("state")
+
+	lastSyncKey   = []byte("last_sync")
+	lastUpdateKey = []byte("last_update")
+)
+
+//go:generate stringer -type State
+//go:generate go-licenser -license Elastic
+type State int
+
+const (
+	Discovered State = iota + 1
+	Modified
+	Deleted
+)
+
+type User struct {
+	Properties *admin.User `json:"properties"`
+	State      State       `json:"state"`
+}
+
+type Group struct {
+	Properties *admin.Group  `json:"properties"`
+	Members    []GroupMember `json:"members"`
+	State      State         `json:"state"`
+
+	// MembersAdded and MembersRemoved are the membership
+	// changes since the group was last stored. They are
+	// not persisted.
+	MembersAdded   []GroupMember `json:"-"`
+	MembersRemoved []GroupMember `json:"-"`
+}
+
+// GroupMember is a member of a group. Members can be users or groups.
+type GroupMember struct {
+	ID    string `json:"id"`
+	Email string `json:"email,omitempty"`
+	Role  string `json:"role,omitempty"`
+	Type  string `json:"type,omitempty"`
+}
+
+// stateStore wraps a kvstore.Transaction and provides convenience methods for
+// accessing and store relevant data within the kvstore database.
+type stateStore struct {
+	tx *kvstore.Transaction
+
+	// lastSync and lastUpdate are the times of the first update
+	// or sync operation of users/groups.
+	lastSync   time.Time
+	lastUpdate time.Time
+	users      map[string]*User
+	groups     map[string]*Group
+
+	// deletedUsers and deletedGroups hold the IDs of
+	// entities that must be removed from the store.
+	deletedUsers  []string
+	deletedGroups []string
+}
+
+// newStateStore creates a new instance of stateStore. It will open a new write
+// transaction on the kvstore and load values from the database. Since this
+// opens a write transaction, only one instance of stateStore may be created
+// at a time. The close function must be called to release the transaction lock
+// on the kvstore database.
+func newStateStore(store *kvstore.Store) (*stateStore, error) {
+	tx, err := store.BeginTx(true)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open state store transaction: %w", err)
+	}
+
+	s := stateStore{
+		users:  make(map[string]*User),
+		groups: make(map[string]*Group),
+		tx:     tx,
+	}
+
+	err = s.tx.Get(stateBucket, lastSyncKey, &s.lastSync)
+	if err != nil && !errIsItemNotFound(err) {
+		return nil, fmt.Errorf("unable to get last sync time from state: %w", err)
+	}
+	err = s.tx.Get(stateBucket, lastUpdateKey, &s.lastUpdate)
+	if err != nil && !errIsItemNotFound(err) {
+		return nil, fmt.Errorf("unable to get last update time from state: %w", err)
+	}
+
+	err = s.tx.ForEach(usersBucket, func(key, value []byte) error {
+		var u User
+		err = json.Unmarshal(value, &u)
+		if err != nil {
+			return fmt.Errorf("unable to unmarshal user from state: %w", err)
+		}
+		if u.Properties == nil {
+			return fmt.Errorf("did not get user properties from state: %s", value)
+		}
+		s.users[u.Properties.Id] = &u
+
+		return nil
+	})
+	if err != nil && !errIsItemNotFound(err) {
+		return nil, fmt.Errorf("unable to get users from state: %w", err)
+	}
+
+	err = s.tx.ForEach(groupsBucket, func(key, value []byte) error {
+		var g Group
+		err = json.Unmarshal(value, &g)
+		if err != nil {
+			return fmt.Errorf("unable to unmarshal group from state: %w", err)
+		}
+		if g.Properties == nil {
+			return fmt.Errorf("did not get group properties from state: %s", value)
+		}
+		s.groups[g.Properties.Id] = &g
+
+		return nil
+	})
+	if err != nil && !errIsItemNotFound(err) {
+		return nil, fmt.Errorf("unable to get groups from state: %w", err)
+	}
+
+	return &s, nil
+}
+
+// storeUser stores a user. If the user does not exist in the store, then the
+// user will be marked as discovered. Otherwise, the user will be marked as
+// modified if its etag has changed. changed will be returned true if the
+// record is updated in any way.
+func (s *stateStore) storeUser(u *admin.User) (_ *User, changed bool) {
+	stored, ok := s.users[u.Id]
+	if !ok {
+		curr := &User{Properties: u, State: Discovered}
+		s.users[u.Id] = curr
+		return curr, true
+	}
+	changed = stored.Properties.Etag != u.Etag
+	stored.Properties = u
+	if changed {
+		stored.State = Modified
+	}
+	return stored, changed
+}
+
+// storeGroup stores a group and its members. If the group does not exist in
+// the store, then the group will be marked as discovered. Otherwise, the group
+// will be marked as modified if its etag or its membership has changed, and
+// the membership changes are recorded in the returned Group. changed will be
+// returned true if the record is updated in any way.
+func (s *stateStore) storeGroup(g *admin.Group, members []GroupMember) (_ *Group, changed bool) {
+	stored, ok := s.groups[g.Id]
+	if !ok {
+		curr := &Group{Properties: g, Members: members, State: Discovered, MembersAdded: members}
+		s.groups[g.Id] = curr
+		return curr, true
+	}
+	stored.MembersAdded, stored.MembersRemoved = membershipChanges(stored.Members, members)
+	changed = stored.Properties.Etag != g.Etag || len(stored.MembersAdded) != 0 || len(stored.MembersRemoved) != 0
+	stored.Properties = g
+	stored.Members = members
+	if changed {
+		stored.State = Modified
+	}
+	return stored, changed
+}
+
+// deleteUser marks the user with the given ID as deleted and removes it from
+// the store.
+func (s *stateStore) deleteUser(id string) *User {
+	u, ok := s.users[id]
+	if !ok {
+		return nil
+	}
+	u.State = Deleted
+	delete(s.users, id)
+	s.deletedUsers = append(s.deletedUsers, id)
+	return u
+}
+
+// deleteGroup marks the group with the given ID as deleted and removes it
+// from the store.
+func (s *stateStore) deleteGroup(id string) *Group {
+	g, ok := s.groups[id]
+	if !ok {
+		return nil
+	}
+	g.State = Deleted
+	g.MembersAdded = nil
+	g.MembersRemoved = g.Members
+	delete(s.groups, id)
+	s.deletedGroups = append(s.deletedGroups, id)
+	return g
+}
+
+// membershipChanges returns the members that are in curr but not in prev and
+// the members that are in prev but not in curr.
+func membershipChanges(prev, curr []GroupMember) (added, removed []GroupMember) {
+	seen := make(map[string]bool, len(prev))
+	for _, m := range prev {
+		seen[m.ID] = true
+	}
+	for _, m := range curr {
+		if !seen[m.ID] {
+			added = append(added, m)
+		}
+		delete(seen, m.ID)
+	}
+	for _, m := range prev {
+		if seen[m.ID] {
+			removed = append(removed, m)
+		}
+	}
+	return added, removed
+}
+
+// close will close out the stateStore. If commit is true, the staged values on the
+// stateStore will be set in the kvstore database, and the transaction will be
+// committed. Otherwise, all changes will be discarded and the transaction will
+// be rolled back. The stateStore must NOT be used after close is called, rather,
+// a new stateStore should be created.
+func (s *stateStore) close(commit bool) (err error) {
+	if !commit {
+		return s.tx.Rollback()
+	}
+
+	// Fallback in case one of the statements below fails. If everything is
+	// successful and Commit is called, then this call to Rollback will be a no-op.
+	defer func() {
+		if err == nil {
+			return
+		}
+		rollbackErr := s.tx.Rollback()
+		if rollbackErr != nil {
+			err = fmt.Errorf("multiple errors during statestore close: %w", errors.Join(err, rollbackErr))
+		}
+	}()
+
+	if !s.lastSync.IsZero() {
+		err = s.tx.Set(stateBucket, lastSyncKey, &s.lastSync)
+		if err != nil {
+			return fmt.Errorf("unable to save last sync time to state: %w", err)
+		}
+	}
+	if !s.lastUpdate.IsZero() {
+		err = s.tx.Set(stateBucket, lastUpdateKey, &s.lastUpdate)
+		if err != nil {
+			return fmt.Errorf("unable to save last update time to state: %w", err)
+		}
+	}
+
+	for key, value := range s.users {
+		err = s.tx.Set(usersBucket, []byte(key), value)
+		if err != nil {
+			return fmt.Errorf("unable to save user %q to state: %w", key, err)
+		}
+	}
+	for _, key := range s.deletedUsers {
+		err = s.tx.Delete(usersBucket, []byte(key))
+		if err != nil && !errIsItemNotFound(err) {
+			return fmt.Errorf("unable to delete user %q from state: %w", key, err)
+		}
+	}
+	for key, value := range s.groups {
+		err = s.tx.Set(groupsBucket, []byte(key), value)
+		if err != nil {
+			return fmt.Errorf("unable to save group %q to state: %w", key, err)
+		}
+	}
+	for _, key := range s.deletedGroups {
+		err = s.tx.Delete(groupsBucket, []byte(key))
+		if err != nil && !errIsItemNotFound(err) {
+			return fmt.Errorf("unable to delete group %q from state: %w", key, err)
+		}
+	}
+
+	return s.tx.Commit()
+}
+
+// getLastSync retrieves the last full synchronization time from the kvstore
+// database. If the value doesn't exist, a zero time.Time is returned.
+func getLastSync(store *kvstore.Store) (time.Time, error) {
+	var t time.Time
+	err := store.RunTransaction(false, func(tx *kvstore.Transaction) error {
+		return tx.Get(stateBucket, lastSyncKey, &t)
+	})
+
+	return t, err
+}
+
+// getLastUpdate retrieves the last incremental update time from the kvstore
+// database. If the value doesn't exist, a zero time.Time is returned.
+func getLastUpdate(store *kvstore.Store) (time.Time, error) {
+	var t time.Time
+	err := store.RunTransaction(false, func(tx *kvstore.Transaction) error {
+		return tx.Get(stateBucket, lastUpdateKey, &t)
+	})
+
+	return t, err
+}
+
+// errIsItemNotFound returns true if the error represents an item not found
+// error (bucket not found or key not found).
+func errIsItemNotFound(err error) bool {
+	return errors.Is(err, kvstore.ErrBucketNotFound) || errors.Is(err, kvstore.ErrKeyNotFound)
+}
@@ -46,8 +46,9 @@ type conf struct {
 	OktaToken  string `config:"okta_token" validate:"required"`
 
 	// Dataset specifies the datasets to collect from
-	// the API. It can be ""/"all", "users", or
-	// "devices".
+	// the API. It can be ""/"all", "users", "devices"
+	// or "groups". Groups are only collected when
+	// "groups" is specified.
 	Dataset string `config:"dataset"`
 	// EnrichWith specifies the additional data that
 	// will be used to enrich user data. It can include
@@ -183,9 +184,9 @@ func (c *conf) Validate() error {
 		return errSyncBeforeUpdate
 	}
 	switch strings.ToLower(c.Dataset) {
-	case "", "all", "users", "devices":
+	case "", "all", "users", "devices", "groups":
 	default:
-		return errors.New("dataset must be 'all', 'users', 'devices' or empty")
+		return errors.New("dataset must be 'all', 'users', 'devices', 'groups' or empty")
 	}
 
 	if c.Tracer == nil {
@@ -220,3 +221,7 @@ func (c *conf) wantDevices() bool {
 		return false
 	}
 }
+
+func (c *conf) wantGroups() bool {
+	return strings.ToLower(c.Dataset) == "groups"
+}
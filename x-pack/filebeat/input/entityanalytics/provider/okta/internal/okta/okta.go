@@ -70,6 +70,12 @@ type Provider struct {
 type Group struct {
 	ID      string         `json:"id"`
 	Profile map[string]any `json:"profile"`
+
+	// The following fields are only populated by the groups API.
+	Type                  string     `json:"type,omitempty"`
+	Created               *time.Time `json:"created,omitempty"`
+	LastUpdated           *time.Time `json:"lastUpdated,omitempty"`
+	LastMembershipUpdated *time.Time `json:"lastMembershipUpdated,omitempty"`
 }
 
 // Factor is an Okta identity factor description.
@@ -270,6 +276,48 @@ func GetUserGroupDetails(ctx context.Context, cli *http.Client, host, key, user
 	return getDetails[Group](ctx, cli, u, key, true, OmitNone, lim, window, log)
 }
 
+// GetGroupDetails returns Okta group details using the groups API endpoint. host is the
+// Okta user domain and key is the API token to use for the query. If group is not empty,
+// details for the specific group are returned, otherwise a list of all groups is returned.
+//
+// See GetUserDetails for details of the query and rate limit parameters.
+//
+// See https://developer.okta.com/docs/api/openapi/okta-management/management/tag/Group/#tag/Group/operation/listGroups for details.
+func GetGroupDetails(ctx context.Context, cli *http.Client, host, key, group string, query url.Values, lim *rate.Limiter, window time.Duration, log *logp.Logger) ([]Group, http.Header, error) {
+	const endpoint = "/api/v1/groups"
+
+	u := &url.URL{
+		Scheme:   "https",
+		Host:     host,
+		Path:     path.Join(endpoint, group),
+		RawQuery: query.Encode(),
+	}
+	return getDetails[Group](ctx, cli, u, key, group == "", OmitNone, lim, window, log)
+}
+
+// GetGroupMembers returns Okta user details for the members of the provided group
+// using the list group members API. host is the Okta user domain and key is the API
+// token to use for the query. group must not be empty.
+//
+// See GetUserDetails for details of the query and rate limit parameters.
+//
+// See https://developer.okta.com/docs/api/openapi/okta-management/management/tag/Group/#tag/Group/operation/listGroupUsers for details.
+func GetGroupMembers(ctx context.Context, cli *http.Client, host, key, group string, query url.Values, omit Response, lim *rate.Limiter, window time.Duration, log *logp.Logger) ([]User, http.Header, error) {
+	const endpoint = "/api/v1/groups"
+
+	if group == "" {
+		return nil, nil, errors.New("no group specified")
+	}
+
+	u := &url.URL{
+		Scheme:   "https",
+		Host:     host,
+		Path:     path.Join(endpoint, group, "users"),
+		RawQuery: query.Encode(),
+	}
+	return getDetails[User](ctx, cli, u, key, true, omit, lim, window, log)
+}
+
 // GetGroupRoles returns Okta group roles using the groups API endpoint. host is the
 // Okta user domain and key is the API token to use for the query. group must not be empty.
 //
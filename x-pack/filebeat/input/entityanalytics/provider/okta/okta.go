@@ -327,10 +327,15 @@ func (p *oktaInput) runFullSync(inputCtx v2.Context, store *kvstore.Store, clien
 	if err != nil {
 		return err
 	}
+	deletedGroups, err := p.doFetchGroups(ctx, state, true)
+	if err != nil {
+		return err
+	}
 
 	wantUsers := p.cfg.wantUsers()
 	wantDevices := p.cfg.wantDevices()
-	if (len(state.users) != 0 && wantUsers) || (len(state.devices) != 0 && wantDevices) {
+	wantGroups := p.cfg.wantGroups()
+	if (len(state.users) != 0 && wantUsers) || (len(state.devices) != 0 && wantDevices) || ((len(state.groups) != 0 || len(deletedGroups) != 0) && wantGroups) {
 		tracker := kvstore.NewTxTracker(ctx)
 
 		start := time.Now()
@@ -345,6 +350,14 @@ func (p *oktaInput) runFullSync(inputCtx v2.Context, store *kvstore.Store, clien
 				p.publishDevice(d, state, inputCtx.ID, client, tracker)
 			}
 		}
+		if wantGroups {
+			for _, g := range state.groups {
+				p.publishGroup(g, inputCtx.ID, client, tracker)
+			}
+			for _, g := range deletedGroups {
+				p.publishGroup(g, inputCtx.ID, client, tracker)
+			}
+		}
 
 		end := time.Now()
 		p.publishMarker(end, end, inputCtx.ID, false, client, tracker)
@@ -391,9 +404,13 @@ func (p *oktaInput) runIncrementalUpdate(inputCtx v2.Context, store *kvstore.Sto
 	if err != nil {
 		return err
 	}
+	updatedGroups, err := p.doFetchGroups(ctx, state, false)
+	if err != nil {
+		return err
+	}
 
 	var tracker *kvstore.TxTracker
-	if len(updatedUsers) != 0 || len(updatedDevices) != 0 {
+	if len(updatedUsers) != 0 || len(updatedDevices) != 0 || len(updatedGroups) != 0 {
 		tracker = kvstore.NewTxTracker(ctx)
 		for _, u := range updatedUsers {
 			p.publishUser(u, state, inputCtx.ID, client, tracker)
@@ -401,6 +418,9 @@ func (p *oktaInput) runIncrementalUpdate(inputCtx v2.Context, store *kvstore.Sto
 		for _, d := range updatedDevices {
 			p.publishDevice(d, state, inputCtx.ID, client, tracker)
 		}
+		for _, g := range updatedGroups {
+			p.publishGroup(g, inputCtx.ID, client, tracker)
+		}
 		tracker.Wait()
 	}
 
@@ -667,6 +687,122 @@ func (p *oktaInput) doFetchDevices(ctx context.Context, state *stateStore, fullS
 	return devices, nil
 }
 
+// doFetchGroups handles fetching groups and their members from Okta. If fullSync is
+// true, then any existing query will be ignored, forcing a full synchronization from
+// Okta, and the groups that no longer exist are returned marked as deleted.
+// Otherwise, only groups that have been modified or had their membership changed
+// since the last fetch are requested and returned.
+func (p *oktaInput) doFetchGroups(ctx context.Context, state *stateStore, fullSync bool) ([]*Group, error) {
+	if !p.cfg.wantGroups() {
+		p.logger.Debugf("Skipping group collection from API: dataset=%s", p.cfg.Dataset)
+		return nil, nil
+	}
+
+	var (
+		query url.Values
+		err   error
+	)
+
+	// Get group changes.
+	if !fullSync && state.nextGroups != "" {
+		query, err = url.ParseQuery(state.nextGroups)
+		if err != nil {
+			p.logger.Warnf("failed to parse next query: %v", err)
+		}
+	}
+
+	var (
+		groups      []*Group
+		seen        = make(map[string]bool)
+		lastUpdated time.Time
+	)
+	for {
+		batch, h, err := okta.GetGroupDetails(ctx, p.client, p.cfg.OktaDomain, p.cfg.OktaToken, "", query, p.lim, p.cfg.LimitWindow, p.logger)
+		if err != nil {
+			p.logger.Debugf("received %d groups from API", len(groups))
+			return nil, err
+		}
+		p.logger.Debugf("received batch of %d groups from API", len(batch))
+
+		for _, g := range batch {
+			members, err := p.doFetchGroupMembers(ctx, g.ID)
+			if err != nil {
+				return nil, err
+			}
+			sg := state.storeGroup(g, members)
+			seen[g.ID] = true
+			if !fullSync {
+				groups = append(groups, sg)
+			}
+			for _, t := range []*time.Time{g.LastUpdated, g.LastMembershipUpdated} {
+				if t != nil && t.After(lastUpdated) {
+					lastUpdated = *t
+				}
+			}
+		}
+
+		next, err := okta.Next(h)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			p.logger.Debugf("received %d groups from API", len(groups))
+			return groups, err
+		}
+		query = next
+	}
+
+	if fullSync {
+		// Groups that were not seen during a full synchronization
+		// have been deleted.
+		for id := range state.groups {
+			if !seen[id] {
+				groups = append(groups, state.deleteGroup(id))
+			}
+		}
+	}
+
+	// Prepare query for next update. This is any group that was updated
+	// or had its membership changed at or after the last change we saw
+	// this round. See the equivalent in doFetchUsers for the rationale
+	// of using ge.
+	if !lastUpdated.IsZero() {
+		ts := lastUpdated.Format(okta.ISO8601)
+		query = url.Values{}
+		query.Add("search", fmt.Sprintf(`lastUpdated ge "%s" or lastMembershipUpdated ge "%s"`, ts, ts))
+		state.nextGroups = query.Encode()
+	}
+
+	p.logger.Debugf("received %d groups from API", len(groups))
+	return groups, nil
+}
+
+// doFetchGroupMembers returns all members of the group with the given ID.
+func (p *oktaInput) doFetchGroupMembers(ctx context.Context, group string) ([]okta.User, error) {
+	const omit = okta.OmitCredentials | okta.OmitCredentialsLinks | okta.OmitTransitioningToStatus
+
+	var (
+		members []okta.User
+		query   url.Values
+	)
+	for {
+		batch, h, err := okta.GetGroupMembers(ctx, p.client, p.cfg.OktaDomain, p.cfg.OktaToken, group, query, omit, p.lim, p.cfg.LimitWindow, p.logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get members of group %s: %w", group, err)
+		}
+		members = append(members, batch...)
+
+		next, err := okta.Next(h)
+		if err != nil {
+			if err == io.EOF {
+				return members, nil
+			}
+			return nil, fmt.Errorf("failed to get members of group %s: %w", group, err)
+		}
+		query = next
+	}
+}
+
 func cloneURLValues(a url.Values) url.Values {
 	b := make(url.Values, len(a))
 	for k, v := range a {
@@ -774,3 +910,42 @@ func (p *oktaInput) publishDevice(d *Device, state *stateStore, inputID string,
 
 	client.Publish(event)
 }
+
+// publishGroup will publish a group document using the given beat.Client.
+func (p *oktaInput) publishGroup(g *Group, inputID string, client beat.Client, tracker *kvstore.TxTracker) {
+	groupDoc := mapstr.M{}
+
+	_, _ = groupDoc.Put("okta", g.Group)
+	_, _ = groupDoc.Put("labels.identity_source", inputID)
+	_, _ = groupDoc.Put("group.id", g.ID)
+	if name, ok := g.Profile["name"].(string); ok {
+		_, _ = groupDoc.Put("group.name", name)
+	}
+	_, _ = groupDoc.Put("members", g.Members)
+	if len(g.MembersAdded) != 0 {
+		_, _ = groupDoc.Put("members_added", g.MembersAdded)
+	}
+	if len(g.MembersRemoved) != 0 {
+		_, _ = groupDoc.Put("members_removed", g.MembersRemoved)
+	}
+
+	switch g.State {
+	case Deleted:
+		_, _ = groupDoc.Put("event.action", "group-deleted")
+	case Discovered:
+		_, _ = groupDoc.Put("event.action", "group-discovered")
+	case Modified:
+		_, _ = groupDoc.Put("event.action", "group-modified")
+	}
+
+	event := beat.Event{
+		Timestamp: time.Now(),
+		Fields:    groupDoc,
+		Private:   tracker,
+	}
+	tracker.Add()
+
+	p.logger.Debugf("Publishing group %q", g.ID)
+
+	client.Publish(event)
+}
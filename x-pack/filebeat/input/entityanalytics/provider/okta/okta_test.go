@@ -280,3 +280,122 @@ func wantCount(n int, want bool) int {
 	}
 	return n
 }
+
+func TestOktaDoFetchGroups(t *testing.T) {
+	logp.TestingSetup()
+
+	const dbFilename = "TestOktaDoFetchGroups.db"
+	store := testSetupStore(t, dbFilename)
+	t.Cleanup(func() {
+		testCleanupStore(store, dbFilename)
+	})
+
+	groups := map[string]string{
+		"group1": `{"id":"group1","type":"OKTA_GROUP","lastUpdated":"2024-05-01T10:00:00.000Z","lastMembershipUpdated":"2024-05-02T10:00:00.000Z","profile":{"name":"Engineering"}}`,
+		"group2": `{"id":"group2","type":"OKTA_GROUP","lastUpdated":"2024-05-01T10:00:00.000Z","lastMembershipUpdated":"2024-05-01T10:00:00.000Z","profile":{"name":"Sales"}}`,
+	}
+	members := map[string][]string{
+		"group1": {"user1", "user2"},
+		"group2": {"user3"},
+	}
+	var searches []string
+	mux := http.NewServeMux()
+	mux.Handle("/api/v1/groups", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		searches = append(searches, r.URL.Query().Get("search"))
+		var list []string
+		for _, id := range []string{"group1", "group2"} {
+			if g, ok := groups[id]; ok {
+				list = append(list, g)
+			}
+		}
+		fmt.Fprintf(w, "[%s]", strings.Join(list, ","))
+	}))
+	mux.Handle("/api/v1/groups/{groupid}/users", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var list []string
+		for _, id := range members[r.PathValue("groupid")] {
+			list = append(list, fmt.Sprintf(`{"id":%q,"profile":{"login":"%s@example.com"}}`, id, id))
+		}
+		fmt.Fprintf(w, "[%s]", strings.Join(list, ","))
+	}))
+	ts := httptest.NewTLSServer(mux)
+	defer ts.Close()
+
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("failed to parse server URL: %v", err)
+	}
+	a := oktaInput{
+		cfg: conf{
+			OktaDomain: u.Host,
+			OktaToken:  "token",
+			Dataset:    "groups",
+		},
+		client: ts.Client(),
+		lim:    rate.NewLimiter(1, 1),
+		logger: logp.L(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	fetch := func(fullSync bool) []*Group {
+		t.Helper()
+		ss, err := newStateStore(store)
+		if err != nil {
+			t.Fatalf("unexpected error making state store: %v", err)
+		}
+		got, err := a.doFetchGroups(ctx, ss, fullSync)
+		if err != nil {
+			t.Fatalf("unexpected error from doFetchGroups: %v", err)
+		}
+		if err = ss.close(true); err != nil {
+			t.Fatalf("unexpected error closing state store: %v", err)
+		}
+		return got
+	}
+
+	// Full sync stores the groups; nothing has been deleted.
+	if got := fetch(true); len(got) != 0 {
+		t.Errorf("unexpected deleted groups after first sync: %v", got)
+	}
+	if searches[0] != "" {
+		t.Errorf("unexpected search for full sync: %q", searches[0])
+	}
+
+	// Membership changes are reported by incremental updates.
+	members["group1"] = []string{"user2", "user4"}
+	delete(groups, "group2")
+	got := fetch(false)
+	wantSearch := `lastUpdated ge "2024-05-02T10:00:00.000Z" or lastMembershipUpdated ge "2024-05-02T10:00:00.000Z"`
+	if searches[1] != wantSearch {
+		t.Errorf("unexpected search for incremental update:\ngot: %q\nwant:%q", searches[1], wantSearch)
+	}
+	if len(got) != 1 {
+		t.Fatalf("unexpected number of updated groups: got:%d want:1", len(got))
+	}
+	if got[0].ID != "group1" || got[0].State != Modified {
+		t.Errorf("unexpected group: got:%s/%s want:group1/Modified", got[0].ID, got[0].State)
+	}
+	wantAdded := []GroupMember{{ID: "user4", Login: "user4@example.com"}}
+	wantRemoved := []GroupMember{{ID: "user1", Login: "user1@example.com"}}
+	if !slices.Equal(got[0].MembersAdded, wantAdded) {
+		t.Errorf("unexpected added members: got:%v want:%v", got[0].MembersAdded, wantAdded)
+	}
+	if !slices.Equal(got[0].MembersRemoved, wantRemoved) {
+		t.Errorf("unexpected removed members: got:%v want:%v", got[0].MembersRemoved, wantRemoved)
+	}
+
+	// The next full sync reports groups that no longer exist.
+	got = fetch(true)
+	if len(got) != 1 || got[0].ID != "group2" || got[0].State != Deleted {
+		t.Errorf("unexpected deleted groups: %v", got)
+	}
+	ss, err := newStateStore(store)
+	if err != nil {
+		t.Fatalf("unexpected error making state store: %v", err)
+	}
+	defer ss.close(false)
+	if _, ok := ss.groups["group2"]; ok {
+		t.Error("deleted group still in state store")
+	}
+}
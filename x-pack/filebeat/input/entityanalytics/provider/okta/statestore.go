@@ -17,12 +17,14 @@ import (
 var (
 	usersBucket   = []byte("users")
 	devicesBucket = []byte("devices")
+	groupsBucket  = []byte("groups")
 	stateBucket   = []byte("state")
 
 	lastSyncKey    = []byte("last_sync")
 	lastUpdateKey  = []byte("last_update")
 	usersLinkKey   = []byte("users_link")
 	devicesLinkKey = []byte("devices_link")
+	groupsLinkKey  = []byte("groups_link")
 )
 
 //go:generate stringer -type State
@@ -48,6 +50,24 @@ type Device struct {
 	State       State `json:"state"`
 }
 
+type Group struct {
+	okta.Group `json:"properties"`
+	Members    []GroupMember `json:"members"`
+	State      State         `json:"state"`
+
+	// MembersAdded and MembersRemoved are the membership
+	// changes since the group was last stored. They are
+	// not persisted.
+	MembersAdded   []GroupMember `json:"-"`
+	MembersRemoved []GroupMember `json:"-"`
+}
+
+// GroupMember is a user that is a member of a group.
+type GroupMember struct {
+	ID    string `json:"id"`
+	Login string `json:"login,omitempty"`
+}
+
 // stateStore wraps a kvstore.Transaction and provides convenience methods for
 // accessing and store relevant data within the kvstore database.
 type stateStore struct {
@@ -58,6 +78,7 @@ type stateStore struct {
 	// rather than encoding/json.
 	nextUsers   string
 	nextDevices string
+	nextGroups  string
 
 	// lastSync and lastUpdate are the times of the first update
 	// or sync operation of users/devices.
@@ -65,6 +86,11 @@ type stateStore struct {
 	lastUpdate time.Time
 	users      map[string]*User
 	devices    map[string]*Device
+	groups     map[string]*Group
+
+	// deletedGroups holds the IDs of groups that
+	// must be removed from the store.
+	deletedGroups []string
 }
 
 // newStateStore creates a new instance of stateStore. It will open a new write
@@ -81,6 +107,7 @@ func newStateStore(store *kvstore.Store) (*stateStore, error) {
 	s := stateStore{
 		users:   make(map[string]*User),
 		devices: make(map[string]*Device),
+		groups:  make(map[string]*Group),
 		tx:      tx,
 	}
 
@@ -100,6 +127,10 @@ func newStateStore(store *kvstore.Store) (*stateStore, error) {
 	if err != nil && !errIsItemNotFound(err) {
 		return nil, fmt.Errorf("unable to get devices link from state: %w", err)
 	}
+	err = s.tx.Get(stateBucket, groupsLinkKey, &s.nextGroups)
+	if err != nil && !errIsItemNotFound(err) {
+		return nil, fmt.Errorf("unable to get groups link from state: %w", err)
+	}
 
 	err = s.tx.ForEach(usersBucket, func(key, value []byte) error {
 		var u User
@@ -129,6 +160,20 @@ func newStateStore(store *kvstore.Store) (*stateStore, error) {
 		return nil, fmt.Errorf("unable to get devices from state: %w", err)
 	}
 
+	err = s.tx.ForEach(groupsBucket, func(key, value []byte) error {
+		var g Group
+		err = json.Unmarshal(value, &g)
+		if err != nil {
+			return fmt.Errorf("unable to unmarshal group from state: %w", err)
+		}
+		s.groups[g.ID] = &g
+
+		return nil
+	})
+	if err != nil && !errIsItemNotFound(err) {
+		return nil, fmt.Errorf("unable to get groups from state: %w", err)
+	}
+
 	return &s, nil
 }
 
@@ -170,6 +215,67 @@ func (s *stateStore) storeDevice(d okta.Device) *Device {
 	return &du
 }
 
+// storeGroup stores a group and its members. If the group does not exist in
+// the store, then the group will be marked as discovered. Otherwise, the group
+// will be marked as modified and the membership changes since the group was
+// last stored are recorded in the returned Group.
+func (s *stateStore) storeGroup(g okta.Group, members []okta.User) *Group {
+	sg := Group{Group: g, Members: make([]GroupMember, 0, len(members))}
+	for _, m := range members {
+		login, _ := m.Profile["login"].(string)
+		sg.Members = append(sg.Members, GroupMember{ID: m.ID, Login: login})
+	}
+
+	existing, ok := s.groups[g.ID]
+	if !ok {
+		sg.State = Discovered
+		sg.MembersAdded = sg.Members
+		s.groups[g.ID] = &sg
+		return &sg
+	}
+
+	sg.State = Modified
+	sg.MembersAdded, sg.MembersRemoved = membershipChanges(existing.Members, sg.Members)
+	*existing = sg
+	return &sg
+}
+
+// deleteGroup marks the group with the given ID as deleted and removes it
+// from the store.
+func (s *stateStore) deleteGroup(id string) *Group {
+	g, ok := s.groups[id]
+	if !ok {
+		return nil
+	}
+	g.State = Deleted
+	g.MembersAdded = nil
+	g.MembersRemoved = g.Members
+	delete(s.groups, id)
+	s.deletedGroups = append(s.deletedGroups, id)
+	return g
+}
+
+// membershipChanges returns the members that are in curr but not in prev and
+// the members that are in prev but not in curr.
+func membershipChanges(prev, curr []GroupMember) (added, removed []GroupMember) {
+	seen := make(map[string]bool, len(prev))
+	for _, m := range prev {
+		seen[m.ID] = true
+	}
+	for _, m := range curr {
+		if !seen[m.ID] {
+			added = append(added, m)
+		}
+		delete(seen, m.ID)
+	}
+	for _, m := range prev {
+		if seen[m.ID] {
+			removed = append(removed, m)
+		}
+	}
+	return added, removed
+}
+
 // close will close out the stateStore. If commit is true, the staged values on the
 // stateStore will be set in the kvstore database, and the transaction will be
 // committed. Otherwise, all changes will be discarded and the transaction will
@@ -216,6 +322,12 @@ func (s *stateStore) close(commit bool) (err error) {
 			return fmt.Errorf("unable to save devices link to state: %w", err)
 		}
 	}
+	if s.nextGroups != "" {
+		err = s.tx.Set(stateBucket, groupsLinkKey, &s.nextGroups)
+		if err != nil {
+			return fmt.Errorf("unable to save groups link to state: %w", err)
+		}
+	}
 
 	for key, value := range s.users {
 		err = s.tx.Set(usersBucket, []byte(key), value)
@@ -229,6 +341,18 @@ func (s *stateStore) close(commit bool) (err error) {
 			return fmt.Errorf("unable to save device %q to state: %w", key, err)
 		}
 	}
+	for key, value := range s.groups {
+		err = s.tx.Set(groupsBucket, []byte(key), value)
+		if err != nil {
+			return fmt.Errorf("unable to save group %q to state: %w", key, err)
+		}
+	}
+	for _, key := range s.deletedGroups {
+		err = s.tx.Delete(groupsBucket, []byte(key))
+		if err != nil && !errIsItemNotFound(err) {
+			return fmt.Errorf("unable to delete group %q from state: %w", key, err)
+		}
+	}
 
 	return s.tx.Commit()
 }
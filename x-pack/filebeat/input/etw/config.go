@@ -7,6 +7,7 @@
 package etw
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/elastic/beats/v7/x-pack/libbeat/reader/etw"
@@ -52,10 +53,37 @@ type config struct {
 	// Session is the name of an existing session to read from.
 	// Run 'logman query -ets' to list existing sessions.
 	Session string `config:"session"`
+	// Providers lists the providers to enable in a new session, each with
+	// its own level and keyword filters. It replaces provider.guid and
+	// provider.name.
+	Providers []providerConfig `config:"providers"`
+	// Sessions lists several sessions to read from concurrently. Each of
+	// them takes the same options as a single session input. It cannot be
+	// combined with a top-level provider, file or session.
+	Sessions []config `config:"sessions"`
+	// ManifestCacheSize is the number of manifest-based events whose
+	// metadata is kept to render the events. Zero disables the cache.
+	ManifestCacheSize int `config:"manifest_cache_size" validate:"min=0"`
+}
+
+type providerConfig struct {
+	// GUID is the GUID of an ETW provider.
+	GUID string `config:"guid"`
+	// Name is the name of an ETW provider.
+	Name string `config:"name"`
+	// TraceLevel filters the provider events with a level value
+	// that is less than or equal to this level.
+	TraceLevel string `config:"trace_level"`
+	// MatchAnyKeyword is the bitmask of keywords of which an event
+	// must match any to be written by the provider.
+	MatchAnyKeyword uint64 `config:"match_any_keyword"`
+	// MatchAllKeyword is the bitmask of keywords of which an event
+	// must match all to be written by the provider.
+	MatchAllKeyword uint64 `config:"match_all_keyword"`
 }
 
 func convertConfig(cfg config) etw.Config {
-	return etw.Config{
+	conf := etw.Config{
 		Logfile:         cfg.Logfile,
 		ProviderGUID:    cfg.ProviderGUID,
 		ProviderName:    cfg.ProviderName,
@@ -65,24 +93,109 @@ func convertConfig(cfg config) etw.Config {
 		MatchAllKeyword: cfg.MatchAllKeyword,
 		Session:         cfg.Session,
 	}
+	for _, p := range cfg.Providers {
+		// Providers inherit the session filters they do not set.
+		provider := etw.ProviderConfig{
+			GUID:            p.GUID,
+			Name:            p.Name,
+			TraceLevel:      p.TraceLevel,
+			MatchAnyKeyword: p.MatchAnyKeyword,
+			MatchAllKeyword: p.MatchAllKeyword,
+		}
+		if provider.TraceLevel == "" {
+			provider.TraceLevel = conf.TraceLevel
+		}
+		if provider.MatchAnyKeyword == 0 {
+			provider.MatchAnyKeyword = conf.MatchAnyKeyword
+		}
+		if provider.MatchAllKeyword == 0 {
+			provider.MatchAllKeyword = conf.MatchAllKeyword
+		}
+		conf.Providers = append(conf.Providers, provider)
+	}
+	return conf
 }
 
 func defaultConfig() config {
 	return config{
-		TraceLevel:      "verbose",
-		MatchAnyKeyword: 0xffffffffffffffff,
+		TraceLevel:        "verbose",
+		MatchAnyKeyword:   0xffffffffffffffff,
+		ManifestCacheSize: 4096,
 	}
 }
 
+// sessionConfigs returns the configuration of each of the sessions to read.
+// The entries of sessions get the default filters they do not set.
+func (c *config) sessionConfigs() []config {
+	if len(c.Sessions) == 0 {
+		return []config{*c}
+	}
+	def := defaultConfig()
+	sessions := make([]config, len(c.Sessions))
+	for i, s := range c.Sessions {
+		if s.TraceLevel == "" {
+			s.TraceLevel = def.TraceLevel
+		}
+		if s.MatchAnyKeyword == 0 {
+			s.MatchAnyKeyword = def.MatchAnyKeyword
+		}
+		sessions[i] = s
+	}
+	return sessions
+}
+
 func (c *config) Validate() error {
-	if c.ProviderName == "" && c.ProviderGUID == "" && c.Logfile == "" && c.Session == "" {
+	if len(c.Sessions) != 0 {
+		if c.ProviderName != "" || c.ProviderGUID != "" || c.Logfile != "" || c.Session != "" || len(c.Providers) != 0 {
+			return errors.New("configuration constraint error: sessions and a top-level provider, file or existing session cannot be defined together")
+		}
+		names := make(map[string]bool, len(c.Sessions))
+		for i, s := range c.sessionConfigs() {
+			if len(s.Sessions) != 0 {
+				return fmt.Errorf("configuration constraint error: sessions cannot be nested in session %d", i)
+			}
+			if err := s.Validate(); err != nil {
+				return fmt.Errorf("session %d: %w", i, err)
+			}
+			name := etw.SessionName(convertConfig(s))
+			if names[name] {
+				return fmt.Errorf("configuration constraint error: session name '%s' is used by more than one session", name)
+			}
+			names[name] = true
+		}
+		return nil
+	}
+
+	if c.ProviderName == "" && c.ProviderGUID == "" && c.Logfile == "" && c.Session == "" && len(c.Providers) == 0 {
 		return fmt.Errorf("provider, existing logfile or running session must be set")
 	}
 
-	if !validTraceLevel[c.TraceLevel] {
+	if c.TraceLevel != "" && !validTraceLevel[c.TraceLevel] {
 		return fmt.Errorf("invalid Trace Level value '%s'", c.TraceLevel)
 	}
 
+	if len(c.Providers) != 0 {
+		if c.ProviderName != "" || c.ProviderGUID != "" {
+			return fmt.Errorf("configuration constraint error: providers and provider GUID or name cannot be defined together")
+		}
+		if c.Logfile != "" {
+			return fmt.Errorf("configuration constraint error: providers and file cannot be defined together")
+		}
+		if c.Session != "" {
+			return fmt.Errorf("configuration constraint error: providers and existing session cannot be defined together")
+		}
+		for i, p := range c.Providers {
+			switch {
+			case p.GUID == "" && p.Name == "":
+				return fmt.Errorf("provider %d: provider GUID or name must be set", i)
+			case p.GUID != "" && p.Name != "":
+				return fmt.Errorf("configuration constraint error: provider %d GUID and name cannot be defined together", i)
+			case p.TraceLevel != "" && !validTraceLevel[p.TraceLevel]:
+				return fmt.Errorf("invalid Trace Level value '%s' for provider %d", p.TraceLevel, i)
+			}
+		}
+	}
+
 	if c.ProviderGUID != "" {
 		if c.ProviderName != "" {
 			return fmt.Errorf("configuration constraint error: provider GUID and provider name cannot be defined together")
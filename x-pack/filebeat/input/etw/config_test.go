@@ -11,6 +11,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/elastic/beats/v7/x-pack/libbeat/reader/etw"
 	confpkg "github.com/elastic/elastic-agent-libs/config"
 )
 
@@ -115,6 +116,85 @@ func Test_validateConfig(t *testing.T) {
 			},
 			wantError: "configuration constraint error: file and existing session cannot be defined together",
 		},
+		{
+			name: "valid sessions config",
+			config: config{
+				Sessions: []config{
+					{ProviderName: "Microsoft-Windows-DNSServer"},
+					{
+						SessionName: "MySession-Kernel",
+						Providers: []providerConfig{
+							{Name: "Microsoft-Windows-Kernel-Process", TraceLevel: "information", MatchAnyKeyword: 0x10},
+							{GUID: "{22FB2CD6-0E7B-422B-A0C7-2FAD1FD0E716}"},
+						},
+					},
+					{Session: "UAL_Usermode_Provider"},
+				},
+			},
+		},
+		{
+			name: "conflict sessions and provider",
+			config: config{
+				ProviderName: "Microsoft-Windows-DNSServer",
+				TraceLevel:   "verbose",
+				Sessions: []config{
+					{ProviderName: "Microsoft-Windows-Kernel-Process"},
+				},
+			},
+			wantError: "configuration constraint error: sessions and a top-level provider, file or existing session cannot be defined together",
+		},
+		{
+			name: "duplicate session name",
+			config: config{
+				TraceLevel: "verbose",
+				Sessions: []config{
+					{ProviderName: "Microsoft-Windows-DNSServer"},
+					{ProviderName: "Microsoft-Windows-DNSServer", TraceLevel: "error"},
+				},
+			},
+			wantError: "configuration constraint error: session name 'Elastic-Microsoft-Windows-DNSServer' is used by more than one session",
+		},
+		{
+			name: "invalid session",
+			config: config{
+				TraceLevel: "verbose",
+				Sessions: []config{
+					{ProviderName: "Microsoft-Windows-DNSServer", Logfile: "C:\\Windows\\System32\\winevt\\File.etl"},
+				},
+			},
+			wantError: "configuration constraint error: provider name and file cannot be defined together",
+		},
+		{
+			name: "conflict providers and provider name",
+			config: config{
+				ProviderName: "Microsoft-Windows-DNSServer",
+				TraceLevel:   "verbose",
+				Providers: []providerConfig{
+					{Name: "Microsoft-Windows-Kernel-Process"},
+				},
+			},
+			wantError: "configuration constraint error: providers and provider GUID or name cannot be defined together",
+		},
+		{
+			name: "provider without GUID or name",
+			config: config{
+				TraceLevel: "verbose",
+				Providers: []providerConfig{
+					{TraceLevel: "error"},
+				},
+			},
+			wantError: "provider 0: provider GUID or name must be set",
+		},
+		{
+			name: "invalid provider trace level",
+			config: config{
+				TraceLevel: "verbose",
+				Providers: []providerConfig{
+					{Name: "Microsoft-Windows-Kernel-Process", TraceLevel: "failed"},
+				},
+			},
+			wantError: "invalid Trace Level value 'failed' for provider 0",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -138,3 +218,31 @@ func Test_validateConfig(t *testing.T) {
 		})
 	}
 }
+
+func Test_sessionConfigs(t *testing.T) {
+	c := config{
+		Sessions: []config{
+			{ProviderName: "Microsoft-Windows-DNSServer"},
+			{
+				Providers: []providerConfig{
+					{Name: "Microsoft-Windows-Kernel-Process", MatchAllKeyword: 0x20},
+				},
+				TraceLevel: "error",
+			},
+		},
+	}
+
+	sessions := c.sessionConfigs()
+	assert.Len(t, sessions, 2)
+	assert.Equal(t, "verbose", sessions[0].TraceLevel)
+	assert.Equal(t, uint64(0xffffffffffffffff), sessions[0].MatchAnyKeyword)
+
+	// Providers inherit the filters of their session.
+	etwCfg := convertConfig(sessions[1])
+	assert.Equal(t, []etw.ProviderConfig{{
+		Name:            "Microsoft-Windows-Kernel-Process",
+		TraceLevel:      "error",
+		MatchAnyKeyword: 0xffffffffffffffff,
+		MatchAllKeyword: 0x20,
+	}}, etwCfg.Providers)
+}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"math"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -68,12 +69,20 @@ func (op *realSessionOperator) stopSession(session *etw.Session) error {
 
 // etwInput struct holds the configuration and state for the ETW input
 type etwInput struct {
-	log        *logp.Logger
-	metrics    *inputMetrics
-	config     config
-	etwSession *etw.Session
-	publisher  stateless.Publisher
-	operator   sessionOperator
+	log       *logp.Logger
+	metrics   *inputMetrics
+	config    config
+	sessions  []*etwSession
+	cache     *etw.RenderCache
+	publisher stateless.Publisher
+	operator  sessionOperator
+}
+
+// etwSession is one of the sessions read by the input.
+type etwSession struct {
+	*etw.Session
+	config config
+	log    *logp.Logger
 }
 
 func Plugin() input.Plugin {
@@ -103,68 +112,105 @@ func (e *etwInput) Test(_ input.TestContext) error {
 	return nil
 }
 
-// Run starts the ETW session and processes incoming events.
+// Run starts the ETW sessions and processes incoming events.
 func (e *etwInput) Run(ctx input.Context, publisher stateless.Publisher) error {
-	var err error
-
-	// Initialize a new ETW session with the provided configuration
-	e.etwSession, err = e.operator.newSession(e.config)
-	if err != nil {
-		return fmt.Errorf("error initializing ETW session: %w", err)
+	// Initialize a new ETW session for each of the configured sessions
+	cfgs := e.config.sessionConfigs()
+	e.sessions = make([]*etwSession, 0, len(cfgs))
+	names := make([]string, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		session, err := e.operator.newSession(cfg)
+		if err != nil {
+			return fmt.Errorf("error initializing ETW session: %w", err)
+		}
+		s := &etwSession{Session: session, config: cfg}
+		s.Callback = func(record *etw.EventRecord) uintptr {
+			return e.consumeEvent(record, s)
+		}
+		e.sessions = append(e.sessions, s)
+		names = append(names, session.Name)
 	}
-	e.etwSession.Callback = e.consumeEvent
+	e.cache = etw.NewRenderCache(e.config.ManifestCacheSize)
 	e.publisher = publisher
-	e.metrics = newInputMetrics(e.etwSession.Name, ctx.ID)
+	e.metrics = newInputMetrics(strings.Join(names, ","), ctx.ID)
 	defer e.metrics.unregister()
 
 	// Set up logger with session information
-	e.log = ctx.Logger.With("session", e.etwSession.Name)
+	e.log = ctx.Logger.With("session", strings.Join(names, ","))
 	e.log.Info("Starting " + inputName + " input")
 	defer e.log.Info(inputName + " input stopped")
 
+	// Stop the sessions that have been set up once the input ends.
+	var (
+		mu      sync.Mutex
+		started []*etwSession
+	)
+	stopConsumer := sync.OnceFunc(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		e.close(started)
+	})
+	defer stopConsumer()
+
 	// Handle realtime session creation or attachment
-	if e.etwSession.Realtime {
-		if !e.etwSession.NewSession {
-			// Attach to an existing session
-			err = e.operator.attachToExistingSession(e.etwSession)
-			if err != nil {
-				return fmt.Errorf("unable to retrieve handler: %w", err)
-			}
-			e.log.Debug("attached to existing session")
-		} else {
-			// Create a new realtime session
-			err = e.operator.createRealtimeSession(e.etwSession)
-			if err != nil {
-				return fmt.Errorf("realtime session could not be created: %w", err)
-			}
-			e.log.Debug("created new session")
+	for _, s := range e.sessions {
+		s.log = ctx.Logger.With("session", s.Name)
+		if err := e.setupSession(s); err != nil {
+			return err
 		}
+		mu.Lock()
+		started = append(started, s)
+		mu.Unlock()
 	}
 
-	stopConsumer := sync.OnceFunc(e.Close)
-	defer stopConsumer()
-
 	// Stop the consumer upon input cancellation (shutdown).
 	go func() {
 		<-ctx.Cancelation.Done()
 		stopConsumer()
 	}()
 
-	// Start a goroutine to consume ETW events
+	// Start a goroutine to consume the events of each ETW session. If one of
+	// them fails, all the sessions are stopped.
 	g := new(errgroup.Group)
-	g.Go(func() error {
-		e.log.Debug("starting ETW consumer")
-		defer e.log.Debug("stopped ETW consumer")
-		if err = e.operator.startConsumer(e.etwSession); err != nil {
-			e.metrics.errors.Inc()
-			return fmt.Errorf("failed running ETW consumer: %w", err)
-		}
-		return nil
-	})
+	for _, s := range e.sessions {
+		g.Go(func() error {
+			s.log.Debug("starting ETW consumer")
+			defer s.log.Debug("stopped ETW consumer")
+			if err := e.operator.startConsumer(s.Session); err != nil {
+				e.metrics.errors.Inc()
+				stopConsumer()
+				return fmt.Errorf("failed running ETW consumer: %w", err)
+			}
+			return nil
+		})
+	}
 
 	return g.Wait()
 }
 
+// setupSession creates the realtime session s or attaches to it.
+func (e *etwInput) setupSession(s *etwSession) error {
+	if !s.Realtime {
+		return nil
+	}
+	if !s.NewSession {
+		// Attach to an existing session
+		err := e.operator.attachToExistingSession(s.Session)
+		if err != nil {
+			return fmt.Errorf("unable to retrieve handler: %w", err)
+		}
+		s.log.Debug("attached to existing session")
+		return nil
+	}
+	// Create a new realtime session
+	err := e.operator.createRealtimeSession(s.Session)
+	if err != nil {
+		return fmt.Errorf("realtime session could not be created: %w", err)
+	}
+	s.log.Debug("created new session")
+	return nil
+}
+
 var (
 	// levelToSeverity maps ETW trace levels to names for use in ECS log.level.
 	levelToSeverity = map[uint8]string{
@@ -208,6 +254,8 @@ func buildEvent(data map[string]any, h etw.EventHeader, session *etw.Session, cf
 	}
 	if cfg.ProviderName != "" {
 		event["provider"] = cfg.ProviderName
+	} else if name, ok := session.ProviderNames[h.ProviderId]; ok {
+		event["provider"] = name
 	}
 
 	fields := mapstr.M{
@@ -244,7 +292,7 @@ func convertFileTimeToGoTime(fileTime64 uint64) time.Time {
 	return time.Unix(0, fileTime.Nanoseconds()).UTC()
 }
 
-func (e *etwInput) consumeEvent(record *etw.EventRecord) uintptr {
+func (e *etwInput) consumeEvent(record *etw.EventRecord, s *etwSession) uintptr {
 	if record == nil {
 		e.log.Error("received null event record")
 		e.metrics.errors.Inc()
@@ -257,7 +305,7 @@ func (e *etwInput) consumeEvent(record *etw.EventRecord) uintptr {
 		e.metrics.processingTime.Update(elapsed.Nanoseconds())
 	}()
 
-	data, err := etw.GetEventProperties(record)
+	data, err := e.cache.GetEventProperties(record)
 	if err != nil {
 		e.log.Errorw("failed to read event properties", "error", err)
 		e.metrics.errors.Inc()
@@ -265,7 +313,7 @@ func (e *etwInput) consumeEvent(record *etw.EventRecord) uintptr {
 		return 1
 	}
 
-	evt := buildEvent(data, record.EventHeader, e.etwSession, e.config)
+	evt := buildEvent(data, record.EventHeader, s.Session, s.config)
 	e.publisher.Publish(evt)
 
 	e.metrics.events.Inc()
@@ -278,14 +326,20 @@ func (e *etwInput) consumeEvent(record *etw.EventRecord) uintptr {
 	return 0
 }
 
-// Close stops the ETW session and logs the outcome.
+// Close stops the ETW sessions and logs the outcome.
 func (e *etwInput) Close() {
-	if err := e.operator.stopSession(e.etwSession); err != nil {
-		e.log.Error("failed to shutdown ETW session")
-		e.metrics.errors.Inc()
-		return
+	e.close(e.sessions)
+}
+
+func (e *etwInput) close(sessions []*etwSession) {
+	for _, s := range sessions {
+		if err := e.operator.stopSession(s.Session); err != nil {
+			s.log.Error("failed to shutdown ETW session")
+			e.metrics.errors.Inc()
+			continue
+		}
+		s.log.Info("successfully shutdown")
 	}
-	e.log.Info("successfully shutdown")
 }
 
 // inputMetrics handles event log metric reporting.
@@ -10,6 +10,7 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -307,6 +308,64 @@ func Test_RunEtwInput_Success(t *testing.T) {
 	cancelFunc() // Trigger cancellation to test cleanup and goroutine exit
 }
 
+func Test_RunEtwInput_Sessions(t *testing.T) {
+	// Mocks
+	mockOperator := &mockSessionOperator{}
+
+	var (
+		mu      sync.Mutex
+		created []string
+		stopped []string
+	)
+	// Setup the mock behavior for NewSession
+	mockOperator.newSessionFunc = func(config config) (*etw.Session, error) {
+		mockSession := &etw.Session{
+			Name:       config.SessionName,
+			Realtime:   true,
+			NewSession: true,
+		}
+		return mockSession, nil
+	}
+	// Setup the mock behavior for CreateRealtimeSession
+	mockOperator.createRealtimeSessionFunc = func(session *etw.Session) error {
+		created = append(created, session.Name)
+		if session.Name == "Failing" {
+			return fmt.Errorf("mock error")
+		}
+		return nil
+	}
+	// Setup the mock behavior for StopSession
+	mockOperator.stopSessionFunc = func(session *etw.Session) error {
+		mu.Lock()
+		defer mu.Unlock()
+		stopped = append(stopped, session.Name)
+		return nil
+	}
+
+	// Setup input
+	inputCtx := input.Context{
+		Cancelation: nil,
+		Logger:      logp.NewLogger("test"),
+	}
+
+	etwInput := &etwInput{
+		config: config{
+			Sessions: []config{
+				{ProviderName: "Microsoft-Windows-Provider", SessionName: "Session1"},
+				{ProviderName: "Microsoft-Windows-Other", SessionName: "Failing"},
+			},
+		},
+		operator: mockOperator,
+		metrics:  newInputMetrics("", ""),
+	}
+
+	// Run test, the sessions that were set up are stopped on failure.
+	err := etwInput.Run(inputCtx, nil)
+	assert.EqualError(t, err, "realtime session could not be created: mock error")
+	assert.Equal(t, []string{"Session1", "Failing"}, created)
+	assert.Equal(t, []string{"Session1"}, stopped)
+}
+
 func Test_buildEvent(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -484,6 +543,23 @@ func Test_buildEvent(t *testing.T) {
 	}
 }
 
+func Test_buildEvent_providerName(t *testing.T) {
+	guid := windows.GUID{Data1: 0x12345678}
+	session := &etw.Session{
+		Name:          "Elastic-TestProvider",
+		ProviderNames: map[windows.GUID]string{guid: "TestProvider"},
+	}
+
+	evt := buildEvent(nil, etw.EventHeader{ProviderId: guid}, session, config{})
+	provider, err := evt.Fields.GetValue("event.provider")
+	assert.NoError(t, err)
+	assert.Equal(t, "TestProvider", provider)
+
+	evt = buildEvent(nil, etw.EventHeader{ProviderId: windows.GUID{Data1: 1}}, session, config{})
+	_, err = evt.Fields.GetValue("event.provider")
+	assert.ErrorIs(t, err, mapstr.ErrKeyNotFound)
+}
+
 func Test_convertFileTimeToGoTime(t *testing.T) {
 	tests := []struct {
 		name     string
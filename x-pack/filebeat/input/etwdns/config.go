@@ -0,0 +1,32 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package etwdns
+
+import (
+	"time"
+)
+
+type config struct {
+	// SessionName is the name of the ETW session created to read the
+	// Microsoft-Windows-DNSServer analytical events.
+	SessionName string `config:"session_name"`
+	// MatchAnyKeyword is an 8-byte bitmask that filters the events written
+	// by the provider, as in the etw input.
+	MatchAnyKeyword uint64 `config:"match_any_keyword"`
+	// Timeout is how long a query waits for its response before it is
+	// published without it.
+	Timeout time.Duration `config:"timeout" validate:"positive,nonzero"`
+	// MaxPending is the maximum number of queries waiting for their response.
+	MaxPending int `config:"max_pending" validate:"min=1"`
+}
+
+func defaultConfig() config {
+	return config{
+		SessionName:     "Elastic-DNSServer-Analytical",
+		MatchAnyKeyword: 0xffffffffffffffff,
+		Timeout:         10 * time.Second,
+		MaxPending:      10000,
+	}
+}
@@ -0,0 +1,338 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package etwdns
+
+import (
+	"container/list"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// Analytical events of the Microsoft-Windows-DNSServer provider.
+// See https://learn.microsoft.com/en-us/previous-versions/windows/it-pro/windows-server-2012-r2-and-2012/dn800669(v=ws.11)#analytic-events
+const (
+	eventQueryReceived   = 256
+	eventResponseSuccess = 257
+	eventResponseFailure = 258
+)
+
+// message is a DNS server analytical event.
+type message struct {
+	ts       time.Time
+	response bool
+	tcp      bool
+	// client is the address of the client sending the query or receiving
+	// the response.
+	client net.IP
+	port   uint16
+	// server is the address of the server interface.
+	server net.IP
+	id     uint16
+	qname  string
+	qtype  uint16
+	rcode  int
+	flags  []string
+	packet []byte
+}
+
+// parseMessage parses the properties of a query or response event. It
+// returns false if the event is not a query or a response.
+func parseMessage(eventID uint16, ts time.Time, data map[string]any) (message, bool, error) {
+	m := message{ts: ts}
+	peer := "Source"
+	switch eventID {
+	case eventQueryReceived:
+	case eventResponseSuccess, eventResponseFailure:
+		m.response = true
+		peer = "Destination"
+	default:
+		return m, false, nil
+	}
+
+	str := func(name string) string {
+		s, _ := data[name].(string)
+		return s
+	}
+	num := func(name string, bits int) (uint64, error) {
+		v, err := strconv.ParseUint(str(name), 0, bits)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		return v, nil
+	}
+
+	m.tcp = str("TCP") == "1"
+	m.client = net.ParseIP(str(peer))
+	m.server = net.ParseIP(str("InterfaceIP"))
+	m.qname = strings.TrimSuffix(str("QNAME"), ".")
+	port, err := num("Port", 16)
+	if err != nil {
+		return m, true, err
+	}
+	m.port = uint16(port)
+	id, err := num("XID", 16)
+	if err != nil {
+		return m, true, err
+	}
+	m.id = uint16(id)
+	qtype, err := num("QTYPE", 16)
+	if err != nil {
+		return m, true, err
+	}
+	m.qtype = uint16(qtype)
+	if m.response {
+		rcode, err := num("RCODE", 16)
+		if err != nil {
+			return m, true, err
+		}
+		m.rcode = int(rcode)
+		if str("AA") == "1" {
+			m.flags = append(m.flags, "AA")
+		}
+		if str("AD") == "1" {
+			m.flags = append(m.flags, "AD")
+		}
+	} else if str("RD") == "1" {
+		m.flags = append(m.flags, "RD")
+	}
+	// Binary properties are rendered as a 0x prefixed hexadecimal string.
+	if p := strings.TrimPrefix(str("PacketData"), "0x"); p != "" {
+		m.packet, err = hex.DecodeString(p)
+		if err != nil {
+			return m, true, fmt.Errorf("invalid PacketData: %w", err)
+		}
+	}
+	return m, true, nil
+}
+
+// transaction identifies a query and its response.
+type transaction struct {
+	client string
+	port   uint16
+	id     uint16
+	tcp    bool
+}
+
+func (m *message) transaction() transaction {
+	return transaction{client: m.client.String(), port: m.port, id: m.id, tcp: m.tcp}
+}
+
+// correlator pairs the queries received by the DNS server with their
+// responses. Queries that are not answered within timeout, or that are
+// evicted to keep at most maxPending queries, are reported without a
+// response.
+type correlator struct {
+	timeout    time.Duration
+	maxPending int
+
+	mu      sync.Mutex
+	pending map[transaction]*list.Element
+	// queue holds the pending queries in arrival order.
+	queue *list.List
+}
+
+func newCorrelator(timeout time.Duration, maxPending int) *correlator {
+	return &correlator{
+		timeout:    timeout,
+		maxPending: maxPending,
+		pending:    make(map[transaction]*list.Element),
+		queue:      list.New(),
+	}
+}
+
+// add adds a message to the correlator and returns the events that are
+// complete: the transaction of a response, and queries that were evicted.
+func (c *correlator) add(m message) []beat.Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var events []beat.Event
+	key := m.transaction()
+	if !m.response {
+		if e, ok := c.pending[key]; ok {
+			// A retransmitted query, report the previous one as unanswered.
+			events = append(events, buildEvent(c.remove(e), nil))
+		}
+		for c.queue.Len() >= c.maxPending {
+			events = append(events, buildEvent(c.remove(c.queue.Front()), nil))
+		}
+		c.pending[key] = c.queue.PushBack(&m)
+		return events
+	}
+
+	var query *message
+	if e, ok := c.pending[key]; ok {
+		query = c.remove(e)
+	}
+	return append(events, buildEvent(query, &m))
+}
+
+// expire returns the events of the queries received before now-timeout.
+func (c *correlator) expire(now time.Time) []beat.Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var events []beat.Event
+	for e := c.queue.Front(); e != nil; e = c.queue.Front() {
+		if now.Sub(e.Value.(*message).ts) < c.timeout {
+			break
+		}
+		events = append(events, buildEvent(c.remove(e), nil))
+	}
+	return events
+}
+
+// flush returns the events of all the pending queries.
+func (c *correlator) flush() []beat.Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var events []beat.Event
+	for e := c.queue.Front(); e != nil; e = c.queue.Front() {
+		events = append(events, buildEvent(c.remove(e), nil))
+	}
+	return events
+}
+
+func (c *correlator) remove(e *list.Element) *message {
+	m := c.queue.Remove(e).(*message)
+	delete(c.pending, m.transaction())
+	return m
+}
+
+// buildEvent builds the ECS event of a transaction. Either query or response
+// may be nil, but not both.
+func buildEvent(query, response *message) beat.Event {
+	m := query
+	if m == nil {
+		m = response
+	}
+
+	transport := "udp"
+	if m.tcp {
+		transport = "tcp"
+	}
+	qtype := dns.TypeToString[m.qtype]
+	if qtype == "" {
+		qtype = strconv.Itoa(int(m.qtype))
+	}
+
+	dnsFields := mapstr.M{
+		"id": m.id,
+		"question": mapstr.M{
+			"name":  m.qname,
+			"type":  qtype,
+			"class": "IN",
+		},
+	}
+	event := mapstr.M{
+		"kind":     "event",
+		"category": []string{"network"},
+		"type":     []string{"protocol"},
+	}
+	fields := mapstr.M{
+		"event": event,
+		"dns":   dnsFields,
+		"network": mapstr.M{
+			"transport": transport,
+			"protocol":  "dns",
+		},
+		"client": mapstr.M{"port": m.port},
+	}
+	if m.client != nil {
+		fields.Put("client.ip", m.client.String())
+	}
+	if m.server != nil {
+		fields.Put("server.ip", m.server.String())
+	}
+
+	var flags []string
+	if query != nil {
+		flags = append(flags, query.flags...)
+		event["start"] = query.ts
+	}
+	if response == nil {
+		dnsFields["type"] = "query"
+		event["outcome"] = "unknown"
+		if len(flags) != 0 {
+			dnsFields["header_flags"] = flags
+		}
+		return beat.Event{Timestamp: query.ts, Fields: fields}
+	}
+
+	dnsFields["type"] = "answer"
+	event["end"] = response.ts
+	if query != nil {
+		event["duration"] = response.ts.Sub(query.ts).Nanoseconds()
+	}
+	flags = append(flags, response.flags...)
+	if rcode, ok := dns.RcodeToString[response.rcode]; ok {
+		dnsFields["response_code"] = rcode
+	} else {
+		dnsFields["response_code"] = strconv.Itoa(response.rcode)
+	}
+	if response.rcode == dns.RcodeSuccess {
+		event["outcome"] = "success"
+	} else {
+		event["outcome"] = "failure"
+	}
+	addAnswers(dnsFields, response.packet)
+	if len(flags) != 0 {
+		dnsFields["header_flags"] = flags
+	}
+
+	ts := response.ts
+	if query != nil {
+		ts = query.ts
+	}
+	return beat.Event{Timestamp: ts, Fields: fields}
+}
+
+// addAnswers adds the answers of the DNS response packet to fields.
+func addAnswers(fields mapstr.M, packet []byte) {
+	if len(packet) == 0 {
+		return
+	}
+	var msg dns.Msg
+	if err := msg.Unpack(packet); err != nil {
+		return
+	}
+
+	answers := make([]mapstr.M, 0, len(msg.Answer))
+	var resolved []string
+	for _, rr := range msg.Answer {
+		h := rr.Header()
+		answer := mapstr.M{
+			"name":  strings.TrimSuffix(h.Name, "."),
+			"type":  dns.TypeToString[h.Rrtype],
+			"class": dns.ClassToString[h.Class],
+			"ttl":   h.Ttl,
+			"data":  strings.TrimPrefix(rr.String(), h.String()),
+		}
+		switch rr := rr.(type) {
+		case *dns.A:
+			resolved = append(resolved, rr.A.String())
+		case *dns.AAAA:
+			resolved = append(resolved, rr.AAAA.String())
+		}
+		answers = append(answers, answer)
+	}
+	if len(answers) != 0 {
+		fields["answers"] = answers
+	}
+	if len(resolved) != 0 {
+		fields["resolved_ip"] = resolved
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package etwdns
+
+import (
+	"encoding/hex"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+var start = time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+func queryData(xid string) map[string]any {
+	return map[string]any{
+		"TCP":         "0",
+		"InterfaceIP": "10.0.0.1",
+		"Source":      "10.0.0.2",
+		"RD":          "1",
+		"QNAME":       "www.example.com.",
+		"QTYPE":       "1",
+		"XID":         xid,
+		"Port":        "53000",
+		"Flags":       "256",
+	}
+}
+
+func responseData(t *testing.T, xid string, rcode string) map[string]any {
+	t.Helper()
+
+	msg := new(dns.Msg)
+	msg.SetQuestion("www.example.com.", dns.TypeA)
+	msg.Response = true
+	msg.Answer = []dns.RR{
+		&dns.CNAME{Hdr: dns.RR_Header{Name: "www.example.com.", Rrtype: dns.TypeCNAME, Class: dns.ClassINET, Ttl: 300}, Target: "example.com."},
+		&dns.A{Hdr: dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60}, A: net.IPv4(192, 0, 2, 10)},
+	}
+	packet, err := msg.Pack()
+	require.NoError(t, err)
+
+	return map[string]any{
+		"TCP":         "0",
+		"InterfaceIP": "10.0.0.1",
+		"Destination": "10.0.0.2",
+		"AA":          "1",
+		"AD":          "0",
+		"QNAME":       "www.example.com.",
+		"QTYPE":       "1",
+		"XID":         xid,
+		"DNSSEC":      "0",
+		"RCODE":       rcode,
+		"Port":        "53000",
+		"PacketData":  "0x" + strings.ToUpper(hex.EncodeToString(packet)),
+	}
+}
+
+func mustParse(t *testing.T, id uint16, ts time.Time, data map[string]any) message {
+	t.Helper()
+	m, ok, err := parseMessage(id, ts, data)
+	require.NoError(t, err)
+	require.True(t, ok)
+	return m
+}
+
+func TestCorrelateTransaction(t *testing.T) {
+	c := newCorrelator(time.Second, 10)
+
+	events := c.add(mustParse(t, eventQueryReceived, start, queryData("4660")))
+	assert.Empty(t, events)
+
+	events = c.add(mustParse(t, eventResponseSuccess, start.Add(3*time.Millisecond), responseData(t, "4660", "0")))
+	require.Len(t, events, 1)
+	assert.Equal(t, start, events[0].Timestamp)
+
+	want := mapstr.M{
+		"event": mapstr.M{
+			"kind":     "event",
+			"category": []string{"network"},
+			"type":     []string{"protocol"},
+			"start":    start,
+			"end":      start.Add(3 * time.Millisecond),
+			"duration": int64(3 * time.Millisecond),
+			"outcome":  "success",
+		},
+		"dns": mapstr.M{
+			"id":   uint16(4660),
+			"type": "answer",
+			"question": mapstr.M{
+				"name":  "www.example.com",
+				"type":  "A",
+				"class": "IN",
+			},
+			"response_code": "NOERROR",
+			"header_flags":  []string{"RD", "AA"},
+			"answers": []mapstr.M{
+				{"name": "www.example.com", "type": "CNAME", "class": "IN", "ttl": uint32(300), "data": "example.com."},
+				{"name": "example.com", "type": "A", "class": "IN", "ttl": uint32(60), "data": "192.0.2.10"},
+			},
+			"resolved_ip": []string{"192.0.2.10"},
+		},
+		"network": mapstr.M{"transport": "udp", "protocol": "dns"},
+		"client":  mapstr.M{"ip": "10.0.0.2", "port": uint16(53000)},
+		"server":  mapstr.M{"ip": "10.0.0.1"},
+	}
+	assert.Equal(t, want, events[0].Fields)
+	assert.Empty(t, c.flush())
+}
+
+func TestCorrelateFailureWithoutQuery(t *testing.T) {
+	c := newCorrelator(time.Second, 10)
+
+	// A query for another client port does not match.
+	query := queryData("4660")
+	query["Port"] = "53001"
+	assert.Empty(t, c.add(mustParse(t, eventQueryReceived, start, query)))
+
+	events := c.add(mustParse(t, eventResponseFailure, start, responseData(t, "4660", "3")))
+	require.Len(t, events, 1)
+	fields := events[0].Fields
+	assertField(t, fields, "dns.response_code", "NXDOMAIN")
+	assertField(t, fields, "event.outcome", "failure")
+	_, err := fields.GetValue("event.duration")
+	assert.ErrorIs(t, err, mapstr.ErrKeyNotFound)
+
+	assert.Len(t, c.flush(), 1)
+}
+
+func TestCorrelateUnanswered(t *testing.T) {
+	c := newCorrelator(time.Second, 2)
+
+	assert.Empty(t, c.add(mustParse(t, eventQueryReceived, start, queryData("1"))))
+	assert.Empty(t, c.add(mustParse(t, eventQueryReceived, start.Add(time.Second), queryData("2"))))
+
+	// The oldest query is evicted to keep at most two pending queries.
+	events := c.add(mustParse(t, eventQueryReceived, start.Add(2*time.Second), queryData("3")))
+	require.Len(t, events, 1)
+	assertField(t, events[0].Fields, "dns.id", uint16(1))
+	assertField(t, events[0].Fields, "dns.type", "query")
+	assertField(t, events[0].Fields, "event.outcome", "unknown")
+	assertField(t, events[0].Fields, "dns.header_flags", []string{"RD"})
+
+	// Queries older than the timeout expire.
+	events = c.expire(start.Add(2500 * time.Millisecond))
+	require.Len(t, events, 1)
+	assertField(t, events[0].Fields, "dns.id", uint16(2))
+
+	// A retransmitted query replaces the pending one.
+	events = c.add(mustParse(t, eventQueryReceived, start.Add(3*time.Second), queryData("3")))
+	require.Len(t, events, 1)
+	assertField(t, events[0].Fields, "dns.id", uint16(3))
+	assert.Equal(t, start.Add(2*time.Second), events[0].Timestamp)
+	assert.Len(t, c.flush(), 1)
+}
+
+func TestParseMessage(t *testing.T) {
+	_, ok, err := parseMessage(260, start, queryData("1"))
+	assert.NoError(t, err)
+	assert.False(t, ok, "recursive queries are ignored")
+
+	data := queryData("not a number")
+	_, ok, err = parseMessage(eventQueryReceived, start, data)
+	assert.True(t, ok)
+	assert.ErrorContains(t, err, "invalid XID")
+}
+
+func assertField(t *testing.T, fields mapstr.M, key string, want any) {
+	t.Helper()
+	got, err := fields.GetValue(key)
+	if assert.NoError(t, err, key) {
+		assert.Equal(t, want, got, key)
+	}
+}
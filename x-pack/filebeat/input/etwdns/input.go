@@ -0,0 +1,161 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+//go:build windows
+
+package etwdns
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/windows"
+
+	input "github.com/elastic/beats/v7/filebeat/input/v2"
+	stateless "github.com/elastic/beats/v7/filebeat/input/v2/input-stateless"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/feature"
+	"github.com/elastic/beats/v7/x-pack/libbeat/reader/etw"
+	conf "github.com/elastic/elastic-agent-libs/config"
+)
+
+const (
+	inputName = "etw-dns"
+
+	// dnsServerProvider is the GUID of the Microsoft-Windows-DNSServer provider.
+	dnsServerProvider = "{EB79061A-A566-4698-9119-3ED2807060E7}"
+)
+
+// dnsInput reads the analytical events of the Windows DNS server and
+// publishes each query with its response as a single event.
+type dnsInput struct {
+	config config
+}
+
+func Plugin() input.Plugin {
+	return input.Plugin{
+		Name:      inputName,
+		Stability: feature.Beta,
+		Info:      "Collect Windows DNS server transactions from ETW.",
+		Manager:   stateless.NewInputManager(configure),
+	}
+}
+
+func configure(cfg *conf.C) (stateless.Input, error) {
+	config := defaultConfig()
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, err
+	}
+	return &dnsInput{config: config}, nil
+}
+
+func (i *dnsInput) Name() string { return inputName }
+
+func (i *dnsInput) Test(_ input.TestContext) error {
+	return nil
+}
+
+// Run creates the ETW session and correlates the received events until the
+// input is cancelled.
+func (i *dnsInput) Run(ctx input.Context, publisher stateless.Publisher) error {
+	session, err := etw.NewSession(etw.Config{
+		ProviderGUID:    dnsServerProvider,
+		SessionName:     i.config.SessionName,
+		TraceLevel:      "verbose",
+		MatchAnyKeyword: i.config.MatchAnyKeyword,
+	})
+	if err != nil {
+		return fmt.Errorf("error initializing ETW session: %w", err)
+	}
+
+	log := ctx.Logger.With("session", session.Name)
+	log.Info("Starting " + inputName + " input")
+	defer log.Info(inputName + " input stopped")
+
+	publish := func(events []beat.Event) {
+		for _, e := range events {
+			publisher.Publish(e)
+		}
+	}
+
+	// The provider only writes a few distinct events.
+	cache := etw.NewRenderCache(64)
+	corr := newCorrelator(i.config.Timeout, i.config.MaxPending)
+	session.Callback = func(record *etw.EventRecord) uintptr {
+		if record == nil {
+			return 1
+		}
+		h := record.EventHeader
+		if h.EventDescriptor.Id < eventQueryReceived || h.EventDescriptor.Id > eventResponseFailure {
+			return 0
+		}
+		data, err := cache.GetEventProperties(record)
+		if err != nil {
+			log.Errorw("failed to read event properties", "error", err)
+			return 1
+		}
+		msg, ok, err := parseMessage(h.EventDescriptor.Id, convertFileTime(uint64(h.TimeStamp)), data)
+		if err != nil {
+			log.Errorw("failed to parse DNS event", "error", err, "event_id", h.EventDescriptor.Id)
+			return 1
+		}
+		if ok {
+			publish(corr.add(msg))
+		}
+		return 0
+	}
+
+	if err := session.CreateRealtimeSession(); err != nil {
+		return fmt.Errorf("realtime session could not be created: %w", err)
+	}
+
+	stop := sync.OnceFunc(func() {
+		if err := session.StopSession(); err != nil {
+			log.Errorw("failed to shutdown ETW session", "error", err)
+		}
+	})
+	defer stop()
+
+	// Publish the unanswered queries, and stop the session upon input
+	// cancellation (shutdown).
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(max(i.config.Timeout/2, time.Second))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Cancelation.Done():
+				stop()
+				return
+			case <-done:
+				return
+			case now := <-ticker.C:
+				publish(corr.expire(now))
+			}
+		}
+	}()
+
+	err = session.StartConsumer()
+	close(done)
+	wg.Wait()
+	publish(corr.flush())
+	if err != nil {
+		return fmt.Errorf("failed running ETW consumer: %w", err)
+	}
+	return nil
+}
+
+// convertFileTime converts a Windows FileTime to a Go time.Time structure.
+func convertFileTime(fileTime uint64) time.Time {
+	ft := windows.Filetime{
+		HighDateTime: uint32(fileTime >> 32),
+		LowDateTime:  uint32(fileTime & math.MaxUint32),
+	}
+	return time.Unix(0, ft.Nanoseconds()).UTC()
+}
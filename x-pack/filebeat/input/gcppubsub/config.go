@@ -30,6 +30,12 @@ type config struct {
 		NumGoroutines          int    `config:"num_goroutines"`
 		MaxOutstandingMessages int    `config:"max_outstanding_messages"`
 		Create                 bool   `config:"create"`
+
+		// Settings applied when the subscription is created by the input.
+		EnableExactlyOnceDelivery bool   `config:"enable_exactly_once_delivery"`
+		EnableMessageOrdering     bool   `config:"enable_message_ordering"`
+		DeadLetterTopic           string `config:"dead_letter_topic"`
+		MaxDeliveryAttempts       int    `config:"max_delivery_attempts"`
 	} `config:"subscription"`
 
 	// JSON file containing authentication credentials and key.
@@ -43,6 +49,12 @@ type config struct {
 }
 
 func (c *config) Validate() error {
+	// dead letter policy, see https://cloud.google.com/pubsub/docs/handling-failures
+	if c.Subscription.DeadLetterTopic != "" && c.Subscription.MaxDeliveryAttempts != 0 &&
+		(c.Subscription.MaxDeliveryAttempts < 5 || c.Subscription.MaxDeliveryAttempts > 100) {
+		return fmt.Errorf("subscription.max_delivery_attempts must be between 5 and 100, got %d", c.Subscription.MaxDeliveryAttempts)
+	}
+
 	// credentials_file
 	if c.CredentialsFile != "" {
 		if _, err := os.Stat(c.CredentialsFile); os.IsNotExist(err) {
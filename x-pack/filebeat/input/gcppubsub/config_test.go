@@ -32,3 +32,15 @@ func TestConfigValidateGoogleAppDefaultCreds(t *testing.T) {
 	c := defaultConfig()
 	assert.NoError(t, c.Validate())
 }
+
+func TestConfigValidateMaxDeliveryAttempts(t *testing.T) {
+	c := defaultConfig()
+	c.CredentialsJSON = []byte("{}")
+	c.Subscription.DeadLetterTopic = "dead-letters"
+
+	c.Subscription.MaxDeliveryAttempts = 4
+	assert.Error(t, c.Validate())
+
+	c.Subscription.MaxDeliveryAttempts = 5
+	assert.NoError(t, c.Validate())
+}
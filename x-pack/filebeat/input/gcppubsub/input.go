@@ -10,7 +10,9 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"cloud.google.com/go/pubsub"
@@ -87,6 +89,10 @@ type pubsubInput struct {
 
 	id      string // id is the ID for metrics registration.
 	metrics *inputMetrics
+
+	// exactlyOnce is set when the subscription has exactly-once delivery
+	// enabled. ACKs are then only counted once confirmed by the server.
+	exactlyOnce atomic.Bool
 }
 
 // NewInput creates a new Google Cloud Pub/Sub input that consumes events from
@@ -142,11 +148,12 @@ func NewInput(cfg *conf.C, connector channel.Connector, inputContext input.Conte
 			acker.EventPrivateReporter(func(_ int, privates []interface{}) {
 				for _, priv := range privates {
 					if msg, ok := priv.(*pubsub.Message); ok {
+						if in.exactlyOnce.Load() {
+							in.ackWithResult(msg)
+							continue
+						}
 						msg.Ack()
-
-						in.metrics.ackedMessageCount.Inc()
-						in.metrics.bytesProcessedTotal.Add(uint64(len(msg.Data)))
-						in.metrics.processingTime.Update(time.Since(msg.PublishTime).Nanoseconds())
+						in.recordACK(msg)
 					} else {
 						in.metrics.failedAckedMessageCount.Inc()
 						in.log.Error("Failed ACKing pub/sub event")
@@ -223,18 +230,55 @@ func (in *pubsubInput) run() error {
 	sub.ReceiveSettings.NumGoroutines = in.Subscription.NumGoroutines
 	sub.ReceiveSettings.MaxOutstandingMessages = in.Subscription.MaxOutstandingMessages
 
+	subConfig, err := sub.Config(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get subscription configuration: %w", err)
+	}
+	in.exactlyOnce.Store(subConfig.EnableExactlyOnceDelivery)
+	deadLetter := subConfig.DeadLetterPolicy
+	in.log.Infow("Subscription configuration.",
+		"exactly_once_delivery", subConfig.EnableExactlyOnceDelivery,
+		"message_ordering", subConfig.EnableMessageOrdering,
+		"dead_letter_topic", deadLetterTopic(deadLetter))
+
 	// Start receiving messages.
 	topicID := makeTopicID(in.ProjectID, in.Topic)
 	return sub.Receive(ctx, func(ctx context.Context, msg *pubsub.Message) {
 		if ok := in.outlet.OnEvent(makeEvent(topicID, msg)); !ok {
 			msg.Nack()
 			in.metrics.nackedMessageCount.Inc()
+			if deadLetter != nil && msg.DeliveryAttempt != nil && *msg.DeliveryAttempt >= deadLetter.MaxDeliveryAttempts {
+				in.log.Warnw("NACKed message reached the maximum delivery attempts and will be forwarded to the dead letter topic.",
+					"pubsub_message_id", msg.ID, "dead_letter_topic", deadLetter.DeadLetterTopic)
+			}
 			in.log.Debug("OnEvent returned false. Stopping input worker.")
 			cancel()
 		}
 	})
 }
 
+// ackWithResult ACKs a message of an exactly-once delivery subscription. The
+// ACK is only counted when it is confirmed by the server, messages with a
+// failed ACK are redelivered.
+func (in *pubsubInput) ackWithResult(msg *pubsub.Message) {
+	result := msg.AckWithResult()
+	go func() {
+		status, err := result.Get(in.inputCtx)
+		if status == pubsub.AcknowledgeStatusSuccess {
+			in.recordACK(msg)
+			return
+		}
+		in.metrics.failedAckedMessageCount.Inc()
+		in.log.Warnw("Failed ACKing pub/sub message.", "pubsub_message_id", msg.ID, "status", status, "error", err)
+	}()
+}
+
+func (in *pubsubInput) recordACK(msg *pubsub.Message) {
+	in.metrics.ackedMessageCount.Inc()
+	in.metrics.bytesProcessedTotal.Add(uint64(len(msg.Data)))
+	in.metrics.processingTime.Update(time.Since(msg.PublishTime).Nanoseconds())
+}
+
 // Stop stops the pubsub input and waits for it to fully stop.
 func (in *pubsubInput) Stop() {
 	in.workerCancel()
@@ -278,6 +322,17 @@ func makeEvent(topicID string, msg *pubsub.Message) beat.Event {
 		event.Fields["labels"] = msg.Attributes
 	}
 
+	pubsubFields := mapstr.M{}
+	if msg.OrderingKey != "" {
+		pubsubFields["ordering_key"] = msg.OrderingKey
+	}
+	if msg.DeliveryAttempt != nil {
+		pubsubFields["delivery_attempt"] = *msg.DeliveryAttempt
+	}
+	if len(pubsubFields) > 0 {
+		event.Fields["gcp"] = mapstr.M{"pubsub": pubsubFields}
+	}
+
 	return event
 }
 
@@ -294,9 +349,7 @@ func (in *pubsubInput) getOrCreateSubscription(ctx context.Context, client *pubs
 
 	// Create subscription.
 	if in.Subscription.Create {
-		sub, err = client.CreateSubscription(ctx, in.Subscription.Name, pubsub.SubscriptionConfig{
-			Topic: client.Topic(in.Topic),
-		})
+		sub, err = client.CreateSubscription(ctx, in.Subscription.Name, in.newSubscriptionConfig(client.Topic(in.Topic)))
 		if err != nil {
 			return nil, fmt.Errorf("failed to create subscription: %w", err)
 		}
@@ -307,6 +360,37 @@ func (in *pubsubInput) getOrCreateSubscription(ctx context.Context, client *pubs
 	return nil, errors.New("no subscription exists and 'subscription.create' is not enabled")
 }
 
+func (in *pubsubInput) newSubscriptionConfig(topic *pubsub.Topic) pubsub.SubscriptionConfig {
+	cfg := pubsub.SubscriptionConfig{
+		Topic:                     topic,
+		EnableExactlyOnceDelivery: in.Subscription.EnableExactlyOnceDelivery,
+		EnableMessageOrdering:     in.Subscription.EnableMessageOrdering,
+	}
+	if in.Subscription.DeadLetterTopic != "" {
+		cfg.DeadLetterPolicy = &pubsub.DeadLetterPolicy{
+			DeadLetterTopic:     fullTopicName(in.ProjectID, in.Subscription.DeadLetterTopic),
+			MaxDeliveryAttempts: in.Subscription.MaxDeliveryAttempts,
+		}
+	}
+	return cfg
+}
+
+// fullTopicName returns the fully qualified name of a topic. Topic names that
+// are already qualified are returned unchanged.
+func fullTopicName(project, topic string) string {
+	if strings.HasPrefix(topic, "projects/") {
+		return topic
+	}
+	return "projects/" + project + "/topics/" + topic
+}
+
+func deadLetterTopic(p *pubsub.DeadLetterPolicy) string {
+	if p == nil {
+		return ""
+	}
+	return p.DeadLetterTopic
+}
+
 func (in *pubsubInput) newPubsubClient(ctx context.Context) (*pubsub.Client, error) {
 	opts := []option.ClientOption{option.WithUserAgent(useragent.UserAgent("Filebeat", version.GetDefaultVersion(), version.Commit(), version.BuildTime().String()))}
 
@@ -8,6 +8,10 @@ package gcppubsub
 
 import (
 	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/stretchr/testify/assert"
 
 	"github.com/elastic/beats/v7/filebeat/input/inputtest"
 	"github.com/elastic/elastic-agent-libs/mapstr"
@@ -25,3 +29,44 @@ func TestNewInputDone(t *testing.T) {
 	}
 	inputtest.AssertNotStartedInputCanBeDone(t, NewInput, &config)
 }
+
+func TestMakeEventOrderingAndDeliveryAttempt(t *testing.T) {
+	attempt := 3
+	msg := &pubsub.Message{
+		ID:              "123",
+		Data:            []byte("hello"),
+		PublishTime:     time.Now(),
+		OrderingKey:     "resource-1",
+		DeliveryAttempt: &attempt,
+	}
+
+	event := makeEvent("topic", msg)
+	assert.Equal(t, mapstr.M{
+		"pubsub": mapstr.M{
+			"ordering_key":     "resource-1",
+			"delivery_attempt": 3,
+		},
+	}, event.Fields["gcp"])
+
+	event = makeEvent("topic", &pubsub.Message{ID: "124", PublishTime: time.Now()})
+	assert.NotContains(t, event.Fields, "gcp")
+}
+
+func TestNewSubscriptionConfig(t *testing.T) {
+	in := &pubsubInput{config: defaultConfig()}
+	in.ProjectID = "some-project"
+	in.Subscription.EnableExactlyOnceDelivery = true
+	in.Subscription.EnableMessageOrdering = true
+	in.Subscription.DeadLetterTopic = "dead-letters"
+	in.Subscription.MaxDeliveryAttempts = 10
+
+	cfg := in.newSubscriptionConfig(nil)
+	assert.True(t, cfg.EnableExactlyOnceDelivery)
+	assert.True(t, cfg.EnableMessageOrdering)
+	assert.Equal(t, &pubsub.DeadLetterPolicy{
+		DeadLetterTopic:     "projects/some-project/topics/dead-letters",
+		MaxDeliveryAttempts: 10,
+	}, cfg.DeadLetterPolicy)
+
+	assert.Equal(t, "projects/other/topics/dlq", fullTopicName("some-project", "projects/other/topics/dlq"))
+}
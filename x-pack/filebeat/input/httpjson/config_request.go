@@ -64,6 +64,9 @@ type rateLimitConfig struct {
 	Reset      *valueTpl `config:"reset"`
 	Remaining  *valueTpl `config:"remaining"`
 	EarlyLimit *float64  `config:"early_limit"`
+
+	// SharedKey is the key of a rate limit shared by all inputs using it.
+	SharedKey string `config:"shared_key"`
 }
 
 func (c rateLimitConfig) Validate() error {
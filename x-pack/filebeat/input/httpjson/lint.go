@@ -0,0 +1,52 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package httpjson
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/elastic/beats/v7/filebeat/lint"
+	conf "github.com/elastic/elastic-agent-libs/config"
+)
+
+func init() {
+	lint.RegisterInputChecker(inputName, checkTemplates)
+}
+
+// templateKeys matches the flattened keys of the settings holding a template.
+var templateKeys = regexp.MustCompile(`^(?:` +
+	`(?:chain\.\d+\.(?:step|while)\.)?request\.transforms\.\d+\.(?:set|append)\.(?:value|default)` +
+	`|(?:chain\.\d+\.(?:step|while)\.)?response\.(?:transforms|pagination)\.\d+\.(?:set|append)\.(?:value|default)` +
+	`|(?:chain\.\d+\.(?:step|while)\.)?request\.rate_limit\.(?:limit|reset|remaining)` +
+	`|chain\.\d+\.while\.until` +
+	`|cursor\..+\.(?:value|default)` +
+	`)$`)
+
+// checkTemplates checks that the templates of the request, response,
+// pagination, rate limit, cursor and chain settings can be parsed.
+func checkTemplates(cfg *conf.C) []lint.Finding {
+	var findings []lint.Finding
+	for _, key := range cfg.FlattenedKeys() {
+		if !templateKeys.MatchString(key) {
+			continue
+		}
+		s, err := cfg.String(key, -1)
+		if err != nil {
+			// Settings of the wrong type are reported by test config.
+			continue
+		}
+		var tpl valueTpl
+		if err := tpl.Unpack(s); err != nil {
+			findings = append(findings, lint.Finding{
+				Severity: lint.SeverityError,
+				Rule:     "httpjson.template",
+				Path:     key,
+				Message:  fmt.Sprintf("invalid template %q: %v", s, err),
+			})
+		}
+	}
+	return findings
+}
@@ -0,0 +1,59 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package httpjson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	conf "github.com/elastic/elastic-agent-libs/config"
+)
+
+func TestCheckTemplates(t *testing.T) {
+	cfg := func(value string) map[string]interface{} {
+		return map[string]interface{}{
+			"interval":       "1m",
+			"request.url":    "http://localhost",
+			"request.method": "GET",
+			"request.transforms": []interface{}{
+				map[string]interface{}{"set": map[string]interface{}{
+					"target": "url.params.since",
+					"value":  value,
+				}},
+			},
+			"cursor.since.value": "[[.last_event.published]]",
+			"chain": []interface{}{
+				map[string]interface{}{"while": map[string]interface{}{
+					"request.url": "http://localhost/$.id",
+					"until":       "[[ eq .last_response.body.status \"done\" ]]",
+				}},
+			},
+		}
+	}
+	assert.Empty(t, checkTemplates(conf.MustNewConfigFrom(cfg("[[.cursor.since]]"))))
+
+	findings := checkTemplates(conf.MustNewConfigFrom(cfg("[[.cursor.since")))
+	require.Len(t, findings, 1)
+	assert.Equal(t, "httpjson.template", findings[0].Rule)
+	assert.Equal(t, "request.transforms.0.set.value", findings[0].Path)
+	assert.Contains(t, findings[0].Message, "unclosed action")
+
+	invalid := cfg("[[.cursor.since]]")
+	invalid["cursor.since.default"] = "[[ now"
+	invalid["chain"] = []interface{}{
+		map[string]interface{}{"while": map[string]interface{}{
+			"request.url": "http://localhost/$.id",
+			"until":       "[[ eq ]",
+		}},
+	}
+	findings = checkTemplates(conf.MustNewConfigFrom(invalid))
+	paths := make([]string, 0, len(findings))
+	for _, f := range findings {
+		paths = append(paths, f.Path)
+	}
+	assert.ElementsMatch(t, []string{"chain.0.while.until", "cursor.since.default"}, paths)
+}
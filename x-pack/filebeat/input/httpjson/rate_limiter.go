@@ -12,6 +12,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/internal/ratelimit"
 	"github.com/elastic/elastic-agent-libs/logp"
 )
 
@@ -21,6 +22,10 @@ type rateLimiter struct {
 	remaining  *valueTpl
 	earlyLimit *float64
 
+	// shared is paused when the quota is exhausted, so that all inputs
+	// using the same API quota wait for it to reset.
+	shared *ratelimit.Limiter
+
 	log *logp.Logger
 }
 
@@ -29,17 +34,27 @@ func newRateLimiterFromConfig(config *rateLimitConfig, log *logp.Logger) *rateLi
 		return nil
 	}
 
-	return &rateLimiter{
+	r := &rateLimiter{
 		log:        log,
 		limit:      config.Limit,
 		reset:      config.Reset,
 		remaining:  config.Remaining,
 		earlyLimit: config.EarlyLimit,
 	}
+	if config.SharedKey != "" {
+		r.shared = ratelimit.Get(config.SharedKey)
+	}
+	return r
 }
 
 func (r *rateLimiter) execute(ctx context.Context, f func() (*http.Response, error)) (*http.Response, error) {
 	for {
+		if r != nil && r.shared != nil {
+			if err := r.shared.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+
 		resp, err := f()
 		if err != nil {
 			return nil, err
@@ -69,6 +84,9 @@ func (r *rateLimiter) applyRateLimit(ctx context.Context, resp *http.Response) (
 	}
 
 	t := time.Unix(resumeAt, 0)
+	if r.shared != nil && resumeAt != 0 {
+		t = r.shared.PauseUntil(t)
+	}
 	w := time.Until(t)
 	if resumeAt == 0 || w <= 0 {
 		r.log.Debugf("Rate Limit: No need to apply rate limit.")
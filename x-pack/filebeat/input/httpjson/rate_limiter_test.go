@@ -5,6 +5,7 @@
 package httpjson
 
 import (
+	"context"
 	"net/http"
 	"strconv"
 	"testing"
@@ -248,3 +249,34 @@ func TestGetRateLimitWhenMissingLimit(t *testing.T) {
 	assert.False(t, applied)
 	assert.EqualValues(t, 0, resumeAt)
 }
+
+func TestSharedRateLimitPausesOtherInputs(t *testing.T) {
+	reset := time.Now().Add(time.Second).Unix() + 1
+
+	header := make(http.Header)
+	header.Add("X-Rate-Limit-Remaining", "0")
+	header.Add("X-Rate-Limit-Reset", strconv.FormatInt(reset, 10))
+	tplReset := &valueTpl{}
+	tplRemaining := &valueTpl{}
+	assert.NoError(t, tplReset.Unpack(`[[.last_response.header.Get "X-Rate-Limit-Reset"]]`))
+	assert.NoError(t, tplRemaining.Unpack(`[[.last_response.header.Get "X-Rate-Limit-Remaining"]]`))
+	cfg := &rateLimitConfig{Reset: tplReset, Remaining: tplRemaining, SharedKey: t.Name()}
+	first := newRateLimiterFromConfig(cfg, logp.NewLogger(""))
+	second := newRateLimiterFromConfig(cfg, logp.NewLogger(""))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	applied, err := first.applyRateLimit(ctx, &http.Response{Header: header})
+	assert.NoError(t, err)
+	assert.True(t, applied)
+
+	// The second input must wait for the quota to reset although it
+	// didn't get a rate limited response itself.
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = second.execute(ctx, func() (*http.Response, error) {
+		t.Fatal("request sent while the shared rate limit is exhausted")
+		return nil, nil
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,108 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// Package ratelimit provides rate limiters that are shared by the inputs of a
+// beat that collect from the same API, so that they collectively respect one
+// API quota.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Limiter is a rate limiter shared by all inputs using the same key.
+type Limiter struct {
+	*rate.Limiter
+
+	mu       sync.Mutex
+	resumeAt time.Time
+
+	// users is the number of inputs that configured the limit and burst
+	// of the limiter.
+	users int
+}
+
+var (
+	mu       sync.Mutex
+	limiters = map[string]*Limiter{}
+)
+
+// Get returns the limiter shared under key, typically the API host or tenant.
+// A new limiter doesn't limit requests until it is configured or paused.
+func Get(key string) *Limiter {
+	mu.Lock()
+	defer mu.Unlock()
+
+	l, ok := limiters[key]
+	if !ok {
+		l = &Limiter{Limiter: rate.NewLimiter(rate.Inf, 1)}
+		limiters[key] = l
+	}
+	return l
+}
+
+// Configure sets the limit and burst of the limiter. All inputs configuring
+// the same shared limiter must use the same settings, otherwise an error is
+// returned and the settings of the inputs already using the limiter are kept.
+// The returned function must be called when the input stops, after which the
+// limiter can be configured with different settings once no input uses it.
+func (l *Limiter) Configure(limit rate.Limit, burst int) (release func(), err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.users == 0 {
+		l.SetLimit(limit)
+		l.SetBurst(burst)
+	} else if l.Limit() != limit || l.Burst() != burst {
+		return nil, fmt.Errorf("rate limit settings (limit=%v burst=%d) conflict with the settings of other inputs sharing the rate limit (limit=%v burst=%d)",
+			limit, burst, l.Limit(), l.Burst())
+	}
+	l.users++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+
+			l.users--
+			if l.users == 0 {
+				l.SetLimit(rate.Inf)
+				l.SetBurst(1)
+			}
+		})
+	}, nil
+}
+
+// PauseUntil stops requests of all inputs sharing the limiter until t, when
+// the quota of the API is exhausted. It returns the time requests can resume,
+// which is later than t if another input paused the limiter for longer.
+func (l *Limiter) PauseUntil(t time.Time) time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if t.After(l.resumeAt) {
+		l.resumeAt = t
+	}
+	return l.resumeAt
+}
+
+// Wait blocks until the limiter is not paused and allows a request.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if wait := time.Until(l.PauseUntil(time.Time{})); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return l.Limiter.Wait(ctx)
+}
@@ -0,0 +1,68 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+)
+
+func TestGet(t *testing.T) {
+	a := Get(t.Name() + "/a")
+	assert.Same(t, a, Get(t.Name()+"/a"))
+	assert.NotSame(t, a, Get(t.Name()+"/b"))
+	assert.Equal(t, rate.Inf, a.Limit())
+}
+
+func TestPauseUntil(t *testing.T) {
+	l := Get(t.Name())
+	now := time.Now()
+
+	assert.Equal(t, now.Add(time.Minute), l.PauseUntil(now.Add(time.Minute)))
+	assert.Equal(t, now.Add(time.Minute), l.PauseUntil(now.Add(time.Second)), "an earlier reset must not shorten the pause")
+	assert.Equal(t, now.Add(time.Minute), l.PauseUntil(time.Time{}))
+}
+
+func TestWait(t *testing.T) {
+	l := Get(t.Name())
+	assert.NoError(t, l.Wait(context.Background()))
+
+	l.PauseUntil(time.Now().Add(time.Hour))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, l.Wait(ctx), context.DeadlineExceeded)
+}
+
+func TestConfigure(t *testing.T) {
+	l := Get(t.Name())
+
+	release, err := l.Configure(10, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, rate.Limit(10), l.Limit())
+	assert.Equal(t, 5, l.Burst())
+
+	other, err := l.Configure(10, 5)
+	assert.NoError(t, err, "inputs with the same settings must share the limiter")
+
+	_, err = l.Configure(20, 5)
+	assert.Error(t, err, "conflicting settings must be rejected")
+	assert.Equal(t, rate.Limit(10), l.Limit(), "conflicting settings must not change the limiter")
+
+	release()
+	release()
+	_, err = l.Configure(20, 5)
+	assert.Error(t, err, "settings must be kept while an input uses the limiter")
+
+	other()
+	release, err = l.Configure(20, 5)
+	assert.NoError(t, err, "the limiter must be reconfigurable once no input uses it")
+	assert.Equal(t, rate.Limit(20), l.Limit())
+	release()
+	assert.Equal(t, rate.Inf, l.Limit())
+}
@@ -0,0 +1,119 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package jetstream
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/acker"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+// ackTracker keeps the messages whose events are not yet acknowledged by the
+// output, so that the server can be told they are still in progress.
+type ackTracker struct {
+	mu      sync.Mutex
+	pending map[string]jetstream.Msg // Keyed by reply subject, unique per delivery.
+}
+
+func newACKTracker() *ackTracker {
+	return &ackTracker{pending: make(map[string]jetstream.Msg)}
+}
+
+func (t *ackTracker) add(msg jetstream.Msg) {
+	t.mu.Lock()
+	t.pending[msg.Reply()] = msg
+	t.mu.Unlock()
+}
+
+func (t *ackTracker) remove(msg jetstream.Msg) {
+	t.mu.Lock()
+	delete(t.pending, msg.Reply())
+	t.mu.Unlock()
+}
+
+// clear forgets all the pending messages, the server delivers them again
+// once their ack_wait expires.
+func (t *ackTracker) clear() {
+	t.mu.Lock()
+	clear(t.pending)
+	t.mu.Unlock()
+}
+
+func (t *ackTracker) snapshot() []jetstream.Msg {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	msgs := make([]jetstream.Msg, 0, len(t.pending))
+	for _, msg := range t.pending {
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+// keepInProgress sends an in-progress acknowledgement for the pending messages
+// every interval until ctx is done, resetting their ack_wait so that the
+// server doesn't deliver them again while their events wait in the queue or
+// are retried by the output.
+func (t *ackTracker) keepInProgress(ctx context.Context, interval time.Duration, metrics *inputMetrics, log *logp.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for _, msg := range t.snapshot() {
+			if err := msg.InProgress(); err != nil {
+				metrics.ackErrorsTotal.Inc()
+				log.Debugw("Failed to mark message in progress.", "reply", msg.Reply(), "error", err)
+				continue
+			}
+			metrics.inProgressTotal.Inc()
+		}
+	}
+}
+
+// newEventACKHandler returns a beat ACKer that acknowledges the messages of
+// the events acknowledged by the output. With the "all" policy acknowledging
+// a message also acknowledges all the previous ones, so only the last message
+// of each group of acknowledged events is acknowledged.
+func newEventACKHandler(policy string, tracker *ackTracker, metrics *inputMetrics, log *logp.Logger) beat.EventListener {
+	ack := func(msg jetstream.Msg) {
+		if err := msg.Ack(); err != nil {
+			// The server will deliver the message again once ack_wait
+			// expires.
+			metrics.ackErrorsTotal.Inc()
+			log.Debugw("Failed to acknowledge message.", "reply", msg.Reply(), "error", err)
+			return
+		}
+		metrics.messagesACKedTotal.Inc()
+	}
+	return acker.ConnectionOnly(
+		acker.EventPrivateReporter(func(_ int, privates []interface{}) {
+			var last jetstream.Msg
+			for _, private := range privates {
+				msg, ok := private.(jetstream.Msg)
+				if !ok {
+					continue
+				}
+				tracker.remove(msg)
+				if policy == "all" {
+					last = msg
+					continue
+				}
+				ack(msg)
+			}
+			if last != nil {
+				ack(last)
+			}
+		}),
+	)
+}
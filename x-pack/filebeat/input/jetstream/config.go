@@ -0,0 +1,185 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package jetstream
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+)
+
+type config struct {
+	// Hosts is the list of NATS servers to connect to, as host:port or as
+	// nats:// or tls:// URLs.
+	Hosts []string `config:"hosts" validate:"required"`
+
+	// Stream is the JetStream stream to consume.
+	Stream string `config:"stream" validate:"required"`
+
+	// Consumer is the name of the durable pull consumer. Several inputs using
+	// the same consumer share its messages, unless AckPolicy is "all".
+	Consumer string `config:"consumer" validate:"required"`
+
+	// Subjects filters the subjects of the stream to consume.
+	Subjects []string `config:"subjects"`
+
+	// DeliverPolicy sets where a new consumer starts in the stream.
+	DeliverPolicy string `config:"deliver_policy"`
+
+	// AckPolicy sets how messages are acknowledged once the events are
+	// acknowledged by the output.
+	AckPolicy string `config:"ack_policy"`
+
+	// AckWait is the time the server waits for an acknowledgement before
+	// delivering a message again.
+	AckWait time.Duration `config:"ack_wait" validate:"positive"`
+
+	// MaxAckPending limits the number of messages delivered but not yet
+	// acknowledged.
+	MaxAckPending int `config:"max_ack_pending" validate:"positive"`
+
+	// MaxDeliver limits the number of delivery attempts of a message, 0 means
+	// no limit.
+	MaxDeliver int `config:"max_deliver" validate:"min=0"`
+
+	// BatchSize is the maximum number of messages requested at once.
+	BatchSize int `config:"batch_size" validate:"positive"`
+
+	// MaxWait is how long a pull request waits for messages.
+	MaxWait time.Duration `config:"max_wait" validate:"positive"`
+
+	Username string `config:"username"`
+	Password string `config:"password"`
+	Token    string `config:"token"`
+
+	TLS *tlscommon.Config `config:"ssl"`
+
+	ConnectTimeout time.Duration `config:"connect_timeout" validate:"positive"`
+	PingInterval   time.Duration `config:"ping_interval" validate:"positive"`
+
+	Backoff struct {
+		Init time.Duration `config:"init" validate:"positive"`
+		Max  time.Duration `config:"max" validate:"positive"`
+	} `config:"backoff"`
+}
+
+func defaultConfig() config {
+	c := config{
+		DeliverPolicy:  "all",
+		AckPolicy:      "explicit",
+		AckWait:        30 * time.Second,
+		MaxAckPending:  1000,
+		BatchSize:      100,
+		MaxWait:        5 * time.Second,
+		ConnectTimeout: 10 * time.Second,
+		PingInterval:   2 * time.Minute,
+	}
+	c.Backoff.Init = time.Second
+	c.Backoff.Max = time.Minute
+	return c
+}
+
+func (c *config) Validate() error {
+	if err := validateName("stream", c.Stream); err != nil {
+		return err
+	}
+	if err := validateName("consumer", c.Consumer); err != nil {
+		return err
+	}
+	for _, s := range c.Subjects {
+		if s == "" || strings.ContainsAny(s, " \t\r\n") {
+			return fmt.Errorf("invalid subject %q", s)
+		}
+	}
+	switch c.DeliverPolicy {
+	case "all", "last", "new", "last_per_subject":
+	default:
+		return fmt.Errorf("invalid deliver_policy %q, must be one of all, last, new or last_per_subject", c.DeliverPolicy)
+	}
+	switch c.AckPolicy {
+	case "explicit", "all", "none":
+	default:
+		return fmt.Errorf("invalid ack_policy %q, must be one of explicit, all or none", c.AckPolicy)
+	}
+	if c.BatchSize > c.MaxAckPending {
+		return fmt.Errorf("batch_size (%d) cannot be larger than max_ack_pending (%d)", c.BatchSize, c.MaxAckPending)
+	}
+	if c.Token != "" && c.Username != "" {
+		return errors.New("token cannot be combined with username and password")
+	}
+	if c.Backoff.Init > c.Backoff.Max {
+		return errors.New("backoff.init cannot be larger than backoff.max")
+	}
+	return nil
+}
+
+// validateName checks that stream and consumer names are valid subject
+// tokens.
+func validateName(kind, name string) error {
+	if strings.ContainsAny(name, ".*> \t\r\n") {
+		return fmt.Errorf("invalid %s name %q, it cannot contain '.', '*', '>' or whitespace", kind, name)
+	}
+	return nil
+}
+
+var (
+	deliverPolicies = map[string]jetstream.DeliverPolicy{
+		"all":              jetstream.DeliverAllPolicy,
+		"last":             jetstream.DeliverLastPolicy,
+		"new":              jetstream.DeliverNewPolicy,
+		"last_per_subject": jetstream.DeliverLastPerSubjectPolicy,
+	}
+	ackPolicies = map[string]jetstream.AckPolicy{
+		"explicit": jetstream.AckExplicitPolicy,
+		"all":      jetstream.AckAllPolicy,
+		"none":     jetstream.AckNonePolicy,
+	}
+)
+
+// ackPolicyName returns the configuration name of an ack policy.
+func ackPolicyName(p jetstream.AckPolicy) string {
+	for name, policy := range ackPolicies {
+		if policy == p {
+			return name
+		}
+	}
+	return p.String()
+}
+
+// consumerConfig returns the configuration of the durable consumer.
+func (c *config) consumerConfig() jetstream.ConsumerConfig {
+	cc := jetstream.ConsumerConfig{
+		Durable:       c.Consumer,
+		DeliverPolicy: deliverPolicies[c.DeliverPolicy],
+		AckPolicy:     ackPolicies[c.AckPolicy],
+		AckWait:       c.AckWait,
+		MaxAckPending: c.MaxAckPending,
+		MaxDeliver:    c.MaxDeliver,
+	}
+	switch c.AckPolicy {
+	case "none":
+		// The server rejects these settings for consumers without acks.
+		cc.AckWait, cc.MaxAckPending, cc.MaxDeliver = 0, 0, 0
+	case "all":
+		// Acknowledging a message acknowledges all the previous ones,
+		// including those delivered to other clients, so the consumer
+		// accepts the pull requests of a single client.
+		cc.MaxWaiting = 1
+	}
+	switch len(c.Subjects) {
+	case 0:
+	case 1:
+		cc.FilterSubject = c.Subjects[0]
+	default:
+		// Multiple filter subjects require NATS server 2.10.
+		cc.FilterSubjects = c.Subjects
+	}
+	return cc
+}
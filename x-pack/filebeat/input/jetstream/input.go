@@ -0,0 +1,293 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// Package jetstream implements an input consuming messages from NATS
+// JetStream streams through a durable pull consumer. Messages are
+// acknowledged to the server once their events are acknowledged by the
+// output, so the consumer tracks the progress of the input.
+package jetstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	v2 "github.com/elastic/beats/v7/filebeat/input/v2"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/backoff"
+	"github.com/elastic/beats/v7/libbeat/feature"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+	"github.com/elastic/go-concert/ctxtool"
+)
+
+const inputName = "jetstream"
+
+func Plugin() v2.Plugin {
+	return v2.Plugin{
+		Name:      inputName,
+		Stability: feature.Experimental,
+		Info:      "NATS JetStream",
+		Doc:       "Consume messages from NATS JetStream streams",
+		Manager:   v2.ConfigureWith(configure),
+	}
+}
+
+func configure(cfg *conf.C) (v2.Input, error) {
+	config := defaultConfig()
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, err
+	}
+	return newInput(config)
+}
+
+// jetstreamInput implements the Filebeat input V2 interface. The input is
+// stateless, the progress is kept by the durable consumer on the server.
+type jetstreamInput struct {
+	config config
+	tls    *tlscommon.TLSConfig
+}
+
+func newInput(config config) (*jetstreamInput, error) {
+	tlsConfig, err := tlscommon.LoadTLSConfig(config.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS configuration: %w", err)
+	}
+	return &jetstreamInput{config: config, tls: tlsConfig}, nil
+}
+
+func (inp *jetstreamInput) Name() string { return inputName }
+
+func (inp *jetstreamInput) Test(ctx v2.TestContext) error {
+	nc, err := inp.connect()
+	if err != nil {
+		return err
+	}
+	nc.Close()
+	return nil
+}
+
+// connect connects to the first available server. The connection doesn't
+// reconnect, the input sets up the consumer again after connection errors.
+func (inp *jetstreamInput) connect() (*nats.Conn, error) {
+	opts := []nats.Option{
+		nats.Name("beats-" + inputName),
+		nats.DontRandomize(),
+		nats.NoReconnect(),
+		nats.Timeout(inp.config.ConnectTimeout),
+		nats.PingInterval(inp.config.PingInterval),
+		nats.MaxPingsOutstanding(2),
+	}
+	if inp.tls != nil {
+		opts = append(opts, nats.Secure(inp.tls.BuildModuleClientConfig("")))
+	}
+	switch {
+	case inp.config.Token != "":
+		opts = append(opts, nats.Token(inp.config.Token))
+	case inp.config.Username != "":
+		opts = append(opts, nats.UserInfo(inp.config.Username, inp.config.Password))
+	}
+	nc, err := nats.Connect(strings.Join(inp.config.Hosts, ","), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %v: %w", inp.config.Hosts, err)
+	}
+	return nc, nil
+}
+
+func (inp *jetstreamInput) Run(ctx v2.Context, pipeline beat.Pipeline) error {
+	log := ctx.Logger.With("stream", inp.config.Stream, "consumer", inp.config.Consumer)
+	log.Info("Starting " + inputName + " input")
+	defer log.Info(inputName + " input stopped")
+
+	metrics := newInputMetrics(ctx.ID, nil)
+	defer metrics.Close()
+
+	tracker := newACKTracker()
+	client, err := pipeline.ConnectWith(beat.ClientConfig{
+		EventListener: newEventACKHandler(inp.config.AckPolicy, tracker, metrics, log),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create pipeline client: %w", err)
+	}
+	defer client.Close()
+
+	cancelCtx := ctxtool.FromCanceller(ctx.Cancelation)
+	b := backoff.NewEqualJitterBackoff(cancelCtx.Done(), inp.config.Backoff.Init, inp.config.Backoff.Max)
+	for cancelCtx.Err() == nil {
+		err := inp.consume(cancelCtx, client, tracker, metrics, log, b)
+		if cancelCtx.Err() != nil {
+			break
+		}
+		metrics.errorsTotal.Inc()
+		log.Errorw("Failed consuming from JetStream, reconnecting.", "error", err)
+		b.Wait()
+	}
+	return nil
+}
+
+// consume connects to a server, sets up the consumer and publishes its
+// messages until an error happens or the input is stopped.
+func (inp *jetstreamInput) consume(ctx context.Context, client beat.Client, tracker *ackTracker, metrics *inputMetrics, log *logp.Logger, b backoff.Backoff) error {
+	nc, err := inp.connect()
+	if err != nil {
+		return err
+	}
+	defer nc.Close()
+	stop := context.AfterFunc(ctx, nc.Close)
+	defer stop()
+	metrics.server.Set(nc.ConnectedAddr())
+
+	cons, err := inp.createConsumer(ctx, nc)
+	if err != nil {
+		return err
+	}
+	log.Infow("Consuming from JetStream.", "server", nc.ConnectedAddr(), "server_version", nc.ConnectedServerVersion(), "filter_subjects", inp.config.Subjects)
+	b.Reset()
+
+	if inp.config.AckPolicy != "none" {
+		// The messages of this connection can't be acknowledged anymore
+		// once it's closed.
+		defer tracker.clear()
+		inProgressCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go tracker.keepInProgress(inProgressCtx, max(inp.config.AckWait/2, time.Millisecond), metrics, log)
+	}
+
+	for {
+		metrics.fetchRequestsTotal.Inc()
+		batch, err := cons.Fetch(inp.config.BatchSize, jetstream.FetchMaxWait(inp.config.MaxWait))
+		if err != nil {
+			return inp.fetchError(ctx, err)
+		}
+		msgs := batch.Messages()
+	messages:
+		for {
+			var msg jetstream.Msg
+			var ok bool
+			select {
+			case <-ctx.Done():
+				// The pull request is dropped with the connection.
+				return nil
+			case msg, ok = <-msgs:
+				if !ok {
+					break messages
+				}
+			}
+			meta, err := msg.Metadata()
+			if err != nil {
+				return err
+			}
+			metrics.messagesReceivedTotal.Inc()
+			metrics.bytesReceivedTotal.Add(uint64(len(msg.Data())))
+			if meta.NumDelivered > 1 {
+				metrics.redeliveredTotal.Inc()
+			}
+			event := makeEvent(msg, meta)
+			if inp.config.AckPolicy != "none" {
+				tracker.add(msg)
+				event.Private = msg
+			}
+			client.Publish(event)
+		}
+		if err := batch.Error(); err != nil {
+			return inp.fetchError(ctx, err)
+		}
+	}
+}
+
+// createConsumer creates the durable consumer or updates the existing one,
+// after checking that its settings that can't be updated match the
+// configuration.
+func (inp *jetstreamInput) createConsumer(ctx context.Context, nc *nats.Conn) (jetstream.Consumer, error) {
+	ctx, cancel := context.WithTimeout(ctx, inp.config.ConnectTimeout)
+	defer cancel()
+
+	js, err := jetstream.New(nc)
+	if err != nil {
+		return nil, err
+	}
+	cons, err := js.Consumer(ctx, inp.config.Stream, inp.config.Consumer)
+	switch {
+	case err == nil:
+		if err := inp.checkConsumer(cons.CachedInfo()); err != nil {
+			return nil, err
+		}
+	case !errors.Is(err, jetstream.ErrConsumerNotFound):
+		return nil, fmt.Errorf("failed to get consumer %q of stream %q: %w", inp.config.Consumer, inp.config.Stream, err)
+	}
+	cons, err = js.CreateOrUpdateConsumer(ctx, inp.config.Stream, inp.config.consumerConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consumer %q of stream %q: %w", inp.config.Consumer, inp.config.Stream, err)
+	}
+	return cons, nil
+}
+
+// checkConsumer checks that an existing consumer can be used with the
+// configured ack policy.
+func (inp *jetstreamInput) checkConsumer(info *jetstream.ConsumerInfo) error {
+	policy := ackPolicyName(info.Config.AckPolicy)
+	if policy != inp.config.AckPolicy {
+		return fmt.Errorf("existing consumer uses ack policy %q, which cannot be changed to %q", policy, inp.config.AckPolicy)
+	}
+	if policy != "all" {
+		return nil
+	}
+	if info.Config.MaxWaiting != 1 {
+		return fmt.Errorf("existing consumer accepts %d concurrent pull requests, ack policy \"all\" requires a consumer created by a single input", info.Config.MaxWaiting)
+	}
+	if info.NumWaiting > 0 {
+		return errSharedConsumer
+	}
+	return nil
+}
+
+var errSharedConsumer = errors.New(`consumer is used by another client, ack policy "all" requires an exclusive consumer`)
+
+// fetchError returns the error of a pull request, or nil if the input is
+// stopped.
+func (inp *jetstreamInput) fetchError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+	// With the "all" ack policy the consumer accepts a single pull request.
+	if strings.Contains(err.Error(), "Exceeded MaxWaiting") {
+		return fmt.Errorf("%w: %w", errSharedConsumer, err)
+	}
+	return err
+}
+
+func makeEvent(msg jetstream.Msg, meta *jetstream.MsgMetadata) beat.Event {
+	fields := mapstr.M{
+		"subject":   msg.Subject(),
+		"stream":    meta.Stream,
+		"consumer":  meta.Consumer,
+		"delivered": meta.NumDelivered,
+		"pending":   meta.NumPending,
+		"sequence": mapstr.M{
+			"stream":   meta.Sequence.Stream,
+			"consumer": meta.Sequence.Consumer,
+		},
+	}
+	if len(msg.Headers()) > 0 {
+		headers := make(mapstr.M, len(msg.Headers()))
+		for k, v := range msg.Headers() {
+			headers[k] = v
+		}
+		fields["headers"] = headers
+	}
+	return beat.Event{
+		Timestamp: meta.Timestamp,
+		Fields: mapstr.M{
+			"message": string(msg.Data()),
+			"nats":    fields,
+		},
+	}
+}
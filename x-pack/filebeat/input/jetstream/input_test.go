@@ -0,0 +1,338 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package jetstream
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v2 "github.com/elastic/beats/v7/filebeat/input/v2"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// testPipeline acknowledges the events as soon as they are published, or
+// after ackDelay.
+type testPipeline struct {
+	ackDelay time.Duration
+
+	mu     sync.Mutex
+	events []beat.Event
+}
+
+func (p *testPipeline) Connect() (beat.Client, error) {
+	return p.ConnectWith(beat.ClientConfig{})
+}
+
+func (p *testPipeline) ConnectWith(cfg beat.ClientConfig) (beat.Client, error) {
+	return &testClient{pipeline: p, listener: cfg.EventListener}, nil
+}
+
+func (p *testPipeline) Events() []beat.Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]beat.Event(nil), p.events...)
+}
+
+type testClient struct {
+	pipeline *testPipeline
+	listener beat.EventListener
+}
+
+func (c *testClient) Publish(event beat.Event) {
+	c.pipeline.mu.Lock()
+	c.pipeline.events = append(c.pipeline.events, event)
+	c.pipeline.mu.Unlock()
+	if c.listener != nil {
+		c.listener.AddEvent(event, true)
+		if c.pipeline.ackDelay > 0 {
+			time.AfterFunc(c.pipeline.ackDelay, func() { c.listener.ACKEvents(1) })
+			return
+		}
+		c.listener.ACKEvents(1)
+	}
+}
+
+func (c *testClient) PublishAll(events []beat.Event) {
+	for _, e := range events {
+		c.Publish(e)
+	}
+}
+
+func (c *testClient) Close() error {
+	if c.listener != nil {
+		c.listener.ClientClosed()
+	}
+	return nil
+}
+
+func runInput(t *testing.T, settings map[string]interface{}, pipeline beat.Pipeline) context.CancelFunc {
+	t.Helper()
+	inp, err := configure(conf.MustNewConfigFrom(settings))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		assert.NoError(t, inp.Run(v2.Context{
+			Logger:      logp.NewLogger(inputName),
+			ID:          t.Name(),
+			Cancelation: ctx,
+		}, pipeline))
+	}()
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+	})
+	return cancel
+}
+
+// startServer starts a NATS server with JetStream and a LOGS stream storing
+// the logs.> subjects.
+func startServer(t *testing.T, opts *server.Options) (*server.Server, jetstream.JetStream) {
+	t.Helper()
+	opts.Host = "127.0.0.1"
+	opts.Port = -1
+	opts.JetStream = true
+	opts.StoreDir = t.TempDir()
+	opts.NoLog = true
+	opts.NoSigs = true
+	srv, err := server.NewServer(opts)
+	require.NoError(t, err)
+	srv.Start()
+	t.Cleanup(srv.Shutdown)
+	require.True(t, srv.ReadyForConnections(10*time.Second), "server not ready")
+
+	nc, err := nats.Connect(srv.ClientURL(), nats.Token(opts.Authorization))
+	require.NoError(t, err)
+	t.Cleanup(nc.Close)
+	js, err := jetstream.New(nc)
+	require.NoError(t, err)
+	_, err = js.CreateStream(context.Background(), jetstream.StreamConfig{Name: "LOGS", Subjects: []string{"logs.>"}})
+	require.NoError(t, err)
+	return srv, js
+}
+
+func consumerInfo(t *testing.T, js jetstream.JetStream) *jetstream.ConsumerInfo {
+	t.Helper()
+	cons, err := js.Consumer(context.Background(), "LOGS", "filebeat")
+	require.NoError(t, err)
+	return cons.CachedInfo()
+}
+
+func TestInput(t *testing.T) {
+	logp.TestingSetup()
+	srv, js := startServer(t, &server.Options{})
+	ctx := context.Background()
+	_, err := js.Publish(ctx, "logs.edge.1", []byte("first"))
+	require.NoError(t, err)
+	_, err = js.PublishMsg(ctx, &nats.Msg{
+		Subject: "logs.edge.2",
+		Header:  nats.Header{"Nats-Msg-Id": {"abc"}, "Device": {"sensor-7"}},
+		Data:    []byte("second"),
+	})
+	require.NoError(t, err)
+	_, err = js.Publish(ctx, "logs.edge.1", []byte("third"))
+	require.NoError(t, err)
+	_, err = js.Publish(ctx, "logs.other", []byte("filtered"))
+	require.NoError(t, err)
+
+	pipeline := &testPipeline{}
+	runInput(t, map[string]interface{}{
+		"hosts":      []string{srv.ClientURL()},
+		"stream":     "LOGS",
+		"consumer":   "filebeat",
+		"subjects":   []string{"logs.edge.>", "logs.core.>"},
+		"batch_size": 2,
+		"max_wait":   "100ms",
+	}, pipeline)
+
+	require.Eventually(t, func() bool {
+		info := consumerInfo(t, js)
+		return info.AckFloor.Consumer == 3 && info.NumAckPending == 0
+	}, 10*time.Second, 10*time.Millisecond)
+
+	info := consumerInfo(t, js)
+	assert.Equal(t, jetstream.AckExplicitPolicy, info.Config.AckPolicy)
+	assert.Equal(t, 30*time.Second, info.Config.AckWait)
+	assert.Equal(t, 1000, info.Config.MaxAckPending)
+	assert.Equal(t, []string{"logs.edge.>", "logs.core.>"}, info.Config.FilterSubjects)
+
+	stream, err := js.Stream(ctx, "LOGS")
+	require.NoError(t, err)
+	stored, err := stream.GetMsg(ctx, 2)
+	require.NoError(t, err)
+
+	events := pipeline.Events()
+	require.Len(t, events, 3)
+	assert.Equal(t, stored.Time, events[1].Timestamp.UTC())
+	assert.Equal(t, mapstr.M{
+		"message": "second",
+		"nats": mapstr.M{
+			"subject":   "logs.edge.2",
+			"stream":    "LOGS",
+			"consumer":  "filebeat",
+			"delivered": uint64(1),
+			"pending":   uint64(1),
+			"sequence": mapstr.M{
+				"stream":   uint64(2),
+				"consumer": uint64(2),
+			},
+			"headers": mapstr.M{
+				"Nats-Msg-Id": []string{"abc"},
+				"Device":      []string{"sensor-7"},
+			},
+		},
+	}, events[1].Fields)
+}
+
+func TestInputAckNone(t *testing.T) {
+	logp.TestingSetup()
+	srv, js := startServer(t, &server.Options{})
+	_, err := js.Publish(context.Background(), "logs.edge", []byte("first"))
+	require.NoError(t, err)
+
+	pipeline := &testPipeline{}
+	runInput(t, map[string]interface{}{
+		"hosts":      []string{srv.ClientURL()},
+		"stream":     "LOGS",
+		"consumer":   "filebeat",
+		"subjects":   []string{"logs.edge"},
+		"ack_policy": "none",
+		"max_wait":   "100ms",
+	}, pipeline)
+
+	require.Eventually(t, func() bool { return len(pipeline.Events()) == 1 }, 10*time.Second, 10*time.Millisecond)
+	info := consumerInfo(t, js)
+	assert.Equal(t, jetstream.AckNonePolicy, info.Config.AckPolicy)
+	assert.Equal(t, "logs.edge", info.Config.FilterSubject)
+	assert.Equal(t, uint64(1), info.AckFloor.Stream)
+}
+
+func TestInputInProgress(t *testing.T) {
+	logp.TestingSetup()
+	srv, js := startServer(t, &server.Options{})
+	_, err := js.Publish(context.Background(), "logs.edge", []byte("slow"))
+	require.NoError(t, err)
+
+	// The output takes longer than ack_wait to acknowledge the event.
+	pipeline := &testPipeline{ackDelay: 2500 * time.Millisecond}
+	runInput(t, map[string]interface{}{
+		"hosts":    []string{srv.ClientURL()},
+		"stream":   "LOGS",
+		"consumer": "filebeat",
+		"ack_wait": "1s",
+		"max_wait": "100ms",
+	}, pipeline)
+
+	require.Eventually(t, func() bool {
+		info := consumerInfo(t, js)
+		return info.AckFloor.Stream == 1 && info.NumAckPending == 0
+	}, 10*time.Second, 10*time.Millisecond)
+	assert.Len(t, pipeline.Events(), 1, "the message must not be delivered again")
+	assert.Zero(t, consumerInfo(t, js).NumRedelivered)
+}
+
+func TestCreateConsumer(t *testing.T) {
+	logp.TestingSetup()
+	srv, js := startServer(t, &server.Options{})
+	ctx := context.Background()
+
+	newInput := func(settings map[string]interface{}) *jetstreamInput {
+		t.Helper()
+		settings["hosts"] = []string{srv.ClientURL()}
+		settings["stream"] = "LOGS"
+		settings["consumer"] = "filebeat"
+		inp, err := configure(conf.MustNewConfigFrom(settings))
+		require.NoError(t, err)
+		return inp.(*jetstreamInput)
+	}
+	createConsumer := func(inp *jetstreamInput) error {
+		nc, err := inp.connect()
+		require.NoError(t, err)
+		defer nc.Close()
+		_, err = inp.createConsumer(ctx, nc)
+		return err
+	}
+
+	all := newInput(map[string]interface{}{"ack_policy": "all"})
+	require.NoError(t, createConsumer(all))
+	assert.Equal(t, 1, consumerInfo(t, js).Config.MaxWaiting)
+	require.NoError(t, createConsumer(all), "the consumer can be reused")
+
+	err := createConsumer(newInput(map[string]interface{}{}))
+	assert.ErrorContains(t, err, `existing consumer uses ack policy "all", which cannot be changed to "explicit"`)
+
+	// Another client has a pending pull request.
+	cons, err := js.Consumer(ctx, "LOGS", "filebeat")
+	require.NoError(t, err)
+	batch, err := cons.Fetch(1, jetstream.FetchMaxWait(5*time.Second))
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return consumerInfo(t, js).NumWaiting == 1 }, 10*time.Second, 10*time.Millisecond)
+	assert.ErrorIs(t, createConsumer(all), errSharedConsumer)
+	_, err = js.Publish(ctx, "logs.edge", []byte("first"))
+	require.NoError(t, err)
+	for range batch.Messages() {
+	}
+
+	// A consumer accepting concurrent pull requests.
+	require.NoError(t, js.DeleteConsumer(ctx, "LOGS", "filebeat"))
+	_, err = js.CreateConsumer(ctx, "LOGS", jetstream.ConsumerConfig{Durable: "filebeat", AckPolicy: jetstream.AckAllPolicy})
+	require.NoError(t, err)
+	assert.ErrorContains(t, createConsumer(all), "existing consumer accepts 512 concurrent pull requests")
+}
+
+func TestConnectAuthentication(t *testing.T) {
+	logp.TestingSetup()
+	srv, _ := startServer(t, &server.Options{Authorization: "secret"})
+
+	connect := func(token string) error {
+		inp, err := configure(conf.MustNewConfigFrom(map[string]interface{}{
+			"hosts":           []string{"localhost:1", srv.ClientURL()},
+			"stream":          "LOGS",
+			"consumer":        "filebeat",
+			"token":           token,
+			"connect_timeout": "1s",
+		}))
+		require.NoError(t, err)
+		nc, err := inp.(*jetstreamInput).connect()
+		if err != nil {
+			return err
+		}
+		nc.Close()
+		return nil
+	}
+	assert.ErrorContains(t, connect("wrong"), "Authorization Violation")
+	assert.NoError(t, connect("secret"))
+}
+
+func TestConfig(t *testing.T) {
+	for name, settings := range map[string]map[string]interface{}{
+		"missing stream":         {"consumer": "c"},
+		"invalid consumer":       {"stream": "S", "consumer": "a.b"},
+		"invalid ack policy":     {"stream": "S", "consumer": "c", "ack_policy": "some"},
+		"invalid deliver policy": {"stream": "S", "consumer": "c", "deliver_policy": "first"},
+		"batch over pending":     {"stream": "S", "consumer": "c", "batch_size": 10, "max_ack_pending": 5},
+		"token and username":     {"stream": "S", "consumer": "c", "token": "t", "username": "u"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			settings["hosts"] = []string{"localhost:4222"}
+			_, err := configure(conf.MustNewConfigFrom(settings))
+			assert.Error(t, err)
+		})
+	}
+}
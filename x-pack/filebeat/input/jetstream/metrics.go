@@ -0,0 +1,45 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package jetstream
+
+import (
+	"github.com/elastic/beats/v7/libbeat/monitoring/inputmon"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+type inputMetrics struct {
+	unregister func()
+
+	server                *monitoring.String // Address of the server the input is connected to.
+	messagesReceivedTotal *monitoring.Uint   // Number of messages received.
+	bytesReceivedTotal    *monitoring.Uint   // Number of bytes of the messages received.
+	messagesACKedTotal    *monitoring.Uint   // Number of messages acknowledged to the server.
+	inProgressTotal       *monitoring.Uint   // Number of in-progress acknowledgements sent for pending messages.
+	ackErrorsTotal        *monitoring.Uint   // Number of messages that failed to be acknowledged.
+	redeliveredTotal      *monitoring.Uint   // Number of messages received more than once.
+	fetchRequestsTotal    *monitoring.Uint   // Number of pull requests sent.
+	errorsTotal           *monitoring.Uint   // Number of connection and consumer errors.
+}
+
+// Close removes the metrics from the registry.
+func (m *inputMetrics) Close() {
+	m.unregister()
+}
+
+func newInputMetrics(id string, optionalParent *monitoring.Registry) *inputMetrics {
+	reg, unreg := inputmon.NewInputRegistry(inputName, id, optionalParent)
+	return &inputMetrics{
+		unregister:            unreg,
+		server:                monitoring.NewString(reg, "server"),
+		messagesReceivedTotal: monitoring.NewUint(reg, "messages_received_total"),
+		bytesReceivedTotal:    monitoring.NewUint(reg, "bytes_received_total"),
+		messagesACKedTotal:    monitoring.NewUint(reg, "messages_acked_total"),
+		inProgressTotal:       monitoring.NewUint(reg, "in_progress_acks_total"),
+		ackErrorsTotal:        monitoring.NewUint(reg, "ack_errors_total"),
+		redeliveredTotal:      monitoring.NewUint(reg, "messages_redelivered_total"),
+		fetchRequestsTotal:    monitoring.NewUint(reg, "fetch_requests_total"),
+		errorsTotal:           monitoring.NewUint(reg, "errors_total"),
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package ldap
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+)
+
+const (
+	syncAuto    = "auto"
+	syncDirSync = "dirsync"
+	syncUSN     = "usn"
+)
+
+type config struct {
+	// URL is the URL of the directory server, ldap:// or ldaps://.
+	URL string `config:"url" validate:"required"`
+	// BindDN and BindPassword are the credentials of the input.
+	BindDN       string            `config:"bind_dn"`
+	BindPassword string            `config:"bind_password"`
+	TLS          *tlscommon.Config `config:"ssl"`
+
+	// BaseDN is the base of the searches. DirSync requires it to be the
+	// root of a naming context, such as the domain.
+	BaseDN string `config:"base_dn" validate:"required"`
+	// ObjectClasses are the classes of the tracked objects.
+	ObjectClasses []string `config:"object_classes"`
+	// Attributes are the attributes collected, all when empty.
+	Attributes []string `config:"attributes"`
+
+	// SyncMode selects how changes are found: dirsync, usn, or auto to
+	// use DirSync where permitted and uSNChanged otherwise.
+	SyncMode string `config:"sync_mode"`
+	// InitialSync publishes all the tracked objects on the first run.
+	// When disabled, only the changes made after the first run are
+	// published.
+	InitialSync bool `config:"initial_sync"`
+	// IncludeDeleted requests the deleted objects in uSNChanged searches.
+	// DirSync always includes them.
+	IncludeDeleted bool `config:"include_deleted"`
+
+	// Interval is the time between searches for changes.
+	Interval time.Duration `config:"interval" validate:"positive,nonzero"`
+	// PagingSize is the page size of the uSNChanged searches.
+	PagingSize uint32 `config:"paging_size"`
+	// Timeout limits the duration of each request.
+	Timeout time.Duration `config:"timeout" validate:"positive,nonzero"`
+}
+
+func defaultConfig() config {
+	return config{
+		ObjectClasses:  []string{"user", "group", "computer"},
+		SyncMode:       syncAuto,
+		InitialSync:    true,
+		IncludeDeleted: true,
+		Interval:       5 * time.Minute,
+		PagingSize:     500,
+		Timeout:        time.Minute,
+	}
+}
+
+func (c *config) Validate() error {
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	if u.Scheme != "ldap" && u.Scheme != "ldaps" {
+		return errors.New("url must be an ldap:// or ldaps:// URL")
+	}
+	if _, err := ldap.ParseDN(c.BaseDN); err != nil {
+		return fmt.Errorf("invalid base_dn: %w", err)
+	}
+	if len(c.ObjectClasses) == 0 {
+		return errors.New("object_classes cannot be empty")
+	}
+	switch c.SyncMode {
+	case syncAuto, syncDirSync, syncUSN:
+	default:
+		return fmt.Errorf("unsupported sync_mode %q", c.SyncMode)
+	}
+	if c.TLS.IsEnabled() {
+		if _, err := tlscommon.LoadTLSConfig(c.TLS); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// filter returns the filter matching the tracked object classes.
+func (c *config) filter() string {
+	f := "(|"
+	for _, class := range c.ObjectClasses {
+		f += "(objectClass=" + ldap.EscapeFilter(class) + ")"
+	}
+	return f + ")"
+}
@@ -0,0 +1,198 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package ldap
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// Changes of the objects, used in event.action.
+const (
+	changeDiscovered = "discovered"
+	changeCreated    = "created"
+	changeModified   = "modified"
+	changeDeleted    = "deleted"
+)
+
+// objectType returns the most specific of the tracked classes of an
+// object. LDAP lists the classes of an object from the most generic to
+// the most specific, so a computer, which is also a user in Active
+// Directory, is reported as a computer.
+func objectType(classes, tracked []string) string {
+	for i := len(classes) - 1; i >= 0; i-- {
+		for _, t := range tracked {
+			if strings.EqualFold(classes[i], t) {
+				return strings.ToLower(t)
+			}
+		}
+	}
+	return "unknown"
+}
+
+func isDeleted(e *ldap.Entry) bool {
+	return strings.EqualFold(e.GetAttributeValue("isDeleted"), "TRUE")
+}
+
+// newEvent returns the event of a change of an object. typ is the type of
+// the object, as returned by objectType.
+func newEvent(e *ldap.Entry, typ, change string, now time.Time) beat.Event {
+	attrs := attributes(e)
+
+	ts := now
+	if t, ok := attrs["whenChanged"].(time.Time); ok {
+		ts = t
+	}
+
+	eventType := []string{}
+	switch typ {
+	case "user", "group":
+		eventType = append(eventType, typ)
+	}
+	switch change {
+	case changeCreated:
+		eventType = append(eventType, "creation")
+	case changeModified:
+		eventType = append(eventType, "change")
+	case changeDeleted:
+		eventType = append(eventType, "deletion")
+	default:
+		eventType = append(eventType, "info")
+	}
+
+	fields := mapstr.M{
+		"event": mapstr.M{
+			"kind":     "event",
+			"category": []string{"iam"},
+			"type":     eventType,
+			"action":   typ + "-" + change,
+		},
+		"ldap": mapstr.M{
+			"dn":          e.DN,
+			"object_type": typ,
+			"attributes":  attrs,
+		},
+	}
+	if guid, ok := attrs["objectGUID"].(string); ok {
+		fields.Put("ldap.object_guid", guid)
+	}
+	if name := e.GetAttributeValue("sAMAccountName"); name != "" {
+		switch typ {
+		case "user":
+			fields.Put("user.name", name)
+		case "group":
+			fields.Put("group.name", name)
+		}
+	}
+	if sid, ok := attrs["objectSid"].(string); ok {
+		switch typ {
+		case "user":
+			fields.Put("user.id", sid)
+		case "group":
+			fields.Put("group.id", sid)
+		}
+	}
+	return beat.Event{Timestamp: ts, Fields: fields}
+}
+
+// attributes returns the attributes of an entry. The values of the
+// attributes with a known type are converted to it, multi-valued
+// attributes are lists.
+func attributes(e *ldap.Entry) mapstr.M {
+	m := make(mapstr.M, len(e.Attributes))
+	for _, a := range e.Attributes {
+		if len(a.Values) == 0 {
+			continue
+		}
+		vals := make([]any, len(a.Values))
+		for i := range a.Values {
+			vals[i] = attributeValue(a.Name, a.Values[i], a.ByteValues[i])
+		}
+		if len(vals) == 1 {
+			m[a.Name] = vals[0]
+		} else {
+			m[a.Name] = vals
+		}
+	}
+	return m
+}
+
+func attributeValue(name, s string, b []byte) any {
+	switch strings.ToLower(name) {
+	case "objectguid":
+		if len(b) == 16 {
+			return formatGUID(b)
+		}
+	case "objectsid":
+		if sid, ok := formatSID(b); ok {
+			return sid
+		}
+	case "whencreated", "whenchanged":
+		if t, err := time.Parse("20060102150405.999999999Z", s); err == nil {
+			return t
+		}
+	case "usnchanged", "usncreated", "useraccountcontrol", "grouptype", "samaccounttype", "primarygroupid":
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n
+		}
+	case "lastlogon", "lastlogontimestamp", "pwdlastset", "badpasswordtime", "lockouttime", "accountexpires":
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			break
+		}
+		// Zero and the largest value mean never.
+		if n == 0 || n == 1<<63-1 {
+			return nil
+		}
+		return fromWindowsNT(n)
+	case "isdeleted":
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	}
+	return s
+}
+
+// formatGUID formats a binary GUID, whose first three parts are little
+// endian.
+func formatGUID(b []byte) string {
+	return fmt.Sprintf("%08x-%04x-%04x-%x-%x",
+		binary.LittleEndian.Uint32(b[0:4]),
+		binary.LittleEndian.Uint16(b[4:6]),
+		binary.LittleEndian.Uint16(b[6:8]),
+		b[8:10], b[10:16])
+}
+
+// formatSID formats a binary security identifier as S-R-I-S-S...
+func formatSID(b []byte) (string, bool) {
+	if len(b) < 8 || len(b) != 8+4*int(b[1]) {
+		return "", false
+	}
+	var auth uint64
+	for _, v := range b[2:8] {
+		auth = auth<<8 | uint64(v)
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "S-%d-%d", b[0], auth)
+	for i := 8; i < len(b); i += 4 {
+		fmt.Fprintf(&sb, "-%d", binary.LittleEndian.Uint32(b[i:i+4]))
+	}
+	return sb.String(), true
+}
+
+// epochDelta is the unix epoch in Windows NT time.
+const epochDelta = 116444736000000000
+
+func fromWindowsNT(ts int64) time.Time {
+	return time.Unix(0, (ts-epochDelta)*100).UTC()
+}
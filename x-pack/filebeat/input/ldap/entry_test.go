@@ -0,0 +1,51 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package ldap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func TestAttributes(t *testing.T) {
+	guid := []byte{0x78, 0x56, 0x34, 0x12, 0x34, 0x12, 0x78, 0x56, 0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc, 0xde, 0xf0}
+	sid := []byte{1, 5, 0, 0, 0, 0, 0, 5, 21, 0, 0, 0, 1, 0, 0, 0, 2, 0, 0, 0, 3, 0, 0, 0, 0xe9, 0x03, 0, 0}
+	e := &ldap.Entry{
+		DN: "CN=alice,DC=example,DC=com",
+		Attributes: []*ldap.EntryAttribute{
+			{Name: "objectGUID", Values: []string{string(guid)}, ByteValues: [][]byte{guid}},
+			{Name: "objectSid", Values: []string{string(sid)}, ByteValues: [][]byte{sid}},
+			ldap.NewEntryAttribute("whenChanged", []string{"20240301100000.0Z"}),
+			ldap.NewEntryAttribute("uSNChanged", []string{"42"}),
+			ldap.NewEntryAttribute("pwdLastSet", []string{"133537248000000000"}),
+			ldap.NewEntryAttribute("accountExpires", []string{"9223372036854775807"}),
+			ldap.NewEntryAttribute("memberOf", []string{"CN=a", "CN=b"}),
+			ldap.NewEntryAttribute("mail", []string{"alice@example.com"}),
+		},
+	}
+	assert.Equal(t, mapstr.M{
+		"objectGUID":     "12345678-1234-5678-1234-56789abcdef0",
+		"objectSid":      "S-1-5-21-1-2-3-1001",
+		"whenChanged":    time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+		"uSNChanged":     int64(42),
+		"pwdLastSet":     time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		"accountExpires": nil,
+		"memberOf":       []any{"CN=a", "CN=b"},
+		"mail":           "alice@example.com",
+	}, attributes(e))
+}
+
+func TestObjectType(t *testing.T) {
+	tracked := []string{"user", "group", "computer"}
+	assert.Equal(t, "computer", objectType([]string{"top", "person", "organizationalPerson", "user", "computer"}, tracked))
+	assert.Equal(t, "user", objectType([]string{"top", "person", "organizationalPerson", "User"}, tracked))
+	assert.Equal(t, "group", objectType([]string{"top", "group"}, tracked))
+	assert.Equal(t, "unknown", objectType(nil, tracked))
+}
@@ -0,0 +1,153 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// Package ldap implements an input publishing the changes of the objects
+// of an LDAP directory, such as the users, groups and computers of Active
+// Directory. Changes are found with the DirSync control where the input
+// has the right to use it, and by searching for the objects whose
+// uSNChanged has grown otherwise.
+package ldap
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+
+	v2 "github.com/elastic/beats/v7/filebeat/input/v2"
+	inputcursor "github.com/elastic/beats/v7/filebeat/input/v2/input-cursor"
+	"github.com/elastic/beats/v7/libbeat/feature"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+	"github.com/elastic/go-concert/ctxtool"
+	"github.com/elastic/go-concert/timed"
+)
+
+const inputName = "ldap"
+
+func Plugin(log *logp.Logger, store inputcursor.StateStore) v2.Plugin {
+	return v2.Plugin{
+		Name:       inputName,
+		Stability:  feature.Experimental,
+		Deprecated: false,
+		Info:       "LDAP directory changes",
+		Doc:        "Collect the changes of the users, groups and computers of an LDAP directory",
+		Manager: &inputcursor.InputManager{
+			Logger:     log,
+			StateStore: store,
+			Type:       inputName,
+			Configure:  configure,
+		},
+	}
+}
+
+func configure(cfg *conf.C) ([]inputcursor.Source, inputcursor.Input, error) {
+	config := defaultConfig()
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, nil, fmt.Errorf("reading config: %w", err)
+	}
+	return []inputcursor.Source{&source{cfg: config}}, input{}, nil
+}
+
+type source struct{ cfg config }
+
+func (s *source) Name() string { return s.cfg.URL + "/" + s.cfg.BaseDN }
+
+type input struct{}
+
+func (input) Name() string { return inputName }
+
+func (input) Test(src inputcursor.Source, _ v2.TestContext) error {
+	client, err := dial(src.(*source).cfg)
+	if err != nil {
+		return err
+	}
+	return client.Close()
+}
+
+// state is the cursor of the input.
+type state struct {
+	// Mode is the sync mode the state belongs to.
+	Mode string `struct:"mode"`
+	// Server is the dsServiceName of the directory server the USN
+	// belongs to, USNs are local to each server.
+	Server string `struct:"server,omitempty"`
+	// USN is the highest uSNChanged collected in usn mode.
+	USN int64 `struct:"usn,omitempty"`
+	// Cookie is the base64 encoded DirSync cookie in dirsync mode.
+	Cookie string `struct:"cookie,omitempty"`
+}
+
+func (input) Run(env v2.Context, src inputcursor.Source, crsr inputcursor.Cursor, pub inputcursor.Publisher) error {
+	cfg := src.(*source).cfg
+
+	var st state
+	if !crsr.IsNew() {
+		if err := crsr.Unpack(&st); err != nil {
+			return fmt.Errorf("failed to unpack cursor: %w", err)
+		}
+	}
+
+	s := &syncer{
+		cfg:   cfg,
+		pub:   pub,
+		log:   env.Logger.With("url", cfg.URL, "base_dn", cfg.BaseDN),
+		state: st,
+		now:   time.Now,
+	}
+	ctx := ctxtool.FromCanceller(env.Cancelation)
+	poll := func() error {
+		client, err := dial(cfg)
+		if err != nil {
+			s.log.Errorw("Failed to connect to the directory", "error", err)
+			return nil
+		}
+		defer client.Close()
+		if err := s.sync(ctx, client); err != nil {
+			s.log.Errorw("Failed to collect directory changes", "error", err)
+		}
+		return nil
+	}
+	_ = poll()
+	err := timed.Periodic(ctx, cfg.Interval, poll)
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// dial connects to the directory and binds with the configured
+// credentials.
+func dial(cfg config) (ldap.Client, error) {
+	opts := []ldap.DialOpt{ldap.DialWithDialer(&net.Dialer{Timeout: cfg.Timeout})}
+	if cfg.TLS.IsEnabled() {
+		tlsConfig, err := tlscommon.LoadTLSConfig(cfg.TLS)
+		if err != nil {
+			return nil, err
+		}
+		u, err := url.Parse(cfg.URL)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, ldap.DialWithTLSConfig(tlsConfig.BuildModuleClientConfig(u.Hostname())))
+	}
+	conn, err := ldap.DialURL(cfg.URL, opts...)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(cfg.Timeout)
+	if cfg.BindDN != "" {
+		err = conn.Bind(cfg.BindDN, cfg.BindPassword)
+	} else {
+		err = conn.UnauthenticatedBind("")
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("bind failed: %w", err)
+	}
+	return conn, nil
+}
@@ -0,0 +1,283 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package ldap
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+
+	inputcursor "github.com/elastic/beats/v7/filebeat/input/v2/input-cursor"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+// mandatoryAttributes are the attributes needed to track the changes,
+// they are added to the configured attributes.
+var mandatoryAttributes = []string{"objectClass", "objectGUID", "uSNChanged", "uSNCreated", "whenCreated", "whenChanged", "isDeleted"}
+
+// syncer publishes the changes of the directory objects.
+type syncer struct {
+	cfg config
+	pub inputcursor.Publisher
+	log *logp.Logger
+	now func() time.Time
+
+	state state
+}
+
+// sync publishes the changes made since the last sync.
+func (s *syncer) sync(ctx context.Context, client ldap.Client) error {
+	mode := s.cfg.SyncMode
+	if mode == syncAuto {
+		mode = s.state.Mode
+		if mode == "" {
+			mode = syncDirSync
+		}
+	}
+	if mode == syncUSN {
+		return s.usnSync(ctx, client)
+	}
+
+	err := s.dirSync(ctx, client)
+	if s.cfg.SyncMode == syncAuto && s.state.Cookie == "" && dirSyncDenied(err) {
+		s.log.Infow("DirSync is not permitted, falling back to uSNChanged searches", "error", err)
+		return s.usnSync(ctx, client)
+	}
+	return err
+}
+
+// dirSyncDenied tells if a DirSync search failed because the server does
+// not support it or the input lacks the right to use it.
+func dirSyncDenied(err error) bool {
+	var ldapErr *ldap.Error
+	if !errors.As(err, &ldapErr) {
+		return false
+	}
+	return ldap.IsErrorAnyOf(ldapErr,
+		ldap.LDAPResultInsufficientAccessRights,
+		ldap.LDAPResultUnavailableCriticalExtension,
+		ldap.LDAPResultUnwillingToPerform,
+	)
+}
+
+// reset starts a new state when the sync mode changes, the progress of
+// a mode cannot be used by the other one.
+func (s *syncer) reset(mode string) {
+	if s.state.Mode == mode {
+		return
+	}
+	if s.state.Mode != "" {
+		s.log.Infof("Sync mode changed from %s to %s, the directory is synced again", s.state.Mode, mode)
+	}
+	s.state = state{Mode: mode}
+}
+
+func (s *syncer) attributes() []string {
+	if len(s.cfg.Attributes) == 0 {
+		return nil
+	}
+	attrs := append([]string(nil), s.cfg.Attributes...)
+outer:
+	for _, m := range mandatoryAttributes {
+		for _, a := range attrs {
+			if a == m {
+				continue outer
+			}
+		}
+		attrs = append(attrs, m)
+	}
+	return attrs
+}
+
+// usnSync publishes the objects whose uSNChanged is higher than the one
+// of the last published object. USNs are local to a directory server,
+// the directory is synced again when the input connects to another one.
+func (s *syncer) usnSync(ctx context.Context, client ldap.Client) error {
+	s.reset(syncUSN)
+
+	server, highest, err := rootDSE(client)
+	if err != nil {
+		return err
+	}
+	if s.state.Server != server {
+		if s.state.Server != "" {
+			s.log.Warnf("Directory server changed from %s to %s, the directory is synced again", s.state.Server, server)
+		}
+		s.state = state{Mode: syncUSN, Server: server}
+	}
+	last := s.state.USN
+	if last >= highest {
+		return nil
+	}
+	initial := last == 0
+	if initial && !s.cfg.InitialSync {
+		s.state.USN = highest
+		return nil
+	}
+
+	filter := fmt.Sprintf("(&%s(uSNChanged<=%d))", s.cfg.filter(), highest)
+	if !initial {
+		filter = fmt.Sprintf("(&%s(uSNChanged>=%d)(uSNChanged<=%d))", s.cfg.filter(), last+1, highest)
+	}
+	req := ldap.NewSearchRequest(s.cfg.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		0, 0, false, filter, s.attributes(), nil)
+	if s.cfg.IncludeDeleted {
+		req.Controls = append(req.Controls, ldap.NewControlMicrosoftShowDeleted())
+	}
+	var res *ldap.SearchResult
+	if s.cfg.PagingSize != 0 {
+		res, err = client.SearchWithPaging(req, s.cfg.PagingSize)
+	} else {
+		res, err = client.Search(req)
+	}
+	if err != nil {
+		return fmt.Errorf("search failed: %w", err)
+	}
+
+	usn := func(e *ldap.Entry, attr string) int64 {
+		n, _ := strconv.ParseInt(e.GetAttributeValue(attr), 10, 64)
+		return n
+	}
+	entries := res.Entries
+	sort.SliceStable(entries, func(i, j int) bool {
+		return usn(entries[i], "uSNChanged") < usn(entries[j], "uSNChanged")
+	})
+
+	for i, e := range entries {
+		if ctx.Err() != nil {
+			return nil
+		}
+		var change string
+		switch {
+		case initial:
+			change = changeDiscovered
+		case isDeleted(e):
+			change = changeDeleted
+		case usn(e, "uSNCreated") > last:
+			change = changeCreated
+		default:
+			change = changeModified
+		}
+		// Changes are published in uSNChanged order so each of them
+		// can move the cursor, the last one moves it to the highest
+		// USN of the server covered by the search.
+		s.state.USN = usn(e, "uSNChanged")
+		if i == len(entries)-1 {
+			s.state.USN = highest
+		}
+		typ := objectType(e.GetAttributeValues("objectClass"), s.cfg.ObjectClasses)
+		if err := s.pub.Publish(newEvent(e, typ, change, s.now()), s.state); err != nil {
+			return err
+		}
+	}
+	s.state.USN = highest
+	s.log.Debugw("Collected directory changes", "changes", len(entries), "usn", highest)
+	return nil
+}
+
+// rootDSE returns the name and the highest committed USN of the server.
+func rootDSE(client ldap.Client) (server string, usn int64, err error) {
+	req := ldap.NewSearchRequest("", ldap.ScopeBaseObject, ldap.NeverDerefAliases,
+		0, 0, false, "(objectClass=*)", []string{"dsServiceName", "highestCommittedUSN"}, nil)
+	res, err := client.Search(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read root DSE: %w", err)
+	}
+	if len(res.Entries) == 0 {
+		return "", 0, errors.New("empty root DSE")
+	}
+	root := res.Entries[0]
+	usn, err = strconv.ParseInt(root.GetAttributeValue("highestCommittedUSN"), 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("server does not expose highestCommittedUSN: %w", err)
+	}
+	return root.GetAttributeValue("dsServiceName"), usn, nil
+}
+
+// dirSync publishes the changes returned by DirSync searches. The
+// returned entries hold the changed attributes only, the object type of
+// the entries without objectClass is looked up.
+func (s *syncer) dirSync(ctx context.Context, client ldap.Client) error {
+	s.reset(syncDirSync)
+
+	cookie, err := base64.StdEncoding.DecodeString(s.state.Cookie)
+	if err != nil {
+		return fmt.Errorf("invalid DirSync cookie: %w", err)
+	}
+	initial := len(cookie) == 0
+
+	for n := 0; ctx.Err() == nil; {
+		req := ldap.NewSearchRequest(s.cfg.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+			0, 0, false, s.cfg.filter(), s.attributes(), nil)
+		res, err := client.DirSync(req, 0, 0, cookie)
+		if err != nil {
+			return fmt.Errorf("DirSync search failed: %w", err)
+		}
+		ctrl, ok := ldap.FindControl(res.Controls, ldap.ControlTypeDirSync).(*ldap.ControlDirSync)
+		if !ok {
+			return errors.New("server did not return a DirSync control")
+		}
+		cookie = ctrl.Cookie
+
+		for i, e := range res.Entries {
+			if initial && !s.cfg.InitialSync {
+				break
+			}
+			var change string
+			switch {
+			case initial:
+				change = changeDiscovered
+			case isDeleted(e):
+				change = changeDeleted
+			case e.GetAttributeValue("whenCreated") != "":
+				// whenCreated is only returned when it changed.
+				change = changeCreated
+			default:
+				change = changeModified
+			}
+			classes := e.GetAttributeValues("objectClass")
+			if len(classes) == 0 {
+				classes = lookupClasses(client, e.DN)
+			}
+			// The cookie covers the whole page, only its last
+			// change moves the cursor.
+			var update any
+			if i == len(res.Entries)-1 {
+				update = state{Mode: syncDirSync, Cookie: base64.StdEncoding.EncodeToString(cookie)}
+			}
+			if err := s.pub.Publish(newEvent(e, objectType(classes, s.cfg.ObjectClasses), change, s.now()), update); err != nil {
+				return err
+			}
+			n++
+		}
+		s.state.Cookie = base64.StdEncoding.EncodeToString(cookie)
+
+		// A non-zero flag tells more changes are waiting.
+		if ctrl.Flags == 0 {
+			s.log.Debugw("Collected directory changes", "changes", n)
+			return nil
+		}
+	}
+	return nil
+}
+
+// lookupClasses returns the object classes of an object, deleted objects
+// included.
+func lookupClasses(client ldap.Client, dn string) []string {
+	req := ldap.NewSearchRequest(dn, ldap.ScopeBaseObject, ldap.NeverDerefAliases,
+		0, 0, false, "(objectClass=*)", []string{"objectClass"},
+		[]ldap.Control{ldap.NewControlMicrosoftShowDeleted()})
+	res, err := client.Search(req)
+	if err != nil || len(res.Entries) == 0 {
+		return nil
+	}
+	return res.Entries[0].GetAttributeValues("objectClass")
+}
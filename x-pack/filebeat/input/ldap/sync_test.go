@@ -0,0 +1,275 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package ldap
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+// fakeDirectory implements the searches of the input on a fixed set of
+// objects.
+type fakeDirectory struct {
+	ldap.Client
+
+	server  string
+	highest int64
+	objects []*ldap.Entry
+
+	// dirSync holds the DirSync pages by cookie, dirSyncErr fails the
+	// DirSync searches.
+	dirSync    map[string]dirSyncPage
+	dirSyncErr error
+
+	filters []string
+}
+
+type dirSyncPage struct {
+	entries []*ldap.Entry
+	next    string
+	more    bool
+}
+
+var (
+	lowPattern  = regexp.MustCompile(`uSNChanged>=(\d+)`)
+	highPattern = regexp.MustCompile(`uSNChanged<=(\d+)`)
+)
+
+func (d *fakeDirectory) Search(req *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	if req.BaseDN == "" {
+		return &ldap.SearchResult{Entries: []*ldap.Entry{ldap.NewEntry("", map[string][]string{
+			"dsServiceName":       {d.server},
+			"highestCommittedUSN": {strconv.FormatInt(d.highest, 10)},
+		})}}, nil
+	}
+	if req.Scope == ldap.ScopeBaseObject {
+		for _, o := range d.objects {
+			if o.DN == req.BaseDN {
+				return &ldap.SearchResult{Entries: []*ldap.Entry{o}}, nil
+			}
+		}
+		return nil, ldap.NewError(ldap.LDAPResultNoSuchObject, errors.New("no such object"))
+	}
+
+	d.filters = append(d.filters, req.Filter)
+	low, high := int64(0), int64(1<<62)
+	if m := lowPattern.FindStringSubmatch(req.Filter); m != nil {
+		low, _ = strconv.ParseInt(m[1], 10, 64)
+	}
+	if m := highPattern.FindStringSubmatch(req.Filter); m != nil {
+		high, _ = strconv.ParseInt(m[1], 10, 64)
+	}
+	res := &ldap.SearchResult{}
+	for _, o := range d.objects {
+		usn, _ := strconv.ParseInt(o.GetAttributeValue("uSNChanged"), 10, 64)
+		if usn >= low && usn <= high {
+			res.Entries = append(res.Entries, o)
+		}
+	}
+	return res, nil
+}
+
+func (d *fakeDirectory) SearchWithPaging(req *ldap.SearchRequest, _ uint32) (*ldap.SearchResult, error) {
+	return d.Search(req)
+}
+
+func (d *fakeDirectory) DirSync(_ *ldap.SearchRequest, flags, maxAttrCount int64, cookie []byte) (*ldap.SearchResult, error) {
+	if d.dirSyncErr != nil {
+		return nil, d.dirSyncErr
+	}
+	page := d.dirSync[string(cookie)]
+	var more int64
+	if page.more {
+		more = 1
+	}
+	return &ldap.SearchResult{
+		Entries:  page.entries,
+		Controls: []ldap.Control{ldap.NewRequestControlDirSync(more, maxAttrCount, []byte(page.next))},
+	}, nil
+}
+
+type publication struct {
+	event  beat.Event
+	cursor any
+}
+
+type testPublisher struct{ published []publication }
+
+func (p *testPublisher) Publish(event beat.Event, cursor any) error {
+	p.published = append(p.published, publication{event: event, cursor: cursor})
+	return nil
+}
+
+func (p *testPublisher) actions() []string {
+	var a []string
+	for _, e := range p.published {
+		v, _ := e.event.Fields.GetValue("event.action")
+		a = append(a, v.(string))
+	}
+	return a
+}
+
+func entry(dn string, attrs map[string][]string) *ldap.Entry {
+	return ldap.NewEntry(dn, attrs)
+}
+
+func newTestSyncer(mode string) (*syncer, *testPublisher) {
+	logp.TestingSetup()
+	cfg := defaultConfig()
+	cfg.URL = "ldap://dc1.example.com"
+	cfg.BaseDN = "dc=example,dc=com"
+	cfg.SyncMode = mode
+	pub := &testPublisher{}
+	return &syncer{cfg: cfg, pub: pub, log: logp.NewLogger(inputName), now: time.Now}, pub
+}
+
+func TestUSNSync(t *testing.T) {
+	dir := &fakeDirectory{
+		server:  "CN=NTDS Settings,CN=DC1",
+		highest: 110,
+		objects: []*ldap.Entry{
+			entry("CN=alice,DC=example,DC=com", map[string][]string{
+				"objectClass": {"top", "person", "organizationalPerson", "user"}, "sAMAccountName": {"alice"},
+				"uSNCreated": {"100"}, "uSNChanged": {"105"}, "whenChanged": {"20240301100000.0Z"},
+			}),
+			entry("CN=ws1,DC=example,DC=com", map[string][]string{
+				"objectClass": {"top", "person", "organizationalPerson", "user", "computer"},
+				"uSNCreated":  {"101"}, "uSNChanged": {"101"},
+			}),
+		},
+	}
+	s, pub := newTestSyncer(syncUSN)
+
+	require.NoError(t, s.sync(context.Background(), dir))
+	assert.Equal(t, []string{"computer-discovered", "user-discovered"}, pub.actions())
+	assert.Equal(t, state{Mode: syncUSN, Server: dir.server, USN: 101}, pub.published[0].cursor)
+	assert.Equal(t, state{Mode: syncUSN, Server: dir.server, USN: 110}, pub.published[1].cursor)
+
+	user := pub.published[1].event
+	assert.Equal(t, time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), user.Timestamp)
+	name, _ := user.Fields.GetValue("user.name")
+	assert.Equal(t, "alice", name)
+
+	// Only the changes since the last sync are collected.
+	pub.published = nil
+	dir.highest = 120
+	dir.objects = append(dir.objects,
+		entry("CN=admins,DC=example,DC=com", map[string][]string{
+			"objectClass": {"top", "group"}, "uSNCreated": {"50"}, "uSNChanged": {"115"},
+		}),
+		entry("CN=bob\\0ADEL:1234,CN=Deleted Objects,DC=example,DC=com", map[string][]string{
+			"objectClass": {"top", "person", "organizationalPerson", "user"}, "isDeleted": {"TRUE"},
+			"uSNCreated": {"20"}, "uSNChanged": {"118"},
+		}),
+		entry("CN=carol,DC=example,DC=com", map[string][]string{
+			"objectClass": {"top", "person", "organizationalPerson", "user"},
+			"uSNCreated":  {"112"}, "uSNChanged": {"112"},
+		}),
+	)
+	require.NoError(t, s.sync(context.Background(), dir))
+	assert.Equal(t, []string{"user-created", "group-modified", "user-deleted"}, pub.actions())
+	assert.Contains(t, dir.filters[len(dir.filters)-1], "(uSNChanged>=111)(uSNChanged<=120)")
+
+	// Nothing is searched without changes.
+	n := len(dir.filters)
+	require.NoError(t, s.sync(context.Background(), dir))
+	assert.Len(t, dir.filters, n)
+
+	// USNs of another server are not comparable.
+	pub.published = nil
+	dir.server = "CN=NTDS Settings,CN=DC2"
+	require.NoError(t, s.sync(context.Background(), dir))
+	assert.Len(t, pub.published, 5)
+	assert.Equal(t, "computer-discovered", pub.actions()[0])
+}
+
+func TestUSNSyncNoInitialSync(t *testing.T) {
+	dir := &fakeDirectory{
+		server:  "dc1",
+		highest: 10,
+		objects: []*ldap.Entry{entry("CN=alice", map[string][]string{"objectClass": {"user"}, "uSNChanged": {"5"}})},
+	}
+	s, pub := newTestSyncer(syncUSN)
+	s.cfg.InitialSync = false
+
+	require.NoError(t, s.sync(context.Background(), dir))
+	assert.Empty(t, pub.published)
+	assert.Equal(t, int64(10), s.state.USN)
+}
+
+func TestDirSync(t *testing.T) {
+	dir := &fakeDirectory{
+		objects: []*ldap.Entry{
+			entry("CN=admins,DC=example,DC=com", map[string][]string{"objectClass": {"top", "group"}}),
+		},
+		dirSync: map[string]dirSyncPage{
+			"": {
+				entries: []*ldap.Entry{
+					entry("CN=alice,DC=example,DC=com", map[string][]string{"objectClass": {"top", "user"}}),
+				},
+				next: "c1",
+				more: true,
+			},
+			"c1": {
+				entries: []*ldap.Entry{
+					entry("CN=admins,DC=example,DC=com", map[string][]string{"objectClass": {"top", "group"}}),
+				},
+				next: "c2",
+			},
+			"c2": {
+				entries: []*ldap.Entry{
+					// Changed attributes only, the object class is looked up.
+					entry("CN=admins,DC=example,DC=com", map[string][]string{"member": {"CN=alice,DC=example,DC=com"}}),
+					entry("CN=bob,DC=example,DC=com", map[string][]string{
+						"objectClass": {"top", "user"}, "whenCreated": {"20240301100000.0Z"},
+					}),
+				},
+				next: "c3",
+			},
+		},
+	}
+	s, pub := newTestSyncer(syncAuto)
+
+	require.NoError(t, s.sync(context.Background(), dir))
+	assert.Equal(t, []string{"user-discovered", "group-discovered"}, pub.actions())
+	assert.Equal(t, state{Mode: syncDirSync, Cookie: base64.StdEncoding.EncodeToString([]byte("c1"))}, pub.published[0].cursor)
+	assert.Equal(t, state{Mode: syncDirSync, Cookie: base64.StdEncoding.EncodeToString([]byte("c2"))}, pub.published[1].cursor)
+
+	pub.published = nil
+	require.NoError(t, s.sync(context.Background(), dir))
+	assert.Equal(t, []string{"group-modified", "user-created"}, pub.actions())
+	assert.Nil(t, pub.published[0].cursor)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("c3")), s.state.Cookie)
+}
+
+func TestDirSyncFallback(t *testing.T) {
+	dir := &fakeDirectory{
+		server:     "dc1",
+		highest:    10,
+		objects:    []*ldap.Entry{entry("CN=alice", map[string][]string{"objectClass": {"user"}, "uSNChanged": {"5"}})},
+		dirSyncErr: ldap.NewError(ldap.LDAPResultInsufficientAccessRights, errors.New("access denied")),
+	}
+
+	s, pub := newTestSyncer(syncAuto)
+	require.NoError(t, s.sync(context.Background(), dir))
+	assert.Equal(t, []string{"user-discovered"}, pub.actions())
+	assert.Equal(t, syncUSN, s.state.Mode)
+
+	// An explicit dirsync mode does not fall back.
+	s, _ = newTestSyncer(syncDirSync)
+	assert.ErrorContains(t, s.sync(context.Background(), dir), "access denied")
+}
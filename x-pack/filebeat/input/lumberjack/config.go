@@ -6,6 +6,7 @@ package lumberjack
 
 import (
 	"fmt"
+	"net"
 	"strings"
 	"time"
 
@@ -19,6 +20,10 @@ type config struct {
 	Keepalive      time.Duration           `config:"keepalive"       validate:"min=0"`  // Keepalive interval for notifying clients that batches that are not yet ACKed.
 	Timeout        time.Duration           `config:"timeout"         validate:"min=0"`  // Read / write timeouts for Lumberjack server.
 	MaxConnections int                     `config:"max_connections" validate:"min=0"`  // Maximum number of concurrent connections. Default is 0 which means no limit.
+
+	AllowedSources            []string `config:"allowed_sources"`                                 // IP addresses or CIDR ranges allowed to connect. Default is to allow all sources.
+	MaxConnectionsPerPeer     int      `config:"max_connections_per_peer"       validate:"min=0"` // Maximum number of concurrent connections from a single source IP. Default is 0 which means no limit.
+	MaxEventsPerSecondPerPeer float64  `config:"max_events_per_second_per_peer" validate:"min=0"` // Maximum rate of events accepted from a single source IP. Default is 0 which means no limit.
 }
 
 func (c *config) InitDefaults() {
@@ -35,5 +40,48 @@ func (c *config) Validate() error {
 		}
 	}
 
+	for _, src := range c.AllowedSources {
+		if _, err := parseSource(src); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
+
+// allowedNetworks returns the configured allowlist as networks. It returns
+// nil if no allowlist is configured. The config must have been validated.
+func (c *config) allowedNetworks() []*net.IPNet {
+	if len(c.AllowedSources) == 0 {
+		return nil
+	}
+	nets := make([]*net.IPNet, 0, len(c.AllowedSources))
+	for _, src := range c.AllowedSources {
+		n, err := parseSource(src)
+		if err != nil {
+			continue
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
+
+// parseSource parses an IP address or CIDR range into a network.
+func parseSource(src string) (*net.IPNet, error) {
+	if strings.Contains(src, "/") {
+		_, n, err := net.ParseCIDR(src)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed_sources entry %q: %w", src, err)
+		}
+		return n, nil
+	}
+	ip := net.ParseIP(src)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid allowed_sources entry %q: not an IP address or CIDR range", src)
+	}
+	bits := 8 * net.IPv6len
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, bits = ip4, 8*net.IPv4len
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
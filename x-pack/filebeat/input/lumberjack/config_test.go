@@ -52,6 +52,22 @@ func TestConfig(t *testing.T) {
 			nil,
 			`requires value >= 0 accessing 'max_connections'`,
 		},
+		{
+			"validate allowed_sources",
+			map[string]interface{}{
+				"allowed_sources": []string{"10.0.0.0/8", "192.0.2.1", "not-an-ip"},
+			},
+			nil,
+			`invalid allowed_sources entry "not-an-ip"`,
+		},
+		{
+			"validate max_events_per_second_per_peer",
+			map[string]interface{}{
+				"max_events_per_second_per_peer": -1,
+			},
+			nil,
+			`requires value >= 0 accessing 'max_events_per_second_per_peer'`,
+		},
 	}
 
 	for _, tc := range testCases {
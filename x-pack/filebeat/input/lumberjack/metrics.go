@@ -14,12 +14,19 @@ import (
 
 type inputMetrics struct {
 	unregister func()
+	registry   *monitoring.Registry
 
 	bindAddress           *monitoring.String // Bind address of input.
 	batchesReceivedTotal  *monitoring.Uint   // Number of Lumberjack batches received (not necessarily processed fully).
 	batchesACKedTotal     *monitoring.Uint   // Number of Lumberjack batches ACKed.
 	messagesReceivedTotal *monitoring.Uint   // Number of Lumberjack messages received (not necessarily processed fully).
 	batchProcessingTime   metrics.Sample     // Histogram of the elapsed batch processing times in nanoseconds (time of receipt to time of ACK for non-empty batches).
+	batchSize             metrics.Sample     // Histogram of the number of messages in each non-empty batch.
+
+	connectionsRejectedTotal *monitoring.Uint // Number of connections rejected because the source is not in the allowlist.
+	connectionsLimitedTotal  *monitoring.Uint // Number of connections rejected because the peer reached max_connections_per_peer.
+	messagesThrottledTotal   *monitoring.Uint // Number of messages delayed by the per-peer events rate limit.
+	batchesRejectedTotal     *monitoring.Uint // Number of batches rejected because the queue of a rate limited peer was full.
 }
 
 // Close removes the metrics from the registry.
@@ -32,14 +39,23 @@ func newInputMetrics(id string, optionalParent *monitoring.Registry) *inputMetri
 
 	out := &inputMetrics{
 		unregister:            unreg,
+		registry:              reg,
 		bindAddress:           monitoring.NewString(reg, "bind_address"),
 		batchesReceivedTotal:  monitoring.NewUint(reg, "batches_received_total"),
 		batchesACKedTotal:     monitoring.NewUint(reg, "batches_acked_total"),
 		messagesReceivedTotal: monitoring.NewUint(reg, "messages_received_total"),
 		batchProcessingTime:   metrics.NewUniformSample(1024),
+		batchSize:             metrics.NewUniformSample(1024),
+
+		connectionsRejectedTotal: monitoring.NewUint(reg, "connections_rejected_total"),
+		connectionsLimitedTotal:  monitoring.NewUint(reg, "connections_limited_total"),
+		messagesThrottledTotal:   monitoring.NewUint(reg, "messages_throttled_total"),
+		batchesRejectedTotal:     monitoring.NewUint(reg, "batches_rejected_total"),
 	}
 	adapter.NewGoMetrics(reg, "batch_processing_time", adapter.Accept).
 		Register("histogram", metrics.NewHistogram(out.batchProcessingTime)) //nolint:errcheck // A unique namespace is used so name collisions are impossible.
+	adapter.NewGoMetrics(reg, "batch_size", adapter.Accept).
+		Register("histogram", metrics.NewHistogram(out.batchSize)) //nolint:errcheck // A unique namespace is used so name collisions are impossible.
 
 	return out
 }
@@ -0,0 +1,280 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package lumberjack
+
+import (
+	"context"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+	"github.com/elastic/go-lumber/lj"
+)
+
+// peerListener is a net.Listener that enforces the source allowlist and the
+// per-peer connection limit. Rejected connections are closed before any
+// TLS handshake or protocol processing takes place.
+type peerListener struct {
+	net.Listener
+	peers *peerTracker
+	log   *logp.Logger
+}
+
+func (l *peerListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		ip := peerIP(conn.RemoteAddr().String())
+		if !l.peers.allowed(ip) {
+			l.log.Debugw("Rejected connection from source not in allowlist.", "source.address", conn.RemoteAddr().String())
+			l.peers.metrics.connectionsRejectedTotal.Inc()
+			conn.Close()
+			continue
+		}
+		if !l.peers.connect(ip) {
+			l.log.Debugw("Rejected connection exceeding per-peer connection limit.", "source.address", conn.RemoteAddr().String())
+			l.peers.metrics.connectionsLimitedTotal.Inc()
+			conn.Close()
+			continue
+		}
+		return &peerConn{Conn: conn, release: func() { l.peers.disconnect(ip) }}, nil
+	}
+}
+
+// peerConn releases its peer connection slot when closed.
+type peerConn struct {
+	net.Conn
+	once    sync.Once
+	release func()
+}
+
+func (c *peerConn) Close() error {
+	c.once.Do(c.release)
+	return c.Conn.Close()
+}
+
+// peerTracker holds the state of each connected peer.
+type peerTracker struct {
+	ctx     context.Context
+	allow   []*net.IPNet // Nil allows all sources.
+	maxConn int
+	eps     float64
+	process func(*lj.Batch)
+	metrics *inputMetrics
+	log     *logp.Logger
+
+	mu    sync.Mutex
+	peers map[string]*peer
+}
+
+// peer is the state of a single source IP. A peer is removed once it has
+// no open connections and no batches pending processing.
+type peer struct {
+	conns   int
+	pending int
+	limiter *rate.Limiter
+	queue   chan *lj.Batch // Nil when no events rate limit is configured.
+
+	batchesReceivedTotal   uint64
+	batchesRejectedTotal   uint64
+	messagesReceivedTotal  uint64
+	messagesThrottledTotal uint64
+}
+
+// peerQueueSize is the number of batches that may be queued for a
+// rate limited peer. Batches received when the queue is full are rejected.
+const peerQueueSize = 16
+
+func newPeerTracker(ctx context.Context, c config, process func(*lj.Batch), metrics *inputMetrics, log *logp.Logger) *peerTracker {
+	t := &peerTracker{
+		ctx:     ctx,
+		allow:   c.allowedNetworks(),
+		maxConn: c.MaxConnectionsPerPeer,
+		eps:     c.MaxEventsPerSecondPerPeer,
+		process: process,
+		metrics: metrics,
+		log:     log,
+		peers:   make(map[string]*peer),
+	}
+	monitoring.NewFunc(metrics.registry, "peers", t.report, monitoring.Report)
+	return t
+}
+
+func (t *peerTracker) allowed(ip net.IP) bool {
+	if t.allow == nil {
+		return true
+	}
+	if ip == nil {
+		return false
+	}
+	for _, n := range t.allow {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// connect registers a new connection from ip, returning false if the peer
+// has reached its connection limit.
+func (t *peerTracker) connect(ip net.IP) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p := t.getOrCreate(ip.String())
+	if t.maxConn > 0 && p.conns >= t.maxConn {
+		t.removeIfIdle(ip.String(), p)
+		return false
+	}
+	p.conns++
+	return true
+}
+
+func (t *peerTracker) disconnect(ip net.IP) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := ip.String()
+	p, ok := t.peers[key]
+	if !ok {
+		return
+	}
+	p.conns--
+	t.removeIfIdle(key, p)
+}
+
+// dispatch processes the batch, applying the per-peer events rate limit if
+// one is configured. Batches from a rate limited peer are processed in order
+// by a per-peer worker so that a throttled peer does not delay other peers.
+// dispatch never blocks on a rate limited peer. When the queue of the peer is
+// full, the batch is rejected without being ACKed, the client sends it again
+// after its ACK timeout.
+func (t *peerTracker) dispatch(batch *lj.Batch) {
+	key := peerIP(batch.RemoteAddr).String()
+
+	t.mu.Lock()
+	p := t.getOrCreate(key)
+	p.batchesReceivedTotal++
+	p.messagesReceivedTotal += uint64(len(batch.Events))
+	if p.queue == nil {
+		t.removeIfIdle(key, p)
+		t.mu.Unlock()
+		t.process(batch)
+		return
+	}
+	defer t.mu.Unlock()
+
+	select {
+	case p.queue <- batch:
+		p.pending++
+	default:
+		p.batchesRejectedTotal++
+		t.metrics.batchesRejectedTotal.Inc()
+		t.removeIfIdle(key, p)
+		t.log.Warnw("Rejected batch from peer exceeding its events rate limit, its queue is full.",
+			"source.address", batch.RemoteAddr, "queue_size", peerQueueSize)
+	}
+}
+
+func (t *peerTracker) getOrCreate(key string) *peer {
+	p, ok := t.peers[key]
+	if ok {
+		return p
+	}
+	p = &peer{}
+	if t.eps > 0 {
+		p.limiter = rate.NewLimiter(rate.Limit(t.eps), max(1, int(math.Ceil(t.eps))))
+		p.queue = make(chan *lj.Batch, peerQueueSize)
+		go t.work(key, p)
+	}
+	t.peers[key] = p
+	return p
+}
+
+// removeIfIdle removes p if it has no connections and no pending batches.
+// It must be called with t.mu held.
+func (t *peerTracker) removeIfIdle(key string, p *peer) {
+	if p.conns > 0 || p.pending > 0 {
+		return
+	}
+	delete(t.peers, key)
+	if p.queue != nil {
+		close(p.queue)
+	}
+}
+
+func (t *peerTracker) work(key string, p *peer) {
+	for batch := range p.queue {
+		if !t.wait(p, len(batch.Events)) {
+			return
+		}
+		t.process(batch)
+
+		t.mu.Lock()
+		p.pending--
+		t.removeIfIdle(key, p)
+		t.mu.Unlock()
+	}
+}
+
+// wait blocks until n events are allowed by the peer's rate limiter. It
+// returns false if the server is closed while waiting.
+func (t *peerTracker) wait(p *peer, n int) bool {
+	if n == 0 {
+		return true
+	}
+	if p.limiter.TokensAt(time.Now()) < float64(n) {
+		t.mu.Lock()
+		p.messagesThrottledTotal += uint64(n)
+		t.mu.Unlock()
+		t.metrics.messagesThrottledTotal.Add(uint64(n))
+	}
+	burst := p.limiter.Burst()
+	for n > 0 {
+		k := min(n, burst)
+		if err := p.limiter.WaitN(t.ctx, k); err != nil {
+			return false
+		}
+		n -= k
+	}
+	return true
+}
+
+// report writes the per-peer metrics to the monitoring visitor.
+func (t *peerTracker) report(_ monitoring.Mode, v monitoring.Visitor) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	v.OnRegistryStart()
+	defer v.OnRegistryFinished()
+	for key, p := range t.peers {
+		monitoring.ReportNamespace(v, key, func() {
+			monitoring.ReportInt(v, "connections_active", int64(p.conns))
+			monitoring.ReportInt(v, "batches_pending", int64(p.pending))
+			monitoring.ReportInt(v, "batches_received_total", int64(p.batchesReceivedTotal))
+			monitoring.ReportInt(v, "batches_rejected_total", int64(p.batchesRejectedTotal))
+			monitoring.ReportInt(v, "messages_received_total", int64(p.messagesReceivedTotal))
+			monitoring.ReportInt(v, "messages_throttled_total", int64(p.messagesThrottledTotal))
+		})
+	}
+}
+
+// peerIP returns the IP address of a host:port address, or nil if the
+// address cannot be parsed.
+func peerIP(addr string) net.IP {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	return net.ParseIP(host)
+}
@@ -5,6 +5,7 @@
 package lumberjack
 
 import (
+	"context"
 	"crypto/tls"
 	"net"
 	"strings"
@@ -26,21 +27,36 @@ type server struct {
 	log            *logp.Logger
 	publish        func(beat.Event)
 	metrics        *inputMetrics
+	peers          *peerTracker
+	cancel         context.CancelFunc
 	ljSvr          lumber.Server
 	ljSvrCloseOnce sync.Once
 	bindAddress    string
 }
 
 func newServer(c config, log *logp.Logger, pub func(beat.Event), metrics *inputMetrics) (*server, error) {
-	ljSvr, bindAddress, err := newLumberjack(c)
-	if err != nil {
-		return nil, err
-	}
-
 	if metrics == nil {
 		metrics = newInputMetrics("", monitoring.NewRegistry())
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &server{
+		config:  c,
+		log:     log,
+		publish: pub,
+		metrics: metrics,
+		cancel:  cancel,
+	}
+	s.peers = newPeerTracker(ctx, c, s.processBatch, metrics, log)
+
+	ljSvr, bindAddress, err := newLumberjack(c, s.peers, log)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	s.ljSvr = ljSvr
+	s.bindAddress = bindAddress
+
 	bindURI := "tcp://" + bindAddress
 	if c.TLS.IsEnabled() {
 		bindURI = "tls://" + bindAddress
@@ -48,19 +64,13 @@ func newServer(c config, log *logp.Logger, pub func(beat.Event), metrics *inputM
 	log.Infof(inputName+" is listening at %v.", bindURI)
 	metrics.bindAddress.Set(bindURI)
 
-	return &server{
-		config:      c,
-		log:         log,
-		publish:     pub,
-		metrics:     metrics,
-		ljSvr:       ljSvr,
-		bindAddress: bindAddress,
-	}, nil
+	return s, nil
 }
 
 func (s *server) Close() error {
 	var err error
 	s.ljSvrCloseOnce.Do(func() {
+		s.cancel()
 		err = s.ljSvr.Close()
 	})
 	return err
@@ -69,7 +79,7 @@ func (s *server) Close() error {
 func (s *server) Run() error {
 	// Process batches until the input is stopped.
 	for batch := range s.ljSvr.ReceiveChan() {
-		s.processBatch(batch)
+		s.peers.dispatch(batch)
 	}
 
 	return nil
@@ -84,6 +94,7 @@ func (s *server) processBatch(batch *lj.Batch) {
 		return
 	}
 	s.metrics.messagesReceivedTotal.Add(uint64(len(batch.Events)))
+	s.metrics.batchSize.Update(int64(len(batch.Events)))
 
 	// Track all the Beat events associated to the Lumberjack batch so that
 	// the batch can be ACKed after the Beat events are delivered successfully.
@@ -127,7 +138,7 @@ func makeEvent(remoteAddr string, tlsState *tls.ConnectionState, lumberjackEvent
 	return event
 }
 
-func newLumberjack(c config) (lj lumber.Server, bindAddress string, err error) {
+func newLumberjack(c config, peers *peerTracker, log *logp.Logger) (lj lumber.Server, bindAddress string, err error) {
 	// Setup optional TLS.
 	var tlsConfig *tls.Config
 	if c.TLS.IsEnabled() {
@@ -146,6 +157,8 @@ func newLumberjack(c config) (lj lumber.Server, bindAddress string, err error) {
 	if err != nil {
 		return nil, "", err
 	}
+	// Filter sources before the TLS handshake.
+	l = &peerListener{Listener: l, peers: peers, log: log}
 	if tlsConfig != nil {
 		l = tls.NewListener(l, tlsConfig)
 	}
@@ -22,6 +22,7 @@ import (
 	"github.com/elastic/elastic-agent-libs/logp"
 	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
 	client "github.com/elastic/go-lumber/client/v2"
+	"github.com/elastic/go-lumber/lj"
 )
 
 const testTimeout = 10 * time.Second
@@ -67,6 +68,102 @@ func TestServer(t *testing.T) {
 
 		testSendReceive(t, c, 10, clientConf)
 	})
+
+	t.Run("allowed source", func(t *testing.T) {
+		c := makeTestConfig()
+		c.AllowedSources = []string{"127.0.0.0/8", "::1"}
+
+		testSendReceive(t, c, 10, nil)
+	})
+
+	t.Run("per-peer limits", func(t *testing.T) {
+		c := makeTestConfig()
+		c.MaxConnectionsPerPeer = 1
+		c.MaxEventsPerSecondPerPeer = 5
+
+		testSendReceive(t, c, 10, nil)
+	})
+}
+
+func TestServerRejectsSource(t *testing.T) {
+	logp.TestingSetup()
+	log := logp.NewLogger(inputName).With("test_name", t.Name())
+
+	var c config
+	c.InitDefaults()
+	c.ListenAddress = "localhost:0"
+	c.Timeout = time.Second
+	c.AllowedSources = []string{"192.0.2.0/24"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	metrics := newInputMetrics("", nil)
+	collect := newEventCollector(ctx, 1)
+	s, err := newServer(c, log, collect.Publish, metrics)
+	require.NoError(t, err)
+	defer s.Close()
+	go s.Run() //nolint:errcheck // Run only returns nil.
+
+	err = sendData(ctx, t, s.bindAddress, 1, nil)
+	require.Error(t, err, "expected connection from disallowed source to fail")
+	require.EqualValues(t, 1, metrics.connectionsRejectedTotal.Get())
+}
+
+func TestPeerTrackerLimits(t *testing.T) {
+	var c config
+	c.InitDefaults()
+	c.MaxConnectionsPerPeer = 2
+
+	peers := newPeerTracker(context.Background(), c, func(*lj.Batch) {}, newInputMetrics("", nil), logp.NewLogger(inputName))
+	ip := net.ParseIP("192.0.2.1")
+	require.True(t, peers.connect(ip))
+	require.True(t, peers.connect(ip))
+	require.False(t, peers.connect(ip), "expected third connection to be rejected")
+	require.True(t, peers.connect(net.ParseIP("192.0.2.2")), "expected limit to be per-peer")
+
+	peers.disconnect(ip)
+	require.True(t, peers.connect(ip), "expected connection after disconnect")
+
+	peers.disconnect(ip)
+	peers.disconnect(ip)
+	peers.disconnect(net.ParseIP("192.0.2.2"))
+	require.Empty(t, peers.peers, "expected idle peers to be removed")
+}
+
+func TestPeerTrackerQueueFull(t *testing.T) {
+	var c config
+	c.InitDefaults()
+	c.MaxEventsPerSecondPerPeer = 1
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	metrics := newInputMetrics("", nil)
+	// The batches of the peer are never processed.
+	peers := newPeerTracker(ctx, c, func(*lj.Batch) { <-ctx.Done() }, metrics, logp.NewLogger(inputName))
+
+	const batches = peerQueueSize + 5
+	for i := 0; i < batches; i++ {
+		peers.dispatch(lj.NewBatchWithSourceMetadata([]interface{}{"event"}, "192.0.2.1:5044", nil))
+	}
+
+	// dispatch doesn't block, batches exceeding the queue of the peer are
+	// rejected. The worker of the peer may have taken one batch.
+	rejected := metrics.batchesRejectedTotal.Get()
+	require.GreaterOrEqual(t, rejected, uint64(4))
+	require.LessOrEqual(t, rejected, uint64(5))
+
+	// Other peers are not affected.
+	other := make(chan struct{})
+	go func() {
+		peers.dispatch(lj.NewBatchWithSourceMetadata([]interface{}{"event"}, "192.0.2.2:5044", nil))
+		close(other)
+	}()
+	select {
+	case <-other:
+	case <-time.After(testTimeout):
+		t.Fatal("dispatch blocked on another peer")
+	}
 }
 
 func testSendReceive(t testing.TB, c config, numberOfEvents int, clientTLSConfig *tls.Config) {
@@ -0,0 +1,112 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package msgraph
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/transport/httpcommon"
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+)
+
+const (
+	defaultAPIEndpoint = "https://graph.microsoft.com/v1.0"
+
+	// Graph allows up to 4230 minutes for most resources, but shorter
+	// lifetimes limit the time notifications are sent to a stopped input.
+	defaultSubscriptionLifetime = time.Hour
+)
+
+type config struct {
+	// APIEndpoint is the base URL of the Graph API.
+	APIEndpoint string `config:"api_endpoint"`
+
+	// NotificationURL is the public HTTPS URL Graph sends the change and
+	// lifecycle notifications to. It must reach the listener of the input.
+	NotificationURL string `config:"notification_url" validate:"required"`
+	// ClientState is the secret sent by Graph with each notification.
+	ClientState string `config:"client_state" validate:"required"`
+
+	ListenAddress string                  `config:"listen_address"`
+	ListenPort    string                  `config:"listen_port"`
+	TLS           *tlscommon.ServerConfig `config:"ssl"`
+
+	// SubscriptionLifetime is the requested lifetime of the subscriptions,
+	// they are renewed when a half of it is left.
+	SubscriptionLifetime time.Duration `config:"subscription_lifetime"`
+
+	Subscriptions []subscriptionConfig `config:"subscriptions" validate:"required"`
+
+	Transport httpcommon.HTTPTransportSettings `config:",inline"`
+}
+
+type subscriptionConfig struct {
+	// Resource is the Graph resource to subscribe to, e.g. users.
+	Resource string `config:"resource" validate:"required"`
+	// ChangeTypes are the changes to be notified of.
+	ChangeTypes []string `config:"change_types"`
+	// Delta is the delta query path of the resource, e.g. users/delta.
+	// When set, it is used to collect the changes missed by notifications.
+	Delta string `config:"delta"`
+}
+
+func defaultConfig() config {
+	return config{
+		APIEndpoint:          defaultAPIEndpoint,
+		ListenAddress:        "localhost",
+		ListenPort:           "8000",
+		SubscriptionLifetime: defaultSubscriptionLifetime,
+		Transport:            httpcommon.DefaultHTTPTransportSettings(),
+	}
+}
+
+func (c *config) Validate() error {
+	u, err := url.Parse(c.NotificationURL)
+	if err != nil {
+		return fmt.Errorf("invalid notification_url: %w", err)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return errors.New("notification_url must be an HTTP(S) URL")
+	}
+	if c.SubscriptionLifetime < time.Minute {
+		return errors.New("subscription_lifetime must be at least 1m")
+	}
+	seen := make(map[string]bool)
+	for i, s := range c.Subscriptions {
+		if seen[s.Resource] {
+			return fmt.Errorf("duplicate subscription to resource %q", s.Resource)
+		}
+		seen[s.Resource] = true
+		for _, t := range s.ChangeTypes {
+			switch t {
+			case "created", "updated", "deleted":
+			default:
+				return fmt.Errorf("invalid change type %q in subscription %d", t, i)
+			}
+		}
+	}
+	return nil
+}
+
+// changeType returns the changeType value of the subscription request.
+func (s subscriptionConfig) changeType() string {
+	if len(s.ChangeTypes) == 0 {
+		return "created,updated,deleted"
+	}
+	return strings.Join(s.ChangeTypes, ",")
+}
+
+// path returns the URL path the listener serves notifications on.
+func (c *config) path() string {
+	u, err := url.Parse(c.NotificationURL)
+	if err != nil || u.Path == "" {
+		return "/"
+	}
+	return u.Path
+}
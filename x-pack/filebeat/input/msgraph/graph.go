@@ -0,0 +1,149 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package msgraph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/entityanalytics/provider/azuread/authenticator"
+)
+
+// subscription is a Graph change notification subscription.
+type subscription struct {
+	ID                       string    `json:"id,omitempty"`
+	Resource                 string    `json:"resource,omitempty"`
+	ChangeType               string    `json:"changeType,omitempty"`
+	NotificationURL          string    `json:"notificationUrl,omitempty"`
+	LifecycleNotificationURL string    `json:"lifecycleNotificationUrl,omitempty"`
+	ClientState              string    `json:"clientState,omitempty"`
+	ExpirationDateTime       time.Time `json:"expirationDateTime"`
+}
+
+// deltaPage is a page of a delta query response.
+type deltaPage struct {
+	Value     []map[string]interface{} `json:"value"`
+	NextLink  string                   `json:"@odata.nextLink"`
+	DeltaLink string                   `json:"@odata.deltaLink"`
+}
+
+// graphClient calls the Graph API.
+type graphClient struct {
+	endpoint string
+	auth     authenticator.Authenticator
+	client   *http.Client
+}
+
+func (g *graphClient) createSubscription(ctx context.Context, sub subscription) (subscription, error) {
+	var created subscription
+	err := g.do(ctx, http.MethodPost, g.endpoint+"/subscriptions", sub, &created)
+	if err != nil {
+		return created, fmt.Errorf("failed to create subscription to %s: %w", sub.Resource, err)
+	}
+	return created, nil
+}
+
+func (g *graphClient) renewSubscription(ctx context.Context, id string, expiration time.Time) (subscription, error) {
+	var renewed subscription
+	err := g.do(ctx, http.MethodPatch, g.endpoint+"/subscriptions/"+url.PathEscape(id), subscription{ExpirationDateTime: expiration}, &renewed)
+	if err != nil {
+		return renewed, fmt.Errorf("failed to renew subscription %s: %w", id, err)
+	}
+	return renewed, nil
+}
+
+func (g *graphClient) deleteSubscription(ctx context.Context, id string) error {
+	err := g.do(ctx, http.MethodDelete, g.endpoint+"/subscriptions/"+url.PathEscape(id), nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete subscription %s: %w", id, err)
+	}
+	return nil
+}
+
+// delta runs a delta query starting at link, which is either the initial
+// delta URL of the resource or the delta link of a previous query. Each page
+// of changes is passed to fn, and the delta link for the next query is
+// returned once all the pages were read.
+func (g *graphClient) delta(ctx context.Context, link string, fn func([]map[string]interface{}) error) (string, error) {
+	seen := make(map[string]bool)
+	for {
+		if seen[link] {
+			return "", fmt.Errorf("delta query loop on %s", link)
+		}
+		seen[link] = true
+
+		var page deltaPage
+		if err := g.do(ctx, http.MethodGet, link, nil, &page); err != nil {
+			return "", fmt.Errorf("failed delta query: %w", err)
+		}
+		if err := fn(page.Value); err != nil {
+			return "", err
+		}
+		switch {
+		case page.NextLink != "":
+			link = page.NextLink
+		case page.DeltaLink != "":
+			return page.DeltaLink, nil
+		default:
+			return "", fmt.Errorf("delta query response without next or delta link")
+		}
+	}
+}
+
+// deltaURL returns the URL of the initial delta query of path. The query
+// only establishes the baseline, so that the first delta link returns the
+// changes that happen after it instead of the whole resource.
+func (g *graphClient) deltaURL(path string) string {
+	u := g.endpoint + "/" + strings.TrimPrefix(path, "/")
+	sep := "?"
+	if strings.Contains(u, "?") {
+		sep = "&"
+	}
+	return u + sep + "$deltatoken=latest"
+}
+
+func (g *graphClient) do(ctx context.Context, method, url string, body, dst interface{}) error {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, r)
+	if err != nil {
+		return fmt.Errorf("unable to create request: %w", err)
+	}
+	token, err := g.auth.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to get bearer token: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("unexpected status code: %s, body: %s", resp.Status, msg)
+	}
+	if dst == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(dst)
+}
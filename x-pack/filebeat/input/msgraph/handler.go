@@ -0,0 +1,80 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package msgraph
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+// maxBodySize limits the size of the notification requests. Graph batches
+// notifications, but without resource data they are small.
+const maxBodySize = 4 << 20
+
+// notification is a change or lifecycle notification sent by Graph.
+type notification struct {
+	SubscriptionID                 string                 `json:"subscriptionId"`
+	SubscriptionExpirationDateTime string                 `json:"subscriptionExpirationDateTime"`
+	ClientState                    string                 `json:"clientState"`
+	ChangeType                     string                 `json:"changeType"`
+	Resource                       string                 `json:"resource"`
+	ResourceData                   map[string]interface{} `json:"resourceData"`
+	TenantID                       string                 `json:"tenantId"`
+	LifecycleEvent                 string                 `json:"lifecycleEvent"`
+}
+
+// handler receives the notifications sent by Graph and passes them to the
+// input.
+type handler struct {
+	clientState   string
+	notifications chan<- notification
+	done          <-chan struct{}
+	log           *logp.Logger
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if token := r.URL.Query().Get("validationToken"); token != "" {
+		// Graph validates the notification URL when a subscription
+		// is created by expecting the token back.
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, token)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST requests are allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body struct {
+		Value []notification `json:"value"`
+	}
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(&body)
+	if err != nil {
+		h.log.Warnw("Failed to decode notifications", "error", err)
+		http.Error(w, "invalid notification body", http.StatusBadRequest)
+		return
+	}
+
+	for _, n := range body.Value {
+		if subtle.ConstantTimeCompare([]byte(n.ClientState), []byte(h.clientState)) != 1 {
+			h.log.Warnw("Dropping notification with invalid client state", "subscription_id", n.SubscriptionID)
+			continue
+		}
+		select {
+		case h.notifications <- n:
+		case <-h.done:
+			// Graph retries, and the changes are otherwise collected
+			// by delta queries when the input restarts.
+			http.Error(w, "input stopped", http.StatusServiceUnavailable)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
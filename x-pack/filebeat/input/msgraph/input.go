@@ -0,0 +1,398 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// Package msgraph implements an input collecting Microsoft Graph change
+// notifications. The input subscribes to the configured resources, receives
+// the notifications on an HTTP listener and uses delta queries to collect
+// the changes that were missed while it was not running or that Graph
+// could not deliver.
+package msgraph
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	v2 "github.com/elastic/beats/v7/filebeat/input/v2"
+	inputcursor "github.com/elastic/beats/v7/filebeat/input/v2/input-cursor"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/feature"
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/entityanalytics/provider/azuread/authenticator/oauth2"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+	"github.com/elastic/go-concert/ctxtool"
+)
+
+const inputName = "msgraph"
+
+func Plugin(log *logp.Logger, store inputcursor.StateStore) v2.Plugin {
+	return v2.Plugin{
+		Name:       inputName,
+		Stability:  feature.Experimental,
+		Deprecated: false,
+		Info:       "Microsoft Graph change notifications",
+		Doc:        "Collect change notifications from Microsoft Graph",
+		Manager: &inputcursor.InputManager{
+			Logger:     log,
+			StateStore: store,
+			Type:       inputName,
+			Configure:  configure,
+		},
+	}
+}
+
+func configure(cfg *conf.C) ([]inputcursor.Source, inputcursor.Input, error) {
+	config := defaultConfig()
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, nil, fmt.Errorf("reading config: %w", err)
+	}
+	return []inputcursor.Source{&source{cfg: config, raw: cfg}}, input{}, nil
+}
+
+type source struct {
+	cfg config
+	// raw holds the authentication settings.
+	raw *conf.C
+}
+
+func (s *source) Name() string { return s.cfg.NotificationURL }
+
+type input struct{}
+
+func (input) Name() string { return inputName }
+
+func (input) Test(src inputcursor.Source, _ v2.TestContext) error {
+	cfg := src.(*source).cfg
+	l, err := net.Listen("tcp", net.JoinHostPort(cfg.ListenAddress, cfg.ListenPort))
+	if err != nil {
+		return err
+	}
+	return l.Close()
+}
+
+// state is the cursor of the input.
+type state struct {
+	// DeltaLinks holds the delta link of each subscribed resource.
+	DeltaLinks map[string]string `struct:"delta_links"`
+}
+
+func (input) Run(env v2.Context, src inputcursor.Source, crsr inputcursor.Cursor, pub inputcursor.Publisher) error {
+	s := src.(*source)
+
+	var st state
+	if err := crsr.Unpack(&st); err != nil {
+		return fmt.Errorf("failed to unpack cursor: %w", err)
+	}
+
+	auth, err := oauth2.New(s.raw, env.Logger)
+	if err != nil {
+		return fmt.Errorf("unable to create authenticator: %w", err)
+	}
+	client, err := s.cfg.Transport.Client()
+	if err != nil {
+		return fmt.Errorf("unable to create HTTP client: %w", err)
+	}
+	graph := &graphClient{
+		endpoint: strings.TrimSuffix(s.cfg.APIEndpoint, "/"),
+		auth:     auth,
+		client:   client,
+	}
+	return run(env, s.cfg, graph, st, pub)
+}
+
+func run(env v2.Context, cfg config, graph *graphClient, st state, pub inputcursor.Publisher) error {
+	ctx := ctxtool.FromCanceller(env.Cancelation)
+	log := env.Logger
+
+	var tlsConfig *tls.Config
+	addr := net.JoinHostPort(cfg.ListenAddress, cfg.ListenPort)
+	tlsConfigBuilder, err := tlscommon.LoadTLSServerConfig(cfg.TLS)
+	if err != nil {
+		return err
+	}
+	if tlsConfigBuilder != nil {
+		tlsConfig = tlsConfigBuilder.BuildServerConfig(addr)
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	if tlsConfig != nil {
+		l = tls.NewListener(l, tlsConfig)
+	}
+
+	// The buffer lets the listener accept notifications while delta
+	// queries are running.
+	notifications := make(chan notification, 64)
+	mux := http.NewServeMux()
+	mux.Handle(cfg.path(), &handler{
+		clientState:   cfg.ClientState,
+		notifications: notifications,
+		done:          ctx.Done(),
+		log:           log,
+	})
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	srvErr := make(chan error, 1)
+	go func() { srvErr <- srv.Serve(l) }()
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	log.Infof("Listening for Graph notifications on %s%s", addr, cfg.path())
+
+	s := &stream{
+		cfg:   cfg,
+		graph: graph,
+		pub:   pub,
+		log:   log,
+		state: st,
+		subs:  make(map[string]*activeSubscription),
+		now:   time.Now,
+	}
+	if s.state.DeltaLinks == nil {
+		s.state.DeltaLinks = make(map[string]string)
+	}
+	defer s.unsubscribeAll()
+
+	for _, sc := range cfg.Subscriptions {
+		if err := s.subscribe(ctx, sc); err != nil {
+			return err
+		}
+	}
+	// Collect the changes that happened while the input was not running.
+	for _, sc := range cfg.Subscriptions {
+		s.collectDelta(ctx, sc)
+	}
+
+	ticker := time.NewTicker(cfg.SubscriptionLifetime / 4)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-srvErr:
+			if errors.Is(err, http.ErrServerClosed) {
+				return nil
+			}
+			return fmt.Errorf("notification listener failed: %w", err)
+		case n := <-notifications:
+			if err := s.handle(ctx, n); err != nil {
+				return err
+			}
+		case <-ticker.C:
+			s.renew(ctx)
+		}
+	}
+}
+
+// stream manages the subscriptions of an input and publishes the changes
+// of the subscribed resources.
+type stream struct {
+	cfg   config
+	graph *graphClient
+	pub   inputcursor.Publisher
+	log   *logp.Logger
+	state state
+
+	// subs holds the active subscriptions by ID.
+	subs map[string]*activeSubscription
+	now  func() time.Time
+}
+
+type activeSubscription struct {
+	cfg        subscriptionConfig
+	id         string
+	expiration time.Time
+}
+
+func (s *stream) subscribe(ctx context.Context, sc subscriptionConfig) error {
+	sub, err := s.graph.createSubscription(ctx, subscription{
+		Resource:                 sc.Resource,
+		ChangeType:               sc.changeType(),
+		NotificationURL:          s.cfg.NotificationURL,
+		LifecycleNotificationURL: s.cfg.NotificationURL,
+		ClientState:              s.cfg.ClientState,
+		ExpirationDateTime:       s.now().Add(s.cfg.SubscriptionLifetime).UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	s.log.Infow("Subscribed to resource", "resource", sc.Resource, "subscription_id", sub.ID, "expiration", sub.ExpirationDateTime)
+	s.subs[sub.ID] = &activeSubscription{cfg: sc, id: sub.ID, expiration: sub.ExpirationDateTime}
+	return nil
+}
+
+// resubscribe replaces a subscription that was removed or could not be
+// renewed, and collects the changes that were missed in between.
+func (s *stream) resubscribe(ctx context.Context, sub *activeSubscription) error {
+	delete(s.subs, sub.id)
+	if err := s.subscribe(ctx, sub.cfg); err != nil {
+		return err
+	}
+	s.collectDelta(ctx, sub.cfg)
+	return nil
+}
+
+// renew extends the subscriptions that reached half of their lifetime.
+func (s *stream) renew(ctx context.Context) {
+	for _, sub := range s.subs {
+		if sub.expiration.Sub(s.now()) > s.cfg.SubscriptionLifetime/2 {
+			continue
+		}
+		s.renewSubscription(ctx, sub)
+	}
+}
+
+func (s *stream) renewSubscription(ctx context.Context, sub *activeSubscription) {
+	renewed, err := s.graph.renewSubscription(ctx, sub.id, s.now().Add(s.cfg.SubscriptionLifetime).UTC())
+	if err == nil {
+		s.log.Debugw("Renewed subscription", "subscription_id", sub.id, "expiration", renewed.ExpirationDateTime)
+		sub.expiration = renewed.ExpirationDateTime
+		return
+	}
+	s.log.Warnw("Failed to renew subscription, subscribing again", "subscription_id", sub.id, "error", err)
+	if err := s.resubscribe(ctx, sub); err != nil {
+		s.log.Errorw("Failed to subscribe again", "resource", sub.cfg.Resource, "error", err)
+	}
+}
+
+func (s *stream) unsubscribeAll() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for id := range s.subs {
+		if err := s.graph.deleteSubscription(ctx, id); err != nil {
+			s.log.Warnw("Failed to delete subscription", "subscription_id", id, "error", err)
+		}
+	}
+}
+
+func (s *stream) handle(ctx context.Context, n notification) error {
+	sub, ok := s.subs[n.SubscriptionID]
+	if !ok {
+		// Left over from a previous run, it expires on its own.
+		s.log.Debugw("Ignoring notification of unknown subscription", "subscription_id", n.SubscriptionID)
+		return nil
+	}
+
+	switch n.LifecycleEvent {
+	case "":
+		return s.pub.Publish(s.notificationEvent(n), nil)
+	case "reauthorizationRequired":
+		s.renewSubscription(ctx, sub)
+	case "subscriptionRemoved":
+		s.log.Infow("Subscription was removed", "subscription_id", sub.id)
+		return s.resubscribe(ctx, sub)
+	case "missed":
+		s.log.Infow("Notifications were missed, running delta query", "resource", sub.cfg.Resource)
+		s.collectDelta(ctx, sub.cfg)
+	default:
+		s.log.Debugw("Ignoring unknown lifecycle event", "lifecycle_event", n.LifecycleEvent)
+	}
+	return nil
+}
+
+func (s *stream) notificationEvent(n notification) beat.Event {
+	fields := mapstr.M{
+		"subscription_id": n.SubscriptionID,
+		"change_type":     n.ChangeType,
+		"resource":        n.Resource,
+	}
+	if n.TenantID != "" {
+		fields["tenant_id"] = n.TenantID
+	}
+	if n.ResourceData != nil {
+		fields["resource_data"] = mapstr.M(n.ResourceData)
+	}
+	return beat.Event{
+		Timestamp: s.now(),
+		Fields: mapstr.M{
+			"event": mapstr.M{
+				"kind":   "event",
+				"action": n.ChangeType,
+			},
+			"msgraph": fields,
+		},
+	}
+}
+
+func (s *stream) deltaEvent(sc subscriptionConfig, item map[string]interface{}) beat.Event {
+	changeType := "updated"
+	if _, removed := item["@removed"]; removed {
+		changeType = "deleted"
+	}
+	return beat.Event{
+		Timestamp: s.now(),
+		Fields: mapstr.M{
+			"event": mapstr.M{
+				"kind":   "event",
+				"action": changeType,
+			},
+			"msgraph": mapstr.M{
+				"change_type":   changeType,
+				"resource":      sc.Resource,
+				"resource_data": mapstr.M(item),
+			},
+		},
+	}
+}
+
+// collectDelta publishes the changes of a resource since its last delta
+// query. The first query of a resource only establishes the baseline. The
+// new delta link is stored with the last published event.
+func (s *stream) collectDelta(ctx context.Context, sc subscriptionConfig) {
+	if sc.Delta == "" {
+		return
+	}
+	link, ok := s.state.DeltaLinks[sc.Resource]
+	if !ok {
+		link = s.graph.deltaURL(sc.Delta)
+	}
+
+	// The last event is held back to carry the cursor update.
+	var last *beat.Event
+	next, err := s.graph.delta(ctx, link, func(items []map[string]interface{}) error {
+		for _, item := range items {
+			if last != nil {
+				if err := s.pub.Publish(*last, nil); err != nil {
+					return err
+				}
+			}
+			event := s.deltaEvent(sc, item)
+			last = &event
+		}
+		return nil
+	})
+	if err != nil {
+		s.log.Errorw("Failed to collect changes with delta query", "resource", sc.Resource, "error", err)
+		if last != nil {
+			_ = s.pub.Publish(*last, nil)
+		}
+		return
+	}
+
+	s.state.DeltaLinks[sc.Resource] = next
+	if last != nil {
+		if err := s.pub.Publish(*last, s.cursor()); err != nil {
+			s.log.Errorw("Failed to publish event", "error", err)
+		}
+	}
+}
+
+// cursor returns a copy of the state to be stored with an event.
+func (s *stream) cursor() state {
+	links := make(map[string]string, len(s.state.DeltaLinks))
+	for k, v := range s.state.DeltaLinks {
+		links[k] = v
+	}
+	return state{DeltaLinks: links}
+}
@@ -0,0 +1,238 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package msgraph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v2 "github.com/elastic/beats/v7/filebeat/input/v2"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/entityanalytics/provider/azuread/authenticator/mock"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+type publishedEvent struct {
+	event  beat.Event
+	cursor interface{}
+}
+
+type publisher struct {
+	mu        sync.Mutex
+	published []publishedEvent
+}
+
+func (p *publisher) Publish(e beat.Event, cursor interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.published = append(p.published, publishedEvent{event: e, cursor: cursor})
+	return nil
+}
+
+func (p *publisher) events() []publishedEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]publishedEvent(nil), p.published...)
+}
+
+// fakeGraph mocks the subscription and delta query endpoints of Graph.
+type fakeGraph struct {
+	t *testing.T
+
+	mu      sync.Mutex
+	deleted []string
+	deltas  int
+}
+
+func (g *fakeGraph) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+mock.DefaultTokenValue {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/subscriptions":
+		var sub subscription
+		if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		// validate the notification URL like Graph does
+		resp, err := http.Post(sub.NotificationURL+"?validationToken="+url.QueryEscape("token a&b"), "text/plain", nil)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != "token a&b" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		sub.ID = "sub-" + sub.Resource
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(sub)
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/subscriptions/"):
+		g.mu.Lock()
+		g.deleted = append(g.deleted, strings.TrimPrefix(r.URL.Path, "/subscriptions/"))
+		g.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet && r.URL.Path == "/users/delta":
+		g.mu.Lock()
+		g.deltas++
+		g.mu.Unlock()
+		base := "http://" + r.Host + "/users/delta"
+		switch r.URL.Query().Get("$deltatoken") {
+		case "1":
+			fmt.Fprintf(w, `{"value":[{"id":"u1","displayName":"One"}],"@odata.nextLink":%q}`, base+"?$skiptoken=a")
+		case "2":
+			fmt.Fprintf(w, `{"value":[],"@odata.deltaLink":%q}`, base+"?$deltatoken=2")
+		default:
+			if r.URL.Query().Get("$skiptoken") == "a" {
+				fmt.Fprintf(w, `{"value":[{"id":"u2","@removed":{"reason":"changed"}}],"@odata.deltaLink":%q}`, base+"?$deltatoken=2")
+				return
+			}
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	default:
+		g.t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func freePort(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	_, port, err := net.SplitHostPort(l.Addr().String())
+	require.NoError(t, err)
+	return port
+}
+
+func postNotifications(t *testing.T, url string, notifications ...notification) {
+	body, err := json.Marshal(map[string]interface{}{"value": notifications})
+	require.NoError(t, err)
+	resp, err := http.Post(url, "application/json", strings.NewReader(string(body)))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+}
+
+func TestInput(t *testing.T) {
+	logp.TestingSetup()
+
+	fake := &fakeGraph{t: t}
+	graphSrv := httptest.NewServer(fake)
+	defer graphSrv.Close()
+
+	port := freePort(t)
+	notificationURL := "http://127.0.0.1:" + port + "/graph"
+
+	cfg := defaultConfig()
+	cfg.APIEndpoint = graphSrv.URL
+	cfg.NotificationURL = notificationURL
+	cfg.ClientState = "secret"
+	cfg.ListenAddress = "127.0.0.1"
+	cfg.ListenPort = port
+	cfg.Subscriptions = []subscriptionConfig{{Resource: "users", Delta: "users/delta"}}
+	require.NoError(t, cfg.Validate())
+
+	graph := &graphClient{endpoint: graphSrv.URL, auth: mock.New(""), client: http.DefaultClient}
+	st := state{DeltaLinks: map[string]string{"users": graphSrv.URL + "/users/delta?$deltatoken=1"}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	env := v2.Context{
+		Logger:      logp.NewLogger("msgraph_test"),
+		ID:          "test",
+		Cancelation: ctx,
+	}
+	pub := &publisher{}
+	done := make(chan error)
+	go func() { done <- run(env, cfg, graph, st, pub) }()
+
+	// changes since the stored delta link
+	require.Eventually(t, func() bool { return len(pub.events()) == 2 }, 10*time.Second, 10*time.Millisecond)
+	events := pub.events()
+	assert.Nil(t, events[0].cursor)
+	assert.Equal(t, "updated", mustGet(t, events[0].event.Fields, "msgraph.change_type"))
+	assert.Equal(t, "u1", mustGet(t, events[0].event.Fields, "msgraph.resource_data.id"))
+	assert.Equal(t, "deleted", mustGet(t, events[1].event.Fields, "msgraph.change_type"))
+	assert.Equal(t, state{DeltaLinks: map[string]string{"users": graphSrv.URL + "/users/delta?$deltatoken=2"}}, events[1].cursor)
+
+	// change notifications, the one with an invalid client state is dropped
+	postNotifications(t, notificationURL,
+		notification{SubscriptionID: "sub-users", ClientState: "secret", ChangeType: "updated", Resource: "Users/u3", ResourceData: map[string]interface{}{"id": "u3"}},
+		notification{SubscriptionID: "sub-users", ClientState: "wrong", ChangeType: "updated", Resource: "Users/u4"},
+		notification{SubscriptionID: "sub-other", ClientState: "secret", ChangeType: "updated", Resource: "Users/u5"},
+	)
+	require.Eventually(t, func() bool { return len(pub.events()) == 3 }, 10*time.Second, 10*time.Millisecond)
+	event := pub.events()[2].event
+	assert.Equal(t, "sub-users", mustGet(t, event.Fields, "msgraph.subscription_id"))
+	assert.Equal(t, "Users/u3", mustGet(t, event.Fields, "msgraph.resource"))
+	assert.Equal(t, "u3", mustGet(t, event.Fields, "msgraph.resource_data.id"))
+	assert.Equal(t, "updated", mustGet(t, event.Fields, "event.action"))
+
+	// missed notifications trigger a delta query
+	postNotifications(t, notificationURL,
+		notification{SubscriptionID: "sub-users", ClientState: "secret", LifecycleEvent: "missed"},
+	)
+	require.Eventually(t, func() bool {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		return fake.deltas == 3
+	}, 10*time.Second, 10*time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("input did not stop")
+	}
+	assert.Len(t, pub.events(), 3)
+	assert.Equal(t, []string{"sub-users"}, fake.deleted)
+}
+
+func TestInitialDeltaURL(t *testing.T) {
+	g := &graphClient{endpoint: "https://graph.example.com/v1.0"}
+	assert.Equal(t, "https://graph.example.com/v1.0/users/delta?$deltatoken=latest", g.deltaURL("users/delta"))
+	assert.Equal(t, "https://graph.example.com/v1.0/groups/delta?$select=id&$deltatoken=latest", g.deltaURL("/groups/delta?$select=id"))
+}
+
+func TestConfigValidate(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.NotificationURL = "https://example.com/graph"
+	cfg.ClientState = "secret"
+	cfg.Subscriptions = []subscriptionConfig{{Resource: "users", ChangeTypes: []string{"updated"}}}
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, "/graph", cfg.path())
+	assert.Equal(t, "updated", cfg.Subscriptions[0].changeType())
+
+	cfg.Subscriptions = append(cfg.Subscriptions, subscriptionConfig{Resource: "users"})
+	assert.ErrorContains(t, cfg.Validate(), "duplicate subscription")
+
+	cfg.Subscriptions = []subscriptionConfig{{Resource: "users", ChangeTypes: []string{"moved"}}}
+	assert.ErrorContains(t, cfg.Validate(), "invalid change type")
+}
+
+func mustGet(t *testing.T, m mapstr.M, key string) interface{} {
+	t.Helper()
+	v, err := m.GetValue(key)
+	require.NoError(t, err)
+	return v
+}
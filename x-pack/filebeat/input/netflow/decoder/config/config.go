@@ -16,6 +16,16 @@ type ActiveSessionsMetric interface {
 	Dec()
 }
 
+// TemplateReplicator replicates the templates received from the exporters
+// to other collectors, so that the collectors behind a load balancer can
+// decode the records whose template was received by another collector.
+type TemplateReplicator interface {
+	// Replicate is called with the IDs of the templates and the body of each
+	// template set received for the given session. exporter is empty when
+	// templates are shared.
+	Replicate(version uint16, exporter string, sourceID uint32, setID uint16, templateIDs []uint16, body []byte)
+}
+
 // Config stores the configuration used by the NetFlow Collector.
 type Config struct {
 	protocols            []string
@@ -26,6 +36,7 @@ type Config struct {
 	sharedTemplates      bool
 	withCache            bool
 	activeSessionsMetric ActiveSessionsMetric
+	templateReplicator   TemplateReplicator
 }
 
 var defaultCfg = Config{
@@ -115,6 +126,13 @@ func (c *Config) WithActiveSessionsMetric(metric ActiveSessionsMetric) *Config {
 	return c
 }
 
+// WithTemplateReplicator configures the replicator that template sets are
+// passed to. Templates are not replicated by default.
+func (c *Config) WithTemplateReplicator(replicator TemplateReplicator) *Config {
+	c.templateReplicator = replicator
+	return c
+}
+
 // Protocols returns a list of the protocols enabled.
 func (c *Config) Protocols() []string {
 	return c.protocols
@@ -157,3 +175,12 @@ func (c *Config) ActiveSessionsMetric() ActiveSessionsMetric {
 
 	return c.activeSessionsMetric
 }
+
+// TemplateReplicator returns the configured template replicator, or nil.
+func (c *Config) TemplateReplicator() TemplateReplicator {
+	if c == nil {
+		return nil
+	}
+
+	return c.templateReplicator
+}
@@ -13,6 +13,7 @@ import (
 	"log"
 	"net"
 	"sync"
+	"time"
 
 	"github.com/elastic/beats/v7/x-pack/filebeat/input/netflow/decoder/config"
 	"github.com/elastic/beats/v7/x-pack/filebeat/input/netflow/decoder/protocol"
@@ -92,6 +93,46 @@ func (p *Decoder) Read(buf *bytes.Buffer, source net.Addr) (records []record.Rec
 	return handler.OnPacket(buf, source)
 }
 
+// templateAdder is implemented by the protocols that use templates.
+type templateAdder interface {
+	AddTemplates(exporter string, sourceID uint32, setID uint16, seenAt time.Time, body []byte) ([]record.Record, error)
+}
+
+// AddTemplates adds the templates of a template set replicated from another
+// collector to the session of the given exporter and source ID. seenAt is
+// when the other collector received the set from the exporter, templates
+// received later are not replaced. It returns the records that were waiting
+// for the added templates.
+func (p *Decoder) AddTemplates(version uint16, exporter string, sourceID uint32, setID uint16, seenAt time.Time, body []byte) ([]record.Record, error) {
+	handler, exists := p.protos[version]
+	if !exists {
+		return nil, fmt.Errorf("netflow protocol version %d not supported", version)
+	}
+	adder, ok := handler.(templateAdder)
+	if !ok {
+		return nil, fmt.Errorf("netflow protocol version %d does not use templates", version)
+	}
+	flows, err := adder.AddTemplates(exporter, sourceID, setID, seenAt, body)
+	if err != nil {
+		return nil, err
+	}
+
+	// The headers of the packets of the waiting records are gone.
+	metadata := record.Map{
+		"version":  uint64(version),
+		"sourceId": uint64(sourceID),
+	}
+	if exporter != "" {
+		metadata["address"] = exporter
+	}
+	now := time.Now()
+	for i := range flows {
+		flows[i].Exporter = metadata
+		flows[i].Timestamp = now
+	}
+	return flows, nil
+}
+
 // NewConfig returns a new configuration structure to be passed to NewDecoder.
 func NewConfig() *config.Config {
 	cfg := config.Defaults()
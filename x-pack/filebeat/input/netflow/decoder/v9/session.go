@@ -38,6 +38,8 @@ type TemplateKey uint16
 type TemplateWrapper struct {
 	Template *template.Template
 	Delete   atomic.Bool
+	// seenAt is when the template was received from the exporter.
+	seenAt time.Time
 }
 
 // SessionState holds the state for a single session (observation domain).
@@ -62,7 +64,22 @@ func (s *SessionState) AddTemplate(t *template.Template) {
 	s.logger.Printf("state %p addTemplate %d %p", s, t.ID, t)
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
-	s.Templates[TemplateKey(t.ID)] = &TemplateWrapper{Template: t}
+	s.Templates[TemplateKey(t.ID)] = &TemplateWrapper{Template: t, seenAt: time.Now()}
+}
+
+// AddTemplateIfNewer adds the passed template, received from the exporter
+// at seenAt, unless the session has a template with the same ID received
+// after it. It returns whether the template was added.
+func (s *SessionState) AddTemplateIfNewer(t *template.Template, seenAt time.Time) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if current, found := s.Templates[TemplateKey(t.ID)]; found && current.seenAt.After(seenAt) {
+		s.logger.Printf("state %p ignored older template %d %p", s, t.ID, t)
+		return false
+	}
+	s.logger.Printf("state %p addTemplate %d %p", s, t.ID, t)
+	s.Templates[TemplateKey(t.ID)] = &TemplateWrapper{Template: t, seenAt: seenAt}
+	return true
 }
 
 // GetTemplate returns a template by ID.
@@ -15,6 +15,7 @@ import (
 	"github.com/elastic/beats/v7/x-pack/filebeat/input/netflow/decoder/config"
 	"github.com/elastic/beats/v7/x-pack/filebeat/input/netflow/decoder/protocol"
 	"github.com/elastic/beats/v7/x-pack/filebeat/input/netflow/decoder/record"
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/netflow/decoder/template"
 )
 
 const (
@@ -36,6 +37,7 @@ type NetflowV9Protocol struct {
 	cache          *pendingTemplatesCache
 	detectReset    bool
 	shareTemplates bool
+	replicator     config.TemplateReplicator
 }
 
 func init() {
@@ -58,6 +60,7 @@ func NewProtocolWithDecoder(decoder Decoder, config config.Config, logger *log.L
 		timeout:        config.ExpirationTimeout(),
 		detectReset:    config.SequenceResetEnabled(),
 		shareTemplates: config.ShareTemplatesEnabled(),
+		replicator:     config.TemplateReplicator(),
 	}
 
 	if config.Cache() {
@@ -120,8 +123,12 @@ func (p *NetflowV9Protocol) OnPacket(buf *bytes.Buffer, source net.Addr) (flows
 			p.logger.Printf("FlowSet ID %+v overflows packet from %s", set, source)
 			break
 		}
-		body := bytes.NewBuffer(buf.Next(set.BodyLength()))
+		raw := buf.Next(set.BodyLength())
+		body := bytes.NewBuffer(raw)
 		p.logger.Printf("FlowSet ID %d length %d", set.SetID, set.BodyLength())
+		if p.replicator != nil && set.SetID < 256 {
+			p.replicate(header.Version, sessionKey, set.SetID, raw)
+		}
 
 		f, err := p.parseSet(set.SetID, sessionKey, session, body)
 		if err != nil {
@@ -167,19 +174,58 @@ func (p *NetflowV9Protocol) parseSet(
 	}
 	for _, template := range templates {
 		session.AddTemplate(template)
+		flows = append(flows, p.applyPending(key, template)...)
+	}
+
+	return flows, nil
+}
 
-		if p.cache == nil {
+// applyPending returns the records of the session that were waiting for a
+// template, decoded with the given template.
+func (p *NetflowV9Protocol) applyPending(key SessionKey, template *template.Template) (flows []record.Record) {
+	if p.cache == nil {
+		return nil
+	}
+	events := p.cache.GetAndRemove(key)
+	for _, e := range events {
+		f, err := template.Apply(e, 0)
+		if err != nil {
 			continue
 		}
-		events := p.cache.GetAndRemove(key)
-		for _, e := range events {
-			f, err := template.Apply(e, 0)
-			if err != nil {
-				continue
-			}
-			flows = append(flows, f...)
-		}
+		flows = append(flows, f...)
 	}
+	return flows
+}
 
+// replicate passes a template set to the replicator along with the IDs of
+// its templates. Invalid sets are not replicated.
+func (p *NetflowV9Protocol) replicate(version uint16, key SessionKey, setID uint16, raw []byte) {
+	templates, err := p.decoder.ReadTemplateSet(setID, bytes.NewBuffer(raw))
+	if err != nil || len(templates) == 0 {
+		return
+	}
+	ids := make([]uint16, len(templates))
+	for i, template := range templates {
+		ids[i] = template.ID
+	}
+	p.replicator.Replicate(version, key.Addr, key.SourceID, setID, ids, append([]byte(nil), raw...))
+}
+
+// AddTemplates adds the templates of a template set replicated from
+// another collector to the session of the given exporter. The templates
+// are ignored if the session has newer ones with the same IDs. It returns
+// the records that were waiting for the added templates.
+func (p *NetflowV9Protocol) AddTemplates(exporter string, sourceID uint32, setID uint16, seenAt time.Time, body []byte) (flows []record.Record, err error) {
+	templates, err := p.decoder.ReadTemplateSet(setID, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+	key := SessionKey{Addr: exporter, SourceID: sourceID}
+	session := p.Session.GetOrCreate(key)
+	for _, template := range templates {
+		if session.AddTemplateIfNewer(template, seenAt) {
+			flows = append(flows, p.applyPending(key, template)...)
+		}
+	}
 	return flows, nil
 }
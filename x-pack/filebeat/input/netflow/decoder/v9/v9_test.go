@@ -7,8 +7,10 @@ package v9
 import (
 	"bytes"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/elastic/beats/v7/x-pack/filebeat/input/netflow/decoder/config"
 	"github.com/elastic/beats/v7/x-pack/filebeat/input/netflow/decoder/fields"
@@ -299,3 +301,124 @@ func TestSharedTemplates(t *testing.T) {
 		assert.Empty(t, flows)
 	})
 }
+
+type testReplicator struct {
+	version     uint16
+	exporter    string
+	sourceID    uint32
+	setID       uint16
+	templateIDs []uint16
+	body        []byte
+}
+
+func (r *testReplicator) Replicate(version uint16, exporter string, sourceID uint32, setID uint16, templateIDs []uint16, body []byte) {
+	*r = testReplicator{version, exporter, sourceID, setID, templateIDs, body}
+}
+
+func TestTemplateReplication(t *testing.T) {
+	addr := test.MakeAddress(t, "127.0.0.1:12345")
+	templatePacket := []uint16{
+		// Header
+		// Version, Count, Uptime, Ts, SeqNo, Source
+		9, 1, 11, 11, 22, 22, 33, 33, 0, 1234,
+		// Set #1 (template)
+		0, 20, /*len of set*/
+		999, 3, /*len*/
+		1, 4, // Fields
+		2, 4,
+		3, 4,
+	}
+	flowsPacket := []uint16{
+		// Header
+		// Version, Count, Uptime, Ts, SeqNo, Source
+		9, 1, 11, 11, 22, 22, 33, 34, 0, 1234,
+		// Set #1 (flows)
+		999, 16, /*len of set*/
+		1, 1,
+		2, 2,
+		3, 3,
+	}
+
+	// The collector that receives the template replicates it.
+	replicator := &testReplicator{}
+	cfg := config.Defaults()
+	cfg.WithLogOutput(test.TestLogWriter{TB: t}).WithTemplateReplicator(replicator)
+	proto := New(cfg)
+	flows, err := proto.OnPacket(test.MakePacket(templatePacket), addr)
+	assert.NoError(t, err)
+	assert.Empty(t, flows)
+	assert.Equal(t, uint16(9), replicator.version)
+	assert.Equal(t, addr.String(), replicator.exporter)
+	assert.Equal(t, uint32(1234), replicator.sourceID)
+	assert.Equal(t, uint16(0), replicator.setID)
+	assert.Equal(t, []uint16{999}, replicator.templateIDs)
+	assert.Len(t, replicator.body, 16)
+
+	// The collector that receives the flows adds the replicated template.
+	cfg = config.Defaults()
+	cfg.WithLogOutput(test.TestLogWriter{TB: t})
+	other := New(cfg).(*NetflowV9Protocol)
+	flows, err = other.OnPacket(test.MakePacket(flowsPacket), addr)
+	assert.NoError(t, err)
+	assert.Empty(t, flows)
+
+	flows, err = other.AddTemplates(replicator.exporter, replicator.sourceID, replicator.setID, time.Now(), replicator.body)
+	assert.NoError(t, err)
+	assert.Empty(t, flows)
+	flows, err = other.OnPacket(test.MakePacket(flowsPacket), addr)
+	assert.NoError(t, err)
+	assert.Len(t, flows, 1)
+}
+
+func TestReplicatedTemplatesAreOlder(t *testing.T) {
+	addr := test.MakeAddress(t, "127.0.0.1:12345")
+	cfg := config.Defaults()
+	cfg.WithLogOutput(test.TestLogWriter{TB: t})
+	proto := New(cfg).(*NetflowV9Protocol)
+
+	// Template 999 with two fields, received from the exporter.
+	flows, err := proto.OnPacket(test.MakePacket([]uint16{
+		9, 1, 11, 11, 22, 22, 33, 33, 0, 1234,
+		0, 16, 999, 2, 1, 4, 2, 4,
+	}), addr)
+	require.NoError(t, err)
+	require.Empty(t, flows)
+
+	// An older version of template 999, with three fields, is replicated.
+	old := test.MakePacket([]uint16{999, 3, 1, 4, 2, 4, 3, 4}).Bytes()
+	_, err = proto.AddTemplates(addr.String(), 1234, 0, time.Now().Add(-time.Minute), old)
+	require.NoError(t, err)
+
+	key := SessionKey{Addr: addr.String(), SourceID: 1234}
+	template := proto.Session.GetOrCreate(key).GetTemplate(999)
+	require.NotNil(t, template)
+	assert.Len(t, template.Fields, 2, "the replicated template must not replace a newer one")
+
+	// A newer replicated version replaces it.
+	_, err = proto.AddTemplates(addr.String(), 1234, 0, time.Now().Add(time.Second), old)
+	require.NoError(t, err)
+	template = proto.Session.GetOrCreate(key).GetTemplate(999)
+	require.NotNil(t, template)
+	assert.Len(t, template.Fields, 3)
+}
+
+func TestReplicatedTemplatesApplyPendingRecords(t *testing.T) {
+	addr := test.MakeAddress(t, "127.0.0.1:12345")
+	cfg := config.Defaults()
+	cfg.WithLogOutput(test.TestLogWriter{TB: t}).WithCache(true)
+	proto := New(cfg).(*NetflowV9Protocol)
+
+	// The records wait for their template.
+	flows, err := proto.OnPacket(test.MakePacket([]uint16{
+		9, 1, 11, 11, 22, 22, 33, 34, 0, 1234,
+		999, 16, 1, 1, 2, 2, 3, 3,
+	}), addr)
+	require.NoError(t, err)
+	require.Empty(t, flows)
+
+	template := test.MakePacket([]uint16{999, 3, 1, 4, 2, 4, 3, 4}).Bytes()
+	flows, err = proto.AddTemplates(addr.String(), 1234, 0, time.Now(), template)
+	require.NoError(t, err)
+	assert.Len(t, flows, 1)
+	assert.Empty(t, proto.cache.GetAndRemove(SessionKey{Addr: addr.String(), SourceID: 1234}))
+}
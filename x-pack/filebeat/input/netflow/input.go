@@ -21,6 +21,7 @@ import (
 	"github.com/elastic/beats/v7/libbeat/management/status"
 	"github.com/elastic/beats/v7/x-pack/filebeat/input/netflow/decoder"
 	"github.com/elastic/beats/v7/x-pack/filebeat/input/netflow/decoder/fields"
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/netflow/decoder/record"
 
 	conf "github.com/elastic/elastic-agent-libs/config"
 	"github.com/elastic/elastic-agent-libs/logp"
@@ -86,6 +87,7 @@ type netflowInput struct {
 	mtx              sync.Mutex
 	cfg              config
 	decoder          *decoder.Decoder
+	replicator       *templateReplicator
 	clients          []beat.Client
 	metrics          *netflowMetrics
 	udpMetrics       *netmetrics.UDP
@@ -128,8 +130,7 @@ func (n *netflowInput) Run(env v2.Context, connector beat.PipelineConnector) err
 	defer n.udpMetrics.Close()
 
 	n.metrics = newInputMetrics(n.udpMetrics.Registry())
-	var err error
-	n.decoder, err = decoder.NewDecoder(decoder.NewConfig().
+	decoderCfg := decoder.NewConfig().
 		WithProtocols(n.cfg.Protocols...).
 		WithExpiration(n.cfg.ExpirationTimeout).
 		WithLogOutput(&logDebugWrapper{Logger: n.logger}).
@@ -137,8 +138,23 @@ func (n *netflowInput) Run(env v2.Context, connector beat.PipelineConnector) err
 		WithSequenceResetEnabled(n.cfg.DetectSequenceReset).
 		WithSharedTemplates(n.cfg.ShareTemplates).
 		WithActiveSessionsMetric(n.metrics.ActiveSessions()).
-		WithCache(n.cfg.NumberOfWorkers > 1))
+		WithCache(n.cfg.NumberOfWorkers > 1)
+
+	var err error
+	if n.cfg.Cluster.Enabled {
+		n.replicator, err = newTemplateReplicator(n.logger.Named("cluster"), n.cfg.Cluster, n.cfg.ExpirationTimeout, n.udpMetrics.Registry())
+		if err != nil {
+			env.UpdateStatus(status.Failed, fmt.Sprintf("Failed to initialize netflow template replication: %v", err))
+			return fmt.Errorf("error initializing netflow template replication: %w", err)
+		}
+		decoderCfg.WithTemplateReplicator(n.replicator)
+	}
+
+	n.decoder, err = decoder.NewDecoder(decoderCfg)
 	if err != nil {
+		if n.replicator != nil {
+			n.replicator.Stop()
+		}
 		env.UpdateStatus(status.Failed, fmt.Sprintf("Failed to initialize netflow decoder: %v", err))
 		return fmt.Errorf("error initializing netflow decoder: %w", err)
 	}
@@ -152,7 +168,7 @@ func (n *netflowInput) Run(env v2.Context, connector beat.PipelineConnector) err
 	}
 
 	n.queueC = make(chan packet, n.queueSize)
-	for i := uint32(0); i < n.cfg.NumberOfWorkers; i++ {
+	connect := func() (beat.Client, error) {
 		client, err := connector.ConnectWith(beat.ClientConfig{
 			PublishMode: beat.DefaultGuarantees,
 			Processing: beat.ProcessingConfig{
@@ -164,10 +180,30 @@ func (n *netflowInput) Run(env v2.Context, connector beat.PipelineConnector) err
 			env.UpdateStatus(status.Failed, fmt.Sprintf("Failed connecting to beat event publishing: %v", err))
 			n.logger.Errorw("Failed connecting to beat event publishing", "error", err)
 			n.stop()
+			return nil, err
+		}
+		n.clients = append(n.clients, client)
+		return client, nil
+	}
+
+	if n.replicator != nil {
+		// Records waiting for a template are published when the template
+		// is replicated from another collector.
+		client, err := connect()
+		if err != nil {
 			return err
 		}
+		n.logger.Infow("Starting netflow template replication", "listen", n.cfg.Cluster.Listen, "peers", n.cfg.Cluster.Peers)
+		n.replicator.Start(n.decoder, func(flows []record.Record) {
+			n.publish(client, flows)
+		})
+	}
 
-		n.clients = append(n.clients, client)
+	for i := uint32(0); i < n.cfg.NumberOfWorkers; i++ {
+		client, err := connect()
+		if err != nil {
+			return err
+		}
 		n.wg.Add(1)
 		go func(client beat.Client) {
 			defer n.wg.Done()
@@ -186,17 +222,7 @@ func (n *netflowInput) Run(env v2.Context, connector beat.PipelineConnector) err
 						continue
 					}
 
-					fLen := len(flows)
-					if fLen != 0 {
-						evs := make([]beat.Event, fLen)
-						if flowsTotal := n.metrics.Flows(); flowsTotal != nil {
-							flowsTotal.Add(uint64(fLen))
-						}
-						for flowIdx, flow := range flows {
-							evs[flowIdx] = toBeatEvent(flow, n.internalNetworks)
-						}
-						client.PublishAll(evs)
-					}
+					n.publish(client, flows)
 					n.udpMetrics.Log(pkt.data, pktStartTime)
 				}
 			}
@@ -236,6 +262,22 @@ func (n *netflowInput) Run(env v2.Context, connector beat.PipelineConnector) err
 	return nil
 }
 
+// publish publishes the flows as events.
+func (n *netflowInput) publish(client beat.Client, flows []record.Record) {
+	fLen := len(flows)
+	if fLen == 0 {
+		return
+	}
+	evs := make([]beat.Event, fLen)
+	if flowsTotal := n.metrics.Flows(); flowsTotal != nil {
+		flowsTotal.Add(uint64(fLen))
+	}
+	for flowIdx, flow := range flows {
+		evs[flowIdx] = toBeatEvent(flow, n.internalNetworks)
+	}
+	client.PublishAll(evs)
+}
+
 // An adapter so that logp.Logger can be used as a log.Logger.
 type logDebugWrapper struct {
 	sync.Mutex
@@ -268,6 +310,11 @@ func (n *netflowInput) stop() {
 		return
 	}
 
+	if n.replicator != nil {
+		n.replicator.Stop()
+		n.replicator = nil
+	}
+
 	if n.decoder != nil {
 		if err := n.decoder.Stop(); err != nil {
 			n.logger.Errorw("Error stopping decoder", "error", err)
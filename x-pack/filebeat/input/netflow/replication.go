@@ -0,0 +1,412 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package netflow
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/netflow/decoder/record"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+// Template replication messages are UDP datagrams made of:
+//
+//	magic (4) | netflow version (2) | source ID (4) | set ID (2) | age (4) |
+//	exporter length (2) | exporter | body length (2) | body | HMAC-SHA256 (32)
+//
+// The age is the number of milliseconds since the sender received the set
+// from the exporter. It is relative so that it doesn't depend on the clocks
+// of the collectors being in sync. The HMAC is computed over everything
+// before it with the shared key.
+var replicationMagic = []byte("NFT2")
+
+const (
+	replicationHeaderLen = 4 + 2 + 4 + 2 + 4
+	replicationMACLen    = sha256.Size
+	replicationQueueSize = 1024
+	maxReplicationMsgLen = 65507
+)
+
+type clusterConfig struct {
+	Enabled bool `config:"enabled"`
+	// Listen is the UDP address where the templates of other collectors
+	// are received.
+	Listen string `config:"listen"`
+	// Peers are the UDP addresses of the other collectors.
+	Peers []string `config:"peers"`
+	// Key authenticates the messages between collectors.
+	Key          string        `config:"key"`
+	SyncInterval time.Duration `config:"sync_interval" validate:"positive,nonzero"`
+}
+
+func (c *clusterConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Listen == "" {
+		return errors.New("cluster.listen is required when cluster is enabled")
+	}
+	if len(c.Peers) == 0 {
+		return errors.New("cluster.peers is required when cluster is enabled")
+	}
+	if c.Key == "" {
+		return errors.New("cluster.key is required when cluster is enabled")
+	}
+	return nil
+}
+
+// templateSetKey identifies a replicated template set by the hash of all
+// its template IDs, so that sets sharing their first template are kept apart.
+type templateSetKey struct {
+	version   uint16
+	exporter  string
+	sourceID  uint32
+	setID     uint16
+	templates uint64
+}
+
+// hashTemplateIDs returns a hash of the template IDs that doesn't depend on
+// their order in the set.
+func hashTemplateIDs(ids []uint16) uint64 {
+	sorted := slices.Clone(ids)
+	slices.Sort(sorted)
+	h := fnv.New64a()
+	var buf [2]byte
+	for _, id := range sorted {
+		binary.BigEndian.PutUint16(buf[:], id)
+		h.Write(buf[:])
+	}
+	return h.Sum64()
+}
+
+type templateSet struct {
+	body     []byte
+	lastSeen time.Time
+}
+
+type replicationMetrics struct {
+	sent     *monitoring.Uint
+	received *monitoring.Uint
+	invalid  *monitoring.Uint
+	dropped  *monitoring.Uint
+}
+
+func newReplicationMetrics(reg *monitoring.Registry) *replicationMetrics {
+	if reg == nil {
+		return nil
+	}
+	return &replicationMetrics{
+		sent:     monitoring.NewUint(reg, "replicated_templates_sent_total"),
+		received: monitoring.NewUint(reg, "replicated_templates_received_total"),
+		invalid:  monitoring.NewUint(reg, "replicated_templates_invalid_total"),
+		dropped:  monitoring.NewUint(reg, "replicated_templates_dropped_total"),
+	}
+}
+
+func (m *replicationMetrics) Sent() *monitoring.Uint {
+	if m == nil {
+		return nil
+	}
+	return m.sent
+}
+
+func (m *replicationMetrics) Received() *monitoring.Uint {
+	if m == nil {
+		return nil
+	}
+	return m.received
+}
+
+func (m *replicationMetrics) Invalid() *monitoring.Uint {
+	if m == nil {
+		return nil
+	}
+	return m.invalid
+}
+
+func (m *replicationMetrics) Dropped() *monitoring.Uint {
+	if m == nil {
+		return nil
+	}
+	return m.dropped
+}
+
+// templateAdder adds templates replicated from other collectors.
+type templateAdder interface {
+	AddTemplates(version uint16, exporter string, sourceID uint32, setID uint16, seenAt time.Time, body []byte) ([]record.Record, error)
+}
+
+// templateReplicator sends the template sets received from the exporters
+// to the other collectors of the cluster and adds the ones they send. Sets
+// are sent as they are received and all the known sets are sent again
+// periodically, so that collectors that start later catch up.
+type templateReplicator struct {
+	log        *logp.Logger
+	conn       net.PacketConn
+	peers      []*net.UDPAddr
+	key        []byte
+	interval   time.Duration
+	expiration time.Duration
+	metrics    *replicationMetrics
+
+	queue chan templateSetMsg
+
+	mu   sync.Mutex
+	sets map[templateSetKey]*templateSet
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+type templateSetMsg struct {
+	key  templateSetKey
+	body []byte
+	// age is the time since the set was received from the exporter.
+	age time.Duration
+}
+
+func newTemplateReplicator(log *logp.Logger, cfg clusterConfig, expiration time.Duration, reg *monitoring.Registry) (*templateReplicator, error) {
+	peers := make([]*net.UDPAddr, 0, len(cfg.Peers))
+	for _, peer := range cfg.Peers {
+		addr, err := net.ResolveUDPAddr("udp", peer)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cluster peer %q: %w", peer, err)
+		}
+		peers = append(peers, addr)
+	}
+
+	conn, err := net.ListenPacket("udp", cfg.Listen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for cluster peers: %w", err)
+	}
+
+	return &templateReplicator{
+		log:        log,
+		conn:       conn,
+		peers:      peers,
+		key:        []byte(cfg.Key),
+		interval:   cfg.SyncInterval,
+		expiration: expiration,
+		metrics:    newReplicationMetrics(reg),
+		queue:      make(chan templateSetMsg, replicationQueueSize),
+		sets:       make(map[templateSetKey]*templateSet),
+		done:       make(chan struct{}),
+	}, nil
+}
+
+// Replicate queues a template set received from an exporter to be sent to
+// the peers. It never blocks the decoder, sets are dropped if the queue is
+// full and will be sent on the next synchronization.
+func (r *templateReplicator) Replicate(version uint16, exporter string, sourceID uint32, setID uint16, templateIDs []uint16, body []byte) {
+	if len(templateIDs) == 0 {
+		return
+	}
+	key := templateSetKey{
+		version:   version,
+		exporter:  exporter,
+		sourceID:  sourceID,
+		setID:     setID,
+		templates: hashTemplateIDs(templateIDs),
+	}
+	select {
+	case r.queue <- templateSetMsg{key: key, body: body}:
+	default:
+		if c := r.metrics.Dropped(); c != nil {
+			c.Inc()
+		}
+	}
+}
+
+// Start starts sending and receiving templates. Received templates are
+// added to the decoder, and the records that were waiting for them are
+// passed to publish.
+func (r *templateReplicator) Start(decoder templateAdder, publish func([]record.Record)) {
+	r.wg.Add(2)
+	go r.sendLoop()
+	go r.receiveLoop(decoder, publish)
+}
+
+// Stop stops the replicator and waits for it to finish.
+func (r *templateReplicator) Stop() {
+	close(r.done)
+	r.conn.Close()
+	r.wg.Wait()
+}
+
+func (r *templateReplicator) sendLoop() {
+	defer r.wg.Done()
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case msg := <-r.queue:
+			r.mu.Lock()
+			r.sets[msg.key] = &templateSet{body: msg.body, lastSeen: time.Now()}
+			r.mu.Unlock()
+			r.send(msg)
+		case <-ticker.C:
+			r.sync()
+		}
+	}
+}
+
+// sync sends all the template sets seen before the expiration timeout,
+// and forgets the older ones.
+func (r *templateReplicator) sync() {
+	var msgs []templateSetMsg
+	r.mu.Lock()
+	for key, set := range r.sets {
+		if r.expiration > 0 && time.Since(set.lastSeen) > r.expiration {
+			delete(r.sets, key)
+			continue
+		}
+		msgs = append(msgs, templateSetMsg{key: key, body: set.body, age: time.Since(set.lastSeen)})
+	}
+	r.mu.Unlock()
+
+	for _, msg := range msgs {
+		r.send(msg)
+	}
+}
+
+func (r *templateReplicator) send(set templateSetMsg) {
+	msg, err := encodeReplicationMsg(r.key, set)
+	if err != nil {
+		r.log.Debugw("Template set not replicated", "error", err)
+		if c := r.metrics.Dropped(); c != nil {
+			c.Inc()
+		}
+		return
+	}
+	for _, peer := range r.peers {
+		if _, err := r.conn.WriteTo(msg, peer); err != nil {
+			r.log.Debugw("Failed to send template set to cluster peer", "peer", peer.String(), "error", err)
+			continue
+		}
+		if c := r.metrics.Sent(); c != nil {
+			c.Inc()
+		}
+	}
+}
+
+func (r *templateReplicator) receiveLoop(decoder templateAdder, publish func([]record.Record)) {
+	defer r.wg.Done()
+	buf := make([]byte, maxReplicationMsgLen)
+	for {
+		n, addr, err := r.conn.ReadFrom(buf)
+		if err != nil {
+			select {
+			case <-r.done:
+				return
+			default:
+			}
+			r.log.Warnw("Failed to read from cluster peers", "error", err)
+			continue
+		}
+		set, err := decodeReplicationMsg(r.key, buf[:n])
+		if err != nil {
+			r.log.Debugw("Invalid template replication message", "peer", addr.String(), "error", err)
+			if c := r.metrics.Invalid(); c != nil {
+				c.Inc()
+			}
+			continue
+		}
+		key := set.key
+		flows, err := decoder.AddTemplates(key.version, key.exporter, key.sourceID, key.setID, time.Now().Add(-set.age), set.body)
+		if err != nil {
+			r.log.Debugw("Failed to add replicated templates", "peer", addr.String(), "error", err)
+			if c := r.metrics.Invalid(); c != nil {
+				c.Inc()
+			}
+			continue
+		}
+		if c := r.metrics.Received(); c != nil {
+			c.Inc()
+		}
+		if len(flows) > 0 {
+			publish(flows)
+		}
+	}
+}
+
+func encodeReplicationMsg(secret []byte, set templateSetMsg) ([]byte, error) {
+	key := set.key
+	size := replicationHeaderLen + 2 + len(key.exporter) + 2 + len(set.body) + replicationMACLen
+	if size > maxReplicationMsgLen {
+		return nil, fmt.Errorf("template set of %d bytes is too large", len(set.body))
+	}
+	age := set.age.Milliseconds()
+	if age < 0 {
+		age = 0
+	} else if age > math.MaxUint32 {
+		age = math.MaxUint32
+	}
+	msg := make([]byte, 0, size)
+	msg = append(msg, replicationMagic...)
+	msg = binary.BigEndian.AppendUint16(msg, key.version)
+	msg = binary.BigEndian.AppendUint32(msg, key.sourceID)
+	msg = binary.BigEndian.AppendUint16(msg, key.setID)
+	msg = binary.BigEndian.AppendUint32(msg, uint32(age))
+	msg = binary.BigEndian.AppendUint16(msg, uint16(len(key.exporter)))
+	msg = append(msg, key.exporter...)
+	msg = binary.BigEndian.AppendUint16(msg, uint16(len(set.body)))
+	msg = append(msg, set.body...)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(msg)
+	return mac.Sum(msg), nil
+}
+
+func decodeReplicationMsg(secret []byte, msg []byte) (set templateSetMsg, err error) {
+	if len(msg) < replicationHeaderLen+4+replicationMACLen {
+		return set, errors.New("message too short")
+	}
+	payload, sum := msg[:len(msg)-replicationMACLen], msg[len(msg)-replicationMACLen:]
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	if !hmac.Equal(sum, mac.Sum(nil)) {
+		return set, errors.New("invalid message authentication code")
+	}
+	if !bytes.HasPrefix(payload, replicationMagic) {
+		return set, errors.New("unknown message format")
+	}
+
+	p := payload[len(replicationMagic):]
+	set.key.version = binary.BigEndian.Uint16(p)
+	set.key.sourceID = binary.BigEndian.Uint32(p[2:])
+	set.key.setID = binary.BigEndian.Uint16(p[6:])
+	set.age = time.Duration(binary.BigEndian.Uint32(p[8:])) * time.Millisecond
+	p = p[12:]
+
+	n := int(binary.BigEndian.Uint16(p))
+	p = p[2:]
+	if len(p) < n+2 {
+		return set, errors.New("message truncated")
+	}
+	set.key.exporter = string(p[:n])
+	p = p[n:]
+
+	n = int(binary.BigEndian.Uint16(p))
+	p = p[2:]
+	if len(p) != n || n < 2 {
+		return set, errors.New("message truncated")
+	}
+	set.body = p
+	return set, nil
+}
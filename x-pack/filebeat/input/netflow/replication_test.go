@@ -0,0 +1,158 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package netflow
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/netflow/decoder/record"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+// A v9 template set with template 256 made of the IPv4 source address.
+var testTemplateSet = []byte{0x01, 0x00, 0x00, 0x01, 0x00, 0x08, 0x00, 0x04}
+
+type addedTemplates struct {
+	key    templateSetKey
+	body   []byte
+	seenAt time.Time
+}
+
+type testTemplateAdder chan addedTemplates
+
+func (a testTemplateAdder) AddTemplates(version uint16, exporter string, sourceID uint32, setID uint16, seenAt time.Time, body []byte) ([]record.Record, error) {
+	a <- addedTemplates{
+		key:    templateSetKey{version: version, exporter: exporter, sourceID: sourceID, setID: setID},
+		body:   append([]byte(nil), body...),
+		seenAt: seenAt,
+	}
+	return []record.Record{{Type: record.Flow}}, nil
+}
+
+func TestClusterConfig(t *testing.T) {
+	for name, test := range map[string]struct {
+		cfg map[string]interface{}
+		err string
+	}{
+		"disabled":     {cfg: map[string]interface{}{}},
+		"no_listen":    {cfg: map[string]interface{}{"cluster.enabled": true, "cluster.peers": []string{"10.0.0.2:2056"}, "cluster.key": "k"}, err: "cluster.listen is required"},
+		"no_peers":     {cfg: map[string]interface{}{"cluster.enabled": true, "cluster.listen": ":2056", "cluster.key": "k"}, err: "cluster.peers is required"},
+		"no_key":       {cfg: map[string]interface{}{"cluster.enabled": true, "cluster.listen": ":2056", "cluster.peers": []string{"10.0.0.2:2056"}}, err: "cluster.key is required"},
+		"bad_interval": {cfg: map[string]interface{}{"cluster.sync_interval": "0s"}, err: "zero value accessing 'cluster.sync_interval'"},
+		"valid":        {cfg: map[string]interface{}{"cluster.enabled": true, "cluster.listen": ":2056", "cluster.peers": []string{"10.0.0.2:2056"}, "cluster.key": "k"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := defaultConfig
+			err := conf.MustNewConfigFrom(test.cfg).Unpack(&cfg)
+			if test.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, test.err)
+			}
+		})
+	}
+}
+
+func TestReplicationMsg(t *testing.T) {
+	set := templateSetMsg{
+		key:  templateSetKey{version: 10, exporter: "192.0.2.1:2055", sourceID: 7, setID: 2},
+		body: testTemplateSet,
+		age:  90 * time.Second,
+	}
+	msg, err := encodeReplicationMsg([]byte("secret"), set)
+	require.NoError(t, err)
+
+	got, err := decodeReplicationMsg([]byte("secret"), msg)
+	require.NoError(t, err)
+	assert.Equal(t, set, got)
+
+	_, err = decodeReplicationMsg([]byte("other"), msg)
+	assert.ErrorContains(t, err, "invalid message authentication code")
+
+	msg[len(replicationMagic)+3] ^= 0xff
+	_, err = decodeReplicationMsg([]byte("secret"), msg)
+	assert.ErrorContains(t, err, "invalid message authentication code")
+
+	_, err = decodeReplicationMsg([]byte("secret"), msg[:10])
+	assert.ErrorContains(t, err, "message too short")
+}
+
+func TestTemplateReplicator(t *testing.T) {
+	log := logp.NewLogger("test")
+	peerConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	peerAddr := peerConn.LocalAddr().String()
+	require.NoError(t, peerConn.Close())
+
+	reg := monitoring.NewRegistry()
+	sender, err := newTemplateReplicator(log, clusterConfig{
+		Listen:       "127.0.0.1:0",
+		Peers:        []string{peerAddr},
+		Key:          "secret",
+		SyncInterval: 50 * time.Millisecond,
+	}, time.Minute, reg)
+	require.NoError(t, err)
+	receiver, err := newTemplateReplicator(log, clusterConfig{
+		Listen:       peerAddr,
+		Peers:        []string{sender.conn.LocalAddr().String()},
+		Key:          "secret",
+		SyncInterval: time.Hour,
+	}, time.Minute, nil)
+	require.NoError(t, err)
+
+	received := make(testTemplateAdder, 10)
+	published := make(chan []record.Record, 10)
+	sender.Start(make(testTemplateAdder, 10), func([]record.Record) {})
+	defer sender.Stop()
+	receiver.Start(received, func(flows []record.Record) { published <- flows })
+	defer receiver.Stop()
+
+	start := time.Now()
+	sender.Replicate(9, "", 1234, 0, []uint16{256}, testTemplateSet)
+
+	want := templateSetKey{version: 9, sourceID: 1234, setID: 0}
+	for i := 0; i < 2; i++ {
+		select {
+		case got := <-received:
+			// The first one is sent as it is received, the second one by
+			// the periodic synchronization. Both keep the time the set was
+			// received from the exporter.
+			assert.Equal(t, want, got.key)
+			assert.Equal(t, testTemplateSet, got.body)
+			assert.WithinDuration(t, start, got.seenAt, 40*time.Millisecond)
+		case <-time.After(5 * time.Second):
+			t.Fatal("template set not replicated")
+		}
+		select {
+		case flows := <-published:
+			assert.Len(t, flows, 1, "the records waiting for the templates must be published")
+		case <-time.After(5 * time.Second):
+			t.Fatal("records not published")
+		}
+	}
+	assert.GreaterOrEqual(t, sender.metrics.Sent().Get(), uint64(2))
+}
+
+func TestHashTemplateIDs(t *testing.T) {
+	assert.Equal(t, hashTemplateIDs([]uint16{256, 257}), hashTemplateIDs([]uint16{257, 256}), "hash must not depend on the order of the templates")
+	assert.NotEqual(t, hashTemplateIDs([]uint16{256}), hashTemplateIDs([]uint16{256, 257}), "sets with the same first template must have different keys")
+	assert.NotEqual(t, hashTemplateIDs([]uint16{256, 257}), hashTemplateIDs([]uint16{256, 258}))
+}
+
+func TestTemplateReplicatorKeepsSetsWithSameFirstTemplate(t *testing.T) {
+	r := &templateReplicator{queue: make(chan templateSetMsg, 2)}
+	r.Replicate(9, "", 1234, 0, []uint16{256}, testTemplateSet)
+	r.Replicate(9, "", 1234, 0, []uint16{256, 257}, testTemplateSet)
+
+	first, second := <-r.queue, <-r.queue
+	assert.NotEqual(t, first.key, second.key)
+}
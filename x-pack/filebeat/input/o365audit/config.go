@@ -89,6 +89,11 @@ type APIConfig struct {
 	// MaxQuerySize is the maximum time window that can be queried. The default
 	// is 24h.
 	MaxQuerySize time.Duration `config:"max_query_size" validate:"positive"`
+
+	// SubscriptionHealthTimeout is the time without any content blob being
+	// listed after which a subscription is reported as unhealthy. A value
+	// of zero disables the health reporting.
+	SubscriptionHealthTimeout time.Duration `config:"subscription_health_timeout" validate:"min=0"`
 }
 
 func defaultConfig() Config {
@@ -129,6 +134,10 @@ func defaultConfig() Config {
 			MaxRequestsPerMinute: 2000,
 
 			SetIDFromAuditRecord: true,
+
+			// Content is usually available within 12 hours of the
+			// audited activity, longer silences are suspicious.
+			SubscriptionHealthTimeout: timeDay,
 		},
 	}
 }
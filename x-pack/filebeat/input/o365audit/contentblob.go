@@ -27,6 +27,8 @@ type contentBlob struct {
 	// skipLines is used when resuming from a saved cursor so that already
 	// acknowledged objects are not duplicated.
 	skipLines int
+	// throttled counts the consecutive throttled attempts of this request.
+	throttled int
 }
 
 // String returns a printable representation of this transaction.
@@ -106,6 +108,15 @@ func (c contentBlob) handleError(response *http.Response) (actions []poll.Action
 		}
 	}
 
+	if isThrottled(response, msg) {
+		c.throttled++
+		delay := c.env.throttleDelay(response, c.throttled)
+		c.env.Logger.Warnf("Request throttled by the server. Retrying in %v.", delay)
+		return []poll.Action{
+			poll.Fetch(withDelay{contentBlob: c, delay: delay}),
+		}
+	}
+
 	switch response.StatusCode {
 	case 401: // Authentication error. Renew oauth token and repeat this op.
 		return []poll.Action{
@@ -0,0 +1,99 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package o365audit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/o365audit/poll"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// subscriptionHealth tracks when a subscription last delivered content blobs
+// so that a subscription that silently stops delivering them is reported.
+// It's kept across restarts of the polling loop for a stream.
+type subscriptionHealth struct {
+	lastContent time.Time
+	unhealthy   bool
+}
+
+// CheckHealth updates the health of the subscription after listing its
+// content and returns an action reporting a change of its state.
+func (env apiEnvironment) CheckHealth(gotContent bool) []poll.Action {
+	if env.Health == nil || env.Config.SubscriptionHealthTimeout <= 0 {
+		return nil
+	}
+	now := env.Clock()
+	if gotContent {
+		env.Health.lastContent = now
+		if env.Health.unhealthy {
+			env.Health.unhealthy = false
+			env.Logger.Infof("Subscription is delivering content again.")
+			return []poll.Action{env.ReportSubscriptionState(true, "content delivery resumed")}
+		}
+		return nil
+	}
+	if env.Health.lastContent.IsZero() {
+		env.Health.lastContent = now
+		return nil
+	}
+	if idle := now.Sub(env.Health.lastContent); !env.Health.unhealthy && idle >= env.Config.SubscriptionHealthTimeout {
+		env.Health.unhealthy = true
+		reason := fmt.Sprintf("no content delivered in %v", idle.Round(time.Second))
+		env.Logger.Warnf("Subscription is unhealthy: %s.", reason)
+		return []poll.Action{env.ReportSubscriptionState(false, reason)}
+	}
+	return nil
+}
+
+// SetUnhealthy marks the subscription as unhealthy for the given reason and
+// returns an action reporting it, unless it was already unhealthy.
+func (env apiEnvironment) SetUnhealthy(reason string) []poll.Action {
+	if env.Health == nil || env.Health.unhealthy {
+		return nil
+	}
+	env.Health.unhealthy = true
+	env.Logger.Warnf("Subscription is unhealthy: %s.", reason)
+	return []poll.Action{env.ReportSubscriptionState(false, reason)}
+}
+
+// ReportSubscriptionState returns an action that produces a beat.Event
+// describing the health of the subscription.
+func (env apiEnvironment) ReportSubscriptionState(healthy bool, reason string) poll.Action {
+	return func(poll.Enqueuer) error {
+		return env.Callback(env.subscriptionEvent(healthy, reason), nil)
+	}
+}
+
+func (env apiEnvironment) subscriptionEvent(healthy bool, reason string) beat.Event {
+	status := "unhealthy"
+	if healthy {
+		status = "healthy"
+	}
+	subscription := mapstr.M{
+		"tenant_id":    env.TenantID,
+		"content_type": env.ContentType,
+		"status":       status,
+		"reason":       reason,
+	}
+	if !env.Health.lastContent.IsZero() {
+		subscription["last_content"] = env.Health.lastContent.UTC()
+	}
+	return beat.Event{
+		Timestamp: time.Now(),
+		Fields: mapstr.M{
+			"message": fmt.Sprintf("Subscription to %s for tenant %s is %s: %s", env.ContentType, env.TenantID, status, reason),
+			"event": mapstr.M{
+				"kind":   "state",
+				"action": "subscription-" + status,
+			},
+			fieldsPrefix: mapstr.M{
+				"subscription": subscription,
+			},
+		},
+	}
+}
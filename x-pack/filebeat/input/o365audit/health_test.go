@@ -0,0 +1,94 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package o365audit
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+)
+
+func TestSubscriptionHealth(t *testing.T) {
+	clock := now
+	env := testConfig()
+	env.TenantID = "1234"
+	env.ContentType = contentType
+	env.Clock = func() time.Time { return clock }
+	env.Health = &subscriptionHealth{}
+	var events []beat.Event
+	env.Callback = func(event beat.Event, _ interface{}) error {
+		events = append(events, event)
+		return nil
+	}
+	var f fakePoll
+	lb := makeListBlob(checkpoint{Timestamp: now.Add(-time.Hour)}, env)
+
+	// Nothing reported while within the timeout.
+	_, next := f.SearchQuery(t, lb, nil)
+	clock = clock.Add(12 * time.Hour)
+	_, next = f.SearchQuery(t, next.(listBlob), nil)
+	assert.Empty(t, events)
+
+	// Reported once after the timeout.
+	clock = clock.Add(12 * time.Hour)
+	_, next = f.SearchQuery(t, next.(listBlob), nil)
+	clock = clock.Add(time.Hour)
+	_, next = f.SearchQuery(t, next.(listBlob), nil)
+	require.Len(t, events, 1)
+	assert.Equal(t, "unhealthy", mustGet(t, events[0], "o365audit.subscription.status"))
+	assert.Equal(t, "state", mustGet(t, events[0], "event.kind"))
+	assert.Equal(t, now, mustGet(t, events[0], "o365audit.subscription.last_content"))
+
+	// Recovery is reported when content is listed again.
+	lb = next.(listBlob)
+	_, _ = f.SearchQuery(t, lb, []blob{makeBlob(lb.startTime.Add(time.Minute), "live")})
+	require.Len(t, events, 2)
+	assert.Equal(t, "healthy", mustGet(t, events[1], "o365audit.subscription.status"))
+	assert.Equal(t, contentType, mustGet(t, events[1], "o365audit.subscription.content_type"))
+	assert.Equal(t, "1234", mustGet(t, events[1], "o365audit.subscription.tenant_id"))
+}
+
+func TestSubscriptionDisabled(t *testing.T) {
+	env := testConfig()
+	env.TenantID = "1234"
+	env.ContentType = contentType
+	env.Health = &subscriptionHealth{}
+	var events []beat.Event
+	env.Callback = func(event beat.Event, _ interface{}) error {
+		events = append(events, event)
+		return nil
+	}
+	var f fakePoll
+	disabled := func() *http.Response {
+		return &http.Response{
+			StatusCode: 400,
+			Body:       io.NopCloser(bytes.NewReader([]byte(`{"error":{"code":"AF20023","message":"The subscription was disabled by the tenant admin."}}`))),
+		}
+	}
+	lb := makeListBlob(checkpoint{}, env)
+	urls, next := f.finishQuery(t, lb, disabled())
+	assert.Len(t, urls, 1)
+	assert.IsType(t, listBlob{}, next)
+	require.Len(t, events, 1)
+	assert.Equal(t, "unhealthy", mustGet(t, events[0], "o365audit.subscription.status"))
+	assert.Equal(t, "subscription disabled: The subscription was disabled by the tenant admin.", mustGet(t, events[0], "o365audit.subscription.reason"))
+
+	// Reported only once.
+	_, _ = f.finishQuery(t, lb, disabled())
+	assert.Len(t, events, 1)
+}
+
+func mustGet(t testing.TB, event beat.Event, key string) interface{} {
+	v, err := event.GetValue(key)
+	require.NoError(t, err)
+	return v
+}
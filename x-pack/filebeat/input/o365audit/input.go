@@ -49,6 +49,7 @@ type apiEnvironment struct {
 	Callback    func(event beat.Event, cursor interface{}) error
 	Logger      *logp.Logger
 	Clock       func() time.Time
+	Health      *subscriptionHealth
 }
 
 func Plugin(log *logp.Logger, store cursor.StateStore) v2.Plugin {
@@ -112,8 +113,9 @@ func (inp *o365input) Run(
 	cursor cursor.Cursor,
 	publisher cursor.Publisher,
 ) error {
+	health := &subscriptionHealth{}
 	for ctx.Cancelation.Err() == nil {
-		err := inp.runOnce(ctx, src, cursor, publisher)
+		err := inp.runOnce(ctx, src, cursor, publisher, health)
 		if err == nil {
 			break
 		}
@@ -139,6 +141,7 @@ func (inp *o365input) runOnce(
 	src cursor.Source,
 	cursor cursor.Cursor,
 	publisher cursor.Publisher,
+	health *subscriptionHealth,
 ) error {
 	stream := src.(*stream)
 	tenantID, contentType := stream.tenantID, stream.contentType
@@ -182,6 +185,7 @@ func (inp *o365input) runOnce(
 		Config:      inp.config.API,
 		Callback:    publisher.Publish,
 		Clock:       time.Now,
+		Health:      health,
 	})
 	if start.Line > 0 {
 		action = action.WithStartTime(start.StartTime)
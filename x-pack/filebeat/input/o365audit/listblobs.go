@@ -24,6 +24,8 @@ type listBlob struct {
 	startTime, endTime time.Time
 	delay              time.Duration
 	env                apiEnvironment
+	// throttled counts the consecutive throttled attempts of this request.
+	throttled int
 }
 
 // makeListBlob creates a new poll.Transaction that lists content starting from
@@ -100,6 +102,7 @@ func (l listBlob) OnResponse(response *http.Response) (actions []poll.Action) {
 	if response.StatusCode != 200 {
 		return l.handleError(response)
 	}
+	l.throttled = 0
 
 	if delta := getServerTimeDelta(response); l.env.Config.AdjustClockWarn && !inRange(delta, l.env.Config.AdjustClockMinDifference) {
 		l.env.Logger.Warnf("Server clock is offset by %v: Check system clock to avoid event loss.", delta)
@@ -145,6 +148,7 @@ func (l listBlob) OnResponse(response *http.Response) (actions []poll.Action) {
 	if url, found := getNextPage(response); found {
 		return append(actions, poll.Fetch(newPager(url, l)))
 	}
+	actions = append(actions, l.env.CheckHealth(len(list) > 0)...)
 	// Otherwise fetch the next time window.
 	return append(actions, poll.Fetch(l.Next()))
 }
@@ -181,6 +185,15 @@ func (l listBlob) handleError(response *http.Response) (actions []poll.Action) {
 	l.env.Logger.Warnf("Got error %s: %+v", response.Status, msg)
 	l.delay = l.env.Config.ErrorRetryInterval
 
+	if isThrottled(response, msg) {
+		l.throttled++
+		l.delay = l.env.throttleDelay(response, l.throttled)
+		l.env.Logger.Warnf("Request throttled by the server. Retrying in %v.", l.delay)
+		return []poll.Action{
+			poll.Fetch(l),
+		}
+	}
+
 	switch response.StatusCode {
 	case 401:
 		// Authentication error. Renew oauth token and repeat this op.
@@ -192,6 +205,9 @@ func (l listBlob) handleError(response *http.Response) (actions []poll.Action) {
 	case 408, 503:
 		// Known errors when the backend is down.
 		// Repeat the request without reporting an error.
+		if delay, found := retryAfter(response); found {
+			l.delay = delay
+		}
 		return []poll.Action{
 			poll.Fetch(l),
 		}
@@ -209,11 +225,14 @@ func (l listBlob) handleError(response *http.Response) (actions []poll.Action) {
 	// AF20023: The subscription was disabled by [..]
 	case "AF20022", "AF20023":
 		l.delay = 0
+		if msg.Error.Code == "AF20023" {
+			actions = l.env.SetUnhealthy("subscription disabled: " + msg.Error.Message)
+		}
 		// Subscribe and retry
-		return []poll.Action{
+		return append(actions,
 			poll.Fetch(Subscribe(l.env)),
 			poll.Fetch(l),
-		}
+		)
 	// AF20030: Start time and end time must both be specified (or both omitted) and must
 	// be less than or equal to 24 hours apart, with the start time no more than
 	// 7 days in the past.
@@ -254,9 +273,6 @@ func (l listBlob) handleError(response *http.Response) (actions []poll.Action) {
 			poll.Fetch(l.adjustTimes(l.startTime)),
 		}
 
-	// Too many requests.
-	case "AF429":
-
 	// Internal server error. Retry the request.
 	case "AF50000":
 
@@ -0,0 +1,63 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package o365audit
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// minThrottleBackoff is the first delay used to retry a throttled request
+// when the server doesn't provide a Retry-After hint.
+const minThrottleBackoff = time.Minute
+
+// isThrottled returns whether a response signals that the tenant exceeded the
+// API request limits.
+func isThrottled(response *http.Response, msg apiError) bool {
+	return response.StatusCode == http.StatusTooManyRequests || msg.Error.Code == "AF429"
+}
+
+// retryAfter parses the Retry-After header of a response, which can be either
+// a number of seconds or an HTTP date.
+func retryAfter(response *http.Response) (delay time.Duration, found bool) {
+	value := strings.TrimSpace(response.Header.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	date, err := httpDateFormats.Parse(value)
+	if err != nil {
+		return 0, false
+	}
+	if delay = time.Until(date); delay < 0 {
+		delay = 0
+	}
+	return delay, true
+}
+
+// throttleDelay returns the delay before retrying a throttled request. The
+// server's Retry-After hint is honored when present, otherwise the delay
+// doubles with each consecutive throttled attempt, up to the configured
+// error retry interval.
+func (env apiEnvironment) throttleDelay(response *http.Response, attempt int) time.Duration {
+	if delay, found := retryAfter(response); found {
+		return delay
+	}
+	delay := minThrottleBackoff
+	for i := 1; i < attempt && delay < env.Config.ErrorRetryInterval; i++ {
+		delay *= 2
+	}
+	if delay > env.Config.ErrorRetryInterval {
+		delay = env.Config.ErrorRetryInterval
+	}
+	return delay
+}
@@ -0,0 +1,105 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package o365audit
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+)
+
+func throttledResponse(retryAfter string) *http.Response {
+	resp := &http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{},
+		Body:       io.NopCloser(bytes.NewReader([]byte(`{"error":{"code":"AF429","message":"Too many requests. Method=GetBlob, PublisherId=00000000-0000-0000-0000-000000000000"}}`))),
+	}
+	if retryAfter != "" {
+		resp.Header.Set("Retry-After", retryAfter)
+	}
+	return resp
+}
+
+func TestRetryAfter(t *testing.T) {
+	for _, tc := range []struct {
+		header string
+		found  bool
+		min    time.Duration
+		max    time.Duration
+	}{
+		{header: "", found: false},
+		{header: "120", found: true, min: 2 * time.Minute, max: 2 * time.Minute},
+		{header: "-1", found: false},
+		{header: "soon", found: false},
+		{header: time.Now().Add(10 * time.Minute).UTC().Format(http.TimeFormat), found: true, min: 9 * time.Minute, max: 10 * time.Minute},
+		{header: time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), found: true, min: 0, max: 0},
+	} {
+		t.Run(tc.header, func(t *testing.T) {
+			delay, found := retryAfter(throttledResponse(tc.header))
+			assert.Equal(t, tc.found, found)
+			assert.GreaterOrEqual(t, delay, tc.min)
+			assert.LessOrEqual(t, delay, tc.max)
+		})
+	}
+}
+
+func TestThrottledListBlob(t *testing.T) {
+	env := testConfig()
+	env.TenantID = "1234"
+	env.ContentType = contentType
+	var events []beat.Event
+	env.Callback = func(event beat.Event, _ interface{}) error {
+		events = append(events, event)
+		return nil
+	}
+	lb := makeListBlob(checkpoint{}, env)
+	var f fakePoll
+
+	// Without a hint, the delay doubles up to error_retry_interval.
+	var next listBlob
+	for _, expected := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute} {
+		urls, tr := f.finishQuery(t, lb, throttledResponse(""))
+		require.IsType(t, listBlob{}, tr)
+		assert.Empty(t, urls)
+		next = tr.(listBlob)
+		assert.Equal(t, expected, next.Delay())
+		assert.Equal(t, lb.startTime, next.startTime)
+		lb = next
+	}
+
+	// The server's hint takes precedence.
+	_, tr := f.finishQuery(t, lb, throttledResponse("30"))
+	assert.Equal(t, 30*time.Second, tr.Delay())
+
+	// Throttling isn't reported as an error.
+	assert.Empty(t, events)
+
+	// A successful response resets the backoff.
+	_, tr = f.SearchQuery(t, tr.(listBlob), nil)
+	assert.Zero(t, tr.(listBlob).throttled)
+}
+
+func TestThrottledContentBlob(t *testing.T) {
+	env := testConfig()
+	var f fakePoll
+	b := ContentBlob("https://test.localhost/", checkpoint{}, env)
+
+	_, tr := f.finishQuery(t, b, throttledResponse(""))
+	require.IsType(t, withDelay{}, tr)
+	assert.Equal(t, time.Minute, tr.Delay())
+
+	_, tr = f.finishQuery(t, tr, throttledResponse(""))
+	assert.Equal(t, 2*time.Minute, tr.Delay())
+
+	_, tr = f.finishQuery(t, tr, throttledResponse("15"))
+	assert.Equal(t, 15*time.Second, tr.Delay())
+}
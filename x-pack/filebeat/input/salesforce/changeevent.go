@@ -0,0 +1,107 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package salesforce
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/elastic/beats/v7/libbeat/common/encoding/avro"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// changeEventBitmaps are the fields of the header of Change Data Capture
+// events that list the fields of the event as bitmaps.
+var changeEventBitmaps = []string{"changedFields", "nulledFields", "diffFields"}
+
+// decodeChangeEventHeader replaces the bitmaps of the ChangeEventHeader of a
+// Change Data Capture event with the names of the fields they refer to.
+// Entries that are not valid bitmaps are kept as they are.
+func decodeChangeEventHeader(schema *avro.Schema, payload interface{}) {
+	event, ok := payload.(mapstr.M)
+	if !ok {
+		return
+	}
+	header, ok := event["ChangeEventHeader"].(mapstr.M)
+	if !ok {
+		return
+	}
+	for _, name := range changeEventBitmaps {
+		bitmaps, ok := header[name].([]interface{})
+		if !ok {
+			continue
+		}
+		fields := make([]interface{}, 0, len(bitmaps))
+		for _, b := range bitmaps {
+			bitmap, _ := b.(string)
+			names, err := bitmapFields(schema, bitmap)
+			if err != nil {
+				fields = append(fields, b)
+				continue
+			}
+			for _, n := range names {
+				fields = append(fields, n)
+			}
+		}
+		header[name] = fields
+	}
+}
+
+// bitmapFields returns the names of the fields set in a bitmap of a change
+// event header. Bit i of a bitmap like "0x6" is set if the i-th field of the
+// event schema is listed. The bitmaps of the fields of a compound field, like
+// a name or an address, are prefixed with the position of the compound field
+// in the event schema, like "3-0x6", and their fields are named
+// <compound field>.<field>.
+func bitmapFields(schema *avro.Schema, bitmap string) ([]string, error) {
+	record, prefix := schema, ""
+	if pos, rest, ok := strings.Cut(bitmap, "-"); ok {
+		i, err := strconv.Atoi(pos)
+		if err != nil || i < 0 || i >= len(schema.Fields) {
+			return nil, fmt.Errorf("invalid field position in bitmap %q", bitmap)
+		}
+		field := schema.Fields[i]
+		if record = recordSchema(field.Type); record == nil {
+			return nil, fmt.Errorf("field %s of bitmap %q is not a compound field", field.Name, bitmap)
+		}
+		prefix = field.Name + "."
+		bitmap = rest
+	}
+
+	hex, ok := strings.CutPrefix(bitmap, "0x")
+	if !ok {
+		return nil, fmt.Errorf("invalid bitmap %q", bitmap)
+	}
+	bits, ok := new(big.Int).SetString(hex, 16)
+	if !ok {
+		return nil, fmt.Errorf("invalid bitmap %q", bitmap)
+	}
+	if bits.BitLen() > len(record.Fields) {
+		return nil, fmt.Errorf("bitmap %q refers to unknown fields", bitmap)
+	}
+	var names []string
+	for i := 0; i < bits.BitLen(); i++ {
+		if bits.Bit(i) == 1 {
+			names = append(names, prefix+record.Fields[i].Name)
+		}
+	}
+	return names, nil
+}
+
+// recordSchema returns the record of a field schema, compound fields are
+// optional records.
+func recordSchema(s *avro.Schema) *avro.Schema {
+	if s.Type == "record" {
+		return s
+	}
+	for _, b := range s.Branches {
+		if b.Type == "record" {
+			return b
+		}
+	}
+	return nil
+}
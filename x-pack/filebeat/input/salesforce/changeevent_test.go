@@ -0,0 +1,75 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package salesforce
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/common/encoding/avro"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+const testAccountChangeEventSchema = `{
+	"type": "record", "name": "AccountChangeEvent", "namespace": "com.sforce.eventbus",
+	"fields": [
+		{"name": "ChangeEventHeader", "type": {"type": "record", "name": "ChangeEventHeader", "fields": [
+			{"name": "changedFields", "type": {"type": "array", "items": "string"}},
+			{"name": "nulledFields", "type": {"type": "array", "items": "string"}},
+			{"name": "diffFields", "type": {"type": "array", "items": "string"}}
+		]}},
+		{"name": "Name", "type": ["null", "string"]},
+		{"name": "Phone", "type": ["null", "string"]},
+		{"name": "BillingAddress", "type": ["null", {"type": "record", "name": "Address", "fields": [
+			{"name": "Street", "type": ["null", "string"]},
+			{"name": "City", "type": ["null", "string"]},
+			{"name": "PostalCode", "type": ["null", "string"]}
+		]}]},
+		{"name": "Description", "type": ["null", "string"]}
+	]
+}`
+
+func TestDecodeChangeEventHeader(t *testing.T) {
+	schema, err := avro.ParseSchema(testAccountChangeEventSchema)
+	require.NoError(t, err)
+
+	header := mapstr.M{
+		"changedFields": []interface{}{"0x6", "3-0x5"},
+		"nulledFields":  []interface{}{"0x10"},
+		"diffFields":    []interface{}{"0x100", "7-0x1", "invalid"},
+	}
+	decodeChangeEventHeader(schema, mapstr.M{"ChangeEventHeader": header})
+
+	assert.Equal(t, mapstr.M{
+		"changedFields": []interface{}{"Name", "Phone", "BillingAddress.Street", "BillingAddress.PostalCode"},
+		"nulledFields":  []interface{}{"Description"},
+		// Bitmaps referring to unknown fields are kept.
+		"diffFields": []interface{}{"0x100", "7-0x1", "invalid"},
+	}, header)
+}
+
+func TestBitmapFields(t *testing.T) {
+	schema, err := avro.ParseSchema(testAccountChangeEventSchema)
+	require.NoError(t, err)
+
+	for bitmap, want := range map[string][]string{
+		"0x0":      nil,
+		"0x2":      {"Name"},
+		"0x1E":     {"Name", "Phone", "BillingAddress", "Description"},
+		"3-0x2":    {"BillingAddress.City"},
+		"3-0x0007": {"BillingAddress.Street", "BillingAddress.City", "BillingAddress.PostalCode"},
+	} {
+		got, err := bitmapFields(schema, bitmap)
+		require.NoError(t, err, bitmap)
+		assert.Equal(t, want, got, bitmap)
+	}
+
+	for _, bitmap := range []string{"", "6", "0xZZ", "0x20", "1-0x1", "9-0x1", "x-0x1", "3-0x8"} {
+		_, err := bitmapFields(schema, bitmap)
+		assert.Error(t, err, bitmap)
+	}
+}
@@ -7,8 +7,10 @@ package salesforce
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/salesforce/internal/eventbus"
 	"github.com/elastic/elastic-agent-libs/transport/httpcommon"
 )
 
@@ -67,6 +69,7 @@ func (c retryConfig) getWaitMax() time.Duration {
 type eventMonitoringMethod struct {
 	EventLogFile EventMonitoringConfig `config:"event_log_file"`
 	Object       EventMonitoringConfig `config:"object"`
+	PubSub       PubSubConfig          `config:"pubsub"`
 }
 
 type EventMonitoringConfig struct {
@@ -80,6 +83,46 @@ func (e *EventMonitoringConfig) isEnabled() bool {
 	return e != nil && (e.Enabled != nil && *e.Enabled)
 }
 
+// PubSubConfig configures the collection of Platform Events and Change
+// Data Capture events from the Salesforce Pub/Sub API.
+type PubSubConfig struct {
+	Enabled      *bool    `config:"enabled"`
+	URL          string   `config:"url"`
+	Topics       []string `config:"topics"`
+	BatchSize    int      `config:"batch_size"`
+	ReplayPreset string   `config:"replay_preset"`
+}
+
+const (
+	defaultPubSubURL       = "api.pubsub.salesforce.com:7443"
+	defaultPubSubBatchSize = 100
+)
+
+func (p *PubSubConfig) isEnabled() bool {
+	return p != nil && (p.Enabled != nil && *p.Enabled)
+}
+
+func (p *PubSubConfig) getURL() string {
+	if p.URL == "" {
+		return defaultPubSubURL
+	}
+	return p.URL
+}
+
+func (p *PubSubConfig) getBatchSize() int32 {
+	if p.BatchSize == 0 {
+		return defaultPubSubBatchSize
+	}
+	return int32(p.BatchSize)
+}
+
+func (p *PubSubConfig) getReplayPreset() eventbus.ReplayPreset {
+	if p.ReplayPreset == "earliest" {
+		return eventbus.ReplayPreset_EARLIEST
+	}
+	return eventbus.ReplayPreset_LATEST
+}
+
 type cursorConfig struct {
 	Field string `config:"field"`
 }
@@ -93,12 +136,18 @@ func (c *config) Validate() error {
 		return errors.New("only one auth provider must be enabled")
 	case c.URL == "":
 		return errors.New("no instance url is configured")
-	case !c.EventMonitoringMethod.Object.isEnabled() && !c.EventMonitoringMethod.EventLogFile.isEnabled():
-		return errors.New(`at least one of "event_monitoring_method.event_log_file.enabled" or "event_monitoring_method.object.enabled" must be set to true`)
+	case !c.EventMonitoringMethod.Object.isEnabled() && !c.EventMonitoringMethod.EventLogFile.isEnabled() && !c.EventMonitoringMethod.PubSub.isEnabled():
+		return errors.New(`at least one of "event_monitoring_method.event_log_file.enabled", "event_monitoring_method.object.enabled" or "event_monitoring_method.pubsub.enabled" must be set to true`)
 	case c.EventMonitoringMethod.EventLogFile.isEnabled() && c.EventMonitoringMethod.EventLogFile.Interval == 0:
 		return fmt.Errorf("not a valid interval %d", c.EventMonitoringMethod.EventLogFile.Interval)
 	case c.EventMonitoringMethod.Object.isEnabled() && c.EventMonitoringMethod.Object.Interval == 0:
 		return fmt.Errorf("not a valid interval %d", c.EventMonitoringMethod.Object.Interval)
+	case c.EventMonitoringMethod.PubSub.isEnabled() && len(c.EventMonitoringMethod.PubSub.Topics) == 0:
+		return errors.New("no pubsub topics are configured")
+	case c.EventMonitoringMethod.PubSub.isEnabled() && (c.EventMonitoringMethod.PubSub.BatchSize < 0 || c.EventMonitoringMethod.PubSub.BatchSize > defaultPubSubBatchSize):
+		return fmt.Errorf("not a valid pubsub batch_size %d", c.EventMonitoringMethod.PubSub.BatchSize)
+	case c.EventMonitoringMethod.PubSub.isEnabled() && !slices.Contains([]string{"", "latest", "earliest"}, c.EventMonitoringMethod.PubSub.ReplayPreset):
+		return fmt.Errorf("not a valid pubsub replay_preset %q", c.EventMonitoringMethod.PubSub.ReplayPreset)
 
 	case c.Version < 46:
 		// - EventLogFile object is available in API version 32.0 or later
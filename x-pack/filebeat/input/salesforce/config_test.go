@@ -61,7 +61,7 @@ func TestValidate(t *testing.T) {
 					},
 				},
 			},
-			wantErr: errors.New(`at least one of "event_monitoring_method.event_log_file.enabled" or "event_monitoring_method.object.enabled" must be set to true`),
+			wantErr: errors.New(`at least one of "event_monitoring_method.event_log_file.enabled", "event_monitoring_method.object.enabled" or "event_monitoring_method.pubsub.enabled" must be set to true`),
 		},
 		"invalid elf interval (1h)": {
 			inputCfg: config{
@@ -97,6 +97,56 @@ func TestValidate(t *testing.T) {
 			},
 			wantErr: fmt.Errorf("not a valid interval %d", time.Duration(0)),
 		},
+		"no pubsub topics": {
+			inputCfg: config{
+				EventMonitoringMethod: &eventMonitoringMethod{
+					PubSub: PubSubConfig{Enabled: pointer(true)},
+				},
+				URL: "https://some-dummy-subdomain.salesforce.com/services/oauth2/token",
+				Auth: &authConfig{
+					OAuth2: &OAuth2{
+						UserPasswordFlow: &UserPasswordFlow{Enabled: pointer(true)},
+					},
+				},
+			},
+			wantErr: errors.New("no pubsub topics are configured"),
+		},
+		"invalid pubsub batch size (101)": {
+			inputCfg: config{
+				EventMonitoringMethod: &eventMonitoringMethod{
+					PubSub: PubSubConfig{
+						Enabled:   pointer(true),
+						Topics:    []string{"/event/LoginEventStream"},
+						BatchSize: 101,
+					},
+				},
+				URL: "https://some-dummy-subdomain.salesforce.com/services/oauth2/token",
+				Auth: &authConfig{
+					OAuth2: &OAuth2{
+						UserPasswordFlow: &UserPasswordFlow{Enabled: pointer(true)},
+					},
+				},
+			},
+			wantErr: fmt.Errorf("not a valid pubsub batch_size %d", 101),
+		},
+		"invalid pubsub replay preset (custom)": {
+			inputCfg: config{
+				EventMonitoringMethod: &eventMonitoringMethod{
+					PubSub: PubSubConfig{
+						Enabled:      pointer(true),
+						Topics:       []string{"/event/LoginEventStream"},
+						ReplayPreset: "custom",
+					},
+				},
+				URL: "https://some-dummy-subdomain.salesforce.com/services/oauth2/token",
+				Auth: &authConfig{
+					OAuth2: &OAuth2{
+						UserPasswordFlow: &UserPasswordFlow{Enabled: pointer(true)},
+					},
+				},
+			},
+			wantErr: fmt.Errorf("not a valid pubsub replay_preset %q", "custom"),
+		},
 		"invalid api version (v45)": {
 			inputCfg: config{
 				Version: 45,
@@ -117,9 +117,24 @@ func (s *salesforceInput) Setup(env v2.Context, src inputcursor.Source, cursor *
 
 // run is the main loop of the input. It will run until the context is cancelled
 // and based on the configuration, it will run the different methods -- EventLogFile
-// or Object to collect events at defined intervals.
+// or Object to collect events at defined intervals, and PubSub to collect events
+// as they are delivered.
 func (s *salesforceInput) run() error {
 	s.log.Info("Starting Salesforce input run")
+	var pubSubEvents chan pubSubEvent
+	if s.srcConfig.EventMonitoringMethod.PubSub.isEnabled() {
+		conn, err := s.dialPubSub()
+		if err != nil {
+			return fmt.Errorf("error setting up connection to Salesforce Pub/Sub API: %w", err)
+		}
+		defer conn.Close()
+
+		pubSubEvents = make(chan pubSubEvent)
+		wg := s.RunPubSub(conn, pubSubEvents)
+		defer wg.Wait()
+		defer s.cancel(nil)
+	}
+
 	if s.srcConfig.EventMonitoringMethod.EventLogFile.isEnabled() {
 		err := s.RunEventLogFile()
 		if err != nil {
@@ -174,6 +189,10 @@ func (s *salesforceInput) run() error {
 			} else {
 				s.log.Info("Object collection completed successfully")
 			}
+		case e := <-pubSubEvents:
+			if err := s.publishPubSubEvent(e); err != nil {
+				s.log.Errorf("Problem publishing Pub/Sub event: %s", err)
+			}
 		}
 	}
 }
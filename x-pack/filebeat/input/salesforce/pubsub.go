@@ -0,0 +1,289 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package salesforce
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/elastic/go-sfdc/session"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/encoding/avro"
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/salesforce/internal/eventbus"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+)
+
+// pubSubEvent is a decoded event received from a Pub/Sub API topic.
+type pubSubEvent struct {
+	topic    string
+	id       string
+	schemaID string
+	replayID []byte
+	payload  interface{}
+}
+
+// pubSubSubscriber subscribes to Pub/Sub API topics over a gRPC connection.
+type pubSubSubscriber struct {
+	client    eventbus.PubSubClient
+	batchSize int32
+	log       *logp.Logger
+
+	mu      sync.Mutex
+	schemas map[string]*avro.Schema
+}
+
+func newPubSubSubscriber(conn grpc.ClientConnInterface, batchSize int32, log *logp.Logger) *pubSubSubscriber {
+	return &pubSubSubscriber{
+		client:    eventbus.NewPubSubClient(conn),
+		batchSize: batchSize,
+		log:       log,
+		schemas:   make(map[string]*avro.Schema),
+	}
+}
+
+// subscribe consumes events from topic until ctx is cancelled or the stream
+// fails, sending them to out. The subscription starts after replayID if it is
+// set, otherwise at preset. replayID is updated with each event sent so that
+// the caller can resume the subscription. subscribed reports whether the
+// subscription was accepted by the server.
+func (p *pubSubSubscriber) subscribe(ctx context.Context, topic string, preset eventbus.ReplayPreset, replayID *[]byte, out chan<- pubSubEvent) (subscribed bool, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := p.client.Subscribe(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to subscribe to %s: %w", topic, err)
+	}
+
+	req := &eventbus.FetchRequest{TopicName: topic, ReplayPreset: preset, NumRequested: p.batchSize}
+	if len(*replayID) != 0 {
+		req.ReplayPreset = eventbus.ReplayPreset_CUSTOM
+		req.ReplayId = *replayID
+	}
+	if err := stream.Send(req); err != nil {
+		return false, fmt.Errorf("failed to request events from %s: %w", topic, err)
+	}
+
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			return subscribed, fmt.Errorf("failed to receive events from %s: %w", topic, err)
+		}
+		// The server answers the first request with events or with a
+		// keepalive once the subscription is established.
+		subscribed = true
+
+		for _, e := range resp.Events {
+			event := e.GetEvent()
+			payload, err := p.decode(ctx, event)
+			if err != nil {
+				return subscribed, fmt.Errorf("failed to decode event %s from %s: %w", event.GetId(), topic, err)
+			}
+			select {
+			case <-ctx.Done():
+				return subscribed, ctx.Err()
+			case out <- pubSubEvent{topic: topic, id: event.GetId(), schemaID: event.GetSchemaId(), replayID: e.ReplayId, payload: payload}:
+				*replayID = e.ReplayId
+			}
+		}
+
+		// The server only sends as many events as requested, so request
+		// a new batch once all requested events have been delivered.
+		if resp.PendingNumRequested == 0 {
+			err := stream.Send(&eventbus.FetchRequest{TopicName: topic, NumRequested: p.batchSize})
+			if err != nil {
+				return subscribed, fmt.Errorf("failed to request events from %s: %w", topic, err)
+			}
+		}
+	}
+}
+
+// decode decodes the Avro payload of e using its schema.
+func (p *pubSubSubscriber) decode(ctx context.Context, e *eventbus.ProducerEvent) (interface{}, error) {
+	schema, err := p.schema(ctx, e.GetSchemaId())
+	if err != nil {
+		return nil, err
+	}
+	v, _, err := avro.Decode(schema, e.GetPayload())
+	if err != nil {
+		return nil, err
+	}
+	decodeChangeEventHeader(schema, v)
+	return v, nil
+}
+
+// schema returns the schema with the given ID, fetching it from the Pub/Sub
+// API if it has not been seen before.
+func (p *pubSubSubscriber) schema(ctx context.Context, id string) (*avro.Schema, error) {
+	p.mu.Lock()
+	s, ok := p.schemas[id]
+	p.mu.Unlock()
+	if ok {
+		return s, nil
+	}
+
+	info, err := p.client.GetSchema(ctx, &eventbus.SchemaRequest{SchemaId: id})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schema %s: %w", id, err)
+	}
+	s, err = avro.ParseSchema(info.GetSchemaJson())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema %s: %w", id, err)
+	}
+
+	p.mu.Lock()
+	p.schemas[id] = s
+	p.mu.Unlock()
+	return s, nil
+}
+
+// pubSubAuth opens a new Salesforce session and returns the gRPC metadata
+// used to authenticate Pub/Sub API calls: the access token, the instance URL
+// and the organization ID.
+func (s *salesforceInput) pubSubAuth(ctx context.Context) (metadata.MD, error) {
+	sess, err := session.Open(*s.sfdcConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open salesforce connection: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sess.InstanceURL()+"/services/oauth2/userinfo", nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request for user info: %w", err)
+	}
+	sess.AuthorizationHeader(req)
+
+	resp, err := s.sfdcConfig.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching user info: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d for user info", resp.StatusCode)
+	}
+	var info struct {
+		OrganizationID string `json:"organization_id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("error decoding user info: %w", err)
+	}
+
+	_, token, _ := strings.Cut(req.Header.Get("Authorization"), " ")
+	return metadata.Pairs(
+		"accesstoken", token,
+		"instanceurl", sess.InstanceURL(),
+		"tenantid", info.OrganizationID,
+	), nil
+}
+
+// dialPubSub connects to the Pub/Sub API endpoint.
+func (s *salesforceInput) dialPubSub() (*grpc.ClientConn, error) {
+	tlsConfig, err := tlscommon.LoadTLSConfig(s.srcConfig.Resource.Transport.TLS)
+	if err != nil {
+		return nil, err
+	}
+	addr := s.srcConfig.EventMonitoringMethod.PubSub.getURL()
+	host, _, _ := strings.Cut(addr, ":")
+	return grpc.NewClient(addr, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig.BuildModuleClientConfig(host))))
+}
+
+// RunPubSub subscribes to the configured Pub/Sub API topics and sends the
+// received events to out until the input's context is cancelled. Failed
+// subscriptions are resumed from the last received event.
+func (s *salesforceInput) RunPubSub(conn grpc.ClientConnInterface, out chan<- pubSubEvent) *sync.WaitGroup {
+	cfg := s.srcConfig.EventMonitoringMethod.PubSub
+	sub := newPubSubSubscriber(conn, cfg.getBatchSize(), s.log)
+
+	var wg sync.WaitGroup
+	for _, topic := range cfg.Topics {
+		var replayID []byte
+		if id, ok := s.cursor.PubSub[topic]; ok {
+			var err error
+			replayID, err = base64.StdEncoding.DecodeString(id)
+			if err != nil {
+				s.log.Warnf("Ignoring invalid replay ID for topic %s: %s", topic, err)
+			}
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			waitMin := max(s.srcConfig.Resource.Retry.getWaitMin(), time.Second)
+			wait := waitMin
+			for {
+				s.log.Infof("Subscribing to Pub/Sub topic %s", topic)
+				md, err := s.pubSubAuth(s.ctx)
+				if err == nil {
+					var subscribed bool
+					ctx := metadata.NewOutgoingContext(s.ctx, md)
+					subscribed, err = sub.subscribe(ctx, topic, cfg.getReplayPreset(), &replayID, out)
+					if subscribed {
+						// Only back off further on consecutive failed
+						// subscriptions.
+						wait = waitMin
+					}
+				}
+				if s.ctx.Err() != nil {
+					return
+				}
+				s.log.Errorf("Problem running Pub/Sub collection for topic %s: %s", topic, err)
+
+				select {
+				case <-s.ctx.Done():
+					return
+				case <-time.After(wait):
+				}
+				wait = min(2*wait, max(s.srcConfig.Resource.Retry.getWaitMax(), wait))
+			}
+		}()
+	}
+	return &wg
+}
+
+// publishPubSubEvent publishes an event received from the Pub/Sub API and
+// checkpoints its replay ID.
+func (s *salesforceInput) publishPubSubEvent(e pubSubEvent) error {
+	jsonStrEvent, err := json.Marshal(e.payload)
+	if err != nil {
+		return fmt.Errorf("error json marshaling event: %w", err)
+	}
+
+	replayID := base64.StdEncoding.EncodeToString(e.replayID)
+	if s.cursor.PubSub == nil {
+		s.cursor.PubSub = make(map[string]string)
+	}
+	s.cursor.PubSub[e.topic] = replayID
+
+	event := beat.Event{
+		Timestamp: timeNow(),
+		Fields: mapstr.M{
+			"message": string(jsonStrEvent),
+			"event": mapstr.M{
+				"provider": "PubSub",
+				"id":       e.id,
+			},
+			"salesforce": mapstr.M{
+				"pubsub": mapstr.M{
+					"topic":     e.topic,
+					"replay_id": replayID,
+					"schema_id": e.schemaID,
+				},
+			},
+		},
+	}
+	return s.publisher.Publish(event, s.cursor)
+}
@@ -0,0 +1,283 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package salesforce
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/elastic/beats/v7/libbeat/common/encoding/avro"
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/salesforce/internal/eventbus"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+const testLoginEventSchema = `{
+	"type": "record", "name": "LoginEventStream", "namespace": "com.sforce.eventbus",
+	"fields": [
+		{"name": "EventDate", "type": "long"},
+		{"name": "Username", "type": ["null", "string"], "default": null},
+		{"name": "Score", "type": "double"},
+		{"name": "Tags", "type": {"type": "array", "items": "string"}},
+		{"name": "ChangeEventHeader", "type": {"type": "record", "name": "Header", "fields": [
+			{"name": "changeType", "type": {"type": "enum", "name": "ChangeType", "symbols": ["CREATE", "UPDATE"]}}
+		]}},
+		{"name": "Previous", "type": ["null", "com.sforce.eventbus.Header"]}
+	]
+}`
+
+// encodeLoginEvent returns the Avro binary encoding of a testLoginEventSchema
+// record.
+func encodeLoginEvent(date int64, username string) []byte {
+	str := func(b []byte, s string) []byte {
+		b = binary.AppendVarint(b, int64(len(s)))
+		return append(b, s...)
+	}
+
+	var b []byte
+	b = binary.AppendVarint(b, date)
+	b = binary.AppendVarint(b, 1) // union branch: string
+	b = str(b, username)
+	b = binary.LittleEndian.AppendUint64(b, math.Float64bits(0.5))
+	b = binary.AppendVarint(b, 2) // array block of two items
+	b = str(b, "a")
+	b = str(b, "b")
+	b = binary.AppendVarint(b, 0) // end of array
+	b = binary.AppendVarint(b, 1) // enum: UPDATE
+	b = binary.AppendVarint(b, 0) // union branch: null
+	return b
+}
+
+func TestPubSubDecode(t *testing.T) {
+	schema, err := avro.ParseSchema(testLoginEventSchema)
+	require.NoError(t, err)
+	sub := newPubSubSubscriber(nil, 1, logp.L())
+	sub.schemas["login"] = schema
+
+	got, err := sub.decode(context.Background(), &eventbus.ProducerEvent{SchemaId: "login", Payload: encodeLoginEvent(1700000000000, "user@example.com")})
+	require.NoError(t, err)
+
+	want := mapstr.M{
+		"EventDate":         int64(1700000000000),
+		"Username":          "user@example.com",
+		"Score":             0.5,
+		"Tags":              []interface{}{"a", "b"},
+		"ChangeEventHeader": mapstr.M{"changeType": "UPDATE"},
+		"Previous":          nil,
+	}
+	assert.Empty(t, cmp.Diff(want, got))
+
+	_, err = sub.decode(context.Background(), &eventbus.ProducerEvent{SchemaId: "login", Payload: encodeLoginEvent(1, "x")[:4]})
+	assert.Error(t, err)
+}
+
+// fakePubSub is an in-process Pub/Sub API server serving events from a
+// single topic.
+type fakePubSub struct {
+	eventbus.UnimplementedPubSubServer
+
+	events []*eventbus.ConsumerEvent
+	// refuse is returned to subscriptions if it is set.
+	refuse error
+
+	mu       sync.Mutex
+	requests []*eventbus.FetchRequest
+	tokens   []string
+}
+
+func (f *fakePubSub) serve(t *testing.T) *grpc.ClientConn {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	eventbus.RegisterPubSubServer(srv, f)
+	go srv.Serve(lis) //nolint:errcheck // Serve returns when the server is stopped.
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func (f *fakePubSub) GetSchema(_ context.Context, req *eventbus.SchemaRequest) (*eventbus.SchemaInfo, error) {
+	if req.SchemaId != "login" {
+		return nil, status.Error(codes.NotFound, "unknown schema")
+	}
+	return &eventbus.SchemaInfo{SchemaId: req.SchemaId, SchemaJson: testLoginEventSchema}, nil
+}
+
+func (f *fakePubSub) Subscribe(stream eventbus.PubSub_SubscribeServer) error {
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	f.mu.Lock()
+	f.tokens = append(f.tokens, md.Get("accesstoken")...)
+	f.mu.Unlock()
+	if f.refuse != nil {
+		return f.refuse
+	}
+
+	events := f.events
+	for {
+		req, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		f.mu.Lock()
+		f.requests = append(f.requests, req)
+		f.mu.Unlock()
+
+		if req.ReplayPreset == eventbus.ReplayPreset_CUSTOM {
+			for i, e := range events {
+				if string(e.ReplayId) == string(req.ReplayId) {
+					events = events[i+1:]
+					break
+				}
+			}
+		}
+
+		n := min(int(req.NumRequested), len(events))
+		resp := &eventbus.FetchResponse{Events: events[:n], PendingNumRequested: req.NumRequested - int32(n)}
+		events = events[n:]
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+func TestPubSubSubscribe(t *testing.T) {
+	logp.TestingSetup()
+
+	f := &fakePubSub{}
+	for i, user := range []string{"one", "two", "three", "four"} {
+		f.events = append(f.events, &eventbus.ConsumerEvent{
+			Event:    &eventbus.ProducerEvent{Id: user, SchemaId: "login", Payload: encodeLoginEvent(int64(i), user)},
+			ReplayId: []byte{byte(i)},
+		})
+	}
+	conn := f.serve(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ctx = metadata.NewOutgoingContext(ctx, metadata.Pairs("accesstoken", "token"))
+
+	sub := newPubSubSubscriber(conn, 2, logp.L())
+	out := make(chan pubSubEvent)
+	replayID := []byte{0}
+	errc := make(chan error, 1)
+	go func() {
+		subscribed, err := sub.subscribe(ctx, "/event/LoginEventStream", eventbus.ReplayPreset_LATEST, &replayID, out)
+		assert.True(t, subscribed)
+		errc <- err
+	}()
+
+	var got []string
+	for len(got) < 3 {
+		select {
+		case e := <-out:
+			assert.Equal(t, "/event/LoginEventStream", e.topic)
+			assert.Equal(t, "login", e.schemaID)
+			got = append(got, e.payload.(mapstr.M)["Username"].(string))
+		case err := <-errc:
+			t.Fatalf("unexpected subscription end: %v", err)
+		}
+	}
+	cancel()
+	assert.Equal(t, codes.Canceled, status.Code(<-errc))
+
+	assert.Equal(t, []string{"two", "three", "four"}, got)
+	assert.Equal(t, []byte{3}, replayID)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	assert.Equal(t, []string{"token"}, f.tokens)
+	want := []*eventbus.FetchRequest{
+		{TopicName: "/event/LoginEventStream", ReplayPreset: eventbus.ReplayPreset_CUSTOM, ReplayId: []byte{0}, NumRequested: 2},
+		{TopicName: "/event/LoginEventStream", NumRequested: 2},
+	}
+	assert.Empty(t, cmp.Diff(want, f.requests, protocmp.Transform()))
+}
+
+func TestPubSubUnknownSchema(t *testing.T) {
+	logp.TestingSetup()
+
+	f := &fakePubSub{events: []*eventbus.ConsumerEvent{{
+		Event:    &eventbus.ProducerEvent{Id: "one", SchemaId: "unknown"},
+		ReplayId: []byte{1},
+	}}}
+	conn := f.serve(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var replayID []byte
+	subscribed, err := newPubSubSubscriber(conn, 10, logp.L()).subscribe(ctx, "/event/Test__e", eventbus.ReplayPreset_EARLIEST, &replayID, make(chan pubSubEvent))
+	assert.True(t, subscribed)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Nil(t, replayID)
+}
+
+func TestPubSubRefused(t *testing.T) {
+	logp.TestingSetup()
+
+	f := &fakePubSub{refuse: status.Error(codes.PermissionDenied, "no access to the topic")}
+	conn := f.serve(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var replayID []byte
+	subscribed, err := newPubSubSubscriber(conn, 10, logp.L()).subscribe(ctx, "/event/Test__e", eventbus.ReplayPreset_EARLIEST, &replayID, make(chan pubSubEvent))
+	assert.False(t, subscribed, "the subscription must not be reported as established")
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestPublishPubSubEvent(t *testing.T) {
+	mockTimeNow(time.Date(2023, time.May, 18, 12, 0, 0, 0, time.UTC))
+	t.Cleanup(resetTimeNow)
+
+	pub := &publisher{done: func() {}}
+	s := &salesforceInput{cursor: &state{}, publisher: pub}
+	e := pubSubEvent{
+		topic:    "/data/AccountChangeEvent",
+		id:       "id",
+		schemaID: "schema",
+		replayID: []byte{0, 1},
+		payload:  map[string]interface{}{"Name": "Acme"},
+	}
+	require.NoError(t, s.publishPubSubEvent(e))
+
+	assert.Equal(t, map[string]string{"/data/AccountChangeEvent": "AAE="}, s.cursor.PubSub)
+	require.Len(t, pub.published, 1)
+	msg, err := pub.published[0].GetValue("message")
+	require.NoError(t, err)
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(msg.(string)), &payload))
+	assert.Equal(t, map[string]interface{}{"Name": "Acme"}, payload)
+	replay, err := pub.published[0].GetValue("salesforce.pubsub.replay_id")
+	require.NoError(t, err)
+	assert.Equal(t, "AAE=", replay)
+}
@@ -11,10 +11,12 @@ import (
 
 // state is the state of the salesforce module. It is used to watermark the state
 // to avoid pulling duplicate data from Salesforce. The state is persisted separately
-// for EventLogFile and Object.
+// for EventLogFile and Object. For the Pub/Sub API, the replay ID of the last
+// published event is kept per topic, base64 encoded.
 type state struct {
-	Object       dateTimeCursor `json:"object,omitempty"`
-	EventLogFile dateTimeCursor `json:"event_log_file,omitempty"`
+	Object       dateTimeCursor    `json:"object,omitempty"`
+	EventLogFile dateTimeCursor    `json:"event_log_file,omitempty"`
+	PubSub       map[string]string `json:"pubsub,omitempty"`
 }
 
 // dateTimeCursor maintains two distinct states for the event collection iteration.
@@ -0,0 +1,82 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package sql
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+const (
+	trackingNumeric   = "numeric"
+	trackingTimestamp = "timestamp"
+)
+
+type config struct {
+	// Driver is the database driver, one of mysql, postgres or mssql.
+	Driver string `config:"driver" validate:"required"`
+	// DSN is the data source name passed to the driver.
+	DSN string `config:"dsn" validate:"required"`
+
+	// Table is the table to tail, optionally qualified by its schema.
+	Table string `config:"table" validate:"required"`
+	// TrackingColumn is the incrementing column used to find new rows.
+	TrackingColumn string `config:"tracking_column" validate:"required"`
+	// TrackingType is the type of the tracking column, numeric or timestamp.
+	TrackingType string `config:"tracking_type"`
+	// TrackingStart is the value of the tracking column to start after
+	// when there is no stored cursor. Rows are read from the start of the
+	// table when it is empty.
+	TrackingStart string `config:"tracking_start"`
+
+	// BatchSize is the maximum number of rows read by a single query.
+	BatchSize int `config:"batch_size" validate:"positive,nonzero"`
+	// Interval is the time waited between polls once all new rows have
+	// been read.
+	Interval time.Duration `config:"interval" validate:"positive,nonzero"`
+	// QueryTimeout limits the duration of a single query.
+	QueryTimeout time.Duration `config:"query_timeout" validate:"positive,nonzero"`
+}
+
+func defaultConfig() config {
+	return config{
+		TrackingType: trackingNumeric,
+		BatchSize:    500,
+		Interval:     time.Minute,
+		QueryTimeout: time.Minute,
+	}
+}
+
+// identifierPattern matches the table and column names accepted by the
+// input. Names are quoted in the queries, this only keeps them from
+// breaking out of the quotes.
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*$`)
+
+func (c *config) Validate() error {
+	if _, ok := dialects[c.Driver]; !ok {
+		return fmt.Errorf("unsupported driver %q", c.Driver)
+	}
+	for _, part := range splitIdentifier(c.Table) {
+		if !identifierPattern.MatchString(part) {
+			return fmt.Errorf("invalid table name %q", c.Table)
+		}
+	}
+	if !identifierPattern.MatchString(c.TrackingColumn) {
+		return fmt.Errorf("invalid tracking_column %q", c.TrackingColumn)
+	}
+	switch c.TrackingType {
+	case trackingNumeric, trackingTimestamp:
+	default:
+		return errors.New("tracking_type must be numeric or timestamp")
+	}
+	if c.TrackingStart != "" {
+		if _, err := parseCursorValue(c.TrackingType, c.TrackingStart); err != nil {
+			return fmt.Errorf("invalid tracking_start: %w", err)
+		}
+	}
+	return nil
+}
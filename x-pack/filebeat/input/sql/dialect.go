@@ -0,0 +1,75 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package sql
+
+import (
+	"strconv"
+	"strings"
+
+	// Register the database drivers.
+	_ "github.com/denisenkom/go-mssqldb"
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+)
+
+// dialect holds the differences between the supported databases.
+type dialect struct {
+	// driver is the name of the database/sql driver.
+	driver string
+	// quote quotes an identifier.
+	quote func(string) string
+	// placeholder is the parameter placeholder of the tracking value.
+	placeholder string
+	// top tells if the row limit goes in a TOP clause instead of LIMIT.
+	top bool
+}
+
+var dialects = map[string]dialect{
+	"mysql": {
+		driver:      "mysql",
+		quote:       func(s string) string { return "`" + s + "`" },
+		placeholder: "?",
+	},
+	"postgres": {
+		driver:      "postgres",
+		quote:       func(s string) string { return `"` + s + `"` },
+		placeholder: "$1",
+	},
+	"mssql": {
+		driver:      "sqlserver",
+		quote:       func(s string) string { return "[" + s + "]" },
+		placeholder: "@p1",
+		top:         true,
+	},
+}
+
+// query returns the query reading the next batch of rows. The tracking
+// value is passed as the only parameter of the query when after is true.
+func (d dialect) query(table, column string, limit int, after bool) string {
+	parts := splitIdentifier(table)
+	for i, p := range parts {
+		parts[i] = d.quote(p)
+	}
+	column = d.quote(column)
+
+	var b strings.Builder
+	b.WriteString("SELECT ")
+	if d.top {
+		b.WriteString("TOP (" + strconv.Itoa(limit) + ") ")
+	}
+	b.WriteString("* FROM " + strings.Join(parts, "."))
+	if after {
+		b.WriteString(" WHERE " + column + " > " + d.placeholder)
+	}
+	b.WriteString(" ORDER BY " + column)
+	if !d.top {
+		b.WriteString(" LIMIT " + strconv.Itoa(limit))
+	}
+	return b.String()
+}
+
+func splitIdentifier(s string) []string {
+	return strings.Split(s, ".")
+}
@@ -0,0 +1,283 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// Package sql implements an input tailing a database table. The input
+// polls the table for the rows whose tracking column, an incrementing
+// number or a timestamp, is past the last published row and stores the
+// tracking value of the last acknowledged row in its cursor.
+package sql
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	v2 "github.com/elastic/beats/v7/filebeat/input/v2"
+	inputcursor "github.com/elastic/beats/v7/filebeat/input/v2/input-cursor"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/feature"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/go-concert/ctxtool"
+	"github.com/elastic/go-concert/timed"
+)
+
+const inputName = "sql"
+
+func Plugin(log *logp.Logger, store inputcursor.StateStore) v2.Plugin {
+	return v2.Plugin{
+		Name:       inputName,
+		Stability:  feature.Experimental,
+		Deprecated: false,
+		Info:       "SQL table tailing",
+		Doc:        "Collect the new rows of a database table",
+		Manager: &inputcursor.InputManager{
+			Logger:     log,
+			StateStore: store,
+			Type:       inputName,
+			Configure:  configure,
+		},
+	}
+}
+
+func configure(cfg *conf.C) ([]inputcursor.Source, inputcursor.Input, error) {
+	config := defaultConfig()
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, nil, fmt.Errorf("reading config: %w", err)
+	}
+	return []inputcursor.Source{&source{cfg: config}}, input{}, nil
+}
+
+type source struct{ cfg config }
+
+// Name identifies the tailed table. The DSN is hashed so the credentials
+// it may contain do not end up in the registry.
+func (s *source) Name() string {
+	h := sha256.Sum256([]byte(s.cfg.DSN))
+	return strings.Join([]string{
+		s.cfg.Driver, hex.EncodeToString(h[:8]), s.cfg.Table, s.cfg.TrackingColumn,
+	}, "::")
+}
+
+type input struct{}
+
+func (input) Name() string { return inputName }
+
+func (input) Test(src inputcursor.Source, ctx v2.TestContext) error {
+	cfg := src.(*source).cfg
+	db, err := sql.Open(dialects[cfg.Driver].driver, cfg.DSN)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	pingCtx, cancel := context.WithTimeout(ctxtool.FromCanceller(ctx.Cancelation), cfg.QueryTimeout)
+	defer cancel()
+	return db.PingContext(pingCtx)
+}
+
+// state is the cursor of the input.
+type state struct {
+	// Value is the tracking value of the last published row.
+	Value string `struct:"value"`
+}
+
+func (input) Run(env v2.Context, src inputcursor.Source, crsr inputcursor.Cursor, pub inputcursor.Publisher) error {
+	cfg := src.(*source).cfg
+
+	var st state
+	if !crsr.IsNew() {
+		if err := crsr.Unpack(&st); err != nil {
+			return fmt.Errorf("failed to unpack cursor: %w", err)
+		}
+	}
+	if st.Value == "" {
+		st.Value = cfg.TrackingStart
+	}
+
+	db, err := sql.Open(dialects[cfg.Driver].driver, cfg.DSN)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	t := &tailer{
+		cfg: cfg,
+		db:  db,
+		pub: pub,
+		log: env.Logger.With("table", cfg.Table),
+		now: time.Now,
+	}
+	if st.Value != "" {
+		if t.last, err = parseCursorValue(cfg.TrackingType, st.Value); err != nil {
+			return fmt.Errorf("invalid cursor value %q: %w", st.Value, err)
+		}
+	}
+	return t.run(ctxtool.FromCanceller(env.Cancelation))
+}
+
+// tailer reads the new rows of a table.
+type tailer struct {
+	cfg config
+	db  *sql.DB
+	pub inputcursor.Publisher
+	log *logp.Logger
+	now func() time.Time
+
+	// last is the tracking value of the last published row, nil before
+	// the first row is read.
+	last any
+}
+
+func (t *tailer) run(ctx context.Context) error {
+	t.log.Infof("Tailing table %s on column %s", t.cfg.Table, t.cfg.TrackingColumn)
+	t.catchUp(ctx)
+	err := timed.Periodic(ctx, t.cfg.Interval, func() error {
+		t.catchUp(ctx)
+		return nil
+	})
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// catchUp reads batches until all the new rows have been published, a
+// table catching up should not wait an interval between batches.
+func (t *tailer) catchUp(ctx context.Context) {
+	for ctx.Err() == nil {
+		more, err := t.poll(ctx)
+		if err != nil {
+			t.log.Errorw("Failed to read table", "error", err)
+			return
+		}
+		if !more {
+			return
+		}
+	}
+}
+
+// row is a row read from the table.
+type row struct {
+	fields mapstr.M
+	value  any
+	cursor string
+}
+
+// poll publishes a batch of new rows. It returns true when the batch
+// was full and more rows may be waiting.
+func (t *tailer) poll(ctx context.Context) (bool, error) {
+	rows, err := t.query(ctx)
+	if err != nil {
+		return false, err
+	}
+	full := len(rows) == t.cfg.BatchSize
+	if full {
+		rows = t.trimLastValue(rows)
+	}
+
+	for i, r := range rows {
+		var update any
+		// Rows sharing a tracking value are only complete once the
+		// last of them is published, earlier ones do not move the
+		// cursor so a restart does not skip their peers.
+		if i == len(rows)-1 || rows[i+1].cursor != r.cursor {
+			update = state{Value: r.cursor}
+		}
+		if err := t.pub.Publish(t.event(r), update); err != nil {
+			return false, err
+		}
+		t.last = r.value
+	}
+	return full, nil
+}
+
+// trimLastValue drops the rows of a full batch that share the tracking
+// value of its last row, they are read again by the next query with the
+// rows of the same value that did not fit in the batch.
+func (t *tailer) trimLastValue(rows []row) []row {
+	last := rows[len(rows)-1].cursor
+	n := len(rows)
+	for n > 0 && rows[n-1].cursor == last {
+		n--
+	}
+	if n == 0 {
+		t.log.Warnf("All %d rows of the batch have the tracking value %s, rows with this value beyond batch_size are skipped; increase batch_size", len(rows), last)
+		return rows
+	}
+	return rows[:n]
+}
+
+func (t *tailer) query(ctx context.Context) ([]row, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.cfg.QueryTimeout)
+	defer cancel()
+
+	q := dialects[t.cfg.Driver].query(t.cfg.Table, t.cfg.TrackingColumn, t.cfg.BatchSize, t.last != nil)
+	var args []any
+	if t.last != nil {
+		args = append(args, t.last)
+	}
+	rs, err := t.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rs.Close()
+
+	types, err := rs.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get columns: %w", err)
+	}
+	tracking := -1
+	for i, ct := range types {
+		if strings.EqualFold(ct.Name(), t.cfg.TrackingColumn) {
+			tracking = i
+		}
+	}
+	if tracking < 0 {
+		return nil, fmt.Errorf("tracking column %s not found in table %s", t.cfg.TrackingColumn, t.cfg.Table)
+	}
+
+	var rows []row
+	vals := make([]any, len(types))
+	ptrs := make([]any, len(types))
+	for i := range vals {
+		ptrs[i] = &vals[i]
+	}
+	for rs.Next() {
+		if err := rs.Scan(ptrs...); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		value, cursor, err := trackingValue(t.cfg.TrackingType, vals[tracking])
+		if err != nil {
+			return nil, fmt.Errorf("invalid tracking value: %w", err)
+		}
+		fields := make(mapstr.M, len(types))
+		for i, ct := range types {
+			fields[strings.ToLower(ct.Name())] = fieldValue(ct.DatabaseTypeName(), vals[i])
+		}
+		rows = append(rows, row{fields: fields, value: value, cursor: cursor})
+	}
+	return rows, rs.Err()
+}
+
+func (t *tailer) event(r row) beat.Event {
+	ts := t.now()
+	if tv, ok := r.value.(time.Time); ok {
+		ts = tv
+	}
+	return beat.Event{
+		Timestamp: ts,
+		Fields: mapstr.M{
+			"sql": mapstr.M{
+				"driver": t.cfg.Driver,
+				"table":  t.cfg.Table,
+				"row":    r.fields,
+			},
+		},
+	}
+}
@@ -0,0 +1,321 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func init() {
+	sql.Register("sqltest", testDriver{})
+	dialects["sqltest"] = dialect{
+		driver:      "sqltest",
+		quote:       func(s string) string { return s },
+		placeholder: "?",
+	}
+}
+
+// testTables holds the tables of the test driver by DSN.
+var testTables sync.Map
+
+// testTable is a table of the test driver, its first column is the
+// tracking column.
+type testTable struct {
+	columns []string
+	types   []string
+	rows    [][]driver.Value
+}
+
+type testDriver struct{}
+
+func (testDriver) Open(dsn string) (driver.Conn, error) {
+	t, ok := testTables.Load(dsn)
+	if !ok {
+		return nil, errors.New("unknown table")
+	}
+	return &testConn{table: t.(*testTable)}, nil
+}
+
+type testConn struct{ table *testTable }
+
+func (c *testConn) Prepare(query string) (driver.Stmt, error) {
+	return &testStmt{table: c.table, query: query}, nil
+}
+func (c *testConn) Close() error              { return nil }
+func (c *testConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+var limitPattern = regexp.MustCompile(`LIMIT (\d+)$`)
+
+type testStmt struct {
+	table *testTable
+	query string
+}
+
+func (s *testStmt) Close() error  { return nil }
+func (s *testStmt) NumInput() int { return -1 }
+func (s *testStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s *testStmt) Query(args []driver.Value) (driver.Rows, error) {
+	limit, _ := strconv.Atoi(limitPattern.FindStringSubmatch(s.query)[1])
+	var rows [][]driver.Value
+	for _, r := range s.table.rows {
+		if len(args) == 0 || less(args[0], r[0]) {
+			rows = append(rows, r)
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return less(rows[i][0], rows[j][0]) })
+	if len(rows) > limit {
+		rows = rows[:limit]
+	}
+	return &testRows{table: s.table, rows: rows}, nil
+}
+
+func less(a, b driver.Value) bool {
+	switch a := a.(type) {
+	case int64:
+		return a < b.(int64)
+	case time.Time:
+		return a.Before(b.(time.Time))
+	}
+	panic("unsupported tracking value")
+}
+
+type testRows struct {
+	table *testTable
+	rows  [][]driver.Value
+}
+
+func (r *testRows) Columns() []string { return r.table.columns }
+func (r *testRows) Close() error      { return nil }
+func (r *testRows) ColumnTypeDatabaseTypeName(i int) string {
+	return r.table.types[i]
+}
+
+func (r *testRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+type publication struct {
+	event  beat.Event
+	cursor any
+}
+
+type testPublisher struct{ published []publication }
+
+func (p *testPublisher) Publish(event beat.Event, cursor any) error {
+	p.published = append(p.published, publication{event: event, cursor: cursor})
+	return nil
+}
+
+func newTestTailer(t *testing.T, table *testTable, cfg config) (*tailer, *testPublisher) {
+	t.Helper()
+	logp.TestingSetup()
+	testTables.Store(t.Name(), table)
+	db, err := sql.Open("sqltest", t.Name())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	pub := &testPublisher{}
+	cfg.Driver = "sqltest"
+	cfg.Table = "audit"
+	cfg.TrackingColumn = "id"
+	return &tailer{
+		cfg: cfg,
+		db:  db,
+		pub: pub,
+		log: logp.NewLogger(inputName),
+		now: time.Now,
+	}, pub
+}
+
+func cursors(pubs []publication) []any {
+	var c []any
+	for _, p := range pubs {
+		c = append(c, p.cursor)
+	}
+	return c
+}
+
+func TestPollNumeric(t *testing.T) {
+	table := &testTable{
+		columns: []string{"ID", "User", "Amount", "Ok"},
+		types:   []string{"BIGINT", "VARCHAR", "DECIMAL", "BOOL"},
+		rows: [][]driver.Value{
+			{int64(2), "bob", []byte("2.50"), true},
+			{int64(1), "alice", []byte("10.25"), false},
+		},
+	}
+	cfg := defaultConfig()
+	tl, pub := newTestTailer(t, table, cfg)
+
+	more, err := tl.poll(context.Background())
+	require.NoError(t, err)
+	assert.False(t, more)
+	require.Len(t, pub.published, 2)
+	assert.Equal(t, []any{state{Value: "1"}, state{Value: "2"}}, cursors(pub.published))
+
+	assert.Equal(t, mapstr.M{
+		"sql": mapstr.M{
+			"driver": "sqltest",
+			"table":  "audit",
+			"row":    mapstr.M{"id": int64(1), "user": "alice", "amount": 10.25, "ok": false},
+		},
+	}, pub.published[0].event.Fields)
+
+	// Only the rows added since the last poll are read.
+	table.rows = append(table.rows, []driver.Value{int64(3), "carol", []byte("1"), true})
+	pub.published = nil
+	_, err = tl.poll(context.Background())
+	require.NoError(t, err)
+	require.Len(t, pub.published, 1)
+	assert.Equal(t, []any{state{Value: "3"}}, cursors(pub.published))
+}
+
+func TestPollBatchTies(t *testing.T) {
+	table := &testTable{
+		columns: []string{"id", "msg"},
+		types:   []string{"INT", "TEXT"},
+		rows: [][]driver.Value{
+			{int64(1), "a"}, {int64(2), "b"}, {int64(2), "c"}, {int64(2), "d"}, {int64(3), "e"}, {int64(3), "f"},
+		},
+	}
+	cfg := defaultConfig()
+	cfg.BatchSize = 3
+	tl, pub := newTestTailer(t, table, cfg)
+
+	// The rows of the last value of a full batch are left to the next
+	// query.
+	more, err := tl.poll(context.Background())
+	require.NoError(t, err)
+	assert.True(t, more)
+	assert.Equal(t, []any{state{Value: "1"}}, cursors(pub.published))
+
+	// A full batch of a single value is published, the cursor only moves
+	// with the last row of the value.
+	pub.published = nil
+	more, err = tl.poll(context.Background())
+	require.NoError(t, err)
+	assert.True(t, more)
+	assert.Equal(t, []any{nil, nil, state{Value: "2"}}, cursors(pub.published))
+
+	pub.published = nil
+	more, err = tl.poll(context.Background())
+	require.NoError(t, err)
+	assert.False(t, more)
+	assert.Equal(t, []any{nil, state{Value: "3"}}, cursors(pub.published))
+}
+
+func TestPollTimestamp(t *testing.T) {
+	t1 := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	t2 := t1.Add(1500 * time.Millisecond)
+	table := &testTable{
+		columns: []string{"created", "msg"},
+		types:   []string{"TIMESTAMP", "TEXT"},
+		rows:    [][]driver.Value{{t1, "a"}, {t2, "b"}},
+	}
+	cfg := defaultConfig()
+	cfg.TrackingType = trackingTimestamp
+	tl, pub := newTestTailer(t, table, cfg)
+	tl.cfg.TrackingColumn = "created"
+	tl.last = t1
+
+	_, err := tl.poll(context.Background())
+	require.NoError(t, err)
+	require.Len(t, pub.published, 1)
+	assert.Equal(t, t2, pub.published[0].event.Timestamp)
+	assert.Equal(t, state{Value: "2024-03-01T10:00:01.5Z"}, pub.published[0].cursor)
+}
+
+func TestPollMissingTrackingColumn(t *testing.T) {
+	table := &testTable{columns: []string{"msg"}, types: []string{"TEXT"}}
+	tl, _ := newTestTailer(t, table, defaultConfig())
+	_, err := tl.poll(context.Background())
+	assert.ErrorContains(t, err, "tracking column id not found")
+}
+
+func TestQuery(t *testing.T) {
+	tests := map[string]struct {
+		after bool
+		want  string
+	}{
+		"mysql":    {true, "SELECT * FROM `app`.`audit` WHERE `id` > ? ORDER BY `id` LIMIT 10"},
+		"postgres": {true, `SELECT * FROM "app"."audit" WHERE "id" > $1 ORDER BY "id" LIMIT 10`},
+		"mssql":    {false, "SELECT TOP (10) * FROM [app].[audit] ORDER BY [id]"},
+	}
+	for driver, test := range tests {
+		t.Run(driver, func(t *testing.T) {
+			assert.Equal(t, test.want, dialects[driver].query("app.audit", "id", 10, test.after))
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := map[string]struct {
+		cfg     map[string]any
+		wantErr string
+	}{
+		"valid": {
+			cfg: map[string]any{"driver": "postgres", "dsn": "postgres://localhost/app", "table": "public.audit", "tracking_column": "id"},
+		},
+		"driver": {
+			cfg:     map[string]any{"driver": "sqlite", "dsn": "app.db", "table": "audit", "tracking_column": "id"},
+			wantErr: `unsupported driver "sqlite"`,
+		},
+		"table": {
+			cfg:     map[string]any{"driver": "mysql", "dsn": "/app", "table": "audit; DROP TABLE audit", "tracking_column": "id"},
+			wantErr: "invalid table name",
+		},
+		"tracking_start": {
+			cfg:     map[string]any{"driver": "mysql", "dsn": "/app", "table": "audit", "tracking_column": "ts", "tracking_type": "timestamp", "tracking_start": "yesterday"},
+			wantErr: "invalid tracking_start",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := defaultConfig()
+			err := conf.MustNewConfigFrom(test.cfg).Unpack(&cfg)
+			if test.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, test.wantErr)
+		})
+	}
+}
+
+func TestFieldValue(t *testing.T) {
+	assert.Equal(t, int64(7), fieldValue("BIGINT", []byte("7")))
+	assert.Equal(t, 1.5, fieldValue("NUMERIC", []byte("1.5")))
+	assert.Equal(t, true, fieldValue("BIT", []byte("1")))
+	assert.Equal(t, time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), fieldValue("DATETIME", []byte("2024-03-01 10:00:00")))
+	assert.Equal(t, "text", fieldValue("VARCHAR", []byte("text")))
+	assert.Equal(t, "/w==", fieldValue("BLOB", []byte{0xff}))
+	assert.Equal(t, int64(3), fieldValue("INT", int64(3)))
+}
@@ -0,0 +1,129 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package sql
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// timestampLayouts are the layouts of timestamps returned as text, as by
+// MySQL when parseTime is not enabled in the DSN.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02",
+}
+
+// parseCursorValue parses a tracking value stored in the cursor or given
+// in the configuration.
+func parseCursorValue(typ, s string) (any, error) {
+	if typ == trackingTimestamp {
+		return parseTimestamp(s)
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+func parseTimestamp(s string) (time.Time, error) {
+	for _, layout := range timestampLayouts {
+		t, err := time.Parse(layout, s)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unsupported timestamp %q", s)
+}
+
+// trackingValue returns the tracking value of a row and its cursor
+// representation.
+func trackingValue(typ string, v any) (any, string, error) {
+	switch typ {
+	case trackingTimestamp:
+		var t time.Time
+		switch v := v.(type) {
+		case time.Time:
+			t = v
+		case []byte:
+			var err error
+			if t, err = parseTimestamp(string(v)); err != nil {
+				return nil, "", err
+			}
+		case string:
+			var err error
+			if t, err = parseTimestamp(v); err != nil {
+				return nil, "", err
+			}
+		default:
+			return nil, "", fmt.Errorf("unsupported timestamp type %T", v)
+		}
+		return t, t.UTC().Format(time.RFC3339Nano), nil
+	default:
+		var n int64
+		switch v := v.(type) {
+		case int64:
+			n = v
+		case int32:
+			n = int64(v)
+		case uint64:
+			n = int64(v)
+		case []byte:
+			var err error
+			if n, err = strconv.ParseInt(string(v), 10, 64); err != nil {
+				return nil, "", err
+			}
+		case string:
+			var err error
+			if n, err = strconv.ParseInt(v, 10, 64); err != nil {
+				return nil, "", err
+			}
+		default:
+			return nil, "", fmt.Errorf("unsupported numeric type %T", v)
+		}
+		return n, strconv.FormatInt(n, 10), nil
+	}
+}
+
+// fieldValue converts a column value to the type of the event field.
+// dbType is the database type name of the column as reported by the
+// driver, it is used to type the values drivers return as text.
+func fieldValue(dbType string, v any) any {
+	b, ok := v.([]byte)
+	if !ok {
+		return v
+	}
+	s := string(b)
+	switch strings.ToUpper(dbType) {
+	case "DECIMAL", "NUMERIC", "MONEY", "SMALLMONEY":
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	case "INT", "INTEGER", "TINYINT", "SMALLINT", "MEDIUMINT", "BIGINT", "INT2", "INT4", "INT8":
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n
+		}
+	case "FLOAT", "DOUBLE", "REAL", "FLOAT4", "FLOAT8":
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	case "BOOL", "BOOLEAN", "BIT":
+		if t, err := strconv.ParseBool(s); err == nil {
+			return t
+		}
+	case "DATETIME", "TIMESTAMP", "DATE":
+		if t, err := parseTimestamp(s); err == nil {
+			return t
+		}
+	}
+	if !utf8.Valid(b) {
+		// Binary columns are kept as base64 encoded strings.
+		return base64.StdEncoding.EncodeToString(b)
+	}
+	return s
+}
@@ -0,0 +1,118 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+//go:build windows
+
+package etw
+
+import (
+	"sync"
+
+	"golang.org/x/sys/windows"
+)
+
+// RenderCache keeps the event metadata that TDH reads from the provider
+// manifests, so that events of manifest-based providers are rendered without
+// looking up the manifest for each event. Events decoded from other sources,
+// like TraceLogging events that carry their own metadata, are not cached.
+// A RenderCache is safe for concurrent use by multiple sessions.
+type RenderCache struct {
+	mu   sync.RWMutex
+	size int
+	// infos holds TRACE_EVENT_INFO buffers by event.
+	infos map[eventKey][]byte
+	// maps holds EVENT_MAP_INFO buffers by provider and map name.
+	// A nil buffer records that the map has no entries.
+	maps map[mapKey][]byte
+}
+
+type eventKey struct {
+	provider   windows.GUID
+	descriptor EventDescriptor
+}
+
+type mapKey struct {
+	provider windows.GUID
+	name     string
+}
+
+// NewRenderCache returns a RenderCache holding up to size events and
+// value maps. A nil *RenderCache is valid and caches nothing.
+func NewRenderCache(size int) *RenderCache {
+	if size <= 0 {
+		return nil
+	}
+	return &RenderCache{
+		size:  size,
+		infos: make(map[eventKey][]byte),
+		maps:  make(map[mapKey][]byte),
+	}
+}
+
+// GetEventProperties extracts and returns properties from an ETW event record
+// using the cached event metadata.
+func (c *RenderCache) GetEventProperties(r *EventRecord) (map[string]interface{}, error) {
+	return getEventProperties(r, c)
+}
+
+// Len returns the number of cached events.
+func (c *RenderCache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.infos)
+}
+
+func (c *RenderCache) getInfo(k eventKey) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	buf, ok := c.infos[k]
+	return buf, ok
+}
+
+func (c *RenderCache) putInfo(k eventKey, buf []byte) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	evict(c.infos, c.size)
+	c.infos[k] = buf
+}
+
+func (c *RenderCache) getMap(k mapKey) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	buf, ok := c.maps[k]
+	return buf, ok
+}
+
+func (c *RenderCache) putMap(k mapKey, buf []byte) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	evict(c.maps, c.size)
+	c.maps[k] = buf
+}
+
+// evict removes an arbitrary entry from m if it holds size entries or more.
+func evict[K comparable](m map[K][]byte, size int) {
+	if len(m) < size {
+		return
+	}
+	for k := range m {
+		delete(m, k)
+		return
+	}
+}
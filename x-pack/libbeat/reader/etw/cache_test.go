@@ -0,0 +1,54 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+//go:build windows
+
+package etw
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/windows"
+)
+
+func TestRenderCache(t *testing.T) {
+	c := NewRenderCache(2)
+
+	key := func(id uint16) eventKey {
+		return eventKey{provider: windows.GUID{Data1: 1}, descriptor: EventDescriptor{Id: id}}
+	}
+
+	_, ok := c.getInfo(key(1))
+	assert.False(t, ok)
+
+	c.putInfo(key(1), []byte{1})
+	c.putInfo(key(2), []byte{2})
+	buf, ok := c.getInfo(key(2))
+	assert.True(t, ok)
+	assert.Equal(t, []byte{2}, buf)
+	assert.Equal(t, 2, c.Len())
+
+	// The cache never holds more than its size.
+	c.putInfo(key(3), []byte{3})
+	assert.Equal(t, 2, c.Len())
+	_, ok = c.getInfo(key(3))
+	assert.True(t, ok)
+
+	// Maps without entries are cached as nil.
+	c.putMap(mapKey{name: "Map"}, nil)
+	buf, ok = c.getMap(mapKey{name: "Map"})
+	assert.True(t, ok)
+	assert.Nil(t, buf)
+}
+
+func TestRenderCache_Disabled(t *testing.T) {
+	c := NewRenderCache(0)
+	assert.Nil(t, c)
+
+	c.putInfo(eventKey{}, []byte{1})
+	_, ok := c.getInfo(eventKey{})
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.Len())
+}
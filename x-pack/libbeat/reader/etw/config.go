@@ -13,4 +13,16 @@ type Config struct {
 	MatchAnyKeyword uint64 // Filter for any matching keywords (bitmask)
 	MatchAllKeyword uint64 // Filter for all matching keywords (bitmask)
 	Session         string // Existing session to attach
+	// Providers lists the providers enabled in a new session. If empty, the
+	// single provider defined by ProviderGUID or ProviderName is used.
+	Providers []ProviderConfig
+}
+
+// ProviderConfig holds the filters used to enable a provider in a session.
+type ProviderConfig struct {
+	GUID            string // GUID of the ETW provider
+	Name            string // Name of the ETW provider
+	TraceLevel      string // Level of tracing (e.g., "verbose")
+	MatchAnyKeyword uint64 // Filter for any matching keywords (bitmask)
+	MatchAllKeyword uint64 // Filter for all matching keywords (bitmask)
 }
@@ -73,21 +73,33 @@ func (s *Session) CreateRealtimeSession() error {
 	// Zero timeout means asynchronous enablement
 	const timeout = 0
 
-	// Enable the trace session with extended options.
-	err = s.enableTrace(s.handler, &s.GUID, EVENT_CONTROL_CODE_ENABLE_PROVIDER, s.traceLevel, s.matchAnyKeyword, s.matchAllKeyword, timeout, &params)
-	switch {
-	case err == nil:
-		return nil
-	// Handle specific errors related to enabling the trace session.
-	case errors.Is(err, ERROR_INVALID_PARAMETER):
-		return fmt.Errorf("invalid parameters when enabling session trace: %w", err)
-	case errors.Is(err, ERROR_TIMEOUT):
-		return fmt.Errorf("timeout value expired before the enable callback completed: %w", err)
-	case errors.Is(err, ERROR_NO_SYSTEM_RESOURCES):
-		return fmt.Errorf("exceeded the number of trace sessions that can enable the provider: %w", err)
-	default:
-		return fmt.Errorf("failed to enable trace: %w", err)
+	providers := s.providers
+	if len(providers) == 0 {
+		providers = []sessionProvider{{
+			guid:            s.GUID,
+			traceLevel:      s.traceLevel,
+			matchAnyKeyword: s.matchAnyKeyword,
+			matchAllKeyword: s.matchAllKeyword,
+		}}
 	}
+
+	// Enable each of the providers in the trace session with extended options.
+	for _, p := range providers {
+		err = s.enableTrace(s.handler, &p.guid, EVENT_CONTROL_CODE_ENABLE_PROVIDER, p.traceLevel, p.matchAnyKeyword, p.matchAllKeyword, timeout, &params)
+		switch {
+		case err == nil:
+		// Handle specific errors related to enabling the trace session.
+		case errors.Is(err, ERROR_INVALID_PARAMETER):
+			return fmt.Errorf("invalid parameters when enabling session trace: %w", err)
+		case errors.Is(err, ERROR_TIMEOUT):
+			return fmt.Errorf("timeout value expired before the enable callback completed: %w", err)
+		case errors.Is(err, ERROR_NO_SYSTEM_RESOURCES):
+			return fmt.Errorf("exceeded the number of trace sessions that can enable the provider: %w", err)
+		default:
+			return fmt.Errorf("failed to enable trace: %w", err)
+		}
+	}
+	return nil
 }
 
 // StopSession closes the ETW session and associated handles if they were created.
@@ -142,6 +142,56 @@ func TestCreateRealtimeSession_Success(t *testing.T) {
 	assert.Equal(t, uintptr(12345), session.handler, "Handler should be set to the mock value")
 }
 
+func TestCreateRealtimeSession_Providers(t *testing.T) {
+	// Mock implementations
+	startTrace := func(traceHandle *uintptr,
+		instanceName *uint16,
+		properties *EventTraceProperties) error {
+		*traceHandle = 12345 // Mock handler value
+		return nil
+	}
+
+	type enabled struct {
+		guid     windows.GUID
+		level    uint8
+		anyMask  uint64
+		allMask  uint64
+		isEnable uint32
+	}
+	var got []enabled
+	enableTrace := func(traceHandle uintptr,
+		providerId *windows.GUID,
+		isEnabled uint32,
+		level uint8,
+		matchAnyKeyword uint64,
+		matchAllKeyword uint64,
+		enableProperty uint32,
+		enableParameters *EnableTraceParameters) error {
+		got = append(got, enabled{*providerId, level, matchAnyKeyword, matchAllKeyword, isEnabled})
+		return nil
+	}
+
+	// Create a Session instance enabling two providers
+	session := &Session{
+		Name:       "TestSession",
+		properties: &EventTraceProperties{},
+		providers: []sessionProvider{
+			{guid: windows.GUID{Data1: 1}, traceLevel: TRACE_LEVEL_WARNING, matchAnyKeyword: 0x10},
+			{guid: windows.GUID{Data1: 2}, traceLevel: TRACE_LEVEL_VERBOSE, matchAllKeyword: 0x20},
+		},
+		startTrace:  startTrace,
+		enableTrace: enableTrace,
+	}
+
+	err := session.CreateRealtimeSession()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []enabled{
+		{windows.GUID{Data1: 1}, TRACE_LEVEL_WARNING, 0x10, 0, EVENT_CONTROL_CODE_ENABLE_PROVIDER},
+		{windows.GUID{Data1: 2}, TRACE_LEVEL_VERBOSE, 0, 0x20, EVENT_CONTROL_CODE_ENABLE_PROVIDER},
+	}, got)
+}
+
 func TestStopSession_Error(t *testing.T) {
 	// Mock implementation of closeTrace
 	closeTrace := func(traceHandle uint64) error {
@@ -20,10 +20,17 @@ type propertyParser struct {
 	info    *TraceEventInfo
 	data    []byte
 	ptrSize uint32
+	cache   *RenderCache
 }
 
 // GetEventProperties extracts and returns properties from an ETW event record.
 func GetEventProperties(r *EventRecord) (map[string]interface{}, error) {
+	return getEventProperties(r, nil)
+}
+
+// getEventProperties extracts and returns properties from an ETW event record,
+// using the event metadata held in cache if it is not nil.
+func getEventProperties(r *EventRecord, cache *RenderCache) (map[string]interface{}, error) {
 	// Handle the case where the event only contains a string.
 	if r.EventHeader.Flags == EVENT_HEADER_FLAG_STRING_ONLY {
 		userDataPtr := (*uint16)(unsafe.Pointer(r.UserData))
@@ -33,7 +40,7 @@ func GetEventProperties(r *EventRecord) (map[string]interface{}, error) {
 	}
 
 	// Initialize a new property parser for the event record.
-	p, err := newPropertyParser(r)
+	p, err := newPropertyParser(r, cache)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse event properties: %w", err)
 	}
@@ -53,8 +60,8 @@ func GetEventProperties(r *EventRecord) (map[string]interface{}, error) {
 }
 
 // newPropertyParser initializes a new property parser for a given event record.
-func newPropertyParser(r *EventRecord) (*propertyParser, error) {
-	info, err := getEventInformation(r)
+func newPropertyParser(r *EventRecord, cache *RenderCache) (*propertyParser, error) {
+	info, err := getEventInformation(r, cache)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get event information: %w", err)
 	}
@@ -65,6 +72,7 @@ func newPropertyParser(r *EventRecord) (*propertyParser, error) {
 		info:    info,
 		ptrSize: ptrSize,
 		data:    unsafe.Slice((*uint8)(unsafe.Pointer(r.UserData)), r.UserDataLength),
+		cache:   cache,
 	}, nil
 }
 
@@ -82,12 +90,21 @@ func (info *TraceEventInfo) getEventPropertyInfoAtIndex(i uint32) *EventProperty
 }
 
 // getEventInformation retrieves detailed metadata about an event record.
-func getEventInformation(r *EventRecord) (info *TraceEventInfo, err error) {
+// The metadata of manifest-based events is read from and stored in cache.
+func getEventInformation(r *EventRecord, cache *RenderCache) (info *TraceEventInfo, err error) {
+	key := eventKey{provider: r.EventHeader.ProviderId, descriptor: r.EventHeader.EventDescriptor}
+	if buff, ok := cache.getInfo(key); ok {
+		return (*TraceEventInfo)(unsafe.Pointer(&buff[0])), nil
+	}
+
 	// Initially call TdhGetEventInformation to get the required buffer size.
-	var bufSize uint32
+	var (
+		bufSize uint32
+		buff    []byte
+	)
 	if err = _TdhGetEventInformation(r, 0, nil, nil, &bufSize); errors.Is(err, ERROR_INSUFFICIENT_BUFFER) {
 		// Allocate enough memory for TRACE_EVENT_INFO based on the required size.
-		buff := make([]byte, bufSize)
+		buff = make([]byte, bufSize)
 		info = ((*TraceEventInfo)(unsafe.Pointer(&buff[0])))
 		// Retrieve the event information into the allocated buffer.
 		err = _TdhGetEventInformation(r, 0, nil, info, &bufSize)
@@ -98,6 +115,12 @@ func getEventInformation(r *EventRecord) (info *TraceEventInfo, err error) {
 		return nil, fmt.Errorf("TdhGetEventInformation failed: %w", err)
 	}
 
+	// Only the metadata of manifest-based events is fully determined by the
+	// provider and the event descriptor.
+	if info.DecodingSource == DecodingSourceXMLFile {
+		cache.putInfo(key, buff)
+	}
+
 	return info, nil
 }
 
@@ -286,11 +309,27 @@ func (p *propertyParser) getMapInfo(propertyInfo EventPropertyInfo) (*EventMapIn
 	// Get the name of the map from the property info.
 	mapName := (*uint16)(unsafe.Add(unsafe.Pointer(p.info), propertyInfo.mapNameOffset()))
 
+	// Maps of manifest-based events are kept in the cache.
+	var key mapKey
+	cacheable := p.cache != nil && p.info.DecodingSource == DecodingSourceXMLFile
+	if cacheable {
+		key = mapKey{provider: p.info.ProviderGUID, name: windows.UTF16PtrToString(mapName)}
+		if buff, ok := p.cache.getMap(key); ok {
+			if buff == nil {
+				return nil, nil
+			}
+			return (*EventMapInfo)(unsafe.Pointer(&buff[0])), nil
+		}
+	}
+
 	// First call to get the required size of the map info.
 	err := _TdhGetEventMapInformation(p.r, mapName, nil, &mapSize)
 	switch {
 	case errors.Is(err, ERROR_NOT_FOUND):
 		// No mapping information available. This is not an error.
+		if cacheable {
+			p.cache.putMap(key, nil)
+		}
 		return nil, nil
 	case errors.Is(err, ERROR_INSUFFICIENT_BUFFER):
 		// Resize the buffer and try again.
@@ -307,7 +346,11 @@ func (p *propertyParser) getMapInfo(propertyInfo EventPropertyInfo) (*EventMapIn
 	}
 
 	if mapInfo.EntryCount == 0 {
-		return nil, nil // No entries in the map.
+		buff = nil // No entries in the map.
+		mapInfo = nil
+	}
+	if cacheable {
+		p.cache.putMap(key, buff)
 	}
 
 	return mapInfo, nil
@@ -49,6 +49,13 @@ type Session struct {
 	// The provider typically writes an event if the event's keyword bits match all of the bits set in this value
 	// or if the event has no keyword bits set, in addition to meeting the level and matchAnyKeyword criteria.
 	matchAllKeyword uint64
+	// providers lists the providers enabled in the session, each with its own
+	// level and keyword filters. If empty, the provider identified by GUID is
+	// enabled with the session's traceLevel, matchAnyKeyword and matchAllKeyword.
+	providers []sessionProvider
+	// ProviderNames maps the GUID of the providers configured by name to
+	// their name.
+	ProviderNames map[windows.GUID]string
 	// traceHandler is the trace processing handle.
 	// It is used to control the trace that receives and processes events.
 	traceHandler uint64
@@ -67,6 +74,19 @@ type Session struct {
 	processTrace func(handleArray *uint64, handleCount uint32, startTime *FileTime, endTime *FileTime) error
 }
 
+// sessionProvider is a provider enabled in a session.
+type sessionProvider struct {
+	guid            windows.GUID
+	traceLevel      uint8
+	matchAnyKeyword uint64
+	matchAllKeyword uint64
+}
+
+// SessionName returns the name of the session read for the provided configuration.
+func SessionName(conf Config) string {
+	return setSessionName(conf)
+}
+
 // setSessionName determines the session name based on the provided configuration.
 func setSessionName(conf Config) string {
 	// Iterate through potential session name values, returning the first non-empty one.
@@ -76,6 +96,12 @@ func setSessionName(conf Config) string {
 		}
 	}
 
+	if conf.ProviderName == "" && conf.ProviderGUID == "" && len(conf.Providers) != 0 {
+		// Name the session after its first provider.
+		conf.ProviderName = conf.Providers[0].Name
+		conf.ProviderGUID = conf.Providers[0].GUID
+	}
+
 	if conf.ProviderName != "" {
 		return fmt.Sprintf("Elastic-%s", conf.ProviderName)
 	}
@@ -181,6 +207,31 @@ func NewSession(conf Config) (*Session, error) {
 	}
 
 	session.NewSession = true // Indicate this is a new session
+	session.properties = newSessionProperties(session.Name)
+
+	if len(conf.Providers) != 0 {
+		// Resolve each of the providers, the first one identifies the session.
+		session.ProviderNames = make(map[windows.GUID]string)
+		for i, p := range conf.Providers {
+			guid, err := setSessionGUIDFunc(Config{ProviderGUID: p.GUID, ProviderName: p.Name})
+			if err != nil {
+				return nil, fmt.Errorf("error when initializing provider %d of session '%s': %w", i, session.Name, err)
+			}
+			if i == 0 {
+				session.GUID = guid
+			}
+			if p.Name != "" {
+				session.ProviderNames[guid] = p.Name
+			}
+			session.providers = append(session.providers, sessionProvider{
+				guid:            guid,
+				traceLevel:      getTraceLevel(p.TraceLevel),
+				matchAnyKeyword: p.MatchAnyKeyword,
+				matchAllKeyword: p.MatchAllKeyword,
+			})
+		}
+		return session, nil
+	}
 
 	var err error
 	session.GUID, err = setSessionGUIDFunc(conf)
@@ -189,7 +240,6 @@ func NewSession(conf Config) (*Session, error) {
 	}
 
 	// Initialize additional session properties.
-	session.properties = newSessionProperties(session.Name)
 	session.traceLevel = getTraceLevel(conf.TraceLevel)
 	session.matchAnyKeyword = conf.MatchAnyKeyword
 	session.matchAllKeyword = conf.MatchAllKeyword
@@ -188,6 +188,40 @@ func TestNewSession_ProviderName(t *testing.T) {
 	assert.NotNil(t, session.properties)
 }
 
+func TestNewSession_Providers(t *testing.T) {
+	// Defer restoration of original function
+	t.Cleanup(func() {
+		setSessionGUIDFunc = setSessionGUID
+	})
+
+	// Override setSessionGUIDFunc with mock resolving names to distinct GUIDs
+	setSessionGUIDFunc = func(conf Config) (windows.GUID, error) {
+		if conf.ProviderName != "" {
+			return windows.GUID{Data1: uint32(len(conf.ProviderName))}, nil
+		}
+		return windows.GUIDFromString(conf.ProviderGUID)
+	}
+
+	conf := Config{
+		Providers: []ProviderConfig{
+			{Name: "Provider1", TraceLevel: "warning", MatchAnyKeyword: 0x10},
+			{GUID: "{12345678-1234-5678-1234-567812345678}", TraceLevel: "verbose", MatchAllKeyword: 0x20},
+		},
+	}
+	session, err := NewSession(conf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Elastic-Provider1", session.Name, "SessionName should be derived from the first provider")
+	assert.Equal(t, windows.GUID{Data1: 9}, session.GUID, "The GUID in the session should be the first provider GUID")
+	assert.Equal(t, map[windows.GUID]string{{Data1: 9}: "Provider1"}, session.ProviderNames)
+	assert.Equal(t, []sessionProvider{
+		{guid: windows.GUID{Data1: 9}, traceLevel: 3, matchAnyKeyword: 0x10},
+		{guid: windows.GUID{Data1: 0x12345678, Data2: 0x1234, Data3: 0x5678, Data4: [8]byte{0x12, 0x34, 0x56, 0x78, 0x12, 0x34, 0x56, 0x78}}, traceLevel: 5, matchAllKeyword: 0x20},
+	}, session.providers)
+	assert.Equal(t, true, session.NewSession)
+	assert.NotNil(t, session.properties)
+}
+
 func TestNewSession_GUIDError(t *testing.T) {
 	// Defer restoration of original function
 	t.Cleanup(func() {
@@ -184,6 +184,15 @@ const (
 )
 
 type DecodingSource int32
+
+// https://learn.microsoft.com/en-us/windows/win32/api/tdh/ne-tdh-decoding_source
+const (
+	DecodingSourceXMLFile = DecodingSource(0)
+	DecodingSourceWbem    = DecodingSource(1)
+	DecodingSourceWPP     = DecodingSource(2)
+	DecodingSourceTlg     = DecodingSource(3)
+)
+
 type TemplateFlags int32
 
 type PropertyFlags int32
@@ -0,0 +1,68 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package query
+
+import (
+	"sync"
+
+	"github.com/elastic/beats/v7/metricbeat/helper/sql"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+// clients holds the database clients shared by the metricsets that connect
+// to the same DSN, so that their queries use a single connection pool.
+var clients = clientRegistry{clients: map[string]*sharedClient{}}
+
+type clientRegistry struct {
+	mu      sync.Mutex
+	clients map[string]*sharedClient
+}
+
+type sharedClient struct {
+	db   *sql.DbClient
+	refs int
+}
+
+func clientKey(driver, uri string) string {
+	return driver + "\x00" + uri
+}
+
+// acquire returns the client for the DSN uri, connecting to the database if
+// no metricset is connected yet. Each successful call must be paired with a
+// call to release.
+func (r *clientRegistry) acquire(driver, uri string, logger *logp.Logger) (*sql.DbClient, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := clientKey(driver, uri)
+	if c, ok := r.clients[key]; ok {
+		c.refs++
+		return c.db, nil
+	}
+	db, err := sql.NewDBClient(driver, uri, logger)
+	if err != nil {
+		return nil, err
+	}
+	r.clients[key] = &sharedClient{db: db, refs: 1}
+	return db, nil
+}
+
+// release closes the client for the DSN uri once no metricset uses it.
+func (r *clientRegistry) release(driver, uri string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := clientKey(driver, uri)
+	c, ok := r.clients[key]
+	if !ok {
+		return nil
+	}
+	c.refs--
+	if c.refs > 0 {
+		return nil
+	}
+	delete(r.clients, key)
+	return c.db.Close()
+}
@@ -8,6 +8,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/elastic/beats/v7/metricbeat/helper/sql"
 	"github.com/elastic/beats/v7/metricbeat/mb"
@@ -34,6 +36,14 @@ func init() {
 type query struct {
 	Query          string `config:"query" validate:"nonzero,required"`
 	ResponseFormat string `config:"response_format" validate:"nonzero,required"`
+
+	// Period is how often the query runs. It defaults to the module period.
+	Period time.Duration `config:"period" validate:"min=0"`
+	// Timeout cancels the query if it runs longer. No timeout if zero.
+	Timeout time.Duration `config:"timeout" validate:"min=0"`
+	// MetricSet overrides the metricset name in the event.dataset of the
+	// query events.
+	MetricSet string `config:"metricset"`
 }
 
 // Metricset configuration
@@ -61,6 +71,13 @@ type config struct {
 type MetricSet struct {
 	mb.BaseMetricSet
 	Config config
+
+	queries []query     // Configured queries, including sql_query.
+	lastRun []time.Time // Time each query of queries last ran.
+	now     func() time.Time
+
+	mu sync.Mutex
+	db *sql.DbClient // Shared client of the host, nil until connected.
 }
 
 // rawData is the minimum required set of fields to generate fully customized events with their own module key space
@@ -102,9 +119,75 @@ func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
 		return nil, fmt.Errorf("both query inputs provided, must provide either sql_query or sql_queries")
 	}
 
+	period := base.Module().Config().Period
+	for _, q := range b.Config.Queries {
+		if q.Period == 0 {
+			continue
+		}
+		if q.Period < period {
+			return nil, fmt.Errorf("period %v of query %q is shorter than the module period %v", q.Period, q.Query, period)
+		}
+		if b.Config.MergeResults && q.Period != period {
+			return nil, fmt.Errorf("merge_results cannot be used with queries that have their own period")
+		}
+	}
+
+	b.queries = b.Config.Queries
+	if len(b.queries) == 0 {
+		b.queries = []query{{Query: b.Config.Query, ResponseFormat: b.Config.ResponseFormat}}
+	}
+	b.lastRun = make([]time.Time, len(b.queries))
+	b.now = time.Now
+
 	return b, nil
 }
 
+// dueQueries returns the queries whose period has elapsed since they last
+// ran, and records that they run now.
+func (m *MetricSet) dueQueries() []query {
+	period := m.Module().Config().Period
+	now := m.now()
+
+	var due []query
+	for i, q := range m.queries {
+		// Allow half a module period of jitter, so that a query that is
+		// fetched slightly early does not wait for another period.
+		if !m.lastRun[i].IsZero() && q.Period > period && now.Sub(m.lastRun[i]) < q.Period-period/2 {
+			continue
+		}
+		m.lastRun[i] = now
+		due = append(due, q)
+	}
+	return due
+}
+
+// client returns the database client of the host. Metricsets connecting to
+// the same DSN share a client, and with it the connection pool.
+func (m *MetricSet) client() (*sql.DbClient, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.db != nil {
+		return m.db, nil
+	}
+	db, err := clients.acquire(m.Config.Driver, m.HostData().URI, m.Logger())
+	if err != nil {
+		return nil, err
+	}
+	m.db = db
+	return db, nil
+}
+
+// Close releases the database client of the host.
+func (m *MetricSet) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.db == nil {
+		return nil
+	}
+	m.db = nil
+	return clients.release(m.Config.Driver, m.HostData().URI)
+}
+
 // queryDBNames returns the query to list databases present in a server
 // as per the driver name. If the given driver is not supported, queryDBNames
 // returns an empty query.
@@ -143,63 +226,126 @@ func dbSelector(driver, dbName string) string {
 	return ""
 }
 
-func (m *MetricSet) fetch(ctx context.Context, db *sql.DbClient, reporter mb.ReporterV2, queries []query) (bool, error) {
+// queryResult holds the rows returned by a query in table format, or the
+// variables returned by a query in variables format.
+type queryResult struct {
+	rows      []mapstr.M
+	variables mapstr.M
+	err       error
+}
+
+// fetcher runs queries, it is implemented by sql.DbClient.
+type fetcher interface {
+	FetchTableMode(ctx context.Context, q string) ([]mapstr.M, error)
+	FetchVariableMode(ctx context.Context, q string) (mapstr.M, error)
+}
+
+// runQueries runs queries concurrently, each with its own timeout, and
+// calls done with the result of each query as soon as it completes, so that
+// slow queries don't delay the others. The calls to done are serialized.
+func runQueries(ctx context.Context, db fetcher, queries []query, done func(i int, q query, res queryResult)) {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i, q := range queries {
+		wg.Add(1)
+		go func(i int, q query) {
+			defer wg.Done()
+			ctx := ctx
+			if q.Timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, q.Timeout)
+				defer cancel()
+			}
+			var res queryResult
+			if q.ResponseFormat == tableResponseFormat {
+				res.rows, res.err = db.FetchTableMode(ctx, q.Query)
+			} else {
+				res.variables, res.err = db.FetchVariableMode(ctx, q.Query)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			done(i, q, res)
+		}(i, q)
+	}
+	wg.Wait()
+}
+
+// fetch runs the queries and reports the events of each query as soon as it
+// completes. A failed query doesn't prevent the events of the others from
+// being reported.
+func (m *MetricSet) fetch(ctx context.Context, db fetcher, reporter mb.ReporterV2, queries []query) (bool, error) {
+	if m.Config.MergeResults {
+		return m.fetchMerged(ctx, db, reporter, queries)
+	}
+
 	var ok bool
+	var errs []error
+	runQueries(ctx, db, queries, func(_ int, q query, res queryResult) {
+		if q.ResponseFormat == tableResponseFormat {
+			// Table format
+			if res.err != nil {
+				errs = append(errs, fmt.Errorf("fetch table mode failed: %w", res.err))
+				return
+			}
+			for _, ms := range res.rows {
+				ok = m.reportEvent(ms, reporter, q)
+			}
+		} else {
+			// Variable format
+			if res.err != nil {
+				errs = append(errs, fmt.Errorf("fetch variable mode failed: %w", res.err))
+				return
+			}
+			ok = m.reportEvent(res.variables, reporter, q)
+		}
+	})
+	return ok, errors.Join(errs...)
+}
+
+// fetchMerged runs the queries and reports the results of all of them in a
+// single event.
+func (m *MetricSet) fetchMerged(ctx context.Context, db fetcher, reporter mb.ReporterV2, queries []query) (bool, error) {
+	results := make([]queryResult, len(queries))
+	runQueries(ctx, db, queries, func(i int, _ query, res queryResult) {
+		results[i] = res
+	})
+
 	merged := make(mapstr.M, 0)
-	for _, q := range queries {
+	merge := func(ms mapstr.M) {
+		for k, v := range ms {
+			_, ok := merged[k]
+			if ok {
+				m.Logger().Warn("overwriting duplicate metrics:", k)
+			}
+			merged[k] = v
+		}
+	}
+	for i, q := range queries {
 		if q.ResponseFormat == tableResponseFormat {
 			// Table format
-			mss, err := db.FetchTableMode(ctx, q.Query)
+			mss, err := results[i].rows, results[i].err
 			if err != nil {
-				return ok, fmt.Errorf("fetch table mode failed: %w", err)
+				return false, fmt.Errorf("fetch table mode failed: %w", err)
+			}
+			if len(mss) > 1 {
+				return false, fmt.Errorf("cannot merge query resulting with more than one rows: %s", q)
 			}
-
 			for _, ms := range mss {
-				if m.Config.MergeResults {
-					if len(mss) > 1 {
-						return ok, fmt.Errorf("cannot merge query resulting with more than one rows: %s", q)
-					} else {
-						for k, v := range ms {
-							_, ok := merged[k]
-							if ok {
-								m.Logger().Warn("overwriting duplicate metrics:", k)
-							}
-							merged[k] = v
-						}
-					}
-				} else {
-					// Report immediately for non-merged cases.
-					ok = m.reportEvent(ms, reporter, q.Query)
-				}
+				merge(ms)
 			}
 		} else {
 			// Variable format
-			ms, err := db.FetchVariableMode(ctx, q.Query)
+			ms, err := results[i].variables, results[i].err
 			if err != nil {
-				return ok, fmt.Errorf("fetch variable mode failed: %w", err)
-			}
-
-			if m.Config.MergeResults {
-				for k, v := range ms {
-					_, ok := merged[k]
-					if ok {
-						m.Logger().Warn("overwriting duplicate metrics:", k)
-					}
-					merged[k] = v
-				}
-			} else {
-				// Report immediately for non-merged cases.
-				ok = m.reportEvent(ms, reporter, q.Query)
+				return false, fmt.Errorf("fetch variable mode failed: %w", err)
 			}
+			merge(ms)
 		}
 	}
 
-	if m.Config.MergeResults {
-		// Report here for merged case.
-		ok = m.reportEvent(merged, reporter, "")
-	}
-
-	return ok, nil
+	// Report here for merged case.
+	return m.reportEvent(merged, reporter, query{}), nil
 }
 
 // Fetch method implements the data gathering and data conversion to the right
@@ -208,26 +354,39 @@ func (m *MetricSet) fetch(ctx context.Context, db *sql.DbClient, reporter mb.Rep
 // It calls m.fetchTableMode() or m.fetchVariableMode() depending on the response
 // format of the query.
 func (m *MetricSet) Fetch(ctx context.Context, reporter mb.ReporterV2) error {
-	db, err := sql.NewDBClient(m.Config.Driver, m.HostData().URI, m.Logger())
+	if m.Config.FetchFromAllDatabases {
+		// Statements selecting a database change the database of the
+		// connection they run on, so the connection cannot be shared.
+		db, err := sql.NewDBClient(m.Config.Driver, m.HostData().URI, m.Logger())
+		if err != nil {
+			return fmt.Errorf("cannot open connection: %w", err)
+		}
+		defer db.Close()
+		return m.fetchFromAllDatabases(ctx, db, reporter, m.dueQueries())
+	}
+
+	db, err := m.client()
 	if err != nil {
 		return fmt.Errorf("cannot open connection: %w", err)
 	}
-	defer db.Close()
-
-	queries := m.Config.Queries
+	queries := m.dueQueries()
 	if len(queries) == 0 {
-		one_query := query{Query: m.Config.Query, ResponseFormat: m.Config.ResponseFormat}
-		queries = append(queries, one_query)
+		return nil
 	}
 
-	if !m.Config.FetchFromAllDatabases {
-		reported, err := m.fetch(ctx, db, reporter, queries)
-		if err != nil {
-			m.Logger().Warn("error while fetching:", err)
-		}
-		if !reported {
-			m.Logger().Debug("error trying to emit event")
-		}
+	reported, err := m.fetch(ctx, db, reporter, queries)
+	if err != nil {
+		m.Logger().Warn("error while fetching:", err)
+	}
+	if !reported {
+		m.Logger().Debug("error trying to emit event")
+	}
+	return nil
+}
+
+// fetchFromAllDatabases runs queries on each of the databases of the server.
+func (m *MetricSet) fetchFromAllDatabases(ctx context.Context, db *sql.DbClient, reporter mb.ReporterV2, queries []query) error {
+	if len(queries) == 0 {
 		return nil
 	}
 
@@ -296,8 +455,13 @@ func (m *MetricSet) Fetch(ctx context.Context, reporter mb.ReporterV2) error {
 
 // reportEvent using 'user' mode with keys under `sql.metrics.*` or using Raw data mode (module and metricset key spaces
 // provided by the user)
-func (m *MetricSet) reportEvent(ms mapstr.M, reporter mb.ReporterV2, qry string) bool {
+func (m *MetricSet) reportEvent(ms mapstr.M, reporter mb.ReporterV2, q query) bool {
 	var ok bool
+	qry := q.Query
+	var namespace string
+	if q.MetricSet != "" {
+		namespace = m.Module().Name() + "." + q.MetricSet
+	}
 	if m.Config.RawData.Enabled {
 		// New usage.
 		// Only driver & query field mapped.
@@ -310,6 +474,7 @@ func (m *MetricSet) reportEvent(ms mapstr.M, reporter mb.ReporterV2, qry string)
 					"driver":  m.Config.Driver,
 					"query":   qry,
 				},
+				Namespace: namespace,
 			})
 		} else {
 			ok = reporter.Event(mb.Event{
@@ -318,6 +483,7 @@ func (m *MetricSet) reportEvent(ms mapstr.M, reporter mb.ReporterV2, qry string)
 					"metrics": ms, // Individual metric
 					"driver":  m.Config.Driver,
 				},
+				Namespace: namespace,
 			})
 		}
 	} else {
@@ -329,6 +495,7 @@ func (m *MetricSet) reportEvent(ms mapstr.M, reporter mb.ReporterV2, qry string)
 				"query":   qry,
 				"metrics": inferTypeFromMetrics(ms),
 			},
+			Namespace: namespace,
 		})
 	}
 	return ok
@@ -0,0 +1,149 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package query
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/metricbeat/mb"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func newTestMetricSet(t *testing.T, queries []map[string]interface{}) (*MetricSet, error) {
+	t.Helper()
+	c, err := conf.NewConfigFrom(map[string]interface{}{
+		"module":      "sql",
+		"metricsets":  []string{"query"},
+		"hosts":       []string{"postgres://localhost:5432/db"},
+		"period":      "10s",
+		"driver":      "postgres",
+		"sql_queries": queries,
+	})
+	require.NoError(t, err)
+	_, metricsets, err := mb.NewModule(c, mb.Registry)
+	if err != nil {
+		return nil, err
+	}
+	require.Len(t, metricsets, 1)
+	return metricsets[0].(*MetricSet), nil
+}
+
+func TestDueQueries(t *testing.T) {
+	m, err := newTestMetricSet(t, []map[string]interface{}{
+		{"query": "SELECT 1", "response_format": "table"},
+		{"query": "SELECT 2", "response_format": "table", "period": "1m"},
+	})
+	require.NoError(t, err)
+
+	now := time.Now()
+	m.now = func() time.Time { return now }
+
+	due := func() []string {
+		var queries []string
+		for _, q := range m.dueQueries() {
+			queries = append(queries, q.Query)
+		}
+		return queries
+	}
+
+	assert.Equal(t, []string{"SELECT 1", "SELECT 2"}, due())
+	for i := 0; i < 5; i++ {
+		// Fetches happen slightly early, which must not delay the query
+		// with its own period.
+		now = now.Add(10*time.Second - 100*time.Millisecond)
+		assert.Equal(t, []string{"SELECT 1"}, due())
+	}
+	now = now.Add(10*time.Second - 100*time.Millisecond)
+	assert.Equal(t, []string{"SELECT 1", "SELECT 2"}, due())
+}
+
+func TestQueryPeriodValidation(t *testing.T) {
+	_, err := newTestMetricSet(t, []map[string]interface{}{
+		{"query": "SELECT 1", "response_format": "table", "period": "1s"},
+	})
+	assert.ErrorContains(t, err, "shorter than the module period")
+}
+
+// testFetcher runs the queries with the functions of the same name.
+type testFetcher map[string]func(ctx context.Context) (mapstr.M, error)
+
+func (f testFetcher) FetchTableMode(ctx context.Context, q string) ([]mapstr.M, error) {
+	ms, err := f[q](ctx)
+	if err != nil {
+		return nil, err
+	}
+	return []mapstr.M{ms}, nil
+}
+
+func (f testFetcher) FetchVariableMode(ctx context.Context, q string) (mapstr.M, error) {
+	return f[q](ctx)
+}
+
+// testReporter calls onEvent with the events it receives.
+type testReporter struct {
+	mu      sync.Mutex
+	events  []mb.Event
+	onEvent func(mb.Event)
+}
+
+func (r *testReporter) Event(event mb.Event) bool {
+	r.mu.Lock()
+	r.events = append(r.events, event)
+	r.mu.Unlock()
+	if r.onEvent != nil {
+		r.onEvent(event)
+	}
+	return true
+}
+
+func (r *testReporter) Error(error) bool { return true }
+
+func TestFetchReportsQueriesAsTheyComplete(t *testing.T) {
+	m, err := newTestMetricSet(t, []map[string]interface{}{
+		{"query": "fast", "response_format": "table"},
+		{"query": "slow", "response_format": "variables", "timeout": "5s"},
+		{"query": "failing", "response_format": "variables", "timeout": "50ms"},
+	})
+	require.NoError(t, err)
+
+	fastReported := make(chan struct{})
+	db := testFetcher{
+		"fast": func(context.Context) (mapstr.M, error) {
+			return mapstr.M{"n": 1}, nil
+		},
+		// The slow query only completes once the fast one is reported.
+		"slow": func(ctx context.Context) (mapstr.M, error) {
+			select {
+			case <-fastReported:
+				return mapstr.M{"n": 2}, nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		},
+		"failing": func(ctx context.Context) (mapstr.M, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}
+	reporter := &testReporter{onEvent: func(event mb.Event) {
+		if event.ModuleFields["query"] == "fast" {
+			close(fastReported)
+		}
+	}}
+
+	_, err = m.fetch(context.Background(), db, reporter, m.queries)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "the timeout of the failing query must be returned, got %v", err)
+	require.Len(t, reporter.events, 2, "the events of the other queries must be reported")
+	assert.Equal(t, "fast", reporter.events[0].ModuleFields["query"])
+	assert.Equal(t, "slow", reporter.events[1].ModuleFields["query"])
+}